@@ -1,7 +1,7 @@
 // Package api contains common constants for daemon and client.
 package api
 
-//go:generate swagger generate spec -o swagger.json
+//go:generate go run ./openapi/gen.go -swagger swagger.json -openapi openapi/openapi.json -client client/client_gen.go
 
 // Common constants for daemon and client.
 const (
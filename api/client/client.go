@@ -0,0 +1,210 @@
+// Package client is a typed Go client for the ipswd REST API
+//
+// The operations and types are generated from the ipswd OpenAPI spec (see api/openapi) by 'go generate ./api'.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/types"
+)
+
+// DefaultHost is the default ipswd address
+const DefaultHost = "http://localhost:3993"
+
+// Client is an ipswd API client
+type Client struct {
+	base      *url.URL
+	http      *http.Client
+	token     string
+	userAgent string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send the requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithToken sets the API key or JWT sent as the requests' bearer token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithSocket sends the requests to ipswd's unix socket
+func WithSocket(path string) Option {
+	return func(c *Client) {
+		c.http = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS config (i.e. the client certificate for an ipswd requiring mutual TLS)
+func WithTLSConfig(tc *tls.Config) Option {
+	return func(c *Client) {
+		c.http = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tc,
+			},
+		}
+	}
+}
+
+// WithUserAgent sets the requests' User-Agent
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// New creates a client for the ipswd at host (i.e. 'http://localhost:3993')
+func New(host string, opts ...Option) (*Client, error) {
+	if len(host) == 0 {
+		host = DefaultHost
+	}
+	base, err := url.Parse(strings.TrimSuffix(host, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipswd host '%s': %w", host, err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid ipswd host '%s': scheme must be http or https", host)
+	}
+	base.Path += "/v" + api.DefaultVersion
+	c := &Client{
+		base:      base,
+		http:      http.DefaultClient,
+		userAgent: "ipsw-client/" + api.DefaultVersion,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError is an ipswd error response
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ipswd: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// pathf formats a path escaping its params
+func pathf(format string, params ...any) string {
+	escaped := make([]any, len(params))
+	for i, p := range params {
+		escaped[i] = url.PathEscape(fmt.Sprint(p))
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+// addQuery adds a query param (if it is required or not the zero value)
+func addQuery(q url.Values, name string, value any, required bool) {
+	v := reflect.ValueOf(value)
+	if !required && v.IsZero() {
+		return
+	}
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			q.Add(name, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return
+	}
+	q.Set(name, fmt.Sprint(value))
+}
+
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	u := *c.base
+	u.Path += path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		dat, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		r = bytes.NewReader(dat)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		dat, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		var gerr types.GenericError
+		if err := json.Unmarshal(dat, &gerr); err == nil && len(gerr.Error) > 0 {
+			apiErr.Message = gerr.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(dat))
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// do sends a request and decodes the JSON response into out (if not nil)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || method == http.MethodHead {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// stream sends a request and returns the response body (the caller must close it)
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values, body any) (io.ReadCloser, error) {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
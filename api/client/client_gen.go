@@ -0,0 +1,3702 @@
+// Code generated by api/openapi/gen.go from the ipswd OpenAPI spec; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// GetDaemonPing Ping
+//
+// This will return "OK" if the daemon is running.
+//
+// GET /_ping
+func (c *Client) GetDaemonPing(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+}
+
+// HeadDaemonPing Ping
+//
+// This will return if 200 the daemon is running.
+//
+// HEAD /_ping
+func (c *Client) HeadDaemonPing(ctx context.Context) error {
+	return c.do(ctx, http.MethodHead, "/_ping", nil, nil, nil)
+}
+
+// GetFcsKeys FcsKeys
+//
+// Get fsc-keys PEM bytes for a given key.
+//
+// GET /aea/fcs-keys/{key}
+func (c *Client) GetFcsKeys(ctx context.Context, key string) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, pathf("/aea/fcs-keys/%s", key), nil, nil)
+}
+
+// GetArtifactsParams are the query parameters of GetArtifacts
+type GetArtifactsParams struct {
+	// only list the artifacts whose keys start with prefix (i.e. a job ID)
+	Prefix string
+}
+
+func (p *GetArtifactsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "prefix", p.Prefix, false)
+	return q
+}
+
+// GetArtifacts List
+//
+// List the stored job artifacts (their keys are prefixed with the ID of the job that produced them).
+//
+// GET /artifacts
+func (c *Client) GetArtifacts(ctx context.Context, params *GetArtifactsParams) ([]Object, error) {
+	var out []Object
+	if err := c.do(ctx, http.MethodGet, "/artifacts", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SuccessResponse is the 'successResponse' response
+type SuccessResponse struct {
+	Success bool `json:"success,omitempty"`
+}
+
+// DeleteArtifact Delete
+//
+// Delete a stored job artifact.
+//
+// DELETE /artifacts/{key}
+func (c *Client) DeleteArtifact(ctx context.Context, key string) (*SuccessResponse, error) {
+	var out *SuccessResponse
+	if err := c.do(ctx, http.MethodDelete, pathf("/artifacts/%s", key), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetArtifact Download
+//
+// Download a stored job artifact.
+//
+// GET /artifacts/{key}
+func (c *Client) GetArtifact(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodGet, pathf("/artifacts/%s", key), nil, nil, nil)
+}
+
+// GetAuditParams are the query parameters of GetAudit
+type GetAuditParams struct {
+	// only the requests since the RFC3339 time or duration ago (i.e. 24h)
+	Since string
+	// only the requests before the RFC3339 time or duration ago
+	Until string
+	// only the requests of the API key (or JWT subject or client SPIFFE ID)
+	Identity string
+	// only the requests to the route (i.e. /v1/syms/scan)
+	Route string
+	// only the most recent requests
+	Limit int64
+	// json (default), jsonl or csv
+	Format string
+}
+
+func (p *GetAuditParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "since", p.Since, false)
+	addQuery(q, "until", p.Until, false)
+	addQuery(q, "identity", p.Identity, false)
+	addQuery(q, "route", p.Route, false)
+	addQuery(q, "limit", p.Limit, false)
+	addQuery(q, "format", p.Format, false)
+	return q
+}
+
+// GetAudit Export
+//
+// Export the audit log of the API requests (oldest first) as JSON, JSON lines or CSV.
+//
+// GET /audit
+func (c *Client) GetAudit(ctx context.Context, params *GetAuditParams) ([]AuditEntry, error) {
+	var out []AuditEntry
+	if err := c.do(ctx, http.MethodGet, "/audit", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WhoamiResponse is the 'whoamiResponse' response
+type WhoamiResponse struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// GetAuthWhoami Whoami
+//
+// This will return the name and role of the request's credentials (admin if auth is disabled).
+//
+// GET /auth/whoami
+func (c *Client) GetAuthWhoami(ctx context.Context) (*WhoamiResponse, error) {
+	var out *WhoamiResponse
+	if err := c.do(ctx, http.MethodGet, "/auth/whoami", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CacheStatsResponse is the 'cacheStatsResponse' response
+type CacheStatsResponse struct {
+	Bytes   int64 `json:"bytes,omitempty"`
+	Entries int64 `json:"entries,omitempty"`
+	Hits    int64 `json:"hits,omitempty"`
+	Misses  int64 `json:"misses,omitempty"`
+	Shared  int64 `json:"shared,omitempty"`
+}
+
+// DeleteCache Purge
+//
+// Remove the cached responses.
+//
+// DELETE /cache
+func (c *Client) DeleteCache(ctx context.Context) (*CacheStatsResponse, error) {
+	var out *CacheStatsResponse
+	if err := c.do(ctx, http.MethodDelete, "/cache", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCache Stats
+//
+// Get the response cache's statistics.
+//
+// GET /cache
+func (c *Client) GetCache(ctx context.Context) (*CacheStatsResponse, error) {
+	var out *CacheStatsResponse
+	if err := c.do(ctx, http.MethodGet, "/cache", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeviceListResponse is the 'deviceListResponse' response
+type DeviceListResponse struct {
+	Devices []Device `json:"devices,omitempty"`
+}
+
+// GetDeviceList List XCode Devices.
+//
+// This will return JSON of all XCode devices.
+//
+// GET /device_list
+func (c *Client) GetDeviceList(ctx context.Context) (*DeviceListResponse, error) {
+	var out *DeviceListResponse
+	if err := c.do(ctx, http.MethodGet, "/device_list", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DiffResponse is the 'diffResponse' response
+type DiffResponse struct {
+	Diff string `json:"diff,omitempty"`
+}
+
+// PostDiffBlobsParams are the query parameters of PostDiffBlobs
+type PostDiffBlobsParams struct {
+	Previous string
+	Current  string
+}
+
+func (p *PostDiffBlobsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "prev", p.Previous, false)
+	addQuery(q, "curr", p.Current, false)
+	return q
+}
+
+// PostDiffBlobs Blobs
+//
+// This will return the diff of two text blobs.
+//
+// POST /diff/blobs
+func (c *Client) PostDiffBlobs(ctx context.Context, params *PostDiffBlobsParams) (*DiffResponse, error) {
+	var out *DiffResponse
+	if err := c.do(ctx, http.MethodPost, "/diff/blobs", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostDiffFilesParams are the query parameters of PostDiffFiles
+type PostDiffFilesParams struct {
+	Previous string
+	Current  string
+}
+
+func (p *PostDiffFilesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "prev", p.Previous, false)
+	addQuery(q, "curr", p.Current, false)
+	return q
+}
+
+// PostDiffFiles Files
+//
+// This will return the diff of two text files.
+//
+// POST /diff/files
+func (c *Client) PostDiffFiles(ctx context.Context, params *PostDiffFilesParams) (*DiffResponse, error) {
+	var out *DiffResponse
+	if err := c.do(ctx, http.MethodPost, "/diff/files", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoDiffResponse is the 'machoDiffResponse' response
+type MachoDiffResponse struct {
+	Diff *MachoDiff `json:"diff,omitempty"`
+}
+
+// PostDiffIPSWParams are the query parameters of PostDiffIPSW
+type PostDiffIPSWParams struct {
+	// path to the previous IPSW
+	Previous string
+	// path to the current IPSW
+	Current string
+	// also diff the MachOs' C strings
+	CStrings bool
+	// diff the IM4P firmwares instead of the filesystem MachOs
+	Firmware bool
+	// only diff these sections (i.e. __TEXT.__text)
+	AllowList []string
+	// skip these sections
+	BlockList []string
+	// path to AEA pem DB JSON file
+	PemDB string
+}
+
+func (p *PostDiffIPSWParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "prev", p.Previous, false)
+	addQuery(q, "curr", p.Current, false)
+	addQuery(q, "cstrings", p.CStrings, false)
+	addQuery(q, "firmware", p.Firmware, false)
+	addQuery(q, "allow_list", p.AllowList, false)
+	addQuery(q, "block_list", p.BlockList, false)
+	addQuery(q, "pem_db", p.PemDB, false)
+	return q
+}
+
+// PostDiffIPSW IPSW
+//
+// This will return the MachO (or IM4P firmware) diff of two IPSWs.
+//
+// POST /diff/ipsw
+func (c *Client) PostDiffIPSW(ctx context.Context, params *PostDiffIPSWParams) (*MachoDiffResponse, error) {
+	var out *MachoDiffResponse
+	if err := c.do(ctx, http.MethodPost, "/diff/ipsw", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostDiffKernel Kernel
+//
+// This will return the diff of two MH_FILESET kernelcaches ('prev' and 'curr' as server paths or uploaded multipart files).
+//
+// POST /diff/kernel
+func (c *Client) PostDiffKernel(ctx context.Context) (*MachoDiffResponse, error) {
+	var out *MachoDiffResponse
+	if err := c.do(ctx, http.MethodPost, "/diff/kernel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostDiffMacho MachO
+//
+// This will return the diff of two MachOs ('prev' and 'curr' as server paths or uploaded multipart files).
+//
+// POST /diff/macho
+func (c *Client) PostDiffMacho(ctx context.Context) (*MachoDiffResponse, error) {
+	var out *MachoDiffResponse
+	if err := c.do(ctx, http.MethodPost, "/diff/macho", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LatestIpswIosBuildResponse is the 'latestIpswIosBuildResponse' response
+type LatestIpswIosBuildResponse struct {
+	Build string `json:"build,omitempty"`
+}
+
+// GetDownloadLatestIPSWsBuild Latest iOS Build
+//
+// Get latest iOS build.
+//
+// GET /download/ipsw/ios/latest/build
+func (c *Client) GetDownloadLatestIPSWsBuild(ctx context.Context) (*LatestIpswIosBuildResponse, error) {
+	var out *LatestIpswIosBuildResponse
+	if err := c.do(ctx, http.MethodGet, "/download/ipsw/ios/latest/build", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LatestIpswIosVersionResponse is the 'latestIpswIosVersionResponse' response
+type LatestIpswIosVersionResponse struct {
+	Version string `json:"version,omitempty"`
+}
+
+// GetDownloadLatestIPSWsVersion Latest iOS Version
+//
+// Get latest iOS version.
+//
+// GET /download/ipsw/ios/latest/version
+func (c *Client) GetDownloadLatestIPSWsVersion(ctx context.Context) (*LatestIpswIosVersionResponse, error) {
+	var out *LatestIpswIosVersionResponse
+	if err := c.do(ctx, http.MethodGet, "/download/ipsw/ios/latest/version", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostDscAddrToOffParams are the query parameters of PostDscAddrToOff
+type PostDscAddrToOffParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// address to convert (required)
+	Addr uint64
+}
+
+func (p *PostDscAddrToOffParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "addr", p.Addr, true)
+	return q
+}
+
+// PostDscAddrToOff a2o
+//
+// Convert virtual address to file offset.
+//
+// POST /dsc/a2o
+func (c *Client) PostDscAddrToOff(ctx context.Context, params *PostDscAddrToOffParams) (*Offset, error) {
+	var out *Offset
+	if err := c.do(ctx, http.MethodPost, "/dsc/a2o", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostDscAddrToSymParams are the query parameters of PostDscAddrToSym
+type PostDscAddrToSymParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// address to convert (required)
+	Addrs []uint64
+}
+
+func (p *PostDscAddrToSymParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "addrs", p.Addrs, true)
+	return q
+}
+
+// PostDscAddrToSym a2s
+//
+// Convert virtual address to symbol.
+//
+// POST /dsc/a2s
+func (c *Client) PostDscAddrToSym(ctx context.Context, params *PostDscAddrToSymParams) ([]SymbolLookup, error) {
+	var out []SymbolLookup
+	if err := c.do(ctx, http.MethodPost, "/dsc/a2s", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscImportsResponse is the 'dscImportsResponse' response
+type DscImportsResponse struct {
+	ImportedBy *ImportedBy `json:"imported_by,omitempty"`
+	// The path to the DSC file
+	Path string `json:"path,omitempty"`
+}
+
+// GetDscImportsParams are the query parameters of GetDscImports
+type GetDscImportsParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// dylib to search for (required)
+	Dylib string
+}
+
+func (p *GetDscImportsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "dylib", p.Dylib, true)
+	return q
+}
+
+// GetDscImports Imports
+//
+// Get list of dylibs that import a given dylib.
+//
+// GET /dsc/imports
+func (c *Client) GetDscImports(ctx context.Context, params *GetDscImportsParams) (*DscImportsResponse, error) {
+	var out *DscImportsResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/imports", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscInfoResponse is the 'dscInfoResponse' response
+type DscInfoResponse struct {
+	Info *Info  `json:"info,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// GetDscInfoParams are the query parameters of GetDscInfo
+type GetDscInfoParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+}
+
+func (p *GetDscInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetDscInfo Info
+//
+// # Get info about a given DSC
+//
+// GET /dsc/info
+func (c *Client) GetDscInfo(ctx context.Context, params *GetDscInfoParams) (*DscInfoResponse, error) {
+	var out *DscInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/info", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscMachoResponse is the 'dscMachoResponse' response
+type DscMachoResponse struct {
+	Macho *File  `json:"macho,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// GetDscMachoParams are the query parameters of GetDscMacho
+type GetDscMachoParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// dylib to search for (required)
+	Dylib string
+}
+
+func (p *GetDscMachoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "dylib", p.Dylib, true)
+	return q
+}
+
+// GetDscMacho MachO
+//
+// Get MachO info for a given dylib in the DSC.
+//
+// GET /dsc/macho
+func (c *Client) GetDscMacho(ctx context.Context, params *GetDscMachoParams) (*DscMachoResponse, error) {
+	var out *DscMachoResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/macho", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscOffToAddrResponse is the 'dscOffToAddrResponse' response
+type DscOffToAddrResponse struct {
+	Cache *Address `json:"cache,omitempty"`
+	// the virtual addresses
+	Files []Address `json:"files,omitempty"`
+}
+
+// PostDscOffToAddrParams are the query parameters of PostDscOffToAddr
+type PostDscOffToAddrParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// offset to convert (required)
+	Offset uint64
+}
+
+func (p *PostDscOffToAddrParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "off", p.Offset, true)
+	return q
+}
+
+// PostDscOffToAddr o2a
+//
+// # Convert file offset to virtual address
+//
+// POST /dsc/o2a
+func (c *Client) PostDscOffToAddr(ctx context.Context, params *PostDscOffToAddrParams) (*DscOffToAddrResponse, error) {
+	var out *DscOffToAddrResponse
+	if err := c.do(ctx, http.MethodPost, "/dsc/o2a", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscSlideInfoResponse is the 'dscSlideInfoResponse' response
+type DscSlideInfoResponse struct {
+	Mapping *CacheMappingWithSlideInfo `json:"mapping,omitempty"`
+	Rebases []Rebase                   `json:"rebases,omitempty"`
+}
+
+// GetDscSlideInfoParams are the query parameters of GetDscSlideInfo
+type GetDscSlideInfoParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// filter by mapping type
+	Type string
+}
+
+func (p *GetDscSlideInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "type", p.Type, false)
+	return q
+}
+
+// GetDscSlideInfo Slide Info
+//
+// Get slide info for the DSC.
+//
+// POST /dsc/slide
+func (c *Client) GetDscSlideInfo(ctx context.Context, params *GetDscSlideInfoParams) (*DscSlideInfoResponse, error) {
+	var out *DscSlideInfoResponse
+	if err := c.do(ctx, http.MethodPost, "/dsc/slide", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscSplitResponse is the 'dscSplitResponse' response
+type DscSplitResponse struct {
+	Dylibs []string `json:"dylibs,omitempty"`
+	Path   string   `json:"path,omitempty"`
+}
+
+// GetDscSplitParams are the query parameters of GetDscSplit
+type GetDscSplitParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// the folder to output the split dylibs
+	Output string
+	// the path to the Xcode.app to use for splitting
+	XCodePath string
+}
+
+func (p *GetDscSplitParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "output", p.Output, false)
+	addQuery(q, "xcode_path", p.XCodePath, false)
+	return q
+}
+
+// GetDscSplit Split
+//
+// Split the DSC into its constituent dylibs using XCode's <code>dsc_extractor.bundle</code>
+//
+// <b>NOTE:</b> darwin ONLY
+//
+// POST /dsc/split
+func (c *Client) GetDscSplit(ctx context.Context, params *GetDscSplitParams) (*DscSplitResponse, error) {
+	var out *DscSplitResponse
+	if err := c.do(ctx, http.MethodPost, "/dsc/split", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscStringsResponse is the 'dscStringsResponse' response
+type DscStringsResponse struct {
+	Path    string   `json:"path,omitempty"`
+	Strings []String `json:"strings,omitempty"`
+}
+
+// GetDscStringsParams are the query parameters of GetDscStrings
+type GetDscStringsParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// regex to search for (required)
+	Pattern string
+}
+
+func (p *GetDscStringsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pattern", p.Pattern, true)
+	return q
+}
+
+// GetDscStrings Strings
+//
+// Get strings in the DSC that match a given pattern.
+//
+// GET /dsc/str
+func (c *Client) GetDscStrings(ctx context.Context, params *GetDscStringsParams) (*DscStringsResponse, error) {
+	var out *DscStringsResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/str", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscSymbolsResponse is the 'dscSymbolsResponse' response
+type DscSymbolsResponse struct {
+	Path    string   `json:"path,omitempty"`
+	Symbols []Symbol `json:"symbols,omitempty"`
+}
+
+// GetDscSymbolsParams are the query parameters of GetDscSymbols
+type GetDscSymbolsParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// symbols to lookup (required)
+	Lookups []Symbol
+}
+
+func (p *GetDscSymbolsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "lookups", p.Lookups, true)
+	return q
+}
+
+// GetDscSymbols Symbols
+//
+// Get symbols addresses in the DSC that match a given lookup JSON payload.
+//
+// POST /dsc/symaddr
+func (c *Client) GetDscSymbols(ctx context.Context, params *GetDscSymbolsParams) (*DscSymbolsResponse, error) {
+	var out *DscSymbolsResponse
+	if err := c.do(ctx, http.MethodPost, "/dsc/symaddr", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscTbdResponse is the 'dscTbdResponse' response
+type DscTbdResponse struct {
+	Dylib string `json:"dylib,omitempty"`
+	Path  string `json:"path,omitempty"`
+	TBD   string `json:"tbd,omitempty"`
+}
+
+// GetDscTbdParams are the query parameters of GetDscTbd
+type GetDscTbdParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+	// dylib to generate the TBD for (required)
+	Dylib string
+	// generate a generic (multi-platform) TBD
+	Generic bool
+}
+
+func (p *GetDscTbdParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "dylib", p.Dylib, true)
+	addQuery(q, "generic", p.Generic, false)
+	return q
+}
+
+// GetDscTbd TBD
+//
+// Generate a <code>.tbd</code> text-based stub for a dylib in the DSC.
+//
+// GET /dsc/tbd
+func (c *Client) GetDscTbd(ctx context.Context, params *GetDscTbdParams) (*DscTbdResponse, error) {
+	var out *DscTbdResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/tbd", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DscWebkitResponse is the 'dscWebkitResponse' response
+type DscWebkitResponse struct {
+	Path   string `json:"path,omitempty"`
+	Webkit string `json:"webkit,omitempty"`
+}
+
+// GetDscWebkitParams are the query parameters of GetDscWebkit
+type GetDscWebkitParams struct {
+	// path to dyld_shared_cache (required)
+	Path string
+}
+
+func (p *GetDscWebkitParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetDscWebkit Webkit
+//
+// Get <code>webkit</code> version from dylib in the DSC.
+//
+// GET /dsc/webkit
+func (c *Client) GetDscWebkit(ctx context.Context, params *GetDscWebkitParams) (*DscWebkitResponse, error) {
+	var out *DscWebkitResponse
+	if err := c.do(ctx, http.MethodGet, "/dsc/webkit", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EntResponse is the 'entResponse' response
+type EntResponse struct {
+	Entitlements map[string]any `json:"entitlements,omitempty"`
+	Path         string         `json:"path,omitempty"`
+}
+
+// GetEntParams are the query parameters of GetEnt
+type GetEntParams struct {
+	// path to MachO (required)
+	Path string
+}
+
+func (p *GetEntParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetEnt Entitlements
+//
+// Get a MachO's entitlements (POST multipart/form-data to upload the MachO as the 'path' file).
+//
+// GET /ent
+func (c *Client) GetEnt(ctx context.Context, params *GetEntParams) (*EntResponse, error) {
+	var out *EntResponse
+	if err := c.do(ctx, http.MethodGet, "/ent", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EntDiffResponse is the 'entDiffResponse' response
+type EntDiffResponse struct {
+	Diff string `json:"diff,omitempty"`
+}
+
+// PostEntDiffParams are the query parameters of PostEntDiff
+type PostEntDiffParams struct {
+	// path to the previous IPSW
+	Previous string
+	// path to the current IPSW
+	Current string
+	// output the diff as markdown
+	Markdown bool
+	// path to AEA pem DB JSON file
+	PemDB string
+}
+
+func (p *PostEntDiffParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "prev", p.Previous, false)
+	addQuery(q, "curr", p.Current, false)
+	addQuery(q, "markdown", p.Markdown, false)
+	addQuery(q, "pem_db", p.PemDB, false)
+	return q
+}
+
+// PostEntDiff Diff
+//
+// Diff the entitlements of two IPSWs.
+//
+// POST /ent/diff
+func (c *Client) PostEntDiff(ctx context.Context, params *PostEntDiffParams) (*EntDiffResponse, error) {
+	var out *EntDiffResponse
+	if err := c.do(ctx, http.MethodPost, "/ent/diff", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EntSearchResponse is the 'entSearchResponse' response
+type EntSearchResponse struct {
+	Matches []EntMatch `json:"matches,omitempty"`
+}
+
+// GetEntSearchParams are the query parameters of GetEntSearch
+type GetEntSearchParams struct {
+	// path to IPSW
+	IPSW string
+	// path to a folder of MachOs (i.e. a mounted DMG)
+	Input string
+	// entitlement key regex
+	Key string
+	// entitlement value regex
+	Value string
+	// path to AEA pem DB JSON file
+	PemDB string
+}
+
+func (p *GetEntSearchParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "ipsw", p.IPSW, false)
+	addQuery(q, "input", p.Input, false)
+	addQuery(q, "key", p.Key, false)
+	addQuery(q, "value", p.Value, false)
+	addQuery(q, "pem_db", p.PemDB, false)
+	return q
+}
+
+// GetEntSearch Search
+//
+// Search the entitlements of an IPSW's (or folder's) MachOs by key and/or value regex.
+//
+// GET /ent/search
+func (c *Client) GetEntSearch(ctx context.Context, params *GetEntSearchParams) (*EntSearchResponse, error) {
+	var out *EntSearchResponse
+	if err := c.do(ctx, http.MethodGet, "/ent/search", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExtractReponse is the extract response message
+type ExtractReponse struct {
+	// The list of extracted files
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// GetExtractDmgRequest is the request body of GetExtractDmg
+type GetExtractDmgRequest struct {
+	DMGType  string `json:"dmg_type,omitempty"`
+	Flatten  bool   `json:"flatten,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	IPSW     string `json:"ipsw,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// GetExtractDmg DMG
+//
+// Extract DMGs from an IPSW.
+//
+// POST /extract/dmg
+func (c *Client) GetExtractDmg(ctx context.Context, body *GetExtractDmgRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/dmg", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExtractDscRequest is the request body of GetExtractDsc
+type GetExtractDscRequest struct {
+	Arches   []string `json:"arches,omitempty"`
+	Flatten  bool     `json:"flatten,omitempty"`
+	Insecure bool     `json:"insecure,omitempty"`
+	IPSW     string   `json:"ipsw,omitempty"`
+	Output   string   `json:"output,omitempty"`
+	Proxy    string   `json:"proxy,omitempty"`
+	URL      string   `json:"url,omitempty"`
+}
+
+// GetExtractDsc DSC
+//
+// Extract dyld_shared_caches from an IPSW.
+//
+// POST /extract/dsc
+func (c *Client) GetExtractDsc(ctx context.Context, body *GetExtractDscRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/dsc", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExtractKbagsRequest is the request body of GetExtractKbags
+type GetExtractKbagsRequest struct {
+	Flatten  bool   `json:"flatten,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	IPSW     string `json:"ipsw,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// GetExtractKbags KBAG
+//
+// Extract KBAGs from an IPSW.
+//
+// POST /extract/kbag
+func (c *Client) GetExtractKbags(ctx context.Context, body *GetExtractKbagsRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/kbag", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExtractKernelRequest is the request body of GetExtractKernel
+type GetExtractKernelRequest struct {
+	Flatten  bool   `json:"flatten,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	IPSW     string `json:"ipsw,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// GetExtractKernel Kernel
+//
+// Extract kernelcaches from an IPSW.
+//
+// POST /extract/kernel
+func (c *Client) GetExtractKernel(ctx context.Context, body *GetExtractKernelRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/kernel", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExtractPatternRequest is the request body of GetExtractPattern
+type GetExtractPatternRequest struct {
+	Dmgs     bool   `json:"dmgs,omitempty"`
+	Flatten  bool   `json:"flatten,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	IPSW     string `json:"ipsw,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// GetExtractPattern Pattern
+//
+// Extract files from an IPSW that match a given pattern.
+//
+// POST /extract/pattern
+func (c *Client) GetExtractPattern(ctx context.Context, body *GetExtractPatternRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/pattern", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetExtractSPTMRequest is the request body of GetExtractSPTM
+type GetExtractSPTMRequest struct {
+	Dmgs     bool   `json:"dmgs,omitempty"`
+	Flatten  bool   `json:"flatten,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	IPSW     string `json:"ipsw,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// GetExtractSPTM SPTM
+//
+// Extract SPTM and TXM Firmwares.
+//
+// POST /extract/sptm
+func (c *Client) GetExtractSPTM(ctx context.Context, body *GetExtractSPTMRequest) (*ExtractReponse, error) {
+	var out *ExtractReponse
+	if err := c.do(ctx, http.MethodPost, "/extract/sptm", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HealthzResponse is the 'healthzResponse' response
+type HealthzResponse struct {
+	APIVersion string `json:"api_version,omitempty"`
+	Status     Status `json:"status,omitempty"`
+	Uptime     string `json:"uptime,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// GetHealthz Liveness
+//
+// This will return 200 while the daemon is serving requests (it doesn't check the dependencies,
+// so orchestrators don't restart ipswd when they are down).
+//
+// GET /healthz
+func (c *Client) GetHealthz(ctx context.Context) (*HealthzResponse, error) {
+	var out *HealthzResponse
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IdevInfoResponse is the 'idevInfoResponse' response
+type IdevInfoResponse struct {
+	Devices []DeviceValues `json:"devices,omitempty"`
+}
+
+// GetIdevInfo Info
+//
+// Get info about USB connected devices.
+//
+// GET /idev/info
+func (c *Client) GetIdevInfo(ctx context.Context) (*IdevInfoResponse, error) {
+	var out *IdevInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/idev/info", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetImg4ExtractParams are the query parameters of GetImg4Extract
+type GetImg4ExtractParams struct {
+	// path to IMG4/IM4P (required)
+	Path string
+	// hex encoded IV + key to decrypt the IM4P payload with
+	IvKey string
+}
+
+func (p *GetImg4ExtractParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "iv_key", p.IvKey, false)
+	return q
+}
+
+// GetImg4Extract Extract
+//
+// Extract (and decrypt if 'iv_key' is given) an IMG4/IM4P's payload.
+//
+// GET /img4/extract
+func (c *Client) GetImg4Extract(ctx context.Context, params *GetImg4ExtractParams) error {
+	return c.do(ctx, http.MethodGet, "/img4/extract", params.values(), nil, nil)
+}
+
+// Img4InfoResponse is the 'img4InfoResponse' response
+type Img4InfoResponse struct {
+	Analysis    *Analysis `json:"analysis,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Keybags     []any     `json:"keybags,omitempty"`
+	Manifest    bool      `json:"manifest,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	Type        string    `json:"type,omitempty"`
+}
+
+// GetImg4InfoParams are the query parameters of GetImg4Info
+type GetImg4InfoParams struct {
+	// path to IMG4/IM4P (required)
+	Path string
+	// identify and parse the payload
+	Analyze bool
+}
+
+func (p *GetImg4InfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "analyze", p.Analyze, false)
+	return q
+}
+
+// GetImg4Info Info
+//
+// Get IMG4/IM4P info.
+//
+// GET /img4/info
+func (c *Client) GetImg4Info(ctx context.Context, params *GetImg4InfoParams) (*Img4InfoResponse, error) {
+	var out *Img4InfoResponse
+	if err := c.do(ctx, http.MethodGet, "/img4/info", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InfoResponse is the 'infoResponse' response
+type InfoResponse struct {
+	Info *Info  `json:"info,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// GetIpswInfoParams are the query parameters of GetIpswInfo
+type GetIpswInfoParams struct {
+	// path to IPSW (required)
+	Path string
+}
+
+func (p *GetIpswInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetIpswInfo IPSW
+//
+// Get IPSW info.
+//
+// GET /info/ipsw
+func (c *Client) GetIpswInfo(ctx context.Context, params *GetIpswInfoParams) (*InfoResponse, error) {
+	var out *InfoResponse
+	if err := c.do(ctx, http.MethodGet, "/info/ipsw", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InfoRemoteResponse is the 'infoRemoteResponse' response
+type InfoRemoteResponse struct {
+	Info *Info  `json:"info,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// GetRemoteIpswInfoParams are the query parameters of GetRemoteIpswInfo
+type GetRemoteIpswInfoParams struct {
+	// url to IPSW (required)
+	URL string
+	// http proxy to use
+	Proxy string
+	// ignore TLS errors
+	Insecure bool
+}
+
+func (p *GetRemoteIpswInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "url", p.URL, true)
+	addQuery(q, "proxy", p.Proxy, false)
+	addQuery(q, "insecure", p.Insecure, false)
+	return q
+}
+
+// GetRemoteIpswInfo Remote IPSW
+//
+// Get remote IPSW info.
+//
+// GET /info/ipsw/remote
+func (c *Client) GetRemoteIpswInfo(ctx context.Context, params *GetRemoteIpswInfoParams) (*InfoRemoteResponse, error) {
+	var out *InfoRemoteResponse
+	if err := c.do(ctx, http.MethodGet, "/info/ipsw/remote", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOtaInfoParams are the query parameters of GetOtaInfo
+type GetOtaInfoParams struct {
+	// path to OTA (required)
+	Path string
+}
+
+func (p *GetOtaInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetOtaInfo OTA
+//
+// Get OTA info.
+//
+// GET /info/ota
+func (c *Client) GetOtaInfo(ctx context.Context, params *GetOtaInfoParams) (*InfoResponse, error) {
+	var out *InfoResponse
+	if err := c.do(ctx, http.MethodGet, "/info/ota", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetRemoteOtaInfoParams are the query parameters of GetRemoteOtaInfo
+type GetRemoteOtaInfoParams struct {
+	// url to OTA (required)
+	URL string
+	// http proxy to use
+	Proxy string
+	// ignore TLS errors
+	Insecure bool
+}
+
+func (p *GetRemoteOtaInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "url", p.URL, true)
+	addQuery(q, "proxy", p.Proxy, false)
+	addQuery(q, "insecure", p.Insecure, false)
+	return q
+}
+
+// GetRemoteOtaInfo Remote OTA
+//
+// Get remote OTA info.
+//
+// GET /info/ota/remote
+func (c *Client) GetRemoteOtaInfo(ctx context.Context, params *GetRemoteOtaInfoParams) (*InfoRemoteResponse, error) {
+	var out *InfoRemoteResponse
+	if err := c.do(ctx, http.MethodGet, "/info/ota/remote", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetFsEntitlementsResponse is the 'getFsEntitlementsResponse' response
+type GetFsEntitlementsResponse struct {
+	Entitlements map[string]map[string]any `json:"entitlements,omitempty"`
+	Path         string                    `json:"path,omitempty"`
+}
+
+// GetIpswFsEntitlementsParams are the query parameters of GetIpswFsEntitlements
+type GetIpswFsEntitlementsParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+}
+
+func (p *GetIpswFsEntitlementsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	return q
+}
+
+// GetIpswFsEntitlements Entitlements
+//
+// Get IPSW Filesystem DMG MachO entitlements.
+//
+// GET /ipsw/fs/ents
+func (c *Client) GetIpswFsEntitlements(ctx context.Context, params *GetIpswFsEntitlementsParams) (*GetFsEntitlementsResponse, error) {
+	var out *GetFsEntitlementsResponse
+	if err := c.do(ctx, http.MethodGet, "/ipsw/fs/ents", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetFsFilesResponse is FS files response
+type GetFsFilesResponse struct {
+	// The files in the IPSW filesystem
+	Files []File `json:"files,omitempty"`
+	// The path to the IPSW
+	Path string `json:"path,omitempty"`
+}
+
+// GetIpswFsFilesParams are the query parameters of GetIpswFsFiles
+type GetIpswFsFilesParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+}
+
+func (p *GetIpswFsFilesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	return q
+}
+
+// GetIpswFsFiles Files
+//
+// Get IPSW Filesystem DMG file listing.
+//
+// GET /ipsw/fs/files
+func (c *Client) GetIpswFsFiles(ctx context.Context, params *GetIpswFsFilesParams) (*GetFsFilesResponse, error) {
+	var out *GetFsFilesResponse
+	if err := c.do(ctx, http.MethodGet, "/ipsw/fs/files", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetFsLaunchdConfigResponse is the 'getFsLaunchdConfigResponse' response
+type GetFsLaunchdConfigResponse struct {
+	LaunchdConfig string `json:"launchd_config,omitempty"`
+	Path          string `json:"path,omitempty"`
+}
+
+// GetIpswFsLaunchdParams are the query parameters of GetIpswFsLaunchd
+type GetIpswFsLaunchdParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+}
+
+func (p *GetIpswFsLaunchdParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	return q
+}
+
+// GetIpswFsLaunchd launchd Config
+//
+// Get <code>launchd</code> config from IPSW Filesystem DMG.
+//
+// GET /ipsw/fs/launchd
+func (c *Client) GetIpswFsLaunchd(ctx context.Context, params *GetIpswFsLaunchdParams) (*GetFsLaunchdConfigResponse, error) {
+	var out *GetFsLaunchdConfigResponse
+	if err := c.do(ctx, http.MethodGet, "/ipsw/fs/launchd", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetJobs List
+//
+// List the background jobs (finished jobs are kept for an hour).
+// Failed attempts are retried with backoff and unfinished jobs are resumed when ipswd restarts.
+// In distributed mode this lists the jobs of all the daemons sharing the job queue.
+//
+// GET /jobs
+func (c *Client) GetJobs(ctx context.Context) ([]JobsStatus, error) {
+	var out []JobsStatus
+	if err := c.do(ctx, http.MethodGet, "/jobs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JobResponse is the 'jobResponse' response
+type JobResponse struct {
+	Attempts    int64     `json:"attempts,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Finished    time.Time `json:"finished,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	Owner       string    `json:"owner,omitempty"`
+	Progress    *Event    `json:"progress,omitempty"`
+	Result      any       `json:"result,omitempty"`
+	Started     time.Time `json:"started,omitempty"`
+	State       JobState  `json:"state,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+}
+
+// PostJobDiffIPSW Diff IPSWs
+//
+// Diff two IPSWs in the background, writing a markdown, JSON or HTML report to 'output/<job ID>'.
+// The report is uploaded to the artifact storage if ipswd is configured with one.
+//
+// POST /jobs/diff/ipsw
+func (c *Client) PostJobDiffIPSW(ctx context.Context) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/diff/ipsw", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobDownloadIPSWParams are the query parameters of PostJobDownloadIPSW
+type PostJobDownloadIPSWParams struct {
+	Device string
+	// build to download (or use version)
+	Build   string
+	Version string
+	// folder to download the IPSW to
+	Output   string
+	Proxy    string
+	Insecure bool
+	// number of parallel HTTP range requests
+	Segments int64
+}
+
+func (p *PostJobDownloadIPSWParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "device", p.Device, false)
+	addQuery(q, "build", p.Build, false)
+	addQuery(q, "version", p.Version, false)
+	addQuery(q, "output", p.Output, false)
+	addQuery(q, "proxy", p.Proxy, false)
+	addQuery(q, "insecure", p.Insecure, false)
+	addQuery(q, "segments", p.Segments, false)
+	return q
+}
+
+// PostJobDownloadIPSW Download IPSW
+//
+// Download an IPSW in the background (reports the download progress).
+//
+// POST /jobs/download/ipsw
+func (c *Client) PostJobDownloadIPSW(ctx context.Context, params *PostJobDownloadIPSWParams) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/download/ipsw", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobDscSplit Split DSC
+//
+// Split a dyld_shared_cache into its dylibs in the background (requires macOS with Xcode).
+// The dylibs are uploaded to the artifact storage if ipswd is configured with one.
+//
+// POST /jobs/dsc/split
+func (c *Client) PostJobDscSplit(ctx context.Context) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/dsc/split", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobEntDump Dump Entitlements
+//
+// Dump the entitlements of an IPSW's MachOs to an entitlements database ('output/<IPSW name>.entdb') in the background.
+// The database is uploaded to the artifact storage if ipswd is configured with one.
+//
+// POST /jobs/ent/dump
+func (c *Client) PostJobEntDump(ctx context.Context) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/ent/dump", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobExtractDSC Extract DSC
+//
+// Extract the dyld_shared_cache(s) from an IPSW in the background (takes the same body as /extract/dsc).
+//
+// POST /jobs/extract/dsc
+func (c *Client) PostJobExtractDSC(ctx context.Context) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/extract/dsc", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobExtractKernel Extract Kernel
+//
+// Extract the kernelcache(s) from an IPSW in the background (takes the same body as /extract/kernel).
+// The kernelcaches are uploaded to the artifact storage if ipswd is configured with one.
+//
+// POST /jobs/extract/kernel
+func (c *Client) PostJobExtractKernel(ctx context.Context) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/extract/kernel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostJobSymsScanParams are the query parameters of PostJobSymsScan
+type PostJobSymsScanParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+	// path to symbolication signatures directory
+	SigDir string
+	// replace the IPSW's existing symbols
+	Rescan bool
+}
+
+func (p *PostJobSymsScanParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	addQuery(q, "sig_dir", p.SigDir, false)
+	addQuery(q, "rescan", p.Rescan, false)
+	return q
+}
+
+// PostJobSymsScan Scan Symbols
+//
+// Scan (or rescan) the symbols of an IPSW into the symbol database in the background.
+//
+// POST /jobs/syms/scan
+func (c *Client) PostJobSymsScan(ctx context.Context, params *PostJobSymsScanParams) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodPost, "/jobs/syms/scan", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteJob Cancel
+//
+// Cancel a background job.
+//
+// DELETE /jobs/{id}
+func (c *Client) DeleteJob(ctx context.Context, id string) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodDelete, pathf("/jobs/%s", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetJob Status
+//
+// Get a background job's status.
+//
+// GET /jobs/{id}
+func (c *Client) GetJob(ctx context.Context, id string) (*JobResponse, error) {
+	var out *JobResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/jobs/%s", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetJobEvents Events
+//
+// Stream a background job's state, log, progress and result events as server-sent events
+// (or as JSON messages over a WebSocket if the request is a WebSocket upgrade).
+// The past events are replayed first and the stream ends when the job finishes.
+//
+// GET /jobs/{id}/events
+func (c *Client) GetJobEvents(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, pathf("/jobs/%s/events", id), nil, nil)
+}
+
+// GetKernelDecParams are the query parameters of GetKernelDec
+type GetKernelDecParams struct {
+	// path to compressed kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelDecParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelDec Decompress
+//
+// Decompress a kernelcache IM4P.
+//
+// GET /kernel/dec
+func (c *Client) GetKernelDec(ctx context.Context, params *GetKernelDecParams) error {
+	return c.do(ctx, http.MethodGet, "/kernel/dec", params.values(), nil, nil)
+}
+
+// KernelKextsResponse is the 'kernelKextsResponse' response
+type KernelKextsResponse struct {
+	Kexts []CFBundle `json:"kexts,omitempty"`
+	Path  string     `json:"path,omitempty"`
+}
+
+// GetKernelKextsParams are the query parameters of GetKernelKexts
+type GetKernelKextsParams struct {
+	// path to kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelKextsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelKexts Kexts
+//
+// Get kernelcache KEXTs info.
+//
+// GET /kernel/kexts
+func (c *Client) GetKernelKexts(ctx context.Context, params *GetKernelKextsParams) (*KernelKextsResponse, error) {
+	var out *KernelKextsResponse
+	if err := c.do(ctx, http.MethodGet, "/kernel/kexts", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KernelMigResponse is the 'kernelMigResponse' response
+type KernelMigResponse struct {
+	Path       string             `json:"path,omitempty"`
+	Subsystems []MigKernSubsystem `json:"subsystems,omitempty"`
+}
+
+// GetKernelMigParams are the query parameters of GetKernelMig
+type GetKernelMigParams struct {
+	// path to kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelMigParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelMig MIG
+//
+// Get kernelcache MIG subsystems.
+//
+// GET /kernel/mig
+func (c *Client) GetKernelMig(ctx context.Context, params *GetKernelMigParams) (*KernelMigResponse, error) {
+	var out *KernelMigResponse
+	if err := c.do(ctx, http.MethodGet, "/kernel/mig", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KernelSyscallsResponse is the 'kernelSyscallsResponse' response
+type KernelSyscallsResponse struct {
+	Path     string   `json:"path,omitempty"`
+	Syscalls []Sysent `json:"syscalls,omitempty"`
+}
+
+// GetKernelSyscallsParams are the query parameters of GetKernelSyscalls
+type GetKernelSyscallsParams struct {
+	// path to kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelSyscallsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelSyscalls Syscalls
+//
+// Get kernelcache syscalls info.
+//
+// GET /kernel/syscall
+func (c *Client) GetKernelSyscalls(ctx context.Context, params *GetKernelSyscallsParams) (*KernelSyscallsResponse, error) {
+	var out *KernelSyscallsResponse
+	if err := c.do(ctx, http.MethodGet, "/kernel/syscall", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KernelMachTrapsResponse is the 'kernelMachTrapsResponse' response
+type KernelMachTrapsResponse struct {
+	Path  string     `json:"path,omitempty"`
+	Traps []MachTrap `json:"traps,omitempty"`
+}
+
+// GetKernelMachTrapsParams are the query parameters of GetKernelMachTraps
+type GetKernelMachTrapsParams struct {
+	// path to kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelMachTrapsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelMachTraps Mach Traps
+//
+// Get kernelcache mach trap table.
+//
+// GET /kernel/traps
+func (c *Client) GetKernelMachTraps(ctx context.Context, params *GetKernelMachTrapsParams) (*KernelMachTrapsResponse, error) {
+	var out *KernelMachTrapsResponse
+	if err := c.do(ctx, http.MethodGet, "/kernel/traps", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KernelVersionResponse is the 'kernelVersionResponse' response
+type KernelVersionResponse struct {
+	Path    string   `json:"path,omitempty"`
+	Version *Version `json:"version,omitempty"`
+}
+
+// GetKernelVersionParams are the query parameters of GetKernelVersion
+type GetKernelVersionParams struct {
+	// path to kernelcache (required)
+	Path string
+}
+
+func (p *GetKernelVersionParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	return q
+}
+
+// GetKernelVersion Version
+//
+// Get kernelcache version.
+//
+// GET /kernel/version
+func (c *Client) GetKernelVersion(ctx context.Context, params *GetKernelVersionParams) (*KernelVersionResponse, error) {
+	var out *KernelVersionResponse
+	if err := c.do(ctx, http.MethodGet, "/kernel/version", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LimitsResponse is the 'limitsResponse' response
+type LimitsResponse struct {
+	Burst  int64                  `json:"burst,omitempty"`
+	Client string                 `json:"client,omitempty"`
+	Quotas map[string]*QuotaUsage `json:"quotas,omitempty"`
+	Rate   float64                `json:"rate,omitempty"`
+}
+
+// GetLimits Usage
+//
+// Get the rate limit, quotas and quota usage of the request's API key (or client IP if auth is disabled).
+// The download quota restarts every period (and when ipswd restarts) and the storage quota is used by
+// the stored artifacts of the client's jobs.
+//
+// GET /limits
+func (c *Client) GetLimits(ctx context.Context) (*LimitsResponse, error) {
+	var out *LimitsResponse
+	if err := c.do(ctx, http.MethodGet, "/limits", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoAddrToOffResponse is the 'machoAddrToOffResponse' response
+type MachoAddrToOffResponse struct {
+	Addr    uint64 `json:"addr,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	Offset  uint64 `json:"offset,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Section string `json:"section,omitempty"`
+	Segment string `json:"segment,omitempty"`
+}
+
+// GetMachoAddrToOffParams are the query parameters of GetMachoAddrToOff
+type GetMachoAddrToOffParams struct {
+	// path to MachO (required)
+	Path string
+	// architecture to get info for in universal MachO
+	Arch string
+	// virtual address (required)
+	Addr string
+}
+
+func (p *GetMachoAddrToOffParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "arch", p.Arch, false)
+	addQuery(q, "addr", p.Addr, true)
+	return q
+}
+
+// GetMachoAddrToOff Address to Offset
+//
+// Convert a MachO virtual address to a file offset.
+//
+// GET /macho/a2o
+func (c *Client) GetMachoAddrToOff(ctx context.Context, params *GetMachoAddrToOffParams) (*MachoAddrToOffResponse, error) {
+	var out *MachoAddrToOffResponse
+	if err := c.do(ctx, http.MethodGet, "/macho/a2o", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoAddrToSymResponse is the 'machoAddrToSymResponse' response
+type MachoAddrToSymResponse struct {
+	Addr    uint64   `json:"addr,omitempty"`
+	Arch    string   `json:"arch,omitempty"`
+	CString string   `json:"cstring,omitempty"`
+	Entry   string   `json:"entry,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// GetMachoAddrToSymParams are the query parameters of GetMachoAddrToSym
+type GetMachoAddrToSymParams struct {
+	// path to MachO (required)
+	Path string
+	// architecture to get info for in universal MachO
+	Arch string
+	// virtual address (required)
+	Addr string
+}
+
+func (p *GetMachoAddrToSymParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "arch", p.Arch, false)
+	addQuery(q, "addr", p.Addr, true)
+	return q
+}
+
+// GetMachoAddrToSym Address to Symbol
+//
+// Lookup the symbol (or C string) at a MachO virtual address.
+//
+// GET /macho/a2s
+func (c *Client) GetMachoAddrToSym(ctx context.Context, params *GetMachoAddrToSymParams) (*MachoAddrToSymResponse, error) {
+	var out *MachoAddrToSymResponse
+	if err := c.do(ctx, http.MethodGet, "/macho/a2s", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoInfoResponse is the 'machoInfoResponse' response
+type MachoInfoResponse struct {
+	Arch string `json:"arch,omitempty"`
+	Info *File  `json:"info,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// GetMachoInfoParams are the query parameters of GetMachoInfo
+type GetMachoInfoParams struct {
+	// path to MachO (required)
+	Path string
+	// architecture to get info for in universal MachO
+	Arch string
+}
+
+func (p *GetMachoInfoParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "arch", p.Arch, false)
+	return q
+}
+
+// GetMachoInfo Info
+//
+// Get MachO info.
+//
+// GET /macho/info
+func (c *Client) GetMachoInfo(ctx context.Context, params *GetMachoInfoParams) (*MachoInfoResponse, error) {
+	var out *MachoInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/macho/info", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoStringsResponse is the 'machoStringsResponse' response
+type MachoStringsResponse struct {
+	Arch    string                       `json:"arch,omitempty"`
+	Path    string                       `json:"path,omitempty"`
+	Strings map[string]map[string]uint64 `json:"strings,omitempty"`
+}
+
+// GetMachoInfoStringsParams are the query parameters of GetMachoInfoStrings
+type GetMachoInfoStringsParams struct {
+	// path to MachO (required)
+	Path string
+	// architecture to get info for in universal MachO
+	Arch string
+}
+
+func (p *GetMachoInfoStringsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "arch", p.Arch, false)
+	return q
+}
+
+// GetMachoInfoStrings Strings
+//
+// Get MachO strings.
+//
+// GET /macho/info/strings
+func (c *Client) GetMachoInfoStrings(ctx context.Context, params *GetMachoInfoStringsParams) (*MachoStringsResponse, error) {
+	var out *MachoStringsResponse
+	if err := c.do(ctx, http.MethodGet, "/macho/info/strings", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachoOffToAddrResponse is the 'machoOffToAddrResponse' response
+type MachoOffToAddrResponse struct {
+	Addr    uint64 `json:"addr,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	Offset  uint64 `json:"offset,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Section string `json:"section,omitempty"`
+	Segment string `json:"segment,omitempty"`
+}
+
+// GetMachoOffToAddrParams are the query parameters of GetMachoOffToAddr
+type GetMachoOffToAddrParams struct {
+	// path to MachO (required)
+	Path string
+	// architecture to get info for in universal MachO
+	Arch string
+	// file offset (required)
+	Off string
+}
+
+func (p *GetMachoOffToAddrParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "arch", p.Arch, false)
+	addQuery(q, "off", p.Off, true)
+	return q
+}
+
+// GetMachoOffToAddr Offset to Address
+//
+// Convert a MachO file offset to a virtual address.
+//
+// GET /macho/o2a
+func (c *Client) GetMachoOffToAddr(ctx context.Context, params *GetMachoOffToAddrParams) (*MachoOffToAddrResponse, error) {
+	var out *MachoOffToAddrResponse
+	if err := c.do(ctx, http.MethodGet, "/macho/o2a", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMetrics Metrics
+//
+// This will return the daemon metrics in the Prometheus text format.
+//
+// GET /metrics
+func (c *Client) GetMetrics(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/metrics", nil, nil, nil)
+}
+
+// MountReponse is the 'mountReponse' response
+type MountReponse struct {
+	AlreadyMounted bool   `json:"already_mounted,omitempty"`
+	DMGPath        string `json:"dmg_path,omitempty"`
+	MountPoint     string `json:"mount_point,omitempty"`
+}
+
+// PostMountParams are the query parameters of PostMount
+type PostMountParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+}
+
+func (p *PostMountParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	return q
+}
+
+// PostMount Mount
+//
+// Mount a DMG inside a given IPSW.
+//
+// POST /mount/{type}
+func (c *Client) PostMount(ctx context.Context, typ string, params *PostMountParams) (*MountReponse, error) {
+	var out *MountReponse
+	if err := c.do(ctx, http.MethodPost, pathf("/mount/%s", typ), params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOpenAPI OpenAPI
+//
+// This will return the ipswd OpenAPI 3 document.
+//
+// GET /openapi.json
+func (c *Client) GetOpenAPI(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, nil)
+}
+
+// GetPipelines List
+//
+// List the pipelines loaded from the daemon's pipeline files.
+//
+// GET /pipelines
+func (c *Client) GetPipelines(ctx context.Context) ([]Pipeline, error) {
+	var out []Pipeline
+	if err := c.do(ctx, http.MethodGet, "/pipelines", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PipelineRunResponse is the 'pipelineRunResponse' response
+type PipelineRunResponse struct {
+	Attempts    int64     `json:"attempts,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Finished    time.Time `json:"finished,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	Owner       string    `json:"owner,omitempty"`
+	Progress    *Event    `json:"progress,omitempty"`
+	Result      any       `json:"result,omitempty"`
+	Started     time.Time `json:"started,omitempty"`
+	State       JobState  `json:"state,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+}
+
+// PostPipelineRunParams are the query parameters of PostPipelineRun
+type PostPipelineRunParams struct {
+	Device  string
+	Build   string
+	Version string
+	// source of the build (ota or ipsw)
+	Source string
+}
+
+func (p *PostPipelineRunParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "device", p.Device, false)
+	addQuery(q, "build", p.Build, false)
+	addQuery(q, "version", p.Version, false)
+	addQuery(q, "source", p.Source, false)
+	return q
+}
+
+// PostPipelineRun Run
+//
+// Run a pipeline for a build in the background (as if the watcher had found the build).
+//
+// POST /pipelines/{name}/run
+func (c *Client) PostPipelineRun(ctx context.Context, name string, params *PostPipelineRunParams) (*PipelineRunResponse, error) {
+	var out *PipelineRunResponse
+	if err := c.do(ctx, http.MethodPost, pathf("/pipelines/%s/run", name), params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadyzResponse is the 'readyzResponse' response
+type ReadyzResponse struct {
+	Checks []Check `json:"checks,omitempty"`
+	Status Status  `json:"status,omitempty"`
+}
+
+// GetReadyz Readiness
+//
+// This will return 200 if the daemon's dependencies (the database, the storage backend and the free disk
+// space of its folders) are ok and 503 with the reasons of the failed checks otherwise.
+//
+// GET /readyz
+func (c *Client) GetReadyz(ctx context.Context) (*ReadyzResponse, error) {
+	var out *ReadyzResponse
+	if err := c.do(ctx, http.MethodGet, "/readyz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAnalyses Analyses
+//
+// Get the results of the plugin analyzers run on an IPSW when it was scanned.
+//
+// GET /syms/analyses/{id}
+func (c *Client) GetAnalyses(ctx context.Context, id string) ([]ModelAnalysis, error) {
+	var out []ModelAnalysis
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/analyses/%s", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetDSC DSC
+//
+// Get dyld_shared_cache for a given uuid.
+//
+// GET /syms/dsc/{uuid}
+func (c *Client) GetDSC(ctx context.Context, uuid string) (*DyldSharedCache, error) {
+	var out *DyldSharedCache
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/dsc/%s", uuid), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetDylib Dylib
+//
+// Get image from a DSC for a given uuid and address.
+//
+// GET /syms/dsc/{uuid}/{addr}
+func (c *Client) GetDylib(ctx context.Context, uuid string, addr int64) (*Macho, error) {
+	var out *Macho
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/dsc/%s/%s", uuid, addr), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetIPSWParams are the query parameters of GetIPSW
+type GetIPSWParams struct {
+	// version of IPSW (required)
+	Version string
+	// build of IPSW (required)
+	Build string
+	// device of IPSW (required)
+	Device string
+}
+
+func (p *GetIPSWParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "version", p.Version, true)
+	addQuery(q, "build", p.Build, true)
+	addQuery(q, "device", p.Device, true)
+	return q
+}
+
+// GetIPSW IPSW
+//
+// Get IPSW for a given version OR build AND device.
+//
+// GET /syms/ipsw
+func (c *Client) GetIPSW(ctx context.Context, params *GetIPSWParams) (*IPSW, error) {
+	var out *IPSW
+	if err := c.do(ctx, http.MethodGet, "/syms/ipsw", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetIPSWs IPSWs
+//
+// List the scanned IPSWs (newest first) with their devices, kernelcache and dyld_shared_cache UUIDs.
+//
+// GET /syms/ipsws
+func (c *Client) GetIPSWs(ctx context.Context) ([]IPSW, error) {
+	var out []IPSW
+	if err := c.do(ctx, http.MethodGet, "/syms/ipsws", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMachO MachO
+//
+// Get MachO for a given uuid.
+//
+// GET /syms/macho/{uuid}
+func (c *Client) GetMachO(ctx context.Context, uuid string) (*Macho, error) {
+	var out *Macho
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/macho/%s", uuid), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetScanQueueParams are the query parameters of GetScanQueue
+type GetScanQueueParams struct {
+	// filter by status (pending, running, done or failed)
+	Status string
+}
+
+func (p *GetScanQueueParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "status", p.Status, false)
+	return q
+}
+
+// GetScanQueue Queue
+//
+// List the symbol scan queue items (highest priority first).
+//
+// GET /syms/queue
+func (c *Client) GetScanQueue(ctx context.Context, params *GetScanQueueParams) ([]ScanItem, error) {
+	var out []ScanItem
+	if err := c.do(ctx, http.MethodGet, "/syms/queue", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostScanQueueRequest is the request body of PostScanQueue
+type PostScanQueueRequest struct {
+	// the items with the highest priority are scanned first
+	Priority int64 `json:"priority,omitempty"`
+	// the IPSW/OTA URLs to scan
+	URLs []string `json:"urls,omitempty"`
+}
+
+// PostScanQueue Enqueue
+//
+// Queue IPSW/OTA URLs to be downloaded and scanned by the background workers. The URLs that are
+// already queued are not duplicated (their priority is raised and failed items are retried) and the
+// IPSWs that were already scanned are skipped.
+//
+// POST /syms/queue
+func (c *Client) PostScanQueue(ctx context.Context, body *PostScanQueueRequest) ([]ScanItem, error) {
+	var out []ScanItem
+	if err := c.do(ctx, http.MethodPost, "/syms/queue", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteScanQueueItem Dequeue
+//
+// Remove an item from the symbol scan queue (running items can't be removed).
+//
+// DELETE /syms/queue/{id}
+func (c *Client) DeleteScanQueueItem(ctx context.Context, id int64) (*SuccessResponse, error) {
+	var out *SuccessResponse
+	if err := c.do(ctx, http.MethodDelete, pathf("/syms/queue/%s", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatedResponse is the 'createdResponse' response
+type CreatedResponse struct {
+	Created bool `json:"created,omitempty"`
+}
+
+// PutRescanParams are the query parameters of PutRescan
+type PutRescanParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+	// path to symbolication signatures directory
+	SigDir string
+}
+
+func (p *PutRescanParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	addQuery(q, "sig_dir", p.SigDir, false)
+	return q
+}
+
+// PutRescan Rescan
+//
+// Rescan symbols for a given IPSW.
+//
+// PUT /syms/rescan
+func (c *Client) PutRescan(ctx context.Context, params *PutRescanParams) (*CreatedResponse, error) {
+	var out *CreatedResponse
+	if err := c.do(ctx, http.MethodPut, "/syms/rescan", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostScanParams are the query parameters of PostScan
+type PostScanParams struct {
+	// path to IPSW (required)
+	Path string
+	// path to AEA pem DB JSON file
+	PemDb string
+	// path to symbolication signatures directory
+	SigDir string
+}
+
+func (p *PostScanParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	addQuery(q, "path", p.Path, true)
+	addQuery(q, "pem_db", p.PemDb, false)
+	addQuery(q, "sig_dir", p.SigDir, false)
+	return q
+}
+
+// PostScan Scan
+//
+// Scan symbols for a given IPSW.
+//
+// POST /syms/scan
+func (c *Client) PostScan(ctx context.Context, params *PostScanParams) (*SuccessResponse, error) {
+	var out *SuccessResponse
+	if err := c.do(ctx, http.MethodPost, "/syms/scan", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSymbols Symbols
+//
+// Get symbols for a given uuid.
+//
+// GET /syms/{uuid}
+func (c *Client) GetSymbols(ctx context.Context, uuid string) ([]Symbol, error) {
+	var out []Symbol
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/%s", uuid), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSymbol Symbol
+//
+// Get symbol for a given uuid and address.
+//
+// GET /syms/{uuid}/{addr}
+func (c *Client) GetSymbol(ctx context.Context, uuid string, addr int64) (*Symbol, error) {
+	var out *Symbol
+	if err := c.do(ctx, http.MethodGet, pathf("/syms/%s/%s", uuid, addr), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostUnmountRequest is the request body of PostUnmount
+type PostUnmountRequest struct {
+	DMGPath    string `json:"dmg_path,omitempty"`
+	MountPoint string `json:"mount_point,omitempty"`
+}
+
+// PostUnmount Unmount
+//
+// Unmount a previously mounted DMG.
+//
+// POST /unmount
+func (c *Client) PostUnmount(ctx context.Context, body *PostUnmountRequest) (*SuccessResponse, error) {
+	var out *SuccessResponse
+	if err := c.do(ctx, http.MethodPost, "/unmount", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VersionResponse is the 'versionResponse' response
+type VersionResponse struct {
+	APIVersion     string `json:"api_version,omitempty"`
+	BuilderVersion string `json:"builder_version,omitempty"`
+	OSType         string `json:"os_type,omitempty"`
+}
+
+// GetDaemonVersion Version
+//
+// This will return the daemon version info.
+//
+// GET /version
+func (c *Client) GetDaemonVersion(ctx context.Context) (*VersionResponse, error) {
+	var out *VersionResponse
+	if err := c.do(ctx, http.MethodGet, "/version", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetWorkers Workers
+//
+// List the workers of a distributed deployment (the daemons sharing the job queue with the worker role).
+// Workers that haven't sent a heartbeat recently are not alive and their jobs are requeued by the coordinator.
+//
+// GET /workers
+func (c *Client) GetWorkers(ctx context.Context) ([]Worker, error) {
+	var out []Worker
+	if err := c.do(ctx, http.MethodGet, "/workers", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Analysis is the result of identifying and parsing an IM4P payload
+type Analysis struct {
+	Compression      Compression    `json:"compression,omitempty"`
+	DecompressedSize int64          `json:"decompressed_size,omitempty"`
+	Description      string         `json:"description,omitempty"`
+	Details          map[string]any `json:"details,omitempty"`
+	Encrypted        bool           `json:"encrypted,omitempty"`
+	Name             string         `json:"name,omitempty"`
+	Payload          PayloadType    `json:"payload,omitempty"`
+	Size             int64          `json:"size,omitempty"`
+	Type             string         `json:"type,omitempty"`
+}
+
+// Asset is an OTA asset object
+type Asset struct {
+	ActualMinimumSystemPartition int64              `json:"ActualMinimumSystemPartition,omitempty"`
+	ArchiveDecryptionKey         string             `json:"ArchiveDecryptionKey,omitempty"`
+	ArchiveID                    string             `json:"ArchiveID,omitempty"`
+	AssetFormat                  string             `json:"AssetFormat,omitempty"`
+	AssetType                    string             `json:"AssetType,omitempty"`
+	AutoUpdate                   bool               `json:"AutoUpdate,omitempty"`
+	BridgeVersionInfo            *BridgeVersionInfo `json:"BridgeVersionInfo,omitempty"`
+	Build                        string             `json:"Build,omitempty"`
+	CryptexSizeInfo              []CryptexSize      `json:"CryptexSizeInfo,omitempty"`
+	DataTemplateSize             int64              `json:"DataTemplateSize,omitempty"`
+	// extras
+	DeviceName                            string              `json:"DeviceName,omitempty"`
+	Devices                               []string            `json:"Devices,omitempty"`
+	DisablePreSoftwareUpdateAssetStaging  bool                `json:"DisablePreSoftwareUpdateAssetStaging,omitempty"`
+	EAPFSEnabled                          bool                `json:"EAPFSEnabled,omitempty"`
+	FirmwareBundle                        string              `json:"FirmwareBundle,omitempty"`
+	FirmwareVersionMajor                  int64               `json:"FirmwareVersionMajor,omitempty"`
+	FirmwareVersionMinor                  int64               `json:"FirmwareVersionMinor,omitempty"`
+	FirmwareVersionRelease                int64               `json:"FirmwareVersionRelease,omitempty"`
+	InstallationSize                      string              `json:"InstallationSize,omitempty"`
+	InstallationSizeSnapshot              string              `json:"InstallationSize-Snapshot,omitempty"`
+	MinimumSystemPartition                int64               `json:"MinimumSystemPartition,omitempty"`
+	OSVersion                             string              `json:"OSVersion,omitempty"`
+	PreflightBuildManifest                []uint8             `json:"PreflightBuildManifest,omitempty"`
+	PreflightGlobalSignatures             []uint8             `json:"PreflightGlobalSignatures,omitempty"`
+	PrerequisiteBuild                     string              `json:"PrerequisiteBuild,omitempty"`
+	PrerequisiteOSVersion                 string              `json:"PrerequisiteOSVersion,omitempty"`
+	ProductVersionExtra                   string              `json:"ProductVersionExtra,omitempty"`
+	RSEPDigest                            []uint8             `json:"RSEPDigest,omitempty"`
+	Ramp                                  bool                `json:"Ramp,omitempty"`
+	ReleaseType                           string              `json:"ReleaseType,omitempty"`
+	RescueMinimumSystemPartition          int64               `json:"RescueMinimumSystemPartition,omitempty"`
+	RestoreVersion                        string              `json:"RestoreVersion,omitempty"`
+	RestoreVersionInfo                    *RestoreVersionInfo `json:"RestoreVersionInfo,omitempty"`
+	SEPDigest                             []uint8             `json:"SEPDigest,omitempty"`
+	ConvReqd                              bool                `json:"SUConvReqd,omitempty"`
+	DocumentationID                       string              `json:"SUDocumentationID,omitempty"`
+	InstallTonightEnabled                 bool                `json:"SUInstallTonightEnabled,omitempty"`
+	MultiPassEnabled                      bool                `json:"SUMultiPassEnabled,omitempty"`
+	ProductSystemName                     string              `json:"SUProductSystemName,omitempty"`
+	Publisher                             string              `json:"SUPublisher,omitempty"`
+	SplatOnly                             bool                `json:"SplatOnly,omitempty"`
+	SupportedDeviceModels                 []string            `json:"SupportedDeviceModels,omitempty"`
+	SupportedDevices                      []string            `json:"SupportedDevices,omitempty"`
+	SystemPartitionPadding                map[string]int64    `json:"SystemPartitionPadding,omitempty"`
+	SystemVolumeSealingOverhead           int64               `json:"SystemVolumeSealingOverhead,omitempty"`
+	TargetUpdateBridgeVersion             string              `json:"TargetUpdateBridgeVersion,omitempty"`
+	TrainName                             string              `json:"TrainName,omitempty"`
+	AssetReceipt                          *AssetReceipt       `json:"_AssetReceipt,omitempty"`
+	CompressionAlgorithm                  string              `json:"_CompressionAlgorithm,omitempty"`
+	DownloadSize                          int64               `json:"_DownloadSize,omitempty"`
+	EventRecordingServiceURL              string              `json:"_EventRecordingServiceURL,omitempty"`
+	IsEncrypted                           bool                `json:"_IsEncrypted,omitempty"`
+	IsZipStreamable                       bool                `json:"_IsZipStreamable,omitempty"`
+	MasteredVersion                       string              `json:"_MasteredVersion,omitempty"`
+	Hash                                  []uint8             `json:"_Measurement,omitempty"`
+	HashAlgorithm                         string              `json:"_MeasurementAlgorithm,omitempty"`
+	UnarchivedSize                        int64               `json:"_UnarchivedSize,omitempty"`
+	AssetDefaultGarbageCollectionBehavior string              `json:"__AssetDefaultGarbageCollectionBehavior,omitempty"`
+	BaseURL                               string              `json:"__BaseURL,omitempty"`
+	CanUseLocalCacheServer                bool                `json:"__CanUseLocalCacheServer,omitempty"`
+	HideInstallAlert                      bool                `json:"__HideInstallAlert,omitempty"`
+	QueuingServiceURL                     string              `json:"__QueuingServiceURL,omitempty"`
+	RelativePath                          string              `json:"__RelativePath,omitempty"`
+}
+
+// AuditEntry is an API request recorded in the audit log.
+type AuditEntry struct {
+	At         time.Time `json:"at,omitempty"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	ID         uint64    `json:"id,omitempty"`
+	// Identity is the name of the request's API key (or JWT subject); it is empty if auth is disabled
+	Identity string `json:"identity,omitempty"`
+	// Inputs are the SHA256 digests of the request's input files by param (JSON)
+	Inputs string `json:"inputs,omitempty"`
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Query  string `json:"query,omitempty"`
+	Role   string `json:"role,omitempty"`
+	// Route is the matched route (i.e. /v1/syms/:uuid) and Path the requested path
+	Route  string `json:"route,omitempty"`
+	Status int64  `json:"status,omitempty"`
+}
+
+// BuildTrigger matches the new builds found by the daemon's watcher
+type BuildTrigger struct {
+	// Devices are the devices to watch (their builds are watched even if they are not in the watch config)
+	Devices []string `json:"devices,omitempty"`
+	// Sources are the sources of the builds (ota and/or ipsw, all sources if empty)
+	Sources []string `json:"sources,omitempty"`
+}
+
+// ByteOrder specifies how to convert byte slices into
+// 16-, 32-, or 64-bit unsigned integers.
+type ByteOrder struct {
+	String string `json:"String,omitempty"`
+}
+
+// CDFlag is the 'CDFlag' schema
+type CDFlag uint32
+
+// CFBundle is the 'CFBundle' schema
+type CFBundle struct {
+	AllowUserLoad                bool              `json:"allow_user_load,omitempty"`
+	AppleSecurityExtension       bool              `json:"apple_security_extension,omitempty"`
+	BuildMachineOSBuild          string            `json:"build_machine_os_build,omitempty"`
+	BundlePath                   string            `json:"bundle_path,omitempty"`
+	CompatibleVersion            string            `json:"compatible_version,omitempty"`
+	Copyright                    string            `json:"copyright,omitempty"`
+	DevelopmentRegion            string            `json:"development_region,omitempty"`
+	Executable                   string            `json:"executable,omitempty"`
+	ExecutableLoadAddr           uint64            `json:"executable_load_addr,omitempty"`
+	GetInfoString                string            `json:"get_info_string,omitempty"`
+	ID                           string            `json:"id,omitempty"`
+	InfoDictionaryVersion        string            `json:"info_dictionary_version,omitempty"`
+	IOKitPersonalities           map[string]any    `json:"io_kit_personalities,omitempty"`
+	MinimumOSVersion             string            `json:"minimum_os_version,omitempty"`
+	ModuleIndex                  uint64            `json:"module_index,omitempty"`
+	Name                         string            `json:"name,omitempty"`
+	OSBundleLibraries            map[string]string `json:"os_bundle_libraries,omitempty"`
+	OSBundleRequired             string            `json:"os_bundle_required,omitempty"`
+	OSKernelResource             bool              `json:"os_kernel_resource,omitempty"`
+	PackageType                  string            `json:"package_type,omitempty"`
+	PlatformBuild                string            `json:"platform_build,omitempty"`
+	PlatformName                 string            `json:"platform_name,omitempty"`
+	PlatformVersion              string            `json:"platform_version,omitempty"`
+	RelativePath                 string            `json:"relative_path,omitempty"`
+	SDK                          string            `json:"sdk,omitempty"`
+	SDKBuild                     string            `json:"sdk_build,omitempty"`
+	ShortVersionString           string            `json:"short_version_string,omitempty"`
+	Signature                    string            `json:"signature,omitempty"`
+	SupportedPlatforms           []string          `json:"supported_platforms,omitempty"`
+	UIDeviceFamily               []int64           `json:"ui_device_family,omitempty"`
+	UIRequiredDeviceCapabilities []string          `json:"ui_required_device_capabilities,omitempty"`
+	Version                      string            `json:"version,omitempty"`
+	Xcode                        string            `json:"xcode,omitempty"`
+	XcodeBuild                   string            `json:"xcode_build,omitempty"`
+}
+
+// CPU is a Mach-O cpu type.
+type CPU uint32
+
+// CPUSubtype is the 'CPUSubtype' schema
+type CPUSubtype uint32
+
+// CacheMappingFlag is the 'CacheMappingFlag' schema
+type CacheMappingFlag uint64
+
+// CacheMappingWithSlideInfo is the 'CacheMappingWithSlideInfo' schema
+type CacheMappingWithSlideInfo struct {
+	Pages           []any            `json:"Pages,omitempty"`
+	SlideInfo       *SlideInfo       `json:"SlideInfo,omitempty"`
+	Address         uint64           `json:"address,omitempty"`
+	FileOffset      uint64           `json:"file_offset,omitempty"`
+	Flags           CacheMappingFlag `json:"flags,omitempty"`
+	InitProt        VmProtection     `json:"init_prot,omitempty"`
+	MaxProt         VmProtection     `json:"max_prot,omitempty"`
+	Name            string           `json:"name,omitempty"`
+	Size            uint64           `json:"size,omitempty"`
+	SlideInfoOffset uint64           `json:"slide_info_offset,omitempty"`
+	SlideInfoSize   uint64           `json:"slide_info_size,omitempty"`
+}
+
+// CacheSlidePointer is the 'CacheSlidePointer' schema
+type CacheSlidePointer struct {
+	Raw uint64 `json:"Raw,omitempty"`
+}
+
+// Check is the result of a dependency check
+type Check struct {
+	Details  map[string]any `json:"details,omitempty"`
+	Duration int64          `json:"duration_ms,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Reason   string         `json:"reason,omitempty"`
+	Status   Status         `json:"status,omitempty"`
+}
+
+// CodeDirectory object
+type CodeDirectory struct {
+	CDHash          string             `json:"cd_hash,omitempty"`
+	CodeLimit       uint64             `json:"code_limit,omitempty"`
+	CodeSlots       []CodeSlot         `json:"code_slots,omitempty"`
+	Header          *CodeDirectoryType `json:"header,omitempty"`
+	ID              string             `json:"id,omitempty"`
+	Length          uint32             `json:"length,omitempty"`
+	LinkageData     []uint8            `json:"linkage_data,omitempty"`
+	Magic           Magic              `json:"magic,omitempty"`
+	PreEncryptSlots [][]uint8          `json:"pre_encrypt_slots,omitempty"`
+	RuntimeVersion  string             `json:"runtime_version,omitempty"`
+	Scatter         *Scatter           `json:"scatter,omitempty"`
+	SpecialSlots    []SpecialSlot      `json:"special_slots,omitempty"`
+	TeamID          string             `json:"team_id,omitempty"`
+}
+
+// CodeDirectoryType header
+type CodeDirectoryType struct {
+	CodeLimit   uint32 `json:"code_limit,omitempty"`
+	CodeLimit64 uint64 `json:"code_limit_64,omitempty"`
+	// Version 0x20400 */
+	ExecSegBase               uint64      `json:"exec_seg_base,omitempty"`
+	ExecSegFlags              ExecSegFlag `json:"exec_seg_flags,omitempty"`
+	ExecSegLimit              uint64      `json:"exec_seg_limit,omitempty"`
+	Flags                     CDFlag      `json:"flags,omitempty"`
+	HashOffset                uint32      `json:"hash_offset,omitempty"`
+	HashSize                  uint8       `json:"hash_size,omitempty"`
+	HashType                  HashType    `json:"hash_type,omitempty"`
+	IdentOffset               uint32      `json:"ident_offset,omitempty"`
+	LinkageApplicationSubType uint16      `json:"linkage_application_sub_type,omitempty"`
+	LinkageApplicationType    uint8       `json:"linkage_application_type,omitempty"`
+	// Version 0x20600 */
+	LinkageHashType  uint8      `json:"linkage_hash_type,omitempty"`
+	LinkageOffset    uint32     `json:"linkage_offset,omitempty"`
+	LinkageSize      uint32     `json:"linkage_size,omitempty"`
+	NCodeSlots       uint32     `json:"n_code_slots,omitempty"`
+	NSpecialSlots    uint32     `json:"n_special_slots,omitempty"`
+	PageSize         uint8      `json:"page_size,omitempty"`
+	Platform         CdPlatform `json:"platform,omitempty"`
+	PreEncryptOffset uint32     `json:"pre_encrypt_offset,omitempty"`
+	Runtime          *Version   `json:"runtime,omitempty"`
+	// Version 0x20100 */
+	ScatterOffset uint32 `json:"scatter_offset,omitempty"`
+	// Version 0x20200 */
+	TeamOffset uint32    `json:"team_offset,omitempty"`
+	Version    CdVersion `json:"version,omitempty"`
+}
+
+// CodeSignature object
+type CodeSignature struct {
+	CMSSignature                 []uint8         `json:"cms_signature,omitempty"`
+	CodeDirectories              []CodeDirectory `json:"code_directories,omitempty"`
+	Entitlements                 string          `json:"entitlements,omitempty"`
+	EntitlementsDER              []uint8         `json:"entitlements_der,omitempty"`
+	Errors                       []string        `json:"errors,omitempty"`
+	LaunchConstraintsParent      []uint8         `json:"launch_constraints_parent,omitempty"`
+	LaunchConstraintsResponsible []uint8         `json:"launch_constraints_responsible,omitempty"`
+	LaunchConstraintsSelf        []uint8         `json:"launch_constraints_self,omitempty"`
+	LibraryConstraints           []uint8         `json:"library_constraints,omitempty"`
+	Requirements                 []Requirement   `json:"requirements,omitempty"`
+}
+
+// CodeSlot is the 'CodeSlot' schema
+type CodeSlot struct {
+	Desc  string  `json:"desc,omitempty"`
+	Hash  []uint8 `json:"hash,omitempty"`
+	Index uint32  `json:"index,omitempty"`
+	Page  uint32  `json:"page,omitempty"`
+}
+
+// Compression is the compression of an IM4P payload
+type Compression string
+
+// DeletedAt is the 'DeletedAt' schema
+type DeletedAt *NullTime
+
+// Device object
+type Device struct {
+	CompatibleDeviceFallback string       `json:"compatible_device_fallback,omitempty"`
+	Platform                 string       `json:"platform,omitempty"`
+	ProductDescription       string       `json:"product_description,omitempty"`
+	ProductType              string       `json:"product_type,omitempty"`
+	Target                   string       `json:"target,omitempty"`
+	TargetType               string       `json:"target_type,omitempty"`
+	TargetVariant            string       `json:"target_variant,omitempty"`
+	Traits                   *DeviceTrait `json:"traits,omitempty"`
+}
+
+// DeviceTrait object
+type DeviceTrait struct {
+	ArtworkDeviceIdiom           string `json:"artwork_device_idiom,omitempty"`
+	ArtworkDeviceSubtype         int64  `json:"artwork_device_subtype,omitempty"`
+	ArtworkDisplayGamut          string `json:"artwork_display_gamut,omitempty"`
+	ArtworkDynamicDisplayMode    string `json:"artwork_dynamic_display_mode,omitempty"`
+	ArtworkHostedIdioms          string `json:"artwork_hosted_idioms,omitempty"`
+	ArtworkScaleFactor           int64  `json:"artwork_scale_factor,omitempty"`
+	DevicePerformanceMemoryClass int64  `json:"device_performance_memory_class,omitempty"`
+	GraphicsFeatureSetClass      string `json:"graphics_feature_set_class,omitempty"`
+	GraphicsFeatureSetFallbacks  string `json:"graphics_feature_set_fallbacks,omitempty"`
+	PreferredArchitecture        string `json:"preferred_architecture,omitempty"`
+}
+
+// DeviceTree object
+type DeviceTree map[string]Properties
+
+// DeviceValues is the 'DeviceValues' schema
+type DeviceValues struct {
+	ActivationState                 string  `json:"activation_state,omitempty"`
+	ActivationStateAcknowledged     bool    `json:"activation_state_acknowledged,omitempty"`
+	ApNonce                         []uint8 `json:"ap_nonce,omitempty"`
+	BasebandActivationTicketVersion string  `json:"baseband_activation_ticket_version,omitempty"`
+	BasebandCertID                  int64   `json:"baseband_cert_id,omitempty"`
+	BasebandChipID                  int64   `json:"baseband_chip_id,omitempty"`
+	BasebandKeyHashInformation      struct {
+		AKeyStatus int64   `json:"a_key_status,omitempty"`
+		SKeyHash   []uint8 `json:"s_key_hash,omitempty"`
+		SKeyStatus int64   `json:"s_key_status,omitempty"`
+	} `json:"baseband_key_hash_information,omitempty"`
+	BasebandMasterKeyHash                         string           `json:"baseband_master_key_hash,omitempty"`
+	BasebandRegionSKU                             []uint8          `json:"baseband_region_sku,omitempty"`
+	BasebandSerialNumber                          []uint8          `json:"baseband_serial_number,omitempty"`
+	BasebandStatus                                string           `json:"baseband_status,omitempty"`
+	BasebandVersion                               string           `json:"baseband_version,omitempty"`
+	BluetoothAddress                              string           `json:"bluetooth_address,omitempty"`
+	BoardID                                       int64            `json:"board_id,omitempty"`
+	BootSessionID                                 string           `json:"boot_session_id,omitempty"`
+	BootstrapVersion                              string           `json:"bootstrap_version,omitempty"`
+	BrickState                                    bool             `json:"brick_state,omitempty"`
+	BuildVersion                                  string           `json:"build_version,omitempty"`
+	CarrierBundleInfoArray                        []map[string]any `json:"carrier_bundle_info_array,omitempty"`
+	CertID                                        int64            `json:"cert_id,omitempty"`
+	ChipID                                        int64            `json:"chip_id,omitempty"`
+	ChipSerialNo                                  []uint8          `json:"chip_serial_no,omitempty"`
+	CPUArchitecture                               string           `json:"cpu_architecture,omitempty"`
+	CTPostponementInfoPRIVersion                  string           `json:"ct_postponement_info_pri_version,omitempty"`
+	CTPostponementInfoPRLName                     int64            `json:"ct_postponement_info_prl_name,omitempty"`
+	CTPostponementInfoServiceProvisioningState    bool             `json:"ct_postponement_info_service_provisioning_state,omitempty"`
+	CTPostponementStatus                          string           `json:"ct_postponement_status,omitempty"`
+	DeviceClass                                   string           `json:"device_class,omitempty"`
+	DeviceColor                                   string           `json:"device_color,omitempty"`
+	DeviceName                                    string           `json:"device_name,omitempty"`
+	DieID                                         int64            `json:"die_id,omitempty"`
+	EthernetAddress                               string           `json:"ethernet_address,omitempty"`
+	FirmwareVersion                               string           `json:"firmware_version,omitempty"`
+	FusingStatus                                  int64            `json:"fusing_status,omitempty"`
+	GID1                                          string           `json:"gid_1,omitempty"`
+	GID2                                          string           `json:"gid_2,omitempty"`
+	HardwareModel                                 string           `json:"hardware_model,omitempty"`
+	HardwarePlatform                              string           `json:"hardware_platform,omitempty"`
+	HasSiDP                                       bool             `json:"has_si_dp,omitempty"`
+	HostAttached                                  bool             `json:"host_attached,omitempty"`
+	Image4Supported                               bool             `json:"img4_supported,omitempty"`
+	IntegratedCircuitCardIdentity                 string           `json:"integrated_circuit_card_identity,omitempty"`
+	InternationalMobileEquipmentIdentity          string           `json:"international_mobile_equipment_identity,omitempty"`
+	InternationalMobileEquipmentIdentity2         string           `json:"international_mobile_equipment_identity_2,omitempty"`
+	InternationalMobileSubscriberIdentity         string           `json:"international_mobile_subscriber_identity,omitempty"`
+	InternationalMobileSubscriberIdentityOverride bool             `json:"international_mobile_subscriber_identity_override,omitempty"`
+	MLBSerialNumber                               string           `json:"mlb_serial_number,omitempty"`
+	MobileEquipmentIdentifier                     string           `json:"mobile_equipment_identifier,omitempty"`
+	MobileSubscriberCountryCode                   string           `json:"mobile_subscriber_country_code,omitempty"`
+	MobileSubscriberNetworkCode                   string           `json:"mobile_subscriber_network_code,omitempty"`
+	ModelNumber                                   string           `json:"model_number,omitempty"`
+	NonVolatileRAM                                map[string]any   `json:"non_volatile_ram,omitempty"`
+	PairRecordProtectionClass                     int64            `json:"pair_record_protection_class,omitempty"`
+	PartitionType                                 string           `json:"partition_type,omitempty"`
+	PasswordProtected                             bool             `json:"password_protected,omitempty"`
+	PhoneNumber                                   string           `json:"phone_number,omitempty"`
+	PkHash                                        []uint8          `json:"pk_hash,omitempty"`
+	FirmwarePreflightInfo                         map[string]any   `json:"preflight_info,omitempty"`
+	PRIVersion_Major                              int64            `json:"pri_version___major,omitempty"`
+	PRIVersion_Minor                              int64            `json:"pri_version___minor,omitempty"`
+	PRIVersion_ReleaseNo                          int64            `json:"pri_version___release_no,omitempty"`
+	ProductName                                   string           `json:"product_name,omitempty"`
+	ProductType                                   string           `json:"product_type,omitempty"`
+	ProductVersion                                string           `json:"product_version,omitempty"`
+	ProductionSOC                                 bool             `json:"production_soc,omitempty"`
+	ProtocolVersion                               string           `json:"protocol_version,omitempty"`
+	ProximitySensorCalibration                    []uint8          `json:"proximity_sensor_calibration,omitempty"`
+	RegionInfo                                    string           `json:"region_info,omitempty"`
+	ReleaseType                                   string           `json:"release_type,omitempty"`
+	SEPNonce                                      []uint8          `json:"sep_nonce,omitempty"`
+	SerialNumber                                  string           `json:"serial_number,omitempty"`
+	SIM1IsBootstrap                               bool             `json:"sim1_is_bootstrap,omitempty"`
+	SIM1IsEmbedded                                bool             `json:"sim_1_is_embedded,omitempty"`
+	SIMStatus                                     any              `json:"sim_status,omitempty"`
+	SIMTrayStatus                                 any              `json:"sim_tray_status,omitempty"`
+	SIMGID1                                       []uint8          `json:"simgid_1,omitempty"`
+	SIMGID2                                       []uint8          `json:"simgid_2,omitempty"`
+	SoftwareBehavior                              []uint8          `json:"software_behavior,omitempty"`
+	SoftwareBundleVersion                         string           `json:"software_bundle_version,omitempty"`
+	SupportedDeviceFamilies                       []int64          `json:"supported_device_families,omitempty"`
+	TelephonyCapability                           bool             `json:"telephony_capability,omitempty"`
+	TimeIntervalSince1970                         float64          `json:"time_interval_since_1970,omitempty"`
+	TimeZone                                      string           `json:"time_zone,omitempty"`
+	TimeZoneOffsetFromUTC                         float64          `json:"time_zone_offset_from_utc,omitempty"`
+	TrustedHostAttached                           bool             `json:"trusted_host_attached,omitempty"`
+	UniqueChipID                                  int64            `json:"unique_chip_id,omitempty"`
+	UniqueDeviceID                                string           `json:"unique_device_id,omitempty"`
+	UntrustedHostBUID                             string           `json:"untrusted_host_buid,omitempty"`
+	UseRaptorCerts                                bool             `json:"use_raptor_certs,omitempty"`
+	Uses24HourClock                               bool             `json:"uses_24_hour_clock,omitempty"`
+	WiFiAddress                                   string           `json:"wi_fi_address,omitempty"`
+	WirelessBoardSerialNumber                     string           `json:"wireless_board_serial_number,omitempty"`
+}
+
+// DyldSharedCache is the model for a dyld_shared_cache.
+type DyldSharedCache struct {
+	CreatedAt         time.Time `json:"CreatedAt,omitempty"`
+	DeletedAt         DeletedAt `json:"DeletedAt,omitempty"`
+	UpdatedAt         time.Time `json:"UpdatedAt,omitempty"`
+	Images            []Macho   `json:"images,omitempty"`
+	SharedRegionStart uint64    `json:"shared_region_start,omitempty"`
+	UUID              string    `json:"uuid,omitempty"`
+}
+
+// Dylib is a struct that contains information about a dyld_shared_cache dylib
+type Dylib struct {
+	Index       int64  `json:"index,omitempty"`
+	LoadAddress uint64 `json:"load_address,omitempty"`
+	Name        string `json:"name,omitempty"`
+	UUID        string `json:"uuid,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// Dysymtab represents a Mach-O LC_DYSYMTAB command.
+type Dysymtab struct {
+	// To support dynamic module binding the module structure for each module
+	// indicates the external references (defined and undefined) each module
+	// makes.  For each module there is an offset and a count into the
+	// reference symbol table for the symbols that the module references.
+	// This exists only in a dynamically linked shared library file.  For
+	// executable and object modules the defined external symbols and the
+	// undefined external symbols indicates the external references.
+	Extrefsymoff uint32 `json:"Extrefsymoff,omitempty"`
+	// To support relocating an individual module in a library file quickly the
+	// external relocation entries for each module in the library need to be
+	// accessed efficiently.  Since the relocation entries can't be accessed
+	// through the section headers for a library file they are separated into
+	// groups of local and external entries further grouped by module.  In this
+	// case the presents of this load command who's extreloff, nextrel,
+	// locreloff and nlocrel fields are non-zero indicates that the relocation
+	// entries of non-merged sections are not referenced through the section
+	// structures (and the reloff and nreloc fields in the section headers are
+	// set to zero).
+	//
+	// Since the relocation entries are not accessed through the section headers
+	// this requires the r_address field to be something other than a section
+	// offset to identify the item to be relocated.  In this case r_address is
+	// set to the offset from the vmaddr of the first LC_SEGMENT command.
+	// For MH_SPLIT_SEGS images r_address is set to the the offset from the
+	// vmaddr of the first read-write LC_SEGMENT command.
+	//
+	// The relocation entries are grouped by module and the module table
+	// entries have indexes and counts into them for the group of external
+	// relocation entries for that the module.
+	//
+	// For sections that are merged across modules there must not be any
+	// remaining external relocation entries for them (for merged sections
+	// remaining relocation entries must be local).
+	Extreloff  uint32 `json:"Extreloff,omitempty"`
+	Iextdefsym uint32 `json:"Iextdefsym,omitempty"`
+	// The symbols indicated by symoff and nsyms of the LC_SYMTAB load command
+	// are grouped into the following three groups:
+	// local symbols (further grouped by the module they are from)
+	// defined external symbols (further grouped by the module they are from)
+	// undefined symbols
+	//
+	// The local symbols are used only for debugging.  The dynamic binding
+	// process may have to use them to indicate to the debugger the local
+	// symbols for a module that is being bound.
+	//
+	// The last two groups are used by the dynamic binding process to do the
+	// binding (indirectly through the module table and the reference symbol
+	// table when this is a dynamically linked shared library file).
+	Ilocalsym    uint32   `json:"Ilocalsym,omitempty"`
+	IndirectSyms []uint32 `json:"IndirectSyms,omitempty"`
+	// The sections that contain "symbol pointers" and "routine stubs" have
+	// indexes and (implied counts based on the size of the section and fixed
+	// size of the entry) into the "indirect symbol" table for each pointer
+	// and stub.  For every section of these two types the index into the
+	// indirect symbol table is stored in the section header in the field
+	// reserved1.  An indirect symbol table entry is simply a 32bit index into
+	// the symbol table to the symbol that the pointer or stub is referring to.
+	// The indirect symbol table is ordered to match the entries in the section.
+	Indirectsymoff uint32 `json:"Indirectsymoff,omitempty"`
+	Iundefsym      uint32 `json:"Iundefsym,omitempty"`
+	Len            uint32 `json:"Len,omitempty"`
+	// All the local relocation entries are grouped together (they are not
+	// grouped by their module since they are only used if the object is moved
+	// from it staticly link edited address).
+	Locreloff uint32 `json:"Locreloff,omitempty"`
+	// To support dynamic binding of "modules" (whole object files) the symbol
+	// table must reflect the modules that the file was created from.  This is
+	// done by having a module table that has indexes and counts into the merged
+	// tables for each module.  The module structure that these two entries
+	// refer to is described below.  This exists only in a dynamically linked
+	// shared library file.  For executable and object modules the file only
+	// contains one module so everything in the file belongs to the module.
+	Modtaboff     uint32 `json:"Modtaboff,omitempty"`
+	Nextdefsym    uint32 `json:"Nextdefsym,omitempty"`
+	Nextrefsyms   uint32 `json:"Nextrefsyms,omitempty"`
+	Nextrel       uint32 `json:"Nextrel,omitempty"`
+	Nindirectsyms uint32 `json:"Nindirectsyms,omitempty"`
+	Nlocalsym     uint32 `json:"Nlocalsym,omitempty"`
+	Nlocrel       uint32 `json:"Nlocrel,omitempty"`
+	Nmodtab       uint32 `json:"Nmodtab,omitempty"`
+	Ntoc          uint32 `json:"Ntoc,omitempty"`
+	Nundefsym     uint32 `json:"Nundefsym,omitempty"`
+	// For the for the dynamic binding process to find which module a symbol
+	// is defined in the table of contents is used (analogous to the ranlib
+	// structure in an archive) which maps defined external symbols to modules
+	// they are defined in.  This exists only in a dynamically linked shared
+	// library file.  For executable and object modules the defined external
+	// symbols are sorted by name and is use as the table of contents.
+	Tocoffset uint32 `json:"Tocoffset,omitempty"`
+}
+
+// EntMatch is a file with an entitlement matching the search
+type EntMatch struct {
+	File  string `json:"file,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Event is a job progress/log event
+type Event struct {
+	Current int64     `json:"current,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Result  any       `json:"result,omitempty"`
+	State   JobState  `json:"state,omitempty"`
+	Time    time.Time `json:"time,omitempty"`
+	Total   int64     `json:"total,omitempty"`
+	Type    EventType `json:"type,omitempty"`
+}
+
+// EventType is the type of a job event
+type EventType string
+
+// File represents an open Mach-O file.
+type File struct {
+	ByteOrder    *ByteOrder     `json:"ByteOrder,omitempty"`
+	CPU          CPU            `json:"CPU,omitempty"`
+	Dysymtab     *Dysymtab      `json:"Dysymtab,omitempty"`
+	Flags        HeaderFlag     `json:"Flags,omitempty"`
+	Loads        Loads          `json:"Loads,omitempty"`
+	Magic        Magic          `json:"Magic,omitempty"`
+	NCommands    uint32         `json:"NCommands,omitempty"`
+	Reserved     uint32         `json:"Reserved,omitempty"`
+	Sections     []Section      `json:"Sections,omitempty"`
+	SizeCommands uint32         `json:"SizeCommands,omitempty"`
+	SubCPU       CPUSubtype     `json:"SubCPU,omitempty"`
+	Symtab       *Symtab        `json:"Symtab,omitempty"`
+	Type         HeaderFileType `json:"Type,omitempty"`
+}
+
+// HeaderFileType is the Mach-O file type, e.g. an object file, executable, or dynamic library.
+type HeaderFileType uint32
+
+// HeaderFlag is the 'HeaderFlag' schema
+type HeaderFlag uint32
+
+// IdentityInfo is the 'IdentityInfo' schema
+type IdentityInfo struct {
+	BuildNumber            string            `json:"build_number,omitempty"`
+	CodeName               string            `json:"code_name,omitempty"`
+	DeviceClass            string            `json:"device_class,omitempty"`
+	FDRSupport             bool              `json:"fdr_support,omitempty"`
+	MinimumSystemPartition int64             `json:"minimum_system_partition,omitempty"`
+	MobileDeviceMinVersion string            `json:"mobile_device_min_version,omitempty"`
+	OSVarContentSize       int64             `json:"os_var_content_size,omitempty"`
+	RestoreBehavior        string            `json:"restore_behavior,omitempty"`
+	SystemPartitionPadding map[string]int64  `json:"system_partition_padding,omitempty"`
+	Variant                string            `json:"variant,omitempty"`
+	VariantContents        map[string]string `json:"variant_contents,omitempty"`
+}
+
+// IdentityManifest is the 'IdentityManifest' schema
+type IdentityManifest struct {
+	BuildString string         `json:"build_string,omitempty"`
+	Digest      []uint8        `json:"digest,omitempty"`
+	EPRO        bool           `json:"epro,omitempty"`
+	ESEC        bool           `json:"esec,omitempty"`
+	Info        map[string]any `json:"info,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	Trusted     bool           `json:"trusted,omitempty"`
+}
+
+// ImportedBy is a struct that contains information about which dyld_shared_cache dylibs import a given dylib
+type ImportedBy struct {
+	Apps []string `json:"apps,omitempty"`
+	DSC  []string `json:"dsc,omitempty"`
+}
+
+// Info in the info object
+type Info struct {
+	DeviceTrees map[string]DeviceTree `json:"DeviceTrees,omitempty"`
+	Plists      *Plists               `json:"Plists,omitempty"`
+}
+
+// IPSW is the model for an Ipsw file.
+type IPSW struct {
+	CreatedAt  time.Time         `json:"CreatedAt,omitempty"`
+	DeletedAt  DeletedAt         `json:"DeletedAt,omitempty"`
+	UpdatedAt  time.Time         `json:"UpdatedAt,omitempty"`
+	BuildID    string            `json:"buildid,omitempty"`
+	Devices    []Device          `json:"devices,omitempty"`
+	DSCs       []DyldSharedCache `json:"dscs,omitempty"`
+	FileSystem []Macho           `json:"file_system,omitempty"`
+	ID         string            `json:"id,omitempty"`
+	Kernels    []Kernelcache     `json:"kernels,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Version    string            `json:"version,omitempty"`
+}
+
+// JobState is the state of a daemon job.
+type JobState string
+
+// KernRoutineDescriptor is the 'KernRoutineDescriptor' schema
+type KernRoutineDescriptor struct {
+	// /* Number of argument words */
+	ArgC uint32 `json:"ArgC,omitempty"`
+	// /* Number complex descriptors */
+	DescrCount uint32 `json:"DescrCount,omitempty"`
+	// /* Server work func pointer */
+	ImplRoutine uint64 `json:"ImplRoutine,omitempty"`
+	// /* Unmarshalling func pointer */
+	KStubRoutine uint64 `json:"KStubRoutine,omitempty"`
+	// /* Max size for reply msg */
+	MaxReplyMsg uint32 `json:"MaxReplyMsg,omitempty"`
+	// /* Number descriptors in reply */
+	ReplyDescrCount uint32 `json:"ReplyDescrCount,omitempty"`
+}
+
+// Kernelcache is the model for a kernelcache.
+type Kernelcache struct {
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	DeletedAt DeletedAt `json:"deleted_at,omitempty"`
+	Kexts     []Macho   `json:"kexts,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	UUID      string    `json:"uuid,omitempty"`
+	Version   string    `json:"version,omitempty"`
+}
+
+// LLVMVersion represents the LLVM version used to compile the kernel.
+type LLVMVersion struct {
+	// The LLVM compiler
+	Clang string `json:"clang,omitempty"`
+	// The LLVM compiler flags
+	Flags []string `json:"flags,omitempty"`
+	// The LLVM version
+	Version string `json:"version,omitempty"`
+}
+
+// Load represents any Mach-O load command.
+type Load struct {
+	Command  LoadCmd `json:"Command,omitempty"`
+	LoadSize uint32  `json:"LoadSize,omitempty"`
+	Raw      []uint8 `json:"Raw,omitempty"`
+	String   string  `json:"String,omitempty"`
+}
+
+// LoadCmd is a Mach-O load command.
+type LoadCmd uint32
+
+// MachTrap is the mach_trap object
+type MachTrap struct {
+	ArgCount    uint8    `json:"ArgCount,omitempty"`
+	ArgMunge32  uint64   `json:"ArgMunge32,omitempty"`
+	Args        []string `json:"Args,omitempty"`
+	Function    uint64   `json:"Function,omitempty"`
+	Name        string   `json:"Name,omitempty"`
+	Number      int64    `json:"Number,omitempty"`
+	Padding     []uint8  `json:"Padding,omitempty"`
+	ReturnsPort uint8    `json:"ReturnsPort,omitempty"`
+	U32Words    uint8    `json:"U32Words,omitempty"`
+}
+
+// Macho is the 'Macho' schema
+type Macho struct {
+	Path      *Path    `json:"Path,omitempty"`
+	Symbols   []Symbol `json:"Symbols,omitempty"`
+	TextEnd   uint64   `json:"text_end,omitempty"`
+	TextStart uint64   `json:"text_start,omitempty"`
+	UUID      string   `json:"uuid,omitempty"`
+}
+
+// MachoDiff is the 'MachoDiff' schema
+type MachoDiff struct {
+	New     []string          `json:"new,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+	Updated map[string]string `json:"updated,omitempty"`
+}
+
+// Magic is the 'Magic' schema
+type Magic uint32
+
+// MigKernSubsystem is the 'MigKernSubsystem' schema
+type MigKernSubsystem struct {
+	// /* Max routine number + 1 */
+	End uint32 `json:"End,omitempty"`
+	// /* pointer to kernel demux routine */
+	KServer uint64 `json:"KServer,omitempty"`
+	// /* Max reply message size */
+	Maxsize uint32 `json:"Maxsize,omitempty"`
+	// /* reserved for MIG use */
+	Reserved uint64 `json:"Reserved,omitempty"`
+	// /* Kernel routine descriptor array */
+	Routines []KernRoutineDescriptor `json:"Routines,omitempty"`
+	Start    SubsystemStart          `json:"Start,omitempty"`
+}
+
+// Name is the 'Name' schema
+type Name struct {
+	Name string `json:"name,omitempty"`
+}
+
+// NullTime represents a [time.Time] that may be null.
+type NullTime struct {
+	Time  time.Time `json:"Time,omitempty"`
+	Valid bool      `json:"Valid,omitempty"`
+}
+
+// Object is a stored artifact
+type Object struct {
+	Key      string    `json:"key,omitempty"`
+	Modified time.Time `json:"modified,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+}
+
+// Path is the 'Path' schema
+type Path struct {
+	Path string `json:"name,omitempty"`
+}
+
+// PayloadType is the kind of firmware contained in an IM4P payload
+type PayloadType string
+
+// Pipeline is a declarative list of steps run when its trigger fires
+type Pipeline struct {
+	// File is the file the pipeline was loaded from
+	File  string   `json:"file,omitempty"`
+	Name  string   `json:"name,omitempty"`
+	On    *Trigger `json:"on,omitempty"`
+	Steps []Step   `json:"steps,omitempty"`
+}
+
+// Plists IPSW/OTA plists object
+type Plists struct {
+	DeviceMap                     []RestoreDeviceMap `json:"DeviceMap,omitempty"`
+	ProductBuildVersion           string             `json:"ProductBuildVersion,omitempty"`
+	ProductVersion                string             `json:"ProductVersion,omitempty"`
+	SupportedProductTypeIDs       map[string][]int64 `json:"SupportedProductTypeIDs,omitempty"`
+	SupportedProductTypes         []string           `json:"SupportedProductTypes,omitempty"`
+	SystemRestoreImageFileSystems map[string]string  `json:"SystemRestoreImageFileSystems,omitempty"`
+	ActualMinimumSystemPartition  int64              `json:"actual_minimum_system_partition,omitempty"`
+	Build                         string             `json:"build,omitempty"`
+	BuildID                       string             `json:"build_id,omitempty"`
+	BuildIdentities               []BuildIdentity    `json:"build_identities,omitempty"`
+	CFBundleIdentifier            string             `json:"cf_bundle_identifier,omitempty"`
+	CFBundleInfoDictionaryVersion string             `json:"cf_bundle_info_dictionary_version,omitempty"`
+	CFBundleName                  string             `json:"cf_bundle_name,omitempty"`
+	CFBundleShortVersionString    string             `json:"cf_bundle_short_version_string,omitempty"`
+	CFBundleVersion               string             `json:"cf_bundle_version,omitempty"`
+	DeviceClass                   string             `json:"device_class,omitempty"`
+	HardwareModel                 string             `json:"hardware_model,omitempty"`
+	ManifestVersion               int64              `json:"manifest_version,omitempty"`
+	MinimumSystemPartition        int64              `json:"minimum_system_partition,omitempty"`
+	MobileAssetProperties         *Asset             `json:"mobile_asset_properties,omitempty"`
+	PackageVersion                string             `json:"package_version,omitempty"`
+	ProductBuildVersion_          string             `json:"product_build_version,omitempty"`
+	ProductCopyright              string             `json:"product_copyright,omitempty"`
+	ProductName                   string             `json:"product_name,omitempty"`
+	ProductType                   string             `json:"product_type,omitempty"`
+	ProductVersion_               string             `json:"product_version,omitempty"`
+	ReleaseType                   string             `json:"release_type,omitempty"`
+	RequiredSpace                 int64              `json:"required_space,omitempty"`
+	ReserveFileAware              bool               `json:"reserve_file_aware,omitempty"`
+	RSEPDigest                    []uint8            `json:"rsep_digest,omitempty"`
+	RSEPTBMDigests                []uint8            `json:"rseptbm_digests,omitempty"`
+	SEPDigest                     []uint8            `json:"sep_digest,omitempty"`
+	SEPTBMDigests                 []uint8            `json:"septbm_digests,omitempty"`
+	SizeArchiveRoot               int64              `json:"size_archive_root,omitempty"`
+	SizePatchedBinaries           int64              `json:"size_patched_binaries,omitempty"`
+	SizePatchedBinariesSnapshot   int64              `json:"size_patched_binaries_snapshot,omitempty"`
+	SupportedProductTypes_        []string           `json:"supported_product_types,omitempty"`
+	SystemImageID                 string             `json:"system_image_id,omitempty"`
+	SystemPartitionPadding        map[string]int64   `json:"system_partition_padding,omitempty"`
+	SystemUpdatePathMap           map[string]string  `json:"system_update_path_map,omitempty"`
+	SystemVolumeSealingOverhead   int64              `json:"system_volume_sealing_overhead,omitempty"`
+	TargetUpdate                  string             `json:"target_update,omitempty"`
+	Type                          string             `json:"type,omitempty"`
+}
+
+// Properties object
+type Properties map[string]any
+
+// QueueStatus is the status of a download queue item.
+type QueueStatus string
+
+// QuotaUsage is a client's usage of one of its quotas
+type QuotaUsage struct {
+	Limit uint64    `json:"limit,omitempty"`
+	Reset time.Time `json:"reset,omitempty"`
+	Used  uint64    `json:"used,omitempty"`
+}
+
+// Rebase is the 'Rebase' schema
+type Rebase struct {
+	CacheFileOffset uint64             `json:"cache_file_offset,omitempty"`
+	CacheVMAddress  uint64             `json:"cache_vm_address,omitempty"`
+	Pointer         *CacheSlidePointer `json:"pointer,omitempty"`
+	Symbol          string             `json:"symbol,omitempty"`
+	Target          uint64             `json:"target,omitempty"`
+}
+
+// Reloc represents a Mach-O relocation.
+type Reloc struct {
+	Addr      uint32 `json:"Addr,omitempty"`
+	Extern    bool   `json:"Extern,omitempty"`
+	Len       uint8  `json:"Len,omitempty"`
+	Pcrel     bool   `json:"Pcrel,omitempty"`
+	Scattered bool   `json:"Scattered,omitempty"`
+	// when Scattered == false && Extern == true, Value is the symbol number.
+	// when Scattered == false && Extern == false, Value is the section number.
+	// when Scattered == true, Value is the value that this reloc refers to.
+	Type  uint8  `json:"Type,omitempty"`
+	Value uint32 `json:"Value,omitempty"`
+}
+
+// Requirement object
+type Requirement struct {
+	Data   uint32          `json:"data,omitempty"`
+	Detail string          `json:"detail,omitempty"`
+	Length uint32          `json:"length,omitempty"`
+	Magic  Magic           `json:"magic,omitempty"`
+	Offset uint32          `json:"offset,omitempty"`
+	Type   RequirementType `json:"type,omitempty"`
+}
+
+// RequirementType is the 'RequirementType' schema
+type RequirementType uint32
+
+// ScanItem is an IPSW/OTA URL in the daemon's symbol scan queue.
+type ScanItem struct {
+	Attempts  int64     `json:"attempts,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	ID        uint64    `json:"id,omitempty"`
+	// IpswID is the SHA1 of the scanned IPSW
+	IpswID      string    `json:"ipsw_id,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	// Owner is the API key (or client IP) that queued the item
+	Owner string `json:"owner,omitempty"`
+	// Priority orders the pending items (the highest priority items are scanned first)
+	Priority int64 `json:"priority,omitempty"`
+	// Skipped is set if the IPSW was already scanned
+	Skipped   bool        `json:"skipped,omitempty"`
+	Status    QueueStatus `json:"status,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at,omitempty"`
+	URL       string      `json:"url,omitempty"`
+}
+
+// Scatter object
+type Scatter struct {
+	Base         uint32 `json:"base,omitempty"`
+	Count        uint32 `json:"count,omitempty"`
+	TargetOffset uint64 `json:"target_offset,omitempty"`
+}
+
+// Section is the 'Section' schema
+type Section struct {
+	Addr      uint64      `json:"Addr,omitempty"`
+	Align     uint32      `json:"Align,omitempty"`
+	Flags     SectionFlag `json:"Flags,omitempty"`
+	Name      string      `json:"Name,omitempty"`
+	Nreloc    uint32      `json:"Nreloc,omitempty"`
+	Offset    uint32      `json:"Offset,omitempty"`
+	Relocs    []Reloc     `json:"Relocs,omitempty"`
+	Reloff    uint32      `json:"Reloff,omitempty"`
+	Reserved1 uint32      `json:"Reserved1,omitempty"`
+	Reserved2 uint32      `json:"Reserved2,omitempty"`
+	Reserved3 uint32      `json:"Reserved3,omitempty"`
+	Seg       string      `json:"Seg,omitempty"`
+	Size      uint64      `json:"Size,omitempty"`
+	Type      uint8       `json:"Type,omitempty"`
+}
+
+// SectionFlag is the 'SectionFlag' schema
+type SectionFlag uint32
+
+// SpecialSlot is the 'SpecialSlot' schema
+type SpecialSlot struct {
+	Desc  string  `json:"desc,omitempty"`
+	Hash  []uint8 `json:"hash,omitempty"`
+	Index uint32  `json:"index,omitempty"`
+}
+
+// Status is the status of a check (or of all of them)
+type Status string
+
+// Step is a daemon job of a pipeline
+type Step struct {
+	// ContinueOnError records the step's error as its result instead of failing the run
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+	// If is a template the step is skipped unless it renders 'true'
+	If string `json:"if,omitempty"`
+	// Name is the step's ID in the templates (i.e. '.Steps.<name>' is its result)
+	Name string `json:"name,omitempty"`
+	// Uses is the kind of job to run (i.e. 'download/ipsw') or 'publish' to post a report to the webhooks
+	Uses string `json:"uses,omitempty"`
+	// With are the job's params (strings are templates)
+	With map[string]any `json:"with,omitempty"`
+}
+
+// String is a struct that contains information about a dyld_shared_cache string
+type String struct {
+	Address uint64 `json:"address,omitempty"`
+	Image   string `json:"image,omitempty"`
+	Mapping string `json:"mapping,omitempty"`
+	Offset  uint64 `json:"offset,omitempty"`
+	String  string `json:"string,omitempty"`
+}
+
+// SubsystemStart is the 'SubsystemStart' schema
+type SubsystemStart uint32
+
+// Symbol is the 'Symbol' schema
+type Symbol struct {
+	Name  *Name  `json:"Name,omitempty"`
+	End   uint64 `json:"end,omitempty"`
+	Start uint64 `json:"start,omitempty"`
+}
+
+// SymbolLookup is a struct that contains information about a dyld_shared_cache symbol lookup
+type SymbolLookup struct {
+	// The address of the symbol
+	Address uint64 `json:"address,omitempty"`
+	// The demangled symbol name
+	Demanged string `json:"demanged,omitempty"`
+	// The DSC sub-cache file extension
+	Extension string `json:"ext,omitempty"`
+	// The containing image name
+	Image string `json:"image,omitempty"`
+	// The DSC mapping name
+	Mapping string `json:"mapping,omitempty"`
+	// The containing image section
+	Section string `json:"section,omitempty"`
+	// The containing image segment
+	Segment string `json:"segment,omitempty"`
+	// Is the symbol in a DSC stub island
+	StubIsland bool `json:"stub_island,omitempty"`
+	// The symbol name
+	Symbol string `json:"symbol,omitempty"`
+	// The DSC sub-cache UUID
+	UUID string `json:"uuid,omitempty"`
+}
+
+// Symtab represents a Mach-O LC_SYMTAB command.
+type Symtab struct {
+	Len     uint32   `json:"Len,omitempty"`
+	Nsyms   uint32   `json:"Nsyms,omitempty"`
+	Stroff  uint32   `json:"Stroff,omitempty"`
+	Strsize uint32   `json:"Strsize,omitempty"`
+	Symoff  uint32   `json:"Symoff,omitempty"`
+	Syms    []Symbol `json:"Syms,omitempty"`
+}
+
+// Sysent is the 'Sysent' schema
+type Sysent struct {
+	ArgBytes   uint16     `json:"arg_bytes,omitempty"`
+	Args       []string   `json:"args,omitempty"`
+	Call       uint64     `json:"call,omitempty"`
+	Munge      uint64     `json:"munge,omitempty"`
+	NArg       int16      `json:"n_arg,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	New        bool       `json:"new,omitempty"`
+	Number     int64      `json:"number,omitempty"`
+	Old        bool       `json:"old,omitempty"`
+	DBName     string     `json:"old_name,omitempty"`
+	Proto      string     `json:"proto,omitempty"`
+	ReturnType ReturnType `json:"return_type,omitempty"`
+}
+
+// Trigger is when a pipeline is run (pipelines can always be run from the API)
+type Trigger struct {
+	Build *BuildTrigger `json:"build,omitempty"`
+}
+
+// Version represents the kernel version and LLVM version.
+type Version struct {
+	LLVMVersion
+	// The kernel architecture
+	Arch string `json:"arch,omitempty"`
+	// The kernel CPU
+	CPU string `json:"cpu,omitempty"`
+	// The darwin version
+	Darwin string `json:"darwin,omitempty"`
+	// The build date
+	Date time.Time `json:"date,omitempty"`
+	// The kernel type
+	Type string `json:"type,omitempty"`
+	// The xnu version
+	XNU string `json:"xnu,omitempty"`
+}
+
+// VmProtection is the 'VmProtection' schema
+type VmProtection int32
+
+// Worker is a snapshot of a worker
+type Worker struct {
+	Alive    bool      `json:"alive,omitempty"`
+	Capacity int64     `json:"capacity,omitempty"`
+	ID       string    `json:"id,omitempty"`
+	Kinds    []string  `json:"kinds,omitempty"`
+	Running  int64     `json:"running,omitempty"`
+	SeenAt   time.Time `json:"seen_at,omitempty"`
+}
+
+// Address is the 'address' schema
+type Address struct {
+	// the offset in the DSC sub-cache
+	Address  uint64    `json:"address,omitempty"`
+	SubCache *SubCache `json:"sub_cache,omitempty"`
+}
+
+// AssetReceipt is the 'assetReceipt' schema
+type AssetReceipt struct {
+	AssetReceipt   string `json:"AssetReceipt,omitempty"`
+	AssetSignature string `json:"AssetSignature,omitempty"`
+}
+
+// BridgeVersionInfo is the 'bridgeVersionInfo' schema
+type BridgeVersionInfo struct {
+	BridgeBuildGroup          string `json:"BridgeBuildGroup,omitempty"`
+	BridgeProductBuildVersion string `json:"BridgeProductBuildVersion,omitempty"`
+	BridgeVersion             string `json:"BridgeVersion,omitempty"`
+	CatalogURL                string `json:"CatalogURL,omitempty"`
+	IsSeed                    string `json:"IsSeed,omitempty"`
+	SEPEpoch                  struct {
+		Major int64 `json:"Major,omitempty"`
+		Minor int64 `json:"Minor,omitempty"`
+	} `json:"SEPEpoch,omitempty"`
+}
+
+// BuildIdentity is the 'buildIdentity' schema
+type BuildIdentity struct {
+	ApBoardID                     string                       `json:"ap_board_id,omitempty"`
+	ApChipID                      string                       `json:"ap_chip_id,omitempty"`
+	ApOSLongVersion               string                       `json:"ap_os_long_version,omitempty"`
+	ApSecurityDomain              string                       `json:"ap_security_domain,omitempty"`
+	BbActivationManifestKeyHash   []uint8                      `json:"bb_activation_manifest_key_hash,omitempty"`
+	BbChipID                      string                       `json:"bb_chip_id,omitempty"`
+	BbFDRSecurityKeyHash          []uint8                      `json:"bb_fdr_security_key_hash,omitempty"`
+	BbProvisioningManifestKeyHash []uint8                      `json:"bb_provisioning_manifest_key_hash,omitempty"`
+	Info                          *IdentityInfo                `json:"info,omitempty"`
+	Manifest                      map[string]*IdentityManifest `json:"manifest,omitempty"`
+	PearlCertificationRootPub     []uint8                      `json:"pearl_certification_root_pub,omitempty"`
+	ProductMarketingVersion       string                       `json:"product_marketing_version,omitempty"`
+	UniqueBuildID                 []uint8                      `json:"unique_build_id,omitempty"`
+}
+
+// CdPlatform is the 'cdPlatform' schema
+type CdPlatform uint8
+
+// CdVersion is the 'cdVersion' schema
+type CdVersion uint32
+
+// CryptexSize is the 'cryptexSize' schema
+type CryptexSize struct {
+	CryptexSize int64  `json:"CryptexSize,omitempty"`
+	CryptexTag  string `json:"CryptexTag,omitempty"`
+}
+
+// ExecSegFlag is the 'execSegFlag' schema
+type ExecSegFlag uint64
+
+// HashType is the 'hashType' schema
+type HashType uint8
+
+// JobsStatus Status is a snapshot of a job
+type JobsStatus struct {
+	Attempts    int64     `json:"attempts,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Finished    time.Time `json:"finished,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	Owner       string    `json:"owner,omitempty"`
+	Progress    *Event    `json:"progress,omitempty"`
+	Result      any       `json:"result,omitempty"`
+	Started     time.Time `json:"started,omitempty"`
+	State       JobState  `json:"state,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+}
+
+// Loads is the 'loads' schema
+type Loads []Load
+
+// ModelAnalysis Analysis is the result of a plugin analyzer run on an IPSW (or one of its MachOs) during a scan.
+type ModelAnalysis struct {
+	Analyzer string    `json:"analyzer,omitempty"`
+	At       time.Time `json:"at,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	// ID is the hash of the IPSW, plugin, analyzer and path (so a rescan updates the result)
+	ID     string `json:"id,omitempty"`
+	IpswID string `json:"ipsw_id,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Plugin string `json:"plugin,omitempty"`
+	Result string `json:"result,omitempty"`
+	UUID   string `json:"uuid,omitempty"`
+}
+
+// Offset is the 'offset' schema
+type Offset struct {
+	// the file offset in the DSC sub-cache
+	Offset   uint64    `json:"offset,omitempty"`
+	SubCache *SubCache `json:"sub_cache,omitempty"`
+}
+
+// RestoreDeviceMap is the 'restoreDeviceMap' schema
+type RestoreDeviceMap struct {
+	BDID        int64  `json:"BDID,omitempty"`
+	BoardConfig string `json:"BoardConfig,omitempty"`
+	CPID        int64  `json:"CPID,omitempty"`
+	Platform    string `json:"Platform,omitempty"`
+	SCEP        int64  `json:"SCEP,omitempty"`
+	SDOM        int64  `json:"SDOM,omitempty"`
+}
+
+// RestoreVersionInfo is the 'restoreVersionInfo' schema
+type RestoreVersionInfo struct {
+	IsSeed             bool   `json:"IsSeed,omitempty"`
+	RestoreBuildGroup  any    `json:"RestoreBuildGroup,omitempty"`
+	RestoreLongVersion string `json:"RestoreLongVersion,omitempty"`
+	RestoreVersion     string `json:"RestoreVersion,omitempty"`
+}
+
+// ReturnType is the 'returnType' schema
+type ReturnType int32
+
+// SlideInfo is the 'slideInfo' schema
+type SlideInfo struct {
+	GetPageSize uint32 `json:"GetPageSize,omitempty"`
+	GetVersion  uint32 `json:"GetVersion,omitempty"`
+}
+
+// SubCache is the 'subCache' schema
+type SubCache struct {
+	// the DSC sub-cache file extension
+	Extension string `json:"ext,omitempty"`
+	// the DSC sub-cache mapping name
+	Mapping string `json:"mapping,omitempty"`
+	// is the offset in a DSC stub island
+	InStubs bool `json:"stubs,omitempty"`
+	// the DSC sub-cache UUID
+	UUID string `json:"uuid,omitempty"`
+}
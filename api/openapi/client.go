@@ -0,0 +1,435 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// initialisms are upper-cased in the generated Go names
+var initialisms = map[string]bool{
+	"api": true, "cpu": true, "dmg": true, "dsc": true, "fcs": true, "id": true, "ipsw": true,
+	"json": true, "os": true, "ota": true, "sptm": true, "uuid": true, "url": true, "vm": true,
+}
+
+// goName returns the exported Go name of a JSON name (i.e. 'sub_cache' => 'SubCache')
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		rs := []rune(part)
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// comment formats text as a Go comment
+func comment(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) == 0 {
+		return ""
+	}
+	return "// " + strings.ReplaceAll(text, "\n", "\n// ") + "\n"
+}
+
+type generator struct {
+	doc   *Document
+	buf   bytes.Buffer
+	names map[string]string // component (or inline) schema keys to Go type names
+	taken map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// name reserves the Go type name for a component (or inline) schema
+func (g *generator) name(key, name string) error {
+	if g.taken[name] {
+		return fmt.Errorf("duplicate Go type name '%s' (for '%s')", name, key)
+	}
+	g.taken[name] = true
+	g.names[key] = name
+	return nil
+}
+
+// goType returns the Go type of a schema
+func (g *generator) goType(s *Schema) string {
+	if s == nil {
+		return "any"
+	}
+	if len(s.Ref) > 0 {
+		key := s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+		name, ok := g.names[key]
+		if !ok {
+			return "any"
+		}
+		if def := g.doc.Components.Schemas[key]; def != nil && isStruct(def) {
+			return "*" + name
+		}
+		return name
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch s.Format {
+		case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+			return s.Format
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + strings.TrimPrefix(g.goType(s.Items), "*")
+	case "object", "":
+		if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+			return g.structType(s)
+		}
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties)
+		}
+		if s.Type == "object" {
+			return "map[string]any"
+		}
+	}
+	return "any"
+}
+
+func isStruct(s *Schema) bool {
+	return (s.Type == "object" || s.Type == "") && (len(s.Properties) > 0 || len(s.AllOf) > 0)
+}
+
+// structType returns the Go struct type of an object schema
+func (g *generator) structType(s *Schema) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	embedded := make(map[string]bool)
+	keys := make(map[string]bool)
+	names := make(map[string]bool)
+	g.fields(&b, s, embedded, keys, names)
+	b.WriteString("}")
+	return b.String()
+}
+
+// fields writes the embedded types and fields of an object schema and its allOf schemas
+// (go-swagger repeats the properties of the embedding struct in its allOf schemas, so they are deduped by JSON key)
+func (g *generator) fields(b *strings.Builder, s *Schema, embedded, keys, names map[string]bool) {
+	for _, sub := range s.AllOf {
+		if len(sub.Ref) > 0 {
+			if typ := strings.TrimPrefix(g.goType(sub), "*"); !embedded[typ] {
+				embedded[typ] = true
+				b.WriteString(typ + "\n")
+			}
+			continue
+		}
+		g.fields(b, sub, embedded, keys, names)
+	}
+	for _, key := range sortedKeys(s.Properties) {
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		prop := s.Properties[key]
+		name := prop.GoName
+		if len(name) == 0 {
+			name = goName(key)
+		}
+		for names[name] {
+			name += "_"
+		}
+		names[name] = true
+		b.WriteString(comment(prop.Description))
+		fmt.Fprintf(b, "%s %s `json:\"%s,omitempty\"`\n", name, g.goType(prop), key)
+	}
+}
+
+func (g *generator) types() {
+	for _, key := range sortedKeys(g.doc.Components.Schemas) {
+		s := g.doc.Components.Schemas[key]
+		doc := s.Title
+		if len(doc) == 0 {
+			doc = s.Description
+		}
+		if len(doc) > 0 {
+			// the docs start with the original Go type name
+			for _, article := range []string{"", "A ", "An "} {
+				if rest, ok := strings.CutPrefix(doc, article); ok {
+					if first, rest, ok := strings.Cut(rest, " "); ok && strings.EqualFold(first, key) {
+						doc = rest
+						break
+					}
+				}
+			}
+			g.printf("%s", comment(g.names[key]+" "+doc))
+		} else {
+			g.printf("// %s is the '%s' schema\n", g.names[key], key)
+		}
+		g.printf("type %s %s\n\n", g.names[key], g.goType(s))
+	}
+}
+
+// A result is the Go type of an operation's successful response
+type result struct {
+	typ    string // the Go type (it is empty if the response has no body)
+	binary bool   // whether the body is returned as an io.ReadCloser
+}
+
+func (g *generator) result(opName string, op *Operation) (result, error) {
+	for _, code := range sortedKeys(op.Responses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		resp := op.Responses[code]
+		key := opName + "Response"
+		if len(resp.Ref) > 0 {
+			key = resp.Ref[strings.LastIndex(resp.Ref, "/")+1:]
+			shared, ok := g.doc.Components.Responses[key]
+			if !ok {
+				return result{}, fmt.Errorf("%s: unknown response '%s'", op.OperationID, resp.Ref)
+			}
+			resp = shared
+		}
+		for typ, media := range resp.Content {
+			if !isJSON(typ) {
+				return result{binary: true}, nil
+			}
+			s := media.Schema
+			if s == nil {
+				return result{}, nil
+			}
+			if isStruct(s) {
+				// the inline objects are named after their response
+				name, ok := g.names["response:"+key]
+				if !ok {
+					name = goName(key)
+					if err := g.name("response:"+key, name); err != nil {
+						return result{}, err
+					}
+					if len(resp.Description) > 0 && resp.Description != "OK" {
+						g.printf("%s", comment(name+" is "+strings.Replace(resp.Description, "The ", "the ", 1)))
+					} else if _, shared := g.doc.Components.Responses[key]; shared {
+						g.printf("// %s is the '%s' response\n", name, key)
+					} else {
+						g.printf("// %s is the response of %s\n", name, op.OperationID)
+					}
+					g.printf("type %s %s\n\n", name, g.structType(s))
+				}
+				return result{typ: "*" + name}, nil
+			}
+			typ := g.goType(s)
+			if typ == "any" || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") {
+				return result{typ: typ}, nil
+			}
+			return result{typ: "*" + typ}, nil
+		}
+		return result{}, nil
+	}
+	return result{}, nil
+}
+
+var pathParam = regexp.MustCompile(`{([^}]+)}`)
+
+func (g *generator) operation(method, path string, op *Operation) error {
+	name := goName(op.OperationID)
+	res, err := g.result(name, op)
+	if err != nil {
+		return err
+	}
+
+	var args, pathArgs []string
+	params := make(map[string]*Parameter)
+	var query []*Parameter
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			params[p.Name] = p
+		case "query":
+			query = append(query, p)
+		default:
+			return fmt.Errorf("%s: unsupported parameter location '%s'", op.OperationID, p.In)
+		}
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		p, ok := params[m[1]]
+		if !ok {
+			return fmt.Errorf("%s: missing path parameter '%s'", op.OperationID, m[1])
+		}
+		arg := safeArg(p.Name)
+		args = append(args, fmt.Sprintf("%s %s", arg, g.goType(p.Schema)))
+		pathArgs = append(pathArgs, arg)
+	}
+	if len(query) > 0 {
+		g.printf("// %sParams are the query parameters of %s\n", name, name)
+		g.printf("type %sParams struct {\n", name)
+		for _, p := range query {
+			desc := p.Description
+			if p.Required {
+				desc = strings.TrimSpace(desc + " (required)")
+			}
+			g.printf("%s", comment(desc))
+			g.printf("%s %s\n", paramName(p), g.goType(p.Schema))
+		}
+		g.printf("}\n\n")
+		g.printf("func (p *%sParams) values() url.Values {\n", name)
+		g.printf("q := url.Values{}\n")
+		g.printf("if p == nil {\nreturn q\n}\n")
+		for _, p := range query {
+			g.printf("addQuery(q, %q, p.%s, %t)\n", p.Name, paramName(p), p.Required)
+		}
+		g.printf("return q\n}\n\n")
+		args = append(args, fmt.Sprintf("params *%sParams", name))
+	}
+	body := "nil"
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			typ := g.goType(media.Schema)
+			if isStruct(media.Schema) && len(media.Schema.Ref) == 0 {
+				tname := name + "Request"
+				if err := g.name("request:"+name, tname); err != nil {
+					return err
+				}
+				g.printf("%s", comment(tname+" is the request body of "+name))
+				g.printf("type %s %s\n\n", tname, typ)
+				typ = "*" + tname
+			}
+			args = append(args, "body "+typ)
+			body = "body"
+		}
+	}
+
+	summary := op.Summary
+	if len(summary) == 0 {
+		summary = op.OperationID
+	}
+	g.printf("%s", comment(name+" "+summary))
+	if len(op.Description) > 0 {
+		g.printf("//\n%s", comment(op.Description))
+	}
+	g.printf("//\n// %s %s\n", strings.ToUpper(method), path)
+	if op.Deprecated {
+		g.printf("//\n// Deprecated: %s is deprecated.\n", name)
+	}
+
+	rets := "error"
+	switch {
+	case res.binary:
+		rets = "(io.ReadCloser, error)"
+	case len(res.typ) > 0:
+		rets = fmt.Sprintf("(%s, error)", res.typ)
+	}
+	g.printf("func (c *Client) %s(%s) %s {\n", name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), rets)
+	pathExpr := fmt.Sprintf("%q", path)
+	if len(pathArgs) > 0 {
+		pathExpr = fmt.Sprintf("pathf(%q, %s)", pathParam.ReplaceAllString(path, "%s"), strings.Join(pathArgs, ", "))
+	}
+	queryExpr := "nil"
+	if len(query) > 0 {
+		queryExpr = "params.values()"
+	}
+	httpMethod := "http.Method" + goName(strings.ToLower(method))
+	switch {
+	case res.binary:
+		g.printf("return c.stream(ctx, %s, %s, %s, %s)\n", httpMethod, pathExpr, queryExpr, body)
+	case len(res.typ) > 0:
+		g.printf("var out %s\n", res.typ)
+		g.printf("if err := c.do(ctx, %s, %s, %s, %s, &out); err != nil {\nreturn nil, err\n}\n", httpMethod, pathExpr, queryExpr, body)
+		g.printf("return out, nil\n")
+	default:
+		g.printf("return c.do(ctx, %s, %s, %s, %s, nil)\n", httpMethod, pathExpr, queryExpr, body)
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// paramName returns the Go field name of a query parameter
+func paramName(p *Parameter) string {
+	if p.Schema != nil && len(p.Schema.GoName) > 0 {
+		return p.Schema.GoName
+	}
+	return goName(p.Name)
+}
+
+// safeArg returns an unexported Go identifier for a parameter name
+func safeArg(name string) string {
+	arg := goName(name)
+	rs := []rune(arg)
+	for i := 0; i < len(rs) && unicode.IsUpper(rs[i]); i++ {
+		if i > 0 && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+			break
+		}
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	switch arg = string(rs); arg {
+	case "type":
+		return "typ"
+	case "func", "range", "map", "chan", "var", "default", "select", "package", "import", "interface", "go", "case":
+		return arg + "_"
+	}
+	return arg
+}
+
+// GenerateClient generates the Go client operations and types of the document (for the package pkg)
+func (d *Document) GenerateClient(pkg string) ([]byte, error) {
+	g := &generator{doc: d, names: make(map[string]string), taken: make(map[string]bool)}
+	for _, key := range sortedKeys(d.Components.Schemas) {
+		if err := g.name(key, goName(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range sortedKeys(d.Paths) {
+		for _, method := range sortedKeys(d.Paths[path]) {
+			if err := g.operation(method, path, d.Paths[path][method]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	g.types()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by api/openapi/gen.go from the ipswd OpenAPI spec; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkg)
+	for _, imp := range [][2]string{
+		{"context", "context.Context"},
+		{"io", "io.ReadCloser"},
+		{"net/http", "http.Method"},
+		{"net/url", "url.Values"},
+		{"time", "time.Time"},
+	} {
+		if bytes.Contains(g.buf.Bytes(), []byte(imp[1])) {
+			fmt.Fprintf(&out, "%q\n", imp[0])
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated client: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,67 @@
+//go:build ignore
+
+// This program generates the ipswd Swagger 2.0 spec, OpenAPI 3 document and Go client from the route annotations.
+// It is invoked by 'go generate' in the api package.
+//
+// The spec is generated with 'swagger generate spec' if go-swagger is installed and the routes it is missing
+// (all the routes added since it was last generated otherwise) are added from the annotations.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/blacktop/ipsw/api/openapi"
+)
+
+func main() {
+	spec := flag.String("swagger", "swagger.json", "go-swagger generated Swagger 2.0 spec")
+	out := flag.String("openapi", "openapi/openapi.json", "OpenAPI 3 document output")
+	client := flag.String("client", "client/client_gen.go", "Go client output")
+	flag.Parse()
+
+	if swagger, err := exec.LookPath("swagger"); err == nil {
+		cmd := exec.Command(swagger, "generate", "spec", "-o", *spec)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		log.Printf("go-swagger isn't installed: adding the missing routes to %s from the annotations", *spec)
+	}
+
+	dat, err := os.ReadFile(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	completed, err := openapi.Complete(dat, ".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(completed, dat) {
+		if err := os.WriteFile(*spec, completed, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	doc, err := openapi.Convert(completed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dat, err = doc.Marshal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, dat, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	src, err := doc.GenerateClient("client")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*client, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,411 @@
+// Package openapi converts the go-swagger generated ipswd Swagger 2.0 spec to an OpenAPI 3 document
+// and generates the typed Go client from it
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Spec is the ipswd OpenAPI 3 document
+//
+//go:embed openapi.json
+var Spec []byte
+
+const version = "3.0.3"
+
+// Schema is a JSON schema (the subset of Swagger 2.0 and OpenAPI 3 schemas used by ipswd)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int64             `json:"minLength,omitempty"`
+	MaxLength            *int64             `json:"maxLength,omitempty"`
+	MinItems             *int64             `json:"minItems,omitempty"`
+	MaxItems             *int64             `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	GoName               string             `json:"x-go-name,omitempty"`
+	GoPackage            string             `json:"x-go-package,omitempty"`
+}
+
+// Info is the API info
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is an API server
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Components are the reusable objects of the document
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is an auth scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+// Operation is an API operation
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is an operation's request body
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType is the schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response is an operation response (or a reference to a component response)
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+/* Swagger 2.0 */
+
+type swagger struct {
+	Swagger     string                                `json:"swagger"`
+	Info        Info                                  `json:"info"`
+	Host        string                                `json:"host"`
+	BasePath    string                                `json:"basePath"`
+	Schemes     []string                              `json:"schemes"`
+	Consumes    []string                              `json:"consumes"`
+	Produces    []string                              `json:"produces"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]*Schema                    `json:"definitions"`
+	Responses   map[string]*swaggerResponse           `json:"responses"`
+}
+
+type swaggerOperation struct {
+	Tags        []string                    `json:"tags"`
+	Summary     string                      `json:"summary"`
+	Description string                      `json:"description"`
+	OperationID string                      `json:"operationId"`
+	Consumes    []string                    `json:"consumes"`
+	Produces    []string                    `json:"produces"`
+	Parameters  []*swaggerParameter         `json:"parameters"`
+	Responses   map[string]*swaggerResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated"`
+}
+
+type swaggerParameter struct {
+	Schema
+	Name             string  `json:"name"`
+	In               string  `json:"in"`
+	Required         bool    `json:"required"`
+	CollectionFormat string  `json:"collectionFormat"`
+	Body             *Schema `json:"schema"`
+}
+
+type swaggerResponse struct {
+	Ref         string             `json:"$ref"`
+	Description string             `json:"description"`
+	Schema      *Schema            `json:"schema"`
+	Headers     map[string]*Schema `json:"headers"`
+}
+
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true}
+
+// Convert converts a Swagger 2.0 spec to an OpenAPI 3 document
+func Convert(dat []byte) (*Document, error) {
+	var s swagger
+	if err := json.Unmarshal(dat, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger spec: %w", err)
+	}
+	if s.Swagger != "2.0" {
+		return nil, fmt.Errorf("unsupported swagger version '%s'", s.Swagger)
+	}
+	doc := &Document{
+		OpenAPI: version,
+		Info:    s.Info,
+		// auth is optional (it is disabled unless ipswd is configured with API keys or a JWT secret)
+		Security: []map[string][]string{{}, {"bearerAuth": {}}, {"apiKey": {}}},
+		Paths:    make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas:   make(map[string]*Schema),
+			Responses: make(map[string]*Response),
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "An API key or HS256 JWT (see `ipswd token`)"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "An API key"},
+			},
+		},
+	}
+	for _, scheme := range s.Schemes {
+		doc.Servers = append(doc.Servers, Server{URL: scheme + "://" + s.Host + s.BasePath})
+	}
+	for name, def := range s.Definitions {
+		doc.Components.Schemas[name] = convertSchema(def)
+	}
+	for name, resp := range s.Responses {
+		doc.Components.Responses[name] = convertResponse(resp, contentType(s.Produces))
+	}
+	for path, item := range s.Paths {
+		for method, raw := range item {
+			if !methods[method] {
+				continue
+			}
+			var op swaggerOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("failed to parse %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			if _, ok := doc.Paths[path]; !ok {
+				doc.Paths[path] = make(map[string]*Operation)
+			}
+			doc.Paths[path][method] = convertOperation(&s, &op)
+		}
+	}
+	return doc, nil
+}
+
+// contentType returns the JSON content type if it's one of the types (or the first of the types)
+func contentType(types []string) string {
+	for _, typ := range types {
+		if typ == "application/json" {
+			return typ
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return "application/json"
+}
+
+func isJSON(typ string) bool {
+	return typ == "application/json" || strings.HasSuffix(typ, "+json")
+}
+
+func convertRef(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+		return "#/components/schemas/" + name
+	}
+	if name, ok := strings.CutPrefix(ref, "#/responses/"); ok {
+		return "#/components/responses/" + name
+	}
+	return ref
+}
+
+func convertSchema(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	out.Ref = convertRef(s.Ref)
+	out.GoPackage = ""
+	out.Items = convertSchema(s.Items)
+	out.AdditionalProperties = convertSchema(s.AdditionalProperties)
+	if s.Properties != nil {
+		out.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			out.Properties[name] = convertSchema(prop)
+		}
+	}
+	out.AllOf = nil
+	for _, sub := range s.AllOf {
+		out.AllOf = append(out.AllOf, convertSchema(sub))
+	}
+	return &out
+}
+
+// responseSchema returns the schema of a response's body.
+//
+// go-swagger emits the fields of a response struct that aren't tagged 'in: body' as headers
+// (with the struct pointer fields as the response's schema), but ipswd sends them all as JSON
+// body fields, so the typed headers are the properties of the body and a single untyped header
+// is the property with the response's schema. The schema is the whole body otherwise.
+func responseSchema(resp *swaggerResponse) *Schema {
+	var typed, untyped []string
+	for name, h := range resp.Headers {
+		if len(h.Type) > 0 || len(h.Ref) > 0 {
+			typed = append(typed, name)
+		} else {
+			untyped = append(untyped, name)
+		}
+	}
+	if len(typed) == 0 && (len(untyped) != 1 || resp.Schema == nil) {
+		return convertSchema(resp.Schema)
+	}
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, name := range typed {
+		body.Properties[name] = convertSchema(resp.Headers[name])
+	}
+	for _, name := range untyped {
+		prop := &Schema{}
+		if len(untyped) == 1 && resp.Schema != nil {
+			prop = convertSchema(resp.Schema)
+		}
+		if desc := resp.Headers[name].Description; len(desc) > 0 && len(prop.Ref) == 0 {
+			prop.Description = desc
+		}
+		body.Properties[name] = prop
+	}
+	return body
+}
+
+func convertResponse(resp *swaggerResponse, typ string) *Response {
+	if len(resp.Ref) > 0 {
+		return &Response{Ref: convertRef(resp.Ref)}
+	}
+	// go-swagger references the shared responses as schemas when a route declares an inline response description
+	if resp.Schema != nil && strings.HasPrefix(resp.Schema.Ref, "#/responses/") {
+		return &Response{Ref: convertRef(resp.Schema.Ref)}
+	}
+	out := &Response{Description: resp.Description}
+	if len(out.Description) == 0 {
+		out.Description = "OK"
+	}
+	schema := responseSchema(resp)
+	if schema == nil {
+		return out
+	}
+	if !isJSON(typ) {
+		schema = &Schema{Type: "string", Format: "binary"}
+	}
+	out.Content = map[string]*MediaType{typ: {Schema: schema}}
+	return out
+}
+
+// shared returns the shared response a response references (or nil)
+func (s *swagger) shared(resp *swaggerResponse) *swaggerResponse {
+	ref := resp.Ref
+	if len(ref) == 0 && resp.Schema != nil {
+		ref = resp.Schema.Ref
+	}
+	name, ok := strings.CutPrefix(ref, "#/responses/")
+	if !ok {
+		return nil
+	}
+	return s.Responses[name]
+}
+
+func convertOperation(s *swagger, op *swaggerOperation) *Operation {
+	out := &Operation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.OperationID,
+		Responses:   make(map[string]*Response),
+		Deprecated:  op.Deprecated,
+	}
+	consumes := contentType(s.Consumes)
+	if len(op.Consumes) > 0 {
+		consumes = contentType(op.Consumes)
+	}
+	produces := contentType(s.Produces)
+	if len(op.Produces) > 0 {
+		produces = contentType(op.Produces)
+	}
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			out.RequestBody = &RequestBody{
+				Description: p.Description,
+				Required:    p.Required,
+				Content:     map[string]*MediaType{consumes: {Schema: convertSchema(p.Body)}},
+			}
+			continue
+		}
+		param := &Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+		}
+		schema := p.Schema
+		schema.Description = ""
+		param.Schema = convertSchema(&schema)
+		if p.Type == "array" {
+			explode := p.CollectionFormat == "multi"
+			param.Style = "form"
+			param.Explode = &explode
+		}
+		out.Parameters = append(out.Parameters, param)
+	}
+	for code, resp := range op.Responses {
+		// the shared responses are JSON so inline the binary ones
+		if shared := s.shared(resp); shared != nil && !isJSON(produces) && strings.HasPrefix(code, "2") {
+			resp = shared
+		}
+		out.Responses[code] = convertResponse(resp, produces)
+	}
+	return out
+}
+
+// Marshal returns the document as indented JSON
+func (d *Document) Marshal() ([]byte, error) {
+	dat, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(dat, '\n'), nil
+}
+
+// sortedKeys returns the sorted keys of a map
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
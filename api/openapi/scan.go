@@ -0,0 +1,863 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* go-swagger annotations */
+
+var (
+	sectionRE  = regexp.MustCompile(`(?i)^(Produces|Consumes|Schemes|Security|Parameters|Responses|Deprecated|Extensions):\s*(.*)$`)
+	responseRE = regexp.MustCompile(`^(\d{3}|default):\s*(.*)$`)
+	fieldRE    = regexp.MustCompile(`(?i)^(in|required):\s*(\S+)$`)
+)
+
+// spec is a go-swagger spec (the sections are kept as is so a completed spec only changes where routes were added)
+type spec struct {
+	Consumes    json.RawMessage                       `json:"consumes,omitempty"`
+	Produces    json.RawMessage                       `json:"produces,omitempty"`
+	Schemes     json.RawMessage                       `json:"schemes,omitempty"`
+	Swagger     json.RawMessage                       `json:"swagger"`
+	Info        json.RawMessage                       `json:"info,omitempty"`
+	Host        json.RawMessage                       `json:"host,omitempty"`
+	BasePath    json.RawMessage                       `json:"basePath,omitempty"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]json.RawMessage            `json:"definitions,omitempty"`
+	Responses   map[string]json.RawMessage            `json:"responses,omitempty"`
+}
+
+// route is a swagger:route annotation
+type route struct {
+	method string
+	path   string
+	operation
+	params []*parameter
+	codes  map[string]string // the response names by status code
+}
+
+// operation is a swagger:route operation (ordered as go-swagger emits them)
+type operation struct {
+	Description string               `json:"description,omitempty"`
+	Consumes    []string             `json:"consumes,omitempty"`
+	Produces    []string             `json:"produces,omitempty"`
+	Schemes     []string             `json:"schemes,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	OperationID string               `json:"operationId"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*parameter         `json:"parameters,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Type        string  `json:"type,omitempty"`
+	Format      string  `json:"format,omitempty"`
+	Items       *Schema `json:"items,omitempty"`
+	GoName      string  `json:"x-go-name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type response struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// scanPackage is a Go package with swagger annotations
+type scanPackage struct {
+	path  string
+	files []*ast.File
+	info  *types.Info
+	types *types.Package
+}
+
+type scanner struct {
+	fset   *token.FileSet
+	files  map[string]*ast.File // the parsed files (with their comments) by name
+	deps   map[string]*ast.File // the parsed files of the dependencies (with their own file sets)
+	pkgs   []*scanPackage
+	routes []*route
+
+	params    map[string][]*ast.TypeSpec // the swagger:parameters types by operation ID
+	responses map[string]*ast.TypeSpec   // the swagger:response types by name
+	typePkg   map[*ast.TypeSpec]*scanPackage
+
+	spec  spec
+	names map[string]string // the definition names of the Go types (by package path and name)
+}
+
+// Complete adds the routes annotated in the Go packages under dir that are missing from the go-swagger spec
+// (with the parameters, responses and definitions they use) and returns the updated spec.
+//
+// The spec isn't otherwise changed, so it is a no-op on a spec 'swagger generate spec' just generated.
+// Only the swagger:route, swagger:parameters and swagger:response annotations are supported
+// (the swagger:operation routes must already be in the spec).
+func Complete(dat []byte, dir string) ([]byte, error) {
+	s := &scanner{
+		fset:      token.NewFileSet(),
+		files:     make(map[string]*ast.File),
+		deps:      make(map[string]*ast.File),
+		params:    make(map[string][]*ast.TypeSpec),
+		responses: make(map[string]*ast.TypeSpec),
+		typePkg:   make(map[*ast.TypeSpec]*scanPackage),
+		names:     make(map[string]string),
+	}
+	if err := json.Unmarshal(dat, &s.spec); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger spec: %w", err)
+	}
+	if s.spec.Paths == nil {
+		s.spec.Paths = make(map[string]map[string]json.RawMessage)
+	}
+	if s.spec.Definitions == nil {
+		s.spec.Definitions = make(map[string]json.RawMessage)
+	}
+	if s.spec.Responses == nil {
+		s.spec.Responses = make(map[string]json.RawMessage)
+	}
+	if err := s.load(dir); err != nil {
+		return nil, err
+	}
+
+	var missing []*route
+	for _, r := range s.routes {
+		if _, ok := s.spec.Paths[r.path][r.method]; !ok {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) == 0 {
+		return dat, nil
+	}
+	for _, r := range missing {
+		if err := s.addRoute(r); err != nil {
+			return nil, fmt.Errorf("failed to add %s %s: %w", strings.ToUpper(r.method), r.path, err)
+		}
+	}
+	return json.MarshalIndent(s.spec, "", "  ")
+}
+
+// modulePath returns the module path and folder of the go.mod containing dir
+func modulePath(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if dat, err := os.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(dat), "\n") {
+				if mod, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return strings.Trim(strings.TrimSpace(mod), `"`), d, nil
+				}
+			}
+			return "", "", fmt.Errorf("missing module path in %s", filepath.Join(d, "go.mod"))
+		}
+		if filepath.Dir(d) == d {
+			return "", "", fmt.Errorf("%s isn't in a Go module", dir)
+		}
+	}
+}
+
+// load parses and type checks the packages under dir and collects their annotations
+func (s *scanner) load(dir string) error {
+	modPath, modDir, err := modulePath(dir)
+	if err != nil {
+		return err
+	}
+	imp := importer.ForCompiler(s.fset, "source", nil).(types.ImporterFrom)
+
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name := d.Name(); path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+			return filepath.SkipDir
+		}
+		pkg, err := s.parseDir(path)
+		if err != nil || pkg == nil {
+			return err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(modDir, abs)
+		if err != nil {
+			return err
+		}
+		pkg.path = filepath.ToSlash(filepath.Join(modPath, rel))
+
+		// type check the files of the current platform (the annotations are collected from all of them)
+		var files []*ast.File
+		for _, f := range pkg.files {
+			name := s.fset.File(f.Pos()).Name()
+			if ok, err := build.Default.MatchFile(filepath.Dir(name), filepath.Base(name)); err == nil && ok {
+				files = append(files, f)
+			}
+		}
+		pkg.info = &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+		conf := types.Config{
+			Importer: importerFrom{imp, abs},
+			Error:    func(error) {}, // the unresolved types are skipped
+		}
+		pkg.types, _ = conf.Check(pkg.path, s.fset, files, pkg.info)
+
+		s.pkgs = append(s.pkgs, pkg)
+		s.collect(pkg)
+		return nil
+	})
+}
+
+// importerFrom imports the packages relative to a folder
+type importerFrom struct {
+	types.ImporterFrom
+	dir string
+}
+
+func (i importerFrom) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, i.dir, 0)
+}
+
+// parseDir parses the non-test Go files of the package in dir (or returns nil if there are none)
+func (s *scanner) parseDir(dir string) (*scanPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &scanPackage{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		name := filepath.Join(dir, e.Name())
+		f, err := parser.ParseFile(s.fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == "main" || isIgnored(f) { // generators
+			continue
+		}
+		s.files[name] = f
+		pkg.files = append(pkg.files, f)
+	}
+	if len(pkg.files) == 0 {
+		return nil, nil
+	}
+	return pkg, nil
+}
+
+// isIgnored returns true if the file has the 'ignore' build constraint
+func isIgnored(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if c.Text == "//go:build ignore" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commentLines returns the lines of a comment group without the comment markers
+func commentLines(cg *ast.CommentGroup) []string {
+	var lines []string
+	for _, c := range cg.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		text = strings.TrimPrefix(text, " ")
+		lines = append(lines, strings.TrimRight(text, " \t"))
+	}
+	return lines
+}
+
+// annotation returns the arguments of the swagger annotation in the comment group (or nil)
+func annotation(cg *ast.CommentGroup, name string) []string {
+	if cg == nil {
+		return nil
+	}
+	for _, line := range commentLines(cg) {
+		if args, ok := strings.CutPrefix(strings.TrimSpace(line), "swagger:"+name); ok && (len(args) == 0 || args[0] == ' ') {
+			return append([]string{}, strings.Fields(args)...)
+		}
+	}
+	return nil
+}
+
+// collect collects the package's swagger:route, swagger:parameters and swagger:response annotations
+func (s *scanner) collect(pkg *scanPackage) {
+	for _, f := range pkg.files {
+		for _, cg := range f.Comments {
+			if r := parseRoute(commentLines(cg)); r != nil {
+				s.routes = append(s.routes, r)
+			}
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if ops := annotation(doc, "parameters"); ops != nil {
+					for _, op := range ops {
+						s.params[op] = append(s.params[op], ts)
+					}
+					s.typePkg[ts] = pkg
+				}
+				if args := annotation(doc, "response"); args != nil {
+					name := ts.Name.Name
+					if len(args) > 0 {
+						name = args[0]
+					}
+					s.responses[name] = ts
+					s.typePkg[ts] = pkg
+				}
+			}
+		}
+	}
+}
+
+// parseRoute parses a swagger:route annotation (or returns nil if the lines aren't one)
+func parseRoute(lines []string) *route {
+	start := -1
+	var args []string
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "swagger:route "); ok {
+			start, args = i, strings.Fields(rest)
+			break
+		}
+	}
+	if start < 0 || len(args) < 3 {
+		return nil
+	}
+	r := &route{
+		method: strings.ToLower(args[0]),
+		path:   args[1],
+		operation: operation{
+			OperationID: args[len(args)-1],
+			Responses:   make(map[string]*response),
+		},
+		codes: make(map[string]string),
+	}
+	if len(args) > 3 {
+		r.Tags = args[2 : len(args)-1]
+	}
+
+	var text []string
+	var section string
+	var param *parameter
+	for _, line := range lines[start+1:] {
+		trimmed := strings.TrimSpace(line)
+		if m := sectionRE.FindStringSubmatch(trimmed); m != nil {
+			section = strings.ToLower(m[1])
+			if section == "deprecated" {
+				r.Deprecated = m[2] == "true"
+			}
+			continue
+		}
+		switch section {
+		case "":
+			text = append(text, trimmed)
+		case "produces", "consumes", "schemes":
+			item, ok := strings.CutPrefix(trimmed, "- ")
+			if !ok {
+				continue
+			}
+			switch section {
+			case "produces":
+				r.Produces = append(r.Produces, strings.TrimSpace(item))
+			case "consumes":
+				r.Consumes = append(r.Consumes, strings.TrimSpace(item))
+			default:
+				r.Schemes = append(r.Schemes, strings.TrimSpace(item))
+			}
+		case "parameters":
+			if item, ok := strings.CutPrefix(trimmed, "+ "); ok {
+				param = &parameter{In: "query"}
+				r.params = append(r.params, param)
+				trimmed = item
+			}
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok || param == nil {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "name":
+				param.Name = value
+			case "in":
+				param.In = value
+			case "description":
+				param.Description = value
+			case "required":
+				param.Required = value == "true"
+			case "type":
+				param.Type = value
+			case "format":
+				param.Format = value
+			}
+		case "responses":
+			if m := responseRE.FindStringSubmatch(trimmed); m != nil {
+				r.codes[m[1]] = strings.TrimSpace(m[2])
+			}
+		}
+	}
+
+	// the first paragraph is the summary and the others are the description
+	var paragraphs [][]string
+	var paragraph []string
+	for _, line := range append(text, "") {
+		if len(line) == 0 {
+			if len(paragraph) > 0 {
+				paragraphs = append(paragraphs, paragraph)
+				paragraph = nil
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	if len(paragraphs) > 0 {
+		r.Summary = strings.Join(paragraphs[0], " ")
+		var desc []string
+		for _, p := range paragraphs[1:] {
+			desc = append(desc, strings.Join(p, "\n"))
+		}
+		r.Description = strings.Join(desc, "\n\n")
+	}
+	return r
+}
+
+/* spec generation */
+
+func (s *scanner) addRoute(r *route) error {
+	r.Parameters = r.params
+	for _, ts := range s.params[r.OperationID] {
+		params, err := s.structParams(ts)
+		if err != nil {
+			return err
+		}
+		r.Parameters = append(r.Parameters, params...)
+	}
+	for code, name := range r.codes {
+		resp, err := s.response(name)
+		if err != nil {
+			return err
+		}
+		r.Responses[code] = resp
+	}
+	dat, err := json.Marshal(r.operation)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.spec.Paths[r.path]; !ok {
+		s.spec.Paths[r.path] = make(map[string]json.RawMessage)
+	}
+	s.spec.Paths[r.path][r.method] = dat
+	return nil
+}
+
+// response returns a route's response (referencing the shared response it declares)
+func (s *scanner) response(name string) (*response, error) {
+	if len(name) == 0 {
+		desc := ""
+		return &response{Description: &desc}, nil
+	}
+	if _, ok := s.spec.Responses[name]; ok {
+		return &response{Ref: "#/responses/" + name}, nil
+	}
+	ts, ok := s.responses[name]
+	if !ok {
+		return &response{Description: &name}, nil
+	}
+	resp, err := s.structResponse(ts)
+	if err != nil {
+		return nil, err
+	}
+	if s.spec.Responses[name], err = json.Marshal(resp); err != nil {
+		return nil, err
+	}
+	return &response{Ref: "#/responses/" + name}, nil
+}
+
+// structResponse returns the shared response of a swagger:response type
+// (its 'in: body' field or the whole type is the body)
+func (s *scanner) structResponse(ts *ast.TypeSpec) (*response, error) {
+	obj, err := s.object(ts)
+	if err != nil {
+		return nil, err
+	}
+	desc := docText(ts.Doc)
+	resp := &response{Description: &desc}
+	if st, ok := obj.Type().Underlying().(*types.Struct); ok {
+		for i := range st.NumFields() {
+			if field := st.Field(i); annotations(s.doc(field.Pos()))["in"] == "body" {
+				resp.Schema = s.schema(field.Type())
+				return resp, nil
+			}
+		}
+	}
+	resp.Schema = s.schema(obj.Type().Underlying())
+	return resp, nil
+}
+
+// structParams returns the parameters of a swagger:parameters type (its fields are query parameters unless
+// they are annotated with an 'in:')
+func (s *scanner) structParams(ts *ast.TypeSpec) ([]*parameter, error) {
+	obj, err := s.object(ts)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("swagger:parameters type %s isn't a struct", ts.Name.Name)
+	}
+	var params []*parameter
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		name, _, ok := jsonName(field, st.Tag(i))
+		if !ok {
+			continue
+		}
+		doc := s.doc(field.Pos())
+		ann := annotations(doc)
+		p := &parameter{
+			Description: docText(doc),
+			Name:        name,
+			In:          ann["in"],
+			Required:    ann["required"] == "true",
+		}
+		if len(p.In) == 0 {
+			p.In = "query"
+		}
+		if name != field.Name() {
+			p.GoName = field.Name()
+		}
+		schema := s.schema(field.Type())
+		if schema == nil {
+			continue
+		}
+		if p.In == "body" {
+			p.Schema = schema
+		} else {
+			p.Type, p.Format, p.Items = schema.Type, schema.Format, schema.Items
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// object returns the type checked object of an annotated type
+func (s *scanner) object(ts *ast.TypeSpec) (types.Object, error) {
+	pkg := s.typePkg[ts]
+	if obj := pkg.info.Defs[ts.Name]; obj != nil {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%s.%s isn't built on %s/%s", pkg.path, ts.Name.Name, build.Default.GOOS, build.Default.GOARCH)
+}
+
+// jsonName returns the JSON name of a struct field, whether it is omitempty and false if it isn't encoded
+func jsonName(field *types.Var, tag string) (string, bool, bool) {
+	if !field.Exported() {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	if name == "-" && len(opts) == 0 {
+		return "", false, false
+	}
+	if len(name) == 0 {
+		name = field.Name()
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,"), true
+}
+
+// doc returns the doc comment of the type or field declared at pos
+func (s *scanner) doc(pos token.Pos) *ast.CommentGroup {
+	if !pos.IsValid() {
+		return nil
+	}
+	position := s.fset.Position(pos)
+	at := func(id *ast.Ident) bool { return id.Pos() == pos }
+	f, ok := s.files[position.Filename]
+	if !ok { // a dependency (the importer doesn't keep the comments)
+		f, ok = s.deps[position.Filename]
+		if !ok {
+			f, _ = parser.ParseFile(token.NewFileSet(), position.Filename, nil, parser.ParseComments)
+			s.deps[position.Filename] = f
+		}
+		if f == nil {
+			return nil
+		}
+		at = func(id *ast.Ident) bool { return int(id.Pos()-f.FileStart) == position.Offset }
+	}
+	var doc *ast.CommentGroup
+	ast.Inspect(f, func(n ast.Node) bool {
+		if doc != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.GenDecl:
+			for _, spec := range n.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && at(ts.Name) {
+					doc = ts.Doc
+					if doc == nil && len(n.Specs) == 1 {
+						doc = n.Doc
+					}
+				}
+			}
+		case *ast.Field:
+			for _, name := range n.Names {
+				if at(name) {
+					doc = n.Doc
+					if doc == nil {
+						doc = n.Comment
+					}
+				}
+			}
+			if id := embeddedIdent(n.Type); len(n.Names) == 0 && id != nil && at(id) {
+				doc = n.Doc
+			}
+		}
+		return true
+	})
+	return doc
+}
+
+// embeddedIdent returns the type name of an embedded field
+func embeddedIdent(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.IndexExpr:
+		return embeddedIdent(t.X)
+	case *ast.IndexListExpr:
+		return embeddedIdent(t.X)
+	}
+	return nil
+}
+
+// annotations returns the 'in:' and 'required:' annotations of a doc comment
+func annotations(cg *ast.CommentGroup) map[string]string {
+	ann := make(map[string]string)
+	if cg == nil {
+		return ann
+	}
+	for _, line := range commentLines(cg) {
+		if m := fieldRE.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			ann[strings.ToLower(m[1])] = m[2]
+		}
+	}
+	return ann
+}
+
+// docText returns the text of a doc comment without its swagger annotations
+func docText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	var lines []string
+	for _, line := range commentLines(cg) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "swagger:") || fieldRE.MatchString(trimmed) {
+			continue
+		}
+		lines = append(lines, trimmed)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+/* schemas */
+
+var formats = map[types.BasicKind][2]string{
+	types.Bool:    {"boolean", ""},
+	types.Int:     {"integer", "int64"},
+	types.Int8:    {"integer", "int8"},
+	types.Int16:   {"integer", "int16"},
+	types.Int32:   {"integer", "int32"},
+	types.Int64:   {"integer", "int64"},
+	types.Uint:    {"integer", "uint64"},
+	types.Uint8:   {"integer", "uint8"},
+	types.Uint16:  {"integer", "uint16"},
+	types.Uint32:  {"integer", "uint32"},
+	types.Uint64:  {"integer", "uint64"},
+	types.Uintptr: {"integer", "uint64"},
+	types.Float32: {"number", "float"},
+	types.Float64: {"number", "double"},
+	types.String:  {"string", ""},
+}
+
+// hasMethod returns true if the type (or its pointer) has the method
+func hasMethod(t types.Type, name string) bool {
+	for _, t := range []types.Type{t, types.NewPointer(t)} {
+		if obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name); obj != nil {
+			if _, ok := obj.(*types.Func); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schema returns the JSON schema of a Go type (the named types are definitions) or nil if it isn't encoded
+func (s *scanner) schema(t types.Type) *Schema {
+	switch t := t.(type) {
+	case *types.Alias:
+		return s.schema(types.Unalias(t))
+	case *types.Named:
+		obj := t.Obj()
+		switch {
+		case obj.Pkg() == nil: // error
+			return &Schema{Type: "string"}
+		case obj.Pkg().Path() == "time" && obj.Name() == "Time":
+			return &Schema{Type: "string", Format: "date-time"}
+		case obj.Pkg().Path() == "time" && obj.Name() == "Duration":
+			return &Schema{Type: "integer", Format: "int64"}
+		case hasMethod(t, "MarshalJSON"):
+			return &Schema{}
+		case hasMethod(t, "MarshalText"):
+			return &Schema{Type: "string"}
+		case t.TypeArgs().Len() > 0:
+			return s.schema(t.Underlying())
+		}
+		if _, ok := t.Underlying().(*types.Basic); ok && obj.Pkg().Path() == "encoding/json" {
+			return &Schema{} // json.Number
+		}
+		return &Schema{Ref: "#/definitions/" + s.definition(obj)}
+	case *types.Basic:
+		f, ok := formats[t.Kind()]
+		if !ok {
+			return nil
+		}
+		return &Schema{Type: f[0], Format: f[1]}
+	case *types.Pointer:
+		return s.schema(t.Elem())
+	case *types.Slice:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		if items := s.schema(t.Elem()); items != nil {
+			return &Schema{Type: "array", Items: items}
+		}
+	case *types.Array:
+		if items := s.schema(t.Elem()); items != nil {
+			return &Schema{Type: "array", Items: items}
+		}
+	case *types.Map:
+		if values := s.schema(t.Elem()); values != nil {
+			return &Schema{Type: "object", AdditionalProperties: values}
+		}
+	case *types.Interface:
+		return &Schema{}
+	case *types.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		s.properties(schema, t)
+		return schema
+	}
+	return nil
+}
+
+// properties adds the struct's fields (and its embedded structs' fields) to an object schema
+func (s *scanner) properties(schema *Schema, st *types.Struct) {
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		name, omitempty, ok := jsonName(field, st.Tag(i))
+		if field.Embedded() && len(reflect.StructTag(st.Tag(i)).Get("json")) == 0 {
+			t := field.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				s.properties(schema, embedded)
+				continue
+			}
+		}
+		if !ok {
+			continue
+		}
+		prop := s.schema(field.Type())
+		if prop == nil {
+			continue
+		}
+		doc := s.doc(field.Pos())
+		if desc := docText(doc); len(desc) > 0 && len(prop.Ref) == 0 {
+			prop.Description = desc
+		}
+		if name != field.Name() && len(prop.Ref) == 0 {
+			prop.GoName = field.Name()
+		}
+		binding := reflect.StructTag(st.Tag(i)).Get("binding")
+		if annotations(doc)["required"] == "true" || (!omitempty && strings.Contains(","+binding+",", ",required,")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	sort.Strings(schema.Required)
+}
+
+// definition returns the definition name of a named type (adding its definition if needed)
+func (s *scanner) definition(obj *types.TypeName) string {
+	key := obj.Pkg().Path() + "." + obj.Name()
+	if name, ok := s.names[key]; ok {
+		return name
+	}
+	name := obj.Name()
+	if existing, ok := s.spec.Definitions[name]; ok {
+		var def struct {
+			Package string `json:"x-go-package"`
+		}
+		if json.Unmarshal(existing, &def) == nil && (len(def.Package) == 0 || def.Package == obj.Pkg().Path()) {
+			s.names[key] = name // generated by go-swagger
+			return name
+		}
+		name = obj.Pkg().Name() + strings.ToUpper(name[:1]) + name[1:]
+		for i := 2; ; i++ {
+			if _, ok := s.spec.Definitions[name]; !ok {
+				break
+			}
+			name = obj.Pkg().Name() + obj.Name() + strconv.Itoa(i)
+		}
+	}
+	s.names[key] = name
+	s.spec.Definitions[name] = json.RawMessage("{}") // reserve the name (the type can be recursive)
+
+	def := s.schema(obj.Type().Underlying())
+	if def == nil {
+		def = &Schema{}
+	}
+	def.Description = docText(s.doc(obj.Pos()))
+	def.GoPackage = obj.Pkg().Path()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(def); err == nil {
+		s.spec.Definitions[name] = bytes.TrimSpace(buf.Bytes())
+	}
+	return name
+}
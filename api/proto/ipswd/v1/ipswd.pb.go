@@ -0,0 +1,1296 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        v5.29.3
+// source: ipswd/v1/ipswd.proto
+
+package ipswdv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DownloadState int32
+
+const (
+	DownloadState_DOWNLOAD_STATE_UNSPECIFIED DownloadState = 0
+	DownloadState_DOWNLOAD_STATE_PENDING     DownloadState = 1
+	DownloadState_DOWNLOAD_STATE_RUNNING     DownloadState = 2
+	DownloadState_DOWNLOAD_STATE_DONE        DownloadState = 3
+	DownloadState_DOWNLOAD_STATE_FAILED      DownloadState = 4
+)
+
+// Enum value maps for DownloadState.
+var (
+	DownloadState_name = map[int32]string{
+		0: "DOWNLOAD_STATE_UNSPECIFIED",
+		1: "DOWNLOAD_STATE_PENDING",
+		2: "DOWNLOAD_STATE_RUNNING",
+		3: "DOWNLOAD_STATE_DONE",
+		4: "DOWNLOAD_STATE_FAILED",
+	}
+	DownloadState_value = map[string]int32{
+		"DOWNLOAD_STATE_UNSPECIFIED": 0,
+		"DOWNLOAD_STATE_PENDING":     1,
+		"DOWNLOAD_STATE_RUNNING":     2,
+		"DOWNLOAD_STATE_DONE":        3,
+		"DOWNLOAD_STATE_FAILED":      4,
+	}
+)
+
+func (x DownloadState) Enum() *DownloadState {
+	p := new(DownloadState)
+	*p = x
+	return p
+}
+
+func (x DownloadState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DownloadState) Descriptor() protoreflect.EnumDescriptor {
+	return file_ipswd_v1_ipswd_proto_enumTypes[0].Descriptor()
+}
+
+func (DownloadState) Type() protoreflect.EnumType {
+	return &file_ipswd_v1_ipswd_proto_enumTypes[0]
+}
+
+func (x DownloadState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DownloadState.Descriptor instead.
+func (DownloadState) EnumDescriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{0}
+}
+
+type VersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionRequest) Reset() {
+	*x = VersionRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionRequest) ProtoMessage() {}
+
+func (x *VersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
+func (*VersionRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{0}
+}
+
+type VersionResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ApiVersion     string                 `protobuf:"bytes,1,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	OsType         string                 `protobuf:"bytes,2,opt,name=os_type,json=osType,proto3" json:"os_type,omitempty"`
+	BuilderVersion string                 `protobuf:"bytes,3,opt,name=builder_version,json=builderVersion,proto3" json:"builder_version,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VersionResponse) Reset() {
+	*x = VersionResponse{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionResponse) ProtoMessage() {}
+
+func (x *VersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
+func (*VersionResponse) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VersionResponse) GetApiVersion() string {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetOsType() string {
+	if x != nil {
+		return x.OsType
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetBuilderVersion() string {
+	if x != nil {
+		return x.BuilderVersion
+	}
+	return ""
+}
+
+type GetMachORequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// path to the MachO on the server
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// architecture to select from a universal MachO
+	Arch          string `protobuf:"bytes,2,opt,name=arch,proto3" json:"arch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMachORequest) Reset() {
+	*x = GetMachORequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMachORequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMachORequest) ProtoMessage() {}
+
+func (x *GetMachORequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMachORequest.ProtoReflect.Descriptor instead.
+func (*GetMachORequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetMachORequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetMachORequest) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+type Section struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Segment       string                 `protobuf:"bytes,1,opt,name=segment,proto3" json:"segment,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Addr          uint64                 `protobuf:"varint,3,opt,name=addr,proto3" json:"addr,omitempty"`
+	Size          uint64                 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Offset        uint32                 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Section) Reset() {
+	*x = Section{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Section) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Section) ProtoMessage() {}
+
+func (x *Section) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Section.ProtoReflect.Descriptor instead.
+func (*Section) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Section) GetSegment() string {
+	if x != nil {
+		return x.Segment
+	}
+	return ""
+}
+
+func (x *Section) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Section) GetAddr() uint64 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+func (x *Section) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Section) GetOffset() uint32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type MachO struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Arch          string                 `protobuf:"bytes,3,opt,name=arch,proto3" json:"arch,omitempty"`
+	FileType      string                 `protobuf:"bytes,4,opt,name=file_type,json=fileType,proto3" json:"file_type,omitempty"`
+	SourceVersion string                 `protobuf:"bytes,5,opt,name=source_version,json=sourceVersion,proto3" json:"source_version,omitempty"`
+	Sections      []*Section             `protobuf:"bytes,6,rep,name=sections,proto3" json:"sections,omitempty"`
+	Imports       []string               `protobuf:"bytes,7,rep,name=imports,proto3" json:"imports,omitempty"`
+	SymbolCount   uint32                 `protobuf:"varint,8,opt,name=symbol_count,json=symbolCount,proto3" json:"symbol_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MachO) Reset() {
+	*x = MachO{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MachO) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MachO) ProtoMessage() {}
+
+func (x *MachO) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MachO.ProtoReflect.Descriptor instead.
+func (*MachO) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MachO) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *MachO) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *MachO) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *MachO) GetFileType() string {
+	if x != nil {
+		return x.FileType
+	}
+	return ""
+}
+
+func (x *MachO) GetSourceVersion() string {
+	if x != nil {
+		return x.SourceVersion
+	}
+	return ""
+}
+
+func (x *MachO) GetSections() []*Section {
+	if x != nil {
+		return x.Sections
+	}
+	return nil
+}
+
+func (x *MachO) GetImports() []string {
+	if x != nil {
+		return x.Imports
+	}
+	return nil
+}
+
+func (x *MachO) GetSymbolCount() uint32 {
+	if x != nil {
+		return x.SymbolCount
+	}
+	return 0
+}
+
+type GetSymbolsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// UUID of the MachO or DSC
+	Uuid          string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSymbolsRequest) Reset() {
+	*x = GetSymbolsRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSymbolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSymbolsRequest) ProtoMessage() {}
+
+func (x *GetSymbolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSymbolsRequest.ProtoReflect.Descriptor instead.
+func (*GetSymbolsRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetSymbolsRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type LookupSymbolRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// UUID of the MachO or DSC
+	Uuid          string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Addr          uint64 `protobuf:"varint,2,opt,name=addr,proto3" json:"addr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupSymbolRequest) Reset() {
+	*x = LookupSymbolRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupSymbolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupSymbolRequest) ProtoMessage() {}
+
+func (x *LookupSymbolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupSymbolRequest.ProtoReflect.Descriptor instead.
+func (*LookupSymbolRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LookupSymbolRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *LookupSymbolRequest) GetAddr() uint64 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+type Symbol struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Start         uint64                 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint64                 `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Symbol) Reset() {
+	*x = Symbol{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Symbol) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Symbol) ProtoMessage() {}
+
+func (x *Symbol) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Symbol.ProtoReflect.Descriptor instead.
+func (*Symbol) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Symbol) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Symbol) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Symbol) GetEnd() uint64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type DiffMachOsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// paths to the MachOs on the server
+	Prev          string `protobuf:"bytes,1,opt,name=prev,proto3" json:"prev,omitempty"`
+	Curr          string `protobuf:"bytes,2,opt,name=curr,proto3" json:"curr,omitempty"`
+	Cstrings      bool   `protobuf:"varint,3,opt,name=cstrings,proto3" json:"cstrings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffMachOsRequest) Reset() {
+	*x = DiffMachOsRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffMachOsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffMachOsRequest) ProtoMessage() {}
+
+func (x *DiffMachOsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffMachOsRequest.ProtoReflect.Descriptor instead.
+func (*DiffMachOsRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DiffMachOsRequest) GetPrev() string {
+	if x != nil {
+		return x.Prev
+	}
+	return ""
+}
+
+func (x *DiffMachOsRequest) GetCurr() string {
+	if x != nil {
+		return x.Curr
+	}
+	return ""
+}
+
+func (x *DiffMachOsRequest) GetCstrings() bool {
+	if x != nil {
+		return x.Cstrings
+	}
+	return false
+}
+
+type DiffIPSWsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// paths to the IPSWs on the server
+	Prev     string `protobuf:"bytes,1,opt,name=prev,proto3" json:"prev,omitempty"`
+	Curr     string `protobuf:"bytes,2,opt,name=curr,proto3" json:"curr,omitempty"`
+	Cstrings bool   `protobuf:"varint,3,opt,name=cstrings,proto3" json:"cstrings,omitempty"`
+	// diff the IM4P firmwares instead of the filesystem MachOs
+	Firmware      bool     `protobuf:"varint,4,opt,name=firmware,proto3" json:"firmware,omitempty"`
+	AllowList     []string `protobuf:"bytes,5,rep,name=allow_list,json=allowList,proto3" json:"allow_list,omitempty"`
+	BlockList     []string `protobuf:"bytes,6,rep,name=block_list,json=blockList,proto3" json:"block_list,omitempty"`
+	PemDb         string   `protobuf:"bytes,7,opt,name=pem_db,json=pemDb,proto3" json:"pem_db,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffIPSWsRequest) Reset() {
+	*x = DiffIPSWsRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffIPSWsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffIPSWsRequest) ProtoMessage() {}
+
+func (x *DiffIPSWsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffIPSWsRequest.ProtoReflect.Descriptor instead.
+func (*DiffIPSWsRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DiffIPSWsRequest) GetPrev() string {
+	if x != nil {
+		return x.Prev
+	}
+	return ""
+}
+
+func (x *DiffIPSWsRequest) GetCurr() string {
+	if x != nil {
+		return x.Curr
+	}
+	return ""
+}
+
+func (x *DiffIPSWsRequest) GetCstrings() bool {
+	if x != nil {
+		return x.Cstrings
+	}
+	return false
+}
+
+func (x *DiffIPSWsRequest) GetFirmware() bool {
+	if x != nil {
+		return x.Firmware
+	}
+	return false
+}
+
+func (x *DiffIPSWsRequest) GetAllowList() []string {
+	if x != nil {
+		return x.AllowList
+	}
+	return nil
+}
+
+func (x *DiffIPSWsRequest) GetBlockList() []string {
+	if x != nil {
+		return x.BlockList
+	}
+	return nil
+}
+
+func (x *DiffIPSWsRequest) GetPemDb() string {
+	if x != nil {
+		return x.PemDb
+	}
+	return ""
+}
+
+type Diff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	New           []string               `protobuf:"bytes,1,rep,name=new,proto3" json:"new,omitempty"`
+	Removed       []string               `protobuf:"bytes,2,rep,name=removed,proto3" json:"removed,omitempty"`
+	Updated       map[string]string      `protobuf:"bytes,3,rep,name=updated,proto3" json:"updated,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Diff) Reset() {
+	*x = Diff{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Diff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diff) ProtoMessage() {}
+
+func (x *Diff) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diff.ProtoReflect.Descriptor instead.
+func (*Diff) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Diff) GetNew() []string {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+func (x *Diff) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *Diff) GetUpdated() map[string]string {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+type DownloadStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Build         string                 `protobuf:"bytes,3,opt,name=build,proto3" json:"build,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	State         DownloadState          `protobuf:"varint,5,opt,name=state,proto3,enum=ipswd.v1.DownloadState" json:"state,omitempty"`
+	Attempts      int32                  `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Path          string                 `protobuf:"bytes,8,opt,name=path,proto3" json:"path,omitempty"`
+	NextAttempt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=next_attempt,json=nextAttempt,proto3" json:"next_attempt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadStatus) Reset() {
+	*x = DownloadStatus{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadStatus) ProtoMessage() {}
+
+func (x *DownloadStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadStatus.ProtoReflect.Descriptor instead.
+func (*DownloadStatus) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DownloadStatus) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DownloadStatus) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *DownloadStatus) GetBuild() string {
+	if x != nil {
+		return x.Build
+	}
+	return ""
+}
+
+func (x *DownloadStatus) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DownloadStatus) GetState() DownloadState {
+	if x != nil {
+		return x.State
+	}
+	return DownloadState_DOWNLOAD_STATE_UNSPECIFIED
+}
+
+func (x *DownloadStatus) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DownloadStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DownloadStatus) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DownloadStatus) GetNextAttempt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextAttempt
+	}
+	return nil
+}
+
+func (x *DownloadStatus) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetDownloadQueueRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// only return items in this state (all items if unspecified)
+	State         DownloadState `protobuf:"varint,1,opt,name=state,proto3,enum=ipswd.v1.DownloadState" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDownloadQueueRequest) Reset() {
+	*x = GetDownloadQueueRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDownloadQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDownloadQueueRequest) ProtoMessage() {}
+
+func (x *GetDownloadQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDownloadQueueRequest.ProtoReflect.Descriptor instead.
+func (*GetDownloadQueueRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetDownloadQueueRequest) GetState() DownloadState {
+	if x != nil {
+		return x.State
+	}
+	return DownloadState_DOWNLOAD_STATE_UNSPECIFIED
+}
+
+type DownloadQueue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*DownloadStatus      `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadQueue) Reset() {
+	*x = DownloadQueue{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadQueue) ProtoMessage() {}
+
+func (x *DownloadQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadQueue.ProtoReflect.Descriptor instead.
+func (*DownloadQueue) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DownloadQueue) GetItems() []*DownloadStatus {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type WatchDownloadQueueRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// only stream items in this state (all items if unspecified)
+	State DownloadState `protobuf:"varint,1,opt,name=state,proto3,enum=ipswd.v1.DownloadState" json:"state,omitempty"`
+	// how often to poll the queue (defaults to 1s)
+	IntervalMs    uint32 `protobuf:"varint,2,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchDownloadQueueRequest) Reset() {
+	*x = WatchDownloadQueueRequest{}
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDownloadQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDownloadQueueRequest) ProtoMessage() {}
+
+func (x *WatchDownloadQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipswd_v1_ipswd_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDownloadQueueRequest.ProtoReflect.Descriptor instead.
+func (*WatchDownloadQueueRequest) Descriptor() ([]byte, []int) {
+	return file_ipswd_v1_ipswd_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchDownloadQueueRequest) GetState() DownloadState {
+	if x != nil {
+		return x.State
+	}
+	return DownloadState_DOWNLOAD_STATE_UNSPECIFIED
+}
+
+func (x *WatchDownloadQueueRequest) GetIntervalMs() uint32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+var File_ipswd_v1_ipswd_proto protoreflect.FileDescriptor
+
+var file_ipswd_v1_ipswd_proto_rawDesc = string([]byte{
+	0x0a, 0x14, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2f, 0x76, 0x31, 0x2f, 0x69, 0x70, 0x73, 0x77, 0x64,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31,
+	0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x22, 0x10, 0x0a, 0x0e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x74, 0x0a, 0x0f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x70, 0x69, 0x5f, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x70, 0x69,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x0a, 0x07, 0x6f, 0x73, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x73, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x27, 0x0a, 0x0f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x65, 0x72, 0x5f, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x39, 0x0a, 0x0f, 0x47, 0x65, 0x74,
+	0x4d, 0x61, 0x63, 0x68, 0x4f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x61, 0x72, 0x63, 0x68, 0x22, 0x77, 0x0a, 0x07, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x18, 0x0a, 0x07, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x61, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x64, 0x64,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0xf3, 0x01,
+	0x0a, 0x05, 0x4d, 0x61, 0x63, 0x68, 0x4f, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x75,
+	0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x61, 0x72, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61,
+	0x72, 0x63, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x25, 0x0a, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2d, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69, 0x70, 0x73, 0x77,
+	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x73, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x73,
+	0x12, 0x21, 0x0a, 0x0c, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x22, 0x27, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x22, 0x3d, 0x0a, 0x13,
+	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x64, 0x64, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x64, 0x64, 0x72, 0x22, 0x44, 0x0a, 0x06, 0x53,
+	0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x65, 0x6e,
+	0x64, 0x22, 0x57, 0x0a, 0x11, 0x44, 0x69, 0x66, 0x66, 0x4d, 0x61, 0x63, 0x68, 0x4f, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x72, 0x65, 0x76, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x72, 0x65, 0x76, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x75,
+	0x72, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x75, 0x72, 0x72, 0x12, 0x1a,
+	0x0a, 0x08, 0x63, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x63, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x73, 0x22, 0xc7, 0x01, 0x0a, 0x10, 0x44,
+	0x69, 0x66, 0x66, 0x49, 0x50, 0x53, 0x57, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x70, 0x72, 0x65, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70,
+	0x72, 0x65, 0x76, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x75, 0x72, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x63, 0x75, 0x72, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x73, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x73, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1d,
+	0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x06, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x15, 0x0a,
+	0x06, 0x70, 0x65, 0x6d, 0x5f, 0x64, 0x62, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70,
+	0x65, 0x6d, 0x44, 0x62, 0x22, 0xa5, 0x01, 0x0a, 0x04, 0x44, 0x69, 0x66, 0x66, 0x12, 0x10, 0x0a,
+	0x03, 0x6e, 0x65, 0x77, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x6e, 0x65, 0x77, 0x12,
+	0x18, 0x0a, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x35, 0x0a, 0x07, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x70, 0x73,
+	0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x66, 0x66, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x1a, 0x3a, 0x0a, 0x0c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xd7, 0x02, 0x0a,
+	0x0e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70,
+	0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70,
+	0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x3d, 0x0a, 0x0c,
+	0x6e, 0x65, 0x78, 0x74, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b,
+	0x6e, 0x65, 0x78, 0x74, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x48, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x77,
+	0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x51, 0x75, 0x65, 0x75, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x17, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e,
+	0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x22, 0x3f, 0x0a, 0x0d, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x51, 0x75, 0x65, 0x75,
+	0x65, 0x12, 0x2e, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e,
+	0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d,
+	0x73, 0x22, 0x6b, 0x0a, 0x19, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f,
+	0x61, 0x64, 0x51, 0x75, 0x65, 0x75, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
+	0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x0a,
+	0x0b, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x2a, 0x9b,
+	0x01, 0x0a, 0x0d, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x1e, 0x0a, 0x1a, 0x44, 0x4f, 0x57, 0x4e, 0x4c, 0x4f, 0x41, 0x44, 0x5f, 0x53, 0x54, 0x41,
+	0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x1a, 0x0a, 0x16, 0x44, 0x4f, 0x57, 0x4e, 0x4c, 0x4f, 0x41, 0x44, 0x5f, 0x53, 0x54, 0x41,
+	0x54, 0x45, 0x5f, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x1a, 0x0a, 0x16,
+	0x44, 0x4f, 0x57, 0x4e, 0x4c, 0x4f, 0x41, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x52,
+	0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x44, 0x4f, 0x57, 0x4e,
+	0x4c, 0x4f, 0x41, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x44, 0x4f, 0x4e, 0x45, 0x10,
+	0x03, 0x12, 0x19, 0x0a, 0x15, 0x44, 0x4f, 0x57, 0x4e, 0x4c, 0x4f, 0x41, 0x44, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x45, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x04, 0x32, 0x9a, 0x04, 0x0a,
+	0x05, 0x49, 0x70, 0x73, 0x77, 0x64, 0x12, 0x3e, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x18, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x69, 0x70,
+	0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x63,
+	0x68, 0x4f, 0x12, 0x19, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
+	0x74, 0x4d, 0x61, 0x63, 0x68, 0x4f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e,
+	0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x63, 0x68, 0x4f, 0x12, 0x3d,
+	0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x12, 0x1b, 0x2e, 0x69,
+	0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x79, 0x6d, 0x62, 0x6f,
+	0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x69, 0x70, 0x73, 0x77,
+	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x30, 0x01, 0x12, 0x3f, 0x0a,
+	0x0c, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x1d, 0x2e,
+	0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x53,
+	0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x69,
+	0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x39,
+	0x0a, 0x0a, 0x44, 0x69, 0x66, 0x66, 0x4d, 0x61, 0x63, 0x68, 0x4f, 0x73, 0x12, 0x1b, 0x2e, 0x69,
+	0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x66, 0x66, 0x4d, 0x61, 0x63, 0x68,
+	0x4f, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x69, 0x70, 0x73, 0x77,
+	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x66, 0x66, 0x12, 0x37, 0x0a, 0x09, 0x44, 0x69, 0x66,
+	0x66, 0x49, 0x50, 0x53, 0x57, 0x73, 0x12, 0x1a, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x69, 0x66, 0x66, 0x49, 0x50, 0x53, 0x57, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69,
+	0x66, 0x66, 0x12, 0x4e, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x51, 0x75, 0x65, 0x75, 0x65, 0x12, 0x21, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x51, 0x75, 0x65,
+	0x75, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x70, 0x73, 0x77,
+	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x51, 0x75, 0x65,
+	0x75, 0x65, 0x12, 0x55, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44, 0x6f, 0x77, 0x6e, 0x6c,
+	0x6f, 0x61, 0x64, 0x51, 0x75, 0x65, 0x75, 0x65, 0x12, 0x23, 0x2e, 0x69, 0x70, 0x73, 0x77, 0x64,
+	0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x51, 0x75, 0x65, 0x75, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
+	0x69, 0x70, 0x73, 0x77, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x30, 0x01, 0x42, 0x35, 0x5a, 0x33, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x6c, 0x61, 0x63, 0x6b, 0x74, 0x6f, 0x70,
+	0x2f, 0x69, 0x70, 0x73, 0x77, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x69, 0x70, 0x73, 0x77, 0x64, 0x2f, 0x76, 0x31, 0x3b, 0x69, 0x70, 0x73, 0x77, 0x64, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_ipswd_v1_ipswd_proto_rawDescOnce sync.Once
+	file_ipswd_v1_ipswd_proto_rawDescData []byte
+)
+
+func file_ipswd_v1_ipswd_proto_rawDescGZIP() []byte {
+	file_ipswd_v1_ipswd_proto_rawDescOnce.Do(func() {
+		file_ipswd_v1_ipswd_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ipswd_v1_ipswd_proto_rawDesc), len(file_ipswd_v1_ipswd_proto_rawDesc)))
+	})
+	return file_ipswd_v1_ipswd_proto_rawDescData
+}
+
+var file_ipswd_v1_ipswd_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ipswd_v1_ipswd_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_ipswd_v1_ipswd_proto_goTypes = []any{
+	(DownloadState)(0),                // 0: ipswd.v1.DownloadState
+	(*VersionRequest)(nil),            // 1: ipswd.v1.VersionRequest
+	(*VersionResponse)(nil),           // 2: ipswd.v1.VersionResponse
+	(*GetMachORequest)(nil),           // 3: ipswd.v1.GetMachORequest
+	(*Section)(nil),                   // 4: ipswd.v1.Section
+	(*MachO)(nil),                     // 5: ipswd.v1.MachO
+	(*GetSymbolsRequest)(nil),         // 6: ipswd.v1.GetSymbolsRequest
+	(*LookupSymbolRequest)(nil),       // 7: ipswd.v1.LookupSymbolRequest
+	(*Symbol)(nil),                    // 8: ipswd.v1.Symbol
+	(*DiffMachOsRequest)(nil),         // 9: ipswd.v1.DiffMachOsRequest
+	(*DiffIPSWsRequest)(nil),          // 10: ipswd.v1.DiffIPSWsRequest
+	(*Diff)(nil),                      // 11: ipswd.v1.Diff
+	(*DownloadStatus)(nil),            // 12: ipswd.v1.DownloadStatus
+	(*GetDownloadQueueRequest)(nil),   // 13: ipswd.v1.GetDownloadQueueRequest
+	(*DownloadQueue)(nil),             // 14: ipswd.v1.DownloadQueue
+	(*WatchDownloadQueueRequest)(nil), // 15: ipswd.v1.WatchDownloadQueueRequest
+	nil,                               // 16: ipswd.v1.Diff.UpdatedEntry
+	(*timestamppb.Timestamp)(nil),     // 17: google.protobuf.Timestamp
+}
+var file_ipswd_v1_ipswd_proto_depIdxs = []int32{
+	4,  // 0: ipswd.v1.MachO.sections:type_name -> ipswd.v1.Section
+	16, // 1: ipswd.v1.Diff.updated:type_name -> ipswd.v1.Diff.UpdatedEntry
+	0,  // 2: ipswd.v1.DownloadStatus.state:type_name -> ipswd.v1.DownloadState
+	17, // 3: ipswd.v1.DownloadStatus.next_attempt:type_name -> google.protobuf.Timestamp
+	17, // 4: ipswd.v1.DownloadStatus.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 5: ipswd.v1.GetDownloadQueueRequest.state:type_name -> ipswd.v1.DownloadState
+	12, // 6: ipswd.v1.DownloadQueue.items:type_name -> ipswd.v1.DownloadStatus
+	0,  // 7: ipswd.v1.WatchDownloadQueueRequest.state:type_name -> ipswd.v1.DownloadState
+	1,  // 8: ipswd.v1.Ipswd.Version:input_type -> ipswd.v1.VersionRequest
+	3,  // 9: ipswd.v1.Ipswd.GetMachO:input_type -> ipswd.v1.GetMachORequest
+	6,  // 10: ipswd.v1.Ipswd.GetSymbols:input_type -> ipswd.v1.GetSymbolsRequest
+	7,  // 11: ipswd.v1.Ipswd.LookupSymbol:input_type -> ipswd.v1.LookupSymbolRequest
+	9,  // 12: ipswd.v1.Ipswd.DiffMachOs:input_type -> ipswd.v1.DiffMachOsRequest
+	10, // 13: ipswd.v1.Ipswd.DiffIPSWs:input_type -> ipswd.v1.DiffIPSWsRequest
+	13, // 14: ipswd.v1.Ipswd.GetDownloadQueue:input_type -> ipswd.v1.GetDownloadQueueRequest
+	15, // 15: ipswd.v1.Ipswd.WatchDownloadQueue:input_type -> ipswd.v1.WatchDownloadQueueRequest
+	2,  // 16: ipswd.v1.Ipswd.Version:output_type -> ipswd.v1.VersionResponse
+	5,  // 17: ipswd.v1.Ipswd.GetMachO:output_type -> ipswd.v1.MachO
+	8,  // 18: ipswd.v1.Ipswd.GetSymbols:output_type -> ipswd.v1.Symbol
+	8,  // 19: ipswd.v1.Ipswd.LookupSymbol:output_type -> ipswd.v1.Symbol
+	11, // 20: ipswd.v1.Ipswd.DiffMachOs:output_type -> ipswd.v1.Diff
+	11, // 21: ipswd.v1.Ipswd.DiffIPSWs:output_type -> ipswd.v1.Diff
+	14, // 22: ipswd.v1.Ipswd.GetDownloadQueue:output_type -> ipswd.v1.DownloadQueue
+	12, // 23: ipswd.v1.Ipswd.WatchDownloadQueue:output_type -> ipswd.v1.DownloadStatus
+	16, // [16:24] is the sub-list for method output_type
+	8,  // [8:16] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_ipswd_v1_ipswd_proto_init() }
+func file_ipswd_v1_ipswd_proto_init() {
+	if File_ipswd_v1_ipswd_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ipswd_v1_ipswd_proto_rawDesc), len(file_ipswd_v1_ipswd_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ipswd_v1_ipswd_proto_goTypes,
+		DependencyIndexes: file_ipswd_v1_ipswd_proto_depIdxs,
+		EnumInfos:         file_ipswd_v1_ipswd_proto_enumTypes,
+		MessageInfos:      file_ipswd_v1_ipswd_proto_msgTypes,
+	}.Build()
+	File_ipswd_v1_ipswd_proto = out.File
+	file_ipswd_v1_ipswd_proto_goTypes = nil
+	file_ipswd_v1_ipswd_proto_depIdxs = nil
+}
@@ -0,0 +1,414 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: ipswd/v1/ipswd.proto
+
+package ipswdv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Ipswd_Version_FullMethodName            = "/ipswd.v1.Ipswd/Version"
+	Ipswd_GetMachO_FullMethodName           = "/ipswd.v1.Ipswd/GetMachO"
+	Ipswd_GetSymbols_FullMethodName         = "/ipswd.v1.Ipswd/GetSymbols"
+	Ipswd_LookupSymbol_FullMethodName       = "/ipswd.v1.Ipswd/LookupSymbol"
+	Ipswd_DiffMachOs_FullMethodName         = "/ipswd.v1.Ipswd/DiffMachOs"
+	Ipswd_DiffIPSWs_FullMethodName          = "/ipswd.v1.Ipswd/DiffIPSWs"
+	Ipswd_GetDownloadQueue_FullMethodName   = "/ipswd.v1.Ipswd/GetDownloadQueue"
+	Ipswd_WatchDownloadQueue_FullMethodName = "/ipswd.v1.Ipswd/WatchDownloadQueue"
+)
+
+// IpswdClient is the client API for Ipswd service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ipswd is the typed (gRPC) counterpart of the ipswd REST API
+type IpswdClient interface {
+	// Version returns the daemon's API version
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+	// GetMachO returns a MachO's info
+	GetMachO(ctx context.Context, in *GetMachORequest, opts ...grpc.CallOption) (*MachO, error)
+	// GetSymbols streams the symbols of a MachO/DSC in the symbol database
+	GetSymbols(ctx context.Context, in *GetSymbolsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Symbol], error)
+	// LookupSymbol returns the symbol containing an address in the symbol database
+	LookupSymbol(ctx context.Context, in *LookupSymbolRequest, opts ...grpc.CallOption) (*Symbol, error)
+	// DiffMachOs diffs two MachOs
+	DiffMachOs(ctx context.Context, in *DiffMachOsRequest, opts ...grpc.CallOption) (*Diff, error)
+	// DiffIPSWs diffs the MachOs (or IM4P firmwares) of two IPSWs
+	DiffIPSWs(ctx context.Context, in *DiffIPSWsRequest, opts ...grpc.CallOption) (*Diff, error)
+	// GetDownloadQueue returns the download queue
+	GetDownloadQueue(ctx context.Context, in *GetDownloadQueueRequest, opts ...grpc.CallOption) (*DownloadQueue, error)
+	// WatchDownloadQueue streams download queue items as their status changes
+	WatchDownloadQueue(ctx context.Context, in *WatchDownloadQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadStatus], error)
+}
+
+type ipswdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIpswdClient(cc grpc.ClientConnInterface) IpswdClient {
+	return &ipswdClient{cc}
+}
+
+func (c *ipswdClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VersionResponse)
+	err := c.cc.Invoke(ctx, Ipswd_Version_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) GetMachO(ctx context.Context, in *GetMachORequest, opts ...grpc.CallOption) (*MachO, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MachO)
+	err := c.cc.Invoke(ctx, Ipswd_GetMachO_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) GetSymbols(ctx context.Context, in *GetSymbolsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Symbol], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ipswd_ServiceDesc.Streams[0], Ipswd_GetSymbols_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetSymbolsRequest, Symbol]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ipswd_GetSymbolsClient = grpc.ServerStreamingClient[Symbol]
+
+func (c *ipswdClient) LookupSymbol(ctx context.Context, in *LookupSymbolRequest, opts ...grpc.CallOption) (*Symbol, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Symbol)
+	err := c.cc.Invoke(ctx, Ipswd_LookupSymbol_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) DiffMachOs(ctx context.Context, in *DiffMachOsRequest, opts ...grpc.CallOption) (*Diff, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Diff)
+	err := c.cc.Invoke(ctx, Ipswd_DiffMachOs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) DiffIPSWs(ctx context.Context, in *DiffIPSWsRequest, opts ...grpc.CallOption) (*Diff, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Diff)
+	err := c.cc.Invoke(ctx, Ipswd_DiffIPSWs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) GetDownloadQueue(ctx context.Context, in *GetDownloadQueueRequest, opts ...grpc.CallOption) (*DownloadQueue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DownloadQueue)
+	err := c.cc.Invoke(ctx, Ipswd_GetDownloadQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipswdClient) WatchDownloadQueue(ctx context.Context, in *WatchDownloadQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ipswd_ServiceDesc.Streams[1], Ipswd_WatchDownloadQueue_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDownloadQueueRequest, DownloadStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ipswd_WatchDownloadQueueClient = grpc.ServerStreamingClient[DownloadStatus]
+
+// IpswdServer is the server API for Ipswd service.
+// All implementations must embed UnimplementedIpswdServer
+// for forward compatibility.
+//
+// Ipswd is the typed (gRPC) counterpart of the ipswd REST API
+type IpswdServer interface {
+	// Version returns the daemon's API version
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	// GetMachO returns a MachO's info
+	GetMachO(context.Context, *GetMachORequest) (*MachO, error)
+	// GetSymbols streams the symbols of a MachO/DSC in the symbol database
+	GetSymbols(*GetSymbolsRequest, grpc.ServerStreamingServer[Symbol]) error
+	// LookupSymbol returns the symbol containing an address in the symbol database
+	LookupSymbol(context.Context, *LookupSymbolRequest) (*Symbol, error)
+	// DiffMachOs diffs two MachOs
+	DiffMachOs(context.Context, *DiffMachOsRequest) (*Diff, error)
+	// DiffIPSWs diffs the MachOs (or IM4P firmwares) of two IPSWs
+	DiffIPSWs(context.Context, *DiffIPSWsRequest) (*Diff, error)
+	// GetDownloadQueue returns the download queue
+	GetDownloadQueue(context.Context, *GetDownloadQueueRequest) (*DownloadQueue, error)
+	// WatchDownloadQueue streams download queue items as their status changes
+	WatchDownloadQueue(*WatchDownloadQueueRequest, grpc.ServerStreamingServer[DownloadStatus]) error
+	mustEmbedUnimplementedIpswdServer()
+}
+
+// UnimplementedIpswdServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIpswdServer struct{}
+
+func (UnimplementedIpswdServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedIpswdServer) GetMachO(context.Context, *GetMachORequest) (*MachO, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachO not implemented")
+}
+func (UnimplementedIpswdServer) GetSymbols(*GetSymbolsRequest, grpc.ServerStreamingServer[Symbol]) error {
+	return status.Errorf(codes.Unimplemented, "method GetSymbols not implemented")
+}
+func (UnimplementedIpswdServer) LookupSymbol(context.Context, *LookupSymbolRequest) (*Symbol, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupSymbol not implemented")
+}
+func (UnimplementedIpswdServer) DiffMachOs(context.Context, *DiffMachOsRequest) (*Diff, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffMachOs not implemented")
+}
+func (UnimplementedIpswdServer) DiffIPSWs(context.Context, *DiffIPSWsRequest) (*Diff, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffIPSWs not implemented")
+}
+func (UnimplementedIpswdServer) GetDownloadQueue(context.Context, *GetDownloadQueueRequest) (*DownloadQueue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDownloadQueue not implemented")
+}
+func (UnimplementedIpswdServer) WatchDownloadQueue(*WatchDownloadQueueRequest, grpc.ServerStreamingServer[DownloadStatus]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDownloadQueue not implemented")
+}
+func (UnimplementedIpswdServer) mustEmbedUnimplementedIpswdServer() {}
+func (UnimplementedIpswdServer) testEmbeddedByValue()               {}
+
+// UnsafeIpswdServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IpswdServer will
+// result in compilation errors.
+type UnsafeIpswdServer interface {
+	mustEmbedUnimplementedIpswdServer()
+}
+
+func RegisterIpswdServer(s grpc.ServiceRegistrar, srv IpswdServer) {
+	// If the following call pancis, it indicates UnimplementedIpswdServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Ipswd_ServiceDesc, srv)
+}
+
+func _Ipswd_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_GetMachO_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMachORequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).GetMachO(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_GetMachO_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).GetMachO(ctx, req.(*GetMachORequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_GetSymbols_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSymbolsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IpswdServer).GetSymbols(m, &grpc.GenericServerStream[GetSymbolsRequest, Symbol]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ipswd_GetSymbolsServer = grpc.ServerStreamingServer[Symbol]
+
+func _Ipswd_LookupSymbol_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupSymbolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).LookupSymbol(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_LookupSymbol_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).LookupSymbol(ctx, req.(*LookupSymbolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_DiffMachOs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffMachOsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).DiffMachOs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_DiffMachOs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).DiffMachOs(ctx, req.(*DiffMachOsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_DiffIPSWs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffIPSWsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).DiffIPSWs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_DiffIPSWs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).DiffIPSWs(ctx, req.(*DiffIPSWsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_GetDownloadQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDownloadQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpswdServer).GetDownloadQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ipswd_GetDownloadQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpswdServer).GetDownloadQueue(ctx, req.(*GetDownloadQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ipswd_WatchDownloadQueue_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDownloadQueueRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IpswdServer).WatchDownloadQueue(m, &grpc.GenericServerStream[WatchDownloadQueueRequest, DownloadStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ipswd_WatchDownloadQueueServer = grpc.ServerStreamingServer[DownloadStatus]
+
+// Ipswd_ServiceDesc is the grpc.ServiceDesc for Ipswd service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ipswd_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ipswd.v1.Ipswd",
+	HandlerType: (*IpswdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Version",
+			Handler:    _Ipswd_Version_Handler,
+		},
+		{
+			MethodName: "GetMachO",
+			Handler:    _Ipswd_GetMachO_Handler,
+		},
+		{
+			MethodName: "LookupSymbol",
+			Handler:    _Ipswd_LookupSymbol_Handler,
+		},
+		{
+			MethodName: "DiffMachOs",
+			Handler:    _Ipswd_DiffMachOs_Handler,
+		},
+		{
+			MethodName: "DiffIPSWs",
+			Handler:    _Ipswd_DiffIPSWs_Handler,
+		},
+		{
+			MethodName: "GetDownloadQueue",
+			Handler:    _Ipswd_GetDownloadQueue_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetSymbols",
+			Handler:       _Ipswd_GetSymbols_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchDownloadQueue",
+			Handler:       _Ipswd_WatchDownloadQueue_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ipswd/v1/ipswd.proto",
+}
@@ -0,0 +1,224 @@
+// Package audit records who (API key) requested what (route, params and input file digests) and when
+// in the daemon's audit log, and exports it
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/api/server/mtls"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Config is the audit log config
+type Config struct {
+	// Enabled records the API requests in the audit log (it requires a database)
+	Enabled bool `json:"enabled"`
+	// Retention is how long the entries are kept (forever if 0)
+	Retention time.Duration `json:"retention"`
+	// Exclude are the prefixes of the routes that are not recorded (i.e. /syms/ to skip the symbol lookups)
+	Exclude []string `json:"exclude"`
+}
+
+// Logger records the API requests in the audit log
+type Logger struct {
+	conf Config
+	db   db.Database
+}
+
+// New creates an audit logger (it returns nil if the audit log is disabled)
+func New(conf Config, d db.Database) (*Logger, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	if d == nil {
+		return nil, fmt.Errorf("audit: the audit log requires a database")
+	}
+	return &Logger{conf: conf, db: d}, nil
+}
+
+// recorded returns true if the requests to the route (a gin full path) are recorded
+func (l *Logger) recorded(method, route string) bool {
+	route, ok := strings.CutPrefix(route, "/v"+api.DefaultVersion)
+	if !ok || auth.RequiredRole(method, route) == auth.Public {
+		return false
+	}
+	return !slices.ContainsFunc(l.conf.Exclude, func(prefix string) bool {
+		return strings.HasPrefix(route, prefix)
+	})
+}
+
+const entryKey = "ipswd.audit"
+
+// Middleware records the API requests (it lets everything through if l is nil)
+//
+// It runs before the authentication and the rate limiting so the rejected requests are recorded too.
+// The public routes and the routes outside the versioned API (metrics, UI) are not recorded.
+func (l *Logger) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil || !l.recorded(c.Request.Method, c.FullPath()) {
+			c.Next()
+			return
+		}
+		entry := &model.AuditEntry{
+			At:       time.Now().UTC(),
+			ClientIP: c.ClientIP(),
+			Method:   c.Request.Method,
+			Route:    c.FullPath(),
+			Path:     c.Request.URL.Path,
+		}
+		query := c.Request.URL.Query()
+		query.Del("access_token")
+		entry.Query = query.Encode()
+		c.Set(entryKey, entry)
+
+		c.Next()
+
+		if id, ok := auth.IdentityFrom(c); ok {
+			entry.Identity = id.Name
+			entry.Role = id.Role.String()
+		} else if tc := c.Request.TLS; tc != nil && len(tc.PeerCertificates) > 0 {
+			if id, err := mtls.SPIFFEID(tc.PeerCertificates[0]); err == nil {
+				entry.Identity = id.String()
+			} else {
+				entry.Identity = tc.PeerCertificates[0].Subject.CommonName
+			}
+		}
+		entry.Status = c.Writer.Status()
+		entry.DurationMs = time.Since(entry.At).Milliseconds()
+		if err := l.db.AddAuditEntry(entry); err != nil {
+			log.WithError(err).Error("audit: failed to record request")
+		}
+	}
+}
+
+// Inputs adds the digests of the request's input files to its audit log entry
+//
+// It runs after the authentication and the rate limiting so the files of the rejected requests aren't read.
+func (l *Logger) Inputs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok := c.Get(entryKey)
+		if l == nil || !ok {
+			c.Next()
+			return
+		}
+		inputs, err := digests(c)
+		if err != nil {
+			log.WithError(err).Debug("audit: failed to hash the request's input files")
+		}
+		if len(inputs) > 0 {
+			dat, _ := json.Marshal(inputs)
+			v.(*model.AuditEntry).Inputs = string(dat)
+		}
+		c.Next()
+	}
+}
+
+// digests returns the SHA256 digests of the request's input files (the query, form and JSON body values
+// that are paths to files on the server and the uploaded files) by param
+func digests(c *gin.Context) (map[string]string, error) {
+	inputs := make(map[string]string)
+	addPaths := func(kind string, values map[string][]string) error {
+		for name, vs := range values {
+			for _, v := range vs {
+				sum, ok, err := utils.FileSha256(v)
+				if err != nil {
+					return err
+				}
+				if ok {
+					inputs[kind+"."+name] = sum
+				}
+			}
+		}
+		return nil
+	}
+	if err := addPaths("query", c.Request.URL.Query()); err != nil {
+		return inputs, err
+	}
+	if c.Request.Body == nil || c.Request.Method == http.MethodGet {
+		return inputs, nil
+	}
+	switch c.ContentType() {
+	case gin.MIMEMultipartPOSTForm:
+		form, err := c.MultipartForm() // the form is kept so the handler can still read it
+		if err != nil {
+			return inputs, err
+		}
+		if err := addPaths("form", form.Value); err != nil {
+			return inputs, err
+		}
+		for name, fhs := range form.File {
+			for _, fh := range fhs {
+				f, err := fh.Open()
+				if err != nil {
+					return inputs, err
+				}
+				sum, err := utils.Sha256Reader(f)
+				f.Close()
+				if err != nil {
+					return inputs, err
+				}
+				inputs["upload."+name] = sum
+			}
+		}
+	case gin.MIMEJSON:
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return inputs, err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return inputs, nil // the handler reports the invalid body
+		}
+		return inputs, addJSONPaths(inputs, "body", v)
+	}
+	return inputs, nil
+}
+
+// addJSONPaths adds the digests of the strings of the JSON value that are paths to files
+func addJSONPaths(inputs map[string]string, name string, v any) error {
+	switch v := v.(type) {
+	case string:
+		sum, ok, err := utils.FileSha256(v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			inputs[name] = sum
+		}
+	case map[string]any:
+		for k, e := range v {
+			if err := addJSONPaths(inputs, name+"."+k, e); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, e := range v {
+			if err := addJSONPaths(inputs, fmt.Sprintf("%s[%d]", name, i), e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Prune removes the entries older than the retention period (none if it is 0)
+func (l *Logger) Prune() (int64, error) {
+	if l.conf.Retention == 0 {
+		return 0, nil
+	}
+	return l.db.DeleteAuditEntries(time.Now().Add(-l.conf.Retention))
+}
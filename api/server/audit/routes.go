@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response auditResponse
+type auditResponse []*model.AuditEntry
+
+// parseTime parses an RFC3339 time or a duration ago (i.e. 24h)
+func parseTime(v string) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func parseQuery(c *gin.Context) (q model.AuditQuery, err error) {
+	if v := c.Query("since"); len(v) > 0 {
+		if q.Since, err = parseTime(v); err != nil {
+			return q, fmt.Errorf("invalid 'since' (must be an RFC3339 time or a duration): %v", err)
+		}
+	}
+	if v := c.Query("until"); len(v) > 0 {
+		if q.Until, err = parseTime(v); err != nil {
+			return q, fmt.Errorf("invalid 'until' (must be an RFC3339 time or a duration): %v", err)
+		}
+	}
+	if v := c.Query("limit"); len(v) > 0 {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 0 {
+			return q, fmt.Errorf("invalid 'limit': %s", v)
+		}
+	}
+	q.Identity = c.Query("identity")
+	q.Route = c.Query("route")
+	return q, nil
+}
+
+var csvHeader = []string{"id", "at", "identity", "role", "client_ip", "method", "route", "path", "query", "inputs", "status", "duration_ms"}
+
+// AddRoutes adds the audit routes to the router
+func AddRoutes(rg *gin.RouterGroup, l *Logger) {
+	// swagger:route GET /audit Audit getAudit
+	//
+	// Export
+	//
+	// Export the audit log of the API requests (oldest first) as JSON, JSON lines or CSV.
+	//
+	//     Produces:
+	//     - application/json
+	//     - application/x-ndjson
+	//     - text/csv
+	//
+	//     Parameters:
+	//       + name: since
+	//         in: query
+	//         description: only the requests since the RFC3339 time or duration ago (i.e. 24h)
+	//         required: false
+	//         type: string
+	//       + name: until
+	//         in: query
+	//         description: only the requests before the RFC3339 time or duration ago
+	//         required: false
+	//         type: string
+	//       + name: identity
+	//         in: query
+	//         description: only the requests of the API key (or JWT subject or client SPIFFE ID)
+	//         required: false
+	//         type: string
+	//       + name: route
+	//         in: query
+	//         description: only the requests to the route (i.e. /v1/syms/scan)
+	//         required: false
+	//         type: string
+	//       + name: limit
+	//         in: query
+	//         description: only the most recent requests
+	//         required: false
+	//         type: integer
+	//       + name: format
+	//         in: query
+	//         description: json (default), jsonl or csv
+	//         required: false
+	//         type: string
+	//
+	//     Responses:
+	//       200: auditResponse
+	//       400: genericError
+	//       500: genericError
+	rg.GET("/audit", func(c *gin.Context) {
+		q, err := parseQuery(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "jsonl" && format != "csv" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("invalid format '%s' (must be one of: json, jsonl, csv)", format)})
+			return
+		}
+		entries, err := l.db.GetAuditEntries(q)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		switch format {
+		case "jsonl":
+			c.Header("Content-Type", "application/x-ndjson")
+			enc := json.NewEncoder(c.Writer)
+			for _, e := range entries {
+				if err := enc.Encode(e); err != nil {
+					return
+				}
+			}
+		case "csv":
+			c.Header("Content-Type", "text/csv")
+			c.Header("Content-Disposition", `attachment; filename="ipswd_audit.csv"`)
+			w := csv.NewWriter(c.Writer)
+			w.Write(csvHeader)
+			for _, e := range entries {
+				w.Write([]string{
+					strconv.FormatUint(uint64(e.ID), 10),
+					e.At.Format(time.RFC3339Nano),
+					e.Identity,
+					e.Role,
+					e.ClientIP,
+					e.Method,
+					e.Route,
+					e.Path,
+					e.Query,
+					e.Inputs,
+					strconv.Itoa(e.Status),
+					strconv.FormatInt(e.DurationMs, 10),
+				})
+			}
+			w.Flush()
+		default:
+			if entries == nil {
+				entries = []*model.AuditEntry{}
+			}
+			c.JSON(http.StatusOK, auditResponse(entries))
+		}
+	})
+}
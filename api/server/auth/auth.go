@@ -0,0 +1,299 @@
+// Package auth provides the ipswd API-key/JWT authentication and role based authorization
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Role is a level of access to the API (each role includes the ones below it)
+type Role int
+
+const (
+	// Public routes don't require credentials
+	Public Role = iota
+	// ReadOnly can query and analyze
+	ReadOnly
+	// Operator can also extract, scan and run jobs
+	Operator
+	// Admin can also mount, rescan and read keys
+	Admin
+)
+
+var roleNames = map[Role]string{
+	Public:   "public",
+	ReadOnly: "read-only",
+	Operator: "operator",
+	Admin:    "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole parses a role name (read-only, operator or admin)
+func ParseRole(name string) (Role, error) {
+	for role, n := range roleNames {
+		if role != Public && strings.EqualFold(n, name) {
+			return role, nil
+		}
+	}
+	return Public, fmt.Errorf("invalid role '%s' (must be one of: read-only, operator, admin)", name)
+}
+
+// APIKey is a static API key
+type APIKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Role string `json:"role"`
+}
+
+// Config is the auth config (auth is disabled if there are no API keys and no JWT secret)
+type Config struct {
+	APIKeys []APIKey `json:"api_keys,omitempty" mapstructure:"api-keys"`
+	// JWTSecret is the HMAC key of the HS256 JWTs
+	JWTSecret string `json:"jwt_secret,omitempty" mapstructure:"jwt-secret"`
+}
+
+// Claims are the ipswd JWT claims
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Identity is an authenticated client
+type Identity struct {
+	Name string `json:"name"`
+	Role Role   `json:"-"`
+}
+
+var (
+	// ErrNoCredentials is returned when a request has no credentials
+	ErrNoCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials is returned for an unknown API key or an invalid JWT
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+const issuer = "ipswd"
+
+// Authenticator checks API keys and JWTs
+type Authenticator struct {
+	keys   []APIKey
+	roles  []Role
+	secret []byte
+}
+
+// NewAuthenticator creates an authenticator (it returns nil if auth is disabled)
+func NewAuthenticator(conf Config) (*Authenticator, error) {
+	if len(conf.APIKeys) == 0 && len(conf.JWTSecret) == 0 {
+		return nil, nil
+	}
+	a := &Authenticator{secret: []byte(conf.JWTSecret)}
+	for _, k := range conf.APIKeys {
+		if len(k.Key) < 16 {
+			return nil, fmt.Errorf("api key '%s' must be at least 16 characters", k.Name)
+		}
+		role, err := ParseRole(k.Role)
+		if err != nil {
+			return nil, fmt.Errorf("api key '%s': %w", k.Name, err)
+		}
+		a.keys = append(a.keys, k)
+		a.roles = append(a.roles, role)
+	}
+	return a, nil
+}
+
+// Authenticate returns the identity of an API key or JWT
+func (a *Authenticator) Authenticate(token string) (*Identity, error) {
+	if len(token) == 0 {
+		return nil, ErrNoCredentials
+	}
+	for i, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(token)) == 1 {
+			return &Identity{Name: k.Name, Role: a.roles[i]}, nil
+		}
+	}
+	if len(a.secret) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return a.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return &Identity{Name: claims.Subject, Role: role}, nil
+}
+
+// NewToken signs a JWT for the subject with the role that expires after ttl
+func NewToken(secret, subject string, role Role, ttl time.Duration) (string, error) {
+	if len(secret) == 0 {
+		return "", fmt.Errorf("jwt secret is not set")
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("ttl must be positive")
+	}
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Role: role.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}).SignedString([]byte(secret))
+}
+
+// rule is the role required by the routes under a path prefix (for a method, or all methods if empty)
+type rule struct {
+	method string
+	prefix string
+	role   Role
+}
+
+// rules are matched in order (routes that don't match any rule require ReadOnly)
+var rules = []rule{
+	{"", "/_ping", Public},
+	{"", "/version", Public},
+	{"", "/healthz", Public},
+	{"", "/readyz", Public},
+	{"", "/openapi.json", Public},
+	{"", "/ui/", Public}, // the UI's static files (its API calls send the credentials)
+	{"", "/aea/", Admin},
+	{"", "/mount/", Admin},
+	{"", "/unmount", Admin},
+	{http.MethodDelete, "/cache", Admin},
+	{"", "/audit", Admin},
+	{http.MethodPut, "/syms/rescan", Admin},
+	{http.MethodPost, "/syms/scan", Operator},
+	{http.MethodPost, "/syms/queue", Operator},
+	{http.MethodDelete, "/syms/queue", Operator},
+	{"", "/extract/", Operator},
+	{http.MethodPost, "/dsc/split", Operator},
+	{"", "/idev/", Operator},
+	{http.MethodPost, "/jobs/", Operator},
+	{http.MethodDelete, "/jobs/", Operator},
+	{http.MethodDelete, "/artifacts/", Operator},
+	{http.MethodPost, "/pipelines/", Operator},
+}
+
+// RequiredRole returns the role required by the route (a gin full path with or without the API version prefix)
+func RequiredRole(method, route string) Role {
+	route = strings.TrimPrefix(route, "/v"+api.DefaultVersion)
+	for _, r := range rules {
+		if (r.method == "" || r.method == method) && strings.HasPrefix(route, r.prefix) {
+			return r.role
+		}
+	}
+	return ReadOnly
+}
+
+// queryTokenRoutes are the event stream routes that accept the access_token query param
+// (EventSource and WebSocket clients can't set headers)
+var queryTokenRoutes = []string{
+	"/jobs/:id/events",
+}
+
+// token returns the request's credentials from the Authorization (Bearer) or X-API-Key headers,
+// or the access_token query param of the event stream routes
+func token(c *gin.Context) string {
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	if key := c.GetHeader("X-API-Key"); len(key) > 0 {
+		return key
+	}
+	if slices.Contains(queryTokenRoutes, strings.TrimPrefix(c.FullPath(), "/v"+api.DefaultVersion)) {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+const identityKey = "ipswd.identity"
+
+// IdentityFrom returns the request's authenticated identity (ok is false if auth is disabled or the route is public)
+func IdentityFrom(c *gin.Context) (id *Identity, ok bool) {
+	v, ok := c.Get(identityKey)
+	if !ok {
+		return nil, false
+	}
+	return v.(*Identity), true
+}
+
+// Middleware enforces the route roles (it lets everything through if a is nil)
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Next()
+			return
+		}
+		required := RequiredRole(c.Request.Method, c.FullPath())
+		if required == Public {
+			c.Next()
+			return
+		}
+		id, err := a.Authenticate(token(c))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="ipswd"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.Set(identityKey, id) // the audit log records who was forbidden
+		if id.Role < required {
+			c.AbortWithStatusJSON(http.StatusForbidden, types.GenericError{
+				Error: fmt.Sprintf("'%s' requires the %s role (%s has %s)", c.FullPath(), required, id.Name, id.Role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// swagger:response whoamiResponse
+type whoamiResponse struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role"`
+}
+
+// AddRoutes adds the auth routes to the router
+func AddRoutes(rg *gin.RouterGroup) {
+	// swagger:route GET /auth/whoami Auth getAuthWhoami
+	//
+	// Whoami
+	//
+	// This will return the name and role of the request's credentials (admin if auth is disabled).
+	//
+	//     Responses:
+	//       200: whoamiResponse
+	//       401: genericError
+	rg.GET("/auth/whoami", func(c *gin.Context) {
+		id, ok := IdentityFrom(c)
+		if !ok {
+			c.IndentedJSON(http.StatusOK, whoamiResponse{Role: Admin.String()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, whoamiResponse{Name: id.Name, Role: id.Role.String()})
+	})
+}
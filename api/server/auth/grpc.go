@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// all the gRPC API methods are queries
+const grpcRole = ReadOnly
+
+type identityCtxKey struct{}
+
+// IdentityFromContext returns the gRPC call's authenticated identity (ok is false if auth is disabled)
+func IdentityFromContext(ctx context.Context) (id *Identity, ok bool) {
+	id, ok = ctx.Value(identityCtxKey{}).(*Identity)
+	return id, ok
+}
+
+// identityStream is a server stream whose context has the call's identity
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize returns ctx with the call's identity
+func (a *Authenticator) authorize(ctx context.Context) (context.Context, error) {
+	if a == nil {
+		return ctx, nil
+	}
+	var tok string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("authorization"); len(vals) > 0 {
+			tok, _ = strings.CutPrefix(vals[0], "Bearer ")
+		} else if vals := md.Get("x-api-key"); len(vals) > 0 {
+			tok = vals[0]
+		}
+	}
+	id, err := a.Authenticate(strings.TrimSpace(tok))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if id.Role < grpcRole {
+		return nil, status.Errorf(codes.PermissionDenied, "requires the %s role", grpcRole)
+	}
+	return context.WithValue(ctx, identityCtxKey{}, id), nil
+}
+
+// ServerOptions returns the gRPC server options that enforce auth (none if a is nil)
+func (a *Authenticator) ServerOptions() []grpc.ServerOption {
+	if a == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := a.authorize(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := a.authorize(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+		}),
+	}
+}
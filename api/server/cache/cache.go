@@ -0,0 +1,251 @@
+// Package cache caches the responses of the ipswd analysis routes so identical requests (same route, params,
+// input file digests and ipsw version) return the stored result instead of re-running multi-minute scans
+//
+// The input files (the server paths in the query, form or JSON body and the uploaded files) are keyed by
+// the SHA256 of their contents, so the same file at another path (i.e. a CI runner's temp folder) is a hit
+// (and a response that echoes its input paths echoes the paths of the request that was cached).
+// Concurrent identical requests are deduplicated: only the first one runs and the others get its result.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	// DefaultMaxMemory is the size of the in-memory cache if the config doesn't set one
+	DefaultMaxMemory = 256 << 20
+	// DefaultTTL is how long the responses are cached if the config doesn't set a TTL
+	DefaultTTL = 24 * time.Hour
+)
+
+// Config is the response cache config
+type Config struct {
+	// Disabled disables the response cache
+	Disabled bool `json:"disabled"`
+	// Dir persists the cached responses in the folder (they are only kept in memory if empty)
+	Dir string `json:"dir"`
+	// MaxMemory is the size (in bytes) of the in-memory cache (responses larger than 1/8th of it are not cached)
+	MaxMemory int64 `json:"max_memory" mapstructure:"max-memory"`
+	// TTL is how long the responses are cached
+	TTL time.Duration `json:"ttl"`
+}
+
+// entry is a cached response
+type entry struct {
+	Key         string
+	Status      int
+	ContentType string
+	Body        []byte
+	Created     time.Time
+}
+
+func (e *entry) expired(ttl time.Duration) bool {
+	return time.Since(e.Created) > ttl
+}
+
+// Stats are the cache's statistics
+type Stats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Shared  int64 `json:"shared"`
+}
+
+// Cache is an LRU cache of responses (optionally persisted to disk)
+type Cache struct {
+	conf Config
+
+	mu      sync.Mutex
+	lru     *list.List // of *entry (most recently used first)
+	entries map[string]*list.Element
+	bytes   int64
+	stats   Stats
+
+	inflight map[string]chan struct{}
+}
+
+// New creates a response cache (it returns nil if the cache is disabled)
+func New(conf Config) (*Cache, error) {
+	if conf.Disabled {
+		return nil, nil
+	}
+	if conf.MaxMemory == 0 {
+		conf.MaxMemory = DefaultMaxMemory
+	}
+	if conf.TTL == 0 {
+		conf.TTL = DefaultTTL
+	}
+	if len(conf.Dir) > 0 {
+		if err := os.MkdirAll(conf.Dir, 0o750); err != nil {
+			return nil, fmt.Errorf("cache: failed to create folder: %w", err)
+		}
+	}
+	return &Cache{
+		conf:     conf,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		inflight: make(map[string]chan struct{}),
+	}, nil
+}
+
+// maxEntrySize is the size of the largest response that is cached
+func (c *Cache) maxEntrySize() int {
+	return int(c.conf.MaxMemory / 8)
+}
+
+func (c *Cache) file(key string) string {
+	return filepath.Join(c.conf.Dir, key[:2], key)
+}
+
+// get returns the cached response for key (from memory or disk)
+func (c *Cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		if !e.expired(c.conf.TTL) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			return e, true
+		}
+		c.remove(el)
+	}
+	c.mu.Unlock()
+	if len(c.conf.Dir) == 0 {
+		return nil, false
+	}
+	f, err := os.Open(c.file(key))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	var e entry
+	if err := gob.NewDecoder(f).Decode(&e); err != nil {
+		log.WithError(err).WithField("key", key).Warn("cache: failed to read cached response")
+		return nil, false
+	}
+	if e.expired(c.conf.TTL) {
+		os.Remove(c.file(key))
+		return nil, false
+	}
+	c.mu.Lock()
+	c.add(&e)
+	c.mu.Unlock()
+	return &e, true
+}
+
+// put caches the response
+func (c *Cache) put(e *entry) {
+	c.mu.Lock()
+	if el, ok := c.entries[e.Key]; ok {
+		c.remove(el)
+	}
+	c.add(e)
+	c.mu.Unlock()
+	if len(c.conf.Dir) == 0 {
+		return
+	}
+	if err := c.persist(e); err != nil {
+		log.WithError(err).WithField("key", e.Key).Warn("cache: failed to persist response")
+	}
+}
+
+func (c *Cache) persist(e *entry) error {
+	path := c.file(e.Key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// add adds the entry to the in-memory LRU (evicting the least recently used entries); c.mu must be held
+func (c *Cache) add(e *entry) {
+	c.entries[e.Key] = c.lru.PushFront(e)
+	c.bytes += int64(len(e.Body))
+	for c.bytes > c.conf.MaxMemory && c.lru.Len() > 1 {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes the element from the in-memory LRU; c.mu must be held
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.Key)
+	c.bytes -= int64(len(e.Body))
+}
+
+// Stats returns the cache's statistics
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Entries = c.lru.Len()
+	st.Bytes = c.bytes
+	return st
+}
+
+// Purge removes the cached responses (from memory and disk)
+func (c *Cache) Purge() error {
+	c.mu.Lock()
+	c.lru.Init()
+	clear(c.entries)
+	c.bytes = 0
+	c.mu.Unlock()
+	if len(c.conf.Dir) == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(c.conf.Dir)
+	if err != nil {
+		return fmt.Errorf("cache: failed to read folder: %w", err)
+	}
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() && len(e.Name()) == 2 {
+			errs = append(errs, os.RemoveAll(filepath.Join(c.conf.Dir, e.Name())))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Prune removes the expired responses from disk (the in-memory ones expire when they are read)
+func (c *Cache) Prune() (int, error) {
+	if len(c.conf.Dir) == 0 {
+		return 0, nil
+	}
+	deleted := 0
+	err := filepath.WalkDir(c.conf.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if time.Since(info.ModTime()) > c.conf.TTL {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the response header set to the cache result of the request (hit, miss, shared or bypass)
+const Header = "X-Ipsw-Cache"
+
+// routes are the prefixes of the cached routes (the analysis routes that only read their inputs)
+var routes = []string{"/diff/", "/dsc/", "/ent", "/img4/", "/info/", "/ipsw/fs/", "/kernel/", "/macho/"}
+
+// uncached are the routes under the cached prefixes that write files or fetch remote inputs
+var uncached = []string{"/dsc/split", "/info/ipsw/remote", "/info/ota/remote"}
+
+// Cached returns true if the responses of the route (a gin full path with or without the API version prefix) are cached
+func Cached(method, route string) bool {
+	if method != http.MethodGet && method != http.MethodPost {
+		return false
+	}
+	route = strings.TrimPrefix(route, "/v"+api.DefaultVersion)
+	if slices.Contains(uncached, route) {
+		return false
+	}
+	return slices.ContainsFunc(routes, func(prefix string) bool {
+		return strings.HasPrefix(route, prefix)
+	})
+}
+
+// Middleware serves the cached responses of the cached routes (it lets everything through if c is nil)
+//
+// Requests with a 'Cache-Control: no-cache' header bypass the cache (their response is still cached).
+func (c *Cache) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if c == nil || !Cached(ctx.Request.Method, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+		key, err := c.key(ctx)
+		if err != nil {
+			log.WithError(err).Debug("cache: failed to compute request key")
+			ctx.Next()
+			return
+		}
+		result := "miss"
+		if strings.Contains(ctx.GetHeader("Cache-Control"), "no-cache") {
+			result = "bypass"
+		} else {
+			if e, ok := c.get(key); ok {
+				c.serve(ctx, e, "hit")
+				return
+			}
+			// wait for an identical request that is already running
+			if done, leader := c.lead(key); !leader {
+				select {
+				case <-done:
+				case <-ctx.Request.Context().Done():
+					ctx.Abort()
+					return
+				}
+				if e, ok := c.get(key); ok {
+					c.serve(ctx, e, "shared")
+					return
+				}
+			} else {
+				defer c.finish(key)
+			}
+		}
+		c.count(result)
+
+		rec := &recorder{ResponseWriter: ctx.Writer, max: c.maxEntrySize()}
+		ctx.Writer = rec
+		ctx.Header(Header, result)
+		ctx.Next()
+		ctx.Writer = rec.ResponseWriter
+
+		if rec.Status() == http.StatusOK && !rec.over && len(ctx.Errors) == 0 {
+			c.put(&entry{
+				Key:         key,
+				Status:      rec.Status(),
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.buf.Bytes(),
+				Created:     time.Now(),
+			})
+		}
+	}
+}
+
+// lead registers the request as the one running key (leader is false if another request already is)
+func (c *Cache) lead(key string) (done chan struct{}, leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if done, ok := c.inflight[key]; ok {
+		return done, false
+	}
+	done = make(chan struct{})
+	c.inflight[key] = done
+	return done, true
+}
+
+// finish wakes up the requests waiting for key
+func (c *Cache) finish(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if done, ok := c.inflight[key]; ok {
+		close(done)
+		delete(c.inflight, key)
+	}
+}
+
+func (c *Cache) count(result string) {
+	c.mu.Lock()
+	switch result {
+	case "hit":
+		c.stats.Hits++
+	case "shared":
+		c.stats.Shared++
+	case "miss":
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+	metrics.CacheRequests.WithLabelValues(result).Inc()
+}
+
+func (c *Cache) serve(ctx *gin.Context, e *entry, result string) {
+	c.count(result)
+	ctx.Header(Header, result)
+	ctx.Data(e.Status, e.ContentType, e.Body)
+	ctx.Abort()
+}
+
+// key is the SHA256 of the request's route, params and input file digests (and the daemon's version)
+func (c *Cache) key(ctx *gin.Context) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", types.BuildVersion, api.DefaultVersion, ctx.Request.Method, ctx.FullPath())
+	for _, p := range ctx.Params {
+		fmt.Fprintf(h, "param:%s=%s\x00", p.Key, p.Value)
+	}
+	query := ctx.Request.URL.Query()
+	query.Del("access_token")
+	if err := c.hashValues(h, "query", query); err != nil {
+		return "", err
+	}
+	if ctx.Request.Body == nil || ctx.Request.Method != http.MethodPost {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	switch ctx.ContentType() {
+	case gin.MIMEMultipartPOSTForm:
+		form, err := ctx.MultipartForm() // the form is kept so the handler can still read it
+		if err != nil {
+			return "", err
+		}
+		if err := c.hashValues(h, "form", form.Value); err != nil {
+			return "", err
+		}
+		for _, name := range sortedKeys(form.File) {
+			for _, fh := range form.File[name] {
+				f, err := fh.Open()
+				if err != nil {
+					return "", err
+				}
+				sum, err := utils.Sha256Reader(f)
+				f.Close()
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(h, "upload:%s=%s\x00", name, sum)
+			}
+		}
+	default:
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var v any
+		if json.Unmarshal(body, &v) == nil {
+			// replace the paths of the input files with their digests
+			if v, err = c.hashPaths(v); err != nil {
+				return "", err
+			}
+			if body, err = json.Marshal(v); err != nil {
+				return "", err
+			}
+		}
+		fmt.Fprintf(h, "body:%s\x00", body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashValues hashes the query/form values (using the digests of the values that are paths to files)
+func (c *Cache) hashValues(w io.Writer, kind string, values map[string][]string) error {
+	for _, name := range sortedKeys(values) {
+		for _, v := range values[name] {
+			sum, ok, err := utils.FileSha256(v)
+			if err != nil {
+				return err
+			}
+			if ok {
+				v = "sha256:" + sum
+			}
+			fmt.Fprintf(w, "%s:%s=%s\x00", kind, name, v)
+		}
+	}
+	return nil
+}
+
+// hashPaths replaces the strings of the JSON value that are paths to files with their digests
+func (c *Cache) hashPaths(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		sum, ok, err := utils.FileSha256(v)
+		if err != nil || !ok {
+			return v, err
+		}
+		return "sha256:" + sum, nil
+	case map[string]any:
+		for k, e := range v {
+			e, err := c.hashPaths(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = e
+		}
+	case []any:
+		for i, e := range v {
+			e, err := c.hashPaths(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = e
+		}
+	}
+	return v, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// recorder records the response body (up to max bytes) while writing it
+type recorder struct {
+	gin.ResponseWriter
+	buf  bytes.Buffer
+	max  int
+	over bool
+}
+
+func (r *recorder) record(n int, b []byte) {
+	if r.over {
+		return
+	}
+	if r.buf.Len()+n > r.max {
+		r.over = true
+		r.buf = bytes.Buffer{}
+		return
+	}
+	r.buf.Write(b[:n])
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.record(n, b)
+	return n, err
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	n, err := r.ResponseWriter.WriteString(s)
+	r.record(n, []byte(s))
+	return n, err
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"net/http"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response cacheStatsResponse
+type cacheStatsResponse Stats
+
+// AddRoutes adds the cache routes to the router
+func AddRoutes(rg *gin.RouterGroup, c *Cache) {
+	// swagger:route GET /cache Cache getCache
+	//
+	// Stats
+	//
+	// Get the response cache's statistics.
+	//
+	//     Responses:
+	//       200: cacheStatsResponse
+	rg.GET("/cache", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, cacheStatsResponse(c.Stats()))
+	})
+	// swagger:route DELETE /cache Cache deleteCache
+	//
+	// Purge
+	//
+	// Remove the cached responses.
+	//
+	//     Responses:
+	//       200: cacheStatsResponse
+	//       500: genericError
+	rg.DELETE("/cache", func(ctx *gin.Context) {
+		if err := c.Purge(); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, cacheStatsResponse(c.Stats()))
+	})
+}
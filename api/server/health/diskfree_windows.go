@@ -0,0 +1,18 @@
+//go:build windows
+
+package health
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the free disk space (in bytes) of the folder's disk
+func freeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
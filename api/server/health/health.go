@@ -0,0 +1,227 @@
+// Package health provides the daemon's liveness (/healthz) and readiness (/readyz) probes
+//
+// The readiness probe checks the daemon's dependencies (the database, the storage backend and the free
+// disk space of its working folders) and reports why each failed check failed, so orchestrators only send
+// it traffic when it can serve it.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/dustin/go-humanize"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMinFreeDisk is the default minimum free disk space
+	DefaultMinFreeDisk = "1GB"
+	// checkTimeout is how long a dependency check can take before it fails
+	checkTimeout = 5 * time.Second
+)
+
+// Config is the health check config
+type Config struct {
+	// MinFreeDisk is the free disk space of the checked folders below which ipswd is not ready, i.e. 10GB
+	// (defaults to 1GB)
+	MinFreeDisk string `json:"min_free_disk" mapstructure:"min-free-disk"`
+	// Paths are more folders whose disk's free space is checked (the temp, cache and local storage folders
+	// are always checked)
+	Paths []string `json:"paths"`
+}
+
+// Status is the status of a check (or of all of them)
+type Status string
+
+const (
+	OK   Status = "ok"
+	Fail Status = "fail"
+)
+
+// The reasons a check fails
+const (
+	ReasonDBUnreachable      = "db_unreachable"
+	ReasonStorageUnreachable = "storage_unreachable"
+	ReasonLowDiskSpace       = "low_disk_space"
+	ReasonDiskCheckFailed    = "disk_check_failed"
+)
+
+// Check is the result of a dependency check
+type Check struct {
+	Name     string         `json:"name"`
+	Status   Status         `json:"status"`
+	Reason   string         `json:"reason,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+	Duration int64          `json:"duration_ms"`
+}
+
+// Report is the result of all the checks
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []*Check `json:"checks,omitempty"`
+}
+
+// Checker checks the daemon's dependencies
+type Checker struct {
+	db      db.Database
+	store   storage.Backend
+	paths   []string
+	minFree uint64
+	started time.Time
+}
+
+// New creates a checker of the database and storage backend (either can be nil) and of the free disk space
+// of the configured folders and the extra paths
+func New(conf Config, d db.Database, store storage.Backend, paths ...string) (*Checker, error) {
+	if len(conf.MinFreeDisk) == 0 {
+		conf.MinFreeDisk = DefaultMinFreeDisk
+	}
+	minFree, err := humanize.ParseBytes(conf.MinFreeDisk)
+	if err != nil {
+		return nil, fmt.Errorf("health: invalid min-free-disk '%s': %v", conf.MinFreeDisk, err)
+	}
+	c := &Checker{db: d, store: store, minFree: minFree, started: time.Now()}
+	for _, p := range append(paths, conf.Paths...) {
+		if len(p) > 0 && !slices.Contains(c.paths, p) {
+			c.paths = append(c.paths, p)
+		}
+	}
+	return c, nil
+}
+
+func (c *Checker) checkDB(ctx context.Context) *Check {
+	ch := &Check{Name: "database", Status: OK}
+	if err := c.db.Ping(ctx); err != nil {
+		ch.Status, ch.Reason, ch.Error = Fail, ReasonDBUnreachable, err.Error()
+	}
+	return ch
+}
+
+func (c *Checker) checkStorage(ctx context.Context) *Check {
+	ch := &Check{Name: "storage", Status: OK}
+	// listing a prefix no artifact has checks the backend is reachable with the credentials
+	if _, err := c.store.List(ctx, ".ipswd-readyz/"); err != nil {
+		ch.Status, ch.Reason, ch.Error = Fail, ReasonStorageUnreachable, err.Error()
+	}
+	return ch
+}
+
+func (c *Checker) checkDisk(path string) *Check {
+	ch := &Check{Name: "disk:" + path, Status: OK}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ch // it is created when needed
+	}
+	free, err := freeSpace(path)
+	if err != nil {
+		ch.Status, ch.Reason, ch.Error = Fail, ReasonDiskCheckFailed, err.Error()
+		return ch
+	}
+	ch.Details = map[string]any{"free_bytes": free, "min_free_bytes": c.minFree}
+	if free < c.minFree {
+		ch.Status, ch.Reason = Fail, ReasonLowDiskSpace
+		ch.Error = fmt.Sprintf("%s free (less than %s)", humanize.Bytes(free), humanize.Bytes(c.minFree))
+	}
+	return ch
+}
+
+// Ready runs the dependency checks (concurrently)
+func (c *Checker) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var checks []func() *Check
+	if c.db != nil {
+		checks = append(checks, func() *Check { return c.checkDB(ctx) })
+	}
+	if c.store != nil {
+		checks = append(checks, func() *Check { return c.checkStorage(ctx) })
+	}
+	for _, p := range c.paths {
+		checks = append(checks, func() *Check { return c.checkDisk(p) })
+	}
+
+	rep := Report{Status: OK, Checks: make([]*Check, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			ch := check()
+			ch.Duration = time.Since(start).Milliseconds()
+			rep.Checks[i] = ch
+		}()
+	}
+	wg.Wait()
+	for _, ch := range rep.Checks {
+		if ch.Status != OK {
+			rep.Status = Fail
+		}
+	}
+	return rep
+}
+
+// swagger:response healthzResponse
+type healthzResponse struct {
+	Status     Status `json:"status"`
+	APIVersion string `json:"api_version"`
+	Version    string `json:"version,omitempty"`
+	Uptime     string `json:"uptime"`
+}
+
+// swagger:response readyzResponse
+type readyzResponse Report
+
+// AddRoutes adds the health check routes to the router (outside the versioned API)
+func AddRoutes(r gin.IRouter, c *Checker) {
+	noCache := func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	}
+	// swagger:route GET /healthz Daemon getHealthz
+	//
+	// Liveness
+	//
+	// This will return 200 while the daemon is serving requests (it doesn't check the dependencies,
+	// so orchestrators don't restart ipswd when they are down).
+	//
+	//     Responses:
+	//       200: healthzResponse
+	r.GET("/healthz", func(ctx *gin.Context) {
+		noCache(ctx)
+		ctx.JSON(http.StatusOK, healthzResponse{
+			Status:     OK,
+			APIVersion: api.DefaultVersion,
+			Version:    types.BuildVersion,
+			Uptime:     time.Since(c.started).Round(time.Second).String(),
+		})
+	})
+	// swagger:route GET /readyz Daemon getReadyz
+	//
+	// Readiness
+	//
+	// This will return 200 if the daemon's dependencies (the database, the storage backend and the free disk
+	// space of its folders) are ok and 503 with the reasons of the failed checks otherwise.
+	//
+	//     Responses:
+	//       200: readyzResponse
+	//       503: readyzResponse
+	r.GET("/readyz", func(ctx *gin.Context) {
+		noCache(ctx)
+		rep := c.Ready(ctx.Request.Context())
+		if rep.Status != OK {
+			ctx.JSON(http.StatusServiceUnavailable, readyzResponse(rep))
+			return
+		}
+		ctx.JSON(http.StatusOK, readyzResponse(rep))
+	})
+}
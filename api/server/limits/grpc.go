@@ -0,0 +1,62 @@
+package limits
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcClient returns the name the gRPC call's client is limited by: its API key (or JWT subject) name,
+// or its IP if auth is disabled
+func grpcClient(ctx context.Context) string {
+	if id, ok := auth.IdentityFromContext(ctx); ok {
+		return id.Name
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:unknown"
+}
+
+func (l *Limiter) allowCall(ctx context.Context) error {
+	client := grpcClient(ctx)
+	if allowed, _, wait := l.allow(client); !allowed {
+		metrics.RateLimited.WithLabelValues("rate").Inc()
+		return status.Errorf(codes.ResourceExhausted, "%s exceeded its rate limit of %g requests per second (retry in %s)",
+			client, l.limit(client).rate, wait.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// ServerOptions returns the gRPC server options that enforce the rate limits (none if l is nil)
+//
+// They must come after the auth server options so the calls are limited by their API key.
+func (l *Limiter) ServerOptions() []grpc.ServerOption {
+	if l == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := l.allowCall(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := l.allowCall(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
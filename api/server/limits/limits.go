@@ -0,0 +1,389 @@
+// Package limits enforces the request rate limits and the download/storage quotas of the API clients
+//
+// The clients are identified by their API key (or JWT subject), or by their IP if auth is disabled. The
+// download usage is tracked in memory per daemon, so it restarts when the daemon does, while the storage
+// usage is the size of the stored artifacts of the client's jobs.
+package limits
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/dustin/go-humanize"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPeriod is the default quota period
+const DefaultPeriod = 24 * time.Hour
+
+// Quota is a kind of quota
+type Quota string
+
+const (
+	// Download is the bytes downloaded from Apple's CDN
+	Download Quota = "download"
+	// Storage is the bytes of the client's job artifacts in the storage (deleting them frees the quota)
+	Storage Quota = "storage"
+)
+
+// Limit is the rate limit and quotas of a client
+type Limit struct {
+	// Rate is the requests per second (unlimited if 0)
+	Rate float64 `json:"rate"`
+	// Burst is the requests allowed at once (defaults to the rate rounded up)
+	Burst int `json:"burst"`
+	// Download is the bytes downloaded from Apple per period, i.e. 50GB (unlimited if empty)
+	Download string `json:"download"`
+	// Storage is the bytes of job artifacts stored, i.e. 10GB (unlimited if empty)
+	Storage string `json:"storage"`
+}
+
+// KeyLimit is the limit of an API key (or JWT subject)
+type KeyLimit struct {
+	Name  string `json:"name"`
+	Limit `mapstructure:",squash"`
+}
+
+// Config is the rate limit and quota config (disabled if there are no limits)
+type Config struct {
+	// Default is the limit of the clients without a key limit
+	Default Limit `json:"default"`
+	// Keys are the limits of the API keys by name
+	Keys []KeyLimit `json:"keys"`
+	// Period is how often the download quota resets (defaults to 24h)
+	Period time.Duration `json:"period"`
+}
+
+// limit is a parsed Limit
+type limit struct {
+	rate   float64
+	burst  float64
+	quotas map[Quota]uint64
+}
+
+func parseLimit(l Limit) (*limit, error) {
+	if l.Rate < 0 || l.Burst < 0 {
+		return nil, fmt.Errorf("rate and burst must not be negative")
+	}
+	pl := &limit{rate: l.Rate, burst: float64(l.Burst), quotas: make(map[Quota]uint64)}
+	if pl.burst == 0 {
+		pl.burst = math.Max(1, math.Ceil(l.Rate))
+	}
+	for q, v := range map[Quota]string{Download: l.Download, Storage: l.Storage} {
+		if len(v) == 0 {
+			continue
+		}
+		n, err := humanize.ParseBytes(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quota '%s': %v", q, v, err)
+		}
+		pl.quotas[q] = n
+	}
+	return pl, nil
+}
+
+func (l *limit) unlimited() bool {
+	return l.rate == 0 && len(l.quotas) == 0
+}
+
+// bucket is a client's request token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// usage is a client's quota usage in the current period
+type usage struct {
+	start time.Time
+	used  map[Quota]uint64
+}
+
+// Limiter enforces the rate limits and quotas
+type Limiter struct {
+	period time.Duration
+	def    *limit
+	keys   map[string]*limit
+
+	// jobs and store size the stored artifacts of the clients' jobs (see SetStorage)
+	jobs  *jobs.Manager
+	store storage.Backend
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	usages  map[string]*usage
+	pending map[string]uint64 // the artifact bytes being stored per client
+	pruned  time.Time         // when the expired usages were last removed
+}
+
+// New creates a limiter (it returns nil if there are no limits)
+func New(conf Config) (*Limiter, error) {
+	l := &Limiter{
+		period:  conf.Period,
+		keys:    make(map[string]*limit),
+		buckets: make(map[string]*bucket),
+		usages:  make(map[string]*usage),
+		pending: make(map[string]uint64),
+	}
+	if l.period == 0 {
+		l.period = DefaultPeriod
+	}
+	var err error
+	if l.def, err = parseLimit(conf.Default); err != nil {
+		return nil, fmt.Errorf("limits: default: %v", err)
+	}
+	unlimited := l.def.unlimited()
+	for _, k := range conf.Keys {
+		if len(k.Name) == 0 {
+			return nil, fmt.Errorf("limits: key limits require a name")
+		}
+		if l.keys[k.Name], err = parseLimit(k.Limit); err != nil {
+			return nil, fmt.Errorf("limits: key '%s': %v", k.Name, err)
+		}
+		unlimited = unlimited && l.keys[k.Name].unlimited()
+	}
+	if unlimited {
+		return nil, nil
+	}
+	return l, nil
+}
+
+// Client returns the name the request's client is limited by: its API key (or JWT subject) name,
+// or its IP if auth is disabled (which is only read from X-Forwarded-For for the daemon's trusted proxies)
+func Client(c *gin.Context) string {
+	if id, ok := auth.IdentityFrom(c); ok {
+		return id.Name
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (l *Limiter) limit(client string) *limit {
+	if kl, ok := l.keys[client]; ok {
+		return kl
+	}
+	return l.def
+}
+
+// allow takes a token from the client's bucket (it returns how long to wait for one if there is none)
+func (l *Limiter) allow(client string) (ok bool, remaining int, wait time.Duration) {
+	lim := l.limit(client)
+	if lim.rate == 0 {
+		return true, -1, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) > 10000 {
+			l.pruneBuckets(now)
+		}
+		b = &bucket{tokens: lim.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(lim.burst, b.tokens+now.Sub(b.last).Seconds()*lim.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) / lim.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// pruneBuckets removes the buckets that have refilled (the caller must hold the lock)
+func (l *Limiter) pruneBuckets(now time.Time) {
+	for client, b := range l.buckets {
+		lim := l.limit(client)
+		if b.tokens+now.Sub(b.last).Seconds()*lim.rate >= lim.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// usage returns the client's usage in the current period (the caller must hold the lock)
+func (l *Limiter) usage(client string) *usage {
+	now := time.Now()
+	if now.Sub(l.pruned) >= l.period || len(l.usages) > 10000 {
+		l.pruneUsages(now)
+	}
+	u, ok := l.usages[client]
+	if !ok || now.Sub(u.start) >= l.period {
+		u = &usage{start: now, used: make(map[Quota]uint64)}
+		l.usages[client] = u
+	}
+	return u
+}
+
+// pruneUsages removes the usages of the previous periods (the caller must hold the lock)
+func (l *Limiter) pruneUsages(now time.Time) {
+	for client, u := range l.usages {
+		if now.Sub(u.start) >= l.period {
+			delete(l.usages, client)
+		}
+	}
+	l.pruned = now
+}
+
+// QuotaError is returned when a client exceeds one of its quotas
+type QuotaError struct {
+	Client string
+	Quota  Quota
+	Limit  uint64
+	Reset  time.Time
+}
+
+func (e *QuotaError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s exceeded its %s quota of %s (delete some of its artifacts to free it)",
+			e.Client, e.Quota, humanize.Bytes(e.Limit))
+	}
+	return fmt.Sprintf("%s exceeded its %s quota of %s (it resets at %s)",
+		e.Client, e.Quota, humanize.Bytes(e.Limit), e.Reset.Format(time.RFC3339))
+}
+
+// Check returns a QuotaError if the client has no quota left (it is nil safe and the daemon's own jobs,
+// which have no client, are unlimited)
+func (l *Limiter) Check(ctx context.Context, client string, q Quota) error {
+	if q == Storage {
+		return l.reserveStorage(ctx, client, 0)
+	}
+	return l.Reserve(client, q, 0)
+}
+
+// Reserve adds n bytes to the client's download quota usage unless that would exceed its quota (it is nil safe)
+func (l *Limiter) Reserve(client string, q Quota, n uint64) error {
+	if l == nil || len(client) == 0 || q == Storage {
+		return nil
+	}
+	max, ok := l.limit(client).quotas[q]
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.usage(client)
+	if u.used[q]+n > max || (n == 0 && u.used[q] >= max) {
+		return &QuotaError{Client: client, Quota: q, Limit: max, Reset: u.start.Add(l.period)}
+	}
+	u.used[q] += n
+	return nil
+}
+
+// QuotaUsage is a client's usage of one of its quotas
+type QuotaUsage struct {
+	Used  uint64 `json:"used"`
+	Limit uint64 `json:"limit"`
+	// Reset is when the quota resets (the storage quota doesn't)
+	Reset *time.Time `json:"reset,omitempty"`
+}
+
+// Usage is a client's limits and quota usage
+type Usage struct {
+	Client string                `json:"client"`
+	Rate   float64               `json:"rate,omitempty"`
+	Burst  int                   `json:"burst,omitempty"`
+	Quotas map[Quota]*QuotaUsage `json:"quotas,omitempty"`
+}
+
+// Usage returns the client's limits and quota usage
+func (l *Limiter) Usage(ctx context.Context, client string) (Usage, error) {
+	lim := l.limit(client)
+	u := Usage{Client: client, Rate: lim.rate, Quotas: make(map[Quota]*QuotaUsage)}
+	if lim.rate > 0 {
+		u.Burst = int(lim.burst)
+	}
+	var stored uint64
+	if _, ok := lim.quotas[Storage]; ok {
+		var err error
+		if stored, err = l.stored(ctx, client); err != nil {
+			return u, err
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cu := l.usage(client)
+	for q, max := range lim.quotas {
+		if q == Storage {
+			u.Quotas[q] = &QuotaUsage{Used: stored + l.pending[client], Limit: max}
+			continue
+		}
+		reset := cu.start.Add(l.period)
+		u.Quotas[q] = &QuotaUsage{Used: cu.used[q], Limit: max, Reset: &reset}
+	}
+	return u, nil
+}
+
+// quotaRoutes are the routes that are rejected if the client has no quota left
+var quotaRoutes = []struct {
+	method string
+	prefix string
+	quota  Quota
+}{
+	{http.MethodPost, "/jobs/download/", Download},
+	{http.MethodPost, "/jobs/extract/", Storage},
+	{http.MethodPost, "/jobs/dsc/split", Storage},
+	{http.MethodPost, "/jobs/diff/", Storage},
+	{http.MethodPost, "/jobs/ent/", Storage},
+	{http.MethodPost, "/syms/queue", Download},
+	{http.MethodPost, "/extract/", Storage},
+	{http.MethodPost, "/dsc/split", Storage},
+	{http.MethodPost, "/diff/", Storage},
+}
+
+// Middleware rate limits the requests and rejects the requests of the clients that exceeded the quota
+// the route uses (it lets everything through if l is nil)
+//
+// The public routes (i.e. the health checks) and the routes outside the versioned API are not limited.
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, ok := strings.CutPrefix(c.FullPath(), "/v"+api.DefaultVersion)
+		if l == nil || !ok || auth.RequiredRole(c.Request.Method, route) == auth.Public {
+			c.Next()
+			return
+		}
+		client := Client(c)
+		allowed, remaining, wait := l.allow(client)
+		if remaining >= 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(int(l.limit(client).burst)))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		}
+		if !allowed {
+			metrics.RateLimited.WithLabelValues("rate").Inc()
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, types.GenericError{
+				Error: fmt.Sprintf("%s exceeded its rate limit of %g requests per second", client, l.limit(client).rate),
+			})
+			return
+		}
+		for _, qr := range quotaRoutes {
+			if c.Request.Method != qr.method || !strings.HasPrefix(route, qr.prefix) {
+				continue
+			}
+			if err := l.Check(c.Request.Context(), client, qr.quota); err != nil {
+				qe, ok := err.(*QuotaError)
+				if !ok {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+					return
+				}
+				metrics.RateLimited.WithLabelValues(string(qr.quota)).Inc()
+				if !qe.Reset.IsZero() {
+					c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(qe.Reset).Seconds()))))
+				}
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, types.GenericError{Error: err.Error()})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package limits
+
+import (
+	"net/http"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response limitsResponse
+type limitsResponse Usage
+
+// AddRoutes adds the limits routes to the router
+func AddRoutes(rg *gin.RouterGroup, l *Limiter) {
+	// swagger:route GET /limits Limits getLimits
+	//
+	// Usage
+	//
+	// Get the rate limit, quotas and quota usage of the request's API key (or client IP if auth is disabled).
+	// The download quota restarts every period (and when ipswd restarts) and the storage quota is used by
+	// the stored artifacts of the client's jobs.
+	//
+	//     Responses:
+	//       200: limitsResponse
+	//       500: genericError
+	rg.GET("/limits", func(c *gin.Context) {
+		u, err := l.Usage(c.Request.Context(), Client(c))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, limitsResponse(u))
+	})
+}
@@ -0,0 +1,112 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/storage"
+)
+
+// quotaStore is a storage backend that counts the stored bytes against a client's storage quota
+type quotaStore struct {
+	storage.Backend
+	l      *Limiter
+	client string
+}
+
+func (s *quotaStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := s.l.reserveStorage(ctx, s.client, uint64(size)); err != nil {
+		return err
+	}
+	defer s.l.release(s.client, uint64(size))
+	return s.Backend.Put(ctx, key, r, size)
+}
+
+// Store returns the storage backend of the client's jobs, which fails to store artifacts that would exceed
+// its storage quota (it returns b if l or b is nil)
+func (l *Limiter) Store(b storage.Backend, client string) storage.Backend {
+	if l == nil || b == nil || len(client) == 0 {
+		return b
+	}
+	return &quotaStore{Backend: b, l: l, client: client}
+}
+
+// SetStorage sets the job manager and storage backend the clients' stored artifacts are sized with
+// (they are stored as '<job ID>/...' so a client's artifacts are the ones of the jobs it submitted)
+func (l *Limiter) SetStorage(m *jobs.Manager, b storage.Backend) {
+	if l == nil {
+		return
+	}
+	l.jobs = m
+	l.store = b
+}
+
+// stored returns the bytes of the client's job artifacts in the storage
+func (l *Limiter) stored(ctx context.Context, client string) (uint64, error) {
+	if l.jobs == nil || l.store == nil {
+		return 0, nil
+	}
+	ids, err := l.jobs.Owned(client)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	owned := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		owned[id] = true
+	}
+	objs, err := l.store.List(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the stored artifacts: %w", err)
+	}
+	var size uint64
+	for _, obj := range objs {
+		if id, _, ok := strings.Cut(obj.Key, "/"); ok && owned[id] {
+			size += uint64(obj.Size)
+		}
+	}
+	return size, nil
+}
+
+// reserveStorage adds n bytes to the client's pending storage unless its stored and pending artifacts
+// would exceed its storage quota (the caller must release them once they are stored)
+func (l *Limiter) reserveStorage(ctx context.Context, client string, n uint64) error {
+	if l == nil || len(client) == 0 {
+		return nil
+	}
+	max, ok := l.limit(client).quotas[Storage]
+	if !ok {
+		return nil
+	}
+	stored, err := l.stored(ctx, client)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	used := stored + l.pending[client]
+	if used+n > max || (n == 0 && used >= max) {
+		return &QuotaError{Client: client, Quota: Storage, Limit: max}
+	}
+	if n > 0 {
+		l.pending[client] += n
+	}
+	return nil
+}
+
+// release removes n bytes from the client's pending storage
+func (l *Limiter) release(client string, n uint64) {
+	if _, ok := l.limit(client).quotas[Storage]; !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending[client] -= n; l.pending[client] == 0 {
+		delete(l.pending, client)
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactToken redacts the credentials passed in the access_token query param of a request path
+func redactToken(path string) string {
+	p, rawQuery, ok := strings.Cut(path, "?")
+	if !ok || !strings.Contains(rawQuery, "access_token") {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return p + "?REDACTED" // don't log a query that may contain the token
+	}
+	if query.Has("access_token") {
+		query.Set("access_token", "REDACTED")
+	}
+	return p + "?" + query.Encode()
+}
+
+// logger logs the requests in gin's default format (without the credentials)
+func logger() gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			var statusColor, methodColor, resetColor string
+			if param.IsOutputColor() {
+				statusColor = param.StatusCodeColor()
+				methodColor = param.MethodColor()
+				resetColor = param.ResetColor()
+			}
+			if param.Latency > time.Minute {
+				param.Latency = param.Latency.Truncate(time.Second)
+			}
+			return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+				param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				statusColor, param.StatusCode, resetColor,
+				param.Latency,
+				param.ClientIP,
+				methodColor, param.Method, resetColor,
+				redactToken(param.Path),
+				param.ErrorMessage,
+			)
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package server
+
+import "testing"
+
+func TestRedactToken(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/jobs", "/v1/jobs"},
+		{"/v1/syms/scan?path=a.ipsw", "/v1/syms/scan?path=a.ipsw"},
+		{"/v1/jobs/1/events?access_token=secret", "/v1/jobs/1/events?access_token=REDACTED"},
+		{"/v1/jobs/1/events?b=2&access_token=secret&a=1", "/v1/jobs/1/events?a=1&access_token=REDACTED&b=2"},
+		{"/v1/jobs/1/events?access_token=%zz", "/v1/jobs/1/events?REDACTED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := redactToken(tt.path); got != tt.want {
+				t.Errorf("redactToken() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// instrument records the request count and latency of the API routes
+func instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // don't let 404s blow up the label cardinality
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).ObserveSince(start)
+	}
+}
+
+// collectQueues sets the collectors of the job, download queue and scan queue depth gauges
+func (s *Server) collectQueues(d db.Database) {
+	metrics.Jobs.SetCollector(func(set func(v float64, values ...string)) {
+		counts := make(map[jobs.State]int)
+		for _, st := range s.jobs.List() {
+			counts[st.State]++
+		}
+		for _, state := range []jobs.State{jobs.Pending, jobs.Running, jobs.Done, jobs.Failed, jobs.Canceled} {
+			set(float64(counts[state]), string(state))
+		}
+	})
+	if d == nil {
+		return
+	}
+	metrics.DownloadQueue.SetCollector(func(set func(v float64, values ...string)) {
+		items, err := d.GetQueue("")
+		if err != nil {
+			return
+		}
+		counts := make(map[model.QueueStatus]int)
+		for _, item := range items {
+			counts[item.Status]++
+		}
+		for _, status := range []model.QueueStatus{model.QueuePending, model.QueueRunning, model.QueueDone, model.QueueFailed} {
+			set(float64(counts[status]), string(status))
+		}
+	})
+	metrics.ScanQueue.SetCollector(func(set func(v float64, values ...string)) {
+		items, err := d.GetScanQueue("")
+		if err != nil {
+			return
+		}
+		counts := make(map[model.QueueStatus]int)
+		for _, item := range items {
+			counts[item.Status]++
+		}
+		for _, status := range []model.QueueStatus{model.QueuePending, model.QueueRunning, model.QueueDone, model.QueueFailed} {
+			set(float64(counts[status]), string(status))
+		}
+	})
+}
+
+// jobFinished records a finished job's metrics
+func jobFinished(st jobs.Status) {
+	metrics.JobsFinished.WithLabelValues(st.Kind, string(st.State)).Inc()
+	if st.Started != nil && st.Finished != nil {
+		metrics.JobDuration.WithLabelValues(st.Kind).Observe(st.Finished.Sub(*st.Started).Seconds())
+	}
+}
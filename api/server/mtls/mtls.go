@@ -0,0 +1,217 @@
+// Package mtls provides the TLS and mutual-TLS config of the daemon's listeners, so ipswd can be deployed
+// without a TLS terminating reverse proxy
+//
+// The server certificate is reloaded when its files change (i.e. when they are rotated by cert-manager or a
+// SPIFFE helper). If a client CA is set the clients must present a certificate signed by it, and if allowed
+// SPIFFE IDs are set the client certificate must have a matching 'spiffe://' URI SAN.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// Config is the TLS config of the daemon's listeners (TLS is disabled if there is no cert)
+type Config struct {
+	// Cert and Key are the PEM files of the server certificate (chain) and its private key
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// ClientCA is the PEM file of the CA(s) the client certificates must be signed by (enables mutual TLS)
+	ClientCA string `json:"client_ca" mapstructure:"client-ca"`
+	// ClientAuth is 'require' (the default if there is a client CA) or 'verify-if-given'
+	ClientAuth string `json:"client_auth" mapstructure:"client-auth"`
+	// AllowedSPIFFEIDs are the SPIFFE IDs (globs, i.e. spiffe://example.org/ns/ci/*) the client certificates can have
+	AllowedSPIFFEIDs []string `json:"allowed_spiffe_ids" mapstructure:"allowed-spiffe-ids"`
+	// MinVersion is the minimum TLS version: 1.2 (the default) or 1.3
+	MinVersion string `json:"min_version" mapstructure:"min-version"`
+}
+
+// Enabled returns true if TLS is configured
+func (c Config) Enabled() bool {
+	return len(c.Cert) > 0
+}
+
+// Mutual returns true if the clients must present a certificate
+func (c Config) Mutual() bool {
+	return len(c.ClientCA) > 0 && c.ClientAuth != "verify-if-given"
+}
+
+func (c Config) verify() error {
+	if len(c.Cert) == 0 || len(c.Key) == 0 {
+		return fmt.Errorf("tls: both cert and key are required")
+	}
+	if len(c.ClientCA) == 0 && (len(c.ClientAuth) > 0 || len(c.AllowedSPIFFEIDs) > 0) {
+		return fmt.Errorf("tls: client-auth and allowed-spiffe-ids require a client-ca")
+	}
+	switch c.ClientAuth {
+	case "", "require", "verify-if-given":
+	default:
+		return fmt.Errorf("tls: invalid client-auth '%s' (must be one of: require, verify-if-given)", c.ClientAuth)
+	}
+	for _, id := range c.AllowedSPIFFEIDs {
+		if !strings.HasPrefix(id, "spiffe://") {
+			return fmt.Errorf("tls: invalid allowed SPIFFE ID '%s' (must start with spiffe://)", id)
+		}
+		if _, err := path.Match(id, ""); err != nil {
+			return fmt.Errorf("tls: invalid allowed SPIFFE ID glob '%s': %v", id, err)
+		}
+	}
+	return nil
+}
+
+// New returns the listeners' TLS config (it returns nil if TLS is disabled)
+func New(conf Config) (*tls.Config, error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+	if err := conf.verify(); err != nil {
+		return nil, err
+	}
+	kp := &keyPair{cert: conf.Cert, key: conf.Key}
+	if err := kp.load(); err != nil {
+		return nil, err
+	}
+	tc := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: kp.get,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	switch conf.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		tc.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls: invalid min-version '%s' (must be one of: 1.2, 1.3)", conf.MinVersion)
+	}
+	if len(conf.ClientCA) > 0 {
+		dat, err := os.ReadFile(conf.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to read client CA: %v", err)
+		}
+		tc.ClientCAs = x509.NewCertPool()
+		if !tc.ClientCAs.AppendCertsFromPEM(dat) {
+			return nil, fmt.Errorf("tls: no certificates found in client CA %s", conf.ClientCA)
+		}
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+		if conf.ClientAuth == "verify-if-given" {
+			tc.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		if len(conf.AllowedSPIFFEIDs) > 0 {
+			tc.VerifyConnection = func(cs tls.ConnectionState) error {
+				if len(cs.PeerCertificates) == 0 {
+					return nil // only possible with verify-if-given
+				}
+				return checkSPIFFEID(cs.PeerCertificates[0], conf.AllowedSPIFFEIDs)
+			}
+		}
+	}
+	return tc, nil
+}
+
+// SPIFFEID returns the certificate's SPIFFE ID (its spiffe:// URI SAN)
+func SPIFFEID(cert *x509.Certificate) (*url.URL, error) {
+	var ids []*url.URL
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			ids = append(ids, u)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return nil, errors.New("client certificate has no SPIFFE ID")
+	case 1:
+		return ids[0], nil
+	default:
+		return nil, errors.New("client certificate has more than one SPIFFE ID")
+	}
+}
+
+// checkSPIFFEID checks the certificate's SPIFFE ID matches one of the allowed globs
+func checkSPIFFEID(cert *x509.Certificate, allowed []string) error {
+	id, err := SPIFFEID(cert)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(pattern, id.String()); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("client SPIFFE ID '%s' is not allowed", id)
+}
+
+// keyPair is the server certificate (reloaded when its files change)
+type keyPair struct {
+	cert, key string
+
+	mu      sync.RWMutex
+	pair    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// modified returns the latest modification time of the cert and key files
+func (kp *keyPair) modified() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{kp.cert, kp.key} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return latest, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (kp *keyPair) load() error {
+	modTime, err := kp.modified()
+	if err != nil {
+		return fmt.Errorf("tls: failed to stat certificate: %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(kp.cert, kp.key)
+	if err != nil {
+		return fmt.Errorf("tls: failed to load certificate: %v", err)
+	}
+	kp.mu.Lock()
+	kp.pair = &pair
+	kp.modTime = modTime
+	kp.checked = time.Now()
+	kp.mu.Unlock()
+	return nil
+}
+
+// get returns the certificate (reloading it if its files changed, checking at most every 10s)
+func (kp *keyPair) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	kp.mu.RLock()
+	pair, modTime, checked := kp.pair, kp.modTime, kp.checked
+	kp.mu.RUnlock()
+	if time.Since(checked) < 10*time.Second {
+		return pair, nil
+	}
+	kp.mu.Lock()
+	kp.checked = time.Now()
+	kp.mu.Unlock()
+	if latest, err := kp.modified(); err == nil && latest.After(modTime) {
+		if err := kp.load(); err != nil {
+			log.WithError(err).Error("Failed to reload TLS certificate (still serving the previous one)")
+		} else {
+			log.Info("Reloaded TLS certificate")
+			kp.mu.RLock()
+			pair = kp.pair
+			kp.mu.RUnlock()
+		}
+	}
+	return pair, nil
+}
@@ -0,0 +1,119 @@
+// Package artifacts provides the /artifacts routes for downloading the stored job artifacts
+package artifacts
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response artifactsResponse
+type artifactsResponse []storage.Object
+
+// swagger:response
+type successResponse struct {
+	Success bool `json:"success,omitempty"`
+}
+
+func storageError(c *gin.Context, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+}
+
+// AddRoutes adds the artifacts routes to the router
+func AddRoutes(rg *gin.RouterGroup, store storage.Backend) {
+	// swagger:route GET /artifacts Artifacts getArtifacts
+	//
+	// List
+	//
+	// List the stored job artifacts (their keys are prefixed with the ID of the job that produced them).
+	//
+	//     Parameters:
+	//       + name: prefix
+	//         in: query
+	//         description: only list the artifacts whose keys start with prefix (i.e. a job ID)
+	//         required: false
+	//         type: string
+	//     Responses:
+	//       200: artifactsResponse
+	//       500: genericError
+	rg.GET("/artifacts", func(c *gin.Context) {
+		objs, err := store.List(c.Request.Context(), c.Query("prefix"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if objs == nil {
+			objs = []storage.Object{}
+		}
+		c.IndentedJSON(http.StatusOK, artifactsResponse(objs))
+	})
+	// swagger:route GET /artifacts/{key} Artifacts getArtifact
+	//
+	// Download
+	//
+	// Download a stored job artifact.
+	//
+	//     Produces:
+	//     - application/octet-stream
+	//
+	//     Parameters:
+	//       + name: key
+	//         in: path
+	//         description: artifact key
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200:
+	//       404: genericError
+	//       500: genericError
+	rg.GET("/artifacts/*key", func(c *gin.Context) {
+		r, obj, err := store.Get(c.Request.Context(), c.Param("key"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		defer r.Close()
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(obj.Key)}))
+		c.Header("Content-Length", strconv.FormatInt(obj.Size, 10))
+		if !obj.Modified.IsZero() {
+			c.Header("Last-Modified", obj.Modified.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Content-Type", "application/octet-stream")
+		c.Status(http.StatusOK)
+		io.Copy(c.Writer, r)
+	})
+	// swagger:route DELETE /artifacts/{key} Artifacts deleteArtifact
+	//
+	// Delete
+	//
+	// Delete a stored job artifact.
+	//
+	//     Parameters:
+	//       + name: key
+	//         in: path
+	//         description: artifact key
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: successResponse
+	//       404: genericError
+	//       500: genericError
+	rg.DELETE("/artifacts/*key", func(c *gin.Context) {
+		if err := store.Delete(c.Request.Context(), c.Param("key")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.IndentedJSON(http.StatusOK, successResponse{Success: true})
+	})
+}
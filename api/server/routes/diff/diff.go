@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/api/server/routes/upload"
+	"github.com/blacktop/ipsw/api/types"
+	kcmd "github.com/blacktop/ipsw/internal/commands/kernel"
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:parameters postDiffIPSW
+type diffIPSWParams struct {
+	// path to the previous IPSW
+	Previous string `json:"prev" binding:"required"`
+	// path to the current IPSW
+	Current string `json:"curr" binding:"required"`
+	// also diff the MachOs' C strings
+	CStrings bool `json:"cstrings"`
+	// diff the IM4P firmwares instead of the filesystem MachOs
+	Firmware bool `json:"firmware"`
+	// only diff these sections (i.e. __TEXT.__text)
+	AllowList []string `json:"allow_list"`
+	// skip these sections
+	BlockList []string `json:"block_list"`
+	// path to AEA pem DB JSON file
+	PemDB string `json:"pem_db"`
+}
+
+// swagger:response machoDiffResponse
+type machoDiffResponse struct {
+	Diff *mcmd.MachoDiff `json:"diff"`
+}
+
+// openPair opens the route's 'prev' and 'curr' MachOs (paths on the server or uploaded files)
+func openPair(c *gin.Context) (*macho.File, *macho.File, string, func(), bool) {
+	var files []*macho.File
+	var cleanups []func()
+	cleanup := func() {
+		for _, m := range files {
+			m.Close()
+		}
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
+	var name string
+	for _, field := range []string{"prev", "curr"} {
+		path, rm, err := upload.File(c, field)
+		cleanups = append(cleanups, rm)
+		if err != nil {
+			cleanup()
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return nil, nil, "", nil, false
+		}
+		m, err := macho.Open(path)
+		if err != nil {
+			cleanup()
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return nil, nil, "", nil, false
+		}
+		files = append(files, m)
+		name = filepath.Base(path)
+	}
+	return files[0], files[1], name, cleanup, true
+}
+
+func diffConfig(c *gin.Context) *mcmd.DiffConfig {
+	cstrs, _ := strconv.ParseBool(c.Query("cstrings"))
+	return &mcmd.DiffConfig{CStrings: cstrs}
+}
+
+func diffMachos(c *gin.Context) {
+	prev, curr, name, cleanup, ok := openPair(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	conf := diffConfig(c)
+	diff := &mcmd.MachoDiff{Updated: make(map[string]string)}
+	if err := diff.Generate(
+		map[string]*mcmd.DiffInfo{name: mcmd.GenerateDiffInfo(prev, conf)},
+		map[string]*mcmd.DiffInfo{name: mcmd.GenerateDiffInfo(curr, conf)},
+		conf,
+	); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, machoDiffResponse{Diff: diff})
+}
+
+func diffKernels(c *gin.Context) {
+	prev, curr, _, cleanup, ok := openPair(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	diff, err := kcmd.Diff(prev, curr, diffConfig(c))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, machoDiffResponse{Diff: diff})
+}
+
+func diffIPSWs(pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params diffIPSWParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if params.PemDB == "" && pemDB != "" {
+			params.PemDB = filepath.Clean(pemDB)
+		}
+		conf := &mcmd.DiffConfig{
+			CStrings:  params.CStrings,
+			AllowList: params.AllowList,
+			BlockList: params.BlockList,
+			PemDB:     params.PemDB,
+		}
+		prev, curr := filepath.Clean(params.Previous), filepath.Clean(params.Current)
+
+		var diff *mcmd.MachoDiff
+		var err error
+		if params.Firmware {
+			diff, err = mcmd.DiffFirmwares(prev, curr, conf)
+		} else {
+			diff, err = mcmd.DiffIPSW(prev, curr, conf)
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, machoDiffResponse{Diff: diff})
+	}
+}
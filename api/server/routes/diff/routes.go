@@ -24,7 +24,7 @@ type diffResponse struct {
 }
 
 // AddRoutes adds the diff routes to the router
-func AddRoutes(rg *gin.RouterGroup) {
+func AddRoutes(rg *gin.RouterGroup, pemDB string) {
 	dr := rg.Group("/diff")
 	// swagger:route POST /diff/files Diff postDiffFiles
 	//
@@ -71,4 +71,43 @@ func AddRoutes(rg *gin.RouterGroup) {
 		}
 		c.IndentedJSON(http.StatusOK, diffResponse{Diff: udiff.Unified("", "", fmt.Sprintln(params.Previous), fmt.Sprintln(params.Current))})
 	})
+	// swagger:route POST /diff/ipsw Diff postDiffIPSW
+	//
+	// IPSW
+	//
+	// This will return the MachO (or IM4P firmware) diff of two IPSWs.
+	//
+	//     Responses:
+	//       200: machoDiffResponse
+	//       400: genericError
+	//       500: genericError
+	dr.POST("/ipsw", diffIPSWs(pemDB))
+	// swagger:route POST /diff/kernel Diff postDiffKernel
+	//
+	// Kernel
+	//
+	// This will return the diff of two MH_FILESET kernelcaches ('prev' and 'curr' as server paths or uploaded multipart files).
+	//
+	//     Consumes:
+	//     - multipart/form-data
+	//
+	//     Responses:
+	//       200: machoDiffResponse
+	//       400: genericError
+	//       500: genericError
+	dr.POST("/kernel", diffKernels)
+	// swagger:route POST /diff/macho Diff postDiffMacho
+	//
+	// MachO
+	//
+	// This will return the diff of two MachOs ('prev' and 'curr' as server paths or uploaded multipart files).
+	//
+	//     Consumes:
+	//     - multipart/form-data
+	//
+	//     Responses:
+	//       200: machoDiffResponse
+	//       400: genericError
+	//       500: genericError
+	dr.POST("/macho", diffMachos)
 }
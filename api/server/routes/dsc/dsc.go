@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/blacktop/go-macho"
@@ -493,3 +494,38 @@ func dscWebkit(c *gin.Context) {
 
 	c.IndentedJSON(http.StatusOK, dscWebkitResponse{Path: dscPath, Webkit: version})
 }
+
+// swagger:response
+type dscTbdResponse struct {
+	Path  string `json:"path,omitempty"`
+	Dylib string `json:"dylib,omitempty"`
+	TBD   string `json:"tbd,omitempty"`
+}
+
+func dscTbd(c *gin.Context) {
+	dscPath := c.Query("path")
+	if dscPath == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing required 'path' query parameter"})
+		return
+	}
+	dylib := c.Query("dylib")
+	if dylib == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing required 'dylib' query parameter"})
+		return
+	}
+	generic, _ := strconv.ParseBool(c.Query("generic"))
+	f, err := dyld.Open(dscPath)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+	defer f.Close()
+
+	tbd, err := cmd.GetTBD(f, dylib, generic)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, dscTbdResponse{Path: dscPath, Dylib: dylib, TBD: tbd})
+}
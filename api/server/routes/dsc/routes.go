@@ -200,7 +200,36 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//       200: dscSymbolsResponse
 	//       500: genericError
 	dr.POST("/symaddr", dscSymbols)
-	// dr.GET("/tbd", handler)    // TODO: implement this
+	// swagger:route GET /dsc/tbd DSC getDscTbd
+	//
+	// TBD
+	//
+	// Generate a <code>.tbd</code> text-based stub for a dylib in the DSC.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to dyld_shared_cache
+	//         required: true
+	//         type: string
+	//       + name: dylib
+	//         in: query
+	//         description: dylib to generate the TBD for
+	//         required: true
+	//         type: string
+	//       + name: generic
+	//         in: query
+	//         description: generate a generic (multi-platform) TBD
+	//         required: false
+	//         type: boolean
+	//     Responses:
+	//       200: dscTbdResponse
+	//       400: genericError
+	//       500: genericError
+	dr.GET("/tbd", dscTbd)
 
 	// swagger:route GET /dsc/webkit DSC getDscWebkit
 	//
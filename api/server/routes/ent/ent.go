@@ -0,0 +1,198 @@
+package ent
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/api/server/routes/upload"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/commands/ent"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response
+type entResponse struct {
+	Path         string         `json:"path"`
+	Entitlements map[string]any `json:"entitlements"`
+}
+
+// swagger:parameters getEntSearch
+type entSearchParams struct {
+	// path to IPSW
+	IPSW string `form:"ipsw" json:"ipsw"`
+	// path to a folder of MachOs (i.e. a mounted DMG)
+	Input string `form:"input" json:"input"`
+	// entitlement key regex
+	Key string `form:"key" json:"key"`
+	// entitlement value regex
+	Value string `form:"value" json:"value"`
+	// path to AEA pem DB JSON file
+	PemDB string `form:"pem_db" json:"pem_db"`
+}
+
+// EntMatch is a file with an entitlement matching the search
+type EntMatch struct {
+	File  string `json:"file"`
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// swagger:response
+type entSearchResponse struct {
+	Matches []EntMatch `json:"matches"`
+}
+
+// swagger:parameters postEntDiff
+type entDiffParams struct {
+	// path to the previous IPSW
+	Previous string `json:"prev" binding:"required"`
+	// path to the current IPSW
+	Current string `json:"curr" binding:"required"`
+	// output the diff as markdown
+	Markdown bool `json:"markdown"`
+	// path to AEA pem DB JSON file
+	PemDB string `json:"pem_db"`
+}
+
+// swagger:response
+type entDiffResponse struct {
+	Diff string `json:"diff"`
+}
+
+func entitlements(c *gin.Context) {
+	path, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return
+	}
+	defer cleanup()
+
+	var m *macho.File
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		m = fat.Arches[len(fat.Arches)-1].File // grab last arch (probably arm64e)
+	} else if err == macho.ErrNotFat {
+		if m, err = macho.Open(path); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		defer m.Close()
+	} else {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	ents := make(map[string]any)
+	if cs := m.CodeSignature(); cs != nil && len(cs.Entitlements) > 0 {
+		if err := plist.NewDecoder(bytes.NewReader([]byte(cs.Entitlements))).Decode(&ents); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: fmt.Sprintf("failed to decode entitlements plist: %v", err)})
+			return
+		}
+	}
+
+	c.IndentedJSON(http.StatusOK, entResponse{Path: path, Entitlements: ents})
+}
+
+func search(pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params entSearchParams
+		if err := c.ShouldBind(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if len(params.IPSW) == 0 && len(params.Input) == 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "'ipsw' or 'input' is required"})
+			return
+		}
+		if len(params.Key) == 0 && len(params.Value) == 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "'key' or 'value' is required"})
+			return
+		}
+		keyRE, err := regexp.Compile(params.Key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("invalid 'key' regex: %v", err)})
+			return
+		}
+		valRE, err := regexp.Compile(params.Value)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("invalid 'value' regex: %v", err)})
+			return
+		}
+		if params.PemDB == "" && pemDB != "" {
+			params.PemDB = pemDB
+		}
+
+		if len(params.IPSW) > 0 {
+			params.IPSW = filepath.Clean(params.IPSW)
+		}
+		entDB, err := ent.GetDatabase(&ent.Config{
+			IPSW:   params.IPSW,
+			Folder: params.Input,
+			PemDB:  params.PemDB,
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+
+		matches := []EntMatch{}
+		for f, e := range entDB {
+			if len(e) == 0 {
+				continue
+			}
+			ents := make(map[string]any)
+			if err := plist.NewDecoder(bytes.NewReader([]byte(e))).Decode(&ents); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: fmt.Sprintf("failed to decode entitlements plist for %s: %v", f, err)})
+				return
+			}
+			for k, v := range ents {
+				if keyRE.MatchString(k) && valRE.MatchString(fmt.Sprint(v)) {
+					matches = append(matches, EntMatch{File: f, Key: k, Value: v})
+				}
+			}
+		}
+		sort.Slice(matches, func(i, j int) bool {
+			if matches[i].File == matches[j].File {
+				return matches[i].Key < matches[j].Key
+			}
+			return matches[i].File < matches[j].File
+		})
+
+		c.IndentedJSON(http.StatusOK, entSearchResponse{Matches: matches})
+	}
+}
+
+func diff(pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params entDiffParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if params.PemDB == "" && pemDB != "" {
+			params.PemDB = pemDB
+		}
+		dbs := make([]map[string]string, 0, 2)
+		for _, ipsw := range []string{params.Previous, params.Current} {
+			entDB, err := ent.GetDatabase(&ent.Config{IPSW: filepath.Clean(ipsw), PemDB: params.PemDB})
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+				return
+			}
+			dbs = append(dbs, entDB)
+		}
+		out, err := ent.DiffDatabases(dbs[0], dbs[1], &ent.Config{Markdown: params.Markdown})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, entDiffResponse{Diff: out})
+	}
+}
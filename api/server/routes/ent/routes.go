@@ -0,0 +1,60 @@
+// Package ent provides the /ent routes
+package ent
+
+import (
+	"github.com/blacktop/ipsw/api/server/routes/upload"
+	"github.com/gin-gonic/gin"
+)
+
+// AddRoutes adds the ent routes to the router
+func AddRoutes(rg *gin.RouterGroup, pemDB string) {
+	eg := rg.Group("/ent")
+	// swagger:route GET /ent Entitlements getEnt
+	//
+	// Entitlements
+	//
+	// Get a MachO's entitlements (POST multipart/form-data to upload the MachO as the 'path' file).
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to MachO
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: entResponse
+	//       400: genericError
+	//       500: genericError
+	eg.Match(upload.Methods, "", entitlements)
+	// swagger:route POST /ent/diff Entitlements postEntDiff
+	//
+	// Diff
+	//
+	// Diff the entitlements of two IPSWs.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Responses:
+	//       200: entDiffResponse
+	//       400: genericError
+	//       500: genericError
+	eg.POST("/diff", diff(pemDB))
+	// swagger:route GET /ent/search Entitlements getEntSearch
+	//
+	// Search
+	//
+	// Search the entitlements of an IPSW's (or folder's) MachOs by key and/or value regex.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Responses:
+	//       200: entSearchResponse
+	//       400: genericError
+	//       500: genericError
+	eg.GET("/search", search(pemDB))
+}
@@ -1 +1,133 @@
 package img4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/blacktop/ipsw/api/server/routes/upload"
+	"github.com/blacktop/ipsw/api/types"
+	icmd "github.com/blacktop/ipsw/internal/commands/img4"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response
+type img4InfoResponse struct {
+	Path        string         `json:"path"`
+	Type        string         `json:"type,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Size        int            `json:"size"`
+	Manifest    bool           `json:"manifest"`
+	Keybags     []img4.Keybag  `json:"keybags,omitempty"`
+	Analysis    *icmd.Analysis `json:"analysis,omitempty"`
+}
+
+// openIm4p parses the route's IMG4/IM4P (a path on the server or an uploaded file)
+func openIm4p(c *gin.Context) (*img4.Im4p, string, bool, func(), bool) {
+	path, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return nil, "", false, nil, false
+	}
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		cleanup()
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return nil, "", false, nil, false
+	}
+
+	var hasManifest bool
+	if magic.IsImg4Data(dat) {
+		i, err := img4.ParseImg4(bytes.NewReader(dat))
+		if err != nil {
+			cleanup()
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("failed to parse IMG4: %v", err)})
+			return nil, "", false, nil, false
+		}
+		hasManifest = len(i.Manifest.Bytes) > 0
+		dat = i.IM4P.Raw
+	} else if !magic.IsIm4pData(dat) {
+		cleanup()
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "unsupported file type: expected IMG4/IM4P"})
+		return nil, "", false, nil, false
+	}
+	im4p, err := img4.ParseIm4p(bytes.NewReader(dat))
+	if err != nil {
+		cleanup()
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("failed to parse IM4P: %v", err)})
+		return nil, "", false, nil, false
+	}
+	return im4p, path, hasManifest, cleanup, true
+}
+
+func info(c *gin.Context) {
+	im4p, path, hasManifest, cleanup, ok := openIm4p(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	resp := img4InfoResponse{
+		Path:        path,
+		Type:        im4p.Type,
+		Description: im4p.Description,
+		Size:        len(im4p.Data),
+		Manifest:    hasManifest,
+		Keybags:     im4p.Kbags,
+	}
+	if analyze, _ := strconv.ParseBool(c.Query("analyze")); analyze {
+		a, err := icmd.AnalyzeIm4p(filepath.Base(path), im4p)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: fmt.Sprintf("failed to analyze payload: %v", err)})
+			return
+		}
+		resp.Analysis = a
+	}
+
+	c.IndentedJSON(http.StatusOK, resp)
+}
+
+func extract(c *gin.Context) {
+	path, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return
+	}
+	defer cleanup()
+
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+	tmp, err := os.CreateTemp("", "ipswd_img4_*.payload")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if len(c.Query("iv_key")) > 0 {
+		ivkey, err := hex.DecodeString(c.Query("iv_key"))
+		if err != nil || len(ivkey) != 48 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "'iv_key' must be 48 hex encoded bytes"})
+			return
+		}
+		err = icmd.DecryptPayload(path, tmp.Name(), ivkey[:16], ivkey[16:])
+	} else {
+		err = icmd.ExtractPayload(path, tmp.Name(), magic.IsImg4Data(dat))
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	c.FileAttachment(tmp.Name(), filepath.Base(path)+".payload")
+}
@@ -0,0 +1,65 @@
+// Package img4 provides the /img4 routes
+package img4
+
+import (
+	"github.com/blacktop/ipsw/api/server/routes/upload"
+	"github.com/gin-gonic/gin"
+)
+
+// AddRoutes adds the img4 routes to the router
+//
+// Every route takes the IMG4/IM4P as either a 'path' query parameter (a path on the server)
+// or, when POSTed as multipart/form-data, an uploaded 'path' file.
+func AddRoutes(rg *gin.RouterGroup) {
+	ig := rg.Group("/img4")
+	// swagger:route GET /img4/extract Img4 getImg4Extract
+	//
+	// Extract
+	//
+	// Extract (and decrypt if 'iv_key' is given) an IMG4/IM4P's payload.
+	//
+	//     Produces:
+	//     - application/octet-stream
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to IMG4/IM4P
+	//         required: true
+	//         type: string
+	//       + name: iv_key
+	//         in: query
+	//         description: hex encoded IV + key to decrypt the IM4P payload with
+	//         required: false
+	//         type: string
+	//     Responses:
+	//       200: body:[]byte
+	//       400: genericError
+	//       500: genericError
+	ig.Match(upload.Methods, "/extract", extract)
+	// swagger:route GET /img4/info Img4 getImg4Info
+	//
+	// Info
+	//
+	// Get IMG4/IM4P info.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to IMG4/IM4P
+	//         required: true
+	//         type: string
+	//       + name: analyze
+	//         in: query
+	//         description: identify and parse the payload
+	//         required: false
+	//         type: boolean
+	//     Responses:
+	//       200: img4InfoResponse
+	//       400: genericError
+	//       500: genericError
+	ig.Match(upload.Methods, "/info", info)
+}
@@ -0,0 +1,266 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/commands/ent"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	"github.com/blacktop/ipsw/internal/diff"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/gin-gonic/gin"
+)
+
+// storeArtifacts uploads the artifacts to the storage backend as '<job ID>/<path relative to root>'
+func storeArtifacts(ctx context.Context, j *jobs.Job, store storage.Backend, root string, artifacts []string) (*extractResult, error) {
+	res := &extractResult{Artifacts: artifacts}
+	if store == nil {
+		return res, nil
+	}
+	for _, name := range artifacts {
+		rel, err := filepath.Rel(root, name)
+		if err != nil || len(root) == 0 || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(name)
+		}
+		key := path.Join(j.ID(), filepath.ToSlash(rel))
+		j.Logf("Storing %s", key)
+		if err := storage.PutFile(ctx, store, key, name); err != nil {
+			return nil, fmt.Errorf("failed to store artifact: %w", err)
+		}
+		res.Objects = append(res.Objects, key)
+	}
+	return res, nil
+}
+
+// files returns the files in the folder
+func files(root string) ([]string, error) {
+	var names []string
+	if err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	return names, nil
+}
+
+func extractKernel(ctx context.Context, j *jobs.Job, store storage.Backend, query extract.Config) (any, error) {
+	j.Logf("Extracting kernelcache(s)")
+	v, err := run(ctx, func() (any, error) {
+		return extract.Kernelcache(&query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []string
+	for name := range v.(map[string][]string) {
+		artifacts = append(artifacts, name)
+	}
+	sort.Strings(artifacts)
+	return storeArtifacts(ctx, j, store, query.Output, artifacts)
+}
+
+func submitExtractKernel(m *jobs.Manager, pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query extract.Config
+		if err := c.ShouldBindJSON(&query); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if query.PemDB == "" && pemDB != "" {
+			query.PemDB = filepath.Clean(pemDB)
+		}
+		submit(c, m, "extract/kernel", query)
+	}
+}
+
+type dscSplitParams struct {
+	// path to dyld_shared_cache
+	Path string `json:"path" binding:"required"`
+	// the folder to output the split dylibs (defaults to the folder of the dyld_shared_cache)
+	Output string `json:"output,omitempty"`
+	// the path to the Xcode.app to use for splitting
+	XCodePath string `json:"xcode_path,omitempty"`
+}
+
+func dscSplit(ctx context.Context, j *jobs.Job, store storage.Backend, params dscSplitParams) (any, error) {
+	if len(params.Output) == 0 {
+		params.Output = filepath.Dir(filepath.Clean(params.Path))
+	}
+	if err := os.MkdirAll(params.Output, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", params.Output, err)
+	}
+	j.Logf("Splitting %s", params.Path)
+	if _, err := run(ctx, func() (any, error) {
+		return nil, dyld.Split(filepath.Clean(params.Path), filepath.Clean(params.Output), filepath.Clean(params.XCodePath), false)
+	}); err != nil {
+		return nil, err
+	}
+	var dylibs []string
+	for _, dir := range []string{"System", "usr"} {
+		names, err := files(filepath.Join(params.Output, dir))
+		if err != nil {
+			return nil, err
+		}
+		dylibs = append(dylibs, names...)
+	}
+	return storeArtifacts(ctx, j, store, params.Output, dylibs)
+}
+
+func submitDscSplit(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params dscSplitParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		submit(c, m, "dsc/split", params)
+	}
+}
+
+type diffIPSWParams struct {
+	// path to the old IPSW
+	Old string `json:"old" binding:"required"`
+	// path to the new IPSW
+	New string `json:"new" binding:"required"`
+	// the title of the diff (defaults to the IPSW versions)
+	Title string `json:"title,omitempty"`
+	// the folder to output the diff report in
+	Output string `json:"output" binding:"required"`
+	// format of the report (markdown, json or html)
+	Format    string   `json:"format,omitempty"`
+	KDKs      []string `json:"kdks,omitempty"`
+	LaunchD   bool     `json:"launchd,omitempty"`
+	Firmware  bool     `json:"firmware,omitempty"`
+	Features  bool     `json:"features,omitempty"`
+	CStrings  bool     `json:"cstrings,omitempty"`
+	AllowList []string `json:"allow_list,omitempty"`
+	BlockList []string `json:"block_list,omitempty"`
+	PemDB     string   `json:"pem_db,omitempty"`
+	// regexes of the binary paths to (not) diff the entitlements of
+	EntInclude []string `json:"ent_include,omitempty"`
+	EntExclude []string `json:"ent_exclude,omitempty"`
+	// globs of the entitlements to highlight (defaults to the security-relevant ones)
+	EntInteresting []string `json:"ent_interesting,omitempty"`
+}
+
+func diffIPSW(ctx context.Context, j *jobs.Job, store storage.Backend, params diffIPSWParams) (any, error) {
+	// write the report to a folder per job so its artifacts can be found
+	output := filepath.Join(filepath.Clean(params.Output), j.ID())
+	d := diff.New(&diff.Config{
+		Title:     params.Title,
+		IpswOld:   filepath.Clean(params.Old),
+		IpswNew:   filepath.Clean(params.New),
+		KDKs:      params.KDKs,
+		LaunchD:   params.LaunchD,
+		Firmware:  params.Firmware,
+		Features:  params.Features,
+		CStrings:  params.CStrings,
+		AllowList: params.AllowList,
+		BlockList: params.BlockList,
+		PemDB:     params.PemDB,
+		Output:    output,
+
+		EntInclude:     params.EntInclude,
+		EntExclude:     params.EntExclude,
+		EntInteresting: params.EntInteresting,
+	})
+	j.Logf("Diffing %s and %s", params.Old, params.New)
+	if _, err := run(ctx, func() (any, error) {
+		if err := d.Diff(); err != nil {
+			return nil, err
+		}
+		switch params.Format {
+		case "", "markdown":
+			return nil, d.Markdown()
+		case "json":
+			return nil, d.ToJSON()
+		case "html":
+			return nil, d.ToHTML()
+		default:
+			return nil, fmt.Errorf("invalid diff format '%s' (must be one of: markdown, json, html)", params.Format)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	reports, err := files(output)
+	if err != nil {
+		return nil, err
+	}
+	return storeArtifacts(ctx, j, store, output, reports)
+}
+
+func submitDiffIPSW(m *jobs.Manager, pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params diffIPSWParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		switch params.Format {
+		case "", "markdown", "json", "html":
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "format must be one of: markdown, json, html"})
+			return
+		}
+		if params.PemDB == "" && pemDB != "" {
+			params.PemDB = filepath.Clean(pemDB)
+		}
+		submit(c, m, "diff/ipsw", params)
+	}
+}
+
+type entDumpParams struct {
+	// path to IPSW
+	IPSW string `json:"ipsw" binding:"required"`
+	// the folder to write the entitlements database to
+	Output string `json:"output" binding:"required"`
+	PemDB  string `json:"pem_db,omitempty"`
+}
+
+func entDump(ctx context.Context, j *jobs.Job, store storage.Backend, params entDumpParams) (any, error) {
+	output := filepath.Clean(params.Output)
+	if err := os.MkdirAll(output, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", output, err)
+	}
+	dbPath := filepath.Join(output, strings.TrimSuffix(filepath.Base(params.IPSW), filepath.Ext(params.IPSW))+".entdb")
+	j.Logf("Dumping the entitlements of %s", params.IPSW)
+	if _, err := run(ctx, func() (any, error) {
+		return ent.GetDatabase(&ent.Config{
+			IPSW:     filepath.Clean(params.IPSW),
+			Database: dbPath,
+			PemDB:    params.PemDB,
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return storeArtifacts(ctx, j, store, output, []string{dbPath})
+}
+
+func submitEntDump(m *jobs.Manager, pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params entDumpParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if params.PemDB == "" && pemDB != "" {
+			params.PemDB = filepath.Clean(pemDB)
+		}
+		submit(c, m, "ent/dump", params)
+	}
+}
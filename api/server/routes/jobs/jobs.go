@@ -0,0 +1,403 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/blacktop/ipsw/api/server/limits"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/plugin"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/blacktop/ipsw/internal/syms"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// swagger:response jobResponse
+type jobResponse jobs.Status
+
+// swagger:response jobsResponse
+type jobsResponse []jobs.Status
+
+// swagger:response workersResponse
+type workersResponse []jobs.Worker
+
+var upgrader = websocket.Upgrader{}
+
+func getJob(m *jobs.Manager, c *gin.Context) (*jobs.Job, bool) {
+	j, err := m.Get(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: err.Error()})
+		return nil, false
+	}
+	return j, true
+}
+
+func listJobs(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.IndentedJSON(http.StatusOK, jobsResponse(m.List()))
+	}
+}
+
+func listWorkers(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workers, err := m.Workers()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, workersResponse(workers))
+	}
+}
+
+func jobStatus(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if j, ok := getJob(m, c); ok {
+			c.IndentedJSON(http.StatusOK, jobResponse(j.Status()))
+		}
+	}
+}
+
+func cancelJob(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		j, ok := getJob(m, c)
+		if !ok {
+			return
+		}
+		j.Cancel()
+		<-j.Done()
+		c.IndentedJSON(http.StatusOK, jobResponse(j.Status()))
+	}
+}
+
+// jobEvents streams the job's events as SSE (or over a WebSocket if the client asks to upgrade)
+func jobEvents(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		j, ok := getJob(m, c)
+		if !ok {
+			return
+		}
+		past, events, unsubscribe := j.Subscribe()
+		defer unsubscribe()
+
+		if websocket.IsWebSocketUpgrade(c.Request) {
+			streamWebSocket(c, past, events)
+			return
+		}
+
+		for _, ev := range past {
+			c.SSEvent(string(ev.Type), ev)
+		}
+		c.Writer.Flush()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					c.SSEvent("end", jobResponse(j.Status()))
+					return false
+				}
+				c.SSEvent(string(ev.Type), ev)
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
+
+func streamWebSocket(c *gin.Context, past []jobs.Event, events <-chan jobs.Event) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has already replied with an error
+	}
+	defer conn.Close()
+
+	// the client only ever closes the socket, so read until it does
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, ev := range past {
+		if err := conn.WriteJSON(ev); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "job finished"))
+				return
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// swagger:parameters postJobDownloadIPSW
+type downloadIPSWParams struct {
+	Device string `json:"device" binding:"required"`
+	// build to download (or use version)
+	Build   string `json:"build,omitempty"`
+	Version string `json:"version,omitempty"`
+	// folder to download the IPSW to
+	Output   string `json:"output,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	// number of parallel HTTP range requests
+	Segments int `json:"segments,omitempty"`
+}
+
+// The download IPSW job result
+type downloadIPSWResult struct {
+	Path string `json:"path"`
+}
+
+func downloadIPSW(ctx context.Context, j *jobs.Job, l *limits.Limiter, params downloadIPSWParams) (any, error) {
+	if params.Build == "" {
+		bld, err := download.GetBuildID(params.Version, params.Device)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get build for %s %s: %w", params.Device, params.Version, err)
+		}
+		params.Build = bld
+	}
+	ipsw, err := download.GetIPSW(params.Device, params.Build)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IPSW info: %w", err)
+	}
+	if len(ipsw.URL) == 0 {
+		return nil, fmt.Errorf("no IPSW found for %s %s", params.Device, params.Build)
+	}
+	dest := filepath.Join(filepath.Clean(params.Output), path.Base(ipsw.URL))
+	if _, err := os.Stat(dest); err == nil {
+		j.Logf("IPSW already downloaded")
+		return downloadIPSWResult{Path: dest}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create output folder: %w", err)
+	}
+	if err := l.Check(ctx, j.Owner(), limits.Download); err != nil {
+		return nil, err
+	}
+
+	j.Logf("Downloading %s %s (%s) to %s", params.Device, ipsw.Version, ipsw.BuildID, dest)
+	// always resume (there is no one to answer the prompt)
+	downloader := download.NewDownload(params.Proxy, params.Insecure, false, true, false, false, false)
+	downloader.URL = ipsw.URL
+	downloader.Sha1 = ipsw.SHA1
+	downloader.DestName = dest
+	downloader.Segments = params.Segments
+	// the download is canceled when the owner's quota is exhausted
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	downloader.Context = ctx
+	var last int64
+	downloader.Progress = func(written, total int64) {
+		if written > last {
+			metrics.DownloadBytes.WithLabelValues().Add(float64(written - last))
+			if err := l.Reserve(j.Owner(), limits.Download, uint64(written-last)); err != nil {
+				cancel(err)
+			}
+		}
+		last = written
+		j.Progress(written, total)
+	}
+
+	// re-submitting a canceled download resumes it
+	return run(ctx, func() (any, error) {
+		err := downloader.Do()
+		metrics.Downloads.WithLabelValues(metrics.Result(err)).Inc()
+		if err != nil {
+			return nil, err
+		}
+		return downloadIPSWResult{Path: dest}, nil
+	})
+}
+
+// submit queues a job and replies with its status
+func submit(c *gin.Context, m *jobs.Manager, kind string, params any) {
+	j, err := m.SubmitAs(limits.Client(c), kind, params)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusAccepted, jobResponse(j.Status()))
+}
+
+func submitDownloadIPSW(m *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params downloadIPSWParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if params.Build == "" && params.Version == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "build or version is required"})
+			return
+		}
+		submit(c, m, "download/ipsw", params)
+	}
+}
+
+// run runs a blocking operation that can't be interrupted, returning early if the job is canceled
+func run(ctx context.Context, fn func() (any, error)) (any, error) {
+	type ret struct {
+		v   any
+		err error
+	}
+	done := make(chan ret, 1)
+	go func() {
+		v, err := fn()
+		done <- ret{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// The extract job result
+type extractResult struct {
+	Artifacts []string `json:"artifacts"`
+	// Objects are the artifacts' storage keys (if ipswd is configured with storage)
+	Objects []string `json:"objects,omitempty"`
+}
+
+func extractDSC(ctx context.Context, j *jobs.Job, store storage.Backend, query extract.Config) (any, error) {
+	j.Logf("Extracting dyld_shared_cache(s)")
+	v, err := run(ctx, func() (any, error) {
+		return extract.DSC(&query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storeArtifacts(ctx, j, store, query.Output, v.([]string))
+}
+
+func submitExtractDSC(m *jobs.Manager, pemDB string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query extract.Config
+		if err := c.ShouldBindJSON(&query); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		if query.PemDB == "" && pemDB != "" {
+			query.PemDB = filepath.Clean(pemDB)
+		}
+		submit(c, m, "extract/dsc", query)
+	}
+}
+
+type symsScanParams struct {
+	Path    string `json:"path"`
+	PemDB   string `json:"pem_db,omitempty"`
+	SigsDir string `json:"sig_dir,omitempty"`
+	Rescan  bool   `json:"rescan,omitempty"`
+}
+
+func symsScan(ctx context.Context, j *jobs.Job, d db.Database, plugins []*plugin.Plugin, params symsScanParams) (any, error) {
+	if d == nil {
+		return nil, fmt.Errorf("ipswd is not configured with a database")
+	}
+	j.Logf("Scanning symbols of %s", params.Path)
+	return run(ctx, func() (any, error) {
+		start := time.Now()
+		var err error
+		if params.Rescan {
+			err = syms.Rescan(params.Path, params.PemDB, params.SigsDir, d, plugins...)
+		} else {
+			err = syms.Scan(params.Path, params.PemDB, params.SigsDir, d, plugins...)
+		}
+		metrics.ScanDuration.WithLabelValues(metrics.Result(err)).ObserveSince(start)
+		return nil, err
+	})
+}
+
+func submitSymsScan(m *jobs.Manager, d db.Database, pemDB, sigsDir string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, types.GenericError{Error: "ipswd is not configured with a database"})
+			return
+		}
+		ipswPath, ok := c.GetQuery("path")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "missing path query parameter"})
+			return
+		}
+		params := symsScanParams{
+			Path:    filepath.Clean(ipswPath),
+			PemDB:   c.DefaultQuery("pem_db", pemDB),
+			SigsDir: c.DefaultQuery("sig_dir", sigsDir),
+			Rescan:  c.Query("rescan") == "true",
+		}
+		if params.PemDB != "" {
+			params.PemDB = filepath.Clean(params.PemDB)
+		}
+		if params.SigsDir != "" {
+			params.SigsDir = filepath.Clean(params.SigsDir)
+		}
+		submit(c, m, "syms/scan", params)
+	}
+}
+
+// handler adapts a job func to a jobs.Handler that decodes its params
+func handler[P any](fn func(ctx context.Context, j *jobs.Job, params P) (any, error)) jobs.Handler {
+	return func(ctx context.Context, j *jobs.Job, raw json.RawMessage) (any, error) {
+		var params P
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, fmt.Errorf("failed to decode job params: %w", err)
+		}
+		return fn(ctx, j, params)
+	}
+}
+
+// RegisterHandlers registers the handlers of the kinds of jobs the routes submit
+//
+// The jobs' downloads and stored artifacts count against the quotas of the clients that submitted them.
+func RegisterHandlers(m *jobs.Manager, d db.Database, store storage.Backend, plugins []*plugin.Plugin, l *limits.Limiter) {
+	m.Register("download/ipsw", handler(func(ctx context.Context, j *jobs.Job, params downloadIPSWParams) (any, error) {
+		return downloadIPSW(ctx, j, l, params)
+	}))
+	m.Register("extract/dsc", handler(func(ctx context.Context, j *jobs.Job, query extract.Config) (any, error) {
+		return extractDSC(ctx, j, l.Store(store, j.Owner()), query)
+	}))
+	m.Register("extract/kernel", handler(func(ctx context.Context, j *jobs.Job, query extract.Config) (any, error) {
+		return extractKernel(ctx, j, l.Store(store, j.Owner()), query)
+	}))
+	m.Register("dsc/split", handler(func(ctx context.Context, j *jobs.Job, params dscSplitParams) (any, error) {
+		return dscSplit(ctx, j, l.Store(store, j.Owner()), params)
+	}))
+	m.Register("diff/ipsw", handler(func(ctx context.Context, j *jobs.Job, params diffIPSWParams) (any, error) {
+		return diffIPSW(ctx, j, l.Store(store, j.Owner()), params)
+	}))
+	m.Register("ent/dump", handler(func(ctx context.Context, j *jobs.Job, params entDumpParams) (any, error) {
+		return entDump(ctx, j, l.Store(store, j.Owner()), params)
+	}))
+	m.Register("syms/scan", handler(func(ctx context.Context, j *jobs.Job, params symsScanParams) (any, error) {
+		return symsScan(ctx, j, d, plugins, params)
+	}))
+}
@@ -0,0 +1,192 @@
+// Package jobs provides the /jobs routes for running long operations in the background
+package jobs
+
+import (
+	"github.com/blacktop/ipsw/api/server/limits"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/plugin"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// AddRoutes adds the jobs routes to the router (and registers their job handlers with the manager)
+func AddRoutes(rg *gin.RouterGroup, m *jobs.Manager, d db.Database, store storage.Backend, pemDB, sigsDir string, plugins []*plugin.Plugin, l *limits.Limiter) {
+	RegisterHandlers(m, d, store, plugins, l)
+
+	// swagger:route GET /workers Jobs getWorkers
+	//
+	// Workers
+	//
+	// List the workers of a distributed deployment (the daemons sharing the job queue with the worker role).
+	// Workers that haven't sent a heartbeat recently are not alive and their jobs are requeued by the coordinator.
+	//
+	//     Responses:
+	//       200: workersResponse
+	//       500: genericError
+	rg.GET("/workers", listWorkers(m))
+
+	jr := rg.Group("/jobs")
+
+	// swagger:route GET /jobs Jobs getJobs
+	//
+	// List
+	//
+	// List the background jobs (finished jobs are kept for an hour).
+	// Failed attempts are retried with backoff and unfinished jobs are resumed when ipswd restarts.
+	// In distributed mode this lists the jobs of all the daemons sharing the job queue.
+	//
+	//     Responses:
+	//       200: jobsResponse
+	jr.GET("", listJobs(m))
+	// swagger:route GET /jobs/{id} Jobs getJob
+	//
+	// Status
+	//
+	// Get a background job's status.
+	//
+	//     Parameters:
+	//       + name: id
+	//         in: path
+	//         description: job ID
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: jobResponse
+	//       404: genericError
+	jr.GET("/:id", jobStatus(m))
+	// swagger:route DELETE /jobs/{id} Jobs deleteJob
+	//
+	// Cancel
+	//
+	// Cancel a background job.
+	//
+	//     Parameters:
+	//       + name: id
+	//         in: path
+	//         description: job ID
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: jobResponse
+	//       404: genericError
+	jr.DELETE("/:id", cancelJob(m))
+	// swagger:route GET /jobs/{id}/events Jobs getJobEvents
+	//
+	// Events
+	//
+	// Stream a background job's state, log, progress and result events as server-sent events
+	// (or as JSON messages over a WebSocket if the request is a WebSocket upgrade).
+	// The past events are replayed first and the stream ends when the job finishes.
+	//
+	//     Produces:
+	//     - text/event-stream
+	//
+	//     Parameters:
+	//       + name: id
+	//         in: path
+	//         description: job ID
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: jobResponse
+	//       404: genericError
+	jr.GET("/:id/events", jobEvents(m))
+
+	// swagger:route POST /jobs/download/ipsw Jobs postJobDownloadIPSW
+	//
+	// Download IPSW
+	//
+	// Download an IPSW in the background (reports the download progress).
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/download/ipsw", submitDownloadIPSW(m))
+	// swagger:route POST /jobs/extract/dsc Jobs postJobExtractDSC
+	//
+	// Extract DSC
+	//
+	// Extract the dyld_shared_cache(s) from an IPSW in the background (takes the same body as /extract/dsc).
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/extract/dsc", submitExtractDSC(m, pemDB))
+	// swagger:route POST /jobs/extract/kernel Jobs postJobExtractKernel
+	//
+	// Extract Kernel
+	//
+	// Extract the kernelcache(s) from an IPSW in the background (takes the same body as /extract/kernel).
+	// The kernelcaches are uploaded to the artifact storage if ipswd is configured with one.
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/extract/kernel", submitExtractKernel(m, pemDB))
+	// swagger:route POST /jobs/dsc/split Jobs postJobDscSplit
+	//
+	// Split DSC
+	//
+	// Split a dyld_shared_cache into its dylibs in the background (requires macOS with Xcode).
+	// The dylibs are uploaded to the artifact storage if ipswd is configured with one.
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/dsc/split", submitDscSplit(m))
+	// swagger:route POST /jobs/diff/ipsw Jobs postJobDiffIPSW
+	//
+	// Diff IPSWs
+	//
+	// Diff two IPSWs in the background, writing a markdown, JSON or HTML report to 'output/<job ID>'.
+	// The report is uploaded to the artifact storage if ipswd is configured with one.
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/diff/ipsw", submitDiffIPSW(m, pemDB))
+	// swagger:route POST /jobs/ent/dump Jobs postJobEntDump
+	//
+	// Dump Entitlements
+	//
+	// Dump the entitlements of an IPSW's MachOs to an entitlements database ('output/<IPSW name>.entdb') in the background.
+	// The database is uploaded to the artifact storage if ipswd is configured with one.
+	//
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	jr.POST("/ent/dump", submitEntDump(m, pemDB))
+	// swagger:route POST /jobs/syms/scan Jobs postJobSymsScan
+	//
+	// Scan Symbols
+	//
+	// Scan (or rescan) the symbols of an IPSW into the symbol database in the background.
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to IPSW
+	//         required: true
+	//         type: string
+	//       + name: pem_db
+	//         in: query
+	//         description: path to AEA pem DB JSON file
+	//         required: false
+	//         type: string
+	//       + name: sig_dir
+	//         in: query
+	//         description: path to symbolication signatures directory
+	//         required: false
+	//         type: string
+	//       + name: rescan
+	//         in: query
+	//         description: replace the IPSW's existing symbols
+	//         required: false
+	//         type: boolean
+	//     Responses:
+	//       202: jobResponse
+	//       400: genericError
+	//       503: genericError
+	jr.POST("/syms/scan", submitSymsScan(m, d, pemDB, sigsDir))
+}
@@ -1,14 +1,37 @@
 package kernel
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/api/server/routes/upload"
 	"github.com/blacktop/ipsw/api/types"
 	"github.com/blacktop/ipsw/pkg/kernelcache"
 	"github.com/gin-gonic/gin"
 )
 
+// openKernel opens the route's kernelcache (a path on the server or an uploaded file)
+func openKernel(c *gin.Context) (*macho.File, string, func(), bool) {
+	kernelPath, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return nil, "", nil, false
+	}
+	m, err := macho.Open(kernelPath)
+	if err != nil {
+		cleanup()
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return nil, "", nil, false
+	}
+	return m, kernelPath, func() {
+		m.Close()
+		cleanup()
+	}, true
+}
+
 // swagger:response kernelKextsResponse
 type kernelKextsResponse struct {
 	Path  string                 `json:"path"`
@@ -16,14 +39,11 @@ type kernelKextsResponse struct {
 }
 
 func listKexts(c *gin.Context) {
-	kernelPath := c.Query("path")
-
-	m, err := macho.Open(kernelPath)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+	m, kernelPath, cleanup, ok := openKernel(c)
+	if !ok {
 		return
 	}
-	defer m.Close()
+	defer cleanup()
 
 	bundles, err := kernelcache.GetKexts(m)
 	if err != nil {
@@ -41,22 +61,63 @@ type kernelSyscallsResponse struct {
 }
 
 func getSyscalls(c *gin.Context) {
-	kernelPath := c.Query("path")
+	m, kernelPath, cleanup, ok := openKernel(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
 
-	m, err := macho.Open(kernelPath)
+	syscalls, err := kernelcache.GetSyscallTable(m)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 		return
 	}
-	defer m.Close()
 
-	syscalls, err := kernelcache.GetSyscallTable(m)
+	c.JSON(http.StatusOK, kernelSyscallsResponse{Path: kernelPath, Syscalls: syscalls})
+}
+
+// swagger:response kernelMachTrapsResponse
+type kernelMachTrapsResponse struct {
+	Path  string                 `json:"path"`
+	Traps []kernelcache.MachTrap `json:"traps"`
+}
+
+func getMachTraps(c *gin.Context) {
+	m, kernelPath, cleanup, ok := openKernel(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	traps, err := kernelcache.GetMachTrapTable(m)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, kernelSyscallsResponse{Path: kernelPath, Syscalls: syscalls})
+	c.JSON(http.StatusOK, kernelMachTrapsResponse{Path: kernelPath, Traps: traps})
+}
+
+// swagger:response kernelMigResponse
+type kernelMigResponse struct {
+	Path       string                         `json:"path"`
+	Subsystems []kernelcache.MigKernSubsystem `json:"subsystems"`
+}
+
+func getMigSubsystems(c *gin.Context) {
+	m, kernelPath, cleanup, ok := openKernel(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	subs, err := kernelcache.GetMigSubsystems(m)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, kernelMigResponse{Path: kernelPath, Subsystems: subs})
 }
 
 // swagger:response kernelVersionResponse
@@ -66,20 +127,45 @@ type kernelVersionResponse struct {
 }
 
 func getVersion(c *gin.Context) {
-	kernelPath := c.Query("path")
+	m, kernelPath, cleanup, ok := openKernel(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
 
-	m, err := macho.Open(kernelPath)
+	v, err := kernelcache.GetVersion(m)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 		return
 	}
-	defer m.Close()
 
-	v, err := kernelcache.GetVersion(m)
+	c.JSON(http.StatusOK, gin.H{"path": kernelPath, "version": v})
+}
+
+func decompress(c *gin.Context) {
+	kernelPath, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(kernelPath)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
+	cc, err := kernelcache.ParseImg4Data(data)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return
+	}
+	dec, err := kernelcache.DecompressData(cc)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"path": kernelPath, "version": v})
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.decompressed", filepath.Base(kernelPath)))
+	c.Data(http.StatusOK, "application/octet-stream", dec)
 }
@@ -2,14 +2,38 @@
 package kernel
 
 import (
+	"github.com/blacktop/ipsw/api/server/routes/upload"
 	"github.com/gin-gonic/gin"
 )
 
-// AddRoutes adds the download routes to the router
+// AddRoutes adds the kernel routes to the router
+//
+// Every route takes the kernelcache as either a 'path' query parameter (a path on the server)
+// or, when POSTed as multipart/form-data, an uploaded 'path' file.
 func AddRoutes(rg *gin.RouterGroup) {
 	kg := rg.Group("/kernel")
 	// kg.GET("/ctfdump", handler) // TODO: implement this
-	// kg.GET("/dec", handler)     // TODO: implement this
+
+	// swagger:route GET /kernel/dec Kernel getKernelDec
+	//
+	// Decompress
+	//
+	// Decompress a kernelcache IM4P.
+	//
+	//     Produces:
+	//     - application/octet-stream
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to compressed kernelcache
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: body:[]byte
+	//       400: genericError
+	//       500: genericError
+	kg.Match(upload.Methods, "/dec", decompress)
 	// kg.GET("/dwarf", handler)   // TODO: implement this
 	// kg.GET("/extract", handler) // TODO: implement this
 
@@ -30,8 +54,30 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//         type: string
 	//     Responses:
 	//       200: kernelKextsResponse
+	//       400: genericError
+	//       500: genericError
+	kg.Match(upload.Methods, "/kexts", listKexts)
+
+	// swagger:route GET /kernel/mig Kernel getKernelMig
+	//
+	// MIG
+	//
+	// Get kernelcache MIG subsystems.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to kernelcache
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: kernelMigResponse
+	//       400: genericError
 	//       500: genericError
-	kg.GET("/kexts", listKexts)
+	kg.Match(upload.Methods, "/mig", getMigSubsystems)
 	// kg.GET("/sbopts", handler)     // TODO: implement this
 	// kg.GET("/symbolsets", handler) // TODO: implement this
 
@@ -52,8 +98,29 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//         type: string
 	//     Responses:
 	//       200: kernelSyscallsResponse
+	//       400: genericError
+	//       500: genericError
+	kg.Match(upload.Methods, "/syscall", getSyscalls)
+	// swagger:route GET /kernel/traps Kernel getKernelMachTraps
+	//
+	// Mach Traps
+	//
+	// Get kernelcache mach trap table.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to kernelcache
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: kernelMachTrapsResponse
+	//       400: genericError
 	//       500: genericError
-	kg.GET("/syscall", getSyscalls)
+	kg.Match(upload.Methods, "/traps", getMachTraps)
 	// swagger:route GET /kernel/version Kernel getKernelVersion
 	//
 	// Version
@@ -71,6 +138,7 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//         type: string
 	//     Responses:
 	//       200: kernelVersionResponse
+	//       400: genericError
 	//       500: genericError
-	kg.GET("/version", getVersion)
+	kg.Match(upload.Methods, "/version", getVersion)
 }
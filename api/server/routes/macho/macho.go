@@ -1,17 +1,21 @@
 package macho
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/blacktop/go-macho"
+	mtypes "github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/api/server/routes/upload"
 	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // Info is the struct for the macho info route parameters
 type Info struct {
-	Path string `form:"path" json:"path" binding:"required"`
+	Path string `form:"path" json:"path"`
 	Arch string `form:"arch" json:"arch"`
 }
 
@@ -29,83 +33,230 @@ type machoStringsResponse struct {
 	Strings map[string]map[string]uint64 `json:"strings"`
 }
 
-func machoInfo(c *gin.Context) {
-	var m *macho.File
-	var params Info
+// swagger:response
+type machoAddrToOffResponse struct {
+	Path    string `json:"path"`
+	Arch    string `json:"arch"`
+	Addr    uint64 `json:"addr"`
+	Offset  uint64 `json:"offset"`
+	Segment string `json:"segment,omitempty"`
+	Section string `json:"section,omitempty"`
+}
 
-	if err := c.BindQuery(&params); err != nil {
+// swagger:response
+type machoOffToAddrResponse struct {
+	Path    string `json:"path"`
+	Arch    string `json:"arch"`
+	Offset  uint64 `json:"offset"`
+	Addr    uint64 `json:"addr"`
+	Segment string `json:"segment,omitempty"`
+	Section string `json:"section,omitempty"`
+}
+
+// swagger:response
+type machoAddrToSymResponse struct {
+	Path    string   `json:"path"`
+	Arch    string   `json:"arch"`
+	Addr    uint64   `json:"addr"`
+	Entry   string   `json:"entry,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+	CString string   `json:"cstring,omitempty"`
+}
+
+// openMacho opens the route's MachO (a path on the server or an uploaded file) selecting 'arch' from universal MachOs
+func openMacho(c *gin.Context) (*macho.File, Info, func(), bool) {
+	var params Info
+	if err := c.ShouldBind(&params); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
-		return
+		return nil, params, nil, false
 	}
+	path, cleanup, err := upload.File(c, "path")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return nil, params, nil, false
+	}
+	params.Path = path
 
 	fat, err := macho.OpenFat(params.Path)
 	if err != nil {
-		if err == macho.ErrNotFat { // not a fat binary
-			m, err = macho.Open(params.Path)
-			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
-				return
-			}
-			defer m.Close()
-		} else {
+		if err != macho.ErrNotFat {
+			cleanup()
 			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
-			return
+			return nil, params, nil, false
 		}
-	} else { // fat binary
-		defer fat.Close()
-		if params.Arch == "" {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: "'arch' query parameter is required for universal binaries"})
-			return
+		// not a fat binary
+		m, err := macho.Open(params.Path)
+		if err != nil {
+			cleanup()
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return nil, params, nil, false
 		}
-		for _, farch := range fat.Arches {
-			if strings.EqualFold(farch.SubCPU.String(farch.CPU), params.Arch) {
-				m = farch.File
-			}
+		return m, params, func() {
+			m.Close()
+			cleanup()
+		}, true
+	}
+	// fat binary
+	if params.Arch == "" {
+		fat.Close()
+		cleanup()
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: "'arch' query parameter is required for universal binaries"})
+		return nil, params, nil, false
+	}
+	for _, farch := range fat.Arches {
+		if strings.EqualFold(farch.SubCPU.String(farch.CPU), params.Arch) {
+			return farch.File, params, func() {
+				fat.Close()
+				cleanup()
+			}, true
 		}
 	}
+	fat.Close()
+	cleanup()
+	c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("arch '%s' not found in universal binary", params.Arch)})
+	return nil, params, nil, false
+}
+
+// location returns the segment and section containing the address
+func location(m *macho.File, addr uint64) (string, string) {
+	var seg, sec string
+	if s := m.FindSegmentForVMAddr(addr); s != nil {
+		seg = s.Name
+	}
+	if s := m.FindSectionForVMAddr(addr); s != nil {
+		sec = fmt.Sprintf("%s.%s", s.Seg, s.Name)
+	}
+	return seg, sec
+}
+
+func intParam(c *gin.Context, name string) (uint64, bool) {
+	v := c.Query(name)
+	if len(v) == 0 {
+		v = c.PostForm(name)
+	}
+	n, err := utils.ConvertStrToInt(v)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("invalid '%s' parameter: %v", name, err)})
+		return 0, false
+	}
+	return n, true
+}
+
+func machoInfo(c *gin.Context) {
+	m, params, cleanup, ok := openMacho(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
 	c.IndentedJSON(http.StatusOK, machoInfoResponse{Path: params.Path, Arch: params.Arch, Info: m})
 }
 
 func machoStrings(c *gin.Context) {
-	var m *macho.File
-	var params Info
+	m, params, cleanup, ok := openMacho(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	cstrs, err := m.GetCStrings()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+		return
+	}
 
-	if err := c.BindQuery(&params); err != nil {
+	c.IndentedJSON(http.StatusOK, machoStringsResponse{Path: params.Path, Arch: params.Arch, Strings: cstrs})
+}
+
+func machoAddrToOff(c *gin.Context) {
+	addr, ok := intParam(c, "addr")
+	if !ok {
+		return
+	}
+	m, params, cleanup, ok := openMacho(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	off, err := m.GetOffset(addr)
+	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
 		return
 	}
+	seg, sec := location(m, addr)
 
-	fat, err := macho.OpenFat(params.Path)
+	c.IndentedJSON(http.StatusOK, machoAddrToOffResponse{Path: params.Path, Arch: params.Arch, Addr: addr, Offset: off, Segment: seg, Section: sec})
+}
+
+func machoOffToAddr(c *gin.Context) {
+	off, ok := intParam(c, "off")
+	if !ok {
+		return
+	}
+	m, params, cleanup, ok := openMacho(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	addr, err := m.GetVMAddress(off)
 	if err != nil {
-		if err == macho.ErrNotFat { // not a fat binary
-			m, err = macho.Open(params.Path)
+		c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+		return
+	}
+	seg, sec := location(m, addr)
+
+	c.IndentedJSON(http.StatusOK, machoOffToAddrResponse{Path: params.Path, Arch: params.Arch, Offset: off, Addr: addr, Segment: seg, Section: sec})
+}
+
+func machoAddrToSym(c *gin.Context) {
+	addr, ok := intParam(c, "addr")
+	if !ok {
+		return
+	}
+	m, params, cleanup, ok := openMacho(c)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	resp := machoAddrToSymResponse{Path: params.Path, Arch: params.Arch, Addr: addr}
+
+	if m.FileTOC.FileHeader.Type == mtypes.MH_FILESET {
+		for _, fse := range m.FileSets() {
+			mfse, err := m.GetFileSetFileByName(fse.EntryID)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+				c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: fmt.Sprintf("failed to parse entry %s: %v", fse.EntryID, err)})
 				return
 			}
-			defer m.Close()
-		} else {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
-			return
-		}
-	} else { // fat binary
-		defer fat.Close()
-		if params.Arch == "" {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: "'arch' query parameter is required for universal binaries"})
-			return
-		}
-		for _, farch := range fat.Arches {
-			if strings.EqualFold(farch.SubCPU.String(farch.CPU), params.Arch) {
-				m = farch.File
+			if mfse.FindSegmentForVMAddr(addr) == nil {
+				continue
+			}
+			resp.Entry = fse.EntryID
+			if cstr, ok := mfse.IsCString(addr); ok {
+				resp.CString = cstr
+			} else if mfse.Symtab != nil {
+				for _, sym := range mfse.Symtab.Syms {
+					if sym.Value == addr {
+						resp.Symbols = append(resp.Symbols, sym.Name)
+					}
+				}
 			}
+			break
+		}
+	} else if cstr, ok := m.IsCString(addr); ok {
+		resp.CString = cstr
+	} else if syms, err := m.FindAddressSymbols(addr); err == nil {
+		for _, sym := range syms {
+			resp.Symbols = append(resp.Symbols, sym.Name)
 		}
 	}
 
-	cstrs, err := m.GetCStrings()
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+	if len(resp.Symbols) == 0 && len(resp.CString) == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: fmt.Sprintf("no symbol found for %#x", addr)})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, machoStringsResponse{Path: params.Path, Arch: params.Arch, Strings: cstrs})
+	c.IndentedJSON(http.StatusOK, resp)
 }
@@ -1,14 +1,77 @@
 package macho
 
 import (
+	"github.com/blacktop/ipsw/api/server/routes/upload"
 	"github.com/gin-gonic/gin"
 )
 
-// AddRoutes adds the download routes to the router
+// AddRoutes adds the macho routes to the router
+//
+// Every route takes the MachO as either a 'path' query parameter (a path on the server)
+// or, when POSTed as multipart/form-data, an uploaded 'path' file.
 func AddRoutes(rg *gin.RouterGroup) {
 	m := rg.Group("/macho")
-	// m.GET("/a2o", handler)    // TODO: implement this
-	// m.GET("/a2s", handler)    // TODO: implement this
+	// swagger:route GET /macho/a2o MachO getMachoAddrToOff
+	//
+	// Address to Offset
+	//
+	// Convert a MachO virtual address to a file offset.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to MachO
+	//         required: true
+	//         type: string
+	//	    + name: arch
+	//         in: query
+	//         description: architecture to get info for in universal MachO
+	//         required: false
+	//         type: string
+	//	    + name: addr
+	//         in: query
+	//         description: virtual address
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: machoAddrToOffResponse
+	//       400: genericError
+	//       500: genericError
+	m.Match(upload.Methods, "/a2o", machoAddrToOff)
+	// swagger:route GET /macho/a2s MachO getMachoAddrToSym
+	//
+	// Address to Symbol
+	//
+	// Lookup the symbol (or C string) at a MachO virtual address.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to MachO
+	//         required: true
+	//         type: string
+	//	    + name: arch
+	//         in: query
+	//         description: architecture to get info for in universal MachO
+	//         required: false
+	//         type: string
+	//	    + name: addr
+	//         in: query
+	//         description: virtual address
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: machoAddrToSymResponse
+	//       400: genericError
+	//       404: genericError
+	//       500: genericError
+	m.Match(upload.Methods, "/a2s", machoAddrToSym)
 	// m.GET("/bbl", handler)    // TODO: implement this
 	// m.GET("/disass", handler) // TODO: implement this
 	// m.GET("/dump", handler)   // TODO: implement this
@@ -37,7 +100,7 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//       200: machoInfoResponse
 	//       400: genericError
 	//       500: genericError
-	m.GET("/info", machoInfo)
+	m.Match(upload.Methods, "/info", machoInfo)
 	// swagger:route GET /macho/info/strings MachO getMachoInfoStrings
 	//
 	// Strings
@@ -62,10 +125,39 @@ func AddRoutes(rg *gin.RouterGroup) {
 	//       200: machoStringsResponse
 	//       400: genericError
 	//       500: genericError
-	m.GET("/info/strings", machoStrings)
+	m.Match(upload.Methods, "/info/strings", machoStrings)
 
 	// m.GET("/lipo", handler)   // TODO: implement this
-	// m.GET("/o2a", handler)    // TODO: implement this
+	// swagger:route GET /macho/o2a MachO getMachoOffToAddr
+	//
+	// Offset to Address
+	//
+	// Convert a MachO file offset to a virtual address.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: path
+	//         in: query
+	//         description: path to MachO
+	//         required: true
+	//         type: string
+	//	    + name: arch
+	//         in: query
+	//         description: architecture to get info for in universal MachO
+	//         required: false
+	//         type: string
+	//	    + name: off
+	//         in: query
+	//         description: file offset
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       200: machoOffToAddrResponse
+	//       400: genericError
+	//       500: genericError
+	m.Match(upload.Methods, "/o2a", machoOffToAddr)
 	// m.GET("/patch", handler)  // TODO: implement this
 	// m.GET("/search", handler) // TODO: implement this
 	// m.GET("/sign", handler)   // TODO: implement this
@@ -0,0 +1,83 @@
+// Package pipelines provides the /pipelines routes for listing and running the daemon's pipelines
+package pipelines
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:response pipelinesResponse
+type pipelinesResponse []*pipeline.Pipeline
+
+// swagger:response pipelineRunResponse
+type pipelineRunResponse jobs.Status
+
+// swagger:parameters postPipelineRun
+type runParams struct {
+	Device  string `json:"device" binding:"required"`
+	Build   string `json:"build" binding:"required"`
+	Version string `json:"version,omitempty"`
+	// source of the build (ota or ipsw)
+	Source string `json:"source,omitempty"`
+}
+
+// AddRoutes adds the pipelines routes to the router
+func AddRoutes(rg *gin.RouterGroup, r *pipeline.Runner) {
+	// swagger:route GET /pipelines Pipelines getPipelines
+	//
+	// List
+	//
+	// List the pipelines loaded from the daemon's pipeline files.
+	//
+	//     Responses:
+	//       200: pipelinesResponse
+	rg.GET("/pipelines", func(c *gin.Context) {
+		c.IndentedJSON(http.StatusOK, pipelinesResponse(r.Pipelines()))
+	})
+	// swagger:route POST /pipelines/{name}/run Pipelines postPipelineRun
+	//
+	// Run
+	//
+	// Run a pipeline for a build in the background (as if the watcher had found the build).
+	//
+	//     Parameters:
+	//       + name: name
+	//         in: path
+	//         description: pipeline name
+	//         required: true
+	//         type: string
+	//     Responses:
+	//       202: pipelineRunResponse
+	//       400: genericError
+	//       404: genericError
+	rg.POST("/pipelines/:name/run", func(c *gin.Context) {
+		var params runParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		p, ok := r.Get(c.Param("name"))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: "pipeline not found"})
+			return
+		}
+		j, err := r.Submit(p.Name, download.WatchBuild{
+			Source:  params.Source,
+			Device:  params.Device,
+			Version: params.Version,
+			Build:   params.Build,
+			Found:   time.Now(),
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusAccepted, pipelineRunResponse(j.Status()))
+	})
+}
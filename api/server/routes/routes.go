@@ -7,8 +7,10 @@ import (
 	"github.com/blacktop/ipsw/api/server/routes/diff"
 	"github.com/blacktop/ipsw/api/server/routes/download"
 	"github.com/blacktop/ipsw/api/server/routes/dsc"
+	"github.com/blacktop/ipsw/api/server/routes/ent"
 	"github.com/blacktop/ipsw/api/server/routes/extract"
 	"github.com/blacktop/ipsw/api/server/routes/idev"
+	"github.com/blacktop/ipsw/api/server/routes/img4"
 	"github.com/blacktop/ipsw/api/server/routes/info"
 	"github.com/blacktop/ipsw/api/server/routes/ipsw"
 	"github.com/blacktop/ipsw/api/server/routes/kernel"
@@ -21,13 +23,14 @@ import (
 func Add(rg *gin.RouterGroup, pemDB string) {
 	daemon.AddRoutes(rg)
 	devicelist.AddRoutes(rg)
-	diff.AddRoutes(rg)
+	diff.AddRoutes(rg, pemDB)
 	download.AddRoutes(rg)
 	// dtree.AddRoutes(rg) // TODO: add dtree routes
 	dsc.AddRoutes(rg)
+	ent.AddRoutes(rg, pemDB)
 	extract.AddRoutes(rg, pemDB)
 	idev.AddRoutes(rg)
-	// img4.AddRoutes(rg) // TODO: add img4 routes
+	img4.AddRoutes(rg)
 	info.AddRoutes(rg)
 	ipsw.AddRoutes(rg, pemDB)
 	kernel.AddRoutes(rg)
@@ -0,0 +1,120 @@
+package syms
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/blacktop/ipsw/api/server/limits"
+	"github.com/blacktop/ipsw/api/types"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/syms/queue"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// swagger:response
+type scanQueueResponse []*model.ScanItem
+
+// swagger:parameters postScanQueue
+type scanQueueParams struct {
+	// in: body
+	Body struct {
+		// the IPSW/OTA URLs to scan
+		URLs []string `json:"urls" binding:"required,min=1"`
+		// the items with the highest priority are scanned first
+		Priority int `json:"priority"`
+	}
+}
+
+// AddQueueRoutes adds the symbol scan queue routes to the router
+func AddQueueRoutes(rg *gin.RouterGroup, d db.Database, f *queue.Feeder) {
+	// swagger:route POST /syms/queue Syms postScanQueue
+	//
+	// Enqueue
+	//
+	// Queue IPSW/OTA URLs to be downloaded and scanned by the background workers. The URLs that are
+	// already queued are not duplicated (their priority is raised and failed items are retried) and the
+	// IPSWs that were already scanned are skipped.
+	//
+	//     Responses:
+	//       202: scanQueueResponse
+	//       400: genericError
+	//       500: genericError
+	rg.POST("/syms/queue", func(c *gin.Context) {
+		var params scanQueueParams
+		if err := c.ShouldBindJSON(&params.Body); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		items, err := f.Enqueue(limits.Client(c), params.Body.Priority, params.Body.URLs...)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, scanQueueResponse(items))
+	})
+	// swagger:route GET /syms/queue Syms getScanQueue
+	//
+	// Queue
+	//
+	// List the symbol scan queue items (highest priority first).
+	//
+	//     Parameters:
+	//       + name: status
+	//         in: query
+	//         description: filter by status (pending, running, done or failed)
+	//         required: false
+	//         type: string
+	//
+	//     Responses:
+	//       200: scanQueueResponse
+	//       500: genericError
+	rg.GET("/syms/queue", func(c *gin.Context) {
+		items, err := d.GetScanQueue(model.QueueStatus(c.Query("status")))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, scanQueueResponse(items))
+	})
+	// swagger:route DELETE /syms/queue/{id} Syms deleteScanQueueItem
+	//
+	// Dequeue
+	//
+	// Remove an item from the symbol scan queue (running items can't be removed).
+	//
+	//     Parameters:
+	//       + name: id
+	//         in: path
+	//         description: queue item ID
+	//         required: true
+	//         type: integer
+	//     Responses:
+	//       200: successResponse
+	//       404: genericError
+	//       409: genericError
+	//       500: genericError
+	rg.DELETE("/syms/queue/:id", func(c *gin.Context) {
+		id := cast.ToUint(c.Param("id"))
+		running, err := d.GetScanQueue(model.QueueRunning)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		if slices.ContainsFunc(running, func(item *model.ScanItem) bool { return item.ID == id }) {
+			c.AbortWithStatusJSON(http.StatusConflict, types.GenericError{Error: "item is being scanned"})
+			return
+		}
+		if err := d.DeleteScanItem(id); err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, successResponse{Success: true})
+	})
+}
@@ -3,12 +3,18 @@ package syms
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
 
 	"github.com/blacktop/ipsw/api/types"
 	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/metrics"
 	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/plugin"
 	"github.com/blacktop/ipsw/internal/syms"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cast"
@@ -28,6 +34,12 @@ type createdResponse struct {
 // swagger:response
 type symIpswResponse *model.Ipsw
 
+// swagger:response
+type symIpswsResponse []*model.Ipsw
+
+// swagger:response
+type symAnalysesResponse []*model.Analysis
+
 // swagger:response
 type symMachoResponse *model.Macho
 
@@ -47,7 +59,7 @@ type IpswParams struct {
 }
 
 // AddRoutes adds the syms routes to the router
-func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
+func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string, plugins []*plugin.Plugin) {
 	// swagger:route POST /syms/scan Syms postScan
 	//
 	// Scan
@@ -101,7 +113,10 @@ func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
 				signaturesDir = filepath.Clean(sigsDir)
 			}
 		}
-		if err := syms.Scan(ipswPath, pemDbPath, signaturesDir, db); err != nil {
+		start := time.Now()
+		err := syms.Scan(ipswPath, pemDbPath, signaturesDir, db, plugins...)
+		metrics.ScanDuration.WithLabelValues(metrics.Result(err)).ObserveSince(start)
+		if err != nil {
 			if errors.Is(err, gorm.ErrDuplicatedKey) {
 				c.AbortWithStatusJSON(http.StatusConflict, types.GenericError{Error: err.Error()})
 				return
@@ -163,12 +178,68 @@ func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
 				signaturesDir = filepath.Clean(sigsDir)
 			}
 		}
-		if err := syms.Rescan(ipswPath, pemDbPath, signaturesDir, db); err != nil {
+		start := time.Now()
+		err := syms.Rescan(ipswPath, pemDbPath, signaturesDir, db, plugins...)
+		metrics.ScanDuration.WithLabelValues(metrics.Result(err)).ObserveSince(start)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 			return
 		}
 		c.JSON(http.StatusCreated, createdResponse{Created: true})
 	})
+	// swagger:route GET /syms/ipsws Syms getIPSWs
+	//
+	// IPSWs
+	//
+	// List the scanned IPSWs (newest first) with their devices, kernelcache and dyld_shared_cache UUIDs.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Responses:
+	//       200: symIpswsResponse
+	//       500: genericError
+	rg.GET("/syms/ipsws", func(c *gin.Context) {
+		ipsws, err := db.GetIPSWs()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		if ipsws == nil {
+			ipsws = []*model.Ipsw{}
+		}
+		c.JSON(http.StatusOK, symIpswsResponse(ipsws))
+	})
+	// swagger:route GET /syms/analyses/{id} Syms getAnalyses
+	//
+	// Analyses
+	//
+	// Get the results of the plugin analyzers run on an IPSW when it was scanned.
+	//
+	//     Produces:
+	//     - application/json
+	//
+	//     Parameters:
+	//       + name: id
+	//         in: path
+	//         description: IPSW ID (its SHA1)
+	//         required: true
+	//         type: string
+	//
+	//     Responses:
+	//       200: symAnalysesResponse
+	//       500: genericError
+	rg.GET("/syms/analyses/:id", func(c *gin.Context) {
+		analyses, err := syms.GetAnalyses(c.Param("id"), db)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
+			return
+		}
+		if analyses == nil {
+			analyses = []*model.Analysis{}
+		}
+		c.JSON(http.StatusOK, symAnalysesResponse(analyses))
+	})
 	// swagger:route GET /syms/ipsw Syms getIPSW
 	//
 	// IPSW
@@ -345,6 +416,7 @@ func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
 		uuid := c.Param("uuid")
 		addr := c.Param("addr")
 		sym, err := syms.GetForAddr(uuid, cast.ToUint64(addr), db)
+		metrics.SymbolLookups.WithLabelValues("rest", lookupResult(err)).Inc()
 		if err != nil {
 			if errors.Is(err, model.ErrNotFound) {
 				c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: err.Error()})
@@ -370,13 +442,27 @@ func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
 	//         description: file UUID
 	//         required: true
 	//         type: string
+	//       + name: pattern
+	//         in: query
+	//         description: only return the symbols whose name matches the regex
+	//         required: false
+	//         type: string
 	//
 	//     Responses:
 	//       200: symsResponse
 	//       500: genericError
 	rg.GET("/syms/:uuid", func(c *gin.Context) {
 		uuid := c.Param("uuid")
+		var re *regexp.Regexp
+		if pattern := c.Query("pattern"); len(pattern) > 0 {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, types.GenericError{Error: fmt.Sprintf("invalid 'pattern' regex: %v", err)})
+				return
+			}
+		}
 		syms, err := syms.Get(uuid, db)
+		metrics.SymbolLookups.WithLabelValues("rest", lookupResult(err)).Inc()
 		if err != nil {
 			if errors.Is(err, model.ErrNotFound) {
 				c.AbortWithStatusJSON(http.StatusNotFound, types.GenericError{Error: err.Error()})
@@ -385,6 +471,23 @@ func AddRoutes(rg *gin.RouterGroup, db db.Database, pemDB, sigsDir string) {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, types.GenericError{Error: err.Error()})
 			return
 		}
+		if re != nil {
+			syms = slices.DeleteFunc(syms, func(sym *model.Symbol) bool {
+				return !re.MatchString(sym.GetName())
+			})
+		}
 		c.JSON(http.StatusOK, symsResponse(syms))
 	})
 }
+
+// lookupResult is the result label of a symbol lookup
+func lookupResult(err error) string {
+	switch {
+	case err == nil:
+		return "found"
+	case errors.Is(err, model.ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
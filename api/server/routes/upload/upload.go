@@ -0,0 +1,43 @@
+// Package upload resolves route inputs that can be either a path on the server or an uploaded file
+package upload
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// File returns the path to the route's input file named field.
+//
+// If the request is a multipart form with a file for field it is saved to a temporary file,
+// otherwise the field's query (or form) value is used as a path on the server.
+// The returned cleanup func removes any temporary file and must always be called.
+func File(c *gin.Context, field string) (string, func(), error) {
+	if fh, err := c.FormFile(field); err == nil {
+		tmp, err := os.MkdirTemp("", "ipswd_upload_")
+		if err != nil {
+			return "", func() {}, fmt.Errorf("failed to create upload dir: %w", err)
+		}
+		cleanup := func() { os.RemoveAll(tmp) }
+		path := filepath.Join(tmp, filepath.Base(fh.Filename))
+		if err := c.SaveUploadedFile(fh, path); err != nil {
+			cleanup()
+			return "", func() {}, fmt.Errorf("failed to save uploaded '%s' file: %w", field, err)
+		}
+		return path, cleanup, nil
+	}
+	path := c.Query(field)
+	if len(path) == 0 {
+		path = c.PostForm(field)
+	}
+	if len(path) == 0 {
+		return "", func() {}, fmt.Errorf("'%s' is required (a path on the server or an uploaded file)", field)
+	}
+	return filepath.Clean(path), func() {}, nil
+}
+
+// Methods are the HTTP methods analysis routes are served on (GET for server paths and POST for uploads)
+var Methods = []string{http.MethodGet, http.MethodPost}
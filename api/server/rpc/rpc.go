@@ -0,0 +1,308 @@
+// Package rpc implements the ipswd gRPC API
+package rpc
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/api"
+	pb "github.com/blacktop/ipsw/api/proto/ipswd/v1"
+	"github.com/blacktop/ipsw/api/types"
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+const defaultWatchInterval = time.Second
+
+// Server is the ipswd gRPC service
+type Server struct {
+	pb.UnimplementedIpswdServer
+	db    db.Database
+	pemDB string
+}
+
+// NewServer creates a gRPC server serving the ipswd service (the symbol and download queue RPCs require a database)
+func NewServer(d db.Database, pemDB string, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	pb.RegisterIpswdServer(s, &Server{db: d, pemDB: pemDB})
+	return s
+}
+
+func (s *Server) requireDB() error {
+	if s.db == nil {
+		return status.Error(codes.FailedPrecondition, "ipswd is not configured with a database")
+	}
+	return nil
+}
+
+func dbError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, model.ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// lookupResult is the result label of a symbol lookup
+func lookupResult(err error) string {
+	switch {
+	case err == nil:
+		return "found"
+	case status.Code(dbError(err)) == codes.NotFound:
+		return "not_found"
+	default:
+		return "error"
+	}
+}
+
+// Version returns the daemon's API version
+func (s *Server) Version(context.Context, *pb.VersionRequest) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{
+		ApiVersion:     api.DefaultVersion,
+		OsType:         runtime.GOOS,
+		BuilderVersion: types.BuildVersion,
+	}, nil
+}
+
+// GetMachO returns a MachO's info
+func (s *Server) GetMachO(_ context.Context, req *pb.GetMachORequest) (*pb.MachO, error) {
+	if len(req.GetPath()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	path := filepath.Clean(req.GetPath())
+
+	var m *macho.File
+	fat, err := macho.OpenFat(path)
+	if err == nil {
+		defer fat.Close()
+		if len(req.GetArch()) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "arch is required for universal binaries")
+		}
+		for _, farch := range fat.Arches {
+			if strings.EqualFold(farch.SubCPU.String(farch.CPU), req.GetArch()) {
+				m = farch.File
+			}
+		}
+		if m == nil {
+			return nil, status.Errorf(codes.NotFound, "arch '%s' not found in universal binary", req.GetArch())
+		}
+	} else if err == macho.ErrNotFat {
+		if m, err = macho.Open(path); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		defer m.Close()
+	} else {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	info := &pb.MachO{
+		Path:     path,
+		Arch:     m.SubCPU.String(m.CPU),
+		FileType: m.Type.String(),
+		Imports:  m.ImportedLibraries(),
+	}
+	if uuid := m.UUID(); uuid != nil {
+		info.Uuid = uuid.String()
+	}
+	if sv := m.SourceVersion(); sv != nil {
+		info.SourceVersion = sv.String()
+	}
+	for _, sec := range m.Sections {
+		info.Sections = append(info.Sections, &pb.Section{
+			Segment: sec.Seg,
+			Name:    sec.Name,
+			Addr:    sec.Addr,
+			Size:    sec.Size,
+			Offset:  sec.Offset,
+		})
+	}
+	if m.Symtab != nil {
+		info.SymbolCount = uint32(len(m.Symtab.Syms))
+	}
+
+	return info, nil
+}
+
+// GetSymbols streams the symbols of a MachO/DSC in the symbol database
+func (s *Server) GetSymbols(req *pb.GetSymbolsRequest, stream grpc.ServerStreamingServer[pb.Symbol]) error {
+	if err := s.requireDB(); err != nil {
+		return err
+	}
+	syms, err := s.db.GetSymbols(req.GetUuid())
+	metrics.SymbolLookups.WithLabelValues("grpc", lookupResult(err)).Inc()
+	if err != nil {
+		return dbError(err)
+	}
+	for _, sym := range syms {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(&pb.Symbol{Name: sym.GetName(), Start: sym.Start, End: sym.End}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupSymbol returns the symbol containing an address in the symbol database
+func (s *Server) LookupSymbol(_ context.Context, req *pb.LookupSymbolRequest) (*pb.Symbol, error) {
+	if err := s.requireDB(); err != nil {
+		return nil, err
+	}
+	sym, err := s.db.GetSymbol(req.GetUuid(), req.GetAddr())
+	metrics.SymbolLookups.WithLabelValues("grpc", lookupResult(err)).Inc()
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return &pb.Symbol{Name: sym.GetName(), Start: sym.Start, End: sym.End}, nil
+}
+
+func toDiff(diff *mcmd.MachoDiff) *pb.Diff {
+	return &pb.Diff{New: diff.New, Removed: diff.Removed, Updated: diff.Updated}
+}
+
+// DiffMachOs diffs two MachOs
+func (s *Server) DiffMachOs(_ context.Context, req *pb.DiffMachOsRequest) (*pb.Diff, error) {
+	if len(req.GetPrev()) == 0 || len(req.GetCurr()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "prev and curr are required")
+	}
+	conf := &mcmd.DiffConfig{CStrings: req.GetCstrings()}
+	infos := make([]map[string]*mcmd.DiffInfo, 0, 2)
+	name := filepath.Base(req.GetCurr())
+	for _, path := range []string{req.GetPrev(), req.GetCurr()} {
+		m, err := macho.Open(filepath.Clean(path))
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		infos = append(infos, map[string]*mcmd.DiffInfo{name: mcmd.GenerateDiffInfo(m, conf)})
+		m.Close()
+	}
+	diff := &mcmd.MachoDiff{Updated: make(map[string]string)}
+	if err := diff.Generate(infos[0], infos[1], conf); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toDiff(diff), nil
+}
+
+// DiffIPSWs diffs the MachOs (or IM4P firmwares) of two IPSWs
+func (s *Server) DiffIPSWs(_ context.Context, req *pb.DiffIPSWsRequest) (*pb.Diff, error) {
+	if len(req.GetPrev()) == 0 || len(req.GetCurr()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "prev and curr are required")
+	}
+	conf := &mcmd.DiffConfig{
+		CStrings:  req.GetCstrings(),
+		AllowList: req.GetAllowList(),
+		BlockList: req.GetBlockList(),
+		PemDB:     req.GetPemDb(),
+	}
+	if conf.PemDB == "" && s.pemDB != "" {
+		conf.PemDB = filepath.Clean(s.pemDB)
+	}
+	prev, curr := filepath.Clean(req.GetPrev()), filepath.Clean(req.GetCurr())
+
+	var diff *mcmd.MachoDiff
+	var err error
+	if req.GetFirmware() {
+		diff, err = mcmd.DiffFirmwares(prev, curr, conf)
+	} else {
+		diff, err = mcmd.DiffIPSW(prev, curr, conf)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toDiff(diff), nil
+}
+
+var queueStates = map[model.QueueStatus]pb.DownloadState{
+	model.QueuePending: pb.DownloadState_DOWNLOAD_STATE_PENDING,
+	model.QueueRunning: pb.DownloadState_DOWNLOAD_STATE_RUNNING,
+	model.QueueDone:    pb.DownloadState_DOWNLOAD_STATE_DONE,
+	model.QueueFailed:  pb.DownloadState_DOWNLOAD_STATE_FAILED,
+}
+
+func queueStatus(state pb.DownloadState) model.QueueStatus {
+	for qs, ds := range queueStates {
+		if ds == state {
+			return qs
+		}
+	}
+	return "" // all items
+}
+
+func toDownloadStatus(item *model.QueueItem) *pb.DownloadStatus {
+	return &pb.DownloadStatus{
+		Id:          uint64(item.ID),
+		Device:      item.Device,
+		Build:       item.Build,
+		Version:     item.Version,
+		State:       queueStates[item.Status],
+		Attempts:    int32(item.Attempts),
+		Error:       item.Error,
+		Path:        item.Path,
+		NextAttempt: timestamppb.New(item.NextAttempt),
+		UpdatedAt:   timestamppb.New(item.UpdatedAt),
+	}
+}
+
+// GetDownloadQueue returns the download queue
+func (s *Server) GetDownloadQueue(_ context.Context, req *pb.GetDownloadQueueRequest) (*pb.DownloadQueue, error) {
+	if err := s.requireDB(); err != nil {
+		return nil, err
+	}
+	items, err := s.db.GetQueue(queueStatus(req.GetState()))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	queue := &pb.DownloadQueue{}
+	for _, item := range items {
+		queue.Items = append(queue.Items, toDownloadStatus(item))
+	}
+	return queue, nil
+}
+
+// WatchDownloadQueue streams download queue items as their status changes (starting with the current queue)
+func (s *Server) WatchDownloadQueue(req *pb.WatchDownloadQueueRequest, stream grpc.ServerStreamingServer[pb.DownloadStatus]) error {
+	if err := s.requireDB(); err != nil {
+		return err
+	}
+	interval := defaultWatchInterval
+	if req.GetIntervalMs() > 0 {
+		interval = time.Duration(req.GetIntervalMs()) * time.Millisecond
+	}
+	filter := queueStatus(req.GetState())
+
+	seen := make(map[uint]time.Time)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		items, err := s.db.GetQueue(filter)
+		if err != nil {
+			return dbError(err)
+		}
+		for _, item := range items {
+			if last, ok := seen[item.ID]; ok && last.Equal(item.UpdatedAt) {
+				continue
+			}
+			seen[item.ID] = item.UpdatedAt
+			if err := stream.Send(toDownloadStatus(item)); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
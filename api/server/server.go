@@ -20,6 +20,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -28,17 +29,41 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/api"
+	"github.com/blacktop/ipsw/api/openapi"
+	"github.com/blacktop/ipsw/api/server/audit"
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/api/server/cache"
+	"github.com/blacktop/ipsw/api/server/health"
+	"github.com/blacktop/ipsw/api/server/limits"
+	"github.com/blacktop/ipsw/api/server/mtls"
 	"github.com/blacktop/ipsw/api/server/routes"
 	"github.com/blacktop/ipsw/api/server/routes/aea"
+	"github.com/blacktop/ipsw/api/server/routes/artifacts"
+	jobsroutes "github.com/blacktop/ipsw/api/server/routes/jobs"
+	"github.com/blacktop/ipsw/api/server/routes/pipelines"
 	"github.com/blacktop/ipsw/api/server/routes/syms"
+	"github.com/blacktop/ipsw/api/server/rpc"
+	"github.com/blacktop/ipsw/api/server/ui"
 	"github.com/blacktop/ipsw/api/types"
 	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/pipeline"
+	"github.com/blacktop/ipsw/internal/plugin"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/blacktop/ipsw/internal/syms/queue"
+	"github.com/blacktop/ipsw/internal/webhook"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Config is the server config
@@ -50,19 +75,58 @@ type Config struct {
 	LogFile string
 	PemDB   string
 	SigsDir string
+	// GRPCPort is the port to serve the gRPC API on (disabled if 0)
+	GRPCPort int
+	// JobRetries is the number of attempts per background job before it is marked as failed
+	JobRetries int
+	// Auth is the API-key/JWT auth config (auth is disabled if empty)
+	Auth auth.Config
+	// Webhooks are POSTed the job and watch events
+	Webhooks []webhook.Config
+	// Watch is the new build watcher config (disabled if there are no devices)
+	Watch         download.WatchConfig
+	WatchInterval time.Duration
+	// Storage is where the job artifacts are uploaded (disabled if the driver is empty)
+	Storage storage.Config
+	// Cluster shares the jobs with other daemons using the same database (disabled if the role is empty)
+	Cluster jobs.ClusterConfig
+	// Pipelines are the pipeline files (or folders of them) to run
+	Pipelines []string
+	// DisableUI disables the web UI
+	DisableUI bool
+	// Plugins are the folders the plugins (run by the symbol scans) are loaded from
+	Plugins []string
+	// Cache is the analysis response cache config
+	Cache cache.Config
+	// Audit is the audit log config
+	Audit audit.Config
+	// Limits are the request rate limits and download/storage quotas of the API keys
+	Limits limits.Config
+	// TrustedProxies are the proxies whose X-Forwarded-For header is trusted for the client IP (none if empty)
+	TrustedProxies []string
+	// Health is the readiness check config
+	Health health.Config
+	// TLS is the TLS (and mutual-TLS) config of the REST and gRPC listeners (disabled if there is no cert)
+	TLS mtls.Config
+	// ScanQueue is the symbol scan queue worker pool config
+	ScanQueue queue.Config
 }
 
 // Server is the main server struct
 type Server struct {
 	router *gin.Engine
 	server *http.Server
+	grpc   *grpc.Server
+	jobs   *jobs.Manager
+	hooks  *webhook.Notifier
+	runner *pipeline.Runner
 	conf   *Config
 }
 
 // NewServer creates a new server
 func NewServer(conf *Config) *Server {
 	return &Server{
-		router: gin.Default(),
+		router: gin.New(),
 		conf:   conf,
 	}
 }
@@ -83,6 +147,35 @@ func (s *Server) Start(db db.Database) error {
 		}
 		gin.DefaultWriter = io.MultiWriter(f, os.Stdout)
 	}
+	s.router.Use(logger(), gin.Recovery())
+
+	authn, err := auth.NewAuthenticator(s.conf.Auth)
+	if err != nil {
+		return fmt.Errorf("server: invalid auth config: %v", err)
+	}
+	tlsConf, err := mtls.New(s.conf.TLS)
+	if err != nil {
+		return fmt.Errorf("server: invalid TLS config: %v", err)
+	}
+	if authn == nil && !s.conf.TLS.Mutual() && len(s.conf.Socket) == 0 && s.conf.Host != "localhost" && s.conf.Host != "127.0.0.1" {
+		log.Warnf("server: auth is disabled and the API is listening on '%s' (anyone who can reach it has full access)", s.conf.Host)
+	}
+	responses, err := cache.New(s.conf.Cache)
+	if err != nil {
+		return fmt.Errorf("server: invalid cache config: %v", err)
+	}
+	auditor, err := audit.New(s.conf.Audit, db)
+	if err != nil {
+		return fmt.Errorf("server: invalid audit config: %v", err)
+	}
+	limiter, err := limits.New(s.conf.Limits)
+	if err != nil {
+		return fmt.Errorf("server: invalid limits config: %v", err)
+	}
+	if err := s.router.SetTrustedProxies(s.conf.TrustedProxies); err != nil {
+		return fmt.Errorf("server: invalid trusted proxies: %v", err)
+	}
+	s.router.Use(instrument(), auditor.Middleware(), authn.Middleware(), limiter.Middleware(), auditor.Inputs(), responses.Middleware())
 
 	s.router.GET("/version", func(c *gin.Context) {
 		c.JSON(http.StatusOK, types.Version{
@@ -92,12 +185,123 @@ func (s *Server) Start(db db.Database) error {
 		})
 	})
 
+	// swagger:route GET /metrics Daemon getMetrics
+	//
+	// Metrics
+	//
+	// This will return the daemon metrics in the Prometheus text format.
+	//
+	//     Produces:
+	//     - text/plain
+	//
+	//     Responses:
+	//       200:
+	s.router.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+	// swagger:route GET /openapi.json Daemon getOpenAPI
+	//
+	// OpenAPI
+	//
+	// This will return the ipswd OpenAPI 3 document.
+	//
+	//     Responses:
+	//       200:
+	s.router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openapi.Spec)
+	})
+
+	if !s.conf.DisableUI {
+		if err := ui.AddRoutes(s.router); err != nil {
+			return fmt.Errorf("server: failed to add UI routes: %v", err)
+		}
+	}
+
 	rg := s.router.Group("/v" + api.DefaultVersion)
 
 	routes.Add(rg, s.conf.PemDB)
+	auth.AddRoutes(rg)
+	if responses != nil {
+		cache.AddRoutes(rg, responses)
+		go s.pruneCache(ctx, responses)
+	}
+	if limiter != nil {
+		limits.AddRoutes(rg, limiter)
+	}
+	if auditor != nil {
+		audit.AddRoutes(rg, auditor)
+		go s.pruneAudit(ctx, auditor)
+	}
+
+	hooks, err := webhook.NewNotifier(s.conf.Webhooks)
+	if err != nil {
+		return fmt.Errorf("server: %v", err)
+	}
+	s.hooks = hooks
+
+	s.jobs = jobs.NewManager(db, jobs.Config{
+		Retries: s.conf.JobRetries,
+		Cluster: s.conf.Cluster,
+		Finished: func(st jobs.Status) {
+			jobFinished(st)
+			s.hooks.Notify("job."+string(st.State), st)
+		},
+	})
+	s.collectQueues(db)
+
+	store, err := storage.New(s.conf.Storage)
+	if err != nil {
+		return fmt.Errorf("server: invalid storage config: %v", err)
+	}
+	if store != nil {
+		artifacts.AddRoutes(rg, store)
+	}
+	limiter.SetStorage(s.jobs, store)
+	paths := []string{os.TempDir(), s.conf.Cache.Dir}
+	if s.conf.Storage.Driver == "local" {
+		paths = append(paths, s.conf.Storage.Path)
+	}
+	checker, err := health.New(s.conf.Health, db, store, paths...)
+	if err != nil {
+		return fmt.Errorf("server: invalid health config: %v", err)
+	}
+	health.AddRoutes(s.router, checker)
+	if s.conf.Cluster.Role != jobs.RoleStandalone && s.conf.Storage.Driver == "local" {
+		log.Warn("server: the daemons of a cluster should share an s3/gcs storage (the local storage only has the artifacts of this daemon's jobs)")
+	}
+	plugins, err := plugin.Load(s.conf.Plugins...)
+	if err != nil {
+		return fmt.Errorf("server: failed to load plugins: %v", err)
+	}
+	for _, p := range plugins {
+		log.WithFields(log.Fields{"name": p.Name, "version": p.Version}).Info("Loaded plugin")
+	}
+	jobsroutes.AddRoutes(rg, s.jobs, db, store, s.conf.PemDB, s.conf.SigsDir, plugins, limiter)
+	defs, err := pipeline.Load(s.conf.Pipelines...)
+	if err != nil {
+		return fmt.Errorf("server: failed to load pipelines: %v", err)
+	}
+	s.runner, err = pipeline.NewRunner(defs, s.jobs, func(r pipeline.Report) {
+		s.hooks.Notify(webhook.PipelineReport, r)
+	})
+	if err != nil {
+		return fmt.Errorf("server: invalid pipeline: %v", err)
+	}
+	pipelines.AddRoutes(rg, s.runner)
+	if err := s.jobs.Resume(); err != nil {
+		return fmt.Errorf("server: failed to resume jobs: %v", err)
+	}
 
 	if db != nil {
-		syms.AddRoutes(rg, db, s.conf.PemDB, s.conf.SigsDir)
+		syms.AddRoutes(rg, db, s.conf.PemDB, s.conf.SigsDir, plugins)
+		feeder := queue.New(s.conf.ScanQueue, db, s.conf.PemDB, s.conf.SigsDir, plugins)
+		feeder.Downloaded = func(item *model.ScanItem, n uint64) error {
+			return limiter.Reserve(item.Owner, limits.Download, n)
+		}
+		syms.AddQueueRoutes(rg, db, feeder)
+		go func() {
+			if err := feeder.Run(ctx); err != nil {
+				log.WithError(err).Error("server: symbol scan queue stopped")
+			}
+		}()
 	}
 
 	if s.conf.PemDB != "" {
@@ -105,8 +309,9 @@ func (s *Server) Start(db db.Database) error {
 	}
 
 	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.conf.Port),
-		Handler: s.router,
+		Addr:      fmt.Sprintf("%s:%d", s.conf.Host, s.conf.Port),
+		Handler:   s.router,
+		TLSConfig: tlsConf,
 	}
 
 	go func() {
@@ -115,9 +320,17 @@ func (s *Server) Start(db db.Database) error {
 			if err != nil {
 				log.Fatalf("server: failed to listen: %v\n", err)
 			}
+			if tlsConf != nil {
+				l = tls.NewListener(l, tlsConf)
+			}
 			if err := s.server.Serve(l); err != nil && err != http.ErrServerClosed {
 				log.Fatalf("server: failed to serve: %v\n", err)
 			}
+		} else if tlsConf != nil {
+			// the certificate is served by the TLS config's GetCertificate (so it can be reloaded)
+			if err := s.server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("server: failed to listen and serve TLS: %v\n", err)
+			}
 		} else {
 			if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				log.Fatalf("server: failed to listen and serve: %v\n", err)
@@ -125,6 +338,40 @@ func (s *Server) Start(db db.Database) error {
 		}
 	}()
 
+	if s.conf.GRPCPort > 0 {
+		l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.conf.Host, s.conf.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("server: failed to listen for gRPC: %v", err)
+		}
+		opts := append(authn.ServerOptions(), limiter.ServerOptions()...)
+		if tlsConf != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+		}
+		s.grpc = rpc.NewServer(db, s.conf.PemDB, opts...)
+		go func() {
+			if err := s.grpc.Serve(l); err != nil && err != grpc.ErrServerStopped {
+				log.Fatalf("server: failed to serve gRPC: %v\n", err)
+			}
+		}()
+	}
+
+	for _, device := range s.runner.Devices() {
+		if !slices.Contains(s.conf.Watch.Devices, device) {
+			s.conf.Watch.Devices = append(s.conf.Watch.Devices, device)
+		}
+	}
+	if len(s.conf.Watch.Devices) > 0 {
+		w, err := download.NewWatcher(s.conf.Watch)
+		if err != nil {
+			return fmt.Errorf("server: failed to create watcher: %v", err)
+		}
+		go s.watch(ctx, w)
+	}
+
+	if store != nil && s.conf.Storage.Retention > 0 {
+		go s.prune(ctx, store)
+	}
+
 	// Listen for the interrupt signal.
 	<-ctx.Done()
 
@@ -143,6 +390,25 @@ func (s *Server) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if s.jobs != nil {
+		s.jobs.Close()
+	}
+	s.hooks.Close()
+
+	if s.grpc != nil {
+		// watch streams only end when their client hangs up, so don't wait on them forever
+		done := make(chan struct{})
+		go func() {
+			s.grpc.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			s.grpc.Stop()
+		}
+	}
+
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %v", err)
 	}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/api/server/audit"
+	"github.com/blacktop/ipsw/api/server/cache"
+	"github.com/blacktop/ipsw/internal/storage"
+)
+
+// prune deletes the artifacts older than the storage retention period (checking hourly) until ctx is canceled
+func (s *Server) prune(ctx context.Context, store storage.Backend) {
+	for {
+		deleted, err := storage.Prune(ctx, store, s.conf.Storage.Retention)
+		if err != nil {
+			log.WithError(err).Error("storage: prune failed")
+		}
+		if deleted > 0 {
+			log.WithField("retention", s.conf.Storage.Retention).Infof("Deleted %d expired artifact(s)", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
+
+// pruneCache deletes the expired cached responses from disk (checking hourly) until ctx is canceled
+func (s *Server) pruneCache(ctx context.Context, c *cache.Cache) {
+	for {
+		deleted, err := c.Prune()
+		if err != nil {
+			log.WithError(err).Error("cache: prune failed")
+		}
+		if deleted > 0 {
+			log.Debugf("Deleted %d expired cached response(s)", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
+
+// pruneAudit deletes the audit log entries older than the audit retention period (checking hourly) until ctx is canceled
+func (s *Server) pruneAudit(ctx context.Context, l *audit.Logger) {
+	for {
+		deleted, err := l.Prune()
+		if err != nil {
+			log.WithError(err).Error("audit: prune failed")
+		}
+		if deleted > 0 {
+			log.WithField("retention", s.conf.Audit.Retention).Infof("Deleted %d expired audit log entries", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
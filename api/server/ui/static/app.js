@@ -0,0 +1,380 @@
+// ipswd web UI: a dependency free frontend for the daemon's REST API
+"use strict";
+
+const API = "/v1";
+const MAX_ROWS = 1000;
+
+/* API */
+
+function token() {
+    return localStorage.getItem("ipswd.token") || "";
+}
+
+async function api(path, opts = {}) {
+    const headers = Object.assign({}, opts.headers);
+    if (token()) {
+        headers["Authorization"] = "Bearer " + token();
+    }
+    if (opts.body !== undefined) {
+        headers["Content-Type"] = "application/json";
+        opts.body = JSON.stringify(opts.body);
+    }
+    const resp = await fetch(API + path, Object.assign({}, opts, { headers }));
+    const text = await resp.text();
+    let data = text;
+    try {
+        data = JSON.parse(text);
+    } catch (e) { /* not JSON */ }
+    if (!resp.ok) {
+        throw new Error((data && data.error) || resp.status + " " + resp.statusText);
+    }
+    return data;
+}
+
+// withToken adds the credentials to an EventSource URL (it can't send headers)
+function withToken(url) {
+    if (!token()) {
+        return url;
+    }
+    return url + (url.includes("?") ? "&" : "?") + "access_token=" + encodeURIComponent(token());
+}
+
+/* DOM */
+
+const $ = (id) => document.getElementById(id);
+
+function el(tag, text, attrs = {}) {
+    const e = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+        e.textContent = text;
+    }
+    for (const [k, v] of Object.entries(attrs)) {
+        if (k === "onclick") {
+            e.addEventListener("click", v);
+        } else {
+            e.setAttribute(k, v);
+        }
+    }
+    return e;
+}
+
+function row(...cells) {
+    const tr = el("tr");
+    for (const c of cells) {
+        const td = c instanceof Node ? el("td") : el("td", c);
+        if (c instanceof Node) {
+            td.appendChild(c);
+        }
+        tr.appendChild(td);
+    }
+    return tr;
+}
+
+function fill(tbody, rows) {
+    tbody.replaceChildren(...rows.slice(0, MAX_ROWS));
+}
+
+function showError(err) {
+    $("error").textContent = err ? err.message || String(err) : "";
+    $("error").hidden = !err;
+}
+
+// guard runs an async UI action and shows its error
+function guard(fn) {
+    return async (e) => {
+        if (e && e.preventDefault) {
+            e.preventDefault();
+        }
+        showError(null);
+        try {
+            await fn(e);
+        } catch (err) {
+            showError(err);
+        }
+    };
+}
+
+function hex(n) {
+    return "0x" + Number(n).toString(16);
+}
+
+function date(s) {
+    return s ? new Date(s).toLocaleString() : "";
+}
+
+/* TABS */
+
+const loaders = {};
+
+function showTab() {
+    const tab = (location.hash || "#ipsws").slice(1).split("?")[0];
+    for (const s of document.querySelectorAll("main section")) {
+        s.hidden = s.id !== tab;
+    }
+    for (const a of document.querySelectorAll("nav a")) {
+        a.classList.toggle("active", a.dataset.tab === tab);
+    }
+    if (loaders[tab]) {
+        guard(loaders[tab])();
+    }
+}
+
+/* AUTH */
+
+async function whoami() {
+    try {
+        const id = await api("/auth/whoami");
+        $("whoami").textContent = (id.name ? id.name + " " : "") + "(" + id.role + ")";
+    } catch (err) {
+        $("whoami").textContent = err.message;
+    }
+}
+
+$("auth").addEventListener("submit", (e) => {
+    e.preventDefault();
+    localStorage.setItem("ipswd.token", $("api-key").value.trim());
+    $("api-key").value = "";
+    whoami();
+    showTab();
+});
+
+/* IPSWS */
+
+let ipsws = [];
+
+function uuidLink(uuid) {
+    return el("a", uuid, {
+        href: "#symbols",
+        onclick: () => {
+            $("symbols-uuid").value = uuid;
+        },
+    });
+}
+
+function uuidList(items) {
+    const div = el("div");
+    for (const i of items || []) {
+        const line = el("div");
+        line.appendChild(uuidLink(i.uuid));
+        if (i.version) {
+            line.appendChild(el("span", " " + i.version, { class: "hint" }));
+        }
+        div.appendChild(line);
+    }
+    return div;
+}
+
+function renderIPSWs() {
+    const q = $("ipsws-filter").value.toLowerCase();
+    fill($("ipsws-rows"), ipsws
+        .filter((i) => !q || [i.name, i.version, i.buildid, ...(i.devices || []).map((d) => d.name)]
+            .some((s) => (s || "").toLowerCase().includes(q)))
+        .map((i) => row(
+            i.name,
+            i.version,
+            i.buildid,
+            (i.devices || []).map((d) => d.name).join(", "),
+            uuidList(i.kernels),
+            uuidList(i.dscs),
+        )));
+}
+
+loaders.ipsws = async () => {
+    ipsws = await api("/syms/ipsws");
+    renderIPSWs();
+};
+
+$("ipsws-filter").addEventListener("input", renderIPSWs);
+
+/* SYMBOLS */
+
+$("symbols-form").addEventListener("submit", guard(async () => {
+    const uuid = encodeURIComponent($("symbols-uuid").value.trim());
+    const addr = $("symbols-addr").value.trim();
+    let syms;
+    if (addr) {
+        syms = [await api("/syms/" + uuid + "/" + encodeURIComponent(addr))];
+    } else {
+        const pattern = $("symbols-pattern").value.trim();
+        syms = await api("/syms/" + uuid + (pattern ? "?pattern=" + encodeURIComponent(pattern) : ""));
+    }
+    syms = syms || [];
+    $("symbols-count").textContent = syms.length + " symbols" + (syms.length > MAX_ROWS ? " (showing the first " + MAX_ROWS + ")" : "");
+    fill($("symbols-rows"), syms.map((s) => {
+        const tr = row((s.Name && s.Name.name) || "", hex(s.start), hex(s.end));
+        tr.firstChild.className = "mono";
+        return tr;
+    }));
+}));
+
+/* ENTITLEMENTS */
+
+$("ents-form").addEventListener("submit", guard(async () => {
+    const params = new URLSearchParams({ ipsw: $("ents-ipsw").value.trim() });
+    if ($("ents-key").value) {
+        params.set("key", $("ents-key").value);
+    }
+    if ($("ents-value").value) {
+        params.set("value", $("ents-value").value);
+    }
+    $("ents-count").textContent = "Searching...";
+    const res = await api("/ent/search?" + params.toString());
+    const matches = res.matches || [];
+    $("ents-count").textContent = matches.length + " matches";
+    fill($("ents-rows"), matches.map((m) => {
+        const value = typeof m.value === "object" ? JSON.stringify(m.value, null, 1) : String(m.value);
+        const tr = row(m.file, m.key, value);
+        tr.lastChild.className = "mono";
+        return tr;
+    }));
+}));
+
+$("ents-diff-form").addEventListener("submit", guard(async () => {
+    $("ents-diff").textContent = "Diffing...";
+    const res = await api("/ent/diff", {
+        method: "POST",
+        body: { prev: $("ents-prev").value.trim(), curr: $("ents-curr").value.trim(), markdown: true },
+    });
+    $("ents-diff").textContent = res.diff || "No differences found";
+}));
+
+/* DIFFS */
+
+async function viewReport(key) {
+    const headers = token() ? { "Authorization": "Bearer " + token() } : {};
+    const resp = await fetch(API + "/artifacts/" + key.split("/").map(encodeURIComponent).join("/"), { headers });
+    if (!resp.ok) {
+        throw new Error("failed to get report: " + resp.status + " " + resp.statusText);
+    }
+    if (key.endsWith(".html")) {
+        const url = URL.createObjectURL(new Blob([await resp.text()], { type: "text/html" }));
+        window.open(url, "_blank", "noopener");
+        setTimeout(() => URL.revokeObjectURL(url), 60000);
+        return;
+    }
+    $("diffs-report").textContent = await resp.text();
+}
+
+function reports(st) {
+    const div = el("div");
+    const res = st.result || {};
+    for (const key of res.objects || []) {
+        const line = el("div");
+        line.appendChild(el("a", key.split("/").pop(), { href: "#diffs", onclick: guard(() => viewReport(key)) }));
+        div.appendChild(line);
+    }
+    if (!(res.objects || []).length) {
+        for (const path of res.artifacts || []) {
+            div.appendChild(el("div", path, { class: "mono" }));
+        }
+    }
+    return div;
+}
+
+loaders.diffs = async () => {
+    const jobs = await api("/jobs");
+    fill($("diffs-rows"), (jobs || [])
+        .filter((j) => j.kind === "diff/ipsw")
+        .map((j) => row(j.id, el("span", j.state, { class: "state-" + j.state }), date(j.finished), reports(j))));
+};
+
+$("diffs-form").addEventListener("submit", guard(async () => {
+    await api("/jobs/diff/ipsw", {
+        method: "POST",
+        body: {
+            old: $("diffs-old").value.trim(),
+            new: $("diffs-new").value.trim(),
+            output: $("diffs-output").value.trim(),
+            format: $("diffs-format").value,
+        },
+    });
+    await loaders.diffs();
+}));
+
+/* JOBS */
+
+let events = null;
+
+function progress(st) {
+    const p = st.progress;
+    if (st.state !== "running" || !p || !p.total) {
+        return "";
+    }
+    return el("progress", null, { value: p.current, max: p.total, title: Math.round(100 * p.current / p.total) + "%" });
+}
+
+function followLog(id) {
+    if (events) {
+        events.close();
+    }
+    $("job-log").hidden = false;
+    $("job-log-id").textContent = id;
+    $("job-log-lines").textContent = "";
+    events = new EventSource(withToken(API + "/jobs/" + encodeURIComponent(id) + "/events"));
+    const append = (line) => {
+        $("job-log-lines").textContent += line + "\n";
+    };
+    events.addEventListener("log", (e) => {
+        const ev = JSON.parse(e.data);
+        append(date(ev.time) + "  " + ev.message);
+    });
+    events.addEventListener("state", (e) => {
+        const ev = JSON.parse(e.data);
+        append(date(ev.time) + "  [" + ev.state + "]" + (ev.message ? " " + ev.message : ""));
+    });
+    events.addEventListener("end", () => {
+        events.close();
+        events = null;
+    });
+    events.onerror = () => {
+        if (events) {
+            events.close();
+            events = null;
+        }
+    };
+}
+
+function jobActions(st) {
+    const div = el("div");
+    div.appendChild(el("button", "Log", { onclick: () => followLog(st.id) }));
+    if (st.state === "pending" || st.state === "running") {
+        div.appendChild(el("button", "Cancel", {
+            class: "danger",
+            onclick: guard(async () => {
+                await api("/jobs/" + encodeURIComponent(st.id), { method: "DELETE" });
+                await loaders.jobs();
+            }),
+        }));
+    }
+    return div;
+}
+
+loaders.jobs = async () => {
+    let jobs = (await api("/jobs")) || [];
+    if ($("jobs-active").checked) {
+        jobs = jobs.filter((j) => j.state === "pending" || j.state === "running");
+    }
+    fill($("jobs-rows"), jobs.map((j) => {
+        const state = el("span", j.state, { class: "state-" + j.state, title: j.error || "" });
+        return row(j.id, j.kind, state, progress(j), String(j.attempts), j.worker || "", date(j.created), jobActions(j));
+    }));
+    const workers = (await api("/workers")) || [];
+    fill($("workers-rows"), workers.map((w) => row(
+        w.id, (w.kinds || ["all"]).join(", "), String(w.running), String(w.capacity), w.alive ? "yes" : "no", date(w.seen_at),
+    )));
+};
+
+$("jobs-active").addEventListener("change", guard(loaders.jobs));
+
+// refresh the jobs while they are shown
+setInterval(() => {
+    if (!document.hidden && !$("jobs").hidden) {
+        loaders.jobs().catch(showError);
+    }
+}, 3000);
+
+/* MAIN */
+
+window.addEventListener("hashchange", showTab);
+whoami();
+showTab();
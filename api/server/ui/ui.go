@@ -0,0 +1,26 @@
+// Package ui serves the embedded ipswd web UI (a static app that calls the REST API)
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Prefix is the path the UI is served under
+const Prefix = "/ui"
+
+//go:embed static
+var static embed.FS
+
+// AddRoutes serves the UI under Prefix
+func AddRoutes(r *gin.Engine) error {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		return err
+	}
+	r.StaticFS(Prefix, http.FS(files))
+	return nil
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/webhook"
+)
+
+// watch polls for new builds of the watched devices (posting them to the webhooks and running the pipelines they trigger)
+// until ctx is canceled
+func (s *Server) watch(ctx context.Context, w *download.Watcher) {
+	interval := s.conf.WatchInterval
+	if interval < time.Minute {
+		interval = time.Hour
+	}
+	for {
+		log.WithField("devices", strings.Join(s.conf.Watch.Devices, ", ")).Debug("Checking for new builds...")
+		builds, err := w.Check()
+		if err != nil {
+			log.WithError(err).Error("watch: check failed")
+		}
+		for _, b := range builds {
+			log.WithFields(log.Fields{
+				"device":  b.Device,
+				"version": b.Version,
+				"build":   b.Build,
+				"source":  b.Source,
+			}).Info("New Build")
+			s.hooks.Notify(webhook.WatchBuild, b)
+			s.runner.Trigger(b)
+			if err := w.Seen(b); err != nil {
+				log.WithError(err).Error("watch: failed to record build")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
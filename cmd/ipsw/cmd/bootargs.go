@@ -0,0 +1,154 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/internal/commands/bootargs"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(bootargsCmd)
+
+	bootargsCmd.Flags().Uint64P("base", "b", 0, "Load address of the iBoot (default: detected or file offsets)")
+	bootargsCmd.Flags().Bool("json", false, "Output as JSON")
+	bootargsCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	viper.BindPFlag("bootargs.base", bootargsCmd.Flags().Lookup("base"))
+	viper.BindPFlag("bootargs.json", bootargsCmd.Flags().Lookup("json"))
+	viper.BindPFlag("bootargs.output", bootargsCmd.Flags().Lookup("output"))
+}
+
+// bootargsCmd represents the bootargs command
+var bootargsCmd = &cobra.Command{
+	Use:     "bootargs <KERNELCACHE|IBOOT>...",
+	Aliases: []string{"nvram"},
+	Short:   "Catalog the boot-args and NVRAM variables read by the kernel and iBoot",
+	Long: heredoc.Doc(`
+		Catalog the boot-args and NVRAM variables (with the code locations reading them) of a build's
+		kernelcache and iBoot to discover their debug switches.
+
+		The strings are cataloged by the function they're passed to: the kernel's PE_parse_boot_argn and
+		PEReadNVRAMProperty family (found by their symbols or, if stripped, by the well-known boot-args
+		they're called with) and iBoot's environment readers (found by the well-known variables).
+		NVRAM variables named with their GUID are cataloged wherever they're referenced.
+
+		NOTE: the kernelcache must be decompressed and the iBoot decrypted.`),
+	Example: heredoc.Doc(`
+		# Catalog a build's kernelcache and iBoot
+		❯ ipsw bootargs kernelcache.release.iPhone16,1 iBoot.d83.RELEASE.im4p
+		# Save the catalog as JSON
+		❯ ipsw bootargs kernelcache.release.iPhone16,1 --json -o 22A3354.json`),
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		catalog := bootargs.NewCatalog()
+
+		for _, path := range args {
+			path = filepath.Clean(path)
+			log.WithField("file", filepath.Base(path)).Info("Scanning")
+			if ok, _ := magic.IsMachO(path); ok {
+				m, err := macho.Open(path)
+				if err != nil {
+					return fmt.Errorf("failed to open kernelcache %s: %v", path, err)
+				}
+				err = catalog.Kernel(m)
+				m.Close()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			if f, err := os.Open(path); err == nil {
+				typ, _, err := img4.ReadIm4pHeader(f)
+				f.Close()
+				if err == nil {
+					if !slices.Contains([]string{"ibot", "ibec", "ibss", "illb", "ibsc"}, typ) {
+						return fmt.Errorf("%s is a '%s' IM4P (expected an iBoot or a decompressed kernelcache)", path, typ)
+					}
+					im4p, err := img4.OpenIm4p(path)
+					if err != nil {
+						return fmt.Errorf("failed to parse IM4P %s: %v", path, err)
+					}
+					if len(im4p.Kbags) > 0 {
+						return fmt.Errorf("%s is encrypted (decrypt it first)", path)
+					}
+					data = im4p.Data
+				}
+			}
+			base := viper.GetUint64("bootargs.base")
+			if !viper.IsSet("bootargs.base") {
+				base = bootargs.IBootBase(data)
+			}
+			log.Debugf("iBoot base %#x", base)
+			if err := catalog.IBoot(data, base); err != nil {
+				return err
+			}
+		}
+
+		write := func(w io.Writer) error {
+			if viper.GetBool("bootargs.json") {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(catalog)
+			}
+			return catalog.Write(w)
+		}
+
+		if output := viper.GetString("bootargs.output"); len(output) > 0 {
+			f, err := os.Create(filepath.Clean(output))
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			if err := write(f); err != nil {
+				return err
+			}
+			log.Infof("Created %s", output)
+			return nil
+		}
+
+		return write(os.Stdout)
+	},
+}
@@ -0,0 +1,189 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/cryptex"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cryptexTypes are the cryptex names by the short names the mount/fs commands use
+var cryptexTypes = map[string]string{
+	"sys": "SystemOS",
+	"app": "AppOS",
+	"exc": "ExclaveOS",
+}
+
+func init() {
+	rootCmd.AddCommand(cryptexCmd)
+	cryptexCmd.AddCommand(cryptexLsCmd)
+	cryptexCmd.AddCommand(cryptexExtractCmd)
+
+	cryptexCmd.PersistentFlags().String("pem-db", "", "AEA pem DB JSON file")
+	cryptexCmd.PersistentFlags().String("key-val", "", "Base64 encoded symmetric encryption key (AEA encrypted OTAs)")
+	viper.BindPFlag("cryptex.pem-db", cryptexCmd.PersistentFlags().Lookup("pem-db"))
+	viper.BindPFlag("cryptex.key-val", cryptexCmd.PersistentFlags().Lookup("key-val"))
+
+	cryptexLsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	viper.BindPFlag("cryptex.ls.json", cryptexLsCmd.Flags().Lookup("json"))
+
+	cryptexExtractCmd.Flags().StringArrayP("type", "t", []string{}, "Cryptex(es) to extract (sys, app or exc; all if not set)")
+	cryptexExtractCmd.Flags().StringP("output", "o", "", "Folder to extract the cryptexes to")
+	cryptexExtractCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"sys", "app", "exc"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cryptexExtractCmd.MarkFlagDirname("output")
+	viper.BindPFlag("cryptex.extract.type", cryptexExtractCmd.Flags().Lookup("type"))
+	viper.BindPFlag("cryptex.extract.output", cryptexExtractCmd.Flags().Lookup("output"))
+}
+
+func cryptexConfig() *cryptex.Config {
+	return &cryptex.Config{
+		PemDB:  viper.GetString("cryptex.pem-db"),
+		OtaKey: viper.GetString("cryptex.key-val"),
+		Output: viper.GetString("cryptex.extract.output"),
+	}
+}
+
+// cryptexCmd represents the cryptex command
+var cryptexCmd = &cobra.Command{
+	Use:   "cryptex",
+	Short: "List and extract the cryptex DMGs in IPSWs and OTAs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// cryptexLsCmd represents the cryptex ls command
+var cryptexLsCmd = &cobra.Command{
+	Use:     "ls <IPSW|OTA>",
+	Aliases: []string{"l"},
+	Short:   "List the cryptexes in an IPSW or OTA",
+	Example: heredoc.Doc(`
+		# List the cryptexes in an IPSW
+		❯ ipsw cryptex ls iPhone16,1_18.0_22A3354_Restore.ipsw
+		# List the cryptexes in an OTA as JSON
+		❯ ipsw cryptex ls --json OTA.zip`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		cryptexes, err := cryptex.List(filepath.Clean(args[0]), cryptexConfig())
+		if err != nil {
+			return fmt.Errorf("failed to list cryptexes: %v", err)
+		}
+
+		if viper.GetBool("cryptex.ls.json") {
+			dat, err := json.MarshalIndent(cryptexes, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal cryptexes: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.DiscardEmptyColumns)
+		for _, c := range cryptexes {
+			var notes string
+			switch {
+			case c.Encrypted:
+				notes = "(AEA encrypted)"
+			case c.Patch:
+				notes = "(RIDIFF patch)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", colorFsMode(c.Name), c.Arch, colorFsSize(humanize.Bytes(c.Size)), c.Path, colorFsTime(notes))
+		}
+		return w.Flush()
+	},
+}
+
+// cryptexExtractCmd represents the cryptex extract command
+var cryptexExtractCmd = &cobra.Command{
+	Use:     "extract <IPSW|OTA>",
+	Aliases: []string{"e"},
+	Short:   "Extract (and decrypt/patch) the cryptex DMGs from an IPSW or OTA",
+	Example: heredoc.Doc(`
+		# Extract and decrypt the SystemOS cryptex from an IPSW
+		❯ ipsw cryptex extract --type sys --pem-db pems.json iPhone16,1_18.0_22A3354_Restore.ipsw
+		# Extract and patch every cryptex in an OTA (macOS only)
+		❯ ipsw cryptex extract -o /tmp/cryptexes OTA.zip`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		want := make(map[string]bool)
+		for _, typ := range viper.GetStringSlice("cryptex.extract.type") {
+			name, ok := cryptexTypes[typ]
+			if !ok {
+				return fmt.Errorf("invalid cryptex type '%s' (must be one of: sys, app or exc)", typ)
+			}
+			want[name] = true
+		}
+
+		conf := cryptexConfig()
+		cryptexes, err := cryptex.List(filepath.Clean(args[0]), conf)
+		if err != nil {
+			return fmt.Errorf("failed to list cryptexes: %v", err)
+		}
+
+		log.Info("Extracting Cryptexes")
+		var found bool
+		for _, c := range cryptexes {
+			if len(want) > 0 && !want[c.Name] {
+				continue
+			}
+			found = true
+			out, err := cryptex.Extract(filepath.Clean(args[0]), c, conf)
+			if err != nil {
+				return fmt.Errorf("failed to extract %s cryptex: %v", c.Name, err)
+			}
+			utils.Indent(log.Info, 2)(fmt.Sprintf("%s\t%s", c.Name, out))
+		}
+		if !found {
+			return fmt.Errorf("no matching cryptexes found in %s", filepath.Base(args[0]))
+		}
+
+		return nil
+	},
+}
@@ -26,12 +26,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	// "sort"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/blacktop/ipsw/internal/magic"
@@ -44,12 +47,15 @@ import (
 
 func init() {
 	rootCmd.AddCommand(deviceTreeCmd)
-	deviceTreeCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	deviceTreeCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	deviceTreeCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	deviceTreeCmd.Flags().BoolP("summary", "s", false, "Output summary only")
 	deviceTreeCmd.Flags().BoolP("json", "j", false, "Output to stdout as JSON")
+	deviceTreeCmd.Flags().Bool("dts", false, "Output to stdout as device tree source (DTS)")
+	deviceTreeCmd.Flags().BoolP("diff", "d", false, "Diff two builds' DeviceTrees")
 	deviceTreeCmd.Flags().BoolP("remote", "r", false, "Extract from URL")
 	deviceTreeCmd.Flags().StringP("filter", "f", "", "Filter DeviceTree to parse (if multiple i.e. macOS)")
+	deviceTreeCmd.MarkFlagsMutuallyExclusive("json", "dts")
 	deviceTreeCmd.MarkZshCompPositionalArgumentFile(1, "DeviceTree*im4p")
 	deviceTreeCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"im4p"}, cobra.ShellCompDirectiveFilterFileExt
@@ -58,15 +64,24 @@ func init() {
 	viper.BindPFlag("dtree.insecure", deviceTreeCmd.Flags().Lookup("insecure"))
 	viper.BindPFlag("dtree.summary", deviceTreeCmd.Flags().Lookup("summary"))
 	viper.BindPFlag("dtree.json", deviceTreeCmd.Flags().Lookup("json"))
+	viper.BindPFlag("dtree.dts", deviceTreeCmd.Flags().Lookup("dts"))
+	viper.BindPFlag("dtree.diff", deviceTreeCmd.Flags().Lookup("diff"))
 	viper.BindPFlag("dtree.remote", deviceTreeCmd.Flags().Lookup("remote"))
 	viper.BindPFlag("dtree.filter", deviceTreeCmd.Flags().Lookup("filter"))
 }
 
 // deviceTreeCmd represents the deviceTree command
 var deviceTreeCmd = &cobra.Command{
-	Use:           "dtree <DeviceTree>",
-	Aliases:       []string{"dt", "devicetree"},
-	Short:         "Parse DeviceTree",
+	Use:     "dtree <DeviceTree>",
+	Aliases: []string{"dt", "devicetree"},
+	Short:   "Parse DeviceTree",
+	Example: heredoc.Doc(`
+		# Print the DeviceTree of an IPSW as device tree source
+		❯ ipsw dtree --dts --filter n104ap iPhone12,1_18.0_22A3354_Restore.ipsw
+		# Diff the DeviceTrees of two builds (new nodes and added/removed/changed properties)
+		❯ ipsw dtree --diff --filter n104ap OLD.ipsw NEW.ipsw
+		# Diff two DeviceTree files as JSON
+		❯ ipsw dtree --diff --json DeviceTree.n104ap.im4p.OLD DeviceTree.n104ap.im4p.NEW`),
 	Args:          cobra.MinimumNArgs(1),
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -76,73 +91,26 @@ var deviceTreeCmd = &cobra.Command{
 			log.SetLevel(log.DebugLevel)
 		}
 
-		dtrees := make(map[string]*devicetree.DeviceTree)
-
-		if viper.GetBool("dtree.remote") {
-			zr, err := download.NewRemoteZipReader(args[0], &download.RemoteConfig{
-				Proxy:    viper.GetString("dtree.proxy"),
-				Insecure: viper.GetBool("dtree.insecure"),
-			})
-			if err != nil {
-				return fmt.Errorf("failed to download DeviceTree: %v", err)
+		dtrees, err := parseDeviceTrees(args[0])
+		if err != nil {
+			return err
+		}
+		if viper.GetBool("dtree.diff") {
+			if len(args) < 2 {
+				return fmt.Errorf("please provide two DeviceTrees to diff")
 			}
-			dtrees, err = devicetree.ParseZipFiles(zr.File)
+			next, err := parseDeviceTrees(args[1])
 			if err != nil {
-				return fmt.Errorf("failed to extract DeviceTree: %v", err)
-			}
-		} else {
-			var dtree *devicetree.DeviceTree
-
-			if ok, _ := magic.IsZip(filepath.Clean(args[0])); ok {
-				zr, err := zip.OpenReader(args[0])
-				if err != nil {
-					return fmt.Errorf("failed to open zip: %v", err)
-				}
-				dtrees, err = devicetree.ParseZipFiles(zr.File)
-				if err != nil {
-					return fmt.Errorf("failed to extract DeviceTree: %v", err)
-				}
-			} else if ok, _ := magic.IsImg3(args[0]); ok {
-				content, err := os.ReadFile(args[0])
-				if err != nil {
-					return fmt.Errorf("failed to read DeviceTree: %v", err)
-				}
-				dtree, err = devicetree.ParseImg3Data(content)
-				if err != nil {
-					return fmt.Errorf("failed to extract DeviceTree: %v", err)
-				}
-				dtrees[args[0]] = dtree
-			} else if ok, _ := magic.IsIm4p(args[0]); ok {
-				content, err := os.ReadFile(args[0])
-				if err != nil {
-					return fmt.Errorf("failed to read DeviceTree: %v", err)
-				}
-				dtree, err = devicetree.ParseImg4Data(content)
-				if err != nil {
-					return fmt.Errorf("failed to extract DeviceTree: %v", err)
-				}
-				dtrees[args[0]] = dtree
-			} else {
-				content, err := os.ReadFile(args[0])
-				if err != nil {
-					return fmt.Errorf("failed to read DeviceTree: %v", err)
-				}
-				dtree, err = devicetree.ParseData(bytes.NewReader(content))
-				if err != nil {
-					return fmt.Errorf("failed to parse DeviceTree: %v", err)
-				}
-				dtrees[args[0]] = dtree
+				return err
 			}
+			return diffDeviceTrees(dtrees, next)
 		}
 
 		for name, dtree := range dtrees {
-			if viper.IsSet("dtree.filter") {
-				if !strings.Contains(strings.ToLower(name), strings.ToLower(viper.GetString("dtree.filter"))) {
-					continue
-				}
-			}
 			log.Infof("DeviceTree: %s", name)
-			if viper.GetBool("dtree.json") {
+			if viper.GetBool("dtree.dts") {
+				fmt.Println(dtree.DTS())
+			} else if viper.GetBool("dtree.json") {
 				// jq '.[ "device-tree" ].children [] | select(.product != null) | .product."product-name"'
 				// jq '.[ "device-tree" ].compatible'
 				// jq '.[ "device-tree" ].model'
@@ -174,3 +142,115 @@ var deviceTreeCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// parseDeviceTrees parses the DeviceTree(s) of an IPSW/OTA (local or remote) or of a DeviceTree file
+func parseDeviceTrees(path string) (map[string]*devicetree.DeviceTree, error) {
+	dtrees := make(map[string]*devicetree.DeviceTree)
+
+	if viper.GetBool("dtree.remote") {
+		zr, err := download.NewRemoteZipReader(path, &download.RemoteConfig{
+			Proxy:    viper.GetString("dtree.proxy"),
+			Insecure: viper.GetBool("dtree.insecure"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download DeviceTree: %v", err)
+		}
+		dtrees, err = devicetree.ParseZipFiles(zr.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract DeviceTree: %v", err)
+		}
+	} else {
+		var dtree *devicetree.DeviceTree
+
+		if ok, _ := magic.IsZip(filepath.Clean(path)); ok {
+			zr, err := zip.OpenReader(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open zip: %v", err)
+			}
+			dtrees, err = devicetree.ParseZipFiles(zr.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract DeviceTree: %v", err)
+			}
+		} else if ok, _ := magic.IsImg3(path); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read DeviceTree: %v", err)
+			}
+			dtree, err = devicetree.ParseImg3Data(content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract DeviceTree: %v", err)
+			}
+			dtrees[path] = dtree
+		} else if ok, _ := magic.IsIm4p(path); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read DeviceTree: %v", err)
+			}
+			dtree, err = devicetree.ParseImg4Data(content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract DeviceTree: %v", err)
+			}
+			dtrees[path] = dtree
+		} else {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read DeviceTree: %v", err)
+			}
+			dtree, err = devicetree.ParseData(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse DeviceTree: %v", err)
+			}
+			dtrees[path] = dtree
+		}
+	}
+	if viper.IsSet("dtree.filter") {
+		maps.DeleteFunc(dtrees, func(name string, _ *devicetree.DeviceTree) bool {
+			return !strings.Contains(strings.ToLower(name), strings.ToLower(viper.GetString("dtree.filter")))
+		})
+	}
+	return dtrees, nil
+}
+
+// diffDeviceTrees diffs the DeviceTrees of two builds (by name if there are more than one)
+func diffDeviceTrees(prev, next map[string]*devicetree.DeviceTree) error {
+	pairs := make(map[string][2]*devicetree.DeviceTree)
+	if len(prev) == 1 && len(next) == 1 {
+		for name, dt1 := range prev {
+			for _, dt2 := range next {
+				pairs[name] = [2]*devicetree.DeviceTree{dt1, dt2}
+			}
+		}
+	} else {
+		for name, dt1 := range prev {
+			if dt2, ok := next[name]; ok {
+				pairs[name] = [2]*devicetree.DeviceTree{dt1, dt2}
+			} else {
+				log.Warnf("DeviceTree %s not found in second build", name)
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return fmt.Errorf("no DeviceTrees to diff (use --filter to select one of each build)")
+	}
+	diffs := make(map[string]*devicetree.Diff, len(pairs))
+	for _, name := range slices.Sorted(maps.Keys(pairs)) {
+		diffs[name] = devicetree.Compare(pairs[name][0], pairs[name][1])
+		if viper.GetBool("dtree.json") {
+			continue
+		}
+		log.Infof("DeviceTree: %s", name)
+		if diffs[name].Empty() {
+			utils.Indent(log.Info, 2)("No differences found")
+			continue
+		}
+		fmt.Println(diffs[name])
+	}
+	if viper.GetBool("dtree.json") {
+		j, err := json.Marshal(diffs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+	}
+	return nil
+}
@@ -40,22 +40,28 @@ func init() {
 	diffCmd.Flags().StringP("title", "t", "", "Title of the diff")
 	diffCmd.Flags().BoolP("markdown", "m", false, "Save diff as Markdown file")
 	diffCmd.Flags().Bool("json", false, "Save diff as JSON file")
-	diffCmd.Flags().Bool("html", false, "Save diff as HTML file")
+	diffCmd.Flags().Bool("html", false, "Save diff as a self-contained HTML report")
+	diffCmd.Flags().StringArray("template", []string{}, "Save diff as Markdown rendered by these Go templates")
 	diffCmd.Flags().StringArrayP("kdk", "k", []string{}, "Path to KDKs to diff")
-	diffCmd.Flags().Bool("launchd", false, "Diff launchd configs")
+	diffCmd.Flags().Bool("launchd", false, "Diff launchd configs and daemons/agents")
 	diffCmd.Flags().Bool("fw", false, "Diff other firmwares")
 	diffCmd.Flags().Bool("feat", false, "Diff feature flags")
 	diffCmd.Flags().Bool("strs", false, "Diff MachO cstrings")
 	diffCmd.Flags().StringSlice("allow-list", []string{}, "Filter MachO sections to diff (e.g. __TEXT.__text)")
 	diffCmd.Flags().StringSlice("block-list", []string{}, "Remove MachO sections to diff (e.g. __TEXT.__info_plist)")
+	diffCmd.Flags().StringSlice("ent-include", []string{}, "Only diff the entitlements of the binaries whose path matches these regexes")
+	diffCmd.Flags().StringSlice("ent-exclude", []string{}, "Don't diff the entitlements of the binaries whose path matches these regexes")
+	diffCmd.Flags().StringSlice("ent-interesting", []string{}, "Entitlement globs to highlight (defaults to the security-relevant ones)")
 	diffCmd.Flags().StringP("output", "o", "", "Folder to save diff output")
 	diffCmd.MarkFlagDirname("output")
-	diffCmd.MarkFlagsMutuallyExclusive("markdown", "json", "html")
+	diffCmd.MarkFlagFilename("template", "tmpl", "gotmpl", "tpl")
+	diffCmd.MarkFlagsMutuallyExclusive("markdown", "json", "html", "template")
 	viper.BindPFlag("diff.in", diffCmd.Flags().Lookup("in"))
 	viper.BindPFlag("diff.title", diffCmd.Flags().Lookup("title"))
 	viper.BindPFlag("diff.markdown", diffCmd.Flags().Lookup("markdown"))
 	viper.BindPFlag("diff.json", diffCmd.Flags().Lookup("json"))
 	viper.BindPFlag("diff.html", diffCmd.Flags().Lookup("html"))
+	viper.BindPFlag("diff.template", diffCmd.Flags().Lookup("template"))
 	viper.BindPFlag("diff.kdk", diffCmd.Flags().Lookup("kdk"))
 	viper.BindPFlag("diff.launchd", diffCmd.Flags().Lookup("launchd"))
 	viper.BindPFlag("diff.fw", diffCmd.Flags().Lookup("fw"))
@@ -63,6 +69,9 @@ func init() {
 	viper.BindPFlag("diff.strs", diffCmd.Flags().Lookup("strs"))
 	viper.BindPFlag("diff.allow-list", diffCmd.Flags().Lookup("allow-list"))
 	viper.BindPFlag("diff.block-list", diffCmd.Flags().Lookup("block-list"))
+	viper.BindPFlag("diff.ent-include", diffCmd.Flags().Lookup("ent-include"))
+	viper.BindPFlag("diff.ent-exclude", diffCmd.Flags().Lookup("ent-exclude"))
+	viper.BindPFlag("diff.ent-interesting", diffCmd.Flags().Lookup("ent-interesting"))
 	viper.BindPFlag("diff.output", diffCmd.Flags().Lookup("output"))
 }
 
@@ -77,6 +86,13 @@ var diffCmd = &cobra.Command{
 		❯ ipsw diff <old.ipsw> <new.ipsw> --output <output/folder> --markdown 
 			--kdk /Library/Developer/KDKs/KDK_15.0_24A5264n.kdk/System/Library/Kernels/kernel.release.t6031 
 			--kdk /Library/Developer/KDKs/KDK_15.0_24A5279h.kdk/System/Library/Kernels/kernel.release.t6031
+		# Share the diff as a single HTML report (with collapsible sections)
+		❯ ipsw diff <old.ipsw> <new.ipsw> --fw --launchd --feat --output <output/folder> --html
+		# Only diff the entitlements of the daemons and highlight the sandbox/TCC ones
+		❯ ipsw diff <old.ipsw> <new.ipsw> --ent-include '^/usr/libexec/' --ent-interesting 'com.apple.private.tcc.*,seatbelt-profiles'
+		# Render the diff with your own Markdown template (i.e. for a blog post)
+		# NOTE: templates can render the default sections in any order with {{ section "kernel" }} ({{ sections }} lists them)
+		❯ ipsw diff <old.ipsw> <new.ipsw> --output <output/folder> --template blog.md.tmpl
 		# Use a previously saved .idiff file
 		❯ ipsw diff --in <path/to/.idiff> --output <output/folder> --markdown`),
 	Args:          cobra.MaximumNArgs(2),
@@ -126,6 +142,10 @@ var diffCmd = &cobra.Command{
 				AllowList: viper.GetStringSlice("diff.allow-list"),
 				BlockList: viper.GetStringSlice("diff.block-list"),
 				Output:    viper.GetString("diff.output"),
+
+				EntInclude:     viper.GetStringSlice("diff.ent-include"),
+				EntExclude:     viper.GetStringSlice("diff.ent-exclude"),
+				EntInteresting: viper.GetStringSlice("diff.ent-interesting"),
 			})
 			if err := d.Diff(); err != nil {
 				return err
@@ -146,6 +166,10 @@ var diffCmd = &cobra.Command{
 				if err := d.ToHTML(); err != nil {
 					return fmt.Errorf("failed to save HTML diff: %s", err)
 				}
+			case len(viper.GetStringSlice("diff.template")) > 0:
+				if err := d.MarkdownTemplate(viper.GetStringSlice("diff.template")...); err != nil {
+					return fmt.Errorf("failed to save templated Markdown diff: %s", err)
+				}
 			default:
 				if err := d.Save(); err != nil {
 					return fmt.Errorf("failed to save diff: %w", err)
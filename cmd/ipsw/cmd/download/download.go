@@ -22,11 +22,21 @@ THE SOFTWARE.
 package download
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/aea"
+	"github.com/blacktop/ipsw/pkg/plist"
+	"github.com/blacktop/ipsw/pkg/tss"
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -39,6 +49,13 @@ type downloadFlags struct {
 	ResumeAll    bool
 	RestartAll   bool
 	RemoveCommas bool
+	Segments     int
+	MaxRate      string
+	Window       string
+	Mirror       string
+	Records      string
+	NoSigned     bool
+	DeviceList   string
 
 	WhiteList []string
 	BlackList []string
@@ -52,13 +69,14 @@ var dFlg downloadFlags
 
 func init() {
 	// Persistent Flags which will work for this command and all subcommands
-	DownloadCmd.PersistentFlags().StringVar(&dFlg.Proxy, "proxy", "", "HTTP/HTTPS proxy")
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.Proxy, "proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	DownloadCmd.PersistentFlags().BoolVar(&dFlg.Insecure, "insecure", false, "do not verify ssl certs")
 	DownloadCmd.PersistentFlags().BoolVarP(&dFlg.Confirm, "confirm", "y", false, "do not prompt user for confirmation")
 	DownloadCmd.PersistentFlags().BoolVar(&dFlg.SkipAll, "skip-all", false, "always skip resumable IPSWs")
 	DownloadCmd.PersistentFlags().BoolVar(&dFlg.ResumeAll, "resume-all", false, "always resume resumable IPSWs")
 	DownloadCmd.PersistentFlags().BoolVar(&dFlg.RestartAll, "restart-all", false, "always restart resumable IPSWs")
 	DownloadCmd.PersistentFlags().BoolVarP(&dFlg.RemoveCommas, "remove-commas", "_", false, "replace commas in IPSW filename with underscores")
+	DownloadCmd.PersistentFlags().IntVar(&dFlg.Segments, "segments", 0, "split large downloads into N parallel (resumable) range requests")
 	viper.BindPFlag("download.proxy", DownloadCmd.Flags().Lookup("proxy"))
 	viper.BindPFlag("download.insecure", DownloadCmd.Flags().Lookup("insecure"))
 	viper.BindPFlag("download.confirm", DownloadCmd.Flags().Lookup("confirm"))
@@ -66,6 +84,19 @@ func init() {
 	viper.BindPFlag("download.resume-all", DownloadCmd.Flags().Lookup("resume-all"))
 	viper.BindPFlag("download.restart-all", DownloadCmd.Flags().Lookup("restart-all"))
 	viper.BindPFlag("download.remove-commas", DownloadCmd.Flags().Lookup("remove-commas"))
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.MaxRate, "max-rate", "", "limit download bandwidth per file (i.e. 10MB, 500KiB per second)")
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.Window, "window", "", "only download during daily time window (i.e. 22:00-06:00)")
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.Mirror, "mirror", "", "download from LAN mirror URL first if it has the file (see ipsw serve --mirror)")
+	viper.BindPFlag("download.segments", DownloadCmd.PersistentFlags().Lookup("segments"))
+	viper.BindPFlag("download.max-rate", DownloadCmd.PersistentFlags().Lookup("max-rate"))
+	viper.BindPFlag("download.window", DownloadCmd.PersistentFlags().Lookup("window"))
+	viper.BindPFlag("download.mirror", DownloadCmd.PersistentFlags().Lookup("mirror"))
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.Records, "records", "", "download records database to save checksums/signing status to (default is downloads.json in the config folder)")
+	DownloadCmd.PersistentFlags().BoolVar(&dFlg.NoSigned, "no-signed-check", false, "do not query TSS for the signing status of downloaded IPSWs")
+	viper.BindPFlag("download.records", DownloadCmd.PersistentFlags().Lookup("records"))
+	viper.BindPFlag("download.no-signed-check", DownloadCmd.PersistentFlags().Lookup("no-signed-check"))
+	DownloadCmd.PersistentFlags().StringVar(&dFlg.DeviceList, "device-list", "", "devices.yaml of the devices/versions to download (default is devices.yaml in the config folder if it exists)")
+	viper.BindPFlag("download.device-list", DownloadCmd.PersistentFlags().Lookup("device-list"))
 	// Filters
 	DownloadCmd.PersistentFlags().StringArrayVar(&dFlg.WhiteList, "white-list", []string{}, "iOS device white list")
 	DownloadCmd.PersistentFlags().StringArrayVar(&dFlg.BlackList, "black-list", []string{}, "iOS device black list")
@@ -167,6 +198,20 @@ func filterIPSWs(cmd *cobra.Command, macos bool) ([]download.IPSW, error) {
 		}
 	}
 
+	devList, err := getDeviceList()
+	if err != nil {
+		return nil, err
+	}
+	if devList != nil {
+		var allowedIPSWs []download.IPSW
+		for _, i := range filteredIPSWs {
+			if devList.Allow(i.Identifier, i.Version) {
+				allowedIPSWs = append(allowedIPSWs, i)
+			}
+		}
+		filteredIPSWs = allowedIPSWs
+	}
+
 	if macos {
 		var furtherFilteredIPSWs []download.IPSW
 		for _, i := range filteredIPSWs {
@@ -202,17 +247,154 @@ func getDestName(url string, removeCommas bool) string {
 	return path.Base(url)
 }
 
+// recordsPath returns the download records database path (--records OR downloads.json in the ipsw config folder)
+func recordsPath() string {
+	if val := viper.GetString("download.records"); len(val) > 0 {
+		return val
+	}
+	if len(viper.ConfigFileUsed()) > 0 {
+		return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "downloads.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.WithError(err).Warn("Failed to get user home directory (not recording downloads)")
+		return ""
+	}
+	return filepath.Join(home, ".config", "ipsw", "downloads.json")
+}
+
+// getDeviceList loads the --device-list devices.yaml (OR devices.yaml in the ipsw config folder if it exists)
+// and returns nil if there is no device list
+func getDeviceList() (*download.DeviceList, error) {
+	path := viper.GetString("download.device-list")
+	if len(path) == 0 {
+		if len(viper.ConfigFileUsed()) > 0 {
+			path = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "devices.yaml")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".config", "ipsw", "devices.yaml")
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+	dl, err := download.LoadDeviceList(path)
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("file", path).Debug("Using device list")
+	return dl, nil
+}
+
+// checkSigned queries TSS for the signing status of a downloaded IPSW and records it in the download records database
+// (the version/build and the device's board/chip are read from the IPSW's BuildManifest); failed TSS requests are returned
+// instead of being recorded as not signed
+func checkSigned(ipswPath, device, version, build string) error {
+	if viper.GetBool("download.no-signed-check") || len(device) == 0 {
+		return nil
+	}
+	conf := &tss.Config{
+		Device:   device,
+		Version:  version,
+		Build:    build,
+		Proxy:    viper.GetString("download.proxy"),
+		Insecure: viper.GetBool("download.insecure"),
+	}
+	if pl, err := plist.Parse(ipswPath); err == nil && pl.BuildManifest != nil {
+		conf.BuildManifest = pl.BuildManifest
+		if len(conf.Version) == 0 {
+			conf.Version = pl.BuildManifest.ProductVersion
+		}
+		if len(conf.Build) == 0 {
+			conf.Build = pl.BuildManifest.ProductBuildVersion
+		}
+	}
+	if len(conf.Version) == 0 && len(conf.Build) == 0 {
+		log.WithField("file", ipswPath).Warn("Failed to get IPSW build (skipping signing check)")
+		return nil
+	}
+	if conf.BuildManifest != nil {
+		var err error
+		conf.ApBoardID, conf.ApChipID, err = tss.LookupBoard(device, conf.BuildManifest)
+		if err != nil {
+			return fmt.Errorf("failed to check if %s is signed: %w", conf.Build, err)
+		}
+	}
+	var signed bool
+	var rerr *tss.ResponseError
+	switch _, err := tss.GetTSSResponse(conf); {
+	case err == nil:
+		signed = true
+		utils.Indent(log.WithField("build", conf.Build).Info, 2)("✅ still being signed")
+	case errors.As(err, &rerr):
+		utils.Indent(log.WithField("build", conf.Build).WithError(err).Warn, 2)("🔥 NO LONGER being signed")
+	default:
+		return fmt.Errorf("failed to check if %s is signed: %w", conf.Build, err)
+	}
+	if err := download.RecordSigned(ipswPath, device, conf.Version, conf.Build, signed); err != nil {
+		log.WithError(err).Warn("Failed to record signing status")
+	}
+	return nil
+}
+
+// decryptAEA decrypts a downloaded Apple Encrypted Archive into the output folder (or its own folder) using the
+// base64 key if provided, otherwise the cached key or the archive's fcs-key (fetched keys are cached in the key database)
+func decryptAEA(in, b64key, output string) (string, error) {
+	if ok, err := magic.IsAEA(in); err != nil {
+		return "", fmt.Errorf("failed to check if %s is AEA: %v", in, err)
+	} else if !ok {
+		return "", fmt.Errorf("%s is not an AEA", in)
+	}
+	if len(output) == 0 {
+		output = filepath.Dir(in)
+	}
+	log.WithField("file", filepath.Base(in)).Info("Decrypting AEA")
+	out, err := aea.Decrypt(&aea.DecryptConfig{
+		Input:     in,
+		Output:    output,
+		B64SymKey: b64key,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt AEA %s: %v", in, err)
+	}
+	utils.Indent(log.Info, 2)("Decrypted to " + out)
+	return out, nil
+}
+
+// setThrottle applies the --max-rate, --window, --mirror and --records flags to all the downloads
+func setThrottle() error {
+	var maxRate int64
+	if val := viper.GetString("download.max-rate"); len(val) > 0 {
+		rate, err := humanize.ParseBytes(val)
+		if err != nil {
+			return fmt.Errorf("failed to parse --max-rate: %v", err)
+		}
+		maxRate = int64(rate)
+	}
+	var window *download.Window
+	if val := viper.GetString("download.window"); len(val) > 0 {
+		var err error
+		window, err = download.ParseWindow(val)
+		if err != nil {
+			return fmt.Errorf("failed to parse --window: %v", err)
+		}
+	}
+	download.SetThrottle(maxRate, window)
+	download.SetRecords(recordsPath())
+	return download.SetMirror(viper.GetString("download.mirror"))
+}
+
 // DownloadCmd represents the download command
 var DownloadCmd = &cobra.Command{
 	Use:     "download",
 	Aliases: []string{"dl"},
 	Short:   "Download Apple Firmware files (and more)",
 	Args:    cobra.NoArgs,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		viper.BindPFlag("color", cmd.Flags().Lookup("color"))
 		viper.BindPFlag("no-color", cmd.Flags().Lookup("no-color"))
 		viper.BindPFlag("verbose", cmd.Flags().Lookup("verbose"))
 		viper.BindPFlag("diff-tool", cmd.Flags().Lookup("diff-tool"))
+		return setThrottle()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
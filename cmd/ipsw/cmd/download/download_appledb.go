@@ -28,7 +28,9 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/alecthomas/chroma/v2/quick"
@@ -37,6 +39,7 @@ import (
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -53,6 +56,7 @@ func init() {
 	downloadAppledbCmd.Flags().Bool("kernel", false, "Extract kernelcache from remote IPSW")
 	downloadAppledbCmd.Flags().Bool("dyld", false, "Extract dyld_shared_cache(s) from remote OTA")
 	downloadAppledbCmd.Flags().String("pattern", "", "Download remote files that match regex")
+	downloadAppledbCmd.Flags().StringArray("glob", []string{}, "Download remote files that match glob (i.e. 'Firmware/**/*.im4p', can be repeated)")
 	downloadAppledbCmd.Flags().Bool("fcs-keys", false, "Download AEA1 DMG fcs-key pem files")
 	downloadAppledbCmd.Flags().Bool("fcs-keys-json", false, "Download AEA1 DMG fcs-keys as JSON")
 	downloadAppledbCmd.Flags().Bool("release", false, "Download release IPSWs")
@@ -60,6 +64,9 @@ func init() {
 	downloadAppledbCmd.Flags().Bool("rc", false, "Download RC (release candidate) IPSWs")
 	downloadAppledbCmd.Flags().Bool("latest", false, "Download latest IPSWs")
 	downloadAppledbCmd.Flags().Bool("show-latest", false, "Show latest version/build")
+	downloadAppledbCmd.Flags().String("since", "", "Only firmwares released on or after date (YYYY-MM-DD)")
+	downloadAppledbCmd.Flags().String("until", "", "Only firmwares released on or before date (YYYY-MM-DD)")
+	downloadAppledbCmd.Flags().BoolP("list", "l", false, "List matching firmwares (version, build, release date) instead of downloading")
 	downloadAppledbCmd.Flags().StringP("prereq-build", "p", "", "OTA prerequisite build")
 	downloadAppledbCmd.Flags().Bool("deltas", false, "Download all OTA deltas")
 	downloadAppledbCmd.Flags().BoolP("urls", "u", false, "Dump URLs only")
@@ -76,6 +83,7 @@ func init() {
 	viper.BindPFlag("download.appledb.kernel", downloadAppledbCmd.Flags().Lookup("kernel"))
 	viper.BindPFlag("download.appledb.dyld", downloadAppledbCmd.Flags().Lookup("dyld"))
 	viper.BindPFlag("download.appledb.pattern", downloadAppledbCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("download.appledb.glob", downloadAppledbCmd.Flags().Lookup("glob"))
 	viper.BindPFlag("download.appledb.fcs-keys", downloadAppledbCmd.Flags().Lookup("fcs-keys"))
 	viper.BindPFlag("download.appledb.fcs-keys-json", downloadAppledbCmd.Flags().Lookup("fcs-keys-json"))
 	viper.BindPFlag("download.appledb.release", downloadAppledbCmd.Flags().Lookup("release"))
@@ -83,6 +91,9 @@ func init() {
 	viper.BindPFlag("download.appledb.rc", downloadAppledbCmd.Flags().Lookup("rc"))
 	viper.BindPFlag("download.appledb.latest", downloadAppledbCmd.Flags().Lookup("latest"))
 	viper.BindPFlag("download.appledb.show-latest", downloadAppledbCmd.Flags().Lookup("show-latest"))
+	viper.BindPFlag("download.appledb.since", downloadAppledbCmd.Flags().Lookup("since"))
+	viper.BindPFlag("download.appledb.until", downloadAppledbCmd.Flags().Lookup("until"))
+	viper.BindPFlag("download.appledb.list", downloadAppledbCmd.Flags().Lookup("list"))
 	viper.BindPFlag("download.appledb.prereq-build", downloadAppledbCmd.Flags().Lookup("prereq-build"))
 	viper.BindPFlag("download.appledb.deltas", downloadAppledbCmd.Flags().Lookup("deltas"))
 	viper.BindPFlag("download.appledb.urls", downloadAppledbCmd.Flags().Lookup("urls"))
@@ -119,7 +130,11 @@ var downloadAppledbCmd = &cobra.Command{
    • Querying AppleDB...
    • Parsing remote IPSW       build=20F5059a devices=iPhone15,2 version=16.5
    • Extracting remote kernelcache
-      • Writing 20F5059a__iPhone15,2/kernelcache.release.iPhone15,2`,
+      • Writing 20F5059a__iPhone15,2/kernelcache.release.iPhone15,2
+  # List all the iOS 17.0 betas released for the iPhone15,2 in 2023 (including ones that are no longer signed/listed elsewhere)
+  ❯ ipsw download appledb --os iOS --version 17.0 --device iPhone15,2 --beta --since 2023-01-01 --until 2023-12-31 --list
+  # Resolve a build number to its marketing version as JSON
+  ❯ ipsw download appledb --os iOS --build 20F5059a --list --json`,
 	Args:          cobra.NoArgs,
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -160,7 +175,7 @@ var downloadAppledbCmd = &cobra.Command{
 		fwType := viper.GetString("download.appledb.type")
 		kernel := viper.GetBool("download.appledb.kernel")
 		dyld := viper.GetBool("download.appledb.dyld")
-		pattern := viper.GetString("download.appledb.pattern")
+		pattern := utils.MergePatterns(viper.GetString("download.appledb.pattern"), viper.GetStringSlice("download.appledb.glob"))
 		fcsKeys := viper.GetBool("download.appledb.fcs-keys")
 		fcsKeysJson := viper.GetBool("download.appledb.fcs-keys-json")
 		isRelease := viper.GetBool("download.appledb.release")
@@ -173,6 +188,20 @@ var downloadAppledbCmd = &cobra.Command{
 		useAPI := viper.GetBool("download.appledb.api")
 		apiToken := viper.GetString("download.appledb.api-token")
 		flat := viper.GetBool("download.appledb.flat")
+		list := viper.GetBool("download.appledb.list")
+		var since, until time.Time
+		if val := viper.GetString("download.appledb.since"); len(val) > 0 {
+			since, err = time.Parse(time.DateOnly, val)
+			if err != nil {
+				return fmt.Errorf("failed to parse --since date (expected YYYY-MM-DD): %v", err)
+			}
+		}
+		if val := viper.GetString("download.appledb.until"); len(val) > 0 {
+			until, err = time.Parse(time.DateOnly, val)
+			if err != nil {
+				return fmt.Errorf("failed to parse --until date (expected YYYY-MM-DD): %v", err)
+			}
+		}
 		// verify args
 		for _, osType := range osTypes {
 			if !slices.Contains(supportedOSes, osType) {
@@ -200,6 +229,12 @@ var downloadAppledbCmd = &cobra.Command{
 		if otaDeltas && !(fwType == "ota" || fwType == "rsr") {
 			return fmt.Errorf("cannot use --prereq-build with --type %s", fwType)
 		}
+		if list && (asURLs || kernel || dyld || len(pattern) > 0 || fcsKeys || fcsKeysJson || viper.GetBool("download.appledb.show-latest")) {
+			return fmt.Errorf("cannot use --list with --urls, --kernel, --dyld, --pattern, --fcs-keys, --fcs-keys-json or --show-latest")
+		}
+		if !since.IsZero() && !until.IsZero() && until.Before(since) {
+			return fmt.Errorf("--until date must be after --since date")
+		}
 		if viper.GetBool("download.appledb.show-latest") && (asURLs || asJSON || kernel || len(pattern) > 0 || fcsKeys || fcsKeysJson) {
 			return fmt.Errorf("cannot use --show-latest with --urls, --json, --kernel, --pattern, --fcs-keys or --fcs-keys-json")
 		}
@@ -239,6 +274,40 @@ var downloadAppledbCmd = &cobra.Command{
 		}
 
 		log.Info("Querying AppleDB...")
+		if list {
+			q := &download.ADBQuery{
+				OSes:      osTypes,
+				Type:      fwType,
+				Version:   version,
+				Build:     build,
+				Device:    device,
+				IsRelease: isRelease,
+				IsBeta:    isBeta,
+				IsRC:      isRC,
+				Since:     since,
+				Until:     until,
+				Proxy:     proxy,
+				Insecure:  insecure,
+				APIToken:  apiToken,
+			}
+			var fws download.OsFiles
+			if useAPI {
+				fws, err = download.AppleDBSearch(q)
+			} else {
+				q.ConfigDir, err = appledbConfigDir()
+				if err != nil {
+					return err
+				}
+				fws, err = download.LocalAppleDBSearch(q)
+			}
+			if err != nil {
+				return err
+			}
+			if latest && len(fws) > 0 {
+				fws = fws[:1]
+			}
+			return printAppleDBFirmwares(fws, asJSON)
+		}
 		var results []download.OsFileSource
 		if useAPI {
 			results, err = download.AppleDBQuery(&download.ADBQuery{
@@ -253,6 +322,8 @@ var downloadAppledbCmd = &cobra.Command{
 				IsBeta:            isBeta,
 				IsRC:              isRC,
 				Latest:            latest,
+				Since:             since,
+				Until:             until,
 				Proxy:             proxy,
 				Insecure:          insecure,
 				APIToken:          apiToken,
@@ -261,18 +332,9 @@ var downloadAppledbCmd = &cobra.Command{
 				return err
 			}
 		} else {
-			var configDir string
-			if len(viper.ConfigFileUsed()) == 0 {
-				home, err := os.UserHomeDir()
-				if err != nil {
-					return err
-				}
-				configDir = filepath.Join(home, ".config", "ipsw")
-				if err := os.MkdirAll(configDir, 0770); err != nil {
-					return fmt.Errorf("failed to create config folder: %v", err)
-				}
-			} else {
-				configDir = filepath.Dir(viper.ConfigFileUsed())
+			configDir, err := appledbConfigDir()
+			if err != nil {
+				return err
 			}
 			if viper.GetBool("download.appledb.show-latest") {
 				latest, err := download.LocalAppleDBLatest(&download.ADBQuery{
@@ -287,6 +349,8 @@ var downloadAppledbCmd = &cobra.Command{
 					IsBeta:            isBeta,
 					IsRC:              isRC,
 					Latest:            latest,
+					Since:             since,
+					Until:             until,
 					Proxy:             proxy,
 					Insecure:          insecure,
 					APIToken:          apiToken,
@@ -336,6 +400,8 @@ var downloadAppledbCmd = &cobra.Command{
 					IsBeta:            isBeta,
 					IsRC:              isRC,
 					Latest:            latest,
+					Since:             since,
+					Until:             until,
 					Proxy:             proxy,
 					Insecure:          insecure,
 					APIToken:          apiToken,
@@ -514,12 +580,20 @@ var downloadAppledbCmd = &cobra.Command{
 						// download file
 						downloader.URL = url
 						downloader.DestName = fname
+						downloader.Segments = viper.GetInt("download.segments")
 						downloader.Sha1 = result.Hashes.Sha1
+						downloader.Sha256 = result.Hashes.Sha2256
 
 						err = downloader.Do()
 						if err != nil {
 							return fmt.Errorf("failed to download IPSW: %v", err)
 						}
+
+						if fwType == "ipsw" && len(result.DeviceMap) > 0 {
+							if err := checkSigned(fname, result.DeviceMap[0], "", ""); err != nil {
+								log.WithError(err).Warn("Failed to check signing status")
+							}
+						}
 					} else {
 						log.Warnf("IPSW already exists: %s", fname)
 					}
@@ -530,3 +604,82 @@ var downloadAppledbCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func appledbConfigDir() (string, error) {
+	if len(viper.ConfigFileUsed()) > 0 {
+		return filepath.Dir(viper.ConfigFileUsed()), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	configDir := filepath.Join(home, ".config", "ipsw")
+	if err := os.MkdirAll(configDir, 0770); err != nil {
+		return "", fmt.Errorf("failed to create config folder: %v", err)
+	}
+	return configDir, nil
+}
+
+func printAppleDBFirmwares(fws download.OsFiles, asJSON bool) error {
+	if len(fws) == 0 {
+		return fmt.Errorf("query return 0 results")
+	}
+	if asJSON {
+		type firmware struct {
+			OS       string                `json:"os"`
+			Version  string                `json:"version"`
+			Build    string                `json:"build"`
+			Beta     bool                  `json:"beta,omitempty"`
+			RC       bool                  `json:"rc,omitempty"`
+			Released download.ReleasedDate `json:"released"`
+			Devices  []string              `json:"devices,omitempty"`
+		}
+		var out []firmware
+		for _, fw := range fws {
+			out = append(out, firmware{
+				OS:       fw.OS,
+				Version:  fw.Version,
+				Build:    fw.Build,
+				Beta:     fw.Beta,
+				RC:       fw.RC,
+				Released: fw.Released,
+				Devices:  fw.DeviceMap,
+			})
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal json: %v", err)
+		}
+		if viper.GetBool("color") && !viper.GetBool("no-color") {
+			if err := quick.Highlight(os.Stdout, string(b)+"\n", "json", "terminal256", "nord"); err != nil {
+				return fmt.Errorf("failed to highlight json: %v", err)
+			}
+		} else {
+			fmt.Println(string(b))
+		}
+		return nil
+	}
+	var data [][]string
+	for _, fw := range fws {
+		status := "release"
+		if fw.Beta {
+			status = "beta"
+		} else if fw.RC {
+			status = "rc"
+		}
+		released := ""
+		if !time.Time(fw.Released).IsZero() {
+			released = fw.Released.Format(time.DateOnly)
+		}
+		data = append(data, []string{fw.OS, fw.Version, fw.Build, status, released, strconv.Itoa(len(fw.DeviceMap))})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"OS", "Version", "Build", "Status", "Released", "Devices"})
+	table.SetAutoWrapText(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.AppendBulk(data)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package download
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var supportedFeedFormats = []string{"rss", "json"}
+
+func init() {
+	DownloadCmd.AddCommand(downloadFeedCmd)
+
+	downloadFeedCmd.Flags().StringArray("os", []string{"iOS"}, fmt.Sprintf("Operating system(s) to include (%s)", strings.Join(supportedOSes, ", ")))
+	downloadFeedCmd.Flags().StringP("format", "f", "rss", fmt.Sprintf("Feed format (%s)", strings.Join(supportedFeedFormats, ", ")))
+	downloadFeedCmd.Flags().IntP("limit", "n", 50, "Max number of releases in the feed (0 for all)")
+	downloadFeedCmd.Flags().String("since", "", "Only firmwares released on or after date (YYYY-MM-DD)")
+	downloadFeedCmd.Flags().Bool("release", false, "Only include release firmwares")
+	downloadFeedCmd.Flags().Bool("beta", false, "Only include beta firmwares")
+	downloadFeedCmd.Flags().String("title", "", "Feed title (default: 'ipsw <OS> releases')")
+	downloadFeedCmd.Flags().BoolP("api", "a", false, "Use Github API")
+	downloadFeedCmd.Flags().String("api-token", "", "Github API Token")
+	downloadFeedCmd.Flags().StringP("output", "o", "", "File to write the feed to (default: stdout)")
+	downloadFeedCmd.MarkFlagsMutuallyExclusive("release", "beta")
+	viper.BindPFlag("download.feed.os", downloadFeedCmd.Flags().Lookup("os"))
+	viper.BindPFlag("download.feed.format", downloadFeedCmd.Flags().Lookup("format"))
+	viper.BindPFlag("download.feed.limit", downloadFeedCmd.Flags().Lookup("limit"))
+	viper.BindPFlag("download.feed.since", downloadFeedCmd.Flags().Lookup("since"))
+	viper.BindPFlag("download.feed.release", downloadFeedCmd.Flags().Lookup("release"))
+	viper.BindPFlag("download.feed.beta", downloadFeedCmd.Flags().Lookup("beta"))
+	viper.BindPFlag("download.feed.title", downloadFeedCmd.Flags().Lookup("title"))
+	viper.BindPFlag("download.feed.api", downloadFeedCmd.Flags().Lookup("api"))
+	viper.BindPFlag("download.feed.api-token", downloadFeedCmd.Flags().Lookup("api-token"))
+	viper.BindPFlag("download.feed.output", downloadFeedCmd.Flags().Lookup("output"))
+
+	downloadFeedCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
+		DownloadCmd.PersistentFlags().MarkHidden("white-list")
+		DownloadCmd.PersistentFlags().MarkHidden("black-list")
+		DownloadCmd.PersistentFlags().MarkHidden("model")
+		DownloadCmd.PersistentFlags().MarkHidden("confirm")
+		DownloadCmd.PersistentFlags().MarkHidden("skip-all")
+		DownloadCmd.PersistentFlags().MarkHidden("resume-all")
+		DownloadCmd.PersistentFlags().MarkHidden("restart-all")
+		DownloadCmd.PersistentFlags().MarkHidden("remove-commas")
+		c.Parent().HelpFunc()(c, s)
+	})
+	downloadFeedCmd.RegisterFlagCompletionFunc("os", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return supportedOSes, cobra.ShellCompDirectiveDefault
+	})
+	downloadFeedCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return supportedFeedFormats, cobra.ShellCompDirectiveDefault
+	})
+}
+
+// downloadFeedCmd represents the feed command
+var downloadFeedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Generate an RSS/JSON feed of firmware releases",
+	Long: heredoc.Doc(`
+		Render the recent firmware releases in appledb (per OS and optionally per device) as an
+		RSS 2.0 or JSON Feed 1.1 feed that dashboards and automation can subscribe to.
+
+		When a --device is given each item links to the device's firmware download.`),
+	Example: heredoc.Doc(`
+		# Generate an RSS feed of the latest iOS and macOS releases
+		❯ ipsw download feed --os iOS --os macOS -o releases.xml
+		# Generate a JSON feed of the iPhone15,2 releases since 2024 (with IPSW download links)
+		❯ ipsw download feed --device iPhone15,2 --since 2024-01-01 --format json -o iPhone15,2.json`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		// parent flags
+		viper.BindPFlag("download.proxy", cmd.Flags().Lookup("proxy"))
+		viper.BindPFlag("download.insecure", cmd.Flags().Lookup("insecure"))
+		viper.BindPFlag("download.device", cmd.Flags().Lookup("device"))
+		viper.BindPFlag("download.version", cmd.Flags().Lookup("version"))
+		viper.BindPFlag("download.build", cmd.Flags().Lookup("build"))
+		// settings
+		proxy := viper.GetString("download.proxy")
+		insecure := viper.GetBool("download.insecure")
+		// filters
+		device := viper.GetString("download.device")
+		version := viper.GetString("download.version")
+		build := viper.GetString("download.build")
+		// flags
+		osTypes := viper.GetStringSlice("download.feed.os")
+		format := viper.GetString("download.feed.format")
+		title := viper.GetString("download.feed.title")
+		apiToken := viper.GetString("download.feed.api-token")
+		output := viper.GetString("download.feed.output")
+		var since time.Time
+		if val := viper.GetString("download.feed.since"); len(val) > 0 {
+			since, err = time.Parse(time.DateOnly, val)
+			if err != nil {
+				return fmt.Errorf("failed to parse --since date (expected YYYY-MM-DD): %v", err)
+			}
+		}
+		// verify args
+		for _, osType := range osTypes {
+			if !slices.Contains(supportedOSes, osType) {
+				return fmt.Errorf("valid --os flag choices are: %v", strings.Join(supportedOSes, ", "))
+			}
+		}
+		if !slices.Contains(supportedFeedFormats, format) {
+			return fmt.Errorf("valid --format flag choices are: %v", strings.Join(supportedFeedFormats, ", "))
+		}
+
+		if len(apiToken) == 0 {
+			if val, ok := os.LookupEnv("GITHUB_TOKEN"); ok {
+				apiToken = val
+			} else if val, ok := os.LookupEnv("GITHUB_API_TOKEN"); ok {
+				apiToken = val
+			}
+		}
+		if len(title) == 0 {
+			title = fmt.Sprintf("ipsw %s releases", strings.Join(osTypes, "/"))
+			if len(device) > 0 {
+				title = fmt.Sprintf("ipsw %s releases", device)
+			}
+		}
+
+		q := &download.ADBQuery{
+			OSes:      osTypes,
+			Version:   version,
+			Build:     build,
+			Device:    device,
+			IsRelease: viper.GetBool("download.feed.release"),
+			IsBeta:    viper.GetBool("download.feed.beta"),
+			Since:     since,
+			Proxy:     proxy,
+			Insecure:  insecure,
+			APIToken:  apiToken,
+		}
+		log.Info("Querying AppleDB...")
+		var fws download.OsFiles
+		if viper.GetBool("download.feed.api") {
+			fws, err = download.AppleDBSearch(q)
+		} else {
+			q.ConfigDir, err = appledbConfigDir()
+			if err != nil {
+				return err
+			}
+			fws, err = download.LocalAppleDBSearch(q)
+		}
+		if err != nil {
+			return err
+		}
+
+		feed := download.NewFeed(title, fws, device, viper.GetInt("download.feed.limit"))
+
+		var dat []byte
+		switch format {
+		case "rss":
+			dat, err = feed.RSS()
+		case "json":
+			dat, err = feed.JSON()
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(output) == 0 {
+			fmt.Println(string(dat))
+			return nil
+		}
+		if err := os.WriteFile(output, dat, 0o644); err != nil {
+			return fmt.Errorf("failed to write feed: %v", err)
+		}
+		log.WithField("items", len(feed.Items)).Infof("Created %s", output)
+		return nil
+	},
+}
@@ -22,7 +22,6 @@ THE SOFTWARE.
 package download
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -184,7 +183,7 @@ var gitCmd = &cobra.Command{
 					client := &http.Client{
 						Transport: &http.Transport{
 							Proxy:           download.GetProxy(proxy),
-							TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+							TLSClientConfig: download.TLSConfig(insecure),
 						},
 					}
 
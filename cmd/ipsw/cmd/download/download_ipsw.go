@@ -22,8 +22,6 @@ THE SOFTWARE.
 package download
 
 import (
-	"crypto/aes"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,6 +32,7 @@ import (
 	"github.com/blacktop/ipsw/internal/commands/extract"
 	"github.com/blacktop/ipsw/internal/commands/img4"
 	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/keys"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/dyld"
 	"github.com/blacktop/ipsw/pkg/info"
@@ -59,9 +58,10 @@ func init() {
 	})
 	// ipswCmd.Flags().BoolP("kernel-spec", "", false, "Download kernels into spec folders")
 	ipswCmd.Flags().String("pattern", "", "Download remote files that match regex")
+	ipswCmd.Flags().StringArray("glob", []string{}, "Download remote files that match glob (i.e. 'Firmware/**/*.im4p', can be repeated)")
 	ipswCmd.Flags().Bool("fcs-keys", false, "Download AEA1 DMG fcs-key pem files")
 	ipswCmd.Flags().Bool("fcs-keys-json", false, "Download AEA1 DMG fcs-keys as JSON")
-	ipswCmd.Flags().Bool("decrypt", false, "Attempt to decrypt the partial files if keys are available")
+	ipswCmd.Flags().Bool("decrypt", false, "Attempt to decrypt the partial files (im4p/AEA) if keys are available (see 'ipsw keys')")
 	ipswCmd.Flags().BoolP("flat", "f", false, "Do NOT perserve directory structure when downloading with --pattern")
 	ipswCmd.Flags().BoolP("urls", "u", false, "Dump URLs only")
 	ipswCmd.Flags().Bool("usb", false, "Download IPSWs for USB attached iDevices")
@@ -78,6 +78,7 @@ func init() {
 	viper.BindPFlag("download.ipsw.dyld-arch", ipswCmd.Flags().Lookup("dyld-arch"))
 	// viper.BindPFlag("download.ipsw.kernel-spec", ipswCmd.Flags().Lookup("kernel-spec"))
 	viper.BindPFlag("download.ipsw.pattern", ipswCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("download.ipsw.glob", ipswCmd.Flags().Lookup("glob"))
 	viper.BindPFlag("download.ipsw.fcs-keys", ipswCmd.Flags().Lookup("fcs-keys"))
 	viper.BindPFlag("download.ipsw.fcs-keys-json", ipswCmd.Flags().Lookup("fcs-keys-json"))
 	viper.BindPFlag("download.ipsw.decrypt", ipswCmd.Flags().Lookup("decrypt"))
@@ -146,7 +147,7 @@ var ipswCmd = &cobra.Command{
 		remoteDSC := viper.GetBool("download.ipsw.dyld")
 		dyldArches := viper.GetStringSlice("download.ipsw.dyld-arch")
 		// kernelSpecFolders := viper.GetBool("download.ipsw.kernel-spec")
-		remotePattern := viper.GetString("download.ipsw.pattern")
+		remotePattern := utils.MergePatterns(viper.GetString("download.ipsw.pattern"), viper.GetStringSlice("download.ipsw.glob"))
 		fcsKeys := viper.GetBool("download.ipsw.fcs-keys")
 		fcsKeysJson := viper.GetBool("download.ipsw.fcs-keys-json")
 		decrypt := viper.GetBool("download.ipsw.decrypt")
@@ -258,7 +259,14 @@ var ipswCmd = &cobra.Command{
 				utils.Indent(log.Info, 1)(fmt.Sprintf("Latest release found is: %s", builds[0].Version))
 			}
 
+			devList, err := getDeviceList()
+			if err != nil {
+				return err
+			}
 			for _, v := range builds {
+				if !devList.Allow(v.Identifier, v.Version) {
+					continue
+				}
 				if len(doDownload) > 0 {
 					if utils.StrSliceHas(doDownload, v.Identifier) {
 						filteredBuilds = append(filteredBuilds, v)
@@ -390,54 +398,41 @@ var ipswCmd = &cobra.Command{
 							}
 							if decrypt {
 								log.Info("Searching for keys to decrypt files")
-								if keys, err := download.GetWikiFirmwareKeys(&download.WikiConfig{
-									Keys:    true,
-									Device:  ipsw.Identifier,
-									Version: ipsw.Version,
-									Build:   ipsw.BuildID,
-								}, proxy, insecure); err == nil {
-									for _, key := range keys {
-										for idx, f := range key.Filename {
-											var in string
-											for _, o := range out {
-												if strings.HasSuffix(strings.ToLower(o), strings.ToLower(strings.ReplaceAll(f, " ", "_"))) {
-													in = o
-													break
-												}
-											}
-											if len(in) == 0 {
-												continue // not found
-											}
-											if len(key.Key) > 0 && len(key.Key[idx]) > 0 && key.Key[idx] != "Unknown" &&
-												len(key.Iv) > 0 && len(key.Iv[idx]) > 0 && key.Iv[idx] != "Unknown" {
-												iv, err := hex.DecodeString(key.Iv[idx])
-												if err != nil {
-													return fmt.Errorf("failed to decode iv: %v", err)
-												}
-												k, err := hex.DecodeString(key.Key[idx])
-												if err != nil {
-													return fmt.Errorf("failed to decode key: %v", err)
-												}
-												utils.Indent(log.Info, 2)("Decrypted " + strings.TrimPrefix(in, cwd) + ".dec")
-												if err := img4.DecryptPayload(in, in+".dec", iv, k); err != nil {
-													return fmt.Errorf("failed to decrypt %s: %v", in, err)
-												}
-											} else if len(key.Kbag) > 0 && len(key.Kbag[idx]) > 0 && key.Kbag[idx] != "Unknown" {
-												kbag, err := hex.DecodeString(key.Kbag[idx])
-												if err != nil {
-													return fmt.Errorf("failed to decode kbag: %v", err)
-												}
-												iv := kbag[:aes.BlockSize]
-												key := kbag[aes.BlockSize:]
-												utils.Indent(log.Info, 2)("Decrypted " + strings.TrimPrefix(in, cwd) + ".dec")
-												if err := img4.DecryptPayload(in, in+".dec", iv, key); err != nil {
-													return fmt.Errorf("failed to decrypt %s: %v", in, err)
-												}
-											}
+								db, err := keys.Open("", nil, proxy, insecure)
+								if err != nil {
+									return err
+								}
+								defer db.Close()
+								fetched := false
+								for _, in := range out {
+									if strings.HasSuffix(strings.ToLower(in), ".aea") {
+										if _, err := decryptAEA(in, "", ""); err != nil {
+											log.WithError(err).Warn("Failed to decrypt AEA")
 										}
+										continue
+									}
+									if !strings.HasSuffix(strings.ToLower(in), ".im4p") {
+										continue
+									}
+									key, err := db.Get(ipsw.Identifier, ipsw.BuildID, in)
+									if err != nil && !fetched {
+										fetched = true
+										if _, err := db.Fetch(ipsw.Identifier, ipsw.BuildID); err != nil {
+											log.WithError(err).Warn("Failed to fetch keys")
+										}
+										key, err = db.Get(ipsw.Identifier, ipsw.BuildID, in)
+									}
+									if err != nil {
+										continue // no known key
+									}
+									iv, k, err := key.Decode()
+									if err != nil {
+										return err
+									}
+									utils.Indent(log.Info, 2)("Decrypted " + strings.TrimPrefix(in, cwd) + ".dec")
+									if err := img4.DecryptPayload(in, in+".dec", iv, k); err != nil {
+										return fmt.Errorf("failed to decrypt %s: %v", in, err)
 									}
-								} else {
-									return fmt.Errorf("failed to get decrypt files: %v", err)
 								}
 							}
 						}
@@ -464,6 +459,7 @@ var ipswCmd = &cobra.Command{
 						downloader.URL = i.URL
 						downloader.Sha1 = i.SHA1
 						downloader.DestName = destName
+						downloader.Segments = viper.GetInt("download.segments")
 
 						if err := downloader.Do(); err != nil {
 							return fmt.Errorf("failed to download file: %v", err)
@@ -471,6 +467,10 @@ var ipswCmd = &cobra.Command{
 
 						log.Info("Created: " + destName)
 
+						if err := checkSigned(destName, i.Identifier, i.Version, i.BuildID); err != nil {
+							log.WithError(err).Warn("Failed to check signing status")
+						}
+
 						// append sha1 and filename to checksums file
 						f, err := os.OpenFile("checksums.txt.sha1", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
 						if err != nil {
@@ -26,14 +26,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
+	kcmd "github.com/blacktop/ipsw/internal/commands/kernel"
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -44,10 +50,12 @@ func init() {
 	downloadKdkCmd.Flags().StringP("build", "b", "", "Download KDK for build")
 	downloadKdkCmd.Flags().BoolP("latest", "l", false, "Download latest KDK")
 	downloadKdkCmd.Flags().BoolP("all", "a", false, "Download all KDKs")
+	downloadKdkCmd.Flags().String("match", "", "Download KDK matching a kernelcache, kernel UUID or macOS build")
+	downloadKdkCmd.Flags().Bool("list", false, "List available KDKs")
 	downloadKdkCmd.Flags().BoolP("install", "i", false, "Install KDK after download")
 	downloadKdkCmd.Flags().StringP("output", "o", "", "Folder to download files to")
 	downloadKdkCmd.MarkFlagDirname("output")
-	downloadKdkCmd.MarkFlagsMutuallyExclusive("host", "build", "latest", "all")
+	downloadKdkCmd.MarkFlagsMutuallyExclusive("host", "build", "latest", "all", "match", "list")
 	downloadKdkCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
 		DownloadCmd.PersistentFlags().MarkHidden("white-list")
 		DownloadCmd.PersistentFlags().MarkHidden("black-list")
@@ -63,17 +71,25 @@ func init() {
 	viper.BindPFlag("download.kdk.build", downloadKdkCmd.Flags().Lookup("build"))
 	viper.BindPFlag("download.kdk.latest", downloadKdkCmd.Flags().Lookup("latest"))
 	viper.BindPFlag("download.kdk.all", downloadKdkCmd.Flags().Lookup("all"))
+	viper.BindPFlag("download.kdk.match", downloadKdkCmd.Flags().Lookup("match"))
+	viper.BindPFlag("download.kdk.list", downloadKdkCmd.Flags().Lookup("list"))
 	viper.BindPFlag("download.kdk.install", downloadKdkCmd.Flags().Lookup("install"))
 	viper.BindPFlag("download.kdk.output", downloadKdkCmd.Flags().Lookup("output"))
 }
 
 // downloadKdkCmd represents the kdk command
 var downloadKdkCmd = &cobra.Command{
-	Use:           "kdk",
-	Short:         "Download KDKs",
+	Use:   "kdk",
+	Short: "Download KDKs",
+	Example: heredoc.Doc(`
+		# List the available KDKs
+		❯ ipsw download kdk --list
+		# Download and install the KDK that matches a kernelcache (skipped if already installed)
+		❯ ipsw download kdk --match kernelcache.release.Mac14,2 --install
+		# Download the KDK for a macOS build
+		❯ ipsw download kdk --match 23A344`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	Hidden:        true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
@@ -98,6 +114,7 @@ var downloadKdkCmd = &cobra.Command{
 		forBuild := viper.GetString("download.kdk.build")
 		latest := viper.GetBool("download.kdk.latest")
 		all := viper.GetBool("download.kdk.all")
+		match := viper.GetString("download.kdk.match")
 		install := viper.GetBool("download.kdk.install")
 		output := viper.GetString("download.kdk.output")
 
@@ -108,7 +125,33 @@ var downloadKdkCmd = &cobra.Command{
 
 		var dlKDKs []download.KDK
 
-		if forHost {
+		if viper.GetBool("download.kdk.list") {
+			sort.Sort(kdks)
+			var data [][]string
+			for _, kdk := range kdks {
+				data = append(data, []string{kdk.Name, kdk.Version, kdk.Build, kdk.Date.Format(time.DateOnly), humanize.Bytes(uint64(kdk.FileSize))})
+			}
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Name", "Version", "Build", "Date", "Size"})
+			table.SetAutoWrapText(false)
+			table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+			table.SetCenterSeparator("|")
+			table.AppendBulk(data)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.Render()
+			return nil
+		}
+
+		if len(match) > 0 {
+			kdk, err := matchKDK(kdks, match)
+			if err != nil {
+				return err
+			}
+			if kdk == nil {
+				return nil // already installed
+			}
+			dlKDKs = append(dlKDKs, *kdk)
+		} else if forHost {
 			binfo, err := utils.GetBuildInfo()
 			if err != nil {
 				return fmt.Errorf("failed to get build info: %v", err)
@@ -184,7 +227,9 @@ var downloadKdkCmd = &cobra.Command{
 				log.Infof("Downloading to %s...", destName)
 				downloader := download.NewDownload(proxy, insecure, skipAll, resumeAll, restartAll, false, viper.GetBool("verbose"))
 				downloader.URL = kdk.URL
+				downloader.Sha256 = kdk.Sha256Sum
 				downloader.DestName = destName
+				downloader.Segments = viper.GetInt("download.segments")
 				if err := downloader.Do(); err != nil {
 					return err
 				}
@@ -203,3 +248,33 @@ var downloadKdkCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var uuidRE = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// matchKDK returns the KDK for a kernelcache, kernel UUID or macOS build (or nil if a matching KDK is already installed)
+func matchKDK(kdks download.KDKs, match string) (*download.KDK, error) {
+	if fi, err := os.Stat(match); err == nil && !fi.IsDir() {
+		kinfo, err := kcmd.GetKernelInfo(match)
+		if err != nil {
+			return nil, err
+		}
+		if kernel, err := kcmd.FindKDKKernel(kcmd.KDKsPath, kinfo.UUID); err == nil {
+			log.WithField("kernel", kernel).Info("Matching KDK already installed")
+			return nil, nil
+		}
+		if kinfo.Version == nil {
+			return nil, fmt.Errorf("failed to get kernel version of %s", match)
+		}
+		log.WithFields(log.Fields{"uuid": kinfo.UUID, "version": kinfo.RawVersion()}).Debug("Matching kernel")
+		return kdks.Match(kinfo.RawVersion())
+	}
+	if uuidRE.MatchString(match) {
+		// the KDK manifest has no kernel UUIDs so only installed KDKs can be matched by UUID
+		if kernel, err := kcmd.FindKDKKernel(kcmd.KDKsPath, match); err == nil {
+			log.WithField("kernel", kernel).Info("Matching KDK already installed")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no installed KDK has kernel UUID %s (supply the kernelcache or macOS build to download it)", match)
+	}
+	return kdks.GetBuild(match)
+}
@@ -22,11 +22,14 @@ THE SOFTWARE.
 package download
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/fatih/color"
@@ -41,7 +44,12 @@ func init() {
 	macosCmd.Flags().StringP("work-dir", "w", "", "macOS installer creator working directory")
 	macosCmd.Flags().Bool("ignore", false, "Do NOT verify pkg digests")
 	macosCmd.Flags().BoolP("assistant", "a", false, "Only download the InstallAssistant.pkg")
-	macosCmd.Flags().Bool("latest", false, "Download latest macOS installer")
+	macosCmd.Flags().Bool("latest", false, "Download latest macOS installer (of --version if supplied)")
+	macosCmd.Flags().StringP("catalog", "c", "", "Software update catalog: release, customer, developer, public OR a sucatalog URL (default: host seed or developer)")
+	macosCmd.Flags().Bool("json", false, "Output --list as JSON")
+	macosCmd.RegisterFlagCompletionFunc("catalog", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"release", "customer", "developer", "public"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	// macosCmd.Flags().BoolP("kernel", "k", false, "Extract kernelcache from remote installer")
 	macosCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
 		DownloadCmd.PersistentFlags().MarkHidden("white-list")
@@ -55,14 +63,23 @@ func init() {
 	viper.BindPFlag("download.macos.ignore", macosCmd.Flags().Lookup("ignore"))
 	viper.BindPFlag("download.macos.assistant", macosCmd.Flags().Lookup("assistant"))
 	viper.BindPFlag("download.macos.latest", macosCmd.Flags().Lookup("latest"))
+	viper.BindPFlag("download.macos.catalog", macosCmd.Flags().Lookup("catalog"))
+	viper.BindPFlag("download.macos.json", macosCmd.Flags().Lookup("json"))
 	// viper.BindPFlag("download.macos.kernel", macosCmd.Flags().Lookup("kernel"))
 }
 
 // macosCmd represents the macos command
 var macosCmd = &cobra.Command{
-	Use:           "macos",
-	Aliases:       []string{"m", "mac"},
-	Short:         "Download macOS installers",
+	Use:     "macos",
+	Aliases: []string{"m", "mac"},
+	Short:   "Download macOS installers",
+	Example: heredoc.Doc(`
+		# List the macOS installers in the public seed catalog
+		❯ ipsw download macos --list --catalog public
+		# Download the latest macOS 15.x InstallAssistant.pkg from the release catalog
+		❯ ipsw download macos --catalog release --version 15 --latest --assistant
+		# Download the full installer for a build
+		❯ ipsw download macos --build 24A335`),
 	SilenceUsage:  false,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -99,34 +116,63 @@ var macosCmd = &cobra.Command{
 		ignoreSha1 := viper.GetBool("download.macos.ignore")
 		assistantOnly := viper.GetBool("download.macos.assistant")
 		latest := viper.GetBool("download.macos.latest")
+		catalog := viper.GetString("download.macos.catalog")
+		asJSON := viper.GetBool("download.macos.json")
 		// remoteKernel := viper.GetString("download.macos.kernel")
 
 		// verify args
 		if len(version) > 0 && len(build) > 0 {
 			return fmt.Errorf("you cannot supply a --version AND a --build (they are mutually exclusive)")
-		} else if (len(version) > 0 || len(build) > 0) && latest {
-			return fmt.Errorf("you cannot supply a --latest AND (--version OR --build) (they are mutually exclusive)")
+		} else if len(build) > 0 && latest {
+			return fmt.Errorf("you cannot supply a --latest AND a --build (they are mutually exclusive)")
 		}
 
-		prods, err := download.GetProductInfo()
+		prods, err := download.GetCatalogProductInfo(catalog, proxy, insecure)
 		if err != nil {
 			return err
 		}
 
-		if showInstallers {
-			fmt.Println(prods)
-			return nil
-		}
-
 		// filter installers
 		if len(version) > 0 {
 			prods = prods.FilterByVersion(version)
 		} else if len(build) > 0 {
 			prods = prods.FilterByBuild(build)
-		} else if latest {
+		}
+		if latest {
 			prods = prods.GetLatest()
 		}
 
+		if showInstallers {
+			if asJSON {
+				type installer struct {
+					ProductID string    `json:"product_id"`
+					Title     string    `json:"title"`
+					Version   string    `json:"version"`
+					Build     string    `json:"build"`
+					PostDate  time.Time `json:"post_date"`
+					Packages  []string  `json:"packages,omitempty"`
+				}
+				var out []installer
+				for _, p := range prods {
+					i := installer{ProductID: p.ProductID, Title: p.Title, Version: p.Version, Build: p.Build, PostDate: p.PostDate}
+					for _, pkg := range p.Product.Packages {
+						if len(pkg.URL) > 0 {
+							i.Packages = append(i.Packages, pkg.URL)
+						}
+					}
+					out = append(out, i)
+				}
+				dat, err := json.MarshalIndent(out, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal installers: %v", err)
+				}
+				fmt.Println(string(dat))
+				return nil
+			}
+			fmt.Println(prods)
+			return nil
+		}
+
 		var prodList []string
 		for _, p := range prods {
 			prodList = append(prodList, fmt.Sprintf("%-35s%-8s %-8s %s", p.Title, p.Version, p.Build, p.PostDate.Format("02Jan2006 15:04:05")))
@@ -169,7 +215,7 @@ var macosCmd = &cobra.Command{
 				log.Fatal(err.Error())
 			}
 			var chosenProds []download.ProductInfo
-			for choice := range choices {
+			for _, choice := range choices {
 				chosenProds = append(chosenProds, prods[choice])
 			}
 			prods = chosenProds
@@ -75,9 +75,11 @@ func init() {
 	})
 	otaDLCmd.Flags().Bool("driver-kit", false, "Extract DriverKit dyld_shared_cache(s) from remote OTA zip")
 	otaDLCmd.Flags().String("pattern", "", "Download remote files that match regex")
+	otaDLCmd.Flags().StringArray("glob", []string{}, "Download remote files that match glob (i.e. 'Firmware/**/*.im4p', can be repeated)")
 	otaDLCmd.Flags().BoolP("flat", "f", false, "Do NOT perserve directory structure when downloading with --pattern")
 	otaDLCmd.Flags().Bool("info", false, "Show all the latest OTAs available")
 	otaDLCmd.Flags().StringP("output", "o", "", "Folder to download files to")
+	otaDLCmd.Flags().Bool("decrypt", false, "Decrypt AEA encrypted OTAs after downloading them")
 	otaDLCmd.MarkFlagDirname("output")
 	otaDLCmd.Flags().Bool("show-latest-version", false, "Show latest iOS version")
 	otaDLCmd.Flags().Bool("show-latest-build", false, "Show latest iOS build")
@@ -94,9 +96,11 @@ func init() {
 	viper.BindPFlag("download.ota.driver-kit", otaDLCmd.Flags().Lookup("driver-kit"))
 	viper.BindPFlag("download.ota.kernel", otaDLCmd.Flags().Lookup("kernel"))
 	viper.BindPFlag("download.ota.pattern", otaDLCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("download.ota.glob", otaDLCmd.Flags().Lookup("glob"))
 	viper.BindPFlag("download.ota.flat", otaDLCmd.Flags().Lookup("flat"))
 	viper.BindPFlag("download.ota.info", otaDLCmd.Flags().Lookup("info"))
 	viper.BindPFlag("download.ota.output", otaDLCmd.Flags().Lookup("output"))
+	viper.BindPFlag("download.ota.decrypt", otaDLCmd.Flags().Lookup("decrypt"))
 	viper.BindPFlag("download.ota.show-latest-version", otaDLCmd.Flags().Lookup("show-latest-version"))
 	viper.BindPFlag("download.ota.show-latest-build", otaDLCmd.Flags().Lookup("show-latest-build"))
 }
@@ -163,10 +167,11 @@ var otaDLCmd = &cobra.Command{
 		dyldArches := viper.GetStringSlice("download.ota.dyld-arch")
 		dyldDriverKit := viper.GetBool("download.ota.driver-kit")
 		remoteKernel := viper.GetBool("download.ota.kernel")
-		remotePattern := viper.GetString("download.ota.pattern")
+		remotePattern := utils.MergePatterns(viper.GetString("download.ota.pattern"), viper.GetStringSlice("download.ota.glob"))
 		flat := viper.GetBool("download.ota.flat")
 		otaInfo := viper.GetBool("download.ota.info")
 		output := viper.GetString("download.ota.output")
+		decrypt := viper.GetBool("download.ota.decrypt")
 		showLatestVersion := viper.GetBool("download.ota.show-latest-version")
 		showLatestBuild := viper.GetBool("download.ota.show-latest-build")
 		// verify args
@@ -383,8 +388,25 @@ var otaDLCmd = &cobra.Command{
 					if err != nil {
 						return err
 					} else if isAEA {
-						log.Warn("This OTA is AEA encrypted and is NOT supported for remote extraction (yet 🤞)")
-						return nil
+						// AEA OTAs can't be read remotely so download and decrypt them and extract from the local OTA instead
+						log.Warn("This OTA is AEA encrypted and must be downloaded and decrypted before extracting")
+						aeaPath := filepath.Join(destPath, getDestName(config.URL, removeCommas))
+						if _, err := os.Stat(aeaPath); os.IsNotExist(err) {
+							downloader := download.NewDownload(proxy, insecure, skipAll, resumeAll, restartAll, false, viper.GetBool("verbose"))
+							downloader.URL = config.URL
+							downloader.DestName = aeaPath
+							downloader.Segments = viper.GetInt("download.segments")
+							if err := downloader.Do(); err != nil {
+								return fmt.Errorf("failed to download file: %v", err)
+							}
+						} else if err != nil {
+							return fmt.Errorf("failed to stat file %s: %v", aeaPath, err)
+						}
+						config.IPSW, err = decryptAEA(aeaPath, o.ArchiveDecryptionKey, destPath)
+						if err != nil {
+							return err
+						}
+						config.URL = ""
 					}
 
 					if remoteKernel {
@@ -467,6 +489,7 @@ var otaDLCmd = &cobra.Command{
 						// download file
 						downloader.URL = url
 						downloader.DestName = destName
+						downloader.Segments = viper.GetInt("download.segments")
 						if err := downloader.Do(); err != nil {
 							return fmt.Errorf("failed to download file: %v", err)
 						}
@@ -475,6 +498,11 @@ var otaDLCmd = &cobra.Command{
 					} else {
 						log.Warnf("OTA already exists: %s", destName)
 					}
+					if decrypt && o.IsEncrypted {
+						if _, err := decryptAEA(destName, o.ArchiveDecryptionKey, ""); err != nil {
+							return err
+						}
+					}
 				}
 			}
 		}
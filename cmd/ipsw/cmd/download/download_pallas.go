@@ -0,0 +1,273 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	DownloadCmd.AddCommand(downloadPallasCmd)
+
+	downloadPallasCmd.Flags().StringP("type", "t", "ota", "Asset type (alias or full asset type name)")
+	downloadPallasCmd.Flags().StringP("audience", "a", "ios", "Asset audience (UUID or <platform>[:release|generic|alternate|<VERSION>-<developer|public|appleseed>-beta])")
+	downloadPallasCmd.Flags().String("hw-model", "", "Hardware model (i.e. D74AP)")
+	downloadPallasCmd.Flags().String("requested-version", "", "Requested product version")
+	downloadPallasCmd.Flags().String("release-type", "", "Release type (i.e. Beta)")
+	downloadPallasCmd.Flags().Bool("supervised", false, "Request as a supervised device")
+	downloadPallasCmd.Flags().StringArray("param", []string{}, "Extra request parameter as KEY=VALUE (can be repeated)")
+	downloadPallasCmd.Flags().Bool("list-types", false, "List the asset type aliases")
+	downloadPallasCmd.Flags().BoolP("json", "j", false, "Dump the pallas response as JSON")
+	downloadPallasCmd.Flags().BoolP("urls", "u", false, "Dump asset URLs only")
+	downloadPallasCmd.Flags().Bool("download", false, "Download the returned assets")
+	downloadPallasCmd.Flags().StringP("output", "o", "", "Folder to download files to")
+	downloadPallasCmd.MarkFlagDirname("output")
+	downloadPallasCmd.MarkFlagsMutuallyExclusive("json", "urls", "download")
+	viper.BindPFlag("download.pallas.type", downloadPallasCmd.Flags().Lookup("type"))
+	viper.BindPFlag("download.pallas.audience", downloadPallasCmd.Flags().Lookup("audience"))
+	viper.BindPFlag("download.pallas.hw-model", downloadPallasCmd.Flags().Lookup("hw-model"))
+	viper.BindPFlag("download.pallas.requested-version", downloadPallasCmd.Flags().Lookup("requested-version"))
+	viper.BindPFlag("download.pallas.release-type", downloadPallasCmd.Flags().Lookup("release-type"))
+	viper.BindPFlag("download.pallas.supervised", downloadPallasCmd.Flags().Lookup("supervised"))
+	viper.BindPFlag("download.pallas.param", downloadPallasCmd.Flags().Lookup("param"))
+	viper.BindPFlag("download.pallas.list-types", downloadPallasCmd.Flags().Lookup("list-types"))
+	viper.BindPFlag("download.pallas.json", downloadPallasCmd.Flags().Lookup("json"))
+	viper.BindPFlag("download.pallas.urls", downloadPallasCmd.Flags().Lookup("urls"))
+	viper.BindPFlag("download.pallas.download", downloadPallasCmd.Flags().Lookup("download"))
+	viper.BindPFlag("download.pallas.output", downloadPallasCmd.Flags().Lookup("output"))
+
+	downloadPallasCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
+		DownloadCmd.PersistentFlags().MarkHidden("white-list")
+		DownloadCmd.PersistentFlags().MarkHidden("black-list")
+		DownloadCmd.PersistentFlags().MarkHidden("model")
+		c.Parent().HelpFunc()(c, s)
+	})
+	downloadPallasCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var types []string
+		for alias := range download.PallasAssetTypes {
+			types = append(types, alias)
+		}
+		sort.Strings(types)
+		return types, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// parsePallasParam parses a KEY=VALUE request parameter (VALUE is a bool or int if it parses as one)
+func parsePallasParam(param string) (string, any, error) {
+	key, val, found := strings.Cut(param, "=")
+	if !found || len(key) == 0 {
+		return "", nil, fmt.Errorf("invalid --param '%s' (expected KEY=VALUE)", param)
+	}
+	if b, err := strconv.ParseBool(val); err == nil {
+		return key, b, nil
+	}
+	if i, err := strconv.Atoi(val); err == nil {
+		return key, i, nil
+	}
+	return key, val, nil
+}
+
+// downloadPallasCmd represents the pallas command
+var downloadPallasCmd = &cobra.Command{
+	Use:   "pallas",
+	Short: "Query Apple's pallas server for any MobileAsset type",
+	Example: heredoc.Doc(`
+		# List the asset type aliases
+		❯ ipsw download pallas --list-types
+		# Get the latest iOS 18 developer beta OTA for the iPhone15,2
+		❯ ipsw download pallas --type ota --audience ios:18-developer-beta --device iPhone15,2 --version 18.0 --build 22A3354
+		# Get the macOS SFR (recoveryOS) assets as JSON
+		❯ ipsw download pallas --type sfr --audience macos --device Mac14,7 --json
+		# Query an arbitrary asset type with extra request parameters and download the results
+		❯ ipsw download pallas --type com.apple.MobileAsset.MobileSoftwareUpdate.UpdateBrain --audience ios --device iPhone15,2 --param RestoreVersion=0.0.0.0.0,0 --download`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		// parent flags
+		viper.BindPFlag("download.proxy", cmd.Flags().Lookup("proxy"))
+		viper.BindPFlag("download.insecure", cmd.Flags().Lookup("insecure"))
+		viper.BindPFlag("download.confirm", cmd.Flags().Lookup("confirm"))
+		viper.BindPFlag("download.skip-all", cmd.Flags().Lookup("skip-all"))
+		viper.BindPFlag("download.resume-all", cmd.Flags().Lookup("resume-all"))
+		viper.BindPFlag("download.restart-all", cmd.Flags().Lookup("restart-all"))
+		viper.BindPFlag("download.remove-commas", cmd.Flags().Lookup("remove-commas"))
+		viper.BindPFlag("download.device", cmd.Flags().Lookup("device"))
+		viper.BindPFlag("download.version", cmd.Flags().Lookup("version"))
+		viper.BindPFlag("download.build", cmd.Flags().Lookup("build"))
+		// settings
+		proxy := viper.GetString("download.proxy")
+		insecure := viper.GetBool("download.insecure")
+		skipAll := viper.GetBool("download.skip-all")
+		resumeAll := viper.GetBool("download.resume-all")
+		restartAll := viper.GetBool("download.restart-all")
+		removeCommas := viper.GetBool("download.remove-commas")
+
+		if viper.GetBool("download.pallas.list-types") {
+			var aliases []string
+			for alias := range download.PallasAssetTypes {
+				aliases = append(aliases, alias)
+			}
+			sort.Strings(aliases)
+			for _, alias := range aliases {
+				fmt.Printf("%-14s %s\n", alias, download.PallasAssetTypes[alias])
+			}
+			return nil
+		}
+
+		audience, err := download.ResolveAudience(viper.GetString("download.pallas.audience"))
+		if err != nil {
+			return fmt.Errorf("failed to resolve --audience: %v", err)
+		}
+
+		params := make(map[string]any)
+		for _, param := range viper.GetStringSlice("download.pallas.param") {
+			key, val, err := parsePallasParam(param)
+			if err != nil {
+				return err
+			}
+			params[key] = val
+		}
+
+		log.WithFields(log.Fields{
+			"type":     download.PallasAssetType(viper.GetString("download.pallas.type")),
+			"audience": audience,
+		}).Info("Querying pallas")
+		res, err := download.QueryPallas(&download.PallasQuery{
+			AssetType:               viper.GetString("download.pallas.type"),
+			AssetAudience:           audience,
+			ProductType:             viper.GetString("download.device"),
+			HWModel:                 viper.GetString("download.pallas.hw-model"),
+			ProductVersion:          viper.GetString("download.version"),
+			BuildVersion:            viper.GetString("download.build"),
+			RequestedProductVersion: viper.GetString("download.pallas.requested-version"),
+			ReleaseType:             viper.GetString("download.pallas.release-type"),
+			Supervised:              viper.GetBool("download.pallas.supervised"),
+			Params:                  params,
+			Proxy:                   proxy,
+			Insecure:                insecure,
+		})
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("download.pallas.json") {
+			dat, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal json: %v", err)
+			}
+			if viper.GetBool("color") && !viper.GetBool("no-color") {
+				if err := quick.Highlight(os.Stdout, string(dat)+"\n", "json", "terminal256", "nord"); err != nil {
+					return fmt.Errorf("failed to highlight json: %v", err)
+				}
+			} else {
+				fmt.Println(string(dat))
+			}
+			return nil
+		}
+
+		if len(res.Assets) == 0 {
+			log.Warn("No assets returned")
+			return nil
+		}
+
+		if viper.GetBool("download.pallas.urls") {
+			for _, asset := range res.Assets {
+				if url := asset.URL(); len(url) > 0 {
+					fmt.Println(url)
+				}
+			}
+			return nil
+		}
+
+		for _, asset := range res.Assets {
+			fields := log.Fields{}
+			for _, key := range []string{"OSVersion", "Build", "SUDocumentationID", "AssetFormat", "_CompatibilityVersion"} {
+				if val := asset.Get(key); len(val) > 0 {
+					fields[key] = val
+				}
+			}
+			if size, ok := asset["_UnarchivedSize"].(float64); ok {
+				fields["size"] = humanize.Bytes(uint64(size))
+			}
+			name := asset.URL()
+			if len(name) == 0 {
+				name = asset.Get("_AssetReceipt")
+			}
+			log.WithFields(fields).Info(name)
+		}
+
+		if viper.GetBool("download.pallas.download") {
+			destPath := filepath.Clean(viper.GetString("download.pallas.output"))
+			if err := os.MkdirAll(destPath, 0o750); err != nil {
+				return fmt.Errorf("failed to create output folder: %v", err)
+			}
+			downloader := download.NewDownload(proxy, insecure, skipAll, resumeAll, restartAll, false, viper.GetBool("verbose"))
+			for idx, asset := range res.Assets {
+				url := asset.URL()
+				if len(url) == 0 {
+					continue
+				}
+				fname := filepath.Join(destPath, getDestName(url, removeCommas))
+				if build := asset.Get("Build"); len(build) > 0 {
+					fname = filepath.Join(destPath, build+"_"+getDestName(url, removeCommas))
+				}
+				if _, err := os.Stat(fname); err == nil {
+					log.Warnf("Asset already exists: %s", fname)
+					continue
+				}
+				log.Infof("Getting (%d/%d) %s", idx+1, len(res.Assets), filepath.Base(fname))
+				downloader.URL = url
+				downloader.DestName = fname
+				downloader.Segments = viper.GetInt("download.segments")
+				if err := downloader.Do(); err != nil {
+					return fmt.Errorf("failed to download asset: %v", err)
+				}
+				utils.Indent(log.Info, 2)("Created " + fname)
+			}
+		}
+
+		return nil
+	},
+}
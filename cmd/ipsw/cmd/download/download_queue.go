@@ -0,0 +1,397 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/download/queue"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	DownloadCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueAddCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueRunCmd)
+	queueCmd.AddCommand(queueClearCmd)
+
+	queueCmd.PersistentFlags().String("db", "", "Download queue database (default is queue.db in the config folder)")
+	viper.BindPFlag("download.queue.db", queueCmd.PersistentFlags().Lookup("db"))
+
+	queueAddCmd.Flags().BoolP("latest", "l", false, "Queue the latest IPSW of each device")
+	viper.BindPFlag("download.queue.add.latest", queueAddCmd.Flags().Lookup("latest"))
+
+	queueListCmd.Flags().StringP("status", "s", "", "Only list items with status (pending, running, done or failed)")
+	queueListCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	viper.BindPFlag("download.queue.ls.status", queueListCmd.Flags().Lookup("status"))
+	viper.BindPFlag("download.queue.ls.json", queueListCmd.Flags().Lookup("json"))
+
+	queueRunCmd.Flags().IntP("workers", "w", 2, "Number of parallel downloads")
+	queueRunCmd.Flags().IntP("retries", "r", 3, "Number of attempts per IPSW before it is marked as failed")
+	queueRunCmd.Flags().Duration("backoff", time.Minute, "Delay before the first retry (doubles with each attempt)")
+	queueRunCmd.Flags().StringP("output", "o", "", "Folder to download files to")
+	queueRunCmd.MarkFlagDirname("output")
+	viper.BindPFlag("download.queue.run.workers", queueRunCmd.Flags().Lookup("workers"))
+	viper.BindPFlag("download.queue.run.retries", queueRunCmd.Flags().Lookup("retries"))
+	viper.BindPFlag("download.queue.run.backoff", queueRunCmd.Flags().Lookup("backoff"))
+	viper.BindPFlag("download.queue.run.output", queueRunCmd.Flags().Lookup("output"))
+
+	queueClearCmd.Flags().StringP("status", "s", "done", "Only remove items with status (pending, running, done, failed or all)")
+	viper.BindPFlag("download.queue.clear.status", queueClearCmd.Flags().Lookup("status"))
+
+	for _, c := range []*cobra.Command{queueListCmd, queueClearCmd} {
+		c.RegisterFlagCompletionFunc("status", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"pending", "running", "done", "failed"}, cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+
+	queueCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
+		DownloadCmd.PersistentFlags().MarkHidden("white-list")
+		DownloadCmd.PersistentFlags().MarkHidden("black-list")
+		DownloadCmd.PersistentFlags().MarkHidden("model")
+		c.Root().HelpFunc()(c, s)
+	})
+}
+
+// openQueue opens (creating if needed) the download queue sqlite database
+func openQueue() (db.Database, error) {
+	path := viper.GetString("download.queue.db")
+	if len(path) == 0 {
+		if len(viper.ConfigFileUsed()) > 0 {
+			path = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "queue.db")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user home directory: %v", err)
+			}
+			path = filepath.Join(home, ".config", "ipsw", "queue.db")
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create queue database folder: %v", err)
+	}
+	d, err := db.NewSqlite(path, 100)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue database: %v", err)
+	}
+	if err := d.Connect(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func parseQueueStatus(status string) (model.QueueStatus, error) {
+	switch s := model.QueueStatus(strings.ToLower(status)); s {
+	case "", "all":
+		return "", nil
+	case model.QueuePending, model.QueueRunning, model.QueueDone, model.QueueFailed:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid status '%s' (must be one of: pending, running, done, failed)", status)
+	}
+}
+
+// queueCmd represents the queue command
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Persistent multi-device IPSW download queue",
+	Long: heredoc.Doc(`
+		Queue device/build IPSWs and download them with a pool of workers.
+
+		The queue is stored in a sqlite database so interrupted runs resume
+		where they left off and failed downloads are retried with backoff.`),
+	Example: heredoc.Doc(`
+		# Queue the latest IPSWs for a few devices
+		❯ ipsw download queue add --latest iPhone15,2 iPhone15,3 iPad14,1
+		# Queue specific builds (or versions)
+		❯ ipsw download queue add iPhone15,2:21A329 iPhone15,3:17.0.1
+		# Queue the latest IPSWs of the devices in a devices.yaml
+		❯ ipsw download queue add --latest --device-list devices.yaml
+		# Download the queue with 4 parallel downloads
+		❯ ipsw download queue run --workers 4 --output /mnt/ipsws
+		# Show the queue
+		❯ ipsw download queue ls`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// queueAddCmd represents the queue add command
+var queueAddCmd = &cobra.Command{
+	Use:           "add <DEVICE[:BUILD|VERSION]>...",
+	Short:         "Add device/build IPSWs to the download queue",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		viper.BindPFlag("download.device", cmd.Flags().Lookup("device"))
+		viper.BindPFlag("download.version", cmd.Flags().Lookup("version"))
+		viper.BindPFlag("download.build", cmd.Flags().Lookup("build"))
+
+		build := viper.GetString("download.build")
+		if len(build) == 0 {
+			build = viper.GetString("download.version")
+		}
+		if viper.GetBool("download.queue.add.latest") {
+			if len(build) > 0 {
+				return fmt.Errorf("--latest cannot be used with --build or --version")
+			}
+			build = "latest"
+		}
+
+		if device := viper.GetString("download.device"); len(device) > 0 {
+			args = append(args, device)
+		}
+		devList, err := getDeviceList()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 && devList != nil {
+			args = devList.Identifiers()
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("no devices provided (or in the --device-list)")
+		}
+
+		var pairs [][2]string
+		for _, arg := range args {
+			device, bld, found := strings.Cut(arg, ":")
+			if !found {
+				bld = build
+			}
+			if len(bld) == 0 {
+				return fmt.Errorf("no build/version for %s (use DEVICE:BUILD, --build, --version or --latest)", device)
+			}
+			pairs = append(pairs, [2]string{device, bld})
+		}
+
+		d, err := openQueue()
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		items, err := queue.Enqueue(d, pairs, devList)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			log.WithFields(log.Fields{
+				"device": item.Device,
+				"build":  item.Build,
+				"status": item.Status,
+			}).Info("Queued")
+		}
+
+		return nil
+	},
+}
+
+// queueListCmd represents the queue ls command
+var queueListCmd = &cobra.Command{
+	Use:           "ls",
+	Aliases:       []string{"list"},
+	Short:         "List the download queue",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		status, err := parseQueueStatus(viper.GetString("download.queue.ls.status"))
+		if err != nil {
+			return err
+		}
+
+		d, err := openQueue()
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		items, err := d.GetQueue(status)
+		if err != nil {
+			return fmt.Errorf("failed to get queue: %v", err)
+		}
+
+		if viper.GetBool("download.queue.ls.json") {
+			dat, err := json.MarshalIndent(items, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal queue: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(items) == 0 {
+			log.Warn("Download queue is empty")
+			return nil
+		}
+
+		var data [][]string
+		for _, item := range items {
+			data = append(data, []string{
+				strconv.Itoa(int(item.ID)),
+				item.Device,
+				item.Build,
+				item.Version,
+				string(item.Status),
+				strconv.Itoa(item.Attempts),
+				item.Error,
+			})
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Device", "Build", "Version", "Status", "Attempts", "Error"})
+		table.SetAutoWrapText(false)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.AppendBulk(data)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.Render()
+
+		return nil
+	},
+}
+
+// queueRunCmd represents the queue run command
+var queueRunCmd = &cobra.Command{
+	Use:           "run",
+	Short:         "Download the queued IPSWs",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		viper.BindPFlag("download.proxy", cmd.Flags().Lookup("proxy"))
+		viper.BindPFlag("download.insecure", cmd.Flags().Lookup("insecure"))
+		viper.BindPFlag("download.remove-commas", cmd.Flags().Lookup("remove-commas"))
+
+		d, err := openQueue()
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := queue.Run(ctx, d, &queue.Config{
+			Workers:      viper.GetInt("download.queue.run.workers"),
+			Retries:      viper.GetInt("download.queue.run.retries"),
+			Backoff:      viper.GetDuration("download.queue.run.backoff"),
+			Output:       viper.GetString("download.queue.run.output"),
+			Proxy:        viper.GetString("download.proxy"),
+			Insecure:     viper.GetBool("download.insecure"),
+			RemoveCommas: viper.GetBool("download.remove-commas"),
+			Segments:     viper.GetInt("download.segments"),
+			Verbose:      viper.GetBool("verbose"),
+			Done: func(item *model.QueueItem) {
+				log.Info("Created: " + item.Path)
+				if err := checkSigned(item.Path, item.Device, item.Version, item.Build); err != nil {
+					log.WithError(err).Warn("Failed to check signing status")
+				}
+			},
+		}); err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			log.Warn("Interrupted (the remaining queue will be resumed by the next run)")
+			return nil
+		}
+
+		failed, err := d.GetQueue(model.QueueFailed)
+		if err != nil {
+			return fmt.Errorf("failed to get failed queue items: %v", err)
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%d queued IPSW(s) failed to download (see `ipsw download queue ls --status failed`)", len(failed))
+		}
+
+		return nil
+	},
+}
+
+// queueClearCmd represents the queue clear command
+var queueClearCmd = &cobra.Command{
+	Use:           "clear",
+	Short:         "Remove items from the download queue",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		status, err := parseQueueStatus(viper.GetString("download.queue.clear.status"))
+		if err != nil {
+			return err
+		}
+
+		d, err := openQueue()
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+
+		if err := d.ClearQueue(status); err != nil {
+			return fmt.Errorf("failed to clear queue: %v", err)
+		}
+
+		return nil
+	},
+}
@@ -24,7 +24,11 @@ THE SOFTWARE.
 package download
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
@@ -39,9 +43,11 @@ func init() {
 
 	tssCmd.Flags().BoolP("signed", "s", false, "Check if iOS version is still being signed")
 	tssCmd.Flags().BoolP("usb", "u", false, "Download blobs for USB connected device")
+	tssCmd.Flags().StringP("input", "i", "", "JSON file from `ipsw idev nonce --json` command")
 	tssCmd.Flags().StringP("output", "o", "", "Output directory to save blobs to")
 	viper.BindPFlag("download.tss.signed", tssCmd.Flags().Lookup("signed"))
 	viper.BindPFlag("download.tss.usb", tssCmd.Flags().Lookup("usb"))
+	viper.BindPFlag("download.tss.input", tssCmd.Flags().Lookup("input"))
 	viper.BindPFlag("download.tss.output", tssCmd.Flags().Lookup("output"))
 
 	tssCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
@@ -57,6 +63,7 @@ func init() {
 	})
 
 	tssCmd.MarkFlagDirname("output")
+	tssCmd.MarkFlagFilename("input", "json")
 }
 
 // tssCmd represents the tss command
@@ -106,12 +113,49 @@ var tssCmd = &cobra.Command{
 				return err
 			}
 			conf.ECID = uint64(dev.UniqueChipID)
+			conf.ApBoardID = uint64(dev.BoardID)
+			conf.ApChipID = uint64(dev.ChipID)
 			conf.Device = dev.ProductType
 			conf.Build = dev.BuildVersion
 			conf.Version = dev.ProductVersion
 			conf.ApNonce = dev.ApNonce
 			conf.SepNonce = dev.SEPNonce
 			conf.Image4Supported = dev.Image4Supported
+		} else if viper.IsSet("download.tss.input") {
+			dat, err := os.ReadFile(filepath.Clean(viper.GetString("download.tss.input")))
+			if err != nil {
+				return fmt.Errorf("failed to read input file: %v", err)
+			}
+			var nonce struct {
+				ProductType    string `json:"ProductType,omitempty"`
+				BuildVersion   string `json:"BuildVersion,omitempty"`
+				ProductVersion string `json:"ProductVersion,omitempty"`
+				BoardID        uint64 `json:"BoardId"`
+				ChipID         uint64 `json:"ChipID"`
+				ECID           uint64 `json:"UniqueChipID"`
+				ApNonce        string `json:"ApNonce"`
+				SEPNonce       string `json:"SEPNonce,omitempty"`
+			}
+			if err := json.Unmarshal(dat, &nonce); err != nil {
+				return fmt.Errorf("failed to parse input file: %v", err)
+			}
+			if len(nonce.ProductType) > 0 {
+				conf.Device = nonce.ProductType
+			}
+			if len(conf.Version) == 0 && len(conf.Build) == 0 {
+				conf.Build = nonce.BuildVersion
+				conf.Version = nonce.ProductVersion
+			}
+			conf.ECID = nonce.ECID
+			conf.ApBoardID = nonce.BoardID
+			conf.ApChipID = nonce.ChipID
+			conf.Image4Supported = true
+			if conf.ApNonce, err = hex.DecodeString(nonce.ApNonce); err != nil {
+				return fmt.Errorf("failed to decode ApNonce: %v", err)
+			}
+			if conf.SepNonce, err = hex.DecodeString(nonce.SEPNonce); err != nil {
+				return fmt.Errorf("failed to decode SEPNonce: %v", err)
+			}
 		}
 
 		if isSigned {
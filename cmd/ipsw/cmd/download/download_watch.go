@@ -0,0 +1,252 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package download
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	DownloadCmd.AddCommand(downloadWatchCmd)
+
+	downloadWatchCmd.Flags().StringSlice("devices", []string{}, "Devices to watch (i.e. iPhone15,2,iPad14,1)")
+	downloadWatchCmd.Flags().Bool("beta", false, "Watch for beta builds")
+	downloadWatchCmd.Flags().Bool("ipsw", false, "Also watch ipsw.me for new IPSWs")
+	downloadWatchCmd.Flags().Duration("interval", time.Hour, "Polling interval")
+	downloadWatchCmd.Flags().Bool("once", false, "Check once and exit (i.e. when run from cron)")
+	downloadWatchCmd.Flags().String("exec", "", "Shell hook to run for each new build (details are in the IPSW_WATCH_* env vars)")
+	downloadWatchCmd.Flags().String("webhook", "", "Slack or Discord webhook URL to notify of new builds")
+	downloadWatchCmd.Flags().Bool("download", false, "Download new builds")
+	downloadWatchCmd.Flags().String("state", "", "Watch state file (default: ~/.config/ipsw/watch.json)")
+	downloadWatchCmd.Flags().StringP("output", "o", "", "Folder to download files to")
+	downloadWatchCmd.MarkFlagDirname("output")
+	viper.BindPFlag("download.watch.devices", downloadWatchCmd.Flags().Lookup("devices"))
+	viper.BindPFlag("download.watch.beta", downloadWatchCmd.Flags().Lookup("beta"))
+	viper.BindPFlag("download.watch.ipsw", downloadWatchCmd.Flags().Lookup("ipsw"))
+	viper.BindPFlag("download.watch.interval", downloadWatchCmd.Flags().Lookup("interval"))
+	viper.BindPFlag("download.watch.once", downloadWatchCmd.Flags().Lookup("once"))
+	viper.BindPFlag("download.watch.exec", downloadWatchCmd.Flags().Lookup("exec"))
+	viper.BindPFlag("download.watch.webhook", downloadWatchCmd.Flags().Lookup("webhook"))
+	viper.BindPFlag("download.watch.download", downloadWatchCmd.Flags().Lookup("download"))
+	viper.BindPFlag("download.watch.state", downloadWatchCmd.Flags().Lookup("state"))
+	viper.BindPFlag("download.watch.output", downloadWatchCmd.Flags().Lookup("output"))
+
+	downloadWatchCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
+		DownloadCmd.PersistentFlags().MarkHidden("white-list")
+		DownloadCmd.PersistentFlags().MarkHidden("black-list")
+		DownloadCmd.PersistentFlags().MarkHidden("model")
+		DownloadCmd.PersistentFlags().MarkHidden("version")
+		DownloadCmd.PersistentFlags().MarkHidden("build")
+		c.Parent().HelpFunc()(c, s)
+	})
+}
+
+// downloadWatchCmd represents the watch command
+var downloadWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for new builds and notify/download them",
+	Long: heredoc.Doc(`
+		Poll Apple's pallas server (and optionally ipsw.me) for new builds of the watched devices.
+
+		The first check of a device records its current builds as a baseline (nothing is reported),
+		every check after that reports the builds that have not been seen before. The devices can also
+		be set in the config file under 'download.watch.devices' or in a devices.yaml (see --device-list).`),
+	Example: heredoc.Doc(`
+		# Watch for new iOS betas every 30 minutes and post them to a Slack channel
+		❯ ipsw download watch --devices iPhone15,2,iPhone16,1 --beta --interval 30m --webhook https://hooks.slack.com/services/...
+		# Run a script for every new build (from cron)
+		❯ ipsw download watch --devices iPhone15,2 --once --exec 'echo "$IPSW_WATCH_VERSION ($IPSW_WATCH_BUILD)" >> builds.txt'
+		# Automatically download new IPSWs
+		❯ ipsw download watch --devices iPhone15,2 --ipsw --download --output /mnt/ipsws`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// parent flags
+		viper.BindPFlag("download.proxy", cmd.Flags().Lookup("proxy"))
+		viper.BindPFlag("download.insecure", cmd.Flags().Lookup("insecure"))
+		viper.BindPFlag("download.skip-all", cmd.Flags().Lookup("skip-all"))
+		viper.BindPFlag("download.resume-all", cmd.Flags().Lookup("resume-all"))
+		viper.BindPFlag("download.restart-all", cmd.Flags().Lookup("restart-all"))
+		viper.BindPFlag("download.remove-commas", cmd.Flags().Lookup("remove-commas"))
+		viper.BindPFlag("download.device", cmd.Flags().Lookup("device"))
+		// settings
+		proxy := viper.GetString("download.proxy")
+		insecure := viper.GetBool("download.insecure")
+		skipAll := viper.GetBool("download.skip-all")
+		resumeAll := viper.GetBool("download.resume-all")
+		restartAll := viper.GetBool("download.restart-all")
+		removeCommas := viper.GetBool("download.remove-commas")
+		// flags
+		devices := viper.GetStringSlice("download.watch.devices")
+		if device := viper.GetString("download.device"); len(device) > 0 {
+			devices = append(devices, device)
+		}
+		interval := viper.GetDuration("download.watch.interval")
+		hook := viper.GetString("download.watch.exec")
+		webhook := viper.GetString("download.watch.webhook")
+		doDownload := viper.GetBool("download.watch.download")
+		output := viper.GetString("download.watch.output")
+		stateFile := viper.GetString("download.watch.state")
+		devList, err := getDeviceList()
+		if err != nil {
+			return err
+		}
+		if len(devices) == 0 && devList != nil {
+			devices = devList.Identifiers()
+		}
+		// verify flags
+		if len(devices) == 0 {
+			return fmt.Errorf("must supply at least one device to watch (--devices, --device or --device-list)")
+		}
+		if interval < time.Minute {
+			return fmt.Errorf("--interval must be at least 1m (be nice to Apple's servers)")
+		}
+
+		if len(stateFile) == 0 {
+			configDir, err := appledbConfigDir()
+			if err != nil {
+				return err
+			}
+			stateFile = filepath.Join(configDir, "watch.json")
+		}
+
+		w, err := download.NewWatcher(download.WatchConfig{
+			Devices:   devices,
+			Beta:      viper.GetBool("download.watch.beta"),
+			IPSWs:     viper.GetBool("download.watch.ipsw"),
+			StateFile: stateFile,
+			Proxy:     proxy,
+			Insecure:  insecure,
+		})
+		if err != nil {
+			return err
+		}
+
+		// handle runs the hook and downloads a new build (it is checked again if either fails)
+		handle := func(b download.WatchBuild) error {
+			if !devList.Allow(b.Device, b.Version) {
+				log.WithFields(log.Fields{
+					"device":  b.Device,
+					"version": b.Version,
+					"build":   b.Build,
+				}).Debug("New build is not in the device list (skipping)")
+				return nil
+			}
+			log.WithFields(log.Fields{
+				"device":  b.Device,
+				"version": b.Version,
+				"build":   b.Build,
+				"source":  b.Source,
+			}).Info("New Build")
+			if len(hook) > 0 {
+				if err := b.RunHook(hook); err != nil {
+					return fmt.Errorf("hook failed: %v", err)
+				}
+			}
+			if len(webhook) > 0 {
+				if err := b.Notify(webhook, proxy, insecure); err != nil {
+					utils.Indent(log.WithError(err).Error, 2)("Webhook failed")
+				}
+			}
+			if doDownload {
+				folder := filepath.Join(output, fmt.Sprintf("%s_%s", b.Version, b.Build))
+				if err := os.MkdirAll(folder, 0o750); err != nil {
+					return fmt.Errorf("failed to create download folder: %v", err)
+				}
+				destName := filepath.Join(folder, getDestName(b.URL, removeCommas))
+				if b.Source == "ota" {
+					destName = filepath.Join(folder, fmt.Sprintf("%s_%s", b.Device, getDestName(b.URL, removeCommas)))
+				}
+				if _, err := os.Stat(destName); err == nil {
+					utils.Indent(log.Warn, 2)(fmt.Sprintf("Already exists: %s", destName))
+					return nil
+				}
+				downloader := download.NewDownload(proxy, insecure, skipAll, resumeAll, restartAll, false, viper.GetBool("verbose"))
+				downloader.URL = b.URL
+				downloader.DestName = destName
+				downloader.Segments = viper.GetInt("download.segments")
+				downloader.Sha1 = b.Sha1
+				if err := downloader.Do(); err != nil {
+					return fmt.Errorf("failed to download: %v", err)
+				}
+				utils.Indent(log.Info, 2)("Created " + destName)
+			}
+			return nil
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+		for {
+			log.WithField("devices", strings.Join(devices, ", ")).Info("Checking for new builds...")
+			builds, err := w.Check()
+			if err != nil {
+				if viper.GetBool("download.watch.once") {
+					return err
+				}
+				log.WithError(err).Error("Check failed")
+			} else {
+				if len(builds) == 0 {
+					utils.Indent(log.Info, 2)("No new builds")
+				}
+				for _, b := range builds {
+					if err := handle(b); err != nil {
+						utils.Indent(log.WithError(err).Error, 2)("Failed to handle new build (will retry)")
+						continue
+					}
+					if err := w.Seen(b); err != nil {
+						log.WithError(err).Error("Failed to record build")
+					}
+				}
+			}
+
+			if viper.GetBool("download.watch.once") {
+				return nil
+			}
+
+			select {
+			case <-sigs:
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	},
+}
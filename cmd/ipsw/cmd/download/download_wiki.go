@@ -319,6 +319,16 @@ var wikiCmd = &cobra.Command{
 								downloader.URL = ipsw.URL
 								downloader.Sha1 = ipsw.Sha1Hash
 								downloader.DestName = destName
+								downloader.Segments = viper.GetInt("download.segments")
+								if err := downloader.Do(); err != nil {
+									return fmt.Errorf("failed to download file: %v", err)
+								}
+
+								if len(ipsw.Devices) > 0 {
+									if err := checkSigned(destName, ipsw.Devices[0], ipsw.Version, ipsw.Build); err != nil {
+										log.WithError(err).Warn("Failed to check signing status")
+									}
+								}
 
 								// append sha1 and filename to checksums file
 								f, err := os.OpenFile("checksums.txt.sha1", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
@@ -563,6 +573,7 @@ var wikiCmd = &cobra.Command{
 								// download file
 								downloader.URL = url
 								downloader.DestName = destName
+								downloader.Segments = viper.GetInt("download.segments")
 								if err := downloader.Do(); err != nil {
 									return fmt.Errorf("failed to download file: %v", err)
 								}
@@ -25,23 +25,43 @@ package download
 
 import (
 	"fmt"
+	"os"
 	"path"
-	"strings"
+	"path/filepath"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 func init() {
 	DownloadCmd.AddCommand(xcodeCmd)
-	xcodeCmd.Flags().BoolP("latest", "l", false, "Download newest XCode")
-	xcodeCmd.Flags().BoolP("sim", "s", false, "Download Simulator Runtimes")
+	xcodeCmd.Flags().BoolP("latest", "l", false, "Download newest XCode (or Simulator Runtime with --sim)")
+	xcodeCmd.Flags().StringP("sim", "s", "", "Download Simulator Runtimes for platform (ios, tvos, watchos, visionos or all)")
+	xcodeCmd.Flags().String("sim-version", "", "Simulator Runtime version (or version prefix) to download (i.e. 17 or 17.2)")
+	xcodeCmd.Flags().StringP("component", "c", "", "Download Xcode downloadable components by name (i.e. 'Metal Toolchain')")
+	xcodeCmd.Flags().Bool("list", false, "List the Simulator Runtimes and Xcode components")
+	xcodeCmd.Flags().BoolP("install", "i", false, "Install the Simulator Runtime after download")
+	xcodeCmd.Flags().StringP("output", "o", "", "Folder to download files to")
+	xcodeCmd.MarkFlagDirname("output")
+	xcodeCmd.RegisterFlagCompletionFunc("sim", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"ios", "tvos", "watchos", "visionos", "all"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	viper.BindPFlag("download.xcode.latest", xcodeCmd.Flags().Lookup("latest"))
+	viper.BindPFlag("download.xcode.sim", xcodeCmd.Flags().Lookup("sim"))
+	viper.BindPFlag("download.xcode.sim-version", xcodeCmd.Flags().Lookup("sim-version"))
+	viper.BindPFlag("download.xcode.component", xcodeCmd.Flags().Lookup("component"))
+	viper.BindPFlag("download.xcode.list", xcodeCmd.Flags().Lookup("list"))
+	viper.BindPFlag("download.xcode.install", xcodeCmd.Flags().Lookup("install"))
+	viper.BindPFlag("download.xcode.output", xcodeCmd.Flags().Lookup("output"))
 
 	xcodeCmd.SetHelpFunc(func(c *cobra.Command, s []string) {
 		DownloadCmd.PersistentFlags().MarkHidden("white-list")
@@ -58,11 +78,17 @@ func init() {
 
 // xcodeCmd represents the xcode command
 var xcodeCmd = &cobra.Command{
-	Use:           "xcode",
-	Short:         "🚧 Download XCode 🚧",
+	Use:   "xcode",
+	Short: "Download XCode, Simulator Runtimes and Xcode components",
+	Example: heredoc.Doc(`
+		# List the Simulator Runtimes and Xcode components
+		❯ ipsw download xcode --list
+		# Download the latest iOS 17.x Simulator Runtime
+		❯ ipsw download xcode --sim ios --sim-version 17 --latest
+		# Choose a visionOS Simulator Runtime to download and install
+		❯ ipsw download xcode --sim visionos --install`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	Hidden:        true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
@@ -83,48 +109,90 @@ var xcodeCmd = &cobra.Command{
 		resumeAll := viper.GetBool("download.resume-all")
 		restartAll := viper.GetBool("download.restart-all")
 		// flags
-		latest, _ := cmd.Flags().GetBool("latest")
-		dlSim, _ := cmd.Flags().GetBool("sim")
+		latest := viper.GetBool("download.xcode.latest")
+		sim := viper.GetString("download.xcode.sim")
+		simVersion := viper.GetString("download.xcode.sim-version")
+		component := viper.GetString("download.xcode.component")
+		install := viper.GetBool("download.xcode.install")
+		output := viper.GetString("download.xcode.output")
+
+		if len(simVersion) > 0 && len(sim) == 0 {
+			return fmt.Errorf("--sim-version requires --sim")
+		}
 
-		if dlSim {
+		if viper.GetBool("download.xcode.list") || len(sim) > 0 || len(component) > 0 {
 			dvt, err := download.GetDVTDownloadableIndex()
 			if err != nil {
 				return err
 			}
 
-			var choices []string
-			for _, dl := range dvt.Downloadables {
-				choices = append(choices, dl.Name)
+			dls, err := dvt.Filter(sim, simVersion, component)
+			if err != nil {
+				return err
 			}
 
-			var choice string
-			prompt := &survey.Select{
-				Message:  "Select what to download:",
-				Options:  choices,
-				PageSize: 10,
-			}
-			if err := survey.AskOne(prompt, &choice); err == terminal.InterruptErr {
-				log.Warn("Exiting...")
+			if viper.GetBool("download.xcode.list") {
+				var data [][]string
+				for _, dl := range dls {
+					auth := ""
+					if dl.NeedsAuth() {
+						auth = "✔︎"
+					}
+					data = append(data, []string{dl.Name, dl.Category, dl.SimulatorVersion.Version, dl.SimulatorVersion.BuildUpdate, humanize.Bytes(uint64(dl.FileSize)), auth})
+				}
+				table := tablewriter.NewWriter(os.Stdout)
+				table.SetHeader([]string{"Name", "Category", "Version", "Build", "Size", "Dev Portal"})
+				table.SetAutoWrapText(false)
+				table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+				table.SetCenterSeparator("|")
+				table.AppendBulk(data)
+				table.SetAlignment(tablewriter.ALIGN_LEFT)
+				table.Render()
 				return nil
 			}
 
 			var dl download.Downloadable
-			for _, d := range dvt.Downloadables {
-				if d.Name == choice {
-					dl = d
+			interactive := !latest && len(dls) > 1
+			if !interactive {
+				dl = dls[0]
+			} else {
+				var choices []string
+				for _, d := range dls {
+					choices = append(choices, d.Name)
+				}
+
+				var choice string
+				prompt := &survey.Select{
+					Message:  "Select what to download:",
+					Options:  choices,
+					PageSize: 10,
+				}
+				if err := survey.AskOne(prompt, &choice); err == terminal.InterruptErr {
+					log.Warn("Exiting...")
+					return nil
+				}
+
+				for _, d := range dls {
+					if d.Name == choice {
+						dl = d
+					}
 				}
 			}
 
-			if dl.Source == "" {
-				dl.Source = fmt.Sprintf("https://download.developer.apple.com/Developer_Tools/%s/%s.dmg", strings.ReplaceAll(dl.Name, " ", "_"), strings.ReplaceAll(dl.Name, " ", "_"))
-				dl.Authentication = "virtual"
+			destName := path.Base(dl.URL())
+			if len(output) > 0 {
+				if err := os.MkdirAll(output, 0o750); err != nil {
+					return fmt.Errorf("failed to create output folder: %v", err)
+				}
+				destName = filepath.Join(output, destName)
 			}
 
-			if dl.Authentication == "" {
-				log.Infof("Downloading %s...", dl.Name)
+			if !dl.NeedsAuth() {
+				log.WithField("size", humanize.Bytes(uint64(dl.FileSize))).Infof("Downloading %s...", dl.Name)
 				downloader := download.NewDownload(proxy, insecure, skipAll, resumeAll, restartAll, false, viper.GetBool("verbose"))
-				downloader.URL = dl.Source
-				downloader.DestName = path.Base(dl.Source)
+				downloader.URL = dl.URL()
+				downloader.DestName = destName
+				downloader.Segments = viper.GetInt("download.segments")
 				if err := downloader.Do(); err != nil {
 					return err
 				}
@@ -136,23 +204,27 @@ var xcodeCmd = &cobra.Command{
 					ResumeAll:  resumeAll,
 					RestartAll: restartAll,
 					Verbose:    viper.GetBool("verbose"),
+					Output:     output,
 				})
-				if err := app.DownloadADC(dl.Source); err != nil {
+				if err := app.DownloadADC(dl.URL()); err != nil {
 					return err
 				}
 			}
 
-			install := false
-			iprompt := &survey.Confirm{
-				Message: "Install Simulator Runtime?",
-			}
-			if err := survey.AskOne(iprompt, &install); err == terminal.InterruptErr {
-				log.Warn("Exiting...")
+			if dl.Category != "simulator" {
 				return nil
 			}
-
+			if !install && interactive {
+				iprompt := &survey.Confirm{
+					Message: "Install Simulator Runtime?",
+				}
+				if err := survey.AskOne(iprompt, &install); err == terminal.InterruptErr {
+					log.Warn("Exiting...")
+					return nil
+				}
+			}
 			if install {
-				return utils.InstallXCodeSimRuntime(path.Base(dl.Source))
+				return utils.InstallXCodeSimRuntime(destName)
 			}
 
 			return nil
@@ -192,6 +264,7 @@ var xcodeCmd = &cobra.Command{
 		downloader.URL = download.XcodeDlURL + "/" + choice
 		downloader.Sha1 = sha1
 		downloader.DestName = choice
+		downloader.Segments = viper.GetInt("download.segments")
 		return downloader.Do()
 	},
 }
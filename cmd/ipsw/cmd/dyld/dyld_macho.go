@@ -24,6 +24,7 @@ package dyld
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -31,8 +32,13 @@ import (
 	"strings"
 
 	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
 	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/ipsw/internal/commands/binja"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/commands/ida"
 	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/internal/commands/r2"
 	"github.com/blacktop/ipsw/internal/demangle"
 	swift "github.com/blacktop/ipsw/internal/swift"
 	"github.com/blacktop/ipsw/pkg/dyld"
@@ -69,6 +75,10 @@ func init() {
 	MachoCmd.Flags().BoolP("stubs", "b", false, "Print stubs")
 	MachoCmd.Flags().String("search", "", "Search for byte pattern")
 
+	MachoCmd.Flags().Bool("ida", false, "Write an IDAPython script applying the recovered names, functions, types and comments")
+	MachoCmd.Flags().Bool("idc", false, "Write the --ida script as IDC")
+	MachoCmd.Flags().Bool("r2", false, "Write a radare2 script applying the recovered names, functions, types and comments")
+	MachoCmd.Flags().Bool("binja", false, "Write a Binary Ninja JSON export of the symbols, function starts and ObjC metadata")
 	MachoCmd.Flags().BoolP("extract", "x", false, "🚧 Extract the dylib")
 	MachoCmd.Flags().String("output", "", "Directory to extract the dylib(s)")
 	MachoCmd.Flags().Bool("force", false, "Overwrite existing extracted dylib(s)")
@@ -112,6 +122,10 @@ var MachoCmd = &cobra.Command{
 		extractDylib, _ := cmd.Flags().GetBool("extract")
 		extractPath, _ := cmd.Flags().GetString("output")
 		forceExtract, _ := cmd.Flags().GetBool("force")
+		idaScript, _ := cmd.Flags().GetBool("ida")
+		idcScript, _ := cmd.Flags().GetBool("idc")
+		binjaExport, _ := cmd.Flags().GetBool("binja")
+		r2Script, _ := cmd.Flags().GetBool("r2")
 		// validate flags
 		if doDemangle && (!showSymbols && !showSwift) {
 			return fmt.Errorf("you must also supply --symbols OR --swift flag to demangle")
@@ -119,6 +133,8 @@ var MachoCmd = &cobra.Command{
 			return fmt.Errorf("you must use --swift flag to use --swift-all")
 		} else if showObjcRefs && !showObjC {
 			return fmt.Errorf("you must use --objc flag to use --objc-refs")
+		} else if idcScript && !idaScript {
+			return fmt.Errorf("you must use --ida flag to use --idc")
 		}
 
 		var options uint32
@@ -225,7 +241,7 @@ var MachoCmd = &cobra.Command{
 							return fmt.Errorf("failed to export entry MachO %s; %v", image.Name, err)
 						}
 
-						if err := rebaseMachO(f, fname); err != nil {
+						if err := dsc.RebaseMachO(f, fname); err != nil {
 							return fmt.Errorf("failed to rebase macho via cache slide info: %v", err)
 						}
 						if !dumpALL {
@@ -239,6 +255,49 @@ var MachoCmd = &cobra.Command{
 					continue
 				}
 
+				if idaScript || r2Script {
+					folder := filepath.Dir(dscPath) // default to folder of shared cache
+					if len(extractPath) > 0 {
+						folder = extractPath
+					}
+					ext := ".r2"
+					if idaScript {
+						ext = ".ida.py"
+						if idcScript {
+							ext = ".ida.idc"
+						}
+					}
+					fname := filepath.Join(folder, filepath.Base(image.Name)+ext)
+					if dumpALL {
+						fname = filepath.Join(folder, image.Name+ext)
+					}
+					if err := writeScript(image, m, fname, idaScript, idcScript); err != nil {
+						return err
+					}
+					if !dumpALL {
+						log.Infof("Created %s", fname)
+					}
+					continue
+				}
+
+				if binjaExport {
+					folder := filepath.Dir(dscPath) // default to folder of shared cache
+					if len(extractPath) > 0 {
+						folder = extractPath
+					}
+					fname := filepath.Join(folder, filepath.Base(image.Name)+".binja.json")
+					if dumpALL {
+						fname = filepath.Join(folder, image.Name+".binja.json")
+					}
+					if err := writeBinjaExport(image, m, fname); err != nil {
+						return err
+					}
+					if !dumpALL {
+						log.Infof("Created %s", fname)
+					}
+					continue
+				}
+
 				if showLoadCommands || options == 0 {
 					if showLoadCommandsAsJSON {
 						dat, err := m.FileTOC.MarshalJSON()
@@ -597,3 +656,58 @@ var MachoCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// writeScript writes an IDA (or r2) script applying the image's names, functions, types and comments
+func writeScript(image *dyld.CacheImage, m *macho.File, fname string, idaScript, idc bool) error {
+	a, err := mcmd.Annotate(image.Name, m, &mcmd.AnnotateConfig{Symbols: true, Starts: true, ObjC: true})
+	if err != nil {
+		return fmt.Errorf("failed to annotate %s: %v", image.Name, err)
+	}
+	image.ParseLocalSymbols(false)
+	locals := make(map[uint64]string)
+	for _, sym := range image.GetLocalSymbolsAsMachoSymbols() {
+		locals[sym.Value] = sym.Name
+	}
+	a.AddNames(locals)
+	a.Sort()
+
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return fmt.Errorf("failed to create folder %s: %v", filepath.Dir(fname), err)
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		return fmt.Errorf("failed to create script %s: %v", fname, err)
+	}
+	defer f.Close()
+
+	if idaScript {
+		return ida.WriteScript(f, a, idc)
+	}
+	return r2.WriteScript(f, a)
+}
+
+func writeBinjaExport(image *dyld.CacheImage, m *macho.File, fname string) error {
+	exp, err := binja.NewExport(image.Name, m)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %v", image.Name, err)
+	}
+	image.ParseLocalSymbols(false)
+	locals := make(map[uint64]string)
+	for _, sym := range image.GetLocalSymbolsAsMachoSymbols() {
+		locals[sym.Value] = sym.Name
+	}
+	exp.AddSymbols(m, locals)
+	exp.Sort()
+
+	dat, err := json.Marshal(exp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Binary Ninja export: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return fmt.Errorf("failed to create folder %s: %v", filepath.Dir(fname), err)
+	}
+	if err := os.WriteFile(fname, dat, 0o644); err != nil {
+		return fmt.Errorf("failed to write Binary Ninja export %s: %v", fname, err)
+	}
+	return nil
+}
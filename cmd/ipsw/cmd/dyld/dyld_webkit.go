@@ -45,7 +45,7 @@ func init() {
 	WebkitCmd.Flags().BoolP("rev", "r", false, "Lookup svn rev on trac.webkit.org")
 	WebkitCmd.Flags().BoolP("git", "g", false, "Lookup git tag on github.com")
 	WebkitCmd.Flags().StringP("api", "a", "", "Github API Token")
-	WebkitCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	WebkitCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	WebkitCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	WebkitCmd.Flags().BoolP("diff", "d", false, "Diff two dyld_shared_cache files")
 	WebkitCmd.Flags().BoolP("json", "j", false, "Output as JSON")
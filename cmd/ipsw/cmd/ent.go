@@ -65,6 +65,9 @@ func init() {
 	entCmd.Flags().Bool("file-only", false, "Only output the file path of matches")
 	entCmd.Flags().BoolP("diff", "d", false, "Diff entitlements")
 	entCmd.Flags().BoolP("md", "m", false, "Markdown style output")
+	entCmd.Flags().StringSlice("include", []string{}, "Only diff the binaries whose path matches these regexes")
+	entCmd.Flags().StringSlice("exclude", []string{}, "Don't diff the binaries whose path matches these regexes")
+	entCmd.Flags().StringSlice("interesting", []string{}, "Entitlement globs to highlight in the diff (defaults to the security-relevant ones)")
 	entCmd.Flags().Bool("ui", false, "Show entitlements Web UI")
 	entCmd.Flags().String("ui-host", "localhost", "UI host to server on")
 	entCmd.Flags().Int("ui-port", 3993, "UI port to server on")
@@ -77,6 +80,9 @@ func init() {
 	viper.BindPFlag("ent.file-only", entCmd.Flags().Lookup("file-only"))
 	viper.BindPFlag("ent.diff", entCmd.Flags().Lookup("diff"))
 	viper.BindPFlag("ent.md", entCmd.Flags().Lookup("md"))
+	viper.BindPFlag("ent.include", entCmd.Flags().Lookup("include"))
+	viper.BindPFlag("ent.exclude", entCmd.Flags().Lookup("exclude"))
+	viper.BindPFlag("ent.interesting", entCmd.Flags().Lookup("interesting"))
 	viper.BindPFlag("ent.ui", entCmd.Flags().Lookup("ui"))
 	viper.BindPFlag("ent.ui-host", entCmd.Flags().Lookup("ui-host"))
 	viper.BindPFlag("ent.ui-port", entCmd.Flags().Lookup("ui-port"))
@@ -250,7 +256,13 @@ var entCmd = &cobra.Command{
 				}
 			} else { // DIFF ENTITLEMENT DATABASES
 				log.Info("Diffing entitlement databases...")
-				out, err := ent.DiffDatabases(entDBs[0], entDBs[1], &ent.Config{Markdown: markdown, Color: viper.GetBool("color") && !viper.GetBool("no-color")})
+				out, err := ent.DiffDatabases(entDBs[0], entDBs[1], &ent.Config{
+					Markdown:    markdown,
+					Color:       viper.GetBool("color") && !viper.GetBool("no-color"),
+					Include:     viper.GetStringSlice("ent.include"),
+					Exclude:     viper.GetStringSlice("ent.exclude"),
+					Interesting: viper.GetStringSlice("ent.interesting"),
+				})
 				if err != nil {
 					return fmt.Errorf("failed to diff entitlement databases: %v", err)
 				}
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/commands/extract"
 	"github.com/blacktop/ipsw/internal/commands/mount"
@@ -39,7 +40,7 @@ func init() {
 	rootCmd.AddCommand(extractCmd)
 
 	extractCmd.Flags().BoolP("remote", "r", false, "Extract from URL")
-	extractCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	extractCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	extractCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	extractCmd.Flags().BoolP("kernel", "k", false, "Extract kernelcache")
 	extractCmd.Flags().BoolP("dyld", "d", false, "Extract dyld_shared_cache")
@@ -50,11 +51,13 @@ func init() {
 	extractCmd.Flags().Bool("sptm", false, "Extract SPTM and TXM Firmwares")
 	extractCmd.Flags().BoolP("exclave", "x", false, "Extract Exclave Bundle")
 	extractCmd.Flags().Bool("kbag", false, "Extract Im4p Keybags")
+	extractCmd.Flags().Bool("kbags", false, "Extract every Im4p's production/development Keybags as a JSON map of path to KBAGs (key database format)")
 	extractCmd.Flags().Bool("fcs-key", false, "Extract AEA1 DMG fcs-key pem files")
 	extractCmd.Flags().Bool("sys-ver", false, "Extract SystemVersion")
-	extractCmd.Flags().BoolP("files", "f", false, "Extract File System files")
+	extractCmd.Flags().BoolP("files", "f", false, "Extract files from every DMG (filesystem, cryptexes, etc) into a mirror of the device's directory tree")
 	extractCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
 	extractCmd.Flags().StringP("pattern", "p", "", "Extract files that match regex")
+	extractCmd.Flags().StringArray("glob", []string{}, "Extract files that match glob (i.e. 'Firmware/**/*.im4p', can be repeated)")
 	extractCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
 	extractCmd.MarkFlagDirname("output")
 	extractCmd.Flags().Bool("flat", false, "Do NOT perserve directory structure when extracting")
@@ -86,11 +89,13 @@ func init() {
 	viper.BindPFlag("extract.sptm", extractCmd.Flags().Lookup("sptm"))
 	viper.BindPFlag("extract.exclave", extractCmd.Flags().Lookup("exclave"))
 	viper.BindPFlag("extract.kbag", extractCmd.Flags().Lookup("kbag"))
+	viper.BindPFlag("extract.kbags", extractCmd.Flags().Lookup("kbags"))
 	viper.BindPFlag("extract.fcs-key", extractCmd.Flags().Lookup("fcs-key"))
 	viper.BindPFlag("extract.sys-ver", extractCmd.Flags().Lookup("sys-ver"))
 	viper.BindPFlag("extract.files", extractCmd.Flags().Lookup("files"))
 	viper.BindPFlag("extract.pem-db", extractCmd.Flags().Lookup("pem-db"))
 	viper.BindPFlag("extract.pattern", extractCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("extract.glob", extractCmd.Flags().Lookup("glob"))
 	viper.BindPFlag("extract.output", extractCmd.Flags().Lookup("output"))
 	viper.BindPFlag("extract.flat", extractCmd.Flags().Lookup("flat"))
 	viper.BindPFlag("extract.json", extractCmd.Flags().Lookup("json"))
@@ -101,9 +106,19 @@ func init() {
 
 // extractCmd represents the extract command
 var extractCmd = &cobra.Command{
-	Use:           "extract <IPSW/OTA | URL>",
-	Aliases:       []string{"e", "ex"},
-	Short:         "Extract kernelcache, dyld_shared_cache or DeviceTree from IPSW/OTA",
+	Use:     "extract <IPSW/OTA | URL>",
+	Aliases: []string{"e", "ex"},
+	Short:   "Extract kernelcache, dyld_shared_cache or DeviceTree from IPSW/OTA",
+	Example: heredoc.Doc(`
+		# Extract the kernelcache and dyld_shared_cache
+		❯ ipsw extract --kernel --dyld iPhone15,2_16.5_20F66_Restore.ipsw
+		# Extract the files matching a pattern from every DMG (filesystem, SystemOS/AppOS cryptexes, etc)
+		# NOTE: cryptex files are extracted to System/Cryptexes/OS and System/Cryptexes/App like on device
+		❯ ipsw extract --files --pattern '.*/usr/libexec/.*' iPhone15,2_16.5_20F66_Restore.ipsw
+		# Same as above from a remote IPSW (the DMGs are downloaded)
+		❯ ipsw extract --remote --files --glob '**/*.plist' https://updates.cdn-apple.com/.../iPhone15,2_16.5_20F66_Restore.ipsw
+		# Dump the KBAGs of every im4p in an IPSW/OTA as JSON (for decryption key databases)
+		❯ ipsw extract --kbags --json iPhone15,2_16.5_20F66_Restore.ipsw`),
 	Args:          cobra.MinimumNArgs(1),
 	SilenceErrors: true,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -115,10 +130,14 @@ var extractCmd = &cobra.Command{
 			log.SetLevel(log.DebugLevel)
 		}
 
+		if globs := viper.GetStringSlice("extract.glob"); len(globs) > 0 {
+			viper.Set("extract.pattern", utils.MergePatterns(viper.GetString("extract.pattern"), globs))
+		}
+
 		// validate args
 		if !viper.GetBool("extract.kernel") && !viper.GetBool("extract.dyld") && !viper.IsSet("extract.dmg") &&
 			!viper.GetBool("extract.dtree") && !viper.GetBool("extract.iboot") && !viper.GetBool("extract.sep") &&
-			!viper.GetBool("extract.sptm") && !viper.GetBool("extract.kbag") && !viper.GetBool("extract.sys-ver") &&
+			!viper.GetBool("extract.sptm") && !viper.GetBool("extract.kbag") && !viper.GetBool("extract.kbags") && !viper.GetBool("extract.sys-ver") &&
 			!viper.GetBool("extract.exclave") && len(viper.GetString("extract.pattern")) == 0 && !viper.GetBool("extract.fcs-key") {
 			return fmt.Errorf("must specify at least one flag to specify what to extract")
 		} else if len(viper.GetStringSlice("extract.dyld-arch")) > 0 && !viper.GetBool("extract.dyld") {
@@ -352,6 +371,19 @@ var extractCmd = &cobra.Command{
 			}
 		}
 
+		if viper.GetBool("extract.kbags") {
+			log.Info("Extracting im4p key bags map")
+			out, err := extract.KeybagMap(config)
+			if err != nil {
+				return err
+			}
+			if viper.GetBool("extract.json") {
+				fmt.Println(out)
+			} else {
+				utils.Indent(log.Info, 2)("Created " + out)
+			}
+		}
+
 		if viper.GetBool("extract.fcs-key") {
 			log.Info("Extracting AEA1 DMG fcs-keys")
 			out, err := extract.FcsKeys(config)
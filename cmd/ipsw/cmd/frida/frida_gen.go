@@ -0,0 +1,192 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package frida
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	fcmd "github.com/blacktop/ipsw/internal/commands/frida"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	FridaCmd.AddCommand(fridaGenCmd)
+
+	fridaGenCmd.Flags().StringP("class", "c", "", "Regex of the ObjC classes to hook")
+	fridaGenCmd.Flags().StringP("sel", "s", "", "Regex of the ObjC selectors to hook")
+	fridaGenCmd.Flags().StringArray("sym", []string{}, "Regex of the symbols to hook (can be used multiple times)")
+	fridaGenCmd.Flags().BoolP("backtrace", "b", false, "Log a backtrace on each hook")
+	fridaGenCmd.Flags().StringP("arch", "a", "", "Which architecture to use for fat/universal MachO")
+	fridaGenCmd.Flags().StringP("output", "o", "", "Output script (default: stdout)")
+	fridaGenCmd.MarkFlagFilename("output", "js")
+	viper.BindPFlag("frida.gen.class", fridaGenCmd.Flags().Lookup("class"))
+	viper.BindPFlag("frida.gen.sel", fridaGenCmd.Flags().Lookup("sel"))
+	viper.BindPFlag("frida.gen.sym", fridaGenCmd.Flags().Lookup("sym"))
+	viper.BindPFlag("frida.gen.backtrace", fridaGenCmd.Flags().Lookup("backtrace"))
+	viper.BindPFlag("frida.gen.arch", fridaGenCmd.Flags().Lookup("arch"))
+	viper.BindPFlag("frida.gen.output", fridaGenCmd.Flags().Lookup("output"))
+}
+
+// fridaGenCmd represents the frida gen command
+var fridaGenCmd = &cobra.Command{
+	Use:     "gen <DSC|MACHO> [DYLIB]",
+	Aliases: []string{"g"},
+	Short:   "Generate a Frida script hooking ObjC methods/symbols",
+	Example: heredoc.Doc(`
+		# Hook all the NSXPCConnection methods of Foundation
+		❯ ipsw frida gen dyld_shared_cache_arm64e Foundation --class '^NSXPCConnection$' -o xpc.js
+		❯ frida -U -n Maps -l xpc.js
+		# Hook the selectors containing 'URL' of a MachO's classes with backtraces
+		❯ ipsw frida gen MACHO --sel URL --backtrace
+		# Hook symbols (the DSC's local symbols included)
+		❯ ipsw frida gen dyld_shared_cache_arm64e libxpc.dylib --sym '^_xpc_connection_send'`),
+	Args:          cobra.RangeArgs(1, 2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		conf := &fcmd.GenConfig{
+			Class:     viper.GetString("frida.gen.class"),
+			Selector:  viper.GetString("frida.gen.sel"),
+			Symbols:   viper.GetStringSlice("frida.gen.sym"),
+			Backtrace: viper.GetBool("frida.gen.backtrace"),
+		}
+
+		var module string
+		var m *macho.File
+		symbols := make(map[uint64]string)
+
+		if ok, _ := magic.IsMachO(args[0]); ok { /* MachO binary */
+			machoPath := filepath.Clean(args[0])
+			fat, err := macho.OpenFat(machoPath)
+			if err != nil && err != macho.ErrNotFat {
+				return err
+			}
+			if err == macho.ErrNotFat {
+				m, err = macho.Open(machoPath)
+				if err != nil {
+					return err
+				}
+				defer m.Close()
+			} else {
+				defer fat.Close()
+				var options []string
+				var shortOptions []string
+				for _, arch := range fat.Arches {
+					options = append(options, fmt.Sprintf("%s, %s", arch.CPU, arch.SubCPU.String(arch.CPU)))
+					shortOptions = append(shortOptions, strings.ToLower(arch.SubCPU.String(arch.CPU)))
+				}
+				if len(viper.GetString("frida.gen.arch")) > 0 {
+					found := false
+					for i, opt := range shortOptions {
+						if strings.Contains(strings.ToLower(opt), strings.ToLower(viper.GetString("frida.gen.arch"))) {
+							m = fat.Arches[i].File
+							found = true
+							break
+						}
+					}
+					if !found {
+						return fmt.Errorf("--arch '%s' not found in: %s", viper.GetString("frida.gen.arch"), strings.Join(shortOptions, ", "))
+					}
+				} else {
+					choice := 0
+					prompt := &survey.Select{
+						Message: "Detected a universal MachO file, please select an architecture to analyze:",
+						Options: options,
+					}
+					survey.AskOne(prompt, &choice)
+					m = fat.Arches[choice].File
+				}
+			}
+			module = filepath.Base(machoPath)
+		} else { /* DSC file */
+			if len(args) < 2 {
+				return fmt.Errorf("must provide an in-cache DYLIB to hook")
+			}
+			f, err := dyld.Open(filepath.Clean(args[0]))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			image, err := f.Image(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to find dylib '%s' in DSC: %v", args[1], err)
+			}
+			m, err = image.GetMacho()
+			if err != nil {
+				return fmt.Errorf("failed to parse MachO from dylib '%s': %v", filepath.Base(image.Name), err)
+			}
+			defer m.Close()
+
+			if len(conf.Symbols) > 0 {
+				if err := image.ParseLocalSymbols(false); err != nil {
+					log.WithError(err).Warn("failed to parse local symbols")
+				}
+				for _, sym := range image.GetLocalSymbolsAsMachoSymbols() {
+					symbols[sym.Value] = sym.Name
+				}
+			}
+			module = filepath.Base(image.Name)
+		}
+
+		script, err := fcmd.Generate(module, m, symbols, conf)
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"methods":   len(script.Methods),
+			"functions": len(script.Functions),
+		}).Info("Generating Frida script")
+
+		if output := viper.GetString("frida.gen.output"); len(output) > 0 {
+			f, err := os.Create(filepath.Clean(output))
+			if err != nil {
+				return fmt.Errorf("failed to create script %s: %v", output, err)
+			}
+			defer f.Close()
+			if err := script.Write(f); err != nil {
+				return err
+			}
+			log.Infof("Created %s", output)
+			return nil
+		}
+
+		return script.Write(os.Stdout)
+	},
+}
@@ -0,0 +1,316 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/mount"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/apfs"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	colorFsMode = color.New(color.FgHiBlue).SprintFunc()
+	colorFsTime = color.New(color.Faint).SprintFunc()
+	colorFsSize = color.New(color.FgHiCyan).SprintFunc()
+	colorFsLink = color.New(color.FgHiMagenta).SprintFunc()
+)
+
+func init() {
+	rootCmd.AddCommand(fsCmd)
+	fsCmd.AddCommand(fsLsCmd)
+	fsCmd.AddCommand(fsExtractCmd)
+	fsCmd.AddCommand(fsSealCmd)
+
+	fsCmd.PersistentFlags().StringP("type", "t", "sys", "IPSW DMG to read (fs, sys, app or exc)")
+	fsCmd.PersistentFlags().String("pem-db", "", "AEA pem DB JSON file")
+	fsCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mount.DmgTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+	viper.BindPFlag("fs.type", fsCmd.PersistentFlags().Lookup("type"))
+	viper.BindPFlag("fs.pem-db", fsCmd.PersistentFlags().Lookup("pem-db"))
+
+	fsLsCmd.Flags().BoolP("recursive", "R", false, "List subdirectories recursively")
+	fsLsCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	viper.BindPFlag("fs.ls.recursive", fsLsCmd.Flags().Lookup("recursive"))
+	viper.BindPFlag("fs.ls.json", fsLsCmd.Flags().Lookup("json"))
+
+	fsExtractCmd.Flags().StringP("pattern", "p", "", "Extract files whose path matches regex")
+	fsExtractCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	fsExtractCmd.MarkFlagRequired("pattern")
+	fsExtractCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fs.extract.pattern", fsExtractCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("fs.extract.output", fsExtractCmd.Flags().Lookup("output"))
+
+	fsSealCmd.Flags().BoolP("verify", "c", false, "Verify the file-system tree against the seal")
+	fsSealCmd.Flags().BoolP("data", "d", false, "Also verify the hashes of the files' data (slow)")
+	fsSealCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	viper.BindPFlag("fs.seal.verify", fsSealCmd.Flags().Lookup("verify"))
+	viper.BindPFlag("fs.seal.data", fsSealCmd.Flags().Lookup("data"))
+	viper.BindPFlag("fs.seal.json", fsSealCmd.Flags().Lookup("json"))
+}
+
+// fsDMG returns the DMG to read (extracting and decrypting it if path is an IPSW) and a func that removes it
+func fsDMG(path string) (string, func(), error) {
+	dmgPath := filepath.Clean(path)
+	isZip, err := magic.IsZip(dmgPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if !isZip {
+		return dmgPath, func() {}, nil
+	}
+	if dmgPath, err = mount.ExtractDMG(dmgPath, viper.GetString("fs.type"), viper.GetString("fs.pem-db")); err != nil {
+		return "", nil, fmt.Errorf("failed to extract %s DMG: %v", viper.GetString("fs.type"), err)
+	}
+	return dmgPath, func() { os.Remove(dmgPath) }, nil
+}
+
+// fsCmd represents the fs command
+var fsCmd = &cobra.Command{
+	Use:   "fs",
+	Short: "List and extract files from IPSW filesystem DMGs (without mounting them)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// fsLsCmd represents the fs ls command
+var fsLsCmd = &cobra.Command{
+	Use:     "ls <IPSW|DMG> [PATH]",
+	Aliases: []string{"l"},
+	Short:   "List files in a filesystem DMG",
+	Example: heredoc.Doc(`
+		# List the SystemOS cryptex's /System/Library/Caches folder
+		❯ ipsw fs ls iPhone16,1_18.0_22A3354_Restore.ipsw /System/Library/Caches
+		# List every file in the root filesystem DMG as JSON
+		❯ ipsw fs ls --type fs -R --json iPhone16,1_18.0_22A3354_Restore.ipsw
+		# List a DMG
+		❯ ipsw fs ls 090-44250-044.dmg /usr/lib`),
+	Args:          cobra.RangeArgs(1, 2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		dmgPath, cleanup, err := fsDMG(args[0])
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		fsys, err := apfs.Open(dmgPath)
+		if err != nil {
+			return fmt.Errorf("failed to open filesystem DMG: %v", err)
+		}
+		defer fsys.Close()
+
+		root := "/"
+		if len(args) > 1 {
+			root = args[1]
+		}
+
+		var files []*apfs.File
+		if viper.GetBool("fs.ls.recursive") {
+			if err := fsys.Walk(root, func(f *apfs.File) error {
+				files = append(files, f)
+				return nil
+			}); err != nil {
+				return err
+			}
+		} else {
+			f, err := fsys.Stat(root)
+			if err != nil {
+				return err
+			}
+			if f.IsDir() {
+				if files, err = fsys.ReadDir(root); err != nil {
+					return err
+				}
+			} else {
+				files = append(files, f)
+			}
+		}
+
+		if viper.GetBool("fs.ls.json") {
+			dat, err := json.MarshalIndent(files, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal files: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.DiscardEmptyColumns)
+		for _, f := range files {
+			name := f.Path
+			switch {
+			case f.Firmlink != "":
+				name += colorFsLink(" -> " + filepath.ToSlash(filepath.Join(apfs.FirmlinkRoot, f.Firmlink)) + " (firmlink)")
+			case f.Link != "":
+				name += colorFsLink(" -> " + f.Link)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", colorFsMode(f.Mode), colorFsTime(f.ModTime.Format(time.RFC3339)), colorFsSize(humanize.Bytes(f.Size)), name)
+		}
+		return w.Flush()
+	},
+}
+
+// fsExtractCmd represents the fs extract command
+var fsExtractCmd = &cobra.Command{
+	Use:     "extract <IPSW|DMG>",
+	Aliases: []string{"e"},
+	Short:   "Extract files from a filesystem DMG",
+	Example: heredoc.Doc(`
+		# Extract the dyld_shared_caches from the SystemOS cryptex
+		❯ ipsw fs extract --pattern 'dyld_shared_cache_arm64e$' iPhone16,1_18.0_22A3354_Restore.ipsw
+		# Extract the launchd configs from the root filesystem DMG
+		❯ ipsw fs extract --type fs --pattern '^/System/Library/LaunchDaemons/.*\.plist$' -o /tmp/launchd iPhone16,1_18.0_22A3354_Restore.ipsw`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		re, err := regexp.Compile(viper.GetString("fs.extract.pattern"))
+		if err != nil {
+			return fmt.Errorf("failed to compile regex pattern: %v", err)
+		}
+
+		dmgPath, cleanup, err := fsDMG(args[0])
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		artifacts, err := utils.ExtractFromAPFS(dmgPath, viper.GetString("fs.extract.output"), re)
+		if err != nil {
+			return err
+		}
+		if len(artifacts) == 0 {
+			log.Warnf("no files matched '%s'", re)
+		}
+
+		return nil
+	},
+}
+
+// fsSealCmd represents the fs seal command
+var fsSealCmd = &cobra.Command{
+	Use:   "seal <IPSW|DMG>",
+	Short: "Show (and verify) the seal of a Signed System Volume DMG",
+	Example: heredoc.Doc(`
+		# Show the seal of a macOS IPSW's system volume
+		❯ ipsw fs seal --type fs UniversalMac_15.0_24A335_Restore.ipsw
+		# Verify the file-system tree and every file's data against the seal
+		❯ ipsw fs seal --verify --data 090-44250-044.dmg`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		dmgPath, cleanup, err := fsDMG(args[0])
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		fsys, err := apfs.Open(dmgPath)
+		if err != nil {
+			return fmt.Errorf("failed to open filesystem DMG: %v", err)
+		}
+		defer fsys.Close()
+
+		seal, err := fsys.Seal()
+		if err != nil {
+			return fmt.Errorf("failed to read %s volume seal: %w", fsys.Name, err)
+		}
+
+		var bad []string
+		if viper.GetBool("fs.seal.verify") || viper.GetBool("fs.seal.data") {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			log.WithField("volume", fsys.Name).Info("Verifying Seal")
+			if bad, err = fsys.VerifySeal(ctx, viper.GetBool("fs.seal.data")); err != nil {
+				return err
+			}
+		}
+
+		if viper.GetBool("fs.seal.json") {
+			dat, err := json.MarshalIndent(struct {
+				Volume   string     `json:"volume"`
+				Seal     *apfs.Seal `json:"seal"`
+				Verified bool       `json:"verified,omitempty"`
+				Modified []string   `json:"modified,omitempty"`
+			}{fsys.Name, seal, viper.GetBool("fs.seal.verify") || viper.GetBool("fs.seal.data"), bad}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal seal: %v", err)
+			}
+			fmt.Println(string(dat))
+		} else {
+			fmt.Printf("Volume:    %s\n", fsys.Name)
+			fmt.Printf("Hash Type: %s\n", seal.HashType)
+			fmt.Printf("Root Hash: %s\n", seal.RootHash)
+			if seal.Broken {
+				fmt.Printf("Broken:    %s (xid %#x)\n", color.New(color.FgRed).Sprint("true"), seal.BrokenXid)
+			}
+			for _, f := range bad {
+				log.Errorf("data of %s does not match the seal", f)
+			}
+		}
+		if len(bad) > 0 {
+			return fmt.Errorf("%d files do not match the seal", len(bad))
+		}
+		if viper.GetBool("fs.seal.verify") || viper.GetBool("fs.seal.data") {
+			log.Info("Seal verified")
+		}
+
+		return nil
+	},
+}
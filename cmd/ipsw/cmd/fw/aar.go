@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/ota"
+	"github.com/blacktop/ipsw/pkg/ota/yaa"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	FwCmd.AddCommand(aarCmd)
+
+	aarCmd.Flags().BoolP("extract", "x", false, "Extract the archive's files")
+	aarCmd.Flags().BoolP("create", "c", false, "Create an archive of a folder")
+	aarCmd.Flags().StringP("pattern", "p", "", "Regex to match the paths to list/extract/archive")
+	aarCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	aarCmd.Flags().StringP("output", "o", "", "Folder to extract files to (or the archive to create)")
+	aarCmd.MarkFlagsMutuallyExclusive("extract", "create")
+	viper.BindPFlag("fw.aar.extract", aarCmd.Flags().Lookup("extract"))
+	viper.BindPFlag("fw.aar.create", aarCmd.Flags().Lookup("create"))
+	viper.BindPFlag("fw.aar.pattern", aarCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("fw.aar.json", aarCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.aar.output", aarCmd.Flags().Lookup("output"))
+}
+
+// aarCmd represents the aar command
+var aarCmd = &cobra.Command{
+	Use:     "aar <AAR|YAA|DIR>",
+	Aliases: []string{"aa", "yaa"},
+	Short:   "List, extract or create Apple Archives (YAA/AAR)",
+	Example: heredoc.Doc(`
+		# List the files in an Apple Archive (i.e. a PCC asset or an OTA payload)
+		❯ ipsw fw aar PrivateCloudSupport.aar
+
+		# Extract the dylibs from an Apple Archive
+		❯ ipsw fw aar --extract --pattern '\.dylib$' --output /tmp/PCS PrivateCloudSupport.aar
+
+		# Create an Apple Archive of a folder
+		❯ ipsw fw aar --create --output /tmp/PCS.aar /tmp/PCS`),
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		output := viper.GetString("fw.aar.output")
+		asJSON := viper.GetBool("fw.aar.json")
+
+		var include *regexp.Regexp
+		if pattern := viper.GetString("fw.aar.pattern"); len(pattern) > 0 {
+			var err error
+			if include, err = regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("failed to compile regex pattern '%s': %v", pattern, err)
+			}
+		}
+
+		path := filepath.Clean(args[0])
+
+		if viper.GetBool("fw.aar.create") {
+			if fi, err := os.Stat(path); err != nil {
+				return err
+			} else if !fi.IsDir() {
+				return fmt.Errorf("%s is not a folder", path)
+			}
+			if len(output) == 0 {
+				output = filepath.Base(path) + ".aar"
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			log.Infof("Archiving %s", path)
+			files, err := yaa.Create(f, path, include)
+			if err != nil {
+				return err
+			}
+			utils.Indent(log.Info, 2)(fmt.Sprintf("Created %s (%d entries)", output, len(files)))
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %v", path, err)
+		}
+		defer f.Close()
+
+		if viper.GetBool("fw.aar.extract") {
+			if len(output) == 0 {
+				output = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+			log.Infof("Extracting %s", path)
+			files, err := ota.ExtractPayload(context.Background(), f, output, include)
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %v", path, err)
+			}
+			for _, fname := range files {
+				utils.Indent(log.Info, 2)("Created " + fname)
+			}
+			return nil
+		}
+
+		entries, err := ota.ListPayload(context.Background(), f, include)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %v", path, err)
+		}
+		if asJSON {
+			if entries == nil {
+				entries = []*yaa.Entry{}
+			}
+			dat, err := json.Marshal(entries)
+			if err != nil {
+				return fmt.Errorf("failed to marshal entries: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+		for _, ent := range entries {
+			fmt.Println(ent)
+		}
+		return nil
+	},
+}
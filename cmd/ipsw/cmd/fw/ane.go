@@ -22,9 +22,7 @@ THE SOFTWARE.
 package fw
 
 import (
-	"github.com/apex/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // NOTE:
@@ -32,29 +30,15 @@ import (
 
 func init() {
 	FwCmd.AddCommand(aneCmd)
-
-	aneCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
-	aneCmd.MarkFlagDirname("output")
-	viper.BindPFlag("fw.ane.output", aneCmd.Flags().Lookup("output"))
+	addRTKitFlags(aneCmd, "ane")
 }
 
 // aneCmd represents the ane command
 var aneCmd = &cobra.Command{
-	Use:    "ane",
-	Short:  "🚧 Dump MachOs",
-	Args:   cobra.ExactArgs(1),
-	Hidden: true,
+	Use:   "ane <IM4P>",
+	Short: "Dump Apple Neural Engine RTKit firmware",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if viper.GetBool("verbose") {
-			log.SetLevel(log.DebugLevel)
-		}
-
-		// flags
-		// output := viper.GetString("fw.ane.output")
-
-		panic("not implemented")
-
-		return nil
+		return runRTKit("ane", args)
 	},
 }
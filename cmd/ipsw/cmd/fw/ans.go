@@ -22,9 +22,7 @@ THE SOFTWARE.
 package fw
 
 import (
-	"github.com/apex/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // NOTE:
@@ -33,29 +31,15 @@ import (
 
 func init() {
 	FwCmd.AddCommand(ansCmd)
-
-	ansCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
-	ansCmd.MarkFlagDirname("output")
-	viper.BindPFlag("fw.ans.output", ansCmd.Flags().Lookup("output"))
+	addRTKitFlags(ansCmd, "ans")
 }
 
 // ansCmd represents the ans command
 var ansCmd = &cobra.Command{
-	Use:    "ans",
-	Short:  "🚧 Dump MachOs",
-	Args:   cobra.ExactArgs(1),
-	Hidden: true,
+	Use:   "ans <IM4P>",
+	Short: "Dump Apple NAND Storage RTKit firmware",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if viper.GetBool("verbose") {
-			log.SetLevel(log.DebugLevel)
-		}
-
-		// flags
-		// output := viper.GetString("fw.ans.output")
-
-		panic("not implemented")
-
-		return nil
+		return runRTKit("ans", args)
 	},
 }
@@ -22,9 +22,7 @@ THE SOFTWARE.
 package fw
 
 import (
-	"github.com/apex/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // NOTE:
@@ -32,29 +30,15 @@ import (
 
 func init() {
 	FwCmd.AddCommand(aveCmd)
-
-	aveCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
-	aveCmd.MarkFlagDirname("output")
-	viper.BindPFlag("fw.ave.output", aveCmd.Flags().Lookup("output"))
+	addRTKitFlags(aveCmd, "ave")
 }
 
 // aveCmd represents the ave command
 var aveCmd = &cobra.Command{
-	Use:    "ave",
-	Short:  "🚧 Dump MachOs",
-	Args:   cobra.ExactArgs(1),
-	Hidden: true,
+	Use:   "ave <IM4P>",
+	Short: "Dump Apple Video Encoder RTKit firmware",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if viper.GetBool("verbose") {
-			log.SetLevel(log.DebugLevel)
-		}
-
-		// flags
-		// output := viper.GetString("fw.ave.output")
-
-		panic("not implemented")
-
-		return nil
+		return runRTKit("ave", args)
 	},
 }
@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/bbfw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NOTE:
+//   Firmware/Mav22-1.52.01.Release.bbfw
+//   Firmware/ICE19-4.05.00.Release.bbfw
+
+func init() {
+	FwCmd.AddCommand(bbCmd)
+
+	bbCmd.Flags().BoolP("info", "i", false, "Print info")
+	bbCmd.Flags().BoolP("json", "j", false, "Output info as JSON")
+	bbCmd.Flags().StringP("pattern", "p", "", "Only extract images matching regex")
+	bbCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	bbCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.bb.info", bbCmd.Flags().Lookup("info"))
+	viper.BindPFlag("fw.bb.json", bbCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.bb.pattern", bbCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("fw.bb.output", bbCmd.Flags().Lookup("output"))
+}
+
+// bbCmd represents the bb command
+var bbCmd = &cobra.Command{
+	Use:     "bb <BBFW|IPSW>",
+	Aliases: []string{"bbfw", "baseband"},
+	Short:   "Dump baseband firmware images",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		showInfo := viper.GetBool("fw.bb.info")
+		asJSON := viper.GetBool("fw.bb.json")
+		pattern := viper.GetString("fw.bb.pattern")
+		output := viper.GetString("fw.bb.output")
+
+		var bbfws []string
+
+		if isZip, err := magic.IsZip(filepath.Clean(args[0])); err != nil {
+			return fmt.Errorf("failed to determine if file is a zip: %v", err)
+		} else if isZip && !strings.HasSuffix(args[0], ".bbfw") {
+			tmpDir, err := os.MkdirTemp("", "bbfw")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			bbfws, err = extract.Search(&extract.Config{
+				IPSW:    filepath.Clean(args[0]),
+				Pattern: `\.bbfw$`,
+				Output:  tmpDir,
+				Flatten: true,
+			})
+			if err != nil {
+				return err
+			}
+			if len(bbfws) == 0 {
+				return fmt.Errorf("no baseband firmware found in %s", args[0])
+			}
+		} else {
+			bbfws = append(bbfws, filepath.Clean(args[0]))
+		}
+
+		var bbs []*bbfw.BBFW
+		for _, f := range bbfws {
+			bb, err := bbfw.Open(f)
+			if err != nil {
+				return err
+			}
+			defer bb.Close()
+			bbs = append(bbs, bb)
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(bbs)
+			if err != nil {
+				return fmt.Errorf("failed to marshal baseband info: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		} else if showInfo {
+			for _, bb := range bbs {
+				fmt.Println(bb)
+			}
+			return nil
+		}
+
+		for _, bb := range bbs {
+			log.Infof("Extracting %s", bb.Name)
+			out, err := bb.Extract(pattern, filepath.Join(output, strings.TrimSuffix(bb.Name, filepath.Ext(bb.Name))))
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %v", bb.Name, err)
+			}
+			for _, f := range out {
+				utils.Indent(log.Info, 2)("Created " + f)
+			}
+		}
+
+		return nil
+	},
+}
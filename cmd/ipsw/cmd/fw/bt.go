@@ -0,0 +1,53 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	FwCmd.AddCommand(btCmd)
+
+	btCmd.Flags().BoolP("info", "i", false, "Print info")
+	btCmd.Flags().BoolP("json", "j", false, "Output info as JSON")
+	btCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	btCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	btCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.bt.info", btCmd.Flags().Lookup("info"))
+	viper.BindPFlag("fw.bt.json", btCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.bt.pem-db", btCmd.Flags().Lookup("pem-db"))
+	viper.BindPFlag("fw.bt.output", btCmd.Flags().Lookup("output"))
+}
+
+// btCmd represents the bt command
+var btCmd = &cobra.Command{
+	Use:     "bt <IPSW|OTA> [IPSW|OTA]",
+	Aliases: []string{"bluetooth"},
+	Short:   "Dump Bluetooth firmware (pass two builds to diff versions)",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWireless(fwcmd.Bluetooth, "fw.bt", args)
+	},
+}
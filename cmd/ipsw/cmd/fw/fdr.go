@@ -0,0 +1,178 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/plist"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NOTE: components are located via their BuildManifest keys
+//   ftap, ftsp, rfta, rfts
+
+func init() {
+	FwCmd.AddCommand(fdrCmd)
+
+	fdrCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	fdrCmd.Flags().StringP("output", "o", "", "Folder to write JSON to")
+	fdrCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.fdr.json", fdrCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.fdr.output", fdrCmd.Flags().Lookup("output"))
+}
+
+// extractManifestComponents extracts the IPSW components whose BuildManifest key matches into a temp folder
+// and returns a map of the extracted files to their BuildManifest key
+func extractManifestComponents(ipswPath, tmpDir string, match func(key string) bool) (map[string]string, error) {
+	p, err := plist.Parse(ipswPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPSW plists: %v", err)
+	}
+	if p.BuildManifest == nil {
+		return nil, fmt.Errorf("no BuildManifest.plist found in %s", ipswPath)
+	}
+	paths := fwcmd.ManifestPaths(p.BuildManifest, match)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	var patterns []string
+	for path := range paths {
+		patterns = append(patterns, regexp.QuoteMeta(path)+"$")
+	}
+	out, err := extract.Search(&extract.Config{
+		IPSW:    ipswPath,
+		Pattern: strings.Join(patterns, "|"),
+		Output:  tmpDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, f := range out {
+		for path, key := range paths {
+			if strings.HasSuffix(f, path) {
+				files[f] = key
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+func writeFwJSON(v any, output, name string) error {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(output) == 0 {
+		fmt.Println(string(dat))
+		return nil
+	}
+	if err := os.MkdirAll(output, 0o750); err != nil {
+		return fmt.Errorf("failed to create output folder: %v", err)
+	}
+	fname := filepath.Join(output, name)
+	log.Info("Creating JSON info file: " + fname)
+	return os.WriteFile(fname, dat, 0o644)
+}
+
+// fdrCmd represents the fdr command
+var fdrCmd = &cobra.Command{
+	Use:     "fdr <IM4P|IPSW>",
+	Aliases: []string{"ftap", "trust"},
+	Short:   "Dump FDR trust objects",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		asJSON := viper.GetBool("fw.fdr.json")
+		output := viper.GetString("fw.fdr.output")
+
+		files := make(map[string]string)
+
+		if isZip, err := magic.IsZip(filepath.Clean(args[0])); err != nil {
+			return fmt.Errorf("failed to determine if file is a zip: %v", err)
+		} else if isZip {
+			tmpDir, err := os.MkdirTemp("", "fdr")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			files, err = extractManifestComponents(filepath.Clean(args[0]), tmpDir, func(key string) bool {
+				return slices.Contains(fwcmd.FDRTrustObjectKeys, key)
+			})
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no FDR trust objects found in %s", args[0])
+			}
+		} else {
+			files[filepath.Clean(args[0])] = ""
+		}
+
+		var tos []*fwcmd.TrustObject
+		for f, key := range files {
+			log.WithField("file", f).Debug("Parsing FDR trust object")
+			dat, err := os.ReadFile(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", f, err)
+			}
+			to, err := fwcmd.ParseTrustObject(f, dat)
+			if err != nil {
+				return fmt.Errorf("failed to parse FDR trust object %s: %v", f, err)
+			}
+			to.Key = key
+			tos = append(tos, to)
+		}
+		sort.Slice(tos, func(i, j int) bool {
+			return tos[i].Path < tos[j].Path
+		})
+
+		if asJSON {
+			return writeFwJSON(tos, output, "fdr.json")
+		}
+
+		for _, to := range tos {
+			fmt.Println(to)
+		}
+
+		return nil
+	},
+}
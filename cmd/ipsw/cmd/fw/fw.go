@@ -22,6 +22,14 @@ THE SOFTWARE.
 package fw
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	icmd "github.com/blacktop/ipsw/internal/commands/img4"
+	"github.com/blacktop/ipsw/internal/keys"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -41,3 +49,34 @@ var FwCmd = &cobra.Command{
 		cmd.Help()
 	},
 }
+
+// decryptIm4p decrypts the im4p with the device/build's key from the key database (see 'ipsw keys')
+// and returns the path of the decrypted payload (in the output folder or next to the im4p)
+func decryptIm4p(in, device, build, output string) (string, error) {
+	db, err := keys.Open("", nil, "", false)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	k, err := db.Find(device, build, in)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup key: %v", err)
+	}
+	utils.Indent(log.WithField("source", k.Source).Info, 2)("Found key for " + filepath.Base(in))
+	iv, key, err := k.Decode()
+	if err != nil {
+		return "", err
+	}
+	out := in + ".dec"
+	if len(output) > 0 {
+		if err := os.MkdirAll(output, 0o750); err != nil {
+			return "", err
+		}
+		out = filepath.Join(output, filepath.Base(out))
+	}
+	if err := icmd.DecryptPayload(in, out, iv, key); err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %v", in, err)
+	}
+	utils.Indent(log.Info, 2)("Decrypted " + out)
+	return out, nil
+}
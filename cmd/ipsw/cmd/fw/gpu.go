@@ -22,59 +22,111 @@ THE SOFTWARE.
 package fw
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/commands/extract"
 	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
 	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// NOTE:
+//   Firmware/agx/armfw_g17p.im4p
+
 func init() {
 	FwCmd.AddCommand(gpuCmd)
 
+	gpuCmd.Flags().BoolP("info", "i", false, "Print info")
+	gpuCmd.Flags().BoolP("json", "j", false, "Output info as JSON")
 	gpuCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
 	gpuCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.gpu.info", gpuCmd.Flags().Lookup("info"))
+	viper.BindPFlag("fw.gpu.json", gpuCmd.Flags().Lookup("json"))
 	viper.BindPFlag("fw.gpu.output", gpuCmd.Flags().Lookup("output"))
 }
 
 // gpuCmd represents the gpu command
 var gpuCmd = &cobra.Command{
-	Use:     "gpu",
+	Use:     "gpu <IM4P|IPSW>",
 	Aliases: []string{"agx"},
-	Short:   "Dump MachOs",
+	Short:   "Dump AGX GPU firmware RTKit images",
 	Args:    cobra.ExactArgs(1),
-	Hidden:  true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
 			log.SetLevel(log.DebugLevel)
 		}
 
-		if isZip, err := magic.IsZip(filepath.Clean(args[0])); err != nil {
+		// flags
+		showInfo := viper.GetBool("fw.gpu.info")
+		asJSON := viper.GetBool("fw.gpu.json")
+		output := viper.GetString("fw.gpu.output")
+
+		var fws []string
+
+		isZip, err := magic.IsZip(filepath.Clean(args[0]))
+		if err != nil {
 			return fmt.Errorf("failed to determine if file is a zip: %v", err)
 		} else if isZip {
 			out, err := extract.Search(&extract.Config{
 				IPSW:    filepath.Clean(args[0]),
 				Pattern: "armfw_.*.im4p$",
-				Output:  viper.GetString("fw.gpu.output"),
+				Output:  output,
 			})
 			if err != nil {
 				return err
 			}
-			for _, f := range out {
-				folder := filepath.Join(filepath.Dir(f), "extracted")
-				if _, err := fwcmd.SplitGpuFW(f, folder); err != nil {
-					return fmt.Errorf("failed to split GPU firmware: %v", err)
+			fws = append(fws, out...)
+		} else {
+			fws = append(fws, filepath.Clean(args[0]))
+		}
+
+		if showInfo || asJSON {
+			var gfws []*fwcmd.GpuFirmware
+			for _, f := range fws {
+				gfw, err := fwcmd.ParseGpuFW(f)
+				if err != nil {
+					return fmt.Errorf("failed to parse GPU firmware %s: %v", f, err)
 				}
+				gfws = append(gfws, gfw)
 			}
-		} else {
-			if _, err := fwcmd.SplitGpuFW(filepath.Clean(args[0]), viper.GetString("fw.gpu.output")); err != nil {
+			if asJSON {
+				dat, err := json.Marshal(gfws)
+				if err != nil {
+					return fmt.Errorf("failed to marshal GPU firmware info: %v", err)
+				}
+				if len(output) > 0 {
+					fname := filepath.Join(output, "agx_fw.json")
+					log.Info("Creating JSON GPU firmware info file: " + fname)
+					return os.WriteFile(fname, dat, 0o644)
+				}
+				fmt.Println(string(dat))
+			} else {
+				for _, gfw := range gfws {
+					fmt.Println(gfw)
+				}
+			}
+			return nil
+		}
+
+		for _, f := range fws {
+			folder := output
+			if isZip {
+				folder = filepath.Join(filepath.Dir(f), "extracted")
+			}
+			out, err := fwcmd.SplitGpuFW(f, folder)
+			if err != nil {
 				return fmt.Errorf("failed to split GPU firmware: %v", err)
 			}
+			for _, o := range out {
+				utils.Indent(log.Info, 2)("Created " + o)
+			}
 		}
 
 		return nil
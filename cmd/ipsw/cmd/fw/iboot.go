@@ -43,8 +43,13 @@ func init() {
 	FwCmd.AddCommand(ibootCmd)
 
 	ibootCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	ibootCmd.Flags().StringP("device", "d", "", "Decrypt im4p with key from key DB for device (i.e. iPhone11,2)")
+	ibootCmd.Flags().StringP("build", "b", "", "Decrypt im4p with key from key DB for build (i.e. 16F203)")
 	ibootCmd.MarkFlagDirname("output")
+	ibootCmd.MarkFlagsRequiredTogether("device", "build")
 	viper.BindPFlag("fw.iboot.output", ibootCmd.Flags().Lookup("output"))
+	viper.BindPFlag("fw.iboot.device", ibootCmd.Flags().Lookup("device"))
+	viper.BindPFlag("fw.iboot.build", ibootCmd.Flags().Lookup("build"))
 }
 
 // ibootCmd represents the iboot command
@@ -62,15 +67,26 @@ var ibootCmd = &cobra.Command{
 
 		// flags
 		output := viper.GetString("fw.iboot.output")
+		device := viper.GetString("fw.iboot.device")
+		build := viper.GetString("fw.iboot.build")
 
-		f, err := os.Open(args[0])
+		infile := filepath.Clean(args[0])
+		if len(device) > 0 && len(build) > 0 {
+			dec, err := decryptIm4p(infile, device, build, output)
+			if err != nil {
+				return err
+			}
+			infile = dec
+		}
+
+		f, err := os.Open(infile)
 		if err != nil {
-			return errors.Wrapf(err, "unabled to open file: %s", args[0])
+			return errors.Wrapf(err, "unabled to open file: %s", infile)
 		}
 
 		dat, err := io.ReadAll(f)
 		if err != nil {
-			return errors.Wrapf(err, "unabled to read file: %s", args[0])
+			return errors.Wrapf(err, "unabled to read file: %s", infile)
 		}
 
 		lzfseStart := make([]byte, 4)
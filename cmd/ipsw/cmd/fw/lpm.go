@@ -35,7 +35,7 @@ func init() {
 
 	lpmCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
 	lpmCmd.MarkFlagDirname("output")
-	viper.BindPFlag("fw.ane.output", lpmCmd.Flags().Lookup("output"))
+	viper.BindPFlag("fw.lpm.output", lpmCmd.Flags().Lookup("output"))
 }
 
 // lpmCmd represents the ane command
@@ -52,7 +52,7 @@ var lpmCmd = &cobra.Command{
 		}
 
 		// flags
-		// output := viper.GetString("fw.ane.output")
+		// output := viper.GetString("fw.lpm.output")
 
 		panic("not implemented")
 
@@ -22,9 +22,7 @@ THE SOFTWARE.
 package fw
 
 import (
-	"github.com/apex/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // NOTE:
@@ -32,30 +30,16 @@ import (
 
 func init() {
 	FwCmd.AddCommand(pmpCmd)
-
-	pmpCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
-	pmpCmd.MarkFlagDirname("output")
-	viper.BindPFlag("fw.pmp.output", pmpCmd.Flags().Lookup("output"))
+	addRTKitFlags(pmpCmd, "pmp")
 }
 
 // pmpCmd represents the pmp command
 var pmpCmd = &cobra.Command{
-	Use:     "pmp",
+	Use:     "pmp <IM4P>",
 	Aliases: []string{"p"},
-	Short:   "🚧 Dump MachOs",
+	Short:   "Dump Power Management Processor RTKit firmware",
 	Args:    cobra.ExactArgs(1),
-	Hidden:  true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		if viper.GetBool("verbose") {
-			log.SetLevel(log.DebugLevel)
-		}
-
-		// flags
-		// output := viper.GetString("fw.pmp.output")
-
-		panic("not implemented")
-
-		return nil
+		return runRTKit("pmp", args)
 	},
 }
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addRTKitFlags adds the flags shared by the RTKit based firmware commands
+func addRTKitFlags(cmd *cobra.Command, name string) {
+	cmd.Flags().BoolP("info", "i", false, "Print info")
+	cmd.Flags().BoolP("json", "j", false, "Output info as JSON")
+	cmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	cmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw."+name+".info", cmd.Flags().Lookup("info"))
+	viper.BindPFlag("fw."+name+".json", cmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw."+name+".output", cmd.Flags().Lookup("output"))
+}
+
+// runRTKit prints the info of (or extracts the MachOs from) an RTKit based firmware
+func runRTKit(name string, args []string) error {
+	if viper.GetBool("verbose") {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	// flags
+	showInfo := viper.GetBool("fw." + name + ".info")
+	asJSON := viper.GetBool("fw." + name + ".json")
+	output := viper.GetString("fw." + name + ".output")
+
+	in := filepath.Clean(args[0])
+
+	if showInfo || asJSON {
+		rfw, err := fwcmd.ParseRTKit(in)
+		if err != nil {
+			return fmt.Errorf("failed to parse RTKit firmware: %v", err)
+		}
+		if asJSON {
+			dat, err := json.Marshal(rfw)
+			if err != nil {
+				return fmt.Errorf("failed to marshal RTKit firmware info: %v", err)
+			}
+			if len(output) > 0 {
+				if err := os.MkdirAll(output, 0o750); err != nil {
+					return fmt.Errorf("failed to create output folder: %v", err)
+				}
+				fname := filepath.Join(output, name+"_fw.json")
+				log.Info("Creating JSON RTKit firmware info file: " + fname)
+				return os.WriteFile(fname, dat, 0o644)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+		fmt.Println(rfw)
+		return nil
+	}
+
+	out, err := fwcmd.SplitRTKit(in, output)
+	if err != nil {
+		return fmt.Errorf("failed to split RTKit firmware: %v", err)
+	}
+	for _, o := range out {
+		utils.Indent(log.Info, 2)("Created " + o)
+	}
+
+	return nil
+}
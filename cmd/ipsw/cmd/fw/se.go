@@ -0,0 +1,118 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NOTE: components are located via their BuildManifest keys
+//   SE,Bootloader, SE,Firmware, SE,UpdatePayload, ...
+
+func init() {
+	FwCmd.AddCommand(seCmd)
+
+	seCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	seCmd.Flags().StringP("output", "o", "", "Folder to write JSON to")
+	seCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.se.json", seCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.se.output", seCmd.Flags().Lookup("output"))
+}
+
+// seCmd represents the se command
+var seCmd = &cobra.Command{
+	Use:     "se <IM4P|IPSW>",
+	Aliases: []string{"stockholm"},
+	Short:   "Dump Secure Element firmware info",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		asJSON := viper.GetBool("fw.se.json")
+		output := viper.GetString("fw.se.output")
+
+		files := make(map[string]string)
+
+		if isZip, err := magic.IsZip(filepath.Clean(args[0])); err != nil {
+			return fmt.Errorf("failed to determine if file is a zip: %v", err)
+		} else if isZip {
+			tmpDir, err := os.MkdirTemp("", "se")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			files, err = extractManifestComponents(filepath.Clean(args[0]), tmpDir, func(key string) bool {
+				return strings.HasPrefix(key, fwcmd.SEManifestPrefix)
+			})
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no Secure Element firmware found in %s", args[0])
+			}
+		} else {
+			files[filepath.Clean(args[0])] = ""
+		}
+
+		var sefws []*fwcmd.SEFirmware
+		for f, key := range files {
+			log.WithField("file", f).Debug("Parsing SE firmware")
+			dat, err := os.ReadFile(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", f, err)
+			}
+			se, err := fwcmd.ParseSEFirmware(f, dat)
+			if err != nil {
+				return fmt.Errorf("failed to parse SE firmware %s: %v", f, err)
+			}
+			se.Key = key
+			sefws = append(sefws, se)
+		}
+		sort.Slice(sefws, func(i, j int) bool {
+			return sefws[i].Path < sefws[j].Path
+		})
+
+		if asJSON {
+			return writeFwJSON(sefws, output, "se.json")
+		}
+
+		for _, se := range sefws {
+			fmt.Println(se)
+		}
+
+		return nil
+	},
+}
@@ -42,9 +42,14 @@ func init() {
 
 	fwSepCmd.Flags().BoolP("info", "i", false, "Print info")
 	fwSepCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	fwSepCmd.Flags().StringP("device", "d", "", "Decrypt im4p with key from key DB for device (i.e. iPhone11,2)")
+	fwSepCmd.Flags().StringP("build", "b", "", "Decrypt im4p with key from key DB for build (i.e. 16F203)")
 	fwSepCmd.MarkFlagDirname("output")
+	fwSepCmd.MarkFlagsRequiredTogether("device", "build")
 	viper.BindPFlag("fw.sep.info", fwSepCmd.Flags().Lookup("info"))
 	viper.BindPFlag("fw.sep.output", fwSepCmd.Flags().Lookup("output"))
+	viper.BindPFlag("fw.sep.device", fwSepCmd.Flags().Lookup("device"))
+	viper.BindPFlag("fw.sep.build", fwSepCmd.Flags().Lookup("build"))
 }
 
 // fwSepCmd represents the sep command
@@ -63,18 +68,29 @@ var fwSepCmd = &cobra.Command{
 		// flags
 		showInfo := viper.GetBool("fw.sep.info")
 		output := viper.GetString("fw.sep.output")
+		device := viper.GetString("fw.sep.device")
+		build := viper.GetString("fw.sep.build")
+
+		infile := filepath.Clean(args[0])
+		if len(device) > 0 && len(build) > 0 {
+			dec, err := decryptIm4p(infile, device, build, output)
+			if err != nil {
+				return err
+			}
+			infile = dec
+		}
 
 		if showInfo {
-			sp, err := sep.Parse(filepath.Clean(args[0]))
+			sp, err := sep.Parse(infile)
 			if err != nil {
-				return fmt.Errorf("failed to parse sep firmware '%s': %v", filepath.Clean(args[0]), err)
+				return fmt.Errorf("failed to parse sep firmware '%s': %v", infile, err)
 			}
 			fmt.Println(sp)
 		} else {
 			log.Info("Extracting Sep Firmware")
-			out, err := fwcmd.SplitSepFW(filepath.Clean(args[0]), output)
+			out, err := fwcmd.SplitSepFW(infile, output)
 			if err != nil {
-				return fmt.Errorf("failed to extract files from sep firmware '%s': %v", filepath.Clean(args[0]), err)
+				return fmt.Errorf("failed to extract files from sep firmware '%s': %v", infile, err)
 			}
 			for _, f := range out {
 				utils.Indent(log.Info, 2)("Created " + f)
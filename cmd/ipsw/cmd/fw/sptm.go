@@ -0,0 +1,197 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/signature"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NOTE:
+//   Firmware/sptm.t8132.release.im4p
+//   Firmware/txm.iphoneos.release.im4p
+
+func init() {
+	FwCmd.AddCommand(sptmCmd)
+
+	sptmCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	sptmCmd.Flags().StringP("signatures", "s", "", "Path to signatures folder (to symbolicate the monitors)")
+	sptmCmd.Flags().StringP("output", "o", "", "Folder to extract the decompressed MachOs to")
+	sptmCmd.MarkFlagDirname("signatures")
+	sptmCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.sptm.json", sptmCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.sptm.signatures", sptmCmd.Flags().Lookup("signatures"))
+	viper.BindPFlag("fw.sptm.output", sptmCmd.Flags().Lookup("output"))
+}
+
+// sptmCmd represents the sptm command
+var sptmCmd = &cobra.Command{
+	Use:     "sptm <IPSW|IM4P|MACHO>",
+	Aliases: []string{"txm"},
+	Short:   "Dump SPTM and TXM monitors",
+	Example: heredoc.Doc(`
+		# Show the version, MachO layout and entry points of an IPSW's SPTM and TXM
+		❯ ipsw fw sptm iPhone16,1_18.0_22A3354_Restore.ipsw
+		# Symbolicate a TXM with signatures
+		❯ ipsw fw sptm --signatures /path/to/symbolicator/ txm.iphoneos.release.im4p
+		# Extract the decompressed SPTM and TXM MachOs
+		❯ ipsw fw sptm -o /tmp/sptm iPhone16,1_18.0_22A3354_Restore.ipsw`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		input := filepath.Clean(args[0])
+
+		var sigs []signature.Symbolicator
+		if viper.IsSet("fw.sptm.signatures") {
+			var err error
+			if sigs, err = signature.Parse(viper.GetString("fw.sptm.signatures")); err != nil {
+				return fmt.Errorf("failed to parse signatures: %v", err)
+			}
+		}
+
+		files := []string{input}
+		if isZip, err := magic.IsZip(input); err != nil {
+			return fmt.Errorf("failed to determine if file is a zip: %v", err)
+		} else if isZip {
+			if viper.IsSet("fw.sptm.output") {
+				out, err := extract.SPTM(&extract.Config{
+					IPSW:   input,
+					Output: viper.GetString("fw.sptm.output"),
+				})
+				if err != nil {
+					return err
+				}
+				for _, f := range out {
+					utils.Indent(log.Info, 2)("Created " + f)
+				}
+				return nil
+			}
+			tmpDir, err := os.MkdirTemp("", "ipsw_fw_sptm")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			if files, err = extract.Search(&extract.Config{
+				IPSW:    input,
+				Pattern: `(sptm|txm)\..*im4p$`,
+				Output:  tmpDir,
+			}); err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no SPTM or TXM firmware found")
+			}
+		}
+
+		var monitors []*fwcmd.Monitor
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", f, err)
+			}
+			name := "SPTM"
+			if strings.HasPrefix(strings.ToLower(filepath.Base(f)), "txm") {
+				name = "TXM"
+			}
+			if viper.IsSet("fw.sptm.output") {
+				dat, err := fwcmd.MonitorPayload(data)
+				if err != nil {
+					return fmt.Errorf("failed to decompress %s: %v", f, err)
+				}
+				fname := filepath.Join(viper.GetString("fw.sptm.output"), strings.TrimSuffix(filepath.Base(f), ".im4p"))
+				if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+					return fmt.Errorf("failed to create output directory: %v", err)
+				}
+				if err := os.WriteFile(fname, dat, 0o644); err != nil {
+					return fmt.Errorf("failed to write %s: %v", fname, err)
+				}
+				utils.Indent(log.Info, 2)("Created " + fname)
+				continue
+			}
+			mon, err := fwcmd.ParseMonitor(name, data)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %v", f, err)
+			}
+			if len(sigs) > 0 {
+				if err := mon.Symbolicate(sigs); err != nil {
+					return err
+				}
+			}
+			monitors = append(monitors, mon)
+		}
+		if viper.IsSet("fw.sptm.output") {
+			return nil
+		}
+
+		if viper.GetBool("fw.sptm.json") {
+			dat, err := json.MarshalIndent(monitors, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal monitors: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		for _, mon := range monitors {
+			fmt.Printf("%s %s (%s)\n", color.New(color.Bold).Sprint(mon.Name), mon.Version, mon.Arch)
+			if len(mon.UUID) > 0 {
+				fmt.Printf("  UUID:  %s\n", mon.UUID)
+			}
+			if mon.Entry != 0 {
+				fmt.Printf("  Entry: %#x\n", mon.Entry)
+			}
+			fmt.Println("  Segments:")
+			for _, seg := range mon.Segments {
+				fmt.Printf("    %#016x-%#016x %s %-20s %s\n", seg.Addr, seg.Addr+seg.Size, seg.Prot, seg.Name, strings.Join(seg.Sections, ", "))
+			}
+			if len(mon.Symbols) > 0 {
+				fmt.Println("  Symbols:")
+				for _, addr := range mon.SortedSymbols() {
+					fmt.Printf("    %#016x %s\n", addr, mon.Symbols[addr])
+				}
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// NOTE:
+//   /usr/share/firmware/wifi/C-4387__s-C1/P-*.trx
+//   /usr/share/firmware/bluetooth/BCM4387C2_*.bin
+
+func init() {
+	FwCmd.AddCommand(wifiCmd)
+
+	wifiCmd.Flags().BoolP("info", "i", false, "Print info")
+	wifiCmd.Flags().BoolP("json", "j", false, "Output info as JSON")
+	wifiCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	wifiCmd.Flags().StringP("output", "o", "", "Folder to extract files to")
+	wifiCmd.MarkFlagDirname("output")
+	viper.BindPFlag("fw.wifi.info", wifiCmd.Flags().Lookup("info"))
+	viper.BindPFlag("fw.wifi.json", wifiCmd.Flags().Lookup("json"))
+	viper.BindPFlag("fw.wifi.pem-db", wifiCmd.Flags().Lookup("pem-db"))
+	viper.BindPFlag("fw.wifi.output", wifiCmd.Flags().Lookup("output"))
+}
+
+func extractWireless(in string, typ fwcmd.WirelessType, output, pemDB string) ([]string, error) {
+	if fwcmd.IsOTA(in) {
+		return fwcmd.ExtractWirelessOTA(in, typ, output)
+	}
+	return extract.Search(&extract.Config{
+		IPSW:    in,
+		Pattern: typ.Pattern().String(),
+		DMGs:    true,
+		PemDB:   pemDB,
+		Output:  output,
+	})
+}
+
+func wirelessInfo(in string, typ fwcmd.WirelessType, pemDB string) ([]*fwcmd.WirelessFirmware, error) {
+	tmpDir, err := os.MkdirTemp("", string(typ))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	out, err := extractWireless(in, typ, tmpDir, pemDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s firmware from %s: %v", typ, in, err)
+	}
+	return fwcmd.ParseWireless(typ, tmpDir, out)
+}
+
+func runWireless(typ fwcmd.WirelessType, key string, args []string) error {
+	if viper.GetBool("verbose") {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	// flags
+	showInfo := viper.GetBool(key + ".info")
+	asJSON := viper.GetBool(key + ".json")
+	pemDB := viper.GetString(key + ".pem-db")
+	output := viper.GetString(key + ".output")
+
+	if len(args) == 2 { // DIFF
+		prev, err := wirelessInfo(filepath.Clean(args[0]), typ, pemDB)
+		if err != nil {
+			return err
+		}
+		next, err := wirelessInfo(filepath.Clean(args[1]), typ, pemDB)
+		if err != nil {
+			return err
+		}
+		if diff := fwcmd.DiffWireless(prev, next); len(diff) > 0 {
+			fmt.Print(diff)
+		} else {
+			log.Info("No differences found")
+		}
+		return nil
+	}
+
+	if showInfo || asJSON {
+		wfws, err := wirelessInfo(filepath.Clean(args[0]), typ, pemDB)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			dat, err := json.Marshal(wfws)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s firmware info: %v", typ, err)
+			}
+			fmt.Println(string(dat))
+		} else {
+			for _, wfw := range wfws {
+				fmt.Println(wfw)
+			}
+		}
+		return nil
+	}
+
+	log.Infof("Extracting %s firmware", typ)
+	out, err := extractWireless(filepath.Clean(args[0]), typ, output, pemDB)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s firmware: %v", typ, err)
+	}
+	for _, f := range out {
+		utils.Indent(log.Info, 2)("Created " + f)
+	}
+
+	return nil
+}
+
+// wifiCmd represents the wifi command
+var wifiCmd = &cobra.Command{
+	Use:   "wifi <IPSW|OTA> [IPSW|OTA]",
+	Short: "Dump WiFi firmware (pass two builds to diff versions)",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWireless(fwcmd.WiFi, "fw.wifi", args)
+	},
+}
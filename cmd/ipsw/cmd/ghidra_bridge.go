@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/ghidra"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(ghidraBridgeCmd)
+
+	ghidraBridgeCmd.Flags().StringP("host", "a", "127.0.0.1", "Host/IP to listen on")
+	ghidraBridgeCmd.Flags().IntP("port", "p", 3995, "Port to listen on")
+	ghidraBridgeCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	ghidraBridgeCmd.Flags().StringP("output", "o", "", "Folder to extract the requested dylibs to (default is ~/.config/ipsw/ghidra)")
+	ghidraBridgeCmd.MarkFlagDirname("output")
+	viper.BindPFlag("ghidra-bridge.host", ghidraBridgeCmd.Flags().Lookup("host"))
+	viper.BindPFlag("ghidra-bridge.port", ghidraBridgeCmd.Flags().Lookup("port"))
+	viper.BindPFlag("ghidra-bridge.pem-db", ghidraBridgeCmd.Flags().Lookup("pem-db"))
+	viper.BindPFlag("ghidra-bridge.output", ghidraBridgeCmd.Flags().Lookup("output"))
+}
+
+// ghidraBridgeCmd represents the ghidra-bridge command
+var ghidraBridgeCmd = &cobra.Command{
+	Use:   "ghidra-bridge <DSC|IPSW>",
+	Short: "Serve dyld_shared_cache symbols, ObjC layouts and dylibs to Ghidra",
+	Long: heredoc.Doc(`
+		Serve an HTTP/JSON bridge to a dyld_shared_cache (or the caches of an IPSW) for the companion
+		Ghidra script (hack/extras/ghidra_bridge.py) so Ghidra can look up the symbols at addresses
+		(by cache or image UUID), apply ObjC class layouts and import fixed-up dylibs on demand.
+
+		Endpoints:
+		  GET /images                                  the caches' images
+		  GET /symbol?addr=0x1800a0000[&uuid=UUID]     the symbol at an address (or offset from the base of the image with UUID)
+		  GET /symbols?pattern=REGEX[&image=NAME]      the symbols matching a regex
+		  GET /objc/class?name=NAME&image=NAME         the layout of an ObjC class
+		  GET /extract?image=NAME|UUID[&slide=false]   the image extracted as a standalone dylib`),
+	Example: heredoc.Doc(`
+		# Serve the bridge to a dyld_shared_cache
+		❯ ipsw ghidra-bridge /System/Volumes/Preboot/Cryptexes/OS/System/Library/dyld/dyld_shared_cache_arm64e
+		# Serve the bridge to the dyld_shared_caches of an IPSW
+		❯ ipsw ghidra-bridge iPhone16,1_18.0_22A3354_Restore.ipsw
+		# Look up a symbol
+		❯ curl -s 'http://127.0.0.1:3995/symbol?addr=0x1800a0000' | jq .`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		output := viper.GetString("ghidra-bridge.output")
+		if len(output) == 0 {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get user home directory: %v", err)
+			}
+			output = filepath.Join(home, ".config", "ipsw", "ghidra")
+		}
+
+		path := filepath.Clean(args[0])
+
+		var files []*dyld.File
+		if isZip, err := magic.IsZip(path); err != nil {
+			return fmt.Errorf("failed to determine if file is a zip: %v", err)
+		} else if isZip {
+			log.WithField("ipsw", path).Info("Mounting dyld_shared_cache")
+			mctx, fs, err := dsc.OpenFromIPSW(path, viper.GetString("ghidra-bridge.pem-db"), false, false)
+			if err != nil {
+				return fmt.Errorf("failed to open dyld_shared_cache from IPSW: %v", err)
+			}
+			defer func() {
+				if err := mctx.Unmount(); err != nil {
+					log.WithError(err).Error("Failed to unmount IPSW")
+				}
+			}()
+			files = fs
+		} else {
+			if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				if path, err = os.Readlink(path); err != nil {
+					return fmt.Errorf("failed to read symlink %s: %v", args[0], err)
+				}
+			}
+			f, err := dyld.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open dyld_shared_cache: %v", err)
+			}
+			files = []*dyld.File{f}
+		}
+		defer func() {
+			for _, f := range files {
+				f.Close()
+			}
+		}()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		bridge := ghidra.NewBridge(files, &ghidra.Config{Output: output})
+		srv := &http.Server{
+			Addr:              net.JoinHostPort(viper.GetString("ghidra-bridge.host"), strconv.Itoa(viper.GetInt("ghidra-bridge.port"))),
+			Handler:           bridge.Handler(),
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		log.WithField("addr", "http://"+srv.Addr).Info("Serving Ghidra bridge")
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Extracting requested dylibs to %s", output))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("failed to serve Ghidra bridge: %v", err)
+		}
+
+		return nil
+	},
+}
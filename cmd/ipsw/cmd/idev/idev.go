@@ -28,6 +28,12 @@ import (
 
 func init() {
 	IDevCmd.PersistentFlags().StringP("udid", "u", "", "Device UniqueDeviceID to connect to")
+	IDevCmd.PersistentFlags().String("host", "", "Connect to the device over the network at this address (instead of USB)")
+	IDevCmd.PersistentFlags().Bool("wifi", false, "Find the paired device on the LAN with Bonjour and connect to it over Wi-Fi")
+	IDevCmd.PersistentFlags().Bool("tunnel", false, "Connect to the services through the device's CoreDevice tunnel (requires 'ipsw idev tunnel start')")
+	viper.BindPFlag("idev.host", IDevCmd.PersistentFlags().Lookup("host"))
+	viper.BindPFlag("idev.wifi", IDevCmd.PersistentFlags().Lookup("wifi"))
+	viper.BindPFlag("idev.tunnel", IDevCmd.PersistentFlags().Lookup("tunnel"))
 }
 
 // IDevCmd represents the idev command
@@ -36,11 +42,20 @@ var IDevCmd = &cobra.Command{
 	Aliases: []string{"usb"},
 	Short:   "USB connected device commands",
 	Args:    cobra.NoArgs,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		viper.BindPFlag("color", cmd.Flags().Lookup("color"))
 		viper.BindPFlag("no-color", cmd.Flags().Lookup("no-color"))
 		viper.BindPFlag("verbose", cmd.Flags().Lookup("verbose"))
 		viper.BindPFlag("diff-tool", cmd.Flags().Lookup("diff-tool"))
+		if host, wifi := viper.GetString("idev.host"), viper.GetBool("idev.wifi"); len(host) > 0 || wifi {
+			udid, _ := cmd.Flags().GetString("udid")
+			return useNetworkDevice(udid, host)
+		}
+		if viper.GetBool("idev.tunnel") {
+			udid, _ := cmd.Flags().GetString("udid")
+			return useTunnel(udid)
+		}
+		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
@@ -22,36 +22,20 @@ THE SOFTWARE.
 package idev
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 )
 
-// backupEraseCmd represents the erase command
-var backupEraseCmd = &cobra.Command{
-	Use:   "erase",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+func init() {
+	IDevCmd.AddCommand(ActivationCmd)
+}
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+// ActivationCmd represents the activation command
+var ActivationCmd = &cobra.Command{
+	Use:     "activation",
+	Aliases: []string{"act"},
+	Short:   "Activation commands",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("erase called")
+		cmd.Help()
 	},
 }
-
-func init() {
-	BackupCmd.AddCommand(backupEraseCmd)
-
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// eraseCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// eraseCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-}
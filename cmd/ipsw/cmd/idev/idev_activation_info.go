@@ -0,0 +1,169 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/mobileactivation"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ActivationCmd.AddCommand(idevActivationInfoCmd)
+	idevActivationInfoCmd.Flags().StringP("record", "r", "", "Parse an activation record plist (i.e. from a backup or activation server response)")
+	idevActivationInfoCmd.Flags().Bool("offline", false, "Only parse the --record (don't connect to a device)")
+	idevActivationInfoCmd.MarkFlagFilename("record", "plist")
+	idevActivationInfoCmd.MarkFlagsRequiredTogether("offline", "record")
+	viper.BindPFlag("idev.activation.info.record", idevActivationInfoCmd.Flags().Lookup("record"))
+}
+
+type activationInfo struct {
+	UDID              string                     `json:"udid,omitempty"`
+	SerialNumber      string                     `json:"serial_number,omitempty"`
+	ProductType       string                     `json:"product_type,omitempty"`
+	BuildVersion      string                     `json:"build_version,omitempty"`
+	State             string                     `json:"activation_state,omitempty"`
+	StateAcknowledged bool                       `json:"activation_state_acknowledged"`
+	BrickState        bool                       `json:"brick_state"`
+	ActivationLocked  bool                       `json:"activation_locked"`
+	Baseband          *mobileactivation.Baseband `json:"baseband,omitempty"`
+	Request           *mobileactivation.Info     `json:"activation_info,omitempty"`
+	Record            *mobileactivation.Record   `json:"activation_record,omitempty"`
+}
+
+// idevActivationInfoCmd represents the activation info command
+var idevActivationInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Dump activation state, baseband and SIM lock details as JSON",
+	Long: heredoc.Doc(`
+		Report the device's activation state, the activation info it would send to the
+		activation server (including whether Find My is enabled) and its baseband/SIM state.
+
+		Use --record to also parse an activation record (omit the device to only parse the record).`),
+	Example: heredoc.Doc(`
+		# Check a device at intake
+		❯ ipsw idev activation info | jq '{activation_state, activation_locked, sim: .baseband.sim_status}'
+		# Parse an activation record offline
+		❯ ipsw idev activation info --record activation_record.plist --offline`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		recordPath := viper.GetString("idev.activation.info.record")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		var info activationInfo
+
+		if len(recordPath) > 0 {
+			dat, err := os.ReadFile(recordPath)
+			if err != nil {
+				return fmt.Errorf("failed to read activation record: %w", err)
+			}
+			if info.Record, err = mobileactivation.ParseRecord(dat); err != nil {
+				return err
+			}
+		}
+
+		if !offline {
+			if len(udid) == 0 {
+				dev, err := utils.PickDevice()
+				if err != nil {
+					return fmt.Errorf("failed to pick USB connected devices: %w", err)
+				}
+				udid = dev.UniqueDeviceID
+			}
+			if err := deviceActivationInfo(udid, &info); err != nil {
+				return err
+			}
+		}
+
+		dat, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal activation info to JSON: %w", err)
+		}
+		fmt.Println(string(dat))
+
+		return nil
+	},
+}
+
+func deviceActivationInfo(udid string, info *activationInfo) error {
+	ldc, err := lockdownd.NewClient(udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+
+	values, err := ldc.GetValues()
+	if err != nil {
+		return fmt.Errorf("failed to get device values: %w", err)
+	}
+	info.UDID = udid
+	info.SerialNumber = values.SerialNumber
+	info.ProductType = values.ProductType
+	info.BuildVersion = values.BuildVersion
+	info.State = values.ActivationState
+	info.StateAcknowledged = values.ActivationStateAcknowledged
+	info.BrickState = values.BrickState
+	info.Baseband = mobileactivation.NewBaseband(values)
+
+	// mobileactivationd is authoritative on iOS 10+ (lockdownd's values can lag behind)
+	if cli, err := mobileactivation.NewClient(udid); err == nil {
+		defer cli.Close()
+		if state, err := cli.State(); err == nil {
+			info.State = state
+		} else {
+			log.WithError(err).Debug("failed to get activation state from mobileactivationd")
+		}
+		if info.Request, err = cli.Info(); err != nil {
+			log.WithError(err).Debug("failed to get activation info from mobileactivationd")
+		}
+	} else {
+		log.WithError(err).Debug("failed to connect to mobileactivationd")
+	}
+	if info.Request == nil {
+		if info.Request, err = mobileactivation.LockdownInfo(ldc); err != nil {
+			log.WithError(err).Warn("failed to get activation info")
+		}
+	}
+	if info.Request != nil {
+		info.ActivationLocked = info.Request.ActivationRequestInfo.FMiPAccountExists
+	}
+
+	return nil
+}
@@ -22,19 +22,79 @@ THE SOFTWARE.
 package idev
 
 import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/blacktop/ipsw/pkg/usb/housearrest"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	IDevCmd.AddCommand(AfcCmd)
+
+	AfcCmd.PersistentFlags().StringP("app", "a", "", "Use the container of the app with this bundle ID (via house_arrest)")
+	AfcCmd.PersistentFlags().BoolP("documents", "d", false, "Only vend the app's Documents folder (for UIFileSharingEnabled apps)")
 }
 
 // AfcCmd represents the afc command
 var AfcCmd = &cobra.Command{
 	Use:   "afc",
 	Short: "FileSystem commands",
-	Args:  cobra.NoArgs,
+	Long: heredoc.Doc(`
+		Browse the /var/mobile/Media partition over AFC or, with --app,
+		an application's data container over house_arrest.
+
+		The whole container (Documents, Library and tmp) is only vended for development signed apps
+		(get-task-allow), apps with file sharing enabled only expose their Documents folder.
+		Use 'ipsw idev afc apps' to see which containers are accessible.`),
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
 }
+
+// fsClient connects to AFC for the selected device, rooted at either the
+// media partition or the container of the --app bundle ID
+func fsClient(cmd *cobra.Command) (*afc.Client, error) {
+	udid, _ := cmd.Flags().GetString("udid")
+	bundleID, _ := cmd.Flags().GetString("app")
+	documents, _ := cmd.Flags().GetBool("documents")
+
+	if len(udid) == 0 {
+		dev, err := utils.PickDevice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick USB connected devices: %w", err)
+		}
+		udid = dev.UniqueDeviceID
+	}
+
+	if len(bundleID) == 0 {
+		if documents {
+			return nil, fmt.Errorf("--documents requires --app")
+		}
+		cli, err := afc.NewClient(udid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to afc: %w", err)
+		}
+		return cli, nil
+	}
+
+	if documents {
+		cli, err := housearrest.NewClient(udid, bundleID, housearrest.VendDocuments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to house_arrest: %w", err)
+		}
+		return cli, nil
+	}
+	cli, vended, err := housearrest.Open(udid, bundleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to house_arrest: %w", err)
+	}
+	if vended == housearrest.VendDocuments {
+		log.WithField("app", bundleID).Warn("Only the app's Documents folder is accessible (it is not development signed)")
+	}
+	return cli, nil
+}
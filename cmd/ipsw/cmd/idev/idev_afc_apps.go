@@ -0,0 +1,109 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/housearrest"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AfcCmd.AddCommand(idevAfcAppsCmd)
+
+	idevAfcAppsCmd.Flags().Bool("all", false, "Include apps whose containers are not accessible")
+	idevAfcAppsCmd.Flags().BoolP("json", "j", false, "Display apps as JSON")
+	viper.BindPFlag("idev.afc.apps.all", idevAfcAppsCmd.Flags().Lookup("all"))
+	viper.BindPFlag("idev.afc.apps.json", idevAfcAppsCmd.Flags().Lookup("json"))
+}
+
+// idevAfcAppsCmd represents the afc apps command
+var idevAfcAppsCmd = &cobra.Command{
+	Use:           "apps",
+	Short:         "List apps with accessible containers",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		containers, err := housearrest.Containers(udid)
+		if err != nil {
+			return err
+		}
+		var accessible []housearrest.Container
+		for _, c := range containers {
+			if viper.GetBool("idev.afc.apps.all") || len(c.Vend()) > 0 {
+				accessible = append(accessible, c)
+			}
+		}
+
+		if viper.GetBool("idev.afc.apps.json") {
+			dat, err := json.Marshal(accessible)
+			if err != nil {
+				return fmt.Errorf("failed to marshal apps to JSON: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(accessible) == 0 {
+			log.Warn("no apps with accessible containers (install a development signed or file sharing enabled app)")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BUNDLE ID\tNAME\tVERSION\tACCESS")
+		for _, c := range accessible {
+			access := "-"
+			switch c.Vend() {
+			case housearrest.VendContainer:
+				access = "container"
+			case housearrest.VendDocuments:
+				access = "documents"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.BundleID, c.Name, c.Version, access)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/housearrest"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AfcCmd.AddCommand(idevAfcExtractCmd)
+
+	idevAfcExtractCmd.Flags().StringP("output", "o", "", "Folder to extract the container to (default: ./<BUNDLE_ID>)")
+	idevAfcExtractCmd.Flags().StringSlice("dirs", housearrest.ContainerDirs, "Container folders to extract")
+	idevAfcExtractCmd.MarkFlagDirname("output")
+	viper.BindPFlag("idev.afc.extract.output", idevAfcExtractCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.afc.extract.dirs", idevAfcExtractCmd.Flags().Lookup("dirs"))
+}
+
+// idevAfcExtractCmd represents the afc extract command
+var idevAfcExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Extract an app's container",
+	Example: heredoc.Doc(`
+		# Extract a development build's Documents, Library and tmp folders
+		❯ ipsw idev afc extract --app com.example.app -o ./container
+		# Only extract the Library folder
+		❯ ipsw idev afc extract --app com.example.app --dirs Library`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		bundleID, _ := cmd.Flags().GetString("app")
+		output := viper.GetString("idev.afc.extract.output")
+		dirs := viper.GetStringSlice("idev.afc.extract.dirs")
+
+		if len(bundleID) == 0 {
+			return fmt.Errorf("--app is required")
+		}
+		if len(output) == 0 {
+			output = bundleID
+		}
+
+		cli, err := fsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		if err := os.MkdirAll(output, 0o750); err != nil {
+			return fmt.Errorf("failed to create output folder: %w", err)
+		}
+
+		var extracted int
+		for _, dir := range dirs {
+			if _, err := cli.GetFileInfo("/" + dir); err != nil {
+				// only Documents is accessible for file sharing (non-development) apps
+				log.WithField("dir", dir).Debug("not found in vended container")
+				continue
+			}
+			if err := cli.CopyFromDevice(output, "/"+dir, func(dst, src string, info os.FileInfo) {
+				log.WithField("file", src).Debug("Extracting")
+			}); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", dir, err)
+			}
+			extracted++
+		}
+		if extracted == 0 {
+			return fmt.Errorf("none of %v found in %s's container", dirs, bundleID)
+		}
+
+		abs, _ := filepath.Abs(output)
+		log.WithField("path", abs).Info("Extracted container")
+		return nil
+	},
+}
@@ -23,10 +23,12 @@ package idev
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"text/tabwriter"
 
 	"github.com/apex/log"
-	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -35,6 +37,7 @@ import (
 func init() {
 	AfcCmd.AddCommand(idevAfcLsCmd)
 
+	idevAfcLsCmd.Flags().BoolP("long", "l", false, "Use a long listing format")
 }
 
 // idevAfcLsCmd represents the ls command
@@ -51,19 +54,11 @@ var idevAfcLsCmd = &cobra.Command{
 		}
 		color.NoColor = viper.GetBool("no-color")
 
-		udid, _ := cmd.Flags().GetString("udid")
+		long, _ := cmd.Flags().GetBool("long")
 
-		if len(udid) == 0 {
-			dev, err := utils.PickDevice()
-			if err != nil {
-				return fmt.Errorf("failed to pick USB connected devices: %w", err)
-			}
-			udid = dev.UniqueDeviceID
-		}
-
-		cli, err := afc.NewClient(udid)
+		cli, err := fsClient(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to connect to afc: %w", err)
+			return err
 		}
 		defer cli.Close()
 
@@ -78,12 +73,29 @@ var idevAfcLsCmd = &cobra.Command{
 			return err
 		}
 
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		for _, f := range fs {
 			if f == "." || f == ".." {
 				continue
 			}
-			fmt.Println(f)
+			if !long {
+				fmt.Println(f)
+				continue
+			}
+			info, err := cli.GetFileInfo(path.Join(fpath, f))
+			if err != nil {
+				log.WithError(err).Warnf("failed to stat %s", f)
+				continue
+			}
+			if info.IsDir() {
+				f = color.New(color.Bold, color.FgBlue).Sprint(f + "/")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n",
+				humanize.Bytes(uint64(info.Size())),
+				info.ModTime().Format("Jan _2 15:04"),
+				f)
 		}
+		w.Flush()
 
 		return nil
 	},
@@ -0,0 +1,93 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AfcCmd.AddCommand(idevAfcMountCmd)
+
+	idevAfcMountCmd.Flags().StringP("root", "r", "/", "Remote directory to mount")
+	idevAfcMountCmd.Flags().Bool("read-only", false, "Mount read-only")
+}
+
+// idevAfcMountCmd represents the mount command
+var idevAfcMountCmd = &cobra.Command{
+	Use:   "mount <MOUNTPOINT>",
+	Short: "Mount the media partition or an app container with FUSE",
+	Long: heredoc.Doc(`
+		Mount the media partition or an app container with FUSE so that it can be
+		browsed with normal shell tools. Unmount with Ctrl+C.
+
+		NOTE: requires macFUSE on macOS or fuse/fusermount on Linux.`),
+	Example: heredoc.Doc(`
+		# Mount the media partition
+		❯ ipsw idev afc mount /tmp/media
+		# Mount an app's data container read-only
+		❯ ipsw idev afc mount --app com.example.app --read-only /tmp/app`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		root, _ := cmd.Flags().GetString("root")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+
+		if err := os.MkdirAll(args[0], 0755); err != nil {
+			return fmt.Errorf("failed to create mountpoint %s: %w", args[0], err)
+		}
+
+		cli, err := fsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.Infof("Mounted at %s (press Ctrl+C to unmount)", args[0])
+		if err := cli.Mount(ctx, args[0], root, readOnly); err != nil {
+			return err
+		}
+		log.Info("Unmounted")
+
+		return nil
+	},
+}
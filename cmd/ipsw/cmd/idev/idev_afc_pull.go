@@ -26,8 +26,6 @@ import (
 	"os"
 
 	"github.com/apex/log"
-	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/blacktop/ipsw/pkg/usb/afc"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -52,19 +50,9 @@ var idevAfcPullCmd = &cobra.Command{
 		}
 		color.NoColor = viper.GetBool("no-color")
 
-		udid, _ := cmd.Flags().GetString("udid")
-
-		if len(udid) == 0 {
-			dev, err := utils.PickDevice()
-			if err != nil {
-				return fmt.Errorf("failed to pick USB connected devices: %w", err)
-			}
-			udid = dev.UniqueDeviceID
-		}
-
-		cli, err := afc.NewClient(udid)
+		cli, err := fsClient(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to connect to afc: %w", err)
+			return err
 		}
 		defer cli.Close()
 
@@ -26,8 +26,6 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/apex/log"
-	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/blacktop/ipsw/pkg/usb/afc"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -37,6 +35,7 @@ func init() {
 	AfcCmd.AddCommand(idevAfcRmCmd)
 
 	idevAfcRmCmd.Flags().BoolP("recursive", "r", false, "recursive delete")
+	idevAfcRmCmd.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
 }
 
 // idevAfcRmCmd represents the rm command
@@ -53,28 +52,22 @@ var idevAfcRmCmd = &cobra.Command{
 		}
 		color.NoColor = viper.GetBool("no-color")
 
-		udid, _ := cmd.Flags().GetString("udid")
 		recursive, _ := cmd.Flags().GetBool("recursive")
+		force, _ := cmd.Flags().GetBool("force")
 
-		if len(udid) == 0 {
-			dev, err := utils.PickDevice()
-			if err != nil {
-				return fmt.Errorf("failed to pick USB connected devices: %w", err)
-			}
-			udid = dev.UniqueDeviceID
-		}
-
-		cli, err := afc.NewClient(udid)
+		cli, err := fsClient(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to connect to afc: %w", err)
+			return err
 		}
 		defer cli.Close()
 
-		yes := false
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("Are you sure you want to delete '%s'?", args[0]),
+		yes := force
+		if !force {
+			prompt := &survey.Confirm{
+				Message: fmt.Sprintf("Are you sure you want to delete '%s'?", args[0]),
+			}
+			survey.AskOne(prompt, &yes)
 		}
-		survey.AskOne(prompt, &yes)
 
 		if yes {
 			if recursive {
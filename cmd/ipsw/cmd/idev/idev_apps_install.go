@@ -37,6 +37,37 @@ import (
 
 func init() {
 	AppsCmd.AddCommand(idevAppsInstallCmd)
+
+	idevAppsInstallCmd.Flags().Bool("skip-checks", false, "Skip the provisioning profile checks")
+	viper.BindPFlag("idev.apps.install.skip-checks", idevAppsInstallCmd.Flags().Lookup("skip-checks"))
+}
+
+// checkProvisioning checks that the IPA's embedded provisioning profile can be installed on the device
+func checkProvisioning(ipaPath, udid string) error {
+	prof, err := apps.ProvisioningProfileFromIPA(ipaPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s provisioning profile: %w", ipaPath, err)
+	}
+	if prof == nil {
+		log.Debug("IPA has no embedded provisioning profile")
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"name":    prof.Name,
+		"team":    prof.TeamName,
+		"expires": prof.ExpirationDate.Format("02Jan2006"),
+	}).Debug("Provisioning Profile")
+	if err := prof.Check(udid); err != nil {
+		return err
+	}
+	if prof.IsDevelopment() {
+		if ok, err := utils.IsDeveloperModeEnabled(udid); !ok && err == nil {
+			return fmt.Errorf("you must enable Developer Mode in your device Settings app to install development signed apps")
+		} else if err != nil {
+			return fmt.Errorf("failed to check if developer mode is enabled for device %s: %w", udid, err)
+		}
+	}
+	return nil
 }
 
 // idevAppsInstallCmd represents the install command
@@ -64,6 +95,12 @@ var idevAppsInstallCmd = &cobra.Command{
 			udid = dev.UniqueDeviceID
 		}
 
+		if !viper.GetBool("idev.apps.install.skip-checks") {
+			if err := checkProvisioning(ipaPath, udid); err != nil {
+				return fmt.Errorf("provisioning check failed (use --skip-checks to install anyway): %w", err)
+			}
+		}
+
 		cli, err := apps.NewClient(udid)
 		if err != nil {
 			return fmt.Errorf("failed to connect to apps client: %w", err)
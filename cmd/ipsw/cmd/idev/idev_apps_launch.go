@@ -0,0 +1,130 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/apps"
+	"github.com/blacktop/ipsw/pkg/usb/debugserver"
+	"github.com/caarlos0/ctrlc"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AppsCmd.AddCommand(idevAppsLaunchCmd)
+
+	idevAppsLaunchCmd.Flags().StringArrayP("env", "e", []string{}, "Environment variable to launch the app with (KEY=VALUE)")
+	idevAppsLaunchCmd.Flags().BoolP("attach", "a", false, "Stay attached and stream the app's stdout (the app is killed on exit)")
+	viper.BindPFlag("idev.apps.launch.env", idevAppsLaunchCmd.Flags().Lookup("env"))
+	viper.BindPFlag("idev.apps.launch.attach", idevAppsLaunchCmd.Flags().Lookup("attach"))
+}
+
+// idevAppsLaunchCmd represents the launch command
+var idevAppsLaunchCmd = &cobra.Command{
+	Use:   "launch <BUNDLE_ID> [ARGS...]",
+	Short: "Launch an application",
+	Long: heredoc.Doc(`
+		Launch an application with the debugserver service.
+
+		NOTE: requires Developer Mode and a mounted Developer Disk Image (see 'ipsw idev img ddi').
+		iOS17+ devices only expose debugserver over a RemoteXPC tunnel which is not supported yet.`),
+	Example: heredoc.Doc(`
+		# Launch Safari
+		❯ ipsw idev apps launch com.apple.mobilesafari
+		# Launch an app with arguments and environment variables and stream its stdout
+		❯ ipsw idev apps launch --attach -e OS_ACTIVITY_DT_MODE=enable com.example.app -- -verbose`),
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		if ok, err := utils.IsDeveloperModeEnabled(udid); !ok && err == nil {
+			return fmt.Errorf("you must enable Developer Mode in your device Settings app for %s", udid)
+		} else if err != nil {
+			return fmt.Errorf("failed to check if developer mode is enabled for device %s: %w", udid, err)
+		}
+		if err := utils.IsDeveloperImageMounted(udid); err != nil {
+			return fmt.Errorf("for device %s: ensure Developer Mode is enabled on iOS16+ AND %w", udid, err)
+		}
+
+		cli, err := apps.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to apps client: %w", err)
+		}
+		exePath, err := cli.LookupExePath(args[0])
+		cli.Close()
+		if err != nil {
+			return fmt.Errorf("failed to lookup %s executable: %w", args[0], err)
+		}
+
+		proc, err := debugserver.NewProcess(udid, append([]string{exePath}, args[1:]...), viper.GetStringSlice("idev.apps.launch.env"))
+		if err != nil {
+			return fmt.Errorf("failed to connect to debugserver: %w", err)
+		}
+
+		log.WithField("path", exePath).Infof("Launching %s", args[0])
+		if err := proc.Start(); err != nil {
+			return err
+		}
+
+		if !viper.GetBool("idev.apps.launch.attach") {
+			return proc.Detach()
+		}
+
+		if err := ctrlc.Default.Run(context.Background(), func() error {
+			_, err := io.Copy(os.Stdout, proc.Stdout())
+			return err
+		}); err != nil {
+			if errors.As(err, &ctrlc.ErrorCtrlC{}) {
+				log.Warn("Exiting...")
+			} else {
+				return err
+			}
+		}
+		return proc.Kill()
+	},
+}
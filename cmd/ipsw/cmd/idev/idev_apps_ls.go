@@ -46,6 +46,7 @@ func init() {
 // idevAppsListCmd represents the list command
 var idevAppsListCmd = &cobra.Command{
 	Use:           "ls",
+	Aliases:       []string{"list"},
 	Short:         "List installed applications",
 	SilenceUsage:  true,
 	SilenceErrors: true,
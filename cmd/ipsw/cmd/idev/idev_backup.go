@@ -22,7 +22,13 @@ THE SOFTWARE.
 package idev
 
 import (
+	"sync/atomic"
+
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
 )
 
 func init() {
@@ -30,13 +36,40 @@ func init() {
 }
 
 // BackupCmd represents the backup command
-var BackupCmd = &cobra.Command{ // FIXME: implement backup command
+var BackupCmd = &cobra.Command{
 	Use:     "backup",
 	Aliases: []string{"bk"},
 	Short:   "Backup commands",
 	Args:    cobra.NoArgs,
-	Hidden:  true,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
 }
+
+// newBackupProgress creates a progress bar for the device's backup/restore progress
+func newBackupProgress(name string) (*mpb.Progress, *mpb.Bar, func(*backup.Progress)) {
+	var transferred atomic.Int64
+	p := mpb.New(mpb.WithWidth(80))
+	bar := p.New(100,
+		mpb.BarStyle().Lbound("[").Filler("=").Tip(">").Padding("-").Rbound("|"),
+		mpb.PrependDecorators(
+			decor.Name(name, decor.WC{W: len(name) + 1, C: decor.DindentRight | decor.DextraSpace}),
+			decor.OnComplete(
+				decor.AverageETA(decor.ET_STYLE_GO, decor.WC{W: 4}), "✅ ",
+			),
+		),
+		mpb.AppendDecorators(
+			decor.Percentage(),
+			decor.Name(" ] "),
+			decor.Any(func(decor.Statistics) string {
+				return humanize.Bytes(uint64(transferred.Load()))
+			}),
+		),
+	)
+	return p, bar, func(prog *backup.Progress) {
+		transferred.Store(prog.Bytes)
+		if prog.Percent < 100 { // the bar is completed once the device reports the result
+			bar.SetCurrent(int64(prog.Percent))
+		}
+	}
+}
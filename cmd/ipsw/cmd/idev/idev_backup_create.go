@@ -23,35 +23,120 @@ package idev
 
 import (
 	"fmt"
+	"path/filepath"
 
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// backupCreateCmd represents the create command
-var backupCreateCmd = &cobra.Command{
-	Use:   "create",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("create called")
-	},
+func init() {
+	BackupCmd.AddCommand(idevBackupCreateCmd)
+
+	idevBackupCreateCmd.Flags().StringP("output", "o", "", "Folder to save the backup in (the backup is saved in <FOLDER>/<UDID>)")
+	idevBackupCreateCmd.Flags().BoolP("full", "f", false, "Force a full backup (instead of updating the previous backup)")
+	idevBackupCreateCmd.Flags().StringP("password", "p", "", "Enable backup encryption with this password (if not already enabled)")
+	idevBackupCreateCmd.MarkFlagDirname("output")
+
+	viper.BindPFlag("idev.backup.create.output", idevBackupCreateCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.backup.create.full", idevBackupCreateCmd.Flags().Lookup("full"))
+	viper.BindPFlag("idev.backup.create.password", idevBackupCreateCmd.Flags().Lookup("password"))
 }
 
-func init() {
-	BackupCmd.AddCommand(backupCreateCmd)
+// idevBackupCreateCmd represents the create command
+var idevBackupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a device backup",
+	Long: heredoc.Doc(`
+		Create a device backup with the mobilebackup2 protocol (the same backup Finder/iTunes create).
+		If the folder already has a backup of the device it is updated incrementally.`),
+	Example: heredoc.Doc(`
+		# Backup the connected device to ./<UDID>
+		❯ ipsw idev backup create
+		# Create an encrypted full backup
+		❯ ipsw idev backup create --full --password 'p@ssw0rd' --output /tmp/backups`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-	// Here you will define your flags and configuration settings.
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// createCmd.PersistentFlags().String("foo", "", "A help for foo")
+		udid, _ := cmd.Flags().GetString("udid")
+		output := viper.GetString("idev.backup.create.output")
+		password := viper.GetString("idev.backup.create.password")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// createCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		if len(password) > 0 {
+			ldc, err := lockdownd.NewClient(udid)
+			if err != nil {
+				return fmt.Errorf("failed to connect to lockdownd: %w", err)
+			}
+			willEncrypt, err := ldc.GetValue("com.apple.mobile.backup", "WillEncrypt")
+			ldc.Close()
+			if err != nil {
+				return fmt.Errorf("failed to get backup encryption status: %w", err)
+			}
+			if enabled, _ := willEncrypt.(bool); enabled {
+				log.Info("Backup encryption is already enabled (using the device's backup password)")
+			} else {
+				cli, err := backup.NewClient(udid)
+				if err != nil {
+					return fmt.Errorf("failed to connect to backup service: %w", err)
+				}
+				log.Warn("Enabling backup encryption (enter the passcode on the device if prompted)")
+				err = cli.ChangePassword("", password)
+				cli.Close()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		cli, err := backup.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to backup service: %w", err)
+		}
+		defer cli.Close()
+
+		p, bar, progress := newBackupProgress("Backing up")
+		var transferred int64
+		if err := cli.Backup(&backup.Config{
+			Directory: output,
+			Full:      viper.GetBool("idev.backup.create.full"),
+			Progress: func(prog *backup.Progress) {
+				transferred = prog.Bytes
+				progress(prog)
+			},
+		}); err != nil {
+			bar.Abort(false)
+			p.Wait()
+			return err
+		}
+		bar.SetCurrent(100)
+		p.Wait()
+
+		log.WithFields(log.Fields{
+			"folder":      filepath.Join(output, udid),
+			"transferred": humanize.Bytes(uint64(transferred)),
+		}).Info("Backup complete")
+
+		return nil
+	},
 }
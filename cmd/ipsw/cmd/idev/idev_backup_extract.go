@@ -23,35 +23,95 @@ package idev
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
 
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// backupExtractCmd represents the extract command
-var backupExtractCmd = &cobra.Command{
-	Use:   "extract",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("extract called")
-	},
+func init() {
+	BackupCmd.AddCommand(idevBackupExtractCmd)
+
+	idevBackupExtractCmd.Flags().StringP("domain", "d", "", "Only extract the files of this domain (i.e. HomeDomain or AppDomain-com.apple.mobilesafari)")
+	idevBackupExtractCmd.Flags().StringP("pattern", "p", "", "Only extract the files whose path matches regex")
+	idevBackupExtractCmd.Flags().StringP("output", "o", "", "Output folder")
+	idevBackupExtractCmd.MarkFlagDirname("output")
+	viper.BindPFlag("idev.backup.extract.domain", idevBackupExtractCmd.Flags().Lookup("domain"))
+	viper.BindPFlag("idev.backup.extract.pattern", idevBackupExtractCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("idev.backup.extract.output", idevBackupExtractCmd.Flags().Lookup("output"))
 }
 
-func init() {
-	BackupCmd.AddCommand(backupExtractCmd)
+// idevBackupExtractCmd represents the extract command
+var idevBackupExtractCmd = &cobra.Command{
+	Use:   "extract <BACKUP>",
+	Short: "Extract the files of a device backup",
+	Long: heredoc.Doc(`
+		Extract the files of a device backup into <OUTPUT>/<DOMAIN>/<PATH>.
+
+		NOTE: encrypted backups are not supported.`),
+	Example: heredoc.Doc(`
+		# Extract the Safari files of a backup
+		❯ ipsw idev backup extract /tmp/backups/00008030-001A2B3C4D5E6F --domain AppDomain-com.apple.mobilesafari -o safari
+		# Extract the sqlite databases of a backup
+		❯ ipsw idev backup extract /tmp/backups/00008030-001A2B3C4D5E6F --pattern '\.(db|sqlite)$' -o dbs`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-	// Here you will define your flags and configuration settings.
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// extractCmd.PersistentFlags().String("foo", "", "A help for foo")
+		folder := filepath.Clean(args[0])
+		domain := viper.GetString("idev.backup.extract.domain")
+		output := viper.GetString("idev.backup.extract.output")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// extractCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		var re *regexp.Regexp
+		if pattern := viper.GetString("idev.backup.extract.pattern"); len(pattern) > 0 {
+			var err error
+			re, err = regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("failed to compile regex pattern '%s': %v", pattern, err)
+			}
+		}
+
+		files, err := backup.ReadFiles(folder)
+		if err != nil {
+			return err
+		}
+
+		var extracted int
+		for _, f := range files {
+			if !f.IsFile() {
+				continue
+			}
+			if len(domain) > 0 && f.Domain != domain {
+				continue
+			}
+			if re != nil && !re.MatchString(f.RelativePath) {
+				continue
+			}
+			fname := filepath.Join(output, f.Domain, filepath.Clean("/"+f.RelativePath))
+			if err := backup.ExtractFile(folder, f, fname); err != nil {
+				return err
+			}
+			utils.Indent(log.Debug, 2)("Created " + fname)
+			extracted++
+		}
+		if extracted == 0 {
+			log.Warn("No files matched")
+			return nil
+		}
+
+		log.WithField("folder", filepath.Clean(output)).Infof("Extracted %d files", extracted)
+		return nil
+	},
 }
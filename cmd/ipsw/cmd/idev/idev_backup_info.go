@@ -22,36 +22,82 @@ THE SOFTWARE.
 package idev
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// backupInfoCmd represents the info command
-var backupInfoCmd = &cobra.Command{
-	Use:   "info",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("info called")
-	},
+func init() {
+	BackupCmd.AddCommand(idevBackupInfoCmd)
+
+	idevBackupInfoCmd.Flags().BoolP("json", "j", false, "Display backup info as JSON")
+	viper.BindPFlag("idev.backup.info.json", idevBackupInfoCmd.Flags().Lookup("json"))
 }
 
-func init() {
-	BackupCmd.AddCommand(backupInfoCmd)
+type backupInfo struct {
+	Info     *backup.Info     `json:"info"`
+	Manifest *backup.Manifest `json:"manifest"`
+	Status   *backup.Status   `json:"status,omitempty"`
+}
 
-	// Here you will define your flags and configuration settings.
+// idevBackupInfoCmd represents the info command
+var idevBackupInfoCmd = &cobra.Command{
+	Use:   "info <BACKUP>",
+	Short: "Display a device backup's info",
+	Example: heredoc.Doc(`
+		# Show the info of a backup created with 'ipsw idev backup create -o /tmp/backups'
+		❯ ipsw idev backup info /tmp/backups/00008030-001A2B3C4D5E6F`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// infoCmd.PersistentFlags().String("foo", "", "A help for foo")
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// infoCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		folder := filepath.Clean(args[0])
+
+		var bi backupInfo
+		var err error
+		if bi.Info, err = backup.ReadInfo(folder); err != nil {
+			return err
+		}
+		if bi.Manifest, err = backup.ReadManifest(folder); err != nil {
+			return err
+		}
+		if bi.Status, err = backup.ReadStatus(folder); err != nil {
+			log.WithError(err).Debug("failed to read backup status")
+		}
+
+		if viper.GetBool("idev.backup.info.json") {
+			dat, err := json.Marshal(bi)
+			if err != nil {
+				return fmt.Errorf("failed to marshal backup info to JSON: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		colorField := color.New(color.Faint, color.FgHiBlue).SprintFunc()
+		fmt.Printf("%s %s\n", colorField("Device:     "), bi.Info.DeviceName)
+		fmt.Printf("%s %s (%s %s)\n", colorField("ProductType:"), bi.Info.ProductType, bi.Info.ProductVersion, bi.Info.BuildVersion)
+		fmt.Printf("%s %s\n", colorField("UDID:       "), bi.Info.TargetIdentifier)
+		fmt.Printf("%s %s\n", colorField("Serial:     "), bi.Info.SerialNumber)
+		fmt.Printf("%s %t\n", colorField("Encrypted:  "), bi.Manifest.IsEncrypted)
+		fmt.Printf("%s %s\n", colorField("LastBackup: "), bi.Info.LastBackupDate.Local().Format(time.DateTime))
+		if bi.Status != nil {
+			fmt.Printf("%s %s (full: %t)\n", colorField("State:      "), bi.Status.BackupState, bi.Status.IsFullBackup)
+		}
+		return nil
+	},
 }
@@ -22,36 +22,132 @@ THE SOFTWARE.
 package idev
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// backupLsCmd represents the ls command
-var backupLsCmd = &cobra.Command{
-	Use:   "ls",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("ls called")
-	},
+func init() {
+	BackupCmd.AddCommand(idevBackupListCmd)
+
+	idevBackupListCmd.Flags().BoolP("files", "f", false, "List the files in the backup (FOLDER is a backup)")
+	idevBackupListCmd.Flags().BoolP("json", "j", false, "Display as JSON")
+	viper.BindPFlag("idev.backup.ls.files", idevBackupListCmd.Flags().Lookup("files"))
+	viper.BindPFlag("idev.backup.ls.json", idevBackupListCmd.Flags().Lookup("json"))
 }
 
-func init() {
-	BackupCmd.AddCommand(backupLsCmd)
+type backupEntry struct {
+	UDID       string    `json:"udid"`
+	DeviceName string    `json:"device_name"`
+	Product    string    `json:"product_type"`
+	Version    string    `json:"version"`
+	Build      string    `json:"build"`
+	Encrypted  bool      `json:"encrypted"`
+	Date       time.Time `json:"date"`
+}
 
-	// Here you will define your flags and configuration settings.
+// idevBackupListCmd represents the backup ls command
+var idevBackupListCmd = &cobra.Command{
+	Use:   "ls [FOLDER]",
+	Short: "List the device backups in a folder (or the files in a backup)",
+	Example: heredoc.Doc(`
+		# List the backups created with 'ipsw idev backup create -o /tmp/backups'
+		❯ ipsw idev backup ls /tmp/backups
+		# List the files in an (unencrypted) backup
+		❯ ipsw idev backup ls --files /tmp/backups/00008030-001A2B3C4D5E6F`),
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// lsCmd.PersistentFlags().String("foo", "", "A help for foo")
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// lsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		folder := "."
+		if len(args) > 0 {
+			folder = filepath.Clean(args[0])
+		}
+		asJSON := viper.GetBool("idev.backup.ls.json")
+
+		if viper.GetBool("idev.backup.ls.files") {
+			files, err := backup.ReadFiles(folder)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				dat, err := json.Marshal(files)
+				if err != nil {
+					return fmt.Errorf("failed to marshal backup files to JSON: %w", err)
+				}
+				fmt.Println(string(dat))
+				return nil
+			}
+			for _, f := range files {
+				if f.IsFile() {
+					fmt.Printf("%s-%s\n", f.Domain, f.RelativePath)
+				}
+			}
+			return nil
+		}
+
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			return fmt.Errorf("failed to read folder %s: %w", folder, err)
+		}
+		var backups []backupEntry
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			info, err := backup.ReadInfo(filepath.Join(folder, entry.Name()))
+			if err != nil {
+				log.WithError(err).Debugf("skipping %s", entry.Name())
+				continue
+			}
+			be := backupEntry{
+				UDID:       entry.Name(),
+				DeviceName: info.DeviceName,
+				Product:    info.ProductType,
+				Version:    info.ProductVersion,
+				Build:      info.BuildVersion,
+				Date:       info.LastBackupDate,
+			}
+			if manifest, err := backup.ReadManifest(filepath.Join(folder, entry.Name())); err == nil {
+				be.Encrypted = manifest.IsEncrypted
+			}
+			backups = append(backups, be)
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(backups)
+			if err != nil {
+				return fmt.Errorf("failed to marshal backups to JSON: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(backups) == 0 {
+			log.Warnf("no backups found in %s", folder)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UDID\tDEVICE\tPRODUCT\tVERSION\tENCRYPTED\tDATE")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s (%s)\t%t\t%s\n", b.UDID, b.DeviceName, b.Product, b.Version, b.Build, b.Encrypted, b.Date.Local().Format(time.DateTime))
+		}
+		return w.Flush()
+	},
 }
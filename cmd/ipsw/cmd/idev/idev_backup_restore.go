@@ -23,35 +23,153 @@ package idev
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/backup"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// backupRestoreCmd represents the restore command
-var backupRestoreCmd = &cobra.Command{
-	Use:   "restore",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("restore called")
-	},
+func init() {
+	BackupCmd.AddCommand(idevBackupRestoreCmd)
+
+	idevBackupRestoreCmd.Flags().StringP("backup", "b", "", "Folder with the backup to restore (the backup is read from <FOLDER>/<SOURCE>)")
+	idevBackupRestoreCmd.Flags().StringP("source", "s", "", "UDID of the backup to restore (default: the device's UDID)")
+	idevBackupRestoreCmd.Flags().StringP("password", "p", "", "Encrypted backup password")
+	idevBackupRestoreCmd.Flags().Bool("system", false, "Restore system files")
+	idevBackupRestoreCmd.Flags().Bool("settings", false, "Restore the device settings")
+	idevBackupRestoreCmd.Flags().Bool("remove", false, "Remove the items that are not in the backup")
+	idevBackupRestoreCmd.Flags().Bool("no-reboot", false, "Do not reboot the device when done")
+	idevBackupRestoreCmd.Flags().Bool("no-copy", false, "Do not copy the backup before restoring it")
+	idevBackupRestoreCmd.Flags().BoolP("yes", "y", false, "Do not prompt for confirmation")
+	idevBackupRestoreCmd.MarkFlagDirname("backup")
+
+	viper.BindPFlag("idev.backup.restore.backup", idevBackupRestoreCmd.Flags().Lookup("backup"))
+	viper.BindPFlag("idev.backup.restore.source", idevBackupRestoreCmd.Flags().Lookup("source"))
+	viper.BindPFlag("idev.backup.restore.password", idevBackupRestoreCmd.Flags().Lookup("password"))
+	viper.BindPFlag("idev.backup.restore.system", idevBackupRestoreCmd.Flags().Lookup("system"))
+	viper.BindPFlag("idev.backup.restore.settings", idevBackupRestoreCmd.Flags().Lookup("settings"))
+	viper.BindPFlag("idev.backup.restore.remove", idevBackupRestoreCmd.Flags().Lookup("remove"))
+	viper.BindPFlag("idev.backup.restore.no-reboot", idevBackupRestoreCmd.Flags().Lookup("no-reboot"))
+	viper.BindPFlag("idev.backup.restore.no-copy", idevBackupRestoreCmd.Flags().Lookup("no-copy"))
+	viper.BindPFlag("idev.backup.restore.yes", idevBackupRestoreCmd.Flags().Lookup("yes"))
 }
 
-func init() {
-	BackupCmd.AddCommand(backupRestoreCmd)
+// idevBackupRestoreCmd represents the restore command
+var idevBackupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a device backup",
+	Long: heredoc.Doc(`
+		Restore a backup created with 'ipsw idev backup create' (or Finder/iTunes) to the device
+		with the mobilebackup2 protocol.
+
+		NOTE: 'Find My' must be disabled on the device.`),
+	Example: heredoc.Doc(`
+		# Restore the device's backup in ./<UDID>
+		❯ ipsw idev backup restore
+		# Restore another device's encrypted backup (and its settings)
+		❯ ipsw idev backup restore --backup /tmp/backups --source 00008110-XXXXXXXXXXXXXXXX --settings -p 'p@ssw0rd'`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 
-	// Here you will define your flags and configuration settings.
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// restoreCmd.PersistentFlags().String("foo", "", "A help for foo")
+		udid, _ := cmd.Flags().GetString("udid")
+		folder := viper.GetString("idev.backup.restore.backup")
+		source := viper.GetString("idev.backup.restore.source")
+		password := viper.GetString("idev.backup.restore.password")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// restoreCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+		if len(source) == 0 {
+			source = udid
+		}
+
+		manifest, err := backup.ReadManifest(filepath.Join(folder, source))
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		log.WithFields(log.Fields{
+			"device":  manifest.Lockdown.DeviceName,
+			"type":    manifest.Lockdown.ProductType,
+			"version": fmt.Sprintf("%s (%s)", manifest.Lockdown.ProductVersion, manifest.Lockdown.BuildVersion),
+			"date":    manifest.Date.Local().Format("02Jan2006 15:04:05"),
+		}).Info("Restoring backup")
+
+		if manifest.IsEncrypted && len(password) == 0 {
+			prompt := &survey.Password{
+				Message: "Backup is encrypted, please enter its password:",
+			}
+			if err := survey.AskOne(prompt, &password); err != nil {
+				if err == terminal.InterruptErr {
+					log.Warn("Exiting...")
+					os.Exit(0)
+				}
+				return err
+			}
+		}
+
+		if !viper.GetBool("idev.backup.restore.yes") {
+			yes := false
+			prompt := &survey.Confirm{
+				Message: "Are you sure you want to replace the device's data with the backup?",
+			}
+			if err := survey.AskOne(prompt, &yes); err != nil {
+				if err == terminal.InterruptErr {
+					log.Warn("Exiting...")
+					os.Exit(0)
+				}
+				return err
+			}
+			if !yes {
+				return nil
+			}
+		}
+
+		cli, err := backup.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to backup service: %w", err)
+		}
+		defer cli.Close()
+
+		p, bar, progress := newBackupProgress("Restoring")
+		if err := cli.Restore(&backup.RestoreConfig{
+			Directory:         folder,
+			SourceUDID:        source,
+			Password:          password,
+			System:            viper.GetBool("idev.backup.restore.system"),
+			NoReboot:          viper.GetBool("idev.backup.restore.no-reboot"),
+			NoCopy:            viper.GetBool("idev.backup.restore.no-copy"),
+			Settings:          viper.GetBool("idev.backup.restore.settings"),
+			RemoveNotRestored: viper.GetBool("idev.backup.restore.remove"),
+			Progress:          progress,
+		}); err != nil {
+			bar.Abort(false)
+			p.Wait()
+			return err
+		}
+		bar.SetCurrent(100)
+		p.Wait()
+
+		log.Info("Restore complete")
+
+		return nil
+	},
 }
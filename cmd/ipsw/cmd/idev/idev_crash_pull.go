@@ -26,12 +26,17 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/blacktop/ipsw/pkg/usb/crashlog"
+	"github.com/blacktop/ipsw/pkg/crashlog"
+	"github.com/blacktop/ipsw/pkg/info"
+	crashmover "github.com/blacktop/ipsw/pkg/usb/crashlog"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -43,13 +48,132 @@ func init() {
 	iDevCrashPullCmd.Flags().BoolP("all", "a", false, "Pull all crashlogs")
 	iDevCrashPullCmd.Flags().BoolP("rm", "r", false, "Remove crashlogs after pulling")
 	iDevCrashPullCmd.Flags().StringP("output", "o", "", "Folder to save crashlogs")
+	iDevCrashPullCmd.Flags().BoolP("symbolicate", "s", false, "Symbolicate the pulled crashlogs")
+	iDevCrashPullCmd.Flags().String("server", "", "Symbol Server DB URL (used to symbolicate)")
+	iDevCrashPullCmd.Flags().String("ipsw", "", "IPSW (or folder of downloaded IPSWs) to symbolicate with")
+	iDevCrashPullCmd.Flags().BoolP("demangle", "d", false, "Demangle symbol names")
 	iDevCrashPullCmd.MarkFlagDirname("output")
+	viper.BindPFlag("idev.crash.pull.symbolicate", iDevCrashPullCmd.Flags().Lookup("symbolicate"))
+	viper.BindPFlag("idev.crash.pull.server", iDevCrashPullCmd.Flags().Lookup("server"))
+	viper.BindPFlag("idev.crash.pull.ipsw", iDevCrashPullCmd.Flags().Lookup("ipsw"))
+	viper.BindPFlag("idev.crash.pull.demangle", iDevCrashPullCmd.Flags().Lookup("demangle"))
+}
+
+type symConfig struct {
+	Server   string // Symbol Server DB URL
+	IPSW     string // IPSW or folder of IPSWs
+	Demangle bool
+
+	infos map[string]*info.Info
+}
+
+// matchingIPSW returns the IPSW (in the IPSW folder) that matches the crashlog's device and build
+func (c *symConfig) matchingIPSW(device, version, build string) (string, error) {
+	if len(c.IPSW) == 0 {
+		return "", fmt.Errorf("no IPSW supplied")
+	}
+	if c.infos == nil {
+		c.infos = make(map[string]*info.Info)
+		if err := filepath.WalkDir(c.IPSW, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".ipsw") {
+				return nil
+			}
+			i, err := info.Parse(path)
+			if err != nil {
+				log.WithError(err).Debugf("Failed to parse %s", path)
+				return nil
+			}
+			c.infos[path] = i
+			return nil
+		}); err != nil {
+			return "", fmt.Errorf("failed to find IPSWs in %s: %w", c.IPSW, err)
+		}
+	}
+	for path, i := range c.infos {
+		if i.Plists.BuildManifest.ProductVersion == version &&
+			i.Plists.BuildManifest.ProductBuildVersion == build &&
+			slices.Contains(i.Plists.Restore.SupportedProductTypes, device) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no IPSW found in %s for %s %s (%s)", c.IPSW, device, version, build)
+}
+
+// symbolicateCrashlogs symbolicates the pulled IPS crashlogs and saves each one's output next to it
+func symbolicateCrashlogs(logs []string, conf *symConfig) error {
+	if len(conf.Server) == 0 {
+		conf.Server = viper.GetString("symbolicate.server")
+	}
+	for _, clog := range logs {
+		if !strings.EqualFold(filepath.Ext(clog), ".ips") {
+			continue
+		}
+		hdr, err := crashlog.ParseHeader(clog)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to parse crashlog header %s (skipping)", clog)
+			continue
+		}
+		if hdr.BugType != "210" && hdr.BugType != "309" {
+			log.Debugf("Skipping unsupported crashlog type %s: %s", hdr.BugType, clog)
+			continue
+		}
+		ips, err := crashlog.OpenIPS(clog, &crashlog.Config{
+			All:      true,
+			Demangle: conf.Demangle,
+			Verbose:  viper.GetBool("verbose"),
+		})
+		if err != nil {
+			log.WithError(err).Warnf("Failed to parse %s (skipping)", clog)
+			continue
+		}
+		if len(conf.Server) > 0 {
+			if hdr.BugType == "210" {
+				err = ips.Symbolicate210WithDatabase(conf.Server)
+			} else {
+				err = ips.Symbolicate309WithDatabase(conf.Server)
+			}
+		} else if ipswPath, ierr := conf.matchingIPSW(ips.Payload.Product, ips.Header.Version(), ips.Header.Build()); ierr == nil {
+			if hdr.BugType == "210" {
+				err = ips.Symbolicate210(ipswPath)
+			} else {
+				err = ips.Symbolicate309(ipswPath)
+			}
+		} else if hdr.BugType == "210" {
+			err = ierr
+		} else { // 309 crashlogs are mostly symbolicated by the OS
+			log.WithError(ierr).Warnf("Failed to symbolicate %s (saving the OS symbolicated frames)", clog)
+		}
+		if err != nil {
+			log.WithError(err).Warnf("Failed to symbolicate %s (skipping)", clog)
+			continue
+		}
+		noColor := color.NoColor
+		color.NoColor = true
+		out := ips.String()
+		color.NoColor = noColor
+		fname := strings.TrimSuffix(clog, filepath.Ext(clog)) + ".symbolicated.txt"
+		log.WithField("log", fname).Info("Symbolicated")
+		if err := os.WriteFile(fname, []byte(out), 0o660); err != nil {
+			return fmt.Errorf("failed to write symbolicated crashlog: %w", err)
+		}
+	}
+	return nil
 }
 
 // iDevCrashPullCmd represents the pull command
 var iDevCrashPullCmd = &cobra.Command{
-	Use:           "pull",
-	Short:         "Pull crashlogs",
+	Use:   "pull",
+	Short: "Pull crashlogs",
+	Example: heredoc.Doc(`
+		# Pull all crashlogs
+		❯ ipsw idev crash pull --all
+		# Pull all crashlogs and symbolicate them with the downloaded IPSWs in ~/Downloads
+		❯ ipsw idev crash pull --all --symbolicate --ipsw ~/Downloads
+		# Pull a crashlog and symbolicate it with a Symbol Server
+		❯ ipsw idev crash pull -s --server http://localhost:3993 panic-full-2024-03-21-004704.000.ips`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -83,17 +207,31 @@ var iDevCrashPullCmd = &cobra.Command{
 			ldc.Close()
 		}
 
-		cli, err := crashlog.NewClient(dev.UniqueDeviceID)
+		// move the pending crash reports so they can be pulled
+		if err := crashmover.Flush(dev.UniqueDeviceID); err != nil {
+			log.WithError(err).Warn("Failed to flush crash reports")
+		}
+
+		cli, err := crashmover.NewClient(dev.UniqueDeviceID)
 		if err != nil {
 			return fmt.Errorf("failed to connect to crashlog service: %w", err)
 		}
 		defer cli.Close()
 
+		var pulled []string
 		if allLogs { // pull all crashlogs
 			destPath := filepath.Join(output, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion))
 			if err := cli.CopyFromDevice(destPath, "/", nil); err != nil {
 				return fmt.Errorf("failed to copy all crashlogs from device: %w", err)
 			}
+			if err := filepath.WalkDir(destPath, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					pulled = append(pulled, path)
+				}
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to list pulled crashlogs: %w", err)
+			}
 			if removeLogs {
 				if err := cli.RemoveAll("/"); err != nil {
 					return fmt.Errorf("failed to remove all crashlogs from device: %w", err)
@@ -143,6 +281,7 @@ var iDevCrashPullCmd = &cobra.Command{
 				if err := cli.CopyFileFromDevice(destPath, clog); err != nil {
 					return fmt.Errorf("failed to copy crashlog from device: %w", err)
 				}
+				pulled = append(pulled, destPath)
 				if removeLogs {
 					if err := cli.RemovePath(clog); err != nil {
 						return fmt.Errorf("failed to remove crashlog from device: %w", err)
@@ -160,6 +299,7 @@ var iDevCrashPullCmd = &cobra.Command{
 			if err := cli.CopyFileFromDevice(destPath, args[0]); err != nil {
 				return fmt.Errorf("failed to copy crashlog from device: %w", err)
 			}
+			pulled = append(pulled, destPath)
 			if removeLogs {
 				if err := cli.RemovePath(args[0]); err != nil {
 					return fmt.Errorf("failed to remove crashlog from device: %w", err)
@@ -167,6 +307,14 @@ var iDevCrashPullCmd = &cobra.Command{
 			}
 		}
 
+		if viper.GetBool("idev.crash.pull.symbolicate") {
+			return symbolicateCrashlogs(pulled, &symConfig{
+				Server:   viper.GetString("idev.crash.pull.server"),
+				IPSW:     viper.GetString("idev.crash.pull.ipsw"),
+				Demangle: viper.GetBool("idev.crash.pull.demangle"),
+			})
+		}
+
 		return nil
 	},
 }
@@ -0,0 +1,332 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/apps"
+	"github.com/blacktop/ipsw/pkg/usb/debugserver"
+	"github.com/blacktop/ipsw/pkg/usb/forward"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/mount"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
+	"github.com/fatih/color"
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	IDevCmd.AddCommand(idevDebugCmd)
+
+	idevDebugCmd.Flags().StringArrayP("env", "e", []string{}, "Environment variable to launch the app with (KEY=VALUE)")
+	idevDebugCmd.Flags().IntP("lport", "l", 0, "Local port to forward debugserver to (default: random)")
+	idevDebugCmd.Flags().StringP("sysroot", "s", "", "Device symbols folder (default: matching '~/Library/Developer/Xcode/iOS DeviceSupport' folder)")
+	idevDebugCmd.Flags().StringP("bundle", "b", "", "Local copy of the .app bundle to load symbols from")
+	idevDebugCmd.Flags().StringP("output", "o", "", "Path to write the lldb command file to (default: temp file)")
+	idevDebugCmd.Flags().Bool("lldb", false, "Launch lldb with the generated command file")
+	idevDebugCmd.Flags().StringP("xcode", "x", "", "Path to Xcode.app to mount the DDI from (pre-iOS17)")
+	idevDebugCmd.Flags().String("rsd", "", "RemoteServiceDiscovery address of an iOS17+ device's CoreDevice tunnel (i.e. fd7b:e5b:6f53::1)")
+	idevDebugCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
+	idevDebugCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
+	idevDebugCmd.MarkFlagDirname("sysroot")
+	idevDebugCmd.MarkFlagDirname("bundle")
+	idevDebugCmd.MarkFlagDirname("xcode")
+
+	viper.BindPFlag("idev.debug.env", idevDebugCmd.Flags().Lookup("env"))
+	viper.BindPFlag("idev.debug.lport", idevDebugCmd.Flags().Lookup("lport"))
+	viper.BindPFlag("idev.debug.sysroot", idevDebugCmd.Flags().Lookup("sysroot"))
+	viper.BindPFlag("idev.debug.bundle", idevDebugCmd.Flags().Lookup("bundle"))
+	viper.BindPFlag("idev.debug.output", idevDebugCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.debug.lldb", idevDebugCmd.Flags().Lookup("lldb"))
+	viper.BindPFlag("idev.debug.xcode", idevDebugCmd.Flags().Lookup("xcode"))
+	viper.BindPFlag("idev.debug.rsd", idevDebugCmd.Flags().Lookup("rsd"))
+	viper.BindPFlag("idev.debug.proxy", idevDebugCmd.Flags().Lookup("proxy"))
+	viper.BindPFlag("idev.debug.insecure", idevDebugCmd.Flags().Lookup("insecure"))
+}
+
+// idevDebugCmd represents the debug command
+var idevDebugCmd = &cobra.Command{
+	Use:   "debug <BUNDLE_ID> [ARGS...]",
+	Short: "Launch an app under debugserver and attach lldb",
+	Long: heredoc.Doc(`
+		Mount the DDI (if needed), launch the app stopped at its entry point under
+		debugserver, forward debugserver to a local port and generate an lldb command file
+		that selects the remote-ios platform, sets up the symbol search paths and connects.
+
+		NOTE: requires Developer Mode. iOS17+ devices need a CoreDevice tunnel (--rsd or 'ipsw idev tunnel start' for USB iOS17.4+ devices).`),
+	Example: heredoc.Doc(`
+		# Launch Safari and debug it with lldb
+		❯ ipsw idev debug --lldb com.apple.mobilesafari
+		# Only generate the lldb command file (run 'lldb -s /tmp/app.lldb' yourself)
+		❯ ipsw idev debug --output /tmp/app.lldb --bundle ./Example.app com.example.app -- -verbose
+		# Debug on an iOS17+ device through an already established CoreDevice tunnel
+		❯ ipsw idev debug --rsd fd7b:e5b:6f53::1 --lldb com.example.app`),
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		bundle := viper.GetString("idev.debug.bundle")
+		output := viper.GetString("idev.debug.output")
+
+		dev, rsdClient, err := imgDevice(udid, viper.GetString("idev.debug.rsd"))
+		if err != nil {
+			return err
+		}
+		ver, err := semver.NewVersion(dev.ProductVersion)
+		if err != nil {
+			return fmt.Errorf("failed to convert version into semver object")
+		}
+
+		if rsdClient == nil {
+			if ok, err := utils.IsDeveloperModeEnabled(dev.UniqueDeviceID); !ok && err == nil {
+				return fmt.Errorf("you must enable Developer Mode in your device Settings app for %s", dev.UniqueDeviceID)
+			} else if err != nil {
+				return fmt.Errorf("failed to check if developer mode is enabled for device %s: %w", dev.UniqueDeviceID, err)
+			}
+		}
+
+		if err := ensureDDI(dev, rsdClient, ver); err != nil {
+			return err
+		}
+
+		exePath, err := lookupExePath(dev, rsdClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		dsc, err := debugserverClient(dev, rsdClient, ver)
+		if err != nil {
+			return err
+		}
+		defer dsc.Close()
+
+		proc := debugserver.NewProcessWithClient(dsc, append([]string{exePath}, args[1:]...), viper.GetStringSlice("idev.debug.env"))
+		log.WithField("path", exePath).Infof("Launching %s (stopped at entry)", args[0])
+		if err := proc.Launch(); err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// the launched process lives on this debugserver connection so hand it to the first client only
+		var once sync.Once
+		addr, err := forward.StartDial(ctx, viper.GetInt("idev.debug.lport"), func() (conn net.Conn, err error) {
+			err = fmt.Errorf("debugserver session already in use")
+			once.Do(func() {
+				conn, err = dsc.Conn(), nil
+			})
+			return conn, err
+		}, func(msg string, err error) {
+			if err != nil {
+				log.WithError(err).Warn("debugserver forward")
+			} else {
+				log.Debug(msg)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		log.WithField("addr", addr).Info("Forwarding debugserver")
+
+		sysroot := viper.GetString("idev.debug.sysroot")
+		if len(sysroot) == 0 {
+			sysroot = deviceSupportSymbols(dev)
+		}
+
+		script := lldbCommands(addr, sysroot, bundle, exePath)
+		if len(output) == 0 {
+			f, err := os.CreateTemp("", "ipsw_debug_*.lldb")
+			if err != nil {
+				return fmt.Errorf("failed to create lldb command file: %w", err)
+			}
+			output = f.Name()
+			f.Close()
+			defer os.Remove(output)
+		}
+		if err := os.WriteFile(output, []byte(script), 0644); err != nil {
+			return fmt.Errorf("failed to write lldb command file: %w", err)
+		}
+		log.Debugf("lldb commands:\n%s", script)
+
+		if viper.GetBool("idev.debug.lldb") {
+			lldb := exec.CommandContext(ctx, "lldb", "-s", output)
+			lldb.Stdin = os.Stdin
+			lldb.Stdout = os.Stdout
+			lldb.Stderr = os.Stderr
+			if err := lldb.Run(); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("lldb failed: %w", err)
+			}
+			return nil
+		}
+
+		log.Infof("Run: lldb -s %s (press Ctrl+C to quit)", output)
+		<-ctx.Done()
+
+		return nil
+	},
+}
+
+// ensureDDI mounts the developer disk image if none is mounted yet
+func ensureDDI(dev *lockdownd.DeviceValues, r *rsd.Client, ver *semver.Version) error {
+	cli, err := imageMounter(dev, r)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	images, err := cli.ListImages()
+	if err != nil {
+		return fmt.Errorf("failed to list images: %w", err)
+	}
+	if len(images) > 0 {
+		log.Debug("Developer disk image already mounted")
+		return nil
+	}
+
+	if ver.LessThan(semver.Must(semver.NewVersion("17.0"))) {
+		if len(viper.GetString("idev.debug.xcode")) == 0 {
+			return fmt.Errorf("no developer disk image mounted (use --xcode or 'ipsw idev img mount')")
+		}
+		imgData, sigData, err := xcodeDeveloperDiskImage(viper.GetString("idev.debug.xcode"), ver)
+		if err != nil {
+			return err
+		}
+		log.Infof("Uploading %s image", mount.ImageTypeDeveloper)
+		if err := cli.Upload(mount.ImageTypeDeveloper, imgData, sigData); err != nil {
+			return fmt.Errorf("failed to upload image: %w", err)
+		}
+		log.Infof("Mounting %s image", mount.ImageTypeDeveloper)
+		if err := cli.Mount(mount.ImageTypeDeveloper, sigData, "", ""); err != nil {
+			return fmt.Errorf("failed to mount image: %w", err)
+		}
+		return nil
+	}
+
+	proxy := viper.GetString("idev.debug.proxy")
+	insecure := viper.GetBool("idev.debug.insecure")
+	ddi, err := stageDDI(dev, "", "ios", false, proxy, insecure)
+	if err != nil {
+		return err
+	}
+	return mountPersonalized(cli, ddi.Manifest, ddi.Image, ddi.TrustCache, ddi.BuildManifest, "", proxy, insecure)
+}
+
+func lookupExePath(dev *lockdownd.DeviceValues, r *rsd.Client, bundleID string) (string, error) {
+	var cli *apps.Client
+	var err error
+	if r != nil {
+		cli, err = apps.NewRemoteClient(r)
+	} else {
+		cli, err = apps.NewClient(dev.UniqueDeviceID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to apps client: %w", err)
+	}
+	defer cli.Close()
+
+	exePath, err := cli.LookupExePath(bundleID)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup %s executable: %w", bundleID, err)
+	}
+	return exePath, nil
+}
+
+// debugserverClient connects to the RemoteXPC debugproxy, the iOS14+ SSL debugserver proxy or the classic debugserver
+func debugserverClient(dev *lockdownd.DeviceValues, r *rsd.Client, ver *semver.Version) (*debugserver.Client, error) {
+	if r != nil {
+		cli, err := debugserver.NewRemoteClient(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to debugserver over RemoteXPC: %w", err)
+		}
+		return cli, nil
+	}
+	if !ver.LessThan(semver.Must(semver.NewVersion("14.0"))) {
+		cli, err := debugserver.NewSecureClient(dev.UniqueDeviceID)
+		if err == nil {
+			return cli, nil
+		}
+		log.WithError(err).Debug("failed to connect to secure debugserver proxy (falling back)")
+	}
+	cli, err := debugserver.NewClient(dev.UniqueDeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to debugserver: %w", err)
+	}
+	return cli, nil
+}
+
+// deviceSupportSymbols returns the Xcode 'iOS DeviceSupport' symbols folder for dev (if present)
+func deviceSupportSymbols(dev *lockdownd.DeviceValues) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	// folders are named "<VERSION> (<BUILD>)" or "<PRODUCT_TYPE> <VERSION> (<BUILD>)" by newer Xcodes
+	matches, _ := filepath.Glob(filepath.Join(home, "Library/Developer/Xcode/iOS DeviceSupport",
+		fmt.Sprintf("*%s (%s)*", dev.ProductVersion, dev.BuildVersion), "Symbols"))
+	for _, m := range matches {
+		if strings.Contains(filepath.Base(filepath.Dir(m)), dev.ProductType) {
+			return m
+		}
+	}
+	if len(matches) > 0 {
+		return matches[0]
+	}
+	return ""
+}
+
+func lldbCommands(addr, sysroot, bundle, exePath string) string {
+	var sb strings.Builder
+	if len(sysroot) > 0 {
+		fmt.Fprintf(&sb, "platform select remote-ios --sysroot %q\n", sysroot)
+	} else {
+		sb.WriteString("platform select remote-ios\n")
+	}
+	if len(bundle) > 0 {
+		fmt.Fprintf(&sb, "target create %q\n", bundle)
+		fmt.Fprintf(&sb, "script lldb.target.module[0].SetPlatformFileSpec(lldb.SBFileSpec(%q))\n", exePath)
+		fmt.Fprintf(&sb, "target modules search-paths add %q %q\n", filepath.Dir(exePath), bundle)
+	}
+	fmt.Fprintf(&sb, "process connect connect://%s\n", addr)
+	return sb.String()
+}
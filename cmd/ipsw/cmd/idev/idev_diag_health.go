@@ -0,0 +1,127 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/diagnostics"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	DiagCmd.AddCommand(diagHealthCmd)
+	diagHealthCmd.Flags().BoolP("json", "j", false, "Display device health as JSON")
+}
+
+type deviceHealth struct {
+	UDID         string                     `json:"udid"`
+	ProductType  string                     `json:"product_type"`
+	BuildVersion string                     `json:"build_version"`
+	Battery      *diagnostics.BatteryHealth `json:"battery,omitempty"`
+	Storage      *lockdownd.DiskUsage       `json:"storage,omitempty"`
+}
+
+// diagHealthCmd represents the health command
+var diagHealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Battery health, temperature and storage",
+	Long: heredoc.Doc(`
+		Report the device's battery health (including the battery temperature) and storage usage.`),
+	Example: heredoc.Doc(`
+		# Collect health from every connected device for monitoring
+		❯ ipsw idev diag health --json | jq .battery.health_percent`),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		ldc, err := lockdownd.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer ldc.Close()
+
+		values, err := ldc.GetValues()
+		if err != nil {
+			return fmt.Errorf("failed to get device values: %w", err)
+		}
+		health := deviceHealth{
+			UDID:         udid,
+			ProductType:  values.ProductType,
+			BuildVersion: values.BuildVersion,
+		}
+		if health.Storage, err = ldc.DiskUsage(); err != nil {
+			log.WithError(err).Warn("failed to get storage usage")
+		}
+
+		cli, err := diagnostics.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to diagnostics: %w", err)
+		}
+		defer cli.Close()
+
+		if health.Battery, err = cli.BatteryHealth(); err != nil {
+			log.WithError(err).Warn("failed to get battery health")
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(health)
+			if err != nil {
+				return fmt.Errorf("failed to marshal device health to JSON: %s", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if health.Battery != nil {
+			fmt.Println(health.Battery)
+		}
+		if health.Storage != nil {
+			fmt.Println(health.Storage)
+		}
+
+		return nil
+	},
+}
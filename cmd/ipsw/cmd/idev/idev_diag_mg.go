@@ -24,6 +24,8 @@ package idev
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
@@ -35,8 +37,10 @@ import (
 
 func init() {
 	DiagCmd.AddCommand(idevDiagMobileGestaltCmd)
-	idevDiagMobileGestaltCmd.Flags().StringSliceP("keys", "k", []string{}, "Keys to retrieve (can be csv)")
-	idevDiagMobileGestaltCmd.MarkFlagRequired("keys")
+	idevDiagMobileGestaltCmd.Flags().StringSliceP("keys", "k", []string{}, "Keys to retrieve (can be csv, prefix with ! to send the obfuscated key)")
+	idevDiagMobileGestaltCmd.Flags().BoolP("all", "a", false, "Retrieve all known keys")
+	idevDiagMobileGestaltCmd.Flags().BoolP("list", "l", false, "List the known keys")
+	idevDiagMobileGestaltCmd.MarkFlagsOneRequired("keys", "all", "list")
 }
 
 // idevDiagMobileGestaltCmd represents the mg command
@@ -54,6 +58,22 @@ var idevDiagMobileGestaltCmd = &cobra.Command{
 
 		udid, _ := cmd.Flags().GetString("udid")
 		keys, _ := cmd.Flags().GetStringSlice("keys")
+		all, _ := cmd.Flags().GetBool("all")
+		list, _ := cmd.Flags().GetBool("list")
+
+		if list {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tOBFUSCATED\tDESCRIPTION")
+			for _, k := range diagnostics.GestaltKeys() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", k.Key, k.Obfuscated, k.Description)
+			}
+			return w.Flush()
+		}
+		if all {
+			for _, k := range diagnostics.GestaltKeys() {
+				keys = append(keys, k.Key)
+			}
+		}
 
 		if len(udid) == 0 {
 			dev, err := utils.PickDevice()
@@ -73,6 +93,15 @@ var idevDiagMobileGestaltCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to query MobileGestalt: %w", err)
 		}
+		// replace obfuscated keys in the answer with their names
+		if mg, ok := resp.Diagnostics["MobileGestalt"].(map[string]any); ok {
+			for k, v := range mg {
+				if name := diagnostics.GestaltKeyName(k); name != k {
+					delete(mg, k)
+					mg[name] = v
+				}
+			}
+		}
 
 		mgJSON, err := json.Marshal(resp)
 		if err != nil {
@@ -0,0 +1,124 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/mdns"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	IDevCmd.AddCommand(idevDiscoverCmd)
+
+	idevDiscoverCmd.Flags().Duration("timeout", 5*time.Second, "How long to browse for each service")
+	idevDiscoverCmd.Flags().BoolP("json", "j", false, "Display devices as JSON")
+	viper.BindPFlag("idev.discover.timeout", idevDiscoverCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("idev.discover.json", idevDiscoverCmd.Flags().Lookup("json"))
+}
+
+type discoveredDevice struct {
+	*mdns.Service
+	UDID string `json:"udid,omitempty"`
+}
+
+// idevDiscoverCmd represents the discover command
+var idevDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find devices on the LAN with Bonjour",
+	Long: heredoc.Doc(`
+		Browse the LAN for devices advertising Wi-Fi sync (_apple-mobdev2._tcp) or
+		remote pairing (_remotepairing._tcp, iOS 17+).
+
+		Devices with a saved pair record are shown with their UDID and can be used with 'ipsw idev --wifi'.`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		store, err := pairRecordStore()
+		if err != nil {
+			return err
+		}
+
+		var devices []discoveredDevice
+		for _, service := range []string{mdns.MobileDeviceService, mdns.RemotePairingService} {
+			ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("idev.discover.timeout"))
+			services, err := mdns.Browse(ctx, service)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to browse for %s: %w", service, err)
+			}
+			for _, svc := range services {
+				dev := discoveredDevice{Service: svc}
+				if service == mdns.MobileDeviceService {
+					dev.UDID, _, _ = store.FindByWiFiMAC(wifiMAC(svc))
+				}
+				devices = append(devices, dev)
+			}
+		}
+
+		if viper.GetBool("idev.discover.json") {
+			dat, err := json.Marshal(devices)
+			if err != nil {
+				return fmt.Errorf("failed to marshal devices to JSON: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(devices) == 0 {
+			log.Warn("no devices found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INSTANCE\tSERVICE\tHOST\tADDRESSES\tPORT\tUDID")
+		for _, d := range devices {
+			var addrs []string
+			for _, ip := range d.Addrs {
+				addrs = append(addrs, ip.String())
+			}
+			udid := d.UDID
+			if len(udid) == 0 {
+				udid = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", d.Instance, d.Type, d.Host, strings.Join(addrs, ","), d.Port, udid)
+		}
+		return w.Flush()
+	},
+}
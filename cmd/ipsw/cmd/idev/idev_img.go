@@ -22,11 +22,24 @@ THE SOFTWARE.
 package idev
 
 import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/mount"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
+	"github.com/blacktop/ipsw/pkg/usb/tunnel"
+	semver "github.com/hashicorp/go-version"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
 	IDevCmd.AddCommand(ImgCmd)
+
+	ImgCmd.PersistentFlags().String("rsd", "", "RemoteServiceDiscovery address of an iOS17+ device's CoreDevice tunnel (i.e. fd7b:e5b:6f53::1)")
+	viper.BindPFlag("idev.img.rsd", ImgCmd.PersistentFlags().Lookup("rsd"))
 }
 
 // ImgCmd represents the img command
@@ -38,3 +51,69 @@ var ImgCmd = &cobra.Command{
 		cmd.Help()
 	},
 }
+
+// imgDevice returns the target device's values and, when rsdAddr is set (or a tunnel
+// daemon is running), the RemoteServiceDiscovery client used to reach its services over the tunnel
+func imgDevice(udid, rsdAddr string) (*lockdownd.DeviceValues, *rsd.Client, error) {
+	if len(rsdAddr) > 0 {
+		r, err := rsd.NewClient(rsdAddr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to RemoteServiceDiscovery at %s: %w", rsdAddr, err)
+		}
+		return &lockdownd.DeviceValues{
+			UniqueDeviceID: r.Property("UniqueDeviceID"),
+			ProductType:    r.Property("ProductType"),
+			ProductVersion: r.Property("OSVersion"),
+			BuildVersion:   r.Property("BuildVersion"),
+		}, r, nil
+	}
+
+	var dev *lockdownd.DeviceValues
+	if len(udid) == 0 {
+		var err error
+		dev, err = utils.PickDevice()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to pick USB connected devices: %w", err)
+		}
+	} else {
+		ldc, err := lockdownd.NewClient(udid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer ldc.Close()
+		dev, err = ldc.GetValues()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get device values for %s: %w", udid, err)
+		}
+	}
+
+	// prefer RemoteXPC on iOS17.4+ devices when 'ipsw idev tunnel start' has a (USB) tunnel up for them
+	if ver, err := semver.NewVersion(dev.ProductVersion); err == nil && !ver.LessThan(semver.Must(semver.NewVersion("17.4"))) {
+		if t, err := tunnel.Lookup(tunnel.DefaultRegistryAddr, dev.UniqueDeviceID); err == nil {
+			r, err := rsd.NewClient(t.RSDAddress())
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to connect to RemoteServiceDiscovery over tunnel %s: %w", t.Interface, err)
+			}
+			log.WithField("rsd", t.RSDAddress()).Debug("Using CoreDevice tunnel")
+			return dev, r, nil
+		}
+	}
+
+	return dev, nil, nil
+}
+
+// imageMounter connects to mobile_image_mounter over RemoteXPC if r is set or lockdownd otherwise
+func imageMounter(dev *lockdownd.DeviceValues, r *rsd.Client) (*mount.Client, error) {
+	if r != nil {
+		cli, err := mount.NewRemoteClient(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to mobile_image_mounter over RemoteXPC: %w", err)
+		}
+		return cli, nil
+	}
+	cli, err := mount.NewClient(dev.UniqueDeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mobile_image_mounter: %w", err)
+	}
+	return cli, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ImgCmd.AddCommand(idevImgDDICmd)
+
+	idevImgDDICmd.Flags().StringP("output", "o", "", "Folder to stage the DDI in (default: ~/.config/ipsw/ddi/<DEVICE>_<BUILD>)")
+	idevImgDDICmd.Flags().String("audience", "ios", "Asset audience (UUID or <platform>[:<release|VERSION-developer-beta>])")
+	idevImgDDICmd.Flags().BoolP("force", "f", false, "Re-download the DDI even if it is already staged")
+	idevImgDDICmd.Flags().Bool("no-mount", false, "Only download and stage the DDI")
+	idevImgDDICmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
+	idevImgDDICmd.Flags().Bool("insecure", false, "do not verify ssl certs")
+	idevImgDDICmd.MarkFlagDirname("output")
+
+	viper.BindPFlag("idev.img.ddi.output", idevImgDDICmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.img.ddi.audience", idevImgDDICmd.Flags().Lookup("audience"))
+	viper.BindPFlag("idev.img.ddi.force", idevImgDDICmd.Flags().Lookup("force"))
+	viper.BindPFlag("idev.img.ddi.no-mount", idevImgDDICmd.Flags().Lookup("no-mount"))
+	viper.BindPFlag("idev.img.ddi.proxy", idevImgDDICmd.Flags().Lookup("proxy"))
+	viper.BindPFlag("idev.img.ddi.insecure", idevImgDDICmd.Flags().Lookup("insecure"))
+}
+
+// idevImgDDICmd represents the ddi command
+var idevImgDDICmd = &cobra.Command{
+	Use:   "ddi",
+	Short: "Download, stage and mount the personalized DDI for an iOS17+ device",
+	Long: heredoc.Doc(`
+		Download the personalized Developer Disk Image (DDI) MobileAsset for the connected device's
+		iOS version, stage its BuildManifest.plist, PersonalizedDMG and trustcache, then personalize
+		and mount it (no Xcode.app required).`),
+	Example: heredoc.Doc(`
+		# Download and mount the DDI for the connected device
+		❯ ipsw idev img ddi
+		# Only download and stage the DDI (mount it later with 'ipsw idev img mount')
+		❯ ipsw idev img ddi --no-mount --output /tmp/DDI
+		# Mount over RemoteXPC through an already established CoreDevice tunnel
+		❯ ipsw idev img ddi --rsd fd7b:e5b:6f53::1`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+		// flags
+		udid, _ := cmd.Flags().GetString("udid")
+		output := viper.GetString("idev.img.ddi.output")
+		proxy := viper.GetString("idev.img.ddi.proxy")
+		insecure := viper.GetBool("idev.img.ddi.insecure")
+
+		dev, rsdClient, err := imgDevice(udid, viper.GetString("idev.img.rsd"))
+		if err != nil {
+			return err
+		}
+
+		ver, err := semver.NewVersion(dev.ProductVersion)
+		if err != nil {
+			return fmt.Errorf("failed to convert version into semver object")
+		}
+		if ver.LessThan(semver.Must(semver.NewVersion("17.0"))) {
+			return fmt.Errorf("personalized DDIs are only used by iOS17+ devices (use 'ipsw idev img mount --xcode' for iOS %s)", dev.ProductVersion)
+		}
+
+		ddi, err := stageDDI(dev, output, viper.GetString("idev.img.ddi.audience"), viper.GetBool("idev.img.ddi.force"), proxy, insecure)
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("idev.img.ddi.no-mount") {
+			return nil
+		}
+
+		cli, err := imageMounter(dev, rsdClient)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		return mountPersonalized(cli, ddi.Manifest, ddi.Image, ddi.TrustCache, ddi.BuildManifest, "", proxy, insecure)
+	},
+}
+
+// stageDDI returns the personalized DDI staged in output (default: ~/.config/ipsw/ddi/<DEVICE>_<BUILD>)
+// downloading it first if it isn't staged yet or force is set
+func stageDDI(dev *lockdownd.DeviceValues, output, audience string, force bool, proxy string, insecure bool) (*download.DDI, error) {
+	if len(output) == 0 {
+		configDir := filepath.Dir(viper.ConfigFileUsed())
+		if len(viper.ConfigFileUsed()) == 0 {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			}
+			configDir = filepath.Join(home, ".config", "ipsw")
+		}
+		output = filepath.Join(configDir, "ddi", fmt.Sprintf("%s_%s", dev.ProductType, dev.BuildVersion))
+	}
+
+	ddi, err := download.StagedDDI(output)
+	if err != nil || force {
+		log.WithFields(log.Fields{
+			"device":  dev.ProductType,
+			"version": dev.ProductVersion,
+			"build":   dev.BuildVersion,
+		}).Info("Downloading personalized DDI")
+		ddi, err = download.GetDDI(&download.DDIConfig{
+			Device:   dev.ProductType,
+			Version:  dev.ProductVersion,
+			Build:    dev.BuildVersion,
+			Audience: audience,
+			Output:   output,
+			Proxy:    proxy,
+			Insecure: insecure,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download DDI: %w", err)
+		}
+	} else {
+		log.WithField("folder", output).Info("Using staged DDI")
+	}
+	utils.Indent(log.Info, 2)("BuildManifest: " + ddi.BuildManifest)
+	utils.Indent(log.Info, 2)("Image:         " + ddi.Image)
+	utils.Indent(log.Info, 2)("TrustCache:    " + ddi.TrustCache)
+
+	return ddi, nil
+}
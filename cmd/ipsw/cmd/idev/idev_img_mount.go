@@ -34,7 +34,6 @@ import (
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/plist"
 	"github.com/blacktop/ipsw/pkg/tss"
-	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 	"github.com/blacktop/ipsw/pkg/usb/mount"
 	"github.com/fatih/color"
 	semver "github.com/hashicorp/go-version"
@@ -51,7 +50,7 @@ func init() {
 	idevImgMountCmd.Flags().StringP("manifest", "m", "", "BuildManifest.plist to use")
 	idevImgMountCmd.Flags().StringP("signature", "s", "", "Image signature to use")
 	idevImgMountCmd.Flags().StringP("image-type", "t", "", "Image type to mount (i.e. Developer)")
-	idevImgMountCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	idevImgMountCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	idevImgMountCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 
 	viper.BindPFlag("idev.img.mount.xcode", idevImgMountCmd.Flags().Lookup("xcode"))
@@ -78,7 +77,7 @@ var idevImgMountCmd = &cobra.Command{
 		}
 		color.NoColor = viper.GetBool("no-color")
 		// flags
-		udid := viper.GetString("idev.udid")
+		udid, _ := cmd.Flags().GetString("udid")
 		xcode := viper.GetString("idev.img.mount.xcode")
 		dmgPath := viper.GetString("idev.img.mount.ddi-img")
 		trustcachePath := viper.GetString("idev.img.mount.trustcache")
@@ -98,23 +97,9 @@ var idevImgMountCmd = &cobra.Command{
 			return fmt.Errorf("invalid flags --trustcache or --manifest (not allowed when --image-type=Developer)")
 		}
 
-		var err error
-		var dev *lockdownd.DeviceValues
-		if len(udid) == 0 {
-			dev, err = utils.PickDevice()
-			if err != nil {
-				return fmt.Errorf("failed to pick USB connected devices: %w", err)
-			}
-		} else {
-			ldc, err := lockdownd.NewClient(udid)
-			if err != nil {
-				return fmt.Errorf("failed to connect to lockdownd: %w", err)
-			}
-			dev, err = ldc.GetValues()
-			if err != nil {
-				return fmt.Errorf("failed to get device values for %s: %w", udid, err)
-			}
-			ldc.Close()
+		dev, rsdClient, err := imgDevice(udid, viper.GetString("idev.img.rsd"))
+		if err != nil {
+			return err
 		}
 
 		ver, err := semver.NewVersion(dev.ProductVersion) // check
@@ -123,6 +108,9 @@ var idevImgMountCmd = &cobra.Command{
 		}
 
 		if ver.LessThan(semver.Must(semver.NewVersion("17.0"))) {
+			if rsdClient != nil {
+				return fmt.Errorf("--rsd is only supported for iOS17+ devices")
+			}
 			cli, err := mount.NewClient(dev.UniqueDeviceID)
 			if err != nil {
 				return fmt.Errorf("failed to connect to mobile_image_mounter: %w", err)
@@ -137,28 +125,10 @@ var idevImgMountCmd = &cobra.Command{
 			var imgData []byte
 			var sigData []byte
 
-			if len(args) == 0 {
-				version, err := semver.NewVersion(dev.ProductVersion)
-				if err != nil {
-					log.Fatal("failed to convert version into semver object")
-				}
-				imgData, err = os.ReadFile(
-					filepath.Join(xcode,
-						fmt.Sprintf("/Contents/Developer/Platforms/iPhoneOS.platform/DeviceSupport/%d.%d/DeveloperDiskImage.dmg",
-							version.Segments()[0],
-							version.Segments()[1],
-						)))
-				if err != nil {
-					return fmt.Errorf("failed to read DeveloperDiskImage.dmg: %w", err)
-				}
-				sigData, err = os.ReadFile(
-					filepath.Join(xcode,
-						fmt.Sprintf("/Contents/Developer/Platforms/iPhoneOS.platform/DeviceSupport/%d.%d/DeveloperDiskImage.dmg.signature",
-							version.Segments()[0],
-							version.Segments()[1],
-						)))
+			if len(dmgPath) == 0 {
+				imgData, sigData, err = xcodeDeveloperDiskImage(xcode, ver)
 				if err != nil {
-					return fmt.Errorf("failed to read DeveloperDiskImage.dmg.signature: %w", err)
+					return err
 				}
 			} else {
 				imgData, err = os.ReadFile(dmgPath)
@@ -182,8 +152,6 @@ var idevImgMountCmd = &cobra.Command{
 		} else { // NEW iOS17 DDIs need to be personalized
 			var buildManifest *plist.BuildManifest
 
-			imageType = "Personalized"
-
 			if len(dmgPath) == 0 {
 				xcodeVersion, err := utils.GetXCodeVersion(xcode)
 				if err != nil {
@@ -254,65 +222,93 @@ var idevImgMountCmd = &cobra.Command{
 				}
 			}
 
-			cli, err := mount.NewClient(dev.UniqueDeviceID)
+			cli, err := imageMounter(dev, rsdClient)
 			if err != nil {
-				return fmt.Errorf("failed to connect to mobile_image_mounter: %w", err)
+				return err
 			}
 			defer cli.Close()
 
-			if _, err := cli.LookupImage(imageType); err == nil {
-				log.Warnf("image type %s already mounted", imageType)
-				return nil
-			}
+			return mountPersonalized(cli, buildManifest, dmgPath, trustcachePath, manifestPath, signaturePath,
+				viper.GetString("idev.img.mount.proxy"),
+				viper.GetBool("idev.img.mount.insecure"))
+		}
 
-			imgData, err := os.ReadFile(dmgPath)
-			if err != nil {
-				return fmt.Errorf("failed to read PersonalizedDMG: %w", err)
-			}
+		return nil
+	},
+}
 
-			var sigData []byte
-			if len(signaturePath) > 0 {
-				sigData, err = os.ReadFile(signaturePath)
-				if err != nil {
-					return fmt.Errorf("failed to read signature '%s': %w", signaturePath, err)
-				}
-			} else {
-				digest := sha512.Sum384(imgData)
-				sigData, err = cli.PersonalizationManifest("DeveloperDiskImage", digest[:])
-				if err != nil {
-					log.Debugf("failed to get personalization manifest: %w", err)
+// xcodeDeveloperDiskImage reads the pre-iOS17 DeveloperDiskImage.dmg and its signature for ver from Xcode.app
+func xcodeDeveloperDiskImage(xcode string, ver *semver.Version) ([]byte, []byte, error) {
+	dir := filepath.Join(xcode, fmt.Sprintf("/Contents/Developer/Platforms/iPhoneOS.platform/DeviceSupport/%d.%d", ver.Segments()[0], ver.Segments()[1]))
+	imgData, err := os.ReadFile(filepath.Join(dir, "DeveloperDiskImage.dmg"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read DeveloperDiskImage.dmg: %w", err)
+	}
+	sigData, err := os.ReadFile(filepath.Join(dir, "DeveloperDiskImage.dmg.signature"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read DeveloperDiskImage.dmg.signature: %w", err)
+	}
+	return imgData, sigData, nil
+}
 
-					nonce, err := cli.Nonce("DeveloperDiskImage")
-					if err != nil {
-						return fmt.Errorf("failed to get nonce: %w", err)
-					}
+// mountPersonalized personalizes (if no signature is provided) and mounts an iOS17+ personalized DDI
+func mountPersonalized(cli *mount.Client, buildManifest *plist.BuildManifest, dmgPath, trustcachePath, manifestPath, signaturePath, proxy string, insecure bool) error {
+	imageType := "Personalized"
 
-					personalID, err := cli.PersonalizationIdentifiers("")
-					if err != nil {
-						log.Errorf("failed to get personalization identifiers: %v ('personalization' might not be supported on this device)", err)
-					}
+	if _, err := cli.LookupImage(imageType); err == nil {
+		log.Warnf("image type %s already mounted", imageType)
+		return nil
+	}
 
-					personalID["ApNonce"] = nonce
+	imgData, err := os.ReadFile(dmgPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PersonalizedDMG: %w", err)
+	}
 
-					sigData, err = tss.Personalize(&tss.PersonalConfig{
-						Proxy:         viper.GetString("idev.img.mount.proxy"),
-						Insecure:      viper.GetBool("idev.img.mount.insecure"),
-						PersonlID:     personalID,
-						BuildManifest: buildManifest,
-					})
-				}
+	var sigData []byte
+	if len(signaturePath) > 0 {
+		sigData, err = os.ReadFile(signaturePath)
+		if err != nil {
+			return fmt.Errorf("failed to read signature '%s': %w", signaturePath, err)
+		}
+	} else {
+		digest := sha512.Sum384(imgData)
+		sigData, err = cli.PersonalizationManifest("DeveloperDiskImage", digest[:])
+		if err != nil {
+			log.Debugf("failed to get personalization manifest: %v", err)
+
+			nonce, err := cli.Nonce("DeveloperDiskImage")
+			if err != nil {
+				return fmt.Errorf("failed to get nonce: %w", err)
 			}
 
-			log.Infof("Uploading %s image", imageType)
-			if err := cli.Upload(imageType, imgData, sigData); err != nil {
-				return fmt.Errorf("failed to upload image: %w", err)
+			personalID, err := cli.PersonalizationIdentifiers("")
+			if err != nil {
+				log.Errorf("failed to get personalization identifiers: %v ('personalization' might not be supported on this device)", err)
 			}
-			log.Infof("Mounting %s image", imageType)
-			if err := cli.Mount(imageType, sigData, trustcachePath, manifestPath); err != nil {
-				return fmt.Errorf("failed to mount image: %w", err)
+
+			personalID["ApNonce"] = nonce
+
+			sigData, err = tss.Personalize(&tss.PersonalConfig{
+				Proxy:         proxy,
+				Insecure:      insecure,
+				PersonlID:     personalID,
+				BuildManifest: buildManifest,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to personalize DDI: %w", err)
 			}
 		}
+	}
 
-		return nil
-	},
+	log.Infof("Uploading %s image", imageType)
+	if err := cli.Upload(imageType, imgData, sigData); err != nil {
+		return fmt.Errorf("failed to upload image: %w", err)
+	}
+	log.Infof("Mounting %s image", imageType)
+	if err := cli.Mount(imageType, sigData, trustcachePath, manifestPath); err != nil {
+		return fmt.Errorf("failed to mount image: %w", err)
+	}
+
+	return nil
 }
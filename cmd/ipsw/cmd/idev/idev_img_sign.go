@@ -49,9 +49,9 @@ func init() {
 	idevImgSignCmd.Flags().Uint64P("ecid", "e", 0, "Device ApECID")
 	idevImgSignCmd.Flags().StringP("nonce", "n", "", "Device ApNonce")
 	idevImgSignCmd.Flags().StringP("ap-item", "a", "", "Ap'Item to personalize (example: --ap-item 'Ap,SikaFuse')")
-	idevImgSignCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	idevImgSignCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	idevImgSignCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
-	idevImgSignCmd.Flags().StringP("input", "i", "", "JSON file from `ipsw idev img nonce --json` command")
+	idevImgSignCmd.Flags().StringP("input", "i", "", "JSON file from `ipsw idev img nonce --json` or `ipsw idev nonce --json` command")
 	idevImgSignCmd.Flags().StringP("output", "o", "", "Folder to write signature to")
 	idevImgSignCmd.MarkFlagDirname("output")
 
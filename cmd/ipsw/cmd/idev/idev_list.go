@@ -58,15 +58,24 @@ var ListDevicesCmd = &cobra.Command{
 
 		color.NoColor = viper.GetBool("no-color")
 
-		conn, err := usb.NewConn()
-		if err != nil {
-			return fmt.Errorf("failed to connect to usbmuxd: %w", err)
-		}
-		defer conn.Close()
+		var devices []*usb.DeviceAttachment
+		if nd := usb.NetworkDeviceInUse(); nd != nil {
+			devices = append(devices, &usb.DeviceAttachment{
+				ConnectionType: "Network",
+				DeviceID:       -1,
+				SerialNumber:   nd.UDID,
+				UDID:           nd.UDID,
+			})
+		} else {
+			conn, err := usb.NewConn()
+			if err != nil {
+				return fmt.Errorf("failed to connect to usbmuxd: %w", err)
+			}
+			defer conn.Close()
 
-		devices, err := conn.ListDevices()
-		if err != nil {
-			return err
+			if devices, err = conn.ListDevices(); err != nil {
+				return err
+			}
 		}
 
 		if len(devices) == 0 {
@@ -0,0 +1,116 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	IDevCmd.AddCommand(LockdownCmd)
+	LockdownCmd.AddCommand(idevLockdownDomainsCmd)
+
+	LockdownCmd.PersistentFlags().StringP("domain", "d", "", "Value domain (i.e. com.apple.disk_usage, default is the global domain)")
+}
+
+// LockdownCmd represents the lockdown command
+var LockdownCmd = &cobra.Command{
+	Use:     "lockdown",
+	Aliases: []string{"ld"},
+	Short:   "Get/Set lockdownd domain values",
+	Long: heredoc.Doc(`
+		Read and write lockdownd values.
+
+		Writes are limited to known-safe keys (see 'ipsw idev lockdown domains') unless --force is given
+		as changing other values can break pairing, activation or backups.`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// idevLockdownDomainsCmd represents the lockdown domains command
+var idevLockdownDomainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "List known domains and their writable keys",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, domain := range append([]string{""}, lockdownd.KnownDomains...) {
+			name := domain
+			if len(name) == 0 {
+				name = "(global)"
+			}
+			if keys := lockdownd.WritableValues[domain]; len(keys) > 0 {
+				fmt.Printf("%s\twritable: %s\n", name, strings.Join(keys, ", "))
+			} else {
+				fmt.Println(name)
+			}
+		}
+	},
+}
+
+// parseLockdownValue converts the command line value to the plist type
+func parseLockdownValue(value, typ string) (any, error) {
+	switch typ {
+	case "string":
+		return value, nil
+	case "bool":
+		return strconv.ParseBool(value)
+	case "int":
+		return strconv.ParseInt(value, 0, 64)
+	case "real":
+		return strconv.ParseFloat(value, 64)
+	case "data":
+		if data, err := hex.DecodeString(strings.TrimPrefix(value, "0x")); err == nil {
+			return data, nil
+		}
+		return base64.StdEncoding.DecodeString(value)
+	case "json":
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON value: %w", err)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %s (must be string, bool, int, real, data or json)", typ)
+	}
+}
+
+func formatLockdownValue(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	dat, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value to JSON: %w", err)
+	}
+	return string(dat), nil
+}
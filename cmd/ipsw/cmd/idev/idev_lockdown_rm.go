@@ -0,0 +1,87 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	LockdownCmd.AddCommand(idevLockdownRmCmd)
+
+	idevLockdownRmCmd.Flags().BoolP("yes", "y", false, "Do not prompt for confirmation")
+	idevLockdownRmCmd.Flags().Bool("force", false, "Allow removing keys that are not known to be safe")
+	viper.BindPFlag("idev.lockdown.rm.yes", idevLockdownRmCmd.Flags().Lookup("yes"))
+	viper.BindPFlag("idev.lockdown.rm.force", idevLockdownRmCmd.Flags().Lookup("force"))
+}
+
+// idevLockdownRmCmd represents the lockdown rm command
+var idevLockdownRmCmd = &cobra.Command{
+	Use:           "rm <KEY>",
+	Short:         "Remove a domain value",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		domain, _ := cmd.Flags().GetString("domain")
+		key := args[0]
+
+		if err := confirmLockdownWrite(domain, key, "Remove", viper.GetBool("idev.lockdown.rm.yes"), viper.GetBool("idev.lockdown.rm.force")); err != nil {
+			return err
+		}
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		cli, err := lockdownd.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer cli.Close()
+
+		if err := cli.RemoveValue(domain, key); err != nil {
+			return err
+		}
+		log.Infof("Removed %s", key)
+
+		return nil
+	},
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	LockdownCmd.AddCommand(idevLockdownSetCmd)
+
+	idevLockdownSetCmd.Flags().StringP("type", "t", "string", "Value type (string, bool, int, real, data or json)")
+	idevLockdownSetCmd.Flags().BoolP("yes", "y", false, "Do not prompt for confirmation")
+	idevLockdownSetCmd.Flags().Bool("force", false, "Allow setting keys that are not known to be safe")
+	idevLockdownSetCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"string", "bool", "int", "real", "data", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	viper.BindPFlag("idev.lockdown.set.type", idevLockdownSetCmd.Flags().Lookup("type"))
+	viper.BindPFlag("idev.lockdown.set.yes", idevLockdownSetCmd.Flags().Lookup("yes"))
+	viper.BindPFlag("idev.lockdown.set.force", idevLockdownSetCmd.Flags().Lookup("force"))
+}
+
+// confirmLockdownWrite applies the lockdown write guardrails
+func confirmLockdownWrite(domain, key, action string, yes, force bool) error {
+	if !lockdownd.IsWritable(domain, key) && !force {
+		return fmt.Errorf("refusing to %s %s in domain %q as it is not known to be safe (use --force to override)", action, key, domain)
+	}
+	if yes {
+		return nil
+	}
+	confirm := false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("%s %s in domain %q?", action, key, domain),
+	}
+	if err := survey.AskOne(prompt, &confirm); err != nil {
+		return err
+	}
+	if !confirm {
+		return fmt.Errorf("aborted")
+	}
+	return nil
+}
+
+// idevLockdownSetCmd represents the lockdown set command
+var idevLockdownSetCmd = &cobra.Command{
+	Use:   "set <KEY> <VALUE>",
+	Short: "Set a domain value",
+	Example: heredoc.Doc(`
+		# Rename the device
+		❯ ipsw idev lockdown set DeviceName "Lab iPhone 7"
+		# Enable Wi-Fi debugging
+		❯ ipsw idev lockdown set -d com.apple.mobile.wireless_lockdown -t bool EnableWifiDebugging true -y`),
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		domain, _ := cmd.Flags().GetString("domain")
+		key := args[0]
+
+		value, err := parseLockdownValue(args[1], viper.GetString("idev.lockdown.set.type"))
+		if err != nil {
+			return err
+		}
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		cli, err := lockdownd.NewClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer cli.Close()
+
+		if old, err := cli.GetValue(domain, key); err == nil {
+			if out, err := formatLockdownValue(old); err == nil {
+				log.WithField("value", out).Infof("Current %s", key)
+			}
+		}
+
+		if err := confirmLockdownWrite(domain, key, "Set", viper.GetBool("idev.lockdown.set.yes"), viper.GetBool("idev.lockdown.set.force")); err != nil {
+			return err
+		}
+
+		if err := cli.SetValue(domain, key, value); err != nil {
+			return err
+		}
+		log.WithField("value", args[1]).Infof("Set %s", key)
+
+		return nil
+	},
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const modeNormal = "Normal"
+
+func init() {
+	IDevCmd.AddCommand(idevEnterRecoveryCmd)
+	IDevCmd.AddCommand(idevExitRecoveryCmd)
+	IDevCmd.AddCommand(idevModeCmd)
+
+	idevEnterRecoveryCmd.Flags().BoolP("wait", "w", false, "Wait for the device to reappear in Recovery mode")
+	idevEnterRecoveryCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the device to reappear")
+	viper.BindPFlag("idev.enter-recovery.wait", idevEnterRecoveryCmd.Flags().Lookup("wait"))
+	viper.BindPFlag("idev.enter-recovery.timeout", idevEnterRecoveryCmd.Flags().Lookup("timeout"))
+}
+
+// normalModeDevice returns the UDID of the (or the first) device in Normal mode or an empty string if there is none
+func normalModeDevice(udid string) (string, error) {
+	conn, err := usb.NewConn()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to usbmuxd: %w", err)
+	}
+	defer conn.Close()
+
+	devices, err := conn.ListDevices()
+	if err != nil {
+		return "", fmt.Errorf("failed to list devices: %w", err)
+	}
+	for _, dev := range devices {
+		if len(udid) == 0 || dev.SerialNumber == udid {
+			return dev.SerialNumber, nil
+		}
+	}
+	return "", nil
+}
+
+// deviceMode detects whether the device is in Normal, Recovery or DFU mode
+func deviceMode(udid string) (string, error) {
+	mode, err := recoveryMode()
+	if err == nil {
+		return mode, nil
+	}
+	log.WithError(err).Debug("no Recovery/DFU mode device")
+	found, err := normalModeDevice(udid)
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("no device found in Normal, Recovery or DFU mode")
+	}
+	return modeNormal, nil
+}
+
+// enterRecovery reboots a Normal mode device into Recovery mode via lockdownd
+func enterRecovery(udid string) error {
+	if len(udid) == 0 {
+		dev, err := utils.PickDevice()
+		if err != nil {
+			return fmt.Errorf("failed to pick USB connected devices: %w", err)
+		}
+		udid = dev.UniqueDeviceID
+	}
+
+	ldc, err := lockdownd.NewClient(udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+
+	log.WithField("udid", udid).Info("Entering Recovery mode")
+	if _, err := ldc.EnterRecovery(); err != nil {
+		return fmt.Errorf("failed to enter recovery: %w", err)
+	}
+
+	return nil
+}
+
+// idevEnterRecoveryCmd represents the enter-recovery command
+var idevEnterRecoveryCmd = &cobra.Command{
+	Use:   "enter-recovery",
+	Short: "Reboot a device into Recovery mode",
+	Example: heredoc.Doc(`
+		# Reboot the connected device into Recovery mode and wait for it
+		❯ ipsw idev enter-recovery --wait`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+
+		if mode, err := recoveryMode(); err == nil {
+			log.Warnf("Device is already in %s mode", mode)
+			return nil
+		}
+
+		if err := enterRecovery(udid); err != nil {
+			return err
+		}
+
+		if viper.GetBool("idev.enter-recovery.wait") {
+			log.Info("Waiting for device to enter Recovery mode")
+			mode, err := waitForRecoveryMode(viper.GetDuration("idev.enter-recovery.timeout"))
+			if err != nil {
+				return err
+			}
+			log.Infof("Device is in %s mode", mode)
+		}
+
+		return nil
+	},
+}
+
+// idevExitRecoveryCmd represents the exit-recovery command
+var idevExitRecoveryCmd = &cobra.Command{
+	Use:           "exit-recovery",
+	Short:         "Reboot a device in Recovery mode back into Normal mode",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		return exitRecovery()
+	},
+}
+
+// idevModeCmd represents the mode command
+var idevModeCmd = &cobra.Command{
+	Use:           "mode",
+	Short:         "Detect if a device is in Normal, Recovery or DFU mode",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+
+		mode, err := deviceMode(udid)
+		if err != nil {
+			return err
+		}
+		fmt.Println(mode)
+
+		return nil
+	},
+}
@@ -0,0 +1,73 @@
+//go:build libusb
+
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blacktop/ipsw/pkg/usb/irecv"
+)
+
+func recoveryMode() (string, error) {
+	cli, err := irecv.NewClient()
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	return cli.Mode.String(), nil
+}
+
+func waitForRecoveryMode(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if mode, err := recoveryMode(); err == nil {
+			return mode, nil
+		}
+		time.Sleep(time.Second)
+	}
+	return "", fmt.Errorf("timed out waiting for device to enter Recovery mode")
+}
+
+func exitRecovery() error {
+	cli, err := irecv.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to irecv: %w", err)
+	}
+	defer cli.Close()
+
+	// SecureROM (DFU) has no environment to set auto-boot in
+	if !cli.Mode.IsRecovery() {
+		return fmt.Errorf("device is in %s mode: only Recovery mode devices can be rebooted into Normal mode (force reboot the device instead)", cli.Mode)
+	}
+	if err := cli.SetAutoboot(true); err != nil {
+		return fmt.Errorf("failed to set autoboot to true: %w", err)
+	}
+	if err := cli.Reboot(true); err != nil {
+		return fmt.Errorf("failed to reboot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	IDevCmd.AddCommand(idevNonceCmd)
+
+	idevNonceCmd.Flags().BoolP("recovery", "r", false, "Query a device in Recovery/DFU mode")
+	idevNonceCmd.Flags().BoolP("json", "j", false, "Print as JSON")
+	idevNonceCmd.Flags().StringP("output", "o", "", "Folder to write JSON to (for use with `ipsw idev img sign --input` or `ipsw download tss --input`)")
+	idevNonceCmd.MarkFlagDirname("output")
+	viper.BindPFlag("idev.nonce.recovery", idevNonceCmd.Flags().Lookup("recovery"))
+	viper.BindPFlag("idev.nonce.json", idevNonceCmd.Flags().Lookup("json"))
+	viper.BindPFlag("idev.nonce.output", idevNonceCmd.Flags().Lookup("output"))
+}
+
+// DeviceNonce are the device identifiers required for TSS personalization
+type DeviceNonce struct {
+	Mode           string `json:"Mode,omitempty"`
+	ProductType    string `json:"ProductType,omitempty"`
+	BuildVersion   string `json:"BuildVersion,omitempty"`
+	ProductVersion string `json:"ProductVersion,omitempty"`
+	BoardID        uint64 `json:"BoardId"`
+	ChipID         uint64 `json:"ChipID"`
+	ECID           uint64 `json:"UniqueChipID"`
+	ApNonce        string `json:"ApNonce"`
+	SEPNonce       string `json:"SEPNonce,omitempty"`
+}
+
+func (n DeviceNonce) String() string {
+	colorField := color.New(color.Faint, color.FgHiBlue).SprintFunc()
+	colorValue := color.New(color.Bold).SprintfFunc()
+	s := fmt.Sprintf("%s %s\n", colorField("Mode:        "), colorValue(n.Mode))
+	if len(n.ProductType) > 0 {
+		s += fmt.Sprintf("%s %s (%s %s)\n", colorField("ProductType: "), colorValue(n.ProductType), n.ProductVersion, n.BuildVersion)
+	}
+	s += fmt.Sprintf("%s %s\n", colorField("ApBoardID:   "), colorValue("%#x", n.BoardID))
+	s += fmt.Sprintf("%s %s\n", colorField("ApChipID:    "), colorValue("%#x", n.ChipID))
+	s += fmt.Sprintf("%s %s\n", colorField("ApECID:      "), colorValue("%#x", n.ECID))
+	s += fmt.Sprintf("%s %s\n", colorField("ApNonce:     "), colorValue(n.ApNonce))
+	s += fmt.Sprintf("%s %s", colorField("SEPNonce:    "), colorValue(n.SEPNonce))
+	return s
+}
+
+func normalNonce(udid string) (*DeviceNonce, error) {
+	var dev *lockdownd.DeviceValues
+	if len(udid) == 0 {
+		var err error
+		dev, err = utils.PickDevice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick USB connected devices: %w", err)
+		}
+	} else {
+		ldc, err := lockdownd.NewClient(udid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer ldc.Close()
+		dev, err = ldc.GetValues()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device values: %w", err)
+		}
+	}
+	return &DeviceNonce{
+		Mode:           "Normal",
+		ProductType:    dev.ProductType,
+		BuildVersion:   dev.BuildVersion,
+		ProductVersion: dev.ProductVersion,
+		BoardID:        uint64(dev.BoardID),
+		ChipID:         uint64(dev.ChipID),
+		ECID:           uint64(dev.UniqueChipID),
+		ApNonce:        hex.EncodeToString(dev.ApNonce),
+		SEPNonce:       hex.EncodeToString(dev.SEPNonce),
+	}, nil
+}
+
+// idevNonceCmd represents the nonce command
+var idevNonceCmd = &cobra.Command{
+	Use:           "nonce",
+	Short:         "Get ECID, ApNonce, SEP nonce and board/chip IDs for TSS personalization",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		// flags
+		asJSON := viper.GetBool("idev.nonce.json")
+		output := viper.GetString("idev.nonce.output")
+
+		var (
+			nonce *DeviceNonce
+			err   error
+		)
+		if viper.GetBool("idev.nonce.recovery") {
+			nonce, err = recoveryNonce()
+		} else {
+			nonce, err = normalNonce(udid)
+		}
+		if err != nil {
+			return err
+		}
+
+		if asJSON || len(output) > 0 {
+			dat, err := json.MarshalIndent(nonce, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal nonce info: %w", err)
+			}
+			if len(output) > 0 {
+				if err := os.MkdirAll(output, 0o750); err != nil {
+					return fmt.Errorf("failed to create output folder: %w", err)
+				}
+				fname := filepath.Join(output, fmt.Sprintf("%d.nonce.json", nonce.ECID))
+				log.Infof("Writing nonce info to %s", fname)
+				return os.WriteFile(fname, dat, 0o644)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		fmt.Println(nonce)
+
+		return nil
+	},
+}
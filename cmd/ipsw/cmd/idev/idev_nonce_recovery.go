@@ -0,0 +1,67 @@
+//go:build libusb
+
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/irecv"
+)
+
+func recoveryNonce() (*DeviceNonce, error) {
+	cli, err := irecv.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to irecv: %w", err)
+	}
+	defer cli.Close()
+
+	nonce := &DeviceNonce{
+		Mode:     cli.Mode.String(),
+		ApNonce:  hex.EncodeToString(cli.ApNonce),
+		SEPNonce: hex.EncodeToString(cli.SEPNonce),
+	}
+	// the fields missing from the device's serial number are left unset
+	for _, f := range []struct {
+		name string
+		val  string
+		dst  *uint64
+	}{
+		{"BDID", cli.BDID, &nonce.BoardID},
+		{"CPID", cli.CPID, &nonce.ChipID},
+		{"ECID", cli.ECID, &nonce.ECID},
+	} {
+		if len(f.val) == 0 {
+			log.Warnf("device did not report its %s (unknown)", f.name)
+			continue
+		}
+		if *f.dst, err = strconv.ParseUint(f.val, 16, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse %s '%s': %w", f.name, f.val, err)
+		}
+	}
+
+	return nonce, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/mdns"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const wifiDiscoverTimeout = 3 * time.Second
+
+func init() {
+	IDevCmd.AddCommand(PairCmd)
+}
+
+// PairCmd represents the pair command
+var PairCmd = &cobra.Command{
+	Use:   "pair",
+	Short: "Pair with devices and manage pair records",
+	Long: heredoc.Doc(`
+		Pair with devices and manage the pair records ipsw uses to connect to them over Wi-Fi.
+
+		Records are stored in the 'pair_records' folder next to the ipsw config (default: ~/.config/ipsw/pair_records).
+		Once a device has a record and Wi-Fi connections enabled every idev command can reach it
+		without a USB cable using the --wifi or --host flags.`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// pairRecordStore returns the pair record store in the ipsw config folder
+func pairRecordStore() (*usb.PairRecordStore, error) {
+	configDir := filepath.Dir(viper.ConfigFileUsed())
+	if len(viper.ConfigFileUsed()) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		configDir = filepath.Join(home, ".config", "ipsw")
+	}
+	return &usb.PairRecordStore{Dir: filepath.Join(configDir, "pair_records")}, nil
+}
+
+// wifiMAC returns the Wi-Fi MAC address of an _apple-mobdev2 instance (named "<MAC>@<IPv6 address>")
+func wifiMAC(svc *mdns.Service) string {
+	mac, _, _ := strings.Cut(svc.Instance, "@")
+	return mac
+}
+
+// useNetworkDevice routes all device connections to a paired device at host (or found with Bonjour if host is empty)
+func useNetworkDevice(udid, host string) error {
+	store, err := pairRecordStore()
+	if err != nil {
+		return err
+	}
+
+	var record *usb.PairRecord
+	if len(udid) > 0 {
+		if record, err = store.Load(udid); err != nil {
+			return fmt.Errorf("%w (pair with 'ipsw idev pair create' or 'ipsw idev pair save')", err)
+		}
+	} else if len(host) > 0 {
+		udids, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(udids) != 1 {
+			return fmt.Errorf("found %d pair records: specify the device with --udid", len(udids))
+		}
+		udid = udids[0]
+		if record, err = store.Load(udid); err != nil {
+			return err
+		}
+	}
+
+	if len(host) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), wifiDiscoverTimeout)
+		defer cancel()
+		services, err := mdns.Browse(ctx, mdns.MobileDeviceService)
+		if err != nil {
+			return fmt.Errorf("failed to browse for devices: %w", err)
+		}
+		for _, svc := range services {
+			mac := wifiMAC(svc)
+			if record != nil {
+				if strings.EqualFold(mac, record.WiFiMACAddress) {
+					host = svc.Address()
+					break
+				}
+				continue
+			}
+			if id, rec, err := store.FindByWiFiMAC(mac); err == nil {
+				udid, record, host = id, rec, svc.Address()
+				break
+			}
+		}
+		if len(host) == 0 {
+			return fmt.Errorf("no paired device found on the LAN (make sure it is awake, on the same network and has Wi-Fi connections enabled)")
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"udid":    udid,
+		"address": host,
+	}).Debug("Connecting to device over the network")
+	usb.UseNetworkDevice(&usb.NetworkDevice{
+		UDID:    udid,
+		Address: host,
+		Record:  record,
+	})
+	return nil
+}
+
+// savePairRecord copies the device's (usbmuxd) pair record and Wi-Fi MAC address into the pair record store
+func savePairRecord(udid string, record *usb.PairRecord, enableWiFi bool) error {
+	ldc, err := lockdownd.NewClient(udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+
+	if record == nil {
+		record = ldc.PairRecord()
+	}
+	if mac, err := ldc.GetValue("", "WiFiAddress"); err == nil {
+		record.WiFiMACAddress, _ = mac.(string)
+	} else {
+		log.WithError(err).Warn("failed to get device Wi-Fi MAC address (it can't be found with --wifi)")
+	}
+	if enableWiFi {
+		if err := ldc.SetWifiConnections(true); err != nil {
+			return fmt.Errorf("failed to enable Wi-Fi connections: %w", err)
+		}
+	}
+
+	store, err := pairRecordStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Save(udid, record); err != nil {
+		return fmt.Errorf("failed to save pair record: %w", err)
+	}
+	log.WithFields(log.Fields{
+		"udid": udid,
+		"path": filepath.Join(store.Dir, udid+".plist"),
+	}).Info("Saved pair record")
+	return nil
+}
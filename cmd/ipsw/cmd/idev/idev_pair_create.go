@@ -0,0 +1,145 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	PairCmd.AddCommand(idevPairCreateCmd)
+
+	idevPairCreateCmd.Flags().Bool("enable-wifi", false, "Enable Wi-Fi connections on the device after pairing")
+	idevPairCreateCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the user to trust the computer")
+	viper.BindPFlag("idev.pair.create.enable-wifi", idevPairCreateCmd.Flags().Lookup("enable-wifi"))
+	viper.BindPFlag("idev.pair.create.timeout", idevPairCreateCmd.Flags().Lookup("timeout"))
+}
+
+// idevPairCreateCmd represents the pair create command
+var idevPairCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Pair with a USB connected device",
+	Long: heredoc.Doc(`
+		Pair with a USB connected device (the "Trust This Computer?" prompt needs to be accepted on the device).
+
+		The new pair record is saved with usbmuxd and in the ipsw pair record folder.`),
+	Example: heredoc.Doc(`
+		# Pair and enable Wi-Fi connections
+		❯ ipsw idev pair create --enable-wifi
+		# Then use the device without a cable
+		❯ ipsw idev --wifi list`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		enableWiFi := viper.GetBool("idev.pair.create.enable-wifi")
+		timeout := viper.GetDuration("idev.pair.create.timeout")
+
+		conn, err := usb.NewConn()
+		if err != nil {
+			return fmt.Errorf("failed to connect to usbmuxd: %w", err)
+		}
+		defer conn.Close()
+
+		devices, err := conn.ListDevices()
+		if err != nil {
+			return fmt.Errorf("failed to list devices: %w", err)
+		}
+		var device *usb.DeviceAttachment
+		for _, d := range devices {
+			if len(udid) == 0 || d.SerialNumber == udid {
+				if device != nil {
+					return fmt.Errorf("found multiple devices: specify the device with --udid")
+				}
+				device = d
+			}
+		}
+		if device == nil {
+			return fmt.Errorf("no USB connected device found")
+		}
+		udid = device.SerialNumber
+
+		ldc, err := lockdownd.NewUnpairedClient(udid)
+		if err != nil {
+			return fmt.Errorf("failed to connect to lockdownd: %w", err)
+		}
+		defer ldc.Close()
+
+		pub, err := ldc.GetValue("", "DevicePublicKey")
+		if err != nil {
+			return fmt.Errorf("failed to get device public key: %w", err)
+		}
+		pubKey, ok := pub.([]byte)
+		if !ok {
+			return fmt.Errorf("unexpected device public key type %T", pub)
+		}
+		buid, err := conn.ReadBUID()
+		if err != nil {
+			log.WithError(err).Debug("failed to read usbmuxd SystemBUID (generating one)")
+		}
+		record, err := lockdownd.NewPairRecord(pubKey, buid)
+		if err != nil {
+			return fmt.Errorf("failed to create pair record: %w", err)
+		}
+
+		deadline := time.Now().Add(timeout)
+		for {
+			err = ldc.Pair(record)
+			if !errors.Is(err, lockdownd.ErrPairingDialogPending) {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out %s", err)
+			}
+			log.Info("Tap 'Trust' on the device and enter the passcode")
+			time.Sleep(2 * time.Second)
+		}
+		if err != nil {
+			return err
+		}
+		log.WithField("udid", udid).Info("Paired with device")
+
+		// USB connections use usbmuxd's copy of the record
+		if err := conn.SavePairRecord(udid, device.DeviceID, record); err != nil {
+			return err
+		}
+
+		return savePairRecord(udid, record, enableWiFi)
+	},
+}
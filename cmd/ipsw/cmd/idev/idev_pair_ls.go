@@ -0,0 +1,108 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	PairCmd.AddCommand(idevPairListCmd)
+
+	idevPairListCmd.Flags().BoolP("json", "j", false, "Display pair records as JSON")
+	viper.BindPFlag("idev.pair.ls.json", idevPairListCmd.Flags().Lookup("json"))
+}
+
+type pairRecordInfo struct {
+	UDID           string `json:"udid"`
+	WiFiMACAddress string `json:"wifi_mac_address,omitempty"`
+	HostID         string `json:"host_id"`
+	SystemBUID     string `json:"system_buid"`
+}
+
+// idevPairListCmd represents the pair ls command
+var idevPairListCmd = &cobra.Command{
+	Use:           "ls",
+	Short:         "List saved pair records",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		store, err := pairRecordStore()
+		if err != nil {
+			return err
+		}
+		udids, err := store.List()
+		if err != nil {
+			return fmt.Errorf("failed to list pair records: %w", err)
+		}
+
+		var records []pairRecordInfo
+		for _, udid := range udids {
+			record, err := store.Load(udid)
+			if err != nil {
+				log.WithError(err).Warn("skipping pair record")
+				continue
+			}
+			records = append(records, pairRecordInfo{
+				UDID:           udid,
+				WiFiMACAddress: record.WiFiMACAddress,
+				HostID:         record.HostID,
+				SystemBUID:     record.SystemBUID,
+			})
+		}
+
+		if viper.GetBool("idev.pair.ls.json") {
+			dat, err := json.Marshal(records)
+			if err != nil {
+				return fmt.Errorf("failed to marshal pair records to JSON: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(records) == 0 {
+			log.Warnf("no pair records found in %s", store.Dir)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UDID\tWI-FI MAC\tHOST ID")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.UDID, r.WiFiMACAddress, r.HostID)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	PairCmd.AddCommand(idevPairRemoveCmd)
+
+	idevPairRemoveCmd.Flags().Bool("unpair", false, "Also unpair the device and remove usbmuxd's pair record")
+	viper.BindPFlag("idev.pair.rm.unpair", idevPairRemoveCmd.Flags().Lookup("unpair"))
+}
+
+// idevPairRemoveCmd represents the pair rm command
+var idevPairRemoveCmd = &cobra.Command{
+	Use:           "rm <UDID>",
+	Short:         "Remove a saved pair record",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid := args[0]
+
+		store, err := pairRecordStore()
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("idev.pair.rm.unpair") {
+			ldc, err := lockdownd.NewClient(udid)
+			if err != nil {
+				return fmt.Errorf("failed to connect to lockdownd: %w", err)
+			}
+			err = ldc.Unpair(ldc.PairRecord())
+			ldc.Close()
+			if err != nil {
+				return err
+			}
+			log.WithField("udid", udid).Info("Unpaired device")
+			if usb.NetworkDeviceInUse() == nil {
+				conn, err := usb.NewConn()
+				if err != nil {
+					return fmt.Errorf("failed to connect to usbmuxd: %w", err)
+				}
+				defer conn.Close()
+				if err := conn.DeletePairRecord(udid); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := store.Remove(udid); err != nil {
+			return fmt.Errorf("failed to remove pair record: %w", err)
+		}
+		log.WithField("udid", udid).Info("Removed pair record")
+		return nil
+	},
+}
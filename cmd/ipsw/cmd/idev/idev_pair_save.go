@@ -0,0 +1,74 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	PairCmd.AddCommand(idevPairSaveCmd)
+
+	idevPairSaveCmd.Flags().Bool("enable-wifi", true, "Enable Wi-Fi connections on the device")
+	viper.BindPFlag("idev.pair.save.enable-wifi", idevPairSaveCmd.Flags().Lookup("enable-wifi"))
+}
+
+// idevPairSaveCmd represents the pair save command
+var idevPairSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save an already paired device's pair record for Wi-Fi use",
+	Long: heredoc.Doc(`
+		Copy the pair record of a device already paired with this computer (i.e. by Finder/iTunes)
+		from usbmuxd into the ipsw pair record folder and enable Wi-Fi connections.`),
+	Example: heredoc.Doc(`
+		❯ ipsw idev pair save
+		❯ ipsw idev --wifi apps ls`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+
+		if len(udid) == 0 {
+			dev, err := utils.PickDevice()
+			if err != nil {
+				return fmt.Errorf("failed to pick USB connected devices: %w", err)
+			}
+			udid = dev.UniqueDeviceID
+		}
+
+		return savePairRecord(udid, nil, viper.GetBool("idev.pair.save.enable-wifi"))
+	},
+}
@@ -22,15 +22,18 @@ THE SOFTWARE.
 package idev
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"math/bits"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
@@ -45,14 +48,57 @@ func init() {
 	IDevCmd.AddCommand(PcapCmd)
 
 	PcapCmd.Flags().StringP("proc", "p", "", "process to get pcap for")
+	PcapCmd.Flags().StringP("filter", "f", "", "Capture filter expression (i.e. 'tcp port 443 and not host 17.253.144.10')")
 	PcapCmd.Flags().StringP("output", "o", "", "Folder to save pcap")
+	PcapCmd.Flags().BoolP("wireshark", "w", false, "Stream packets into Wireshark")
+	PcapCmd.Flags().Bool("stdout", false, "Write pcapng to stdout (i.e. pipe into tshark)")
+	PcapCmd.Flags().IntP("count", "c", 0, "Stop after capturing this many packets")
+	PcapCmd.Flags().BoolP("quiet", "q", false, "Do not print captured packets")
 	PcapCmd.MarkFlagDirname("output")
+	PcapCmd.MarkFlagsMutuallyExclusive("wireshark", "stdout")
+	viper.BindPFlag("idev.pcap.proc", PcapCmd.Flags().Lookup("proc"))
+	viper.BindPFlag("idev.pcap.filter", PcapCmd.Flags().Lookup("filter"))
+	viper.BindPFlag("idev.pcap.output", PcapCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.pcap.wireshark", PcapCmd.Flags().Lookup("wireshark"))
+	viper.BindPFlag("idev.pcap.stdout", PcapCmd.Flags().Lookup("stdout"))
+	viper.BindPFlag("idev.pcap.count", PcapCmd.Flags().Lookup("count"))
+	viper.BindPFlag("idev.pcap.quiet", PcapCmd.Flags().Lookup("quiet"))
+}
+
+func wiresharkPath() (string, error) {
+	if path, err := exec.LookPath("wireshark"); err == nil {
+		return path, nil
+	}
+	if runtime.GOOS == "darwin" {
+		path := "/Applications/Wireshark.app/Contents/MacOS/Wireshark"
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("wireshark not found in $PATH")
 }
 
 // PcapCmd represents the pcap command
 var PcapCmd = &cobra.Command{
-	Use:           "pcap",
-	Short:         "Dump network traffic",
+	Use:   "pcap",
+	Short: "Dump network traffic",
+	Long: heredoc.Doc(`
+		Capture the device's network traffic (via pcapd) as pcapng.
+
+		Packets are annotated with the process that sent/received them and can be
+		filtered with a tcpdump style expression supporting: ip, ip6, tcp, udp, icmp,
+		icmp6, [src|dst] host/net/port, proc <name>, pid <pid> and iface <name>
+		combined with and, or, not and parentheses.`),
+	Example: heredoc.Doc(`
+		# Save HTTPS traffic to a pcapng file
+		❯ ipsw idev pcap -f 'tcp port 443' -o captures
+
+		# Watch a process's traffic live in Wireshark
+		❯ ipsw idev pcap -w -p nsurlsessiond
+
+		# Pipe into tshark
+		❯ ipsw idev pcap --stdout -f 'udp port 53' | tshark -r -`),
+	Args:          cobra.NoArgs,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -63,10 +109,26 @@ var PcapCmd = &cobra.Command{
 		color.NoColor = viper.GetBool("no-color")
 
 		udid, _ := cmd.Flags().GetString("udid")
-		proc, _ := cmd.Flags().GetString("proc")
-		output, _ := cmd.Flags().GetString("output")
+		// flags
+		proc := viper.GetString("idev.pcap.proc")
+		expr := viper.GetString("idev.pcap.filter")
+		output := viper.GetString("idev.pcap.output")
+		toStdout := viper.GetBool("idev.pcap.stdout")
+		count := viper.GetInt("idev.pcap.count")
+		quiet := viper.GetBool("idev.pcap.quiet") || toStdout
+
+		if len(proc) > 0 {
+			if len(expr) > 0 {
+				expr = fmt.Sprintf("proc %s and (%s)", proc, expr)
+			} else {
+				expr = "proc " + proc
+			}
+		}
+		filter, err := pcap.ParseFilter(expr)
+		if err != nil {
+			return err
+		}
 
-		var err error
 		var dev *lockdownd.DeviceValues
 		if len(udid) == 0 {
 			dev, err = utils.PickDevice()
@@ -91,40 +153,80 @@ var PcapCmd = &cobra.Command{
 		}
 		defer cli.Close()
 
-		pcapName := fmt.Sprintf("%s.pcap", time.Now())
-		pcapName = filepath.Join(output, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion), pcapName)
-		if err := os.MkdirAll(filepath.Dir(pcapName), 0755); err != nil {
-			return fmt.Errorf("failed to create pcap directory %s: %w", filepath.Dir(pcapName), err)
+		var out io.Writer
+		switch {
+		case toStdout:
+			out = os.Stdout
+		case viper.GetBool("idev.pcap.wireshark"):
+			wireshark, err := wiresharkPath()
+			if err != nil {
+				return err
+			}
+			ws := exec.Command(wireshark, "-k", "-i", "-")
+			stdin, err := ws.StdinPipe()
+			if err != nil {
+				return err
+			}
+			if err := ws.Start(); err != nil {
+				return fmt.Errorf("failed to start wireshark: %w", err)
+			}
+			defer func() {
+				stdin.Close()
+				ws.Wait()
+			}()
+			out = stdin
+		default:
+			pcapName := filepath.Join(output,
+				fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion),
+				fmt.Sprintf("%s.pcapng", time.Now().Format("2006-01-02T15_04_05")))
+			if err := os.MkdirAll(filepath.Dir(pcapName), 0o750); err != nil {
+				return fmt.Errorf("failed to create pcap directory %s: %w", filepath.Dir(pcapName), err)
+			}
+			pcapfile, err := os.Create(pcapName)
+			if err != nil {
+				return fmt.Errorf("failed to create pcap file: %w", err)
+			}
+			defer pcapfile.Close()
+			log.Infof("Capturing to %s", pcapName)
+			out = pcapfile
 		}
-		pcapfile, err := os.Create(pcapName)
+
+		ng, err := pcap.NewNGWriter(out)
 		if err != nil {
-			return fmt.Errorf("failed to create pcap file: %w", err)
+			return fmt.Errorf("failed to write pcapng header: %w", err)
 		}
-		defer pcapfile.Close()
 
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
+		var captured int
 		if err := ctrlc.Default.Run(ctx, func() error {
-			if err := cli.ReadPacket(ctx, proc, pcapfile, func(hdr pcap.IOSPacketHeader, data []byte) {
-				var subProc string
-				if len(bytes.Trim(hdr.SubProcName[:], "\x00")) > 0 {
-					subProc = fmt.Sprintf(", Sub Process %s[%s]", colorProc(string(hdr.SubProcName[:])), colorDebug(int32(bits.ReverseBytes32(hdr.SubPid))))
+			if err := cli.Packets(ctx, func(p *pcap.Packet) error {
+				if !filter.Match(p) {
+					return nil
+				}
+				if err := ng.WritePacket(p); err != nil {
+					return fmt.Errorf("failed to write packet: %w", err)
+				}
+				if !quiet {
+					var subProc string
+					if len(p.SubProcess) > 0 {
+						subProc = fmt.Sprintf(", Sub Process %s[%s]", colorProc(p.SubProcess), colorDebug(p.SubPID))
+					}
+					fmt.Printf("%s: Process %s[%s]%s, Interface: %s (%s) %s\n%s\n",
+						colorTime(p.Timestamp.Format("02Jan06 15:04:05")),
+						colorProc(p.Process),
+						colorDebug(p.PID),
+						subProc,
+						colorDebug(p.Interface),
+						colorLib(p.Header.InterfaceType),
+						colorNotice(p.Header.ProtocolFamily),
+						strings.TrimSuffix(utils.HexDump(p.Data, 0), "\n"))
 				}
-				var sevice string
-				if bits.ReverseBytes32(hdr.Svc) > 0 {
-					sevice = fmt.Sprintf(", Service %s", colorDebug(int32(bits.ReverseBytes32(hdr.Svc))))
+				if captured++; count > 0 && captured >= count {
+					cancel()
 				}
-				fmt.Printf("%s: Process %s[%s]%s%s, Interface: %s (%s) %s\n%s\n",
-					colorTime(time.Unix(int64(hdr.Seconds), int64(hdr.MicroSeconds)).Format("02Jan06 15:04:05")),
-					colorProc(string(hdr.ProcName[:])),
-					colorDebug(int32(bits.ReverseBytes32(hdr.Pid))),
-					subProc,
-					sevice,
-					colorDebug(string(hdr.InterfaceName[:])),
-					colorLib(hdr.InterfaceType),
-					colorNotice(hdr.ProtocolFamily),
-					utils.HexDump(data, 0))
+				return nil
 			}); err != nil {
 				return fmt.Errorf("failed to read packets: %w", err)
 			}
@@ -136,6 +238,7 @@ var PcapCmd = &cobra.Command{
 				return err
 			}
 		}
+		log.Infof("Captured %d packets", captured)
 
 		return nil
 	},
@@ -0,0 +1,147 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/diagnostics"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{
+		newPowerCmd("reboot", []string{"restart"}, "Restart", "Reboot the device", "Rebooting"),
+		newPowerCmd("shutdown", nil, "Shutdown", "Shut down the device", "Shutting down"),
+		newPowerCmd("sleep", nil, "Sleep", "Put the device to sleep", "Sleeping"),
+	} {
+		IDevCmd.AddCommand(cmd)
+	}
+}
+
+// waitForDisconnect waits for the device to drop off usbmuxd
+func waitForDisconnect(udid string, timeout time.Duration) error {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(time.Second) {
+		if found, err := normalModeDevice(udid); err == nil && len(found) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out waiting for device %s to disconnect", udid)
+}
+
+// waitForLockdown waits for the device to come back and lockdownd to accept a session
+func waitForLockdown(udid string, timeout time.Duration) error {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(2 * time.Second) {
+		if found, err := normalModeDevice(udid); err != nil || len(found) == 0 {
+			continue
+		}
+		if cli, err := lockdownd.NewClient(udid); err == nil {
+			cli.Close()
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out waiting for device %s to come back", udid)
+}
+
+func newPowerCmd(use string, aliases []string, action, short, doing string) *cobra.Command {
+	key := func(flag string) string { return "idev." + use + "." + flag }
+	cmd := &cobra.Command{
+		Use:     use,
+		Aliases: aliases,
+		Short:   short,
+		Example: heredoc.Docf(`
+			# %s and wait for it
+			❯ ipsw idev %s --wait`, short, use),
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if viper.GetBool("verbose") {
+				log.SetLevel(log.DebugLevel)
+			}
+			color.NoColor = viper.GetBool("no-color")
+
+			udid, _ := cmd.Flags().GetString("udid")
+			wait := viper.GetBool(key("wait"))
+			timeout := viper.GetDuration(key("timeout"))
+
+			if len(udid) == 0 {
+				dev, err := utils.PickDevice()
+				if err != nil {
+					return fmt.Errorf("failed to pick USB connected devices: %w", err)
+				}
+				udid = dev.UniqueDeviceID
+			}
+
+			cli, err := diagnostics.NewClient(udid)
+			if err != nil {
+				return fmt.Errorf("failed to connect to diagnostics: %w", err)
+			}
+
+			log.WithField("udid", udid).Infof("%s device...", doing)
+			err = cli.Power(action, diagnostics.PowerOptions{
+				// the action happens once we disconnect
+				WaitForDisconnect: true,
+				DisplayPass:       viper.GetBool(key("pass")),
+				DisplayFail:       viper.GetBool(key("fail")),
+			})
+			cli.Goodbye()
+			cli.Close()
+			if err != nil {
+				return err
+			}
+
+			if !wait || action == "Sleep" {
+				return nil
+			}
+			if err := waitForDisconnect(udid, timeout); err != nil {
+				return err
+			}
+			if action == "Shutdown" {
+				log.Info("Device shut down")
+				return nil
+			}
+			log.Info("Waiting for device to boot")
+			if err := waitForLockdown(udid, timeout); err != nil {
+				return err
+			}
+			log.Info("Device is back")
+			return nil
+		},
+	}
+	cmd.Flags().BoolP("wait", "w", false, "Wait for the device to go down (and come back up after a reboot)")
+	cmd.Flags().Duration("timeout", 3*time.Minute, "How long to wait for the device")
+	cmd.Flags().Bool("pass", false, "Show a pass screen first")
+	cmd.Flags().Bool("fail", false, "Show a fail screen first")
+	for _, flag := range []string{"wait", "timeout", "pass", "fail"} {
+		viper.BindPFlag(key(flag), cmd.Flags().Lookup(flag))
+	}
+	return cmd
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/blacktop/ipsw/pkg/usb/dvt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	IDevCmd.AddCommand(ProcCmd)
+
+	ProcCmd.PersistentFlags().String("rsd", "", "RemoteServiceDiscovery address of an iOS17+ device's CoreDevice tunnel (i.e. fd7b:e5b:6f53::1)")
+	viper.BindPFlag("idev.proc.rsd", ProcCmd.PersistentFlags().Lookup("rsd"))
+}
+
+// ProcCmd represents the proc command
+var ProcCmd = &cobra.Command{
+	Use:   "proc",
+	Short: "Process and performance commands (via instruments)",
+	Long:  "NOTE: requires a mounted developer disk image (see 'ipsw idev img mount').",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// instrumentsClient connects to the instruments remote server over RemoteXPC for tunneled iOS17+ devices or lockdownd otherwise
+func instrumentsClient(cmd *cobra.Command) (*dvt.Client, error) {
+	udid, _ := cmd.Flags().GetString("udid")
+
+	dev, r, err := imgDevice(udid, viper.GetString("idev.proc.rsd"))
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		cli, err := dvt.NewRemoteClient(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to instruments over RemoteXPC: %w", err)
+		}
+		return cli, nil
+	}
+	cli, err := dvt.NewClient(dev.UniqueDeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to instruments (is the developer disk image mounted?): %w", err)
+	}
+	return cli, nil
+}
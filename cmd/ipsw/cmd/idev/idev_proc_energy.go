@@ -0,0 +1,104 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/dvt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ProcCmd.AddCommand(idevProcEnergyCmd)
+
+	idevProcEnergyCmd.Flags().DurationP("interval", "i", time.Second, "Sampling interval")
+	idevProcEnergyCmd.Flags().BoolP("json", "j", false, "Stream samples as JSON lines")
+}
+
+// idevProcEnergyCmd represents the energy command
+var idevProcEnergyCmd = &cobra.Command{
+	Use:   "energy <PID> [PID...]",
+	Short: "Live energy impact sampling of processes",
+	Example: heredoc.Doc(`
+		# Sample the energy impact of SpringBoard
+		❯ ipsw idev proc energy $(ipsw idev proc ls --name SpringBoard --json | jq '.[0].pid')`),
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		var pids []int
+		for _, arg := range args {
+			pid, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid pid %s: %w", arg, err)
+			}
+			pids = append(pids, pid)
+		}
+
+		cli, err := instrumentsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		enc := json.NewEncoder(os.Stdout)
+
+		return cli.Energy(ctx, pids, interval, func(s *dvt.EnergySample) error {
+			if asJSON {
+				return enc.Encode(s)
+			}
+			fmt.Printf("%s  %s  cost: %s  CPU: %.2f  GPU: %.2f  network: %.2f  location: %.2f\n",
+				color.New(color.Bold).Sprint(s.Timestamp.Format("15:04:05")),
+				color.New(color.FgHiBlue).Sprintf("%d", s.PID),
+				color.New(color.FgHiYellow).Sprintf("%.2f", toFloat(s.Energy["energy.cost"])),
+				toFloat(s.Energy["energy.CPU.cost"]),
+				toFloat(s.Energy["energy.GPU.cost"]),
+				toFloat(s.Energy["energy.networking.cost"]),
+				toFloat(s.Energy["energy.location.cost"]))
+			return nil
+		})
+	},
+}
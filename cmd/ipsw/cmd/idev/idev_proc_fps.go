@@ -0,0 +1,85 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/dvt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ProcCmd.AddCommand(idevProcFpsCmd)
+
+	idevProcFpsCmd.Flags().BoolP("json", "j", false, "Stream samples as JSON lines")
+}
+
+// idevProcFpsCmd represents the fps command
+var idevProcFpsCmd = &cobra.Command{
+	Use:           "fps",
+	Short:         "Live CoreAnimation FPS and GPU utilization",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		cli, err := instrumentsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		enc := json.NewEncoder(os.Stdout)
+
+		return cli.Graphics(ctx, func(s *dvt.GraphicsSample) error {
+			if asJSON {
+				return enc.Encode(s)
+			}
+			fmt.Printf("%s  FPS: %s  Device: %.0f%%  Renderer: %.0f%%  Tiler: %.0f%%\n",
+				color.New(color.Bold).Sprint(s.Timestamp.Format("15:04:05")),
+				color.New(color.FgHiGreen).Sprintf("%3d", s.FPS),
+				toFloat(s.Stats["Device Utilization %"]),
+				toFloat(s.Stats["Renderer Utilization %"]),
+				toFloat(s.Stats["Tiler Utilization %"]))
+			return nil
+		})
+	},
+}
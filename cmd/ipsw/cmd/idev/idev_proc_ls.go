@@ -0,0 +1,112 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/dvt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ProcCmd.AddCommand(idevProcLsCmd)
+
+	idevProcLsCmd.Flags().BoolP("apps", "a", false, "Only list applications")
+	idevProcLsCmd.Flags().StringP("name", "n", "", "Only list processes whose name contains this string")
+	idevProcLsCmd.Flags().BoolP("json", "j", false, "Display processes as JSON")
+}
+
+// idevProcLsCmd represents the ls command
+var idevProcLsCmd = &cobra.Command{
+	Use:           "ls",
+	Short:         "List running processes",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		appsOnly, _ := cmd.Flags().GetBool("apps")
+		name, _ := cmd.Flags().GetString("name")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		cli, err := instrumentsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		procs, err := cli.ProcessList()
+		if err != nil {
+			return fmt.Errorf("failed to get process list: %w", err)
+		}
+
+		var filtered []dvt.Process
+		for _, p := range procs {
+			if appsOnly && !p.IsApplication {
+				continue
+			}
+			if len(name) > 0 && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(name)) {
+				continue
+			}
+			filtered = append(filtered, p)
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(filtered)
+			if err != nil {
+				return fmt.Errorf("failed to marshal processes: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PID\tNAME\tBUNDLE ID\tSTARTED")
+		for _, p := range filtered {
+			name := p.Name
+			if p.IsApplication {
+				name = color.New(color.Bold, color.FgHiGreen).Sprint(name)
+			}
+			started := ""
+			if !p.StartDate.IsZero() {
+				started = p.StartDate.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", p.PID, name, p.BundleID, started)
+		}
+		w.Flush()
+
+		return nil
+	},
+}
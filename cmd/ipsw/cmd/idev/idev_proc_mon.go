@@ -0,0 +1,149 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/dvt"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ProcCmd.AddCommand(idevProcMonCmd)
+
+	idevProcMonCmd.Flags().DurationP("interval", "i", time.Second, "Sampling interval")
+	idevProcMonCmd.Flags().IntSliceP("pid", "p", []int{}, "Only sample these pids")
+	idevProcMonCmd.Flags().StringSlice("proc-attr", []string{}, "Process attributes to sample (default: pid,name,cpuUsage,physFootprint,...)")
+	idevProcMonCmd.Flags().StringSlice("sys-attr", []string{}, "System attributes to sample (default: all)")
+	idevProcMonCmd.Flags().IntP("top", "t", 10, "Number of processes to display (sorted by CPU usage)")
+	idevProcMonCmd.Flags().BoolP("json", "j", false, "Stream samples as JSON lines")
+}
+
+// idevProcMonCmd represents the mon command
+var idevProcMonCmd = &cobra.Command{
+	Use:   "mon",
+	Short: "Live system and process CPU/memory sampling",
+	Example: heredoc.Doc(`
+		# Show the top 10 processes by CPU usage every second
+		❯ ipsw idev proc mon
+		# Stream samples of two processes as JSON lines every 500ms
+		❯ ipsw idev proc mon --pid 123 --pid 456 --interval 500ms --json`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		pids, _ := cmd.Flags().GetIntSlice("pid")
+		procAttrs, _ := cmd.Flags().GetStringSlice("proc-attr")
+		sysAttrs, _ := cmd.Flags().GetStringSlice("sys-attr")
+		top, _ := cmd.Flags().GetInt("top")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		cli, err := instrumentsClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		enc := json.NewEncoder(os.Stdout)
+
+		return cli.Sysmon(ctx, &dvt.SysmonConfig{
+			Interval:          interval,
+			ProcessAttributes: procAttrs,
+			SystemAttributes:  sysAttrs,
+			PIDs:              pids,
+		}, func(s *dvt.SysmonSample) error {
+			if asJSON {
+				return enc.Encode(s)
+			}
+			printSysmonSample(s, top)
+			return nil
+		})
+	},
+}
+
+func toFloat(v any) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case float32:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case int:
+		return float64(t)
+	}
+	return 0
+}
+
+func printSysmonSample(s *dvt.SysmonSample, top int) {
+	if len(s.Processes) == 0 {
+		return
+	}
+	fmt.Printf("%s  CPU: %s\n",
+		color.New(color.Bold).Sprint(s.Timestamp.Format("15:04:05")),
+		color.New(color.FgHiYellow).Sprintf("%.1f%%", toFloat(s.CPUUsage["CPU_TotalLoad"])))
+
+	procs := s.Processes
+	sort.SliceStable(procs, func(i, j int) bool {
+		return toFloat(procs[i]["cpuUsage"]) > toFloat(procs[j]["cpuUsage"])
+	})
+	if top > 0 && len(procs) > top {
+		procs = procs[:top]
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tNAME\tCPU\tMEMORY\tTHREADS")
+	for _, p := range procs {
+		mem := ""
+		if v, ok := p["physFootprint"]; ok {
+			mem = humanize.Bytes(uint64(toFloat(v)))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%.1f%%\t%s\t%v\n", p["pid"], p["name"], toFloat(p["cpuUsage"]), mem, p["threadCount"])
+	}
+	w.Flush()
+	fmt.Println()
+}
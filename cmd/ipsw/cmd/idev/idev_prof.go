@@ -31,9 +31,10 @@ func init() {
 
 // ProfCmd represents the prof command
 var ProfCmd = &cobra.Command{
-	Use:   "prof",
-	Short: "Profile commands",
-	Args:  cobra.NoArgs,
+	Use:     "prof",
+	Aliases: []string{"profile"},
+	Short:   "Configuration profile commands",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -22,8 +22,14 @@ THE SOFTWARE.
 package idev
 
 import (
+	"crypto"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/usb/mcinstall"
@@ -34,16 +40,45 @@ import (
 
 func init() {
 	ProfCmd.AddCommand(profInstallCmd)
+
+	profInstallCmd.Flags().Bool("cert", false, "Install the file(s) as CA root certificates (default for .pem/.cer/.crt/.der files)")
+	profInstallCmd.Flags().String("supervisor", "", "Supervising organization identity (.p12) to install profiles silently on supervised devices")
+	profInstallCmd.Flags().String("password", "", "Supervisor identity password")
+	profInstallCmd.MarkFlagFilename("supervisor", "p12")
+	viper.BindPFlag("idev.prof.install.cert", profInstallCmd.Flags().Lookup("cert"))
+	viper.BindPFlag("idev.prof.install.supervisor", profInstallCmd.Flags().Lookup("supervisor"))
+	viper.BindPFlag("idev.prof.install.password", profInstallCmd.Flags().Lookup("password"))
+}
+
+func isCertificateFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pem", ".cer", ".crt", ".der":
+		return true
+	}
+	return false
 }
 
 // profInstallCmd represents the install command
 var profInstallCmd = &cobra.Command{
-	Use:           "install <PROF_FILE>",
-	Aliases:       []string{"i"},
-	Short:         "Install profile",
+	Use:     "install <PROF_FILE>...",
+	Aliases: []string{"i"},
+	Short:   "Install profile",
+	Long: heredoc.Doc(`
+		Install configuration profiles (.mobileconfig) or CA root certificates.
+
+		The install has to be confirmed in Settings > General > VPN & Device Management unless the
+		device is supervised and the supervising organization's identity is given with --supervisor.
+		Installed root certificates also need full trust enabled in Settings > General > About > Certificate Trust Settings.`),
+	Example: heredoc.Doc(`
+		# Install a profile
+		❯ ipsw idev prof install feature_flags.mobileconfig
+		# Install a proxy's root CA
+		❯ ipsw idev prof install ~/.mitmproxy/mitmproxy-ca-cert.pem
+		# Silently install profiles on a supervised device
+		❯ ipsw idev prof install --supervisor supervisor.p12 --password $PASS *.mobileconfig`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	Args:          cobra.ExactArgs(1),
+	Args:          cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
@@ -52,6 +87,8 @@ var profInstallCmd = &cobra.Command{
 		color.NoColor = viper.GetBool("no-color")
 
 		udid, _ := cmd.Flags().GetString("udid")
+		asCert := viper.GetBool("idev.prof.install.cert")
+		supervisor := viper.GetString("idev.prof.install.supervisor")
 
 		if len(udid) == 0 {
 			dev, err := utils.PickDevice()
@@ -61,14 +98,49 @@ var profInstallCmd = &cobra.Command{
 			udid = dev.UniqueDeviceID
 		}
 
+		var cert *x509.Certificate
+		var key crypto.PrivateKey
+		if len(supervisor) > 0 {
+			p12, err := os.ReadFile(supervisor)
+			if err != nil {
+				return fmt.Errorf("failed to read supervisor identity: %w", err)
+			}
+			cert, key, err = mcinstall.ParseSupervisorIdentity(p12, viper.GetString("idev.prof.install.password"))
+			if err != nil {
+				return err
+			}
+		}
+
 		mc, err := mcinstall.NewClient(udid)
 		if err != nil {
 			return err
 		}
+		defer mc.Close()
+
+		for _, path := range args {
+			dat, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if asCert || isCertificateFile(path) {
+				if dat, err = mcinstall.RootCertificateProfile(dat); err != nil {
+					return fmt.Errorf("failed to create root certificate profile for %s: %w", path, err)
+				}
+			}
+
+			log.WithField("profile", path).Info("Installing profile to device...")
+			if cert != nil {
+				err = mc.InstallSilent(dat, cert, key)
+			} else {
+				err = mc.InstallData(dat)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to install profile %s: %w", path, err)
+			}
+		}
 
-		log.Info("Installing profile to device...")
-		if err := mc.Install(args[0]); err != nil {
-			return fmt.Errorf("failed to install profile %s: %w", args[0], err)
+		if cert == nil {
+			log.Info("Confirm the install(s) in Settings > General > VPN & Device Management")
 		}
 
 		return nil
@@ -42,7 +42,8 @@ func init() {
 // profLsCmd represents the ls command
 var profLsCmd = &cobra.Command{
 	Use:           "ls",
-	Short:         "List installed provision profiles",
+	Aliases:       []string{"list"},
+	Short:         "List installed configuration profiles",
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -67,6 +68,7 @@ var profLsCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		defer mc.Close()
 
 		profs, err := mc.List()
 		if err != nil {
@@ -39,7 +39,8 @@ func init() {
 // profRmCmd represents the rm command
 var profRmCmd = &cobra.Command{
 	Use:           "rm <PROF>",
-	Short:         "Remove profile by name",
+	Aliases:       []string{"remove"},
+	Short:         "Remove profile by identifier, UUID or name",
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Args:          cobra.ExactArgs(1),
@@ -64,6 +65,7 @@ var profRmCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		defer mc.Close()
 
 		log.Info("Removing profile...")
 		if err := mc.Remove(args[0]); err != nil {
@@ -0,0 +1,162 @@
+//go:build libusb
+
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/irecv"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RecoveryCmd.AddCommand(recoveryCommandCmd)
+	RecoveryCmd.AddCommand(recoveryGetenvCmd)
+	RecoveryCmd.AddCommand(recoverySetenvCmd)
+	RecoveryCmd.AddCommand(recoveryRebootCmd)
+	RecoveryCmd.AddCommand(recoveryInfoCmd)
+
+	recoverySetenvCmd.Flags().BoolP("save", "s", false, "Save environment to NVRAM (saveenv)")
+	viper.BindPFlag("idev.recovery.setenv.save", recoverySetenvCmd.Flags().Lookup("save"))
+}
+
+func recoveryClient() (*irecv.Client, error) {
+	if viper.GetBool("verbose") {
+		log.SetLevel(log.DebugLevel)
+	}
+	color.NoColor = viper.GetBool("no-color")
+
+	cli, err := irecv.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to irecv: %w", err)
+	}
+	return cli, nil
+}
+
+// recoveryCommandCmd represents the recovery command command
+var recoveryCommandCmd = &cobra.Command{
+	Use:           "command <CMD>",
+	Aliases:       []string{"cmd"},
+	Short:         "Send an iBoot command to a device in Recovery mode",
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := recoveryClient()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		return cli.SendCommand(strings.Join(args, " "))
+	},
+}
+
+// recoveryGetenvCmd represents the recovery getenv command
+var recoveryGetenvCmd = &cobra.Command{
+	Use:           "getenv <NAME>",
+	Short:         "Get an iBoot environment variable",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := recoveryClient()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		val, err := cli.Getenv(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get env '%s': %w", args[0], err)
+		}
+		fmt.Println(val)
+
+		return nil
+	},
+}
+
+// recoverySetenvCmd represents the recovery setenv command
+var recoverySetenvCmd = &cobra.Command{
+	Use:           "setenv <NAME> <VALUE>",
+	Short:         "Set an iBoot environment variable",
+	Args:          cobra.MinimumNArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := recoveryClient()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		return cli.Setenv(args[0], strings.Join(args[1:], " "), viper.GetBool("idev.recovery.setenv.save"))
+	},
+}
+
+// recoveryRebootCmd represents the recovery reboot command
+var recoveryRebootCmd = &cobra.Command{
+	Use:           "reboot",
+	Short:         "Reboot a device in Recovery mode",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := recoveryClient()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		return cli.Reboot(false)
+	},
+}
+
+// recoveryInfoCmd represents the recovery info command
+var recoveryInfoCmd = &cobra.Command{
+	Use:           "info",
+	Short:         "Display info about a device in Recovery/DFU mode",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cli, err := recoveryClient()
+		if err != nil {
+			udid, _ := cmd.Flags().GetString("udid")
+			if found, _ := normalModeDevice(udid); len(found) > 0 {
+				return fmt.Errorf("device %s is in Normal mode (use `ipsw idev enter-recovery` first)", found)
+			}
+			return err
+		}
+		defer cli.Close()
+
+		fmt.Println(cli)
+
+		return nil
+	},
+}
@@ -0,0 +1,158 @@
+//go:build libusb
+
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/usb/irecv"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+func init() {
+	RecoveryCmd.AddCommand(recoverySendCmd)
+
+	recoverySendCmd.Flags().StringP("im4m", "m", "", "IM4M manifest (or SHSH blob) to stitch IM4P into an IMG4 before sending")
+	recoverySendCmd.Flags().StringP("im4r", "r", "", "IM4R restore info to include when stitching an IMG4")
+	recoverySendCmd.Flags().StringP("command", "c", "", "iBoot command to run after upload (e.g. 'go' or 'bootx')")
+	recoverySendCmd.MarkFlagFilename("im4m")
+	recoverySendCmd.MarkFlagFilename("im4r")
+	viper.BindPFlag("idev.recovery.send.im4m", recoverySendCmd.Flags().Lookup("im4m"))
+	viper.BindPFlag("idev.recovery.send.im4r", recoverySendCmd.Flags().Lookup("im4r"))
+	viper.BindPFlag("idev.recovery.send.command", recoverySendCmd.Flags().Lookup("command"))
+}
+
+func readIm4m(path string) ([]byte, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IM4M %s: %v", path, err)
+	}
+	if bytes.Contains(dat[:min(len(dat), 0x40)], []byte("<?xml")) || bytes.HasPrefix(dat, []byte("bplist")) {
+		var shsh struct {
+			ApImg4Ticket []byte `plist:"ApImg4Ticket,omitempty"`
+		}
+		if _, err := plist.Unmarshal(dat, &shsh); err != nil {
+			return nil, fmt.Errorf("failed to parse SHSH blob %s: %v", path, err)
+		}
+		if len(shsh.ApImg4Ticket) == 0 {
+			return nil, fmt.Errorf("SHSH blob %s does not contain an ApImg4Ticket", path)
+		}
+		return shsh.ApImg4Ticket, nil
+	}
+	return dat, nil
+}
+
+// recoverySendCmd represents the recovery send command
+var recoverySendCmd = &cobra.Command{
+	Use:           "send <IMG4|IM4P>",
+	Short:         "Upload an IMG4 to a device in Recovery/DFU mode",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		infile := filepath.Clean(args[0])
+
+		dat, err := os.ReadFile(infile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", infile, err)
+		}
+
+		if ok, _ := magic.IsIm4p(infile); ok {
+			if !viper.IsSet("idev.recovery.send.im4m") {
+				return fmt.Errorf("input is an IM4P: you must supply an IM4M (--im4m) to create an IMG4")
+			}
+			im4m, err := readIm4m(viper.GetString("idev.recovery.send.im4m"))
+			if err != nil {
+				return err
+			}
+			var im4r []byte
+			if viper.IsSet("idev.recovery.send.im4r") {
+				im4r, err = os.ReadFile(viper.GetString("idev.recovery.send.im4r"))
+				if err != nil {
+					return fmt.Errorf("failed to read IM4R: %v", err)
+				}
+			}
+			log.Info("Creating IMG4 from IM4P and IM4M")
+			dat, err = img4.Create(dat, im4m, im4r)
+			if err != nil {
+				return fmt.Errorf("failed to create IMG4: %v", err)
+			}
+		}
+
+		cli, err := irecv.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to connect to irecv: %w", err)
+		}
+		defer cli.Close()
+
+		log.WithFields(log.Fields{
+			"mode": cli.Mode,
+			"ecid": cli.ECID,
+		}).Infof("Sending %s", filepath.Base(infile))
+
+		p := mpb.New(mpb.WithWidth(60))
+		bar := p.AddBar(int64(len(dat)),
+			mpb.PrependDecorators(
+				decor.CountersKibiByte("% .2f / % .2f"),
+			),
+			mpb.AppendDecorators(
+				decor.Percentage(),
+			),
+		)
+		if err := cli.SendBuffer(dat, func(sent, total int) {
+			bar.SetCurrent(int64(min(sent, len(dat))))
+		}); err != nil {
+			bar.Abort(false)
+			p.Wait()
+			return fmt.Errorf("failed to send %s: %w", infile, err)
+		}
+		bar.SetCurrent(int64(len(dat)))
+		p.Wait()
+
+		if viper.IsSet("idev.recovery.send.command") {
+			if err := cli.SendCommand(viper.GetString("idev.recovery.send.command")); err != nil {
+				return fmt.Errorf("failed to send command: %w", err)
+			}
+		}
+
+		return nil
+	},
+}
@@ -22,11 +22,7 @@ THE SOFTWARE.
 package idev
 
 import (
-	"fmt"
-
 	"github.com/apex/log"
-	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -52,24 +48,6 @@ var restoreEnterCmd = &cobra.Command{
 
 		udid, _ := cmd.Flags().GetString("udid")
 
-		if len(udid) == 0 {
-			dev, err := utils.PickDevice()
-			if err != nil {
-				return fmt.Errorf("failed to pick USB connected devices: %w", err)
-			}
-			udid = dev.UniqueDeviceID
-		}
-
-		ldc, err := lockdownd.NewClient(udid)
-		if err != nil {
-			return fmt.Errorf("failed to connect to lockdownd: %w", err)
-		}
-		defer ldc.Close()
-
-		if _, err := ldc.EnterRecovery(); err != nil {
-			return fmt.Errorf("failed to enter recovery: %w", err)
-		}
-
-		return nil
+		return enterRecovery(udid)
 	},
 }
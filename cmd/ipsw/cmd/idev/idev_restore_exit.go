@@ -24,10 +24,7 @@ THE SOFTWARE.
 package idev
 
 import (
-	"fmt"
-
 	"github.com/apex/log"
-	"github.com/blacktop/ipsw/pkg/usb/irecv"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -51,19 +48,6 @@ var restoreExitCmd = &cobra.Command{
 		}
 		color.NoColor = viper.GetBool("no-color")
 
-		cli, err := irecv.NewClient()
-		if err != nil {
-			return fmt.Errorf("failed to connect to irecv: %w", err)
-		}
-		defer cli.Close()
-
-		if err := cli.SetAutoboot(true); err != nil {
-			return fmt.Errorf("failed to set autoboot to true: %w", err)
-		}
-		if err := cli.Reboot(true); err != nil {
-			return fmt.Errorf("failed to reboot: %w", err)
-		}
-
-		return nil
+		return exitRecovery()
 	},
 }
@@ -40,33 +40,44 @@ func init() {
 	IDevCmd.AddCommand(ScreenCmd)
 
 	ScreenCmd.Flags().StringP("output", "o", "", "Folder to save screenshot(s)")
+	ScreenCmd.Flags().IntP("count", "n", 1, "Number of screenshots to take")
+	ScreenCmd.Flags().Duration("interval", time.Second, "Time between screenshots")
 	ScreenCmd.MarkFlagDirname("output")
 }
 
-func saveScreenshot(dev *lockdownd.DeviceValues, destPath string) error {
+func saveScreenshot(dev *lockdownd.DeviceValues, destPath string, count int, interval time.Duration) error {
 	cli, err := screenshot.NewClient(dev.UniqueDeviceID)
 	if err != nil {
 		return fmt.Errorf("failed to connect to iDevice with UUID %s: %w", dev.UniqueDeviceID, err)
 	}
 	defer cli.Close()
 
-	png, err := cli.Screenshot()
-	if err != nil {
-		return fmt.Errorf("failed to get screenshot: %w", err)
-	}
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		png, err := cli.Screenshot()
+		if err != nil {
+			return fmt.Errorf("failed to get screenshot: %w", err)
+		}
 
-	fname := fmt.Sprintf("screenshot_%s.png", time.Now().Format("02Jan2006_15:04:05MST"))
-	fname = filepath.Join(destPath, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion), fname)
-	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
-		return fmt.Errorf("failed to create screenshot directory %s: %w", filepath.Dir(fname), err)
+		fname := fmt.Sprintf("screenshot_%s.png", time.Now().Format("02Jan2006_15:04:05.000MST"))
+		fname = filepath.Join(destPath, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion), fname)
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			return fmt.Errorf("failed to create screenshot directory %s: %w", filepath.Dir(fname), err)
+		}
+		log.Infof("Creating screenshot: %s", fname)
+		if err := os.WriteFile(fname, png, 0660); err != nil {
+			return err
+		}
 	}
-	log.Infof("Creating screenshot: %s", fname)
-	return os.WriteFile(fname, png, 0660)
+	return nil
 }
 
 // ScreenCmd represents the screen command
 var ScreenCmd = &cobra.Command{
 	Use:           "screen",
+	Aliases:       []string{"screenshot"},
 	Short:         "Dump screenshot as a PNG",
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -79,6 +90,8 @@ var ScreenCmd = &cobra.Command{
 
 		udid, _ := cmd.Flags().GetString("udid")
 		output, _ := cmd.Flags().GetString("output")
+		count, _ := cmd.Flags().GetInt("count")
+		interval, _ := cmd.Flags().GetDuration("interval")
 
 		if len(udid) > 0 {
 			ldc, err := lockdownd.NewClient(udid)
@@ -101,7 +114,7 @@ var ScreenCmd = &cobra.Command{
 				return fmt.Errorf("for device %s: ensure Developer Mode is enabled on iOS16+ AND %w", dev.UniqueDeviceID, err)
 			}
 
-			return saveScreenshot(dev, output)
+			return saveScreenshot(dev, output, count, interval)
 		} else {
 			devs, err := utils.PickDevices()
 			if err != nil {
@@ -118,7 +131,7 @@ var ScreenCmd = &cobra.Command{
 					return fmt.Errorf("for device %s: ensure Developer Mode is enabled on iOS16+ AND %w", dev.UniqueDeviceID, err)
 				}
 
-				if err := saveScreenshot(dev, output); err != nil {
+				if err := saveScreenshot(dev, output, count, interval); err != nil {
 					return fmt.Errorf("failed to save screenshot: %w", err)
 				}
 			}
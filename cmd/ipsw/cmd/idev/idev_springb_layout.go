@@ -0,0 +1,67 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/springboard"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	SpringbCmd.AddCommand(SpringbLayoutCmd)
+}
+
+// SpringbLayoutCmd represents the springb layout command
+var SpringbLayoutCmd = &cobra.Command{
+	Use:     "layout",
+	Aliases: []string{"icons"},
+	Short:   "Export/Import the home screen icon layout",
+	Long: heredoc.Doc(`
+		Export the home screen icon layout (the dock, pages and folders) to a plist
+		and import it onto the same or another device to reproduce a test setup.`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func springboardClient(cmd *cobra.Command) (*springboard.Client, error) {
+	udid, _ := cmd.Flags().GetString("udid")
+
+	if len(udid) == 0 {
+		dev, err := utils.PickDevice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick USB connected devices: %w", err)
+		}
+		udid = dev.UniqueDeviceID
+	}
+
+	cli, err := springboard.NewClient(udid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to springboard: %w", err)
+	}
+	return cli, nil
+}
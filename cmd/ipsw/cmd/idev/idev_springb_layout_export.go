@@ -0,0 +1,90 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	SpringbLayoutCmd.AddCommand(idevSpringbLayoutExportCmd)
+
+	idevSpringbLayoutExportCmd.Flags().StringP("output", "o", "", "File to save the layout to (.plist or .json, default: stdout)")
+	idevSpringbLayoutExportCmd.MarkFlagFilename("output", "plist", "json")
+	viper.BindPFlag("idev.springb.layout.export.output", idevSpringbLayoutExportCmd.Flags().Lookup("output"))
+}
+
+// idevSpringbLayoutExportCmd represents the springb layout export command
+var idevSpringbLayoutExportCmd = &cobra.Command{
+	Use:           "export",
+	Short:         "Export the home screen layout",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		output := viper.GetString("idev.springb.layout.export.output")
+
+		cli, err := springboardClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		state, err := cli.GetIconState()
+		if err != nil {
+			return fmt.Errorf("failed to get icon state: %w", err)
+		}
+
+		var dat []byte
+		if strings.EqualFold(filepath.Ext(output), ".json") {
+			dat, err = json.MarshalIndent(state, "", "  ")
+		} else {
+			dat, err = plist.MarshalIndent(state, plist.XMLFormat, "\t")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal icon state: %w", err)
+		}
+
+		if len(output) == 0 {
+			fmt.Println(string(dat))
+			return nil
+		}
+		log.Infof("Saving home screen layout to %s", output)
+		return os.WriteFile(output, dat, 0o644)
+	},
+}
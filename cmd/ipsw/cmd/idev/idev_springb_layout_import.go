@@ -0,0 +1,89 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	SpringbLayoutCmd.AddCommand(idevSpringbLayoutImportCmd)
+}
+
+// idevSpringbLayoutImportCmd represents the springb layout import command
+var idevSpringbLayoutImportCmd = &cobra.Command{
+	Use:           "import <LAYOUT>",
+	Short:         "Import a home screen layout",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"plist", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		dat, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read layout: %w", err)
+		}
+		var state []any
+		if strings.EqualFold(filepath.Ext(args[0]), ".json") {
+			err = json.Unmarshal(dat, &state)
+		} else {
+			_, err = plist.Unmarshal(dat, &state)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse layout %s: %w", args[0], err)
+		}
+		if len(state) == 0 {
+			return fmt.Errorf("layout %s has no pages", args[0])
+		}
+
+		cli, err := springboardClient(cmd)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		log.Infof("Importing home screen layout (%d pages)", len(state)-1)
+		if err := cli.SetIconState(state); err != nil {
+			return fmt.Errorf("failed to set icon state: %w", err)
+		}
+		log.Info("Imported layout (apps that are not installed are skipped)")
+		return nil
+	},
+}
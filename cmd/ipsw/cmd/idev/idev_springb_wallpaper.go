@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
@@ -39,13 +40,19 @@ func init() {
 	SpringbCmd.AddCommand(idevSpringbWallpaperCmd)
 
 	idevSpringbWallpaperCmd.Flags().StringP("output", "o", "", "Folder to save wallpaper")
+	idevSpringbWallpaperCmd.Flags().BoolP("lock", "l", false, "Dump the lock screen wallpaper")
 	idevSpringbWallpaperCmd.MarkFlagDirname("output")
 }
 
 // idevSpringbWallpaperCmd represents the wallpaper command
 var idevSpringbWallpaperCmd = &cobra.Command{
-	Use:           "wallpaper",
-	Short:         "Dump wallpaper as PNG",
+	Use:   "wallpaper",
+	Short: "Dump home (or lock) screen wallpaper as PNG",
+	Long: heredoc.Doc(`
+		Dump the home (or lock) screen wallpaper as PNG.
+
+		Setting the wallpaper is NOT supported: springboardservices only vends the
+		wallpaper images and no other lockdown service sets them.`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -57,6 +64,7 @@ var idevSpringbWallpaperCmd = &cobra.Command{
 
 		udid, _ := cmd.Flags().GetString("udid")
 		output, _ := cmd.Flags().GetString("output")
+		lock, _ := cmd.Flags().GetBool("lock")
 
 		var err error
 		var dev *lockdownd.DeviceValues
@@ -83,12 +91,19 @@ var idevSpringbWallpaperCmd = &cobra.Command{
 		}
 		defer cli.Close()
 
-		pngData, err := cli.GetWallpaper()
+		var pngData []byte
+		name := "wallpaper.png"
+		if lock {
+			pngData, err = cli.GetWallpaperPreview(springboard.LockScreenWallpaper)
+			name = "lockscreen_wallpaper.png"
+		} else {
+			pngData, err = cli.GetWallpaper()
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get wallpaper: %w", err)
 		}
 
-		fname := filepath.Join(output, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion), "wallpaper.png")
+		fname := filepath.Join(output, fmt.Sprintf("%s_%s_%s", dev.ProductType, dev.HardwareModel, dev.BuildVersion), name)
 		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
 			return fmt.Errorf("failed to create wallpaper directory %s: %w", filepath.Dir(fname), err)
 		}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/sysdiagnose"
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	IDevCmd.AddCommand(idevSysdiagnoseCmd)
+
+	idevSysdiagnoseCmd.Flags().String("rsd", "", "RemoteServiceDiscovery address of an iOS17+ device's CoreDevice tunnel (i.e. fd7b:e5b:6f53::1)")
+	idevSysdiagnoseCmd.Flags().StringP("output", "o", "", "Folder to save the sysdiagnose to")
+	idevSysdiagnoseCmd.Flags().Bool("full", false, "Collect full logs (RemoteXPC only)")
+	idevSysdiagnoseCmd.Flags().Duration("timeout", 15*time.Minute, "How long to wait for the sysdiagnose to finish")
+	idevSysdiagnoseCmd.Flags().Bool("rm", false, "Remove the sysdiagnose from the device after pulling it")
+	idevSysdiagnoseCmd.Flags().BoolP("extract", "x", false, "Unpack the sysdiagnose archive")
+	idevSysdiagnoseCmd.Flags().Bool("index", false, "Unpack the sysdiagnose and index its interesting logs")
+	idevSysdiagnoseCmd.Flags().BoolP("json", "j", false, "Print the index as JSON")
+	idevSysdiagnoseCmd.MarkFlagDirname("output")
+	viper.BindPFlag("idev.sysdiagnose.rsd", idevSysdiagnoseCmd.Flags().Lookup("rsd"))
+	viper.BindPFlag("idev.sysdiagnose.output", idevSysdiagnoseCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.sysdiagnose.full", idevSysdiagnoseCmd.Flags().Lookup("full"))
+	viper.BindPFlag("idev.sysdiagnose.timeout", idevSysdiagnoseCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("idev.sysdiagnose.rm", idevSysdiagnoseCmd.Flags().Lookup("rm"))
+	viper.BindPFlag("idev.sysdiagnose.extract", idevSysdiagnoseCmd.Flags().Lookup("extract"))
+	viper.BindPFlag("idev.sysdiagnose.index", idevSysdiagnoseCmd.Flags().Lookup("index"))
+	viper.BindPFlag("idev.sysdiagnose.json", idevSysdiagnoseCmd.Flags().Lookup("json"))
+}
+
+// idevSysdiagnoseCmd represents the sysdiagnose command
+var idevSysdiagnoseCmd = &cobra.Command{
+	Use:     "sysdiagnose",
+	Aliases: []string{"sysd"},
+	Short:   "Trigger, wait for and pull a sysdiagnose",
+	Long: heredoc.Doc(`
+		Trigger a sysdiagnose on the device, wait for it to finish and pull the archive.
+
+		iOS 17+ devices with a CoreDevice tunnel (--rsd or 'ipsw idev tunnel start' for USB iOS 17.4+) are triggered
+		over RemoteXPC, otherwise press the sysdiagnose key-chord on the device when prompted
+		(Volume Up + Volume Down + Side/Top button) and ipsw will pick up the new archive.`),
+	Example: heredoc.Doc(`
+		# Pull a new sysdiagnose into the 'diags' folder
+		❯ ipsw idev sysdiagnose -o diags
+
+		# Pull, unpack and list the interesting logs (crashes, panics, logarchive, etc.)
+		❯ ipsw idev sysdiagnose -o diags --index`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		udid, _ := cmd.Flags().GetString("udid")
+		// flags
+		output := filepath.Clean(viper.GetString("idev.sysdiagnose.output"))
+		index := viper.GetBool("idev.sysdiagnose.index")
+		extract := viper.GetBool("idev.sysdiagnose.extract") || index
+
+		if err := os.MkdirAll(output, 0o750); err != nil {
+			return fmt.Errorf("failed to create output folder: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, viper.GetDuration("idev.sysdiagnose.timeout"))
+		defer cancel()
+
+		dev, r, err := imgDevice(udid, viper.GetString("idev.sysdiagnose.rsd"))
+		if err != nil {
+			return err
+		}
+
+		var archive string
+		if r != nil {
+			tmp, err := os.CreateTemp(output, "sysdiagnose_*.tar.gz.part")
+			if err != nil {
+				return err
+			}
+			name, err := sysdiagnose.Capture(r, viper.GetBool("idev.sysdiagnose.full"), tmp)
+			tmp.Close()
+			if err != nil {
+				os.Remove(tmp.Name())
+				return err
+			}
+			archive = filepath.Join(output, filepath.Base(name))
+			if err := os.Rename(tmp.Name(), archive); err != nil {
+				return err
+			}
+		} else {
+			cli, err := sysdiagnose.NewClient(dev.UniqueDeviceID)
+			if err != nil {
+				return err
+			}
+			defer cli.Close()
+
+			existing, err := cli.Archives()
+			if err != nil {
+				return err
+			}
+			log.Warn("Trigger a sysdiagnose on the device now: press and release Volume Up + Volume Down + Side/Top button together")
+			log.Info("Waiting for sysdiagnose to finish (this can take several minutes)")
+			src, err := cli.Wait(ctx, existing)
+			if err != nil {
+				return err
+			}
+			archive = filepath.Join(output, path.Base(src))
+			log.Infof("Pulling %s", src)
+			if err := cli.Pull(archive, src); err != nil {
+				return fmt.Errorf("failed to pull %s: %w", src, err)
+			}
+			if viper.GetBool("idev.sysdiagnose.rm") {
+				if err := cli.Remove(src); err != nil {
+					return fmt.Errorf("failed to remove %s from device: %w", src, err)
+				}
+			}
+		}
+		log.Infof("Created %s", archive)
+
+		if !extract {
+			return nil
+		}
+
+		log.Info("Unpacking sysdiagnose")
+		root, err := sysdiagnose.Extract(archive, output)
+		if err != nil {
+			return err
+		}
+		log.Infof("Extracted to %s", root)
+
+		if !index {
+			return nil
+		}
+
+		entries, err := sysdiagnose.Index(root)
+		if err != nil {
+			return err
+		}
+		dat, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal index: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "index.json"), dat, 0o644); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+
+		if viper.GetBool("idev.sysdiagnose.json") {
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CATEGORY\tSIZE\tPATH")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", e.Category, humanize.Bytes(uint64(e.Size)), e.Path)
+		}
+		return w.Flush()
+	},
+}
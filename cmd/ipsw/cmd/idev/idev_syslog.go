@@ -24,18 +24,23 @@ package idev
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/usb/ostrace"
 	"github.com/blacktop/ipsw/pkg/usb/syslog"
 	"github.com/caarlos0/ctrlc"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -47,6 +52,24 @@ func init() {
 	IDevCmd.AddCommand(SyslogCmd)
 
 	SyslogCmd.Flags().Uint64P("timeout", "t", 0, "Log timeout in seconds")
+	SyslogCmd.Flags().StringArrayP("proc", "p", []string{}, "Only show logs of these process names")
+	SyslogCmd.Flags().Int("pid", -1, "Only show logs of this process ID")
+	SyslogCmd.Flags().StringP("match", "m", "", "Only show logs whose message matches this regex")
+	SyslogCmd.Flags().BoolP("json", "j", false, "Output logs as JSON lines")
+	SyslogCmd.Flags().StringP("output", "o", "", "File to save logs to (instead of stdout)")
+	SyslogCmd.Flags().String("max-size", "", "Rotate the output file when it reaches this size (i.e. 100MB)")
+	SyslogCmd.Flags().Int("max-files", 5, "Number of rotated output files to keep")
+	SyslogCmd.Flags().Bool("relay", false, "Use the legacy syslog_relay service (only supports --match)")
+	SyslogCmd.MarkFlagFilename("output")
+	viper.BindPFlag("idev.syslog.timeout", SyslogCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("idev.syslog.proc", SyslogCmd.Flags().Lookup("proc"))
+	viper.BindPFlag("idev.syslog.pid", SyslogCmd.Flags().Lookup("pid"))
+	viper.BindPFlag("idev.syslog.match", SyslogCmd.Flags().Lookup("match"))
+	viper.BindPFlag("idev.syslog.json", SyslogCmd.Flags().Lookup("json"))
+	viper.BindPFlag("idev.syslog.output", SyslogCmd.Flags().Lookup("output"))
+	viper.BindPFlag("idev.syslog.max-size", SyslogCmd.Flags().Lookup("max-size"))
+	viper.BindPFlag("idev.syslog.max-files", SyslogCmd.Flags().Lookup("max-files"))
+	viper.BindPFlag("idev.syslog.relay", SyslogCmd.Flags().Lookup("relay"))
 }
 
 var colorTime = color.New(color.Bold, color.FgHiBlue).SprintFunc()
@@ -90,10 +113,110 @@ func colorSyslog(line string) string {
 	})
 }
 
+// formatSyslogEntry formats an os_trace log entry like a syslog line
+func formatSyslogEntry(e *ostrace.SyslogEntry) string {
+	level := e.Level.String()
+	body := e.Message
+	switch e.Level {
+	case ostrace.LevelNotice:
+		level = colorNotice(level)
+	case ostrace.LevelError:
+		level = colorError(level)
+		body = colorErrorMsg(body)
+	case ostrace.LevelFault:
+		level = colorWarning(level)
+		body = colorWarningMsg(body)
+	default:
+		level = colorDebug(level)
+	}
+	var lib string
+	if len(e.ImageName) > 0 && e.ImageName != e.ProcessName() {
+		lib = fmt.Sprintf("(%s)", colorLib(e.ImageName))
+	}
+	proc := fmt.Sprintf("%s%s[%s]", colorProc(e.ProcessName()), lib, colorDebug(e.PID))
+	if len(e.Subsystem) > 0 {
+		proc += fmt.Sprintf(" <%s:%s>", e.Subsystem, e.Category)
+	}
+	return colorTime(e.Timestamp.In(loc).Format("02Jan2006 15:04:05 MST")) + " " + level + " " + proc + " " + body
+}
+
+// rotatingFile is a log file that is rotated (to <name>.1, <name>.2, ...) once it reaches its max size
+type rotatingFile struct {
+	name     string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func newRotatingFile(name string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	r := &rotatingFile{name: name, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	if r.maxFiles > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", r.name, r.maxFiles))
+		for i := r.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.name, i), fmt.Sprintf("%s.%d", r.name, i+1))
+		}
+		if err := os.Rename(r.name, r.name+".1"); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(r.name); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	return r.f.Close()
+}
+
 // SyslogCmd represents the syslog command
 var SyslogCmd = &cobra.Command{
-	Use:           "syslog",
-	Short:         "Dump syslog lines",
+	Use:   "syslog",
+	Short: "Stream device logs",
+	Example: heredoc.Doc(`
+		# Stream all logs
+		❯ ipsw idev syslog
+		# Stream the logs of SpringBoard and backboardd that mention 'scene'
+		❯ ipsw idev syslog --proc SpringBoard --proc backboardd --match '(?i)scene'
+		# Capture JSON logs for an hour in 100MB rotated files
+		❯ ipsw idev syslog --json --timeout 3600 --output device.log --max-size 100MB`),
+	Args:          cobra.NoArgs,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -104,7 +227,29 @@ var SyslogCmd = &cobra.Command{
 		color.NoColor = viper.GetBool("no-color")
 
 		udid, _ := cmd.Flags().GetString("udid")
-		timeout, _ := cmd.Flags().GetDuration("timeout")
+		timeout := time.Duration(viper.GetUint64("idev.syslog.timeout")) * time.Second
+		procs := viper.GetStringSlice("idev.syslog.proc")
+		pid := viper.GetInt("idev.syslog.pid")
+		asJSON := viper.GetBool("idev.syslog.json")
+		output := viper.GetString("idev.syslog.output")
+		// validate flags
+		if viper.GetBool("idev.syslog.relay") && (len(procs) > 0 || pid >= 0 || asJSON) {
+			return fmt.Errorf("cannot use --relay with --proc, --pid or --json")
+		}
+		var match *regexp.Regexp
+		if len(viper.GetString("idev.syslog.match")) > 0 {
+			var err error
+			if match, err = regexp.Compile(viper.GetString("idev.syslog.match")); err != nil {
+				return fmt.Errorf("invalid --match regex: %w", err)
+			}
+		}
+		var maxSize uint64
+		if len(viper.GetString("idev.syslog.max-size")) > 0 {
+			var err error
+			if maxSize, err = humanize.ParseBytes(viper.GetString("idev.syslog.max-size")); err != nil {
+				return fmt.Errorf("invalid --max-size: %w", err)
+			}
+		}
 
 		if len(udid) == 0 {
 			dev, err := utils.PickDevice()
@@ -114,6 +259,25 @@ var SyslogCmd = &cobra.Command{
 			udid = dev.UniqueDeviceID
 			loc, _ = time.LoadLocation(dev.TimeZone)
 		}
+		if loc == nil {
+			loc = time.Local
+		}
+
+		var out io.Writer = os.Stdout
+		colorize := viper.GetBool("color") && !viper.GetBool("no-color")
+		if len(output) > 0 {
+			rf, err := newRotatingFile(output, int64(maxSize), viper.GetInt("idev.syslog.max-files"))
+			if err != nil {
+				return err
+			}
+			defer rf.Close()
+			out = rf
+			colorize = false
+			log.WithField("file", output).Info("Saving logs")
+		}
+		if !colorize {
+			color.NoColor = true // os_trace entries are formatted with the syslog colors
+		}
 
 		var ctx context.Context
 		var cancel context.CancelFunc
@@ -125,13 +289,13 @@ var SyslogCmd = &cobra.Command{
 		defer cancel()
 
 		if err := ctrlc.Default.Run(ctx, func() error {
-			r, err := syslog.Syslog(udid)
-			if err != nil {
-				return err
-			}
-			defer r.Close()
+			if viper.GetBool("idev.syslog.relay") {
+				r, err := syslog.Syslog(udid)
+				if err != nil {
+					return err
+				}
+				defer r.Close()
 
-			if viper.GetBool("color") && !viper.GetBool("no-color") {
 				br := bufio.NewReader(r)
 				for {
 					line, err := br.ReadString('\x00')
@@ -141,13 +305,52 @@ var SyslogCmd = &cobra.Command{
 						}
 						return fmt.Errorf("failed to read syslog line: %w", err)
 					}
-					fmt.Println(colorSyslog(strings.Trim(line, "\x00")))
+					line = strings.Trim(line, "\x00")
+					if match != nil && !match.MatchString(line) {
+						continue
+					}
+					if colorize {
+						line = colorSyslog(line)
+					}
+					if _, err := fmt.Fprintln(out, strings.TrimRight(line, "\n")); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			stream, err := ostrace.NewClient(udid).Syslog(pid)
+			if err != nil {
+				return fmt.Errorf("failed to start os_trace relay log stream: %w", err)
+			}
+			defer stream.Close()
+
+			enc := json.NewEncoder(out)
+			for {
+				entry, err := stream.Next()
+				if err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return fmt.Errorf("failed to read log entry: %w", err)
+				}
+				if len(procs) > 0 && !slices.ContainsFunc(procs, func(p string) bool {
+					return strings.EqualFold(p, entry.ProcessName())
+				}) {
+					continue
+				}
+				if match != nil && !match.MatchString(entry.Message) {
+					continue
+				}
+				if asJSON {
+					err = enc.Encode(entry)
+				} else {
+					_, err = fmt.Fprintln(out, formatSyslogEntry(entry))
+				}
+				if err != nil {
+					return fmt.Errorf("failed to write log entry: %w", err)
 				}
-			} else {
-				_, err = io.Copy(os.Stdout, r)
-				return err
 			}
-			return nil
 		}); err != nil {
 			if errors.As(err, &ctrlc.ErrorCtrlC{}) {
 				log.Warn("Exiting...")
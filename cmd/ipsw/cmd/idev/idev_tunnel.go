@@ -0,0 +1,75 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/tunnel"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	IDevCmd.AddCommand(TunnelCmd)
+
+	TunnelCmd.PersistentFlags().String("registry", tunnel.DefaultRegistryAddr, "Address of the tunnel daemon's registry")
+}
+
+// TunnelCmd represents the tunnel command
+var TunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: "CoreDevice USB tunnel commands (iOS17.4+)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// useTunnel makes the lockdown services of the device (or of every device with a tunnel if udid is empty)
+// connect through its tunnel registered with the tunnel daemon
+func useTunnel(udid string) error {
+	tunnels, err := tunnel.List(tunnel.DefaultRegistryAddr)
+	if err != nil {
+		return err
+	}
+	var found bool
+	for _, t := range tunnels {
+		if len(udid) > 0 && t.UDID != udid {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"udid": t.UDID,
+			"rsd":  t.RSDAddress(),
+		}).Debug("Connecting to device services through its tunnel")
+		lockdownd.UseTunnel(t.UDID, t.RSDAddress())
+		found = true
+	}
+	if !found {
+		if len(udid) > 0 {
+			return fmt.Errorf("no tunnel registered for device %s", udid)
+		}
+		return fmt.Errorf("no tunnels registered (is the device connected over USB and running iOS 17.4+?)")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/tunnel"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	TunnelCmd.AddCommand(idevTunnelLsCmd)
+
+	idevTunnelLsCmd.Flags().BoolP("json", "j", false, "Display tunnels as JSON")
+}
+
+// idevTunnelLsCmd represents the ls command
+var idevTunnelLsCmd = &cobra.Command{
+	Use:           "ls",
+	Short:         "List active CoreDevice tunnels",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		registry, _ := cmd.Flags().GetString("registry")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		tunnels, err := tunnel.List(registry)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(tunnels)
+			if err != nil {
+				return fmt.Errorf("failed to marshal tunnels: %w", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		if len(tunnels) == 0 {
+			log.Warn("No active tunnels")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UDID\tINTERFACE\tRSD")
+		for _, t := range tunnels {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.UDID, t.Interface, t.RSDAddress())
+		}
+		w.Flush()
+
+		return nil
+	},
+}
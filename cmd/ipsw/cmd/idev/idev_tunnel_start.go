@@ -0,0 +1,79 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package idev
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/tunnel"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	TunnelCmd.AddCommand(idevTunnelStartCmd)
+}
+
+// idevTunnelStartCmd represents the start command
+var idevTunnelStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Run the CoreDevice tunnel daemon",
+	Long: heredoc.Doc(`
+		Keep a CoreDevice tunnel up for every USB connected iOS 17.4+ device so their
+		RemoteXPC services can be reached by the other 'ipsw idev' commands.
+
+		Tunnels are created on a TUN interface (requires root) and are registered with a
+		local registry that 'ipsw idev' commands query automatically. Pass --tunnel to the
+		other 'ipsw idev' commands to also connect to their lockdown services through it.
+
+		NOTE: only USB tunnels are supported (the CoreDeviceProxy service was added in iOS 17.4);
+		Wi-Fi (RemotePairing) tunnels and older iOS 17 devices are not.`),
+	Example: heredoc.Doc(`
+		# Start the tunnel daemon
+		❯ sudo ipsw idev tunnel start
+		# List the apps of the device through its tunnel
+		❯ ipsw idev apps ls --tunnel`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		registry, _ := cmd.Flags().GetString("registry")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.WithField("registry", registry).Info("Starting tunnel daemon (press Ctrl+C to stop)")
+		return tunnel.NewManager().Serve(ctx, registry)
+	},
+}
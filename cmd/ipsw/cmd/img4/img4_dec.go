@@ -27,8 +27,10 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	icmd "github.com/blacktop/ipsw/internal/commands/img4"
+	"github.com/blacktop/ipsw/internal/keys"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -41,11 +43,15 @@ func init() {
 	img4DecCmd.Flags().String("iv-key", "", "AES iv+key")
 	img4DecCmd.Flags().StringP("iv", "i", "", "AES iv")
 	img4DecCmd.Flags().StringP("key", "k", "", "AES key")
+	img4DecCmd.Flags().StringP("device", "d", "", "Lookup key in key DB for device (i.e. iPhone11,2)")
+	img4DecCmd.Flags().StringP("build", "b", "", "Lookup key in key DB for build (i.e. 16F203)")
 	img4DecCmd.Flags().StringP("output", "o", "", "Output folder")
 	img4DecCmd.MarkFlagDirname("output")
 	viper.BindPFlag("img4.dec.iv-key", img4DecCmd.Flags().Lookup("iv-key"))
 	viper.BindPFlag("img4.dec.iv", img4DecCmd.Flags().Lookup("iv"))
 	viper.BindPFlag("img4.dec.key", img4DecCmd.Flags().Lookup("key"))
+	viper.BindPFlag("img4.dec.device", img4DecCmd.Flags().Lookup("device"))
+	viper.BindPFlag("img4.dec.build", img4DecCmd.Flags().Lookup("build"))
 	viper.BindPFlag("img4.dec.output", img4DecCmd.Flags().Lookup("output"))
 }
 
@@ -54,7 +60,12 @@ var img4DecCmd = &cobra.Command{
 	Use:     "dec <img4>",
 	Aliases: []string{"d"},
 	Short:   "Decrypt img4 payloads",
-	Args:    cobra.ExactArgs(1),
+	Example: heredoc.Doc(`
+		# Decrypt an im4p with its iv/key
+		❯ ipsw img4 dec --iv-key <IVKEY> iBoot.d321.RELEASE.im4p
+		# Decrypt an im4p with the key from the key DB (fetched and cached if missing, see 'ipsw keys')
+		❯ ipsw img4 dec --device iPhone11,2 --build 17A577 iBoot.d321.RELEASE.im4p`),
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
@@ -66,12 +77,20 @@ var img4DecCmd = &cobra.Command{
 		ivkeyStr := viper.GetString("img4.dec.iv-key")
 		ivStr := viper.GetString("img4.dec.iv")
 		keyStr := viper.GetString("img4.dec.key")
+		device := viper.GetString("img4.dec.device")
+		build := viper.GetString("img4.dec.build")
 		outputDir := viper.GetString("img4.dec.output")
 		// validate flags
+		lookup := len(device) > 0 || len(build) > 0
+		if lookup && (len(device) == 0 || len(build) == 0) {
+			return fmt.Errorf("must specify both --device AND --build to lookup the key")
+		}
 		if len(ivkeyStr) != 0 && (len(ivStr) != 0 || len(keyStr) != 0) {
 			return fmt.Errorf("cannot specify both --iv-key AND --iv/--key")
-		} else if len(ivkeyStr) == 0 && (len(ivStr) == 0 || len(keyStr) == 0) {
-			return fmt.Errorf("must specify either --iv-key OR --iv/--key")
+		} else if lookup && (len(ivkeyStr) != 0 || len(ivStr) != 0 || len(keyStr) != 0) {
+			return fmt.Errorf("cannot specify both --device/--build AND --iv-key OR --iv/--key")
+		} else if !lookup && len(ivkeyStr) == 0 && (len(ivStr) == 0 || len(keyStr) == 0) {
+			return fmt.Errorf("must specify either --iv-key OR --iv/--key OR --device/--build")
 		}
 
 		infile := filepath.Clean(args[0])
@@ -84,7 +103,22 @@ var img4DecCmd = &cobra.Command{
 		var iv []byte
 		var key []byte
 
-		if len(ivkeyStr) != 0 {
+		if lookup {
+			db, err := keys.Open("", nil, "", false)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			k, err := db.Find(device, build, infile)
+			if err != nil {
+				return fmt.Errorf("failed to lookup key: %v", err)
+			}
+			utils.Indent(log.WithField("source", k.Source).Info, 2)("Found key for " + filepath.Base(infile))
+			iv, key, err = k.Decode()
+			if err != nil {
+				return err
+			}
+		} else if len(ivkeyStr) != 0 {
 			ivkey, err := hex.DecodeString(ivkeyStr)
 			if err != nil {
 				return fmt.Errorf("failed to decode --iv-key: %v", err)
@@ -0,0 +1,149 @@
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package img4
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	icmd "github.com/blacktop/ipsw/internal/commands/img4"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	Img4Cmd.AddCommand(img4InfoCmd)
+	img4InfoCmd.Flags().BoolP("analyze", "a", false, "Detect the payload type and parse it")
+	img4InfoCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	img4InfoCmd.MarkZshCompPositionalArgumentFile(1)
+
+	viper.BindPFlag("img4.info.analyze", img4InfoCmd.Flags().Lookup("analyze"))
+	viper.BindPFlag("img4.info.json", img4InfoCmd.Flags().Lookup("json"))
+}
+
+// img4InfoCmd represents the info command
+var img4InfoCmd = &cobra.Command{
+	Use:           "info <IMG4|IM4P>",
+	Aliases:       []string{"i"},
+	Short:         "Display IMG4/IM4P info",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		// flags
+		analyze := viper.GetBool("img4.info.analyze")
+		asJSON := viper.GetBool("img4.info.json")
+
+		dat, err := os.ReadFile(filepath.Clean(args[0]))
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", args[0], err)
+		}
+
+		var im4p *img4.Im4p
+		var hasManifest bool
+		if magic.IsImg4Data(dat) {
+			i, err := img4.ParseImg4(bytes.NewReader(dat))
+			if err != nil {
+				return fmt.Errorf("failed to parse IMG4: %v", err)
+			}
+			hasManifest = len(i.Manifest.Bytes) > 0
+			// re-parse the IM4P to get the keybags
+			im4p, err = img4.ParseIm4p(bytes.NewReader(i.IM4P.Raw))
+			if err != nil {
+				return fmt.Errorf("failed to parse IM4P: %v", err)
+			}
+		} else if magic.IsIm4pData(dat) {
+			im4p, err = img4.ParseIm4p(bytes.NewReader(dat))
+			if err != nil {
+				return fmt.Errorf("failed to parse IM4P: %v", err)
+			}
+		} else {
+			return fmt.Errorf("unsupported file type: expected IMG4/IM4P")
+		}
+
+		if analyze {
+			a, err := icmd.AnalyzeIm4p(filepath.Base(args[0]), im4p)
+			if err != nil {
+				return fmt.Errorf("failed to analyze payload: %v", err)
+			}
+			if asJSON {
+				dat, err := json.Marshal(a)
+				if err != nil {
+					return fmt.Errorf("failed to marshal analysis: %v", err)
+				}
+				fmt.Println(string(dat))
+				return nil
+			}
+			fmt.Print(a)
+			return nil
+		}
+
+		if asJSON {
+			dat, err := json.Marshal(&struct {
+				Name        string        `json:"name,omitempty"`
+				Type        string        `json:"type,omitempty"`
+				Description string        `json:"description,omitempty"`
+				Size        int           `json:"size"`
+				Manifest    bool          `json:"manifest"`
+				Keybags     []img4.Keybag `json:"keybags,omitempty"`
+			}{
+				Name:        filepath.Base(args[0]),
+				Type:        im4p.Type,
+				Description: im4p.Description,
+				Size:        len(im4p.Data),
+				Manifest:    hasManifest,
+				Keybags:     im4p.Kbags,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal info: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		fmt.Printf("Type:        %s\n", im4p.Type)
+		fmt.Printf("Description: %s\n", im4p.Description)
+		fmt.Printf("Size:        %#x\n", len(im4p.Data))
+		fmt.Printf("Manifest:    %t\n", hasManifest)
+		if len(im4p.Kbags) > 0 {
+			fmt.Println("Keybags:")
+			for _, kb := range im4p.Kbags {
+				fmt.Println(kb)
+			}
+		}
+
+		return nil
+	},
+}
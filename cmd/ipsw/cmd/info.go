@@ -30,9 +30,11 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/download"
 	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/blacktop/ipsw/pkg/plist"
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -40,17 +42,21 @@ import (
 
 func init() {
 	rootCmd.AddCommand(infoCmd)
-	infoCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	infoCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	infoCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	infoCmd.Flags().BoolP("remote", "r", false, "Extract from URL")
 	infoCmd.Flags().BoolP("list", "l", false, "List files in IPSW/OTA")
 	infoCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	infoCmd.Flags().BoolP("manifest", "m", false, "Output the full BuildManifest/Restore plists as structured data")
+	infoCmd.Flags().StringP("query", "q", "", "Query the manifests (i.e. BuildManifest.BuildIdentities[Info.DeviceClass=j414sap].Manifest.KernelCache.Info.Path)")
 
 	viper.BindPFlag("info.proxy", infoCmd.Flags().Lookup("proxy"))
 	viper.BindPFlag("info.insecure", infoCmd.Flags().Lookup("insecure"))
 	viper.BindPFlag("info.remote", infoCmd.Flags().Lookup("remote"))
 	viper.BindPFlag("info.list", infoCmd.Flags().Lookup("list"))
 	viper.BindPFlag("info.json", infoCmd.Flags().Lookup("json"))
+	viper.BindPFlag("info.manifest", infoCmd.Flags().Lookup("manifest"))
+	viper.BindPFlag("info.query", infoCmd.Flags().Lookup("query"))
 
 	infoCmd.MarkZshCompPositionalArgumentFile(1, "*.ipsw", "*.zip")
 	infoCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -63,6 +69,19 @@ var infoCmd = &cobra.Command{
 	Use:           "info <IPSW>",
 	Aliases:       []string{"i"},
 	Short:         "Display IPSW/OTA Info",
+	Example: heredoc.Doc(`
+		# Display IPSW info
+		❯ ipsw info iPhone16,1_18.0_22A3354_Restore.ipsw
+
+		# Output the full BuildManifest and Restore plists as JSON
+		❯ ipsw info --manifest --json iPhone16,1_18.0_22A3354_Restore.ipsw
+
+		# Get the path and digest of the kernelcache for a board
+		❯ ipsw info -m -q 'BuildManifest.BuildIdentities[Info.DeviceClass=d83ap][0].Manifest.KernelCache.Info.Path' iPhone16,1_18.0_22A3354_Restore.ipsw
+		❯ ipsw info -m -q 'BuildManifest.BuildIdentities[Info.DeviceClass=d83ap][0].Manifest.KernelCache.Digest' iPhone16,1_18.0_22A3354_Restore.ipsw
+
+		# List the paths of every component of the erase install identities
+		❯ ipsw info -m -q 'BuildManifest.BuildIdentities[Info.RestoreBehavior=Erase].Manifest.*.Info.Path' iPhone16,1_18.0_22A3354_Restore.ipsw`),
 	Args:          cobra.MinimumNArgs(1),
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -74,6 +93,10 @@ var infoCmd = &cobra.Command{
 			log.SetLevel(log.DebugLevel)
 		}
 
+		if viper.GetBool("info.manifest") || len(viper.GetString("info.query")) > 0 {
+			return queryManifests(args[0])
+		}
+
 		if viper.GetBool("info.remote") {
 			zr, err := download.NewRemoteZipReader(args[0], &download.RemoteConfig{
 				Proxy:    viper.GetString("info.proxy"),
@@ -156,3 +179,58 @@ var infoCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// queryManifests outputs the BuildManifest/Restore plists of an IPSW/OTA (or the values selected by the query)
+func queryManifests(path string) error {
+	var files []*zip.File
+	if viper.GetBool("info.remote") {
+		zr, err := download.NewRemoteZipReader(path, &download.RemoteConfig{
+			Proxy:    viper.GetString("info.proxy"),
+			Insecure: viper.GetBool("info.insecure"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create new remote zip reader: %w", err)
+		}
+		files = zr.File
+	} else {
+		zr, err := zip.OpenReader(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %v", path, err)
+		}
+		defer zr.Close()
+		files = zr.File
+	}
+
+	var out any
+	manifests, err := plist.ParseManifests(files)
+	if err != nil {
+		return fmt.Errorf("failed to parse manifests: %w", err)
+	}
+	out = manifests
+	if q := viper.GetString("info.query"); len(q) > 0 {
+		out, err = plist.Query(manifests, q)
+		if err != nil {
+			return fmt.Errorf("failed to query manifests: %w", err)
+		}
+	}
+
+	if viper.GetBool("info.json") {
+		dat, err := json.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to JSON marshal manifests: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	switch v := out.(type) {
+	case map[string]any, []any:
+		dat, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to JSON marshal manifests: %v", err)
+		}
+		fmt.Println(string(dat))
+	default: // print scalars as is so scripts can use them directly
+		fmt.Println(v)
+	}
+	return nil
+}
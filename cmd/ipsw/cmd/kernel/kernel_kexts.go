@@ -22,11 +22,15 @@ THE SOFTWARE.
 package kernel
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	kcmd "github.com/blacktop/ipsw/internal/commands/kernel"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/kernelcache"
 	"github.com/fatih/color"
@@ -37,6 +41,8 @@ import (
 func init() {
 	KernelcacheCmd.AddCommand(kextsCmd)
 	kextsCmd.Flags().BoolP("diff", "d", false, "Diff two kernel's kexts")
+	kextsCmd.Flags().BoolP("iokit", "i", false, "Diff kext versions and IOKit classes (flags new user clients and user client C++ methods)")
+	kextsCmd.Flags().Bool("json", false, "Output IOKit diff as JSON")
 	kextsCmd.MarkZshCompPositionalArgumentFile(1, "kernelcache*")
 }
 
@@ -45,7 +51,12 @@ var kextsCmd = &cobra.Command{
 	Use:     "kexts <kernelcache>",
 	Aliases: []string{"k"},
 	Short:   "List kernel extentions",
-	Args:    cobra.MinimumNArgs(1),
+	Example: heredoc.Doc(`
+		# List kexts
+		❯ ipsw kernel kexts kernelcache.release.iPhone17,1
+		# Diff the kext versions and IOKit classes/user clients of two kernelcaches
+		❯ ipsw kernel kexts --diff --iokit kernelcache.release.iPhone17,1.OLD kernelcache.release.iPhone17,1.NEW`),
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if viper.GetBool("verbose") {
@@ -54,6 +65,8 @@ var kextsCmd = &cobra.Command{
 		color.NoColor = viper.GetBool("no-color")
 
 		diff, _ := cmd.Flags().GetBool("diff")
+		iokit, _ := cmd.Flags().GetBool("iokit")
+		asJSON, _ := cmd.Flags().GetBool("json")
 
 		if _, err := os.Stat(args[0]); os.IsNotExist(err) {
 			return fmt.Errorf("file %s does not exist", args[0])
@@ -64,6 +77,38 @@ var kextsCmd = &cobra.Command{
 				return fmt.Errorf("please provide two kernelcache files to diff")
 			}
 
+			if iokit {
+				m1, err := macho.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open kernelcache %s: %v", args[0], err)
+				}
+				defer m1.Close()
+				m2, err := macho.Open(args[1])
+				if err != nil {
+					return fmt.Errorf("failed to open kernelcache %s: %v", args[1], err)
+				}
+				defer m2.Close()
+				idiff, err := kcmd.DiffIOKit(m1, m2)
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					dat, err := json.MarshalIndent(idiff, "", "  ")
+					if err != nil {
+						return fmt.Errorf("failed to marshal IOKit diff: %v", err)
+					}
+					fmt.Println(string(dat))
+					return nil
+				}
+				if idiff.Empty() {
+					log.Info("No differences found")
+					return nil
+				}
+				log.Info("Differences found")
+				fmt.Println(idiff.Markdown())
+				return nil
+			}
+
 			kout1, err := kernelcache.KextList(args[0], true)
 			if err != nil {
 				return err
@@ -31,6 +31,11 @@ import (
 
 	"github.com/apex/log"
 	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/internal/commands/ida"
+	kcmd "github.com/blacktop/ipsw/internal/commands/kernel"
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/internal/commands/r2"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/signature"
 	"github.com/fatih/color"
@@ -44,21 +49,31 @@ func init() {
 
 	kernelSymbolicateCmd.Flags().BoolP("flat", "f", false, "Output results in flat file '.syms' format")
 	kernelSymbolicateCmd.Flags().BoolP("json", "j", false, "Output results in JSON format")
+	kernelSymbolicateCmd.Flags().Bool("ida", false, "Output results as an IDAPython script applying the symbols and function boundaries")
+	kernelSymbolicateCmd.Flags().Bool("idc", false, "Write the --ida script as IDC")
+	kernelSymbolicateCmd.Flags().Bool("r2", false, "Output results as a radare2 script applying the symbols and function boundaries")
 	kernelSymbolicateCmd.Flags().BoolP("quiet", "q", false, "Do NOT display logging")
 	kernelSymbolicateCmd.Flags().Bool("test", false, "Test symbol matches")
 	kernelSymbolicateCmd.Flags().MarkHidden("test")
 	kernelSymbolicateCmd.Flags().Bool("schema", false, "Generate JSON schema")
 	kernelSymbolicateCmd.Flags().MarkHidden("schema")
 	kernelSymbolicateCmd.Flags().StringP("signatures", "s", "", "Path to signatures folder")
+	kernelSymbolicateCmd.Flags().String("kdk", kcmd.KDKsPath, "Path to KDK(s) to add symbols from if a KDK kernel matches the kernelcache")
+	kernelSymbolicateCmd.Flags().Bool("no-kdk", false, "Do NOT add symbols from a matching KDK")
 	kernelSymbolicateCmd.Flags().Uint64P("lookup", "l", 0, "Lookup a symbol by address")
 	kernelSymbolicateCmd.Flags().StringP("output", "o", "", "Folder to write files to")
 	kernelSymbolicateCmd.MarkFlagDirname("output")
 	viper.BindPFlag("kernel.symbolicate.flat", kernelSymbolicateCmd.Flags().Lookup("flat"))
 	viper.BindPFlag("kernel.symbolicate.json", kernelSymbolicateCmd.Flags().Lookup("json"))
+	viper.BindPFlag("kernel.symbolicate.ida", kernelSymbolicateCmd.Flags().Lookup("ida"))
+	viper.BindPFlag("kernel.symbolicate.idc", kernelSymbolicateCmd.Flags().Lookup("idc"))
+	viper.BindPFlag("kernel.symbolicate.r2", kernelSymbolicateCmd.Flags().Lookup("r2"))
 	viper.BindPFlag("kernel.symbolicate.quiet", kernelSymbolicateCmd.Flags().Lookup("quiet"))
 	viper.BindPFlag("kernel.symbolicate.test", kernelSymbolicateCmd.Flags().Lookup("test"))
 	viper.BindPFlag("kernel.symbolicate.schema", kernelSymbolicateCmd.Flags().Lookup("schema"))
 	viper.BindPFlag("kernel.symbolicate.signatures", kernelSymbolicateCmd.Flags().Lookup("signatures"))
+	viper.BindPFlag("kernel.symbolicate.kdk", kernelSymbolicateCmd.Flags().Lookup("kdk"))
+	viper.BindPFlag("kernel.symbolicate.no-kdk", kernelSymbolicateCmd.Flags().Lookup("no-kdk"))
 	viper.BindPFlag("kernel.symbolicate.lookup", kernelSymbolicateCmd.Flags().Lookup("lookup"))
 	viper.BindPFlag("kernel.symbolicate.output", kernelSymbolicateCmd.Flags().Lookup("output"))
 }
@@ -80,6 +95,10 @@ var kernelSymbolicateCmd = &cobra.Command{
 
 		quiet := viper.GetBool("kernel.symbolicate.quiet")
 
+		if viper.GetBool("kernel.symbolicate.idc") && !viper.GetBool("kernel.symbolicate.ida") {
+			return fmt.Errorf("you must also supply --ida flag with the --idc flag")
+		}
+
 		output := viper.GetString("kernel.symbolicate.output")
 		if output == "" {
 			output = filepath.Dir(filepath.Clean(args[0]))
@@ -117,22 +136,44 @@ var kernelSymbolicateCmd = &cobra.Command{
 			return fmt.Errorf("symbol not found at address %#x", addr)
 		}
 
-		if !viper.IsSet("kernel.symbolicate.signatures") {
-			return fmt.Errorf("you must provide a path to the --signatures folder")
+		var kdkSyms map[uint64]string
+		if !viper.GetBool("kernel.symbolicate.no-kdk") {
+			if kinfo, err := kcmd.GetKernelInfo(args[0]); err == nil {
+				if kernel, err := kcmd.FindKDKKernel(viper.GetString("kernel.symbolicate.kdk"), kinfo.UUID); err == nil {
+					log.WithField("kernel", kernel).Info("Found matching KDK")
+					kdkSyms, err = kcmd.KDKSymbols(kernel, kinfo.Text)
+					if err != nil {
+						log.WithError(err).Warn("failed to get KDK symbols")
+					}
+				} else {
+					log.WithError(err).Debug("no matching KDK")
+				}
+			}
 		}
 
-		log.Info("Parsing Signatures")
-		sigs, err := signature.Parse(viper.GetString("kernel.symbolicate.signatures"))
-		if err != nil {
-			return fmt.Errorf("failed to parse signatures: %v", err)
+		if !viper.IsSet("kernel.symbolicate.signatures") && len(kdkSyms) == 0 {
+			return fmt.Errorf("you must provide a path to the --signatures folder (or install the matching KDK with 'ipsw download kdk --match %s --install')", args[0])
 		}
 
 		smap := signature.NewSymbolMap()
 
-		// symbolicate kernelcache
-		log.WithField("kernelcache", filepath.Base(args[0])).Info("Symbolicating...")
-		if err := smap.Symbolicate(args[0], sigs, quiet); err != nil {
-			return fmt.Errorf("failed to symbolicate kernelcache: %v", err)
+		if viper.IsSet("kernel.symbolicate.signatures") {
+			log.Info("Parsing Signatures")
+			sigs, err := signature.Parse(viper.GetString("kernel.symbolicate.signatures"))
+			if err != nil {
+				return fmt.Errorf("failed to parse signatures: %v", err)
+			}
+
+			// symbolicate kernelcache
+			log.WithField("kernelcache", filepath.Base(args[0])).Info("Symbolicating...")
+			if err := smap.Symbolicate(args[0], sigs, quiet); err != nil {
+				return fmt.Errorf("failed to symbolicate kernelcache: %v", err)
+			}
+		}
+
+		if len(kdkSyms) > 0 {
+			utils.Indent(log.Info, 2)(fmt.Sprintf("Adding %d symbols from KDK", len(kdkSyms)))
+			smap.Copy(kdkSyms)
 		}
 
 		// test the accuracy of the symbolication on the source KDK material
@@ -198,6 +239,32 @@ var kernelSymbolicateCmd = &cobra.Command{
 			return os.WriteFile(fname, jdat, 0o644)
 		}
 
+		/* IDA/R2 SCRIPT OUTPUT */
+
+		if viper.GetBool("kernel.symbolicate.ida") || viper.GetBool("kernel.symbolicate.r2") {
+			a, err := kernelAnnotations(args[0], smap)
+			if err != nil {
+				return err
+			}
+			fname := filepath.Join(output, filepath.Base(args[0])+".r2")
+			if viper.GetBool("kernel.symbolicate.ida") {
+				fname = filepath.Join(output, filepath.Base(args[0])+".ida.py")
+				if viper.GetBool("kernel.symbolicate.idc") {
+					fname = filepath.Join(output, filepath.Base(args[0])+".ida.idc")
+				}
+			}
+			log.Infof("Writing symbols as script to %s", fname)
+			f, err := os.Create(fname)
+			if err != nil {
+				return fmt.Errorf("failed to create script: %v", err)
+			}
+			defer f.Close()
+			if viper.GetBool("kernel.symbolicate.ida") {
+				return ida.WriteScript(f, a, viper.GetBool("kernel.symbolicate.idc"))
+			}
+			return r2.WriteScript(f, a)
+		}
+
 		/* FLAT FILE OUTPUT */
 
 		if viper.GetBool("kernel.symbolicate.flat") {
@@ -222,3 +289,34 @@ var kernelSymbolicateCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// kernelAnnotations returns the symbol map's names and the function starts of the kernelcache (and its fileset entries)
+func kernelAnnotations(kernelcache string, smap signature.SymbolMap) (*mcmd.Annotations, error) {
+	a := &mcmd.Annotations{Binary: filepath.Base(kernelcache)}
+	m, err := macho.Open(kernelcache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kernelcache: %v", err)
+	}
+	defer m.Close()
+	entries := []*macho.File{m}
+	if m.FileTOC.FileHeader.Type == types.MH_FILESET {
+		entries = nil
+		for _, fe := range m.FileSets() {
+			entry, err := m.GetFileSetFileByName(fe.EntryID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse entry %s: %v", fe.EntryID, err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range entries {
+		ea, err := mcmd.Annotate(a.Binary, entry, &mcmd.AnnotateConfig{Starts: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get function starts: %v", err)
+		}
+		a.Functions = append(a.Functions, ea.Functions...)
+	}
+	a.AddNames(smap)
+	a.Sort()
+	return a, nil
+}
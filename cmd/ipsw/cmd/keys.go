@@ -0,0 +1,199 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/keys"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+
+	keysCmd.Flags().StringP("device", "d", "", "iOS Device (i.e. iPhone11,2)")
+	keysCmd.Flags().StringP("version", "v", "", "iOS Version (i.e. 12.3.1)")
+	keysCmd.Flags().StringP("build", "b", "", "iOS BuildID (i.e. 16F203)")
+	keysCmd.Flags().StringP("file", "f", "", "Only show the key for this firmware file (i.e. iBoot.d321.RELEASE.im4p)")
+	keysCmd.Flags().Bool("fetch", false, "Fetch (and cache) the keys from the key sources even if already cached")
+	keysCmd.Flags().StringArray("source", nil, "Key sources: 'wiki' or a JSON URL template with {device}/{build} (default: config 'keys.sources' or wiki)")
+	keysCmd.Flags().String("import", "", "Import keys from a JSON file into the key database")
+	keysCmd.Flags().String("db", "", "Path to the key database (default: keys.db in the config folder)")
+	keysCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
+	keysCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
+	keysCmd.Flags().Bool("json", false, "Output as JSON")
+	keysCmd.MarkFlagFilename("import", "json")
+	viper.BindPFlag("keys.device", keysCmd.Flags().Lookup("device"))
+	viper.BindPFlag("keys.version", keysCmd.Flags().Lookup("version"))
+	viper.BindPFlag("keys.build", keysCmd.Flags().Lookup("build"))
+	viper.BindPFlag("keys.file", keysCmd.Flags().Lookup("file"))
+	viper.BindPFlag("keys.fetch", keysCmd.Flags().Lookup("fetch"))
+	viper.BindPFlag("keys.source", keysCmd.Flags().Lookup("source"))
+	viper.BindPFlag("keys.import", keysCmd.Flags().Lookup("import"))
+	viper.BindPFlag("keys.db", keysCmd.Flags().Lookup("db"))
+	viper.BindPFlag("keys.proxy", keysCmd.Flags().Lookup("proxy"))
+	viper.BindPFlag("keys.insecure", keysCmd.Flags().Lookup("insecure"))
+	viper.BindPFlag("keys.json", keysCmd.Flags().Lookup("json"))
+}
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Lookup and cache firmware decryption keys",
+	Long: heredoc.Doc(`
+		Lookup firmware decryption keys (iBoot, LLB, iBSS/iBEC and SEP where public) for a device/build.
+
+		Keys are looked up in the local key database (keys.db in the config folder) and ipsw's embedded keys,
+		and fetched (and cached in the key database) from the key sources when missing. The cached keys are
+		used automatically by 'ipsw img4 dec --device/--build', 'ipsw fw iboot --device/--build' and
+		'ipsw download ipsw --decrypt'.
+
+		Key sources can be set in the config file as 'keys.sources' and are either 'wiki' (theapplewiki.com)
+		or a URL template (with {device} and {build}) that returns a JSON list of keys:
+		  [{"filename": "iBoot.d321.RELEASE.im4p", "iv": "...", "key": "..."}]`),
+	Example: heredoc.Doc(`
+		# Show the keys for an iPhone XS 13.0 build
+		❯ ipsw keys --device iPhone11,2 --build 17A577
+		# Show the key of a single file as JSON
+		❯ ipsw keys -d iPhone11,2 -b 17A577 --file iBoot.d321.RELEASE.im4p --json
+		# Fetch keys from your own key server (in addition to the wiki)
+		❯ ipsw keys -d iPhone11,2 -b 17A577 --fetch --source wiki --source 'https://keys.lan/{device}/{build}.json'
+		# Import keys into the key database
+		❯ ipsw keys --import my_keys.json`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		device := viper.GetString("keys.device")
+		version := viper.GetString("keys.version")
+		build := viper.GetString("keys.build")
+		file := viper.GetString("keys.file")
+		asJSON := viper.GetBool("keys.json")
+
+		db, err := keys.Open(viper.GetString("keys.db"), viper.GetStringSlice("keys.source"), viper.GetString("keys.proxy"), viper.GetBool("keys.insecure"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if importFile := viper.GetString("keys.import"); len(importFile) > 0 {
+			dat, err := os.ReadFile(importFile)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", importFile, err)
+			}
+			var imported []keys.Key
+			if err := json.Unmarshal(dat, &imported); err != nil {
+				return fmt.Errorf("failed to parse %s: %v", importFile, err)
+			}
+			for _, k := range imported {
+				if len(k.Device) == 0 || len(k.Build) == 0 || len(k.Filename) == 0 {
+					return fmt.Errorf("imported keys must have a device, build and filename: %v", k)
+				}
+			}
+			added, err := db.Add(imported...)
+			if err != nil {
+				return err
+			}
+			log.Infof("Imported %d keys into %s", added, db.Path)
+			return nil
+		}
+
+		// validate flags
+		if len(device) == 0 {
+			return fmt.Errorf("please supply a --device")
+		}
+		if len(version) == 0 && len(build) == 0 {
+			return fmt.Errorf("please supply a --version OR --build")
+		}
+		if len(build) == 0 {
+			build, err = download.GetBuildID(version, device)
+			if err != nil {
+				return fmt.Errorf("failed to query ipsw.me api for --version %s (please supply '--build' instead): %v", version, err)
+			}
+		}
+
+		var found []keys.Key
+		if len(file) > 0 {
+			var key *keys.Key
+			if viper.GetBool("keys.fetch") {
+				if _, err := db.Fetch(device, build); err != nil {
+					log.WithError(err).Warn("Failed to fetch keys")
+				}
+				key, err = db.Get(device, build, file)
+			} else {
+				key, err = db.Find(device, build, file)
+			}
+			if err != nil {
+				return err
+			}
+			found = append(found, *key)
+		} else {
+			found, err = db.List(device, build)
+			if err != nil {
+				return err
+			}
+			if viper.GetBool("keys.fetch") || len(found) == 0 {
+				log.WithFields(log.Fields{"device": device, "build": build}).Info("Fetching keys")
+				if _, err := db.Fetch(device, build); err != nil && !errors.Is(err, keys.ErrNotFound) {
+					log.WithError(err).Warn("Failed to fetch keys")
+				}
+			}
+			if found, err = db.List(device, build); err != nil {
+				return err
+			}
+			if len(found) == 0 {
+				return fmt.Errorf("no keys found for %s %s", device, build)
+			}
+		}
+
+		if asJSON {
+			dat, err := json.MarshalIndent(found, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal keys: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+
+		for _, k := range found {
+			fmt.Print(k)
+		}
+		utils.Indent(log.Debug, 2)("Key database: " + db.Path)
+
+		return nil
+	},
+}
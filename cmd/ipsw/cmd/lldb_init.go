@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/lldb"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(lldbInitCmd)
+
+	lldbInitCmd.Flags().Uint64P("slide", "s", 0, "KASLR slide of the kernelcache")
+	lldbInitCmd.Flags().StringP("kernel", "k", "", "Kernelcache of the panic")
+	lldbInitCmd.Flags().String("sysroot", "", "Folder the crashlog's image paths are relative to (i.e. Xcode's DeviceSupport Symbols)")
+	lldbInitCmd.Flags().StringArrayP("symbols", "y", []string{}, "Folder to search for binaries/dSYMs by UUID (i.e. a KDK)")
+	lldbInitCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	lldbInitCmd.MarkFlagFilename("kernel")
+	lldbInitCmd.MarkFlagDirname("sysroot")
+	lldbInitCmd.MarkFlagDirname("symbols")
+	viper.BindPFlag("lldb-init.slide", lldbInitCmd.Flags().Lookup("slide"))
+	viper.BindPFlag("lldb-init.kernel", lldbInitCmd.Flags().Lookup("kernel"))
+	viper.BindPFlag("lldb-init.sysroot", lldbInitCmd.Flags().Lookup("sysroot"))
+	viper.BindPFlag("lldb-init.symbols", lldbInitCmd.Flags().Lookup("symbols"))
+	viper.BindPFlag("lldb-init.output", lldbInitCmd.Flags().Lookup("output"))
+}
+
+// lldbInitCmd represents the lldb-init command
+var lldbInitCmd = &cobra.Command{
+	Use:   "lldb-init <CRASHLOG|KERNELCACHE>",
+	Short: "Generate LLDB commands to set up debugging a crash, panic or kernelcache",
+	Long: heredoc.Doc(`
+		Generate the LLDB commands (to source or add to an .lldbinit) creating the target, applying the
+		slide/load addresses and adding the symbol files of a crashed process (BugType=309), a panic
+		(BugType=210) or a kernelcache + KASLR slide.
+
+		Binaries and dSYMs are matched by UUID in the --symbols folders (i.e. a KDK) and the images
+		not found locally are left to LLDB to locate by UUID.`),
+	Example: heredoc.Doc(`
+		# Debug a kernelcache with its KDK
+		❯ ipsw lldb-init kernelcache.release.mac14j --slide 0x1c8c000 --symbols /Library/Developer/KDKs/KDK_14.0_23A344.kdk -o kdk.lldb
+		❯ lldb -s kdk.lldb
+		# Set up a panic's kernelcache
+		❯ ipsw lldb-init panic-full-2024-03-21-004704.000.ips --kernel kernelcache.release.iPhone16,1
+		# Set up a crashed process with the device's symbols
+		❯ ipsw lldb-init Maps-2024-04-20-135807.ips --sysroot ~/Library/Developer/Xcode/iOS\ DeviceSupport/iPhone16,1\ 17.4\ \(21E219\)/Symbols`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		conf := &lldb.Config{
+			Kernelcache: viper.GetString("lldb-init.kernel"),
+			Sysroot:     viper.GetString("lldb-init.sysroot"),
+			SymbolDirs:  viper.GetStringSlice("lldb-init.symbols"),
+		}
+
+		var err error
+		var lldbInit *lldb.Init
+		if ok, _ := magic.IsMachO(args[0]); ok {
+			if !viper.IsSet("lldb-init.slide") {
+				log.Warn("no --slide supplied (the kernelcache is NOT slid)")
+			}
+			lldbInit, err = lldb.NewFromKernelcache(filepath.Clean(args[0]), viper.GetUint64("lldb-init.slide"), conf)
+		} else {
+			if viper.IsSet("lldb-init.slide") {
+				return fmt.Errorf("--slide is only for kernelcaches (the crashlog has the slides)")
+			}
+			lldbInit, err = lldb.NewFromCrashlog(filepath.Clean(args[0]), conf)
+		}
+		if err != nil {
+			return err
+		}
+
+		if output := viper.GetString("lldb-init.output"); len(output) > 0 {
+			f, err := os.Create(filepath.Clean(output))
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			if err := lldbInit.Write(f); err != nil {
+				return err
+			}
+			log.Infof("Created %s", output)
+			return nil
+		}
+
+		return lldbInit.Write(os.Stdout)
+	},
+}
@@ -42,7 +42,10 @@ import (
 	"github.com/blacktop/go-macho/pkg/fixupchains"
 	"github.com/blacktop/go-macho/types"
 	"github.com/blacktop/ipsw/internal/certs"
+	"github.com/blacktop/ipsw/internal/commands/binja"
+	"github.com/blacktop/ipsw/internal/commands/ida"
 	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/internal/commands/r2"
 	"github.com/blacktop/ipsw/internal/demangle"
 	"github.com/blacktop/ipsw/internal/magic"
 	swift "github.com/blacktop/ipsw/internal/swift"
@@ -123,6 +126,10 @@ func init() {
 	machoInfoCmd.Flags().Bool("dump-cert", false, "Dump the certificate")
 	machoInfoCmd.Flags().BoolP("bit-code", "b", false, "Dump the LLVM bitcode")
 	machoInfoCmd.Flags().Bool("demangle", false, "Demangle symbol names")
+	machoInfoCmd.Flags().Bool("ida", false, "Write an IDAPython script applying the recovered names, functions, types and comments")
+	machoInfoCmd.Flags().Bool("idc", false, "Write the --ida script as IDC")
+	machoInfoCmd.Flags().Bool("r2", false, "Write a radare2 script applying the recovered names, functions, types and comments")
+	machoInfoCmd.Flags().Bool("binja", false, "Write a Binary Ninja JSON export of the symbols, function starts, ObjC metadata and fixups")
 	machoInfoCmd.Flags().String("output", "", "Directory to extract files to")
 
 	viper.BindPFlag("macho.info.arch", machoInfoCmd.Flags().Lookup("arch"))
@@ -146,6 +153,10 @@ func init() {
 	viper.BindPFlag("macho.info.dump-cert", machoInfoCmd.Flags().Lookup("dump-cert"))
 	viper.BindPFlag("macho.info.bit-code", machoInfoCmd.Flags().Lookup("bit-code"))
 	viper.BindPFlag("macho.info.demangle", machoInfoCmd.Flags().Lookup("demangle"))
+	viper.BindPFlag("macho.info.ida", machoInfoCmd.Flags().Lookup("ida"))
+	viper.BindPFlag("macho.info.idc", machoInfoCmd.Flags().Lookup("idc"))
+	viper.BindPFlag("macho.info.r2", machoInfoCmd.Flags().Lookup("r2"))
+	viper.BindPFlag("macho.info.binja", machoInfoCmd.Flags().Lookup("binja"))
 	viper.BindPFlag("macho.info.output", machoInfoCmd.Flags().Lookup("output"))
 
 	machoInfoCmd.MarkZshCompPositionalArgumentFile(1)
@@ -204,6 +215,8 @@ var machoInfoCmd = &cobra.Command{
 			return fmt.Errorf("you must also supply --swift flag with the --swift-all flag")
 		} else if len(filesetEntry) == 0 && extractfilesetEntry {
 			return fmt.Errorf("you must supply a --fileset-entry|-t AND --extract-fileset-entry|-x to extract a file-set entry")
+		} else if viper.GetBool("macho.info.idc") && !viper.GetBool("macho.info.ida") {
+			return fmt.Errorf("you must also supply --ida flag with the --idc flag")
 		}
 
 		var options uint32
@@ -459,6 +472,68 @@ var machoInfoCmd = &cobra.Command{
 			}
 		}
 
+		if viper.GetBool("macho.info.ida") || viper.GetBool("macho.info.r2") {
+			name := filepath.Base(machoPath)
+			if len(filesetEntry) > 0 {
+				name = filesetEntry
+			}
+			a, err := mcmd.Annotate(name, m, &mcmd.AnnotateConfig{Symbols: true, Starts: true, ObjC: true})
+			if err != nil {
+				return fmt.Errorf("failed to annotate MachO: %v", err)
+			}
+			fname := filepath.Join(folder, name+".r2")
+			if viper.GetBool("macho.info.ida") {
+				fname = filepath.Join(folder, name+".ida.py")
+				if viper.GetBool("macho.info.idc") {
+					fname = filepath.Join(folder, name+".ida.idc")
+				}
+			}
+			f, err := os.Create(fname)
+			if err != nil {
+				return fmt.Errorf("failed to create script %s: %v", fname, err)
+			}
+			defer f.Close()
+			if viper.GetBool("macho.info.ida") {
+				err = ida.WriteScript(f, a, viper.GetBool("macho.info.idc"))
+			} else {
+				err = r2.WriteScript(f, a)
+			}
+			if err != nil {
+				return err
+			}
+			log.WithFields(log.Fields{
+				"names":     len(a.Names),
+				"functions": len(a.Functions),
+				"types":     len(a.Types),
+			}).Infof("Created %s", fname)
+			return nil
+		}
+
+		if viper.GetBool("macho.info.binja") {
+			name := filepath.Base(machoPath)
+			if len(filesetEntry) > 0 {
+				name = filesetEntry
+			}
+			exp, err := binja.NewExport(name, m)
+			if err != nil {
+				return fmt.Errorf("failed to export MachO: %v", err)
+			}
+			dat, err := json.Marshal(exp)
+			if err != nil {
+				return fmt.Errorf("failed to marshal Binary Ninja export: %v", err)
+			}
+			fname := filepath.Join(folder, name+".binja.json")
+			if err := os.WriteFile(fname, dat, 0o644); err != nil {
+				return fmt.Errorf("failed to write Binary Ninja export %s: %v", fname, err)
+			}
+			log.WithFields(log.Fields{
+				"symbols":   len(exp.Symbols),
+				"functions": len(exp.Functions),
+				"fixups":    len(exp.Fixups),
+			}).Infof("Created %s", fname)
+			return nil
+		}
+
 		if showHeader && !showLoadCommands {
 			if asJSON {
 				dat, err := m.FileHeader.MarshalJSON()
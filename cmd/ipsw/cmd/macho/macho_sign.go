@@ -57,7 +57,7 @@ func init() {
 	machoSignCmd.Flags().StringP("ent-der", "d", "", "entitlements asn1/der file")
 	machoSignCmd.Flags().Bool("ts", false, "timestamp signature")
 	machoSignCmd.Flags().String("timeserver", "http://timestamp.apple.com/ts01", "timeserver URL")
-	machoSignCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	machoSignCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	machoSignCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	machoSignCmd.Flags().BoolP("overwrite", "f", false, "Overwrite file")
 	machoSignCmd.Flags().StringP("output", "o", "", "Output codesigned file")
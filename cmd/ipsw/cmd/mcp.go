@@ -0,0 +1,150 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const mcpInstructions = `Tools to analyze Apple IPSW/OTA firmware files on the local machine.
+Paths are local file paths. Use ipsw_info first to learn an IPSW's version, build and devices.
+The dyld_shared_cache and entitlement tools mount/scan the IPSW on their first call (which can take a minute) and reuse it afterwards.
+The syms_* tools query the symbol database of IPSWs scanned by ipswd (use syms_ipsw to get the UUIDs to look up).`
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+
+	mcpCmd.Flags().String("db", "", "Path to the symbol database (sqlite) to query with the syms_* tools")
+	mcpCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	mcpCmd.Flags().String("cache", "", "Folder to cache the entitlement databases in (default is ~/.config/ipsw/mcp)")
+	mcpCmd.Flags().Bool("list", false, "List the tools and exit")
+	mcpCmd.MarkFlagFilename("db")
+	mcpCmd.MarkFlagDirname("cache")
+	viper.BindPFlag("mcp.db", mcpCmd.Flags().Lookup("db"))
+	viper.BindPFlag("mcp.pem-db", mcpCmd.Flags().Lookup("pem-db"))
+	viper.BindPFlag("mcp.cache", mcpCmd.Flags().Lookup("cache"))
+	viper.BindPFlag("mcp.list", mcpCmd.Flags().Lookup("list"))
+}
+
+// mcpCmd represents the mcp command
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run a Model Context Protocol (MCP) server exposing ipsw analysis tools",
+	Long: heredoc.Doc(`
+		Run a Model Context Protocol server on stdin/stdout so LLM agents can call ipsw's
+		analysis as structured tools against local IPSWs: IPSW info, dyld_shared_cache symbol
+		and string search, entitlement queries and diffs, IPSW diffs and (with --db) symbol
+		lookups in the symbol database of IPSWs scanned by ipswd.
+
+		The server is meant to be launched by an MCP client (logs are written to stderr).`),
+	Example: heredoc.Doc(`
+		# List the tools
+		❯ ipsw mcp --list
+		# Add ipsw to an MCP client's config (i.e. Claude Desktop's claude_desktop_config.json)
+		{
+		  "mcpServers": {
+		    "ipsw": {
+		      "command": "ipsw",
+		      "args": ["mcp", "--db", "/var/lib/ipswd/ipswd.db"]
+		    }
+		  }
+		}`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		cache := viper.GetString("mcp.cache")
+		if len(cache) == 0 {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get user home directory: %v", err)
+			}
+			cache = filepath.Join(home, ".config", "ipsw", "mcp")
+		}
+		conf := &mcp.Config{
+			PemDB: viper.GetString("mcp.pem-db"),
+			Cache: cache,
+		}
+		if path := viper.GetString("mcp.db"); len(path) > 0 {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("failed to find symbol database: %v", err)
+			}
+			d, err := db.NewSqlite(path, 100)
+			if err != nil {
+				return fmt.Errorf("failed to create symbol database: %v", err)
+			}
+			if err := d.Connect(); err != nil {
+				return fmt.Errorf("failed to connect to symbol database: %v", err)
+			}
+			defer d.Close()
+			conf.DB = d
+		}
+
+		tools := mcp.NewTools(conf)
+		defer func() {
+			if err := tools.Close(); err != nil {
+				log.WithError(err).Error("Failed to close tools")
+			}
+		}()
+
+		if viper.GetBool("mcp.list") {
+			for _, t := range tools.List() {
+				fmt.Printf("%s\n    %s\n", t.Name, t.Description)
+			}
+			return nil
+		}
+
+		// reserve stdout for the protocol (anything the tools print goes to stderr instead)
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		srv := mcp.NewServer("ipsw", strings.TrimSpace(AppVersion), mcpInstructions, tools.List()...)
+		log.Info("Serving MCP on stdio")
+		if err := srv.Serve(ctx, os.Stdin, stdout); err != nil {
+			return fmt.Errorf("failed to serve MCP: %v", err)
+		}
+
+		return nil
+	},
+}
@@ -22,12 +22,16 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"syscall"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/commands/mount"
 	"github.com/blacktop/ipsw/internal/utils"
@@ -38,19 +42,33 @@ func init() {
 	rootCmd.AddCommand(mountCmd)
 
 	mountCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	mountCmd.Flags().StringP("mount-point", "m", "", "Folder to mount the DMG(s) at with FUSE (linux only)")
 }
 
 // mountCmd represents the mount command
 var mountCmd = &cobra.Command{
-	Use:           "mount [fs|sys|app|exc] IPSW",
-	Aliases:       []string{"mo", "mnt"},
-	Short:         "Mount DMG from IPSW",
+	Use:     "mount [fs|sys|app|exc|all] IPSW",
+	Aliases: []string{"mo", "mnt"},
+	Short:   "Mount DMG from IPSW",
+	Long: heredoc.Doc(`
+		Mount a DMG from an IPSW.
+
+		On linux the DMGs are mounted read-only with FUSE (no apfs-fuse needed) and
+		'all' mounts the filesystem DMG with its cryptexes at the folders they are
+		mounted at on device (i.e. System/Cryptexes/OS).
+
+		NOTE: requires fuse/fusermount on linux.`),
+	Example: heredoc.Doc(`
+		# Mount the SystemOS cryptex DMG
+		❯ ipsw mount sys iPhone15,2_18.0_22A3354_Restore.ipsw
+		# Mount the filesystem DMG and all its cryptexes at /tmp/ipsw (linux only)
+		❯ ipsw mount all iPhone15,2_18.0_22A3354_Restore.ipsw --mount-point /tmp/ipsw`),
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Args:          cobra.ExactArgs(2),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
-			return mount.DmgTypes, cobra.ShellCompDirectiveNoFileComp
+			return append(mount.DmgTypes, "all"), cobra.ShellCompDirectiveNoFileComp
 		}
 		return []string{"ipsw"}, cobra.ShellCompDirectiveFilterFileExt
 	},
@@ -61,6 +79,30 @@ var mountCmd = &cobra.Command{
 		}
 
 		pemDB, _ := cmd.Flags().GetString("pem-db")
+		mountPoint, _ := cmd.Flags().GetString("mount-point")
+
+		if runtime.GOOS == "linux" {
+			typs := []string{args[0]}
+			if args[0] == "all" {
+				typs = mount.DmgTypes
+			}
+			if len(mountPoint) == 0 {
+				mountPoint = filepath.Join(os.TempDir(), strings.TrimSuffix(filepath.Base(args[1]), filepath.Ext(args[1]))+".mount")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				utils.Indent(log.Info, 2)(fmt.Sprintf("Unmounting %s", mountPoint))
+			}()
+			log.Infof("Mounting %s DMG(s) at %s (press Ctrl+C to unmount)", args[0], mountPoint)
+			if err := mount.FuseInIPSW(ctx, args[1], typs, mountPoint, pemDB); err != nil {
+				return fmt.Errorf("failed to mount %s DMG: %v", args[0], err)
+			}
+			return nil
+		} else if args[0] == "all" || len(mountPoint) > 0 {
+			return fmt.Errorf("'all' and --mount-point are only supported on linux")
+		}
 
 		mctx, err := mount.DmgInIPSW(args[1], args[0], pemDB)
 		if err != nil {
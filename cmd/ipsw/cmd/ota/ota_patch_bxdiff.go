@@ -23,13 +23,21 @@ package ota
 
 import (
 	"archive/zip"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/info"
 	"github.com/blacktop/ipsw/pkg/ota/bxdiff50"
+	"github.com/blacktop/ipsw/pkg/ota/ridiff"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -38,17 +46,37 @@ func init() {
 	otaPatchCmd.AddCommand(otaPatchBxdiffCmd)
 
 	otaPatchBxdiffCmd.Flags().BoolP("single", "s", false, "Patch single file")
+	otaPatchBxdiffCmd.Flags().StringP("pattern", "p", "", "Only patch files whose path matches regex")
 	otaPatchBxdiffCmd.Flags().StringP("output", "o", "", "Output folder")
+	otaPatchBxdiffCmd.Flags().Bool("strict", false, "Fail on SHA1 mismatches of the prior files or the patched output (instead of warning)")
 	otaPatchBxdiffCmd.MarkFlagDirname("output")
 	viper.BindPFlag("ota.patch.bxdiff.single", otaPatchBxdiffCmd.Flags().Lookup("single"))
+	viper.BindPFlag("ota.patch.bxdiff.pattern", otaPatchBxdiffCmd.Flags().Lookup("pattern"))
 	viper.BindPFlag("ota.patch.bxdiff.output", otaPatchBxdiffCmd.Flags().Lookup("output"))
+	viper.BindPFlag("ota.patch.bxdiff.strict", otaPatchBxdiffCmd.Flags().Lookup("strict"))
 }
 
+const otaPatchesPrefix = "AssetData/payloadv2/patches/"
+
 // otaPatchBxdiffCmd represents the bxdiff command
 var otaPatchBxdiffCmd = &cobra.Command{
-	Use:           "bxdiff <DELTA> <TARGET>",
-	Aliases:       []string{"b"},
-	Short:         "Patch BXDIFF50 OTAs",
+	Use:     "bxdiff <DELTA> <TARGET>",
+	Aliases: []string{"b"},
+	Short:   "Patch BXDIFF50 OTAs",
+	Long: `Patch BXDIFF50 OTAs
+
+Apply the patches of a delta OTA (see 'ipsw download ota --delta') against the
+files of the prior build (a mounted or extracted filesystem) to materialize the
+updated files. With --single <DELTA> is a single patch and <TARGET> the file it patches.`,
+	Example: heredoc.Doc(`
+		# Apply all the patches of a delta OTA against the mounted prior build's filesystem
+		❯ ipsw ota patch bxdiff OTA.zip /Volumes/PriorBuild
+		# Only patch the dyld_shared_caches
+		❯ ipsw ota patch bxdiff OTA.zip /Volumes/PriorBuild --pattern dyld_shared_cache
+		# Fail instead of warning when a prior file isn't the one a patch was created against
+		❯ ipsw ota patch bxdiff OTA.zip /Volumes/PriorBuild --strict
+		# Apply a single BXDIFF50 patch
+		❯ ipsw ota patch bxdiff --single path/to/patch path/to/prior/file`),
 	Args:          cobra.ExactArgs(2),
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -60,18 +88,33 @@ var otaPatchBxdiffCmd = &cobra.Command{
 		// flags
 		single := viper.GetBool("ota.patch.bxdiff.single")
 		output := viper.GetString("ota.patch.bxdiff.output")
+		strict := viper.GetBool("ota.patch.bxdiff.strict")
 
 		if single {
-			return bxdiff50.Patch(args[0], args[1], output)
+			return bxdiff50.Patch(args[0], args[1], output, strict)
 		}
 
-		panic("not implemented yet (try --single mode)")
+		var re *regexp.Regexp
+		if pattern := viper.GetString("ota.patch.bxdiff.pattern"); len(pattern) > 0 {
+			var err error
+			re, err = regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("failed to compile regex pattern '%s': %v", pattern, err)
+			}
+		}
 
 		patchPath := filepath.Clean(args[0])
+		priorRoot := filepath.Clean(args[1])
+
+		if fi, err := os.Stat(priorRoot); err != nil {
+			return fmt.Errorf("failed to stat prior build folder: %v", err)
+		} else if !fi.IsDir() {
+			return fmt.Errorf("prior build '%s' must be a folder (the mounted/extracted filesystem of the prior build)", priorRoot)
+		}
 
 		i, err := info.Parse(patchPath)
 		if err != nil {
-			return fmt.Errorf("failed to parse IPSW: %v", err)
+			return fmt.Errorf("failed to parse OTA: %v", err)
 		}
 		infoFolder, err := i.GetFolder()
 		if err != nil {
@@ -90,19 +133,98 @@ var otaPatchBxdiffCmd = &cobra.Command{
 		}
 		defer zr.Close()
 
-		var patchFiles []string
+		var patchFiles []*zip.File
 		for _, zf := range zr.File {
-			if regexp.MustCompile(`AssetData/payloadv2/patches/.*$`).MatchString(zf.Name) {
-				if !zf.FileInfo().IsDir() {
-					patchFiles = append(patchFiles, zf.Name)
+			if strings.HasPrefix(zf.Name, otaPatchesPrefix) && !zf.FileInfo().IsDir() {
+				if re != nil && !re.MatchString(strings.TrimPrefix(zf.Name, otaPatchesPrefix)) {
+					continue
 				}
+				patchFiles = append(patchFiles, zf)
+			}
+		}
+		if len(patchFiles) == 0 {
+			return fmt.Errorf("no patches found in OTA (is it a delta OTA?)")
+		}
+
+		log.Infof("Applying %d patches from %s", len(patchFiles), filepath.Base(patchPath))
+
+		var patched, missing, failed int
+		for _, zf := range patchFiles {
+			relPath := strings.TrimPrefix(zf.Name, otaPatchesPrefix)
+			target := filepath.Join(priorRoot, relPath)
+			if _, err := os.Stat(target); err != nil {
+				utils.Indent(log.WithField("file", relPath).Debug, 2)("Prior file not found")
+				missing++
+				continue
+			}
+			fname := filepath.Join(output, relPath)
+			if err := applyDeltaPatch(zf, target, fname, strict); err != nil {
+				utils.Indent(log.WithError(err).WithField("file", relPath).Error, 2)("Failed to patch")
+				failed++
+				continue
 			}
+			utils.Indent(log.Info, 2)("Created " + fname)
+			patched++
 		}
 
-		// FIXME: finish this
+		log.WithFields(log.Fields{
+			"patched": patched,
+			"missing": missing,
+			"failed":  failed,
+		}).Info("Done")
 
-		_ = patchFiles
+		if failed > 0 {
+			return fmt.Errorf("failed to apply %d patches", failed)
+		}
 
 		return nil
 	},
 }
+
+// applyDeltaPatch applies a BXDIFF50 or RIDIFF10 patch from the OTA to the prior build's file
+// (the BXDIFF50 SHA1 mismatches are only errors if strict)
+func applyDeltaPatch(zf *zip.File, target, output string, strict bool) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open patch: %v", err)
+	}
+	patch, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read patch: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0o750); err != nil {
+		return fmt.Errorf("failed to create output folder: %v", err)
+	}
+
+	switch {
+	case bxdiff50.IsBXDIFF50(patch):
+		tdat, err := os.ReadFile(target)
+		if err != nil {
+			return fmt.Errorf("failed to read prior file: %v", err)
+		}
+		out, err := bxdiff50.Apply(patch, tdat, strict)
+		if err != nil {
+			if strict || !errors.Is(err, bxdiff50.ErrResultMismatch) {
+				return err
+			}
+			utils.Indent(log.WithField("file", filepath.Base(output)).Warn, 2)(err.Error())
+		}
+		return os.WriteFile(output, out, 0o660)
+	case len(patch) >= 8 && binary.LittleEndian.Uint64(patch) == ridiff.RIDIFF10Magic:
+		tmp, err := os.CreateTemp("", "ridiff")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %v", err)
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(patch); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write temp file: %v", err)
+		}
+		tmp.Close()
+		return ridiff.RawImagePatch(target, tmp.Name(), output, 0)
+	default:
+		return fmt.Errorf("unsupported patch format (magic=%x)", patch[:min(len(patch), 8)])
+	}
+}
@@ -0,0 +1,211 @@
+//go:build darwin && cgo
+
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package ota
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/cryptex"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/blacktop/ipsw/pkg/ota"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	otaPatchCmd.AddCommand(otaPatchCryptexCmd)
+
+	otaPatchCryptexCmd.Flags().StringP("input", "i", "", "Prior IPSW or full cryptex DMG to apply the delta patch to")
+	otaPatchCryptexCmd.Flags().StringP("type", "t", "sys", "Cryptex to patch (sys or app)")
+	otaPatchCryptexCmd.Flags().StringP("arch", "a", "", "SystemOS cryptex architecture (i.e. arm64e)")
+	otaPatchCryptexCmd.Flags().String("pem-db", "", "AEA pem DB JSON file (to decrypt the prior IPSW's cryptex)")
+	otaPatchCryptexCmd.Flags().BoolP("dyld", "d", false, "Extract the dyld_shared_caches from the patched cryptex")
+	otaPatchCryptexCmd.Flags().StringP("pattern", "p", "", "Extract the files matching regex from the patched cryptex")
+	otaPatchCryptexCmd.Flags().StringP("output", "o", "", "Output folder")
+	otaPatchCryptexCmd.MarkFlagRequired("input")
+	otaPatchCryptexCmd.MarkFlagDirname("output")
+	otaPatchCryptexCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"sys", "app"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	viper.BindPFlag("ota.patch.cryptex.input", otaPatchCryptexCmd.Flags().Lookup("input"))
+	viper.BindPFlag("ota.patch.cryptex.type", otaPatchCryptexCmd.Flags().Lookup("type"))
+	viper.BindPFlag("ota.patch.cryptex.arch", otaPatchCryptexCmd.Flags().Lookup("arch"))
+	viper.BindPFlag("ota.patch.cryptex.pem-db", otaPatchCryptexCmd.Flags().Lookup("pem-db"))
+	viper.BindPFlag("ota.patch.cryptex.dyld", otaPatchCryptexCmd.Flags().Lookup("dyld"))
+	viper.BindPFlag("ota.patch.cryptex.pattern", otaPatchCryptexCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("ota.patch.cryptex.output", otaPatchCryptexCmd.Flags().Lookup("output"))
+}
+
+// otaPatchCryptexCmd represents the ota patch cryptex command
+var otaPatchCryptexCmd = &cobra.Command{
+	Use:     "cryptex <OTA>",
+	Aliases: []string{"c"},
+	Short:   "Apply a delta OTA's cryptex patch to the prior build's cryptex",
+	Long: heredoc.Doc(`
+		Apply a delta OTA's cryptex RIDIFF patch to the prior build's full cryptex to
+		produce the updated cryptex DMG (and its dyld_shared_caches/files) without
+		downloading the full new build.
+
+		NOTE: patching is only supported on macOS 13+.`),
+	Example: heredoc.Doc(`
+		# Patch the prior IPSW's SystemOS cryptex and extract the new dyld_shared_caches
+		❯ ipsw ota patch cryptex --input iPhone16,1_18.0_22A3354_Restore.ipsw --dyld OTA.zip
+		# Patch a prior AppOS cryptex DMG and extract the new Safari binaries
+		❯ ipsw ota patch cryptex --type app --input 090-29713-049.dmg --pattern 'MobileSafari' OTA.zip`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// flags
+		input := filepath.Clean(viper.GetString("ota.patch.cryptex.input"))
+		name, ok := map[string]string{"sys": "SystemOS", "app": "AppOS"}[viper.GetString("ota.patch.cryptex.type")]
+		if !ok {
+			return fmt.Errorf("invalid --type '%s' (must be one of: sys or app)", viper.GetString("ota.patch.cryptex.type"))
+		}
+		var re *regexp.Regexp
+		if viper.IsSet("ota.patch.cryptex.pattern") {
+			var err error
+			if re, err = regexp.Compile(viper.GetString("ota.patch.cryptex.pattern")); err != nil {
+				return fmt.Errorf("failed to compile regex pattern '%s': %v", viper.GetString("ota.patch.cryptex.pattern"), err)
+			}
+		}
+
+		otaPath := filepath.Clean(args[0])
+
+		o, err := ota.Open(otaPath, viper.GetString("ota.key-val"))
+		if err != nil {
+			return fmt.Errorf("failed to open OTA: %v", err)
+		}
+		i, err := o.Info()
+		o.Close()
+		if err != nil {
+			return fmt.Errorf("failed to get OTA info: %v", err)
+		}
+		output, err := i.GetFolder()
+		if err != nil {
+			return fmt.Errorf("failed to get OTA folder: %v", err)
+		}
+		if viper.IsSet("ota.patch.cryptex.output") {
+			output = filepath.Join(viper.GetString("ota.patch.cryptex.output"), output)
+		}
+
+		tmpDir, err := os.MkdirTemp("", "ota_patch_cryptex")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		conf := &cryptex.Config{
+			PemDB:  viper.GetString("ota.patch.cryptex.pem-db"),
+			OtaKey: viper.GetString("ota.key-val"),
+			Output: tmpDir,
+		}
+
+		// find the OTA's cryptex patch
+		patches, err := cryptex.List(otaPath, conf)
+		if err != nil {
+			return fmt.Errorf("failed to find cryptex patches in OTA: %v", err)
+		}
+		var patch *cryptex.Cryptex
+		for _, c := range patches {
+			if c.Name != name || (viper.IsSet("ota.patch.cryptex.arch") && c.Arch != viper.GetString("ota.patch.cryptex.arch")) {
+				continue
+			}
+			if patch != nil {
+				return fmt.Errorf("found multiple %s cryptex patches (use --arch to pick one)", name)
+			}
+			patch = c
+		}
+		if patch == nil {
+			return fmt.Errorf("no %s cryptex patch found in OTA", name)
+		}
+
+		// get the prior build's full cryptex
+		prior := input
+		if isZip, err := magic.IsZip(input); err != nil {
+			return fmt.Errorf("failed to read --input: %v", err)
+		} else if isZip {
+			cryptexes, err := cryptex.List(input, conf)
+			if err != nil {
+				return fmt.Errorf("failed to find cryptexes in %s: %v", filepath.Base(input), err)
+			}
+			prior = ""
+			for _, c := range cryptexes {
+				if c.Name == name {
+					log.Infof("Extracting prior %s cryptex %s", name, c.Path)
+					if prior, err = cryptex.Extract(input, c, conf); err != nil {
+						return fmt.Errorf("failed to extract prior %s cryptex: %v", name, err)
+					}
+					break
+				}
+			}
+			if len(prior) == 0 {
+				return fmt.Errorf("no %s cryptex found in %s", name, filepath.Base(input))
+			}
+		}
+
+		log.Infof("Patching %s onto %s", patch.Path, filepath.Base(prior))
+		conf.Output = output
+		patched, err := cryptex.Patch(otaPath, patch, prior, conf)
+		if err != nil {
+			return err
+		}
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Created %s", patched))
+
+		if viper.GetBool("ota.patch.cryptex.dyld") {
+			log.Info("Extracting dyld_shared_cache Files")
+			var arches []string
+			if len(patch.Arch) > 0 {
+				arches = append(arches, patch.Arch)
+			}
+			if _, err := dyld.ExtractFromDMG(i, patched, output, conf.PemDB, arches, false, false); err != nil {
+				return fmt.Errorf("failed to extract dyld_shared_caches from patched cryptex: %v", err)
+			}
+		}
+		if re != nil {
+			log.WithField("pattern", re.String()).Info("Extracting Files Matching Pattern")
+			out, err := utils.ExtractFromDMG("", patched, filepath.Join(output, name), conf.PemDB, re)
+			if err != nil {
+				return fmt.Errorf("failed to extract files from patched cryptex: %v", err)
+			}
+			if len(out) == 0 {
+				log.Warnf("no files matched '%s'", re)
+			}
+		}
+
+		return nil
+	},
+}
@@ -28,13 +28,16 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/ota"
 	"github.com/blacktop/ipsw/pkg/ota/pbzx"
 	"github.com/blacktop/ipsw/pkg/ota/yaa"
@@ -49,16 +52,26 @@ func init() {
 
 	otaPayloadCmd.Flags().BoolP("files", "f", false, "Files only")
 	otaPayloadCmd.Flags().BoolP("dirs", "d", false, "Directories only")
+	otaPayloadCmd.Flags().StringP("pattern", "p", "", "Regex pattern of the entries to list/extract")
+	otaPayloadCmd.Flags().StringP("output", "o", "", "Extract the (matching) entries to output folder")
+	otaPayloadCmd.MarkFlagDirname("output")
 	viper.BindPFlag("ota.payload.files", otaPayloadCmd.Flags().Lookup("files"))
 	viper.BindPFlag("ota.payload.dirs", otaPayloadCmd.Flags().Lookup("dirs"))
+	viper.BindPFlag("ota.payload.pattern", otaPayloadCmd.Flags().Lookup("pattern"))
+	viper.BindPFlag("ota.payload.output", otaPayloadCmd.Flags().Lookup("output"))
 }
 
 // otaPayloadCmd represents the payload command
 var otaPayloadCmd = &cobra.Command{
-	Use:           "payload <PAYLOAD>|<OTA> <PAYLOAD>",
-	Aliases:       []string{"p"},
-	Short:         "List contents of a payloadv2 file",
-	Args:          cobra.MaximumNArgs(2),
+	Use:     "payload <PAYLOAD>|<OTA> <PAYLOAD>",
+	Aliases: []string{"p"},
+	Short:   "List/extract contents of a payloadv2 file",
+	Example: heredoc.Doc(`
+		# List the files in a payloadv2 file
+		❯ ipsw ota payload --files payload.000
+		# Extract the dylibs from a payloadv2 file in an OTA
+		❯ ipsw ota payload OTA.zip payload.000 --pattern '\.dylib$' --output /tmp/payload`),
+	Args:          cobra.RangeArgs(1, 2),
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -72,6 +85,47 @@ var otaPayloadCmd = &cobra.Command{
 			return fmt.Errorf("cannot use both --files and --dirs flags")
 		}
 
+		var re *regexp.Regexp
+		if viper.IsSet("ota.payload.pattern") {
+			var err error
+			if re, err = regexp.Compile(viper.GetString("ota.payload.pattern")); err != nil {
+				return fmt.Errorf("failed to compile regex pattern '%s': %v", viper.GetString("ota.payload.pattern"), err)
+			}
+		}
+
+		if viper.IsSet("ota.payload.output") {
+			var in io.Reader
+			if len(args) < 2 {
+				pf, err := os.Open(filepath.Clean(args[0]))
+				if err != nil {
+					return fmt.Errorf("failed to open payload: %v", err)
+				}
+				defer pf.Close()
+				in = pf
+			} else {
+				o, err := ota.Open(filepath.Clean(args[0]), viper.GetString("ota.key-val"))
+				if err != nil {
+					return fmt.Errorf("failed to open OTA file: %v", err)
+				}
+				defer o.Close()
+				f, err := o.Open(filepath.Clean(args[1]), false)
+				if err != nil {
+					return fmt.Errorf("failed to open payload: %v", err)
+				}
+				defer f.Close()
+				in = f
+			}
+			log.Info("Extracting Payload Entries")
+			out, err := ota.ExtractPayload(context.Background(), in, viper.GetString("ota.payload.output"), re)
+			if err != nil {
+				return fmt.Errorf("failed to extract payload: %v", err)
+			}
+			for _, fname := range out {
+				utils.Indent(log.Info, 2)(fname)
+			}
+			return nil
+		}
+
 		var aa *yaa.YAA
 
 		if len(args) < 2 {
@@ -145,7 +199,9 @@ var otaPayloadCmd = &cobra.Command{
 					fmt.Fprintf(w, "%s\n", colorModTime(f.String()))
 				}
 			} else {
-				if viper.GetBool("ota.payload.files") && f.Type != yaa.RegularFile {
+				if re != nil && !re.MatchString(f.Path) {
+					continue
+				} else if viper.GetBool("ota.payload.files") && f.Type != yaa.RegularFile {
 					continue
 				} else if viper.GetBool("ota.payload.dirs") && f.Type != yaa.Directory {
 					continue
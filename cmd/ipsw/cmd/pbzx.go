@@ -22,69 +22,156 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/magic"
 	"github.com/blacktop/ipsw/pkg/ota/pbzx"
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
 	rootCmd.AddCommand(pbzxCmd)
+
+	pbzxCmd.Flags().BoolP("compress", "c", false, "Compress the file into a pbzx stream")
+	pbzxCmd.Flags().StringP("block-size", "b", "16MB", "Size of the compressed chunks")
+	pbzxCmd.Flags().IntP("workers", "w", runtime.NumCPU(), "Number of (de)compression workers")
+	pbzxCmd.Flags().StringP("output", "o", "", "Output file ('-' for stdout)")
+	viper.BindPFlag("pbzx.compress", pbzxCmd.Flags().Lookup("compress"))
+	viper.BindPFlag("pbzx.block-size", pbzxCmd.Flags().Lookup("block-size"))
+	viper.BindPFlag("pbzx.workers", pbzxCmd.Flags().Lookup("workers"))
+	viper.BindPFlag("pbzx.output", pbzxCmd.Flags().Lookup("output"))
 }
 
 // pbzxCmd represents the pbzx command
 var pbzxCmd = &cobra.Command{
-	Use:           "pbzx",
-	Short:         "Decompess pbzx files",
+	Use:   "pbzx <FILE>",
+	Short: "Decompress/compress pbzx files",
+	Example: heredoc.Doc(`
+		# Decompress an OTA payload (to payload.000.extracted)
+		❯ ipsw pbzx payload.000
+
+		# Decompress a pbzx stream from stdin into a YAA stream on stdout
+		❯ cat payload.000 | ipsw pbzx -o - - | aa list
+
+		# Compress a file into a pbzx stream (to payload.pbzx)
+		❯ ipsw pbzx --compress --output payload.pbzx payload.yaa`),
 	Args:          cobra.ExactArgs(1),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	Hidden:        true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if Verbose {
 			log.SetLevel(log.DebugLevel)
 		}
 
-		infile := filepath.Clean(args[0])
+		// flags
+		compress := viper.GetBool("pbzx.compress")
+		output := viper.GetString("pbzx.output")
+		blockSize, err := humanize.ParseBytes(viper.GetString("pbzx.block-size"))
+		if err != nil {
+			return fmt.Errorf("invalid block size '%s': %v", viper.GetString("pbzx.block-size"), err)
+		}
 
-		if isPBZX, err := magic.IsPBZX(infile); err != nil {
-			return err
-		} else if !isPBZX {
-			return fmt.Errorf("file is not a pbzx file")
+		var in io.Reader = os.Stdin
+		infile := args[0]
+		if infile != "-" {
+			infile = filepath.Clean(infile)
+			if !compress {
+				if isPBZX, err := magic.IsPBZX(infile); err != nil {
+					return err
+				} else if !isPBZX {
+					return fmt.Errorf("file is not a pbzx file")
+				}
+			}
+			f, err := os.Open(infile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
 		}
 
-		f, err := os.Open(infile)
-		if err != nil {
-			return err
+		if len(output) == 0 {
+			switch {
+			case infile == "-":
+				output = "-"
+			case compress:
+				output = infile + ".pbzx"
+			default:
+				output = strings.TrimSuffix(infile, ".pbzx") + ".extracted"
+			}
 		}
-		defer f.Close()
+		var out io.Writer = os.Stdout
+		if output != "-" {
+			f, err := os.Create(filepath.Clean(output))
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
-		finfo, err := f.Stat()
+		cr := &countingReader{r: in}
+		cw := &countingWriter{w: out}
+		if compress {
+			err = pbzx.Compress(ctx, cr, cw, int(blockSize), viper.GetInt("pbzx.workers"))
+		} else {
+			err = pbzx.Extract(ctx, cr, cw, viper.GetInt("pbzx.workers"))
+		}
 		if err != nil {
+			if output != "-" {
+				os.Remove(output)
+			}
 			return err
 		}
 
-		var pbuf bytes.Buffer
-		if err := pbzx.Extract(context.Background(), f, &pbuf, runtime.NumCPU()); err != nil {
-			return err
+		if output != "-" {
+			compressed, decompressed, msg := cr.n, cw.n, "Extracted PBZX file"
+			if compress {
+				compressed, decompressed, msg = cw.n, cr.n, "Compressed PBZX file"
+			}
+			log.WithFields(log.Fields{
+				"compressed":   fmt.Sprintf("%d bytes (%s)", compressed, humanize.IBytes(uint64(compressed))),
+				"decompressed": fmt.Sprintf("%d bytes (%s)", decompressed, humanize.IBytes(uint64(decompressed))),
+				"output":       output,
+			}).Info(msg)
 		}
+		return nil
+	},
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
 
-		fname := infile + ".extracted"
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
 
-		log.WithFields(log.Fields{
-			"compressed":   fmt.Sprintf("%d bytes (%s)", finfo.Size(), humanize.IBytes(uint64(finfo.Size()))),
-			"decompressed": fmt.Sprintf("%d bytes (%s)", pbuf.Len(), humanize.IBytes(uint64(pbuf.Len()))),
-			"output":       fname,
-		}).Info("Extracted PBZX file")
-		return os.WriteFile(fname, pbuf.Bytes(), 0o644)
-	},
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
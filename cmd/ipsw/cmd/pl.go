@@ -31,15 +31,19 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/alecthomas/chroma/v2/quick"
 	"github.com/apex/log"
 	"github.com/blacktop/go-plist"
+	plcmd "github.com/blacktop/ipsw/internal/commands/plist"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var cache map[string]string
@@ -85,8 +89,20 @@ func init() {
 		"ContextStoreAgent.plist",
 		"com.apple.knowledge-agent.plist",
 		"com.apple.universalaccess.plist"}, "Exclude files/directories from watching")
+	plistCmd.Flags().StringP("convert", "c", "", fmt.Sprintf("Convert to format (%s)", strings.Join(plcmd.Formats, ", ")))
+	plistCmd.Flags().StringP("query", "q", "", "Query the values at a jq-like path (i.e. '.Key.SubKey[0]', '.Array[].Key' or '.[\"key.with.dots\"]')")
+	plistCmd.Flags().BoolP("diff", "d", false, "Diff two plists (by path)")
+	plistCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	plistCmd.RegisterFlagCompletionFunc("convert", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return plcmd.Formats, cobra.ShellCompDirectiveDefault
+	})
 	viper.BindPFlag("plist.watch", plistCmd.Flags().Lookup("watch"))
 	viper.BindPFlag("plist.exclude", plistCmd.Flags().Lookup("exclude"))
+	viper.BindPFlag("plist.convert", plistCmd.Flags().Lookup("convert"))
+	viper.BindPFlag("plist.query", plistCmd.Flags().Lookup("query"))
+	viper.BindPFlag("plist.diff", plistCmd.Flags().Lookup("diff"))
+	viper.BindPFlag("plist.output", plistCmd.Flags().Lookup("output"))
+	plistCmd.MarkFlagsMutuallyExclusive("watch", "convert", "query", "diff")
 
 	cache = make(map[string]string)
 }
@@ -95,8 +111,19 @@ func init() {
 var plistCmd = &cobra.Command{
 	Use:     "plist <file|watch-path>",
 	Aliases: []string{"pl"},
-	Short:   "Dump plist as JSON",
-	Args:    cobra.MaximumNArgs(1),
+	Short:   "Dump, convert, query or diff plists",
+	Example: heredoc.Doc(`
+		# Dump a (binary, XML or OpenStep) plist as JSON
+		❯ ipsw plist Info.plist
+		# Convert JSON (or a plist) to a binary plist
+		❯ ipsw plist --convert binary -o Info.plist Info.json
+		# Query the values at a path
+		❯ ipsw plist BuildManifest.plist --query '.BuildIdentities[].Info.Variant'
+		# Diff two plists
+		❯ ipsw plist --diff 22A3354/BuildManifest.plist 22B83/BuildManifest.plist
+		# Watch the defaults changes
+		❯ ipsw plist --watch`),
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 
 		if Verbose {
@@ -193,47 +220,122 @@ var plistCmd = &cobra.Command{
 			return watcher.Close()
 		}
 
-		// print mode
-		var data []byte
-		if len(args) > 0 {
-			data, err = os.ReadFile(args[0])
-			if err != nil {
-				return fmt.Errorf("failed to read plist: %v", err)
+		readInput := func(path string) ([]byte, error) {
+			if len(path) > 0 {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read plist: %v", err)
+				}
+				return data, nil
 			}
-		} else {
 			// Read from stdin
 			stat, err := os.Stdin.Stat()
 			if err != nil {
-				return fmt.Errorf("failed to read from stdin: %v", err)
+				return nil, fmt.Errorf("failed to read from stdin: %v", err)
 			}
-			if (stat.Mode() & os.ModeCharDevice) == 0 {
-				data, err = io.ReadAll(os.Stdin)
-				if err != nil {
-					return fmt.Errorf("failed to read from stdin: %v", err)
+			if (stat.Mode() & os.ModeCharDevice) != 0 {
+				return nil, fmt.Errorf("no input provided via stdin")
+			}
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read from stdin: %v", err)
+			}
+			return data, nil
+		}
+
+		writeOutput := func(data []byte, lexer string) error {
+			if output := viper.GetString("plist.output"); len(output) > 0 {
+				if err := os.WriteFile(filepath.Clean(output), data, 0o644); err != nil {
+					return fmt.Errorf("failed to write %s: %v", output, err)
 				}
-			} else {
-				return fmt.Errorf("no input provided via stdin")
+				log.Infof("Created %s", output)
+				return nil
+			}
+			if len(lexer) > 0 && viper.GetBool("color") && !viper.GetBool("no-color") {
+				if err := quick.Highlight(os.Stdout, string(data)+"\n", lexer, "terminal256", "nord"); err != nil {
+					return fmt.Errorf("failed to highlight %s: %v", lexer, err)
+				}
+				return nil
 			}
+			if _, err := os.Stdout.Write(data); err != nil {
+				return err
+			}
+			if len(lexer) > 0 {
+				fmt.Println()
+			}
+			return nil
 		}
 
-		var out map[string]any
-		if err := plist.NewDecoder(bytes.NewReader(data)).Decode(&out); err != nil {
-			return fmt.Errorf("failed to decode plist: %v", err)
+		if viper.GetBool("plist.diff") { // diff mode
+			if len(args) != 2 {
+				return fmt.Errorf("must supply two plists to --diff")
+			}
+			var plists [2]any
+			for i, path := range args {
+				data, err := readInput(path)
+				if err != nil {
+					return err
+				}
+				if plists[i], _, err = plcmd.Decode(data); err != nil {
+					return fmt.Errorf("failed to decode %s: %v", path, err)
+				}
+			}
+			var out bytes.Buffer
+			for _, change := range plcmd.Diff(plists[0], plists[1]) {
+				fmt.Fprintln(&out, change)
+			}
+			if out.Len() == 0 {
+				log.Info("No differences found")
+				return nil
+			}
+			return writeOutput(bytes.TrimSuffix(out.Bytes(), []byte("\n")), "diff")
+		} else if len(args) > 1 {
+			return fmt.Errorf("only --diff takes two plists")
 		}
 
-		jsonData, err := json.MarshalIndent(out, "", "  ")
+		// print mode
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		}
+		data, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		out, _, err := plcmd.Decode(data)
 		if err != nil {
-			return fmt.Errorf("failed to marshal json: %v", err)
+			return err
 		}
 
-		if viper.GetBool("color") && !viper.GetBool("no-color") {
-			if err := quick.Highlight(os.Stdout, string(jsonData)+"\n", "json", "terminal256", "nord"); err != nil {
-				return fmt.Errorf("failed to highlight json: %v", err)
+		if query := viper.GetString("plist.query"); len(query) > 0 {
+			values, err := plcmd.Query(out, query)
+			if err != nil {
+				return err
+			}
+			if len(values) == 1 {
+				out = values[0]
+			} else {
+				out = values
 			}
-		} else {
-			fmt.Println(string(jsonData))
 		}
 
-		return nil
+		format := plcmd.FormatJSON
+		if viper.IsSet("plist.convert") {
+			format = viper.GetString("plist.convert")
+		}
+		dat, err := plcmd.Encode(out, format)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %v", format, err)
+		}
+		switch format {
+		case plcmd.FormatJSON:
+			return writeOutput(dat, "json")
+		case plcmd.FormatXML:
+			return writeOutput(dat, "xml")
+		}
+		if len(viper.GetString("plist.output")) == 0 && term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("refusing to write a binary plist to the terminal (use --output)")
+		}
+		return writeOutput(dat, "")
 	},
 }
@@ -0,0 +1,218 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/plugin"
+	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginAnalyzeCmd)
+
+	pluginListCmd.Flags().Bool("json", false, "Output as JSON")
+	pluginAnalyzeCmd.Flags().String("path", "", "Path of the MachO in the IPSW's filesystem (defaults to the file's name)")
+}
+
+// addPluginCommands adds the plugins' commands to the root command
+// (the plugins are loaded before the config so they are only loaded from the default folders)
+func addPluginCommands() {
+	plugins, err := plugin.Load(plugin.DefaultDirs()...)
+	if err != nil {
+		log.WithError(err).Debug("Failed to load plugins")
+		return
+	}
+	for _, p := range plugins {
+		for _, c := range p.Commands {
+			if cmd, _, err := rootCmd.Find([]string{c.Name}); err == nil && cmd != rootCmd {
+				log.Debugf("Skipping plugin '%s' command '%s' (it conflicts with an ipsw command)", p.Name, c.Name)
+				continue
+			}
+			rootCmd.AddCommand(&cobra.Command{
+				Use:                c.Name,
+				Short:              c.Short,
+				Long:               c.Long,
+				DisableFlagParsing: true,
+				SilenceUsage:       true,
+				Annotations:        map[string]string{"plugin": p.Name},
+				RunE: func(cmd *cobra.Command, args []string) error {
+					ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+					defer stop()
+					return p.Run(ctx, c.Name, args, os.Stdin, os.Stdout, os.Stderr)
+				},
+			})
+		}
+	}
+}
+
+// pluginCmd represents the plugin command
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage and test plugins",
+	Long: heredoc.Doc(`
+		Plugins add analyzers (run by ipswd's symbol scans on each IPSW or MachO) and ipsw
+		subcommands without forking ipsw. A plugin is a folder with a plugin.yml manifest
+		and an executable; they are loaded from the IPSW_PLUGINS_PATH folders or
+		~/.config/ipsw/plugins (and from the daemon's 'plugins' config).`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// pluginListCmd represents the plugin list command
+var pluginListCmd = &cobra.Command{
+	Use:           "list",
+	Aliases:       []string{"ls"},
+	Short:         "List the installed plugins",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		plugins, err := plugin.Load(plugin.DefaultDirs()...)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			dat, err := json.MarshalIndent(plugins, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal plugins: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+		if len(plugins) == 0 {
+			log.Warnf("No plugins found in %s", strings.Join(plugin.DefaultDirs(), ", "))
+			return nil
+		}
+		for _, p := range plugins {
+			fmt.Printf("%s %s (%s)\n", p.Name, p.Version, p.Dir)
+			if len(p.Description) > 0 {
+				fmt.Printf("    %s\n", p.Description)
+			}
+			for _, a := range p.Analyzers {
+				fmt.Printf("    analyzer: %s (%s)\n", a.Name, a.Scope)
+			}
+			for _, c := range p.Commands {
+				fmt.Printf("    command:  %s\n", c.Name)
+			}
+		}
+		return nil
+	},
+}
+
+// pluginAnalyzeCmd represents the plugin analyze command
+var pluginAnalyzeCmd = &cobra.Command{
+	Use:   "analyze <PLUGIN> <ANALYZER> <IPSW|MACHO>",
+	Short: "Run a plugin analyzer on an IPSW or MachO",
+	Example: heredoc.Doc(`
+		# Run the 'secrets' analyzer of the 'acme' plugin on a MachO
+		❯ ipsw plugin analyze acme secrets /tmp/locationd --path /usr/libexec/locationd
+		# Run the 'manifest' analyzer of the 'acme' plugin on an IPSW
+		❯ ipsw plugin analyze acme manifest iPhone15,2_18.0_22A3354_Restore.ipsw`),
+	Args:          cobra.ExactArgs(3),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		path, _ := cmd.Flags().GetString("path")
+
+		plugins, err := plugin.Load(plugin.DefaultDirs()...)
+		if err != nil {
+			return err
+		}
+		an, err := plugin.Find(plugins, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		file := filepath.Clean(args[2])
+
+		in := &plugin.Input{}
+		switch an.Scope {
+		case plugin.ScopeIPSW:
+			inf, err := info.Parse(file)
+			if err != nil {
+				return fmt.Errorf("failed to parse IPSW info: %v", err)
+			}
+			in.IPSW = &plugin.IPSW{
+				Path:    file,
+				Version: inf.Plists.BuildManifest.ProductVersion,
+				Build:   inf.Plists.BuildManifest.ProductBuildVersion,
+				Devices: inf.Plists.BuildManifest.SupportedProductTypes,
+			}
+		case plugin.ScopeMacho:
+			if ok, _ := magic.IsMachO(file); !ok {
+				return fmt.Errorf("%s is not a MachO", file)
+			}
+			if len(path) == 0 {
+				path = "/" + filepath.Base(file)
+			}
+			in.Macho = &plugin.Macho{Path: path, File: file}
+			if m, err := macho.Open(file); err == nil {
+				if m.UUID() != nil {
+					in.Macho.UUID = m.UUID().String()
+				}
+				m.Close()
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		out, err := an.Analyze(ctx, in)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			log.Info("Analyzer reported nothing")
+			return nil
+		}
+		dat, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	},
+}
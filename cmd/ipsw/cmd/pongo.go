@@ -47,7 +47,7 @@ import (
 
 func init() {
 	rootCmd.AddCommand(pongoCmd)
-	pongoCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	pongoCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	pongoCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	pongoCmd.Flags().BoolP("remote", "r", false, "Use remote IPSW")
 	pongoCmd.Flags().BoolP("decrypt", "d", false, "Extract and decrypt im4p files")
@@ -0,0 +1,136 @@
+//go:build libusb
+
+/*
+Copyright © 2018-2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/restore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringP("ipsw", "i", "", "IPSW to restore")
+	restoreCmd.Flags().StringP("udid", "u", "", "Device UniqueDeviceID (if not already in Recovery/DFU mode)")
+	restoreCmd.Flags().Bool("update", false, "Update the device (preserve user data) instead of erasing it")
+	restoreCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
+	restoreCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
+	restoreCmd.Flags().BoolP("force", "f", false, "Do not ask for confirmation before erasing the device")
+	restoreCmd.MarkFlagRequired("ipsw")
+	restoreCmd.MarkFlagFilename("ipsw", "ipsw")
+	viper.BindPFlag("restore.ipsw", restoreCmd.Flags().Lookup("ipsw"))
+	viper.BindPFlag("restore.udid", restoreCmd.Flags().Lookup("udid"))
+	viper.BindPFlag("restore.update", restoreCmd.Flags().Lookup("update"))
+	viper.BindPFlag("restore.proxy", restoreCmd.Flags().Lookup("proxy"))
+	viper.BindPFlag("restore.insecure", restoreCmd.Flags().Lookup("insecure"))
+	viper.BindPFlag("restore.force", restoreCmd.Flags().Lookup("force"))
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore an IPSW onto a device",
+	Long: heredoc.Doc(`
+		Restore (or update) a device with an IPSW.
+
+		Puts the device into recovery mode (if needed), personalizes the firmware
+		with TSS, boots the restore ramdisk via iBSS/iBEC and drives restored to
+		install the root filesystem.
+
+		NOTE: baseband and coprocessor firmware updaters (Savage, Yonkers, Rose and Veridian)
+		are NOT supported, so devices that require them (or have a baseband, i.e. cellular
+		iPhones/iPads) are refused before anything is sent to them.`),
+	Example: heredoc.Doc(`
+		# Erase and restore a device in DFU/Recovery mode
+		❯ ipsw restore --ipsw iPad13,1_17.0_21A329_Restore.ipsw
+
+		# Erase and restore without asking for confirmation
+		❯ ipsw restore --ipsw iPad13,1_17.0_21A329_Restore.ipsw --force
+
+		# Update a connected device keeping user data
+		❯ ipsw restore --ipsw iPad13,1_17.0_21A329_Restore.ipsw --update`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		ipswPath := filepath.Clean(viper.GetString("restore.ipsw"))
+		if _, err := os.Stat(ipswPath); os.IsNotExist(err) {
+			return fmt.Errorf("file %s does not exist", ipswPath)
+		}
+
+		r, err := restore.New(&restore.Config{
+			IPSW:     ipswPath,
+			UDID:     viper.GetString("restore.udid"),
+			Update:   viper.GetBool("restore.update"),
+			Proxy:    viper.GetString("restore.proxy"),
+			Insecure: viper.GetBool("restore.insecure"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize restore: %w", err)
+		}
+		defer r.Close()
+
+		if err := r.Check(); err != nil {
+			return fmt.Errorf("failed to restore device: %w", err)
+		}
+
+		if !viper.GetBool("restore.update") && !viper.GetBool("restore.force") {
+			yes := false
+			prompt := &survey.Confirm{
+				Message: "This will ERASE all data on the device. Continue?",
+			}
+			if err := survey.AskOne(prompt, &yes); err != nil {
+				return err
+			}
+			if !yes {
+				log.Warn("Restore cancelled")
+				return nil
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if err := r.Run(ctx); err != nil {
+			return fmt.Errorf("failed to restore device: %w", err)
+		}
+
+		log.Info("Restore complete")
+		return nil
+	},
+}
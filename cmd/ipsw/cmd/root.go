@@ -41,6 +41,10 @@ import (
 	"github.com/blacktop/ipsw/cmd/ipsw/cmd/ota"
 	"github.com/blacktop/ipsw/cmd/ipsw/cmd/sb"
 	"github.com/blacktop/ipsw/cmd/ipsw/cmd/ssh"
+	"github.com/blacktop/ipsw/cmd/ipsw/cmd/tss"
+	dl "github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/keys"
+	"github.com/blacktop/ipsw/pkg/aea"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -64,6 +68,7 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	addPluginCommands()
 	if err := rootCmd.Execute(); err != nil {
 		log.Error(err.Error())
 		os.Exit(1)
@@ -86,6 +91,7 @@ func init() {
 	rootCmd.PersistentFlags().Bool("no-color", false, "disable colorize output")
 	rootCmd.PersistentFlags().String("diff-tool", "", "git diff tool (for --diff commands)")
 	rootCmd.PersistentFlags().MarkHidden("diff-tool")
+	rootCmd.PersistentFlags().StringSlice("ca-cert", []string{}, "PEM CA bundle(s) to trust for all network requests (also IPSW_CA_CERT)")
 	rootCmd.PersistentFlags().Bool("config-quiet", false, "silence config file loading message")
 	rootCmd.PersistentFlags().MarkHidden("config-quiet")
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -93,6 +99,7 @@ func init() {
 	viper.BindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color"))
 	viper.BindPFlag("diff-tool", rootCmd.PersistentFlags().Lookup("diff-tool"))
 	viper.BindPFlag("config-quiet", rootCmd.PersistentFlags().Lookup("config-quiet"))
+	viper.BindPFlag("ca-cert", rootCmd.PersistentFlags().Lookup("ca-cert"))
 	viper.BindEnv("color", "CLICOLOR")
 	viper.BindEnv("no-color", "NO_COLOR")
 	// Add subcommand groups
@@ -108,6 +115,7 @@ func init() {
 	rootCmd.AddCommand(ota.OtaCmd)
 	rootCmd.AddCommand(sb.SbCmd)
 	rootCmd.AddCommand(ssh.SSHCmd)
+	rootCmd.AddCommand(tss.TssCmd)
 	// Settings
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 }
@@ -138,4 +146,9 @@ func initConfig() {
 			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 		}
 	}
+
+	// apply the default proxy (`proxy` in the config file or IPSW_PROXY) and CA bundles to all network clients
+	cobra.CheckErr(dl.SetNetworkConfig(viper.GetString("proxy"), viper.GetStringSlice("ca-cert")))
+	// cache fetched fcs-keys and derived AEA keys in the key database for offline reuse
+	aea.SetKeyStore(keys.AEAKeyStore(""))
 }
@@ -28,10 +28,9 @@ import (
 
 // SbCmd represents the sb command
 var SbCmd = &cobra.Command{
-	Use:    "sb",
-	Short:  "Sandbox commands",
-	Args:   cobra.NoArgs,
-	Hidden: true,
+	Use:   "sb",
+	Short: "Sandbox commands",
+	Args:  cobra.NoArgs,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		viper.BindPFlag("color", cmd.Flags().Lookup("color"))
 		viper.BindPFlag("no-color", cmd.Flags().Lookup("no-color"))
@@ -0,0 +1,181 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package sb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+	"github.com/blacktop/ipsw/pkg/sandbox"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	SbCmd.AddCommand(sbDecCmd)
+
+	sbDecCmd.Flags().StringP("kernel", "k", "", "Kernelcache with the operations of the profile's OS version")
+	sbDecCmd.Flags().String("ops", "", "File with the operations of the profile's OS version (from 'ipsw sb ops')")
+	sbDecCmd.Flags().StringP("profile", "p", "", "Decompile only the profile with this name")
+	sbDecCmd.Flags().BoolP("list", "l", false, "List the profiles")
+	sbDecCmd.Flags().StringP("output", "o", "", "Folder to write the decompiled profiles to")
+	sbDecCmd.MarkFlagFilename("kernel")
+	sbDecCmd.MarkFlagFilename("ops")
+	sbDecCmd.MarkFlagDirname("output")
+	viper.BindPFlag("sb.dec.kernel", sbDecCmd.Flags().Lookup("kernel"))
+	viper.BindPFlag("sb.dec.ops", sbDecCmd.Flags().Lookup("ops"))
+	viper.BindPFlag("sb.dec.profile", sbDecCmd.Flags().Lookup("profile"))
+	viper.BindPFlag("sb.dec.list", sbDecCmd.Flags().Lookup("list"))
+	viper.BindPFlag("sb.dec.output", sbDecCmd.Flags().Lookup("output"))
+}
+
+// sbDecCmd represents the dec command
+var sbDecCmd = &cobra.Command{
+	Use:     "dec <KERNELCACHE|SB>",
+	Aliases: []string{"decompile"},
+	Short:   "Decompile compiled sandbox profiles to SBPL",
+	Long: heredoc.Doc(`
+		Decompile the compiled sandbox profiles embedded in a kernelcache (the profile collection and the
+		platform profile) or a standalone compiled profile to SBPL-like rules.
+
+		The operations are numbered differently in each OS version so a standalone profile needs the
+		operations of its OS version (from its kernelcache or 'ipsw sb ops').`),
+	Example: heredoc.Doc(`
+		# Decompile the kernelcache's profiles to a folder (to diff them between versions)
+		❯ ipsw sb dec kernelcache.release.iPhone16,1 -o sb/22A3354
+		# Decompile one of the kernelcache's profiles
+		❯ ipsw sb dec kernelcache.release.iPhone16,1 --profile com.apple.WebKit.WebContent
+		# Decompile a standalone compiled profile
+		❯ ipsw sb ops kernelcache.release.iPhone16,1 > ops.txt
+		❯ ipsw sb dec profile.sb.bin --ops ops.txt`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		kernelPath := viper.GetString("sb.dec.kernel")
+		opsPath := viper.GetString("sb.dec.ops")
+		profileName := viper.GetString("sb.dec.profile")
+		output := viper.GetString("sb.dec.output")
+
+		var sbs []*sandbox.Sandbox
+
+		if ok, _ := magic.IsMachO(args[0]); ok { /* kernelcache */
+			m, err := macho.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open kernelcache: %v", err)
+			}
+			defer m.Close()
+			ops, err := kernelcache.GetSandboxOperations(m)
+			if err != nil {
+				return err
+			}
+			sbs, err = kernelcache.GetSandboxProfiles(m, ops)
+			if err != nil {
+				return err
+			}
+		} else { /* standalone compiled profile */
+			var ops []string
+			switch {
+			case len(opsPath) > 0:
+				dat, err := os.ReadFile(opsPath)
+				if err != nil {
+					return fmt.Errorf("failed to read operations file: %v", err)
+				}
+				ops = strings.Fields(string(dat))
+			case len(kernelPath) > 0:
+				m, err := macho.Open(kernelPath)
+				if err != nil {
+					return fmt.Errorf("failed to open kernelcache: %v", err)
+				}
+				defer m.Close()
+				ops, err = kernelcache.GetSandboxOperations(m)
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("must supply --kernel or --ops with the operations of the profile's OS version")
+			}
+			dat, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read compiled profile: %v", err)
+			}
+			sb, err := sandbox.Parse(dat, ops)
+			if err != nil {
+				return fmt.Errorf("failed to parse compiled profile: %v", err)
+			}
+			if !sb.Collection {
+				sb.Profiles[0].Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+			sbs = append(sbs, sb)
+		}
+
+		for _, sb := range sbs {
+			for idx := range sb.Profiles {
+				p := &sb.Profiles[idx]
+				if len(p.Name) == 0 {
+					p.Name = "platform" // the kernel's standalone profile
+				}
+				if len(profileName) > 0 && p.Name != profileName {
+					continue
+				}
+				if viper.GetBool("sb.dec.list") {
+					fmt.Println(p.Name)
+					continue
+				}
+				sbpl := sb.Decompile(p)
+				if len(output) > 0 {
+					if err := os.MkdirAll(output, 0o750); err != nil {
+						return fmt.Errorf("failed to create output folder %s: %v", output, err)
+					}
+					fname := filepath.Join(output, p.Name+".sb")
+					log.Infof("Creating %s", fname)
+					if err := os.WriteFile(fname, []byte(sbpl), 0o644); err != nil {
+						return fmt.Errorf("failed to write %s: %v", fname, err)
+					}
+					continue
+				}
+				if viper.GetBool("color") && !viper.GetBool("no-color") {
+					quick.Highlight(os.Stdout, sbpl+"\n", "scheme", "terminal256", "nord")
+				} else {
+					fmt.Println(sbpl)
+				}
+			}
+		}
+
+		return nil
+	},
+}
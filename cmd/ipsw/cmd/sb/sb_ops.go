@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package sb
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	SbCmd.AddCommand(sbOpsCmd)
+	sbOpsCmd.MarkZshCompPositionalArgumentFile(1, "kernelcache*")
+}
+
+// sbOpsCmd represents the ops command
+var sbOpsCmd = &cobra.Command{
+	Use:           "ops <KERNELCACHE>",
+	Short:         "List the sandbox operations of a kernelcache",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		m, err := macho.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open kernelcache: %v", err)
+		}
+		defer m.Close()
+
+		ops, err := kernelcache.GetSandboxOperations(m)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			fmt.Println(op)
+		}
+
+		return nil
+	},
+}
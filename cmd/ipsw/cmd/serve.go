@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/mirror"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringP("mirror", "m", "", "Folder of downloaded IPSWs/OTAs to serve")
+	serveCmd.Flags().StringP("host", "a", "0.0.0.0", "Host/IP to listen on")
+	serveCmd.Flags().IntP("port", "p", 3994, "Port to listen on")
+	serveCmd.Flags().Duration("reindex", 5*time.Minute, "Interval to re-index the mirror folder (0 to disable)")
+	serveCmd.MarkFlagRequired("mirror")
+	serveCmd.MarkFlagDirname("mirror")
+	viper.BindPFlag("serve.mirror", serveCmd.Flags().Lookup("mirror"))
+	viper.BindPFlag("serve.host", serveCmd.Flags().Lookup("host"))
+	viper.BindPFlag("serve.port", serveCmd.Flags().Lookup("port"))
+	viper.BindPFlag("serve.reindex", serveCmd.Flags().Lookup("reindex"))
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local IPSW/OTA mirror over HTTP",
+	Long: heredoc.Doc(`
+		Index a folder of downloaded IPSWs/OTAs and serve them over HTTP.
+
+		Files are served by their path in the folder OR by the path of the Apple CDN URL
+		they were downloaded from, so lab machines can fetch from the LAN cache by passing
+		the mirror to any download command with --mirror (falls back to Apple if missing).
+		The index of the mirrored files is served at /index.json`),
+	Example: heredoc.Doc(`
+		# Serve the IPSWs/OTAs in ~/Downloads/ipsws
+		❯ ipsw serve --mirror ~/Downloads/ipsws
+		# Download an IPSW from the LAN mirror (if it has it)
+		❯ ipsw download ipsw --device iPhone15,2 --latest --mirror http://cache.lan:3994
+		# List the mirrored files
+		❯ curl -s http://cache.lan:3994/index.json | jq .`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		reindex := viper.GetDuration("serve.reindex")
+		if reindex < 0 {
+			return fmt.Errorf("--reindex must be positive")
+		}
+
+		log.WithField("folder", viper.GetString("serve.mirror")).Info("Indexing mirror")
+		m, err := mirror.New(viper.GetString("serve.mirror"))
+		if err != nil {
+			return err
+		}
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Indexed %d files", len(m.Entries())))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if reindex > 0 {
+			go func() {
+				ticker := time.NewTicker(reindex)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if err := m.Index(); err != nil {
+							log.WithError(err).Error("Failed to re-index mirror")
+							continue
+						}
+						log.Debugf("Re-indexed %d files", len(m.Entries()))
+					}
+				}
+			}()
+		}
+
+		srv := &http.Server{
+			Addr:              net.JoinHostPort(viper.GetString("serve.host"), strconv.Itoa(viper.GetInt("serve.port"))),
+			Handler:           m,
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		log.WithField("addr", "http://"+srv.Addr).Info("Serving mirror")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("failed to serve mirror: %v", err)
+		}
+
+		return nil
+	},
+}
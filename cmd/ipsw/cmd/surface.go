@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/surface"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(surfaceCmd)
+
+	surfaceCmd.Flags().String("db", "", "Folder to r/w entitlement databases")
+	surfaceCmd.Flags().BoolP("agents", "a", false, "Also report the launch agents")
+	surfaceCmd.Flags().IntP("top", "n", 0, "Only report the N highest ranked daemons")
+	surfaceCmd.Flags().Bool("html", false, "Output as HTML (instead of JSON)")
+	surfaceCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	surfaceCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	surfaceCmd.MarkFlagDirname("db")
+	viper.BindPFlag("surface.db", surfaceCmd.Flags().Lookup("db"))
+	viper.BindPFlag("surface.agents", surfaceCmd.Flags().Lookup("agents"))
+	viper.BindPFlag("surface.top", surfaceCmd.Flags().Lookup("top"))
+	viper.BindPFlag("surface.html", surfaceCmd.Flags().Lookup("html"))
+	viper.BindPFlag("surface.output", surfaceCmd.Flags().Lookup("output"))
+	viper.BindPFlag("surface.pem-db", surfaceCmd.Flags().Lookup("pem-db"))
+}
+
+// surfaceCmd represents the surface command
+var surfaceCmd = &cobra.Command{
+	Use:   "surface <IPSW>",
+	Short: "Rank the attack surface of an IPSW's launchd daemons",
+	Long: heredoc.Doc(`
+		Rank the LaunchDaemons of an IPSW by attack surface: combines each daemon's launchd job
+		(user, mach services, sockets) with its entitlements (privileges and sandbox profile) and
+		flags the daemons parsing external input (network sockets/entitlements or attaching devices).
+
+		NOTE: only the declared sandbox profiles are reported (not the daemons calling sandbox_init themselves).`),
+	Example: heredoc.Doc(`
+		# Rank the daemons as JSON (caching the entitlement database in /tmp)
+		❯ ipsw surface iPhone16,1_18.0_22A3354_Restore.ipsw --db /tmp
+		# Create an HTML report of the top 50 daemons and agents
+		❯ ipsw surface iPhone16,1_18.0_22A3354_Restore.ipsw --agents --top 50 --html -o surface.html`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		ipswPath := filepath.Clean(args[0])
+		var entDBPath string
+		if dbFolder := viper.GetString("surface.db"); len(dbFolder) > 0 {
+			entDBPath = filepath.Join(dbFolder, strings.TrimSuffix(filepath.Base(ipswPath), filepath.Ext(ipswPath))+".entDB")
+		}
+
+		report, err := surface.Analyze(&surface.Config{
+			IPSW:     ipswPath,
+			Database: entDBPath,
+			PemDB:    viper.GetString("surface.pem-db"),
+			Agents:   viper.GetBool("surface.agents"),
+		})
+		if err != nil {
+			return err
+		}
+		if top := viper.GetInt("surface.top"); top > 0 && top < len(report.Daemons) {
+			report.Daemons = report.Daemons[:top]
+		}
+
+		write := func(w io.Writer) error {
+			if viper.GetBool("surface.html") {
+				return report.WriteHTML(w)
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+
+		if output := viper.GetString("surface.output"); len(output) > 0 {
+			f, err := os.Create(filepath.Clean(output))
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			if err := write(f); err != nil {
+				return err
+			}
+			log.Infof("Created %s", output)
+			return nil
+		}
+
+		return write(os.Stdout)
+	},
+}
@@ -80,6 +80,8 @@ var symbolicateCmd = &cobra.Command{
 	❯ ipsw symbolicate panic-full-2024-03-21-004704.000.ips iPad_Pro_HFR_17.4_21E219_Restore.ipsw
 	# Pretty print a crashlog (BugType=309) these are usually symbolicated by the OS
 		  ❯ ipsw symbolicate --color Delta-2024-04-20-135807.ips
+		  # Symbolicate the frames of a crashlog (BugType=309) the OS didn't symbolicate with an IPSW (and your app's MachOs)
+		  ❯ ipsw symbolicate --extra MyApp.app Delta-2024-04-20-135807.ips iPhone15,2_17.4.1_21E236_Restore.ipsw
 		  # Symbolicate a (old stype) crashlog (BugType=109) requiring a dyld_shared_cache to symbolicate
 		  ❯ ipsw symbolicate Delta-2024-04-20-135807.ips
 		  ⨯ please supply a dyld_shared_cache for iPhone13,3 running 14.5 (18E5154f)`),
@@ -136,7 +138,7 @@ var symbolicateCmd = &cobra.Command{
 				return fmt.Errorf("failed to parse IPS file: %v", err)
 			}
 
-			if len(args) < 2 && hdr.BugType == "210" {
+			if len(args) < 2 {
 				if viper.IsSet("symbolicate.server") {
 					u, err := url.ParseRequestURI(viper.GetString("symbolicate.server"))
 					if err != nil {
@@ -145,32 +147,43 @@ var symbolicateCmd = &cobra.Command{
 					if u.Scheme == "" || u.Host == "" {
 						return fmt.Errorf("invalid symbol server URL: %s (needs a valid schema AND host)", u.String())
 					}
-					log.WithField("server", u.String()).Info("Symbolicating 210 Panic with Symbol Server")
-					if err := ips.Symbolicate210WithDatabase(u.String()); err != nil {
-						return err
+					if hdr.BugType == "210" {
+						log.WithField("server", u.String()).Info("Symbolicating 210 Panic with Symbol Server")
+						if err := ips.Symbolicate210WithDatabase(u.String()); err != nil {
+							return err
+						}
+					} else {
+						log.WithField("server", u.String()).Info("Symbolicating 309 Crash with Symbol Server")
+						if err := ips.Symbolicate309WithDatabase(u.String()); err != nil {
+							return err
+						}
 					}
-				} else {
+				} else if hdr.BugType == "210" {
 					log.Warnf("please supply %s %s IPSW for symbolication", ips.Payload.Product, ips.Header.OsVersion)
 				}
 			} else {
 				// TODO: use IPSW to populate symbol server if both are supplied
+				/* validate IPSW */
+				i, err := info.Parse(args[1])
+				if err != nil {
+					return err
+				}
+				if i.Plists.BuildManifest.ProductVersion != ips.Header.Version() ||
+					i.Plists.BuildManifest.ProductBuildVersion != ips.Header.Build() ||
+					!slices.Contains(i.Plists.Restore.SupportedProductTypes, ips.Payload.Product) {
+					return fmt.Errorf("supplied IPSW %s does NOT match crashlog: NEED %s; %s (%s), GOT %s; %s (%s)",
+						filepath.Base(args[1]),
+						ips.Payload.Product, ips.Header.Version(), ips.Header.Build(),
+						strings.Join(i.Plists.Restore.SupportedProductTypes, ", "),
+						i.Plists.BuildManifest.ProductVersion, i.Plists.BuildManifest.ProductBuildVersion,
+					)
+				}
 				if hdr.BugType == "210" {
-					/* validate IPSW */
-					i, err := info.Parse(args[1])
-					if err != nil {
+					if err := ips.Symbolicate210(filepath.Clean(args[1])); err != nil {
 						return err
 					}
-					if i.Plists.BuildManifest.ProductVersion != ips.Header.Version() ||
-						i.Plists.BuildManifest.ProductBuildVersion != ips.Header.Build() ||
-						!slices.Contains(i.Plists.Restore.SupportedProductTypes, ips.Payload.Product) {
-						return fmt.Errorf("supplied IPSW %s does NOT match crashlog: NEED %s; %s (%s), GOT %s; %s (%s)",
-							filepath.Base(args[1]),
-							ips.Payload.Product, ips.Header.Version(), ips.Header.Build(),
-							strings.Join(i.Plists.Restore.SupportedProductTypes, ", "),
-							i.Plists.BuildManifest.ProductVersion, i.Plists.BuildManifest.ProductBuildVersion,
-						)
-					}
-					if err := ips.Symbolicate210(filepath.Clean(args[1])); err != nil {
+				} else {
+					if err := ips.Symbolicate309(filepath.Clean(args[1])); err != nil {
 						return err
 					}
 				}
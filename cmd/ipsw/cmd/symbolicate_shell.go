@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/symshell"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	symbolicateCmd.AddCommand(symbolicateShellCmd)
+
+	symbolicateShellCmd.Flags().StringP("server", "s", "", "Symbol Server DB URL")
+	symbolicateShellCmd.Flags().Uint64("slide", 0, "Slide of the addresses in and out")
+	symbolicateShellCmd.Flags().BoolP("demangle", "d", false, "Demangle symbol names")
+	symbolicateShellCmd.MarkZshCompPositionalArgumentFile(1, "dyld_shared_cache*")
+	viper.BindPFlag("symbolicate.shell.server", symbolicateShellCmd.Flags().Lookup("server"))
+	viper.BindPFlag("symbolicate.shell.slide", symbolicateShellCmd.Flags().Lookup("slide"))
+	viper.BindPFlag("symbolicate.shell.demangle", symbolicateShellCmd.Flags().Lookup("demangle"))
+}
+
+// symbolicateShellCmd represents the symbolicate shell command
+var symbolicateShellCmd = &cobra.Command{
+	Use:   "shell [DSC]",
+	Short: "Interactive symbol lookup shell for a dyld_shared_cache and/or symbol server",
+	Long: heredoc.Doc(`
+		Interactive shell to lookup the symbols of a dyld_shared_cache (and/or the symbol server)
+		while debugging: symbolicate addresses, lookup symbols, evaluate address math (with symbols,
+		variables and the slide) and select a dylib to TAB complete its symbols; with history.
+
+		The commands are read line by line when stdin isn't a terminal (to script lookups).`),
+	Example: heredoc.Doc(`
+		# Lookup the symbols of a dyld_shared_cache slid by 0x1c000
+		❯ ipsw symbolicate shell /path/to/dyld_shared_cache_arm64e --slide 0x1c000
+		❯ image libsystem_malloc.dylib
+		(libsystem_malloc.dylib) ❯ a2s _malloc + 0x24
+		# Lookup addresses of the MachOs in the symbol server
+		❯ ipsw symbolicate shell --server http://localhost:3993
+		❯ db 3C6E9B3E-FA37-3E4E-8B3E-1F7B5B1B5E8A 0x1a2b3c
+		# Script the lookups
+		❯ echo 'a2s 0x18f5a1b24' | ipsw symbolicate shell /path/to/dyld_shared_cache_arm64e`),
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if Verbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		serverURL := viper.GetString("symbolicate.shell.server")
+		if len(serverURL) > 0 {
+			u, err := url.ParseRequestURI(serverURL)
+			if err != nil {
+				return fmt.Errorf("failed to parse symbol server URL: %v", err)
+			}
+			if u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("invalid symbol server URL: %s (needs a valid schema AND host)", u.String())
+			}
+		} else if len(args) == 0 {
+			return fmt.Errorf("please supply a dyld_shared_cache and/or a symbol server (--server)")
+		}
+
+		var f *dyld.File
+		if len(args) > 0 {
+			dscPath, err := filepath.EvalSymlinks(filepath.Clean(args[0]))
+			if err != nil {
+				return fmt.Errorf("file %s does not exist", args[0])
+			}
+			f, err = dyld.Open(dscPath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+		}
+
+		sh, err := symshell.New(f, &symshell.Config{
+			Server:   serverURL,
+			Slide:    viper.GetUint64("symbolicate.shell.slide"),
+			Demangle: viper.GetBool("symbolicate.shell.demangle"),
+		})
+		if err != nil {
+			return err
+		}
+
+		return sh.Run()
+	},
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tss
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/pkg/tss"
+	"github.com/fatih/color"
+	semver "github.com/hashicorp/go-version"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	TssCmd.AddCommand(tssStatusCmd)
+
+	tssStatusCmd.Flags().StringArrayP("device", "d", []string{}, "Device(s) to check (i.e. iPhone15,2, can be repeated)")
+	tssStatusCmd.Flags().BoolP("all", "a", false, "Check all devices")
+	tssStatusCmd.Flags().IntP("max", "m", 10, "Newest builds per device to check (0 for all)")
+	tssStatusCmd.Flags().IntP("workers", "w", 4, "Parallel TSS requests")
+	tssStatusCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	tssStatusCmd.Flags().String("cache", "", "Signing status cache file (default: tss_status.json in the config folder)")
+	tssStatusCmd.Flags().Duration("ttl", time.Hour, "How long cached signing statuses are used for")
+	tssStatusCmd.Flags().Bool("no-cache", false, "Do not use cached signing statuses")
+	tssStatusCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
+	tssStatusCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
+	tssStatusCmd.MarkFlagsMutuallyExclusive("device", "all")
+	viper.BindPFlag("tss.status.device", tssStatusCmd.Flags().Lookup("device"))
+	viper.BindPFlag("tss.status.all", tssStatusCmd.Flags().Lookup("all"))
+	viper.BindPFlag("tss.status.max", tssStatusCmd.Flags().Lookup("max"))
+	viper.BindPFlag("tss.status.workers", tssStatusCmd.Flags().Lookup("workers"))
+	viper.BindPFlag("tss.status.json", tssStatusCmd.Flags().Lookup("json"))
+	viper.BindPFlag("tss.status.cache", tssStatusCmd.Flags().Lookup("cache"))
+	viper.BindPFlag("tss.status.ttl", tssStatusCmd.Flags().Lookup("ttl"))
+	viper.BindPFlag("tss.status.no-cache", tssStatusCmd.Flags().Lookup("no-cache"))
+	viper.BindPFlag("tss.status.proxy", tssStatusCmd.Flags().Lookup("proxy"))
+	viper.BindPFlag("tss.status.insecure", tssStatusCmd.Flags().Lookup("insecure"))
+}
+
+// tssStatusCmd represents the tss status command
+var tssStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which builds are currently being signed",
+	Long: heredoc.Doc(`
+		Ask Apple's TSS server which of a device's newest builds are still being signed
+		and show the results as a matrix of devices and builds.
+
+		Results are cached (see --cache and --ttl) so repeated checks are fast.`),
+	Example: heredoc.Doc(`
+		# Show the signing status of the newest iPhone15,2 builds
+		❯ ipsw tss status --device iPhone15,2
+		# Show the signing status matrix of a few devices as JSON
+		❯ ipsw tss status -d iPhone15,2 -d iPhone16,1 --max 5 --json
+		# Check the newest 3 builds of ALL devices
+		❯ ipsw tss status --all --max 3 --workers 8`),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		devices := viper.GetStringSlice("tss.status.device")
+		if viper.GetBool("tss.status.all") {
+			devs, err := download.GetAllDevices()
+			if err != nil {
+				return fmt.Errorf("failed to get devices: %v", err)
+			}
+			for _, dev := range devs {
+				devices = append(devices, dev.Identifier)
+			}
+		}
+		if len(devices) == 0 {
+			return fmt.Errorf("must supply at least one --device (or --all)")
+		}
+
+		cache := viper.GetString("tss.status.cache")
+		if viper.GetBool("tss.status.no-cache") {
+			cache = ""
+		} else if len(cache) == 0 {
+			if len(viper.ConfigFileUsed()) > 0 {
+				cache = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "tss_status.json")
+			} else if home, err := os.UserHomeDir(); err == nil {
+				cache = filepath.Join(home, ".config", "ipsw", "tss_status.json")
+			}
+		}
+
+		log.WithField("devices", len(devices)).Info("Checking signing status")
+		statuses, err := tss.GetSigningStatus(&tss.StatusConfig{
+			Devices:   devices,
+			MaxBuilds: viper.GetInt("tss.status.max"),
+			Workers:   viper.GetInt("tss.status.workers"),
+			CacheFile: cache,
+			CacheTTL:  viper.GetDuration("tss.status.ttl"),
+			Proxy:     viper.GetString("tss.status.proxy"),
+			Insecure:  viper.GetBool("tss.status.insecure"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("tss.status.json") {
+			dat, err := json.MarshalIndent(statuses, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal signing status: %v", err)
+			}
+			if viper.GetBool("color") && !viper.GetBool("no-color") {
+				if err := quick.Highlight(os.Stdout, string(dat)+"\n", "json", "terminal256", "nord"); err != nil {
+					return fmt.Errorf("failed to highlight json: %v", err)
+				}
+			} else {
+				fmt.Println(string(dat))
+			}
+			return nil
+		}
+
+		printStatusMatrix(statuses)
+		return nil
+	},
+}
+
+func statusCell(st tss.Status) string {
+	switch {
+	case len(st.Error) > 0:
+		return "?"
+	case st.Signed:
+		return "✅"
+	default:
+		return "❌"
+	}
+}
+
+// printStatusMatrix prints the signing statuses as a list (for a single device) or a device/build matrix
+func printStatusMatrix(statuses []tss.Status) {
+	var devices []string
+	var builds []string
+	cells := make(map[string]map[string]string)
+	versions := make(map[string]string)
+	for _, st := range statuses {
+		if _, ok := cells[st.Device]; !ok {
+			devices = append(devices, st.Device)
+			cells[st.Device] = make(map[string]string)
+		}
+		if _, ok := versions[st.Build]; !ok {
+			builds = append(builds, st.Build)
+			versions[st.Build] = st.Version
+		}
+		cells[st.Device][st.Build] = statusCell(st)
+	}
+	// newest builds first
+	sort.SliceStable(builds, func(i, j int) bool {
+		vi, erri := semver.NewVersion(versions[builds[i]])
+		vj, errj := semver.NewVersion(versions[builds[j]])
+		if erri != nil || errj != nil || vi.Equal(vj) {
+			return builds[i] > builds[j]
+		}
+		return vi.GreaterThan(vj)
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	if len(devices) == 1 {
+		table.SetHeader([]string{"Version", "Build", "Signed"})
+		for _, build := range builds {
+			table.Append([]string{versions[build], build, cells[devices[0]][build]})
+		}
+	} else {
+		header := []string{"Device"}
+		for _, build := range builds {
+			header = append(header, fmt.Sprintf("%s (%s)", versions[build], build))
+		}
+		table.SetHeader(header)
+		for _, device := range devices {
+			row := []string{device}
+			for _, build := range builds {
+				row = append(row, cells[device][build])
+			}
+			table.Append(row)
+		}
+	}
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+
+	fmt.Printf("✅ signed  ❌ not signed  ? unknown (%d checks)\n", len(statuses))
+}
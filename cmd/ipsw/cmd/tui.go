@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().StringP("output", "o", ".", "Folder to extract files to")
+	tuiCmd.Flags().String("pem-db", "", "AEA pem DB JSON file")
+	tuiCmd.MarkFlagDirname("output")
+	viper.BindPFlag("tui.output", tuiCmd.Flags().Lookup("output"))
+	viper.BindPFlag("tui.pem-db", tuiCmd.Flags().Lookup("pem-db"))
+}
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui <IPSW>",
+	Short: "Browse an IPSW in a terminal UI",
+	Long: heredoc.Doc(`
+		Browse an IPSW in a terminal UI: its archive, the filesystem DMG, the kernelcaches' KEXTs
+		and the dyld_shared_cache images; preview their info and extract them.
+
+		Keys: ↑/k ↓/j to move, enter/l to open, esc/h to go back, e to extract, / to filter and q to quit.
+
+		NOTE: the DMGs are extracted (and decrypted) into a temporary folder the first time they are opened.`),
+	Example: heredoc.Doc(`
+		# Browse an IPSW and extract into the ./extracted folder
+		❯ ipsw tui iPhone16,1_18.0_22A3354_Restore.ipsw -o extracted`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		explorer, err := tui.NewExplorer(&tui.Config{
+			IPSW:   filepath.Clean(args[0]),
+			Output: filepath.Clean(viper.GetString("tui.output")),
+			PemDB:  viper.GetString("tui.pem-db"),
+		})
+		if err != nil {
+			return err
+		}
+		defer explorer.Close()
+
+		log.SetLevel(log.FatalLevel) // the logs would draw over the UI (errors are shown in its status line)
+
+		return tui.Run(explorer)
+	},
+}
@@ -42,7 +42,7 @@ import (
 func init() {
 	rootCmd.AddCommand(updateCmd)
 
-	updateCmd.Flags().String("proxy", "", "HTTP/HTTPS proxy")
+	updateCmd.Flags().String("proxy", "", "HTTP/HTTPS/SOCKS5 proxy")
 	updateCmd.Flags().Bool("insecure", false, "do not verify ssl certs")
 	updateCmd.Flags().Bool("detect", false, "detect my platform")
 	updateCmd.Flags().Bool("replace", false, "overwrite current ipsw")
@@ -0,0 +1,75 @@
+/*
+Copyright © 2025 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+
+	tokenCmd.Flags().StringP("role", "r", "read-only", "Role to grant (read-only, operator or admin)")
+	tokenCmd.Flags().Duration("ttl", 24*time.Hour, "Time until the token expires")
+	viper.BindPFlag("token.role", tokenCmd.Flags().Lookup("role"))
+	viper.BindPFlag("token.ttl", tokenCmd.Flags().Lookup("ttl"))
+}
+
+// tokenCmd represents the token command
+var tokenCmd = &cobra.Command{
+	Use:   "token <SUBJECT>",
+	Short: "Create an ipswd API JWT",
+	Long: heredoc.Doc(`
+		Sign a JWT for the subject (i.e. a user or CI job name) with the daemon's
+		'daemon.auth.jwt-secret' that grants the role until it expires.`),
+	Example: heredoc.Doc(`
+		# Create a token for a CI job that can run jobs for a week
+		❯ ipswd token ci-nightly --role operator --ttl 168h
+		# Use it
+		❯ curl -H "Authorization: Bearer $TOKEN" http://localhost:3993/v1/auth/whoami`),
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		role, err := auth.ParseRole(viper.GetString("token.role"))
+		if err != nil {
+			return err
+		}
+		token, err := auth.NewToken(conf.Daemon.Auth.JWTSecret, args[0], role, viper.GetDuration("token.ttl"))
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		fmt.Println(token)
+		return nil
+	},
+}
@@ -0,0 +1,155 @@
+# Binary Ninja plugin importing the symbols, function starts, ObjC metadata and fixups exported by:
+#
+#   ipsw macho info --binja <MACHO>
+#   ipsw dyld macho --binja <DSC> <DYLIB>
+#
+# Install it by copying this file to Binary Ninja's plugin folder (Plugins > Open Plugin Folder...) and run it
+# with Plugins > ipsw > Import export... (the export's schema is documented in ipsw.schema.json)
+
+import json
+
+from binaryninja import (
+    BackgroundTaskThread,
+    PluginCommand,
+    StructureBuilder,
+    Symbol,
+    SymbolType,
+    Type,
+    interaction,
+    log_info,
+    log_warn,
+)
+
+SCHEMA_VERSION = 1
+
+
+def get_delta(bv, export):
+    """Returns the slide between the export's and the view's addresses (if the view was rebased)"""
+    if bv.get_segment_at(export["base"]) is not None:
+        return 0
+    return bv.start - export["base"]
+
+
+def define_symbols(bv, export, delta):
+    for sym in export["symbols"]:
+        addr = sym["addr"] + delta
+        if sym["type"] == "function":
+            bv.define_user_symbol(Symbol(SymbolType.FunctionSymbol, addr, sym["name"]))
+        else:
+            bv.define_user_symbol(Symbol(SymbolType.DataSymbol, addr, sym["name"]))
+        if sym.get("demangled"):
+            bv.set_comment_at(addr, sym["demangled"])
+
+
+def define_functions(bv, export, delta):
+    for fn in export["functions"]:
+        if not bv.get_function_at(fn["start"] + delta):
+            bv.add_function(fn["start"] + delta)
+
+
+def define_methods(bv, class_name, kind, methods, delta):
+    for meth in methods or []:
+        if not meth.get("impl"):
+            continue
+        addr = meth["impl"] + delta
+        name = "%s[%s %s]" % (kind, class_name, meth["name"])
+        bv.add_function(addr)
+        bv.define_user_symbol(Symbol(SymbolType.FunctionSymbol, addr, name))
+        if meth.get("prototype"):
+            func = bv.get_function_at(addr)
+            try:
+                typ, _ = bv.parse_type_string(meth["prototype"])
+                if func:
+                    func.type = typ
+            except SyntaxError:
+                log_warn("ipsw: failed to parse %s prototype: %s" % (name, meth["prototype"]))
+
+
+def define_class_type(bv, cls):
+    if not cls.get("ivars") or not cls["instance_size"]:
+        return
+    sb = StructureBuilder.create()
+    sb.width = cls["instance_size"]
+    for ivar in cls["ivars"]:
+        if ivar["offset"] + ivar["size"] > cls["instance_size"]:
+            continue
+        if ivar["type"].startswith("@") and ivar["size"] == bv.arch.address_size:
+            typ = Type.pointer(bv.arch, Type.void())
+        elif ivar["size"] in (1, 2, 4, 8):
+            typ = Type.int(ivar["size"], False)
+        else:
+            typ = Type.array(Type.int(1, False), max(ivar["size"], 1))
+        sb.insert(ivar["offset"], typ, ivar["name"])
+    bv.define_user_type(cls["name"], Type.structure_type(sb))
+
+
+def define_objc(bv, objc, delta):
+    ptr = Type.pointer(bv.arch, Type.void())
+    for cls in objc.get("classes") or []:
+        bv.define_user_symbol(Symbol(SymbolType.DataSymbol, cls["addr"] + delta, "_OBJC_CLASS_$_" + cls["name"]))
+        define_methods(bv, cls["name"], "+", cls.get("class_methods"), delta)
+        define_methods(bv, cls["name"], "-", cls.get("instance_methods"), delta)
+        define_class_type(bv, cls)
+    for cat in objc.get("categories") or []:
+        name = "%s(%s)" % (cat["class"], cat["name"]) if cat.get("class") else cat["name"]
+        define_methods(bv, name, "+", cat.get("class_methods"), delta)
+        define_methods(bv, name, "-", cat.get("instance_methods"), delta)
+    for proto in objc.get("protocols") or []:
+        bv.define_user_symbol(Symbol(SymbolType.DataSymbol, proto["addr"] + delta, "_OBJC_PROTOCOL_$_" + proto["name"]))
+    for ref in objc.get("selrefs") or []:
+        bv.define_user_data_var(ref["addr"] + delta, ptr)
+        bv.define_user_symbol(Symbol(SymbolType.DataSymbol, ref["addr"] + delta, "selRef_" + ref["name"]))
+    for ref in objc.get("classrefs") or []:
+        bv.define_user_data_var(ref["addr"] + delta, ptr)
+        bv.define_user_symbol(Symbol(SymbolType.DataSymbol, ref["addr"] + delta, "classRef_" + ref["name"]))
+
+
+def define_fixups(bv, export, delta):
+    ptr = Type.pointer(bv.arch, Type.void())
+    for fixup in export["fixups"]:
+        addr = fixup["addr"] + delta
+        if bv.get_data_var_at(addr) is None:
+            bv.define_user_data_var(addr, ptr)
+        if fixup["kind"] == "bind":
+            target = fixup["symbol"]
+            if fixup.get("addend"):
+                target += " + %#x" % fixup["addend"]
+            bv.set_comment_at(addr, "%s!%s" % (fixup.get("library", "?"), target))
+
+
+def apply_export(bv, export):
+    """Applies an ipsw export (a dict of the JSON file) to a BinaryView"""
+    if export.get("version") != SCHEMA_VERSION:
+        raise ValueError("unsupported ipsw export version %s (expected %d)" % (export.get("version"), SCHEMA_VERSION))
+    delta = get_delta(bv, export)
+    define_functions(bv, export, delta)
+    define_symbols(bv, export, delta)
+    if export.get("objc"):
+        define_objc(bv, export["objc"], delta)
+    define_fixups(bv, export, delta)
+    bv.update_analysis()
+    log_info(
+        "ipsw: imported %d symbols, %d functions and %d fixups for %s"
+        % (len(export["symbols"]), len(export["functions"]), len(export["fixups"]), export["binary"])
+    )
+
+
+class ImportTask(BackgroundTaskThread):
+    def __init__(self, bv, path):
+        BackgroundTaskThread.__init__(self, "Importing ipsw export...", False)
+        self.bv = bv
+        self.path = path
+
+    def run(self):
+        with open(self.path) as f:
+            export = json.load(f)
+        apply_export(self.bv, export)
+
+
+def import_export(bv):
+    path = interaction.get_open_filename_input("ipsw export", "*.binja.json")
+    if path:
+        ImportTask(bv, path).start()
+
+
+PluginCommand.register("ipsw\\Import export...", "Import the symbols, functions, ObjC metadata and fixups exported by ipsw", import_export)
@@ -0,0 +1,97 @@
+# Query `ipsw ghidra-bridge` for dyld_shared_cache symbols, ObjC class layouts and dylibs
+# @author blacktop
+# @category iOS
+# @menupath Tools.ipsw.Ghidra Bridge
+#
+# Start the bridge first:
+#   ipsw ghidra-bridge /path/to/dyld_shared_cache_arm64e
+# and set IPSW_BRIDGE_URL if it doesn't listen on http://127.0.0.1:3995
+
+import json
+import os
+import tempfile
+import urllib
+import urllib2
+
+from ghidra.program.model.data import (
+    ArrayDataType,
+    CategoryPath,
+    DataTypeConflictHandler,
+    PointerDataType,
+    StructureDataType,
+    Undefined,
+)
+from ghidra.program.model.symbol import SourceType
+from java.io import File
+
+BRIDGE_URL = os.environ.get("IPSW_BRIDGE_URL", "http://127.0.0.1:3995")
+
+
+def get(path, **params):
+    url = "%s%s?%s" % (BRIDGE_URL, path, urllib.urlencode(params))
+    try:
+        return urllib2.urlopen(url)
+    except urllib2.HTTPError as e:
+        raise Exception(json.load(e).get("error", str(e)))
+
+
+def get_json(path, **params):
+    return json.load(get(path, **params))
+
+
+def image_name():
+    """Returns the dyld_shared_cache image the current program was extracted from"""
+    return currentProgram.getExecutablePath() or currentProgram.getName()
+
+
+def label_symbol():
+    addr = currentAddress.getOffset()
+    sym = get_json("/symbol", addr=hex(addr).rstrip("L"))
+    name = sym.get("demanged") or sym["symbol"]
+    createLabel(currentAddress, name.replace(" ", "_"), True, SourceType.IMPORTED)
+    print("%#x: %s (%s)" % (addr, name, sym.get("image", "")))
+
+
+def ivar_type(ivar):
+    size = ivar["size"]
+    if ivar["type"].startswith("@") and size == currentProgram.getDefaultPointerSize():
+        return PointerDataType.dataType
+    if size in (1, 2, 4, 8):
+        return Undefined.getUndefinedDataType(size)
+    return ArrayDataType(Undefined.getUndefinedDataType(1), max(size, 1), 1)
+
+
+def apply_objc_class():
+    name = askString("ObjC Class", "Class name:")
+    image = askString("ObjC Class", "Image (name or UUID):", image_name())
+    cls = get_json("/objc/class", name=name, image=image)
+    struct = StructureDataType(CategoryPath("/ObjC"), cls["name"], cls["instance_size"])
+    for ivar in cls.get("ivars") or []:
+        if ivar["offset"] + ivar["size"] > cls["instance_size"]:
+            continue
+        struct.replaceAtOffset(ivar["offset"], ivar_type(ivar), ivar["size"], ivar["name"], ivar["decl"])
+    dtm = currentProgram.getDataTypeManager()
+    dtm.addDataType(struct, DataTypeConflictHandler.REPLACE_HANDLER)
+    print("Applied %s layout (%d ivars, %d bytes) to /ObjC/%s" % (cls["name"], len(cls.get("ivars") or []), cls["instance_size"], cls["name"]))
+
+
+def import_dylib():
+    image = askString("Import Dylib", "Image (name or UUID):")
+    resp = get("/extract", image=image)
+    path = os.path.join(tempfile.mkdtemp(), os.path.basename(image))
+    with open(path, "wb") as f:
+        f.write(resp.read())
+    program = importFile(File(path))
+    openProgram(program)
+    print("Imported %s" % image)
+
+
+ACTIONS = {
+    "Label the symbol at the current address": label_symbol,
+    "Apply an ObjC class layout": apply_objc_class,
+    "Import a dylib from the cache": import_dylib,
+}
+
+if __name__ == "__main__":
+    action = askChoice("ipsw ghidra-bridge", "Action:", sorted(ACTIONS.keys()), None)
+    ACTIONS[action]()
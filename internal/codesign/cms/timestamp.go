@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/rand"
-	"crypto/tls"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"fmt"
@@ -78,7 +77,7 @@ func GetTimestamp(url, proxy string, insecure bool, si SignerInfo) (Attribute, e
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: download.TLSConfig(insecure),
 		},
 	}
 
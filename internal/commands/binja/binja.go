@@ -0,0 +1,359 @@
+// Package binja exports the knowledge ipsw recovered about a MachO as JSON for the reference Binary Ninja
+// plugin (hack/extras/binja/ipsw_import.py); the schema is documented in hack/extras/binja/ipsw.schema.json
+package binja
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/go-macho/types/objc"
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/internal/swift"
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaVersion is the version of the export's schema (bumped on breaking changes)
+const SchemaVersion = 1
+
+// Export is the knowledge ipsw recovered about a MachO
+type Export struct {
+	Version   int        `json:"version" jsonschema_description:"The version of the export's schema"`
+	Binary    string     `json:"binary" jsonschema_description:"The name of the binary (the dylib's install name for dyld_shared_cache images)"`
+	UUID      string     `json:"uuid,omitempty" jsonschema_description:"The binary's LC_UUID"`
+	Arch      string     `json:"arch" jsonschema_description:"The binary's CPU architecture"`
+	Base      uint64     `json:"base" jsonschema_description:"The binary's preferred load address (all the addresses are virtual addresses relative to it)"`
+	Symbols   []Symbol   `json:"symbols" jsonschema_description:"The binary's symbols sorted by address"`
+	Functions []Function `json:"functions" jsonschema_description:"The binary's function starts sorted by address"`
+	ObjC      *ObjC      `json:"objc,omitempty" jsonschema_description:"The binary's Objective-C metadata"`
+	Fixups    []Fixup    `json:"fixups" jsonschema_description:"The binary's rebases and binds sorted by address"`
+}
+
+// Symbol is a named address
+type Symbol struct {
+	Addr      uint64 `json:"addr"`
+	Name      string `json:"name"`
+	Type      string `json:"type" jsonschema:"enum=function,enum=data" jsonschema_description:"Whether the symbol is in an executable section"`
+	Demangled string `json:"demangled,omitempty" jsonschema_description:"The demangled C++/Swift name"`
+}
+
+// Function is a function's boundaries
+type Function struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end,omitempty" jsonschema_description:"The start of the next function (omitted when unknown)"`
+}
+
+// ObjC is a binary's Objective-C metadata
+type ObjC struct {
+	Classes    []Class    `json:"classes,omitempty"`
+	Categories []Category `json:"categories,omitempty"`
+	Protocols  []Protocol `json:"protocols,omitempty"`
+	SelRefs    []Ref      `json:"selrefs,omitempty" jsonschema_description:"The __objc_selrefs pointers and their selectors"`
+	ClassRefs  []Ref      `json:"classrefs,omitempty" jsonschema_description:"The __objc_classrefs pointers and their classes"`
+}
+
+// Class is an Objective-C class
+type Class struct {
+	Name            string   `json:"name"`
+	SuperClass      string   `json:"superclass,omitempty"`
+	Addr            uint64   `json:"addr" jsonschema_description:"The address of the class_t"`
+	InstanceSize    uint64   `json:"instance_size"`
+	Ivars           []Ivar   `json:"ivars,omitempty"`
+	ClassMethods    []Method `json:"class_methods,omitempty"`
+	InstanceMethods []Method `json:"instance_methods,omitempty"`
+	Protocols       []string `json:"protocols,omitempty"`
+}
+
+// Ivar is an Objective-C instance variable
+type Ivar struct {
+	Name   string `json:"name"`
+	Type   string `json:"type" jsonschema_description:"The ivar's type encoding"`
+	Decl   string `json:"decl" jsonschema_description:"The ivar's C declaration"`
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+// Method is an Objective-C method
+type Method struct {
+	Name      string `json:"name" jsonschema_description:"The method's selector"`
+	Types     string `json:"types" jsonschema_description:"The method's type encoding"`
+	Prototype string `json:"prototype,omitempty" jsonschema_description:"The C prototype of the method's implementation (omitted if it takes structs)"`
+	Impl      uint64 `json:"impl,omitempty" jsonschema_description:"The address of the method's implementation"`
+}
+
+// Category is an Objective-C category
+type Category struct {
+	Name            string   `json:"name"`
+	Class           string   `json:"class,omitempty" jsonschema_description:"The name of the class it extends"`
+	Addr            uint64   `json:"addr" jsonschema_description:"The address of the category_t"`
+	ClassMethods    []Method `json:"class_methods,omitempty"`
+	InstanceMethods []Method `json:"instance_methods,omitempty"`
+}
+
+// Protocol is an Objective-C protocol
+type Protocol struct {
+	Name string `json:"name"`
+	Addr uint64 `json:"addr" jsonschema_description:"The address of the protocol_t"`
+}
+
+// Ref is a pointer to an Objective-C selector or class
+type Ref struct {
+	Addr   uint64 `json:"addr" jsonschema_description:"The address of the reference"`
+	Target uint64 `json:"target" jsonschema_description:"The address of the selector's name or the class_t"`
+	Name   string `json:"name"`
+}
+
+// Fixup is a pointer rebased or bound by dyld
+type Fixup struct {
+	Addr    uint64 `json:"addr" jsonschema_description:"The address of the pointer"`
+	Kind    string `json:"kind" jsonschema:"enum=rebase,enum=bind"`
+	Target  uint64 `json:"target,omitempty" jsonschema_description:"The address the rebase points to"`
+	Symbol  string `json:"symbol,omitempty" jsonschema_description:"The symbol the bind points to"`
+	Library string `json:"library,omitempty" jsonschema_description:"The library the bind's symbol is imported from"`
+	Addend  int64  `json:"addend,omitempty" jsonschema_description:"The bind's addend"`
+}
+
+// Schema returns the JSON schema of the export
+func Schema() *jsonschema.Schema {
+	schema := jsonschema.Reflect(&Export{})
+	schema.Description = "ipsw Binary Ninja export file"
+	return schema
+}
+
+// NewExport returns the export of a MachO's symbols, function starts, ObjC metadata and fixups
+func NewExport(name string, m *macho.File) (*Export, error) {
+	e := &Export{
+		Version: SchemaVersion,
+		Binary:  name,
+		Arch:    strings.ToLower(m.SubCPU.String(m.CPU)),
+		Base:    m.GetBaseAddress(),
+	}
+	if uuid := m.UUID(); uuid != nil {
+		e.UUID = uuid.String()
+	}
+
+	if m.Symtab != nil {
+		syms := make(map[uint64]string)
+		for _, sym := range m.Symtab.Syms {
+			if sym.Value == 0 || len(sym.Name) == 0 || sym.Name == "<redacted>" || sym.Type.IsDebugSym() {
+				continue
+			}
+			syms[sym.Value] = sym.Name
+		}
+		e.AddSymbols(m, syms)
+	}
+
+	for _, fn := range m.GetFunctions() {
+		e.Functions = append(e.Functions, Function{Start: fn.StartAddr, End: fn.EndAddr})
+	}
+
+	if m.HasObjC() {
+		objc, err := newObjC(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ObjC metadata: %v", err)
+		}
+		e.ObjC = objc
+	}
+
+	fixups, err := getFixups(m)
+	if err != nil {
+		log.WithError(err).Warn("failed to parse fixups")
+	}
+	e.Fixups = fixups
+
+	e.Sort()
+
+	return e, nil
+}
+
+// AddSymbols adds symbols (i.e. a dyld_shared_cache image's local symbols) keeping the existing ones
+func (e *Export) AddSymbols(m *macho.File, syms map[uint64]string) {
+	for _, addr := range slices.Sorted(maps.Keys(syms)) {
+		name := syms[addr]
+		sym := Symbol{Addr: addr, Name: name, Type: "data"}
+		if sec := m.FindSectionForVMAddr(addr); sec != nil && (sec.Flags.IsPureInstructions() || sec.Flags.IsSomeInstructions()) {
+			sym.Type = "function"
+		}
+		switch {
+		case strings.HasPrefix(name, "_$s") || strings.HasPrefix(name, "$s"):
+			sym.Demangled = swift.DemangleBlob(name)
+		case strings.HasPrefix(name, "__Z") || strings.HasPrefix(name, "_Z"):
+			sym.Demangled = demangle.Do(name, false, false)
+		}
+		if sym.Demangled == name {
+			sym.Demangled = ""
+		}
+		e.Symbols = append(e.Symbols, sym)
+	}
+}
+
+// Sort sorts the symbols, functions and fixups by address (de-duplicating the symbols)
+func (e *Export) Sort() {
+	slices.SortStableFunc(e.Symbols, func(x, y Symbol) int { return cmp.Compare(x.Addr, y.Addr) })
+	e.Symbols = slices.CompactFunc(e.Symbols, func(x, y Symbol) bool { return x.Addr == y.Addr })
+	slices.SortFunc(e.Functions, func(x, y Function) int { return cmp.Compare(x.Start, y.Start) })
+	slices.SortFunc(e.Fixups, func(x, y Fixup) int { return cmp.Compare(x.Addr, y.Addr) })
+	// the schema's arrays are never null
+	if e.Symbols == nil {
+		e.Symbols = []Symbol{}
+	}
+	if e.Functions == nil {
+		e.Functions = []Function{}
+	}
+	if e.Fixups == nil {
+		e.Fixups = []Fixup{}
+	}
+}
+
+func newMethods(meths []objc.Method) []Method {
+	var out []Method
+	for _, m := range meths {
+		out = append(out, Method{
+			Name:      m.Name,
+			Types:     m.Types,
+			Prototype: mcmd.ObjCMethodPrototype(&m),
+			Impl:      m.ImpVMAddr,
+		})
+	}
+	return out
+}
+
+func newObjC(m *macho.File) (*ObjC, error) {
+	o := &ObjC{}
+
+	classes, err := m.GetObjCClasses()
+	if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		return nil, err
+	}
+	for _, c := range classes {
+		class := Class{
+			Name:            c.Name,
+			SuperClass:      c.SuperClass,
+			Addr:            c.ClassPtr,
+			InstanceSize:    c.ReadOnlyData.InstanceSize,
+			ClassMethods:    newMethods(c.ClassMethods),
+			InstanceMethods: newMethods(c.InstanceMethods),
+		}
+		for _, iv := range c.Ivars {
+			class.Ivars = append(class.Ivars, Ivar{
+				Name:   iv.Name,
+				Type:   iv.Type,
+				Decl:   iv.Verbose(),
+				Offset: iv.Offset,
+				Size:   iv.Size,
+			})
+		}
+		for _, p := range c.Protocols {
+			class.Protocols = append(class.Protocols, p.Name)
+		}
+		o.Classes = append(o.Classes, class)
+	}
+
+	cats, err := m.GetObjCCategories()
+	if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.WithError(err).Warn("failed to parse ObjC categories")
+	}
+	for _, c := range cats {
+		cat := Category{
+			Name:            c.Name,
+			Addr:            c.VMAddr,
+			ClassMethods:    newMethods(c.ClassMethods),
+			InstanceMethods: newMethods(c.InstanceMethods),
+		}
+		if c.Class != nil {
+			cat.Class = c.Class.Name
+		}
+		o.Categories = append(o.Categories, cat)
+	}
+
+	protos, err := m.GetObjCProtocols()
+	if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.WithError(err).Warn("failed to parse ObjC protocols")
+	}
+	for _, p := range protos {
+		o.Protocols = append(o.Protocols, Protocol{Name: p.Name, Addr: p.Ptr})
+	}
+
+	if selRefs, err := m.GetObjCSelectorReferences(); err == nil {
+		for _, addr := range slices.Sorted(maps.Keys(selRefs)) {
+			o.SelRefs = append(o.SelRefs, Ref{Addr: addr, Target: selRefs[addr].VMAddr, Name: selRefs[addr].Name})
+		}
+	} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.WithError(err).Warn("failed to parse ObjC selector references")
+	}
+	if classRefs, err := m.GetObjCClassReferences(); err == nil {
+		for _, addr := range slices.Sorted(maps.Keys(classRefs)) {
+			o.ClassRefs = append(o.ClassRefs, Ref{Addr: addr, Target: classRefs[addr].ClassPtr, Name: classRefs[addr].Name})
+		}
+	} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.WithError(err).Warn("failed to parse ObjC class references")
+	}
+
+	return o, nil
+}
+
+// getFixups returns the MachO's chained fixups (or its dyld info rebases and binds)
+func getFixups(m *macho.File) ([]Fixup, error) {
+	var fixups []Fixup
+
+	if m.HasDyldChainedFixups() {
+		dcf, err := m.DyldChainedFixups()
+		if err != nil {
+			return nil, err
+		}
+		for _, start := range dcf.Starts {
+			for _, fixup := range start.Fixups {
+				switch f := fixup.(type) {
+				case fixupchains.Bind:
+					imp := dcf.Imports[f.Ordinal()]
+					fixups = append(fixups, Fixup{
+						Addr:    f.Offset() + m.GetBaseAddress(),
+						Kind:    "bind",
+						Symbol:  f.Name(),
+						Library: m.LibraryOrdinalName(imp.LibOrdinal()),
+						Addend:  int64(imp.Addend() + f.Addend()),
+					})
+				case fixupchains.Rebase:
+					fixups = append(fixups, Fixup{
+						Addr:   f.Offset() + m.GetBaseAddress(),
+						Kind:   "rebase",
+						Target: m.SlidePointer(f.Raw()),
+					})
+				}
+			}
+		}
+		return fixups, nil
+	}
+
+	if m.DyldInfo() == nil && m.DyldInfoOnly() == nil {
+		return nil, nil
+	}
+	rebases, err := m.GetRebaseInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rebases {
+		fixups = append(fixups, Fixup{Addr: r.Start + r.Offset, Kind: "rebase", Target: r.Value})
+	}
+	binds, err := m.GetBindInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range binds {
+		fixups = append(fixups, Fixup{
+			Addr:    b.Start + b.SegOffset,
+			Kind:    "bind",
+			Symbol:  b.Name,
+			Library: b.Dylib,
+			Addend:  b.Addend,
+		})
+	}
+	return fixups, nil
+}
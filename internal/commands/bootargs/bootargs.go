@@ -0,0 +1,347 @@
+// Package bootargs catalogs the boot-args and NVRAM variables read by the kernel and iBoot
+package bootargs
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/pkg/disass"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+)
+
+// Kind is what a referenced string is read as
+type Kind string
+
+const (
+	KindBootArg Kind = "boot-arg"
+	KindNVRAM   Kind = "nvram"
+)
+
+// the kernel functions reading a boot-arg or NVRAM variable (named by their first argument)
+var kernelReaders = map[string]Kind{
+	"_PE_parse_boot_argn":          KindBootArg,
+	"_PE_parse_boot_arg_str":       KindBootArg,
+	"_PE_boot_arg_uint64_eq":       KindBootArg,
+	"_PEReadNVRAMProperty":         KindNVRAM,
+	"_PEReadNVRAMBooleanProperty":  KindNVRAM,
+	"_PEWriteNVRAMProperty":        KindNVRAM,
+	"_PEWriteNVRAMBooleanProperty": KindNVRAM,
+	"_PERemoveNVRAMProperty":       KindNVRAM,
+}
+
+// the well-known names passed to the readers (to find the readers of stripped binaries)
+var (
+	kernelAnchors = map[Kind][]string{
+		KindBootArg: {"debug", "serial", "msgbuf", "kextlog", "cpus", "maxmem", "wdt", "io"},
+	}
+	ibootAnchors = map[Kind][]string{
+		KindNVRAM: {"auto-boot", "boot-args", "boot-command", "debug-uarts", "bootdelay", "idle-off"},
+	}
+)
+
+var (
+	// NVRAM variables can be named with their GUID (i.e. 7C436110-AB2A-4BBB-A880-FE41995C9F82:boot-args)
+	guidVarRE      = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}:.+$`)
+	ibootVersionRE = regexp.MustCompile(`iBoot-[0-9]+(?:\.[0-9]+)*`)
+)
+
+// Location is a code location reading a boot-arg or NVRAM variable
+type Location struct {
+	Image    string `json:"image"`
+	Addr     uint64 `json:"addr"`
+	Function string `json:"function,omitempty"`
+	Reader   string `json:"reader,omitempty"` // the function reading it
+}
+
+// Entry is a boot-arg or NVRAM variable and where it's read
+type Entry struct {
+	Name      string      `json:"name"`
+	Kind      Kind        `json:"kind"`
+	Locations []*Location `json:"locations"`
+}
+
+// Catalog is the boot-args and NVRAM variables of a build
+type Catalog struct {
+	Sources []string `json:"sources"` // the kernel and iBoot versions
+	Entries []*Entry `json:"entries"`
+
+	byName map[string]*Entry
+}
+
+// NewCatalog returns an empty catalog
+func NewCatalog() *Catalog {
+	return &Catalog{byName: make(map[string]*Entry)}
+}
+
+func (c *Catalog) add(name string, kind Kind, loc *Location) {
+	key := string(kind) + ":" + name
+	e, ok := c.byName[key]
+	if !ok {
+		e = &Entry{Name: name, Kind: kind}
+		c.byName[key] = e
+		c.Entries = append(c.Entries, e)
+	}
+	e.Locations = append(e.Locations, loc)
+}
+
+func (c *Catalog) sort() {
+	slices.SortFunc(c.Entries, func(a, b *Entry) int {
+		return cmp.Or(strings.Compare(string(a.Kind), string(b.Kind)), strings.Compare(a.Name, b.Name))
+	})
+	for _, e := range c.Entries {
+		slices.SortFunc(e.Locations, func(a, b *Location) int {
+			return cmp.Or(strings.Compare(a.Image, b.Image), cmp.Compare(a.Addr, b.Addr))
+		})
+	}
+}
+
+// findReaders returns the functions called with at least two of the anchors as their first argument
+func findReaders(xrefs []xref, anchors map[Kind][]string) map[uint64]Kind {
+	readers := make(map[uint64]Kind)
+	for kind, names := range anchors {
+		seen := make(map[uint64]map[string]bool)
+		for _, x := range xrefs {
+			if x.called == 0 || !slices.Contains(names, x.str) {
+				continue
+			}
+			if seen[x.called] == nil {
+				seen[x.called] = make(map[string]bool)
+			}
+			seen[x.called][x.str] = true
+		}
+		for fn, strs := range seen {
+			if len(strs) >= 2 {
+				readers[fn] = kind
+			}
+		}
+	}
+	return readers
+}
+
+func (c *Catalog) addXrefs(image string, xrefs []xref, readers map[uint64]Kind, readerName, funcName func(uint64) string) {
+	for _, x := range xrefs {
+		if kind, ok := readers[x.called]; ok {
+			c.add(x.str, kind, &Location{Image: image, Addr: x.addr, Function: funcName(x.addr), Reader: readerName(x.called)})
+		} else if guidVarRE.MatchString(x.str) {
+			c.add(x.str, KindNVRAM, &Location{Image: image, Addr: x.addr, Function: funcName(x.addr)})
+		}
+	}
+}
+
+type kernelImage struct {
+	name  string
+	m     *macho.File
+	xrefs []xref
+	stubs map[uint64]uint64
+}
+
+func cstrings(m *macho.File) (map[uint64]string, error) {
+	cstrs, err := m.GetCStrings()
+	if err != nil {
+		return nil, err
+	}
+	strs := make(map[uint64]string)
+	for _, sec := range cstrs {
+		for s, addr := range sec {
+			strs[addr] = s
+		}
+	}
+	return strs, nil
+}
+
+func scanKernelImage(name string, m *macho.File) (*kernelImage, error) {
+	img := &kernelImage{name: name, m: m}
+	strs, err := cstrings(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s cstrings: %v", name, err)
+	}
+	for _, sec := range m.Sections {
+		if !sec.Flags.IsPureInstructions() || sec.Flags.IsSymbolStubs() {
+			continue
+		}
+		dat, err := sec.Data()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s %s.%s: %v", name, sec.Seg, sec.Name, err)
+		}
+		img.xrefs = append(img.xrefs, scan(dat, sec.Addr, strs)...)
+	}
+	if stubs, err := disass.ParseStubsForMachO(m); err == nil {
+		img.stubs = make(map[uint64]uint64, len(stubs))
+		for stub, ptr := range stubs {
+			img.stubs[stub] = m.SlidePointer(ptr)
+		}
+	}
+	return img, nil
+}
+
+func (img *kernelImage) funcName(addr uint64) string {
+	fn, err := img.m.GetFunctionForVMAddr(addr)
+	if err != nil {
+		return ""
+	}
+	if syms, err := img.m.FindAddressSymbols(fn.StartAddr); err == nil && len(syms) > 0 {
+		return syms[0].Name
+	}
+	return fmt.Sprintf("sub_%x", fn.StartAddr)
+}
+
+// Kernel adds the boot-args and NVRAM variables read by the kernelcache (and its kexts)
+func (c *Catalog) Kernel(m *macho.File) error {
+	if kv, err := kernelcache.GetVersion(m); err == nil {
+		c.Sources = append(c.Sources, strings.SplitN(kv.String(), "\n", 2)[0])
+	}
+
+	var imgs []*kernelImage
+	if m.FileTOC.FileHeader.Type == types.MH_FILESET {
+		for _, fe := range m.FileSets() {
+			entry, err := m.GetFileSetFileByName(fe.EntryID)
+			if err != nil {
+				return fmt.Errorf("failed to parse fileset entry %s: %v", fe.EntryID, err)
+			}
+			log.WithField("entry", fe.EntryID).Debug("Scanning")
+			img, err := scanKernelImage(fe.EntryID, entry)
+			if err != nil {
+				return err
+			}
+			imgs = append(imgs, img)
+		}
+	} else {
+		img, err := scanKernelImage("kernel", m)
+		if err != nil {
+			return err
+		}
+		imgs = append(imgs, img)
+	}
+
+	// the readers (by their symbols or, if stripped, the anchors the kernel calls them with)
+	names := make(map[uint64]string)
+	readers := make(map[uint64]Kind)
+	for _, img := range imgs {
+		for name, kind := range kernelReaders {
+			if addr, err := img.m.FindSymbolAddress(name); err == nil {
+				readers[addr] = kind
+				names[addr] = name
+			}
+		}
+	}
+	if len(readers) == 0 {
+		for _, img := range imgs {
+			maps.Copy(readers, findReaders(img.xrefs, kernelAnchors))
+		}
+		if len(readers) == 0 {
+			log.Warn("failed to find the kernel's boot-arg/NVRAM readers (only the GUID named NVRAM variables are cataloged)")
+		}
+	}
+	readerName := func(addr uint64) string {
+		if name, ok := names[addr]; ok {
+			return name
+		}
+		return fmt.Sprintf("sub_%x", addr)
+	}
+
+	for _, img := range imgs {
+		// resolve the kexts' calls through their stubs
+		for i, x := range img.xrefs {
+			if target, ok := img.stubs[x.called]; ok {
+				img.xrefs[i].called = target
+			}
+		}
+		c.addXrefs(img.name, img.xrefs, readers, readerName, img.funcName)
+	}
+
+	c.sort()
+	return nil
+}
+
+// IBootBase returns the load address of a (decrypted) iBoot (or 0 if it can't be found)
+func IBootBase(data []byte) uint64 {
+	for _, off := range []int{0x318, 0x300} {
+		if off+8 > len(data) {
+			continue
+		}
+		if base := binary.LittleEndian.Uint64(data[off:]); base != 0 && base&0xfff == 0 && base < 1<<40 {
+			return base
+		}
+	}
+	return 0
+}
+
+// IBoot adds the NVRAM (environment) variables read by a decrypted iBoot loaded at base
+//
+// NOTE: the addresses are file offsets if base is 0
+func (c *Catalog) IBoot(data []byte, base uint64) error {
+	version := "iBoot"
+	if v := ibootVersionRE.Find(data); v != nil {
+		version = string(v)
+	}
+	c.Sources = append(c.Sources, version)
+
+	strs := make(map[uint64]string)
+	for off := 0; off < len(data); {
+		end := bytes.IndexByte(data[off:], 0)
+		if end < 0 {
+			break
+		}
+		if s := data[off : off+end]; len(s) >= 2 && isPrintable(s) {
+			strs[base+uint64(off)] = string(s)
+		}
+		off += end + 1
+	}
+
+	xrefs := scan(data[:len(data)&^3], base, strs)
+	readers := findReaders(xrefs, ibootAnchors)
+	if len(readers) == 0 {
+		log.Warn("failed to find iBoot's environment variable readers (only the GUID named NVRAM variables are cataloged)")
+	}
+	c.addXrefs(version, xrefs, readers, func(addr uint64) string {
+		return fmt.Sprintf("sub_%x", addr)
+	}, func(uint64) string {
+		return "" // no function starts
+	})
+
+	c.sort()
+	return nil
+}
+
+func isPrintable(s []byte) bool {
+	for _, b := range s {
+		if b < 0x20 || b >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes the catalog as a table
+func (c *Catalog) Write(out io.Writer) error {
+	if len(c.Sources) > 0 {
+		fmt.Fprintf(out, "%s\n\n", strings.Join(c.Sources, "\n"))
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tREAD BY")
+	for _, e := range c.Entries {
+		for i, loc := range e.Locations {
+			var kind, name string
+			if i == 0 {
+				kind, name = string(e.Kind), e.Name
+			}
+			where := fmt.Sprintf("%#x", loc.Addr)
+			if len(loc.Function) > 0 {
+				where = fmt.Sprintf("%s (%s)", where, loc.Function)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s: %s\n", kind, name, loc.Image, where)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,103 @@
+package bootargs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/blacktop/arm64-cgo/disassemble"
+)
+
+// the maximum number of instructions between loading a string and the call it's the first argument of
+const callWindow = 8
+
+// xref is a code location loading the address of a string
+type xref struct {
+	str    string
+	addr   uint64 // the instruction's address
+	called uint64 // the function called with the string as its first argument (0 if none)
+}
+
+// writesDest returns true if the instruction (most likely) writes its first operand
+func writesDest(i *disassemble.Instruction) bool {
+	if len(i.Operands) == 0 || i.Operands[0].Class != disassemble.REG || len(i.Operands[0].Registers) == 0 {
+		return false
+	}
+	op := i.Operation.String()
+	for _, prefix := range []string{"st", "cmp", "cmn", "tst", "cb", "tb", "b.", "prfm"} {
+		if strings.HasPrefix(op, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// scan returns the code's references (ADRP+ADD or ADR) to the strings (by address) and the functions they're
+// passed to as the first argument
+func scan(code []byte, start uint64, strs map[uint64]string) []xref {
+	var xrefs []xref
+	var results [1024]byte
+
+	pages := make(map[disassemble.Register]uint64) // the registers holding an ADRP page
+	loaded := make(map[disassemble.Register]int)   // the registers holding a string (the index of its xref)
+
+	r := bytes.NewReader(code)
+	var instrValue uint32
+	for addr := start; binary.Read(r, binary.LittleEndian, &instrValue) == nil; addr += 4 {
+		i, err := disassemble.Decompose(addr, instrValue, &results)
+		if err != nil {
+			continue
+		}
+		load := func(reg disassemble.Register, target uint64) {
+			if s, ok := strs[target]; ok {
+				xrefs = append(xrefs, xref{str: s, addr: addr})
+				loaded[reg] = len(xrefs) - 1
+			} else {
+				delete(loaded, reg)
+			}
+		}
+		switch i.Operation {
+		case disassemble.ARM64_ADRP:
+			pages[i.Operands[0].Registers[0]] = i.Operands[1].Immediate
+			delete(loaded, i.Operands[0].Registers[0])
+		case disassemble.ARM64_ADR:
+			delete(pages, i.Operands[0].Registers[0])
+			load(i.Operands[0].Registers[0], i.Operands[1].Immediate)
+		case disassemble.ARM64_ADD:
+			page, ok := pages[i.Operands[1].Registers[0]]
+			delete(pages, i.Operands[0].Registers[0])
+			if ok && len(i.Operands) > 2 && (i.Operands[2].Class == disassemble.IMM32 || i.Operands[2].Class == disassemble.IMM64) {
+				load(i.Operands[0].Registers[0], page+i.Operands[2].Immediate)
+			} else {
+				delete(loaded, i.Operands[0].Registers[0])
+			}
+		case disassemble.ARM64_MOV:
+			delete(loaded, i.Operands[0].Registers[0])
+			if i.Operands[1].Class == disassemble.REG {
+				if idx, ok := loaded[i.Operands[1].Registers[0]]; ok {
+					loaded[i.Operands[0].Registers[0]] = idx
+				}
+			}
+			delete(pages, i.Operands[0].Registers[0])
+		case disassemble.ARM64_BL:
+			if idx, ok := loaded[disassemble.REG_X0]; ok && addr-xrefs[idx].addr <= callWindow*4 {
+				xrefs[idx].called = i.Operands[0].Immediate
+			}
+			clear(pages)
+			clear(loaded)
+		case disassemble.ARM64_B, disassemble.ARM64_BR, disassemble.ARM64_BLR, disassemble.ARM64_RET,
+			disassemble.ARM64_BRAA, disassemble.ARM64_BRAAZ, disassemble.ARM64_BRAB, disassemble.ARM64_BRABZ,
+			disassemble.ARM64_BLRAA, disassemble.ARM64_BLRAAZ, disassemble.ARM64_BLRAB, disassemble.ARM64_BLRABZ,
+			disassemble.ARM64_RETAA, disassemble.ARM64_RETAB:
+			clear(pages)
+			clear(loaded)
+		default:
+			if writesDest(i) {
+				delete(pages, i.Operands[0].Registers[0])
+				delete(loaded, i.Operands[0].Registers[0])
+			}
+		}
+	}
+
+	return xrefs
+}
@@ -0,0 +1,220 @@
+// Package cryptex contains functions to locate and extract the cryptex DMGs in an IPSW or OTA
+package cryptex
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/aea"
+	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/blacktop/ipsw/pkg/ota"
+	"github.com/blacktop/ipsw/pkg/ota/ridiff"
+)
+
+// Names are the cryptex names by BuildManifest component
+var Names = map[string]string{
+	"Cryptex1,SystemOS": "SystemOS",
+	"Cryptex1,AppOS":    "AppOS",
+	"Ap,ExclaveOS":      "ExclaveOS",
+}
+
+// otaCryptexRE matches the cryptex RIDIFF patches in an OTA (i.e. cryptex-system-arm64e and cryptex-app)
+var otaCryptexRE = regexp.MustCompile(`cryptex-(system|app)(-(arm64e?|x86_64h?))?$`)
+
+// Cryptex is a cryptex DMG in an IPSW or OTA
+type Cryptex struct {
+	Name      string `json:"name"`                // SystemOS, AppOS or ExclaveOS
+	Component string `json:"component,omitempty"` // BuildManifest component
+	Arch      string `json:"arch,omitempty"`      // OTA system cryptexes are per arch
+	Path      string `json:"path"`                // path in the IPSW/OTA
+	Size      uint64 `json:"size"`
+	Encrypted bool   `json:"encrypted,omitempty"` // AEA encrypted DMG
+	Patch     bool   `json:"patch,omitempty"`     // OTA RIDIFF patch (applied to an empty image when extracted)
+}
+
+// Config is the configuration for the cryptex commands
+type Config struct {
+	PemDB  string // AEA pem DB JSON file (to decrypt IPSW cryptexes)
+	OtaKey string // base64 symmetric key of an AEA encrypted OTA
+	Output string // folder to extract the cryptexes to
+}
+
+// List returns the cryptexes in an IPSW or OTA
+func List(path string, conf *Config) ([]*Cryptex, error) {
+	if fwcmd.IsOTA(path) {
+		return listOTA(path, conf)
+	}
+	return listIPSW(path)
+}
+
+func listIPSW(path string) ([]*Cryptex, error) {
+	i, err := info.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPSW: %v", err)
+	}
+	if i.Plists == nil || i.Plists.BuildManifest == nil {
+		return nil, fmt.Errorf("no BuildManifest.plist found")
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IPSW: %v", err)
+	}
+	defer zr.Close()
+
+	var cryptexes []*Cryptex
+	seen := make(map[string]bool)
+	for _, bi := range i.Plists.BuildIdentities {
+		for _, comp := range slices.Sorted(maps.Keys(Names)) {
+			m, ok := bi.Manifest[comp]
+			if !ok {
+				continue
+			}
+			dmg, _ := m.Info["Path"].(string)
+			if len(dmg) == 0 || seen[dmg] {
+				continue
+			}
+			seen[dmg] = true
+			c := &Cryptex{
+				Name:      Names[comp],
+				Component: comp,
+				Path:      dmg,
+				Encrypted: filepath.Ext(dmg) == ".aea",
+			}
+			for _, f := range zr.File {
+				if f.Name == dmg {
+					c.Size = f.UncompressedSize64
+					break
+				}
+			}
+			cryptexes = append(cryptexes, c)
+		}
+	}
+	if len(cryptexes) == 0 {
+		return nil, info.ErrorCryptexNotFound
+	}
+	return cryptexes, nil
+}
+
+func listOTA(path string, conf *Config) ([]*Cryptex, error) {
+	o, err := ota.Open(path, conf.OtaKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OTA: %v", err)
+	}
+	defer o.Close()
+
+	var cryptexes []*Cryptex
+	for _, f := range o.Files() {
+		if f.IsDir() {
+			continue
+		}
+		matches := otaCryptexRE.FindStringSubmatch(f.Path())
+		if matches == nil {
+			continue
+		}
+		c := &Cryptex{
+			Name:  "AppOS",
+			Arch:  matches[3],
+			Path:  f.Path(),
+			Size:  uint64(f.Size()),
+			Patch: true,
+		}
+		if matches[1] == "system" {
+			c.Name = "SystemOS"
+		}
+		cryptexes = append(cryptexes, c)
+	}
+	if len(cryptexes) == 0 {
+		return nil, info.ErrorCryptexNotFound
+	}
+	return cryptexes, nil
+}
+
+// Extract extracts (and decrypts/patches) the cryptex DMG from an IPSW or OTA into the output folder
+func Extract(path string, c *Cryptex, conf *Config) (string, error) {
+	if err := os.MkdirAll(conf.Output, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create output folder %s: %v", conf.Output, err)
+	}
+	if c.Patch {
+		return Patch(path, c, "", conf)
+	}
+
+	dmgs, err := utils.Unzip(path, conf.Output, func(f *zip.File) bool {
+		return f.Name == c.Path
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s from IPSW: %v", c.Path, err)
+	}
+	if len(dmgs) == 0 {
+		return "", fmt.Errorf("failed to find %s in IPSW", c.Path)
+	}
+	if !c.Encrypted {
+		return dmgs[0], nil
+	}
+
+	defer os.Remove(dmgs[0])
+	utils.Indent(log.Debug, 2)(fmt.Sprintf("Decrypting %s", filepath.Base(dmgs[0])))
+	out, err := aea.Decrypt(&aea.DecryptConfig{
+		Input:  dmgs[0],
+		Output: conf.Output,
+		PemDB:  conf.PemDB,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %v", filepath.Base(dmgs[0]), err)
+	}
+	return out, nil
+}
+
+// Patch applies an OTA cryptex RIDIFF patch to the prior full cryptex DMG (or an empty image if prior is empty
+// i.e. RSR and full OTAs) and writes the updated cryptex DMG to the output folder (macOS only)
+func Patch(otaPath string, c *Cryptex, prior string, conf *Config) (string, error) {
+	if !c.Patch {
+		return "", fmt.Errorf("%s is not an OTA cryptex patch", c.Path)
+	}
+	if err := os.MkdirAll(conf.Output, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create output folder %s: %v", conf.Output, err)
+	}
+
+	o, err := ota.Open(otaPath, conf.OtaKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to open OTA: %v", err)
+	}
+	defer o.Close()
+
+	f, err := o.Open(c.Path, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s in OTA: %v", c.Path, err)
+	}
+	defer f.Close()
+
+	patch, err := os.CreateTemp("", filepath.Base(c.Path))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file for %s: %v", c.Path, err)
+	}
+	defer os.Remove(patch.Name())
+	if _, err := io.Copy(patch, f); err != nil {
+		patch.Close()
+		return "", fmt.Errorf("failed to read %s: %v", c.Path, err)
+	}
+	patch.Close()
+
+	out := filepath.Join(conf.Output, strings.TrimPrefix(filepath.Base(c.Path), ".")+".dmg")
+	if len(prior) > 0 {
+		utils.Indent(log.Debug, 2)(fmt.Sprintf("Patching %s onto %s", filepath.Base(c.Path), filepath.Base(prior)))
+	} else {
+		utils.Indent(log.Debug, 2)(fmt.Sprintf("Patching %s", filepath.Base(c.Path)))
+	}
+	if err := ridiff.RawImagePatch(prior, patch.Name(), out, 0); err != nil {
+		return "", fmt.Errorf("failed to patch %s: %v", c.Path, err)
+	}
+	return out, nil
+}
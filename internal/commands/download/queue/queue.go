@@ -0,0 +1,230 @@
+// Package queue downloads the IPSWs in the persistent download queue with a pool of workers
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/model"
+)
+
+// pollInterval is how often idle workers check for items that are ready to be retried
+const pollInterval = 5 * time.Second
+
+// Config is the download queue worker pool config
+type Config struct {
+	// Workers is the number of parallel downloads
+	Workers int
+	// Retries is the number of attempts per item before it is marked as failed
+	Retries int
+	// Backoff is the delay before the first retry (it doubles with each attempt)
+	Backoff time.Duration
+	// download config
+	Output       string
+	Proxy        string
+	Insecure     bool
+	RemoveCommas bool
+	Segments     int
+	Verbose      bool
+	// Done is called after an item's IPSW is downloaded
+	Done func(item *model.QueueItem)
+}
+
+// Enqueue resolves the device/build pairs (where the build is a build, version or 'latest') and adds the ones
+// the device list allows (if not nil) to the queue
+func Enqueue(d db.Database, pairs [][2]string, devList *download.DeviceList) ([]*model.QueueItem, error) {
+	var items []*model.QueueItem
+	for _, pair := range pairs {
+		device, build := pair[0], pair[1]
+		item := &model.QueueItem{Device: device, Build: build}
+		switch {
+		case strings.EqualFold(build, "latest"):
+			ipsws, err := download.GetDeviceIPSWs(device)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get IPSWs for %s: %v", device, err)
+			}
+			if len(ipsws) == 0 {
+				return nil, fmt.Errorf("no IPSWs found for %s", device)
+			}
+			item.Build = ipsws[0].BuildID
+			item.Version = ipsws[0].Version
+		case strings.Contains(build, "."): // version
+			bld, err := download.GetBuildID(build, device)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get build for %s %s: %v", device, build, err)
+			}
+			item.Build = bld
+			item.Version = build
+		default:
+			if devList != nil {
+				ver, err := download.GetVersion(build)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get version of %s: %v", build, err)
+				}
+				item.Version = ver
+			}
+		}
+		if !devList.Allow(item.Device, item.Version) {
+			log.WithFields(log.Fields{
+				"device":  item.Device,
+				"build":   item.Build,
+				"version": item.Version,
+			}).Warn("Not in the device list (skipping)")
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	if err := d.Enqueue(items...); err != nil {
+		return nil, fmt.Errorf("failed to enqueue: %v", err)
+	}
+	return items, nil
+}
+
+// Run downloads the queued items with a pool of workers until there are none left to attempt
+func Run(ctx context.Context, d db.Database, conf *Config) error {
+	if conf.Workers < 1 {
+		conf.Workers = 1
+	}
+	if conf.Retries < 1 {
+		conf.Retries = 1
+	}
+
+	// requeue the items of an interrupted run
+	running, err := d.GetQueue(model.QueueRunning)
+	if err != nil {
+		return fmt.Errorf("failed to get running queue items: %v", err)
+	}
+	for _, item := range running {
+		item.Status = model.QueuePending
+		if err := d.Save(item); err != nil {
+			return fmt.Errorf("failed to requeue %s: %v", item, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, conf.Workers)
+	for i := range conf.Workers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = worker(ctx, d, conf)
+		}(i)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func worker(ctx context.Context, d db.Database, conf *Config) error {
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		item, err := d.NextQueueItem()
+		if errors.Is(err, model.ErrNotFound) {
+			// wait for the items that are being retried (or are still running in other workers)
+			pending, err := d.GetQueue(model.QueuePending)
+			if err != nil {
+				return err
+			}
+			running, err := d.GetQueue(model.QueueRunning)
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 && len(running) == 0 {
+				return nil
+			}
+			wait := pollInterval
+			for _, p := range pending {
+				if until := time.Until(p.NextAttempt); until > 0 && until < wait {
+					wait = until
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get next queue item: %v", err)
+		}
+
+		item.Attempts++
+		if err := fetch(item, conf); err != nil {
+			item.Error = err.Error()
+			if item.Attempts >= conf.Retries {
+				item.Status = model.QueueFailed
+				log.WithError(err).WithField("attempts", item.Attempts).Errorf("Failed to download %s", item)
+			} else {
+				item.Status = model.QueuePending
+				item.NextAttempt = time.Now().Add(conf.Backoff << (item.Attempts - 1))
+				log.WithError(err).WithField("retry", item.NextAttempt.Format(time.Kitchen)).Warnf("Failed to download %s", item)
+			}
+		} else {
+			item.Status = model.QueueDone
+			item.Error = ""
+			if conf.Done != nil {
+				conf.Done(item)
+			}
+		}
+		if err := d.Save(item); err != nil {
+			return fmt.Errorf("failed to update queue item %s: %v", item, err)
+		}
+	}
+}
+
+// fetch downloads the item's IPSW
+func fetch(item *model.QueueItem, conf *Config) error {
+	ipsw, err := download.GetIPSW(item.Device, item.Build)
+	if err != nil {
+		return fmt.Errorf("failed to get IPSW info: %v", err)
+	}
+	if len(ipsw.URL) == 0 {
+		return fmt.Errorf("no IPSW found")
+	}
+	item.Version = ipsw.Version
+
+	name := path.Base(ipsw.URL)
+	if conf.RemoveCommas {
+		name = strings.ReplaceAll(name, ",", "_")
+	}
+	item.Path = filepath.Join(conf.Output, name)
+
+	if _, err := os.Stat(item.Path); err == nil {
+		log.WithField("file", item.Path).Info("IPSW already downloaded")
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0o750); err != nil {
+		return fmt.Errorf("failed to create output folder: %v", err)
+	}
+
+	log.WithFields(log.Fields{
+		"device":  item.Device,
+		"build":   item.Build,
+		"version": item.Version,
+		"attempt": item.Attempts,
+	}).Info("Downloading IPSW")
+
+	// always resume (there is no one to answer the prompt)
+	downloader := download.NewDownload(conf.Proxy, conf.Insecure, false, true, false, false, conf.Verbose)
+	downloader.URL = ipsw.URL
+	downloader.Sha1 = ipsw.SHA1
+	downloader.DestName = item.Path
+	downloader.Segments = conf.Segments
+	return downloader.Do()
+}
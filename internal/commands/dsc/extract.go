@@ -0,0 +1,186 @@
+package dsc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// ExtractConfig is the configuration for extracting a dylib from a dyld_shared_cache
+type ExtractConfig struct {
+	ObjC  bool // add the ObjC classes, categories, protocols and methods to the symtab
+	Stubs bool // add the stub islands to the symtab
+	Slide bool // apply the cache's slide info
+}
+
+// ExtractDylib exports the dyld_shared_cache image as a standalone MachO to fname (with its local symbols)
+func ExtractDylib(f *dyld.File, image *dyld.CacheImage, fname string, conf *ExtractConfig) error {
+	m, err := image.GetMacho()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	var dcf *fixupchains.DyldChainedFixups
+	if m.HasFixups() {
+		dcf, err = m.DyldChainedFixups()
+		if err != nil {
+			log.Errorf("failed to parse fixups from in memory MachO for %s: %v", image.Name, err)
+		}
+	}
+
+	image.ParseLocalSymbols(false)
+
+	syms := image.GetLocalSymbolsAsMachoSymbols()
+
+	if conf.ObjC && m.HasObjC() {
+		log.Info("Adding ObjC symbols")
+		syms = append(syms, ObjCSymbols(m)...)
+	}
+
+	if conf.Stubs {
+		log.Info("Adding Stub Islands symbols")
+		stubIslands, err := f.GetStubIslands()
+		if err != nil {
+			return err
+		}
+		for addr, sym := range stubIslands {
+			syms = append(syms, macho.Symbol{
+				Name:  sym,
+				Value: addr,
+				Desc:  0xa00,
+			})
+		}
+	}
+
+	if err := m.Export(fname, dcf, m.GetBaseAddress(), syms); err != nil {
+		return err
+	}
+
+	if conf.Slide {
+		log.Info("Applying DSC slide-info")
+		if err := RebaseMachO(f, fname); err != nil {
+			return fmt.Errorf("failed to rebase dylib via cache slide info: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ObjCSymbols returns the symbols of the MachO's ObjC protocols, classes, categories and their methods
+func ObjCSymbols(m *macho.File) []macho.Symbol {
+	var syms []macho.Symbol
+	if protos, err := m.GetObjCProtocols(); err == nil {
+		for _, proto := range protos {
+			syms = append(syms, macho.Symbol{
+				Name:  proto.Name,
+				Value: proto.Ptr,
+				Desc:  0xa00,
+			})
+		}
+	} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.Error(err.Error())
+	}
+	if classes, err := m.GetObjCClasses(); err == nil {
+		for _, class := range classes {
+			syms = append(syms, macho.Symbol{
+				Name:  class.Name,
+				Value: class.ClassPtr,
+				Desc:  0xa00,
+			})
+			for _, cmeth := range class.ClassMethods {
+				syms = append(syms, macho.Symbol{
+					Name:  fmt.Sprintf("+[%s %s]", class.Name, cmeth.Name),
+					Value: cmeth.ImpVMAddr,
+					Desc:  0xa00,
+				})
+			}
+			for _, imeth := range class.InstanceMethods {
+				syms = append(syms, macho.Symbol{
+					Name:  fmt.Sprintf("-[%s %s]", class.Name, imeth.Name),
+					Value: imeth.ImpVMAddr,
+					Desc:  0xa00,
+				})
+			}
+		}
+	} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.Error(err.Error())
+	}
+	if cats, err := m.GetObjCCategories(); err == nil {
+		for _, cat := range cats {
+			syms = append(syms, macho.Symbol{
+				Name:  cat.Name,
+				Value: cat.VMAddr,
+				Desc:  0xa00,
+			})
+			for _, imeth := range cat.InstanceMethods {
+				syms = append(syms, macho.Symbol{
+					Name:  fmt.Sprintf("-[%s %s]", cat.Name, imeth.Name),
+					Value: imeth.ImpVMAddr,
+					Desc:  0xa00,
+				})
+			}
+		}
+	} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		log.Error(err.Error())
+	}
+	return syms
+}
+
+// RebaseMachO applies the dyld_shared_cache's slide info to the pointers of a MachO exported from it
+func RebaseMachO(dsc *dyld.File, machoPath string) error {
+	f, err := os.OpenFile(machoPath, os.O_RDWR, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to open exported MachO %s: %v", machoPath, err)
+	}
+	defer f.Close()
+
+	mm, err := macho.NewFile(f)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range mm.Segments() {
+		uuid, mapping, err := dsc.GetMappingForVMAddress(seg.Addr)
+		if err != nil {
+			return err
+		}
+
+		if mapping.SlideInfoOffset == 0 {
+			continue
+		}
+
+		startAddr := seg.Addr - mapping.Address
+		endAddr := ((seg.Addr + seg.Memsz) - mapping.Address) + uint64(dsc.SlideInfo.GetPageSize())
+
+		start := startAddr / uint64(dsc.SlideInfo.GetPageSize())
+		end := endAddr / uint64(dsc.SlideInfo.GetPageSize())
+
+		rebases, err := dsc.GetRebaseInfoForPages(uuid, mapping, start, end)
+		if err != nil {
+			return err
+		}
+
+		for _, rebase := range rebases {
+			off, err := mm.GetOffset(rebase.CacheVMAddress)
+			if err != nil {
+				continue
+			}
+			if _, err := f.Seek(int64(off), io.SeekStart); err != nil {
+				return fmt.Errorf("failed to seek in exported file to offset %#x from the start: %v", off, err)
+			}
+			if err := binary.Write(f, dsc.ByteOrder, rebase.Target); err != nil {
+				return fmt.Errorf("failed to write rebase address %#x: %v", rebase.Target, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package ent
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-plist"
+)
+
+// DefaultInteresting are the security-relevant entitlements highlighted by the diffs by default
+var DefaultInteresting = []string{
+	"com.apple.private.security.*",
+	"com.apple.private.kernel.*",
+	"com.apple.private.amfi.*",
+	"com.apple.private.cs.*",
+	"com.apple.private.tcc.*",
+	"com.apple.private.skip-library-validation",
+	"com.apple.private.iokit.*",
+	"com.apple.private.xpc.*",
+	"com.apple.rootless.*",
+	"com.apple.security.cs.*",
+	"com.apple.security.app-sandbox",
+	"com.apple.system-task-ports*",
+	"com.apple.keystore.*",
+	"seatbelt-profiles",
+	"platform-application",
+	"task_for_pid-allow",
+	"get-task-allow",
+	"run-unsigned-code",
+	"dynamic-codesigning",
+}
+
+// Status is how a binary's entitlements changed
+type Status string
+
+const (
+	StatusNew     Status = "new"
+	StatusRemoved Status = "removed"
+	StatusUpdated Status = "updated"
+)
+
+// Severity is how security-relevant an entitlement change is
+type Severity string
+
+const (
+	// SeverityHigh is an interesting entitlement that was added or changed
+	SeverityHigh Severity = "high"
+	// SeverityMedium is an interesting entitlement that was removed
+	SeverityMedium Severity = "medium"
+	// SeverityLow is any other change
+	SeverityLow Severity = "low"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityHigh:
+		return 0
+	case SeverityMedium:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EntDiff is the entitlement keys added, removed and changed in a binary
+type EntDiff struct {
+	Path        string   `json:"path"`
+	Status      Status   `json:"status"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	Changed     []string `json:"changed,omitempty"`
+	Interesting []string `json:"interesting,omitempty"`
+	Severity    Severity `json:"severity"`
+}
+
+// filter matches the paths to diff and the interesting entitlements
+type filter struct {
+	include     []*regexp.Regexp
+	exclude     []*regexp.Regexp
+	interesting []string
+}
+
+func newFilter(conf *Config) (*filter, error) {
+	f := &filter{interesting: conf.Interesting}
+	if len(f.interesting) == 0 {
+		f.interesting = DefaultInteresting
+	}
+	for _, pattern := range f.interesting {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid interesting entitlement glob '%s': %v", pattern, err)
+		}
+	}
+	for _, r := range []struct {
+		patterns []string
+		res      *[]*regexp.Regexp
+	}{{conf.Include, &f.include}, {conf.Exclude, &f.exclude}} {
+		for _, pattern := range r.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path regex '%s': %v", pattern, err)
+			}
+			*r.res = append(*r.res, re)
+		}
+	}
+	return f, nil
+}
+
+// match returns true if the path should be diffed
+func (f *filter) match(p string) bool {
+	matches := func(res []*regexp.Regexp) bool {
+		return slices.ContainsFunc(res, func(re *regexp.Regexp) bool { return re.MatchString(p) })
+	}
+	if len(f.include) > 0 && !matches(f.include) {
+		return false
+	}
+	return !matches(f.exclude)
+}
+
+func (f *filter) isInteresting(key string) bool {
+	return slices.ContainsFunc(f.interesting, func(pattern string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	})
+}
+
+// parse parses the entitlements plist of a binary
+func parse(ents string) (map[string]any, error) {
+	m := make(map[string]any)
+	if len(strings.TrimSpace(ents)) == 0 {
+		return m, nil
+	}
+	if _, err := plist.Unmarshal([]byte(ents), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diffKeys compares the entitlements of a binary (either can be nil)
+func (f *filter) diffKeys(p string, e1, e2 map[string]any) *EntDiff {
+	d := &EntDiff{Path: p, Status: StatusUpdated, Severity: SeverityLow}
+	switch {
+	case e1 == nil:
+		d.Status = StatusNew
+	case e2 == nil:
+		d.Status = StatusRemoved
+	}
+	for k, v2 := range e2 {
+		if v1, ok := e1[k]; !ok {
+			d.Added = append(d.Added, k)
+		} else if !reflect.DeepEqual(v1, v2) {
+			d.Changed = append(d.Changed, k)
+		}
+	}
+	for k := range e1 {
+		if _, ok := e2[k]; !ok {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+	slices.Sort(d.Added)
+	slices.Sort(d.Removed)
+	slices.Sort(d.Changed)
+	for _, k := range slices.Concat(d.Added, d.Changed) {
+		if f.isInteresting(k) {
+			d.Interesting = append(d.Interesting, k)
+			d.Severity = SeverityHigh
+		}
+	}
+	for _, k := range d.Removed {
+		if f.isInteresting(k) {
+			d.Interesting = append(d.Interesting, k)
+			if d.Severity != SeverityHigh {
+				d.Severity = SeverityMedium
+			}
+		}
+	}
+	return d
+}
+
+// Diff compares the entitlement keys of the binaries in two entitlement databases
+// (sorted by severity, then path)
+func Diff(db1, db2 map[string]string, conf *Config) ([]*EntDiff, error) {
+	f, err := newFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []*EntDiff
+	for p, ents2 := range db2 {
+		if !f.match(p) {
+			continue
+		}
+		e2, err := parse(ents2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse entitlements of %s: %v", p, err)
+		}
+		var e1 map[string]any
+		if ents1, ok := db1[p]; ok {
+			if ents1 == ents2 {
+				continue
+			}
+			if e1, err = parse(ents1); err != nil {
+				return nil, fmt.Errorf("failed to parse entitlements of %s: %v", p, err)
+			}
+		}
+		if d := f.diffKeys(p, e1, e2); d.Status == StatusNew || len(d.Added)+len(d.Removed)+len(d.Changed) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	for p, ents1 := range db1 {
+		if _, ok := db2[p]; ok || !f.match(p) {
+			continue
+		}
+		e1, err := parse(ents1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse entitlements of %s: %v", p, err)
+		}
+		diffs = append(diffs, f.diffKeys(p, e1, nil))
+	}
+	slices.SortFunc(diffs, func(a, b *EntDiff) int {
+		return cmp.Or(cmp.Compare(a.Severity.rank(), b.Severity.rank()), strings.Compare(a.Path, b.Path))
+	})
+	return diffs, nil
+}
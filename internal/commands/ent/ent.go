@@ -36,6 +36,11 @@ type Config struct {
 	Color    bool
 	DiffTool string
 
+	// Diff Config
+	Include     []string // regexes of the binary paths to diff (all if empty)
+	Exclude     []string // regexes of the binary paths not to diff
+	Interesting []string // globs of the entitlements to highlight (defaults to DefaultInteresting)
+
 	// UI Config
 	Version string
 	Host    string
@@ -185,20 +190,32 @@ func GetDatabase(conf *Config) (map[string]string, error) {
 }
 
 // DiffDatabases compares two entitlement databases and returns a diff
+// (the interesting entitlement changes are summarized first and highlighted)
 func DiffDatabases(db1, db2 map[string]string, conf *Config) (string, error) {
-	var err error
 	var dat bytes.Buffer
 	buf := bufio.NewWriter(&dat)
 
+	diffs, err := Diff(db1, db2, conf)
+	if err != nil {
+		return "", err
+	}
+	changes := make(map[string]*EntDiff, len(diffs))
+	for _, d := range diffs {
+		changes[d.Path] = d
+	}
+
+	writeInteresting(buf, diffs, conf)
+
 	// sort latest entitlements DB's files
 	var files []string
 	for f := range db2 {
-		files = append(files, f)
+		if _, ok := changes[f]; ok {
+			files = append(files, f)
+		}
 	}
 
 	sort.Strings(files)
 
-	found := false
 	for _, f2 := range files { // DIFF ALL ENTITLEMENTS
 		e2 := db2[f2]
 		if e1, ok := db1[f2]; ok {
@@ -217,20 +234,18 @@ func DiffDatabases(db1, db2 map[string]string, conf *Config) (string, error) {
 			if len(out) == 0 {
 				continue
 			}
-			found = true
 			if conf.Markdown {
-				buf.WriteString(fmt.Sprintf("### %s\n\n> `%s`\n\n", filepath.Base(f2), f2))
+				buf.WriteString(fmt.Sprintf("### %s%s\n\n> `%s`\n\n", severityIcon(changes[f2]), filepath.Base(f2), f2))
 				buf.WriteString("```diff\n" + out + "\n```\n")
 			} else {
-				buf.WriteString(color.New(color.Bold).Sprintf("\n%s\n\n", f2))
+				buf.WriteString(color.New(color.Bold).Sprintf("\n%s%s\n\n", severityIcon(changes[f2]), f2))
 				buf.WriteString(out + "\n")
 			}
 		} else {
-			found = true
 			if conf.Markdown {
-				buf.WriteString(fmt.Sprintf("\n### 🆕 %s\n\n> `%s`\n\n", filepath.Base(f2), f2))
+				buf.WriteString(fmt.Sprintf("\n### 🆕 %s%s\n\n> `%s`\n\n", severityIcon(changes[f2]), filepath.Base(f2), f2))
 			} else {
-				buf.WriteString(color.New(color.Bold).Sprintf("\n🆕 %s\n\n", f2))
+				buf.WriteString(color.New(color.Bold).Sprintf("\n🆕 %s%s\n\n", severityIcon(changes[f2]), f2))
 			}
 			if len(e2) == 0 {
 				buf.WriteString("- No entitlements *(yet)*\n")
@@ -250,7 +265,18 @@ func DiffDatabases(db1, db2 map[string]string, conf *Config) (string, error) {
 		}
 	}
 
-	if !found {
+	for _, d := range diffs {
+		if d.Status != StatusRemoved {
+			continue
+		}
+		if conf.Markdown {
+			buf.WriteString(fmt.Sprintf("\n### ❌ %s%s\n\n> `%s`\n\n", severityIcon(d), filepath.Base(d.Path), d.Path))
+		} else {
+			buf.WriteString(color.New(color.Bold).Sprintf("\n❌ %s%s\n", severityIcon(d), d.Path))
+		}
+	}
+
+	if len(diffs) == 0 {
 		buf.WriteString("- No differences found\n")
 	}
 
@@ -259,6 +285,50 @@ func DiffDatabases(db1, db2 map[string]string, conf *Config) (string, error) {
 	return dat.String(), nil
 }
 
+// severityIcon returns the icon of the interesting entitlement changes
+func severityIcon(d *EntDiff) string {
+	switch d.Severity {
+	case SeverityHigh:
+		return "🔴 "
+	case SeverityMedium:
+		return "🟠 "
+	default:
+		return ""
+	}
+}
+
+// writeInteresting writes the summary of the interesting entitlement changes
+func writeInteresting(buf *bufio.Writer, diffs []*EntDiff, conf *Config) {
+	var interesting []*EntDiff
+	for _, d := range diffs {
+		if len(d.Interesting) > 0 {
+			interesting = append(interesting, d)
+		}
+	}
+	if len(interesting) == 0 {
+		return
+	}
+	if conf.Markdown {
+		buf.WriteString(fmt.Sprintf("### ⚠️ Interesting (%d)\n\n", len(interesting)))
+		buf.WriteString("| Severity | Binary | Status | Entitlements |\n")
+		buf.WriteString("| :------- | :----- | :----- | :----------- |\n")
+		for _, d := range interesting {
+			buf.WriteString(fmt.Sprintf("| %s%s | `%s` | %s | `%s` |\n",
+				severityIcon(d), d.Severity, d.Path, d.Status, strings.Join(d.Interesting, "`, `")))
+		}
+		buf.WriteString("\n")
+		return
+	}
+	buf.WriteString(color.New(color.Bold).Sprintf("Interesting (%d)\n\n", len(interesting)))
+	for _, d := range interesting {
+		sev := color.New(color.FgYellow).Sprint(d.Severity)
+		if d.Severity == SeverityHigh {
+			sev = color.New(color.FgRed, color.Bold).Sprint(d.Severity)
+		}
+		buf.WriteString(fmt.Sprintf("  [%s] %s (%s): %s\n", sev, d.Path, d.Status, strings.Join(d.Interesting, ", ")))
+	}
+}
+
 func scanEnts(ipswPath, dmgPath, dmgType, pemDbPath string) (map[string]string, error) {
 	// check if filesystem DMG already exists (due to previous mount command)
 	if _, err := os.Stat(dmgPath); os.IsNotExist(err) {
@@ -0,0 +1,149 @@
+package extract
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/dustin/go-humanize"
+)
+
+// dmgMirrors are the folders (relative to the device's root) the DMGs are mounted at by BuildManifest component
+var dmgMirrors = map[string]string{
+	"OS":                "",
+	"Cryptex1,SystemOS": "System/Cryptexes/OS",
+	"Cryptex1,AppOS":    "System/Cryptexes/App",
+	"Ap,ExclaveOS":      "System/ExclaveKit",
+}
+
+type dmgTarget struct {
+	Path      string // path in the IPSW
+	Component string // BuildManifest component (empty if not in the manifest i.e. a DDI)
+	Mirror    string // folder to extract the DMG's files to
+}
+
+func isDMG(name string) bool {
+	return strings.HasSuffix(name, ".dmg") || strings.HasSuffix(name, ".dmg.aea")
+}
+
+// listDMGs returns the filesystem DMGs in the IPSW (the root filesystem, the cryptexes and any other DMG)
+// with the folder their files are mirrored to
+func listDMGs(i *info.Info, files []*zip.File) []dmgTarget {
+	var dmgs []dmgTarget
+	seen := make(map[string]bool)
+	if i != nil && i.Plists != nil && i.Plists.BuildManifest != nil {
+		for _, bi := range i.Plists.BuildIdentities {
+			for _, comp := range slices.Sorted(maps.Keys(bi.Manifest)) {
+				path, _ := bi.Manifest[comp].Info["Path"].(string)
+				if !isDMG(path) || seen[path] {
+					continue
+				}
+				if comp == "OS" && strings.Contains(bi.Info.Variant, "Recovery") {
+					continue
+				}
+				seen[path] = true
+				if strings.Contains(comp, "RamDisk") {
+					// ramdisks are IM4P payloads (not mountable as is)
+					log.Debugf("skipping %s %s (use `ipsw img4 im4p extract` on it first)", comp, path)
+					continue
+				}
+				mirror, ok := dmgMirrors[comp]
+				if !ok {
+					mirror = filepath.Join(strings.ReplaceAll(comp, ",", "_"), strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+				}
+				dmgs = append(dmgs, dmgTarget{Path: path, Component: comp, Mirror: mirror})
+			}
+		}
+	}
+	for _, f := range files {
+		if !isDMG(f.Name) || seen[f.Name] || strings.HasPrefix(filepath.Base(f.Name), "._") {
+			continue
+		}
+		seen[f.Name] = true
+		dmgs = append(dmgs, dmgTarget{
+			Path:   f.Name,
+			Mirror: strings.TrimSuffix(strings.TrimSuffix(f.Name, ".aea"), ".dmg"),
+		})
+	}
+	return dmgs
+}
+
+// downloadDMG extracts a DMG from a (remote) zip into a temporary folder
+func downloadDMG(files []*zip.File, name, tmpDir string) (string, error) {
+	for _, f := range files {
+		if f.Name != name {
+			continue
+		}
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Downloading %s (%s)", name, humanize.Bytes(f.UncompressedSize64)))
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s in zip: %v", name, err)
+		}
+		defer rc.Close()
+		fname := filepath.Join(tmpDir, filepath.Base(name))
+		out, err := os.Create(fname)
+		if err != nil {
+			return "", fmt.Errorf("failed to create %s: %v", fname, err)
+		}
+		defer out.Close()
+		if _, err := io.Copy(out, rc); err != nil {
+			return "", fmt.Errorf("failed to download %s: %v", name, err)
+		}
+		return fname, nil
+	}
+	return "", fmt.Errorf("failed to find %s in zip", name)
+}
+
+// searchDMGs extracts the files matching the pattern from every DMG in the IPSW into a mirror
+// of the device's directory tree (i.e. SystemOS cryptex files are extracted to System/Cryptexes/OS)
+func searchDMGs(c *Config, i *info.Info, files []*zip.File, re *regexp.Regexp, destPath string, remote bool) ([]string, error) {
+	var artifacts []string
+
+	var tmpDir string
+	if remote {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "ipsw_extract_dmgs")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+	}
+
+	for _, dmg := range listDMGs(i, files) {
+		name := dmg.Component
+		if len(name) == 0 {
+			name = "DMG"
+		}
+		log.Infof("Searching %s %s", name, dmg.Path)
+		dmgPath := filepath.Base(dmg.Path)
+		if remote {
+			var err error
+			if dmgPath, err = downloadDMG(files, dmg.Path, tmpDir); err != nil {
+				return nil, err
+			}
+		}
+		out, err := utils.ExtractFromDMG(c.IPSW, dmgPath, filepath.Join(destPath, dmg.Mirror), c.PemDB, re)
+		if remote {
+			os.Remove(dmgPath)
+		}
+		if err != nil {
+			if _, core := dmgMirrors[dmg.Component]; core {
+				return nil, fmt.Errorf("failed to extract files from %s %s: %v", name, dmg.Path, err)
+			}
+			log.WithError(err).Warnf("failed to extract files from %s %s", name, dmg.Path)
+			continue
+		}
+		artifacts = append(artifacts, out...)
+	}
+
+	return artifacts, nil
+}
@@ -539,6 +539,88 @@ func Keybags(c *Config) (fname string, err error) {
 	return
 }
 
+// KeybagMap extracts the production/development keybags of every IM4P in an IPSW or OTA as a JSON map
+// of their paths to their keybags (the format used by decryption key databases)
+func KeybagMap(c *Config) (fname string, err error) {
+	var folder string
+	var names []string
+	var open func(name string) (io.ReadCloser, error)
+
+	switch {
+	case len(c.IPSW) > 0 && fwcmd.IsOTA(filepath.Clean(c.IPSW)):
+		o, err := ota.Open(filepath.Clean(c.IPSW), c.AEAKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to open OTA: %v", err)
+		}
+		defer o.Close()
+		i, err := o.Info()
+		if err != nil {
+			return "", fmt.Errorf("failed to get OTA info: %v", err)
+		}
+		if folder, err = i.GetFolder(); err != nil {
+			return "", fmt.Errorf("failed to get folder from OTA metadata: %v", err)
+		}
+		for _, f := range o.Files() {
+			if !f.IsDir() {
+				names = append(names, f.Path())
+			}
+		}
+		open = func(name string) (io.ReadCloser, error) { return o.Open(name, false) }
+	case len(c.IPSW) > 0 || len(c.URL) > 0:
+		var zr *zip.Reader
+		if len(c.IPSW) > 0 {
+			if _, folder, err = getFolder(c); err != nil {
+				return "", err
+			}
+			zrc, err := zip.OpenReader(filepath.Clean(c.IPSW))
+			if err != nil {
+				return "", fmt.Errorf("failed to open IPSW: %v", err)
+			}
+			defer zrc.Close()
+			zr = &zrc.Reader
+		} else {
+			if !isURL(c.URL) {
+				return "", fmt.Errorf("invalid URL provided: %s", c.URL)
+			}
+			if _, zr, folder, err = getRemoteFolder(c); err != nil {
+				return "", err
+			}
+		}
+		files := make(map[string]*zip.File, len(zr.File))
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+			files[f.Name] = f
+		}
+		open = func(name string) (io.ReadCloser, error) { return files[name].Open() }
+	default:
+		return "", fmt.Errorf("no IPSW, OTA or URL provided")
+	}
+
+	kbags, err := img4.ParseKeybagMap(names, c.Pattern, open)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse im4p kbags: %v", err)
+	}
+
+	out, err := json.MarshalIndent(kbags, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal im4p kbags: %v", err)
+	}
+
+	if c.JSON {
+		return string(out), nil
+	}
+
+	fname = filepath.Join(filepath.Clean(c.Output), folder, "keybags.json")
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %v", filepath.Dir(fname), err)
+	}
+	if err := os.WriteFile(fname, out, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", fname, err)
+	}
+
+	return fname, nil
+}
+
 // FcsKeys extracts the AEA1 DMG fsc-keys from an IPSW
 func FcsKeys(c *Config) ([]string, error) {
 	if len(c.IPSW) == 0 && len(c.URL) == 0 {
@@ -691,56 +773,63 @@ func Search(c *Config) ([]string, error) {
 		}
 		artifacts = append(artifacts, out...)
 		if c.DMGs { // SEARCH THE DMGs
-			if appOS, err := i.GetAppOsDmg(); err == nil {
-				out, err := utils.ExtractFromDMG(c.IPSW, appOS, destPath, c.PemDB, re)
-				if err != nil {
-					return nil, fmt.Errorf("failed to extract files from AppOS %s: %v", appOS, err)
-				}
-				artifacts = append(artifacts, out...)
-			}
-			if systemOS, err := i.GetSystemOsDmg(); err == nil {
-				out, err := utils.ExtractFromDMG(c.IPSW, systemOS, destPath, c.PemDB, re)
-				if err != nil {
-					return nil, fmt.Errorf("failed to extract files from SystemOS %s: %v", systemOS, err)
-				}
-				artifacts = append(artifacts, out...)
-			}
-			if fsOS, err := i.GetFileSystemOsDmg(); err == nil {
-				out, err := utils.ExtractFromDMG(c.IPSW, fsOS, destPath, c.PemDB, re)
-				if err != nil {
-					return nil, fmt.Errorf("failed to extract files from filesystem %s: %v", fsOS, err)
-				}
-				artifacts = append(artifacts, out...)
-			}
-			if excOS, err := i.GetExclaveOSDmg(); err == nil {
-				out, err := utils.ExtractFromDMG(c.IPSW, excOS, destPath, c.PemDB, re)
-				if err != nil {
-					return nil, fmt.Errorf("failed to extract files from ExclaveOS %s: %v", excOS, err)
-				}
-				artifacts = append(artifacts, out...)
+			out, err := searchDMGs(c, i, zr.File, re, destPath, false)
+			if err != nil {
+				return nil, err
 			}
+			artifacts = append(artifacts, out...)
 		}
 		return artifacts, nil
 	} else if len(c.URL) > 0 {
 		if !isURL(c.URL) {
 			return nil, fmt.Errorf("invalid URL provided: %s", c.URL)
 		}
-		if c.DMGs { // SEARCH THE DMGs
-			return nil, fmt.Errorf("searching DMGs in remote IPSW is not supported")
-		}
-		_, zr, folder, err := getRemoteFolder(c)
+		i, zr, folder, err := getRemoteFolder(c)
 		if err != nil {
 			return nil, err
 		}
-		artifacts, err = utils.SearchZip(zr.File, re, filepath.Join(filepath.Clean(c.Output), folder), c.Flatten, true)
-		if err != nil {
+		destPath := filepath.Join(filepath.Clean(c.Output), folder)
+		artifacts, err = utils.SearchZip(zr.File, re, destPath, c.Flatten, true)
+		if err != nil && !c.DMGs {
 			return nil, fmt.Errorf("failed to extract files matching pattern '%s' in remote IPSW: %v", c.Pattern, err)
 		}
+		if c.DMGs { // SEARCH THE DMGs (downloads them)
+			out, err := searchDMGs(c, i, zr.File, re, destPath, true)
+			if err != nil {
+				return nil, err
+			}
+			artifacts = append(artifacts, out...)
+		}
 		return artifacts, nil
 	}
 	return nil, fmt.Errorf("no IPSW or URL provided")
 }
 
+// extractFromDMGs extracts the files matching the pattern from the filesystem DMG and falls back
+// to the SystemOS and AppOS cryptexes when they have moved there; it returns the DMG they were found in
+func extractFromDMGs(i *info.Info, ipswPath, pemDB string, re *regexp.Regexp) ([]string, string, error) {
+	var searched []string
+	for _, getDMG := range []func() (string, error){i.GetFileSystemOsDmg, i.GetSystemOsDmg, i.GetAppOsDmg} {
+		dmgPath, err := getDMG()
+		if err != nil {
+			if errors.Is(err, info.ErrorCryptexNotFound) {
+				continue
+			}
+			return nil, "", err
+		}
+		searched = append(searched, dmgPath)
+		extracted, err := utils.ExtractFromDMG(ipswPath, dmgPath, os.TempDir(), pemDB, re)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to search %s: %v", dmgPath, err)
+		}
+		if len(extracted) > 0 {
+			return extracted, dmgPath, nil
+		}
+		log.Debugf("no files matching '%s' in %s", re, dmgPath)
+	}
+	return nil, "", fmt.Errorf("no files matching '%s' found in %s", re, strings.Join(searched, ", "))
+}
+
 // LaunchdConfig extracts launchd config from an IPSW
 func LaunchdConfig(path, pemDB string) (string, error) {
 	ipswPath := filepath.Clean(path)
@@ -749,19 +838,13 @@ func LaunchdConfig(path, pemDB string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to parse IPSW: %v", err)
 	}
-	fsDMG, err := i.GetFileSystemOsDmg()
+	extracted, dmgPath, err := extractFromDMGs(i, ipswPath, pemDB, regexp.MustCompile(`.*/sbin/launchd$`))
 	if err != nil {
-		return "", fmt.Errorf("failed to get filesystem DMG path: %v", err)
-	}
-	extracted, err := utils.ExtractFromDMG(ipswPath, fsDMG, os.TempDir(), pemDB, regexp.MustCompile(`.*/sbin/launchd$`))
-	if err != nil {
-		return "", fmt.Errorf("failed to extract launchd from %s: %v", fsDMG, err)
+		return "", fmt.Errorf("failed to extract launchd: %v", err)
 	}
 
-	if len(extracted) == 0 {
-		return "", fmt.Errorf("failed to extract launchd from %s: no files extracted", fsDMG)
-	} else if len(extracted) > 1 {
-		return "", fmt.Errorf("failed to extract launchd from %s: too many files extracted", fsDMG)
+	if len(extracted) > 1 {
+		return "", fmt.Errorf("failed to extract launchd from %s: too many files extracted", dmgPath)
 	}
 	defer os.Remove(filepath.Clean(extracted[0]))
 
@@ -796,20 +879,13 @@ func SystemVersion(path, pemDB string) (*plist.SystemVersion, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse IPSW: %v", err)
 	}
-	fsDMG, err := i.GetFileSystemOsDmg()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get filesystem DMG path: %v", err)
-	}
-
-	extracted, err := utils.ExtractFromDMG(ipswPath, fsDMG, os.TempDir(), pemDB, regexp.MustCompile(`System/Library/CoreServices/SystemVersion.plist$`))
+	extracted, dmgPath, err := extractFromDMGs(i, ipswPath, pemDB, regexp.MustCompile(`System/Library/CoreServices/SystemVersion.plist$`))
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract launchd from %s: %v", fsDMG, err)
+		return nil, fmt.Errorf("failed to extract SystemVersion.plist: %v", err)
 	}
 
-	if len(extracted) == 0 {
-		return nil, fmt.Errorf("failed to extract SystemVersion.plist from %s: no files extracted", fsDMG)
-	} else if len(extracted) > 1 {
-		return nil, fmt.Errorf("failed to extract SystemVersion.plist from %s: too many files extracted", fsDMG)
+	if len(extracted) > 1 {
+		return nil, fmt.Errorf("failed to extract SystemVersion.plist from %s: too many files extracted", dmgPath)
 	}
 	defer os.Remove(filepath.Clean(extracted[0]))
 
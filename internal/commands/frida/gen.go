@@ -0,0 +1,369 @@
+// Package frida generates Frida scripts hooking the ObjC methods and functions selected from a MachO's static analysis
+package frida
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// Method is an ObjC method to hook
+type Method struct {
+	Kind   string   // "+" (class) or "-" (instance) method
+	Class  string   // the class (or the category's class) name
+	Sel    string   // the selector
+	Return string   // the return value's kind (see argKind)
+	Args   []string // the arguments' kinds (see argKind)
+}
+
+// Function is a function to hook (at Offset from the module's base)
+type Function struct {
+	Name   string
+	Offset uint64
+}
+
+// GenConfig is the configuration for generating a Frida script
+type GenConfig struct {
+	Class     string   // regex of the classes to hook
+	Selector  string   // regex of the selectors to hook
+	Symbols   []string // regexes of the symbols to hook
+	Backtrace bool     // log a backtrace on each hook
+}
+
+// Script is a Frida script hooking a module's ObjC methods and functions
+type Script struct {
+	Module    string
+	Methods   []Method
+	Functions []Function
+	Backtrace bool
+}
+
+// Generate returns the Frida script hooking the methods and functions of m (plus extra symbols like the DSC's local symbols)
+// matching the config's selection
+func Generate(module string, m *macho.File, symbols map[uint64]string, conf *GenConfig) (*Script, error) {
+	if len(conf.Class) == 0 && len(conf.Selector) == 0 && len(conf.Symbols) == 0 {
+		return nil, fmt.Errorf("must select ObjC classes/selectors or symbols to hook")
+	}
+
+	s := &Script{
+		Module:    module,
+		Backtrace: conf.Backtrace,
+	}
+
+	if len(conf.Class) > 0 || len(conf.Selector) > 0 {
+		classRE, err := regexp.Compile(conf.Class)
+		if err != nil {
+			return nil, fmt.Errorf("invalid class regex '%s': %v", conf.Class, err)
+		}
+		selRE, err := regexp.Compile(conf.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector regex '%s': %v", conf.Selector, err)
+		}
+		if !m.HasObjC() {
+			return nil, fmt.Errorf("%s does not contain ObjC classes", module)
+		}
+		classes, err := m.GetObjCClasses()
+		if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return nil, fmt.Errorf("failed to parse ObjC classes: %v", err)
+		}
+		for _, c := range classes {
+			if classRE.MatchString(c.Name) {
+				s.addMethods(c.Name, "+", c.ClassMethods, selRE)
+				s.addMethods(c.Name, "-", c.InstanceMethods, selRE)
+			}
+		}
+		cats, err := m.GetObjCCategories()
+		if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			log.WithError(err).Warn("failed to parse ObjC categories")
+		}
+		for _, cat := range cats {
+			if cat.Class == nil || len(cat.Class.Name) == 0 {
+				log.Debugf("skipping category %s (its class is unknown)", cat.Name)
+				continue
+			}
+			if classRE.MatchString(cat.Class.Name) {
+				s.addMethods(cat.Class.Name, "+", cat.ClassMethods, selRE)
+				s.addMethods(cat.Class.Name, "-", cat.InstanceMethods, selRE)
+			}
+		}
+	}
+
+	if len(conf.Symbols) > 0 {
+		var symREs []*regexp.Regexp
+		for _, pattern := range conf.Symbols {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid symbol regex '%s': %v", pattern, err)
+			}
+			symREs = append(symREs, re)
+		}
+		text := m.Segment("__TEXT")
+		if text == nil {
+			return nil, fmt.Errorf("%s has no __TEXT segment", module)
+		}
+		syms := make(map[uint64]string)
+		if m.Symtab != nil {
+			for _, sym := range m.Symtab.Syms {
+				if sym.Value != 0 && len(sym.Name) > 0 && sym.Name != "<redacted>" && !sym.Type.IsDebugSym() {
+					syms[sym.Value] = sym.Name
+				}
+			}
+		}
+		for addr, name := range symbols {
+			syms[addr] = name
+		}
+		for addr, name := range syms {
+			if !slices.ContainsFunc(symREs, func(re *regexp.Regexp) bool { return re.MatchString(name) }) {
+				continue
+			}
+			if sec := m.FindSectionForVMAddr(addr); sec == nil || !(sec.Flags.IsPureInstructions() || sec.Flags.IsSomeInstructions()) {
+				continue // not a function
+			}
+			s.Functions = append(s.Functions, Function{Name: name, Offset: addr - text.Addr})
+		}
+		slices.SortFunc(s.Functions, func(a, b Function) int { return cmp.Compare(a.Offset, b.Offset) })
+	}
+
+	if len(s.Methods) == 0 && len(s.Functions) == 0 {
+		return nil, fmt.Errorf("no ObjC methods or functions in %s matched the selection", module)
+	}
+
+	return s, nil
+}
+
+func (s *Script) addMethods(class, kind string, methods []objc.Method, selRE *regexp.Regexp) {
+	for _, m := range methods {
+		if !selRE.MatchString(m.Name) {
+			continue
+		}
+		types := methodTypes(m.Types)
+		if len(types) < 3 {
+			log.Debugf("skipping %s[%s %s] (invalid types '%s')", kind, class, m.Name, m.Types)
+			continue
+		}
+		meth := Method{
+			Kind:   kind,
+			Class:  class,
+			Sel:    m.Name,
+			Return: argKind(types[0]),
+		}
+		for _, typ := range types[3:] { // skip the return type, self and _cmd
+			meth.Args = append(meth.Args, argKind(typ))
+		}
+		s.Methods = append(s.Methods, meth)
+	}
+}
+
+// methodTypes splits a method's type encoding into its return and arguments' types (dropping the stack offsets)
+func methodTypes(types string) []string {
+	var out []string
+	typ, rest, ok := objc.CutType(types)
+	for ok {
+		out = append(out, typ)
+		typ, rest, ok = objc.CutType(strings.TrimLeft(rest, "0123456789"))
+	}
+	return out
+}
+
+// argKind returns how the script logs a value with the type encoding typ:
+//
+//	id, sel, bool, int, long, uint, str or ptr (read from the integer registers/stack)
+//	float (read from the FP registers so not available) or struct (by value so the following arguments are unknown)
+func argKind(typ string) string {
+	typ = strings.TrimLeft(typ, "rnNoORVAj|+") // qualifiers
+	if len(typ) == 0 {
+		return "ptr"
+	}
+	switch typ[0] {
+	case '@', '#':
+		return "id"
+	case ':':
+		return "sel"
+	case 'B':
+		return "bool"
+	case 'c', 'i', 's':
+		return "int"
+	case 'l', 'q':
+		return "long"
+	case 'C', 'I', 'S', 'L', 'Q':
+		return "uint"
+	case '*':
+		return "str"
+	case 'f', 'd', 'D':
+		return "float"
+	case '{', '(', '[', '!':
+		return "struct"
+	case 'v':
+		return "void"
+	}
+	return "ptr"
+}
+
+const scriptTemplate = `// Frida script generated by ipsw hooking the ObjC methods and functions selected from:
+//   {{ .Module }}
+// Run it with: frida -U -n PROCESS -l SCRIPT
+
+const MODULE = {{ quote .Module }};
+const BACKTRACE = {{ .Backtrace }};
+const MAX_FUNC_ARGS = 4;
+
+// [kind, class, selector, return kind, argument kinds]
+const METHODS = [
+{{- range $m := .Methods }}
+  [{{ quote $m.Kind }}, {{ quote $m.Class }}, {{ quote $m.Sel }}, {{ quote $m.Return }}, [{{ range $i, $a := $m.Args }}{{ if $i }}, {{ end }}{{ quote $a }}{{ end }}]],
+{{- end }}
+];
+
+// [name, offset from the module's base]
+const FUNCTIONS = [
+{{- range $f := .Functions }}
+  [{{ quote $f.Name }}, {{ hex $f.Offset }}],
+{{- end }}
+];
+
+const SIGN_BIT = ptr("0x8000000000000000");
+
+function format(kind, value) {
+  try {
+    switch (kind) {
+      case "id":
+        return value.isNull() ? "nil" : new ObjC.Object(value).toString();
+      case "sel":
+        return value.isNull() ? "NULL" : ObjC.selectorAsString(value);
+      case "bool":
+        return (value.toInt32() & 0xff) !== 0 ? "YES" : "NO";
+      case "int":
+        return value.toInt32().toString();
+      case "long":
+        return value.compare(SIGN_BIT) >= 0 ? "-" + ptr(0).sub(value).toString(10) : value.toString(10);
+      case "uint":
+        return value.toString(10);
+      case "str":
+        return value.isNull() ? "NULL" : JSON.stringify(value.readUtf8String());
+      default:
+        return value.toString();
+    }
+  } catch (e) {
+    return value.toString();
+  }
+}
+
+function formatArgs(sel, kinds, args) {
+  if (kinds.length === 0) {
+    return sel;
+  }
+  const parts = sel.split(":");
+  const out = [];
+  let reg = 2; // skip self and _cmd
+  let known = true;
+  kinds.forEach((kind, i) => {
+    let value = "?";
+    if (kind === "struct") {
+      known = false; // passed by value so we lose track of the following arguments
+    } else if (known && kind !== "float") {
+      value = format(kind, args[reg++]);
+    }
+    out.push(parts[i] + ":" + value);
+  });
+  return out.join(" ");
+}
+
+function backtrace(context) {
+  return Thread.backtrace(context, Backtracer.ACCURATE).map(DebugSymbol.fromAddress).join("\n\t");
+}
+
+function hookMethods() {
+  if (METHODS.length === 0) {
+    return 0;
+  }
+  if (!ObjC.available) {
+    console.log("[!] the ObjC runtime is not available");
+    return 0;
+  }
+  let hooked = 0;
+  for (const [kind, cls, sel, ret, kinds] of METHODS) {
+    const name = kind + "[" + cls + " " + sel + "]";
+    const klass = ObjC.classes[cls];
+    if (klass === undefined) {
+      console.log("[!] class " + cls + " not found");
+      continue;
+    }
+    const method = klass[kind + " " + sel];
+    if (method === undefined) {
+      console.log("[!] method " + name + " not found");
+      continue;
+    }
+    Interceptor.attach(method.implementation, {
+      onEnter(args) {
+        const self = kind === "+" ? cls : format("id", args[0]);
+        console.log(kind + "[" + self + " " + formatArgs(sel, kinds, args) + "]");
+        if (BACKTRACE) {
+          console.log("\t" + backtrace(this.context));
+        }
+      },
+      onLeave(retval) {
+        if (ret !== "void") {
+          console.log(name + " => " + (ret === "float" || ret === "struct" ? "?" : format(ret, retval)));
+        }
+      },
+    });
+    hooked++;
+  }
+  return hooked;
+}
+
+function hookFunctions() {
+  if (FUNCTIONS.length === 0) {
+    return 0;
+  }
+  const mod = Process.findModuleByName(MODULE);
+  if (mod === null) {
+    console.log("[!] module " + MODULE + " not loaded");
+    return 0;
+  }
+  for (const [name, offset] of FUNCTIONS) {
+    Interceptor.attach(mod.base.add(offset), {
+      onEnter(args) {
+        const values = [];
+        for (let i = 0; i < MAX_FUNC_ARGS; i++) {
+          values.push(args[i].toString());
+        }
+        console.log(name + "(" + values.join(", ") + ")");
+        if (BACKTRACE) {
+          console.log("\t" + backtrace(this.context));
+        }
+      },
+      onLeave(retval) {
+        console.log(name + " => " + retval);
+      },
+    });
+  }
+  return FUNCTIONS.length;
+}
+
+console.log("[ipsw] hooked " + hookMethods() + " methods and " + hookFunctions() + " functions of " + MODULE);
+`
+
+// Write writes the Frida script
+func (s *Script) Write(w io.Writer) error {
+	t := template.Must(template.New("frida").Funcs(template.FuncMap{
+		"hex":   func(v uint64) string { return fmt.Sprintf("%#x", v) },
+		"quote": strconv.QuoteToASCII, // valid JS string literals
+	}).Parse(scriptTemplate))
+
+	if err := t.Execute(w, s); err != nil {
+		return fmt.Errorf("failed to generate Frida script: %v", err)
+	}
+
+	return nil
+}
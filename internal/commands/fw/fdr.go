@@ -0,0 +1,188 @@
+package fw
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/plist"
+)
+
+// FDRTrustObjectKeys are the BuildManifest keys of the FDR (Factory Data Restore) trust objects
+var FDRTrustObjectKeys = []string{
+	"ftap", // FDR Trust Object (AP)
+	"ftsp", // FDR Trust Object (SEP)
+	"rfta", // Restore FDR Trust Object (AP)
+	"rfts", // Restore FDR Trust Object (SEP)
+}
+
+// Certificate is the summary of an X.509 certificate found in a firmware payload
+type Certificate struct {
+	Offset             int       `json:"offset"`
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serial_number"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	PublicKeyAlgorithm string    `json:"public_key_algorithm"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	SHA256             string    `json:"sha256"`
+}
+
+func (c Certificate) String() string {
+	ca := ""
+	if c.IsCA {
+		ca = " (CA)"
+	}
+	return fmt.Sprintf(
+		"Subject:   %s%s\n"+
+			"Issuer:    %s\n"+
+			"Serial:    %s\n"+
+			"Validity:  %s -> %s\n"+
+			"Key:       %s (%s)\n"+
+			"SHA256:    %s",
+		c.Subject, ca,
+		c.Issuer,
+		c.SerialNumber,
+		c.NotBefore.Format(time.DateOnly), c.NotAfter.Format(time.DateOnly),
+		c.PublicKeyAlgorithm, c.SignatureAlgorithm,
+		c.SHA256,
+	)
+}
+
+// FindCertificates scans a blob for DER encoded X.509 certificates
+func FindCertificates(dat []byte) []Certificate {
+	var certs []Certificate
+
+	for off := 0; off+4 < len(dat); {
+		// all certs we care about are > 255 bytes so they use the 2 byte long-form length (0x30 0x82 LL LL)
+		idx := bytes.Index(dat[off:], []byte{0x30, 0x82})
+		if idx < 0 {
+			break
+		}
+		off += idx
+		if off+4 > len(dat) {
+			break
+		}
+		size := int(dat[off+2])<<8 | int(dat[off+3]) + 4
+		if off+size > len(dat) {
+			off++
+			continue
+		}
+		cert, err := x509.ParseCertificate(dat[off : off+size])
+		if err != nil {
+			off++
+			continue
+		}
+		sum := sha256.Sum256(cert.Raw)
+		certs = append(certs, Certificate{
+			Offset:             off,
+			Subject:            cert.Subject.String(),
+			Issuer:             cert.Issuer.String(),
+			SerialNumber:       cert.SerialNumber.Text(16),
+			NotBefore:          cert.NotBefore,
+			NotAfter:           cert.NotAfter,
+			PublicKeyAlgorithm: cert.PublicKeyAlgorithm.String(),
+			SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+			IsCA:               cert.IsCA,
+			SHA256:             hex.EncodeToString(sum[:]),
+		})
+		off += size
+	}
+
+	return certs
+}
+
+// ManifestPaths returns the BuildManifest component paths (mapped to their manifest key) whose key matches
+func ManifestPaths(bm *plist.BuildManifest, match func(key string) bool) map[string]string {
+	paths := make(map[string]string)
+	for _, bID := range bm.BuildIdentities {
+		for key, m := range bID.Manifest {
+			if !match(key) {
+				continue
+			}
+			if path, ok := m.Info["Path"].(string); ok && len(path) > 0 {
+				paths[path] = key
+			}
+		}
+	}
+	return paths
+}
+
+// TrustObject is a parsed FDR trust object
+type TrustObject struct {
+	Path         string        `json:"path"`
+	Key          string        `json:"key,omitempty"`
+	Type         string        `json:"type,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Size         int           `json:"size"`
+	SHA256       string        `json:"sha256"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+}
+
+func (t TrustObject) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n", t.Path))
+	if len(t.Key) > 0 {
+		sb.WriteString(fmt.Sprintf("  Key:         %s\n", t.Key))
+	}
+	if len(t.Type) > 0 {
+		sb.WriteString(fmt.Sprintf("  Type:        %s\n", t.Type))
+	}
+	if len(t.Description) > 0 {
+		sb.WriteString(fmt.Sprintf("  Description: %s\n", t.Description))
+	}
+	sb.WriteString(fmt.Sprintf("  Size:        %d\n", t.Size))
+	sb.WriteString(fmt.Sprintf("  SHA256:      %s\n", t.SHA256))
+	if len(t.Certificates) > 0 {
+		sb.WriteString(fmt.Sprintf("  Certificates (%d):\n", len(t.Certificates)))
+		for _, c := range t.Certificates {
+			sb.WriteString("    " + strings.ReplaceAll(c.String(), "\n", "\n    ") + "\n\n")
+		}
+	}
+	return sb.String()
+}
+
+// unwrapIm4p returns the payload of an IM4P (or IMG4) along with its type and description
+func unwrapIm4p(dat []byte) ([]byte, string, string, error) {
+	if magic.IsImg4Data(dat) {
+		i, err := img4.ParseImg4(bytes.NewReader(dat))
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to parse IMG4: %v", err)
+		}
+		return i.IM4P.Data, i.IM4P.Type, i.IM4P.Description, nil
+	}
+	if magic.IsIm4pData(dat) {
+		i, err := img4.ParseIm4p(bytes.NewReader(dat))
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to parse IM4P: %v", err)
+		}
+		return i.Data, i.Type, i.Description, nil
+	}
+	return dat, "", "", nil
+}
+
+// ParseTrustObject parses an FDR trust object (raw or wrapped in an IM4P)
+func ParseTrustObject(path string, dat []byte) (*TrustObject, error) {
+	payload, typ, desc, err := unwrapIm4p(dat)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(payload)
+	return &TrustObject{
+		Path:         filepath.Base(path),
+		Type:         typ,
+		Description:  desc,
+		Size:         len(payload),
+		SHA256:       hex.EncodeToString(sum[:]),
+		Certificates: FindCertificates(payload),
+	}, nil
+}
@@ -1,101 +1,53 @@
 package fw
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/apex/log"
-	"github.com/blacktop/ipsw/internal/commands/img4"
+	"github.com/blacktop/ipsw/pkg/rtkit"
 )
 
-const RTKitMagic = "rkosftab"
-
-type RTKitHeader struct {
-	_        [32]byte
-	Magic    [8]byte // "rkosftab"
-	NumBlobs uint32
-	_        uint32
+// GpuFirmware is a parsed AGX GPU firmware file
+type GpuFirmware struct {
+	Path string `json:"path,omitempty"`
+	*rtkit.Firmware
 }
 
-type RTKitBlob struct {
-	Name   [4]byte
-	Offset uint32
-	Size   uint32
-	_      uint32
+func (g GpuFirmware) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("AGX Firmware: %s (%d blobs)\n", filepath.Base(g.Path), len(g.Images)))
+	for _, img := range g.Images {
+		kind := "raw"
+		if len(img.Firmware.MachOs) > 0 {
+			kind = "macho"
+		}
+		sb.WriteString(fmt.Sprintf("  %-4s  off=%#08x  size=%#08x  %-5s", img.Name, img.Offset, img.Size, kind))
+		if len(img.Firmware.Version) > 0 {
+			sb.WriteString("  " + img.Firmware.Version)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
 }
 
-func SplitGpuFW(in, folder string) ([]string, error) {
-	var out []string
-
-	dat, err := os.ReadFile(in)
+// ParseGpuFW parses an AGX GPU firmware file (raw or im4p wrapped)
+func ParseGpuFW(in string) (*GpuFirmware, error) {
+	rfw, err := ParseRTKit(in)
 	if err != nil {
 		return nil, err
 	}
-
-	r := bytes.NewReader(dat)
-
-	var hdr RTKitHeader
-	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
-		return nil, err
-	}
-
-	if string(hdr.Magic[:]) != RTKitMagic {
-		if !bytes.Contains(dat[:16], []byte("IM4P")) {
-			return nil, fmt.Errorf("invalid RTKit header magic: %s; input file might be an im4p (extract via `ipsw img4 extract` first)", string(hdr.Magic[:]))
-		}
-		tmpDir, err := os.MkdirTemp(os.TempDir(), "gpu")
-		if err != nil {
-			return nil, err
-		}
-		defer os.RemoveAll(tmpDir)
-		infile := filepath.Join(tmpDir, filepath.Clean(in)+".payload")
-		log.Warn("IM4P header detected, extracting payload")
-		if err := img4.ExtractPayload(filepath.Clean(in), infile, false); err != nil {
-			return nil, err
-		}
-		// reread the extracted file
-		dat, err = os.ReadFile(infile)
-		if err != nil {
-			return nil, err
-		}
-		r = bytes.NewReader(dat)
-		if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
-			return nil, err
-		}
+	if !rfw.IsTable() {
+		return nil, fmt.Errorf("invalid AGX firmware: missing RTKit '%s' table", rtkit.TableMagic)
 	}
+	return &GpuFirmware{Path: in, Firmware: rfw}, nil
+}
 
-	blobs := make([]RTKitBlob, hdr.NumBlobs)
-	if err := binary.Read(r, binary.LittleEndian, &blobs); err != nil {
+// SplitGpuFW writes the images of an AGX GPU firmware file (raw or im4p wrapped) to the folder
+func SplitGpuFW(in, folder string) ([]string, error) {
+	gfw, err := ParseGpuFW(in)
+	if err != nil {
 		return nil, err
 	}
-
-	for _, blob := range blobs {
-		r.Seek(int64(blob.Offset), 0)
-		buf := make([]byte, blob.Size)
-		if _, err := r.Read(buf); err != nil {
-			return nil, err
-		}
-
-		fname := string(blob.Name[:]) + ".bin"
-		if len(folder) > 0 {
-			if err := os.MkdirAll(folder, 0o750); err != nil {
-				return nil, err
-			}
-			fname = filepath.Join(folder, fname)
-		}
-		log.WithFields(log.Fields{
-			"name":   string(blob.Name[:]),
-			"size":   fmt.Sprintf("%#x", blob.Size),
-			"offset": fmt.Sprintf("%#x", blob.Offset),
-		}).Info("Extracting")
-		if err := os.WriteFile(fname, buf, 0o644); err != nil {
-			return nil, err
-		}
-		out = append(out, fname)
-	}
-
-	return out, nil
+	return writeRTKit(gfw.Firmware, in, folder)
 }
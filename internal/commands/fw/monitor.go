@@ -0,0 +1,172 @@
+package fw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+	"github.com/blacktop/ipsw/pkg/lzfse"
+	"github.com/blacktop/ipsw/pkg/signature"
+)
+
+// monitorVersionRE matches the build version strings of the monitors (i.e. SPTM-334.0.40.0.1 or TXM-119.0.30)
+var monitorVersionRE = regexp.MustCompile(`\b(?:SPTM|TXM|sptm|txm)-[0-9]+(?:\.[0-9]+)+`)
+
+// Monitor is an SPTM (Secure Page Table Monitor) or TXM (Trusted Execution Monitor) firmware
+type Monitor struct {
+	Name     string            `json:"name"` // SPTM or TXM
+	Version  string            `json:"version,omitempty"`
+	UUID     string            `json:"uuid,omitempty"`
+	Arch     string            `json:"arch"`
+	Entry    uint64            `json:"entry,omitempty"`
+	Segments []*MonitorSegment `json:"segments"`
+	Symbols  map[uint64]string `json:"symbols,omitempty"`
+
+	m *macho.File
+}
+
+// MonitorSegment is a segment of a monitor's MachO
+type MonitorSegment struct {
+	Name     string   `json:"name"`
+	Addr     uint64   `json:"addr"`
+	Size     uint64   `json:"size"`
+	Offset   uint64   `json:"offset"`
+	Prot     string   `json:"prot"`
+	Sections []string `json:"sections,omitempty"`
+}
+
+// IsMonitor returns true if the firmware file is an SPTM or TXM (i.e. Firmware/sptm.t8132.release.im4p)
+func IsMonitor(path string) bool {
+	name := strings.ToLower(path[strings.LastIndexAny(path, `/\`)+1:])
+	return strings.HasPrefix(name, "sptm.") || strings.HasPrefix(name, "txm.")
+}
+
+// MonitorPayload returns the decompressed MachO of an SPTM or TXM IM4P (or the data if it already is one)
+func MonitorPayload(data []byte) ([]byte, error) {
+	if _, _, err := img4.ReadIm4pHeader(bytes.NewReader(data)); err == nil {
+		im4p, err := img4.ParseIm4p(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse im4p: %v", err)
+		}
+		data = im4p.Data
+	}
+	switch img4.DetectCompression(data) {
+	case img4.CompressionLZFSE:
+		dec, err := lzfse.NewDecoder(data).DecodeBuffer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lzfse decompress payload: %v", err)
+		}
+		return dec, nil
+	case img4.CompressionLZSS:
+		dec, err := kernelcache.DecompressData(&kernelcache.CompressedCache{Magic: data[:4], Size: len(data), Data: data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to lzss decompress payload: %v", err)
+		}
+		return dec, nil
+	}
+	return data, nil
+}
+
+// ParseMonitor parses an SPTM or TXM IM4P or MachO
+func ParseMonitor(name string, data []byte) (*Monitor, error) {
+	dat, err := MonitorPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := macho.NewFile(bytes.NewReader(dat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s MachO: %v", name, err)
+	}
+
+	mon := &Monitor{
+		Name:    name,
+		Arch:    m.SubCPU.String(m.CPU),
+		Symbols: make(map[uint64]string),
+		m:       m,
+	}
+	if v := monitorVersionRE.Find(dat); v != nil {
+		mon.Version = string(v)
+	} else if sv := m.SourceVersion(); sv != nil && sv.Version > 0 {
+		mon.Version = sv.Version.String()
+	}
+	if uuid := m.UUID(); uuid != nil {
+		mon.UUID = uuid.String()
+	}
+	for _, seg := range m.Segments() {
+		s := &MonitorSegment{
+			Name:   seg.Name,
+			Addr:   seg.Addr,
+			Size:   seg.Memsz,
+			Offset: seg.Offset,
+			Prot:   seg.Prot.String(),
+		}
+		for _, sec := range m.Sections {
+			if sec.Seg == seg.Name {
+				s.Sections = append(s.Sections, sec.Name)
+			}
+		}
+		mon.Segments = append(mon.Segments, s)
+	}
+
+	// known entry points
+	mon.Entry = entryPoint(m)
+	if mon.Entry != 0 {
+		mon.Symbols[mon.Entry] = "_start"
+	}
+	if m.Symtab != nil {
+		for _, sym := range m.Symtab.Syms {
+			if sym.Value != 0 && len(sym.Name) > 0 && sym.Type.IsDefinedInSection() {
+				mon.Symbols[sym.Value] = sym.Name
+			}
+		}
+	}
+
+	return mon, nil
+}
+
+// entryPoint returns the pc of the MachO's LC_UNIXTHREAD (or its LC_MAIN entry)
+func entryPoint(m *macho.File) uint64 {
+	for _, l := range m.Loads {
+		switch cmd := l.(type) {
+		case *macho.UnixThread:
+			for _, thread := range cmd.Threads {
+				if types.ArmThreadFlavor(thread.Flavor) != types.ARM_THREAD_STATE64 {
+					continue
+				}
+				var regs macho.RegsARM64
+				if err := binary.Read(bytes.NewReader(thread.Data), binary.LittleEndian, &regs); err == nil {
+					return regs.PC
+				}
+			}
+		case *macho.EntryPoint:
+			if text := m.Segment("__TEXT"); text != nil {
+				return text.Addr + cmd.EntryOffset
+			}
+		}
+	}
+	return 0
+}
+
+// Symbolicate adds the symbols of the functions matching the signatures targeting the monitor (i.e. "sptm" or "txm")
+func (mon *Monitor) Symbolicate(sigs []signature.Symbolicator) error {
+	sm := signature.NewSymbolMap()
+	sm.Copy(mon.Symbols)
+	if err := sm.SymbolicateMachO(mon.m, strings.ToLower(mon.Name), sigs, true); err != nil {
+		return fmt.Errorf("failed to symbolicate %s: %v", mon.Name, err)
+	}
+	mon.Symbols = sm
+	return nil
+}
+
+// SortedSymbols returns the monitor's symbol addresses in order
+func (mon *Monitor) SortedSymbols() []uint64 {
+	return slices.Sorted(maps.Keys(mon.Symbols))
+}
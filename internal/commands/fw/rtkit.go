@@ -0,0 +1,116 @@
+package fw
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/lzfse"
+	"github.com/blacktop/ipsw/pkg/rtkit"
+)
+
+// ReadFirmware returns the payload of a raw or im4p wrapped firmware file
+func ReadFirmware(in string) ([]byte, error) {
+	if ok, _ := magic.IsIm4p(in); ok {
+		log.Debug("IM4P header detected, extracting payload")
+		im4p, err := img4.OpenIm4p(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse im4p: %v", err)
+		}
+		dat := im4p.Data
+		if bytes.HasPrefix(dat, []byte("bvx2")) {
+			dat, err = lzfse.NewDecoder(dat).DecodeBuffer()
+			if err != nil {
+				return nil, fmt.Errorf("failed to lzfse decompress %s: %v", in, err)
+			}
+		}
+		return dat, nil
+	}
+	return os.ReadFile(in)
+}
+
+// ParseRTKit parses an RTKit firmware file (raw or im4p wrapped)
+func ParseRTKit(in string) (*rtkit.Firmware, error) {
+	dat, err := ReadFirmware(in)
+	if err != nil {
+		return nil, err
+	}
+	if !rtkit.IsRTKit(dat) {
+		return nil, fmt.Errorf("%s does not look like RTKit firmware", filepath.Base(in))
+	}
+	return rtkit.Parse(dat)
+}
+
+// SplitRTKit writes the images of an RTKit firmware table (or the MachOs of a single RTKit image) to the folder
+func SplitRTKit(in, folder string) ([]string, error) {
+	rfw, err := ParseRTKit(in)
+	if err != nil {
+		return nil, err
+	}
+	return writeRTKit(rfw, in, folder)
+}
+
+// writeRTKit writes the images (or MachOs) of the parsed RTKit firmware file in to the folder
+func writeRTKit(rfw *rtkit.Firmware, in, folder string) ([]string, error) {
+	var out []string
+
+	if len(folder) > 0 {
+		if err := os.MkdirAll(folder, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create output folder: %v", err)
+		}
+	}
+
+	if rfw.IsTable() {
+		for _, img := range rfw.Images {
+			fname := filepath.Join(folder, img.Name+".bin")
+			log.WithFields(log.Fields{
+				"name":    img.Name,
+				"size":    fmt.Sprintf("%#x", img.Size),
+				"offset":  fmt.Sprintf("%#x", img.Offset),
+				"version": img.Firmware.Version,
+			}).Info("Extracting")
+			if err := os.WriteFile(fname, img.Firmware.Data(), 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %v", fname, err)
+			}
+			out = append(out, fname)
+		}
+		return out, nil
+	}
+
+	base := strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))
+	dat := rfw.Data()
+
+	if len(rfw.MachOs) == 0 {
+		fname := filepath.Join(folder, base+".bin")
+		if err := os.WriteFile(fname, dat, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", fname, err)
+		}
+		return append(out, fname), nil
+	}
+
+	for _, m := range rfw.MachOs {
+		fname := filepath.Join(folder, fmt.Sprintf("%s_%#x.macho", base, m.Offset))
+		end := m.Offset + m.Size
+		if m.Offset == 0 && len(rfw.MachOs) == 1 {
+			fname = filepath.Join(folder, base+".macho")
+			end = uint64(len(dat))
+		}
+		end = min(end, uint64(len(dat)))
+		log.WithFields(log.Fields{
+			"offset": fmt.Sprintf("%#x", m.Offset),
+			"size":   fmt.Sprintf("%#x", end-m.Offset),
+			"cpu":    m.CPU,
+		}).Info("Extracting MachO")
+		if err := os.WriteFile(fname, dat[m.Offset:end], 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", fname, err)
+		}
+		out = append(out, fname)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package fw
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SEManifestPrefix is the BuildManifest key prefix of the Secure Element firmware components
+const SEManifestPrefix = "SE,"
+
+// e.g. "JCOP 4.7 R1.01.4" or "JCOP5.2 R2.03.1"
+var seVersionRE = regexp.MustCompile(`JCOP ?[0-9]+(?:\.[0-9]+)*(?: R[0-9]+(?:\.[0-9]+)*)?`)
+
+// SEFirmware is a parsed Secure Element firmware payload
+type SEFirmware struct {
+	Path         string        `json:"path"`
+	Key          string        `json:"key,omitempty"`
+	Type         string        `json:"type,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Version      string        `json:"version,omitempty"`
+	Size         int           `json:"size"`
+	SHA256       string        `json:"sha256"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+}
+
+func (s SEFirmware) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n", s.Path))
+	if len(s.Key) > 0 {
+		sb.WriteString(fmt.Sprintf("  Key:         %s\n", s.Key))
+	}
+	if len(s.Type) > 0 {
+		sb.WriteString(fmt.Sprintf("  Type:        %s\n", s.Type))
+	}
+	if len(s.Description) > 0 {
+		sb.WriteString(fmt.Sprintf("  Description: %s\n", s.Description))
+	}
+	if len(s.Version) > 0 {
+		sb.WriteString(fmt.Sprintf("  Version:     %s\n", s.Version))
+	}
+	sb.WriteString(fmt.Sprintf("  Size:        %d\n", s.Size))
+	sb.WriteString(fmt.Sprintf("  SHA256:      %s\n", s.SHA256))
+	if len(s.Certificates) > 0 {
+		sb.WriteString(fmt.Sprintf("  Certificates (%d):\n", len(s.Certificates)))
+		for _, c := range s.Certificates {
+			sb.WriteString("    " + strings.ReplaceAll(c.String(), "\n", "\n    ") + "\n\n")
+		}
+	}
+	return sb.String()
+}
+
+// ParseSEFirmware parses a Secure Element firmware payload (raw or wrapped in an IM4P)
+func ParseSEFirmware(path string, dat []byte) (*SEFirmware, error) {
+	payload, typ, desc, err := unwrapIm4p(dat)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(payload)
+	se := &SEFirmware{
+		Path:         filepath.Base(path),
+		Type:         typ,
+		Description:  desc,
+		Size:         len(payload),
+		SHA256:       hex.EncodeToString(sum[:]),
+		Certificates: FindCertificates(payload),
+	}
+	if v := seVersionRE.Find(payload); v != nil {
+		se.Version = string(v)
+	}
+	return se, nil
+}
@@ -0,0 +1,232 @@
+package fw
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/ota"
+)
+
+// WirelessType is the type of wireless firmware
+type WirelessType string
+
+const (
+	WiFi      WirelessType = "wifi"
+	Bluetooth WirelessType = "bluetooth"
+)
+
+var (
+	wifiPathRE = regexp.MustCompile(`usr/share/firmware/wifi/.*\.(bin|trx|clmb|txcb|txt|sig)$`)
+	btPathRE   = regexp.MustCompile(`usr/share/firmware/bluetooth/.*\.(bin|hcd|ptb|dfu)$`)
+	// e.g. C-4387__s-C1 or C-4378__s-B1
+	chipDirRE = regexp.MustCompile(`C-([0-9a-zA-Z]+)__s-([A-Z][0-9])`)
+	// e.g. BCM4387C2_19.3.395.4422_PCIE_macOS_MAUI.bin
+	btNameRE = regexp.MustCompile(`^(BCM|APL)?([0-9]{4})([A-Z][0-9])?[_-]([0-9]+\.[0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+	// the version in a firmware file name (normalized out when diffing)
+	fileVersionRE = regexp.MustCompile(`[0-9]+(?:\.[0-9]+){2,}`)
+	// e.g. "18.20.439.3.7.8.124 (wlan=r1044753) FWID 01-ad3bde1a"
+	wifiVersionRE = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+(?:\.[0-9]+)*\s+\([^)]*\)(?:\s+(?:CRC:\s+[0-9a-f]+|FWID\s+[0-9a-f-]+))?`)
+)
+
+// Pattern returns the regex matching the firmware files of the wireless type
+func (t WirelessType) Pattern() *regexp.Regexp {
+	if t == Bluetooth {
+		return btPathRE
+	}
+	return wifiPathRE
+}
+
+// WirelessFirmware is a single wireless firmware blob
+type WirelessFirmware struct {
+	Path     string       `json:"path"`
+	Type     WirelessType `json:"type"`
+	Chip     string       `json:"chip,omitempty"`
+	Revision string       `json:"revision,omitempty"`
+	Version  string       `json:"version,omitempty"`
+	Size     int64        `json:"size"`
+}
+
+func (w WirelessFirmware) String() string {
+	chip := w.Chip
+	if len(w.Revision) > 0 {
+		chip += " " + w.Revision
+	}
+	return fmt.Sprintf("%-10s %-60s %8d  %s", chip, w.Path, w.Size, w.Version)
+}
+
+// IsOTA returns true if the input is an OTA (and not an IPSW)
+func IsOTA(in string) bool {
+	if ok, _ := magic.IsAA(in); ok {
+		return true
+	}
+	if ok, _ := magic.IsAEA(in); ok {
+		return true
+	}
+	zr, err := zip.OpenReader(in)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "AssetData/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractWirelessOTA extracts the wireless firmware of the given type from an OTA
+func ExtractWirelessOTA(in string, typ WirelessType, output string) ([]string, error) {
+	var out []string
+
+	re := typ.Pattern()
+
+	o, err := ota.Open(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OTA file: %v", err)
+	}
+	defer o.Close()
+
+	for _, f := range o.Files() {
+		if f.IsDir() || !re.MatchString(f.Path()) {
+			continue
+		}
+		ff, err := o.Open(f.Path(), true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file '%s' in OTA: %v", f.Path(), err)
+		}
+		fname := filepath.Join(output, f.Path())
+		if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+			ff.Close()
+			return nil, fmt.Errorf("failed to create output directory: %v", err)
+		}
+		of, err := os.Create(fname)
+		if err != nil {
+			ff.Close()
+			return nil, fmt.Errorf("failed to create file: %v", err)
+		}
+		_, err = io.Copy(of, ff)
+		ff.Close()
+		of.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to write file: %v", err)
+		}
+		out = append(out, fname)
+	}
+
+	return out, nil
+}
+
+// ParseWireless parses the wireless firmware metadata of the extracted files
+func ParseWireless(typ WirelessType, root string, files []string) ([]*WirelessFirmware, error) {
+	var wfws []*WirelessFirmware
+
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %v", f, err)
+		}
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			rel = f
+		}
+		if idx := strings.Index(rel, "usr/share/firmware/"); idx >= 0 {
+			rel = rel[idx+len("usr/share/firmware/"):]
+		}
+		wfw := &WirelessFirmware{
+			Path: rel,
+			Type: typ,
+			Size: fi.Size(),
+		}
+		if m := chipDirRE.FindStringSubmatch(rel); m != nil {
+			wfw.Chip = m[1]
+			wfw.Revision = m[2]
+		}
+		if m := btNameRE.FindStringSubmatch(filepath.Base(f)); m != nil {
+			wfw.Chip = m[2]
+			wfw.Revision = m[3]
+			wfw.Version = m[4]
+		}
+		if len(wfw.Version) == 0 && (strings.HasSuffix(f, ".bin") || strings.HasSuffix(f, ".trx")) {
+			dat, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", f, err)
+			}
+			if v := wifiVersionRE.Find(dat); v != nil {
+				wfw.Version = string(v)
+			}
+		}
+		utils.Indent(log.Debug, 2)(fmt.Sprintf("Parsed %s", wfw.Path))
+		wfws = append(wfws, wfw)
+	}
+
+	sort.Slice(wfws, func(i, j int) bool {
+		return wfws[i].Path < wfws[j].Path
+	})
+
+	return wfws, nil
+}
+
+// diffKeys returns the keys the firmware are matched on between builds: their path with the version
+// component of the file name (i.e. BCM4387C2_19.3.395.4422_PCIE_macOS_MAUI.bin) normalized out
+// (firmware whose normalized paths collide are keyed on their full path)
+func diffKeys(wfws []*WirelessFirmware) []string {
+	keys := make([]string, len(wfws))
+	count := make(map[string]int)
+	for i, w := range wfws {
+		keys[i] = fileVersionRE.ReplaceAllString(w.Path, "*")
+		count[keys[i]]++
+	}
+	for i, w := range wfws {
+		if count[keys[i]] > 1 {
+			keys[i] = w.Path
+		}
+	}
+	return keys
+}
+
+// DiffWireless returns a report of the wireless firmware changes between two builds
+func DiffWireless(prev, next []*WirelessFirmware) string {
+	var sb strings.Builder
+
+	old := make(map[string]*WirelessFirmware)
+	for i, key := range diffKeys(prev) {
+		old[key] = prev[i]
+	}
+	seen := make(map[string]bool)
+
+	for i, key := range diffKeys(next) {
+		w := next[i]
+		seen[key] = true
+		o, ok := old[key]
+		if !ok {
+			sb.WriteString(fmt.Sprintf("+ %s  %s\n", w.Path, w.Version))
+			continue
+		}
+		path := w.Path
+		if o.Path != w.Path {
+			path = o.Path + " -> " + w.Path
+		}
+		if o.Version != w.Version {
+			sb.WriteString(fmt.Sprintf("~ %s  %s -> %s\n", path, o.Version, w.Version))
+		} else if o.Size != w.Size {
+			sb.WriteString(fmt.Sprintf("~ %s  size %d -> %d\n", path, o.Size, w.Size))
+		}
+	}
+	for i, key := range diffKeys(prev) {
+		if !seen[key] {
+			sb.WriteString(fmt.Sprintf("- %s  %s\n", prev[i].Path, prev[i].Version))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+package ida
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+)
+
+// the ObjC types used by the methods' prototypes (the IDAPython script only declares them if the IDB doesn't have them)
+var objcTypedefs = [][2]string{
+	{"id", "typedef struct objc_object *id;"},
+	{"SEL", "typedef struct objc_selector *SEL;"},
+	{"BOOL", "typedef signed char BOOL;"},
+}
+
+const idaPythonScriptTemplate = `# IDAPython script generated by ipsw applying the names, functions, types and comments recovered for:
+#   {{ .Binary }}
+# Run it with File > Script file... (Alt+F7) on an IDB of the same binary
+
+import ida_auto
+import ida_funcs
+import ida_typeinf
+import idaapi
+import idc
+
+# add to every address if the IDB was rebased
+DELTA = 0
+
+TYPEDEFS = [
+{{- range $td := .Typedefs }}
+    ({{ index $td 0 | quote }}, {{ index $td 1 | quote }}),
+{{- end }}
+]
+
+TYPES = [
+{{- range $decl := .Types }}
+    {{ quote $decl }},
+{{- end }}
+]
+
+FUNCTIONS = [
+{{- range $fn := .Functions }}
+    ({{ hex $fn.Start }}, {{ hex $fn.End }}, {{ quote $fn.Prototype }}),
+{{- end }}
+]
+
+NAMES = [
+{{- range $n := .Names }}
+    ({{ hex $n.Addr }}, {{ quote $n.Name }}),
+{{- end }}
+]
+
+COMMENTS = [
+{{- range $c := .Comments }}
+    ({{ hex $c.Addr }}, {{ quote $c.Text }}),
+{{- end }}
+]
+
+
+def main():
+    for name, decl in TYPEDEFS:
+        if ida_typeinf.get_named_type(None, name, ida_typeinf.NTF_TYPE) is None:
+            idc.parse_decls(decl, idc.PT_SILENT)
+    types = 0
+    for decl in TYPES:
+        if idc.parse_decls(decl, idc.PT_SILENT) == 0:
+            types += 1
+    print("[ipsw] added %d/%d types" % (types, len(TYPES)))
+
+    funcs = 0
+    for start, end, proto in FUNCTIONS:
+        start += DELTA
+        if end:
+            end += DELTA
+        else:
+            end = idaapi.BADADDR
+        fn = ida_funcs.get_func(start)
+        if fn is None or fn.start_ea != start:
+            if not ida_funcs.add_func(start, end):
+                continue
+        elif end != idaapi.BADADDR and fn.end_ea != end:
+            ida_funcs.set_func_end(start, end)
+        funcs += 1
+        if proto:
+            idc.SetType(start, proto + ";")
+    print("[ipsw] applied %d/%d functions" % (funcs, len(FUNCTIONS)))
+
+    names = 0
+    for ea, name in NAMES:
+        if idc.set_name(ea + DELTA, name, idc.SN_NOWARN | idc.SN_NOCHECK | idc.SN_FORCE):
+            names += 1
+    print("[ipsw] applied %d/%d names" % (names, len(NAMES)))
+
+    for ea, cmt in COMMENTS:
+        idc.set_cmt(ea + DELTA, cmt, 0)
+    print("[ipsw] applied %d comments" % len(COMMENTS))
+
+    ida_auto.auto_wait()
+
+
+if __name__ == "__main__":
+    main()
+`
+
+const idcScriptTemplate = `// IDC script generated by ipsw applying the names, functions, types and comments recovered for:
+//   {{ .Binary }}
+// Run it with File > Script file... (Alt+F7) on an IDB of the same binary
+
+#include <idc.idc>
+
+static main() {
+{{- range $td := .Typedefs }}
+  parse_decls({{ index $td 1 | quote }}, PT_SILENT);
+{{- end }}
+{{- range $decl := .Types }}
+  parse_decls({{ quote $decl }}, PT_SILENT);
+{{- end }}
+{{- range $fn := .Functions }}
+  add_func({{ hex $fn.Start }}{{ if $fn.End }}, {{ hex $fn.End }}{{ end }});
+{{- if $fn.Prototype }}
+  SetType({{ hex $fn.Start }}, {{ quote $fn.Prototype }} + ";");
+{{- end }}
+{{- end }}
+{{- range $n := .Names }}
+  set_name({{ hex $n.Addr }}, {{ quote $n.Name }}, SN_NOWARN | SN_NOCHECK | SN_FORCE);
+{{- end }}
+{{- range $c := .Comments }}
+  set_cmt({{ hex $c.Addr }}, {{ quote $c.Text }}, 0);
+{{- end }}
+  auto_wait();
+  msg("[ipsw] applied {{ len .Names }} names and {{ len .Functions }} functions\n");
+}
+`
+
+// WriteScript writes an IDAPython (or IDC) script applying the annotations to an IDB
+func WriteScript(w io.Writer, a *mcmd.Annotations, idc bool) error {
+	tmpl := idaPythonScriptTemplate
+	if idc {
+		tmpl = idcScriptTemplate
+	}
+	t := template.Must(template.New("ida").Funcs(template.FuncMap{
+		"hex": func(v uint64) string { return fmt.Sprintf("%#x", v) },
+		"quote": func(s string) string {
+			if idc { // IDC strings are UTF-8 (no \u escapes)
+				return strconv.Quote(strings.ToValidUTF8(s, "?"))
+			}
+			return strconv.QuoteToASCII(s)
+		},
+	}).Parse(tmpl))
+
+	if err := t.Execute(w, struct {
+		*mcmd.Annotations
+		Typedefs [][2]string
+	}{
+		Annotations: a,
+		Typedefs:    objcTypedefs,
+	}); err != nil {
+		return fmt.Errorf("failed to generate IDA script: %v", err)
+	}
+
+	return nil
+}
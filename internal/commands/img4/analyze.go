@@ -0,0 +1,223 @@
+package img4
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blacktop/go-macho"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/pkg/devicetree"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+	"github.com/blacktop/ipsw/pkg/lzfse"
+	"github.com/blacktop/ipsw/pkg/rtkit"
+)
+
+var (
+	ibootVersionRE = regexp.MustCompile(`iBoot-[0-9]+(?:\.[0-9]+)*`)
+	ibootBoardRE   = regexp.MustCompile(`iBoot for ([[:alnum:]]+), Copyright`)
+	sepVersionRE   = regexp.MustCompile(`AppleSEPOS-[0-9]+(?:\.[0-9]+)*`)
+)
+
+// Analysis is the result of identifying and parsing an IM4P payload
+type Analysis struct {
+	Name             string           `json:"name,omitempty"`
+	Type             string           `json:"type,omitempty"`
+	Description      string           `json:"description,omitempty"`
+	Encrypted        bool             `json:"encrypted"`
+	Compression      img4.Compression `json:"compression"`
+	Size             int              `json:"size"`
+	DecompressedSize int              `json:"decompressed_size,omitempty"`
+	Payload          img4.PayloadType `json:"payload"`
+	Details          map[string]any   `json:"details,omitempty"`
+}
+
+func (a Analysis) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Type:         %s\n", a.Type))
+	sb.WriteString(fmt.Sprintf("Description:  %s\n", a.Description))
+	sb.WriteString(fmt.Sprintf("Encrypted:    %t\n", a.Encrypted))
+	sb.WriteString(fmt.Sprintf("Compression:  %s\n", a.Compression))
+	sb.WriteString(fmt.Sprintf("Size:         %#x\n", a.Size))
+	if a.DecompressedSize > 0 {
+		sb.WriteString(fmt.Sprintf("Decompressed: %#x\n", a.DecompressedSize))
+	}
+	sb.WriteString(fmt.Sprintf("Payload:      %s\n", a.Payload))
+	if len(a.Details) > 0 {
+		keys := make([]string, 0, len(a.Details))
+		for k := range a.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("Details:\n")
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("  %-14s %v\n", k+":", a.Details[k]))
+		}
+	}
+	return sb.String()
+}
+
+func decompressPayload(comp img4.Compression, dat []byte) ([]byte, error) {
+	switch comp {
+	case img4.CompressionLZFSE:
+		return lzfse.NewDecoder(dat).DecodeBuffer()
+	case img4.CompressionLZSS:
+		return kernelcache.DecompressData(&kernelcache.CompressedCache{
+			Magic: dat[:4],
+			Size:  len(dat),
+			Data:  dat,
+		})
+	default:
+		return dat, nil
+	}
+}
+
+// AnalyzeIm4p identifies the payload of an IM4P and routes it to the matching parser
+func AnalyzeIm4p(name string, i *img4.Im4p) (*Analysis, error) {
+	a := &Analysis{
+		Name:        name,
+		Type:        i.Type,
+		Description: i.Description,
+		Encrypted:   len(i.Kbags) > 0,
+		Compression: img4.DetectCompression(i.Data),
+		Size:        len(i.Data),
+		Details:     make(map[string]any),
+	}
+
+	dat := i.Data
+	if a.Compression != img4.CompressionNone {
+		var err error
+		dat, err = decompressPayload(a.Compression, i.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s decompress payload: %v", a.Compression, err)
+		}
+		a.DecompressedSize = len(dat)
+	}
+
+	a.Payload = img4.DetectPayloadType(i.Type, dat)
+	if a.Encrypted && a.Compression == img4.CompressionNone && a.Payload == img4.PayloadUnknown {
+		a.Payload = img4.PayloadEncrypted
+		return a, nil
+	}
+
+	if err := analyzePayload(a, dat); err != nil {
+		a.Details["error"] = err.Error()
+	}
+
+	return a, nil
+}
+
+func analyzePayload(a *Analysis, dat []byte) error {
+	switch a.Payload {
+	case img4.PayloadKernelCache:
+		m, err := macho.NewFile(bytes.NewReader(dat))
+		if err != nil {
+			return fmt.Errorf("failed to parse kernelcache MachO: %v", err)
+		}
+		defer m.Close()
+		kv, err := kernelcache.GetVersion(m)
+		if err != nil {
+			return fmt.Errorf("failed to get kernel version: %v", err)
+		}
+		a.Details["xnu"] = kv.XNU
+		a.Details["darwin"] = kv.Darwin
+		a.Details["kernel_type"] = kv.KernelVersion.Type
+		if len(kv.LLVMVersion.Version) > 0 {
+			a.Details["llvm"] = kv.LLVMVersion.Version
+		}
+	case img4.PayloadIBoot:
+		if v := ibootVersionRE.Find(dat); v != nil {
+			a.Details["version"] = string(v)
+		}
+		if m := ibootBoardRE.FindSubmatch(dat); m != nil {
+			a.Details["board"] = string(m[1])
+		}
+	case img4.PayloadSEP:
+		if v := sepVersionRE.Find(dat); v != nil {
+			a.Details["version"] = string(v)
+		}
+	case img4.PayloadDeviceTree:
+		dt, err := devicetree.ParseData(bytes.NewReader(dat))
+		if err != nil {
+			return fmt.Errorf("failed to parse devicetree: %v", err)
+		}
+		s, err := dt.Summary()
+		if err != nil {
+			return fmt.Errorf("failed to get devicetree summary: %v", err)
+		}
+		a.Details["model"] = s.ProductType
+		a.Details["board_config"] = s.BoardConfig
+		a.Details["product_name"] = s.ProductName
+		if len(s.SocName) > 0 {
+			a.Details["soc"] = s.SocName
+		}
+	case img4.PayloadTrustCache:
+		tc, err := fwcmd.ParseTrustCache(dat)
+		if err != nil {
+			return fmt.Errorf("failed to parse trust cache: %v", err)
+		}
+		a.Details["version"] = tc.Version
+		a.Details["uuid"] = tc.UUID.String()
+		a.Details["entries"] = tc.NumEntries
+	case img4.PayloadSPTM, img4.PayloadTXM:
+		mon, err := fwcmd.ParseMonitor(strings.ToUpper(string(a.Payload)), dat)
+		if err != nil {
+			return err
+		}
+		if len(mon.Version) > 0 {
+			a.Details["version"] = mon.Version
+		}
+		if len(mon.UUID) > 0 {
+			a.Details["uuid"] = mon.UUID
+		}
+		if mon.Entry != 0 {
+			a.Details["entry"] = fmt.Sprintf("%#x", mon.Entry)
+		}
+		var segs []string
+		for _, seg := range mon.Segments {
+			segs = append(segs, seg.Name)
+		}
+		a.Details["segments"] = strings.Join(segs, ", ")
+	case img4.PayloadRTKit:
+		rfw, err := rtkit.Parse(dat)
+		if err != nil {
+			return fmt.Errorf("failed to parse RTKit firmware: %v", err)
+		}
+		if len(rfw.Version) > 0 {
+			a.Details["version"] = rfw.Version
+		}
+		if rfw.IsTable() {
+			var names []string
+			for _, img := range rfw.Images {
+				names = append(names, img.Name)
+			}
+			a.Details["images"] = strings.Join(names, ", ")
+		}
+		if len(rfw.MachOs) > 0 {
+			a.Details["machos"] = len(rfw.MachOs)
+			var segs []string
+			for _, seg := range rfw.MachOs[0].Segments {
+				segs = append(segs, seg.Name)
+			}
+			a.Details["segments"] = strings.Join(segs, ", ")
+		}
+	case img4.PayloadMachO:
+		m, err := macho.NewFile(bytes.NewReader(dat))
+		if err != nil {
+			return fmt.Errorf("failed to parse MachO: %v", err)
+		}
+		defer m.Close()
+		a.Details["cpu"] = m.CPU.String()
+		a.Details["filetype"] = m.Type.String()
+		if id := m.UUID(); id != nil {
+			a.Details["uuid"] = id.String()
+		}
+		if sv := m.SourceVersion(); sv != nil {
+			a.Details["source_version"] = sv.Version.String()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package kernel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+)
+
+// KextChange is a kext that was added, removed or whose version changed
+type KextChange struct {
+	ID  string `json:"id"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// ClassChange is an IOKit class whose methods changed
+type ClassChange struct {
+	Name           string   `json:"name"`
+	Kext           string   `json:"kext,omitempty"`
+	UserClient     bool     `json:"user_client,omitempty"`
+	NewMethods     []string `json:"new_methods,omitempty"`
+	RemovedMethods []string `json:"removed_methods,omitempty"`
+}
+
+// IOKitDiff is the diff of the kexts and IOKit classes of two kernelcaches
+type IOKitDiff struct {
+	NewKexts       []*KextChange             `json:"new_kexts,omitempty"`
+	RemovedKexts   []*KextChange             `json:"removed_kexts,omitempty"`
+	UpdatedKexts   []*KextChange             `json:"updated_kexts,omitempty"`
+	NewClasses     []*kernelcache.IOKitClass `json:"new_classes,omitempty"`
+	RemovedClasses []*kernelcache.IOKitClass `json:"removed_classes,omitempty"`
+	UpdatedClasses []*ClassChange            `json:"updated_classes,omitempty"`
+}
+
+// Empty returns true if no kext or IOKit class changed
+func (d *IOKitDiff) Empty() bool {
+	return d == nil || len(d.NewKexts)+len(d.RemovedKexts)+len(d.UpdatedKexts)+
+		len(d.NewClasses)+len(d.RemovedClasses)+len(d.UpdatedClasses) == 0
+}
+
+// NewUserClients returns the new IOUserClient classes (new attack surface)
+func (d *IOKitDiff) NewUserClients() []*kernelcache.IOKitClass {
+	var ucs []*kernelcache.IOKitClass
+	for _, class := range d.NewClasses {
+		if class.UserClient {
+			ucs = append(ucs, class)
+		}
+	}
+	return ucs
+}
+
+// NewUserClientMethods returns the IOUserClient classes with new C++ methods (from the kernelcache symbols)
+//
+// NOTE: these are the classes' symbolicated methods, not the selectors of their IOExternalMethodDispatch tables
+func (d *IOKitDiff) NewUserClientMethods() []*ClassChange {
+	var ucs []*ClassChange
+	for _, class := range d.UpdatedClasses {
+		if class.UserClient && len(class.NewMethods) > 0 {
+			ucs = append(ucs, class)
+		}
+	}
+	return ucs
+}
+
+// DiffInventories compares two kext inventories
+func DiffInventories(prev, next *kernelcache.KextInventory) *IOKitDiff {
+	diff := &IOKitDiff{}
+	for id, version := range next.Kexts {
+		if old, ok := prev.Kexts[id]; !ok {
+			diff.NewKexts = append(diff.NewKexts, &KextChange{ID: id, New: version})
+		} else if old != version {
+			diff.UpdatedKexts = append(diff.UpdatedKexts, &KextChange{ID: id, Old: old, New: version})
+		}
+	}
+	for id, version := range prev.Kexts {
+		if _, ok := next.Kexts[id]; !ok {
+			diff.RemovedKexts = append(diff.RemovedKexts, &KextChange{ID: id, Old: version})
+		}
+	}
+	for name, class := range next.Classes {
+		old, ok := prev.Classes[name]
+		if !ok {
+			diff.NewClasses = append(diff.NewClasses, class)
+			continue
+		}
+		change := &ClassChange{Name: name, Kext: class.Kext, UserClient: class.UserClient}
+		for _, meth := range class.Methods {
+			if !slices.Contains(old.Methods, meth) {
+				change.NewMethods = append(change.NewMethods, meth)
+			}
+		}
+		for _, meth := range old.Methods {
+			if !slices.Contains(class.Methods, meth) {
+				change.RemovedMethods = append(change.RemovedMethods, meth)
+			}
+		}
+		if len(change.NewMethods)+len(change.RemovedMethods) > 0 {
+			diff.UpdatedClasses = append(diff.UpdatedClasses, change)
+		}
+	}
+	for name, class := range prev.Classes {
+		if _, ok := next.Classes[name]; !ok {
+			diff.RemovedClasses = append(diff.RemovedClasses, class)
+		}
+	}
+
+	byID := func(a, b *KextChange) int { return strings.Compare(a.ID, b.ID) }
+	slices.SortFunc(diff.NewKexts, byID)
+	slices.SortFunc(diff.RemovedKexts, byID)
+	slices.SortFunc(diff.UpdatedKexts, byID)
+	byName := func(a, b *kernelcache.IOKitClass) int { return strings.Compare(a.Name, b.Name) }
+	slices.SortFunc(diff.NewClasses, byName)
+	slices.SortFunc(diff.RemovedClasses, byName)
+	slices.SortFunc(diff.UpdatedClasses, func(a, b *ClassChange) int { return strings.Compare(a.Name, b.Name) })
+
+	return diff
+}
+
+// DiffIOKit compares the kexts (bundle IDs and versions) and IOKit classes of two kernelcaches
+func DiffIOKit(k1, k2 *macho.File) (*IOKitDiff, error) {
+	prev, err := kernelcache.GetKextInventory(k1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get 'Old' kext inventory: %v", err)
+	}
+	next, err := kernelcache.GetKextInventory(k2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get 'New' kext inventory: %v", err)
+	}
+	return DiffInventories(prev, next), nil
+}
+
+func codeList(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(values, "`, `") + "`"
+}
+
+// Markdown returns the kext and IOKit class changes as Markdown, starting with the new attack surface
+func (d *IOKitDiff) Markdown() string {
+	var out strings.Builder
+
+	ucs, ems := d.NewUserClients(), d.NewUserClientMethods()
+	if len(ucs)+len(ems) > 0 {
+		out.WriteString("#### ⚠️ New User Clients and User Client Methods\n\n")
+		out.WriteString("| User Client | Kext | New C++ Methods |\n")
+		out.WriteString("| :---------- | :--- | :-------------- |\n")
+		for _, uc := range ucs {
+			out.WriteString(fmt.Sprintf("| 🆕 `%s` | %s | %s |\n", uc.Name, uc.Kext, codeList(uc.Methods)))
+		}
+		for _, em := range ems {
+			out.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", em.Name, em.Kext, codeList(em.NewMethods)))
+		}
+		out.WriteString("\n")
+	}
+
+	for _, section := range []struct {
+		title string
+		kexts []*KextChange
+	}{
+		{"🆕 NEW Kexts", d.NewKexts},
+		{"❌ Removed Kexts", d.RemovedKexts},
+		{"⬆️ Updated Kexts", d.UpdatedKexts},
+	} {
+		if len(section.kexts) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("#### %s (%d)\n\n", section.title, len(section.kexts)))
+		out.WriteString("| Bundle ID | Old | New |\n")
+		out.WriteString("| :-------- | :-- | :-- |\n")
+		for _, k := range section.kexts {
+			out.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", k.ID, k.Old, k.New))
+		}
+		out.WriteString("\n")
+	}
+
+	for _, section := range []struct {
+		title   string
+		classes []*kernelcache.IOKitClass
+	}{
+		{"🆕 NEW IOKit Classes", d.NewClasses},
+		{"❌ Removed IOKit Classes", d.RemovedClasses},
+	} {
+		if len(section.classes) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("#### %s (%d)\n\n", section.title, len(section.classes)))
+		out.WriteString("| Class | Kext | User Client |\n")
+		out.WriteString("| :---- | :--- | :---------- |\n")
+		for _, class := range section.classes {
+			var uc string
+			if class.UserClient {
+				uc = "✅"
+			}
+			out.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", class.Name, class.Kext, uc))
+		}
+		out.WriteString("\n")
+	}
+
+	if len(d.UpdatedClasses) > 0 {
+		out.WriteString(fmt.Sprintf("#### ⬆️ Updated IOKit Classes (%d)\n\n", len(d.UpdatedClasses)))
+		out.WriteString("| Class | Kext | New Methods | Removed Methods |\n")
+		out.WriteString("| :---- | :--- | :---------- | :-------------- |\n")
+		for _, c := range d.UpdatedClasses {
+			out.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", c.Name, c.Kext, codeList(c.NewMethods), codeList(c.RemovedMethods)))
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,140 @@
+package kernel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+)
+
+// KDKsPath is the folder KDKs are installed to
+const KDKsPath = "/Library/Developer/KDKs"
+
+// KernelInfo is the identity of a kernel used to match it to a KDK
+type KernelInfo struct {
+	UUID    string
+	Version *kernelcache.Version
+	Text    uint64 // __TEXT vmaddr
+}
+
+// RawVersion returns the kernel's 'Darwin Kernel Version ...' string
+func (k *KernelInfo) RawVersion() string {
+	if k.Version == nil {
+		return ""
+	}
+	raw, _, _ := strings.Cut(k.Version.String(), "\n")
+	return raw
+}
+
+func kernelInfo(m *macho.File) (*KernelInfo, error) {
+	if m.FileTOC.FileHeader.Type == types.MH_FILESET {
+		var err error
+		m, err = m.GetFileSetFileByName("kernel")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fileset entry 'kernel': %v", err)
+		}
+	}
+	if m.UUID() == nil {
+		return nil, fmt.Errorf("kernel has no LC_UUID")
+	}
+	info := &KernelInfo{UUID: strings.ToUpper(m.UUID().String())}
+	if text := m.Segment("__TEXT"); text != nil {
+		info.Text = text.Addr
+	}
+	if kv, err := kernelcache.GetVersion(m); err == nil {
+		info.Version = kv
+	}
+	return info, nil
+}
+
+// GetKernelInfo returns the UUID and version of a kernelcache's (or KDK's) kernel
+func GetKernelInfo(path string) (*KernelInfo, error) {
+	m, err := macho.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kernel %s: %v", path, err)
+	}
+	defer m.Close()
+	return kernelInfo(m)
+}
+
+// kdkKernels returns the kernels of the KDKs in a folder (or of a single KDK)
+func kdkKernels(kdks string) ([]string, error) {
+	patterns := []string{
+		filepath.Join(kdks, "System/Library/Kernels/kernel*"),
+		filepath.Join(kdks, "*", "System/Library/Kernels/kernel*"),
+	}
+	var kernels []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if strings.HasSuffix(match, ".dSYM") {
+				continue
+			}
+			kernels = append(kernels, match)
+		}
+	}
+	return kernels, nil
+}
+
+// FindKDKKernel returns the kernel in the installed KDKs (or single KDK) with the UUID
+func FindKDKKernel(kdks, uuid string) (string, error) {
+	kernels, err := kdkKernels(kdks)
+	if err != nil {
+		return "", fmt.Errorf("failed to find KDK kernels in %s: %v", kdks, err)
+	}
+	for _, kernel := range kernels {
+		info, err := GetKernelInfo(kernel)
+		if err != nil {
+			log.WithError(err).Debugf("failed to parse KDK kernel %s", kernel)
+			continue
+		}
+		if strings.EqualFold(info.UUID, uuid) {
+			return kernel, nil
+		}
+	}
+	return "", fmt.Errorf("no KDK kernel in %s with UUID %s", kdks, uuid)
+}
+
+// KDKSymbols returns the symbols of a KDK kernel (from its dSYM if present) slid to the kernel at text
+func KDKSymbols(kernel string, text uint64) (map[uint64]string, error) {
+	path := kernel
+	dsym := filepath.Join(kernel+".dSYM", "Contents/Resources/DWARF", filepath.Base(kernel))
+	if _, err := os.Stat(dsym); err == nil {
+		path = dsym
+	}
+
+	m, err := macho.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KDK kernel %s: %v", path, err)
+	}
+	defer m.Close()
+
+	if m.Symtab == nil {
+		return nil, fmt.Errorf("KDK kernel %s has no symbol table", path)
+	}
+
+	var slide uint64
+	if kdkText := m.Segment("__TEXT"); kdkText != nil && text != 0 {
+		slide = text - kdkText.Addr
+	}
+
+	syms := make(map[uint64]string)
+	for _, sym := range m.Symtab.Syms {
+		if sym.Value == 0 || sym.Sect == 0 || len(sym.Name) == 0 || sym.Type.IsDebugSym() {
+			continue
+		}
+		if _, ok := syms[sym.Value+slide]; !ok {
+			syms[sym.Value+slide] = sym.Name
+		}
+	}
+
+	return syms, nil
+}
@@ -0,0 +1,293 @@
+// Package lldb generates LLDB commands setting up the debugging session of a crashed process or a (slid) kernelcache
+package lldb
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/crashlog"
+)
+
+// Module is a binary to load in the target
+type Module struct {
+	Name    string
+	Path    string // the local binary (empty if not found so LLDB locates it by UUID)
+	UUID    string
+	Base    uint64 // the address __TEXT was loaded at (if 0 the module is slid by Slide)
+	Slide   uint64
+	Symbols string // the matching dSYM
+}
+
+// Init is the setup of an LLDB debugging session
+type Init struct {
+	Source  string // the crashlog or kernelcache the session is for
+	Arch    string
+	Kernel  bool    // load the scripts of the symbol files (i.e. the KDK's xnu macros)
+	Target  *Module // the main executable (nil if not found locally)
+	Modules []Module
+}
+
+// Config is the configuration for generating an LLDB init
+type Config struct {
+	Kernelcache string   // the kernelcache of a panic
+	Sysroot     string   // the folder the crashlog's images paths are relative to (i.e. Xcode's DeviceSupport Symbols)
+	SymbolDirs  []string // folders searched for binaries and dSYMs by UUID (i.e. a KDK)
+
+	symbols *symbolFiles
+}
+
+type symbolFiles struct {
+	binaries map[string]string // UUID -> binary
+	dsyms    map[string]string // UUID -> dSYM's DWARF file
+}
+
+func (c *Config) findSymbolFiles() (*symbolFiles, error) {
+	if c.symbols != nil {
+		return c.symbols, nil
+	}
+	c.symbols = &symbolFiles{
+		binaries: make(map[string]string),
+		dsyms:    make(map[string]string),
+	}
+	add := func(path string, m *macho.File) {
+		if m.UUID() == nil {
+			return
+		}
+		uuid := m.UUID().UUID.String()
+		if m.Type == types.MH_DSYM {
+			c.symbols.dsyms[uuid] = path
+		} else if _, ok := c.symbols.binaries[uuid]; !ok {
+			c.symbols.binaries[uuid] = path
+		}
+	}
+	for _, dir := range c.SymbolDirs {
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if ok, _ := magic.IsMachO(path); !ok {
+				return nil
+			}
+			if fat, err := macho.OpenFat(path); err == nil {
+				for _, arch := range fat.Arches {
+					add(path, arch.File)
+				}
+				fat.Close()
+			} else if m, err := macho.Open(path); err == nil {
+				add(path, m)
+				m.Close()
+			} else {
+				log.WithError(err).Debugf("failed to parse %s", path)
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to search symbol files in %s: %v", dir, err)
+		}
+	}
+	log.WithFields(log.Fields{
+		"binaries": len(c.symbols.binaries),
+		"dsyms":    len(c.symbols.dsyms),
+	}).Debug("Found symbol files")
+	return c.symbols, nil
+}
+
+func archName(m *macho.File) string {
+	switch m.CPU {
+	case types.CPUArm64:
+		if m.SubCPU&types.CpuSubtypeMask == types.CPUSubtypeArm64E {
+			return "arm64e"
+		}
+		return "arm64"
+	case types.CPUAmd64:
+		return "x86_64"
+	}
+	return strings.ToLower(m.CPU.String())
+}
+
+// NewFromKernelcache returns the LLDB li of a kernelcache slid by slide (if the config's symbol folders contain the
+// matching kernel, i.e. a KDK's, it is the target and the kexts found are added as modules)
+func NewFromKernelcache(kernelcache string, slide uint64, conf *Config) (*Init, error) {
+	m, err := macho.Open(kernelcache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kernelcache %s: %v", kernelcache, err)
+	}
+	defer m.Close()
+
+	syms, err := conf.findSymbolFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	li := &Init{
+		Source: kernelcache,
+		Arch:   archName(m),
+		Kernel: true,
+		Target: &Module{
+			Name:  filepath.Base(kernelcache),
+			Path:  kernelcache,
+			Slide: slide,
+		},
+	}
+	if m.UUID() != nil {
+		li.Target.UUID = m.UUID().UUID.String()
+		li.Target.Symbols = syms.dsyms[li.Target.UUID]
+	}
+
+	if m.Type != types.MH_FILESET {
+		return li, nil
+	}
+
+	for _, fe := range m.FileSets() {
+		mfe, err := m.GetFileSetFileByName(fe.EntryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse entry %s: %v", fe.EntryID, err)
+		}
+		if mfe.UUID() == nil {
+			continue
+		}
+		mod := Module{
+			Name:    fe.EntryID,
+			Path:    syms.binaries[mfe.UUID().UUID.String()],
+			UUID:    mfe.UUID().UUID.String(),
+			Slide:   slide,
+			Symbols: syms.dsyms[mfe.UUID().UUID.String()],
+		}
+		if len(mod.Path) == 0 && len(mod.Symbols) == 0 {
+			continue // LLDB already has the kernelcache's entry
+		}
+		if fe.EntryID == "com.apple.kernel" && len(mod.Path) > 0 {
+			li.Target = &mod // debug the KDK's kernel (LLDB matches its dSYM and macros)
+			continue
+		}
+		li.Modules = append(li.Modules, mod)
+	}
+
+	return li, nil
+}
+
+// NewFromCrashlog returns the LLDB li of a crashed process (BugType=309) or of a panic (BugType=210) given
+// the config's kernelcache
+func NewFromCrashlog(crashlogPath string, conf *Config) (*Init, error) {
+	ips, err := crashlog.OpenIPS(crashlogPath, &crashlog.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPS file: %v", err)
+	}
+
+	switch ips.Header.BugType {
+	case "210":
+		if len(conf.Kernelcache) == 0 {
+			return nil, fmt.Errorf("must supply the kernelcache of %s %s", ips.Payload.Product, ips.Header.OsVersion)
+		}
+		p, err := ips.Panic()
+		if err != nil {
+			return nil, err
+		}
+		var slide uint64
+		if p.KernelCacheSlide != nil {
+			slide = p.KernelCacheSlide.Value.(uint64)
+		} else if p.KernelSlide != nil {
+			slide = p.KernelSlide.Value.(uint64)
+		} else {
+			return nil, fmt.Errorf("failed to find the kernel slide in the panic")
+		}
+		li, err := NewFromKernelcache(conf.Kernelcache, slide, conf)
+		if err != nil {
+			return nil, err
+		}
+		if p.KernelCacheUUID != nil && len(li.Target.UUID) > 0 && li.Target.Path == conf.Kernelcache &&
+			!strings.EqualFold(p.KernelCacheUUID.Value.(string), li.Target.UUID) {
+			log.Warnf("kernelcache UUID %s does NOT match the panic's %s", li.Target.UUID, p.KernelCacheUUID.Value.(string))
+		}
+		li.Source = crashlogPath
+		return li, nil
+	case "309":
+		syms, err := conf.findSymbolFiles()
+		if err != nil {
+			return nil, err
+		}
+		li := &Init{Source: crashlogPath}
+		for _, img := range ips.Payload.UsedImages {
+			if len(img.UUID) == 0 || img.Base == 0 {
+				continue // absolute/unknown images
+			}
+			li.Arch = cmp.Or(li.Arch, img.Arch)
+			mod := Module{
+				Name:    cmp.Or(img.Name, filepath.Base(img.Path)),
+				Path:    syms.binaries[strings.ToUpper(img.UUID)],
+				UUID:    strings.ToUpper(img.UUID),
+				Base:    img.Base,
+				Symbols: syms.dsyms[strings.ToUpper(img.UUID)],
+			}
+			if len(mod.Path) == 0 && len(conf.Sysroot) > 0 && len(img.Path) > 0 {
+				if _, err := os.Stat(filepath.Join(conf.Sysroot, img.Path)); err == nil {
+					mod.Path = filepath.Join(conf.Sysroot, img.Path)
+				}
+			}
+			if img.Path == ips.Payload.ProcPath && len(mod.Path) > 0 && li.Target == nil {
+				li.Target = &mod
+				continue
+			}
+			li.Modules = append(li.Modules, mod)
+		}
+		li.Arch = cmp.Or(li.Arch, "arm64")
+		return li, nil
+	default:
+		return nil, fmt.Errorf("unsupported crashlog BugType=%s (only 210 panics and 309 crashes are supported)", ips.Header.BugType)
+	}
+}
+
+const initTemplate = `{{ define "load" -}}
+target modules load --uuid {{ .UUID }} {{ if .Base }}__TEXT {{ hex .Base }}{{ else }}--slide {{ hex .Slide }}{{ end }}
+{{- end -}}
+# LLDB commands generated by ipsw setting up the debugging session of:
+#   {{ .Source }}
+# Run them with: lldb -s FILE (or 'command source FILE' in LLDB or add them to ~/.lldbinit)
+{{ if .Kernel }}
+settings set target.load-script-from-symbol-file true
+{{- end }}
+target create --arch {{ .Arch }}{{ with .Target }} --no-dependents {{ quote .Path }}{{ end }}
+{{- with .Target }}
+{{- if .Symbols }}
+target symbols add {{ quote .Symbols }}
+{{- end }}
+{{- if .UUID }}
+{{ template "load" . }}
+{{- else }}
+target modules load --file {{ quote .Path }} --slide {{ hex .Slide }}
+{{- end }}
+{{- end }}
+{{- range .Modules }}
+
+# {{ .Name }}{{ if not .Path }} (not found locally: located by UUID){{ end }}
+target modules add{{ if not .Path }} --uuid {{ .UUID }}{{ end }}{{ with .Symbols }} --symfile {{ quote . }}{{ end }}{{ with .Path }} {{ quote . }}{{ end }}
+{{ template "load" . }}
+{{- end }}
+`
+
+// Write writes the LLDB commands
+func (i *Init) Write(w io.Writer) error {
+	t := template.Must(template.New("lldb").Funcs(template.FuncMap{
+		"hex":   func(v uint64) string { return fmt.Sprintf("%#x", v) },
+		"quote": strconv.Quote,
+	}).Parse(initTemplate))
+
+	if err := t.Execute(w, i); err != nil {
+		return fmt.Errorf("failed to generate LLDB init: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package macho
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/internal/swift"
+)
+
+// Name is a name recovered for an address
+type Name struct {
+	Addr uint64 `json:"addr"`
+	Name string `json:"name"`
+}
+
+// Function is a function's boundaries (End is 0 when unknown) and its recovered name and C prototype
+type Function struct {
+	Start     uint64 `json:"start"`
+	End       uint64 `json:"end,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Prototype string `json:"prototype,omitempty"`
+}
+
+// Comment is a comment for an address
+type Comment struct {
+	Addr uint64 `json:"addr"`
+	Text string `json:"text"`
+}
+
+// Annotations is the knowledge ipsw recovered about a binary (names, function boundaries, types and comments)
+// to be transferred into a disassembler's database
+type Annotations struct {
+	Binary    string     `json:"binary"`
+	Names     []Name     `json:"names,omitempty"`
+	Functions []Function `json:"functions,omitempty"`
+	Comments  []Comment  `json:"comments,omitempty"`
+	Types     []string   `json:"types,omitempty"` // C declarations (i.e. the layout of the ObjC classes)
+}
+
+// AnnotateConfig is the configuration for annotating a MachO
+type AnnotateConfig struct {
+	Symbols bool // add the symtab's names (and demangle them in comments)
+	Starts  bool // add the function starts
+	ObjC    bool // add the ObjC methods' names, prototypes and the classes' layouts
+}
+
+// Annotate returns the annotations recovered from a MachO
+func Annotate(name string, m *macho.File, conf *AnnotateConfig) (*Annotations, error) {
+	a := &Annotations{Binary: name}
+
+	if conf.Starts {
+		for _, fn := range m.GetFunctions() {
+			a.Functions = append(a.Functions, Function{Start: fn.StartAddr, End: fn.EndAddr})
+		}
+	}
+
+	if conf.Symbols && m.Symtab != nil {
+		syms := make(map[uint64]string)
+		for _, sym := range m.Symtab.Syms {
+			if sym.Value == 0 || len(sym.Name) == 0 || sym.Name == "<redacted>" || sym.Type.IsDebugSym() {
+				continue
+			}
+			syms[sym.Value] = sym.Name
+		}
+		a.AddNames(syms)
+	}
+
+	if conf.ObjC && m.HasObjC() {
+		classes, err := m.GetObjCClasses()
+		if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return nil, fmt.Errorf("failed to parse ObjC classes: %v", err)
+		}
+		for _, c := range classes {
+			a.addObjCMethods(c.Name, "+", c.ClassMethods)
+			a.addObjCMethods(c.Name, "-", c.InstanceMethods)
+			if decl := objcClassDecl(&c); len(decl) > 0 {
+				a.Types = append(a.Types, decl)
+			}
+		}
+		cats, err := m.GetObjCCategories()
+		if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			log.WithError(err).Warn("failed to parse ObjC categories")
+		}
+		for _, cat := range cats {
+			class := cat.Name
+			if cat.Class != nil && len(cat.Class.Name) > 0 {
+				class = fmt.Sprintf("%s(%s)", cat.Class.Name, cat.Name)
+			}
+			a.addObjCMethods(class, "+", cat.ClassMethods)
+			a.addObjCMethods(class, "-", cat.InstanceMethods)
+		}
+	}
+
+	a.Sort()
+
+	return a, nil
+}
+
+// AddNames adds names (i.e. symbolicated or local symbols) adding the demangled C++/Swift names as comments
+func (a *Annotations) AddNames(names map[uint64]string) {
+	for addr, name := range names {
+		a.Names = append(a.Names, Name{Addr: addr, Name: name})
+		var demangled string
+		switch {
+		case strings.HasPrefix(name, "_$s") || strings.HasPrefix(name, "$s"):
+			demangled = swift.DemangleBlob(name)
+		case strings.HasPrefix(name, "__Z") || strings.HasPrefix(name, "_Z"):
+			demangled = demangle.Do(name, false, false)
+		}
+		if len(demangled) > 0 && demangled != name {
+			a.Comments = append(a.Comments, Comment{Addr: addr, Text: demangled})
+		}
+	}
+}
+
+// AddFunctions adds the functions starting at the names' addresses (i.e. symbolicated functions)
+func (a *Annotations) AddFunctions(names map[uint64]string) {
+	for addr, name := range names {
+		a.Functions = append(a.Functions, Function{Start: addr, Name: name})
+	}
+}
+
+// Sort sorts the annotations by address (de-duplicating the functions and keeping the named ones)
+func (a *Annotations) Sort() {
+	slices.SortStableFunc(a.Names, func(x, y Name) int { return cmp.Compare(x.Addr, y.Addr) })
+	slices.SortStableFunc(a.Comments, func(x, y Comment) int { return cmp.Compare(x.Addr, y.Addr) })
+	a.Names = slices.CompactFunc(a.Names, func(x, y Name) bool { return x.Addr == y.Addr })
+	slices.SortStableFunc(a.Functions, func(x, y Function) int { return cmp.Compare(x.Start, y.Start) })
+	var funcs []Function
+	for _, fn := range a.Functions {
+		if n := len(funcs); n > 0 && funcs[n-1].Start == fn.Start {
+			// keep the boundaries of one and the name/prototype of the other
+			funcs[n-1].End = max(funcs[n-1].End, fn.End)
+			funcs[n-1].Name = cmp.Or(funcs[n-1].Name, fn.Name)
+			funcs[n-1].Prototype = cmp.Or(funcs[n-1].Prototype, fn.Prototype)
+			continue
+		}
+		funcs = append(funcs, fn)
+	}
+	a.Functions = funcs
+}
+
+func (a *Annotations) addObjCMethods(class, kind string, methods []objc.Method) {
+	for _, m := range methods {
+		if m.ImpVMAddr == 0 {
+			continue
+		}
+		name := fmt.Sprintf("%s[%s %s]", kind, class, m.Name)
+		a.Names = append(a.Names, Name{Addr: m.ImpVMAddr, Name: name})
+		a.Functions = append(a.Functions, Function{
+			Start:     m.ImpVMAddr,
+			Name:      name,
+			Prototype: ObjCMethodPrototype(&m),
+		})
+		a.Comments = append(a.Comments, Comment{Addr: m.ImpVMAddr, Text: fmt.Sprintf("%s %s", name, m.Types)})
+	}
+}
+
+// ObjCMethodPrototype returns the C prototype of an ObjC method's implementation (with the ObjC types as id)
+func ObjCMethodPrototype(m *objc.Method) string {
+	ctype := func(typ string) string {
+		typ = strings.TrimSpace(typ)
+		switch {
+		case typ == "void", typ == "BOOL", typ == "char", typ == "int", typ == "short", typ == "long",
+			typ == "unsigned char", typ == "unsigned int", typ == "unsigned short", typ == "unsigned long",
+			typ == "long long", typ == "unsigned long long", typ == "float", typ == "double", typ == "SEL":
+			return typ
+		case strings.HasSuffix(typ, "*"), typ == "id", typ == "Class", strings.HasPrefix(typ, "id<"):
+			return "id"
+		}
+		return ""
+	}
+	ret := ctype(m.ReturnType())
+	if len(ret) == 0 {
+		return ""
+	}
+	args := []string{"id self", "SEL _cmd"}
+	for i := 2; i < m.NumberOfArguments(); i++ {
+		typ := ctype(m.ArgumentType(i + 1)) // the 0th type is the return type
+		if len(typ) == 0 || typ == "void" {
+			return "" // struct/union arguments
+		}
+		args = append(args, fmt.Sprintf("%s arg%d", typ, i-2))
+	}
+	return fmt.Sprintf("%s f(%s)", ret, strings.Join(args, ", "))
+}
+
+// objcClassDecl returns the C struct of an ObjC class's instance layout (the superclasses' ivars are padding)
+func objcClassDecl(c *objc.Class) string {
+	if len(c.Ivars) == 0 || c.ReadOnlyData.InstanceSize == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "struct %s {\n", cIdent(c.Name))
+	var off uint64
+	for _, iv := range c.Ivars {
+		if uint64(iv.Offset) < off || uint64(iv.Offset)+uint64(iv.Size) > c.ReadOnlyData.InstanceSize {
+			continue // bitfields and ivars outside the instance
+		}
+		if pad := uint64(iv.Offset) - off; pad > 0 {
+			fmt.Fprintf(&sb, "  unsigned __int8 _pad%x[%d];\n", off, pad)
+		}
+		switch {
+		case strings.HasPrefix(iv.Type, "@") && iv.Size == 8:
+			fmt.Fprintf(&sb, "  void *%s;\n", cIdent(iv.Name))
+		case iv.Size == 1 || iv.Size == 2 || iv.Size == 4 || iv.Size == 8:
+			fmt.Fprintf(&sb, "  unsigned __int%d %s;\n", iv.Size*8, cIdent(iv.Name))
+		default:
+			fmt.Fprintf(&sb, "  unsigned __int8 %s[%d];\n", cIdent(iv.Name), max(iv.Size, 1))
+		}
+		off = uint64(iv.Offset) + uint64(max(iv.Size, 1))
+	}
+	if off < c.ReadOnlyData.InstanceSize {
+		fmt.Fprintf(&sb, "  unsigned __int8 _pad%x[%d];\n", off, c.ReadOnlyData.InstanceSize-off)
+	}
+	sb.WriteString("};")
+	return sb.String()
+}
+
+// cIdent returns name as a valid C identifier
+func cIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,205 @@
+// Package mirror serves a local folder of downloaded IPSWs/OTAs over HTTP
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/plist"
+)
+
+// IndexPath is the URL path of the mirror's JSON index
+const IndexPath = "/index.json"
+
+// extensions are the file extensions that are indexed
+var extensions = []string{".ipsw", ".zip", ".aea", ".dmg", ".pkg", ".xip"}
+
+// Entry is an indexed firmware file
+type Entry struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Version  string    `json:"version,omitempty"`
+	Build    string    `json:"build,omitempty"`
+	Devices  []string  `json:"devices,omitempty"`
+	Location string    `json:"-"`
+}
+
+// Mirror is an index of the firmware files in a folder
+type Mirror struct {
+	Root string
+
+	mu      sync.RWMutex
+	entries []Entry
+	byName  map[string]*Entry
+}
+
+// New creates a new mirror of the folder
+func New(root string) (*Mirror, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat mirror folder: %v", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("mirror '%s' is not a folder", root)
+	}
+	m := &Mirror{Root: filepath.Clean(root)}
+	if err := m.Index(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Index (re)indexes the mirror folder
+func (m *Mirror) Index() error {
+	var entries []Entry
+
+	if err := filepath.WalkDir(m.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if !slices.Contains(extensions, ext) { // also skips in-progress .download files
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.Root, p)
+		if err != nil {
+			return err
+		}
+		e := Entry{
+			Name:     d.Name(),
+			Path:     "/" + filepath.ToSlash(rel),
+			Size:     fi.Size(),
+			ModTime:  fi.ModTime(),
+			Location: p,
+		}
+		if ext == ".ipsw" || ext == ".zip" {
+			if old := m.lookup(d.Name()); old != nil && old.ModTime.Equal(e.ModTime) && old.Size == e.Size {
+				e.Version, e.Build, e.Devices = old.Version, old.Build, old.Devices
+			} else if pl, err := plist.Parse(p); err == nil && pl.BuildManifest != nil {
+				e.Version = pl.BuildManifest.ProductVersion
+				e.Build = pl.BuildManifest.ProductBuildVersion
+				e.Devices = pl.BuildManifest.SupportedProductTypes
+				sort.Strings(e.Devices)
+			} else {
+				log.WithField("file", rel).Debug("Failed to parse firmware metadata")
+			}
+		}
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to index mirror folder: %v", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	byName := make(map[string]*Entry, len(entries))
+	for idx := range entries {
+		if _, dup := byName[entries[idx].Name]; dup {
+			log.WithField("name", entries[idx].Name).Warn("Duplicate file name in mirror (only the first will be served by name)")
+			continue
+		}
+		byName[entries[idx].Name] = &entries[idx]
+	}
+
+	m.mu.Lock()
+	m.entries = entries
+	m.byName = byName
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *Mirror) lookup(name string) *Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.byName[name]; ok {
+		return e
+	}
+	return nil
+}
+
+// Entries returns the indexed files
+func (m *Mirror) Entries() []Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Entry(nil), m.entries...)
+}
+
+// ServeHTTP serves the index and the mirrored files
+//
+// Files are served by their path in the mirror folder OR by the path of the URL they were
+// downloaded from (i.e. /<CDN path>/<name>) as ipsw's downloaders name files after the URL's base name,
+// so an Apple CDN URL can be used as-is by only swapping its scheme/host for the mirror's.
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == IndexPath {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.Entries()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	upath := path.Clean("/" + r.URL.Path)
+
+	var e *Entry
+	m.mu.RLock()
+	for idx := range m.entries {
+		if m.entries[idx].Path == upath {
+			e = &m.entries[idx]
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if e == nil {
+		e = m.lookup(path.Base(upath))
+	}
+	if e == nil { // downloaders can be told to remove commas from file names
+		e = m.lookup(strings.ReplaceAll(path.Base(upath), ",", "_"))
+	}
+	if e == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(e.Location)
+	if err != nil {
+		http.Error(w, "failed to open file", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	log.WithFields(log.Fields{
+		"client": r.RemoteAddr,
+		"range":  r.Header.Get("Range"),
+	}).Debug(e.Path)
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	http.ServeContent(w, r, e.Name, e.ModTime, f)
+}
@@ -3,6 +3,7 @@ package mount
 
 import (
 	"archive/zip"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/aea"
+	"github.com/blacktop/ipsw/pkg/apfs"
 	"github.com/blacktop/ipsw/pkg/info"
 )
 
@@ -43,11 +45,30 @@ func (c Context) Unmount() error {
 
 // DmgInIPSW will mount a DMG from an IPSW
 func DmgInIPSW(path, typ, pemDbPath string) (*Context, error) {
+	extractedDMG, err := ExtractDMG(path, typ, pemDbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, am, err := utils.MountDMG(extractedDMG)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount %s: %v", extractedDMG, err)
+	}
+
+	return &Context{
+		DmgPath:        extractedDMG,
+		MountPoint:     mp,
+		AlreadyMounted: am,
+	}, nil
+}
+
+// ExtractDMG will extract (and decrypt) a DMG from an IPSW into the temp directory
+func ExtractDMG(path, typ, pemDbPath string) (string, error) {
 	ipswPath := filepath.Clean(path)
 
 	i, err := info.Parse(ipswPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse IPSW: %v", err)
+		return "", fmt.Errorf("failed to parse IPSW: %v", err)
 	}
 
 	var dmgPath string
@@ -56,7 +77,7 @@ func DmgInIPSW(path, typ, pemDbPath string) (*Context, error) {
 	case "fs":
 		dmgPath, err = i.GetFileSystemOsDmg()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get filesystem DMG: %v", err)
+			return "", fmt.Errorf("failed to get filesystem DMG: %v", err)
 		}
 	case "sys":
 		dmgPath, err = i.GetSystemOsDmg()
@@ -65,24 +86,24 @@ func DmgInIPSW(path, typ, pemDbPath string) (*Context, error) {
 				log.Warn("could not find SystemOS DMG; trying filesystem DMG (older IPSWs don't have cryptexes)")
 				dmgPath, err = i.GetFileSystemOsDmg()
 				if err != nil {
-					return nil, fmt.Errorf("failed to get filesystem DMG: %v", err)
+					return "", fmt.Errorf("failed to get filesystem DMG: %v", err)
 				}
 			} else {
-				return nil, fmt.Errorf("failed to get SystemOS DMG: %v", err)
+				return "", fmt.Errorf("failed to get SystemOS DMG: %v", err)
 			}
 		}
 	case "app":
 		dmgPath, err = i.GetAppOsDmg()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get AppOS DMG: %v", err)
+			return "", fmt.Errorf("failed to get AppOS DMG: %w", err)
 		}
 	case "exc":
 		dmgPath, err = i.GetExclaveOSDmg()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get ExclaveOS DMG: %v", err)
+			return "", fmt.Errorf("failed to get ExclaveOS DMG: %w", err)
 		}
 	default:
-		return nil, fmt.Errorf("invalid subcommand: %s; must be one of: '%s'", typ, strings.Join(DmgTypes, "', '"))
+		return "", fmt.Errorf("invalid subcommand: %s; must be one of: '%s'", typ, strings.Join(DmgTypes, "', '"))
 	}
 
 	extractedDMG := filepath.Join(os.TempDir(), dmgPath)
@@ -92,10 +113,10 @@ func DmgInIPSW(path, typ, pemDbPath string) (*Context, error) {
 			return strings.EqualFold(filepath.Base(f.Name), dmgPath)
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract %s from IPSW: %v", dmgPath, err)
+			return "", fmt.Errorf("failed to extract %s from IPSW: %v", dmgPath, err)
 		}
 		if len(dmgs) == 0 {
-			return nil, fmt.Errorf("failed to find %s in IPSW", dmgPath)
+			return "", fmt.Errorf("failed to find %s in IPSW", dmgPath)
 		}
 	}
 
@@ -107,18 +128,59 @@ func DmgInIPSW(path, typ, pemDbPath string) (*Context, error) {
 			PemDB:  pemDbPath,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse AEA encrypted DMG: %v", err)
+			return "", fmt.Errorf("failed to parse AEA encrypted DMG: %v", err)
 		}
 	}
 
-	mp, am, err := utils.MountDMG(extractedDMG)
-	if err != nil {
-		return nil, fmt.Errorf("failed to mount %s: %v", extractedDMG, err)
-	}
+	return extractedDMG, nil
+}
 
-	return &Context{
-		DmgPath:        extractedDMG,
-		MountPoint:     mp,
-		AlreadyMounted: am,
-	}, nil
+// fuseMirrors are the folders (relative to the mountpoint) the DMGs are mounted at when mounting more than one
+// (the same folders they are mounted at on device)
+var fuseMirrors = map[string]string{
+	"fs":  "",
+	"sys": "System/Cryptexes/OS",
+	"app": "System/Cryptexes/App",
+	"exc": "System/ExclaveKit",
+}
+
+// FuseInIPSW will mount the DMGs of the given types from an IPSW read-only on mountPoint with FUSE
+// (without needing apfs-fuse) until ctx is canceled; a single DMG is mounted at the root of mountPoint
+// and several at the folders they are mounted at on device (i.e. the SystemOS cryptex at System/Cryptexes/OS)
+func FuseInIPSW(ctx context.Context, path string, typs []string, mountPoint, pemDbPath string) error {
+	volumes := make(map[string]*apfs.FS)
+	seen := make(map[string]bool)
+	for _, typ := range typs {
+		extractedDMG, err := ExtractDMG(path, typ, pemDbPath)
+		if err != nil {
+			if len(typs) > 1 && errors.Is(err, info.ErrorCryptexNotFound) {
+				log.Debugf("skipping %s DMG: %v", typ, err)
+				continue
+			}
+			return err
+		}
+		if seen[extractedDMG] { // i.e. 'sys' falls back to the filesystem DMG on older IPSWs
+			continue
+		}
+		seen[extractedDMG] = true
+		defer os.Remove(extractedDMG)
+		fsys, err := apfs.Open(extractedDMG)
+		if err != nil {
+			return fmt.Errorf("failed to open %s DMG: %v", typ, err)
+		}
+		defer fsys.Close()
+		dir := fuseMirrors[typ]
+		if len(typs) == 1 {
+			dir = ""
+		}
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Mounting %s DMG %s at %s", typ, filepath.Base(extractedDMG), filepath.Join(mountPoint, dir)))
+		volumes[dir] = fsys
+	}
+	if len(volumes) == 0 {
+		return fmt.Errorf("no DMGs found in %s", path)
+	}
+	if err := os.MkdirAll(mountPoint, 0o750); err != nil {
+		return fmt.Errorf("failed to create mount point %s: %v", mountPoint, err)
+	}
+	return apfs.Mount(ctx, mountPoint, volumes)
 }
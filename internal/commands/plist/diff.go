@@ -0,0 +1,120 @@
+package plist
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ChangeKind is how a value changed
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a value added, removed or changed at a path
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  any        `json:"old,omitempty"`
+	New  any        `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", c.Path, value(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", c.Path, value(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, value(c.Old), value(c.New))
+	}
+}
+
+func value(v any) string {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(dat)
+}
+
+// Diff returns the structural changes (by path) from the old to the new plist
+func Diff(old, new any) []Change {
+	var changes []Change
+	diff(".", old, new, &changes)
+	return changes
+}
+
+func join(path, step string) string {
+	if path == "." {
+		if step[0] == '.' {
+			return step
+		}
+		return "." + step
+	}
+	return path + step
+}
+
+func diff(path string, old, new any, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]any:
+		if n, ok := new.(map[string]any); ok {
+			keys := slices.Sorted(maps.Keys(o))
+			for _, k := range slices.Sorted(maps.Keys(n)) {
+				if _, ok := o[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			for _, k := range keys {
+				p := join(path, keyPath(k))
+				ov, inOld := o[k]
+				nv, inNew := n[k]
+				switch {
+				case !inNew:
+					*changes = append(*changes, Change{Path: p, Kind: Removed, Old: ov})
+				case !inOld:
+					*changes = append(*changes, Change{Path: p, Kind: Added, New: nv})
+				default:
+					diff(p, ov, nv, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if n, ok := new.([]any); ok {
+			for i := range max(len(o), len(n)) {
+				p := join(path, fmt.Sprintf("[%d]", i))
+				switch {
+				case i >= len(n):
+					*changes = append(*changes, Change{Path: p, Kind: Removed, Old: o[i]})
+				case i >= len(o):
+					*changes = append(*changes, Change{Path: p, Kind: Added, New: n[i]})
+				default:
+					diff(p, o[i], n[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !equal(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: old, New: new})
+	}
+}
+
+// equal compares the values (the integers by value as plists decode them as uint64 and JSON as int64)
+func equal(a, b any) bool {
+	isInt := func(v reflect.Value) bool {
+		return v.CanInt() || v.CanUint()
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsValid() && vb.IsValid() && isInt(va) && isInt(vb) {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
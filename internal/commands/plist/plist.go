@@ -0,0 +1,75 @@
+// Package plist converts, queries and diffs plists
+package plist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blacktop/go-plist"
+)
+
+// the conversion formats
+const (
+	FormatJSON   = "json"
+	FormatXML    = "xml"
+	FormatBinary = "binary"
+)
+
+// Formats are the conversion formats
+var Formats = []string{FormatJSON, FormatXML, FormatBinary}
+
+// Decode decodes a binary, XML, OpenStep or JSON plist and returns its format's name
+func Decode(data []byte) (any, string, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, "", fmt.Errorf("failed to decode JSON: %v", err)
+		}
+		return fromJSON(v), FormatJSON, nil
+	}
+	var v any
+	format, err := plist.Unmarshal(data, &v)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode plist: %v", err)
+	}
+	return v, strings.ToLower(plist.FormatNames[format]), nil
+}
+
+// fromJSON converts the JSON numbers to the plist's integers (or reals)
+func fromJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, vv := range v {
+			v[k] = fromJSON(vv)
+		}
+	case []any:
+		for i, vv := range v {
+			v[i] = fromJSON(vv)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// Encode encodes the value as JSON, an XML plist or a binary plist
+func Encode(v any, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(v, "", "  ")
+	case FormatXML:
+		return plist.MarshalIndent(v, plist.XMLFormat, "\t")
+	case FormatBinary:
+		return plist.Marshal(v, plist.BinaryFormat)
+	default:
+		return nil, fmt.Errorf("unsupported format '%s' (expected one of %s)", format, strings.Join(Formats, ", "))
+	}
+}
@@ -0,0 +1,154 @@
+package plist
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var plainKeyRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// step is a path step: a dict key, an array index or all the values (of a dict or array)
+type step struct {
+	key   *string
+	index *int
+}
+
+// parsePath parses a jq-like path (i.e. .Key.SubKey[0], .["key.with.dots"] or .Array[].Key)
+func parsePath(path string) ([]step, error) {
+	var steps []step
+	p := strings.TrimSpace(path)
+	if p == "." || len(p) == 0 {
+		return nil, nil
+	}
+	for len(p) > 0 {
+		switch {
+		case strings.HasPrefix(p, "["):
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in path '%s'", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			switch {
+			case len(inner) == 0:
+				steps = append(steps, step{})
+			case strings.HasPrefix(inner, `"`):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s in path '%s': %v", inner, path, err)
+				}
+				steps = append(steps, step{key: &key})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index '%s' in path '%s'", inner, path)
+				}
+				steps = append(steps, step{index: &idx})
+			}
+			p = p[end+1:]
+		case strings.HasPrefix(p, "."):
+			p = p[1:]
+			if strings.HasPrefix(p, `"`) { // ."key"
+				end := strings.Index(p[1:], `"`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated '\"' in path '%s'", path)
+				}
+				key := p[1 : end+1]
+				steps = append(steps, step{key: &key})
+				p = p[end+2:]
+				continue
+			}
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end > 0 {
+				key := p[:end]
+				steps = append(steps, step{key: &key})
+			}
+			p = p[end:]
+		default:
+			return nil, fmt.Errorf("invalid path '%s' (expected '.' or '[' at '%s')", path, p)
+		}
+	}
+	return steps, nil
+}
+
+// Query returns the values at the jq-like path (i.e. .Key.SubKey[0], .["key.with.dots"] or .Array[].Key)
+func Query(v any, path string) ([]any, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	values := []any{v}
+	for _, s := range steps {
+		var next []any
+		for _, v := range values {
+			switch {
+			case s.key != nil:
+				dict, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("cannot get key '%s' of %s", *s.key, typeName(v))
+				}
+				if vv, ok := dict[*s.key]; ok {
+					next = append(next, vv)
+				}
+			case s.index != nil:
+				arr, ok := v.([]any)
+				if !ok {
+					return nil, fmt.Errorf("cannot get index %d of %s", *s.index, typeName(v))
+				}
+				idx := *s.index
+				if idx < 0 {
+					idx += len(arr)
+				}
+				if idx >= 0 && idx < len(arr) {
+					next = append(next, arr[idx])
+				}
+			default:
+				switch vv := v.(type) {
+				case []any:
+					next = append(next, vv...)
+				case map[string]any:
+					for _, k := range slices.Sorted(maps.Keys(vv)) {
+						next = append(next, vv[k])
+					}
+				default:
+					return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+				}
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "dict"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []byte:
+		return "data"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// keyPath returns the path step of a dict key
+func keyPath(key string) string {
+	if plainKeyRE.MatchString(key) {
+		return "." + key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
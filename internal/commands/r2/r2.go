@@ -0,0 +1,98 @@
+// Package r2 generates radare2/rizin scripts applying the knowledge ipsw recovered about a binary
+package r2
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+)
+
+// the ObjC types used by the methods' prototypes
+var objcTypedefs = []string{
+	"typedef struct objc_object *id;",
+	"typedef struct objc_selector *SEL;",
+	"typedef signed char BOOL;",
+}
+
+// flagName returns name as a valid r2 flag name (ObjC methods are named like r2 does: method.[class.]CLASS.SEL)
+func flagName(name string) string {
+	var sb strings.Builder
+	if class, sel, ok := strings.Cut(strings.Trim(name, "-+[]"), " "); ok && strings.HasSuffix(name, "]") &&
+		(strings.HasPrefix(name, "-[") || strings.HasPrefix(name, "+[")) {
+		sb.WriteString("method.")
+		if name[0] == '+' {
+			sb.WriteString("class.")
+		}
+		name = class + "." + sel
+	} else {
+		sb.WriteString("sym.")
+		name = strings.TrimLeft(name, "_")
+	}
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ' ' || r == '(':
+			sb.WriteRune('.')
+		case r == ')':
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// WriteScript writes an r2 script (run with `r2 -i SCRIPT BINARY` or `. SCRIPT`) applying the annotations as
+// flags, functions, signatures, types and comments
+func WriteScript(w io.Writer, a *mcmd.Annotations) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# r2 script generated by ipsw applying the names, functions, types and comments recovered for:\n")
+	fmt.Fprintf(bw, "#   %s\n", a.Binary)
+	fmt.Fprintf(bw, "# Run it with: r2 -i %s.r2 %s\n\n", a.Binary, a.Binary)
+
+	fmt.Fprintln(bw, "# types")
+	for _, decl := range append(objcTypedefs, a.Types...) {
+		fmt.Fprintf(bw, "\"td %s\"\n", strings.Join(strings.Fields(decl), " "))
+	}
+
+	names := make(map[uint64]string, len(a.Names))
+	fmt.Fprintln(bw, "\n# flags")
+	fmt.Fprintln(bw, "fs ipsw")
+	for _, n := range a.Names {
+		names[n.Addr] = flagName(n.Name)
+		fmt.Fprintf(bw, "f %s @ %#x\n", names[n.Addr], n.Addr)
+	}
+	fmt.Fprintln(bw, "fs *")
+
+	fmt.Fprintln(bw, "\n# functions")
+	for _, fn := range a.Functions {
+		name, ok := names[fn.Start]
+		if !ok && len(fn.Name) > 0 {
+			name = flagName(fn.Name)
+		}
+		if len(name) > 0 {
+			fmt.Fprintf(bw, "af %s %#x\n", name, fn.Start)
+		} else {
+			fmt.Fprintf(bw, "af @ %#x\n", fn.Start)
+		}
+		if len(fn.Prototype) > 0 {
+			fmt.Fprintf(bw, "s %#x\n\"afs %s\"\n", fn.Start, fn.Prototype)
+		}
+	}
+
+	fmt.Fprintln(bw, "\n# comments")
+	for _, c := range a.Comments {
+		fmt.Fprintf(bw, "CCu base64:%s @ %#x\n", base64.StdEncoding.EncodeToString([]byte(c.Text)), c.Addr)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write r2 script: %v", err)
+	}
+
+	return nil
+}
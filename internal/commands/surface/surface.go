@@ -0,0 +1,281 @@
+// Package surface ranks the attack surface of the launchd daemons of an IPSW
+package surface
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/internal/commands/ent"
+	"github.com/blacktop/ipsw/internal/commands/extract"
+	"github.com/blacktop/ipsw/internal/diff"
+	"github.com/blacktop/ipsw/pkg/info"
+)
+
+//go:embed templates/report.html
+var templatesFs embed.FS
+
+// the entitlements lowering the attack surface (instead of granting privileges)
+var sandboxEntitlements = []string{"com.apple.security.app-sandbox", "seatbelt-profiles"}
+
+var cryptexPrefix = regexp.MustCompile(`^/System/Cryptexes/(App|OS)`)
+
+// Config is the configuration for the attack surface report
+type Config struct {
+	IPSW     string
+	Database string // the entitlements database (created if it doesn't exist)
+	PemDB    string
+	Agents   bool // also report the launch agents
+}
+
+// Daemon is a launchd daemon (or agent) and the attack surface it exposes
+type Daemon struct {
+	Label        string         `json:"label"`
+	Kind         string         `json:"kind"`
+	Path         string         `json:"path,omitempty"` // the executable
+	User         string         `json:"user"`
+	MachServices []string       `json:"mach_services,omitempty"`
+	Sockets      []string       `json:"sockets,omitempty"`
+	Sandbox      string         `json:"sandbox,omitempty"` // the declared sandbox profile (empty if none)
+	Entitlements map[string]any `json:"entitlements,omitempty"`
+	Privileged   []string       `json:"privileged_entitlements,omitempty"`
+	// ExternalInput is true if the daemon parses input from outside of the device (the network or devices)
+	ExternalInput bool     `json:"external_input"`
+	Inputs        []string `json:"inputs,omitempty"`
+	Score         int      `json:"score"`
+	Reasons       []string `json:"reasons,omitempty"`
+}
+
+// Report is the daemons ranked by attack surface
+type Report struct {
+	Version string    `json:"version,omitempty"`
+	Build   string    `json:"build,omitempty"`
+	Daemons []*Daemon `json:"daemons"`
+}
+
+func isPrivileged(key string) bool {
+	if slices.Contains(sandboxEntitlements, key) {
+		return false
+	}
+	return slices.ContainsFunc(ent.DefaultInteresting, func(pattern string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	})
+}
+
+// sockets returns the job's sockets and whether any of them is a network (i.e. NOT a unix domain) socket
+func sockets(job map[string]any) ([]string, bool) {
+	socks, _ := job["Sockets"].(map[string]any)
+	var names []string
+	network := false
+	for _, name := range slices.Sorted(maps.Keys(socks)) {
+		var descs []map[string]any
+		switch sock := socks[name].(type) {
+		case map[string]any:
+			descs = append(descs, sock)
+		case []any:
+			for _, s := range sock {
+				if s, ok := s.(map[string]any); ok {
+					descs = append(descs, s)
+				}
+			}
+		}
+		for _, desc := range descs {
+			switch {
+			case desc["SockPathName"] != nil:
+				names = append(names, fmt.Sprintf("%s (%v)", name, desc["SockPathName"]))
+			case desc["SockServiceName"] != nil:
+				names = append(names, fmt.Sprintf("%s (port %v)", name, desc["SockServiceName"]))
+				network = true
+			default:
+				names = append(names, name)
+				network = true
+			}
+		}
+	}
+	return names, network
+}
+
+// sandboxProfile returns the sandbox profile the daemon declares in its entitlements or job
+//
+// NOTE: daemons entering their sandbox themselves (i.e. calling sandbox_init) are NOT detected
+func sandboxProfile(job, ents map[string]any) string {
+	if profiles, ok := ents["seatbelt-profiles"].([]any); ok && len(profiles) > 0 {
+		var names []string
+		for _, p := range profiles {
+			names = append(names, fmt.Sprint(p))
+		}
+		return strings.Join(names, ", ")
+	}
+	if sandboxed, _ := ents["com.apple.security.app-sandbox"].(bool); sandboxed {
+		return "app-sandbox"
+	}
+	if profile, ok := job["SandboxProfile"].(string); ok {
+		return profile
+	}
+	return ""
+}
+
+// newDaemon returns the daemon of the launchd service with its entitlements (plist) scored
+func newDaemon(svc *diff.LaunchdService, entitlements string) (*Daemon, error) {
+	job := svc.Job()
+	d := &Daemon{
+		Label:        svc.Label,
+		Kind:         svc.Kind,
+		Path:         svc.Program,
+		User:         "root",
+		MachServices: svc.MachServices,
+		Entitlements: make(map[string]any),
+	}
+	if len(d.Path) == 0 && len(svc.ProgramArguments) > 0 {
+		d.Path = svc.ProgramArguments[0]
+	}
+	if user, ok := job["UserName"].(string); ok && len(user) > 0 {
+		d.User = user
+	}
+	if len(strings.TrimSpace(entitlements)) > 0 {
+		if _, err := plist.Unmarshal([]byte(entitlements), &d.Entitlements); err != nil {
+			return nil, fmt.Errorf("failed to parse entitlements of %s: %v", d.Path, err)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(d.Entitlements)) {
+		if v, ok := d.Entitlements[key].(bool); ok && !v {
+			continue
+		}
+		if isPrivileged(key) {
+			d.Privileged = append(d.Privileged, key)
+		}
+	}
+	d.Sandbox = sandboxProfile(job, d.Entitlements)
+
+	// external input
+	var network bool
+	d.Sockets, network = sockets(job)
+	if network {
+		d.Inputs = append(d.Inputs, "listens on network sockets")
+		d.Score += 10
+	}
+	if _, ok := job["inetdCompatibility"]; ok {
+		d.Inputs = append(d.Inputs, "inetd service")
+		d.Score += 10
+	}
+	if server, _ := d.Entitlements["com.apple.security.network.server"].(bool); server {
+		d.Inputs = append(d.Inputs, "network server")
+		d.Score += 8
+	}
+	if client, _ := d.Entitlements["com.apple.security.network.client"].(bool); client {
+		d.Inputs = append(d.Inputs, "network client")
+		d.Score += 4
+	}
+	if events, ok := job["LaunchEvents"].(map[string]any); ok {
+		for _, stream := range []string{"com.apple.iokit.matching", "com.apple.usb.matching"} {
+			if _, ok := events[stream]; ok {
+				d.Inputs = append(d.Inputs, "launched by devices attaching ("+stream+")")
+				d.Score += 3
+			}
+		}
+	}
+	d.ExternalInput = len(d.Inputs) > 0
+	if d.ExternalInput {
+		d.Reasons = append(d.Reasons, "parses external input: "+strings.Join(d.Inputs, ", "))
+	}
+
+	// local IPC reachable by other processes
+	if len(d.MachServices) > 0 {
+		d.Score += min(len(d.MachServices), 10)
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d mach service(s)", len(d.MachServices)))
+	}
+
+	// the impact of a compromise
+	if d.User == "root" {
+		d.Score += 5
+		d.Reasons = append(d.Reasons, "runs as root")
+	}
+	if len(d.Sandbox) == 0 {
+		d.Score += 5
+		d.Reasons = append(d.Reasons, "no declared sandbox profile")
+	}
+	if len(d.Privileged) > 0 {
+		d.Score += min(2*len(d.Privileged), 10)
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d privileged entitlement(s)", len(d.Privileged)))
+	}
+
+	return d, nil
+}
+
+// lookupEntitlements returns the entitlements of the executable (in the filesystem or in a cryptex)
+func lookupEntitlements(entDB map[string]string, path string) (string, bool) {
+	if ents, ok := entDB[path]; ok {
+		return ents, true
+	}
+	ents, ok := entDB[cryptexPrefix.ReplaceAllString(path, "")]
+	return ents, ok
+}
+
+// Analyze returns the launchd daemons of the IPSW ranked by attack surface
+func Analyze(conf *Config) (*Report, error) {
+	entDB, err := ent.GetDatabase(&ent.Config{IPSW: conf.IPSW, Database: conf.Database, PemDB: conf.PemDB})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entitlement database: %v", err)
+	}
+	// macOS's launchd has no embedded config (its services are read from the plists instead)
+	config, err := extract.LaunchdConfig(conf.IPSW, conf.PemDB)
+	if err != nil {
+		log.WithError(err).Warn("failed to get launchd config")
+	}
+	svcs, err := diff.LaunchdServices(conf.IPSW, config, conf.PemDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get launchd services: %v", err)
+	}
+
+	r := &Report{}
+	if i, err := info.Parse(conf.IPSW); err == nil {
+		r.Version = i.Plists.BuildManifest.ProductVersion
+		r.Build = i.Plists.BuildManifest.ProductBuildVersion
+	}
+
+	for _, svc := range svcs {
+		if svc.Kind != "daemon" && !conf.Agents {
+			continue
+		}
+		ents, ok := lookupEntitlements(entDB, svc.Program)
+		if !ok && len(svc.ProgramArguments) > 0 {
+			ents, ok = lookupEntitlements(entDB, svc.ProgramArguments[0])
+		}
+		if !ok {
+			log.WithField("label", svc.Label).Debug("executable not found in the filesystem")
+		}
+		d, err := newDaemon(svc, ents)
+		if err != nil {
+			log.WithError(err).Warnf("failed to analyze %s", svc.Label)
+			continue
+		}
+		r.Daemons = append(r.Daemons, d)
+	}
+
+	slices.SortFunc(r.Daemons, func(a, b *Daemon) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Label, b.Label))
+	})
+
+	return r, nil
+}
+
+// WriteHTML writes the report as an HTML page
+func (r *Report) WriteHTML(w io.Writer) error {
+	tmpl, err := template.New("report.html").Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}).ParseFS(templatesFs, "templates/report.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse the template: %v", err)
+	}
+	return tmpl.Execute(w, r)
+}
@@ -0,0 +1,208 @@
+package symshell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token kinds
+const (
+	tokNum = iota
+	tokIdent
+	tokVar
+	tokOp
+	tokEOF
+)
+
+type token struct {
+	kind int
+	text string
+	num  uint64
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '$' || r == ':'
+}
+
+// lex splits an address expression into numbers, symbol names, $variables and operators
+// (a symbol name with other characters, i.e. an ObjC method, can be quoted with backticks)
+func lex(expr string) ([]token, error) {
+	var toks []token
+	rs := []rune(expr)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '`':
+			end := strings.IndexRune(string(rs[i+1:]), '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '`' in '%s'", expr)
+			}
+			name := []rune(string(rs[i+1:])[:end])
+			toks = append(toks, token{kind: tokIdent, text: string(name)})
+			i += len(name) + 2
+		case r == '$':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("missing variable name after '$' in '%s'", expr)
+			}
+			toks = append(toks, token{kind: tokVar, text: string(rs[i+1 : j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || unicode.IsLetter(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			num, err := strconv.ParseUint(string(rs[i:j]), 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s'", string(rs[i:j]))
+			}
+			toks = append(toks, token{kind: tokNum, text: string(rs[i:j]), num: num})
+			i = j
+		case isIdentRune(r):
+			j := i
+			for j < len(rs) && isIdentRune(rs[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: string(rs[i:j])})
+			i = j
+		default:
+			op := string(r)
+			if i+1 < len(rs) && (op == "<" || op == ">") && rs[i+1] == r {
+				op += op
+			}
+			if !strings.Contains("+-*/%&|^~()<<>>", op) {
+				return nil, fmt.Errorf("invalid character '%c' in '%s'", r, expr)
+			}
+			toks = append(toks, token{kind: tokOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+// the binary operators' precedence (as in Go)
+var precedence = map[string]int{
+	"|": 1, "^": 1, "+": 1, "-": 1,
+	"*": 2, "/": 2, "%": 2, "&": 2, "<<": 2, ">>": 2,
+}
+
+type parser struct {
+	toks    []token
+	pos     int
+	resolve func(token) (uint64, error)
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) unary() (uint64, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		return t.num, nil
+	case tokIdent, tokVar:
+		return p.resolve(t)
+	case tokOp:
+		switch t.text {
+		case "(":
+			v, err := p.binary(1)
+			if err != nil {
+				return 0, err
+			}
+			if t := p.next(); t.text != ")" {
+				return 0, fmt.Errorf("missing ')'")
+			}
+			return v, nil
+		case "-":
+			v, err := p.unary()
+			return -v, err
+		case "~":
+			v, err := p.unary()
+			return ^v, err
+		case "+":
+			return p.unary()
+		}
+		return 0, fmt.Errorf("unexpected '%s'", t.text)
+	default:
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+func (p *parser) binary(minPrec int) (uint64, error) {
+	lhs, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < minPrec {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := p.binary(prec + 1)
+		if err != nil {
+			return 0, err
+		}
+		switch t.text {
+		case "+":
+			lhs += rhs
+		case "-":
+			lhs -= rhs
+		case "*":
+			lhs *= rhs
+		case "/", "%":
+			if rhs == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if t.text == "/" {
+				lhs /= rhs
+			} else {
+				lhs %= rhs
+			}
+		case "&":
+			lhs &= rhs
+		case "|":
+			lhs |= rhs
+		case "^":
+			lhs ^= rhs
+		case "<<":
+			lhs <<= rhs
+		case ">>":
+			lhs >>= rhs
+		}
+	}
+}
+
+// eval evaluates an address expression (resolving its symbol names and variables)
+func eval(expr string, resolve func(token) (uint64, error)) (uint64, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return 0, err
+	}
+	p := &parser{toks: toks, resolve: resolve}
+	v, err := p.binary(1)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expression '%s': %v", expr, err)
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return 0, fmt.Errorf("invalid expression '%s': unexpected '%s'", expr, t.text)
+	}
+	return v, nil
+}
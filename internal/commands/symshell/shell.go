@@ -0,0 +1,522 @@
+// Package symshell is an interactive shell to lookup the symbols of a dyld_shared_cache (or the symbol server)
+package symshell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/internal/swift"
+	"github.com/blacktop/ipsw/internal/syms/server"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"golang.org/x/term"
+)
+
+// the most completions listed
+const maxCompletions = 64
+
+// errExit is returned by the exit command
+var errExit = errors.New("exit")
+
+type command struct {
+	usage string
+	help  string
+	run   func(s *Shell, w io.Writer, args string) error
+	arg   func(s *Shell) []string // the completions of its argument (or nil)
+	expr  bool                    // its argument is an expression (of the completions)
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"a2s":     {"a2s <EXPR>", "Lookup the symbol at an address", (*Shell).a2s, (*Shell).exprWords, true},
+		"s2a":     {"s2a <SYMBOL>", "Lookup the address of a symbol", (*Shell).s2a, (*Shell).symbolWords, false},
+		"image":   {"image [DYLIB]", "Select the dylib to complete (and first lookup) the symbols of", (*Shell).selectImage, (*Shell).imageWords, false},
+		"images":  {"images [FILTER]", "List the dylibs (containing FILTER)", (*Shell).images, nil, false},
+		"slide":   {"slide [EXPR]", "Show (or set) the slide of the addresses in and out", (*Shell).setSlide, (*Shell).exprWords, true},
+		"p":       {"p <EXPR>", "Evaluate an address expression (i.e. `_malloc + 0x10 - $slide`)", (*Shell).print, (*Shell).exprWords, true},
+		"set":     {"set <NAME> <EXPR>", "Set the $NAME variable", (*Shell).set, nil, false},
+		"db":      {"db <UUID> <EXPR>", "Lookup the symbol at an address of a MachO in the symbol server", (*Shell).lookupDB, nil, false},
+		"history": {"history", "List the commands run (rerun one with !N or the last with !!)", (*Shell).listHistory, nil, false},
+		"help":    {"help", "Show this help", (*Shell).help, nil, false},
+		"exit":    {"exit", "Exit the shell", func(*Shell, io.Writer, string) error { return errExit }, nil, false},
+	}
+}
+
+// Config is the configuration of the shell
+type Config struct {
+	Server   string // the symbol server URL (or empty)
+	Slide    uint64 // the initial slide
+	Demangle bool   // demangle the symbol names
+}
+
+// Shell is a symbol lookup shell
+type Shell struct {
+	f    *dyld.File
+	db   *server.Server
+	conf *Config
+
+	slide   uint64
+	vars    map[string]uint64
+	image   *dyld.CacheImage
+	symbols map[string]uint64 // the selected image's symbols
+	names   []string          // the selected image's symbol names (sorted)
+	history []string
+}
+
+// New returns a shell looking up the symbols of the dyld_shared_cache f (or nil) and/or the symbol server
+func New(f *dyld.File, conf *Config) (*Shell, error) {
+	s := &Shell{f: f, conf: conf, slide: conf.Slide, vars: make(map[string]uint64)}
+	if len(conf.Server) > 0 {
+		s.db = server.NewServer(conf.Server)
+		if err := s.db.Ping(); err != nil {
+			return nil, err
+		}
+	}
+	if s.f == nil && s.db == nil {
+		return nil, fmt.Errorf("a dyld_shared_cache or symbol server is required")
+	}
+	return s, nil
+}
+
+func (s *Shell) prompt() string {
+	if s.image != nil {
+		return fmt.Sprintf("(%s) ❯ ", filepath.Base(s.image.Name))
+	}
+	return "❯ "
+}
+
+func (s *Shell) demangle(name string) string {
+	if !s.conf.Demangle {
+		return name
+	}
+	if strings.HasPrefix(name, "_$s") || strings.HasPrefix(name, "$s") {
+		name, _ = swift.Demangle(name)
+	} else if strings.HasPrefix(name, "__Z") || strings.HasPrefix(name, "_Z") {
+		name = demangle.Do(name, false, false)
+	}
+	return name
+}
+
+func (s *Shell) needDSC() error {
+	if s.f == nil {
+		return fmt.Errorf("a dyld_shared_cache is required")
+	}
+	return nil
+}
+
+/* EXPRESSIONS */
+
+// lookup returns the (unslid) address of a symbol (searching the selected image first)
+func (s *Shell) lookup(name string) (uint64, *dyld.CacheImage, error) {
+	if addr, ok := s.symbols[name]; ok {
+		return addr, s.image, nil
+	}
+	if err := s.needDSC(); err != nil {
+		return 0, nil, err
+	}
+	return s.f.GetSymbolAddress(name)
+}
+
+// eval evaluates an address expression; symbol names are their (slid) addresses
+func (s *Shell) eval(expr string) (uint64, error) {
+	return eval(expr, func(t token) (uint64, error) {
+		if t.kind == tokVar {
+			if t.text == "slide" {
+				return s.slide, nil
+			}
+			v, ok := s.vars[t.text]
+			if !ok {
+				return 0, fmt.Errorf("undefined variable $%s", t.text)
+			}
+			return v, nil
+		}
+		addr, _, err := s.lookup(t.text)
+		if err != nil {
+			return 0, err
+		}
+		return addr + s.slide, nil
+	})
+}
+
+func (s *Shell) result(w io.Writer, v uint64) {
+	s.vars["_"] = v
+	fmt.Fprintf(w, "%#x (%d)\n", v, v)
+}
+
+/* COMMANDS */
+
+func (s *Shell) a2s(w io.Writer, args string) error {
+	if err := s.needDSC(); err != nil {
+		return err
+	}
+	addr, err := s.eval(args)
+	if err != nil {
+		return err
+	}
+	sym, err := dsc.LookupSymbol(s.f, addr-s.slide)
+	if err != nil {
+		return err
+	}
+	s.vars["_"] = addr
+	where := sym.Image
+	if len(sym.Section) > 0 {
+		where = fmt.Sprintf("%s %s.%s", sym.Image, sym.Segment, sym.Section)
+	} else if len(sym.Segment) > 0 {
+		where = fmt.Sprintf("%s %s", sym.Image, sym.Segment)
+	}
+	if len(where) == 0 {
+		where = sym.Mapping
+	}
+	fmt.Fprintf(w, "%#x: %s (%s)\n", addr, s.demangle(sym.Symbol), where)
+	return nil
+}
+
+func (s *Shell) s2a(w io.Writer, args string) error {
+	name := strings.Trim(strings.TrimSpace(args), "`")
+	if len(name) == 0 {
+		return fmt.Errorf("usage: %s", commands["s2a"].usage)
+	}
+	addr, image, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+	s.vars["_"] = addr + s.slide
+	fmt.Fprintf(w, "%#x: %s (%s)\n", addr+s.slide, s.demangle(name), image.Name)
+	return nil
+}
+
+func (s *Shell) selectImage(w io.Writer, args string) error {
+	if err := s.needDSC(); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args)
+	if len(name) == 0 {
+		if s.image == nil {
+			return fmt.Errorf("no dylib selected")
+		}
+	} else {
+		image, err := s.f.Image(name)
+		if err != nil {
+			return err
+		}
+		if err := image.ParseLocalSymbols(false); err != nil {
+			return fmt.Errorf("failed to parse %s local symbols: %v", image.Name, err)
+		}
+		if err := image.ParsePublicSymbols(false); err != nil {
+			return fmt.Errorf("failed to parse %s public symbols: %v", image.Name, err)
+		}
+		s.image = image
+		s.symbols = make(map[string]uint64)
+		for _, sym := range image.LocalSymbols {
+			s.symbols[sym.Name] = sym.Value
+		}
+		for _, sym := range image.PublicSymbols {
+			if sym.Address > 0 {
+				s.symbols[sym.Name] = sym.Address
+			}
+		}
+		s.names = slices.Sorted(maps.Keys(s.symbols))
+	}
+	fmt.Fprintf(w, "%s\n", s.image.Name)
+	fmt.Fprintf(w, "  UUID:    %s\n", s.image.UUID)
+	fmt.Fprintf(w, "  __TEXT:  %#x-%#x\n", s.image.LoadAddress+s.slide, s.image.LoadAddress+uint64(s.image.TextSegmentSize)+s.slide)
+	fmt.Fprintf(w, "  Symbols: %d\n", len(s.names))
+	return nil
+}
+
+func (s *Shell) images(w io.Writer, args string) error {
+	if err := s.needDSC(); err != nil {
+		return err
+	}
+	filter := strings.ToLower(strings.TrimSpace(args))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, image := range s.f.Images {
+		if strings.Contains(strings.ToLower(image.Name), filter) {
+			fmt.Fprintf(tw, "%#x\t%s\t%s\n", image.LoadAddress+s.slide, image.UUID, image.Name)
+		}
+	}
+	return tw.Flush()
+}
+
+func (s *Shell) setSlide(w io.Writer, args string) error {
+	if len(strings.TrimSpace(args)) > 0 {
+		slide, err := s.eval(args)
+		if err != nil {
+			return err
+		}
+		s.slide = slide
+	}
+	fmt.Fprintf(w, "slide: %#x\n", s.slide)
+	return nil
+}
+
+func (s *Shell) print(w io.Writer, args string) error {
+	v, err := s.eval(args)
+	if err != nil {
+		return err
+	}
+	s.result(w, v)
+	return nil
+}
+
+func (s *Shell) set(w io.Writer, args string) error {
+	name, expr, ok := strings.Cut(strings.TrimSpace(args), " ")
+	name = strings.TrimPrefix(name, "$")
+	if !ok || len(name) == 0 {
+		return fmt.Errorf("usage: %s", commands["set"].usage)
+	}
+	if name == "slide" {
+		return s.setSlide(w, expr)
+	}
+	v, err := s.eval(expr)
+	if err != nil {
+		return err
+	}
+	s.vars[name] = v
+	fmt.Fprintf(w, "$%s = %#x\n", name, v)
+	return nil
+}
+
+func (s *Shell) lookupDB(w io.Writer, args string) error {
+	if s.db == nil {
+		return fmt.Errorf("a symbol server is required (see --server)")
+	}
+	uuid, expr, ok := strings.Cut(strings.TrimSpace(args), " ")
+	if !ok {
+		return fmt.Errorf("usage: %s", commands["db"].usage)
+	}
+	addr, err := s.eval(expr)
+	if err != nil {
+		return err
+	}
+	m, err := s.db.GetMachO(strings.ToUpper(uuid))
+	if err != nil {
+		return fmt.Errorf("failed to find MachO %s: %v", uuid, err)
+	}
+	sym, err := s.db.GetSymbol(strings.ToUpper(uuid), addr-s.slide)
+	if err != nil {
+		return fmt.Errorf("failed to find symbol at %#x in %s: %v", addr, m.GetPath(), err)
+	}
+	s.vars["_"] = addr
+	name := s.demangle(sym.GetName())
+	if delta := addr - s.slide - sym.Start; delta != 0 {
+		name = fmt.Sprintf("%s + %d", name, delta)
+	}
+	fmt.Fprintf(w, "%#x: %s (%s)\n", addr, name, m.GetPath())
+	return nil
+}
+
+func (s *Shell) listHistory(w io.Writer, _ string) error {
+	for i, line := range s.history {
+		fmt.Fprintf(w, "%4d  %s\n", i+1, line)
+	}
+	return nil
+}
+
+func (s *Shell) help(w io.Writer, _ string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].help)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, exprHelp)
+	return nil
+}
+
+const exprHelp = `
+EXPR is a number (0x, 0o, 0b or decimal), a symbol (its slid address; quote names like ObjC methods or Swift symbols
+with backticks), a $variable ($_ is the last result and $slide the slide) or an expression of them with the
++ - * / % & | ^ << >> ~ operators and parentheses. The addresses in and out are slid (by $slide).
+Press TAB to complete the commands, dylibs and the symbols of the selected dylib.`
+
+// Exec runs a command line
+func (s *Shell) Exec(w io.Writer, line string) error {
+	line = strings.TrimSpace(line)
+	switch {
+	case len(line) == 0 || strings.HasPrefix(line, "#"):
+		return nil
+	case line == "!!":
+		if len(s.history) == 0 {
+			return fmt.Errorf("no history")
+		}
+		line = s.history[len(s.history)-1]
+		fmt.Fprintln(w, line)
+	case strings.HasPrefix(line, "!"):
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 1 || n > len(s.history) {
+			return fmt.Errorf("%s: event not found", line)
+		}
+		line = s.history[n-1]
+		fmt.Fprintln(w, line)
+	}
+	s.history = append(s.history, line)
+
+	name, args, _ := strings.Cut(line, " ")
+	switch name {
+	case "quit", "q":
+		name = "exit"
+	case "?":
+		name = "help"
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command '%s' (see help)", name)
+	}
+	return cmd.run(s, w, args)
+}
+
+/* COMPLETION */
+
+func (s *Shell) symbolWords() []string {
+	return s.names
+}
+
+func (s *Shell) exprWords() []string {
+	words := []string{"$slide"}
+	for name := range s.vars {
+		words = append(words, "$"+name)
+	}
+	return append(words, s.names...)
+}
+
+func (s *Shell) imageWords() []string {
+	if s.f == nil {
+		return nil
+	}
+	var words []string
+	for _, image := range s.f.Images {
+		words = append(words, image.Name, filepath.Base(image.Name))
+	}
+	return words
+}
+
+// Complete completes the word before pos in line; it returns the completed line and position and the candidates (if ambiguous)
+func (s *Shell) Complete(line string, pos int) (string, int, []string) {
+	before, after := line[:pos], line[pos:]
+	start := strings.LastIndex(before, " ") + 1
+	var quoted bool
+
+	var words []string
+	if name, _, ok := strings.Cut(strings.TrimLeft(before, " "), " "); !ok {
+		for name := range commands {
+			words = append(words, name)
+		}
+	} else if cmd, ok := commands[name]; ok && cmd.arg != nil {
+		words = cmd.arg(s)
+		switch {
+		case !cmd.expr: // the argument is a single name
+			start = strings.Index(before, name) + len(name) + 1
+		case strings.Count(before, "`")%2 == 1: // a quoted symbol
+			start = strings.LastIndex(before, "`") + 1
+			quoted = true
+		default:
+			start = strings.LastIndexAny(before, " (`+-*/%&|^~") + 1
+		}
+	}
+	word := before[start:]
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, w := range words {
+		if strings.HasPrefix(w, word) && !seen[w] {
+			seen[w] = true
+			matches = append(matches, w)
+		}
+	}
+	if len(matches) == 0 {
+		return line, pos, nil
+	}
+	slices.Sort(matches)
+	completed := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, completed) {
+			completed = completed[:len(completed)-1]
+		}
+	}
+	if len(matches) == 1 {
+		if quoted {
+			completed += "`"
+		}
+		completed += " "
+	}
+	before = before[:start] + completed
+	if len(matches) == 1 {
+		return before + after, len(before), nil
+	}
+	return before + after, len(before), matches
+}
+
+/* REPL */
+
+// Run runs the shell reading the commands from stdin (with line editing, history and completion in a terminal)
+func (s *Shell) Run() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if err := s.Exec(os.Stdout, scanner.Text()); err != nil {
+				if errors.Is(err, errExit) {
+					return nil
+				}
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+		}
+		return scanner.Err()
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set the terminal to raw mode: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, s.prompt())
+	if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		t.SetSize(width, height)
+	}
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		line, pos, candidates := s.Complete(line, pos)
+		if len(candidates) > 0 {
+			if len(candidates) > maxCompletions {
+				candidates = append(candidates[:maxCompletions], fmt.Sprintf("... (%d more)", len(candidates)-maxCompletions))
+			}
+			fmt.Fprintln(t, strings.Join(candidates, "  "))
+		}
+		return line, pos, true
+	}
+
+	fmt.Fprintln(t, "Type 'help' for the commands (TAB to complete, ↑/↓ for the history and 'exit' or ctrl+d to exit)")
+	for {
+		line, err := t.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := s.Exec(t, line); err != nil {
+			if errors.Is(err, errExit) {
+				return nil
+			}
+			fmt.Fprintf(t, "error: %v\n", err)
+		}
+		t.SetPrompt(s.prompt())
+	}
+}
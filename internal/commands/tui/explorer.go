@@ -0,0 +1,346 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+const previewHeight = 10
+
+var (
+	titleStyle    = color.New(color.Bold, color.FgHiBlue).SprintFunc()
+	selectedStyle = color.New(color.ReverseVideo).SprintFunc()
+	dirStyle      = color.New(color.FgHiCyan).SprintFunc()
+	faintStyle    = color.New(color.Faint).SprintFunc()
+	errorStyle    = color.New(color.FgHiRed).SprintFunc()
+)
+
+// item is an entry of a list
+type item struct {
+	name    string
+	info    func() string                     // the preview (or nil)
+	open    func() ([]*item, error)           // the entries it contains (or nil if it can't be opened)
+	extract func(dest string) (string, error) // extracts it into the dest folder and returns the created path (or nil)
+
+	preview *string // the cached preview
+}
+
+func (it *item) Preview() string {
+	if it.preview == nil {
+		var p string
+		if it.info != nil {
+			p = it.info()
+		}
+		it.preview = &p
+	}
+	return *it.preview
+}
+
+// list is an opened entry
+type list struct {
+	title  string
+	items  []*item
+	filter string
+	cursor int
+	offset int
+}
+
+func (l *list) visible() []*item {
+	if len(l.filter) == 0 {
+		return l.items
+	}
+	var items []*item
+	for _, it := range l.items {
+		if strings.Contains(strings.ToLower(it.name), strings.ToLower(l.filter)) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+func (l *list) selected() *item {
+	if items := l.visible(); l.cursor < len(items) {
+		return items[l.cursor]
+	}
+	return nil
+}
+
+func (l *list) move(delta int) {
+	l.cursor = max(min(l.cursor+delta, len(l.visible())-1), 0)
+}
+
+type openedMsg struct {
+	title string
+	items []*item
+	err   error
+}
+
+type extractedMsg struct {
+	path string
+	err  error
+}
+
+// Explorer is the model browsing an IPSW
+type Explorer struct {
+	dest      string
+	stack     []*list
+	status    string
+	busy      bool
+	filtering bool
+	height    int
+
+	mu      sync.Mutex
+	closers []func() error
+}
+
+// onClose registers a func closing an opened file or removing a temporary file
+func (e *Explorer) onClose(fn func() error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closers = append(e.closers, fn)
+}
+
+func (e *Explorer) current() *list {
+	return e.stack[len(e.stack)-1]
+}
+
+// Init implements Model
+func (e *Explorer) Init() Cmd {
+	return nil
+}
+
+// Update implements Model
+func (e *Explorer) Update(msg Msg) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case openedMsg:
+		e.busy = false
+		if msg.err != nil {
+			e.status = errorStyle(msg.err.Error())
+			return e, nil
+		}
+		e.status = ""
+		e.stack = append(e.stack, &list{title: msg.title, items: msg.items})
+	case extractedMsg:
+		e.busy = false
+		if msg.err != nil {
+			e.status = errorStyle(msg.err.Error())
+			return e, nil
+		}
+		e.status = fmt.Sprintf("Created %s", msg.path)
+	case KeyMsg:
+		if e.filtering {
+			return e, e.updateFilter(msg)
+		}
+		return e, e.updateKey(msg)
+	}
+	return e, nil
+}
+
+func (e *Explorer) updateFilter(key KeyMsg) Cmd {
+	l := e.current()
+	switch key {
+	case "ctrl+c":
+		return Quit
+	case "enter":
+		e.filtering = false
+	case "esc":
+		e.filtering = false
+		l.filter = ""
+	case "backspace":
+		if len(l.filter) > 0 {
+			_, size := utf8.DecodeLastRuneInString(l.filter)
+			l.filter = l.filter[:len(l.filter)-size]
+		}
+	default:
+		if utf8.RuneCountInString(string(key)) == 1 {
+			l.filter += string(key)
+		}
+	}
+	l.cursor, l.offset = 0, 0
+	return nil
+}
+
+func (e *Explorer) updateKey(key KeyMsg) Cmd {
+	l := e.current()
+	page := max(e.height-previewHeight-5, 1)
+	switch key {
+	case "q", "ctrl+c":
+		return Quit
+	case "up", "k":
+		l.move(-1)
+	case "down", "j":
+		l.move(1)
+	case "pgup":
+		l.move(-page)
+	case "pgdown":
+		l.move(page)
+	case "home", "g":
+		l.move(-len(l.items))
+	case "end", "G":
+		l.move(len(l.items))
+	case "/":
+		e.filtering = true
+	case "esc", "left", "h", "backspace":
+		if len(l.filter) > 0 {
+			l.filter, l.cursor, l.offset = "", 0, 0
+		} else if len(e.stack) > 1 {
+			e.stack = e.stack[:len(e.stack)-1]
+		}
+		e.status = ""
+	case "enter", "right", "l":
+		it := l.selected()
+		if it == nil || it.open == nil || e.busy {
+			break
+		}
+		e.busy = true
+		e.status = fmt.Sprintf("Opening %s...", it.name)
+		title := it.name
+		return func() Msg {
+			items, err := it.open()
+			return openedMsg{title: title, items: items, err: err}
+		}
+	case "e":
+		it := l.selected()
+		if it == nil || e.busy {
+			break
+		}
+		if it.extract == nil {
+			e.status = errorStyle(fmt.Sprintf("%s can't be extracted", it.name))
+			break
+		}
+		e.busy = true
+		e.status = fmt.Sprintf("Extracting %s...", it.name)
+		return func() Msg {
+			path, err := it.extract(e.dest)
+			return extractedMsg{path: path, err: err}
+		}
+	}
+	return nil
+}
+
+// truncate truncates the (ANSI colored) line to width visible runes
+func truncate(s string, width int) string {
+	var b strings.Builder
+	var n int
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' { // keep the escape sequences
+			end := strings.IndexByte(s[i:], 'm')
+			if end < 0 {
+				break
+			}
+			b.WriteString(s[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if n == width {
+			b.WriteString("\x1b[0m")
+			break
+		}
+		b.WriteRune(r)
+		n++
+		i += size
+	}
+	return b.String()
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// View implements Model
+func (e *Explorer) View(width, height int) string {
+	e.height = height
+	l := e.current()
+
+	var titles []string
+	for _, l := range e.stack {
+		titles = append(titles, l.title)
+	}
+	lines := []string{
+		titleStyle(truncate(strings.Join(titles, " › "), width)),
+		faintStyle(strings.Repeat("─", width)),
+	}
+
+	// the list
+	items := l.visible()
+	rows := max(height-previewHeight-5, 1)
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	} else if l.cursor >= l.offset+rows {
+		l.offset = l.cursor - rows + 1
+	}
+	for i := l.offset; i < l.offset+rows; i++ {
+		if i >= len(items) {
+			lines = append(lines, "")
+			continue
+		}
+		name := items[i].name
+		if items[i].open != nil {
+			name += "/"
+		}
+		name = truncate(" "+name, width-1)
+		switch {
+		case i == l.cursor:
+			lines = append(lines, selectedStyle(pad(name, width-1)))
+		case items[i].open != nil:
+			lines = append(lines, dirStyle(name))
+		default:
+			lines = append(lines, name)
+		}
+	}
+
+	// the preview of the selected entry
+	lines = append(lines, faintStyle(strings.Repeat("─", width)))
+	var preview []string
+	if it := l.selected(); it != nil && (it.preview != nil || !e.busy) { // don't read the files being extracted
+		preview = strings.Split(strings.TrimRight(it.Preview(), "\n"), "\n")
+	}
+	for i := range previewHeight {
+		if i < len(preview) {
+			lines = append(lines, truncate(" "+preview[i], width))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	// the status and help
+	status := e.status
+	switch {
+	case e.filtering:
+		status = "/" + l.filter + "█"
+	case len(status) == 0 && len(l.filter) > 0:
+		status = fmt.Sprintf("filter: %s (%d/%d)", l.filter, len(items), len(l.items))
+	case len(status) == 0:
+		status = fmt.Sprintf("%d/%d", min(l.cursor+1, len(items)), len(items))
+	}
+	lines = append(lines,
+		truncate(status, width),
+		faintStyle(truncate(fmt.Sprintf("↑/k ↓/j move • enter/l open • esc/h back • e extract (to %s) • / filter • q quit", filepath.Clean(e.dest)), width)),
+	)
+	return strings.Join(lines, "\n")
+}
+
+// Close closes the opened files and removes the temporary files
+func (e *Explorer) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var errs []string
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		if err := e.closers[i](); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to clean up: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
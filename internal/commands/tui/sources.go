@@ -0,0 +1,507 @@
+package tui
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/aea"
+	"github.com/blacktop/ipsw/pkg/apfs"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/blacktop/ipsw/pkg/kernelcache"
+	"github.com/dustin/go-humanize"
+)
+
+// the dyld_shared_cache files that aren't a cache (or are one of its subcaches)
+var subCacheRE = regexp.MustCompile(`\.(\d+|symbols|map|atlas|dylddata)$`)
+
+// Config is the configuration of the IPSW explorer
+type Config struct {
+	IPSW   string // the IPSW to browse
+	Output string // the folder to extract to
+	PemDB  string // the AEA pem DB JSON file (to decrypt the DMGs)
+}
+
+type source struct {
+	conf *Config
+	info *info.Info
+	zr   *zip.ReadCloser
+	e    *Explorer
+	dmgs map[string]*apfs.FS
+}
+
+// NewExplorer returns the model browsing the IPSW's archive, filesystem, kernelcaches and dyld_shared_caches
+func NewExplorer(conf *Config) (*Explorer, error) {
+	i, err := info.Parse(conf.IPSW)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPSW: %v", err)
+	}
+	zr, err := zip.OpenReader(conf.IPSW)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IPSW: %v", err)
+	}
+	e := &Explorer{dest: conf.Output}
+	e.onClose(zr.Close)
+	s := &source{conf: conf, info: i, zr: zr, e: e, dmgs: make(map[string]*apfs.FS)}
+
+	summary := func() string {
+		return i.String()
+	}
+	e.stack = []*list{{
+		title: filepath.Base(conf.IPSW),
+		items: []*item{
+			{name: "Archive", info: summary, open: func() ([]*item, error) { return s.archive(""), nil }},
+			{name: "Filesystem", info: summary, open: s.filesystem},
+			{name: "Kernelcaches", info: summary, open: s.kernelcaches},
+			{name: "dyld_shared_cache", info: summary, open: s.sharedCaches},
+		},
+	}}
+	return e, nil
+}
+
+/* ARCHIVE */
+
+func (s *source) archive(dir string) []*item {
+	var dirs, files []*item
+	seen := make(map[string]bool)
+	for _, zf := range s.zr.File {
+		name := strings.TrimSuffix(zf.Name, "/")
+		if !strings.HasPrefix(name, dir) || name == strings.TrimSuffix(dir, "/") {
+			continue
+		}
+		rel, _, isDir := strings.Cut(strings.TrimPrefix(name, dir), "/")
+		isDir = isDir || zf.FileInfo().IsDir()
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		if isDir {
+			prefix := dir + rel + "/"
+			dirs = append(dirs, &item{
+				name: rel,
+				open: func() ([]*item, error) { return s.archive(prefix), nil },
+				extract: func(dest string) (string, error) {
+					return s.unzip(prefix, dest)
+				},
+			})
+			continue
+		}
+		it := &item{
+			name: rel,
+			info: func() string {
+				return fmt.Sprintf("Name:       %s\nSize:       %s\nCompressed: %s\nModified:   %s",
+					zf.Name,
+					humanize.Bytes(zf.UncompressedSize64),
+					humanize.Bytes(zf.CompressedSize64),
+					zf.Modified.Format(time.RFC3339))
+			},
+			extract: func(dest string) (string, error) {
+				return s.unzip(zf.Name, dest)
+			},
+		}
+		if ext := filepath.Ext(zf.Name); ext == ".dmg" || ext == ".aea" {
+			it.open = func() ([]*item, error) {
+				fsys, err := s.dmg(zf.Name)
+				if err != nil {
+					return nil, err
+				}
+				return s.apfsDir(fsys, "/")
+			}
+		}
+		files = append(files, it)
+	}
+	byName := func(a, b *item) int { return strings.Compare(a.name, b.name) }
+	slices.SortFunc(dirs, byName)
+	slices.SortFunc(files, byName)
+	return append(dirs, files...)
+}
+
+// unzip extracts the archive's file (or folder) into dest (keeping its path)
+func (s *source) unzip(name, dest string) (string, error) {
+	for _, zf := range s.zr.File {
+		if zf.FileInfo().IsDir() || (zf.Name != name && !(strings.HasSuffix(name, "/") && strings.HasPrefix(zf.Name, name))) {
+			continue
+		}
+		fname := filepath.Join(dest, filepath.Clean(zf.Name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+			return "", fmt.Errorf("failed to create directory %s: %v", filepath.Dir(fname), err)
+		}
+		if err := copyZipFile(zf, fname); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dest, filepath.Clean(name)), nil
+}
+
+func copyZipFile(zf *zip.File, fname string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", zf.Name, err)
+	}
+	defer rc.Close()
+	out, err := os.Create(fname)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", fname, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, rc); err != nil {
+		return fmt.Errorf("failed to extract %s: %v", zf.Name, err)
+	}
+	return nil
+}
+
+/* FILESYSTEM */
+
+// dmg extracts (and decrypts) the archive's DMG into a temporary folder and opens its APFS volume
+func (s *source) dmg(name string) (*apfs.FS, error) {
+	if fsys, ok := s.dmgs[name]; ok {
+		return fsys, nil
+	}
+	tmp, err := os.MkdirTemp("", "ipsw_tui")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	s.e.onClose(func() error { return os.RemoveAll(tmp) })
+	dmgs, err := utils.Unzip(s.conf.IPSW, tmp, func(f *zip.File) bool {
+		return f.Name == name
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s from IPSW: %v", name, err)
+	}
+	if len(dmgs) == 0 {
+		return nil, fmt.Errorf("failed to find %s in IPSW", name)
+	}
+	dmgPath := dmgs[0]
+	if filepath.Ext(dmgPath) == ".aea" {
+		dmgPath, err = aea.Decrypt(&aea.DecryptConfig{
+			Input:  dmgPath,
+			Output: tmp,
+			PemDB:  s.conf.PemDB,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AEA encrypted DMG: %v", err)
+		}
+	}
+	fsys, err := apfs.Open(dmgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open APFS volume in %s: %v", name, err)
+	}
+	s.e.onClose(fsys.Close)
+	s.dmgs[name] = fsys
+	return fsys, nil
+}
+
+func (s *source) filesystem() ([]*item, error) {
+	name, err := s.info.GetFileSystemOsDmg()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filesystem DMG: %v", err)
+	}
+	fsys, err := s.dmg(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.apfsDir(fsys, "/")
+}
+
+func (s *source) apfsDir(fsys *apfs.FS, dir string) ([]*item, error) {
+	files, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(files, func(a, b *apfs.File) int {
+		if a.IsDir() != b.IsDir() {
+			if a.IsDir() {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	var items []*item
+	for _, f := range files {
+		it := &item{
+			name: path.Base(f.Path),
+			info: func() string { return apfsInfo(fsys, f) },
+			extract: func(dest string) (string, error) {
+				return apfsExtract(fsys, f, dest)
+			},
+		}
+		if f.IsDir() {
+			it.open = func() ([]*item, error) { return s.apfsDir(fsys, f.Path) }
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+func apfsInfo(fsys *apfs.FS, f *apfs.File) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Path:     %s\nMode:     %s\n", f.Path, f.Mode)
+	if !f.IsDir() {
+		fmt.Fprintf(&b, "Size:     %s\n", humanize.Bytes(f.Size))
+	}
+	fmt.Fprintf(&b, "Modified: %s\n", f.ModTime.Format(time.RFC3339))
+	switch {
+	case f.Firmlink != "":
+		fmt.Fprintf(&b, "Firmlink: %s\n", f.Firmlink)
+	case f.Link != "":
+		fmt.Fprintf(&b, "Link:     %s\n", f.Link)
+	case f.Mode.IsRegular() && f.Size >= 4:
+		r, err := fsys.OpenFile(f)
+		if err != nil {
+			break
+		}
+		if m, err := macho.NewFile(io.NewSectionReader(r, 0, int64(f.Size))); err == nil {
+			fmt.Fprintf(&b, "Mach-O:   %s %s %s\n", m.CPU, m.SubCPU.String(m.CPU), m.Type)
+			if id := m.UUID(); id != nil {
+				fmt.Fprintf(&b, "UUID:     %s\n", id)
+			}
+			if sv := m.SourceVersion(); sv != nil {
+				fmt.Fprintf(&b, "Version:  %s\n", sv.Version)
+			}
+			m.Close()
+		} else if ff, err := macho.NewFatFile(io.NewSectionReader(r, 0, int64(f.Size))); err == nil {
+			var arches []string
+			for _, arch := range ff.Arches {
+				arches = append(arches, arch.SubCPU.String(arch.CPU))
+			}
+			fmt.Fprintf(&b, "Mach-O:   universal (%s)\n", strings.Join(arches, ", "))
+			ff.Close()
+		}
+	}
+	return b.String()
+}
+
+// apfsExtract extracts the file (or folder) into dest (keeping its path)
+func apfsExtract(fsys *apfs.FS, f *apfs.File, dest string) (string, error) {
+	if err := fsys.Walk(f.Path, func(f *apfs.File) error {
+		return fsys.Extract(f, filepath.Join(dest, filepath.Clean(f.Path)))
+	}); err != nil {
+		return "", fmt.Errorf("failed to extract %s: %v", f.Path, err)
+	}
+	return filepath.Join(dest, filepath.Clean(f.Path)), nil
+}
+
+/* KERNELCACHES */
+
+func (s *source) kernelcaches() ([]*item, error) {
+	var items []*item
+	for _, zf := range s.zr.File {
+		if !strings.Contains(path.Base(zf.Name), "kernelcache") {
+			continue
+		}
+		items = append(items, &item{
+			name: zf.Name,
+			info: func() string {
+				return fmt.Sprintf("Name:    %s\nSize:    %s\nDevices: %s",
+					zf.Name,
+					humanize.Bytes(zf.UncompressedSize64),
+					strings.Join(s.info.GetDevicesForKernelCache(path.Base(zf.Name)), ", "))
+			},
+			open: func() ([]*item, error) { return s.kexts(zf) },
+			extract: func(dest string) (string, error) {
+				dat, err := decompressKernelcache(zf)
+				if err != nil {
+					return "", err
+				}
+				fname := filepath.Join(dest, path.Base(zf.Name)+".decompressed")
+				if err := os.MkdirAll(dest, 0o750); err != nil {
+					return "", fmt.Errorf("failed to create directory %s: %v", dest, err)
+				}
+				return fname, os.WriteFile(fname, dat, 0o660)
+			},
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("failed to find a kernelcache in IPSW")
+	}
+	return items, nil
+}
+
+func decompressKernelcache(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", zf.Name, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", zf.Name, err)
+	}
+	kc, err := kernelcache.ParseImg4Data(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compressed kernelcache Img4: %v", err)
+	}
+	dat, err := kernelcache.DecompressData(kc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress kernelcache %s: %v", zf.Name, err)
+	}
+	return dat, nil
+}
+
+func (s *source) kexts(zf *zip.File) ([]*item, error) {
+	dat, err := decompressKernelcache(zf)
+	if err != nil {
+		return nil, err
+	}
+	m, err := macho.NewFile(bytes.NewReader(dat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kernelcache: %v", err)
+	}
+	if m.FileTOC.FileHeader.Type != types.MH_FILESET {
+		return nil, fmt.Errorf("kernelcache type is not MH_FILESET (KEXT browsing not supported yet)")
+	}
+	var dcf *fixupchains.DyldChainedFixups
+	if m.HasFixups() {
+		dcf, err = m.DyldChainedFixups()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixups from in memory MachO: %v", err)
+		}
+	}
+
+	var items []*item
+	for _, fse := range m.FileSets() {
+		items = append(items, &item{
+			name: fse.EntryID,
+			info: func() string {
+				var b strings.Builder
+				fmt.Fprintf(&b, "Bundle:  %s\nAddress: %#x\nOffset:  %#x\n", fse.EntryID, fse.Addr, fse.FileOffset)
+				if kext, err := m.GetFileSetFileByName(fse.EntryID); err == nil {
+					if id := kext.UUID(); id != nil {
+						fmt.Fprintf(&b, "UUID:    %s\n", id)
+					}
+					if sv := kext.SourceVersion(); sv != nil {
+						fmt.Fprintf(&b, "Version: %s\n", sv.Version)
+					}
+				}
+				return b.String()
+			},
+			extract: func(dest string) (string, error) {
+				kext, err := m.GetFileSetFileByName(fse.EntryID)
+				if err != nil {
+					return "", fmt.Errorf("failed to parse KEXT %s: %v", fse.EntryID, err)
+				}
+				if err := os.MkdirAll(dest, 0o750); err != nil {
+					return "", fmt.Errorf("failed to create directory %s: %v", dest, err)
+				}
+				fname := filepath.Join(dest, fse.EntryID)
+				if err := kext.Export(fname, dcf, m.GetBaseAddress(), nil); err != nil {
+					return "", fmt.Errorf("failed to export KEXT %s; %v", fse.EntryID, err)
+				}
+				return fname, nil
+			},
+		})
+	}
+	return items, nil
+}
+
+/* DYLD_SHARED_CACHE */
+
+func (s *source) sharedCaches() ([]*item, error) {
+	name, err := s.info.GetSystemOsDmg()
+	if err != nil {
+		if !errors.Is(err, info.ErrorCryptexNotFound) {
+			return nil, fmt.Errorf("failed to get SystemOS DMG: %v", err)
+		}
+		// older IPSWs don't have cryptexes
+		name, err = s.info.GetFileSystemOsDmg()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get filesystem DMG: %v", err)
+		}
+	}
+	fsys, err := s.dmg(name)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := dyld.GetDscPathsInAPFS(fsys, false, true)
+	if err != nil {
+		return nil, err
+	}
+	var items []*item
+	for _, p := range paths {
+		if subCacheRE.MatchString(p) {
+			continue
+		}
+		items = append(items, &item{
+			name: p,
+			info: func() string {
+				var b strings.Builder
+				for _, sub := range paths {
+					if sub == p || strings.HasPrefix(sub, p+".") {
+						if f, err := fsys.Stat(sub); err == nil {
+							fmt.Fprintf(&b, "%-10s %s\n", humanize.Bytes(f.Size), path.Base(sub))
+						}
+					}
+				}
+				return b.String()
+			},
+			open: func() ([]*item, error) { return s.images(fsys, p, paths) },
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("failed to find a dyld_shared_cache in %s", name)
+	}
+	return items, nil
+}
+
+// images extracts the dyld_shared_cache (and its subcaches) into a temporary folder and lists its images
+func (s *source) images(fsys *apfs.FS, cache string, paths []string) ([]*item, error) {
+	tmp, err := os.MkdirTemp("", "ipsw_tui")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	s.e.onClose(func() error { return os.RemoveAll(tmp) })
+	for _, p := range paths {
+		if p != cache && !strings.HasPrefix(p, cache+".") {
+			continue
+		}
+		f, err := fsys.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := fsys.Extract(f, filepath.Join(tmp, path.Base(p))); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %v", p, err)
+		}
+	}
+	f, err := dyld.Open(filepath.Join(tmp, path.Base(cache)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dyld_shared_cache %s: %v", cache, err)
+	}
+	s.e.onClose(f.Close)
+
+	var items []*item
+	for _, img := range f.Images {
+		items = append(items, &item{
+			name: img.Name,
+			info: func() string {
+				return fmt.Sprintf("Name:    %s\nAddress: %#x\nUUID:    %s", img.Name, img.Info.Address, img.CacheImageTextInfo.UUID)
+			},
+			extract: func(dest string) (string, error) {
+				if err := os.MkdirAll(dest, 0o750); err != nil {
+					return "", fmt.Errorf("failed to create directory %s: %v", dest, err)
+				}
+				fname := filepath.Join(dest, filepath.Base(img.Name))
+				if err := dsc.ExtractDylib(f, img, fname, &dsc.ExtractConfig{}); err != nil {
+					return "", fmt.Errorf("failed to extract %s: %v", img.Name, err)
+				}
+				return fname, nil
+			},
+		})
+	}
+	return items, nil
+}
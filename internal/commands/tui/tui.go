@@ -0,0 +1,133 @@
+// Package tui is a minimal Elm-style (model, update, view) terminal UI runtime and the IPSW explorer built on it
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Msg is a key press or the result of a Cmd
+type Msg any
+
+// Cmd is run in the background and its Msg is sent to the model's Update
+type Cmd func() Msg
+
+// KeyMsg is a key press: the name of a special key (i.e. "up", "enter", "esc", "ctrl+c") or the typed character
+type KeyMsg string
+
+type quitMsg struct{}
+
+// Quit is the Cmd that exits the program
+func Quit() Msg {
+	return quitMsg{}
+}
+
+// Model is the state of a program
+type Model interface {
+	// Init returns the Cmd to run at startup (or nil)
+	Init() Cmd
+	// Update handles a message and returns the updated model and the Cmd to run (or nil)
+	Update(Msg) (Model, Cmd)
+	// View renders the model to the screen size
+	View(width, height int) string
+}
+
+var keys = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOC":  "right",
+	"\x1bOD":  "left",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1b[1~": "home",
+	"\x1b[4~": "end",
+	"\x1b":    "esc",
+	"\r":      "enter",
+	"\n":      "enter",
+	"\x7f":    "backspace",
+	"\x08":    "backspace",
+	"\t":      "tab",
+	"\x03":    "ctrl+c",
+}
+
+func readKeys(msgs chan<- Msg) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			msgs <- quitMsg{}
+			return
+		}
+		in := string(buf[:n])
+		if key, ok := keys[in]; ok {
+			msgs <- KeyMsg(key)
+			continue
+		}
+		if strings.HasPrefix(in, "\x1b") {
+			continue // unsupported escape sequence
+		}
+		for _, r := range in {
+			msgs <- KeyMsg(string(r))
+		}
+	}
+}
+
+// Run runs the program in the terminal's alternate screen until a Cmd returns Quit
+func Run(m Model) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("not running in a terminal")
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set the terminal to raw mode: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString("\x1b[?1049h\x1b[?25l") // alternate screen and hide the cursor
+	defer func() {
+		out.WriteString("\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	msgs := make(chan Msg)
+	run := func(cmd Cmd) {
+		if cmd != nil {
+			go func() { msgs <- cmd() }()
+		}
+	}
+	go readKeys(msgs)
+	run(m.Init())
+
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		lines := strings.Split(m.View(width, height), "\n")
+		if len(lines) > height {
+			lines = lines[:height]
+		}
+		out.WriteString("\x1b[H\x1b[2J")
+		out.WriteString(strings.Join(lines, "\r\n"))
+		out.Flush()
+
+		msg := <-msgs
+		if _, ok := msg.(quitMsg); ok {
+			return nil
+		}
+		var cmd Cmd
+		m, cmd = m.Update(msg)
+		run(cmd)
+	}
+}
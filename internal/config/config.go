@@ -6,7 +6,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/blacktop/ipsw/api/server/audit"
+	"github.com/blacktop/ipsw/api/server/auth"
+	"github.com/blacktop/ipsw/api/server/cache"
+	"github.com/blacktop/ipsw/api/server/health"
+	"github.com/blacktop/ipsw/api/server/limits"
+	"github.com/blacktop/ipsw/api/server/mtls"
+	"github.com/blacktop/ipsw/internal/jobs"
+	"github.com/blacktop/ipsw/internal/storage"
+	"github.com/blacktop/ipsw/internal/syms/queue"
+	"github.com/blacktop/ipsw/internal/webhook"
 	env "github.com/caarlos0/env/v8"
 	"github.com/spf13/viper"
 )
@@ -19,6 +30,59 @@ type daemon struct {
 	LogFile string `json:"logfile" env:"DAEMON_LOGFILE"`
 	PemDB   string `json:"pem_db" mapstructure:"pem-db" env:"DAEMON_PEM_DB"`
 	SigsDir string `json:"sigs_dir" mapstructure:"sigs-dir" env:"DAEMON_SIGS_DIR"`
+	// DisableUI disables the web UI served at /ui/
+	DisableUI bool `json:"disable_ui" mapstructure:"disable-ui" env:"DAEMON_DISABLE_UI"`
+	// GRPCPort serves the gRPC API alongside the REST API (disabled if 0)
+	GRPCPort int `json:"grpc_port" mapstructure:"grpc-port" env:"DAEMON_GRPC_PORT"`
+	// JobRetries is the number of attempts per background job (defaults to 3)
+	JobRetries int `json:"job_retries" mapstructure:"job-retries" env:"DAEMON_JOB_RETRIES"`
+	// Proxy is the HTTP/HTTPS/SOCKS5 proxy used by the downloads and other network clients
+	Proxy string `json:"proxy" env:"DAEMON_PROXY"`
+	// CACerts are the extra PEM CA bundles trusted by the network clients
+	CACerts []string `json:"ca_certs" mapstructure:"ca-certs" env:"DAEMON_CA_CERTS"`
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header is trusted for the client IP
+	TrustedProxies []string `json:"trusted_proxies" mapstructure:"trusted-proxies" env:"DAEMON_TRUSTED_PROXIES"`
+	// Auth is the API-key/JWT auth config (auth is disabled if empty)
+	Auth auth.Config `json:"auth"`
+	// Webhooks are POSTed the job and watch events
+	Webhooks []webhook.Config `json:"webhooks,omitempty"`
+	// Watch polls for new builds (posting them to the webhooks)
+	Watch watch `json:"watch"`
+	// Storage is where the job artifacts are uploaded (disabled if the driver is empty)
+	Storage storage.Config `json:"storage"`
+	// Cluster shares the jobs with other daemons using the same (postgres) database
+	Cluster cluster `json:"cluster"`
+	// Pipelines are the pipeline files (or folders of them) to run
+	Pipelines []string `json:"pipelines"`
+	// Plugins are the folders the plugins (run by the symbol scans) are loaded from
+	Plugins []string `json:"plugins"`
+	// Cache caches the analysis responses by the digests of their input files
+	Cache cache.Config `json:"cache"`
+	// Audit records the API requests in the database's audit log
+	Audit audit.Config `json:"audit"`
+	// Limits are the request rate limits and download/storage quotas of the API keys
+	Limits limits.Config `json:"limits"`
+	// Health is the readiness check (/readyz) config
+	Health health.Config `json:"health"`
+	// TLS terminates TLS (and verifies the client certificates) on the REST and gRPC listeners
+	TLS mtls.Config `json:"tls"`
+	// ScanQueue downloads and scans the IPSW/OTA URLs queued to feed the symbol server
+	ScanQueue queue.Config `json:"scan_queue" mapstructure:"scan-queue"`
+}
+
+type cluster struct {
+	Role     string   `json:"role"`
+	WorkerID string   `json:"worker_id" mapstructure:"worker-id"`
+	Capacity int      `json:"capacity"`
+	Kinds    []string `json:"kinds"`
+}
+
+type watch struct {
+	Devices  []string      `json:"devices"`
+	Beta     bool          `json:"beta"`
+	IPSW     bool          `json:"ipsw"`
+	Interval time.Duration `json:"interval"`
+	State    string        `json:"state"`
 }
 
 type database struct {
@@ -61,10 +125,30 @@ func (c *Config) verify() error {
 	} else if strings.HasPrefix(c.Daemon.Socket, "~/") {
 		c.Daemon.Socket = filepath.Join(home, c.Daemon.Socket[2:]) // TODO: is this bad practice?
 	}
+	// verify watch (the pipelines can also watch devices)
+	if len(c.Daemon.Watch.Devices) > 0 || len(c.Daemon.Pipelines) > 0 {
+		if c.Daemon.Watch.Interval == 0 {
+			c.Daemon.Watch.Interval = time.Hour
+		} else if c.Daemon.Watch.Interval < time.Minute {
+			return fmt.Errorf("config: watch interval must be at least 1m")
+		}
+		if c.Daemon.Watch.State == "" {
+			c.Daemon.Watch.State = filepath.Join(home, ".config", "ipsw", "ipswd_watch.json")
+		} else if strings.HasPrefix(c.Daemon.Watch.State, "~/") {
+			c.Daemon.Watch.State = filepath.Join(home, c.Daemon.Watch.State[2:])
+		}
+	}
 	// verify database
 	if c.Database.BatchSize == 0 {
 		c.Database.BatchSize = 1000
 	}
+	// verify cluster
+	if _, err := jobs.ParseRole(c.Daemon.Cluster.Role); err != nil {
+		return fmt.Errorf("config: cluster %v", err)
+	}
+	if c.Daemon.Cluster.Role != "" && c.Database.Driver != "postgres" {
+		return fmt.Errorf("config: cluster mode requires a postgres database shared by the daemons")
+	}
 
 	return nil
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/blacktop/ipsw/api/server"
 	"github.com/blacktop/ipsw/internal/config"
 	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/jobs"
 	"github.com/gin-gonic/gin"
 )
 
@@ -71,24 +73,59 @@ func (d *daemon) Start() (err error) {
 	if err != nil {
 		return err
 	}
+	if err := download.SetNetworkConfig(d.conf.Daemon.Proxy, d.conf.Daemon.CACerts); err != nil {
+		return fmt.Errorf("failed to set network config: %w", err)
+	}
+	role, err := jobs.ParseRole(d.conf.Daemon.Cluster.Role)
+	if err != nil {
+		return err
+	}
 	if d.conf.Daemon.Debug {
 		gin.SetMode(gin.DebugMode)
 	} else {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	d.server = server.NewServer(&server.Config{
-		Host:    d.conf.Daemon.Host,
-		Port:    d.conf.Daemon.Port,
-		Socket:  d.conf.Daemon.Socket,
-		Debug:   d.conf.Daemon.Debug,
-		LogFile: d.conf.Daemon.LogFile,
-		PemDB:   d.conf.Daemon.PemDB,
-		SigsDir: d.conf.Daemon.SigsDir,
+		Host:       d.conf.Daemon.Host,
+		Port:       d.conf.Daemon.Port,
+		Socket:     d.conf.Daemon.Socket,
+		Debug:      d.conf.Daemon.Debug,
+		LogFile:    d.conf.Daemon.LogFile,
+		PemDB:      d.conf.Daemon.PemDB,
+		SigsDir:    d.conf.Daemon.SigsDir,
+		GRPCPort:   d.conf.Daemon.GRPCPort,
+		JobRetries: d.conf.Daemon.JobRetries,
+		Auth:       d.conf.Daemon.Auth,
+		Webhooks:   d.conf.Daemon.Webhooks,
+		Watch: download.WatchConfig{
+			Devices:   d.conf.Daemon.Watch.Devices,
+			Beta:      d.conf.Daemon.Watch.Beta,
+			IPSWs:     d.conf.Daemon.Watch.IPSW,
+			StateFile: d.conf.Daemon.Watch.State,
+		},
+		WatchInterval:  d.conf.Daemon.Watch.Interval,
+		Storage:        d.conf.Daemon.Storage,
+		Pipelines:      d.conf.Daemon.Pipelines,
+		DisableUI:      d.conf.Daemon.DisableUI,
+		Plugins:        d.conf.Daemon.Plugins,
+		Cache:          d.conf.Daemon.Cache,
+		Audit:          d.conf.Daemon.Audit,
+		Limits:         d.conf.Daemon.Limits,
+		TrustedProxies: d.conf.Daemon.TrustedProxies,
+		Health:         d.conf.Daemon.Health,
+		TLS:            d.conf.Daemon.TLS,
+		ScanQueue:      d.conf.Daemon.ScanQueue,
+		Cluster: jobs.ClusterConfig{
+			Role:     role,
+			WorkerID: d.conf.Daemon.Cluster.WorkerID,
+			Capacity: d.conf.Daemon.Cluster.Capacity,
+			Kinds:    d.conf.Daemon.Cluster.Kinds,
+		},
 	})
 	if err := d.setupDB(); err != nil {
 		return err
 	}
-	return d.server.Start(d.db)
+	return d.server.Start(db.WithMetrics(d.db))
 }
 
 func (d *daemon) Stop() error {
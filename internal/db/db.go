@@ -2,6 +2,9 @@
 package db
 
 import (
+	"context"
+	"time"
+
 	"github.com/blacktop/ipsw/internal/model"
 )
 
@@ -10,6 +13,9 @@ type Database interface {
 	// Connect connects to the database.
 	Connect() error
 
+	// Ping checks the database connection is alive.
+	Ping(ctx context.Context) error
+
 	// Create creates a new entry in the database.
 	// It returns gorm.ErrDuplicatedKey if the key already exists.
 	Create(value any) error
@@ -26,6 +32,9 @@ type Database interface {
 	// It returns ErrNotFound if the IPSW does not exist.
 	GetIPSW(version, build, device string) (*model.Ipsw, error)
 
+	// GetIPSWs returns the IPSWs (with their devices, kernelcaches and dyld_shared_caches but not their files).
+	GetIPSWs() ([]*model.Ipsw, error)
+
 	// GetDSC returns the DyldSharedCache for the given UUID.
 	GetDSC(uuid string) (*model.DyldSharedCache, error)
 
@@ -41,6 +50,99 @@ type Database interface {
 	// GetSymbols returns all symbols for the given UUID.
 	GetSymbols(uuid string) ([]*model.Symbol, error)
 
+	// Enqueue adds items to the download queue.
+	// Items that are already queued are skipped (failed items are reset to pending).
+	Enqueue(items ...*model.QueueItem) error
+
+	// GetQueue returns the download queue items with the given status (or all items if status is empty).
+	GetQueue(status model.QueueStatus) ([]*model.QueueItem, error)
+
+	// NextQueueItem claims the next pending download queue item that is ready to be attempted.
+	// It returns ErrNotFound if no item is ready.
+	NextQueueItem() (*model.QueueItem, error)
+
+	// ClearQueue removes the download queue items with the given status (or all items if status is empty).
+	ClearQueue(status model.QueueStatus) error
+
+	// EnqueueScans adds items to the symbol scan queue.
+	// Items whose URL is already queued are skipped (failed items are reset to pending and the priority
+	// of the pending items is raised to the new item's).
+	EnqueueScans(items ...*model.ScanItem) error
+
+	// GetScanQueue returns the symbol scan queue items with the given status (or all items if status is empty),
+	// highest priority first.
+	GetScanQueue(status model.QueueStatus) ([]*model.ScanItem, error)
+
+	// NextScanItem claims the highest priority pending symbol scan queue item that is ready to be attempted.
+	// It returns ErrNotFound if no item is ready.
+	NextScanItem() (*model.ScanItem, error)
+
+	// DeleteScanItem removes a symbol scan queue item.
+	// It returns ErrNotFound if the item does not exist.
+	DeleteScanItem(id uint) error
+
+	// SaveJob creates or updates a daemon job.
+	SaveJob(job *model.Job) error
+
+	// SaveAssignedJob updates a daemon job if it is still assigned to job.Worker.
+	// It returns false if the job was reassigned to another worker (or no longer exists).
+	SaveAssignedJob(job *model.Job) (bool, error)
+
+	// GetJobs returns the daemon jobs with the given state (or all jobs if state is empty).
+	GetJobs(state model.JobState) ([]*model.Job, error)
+
+	// GetJob returns the daemon job with the given ID.
+	// It returns ErrNotFound if the job does not exist.
+	GetJob(id string) (*model.Job, error)
+
+	// GetAssignedJobs returns the unfinished daemon jobs assigned to the worker.
+	GetAssignedJobs(worker string) ([]*model.Job, error)
+
+	// AssignJob assigns a pending daemon job that isn't assigned yet to the worker.
+	// It returns false if the job was already assigned (or is no longer pending).
+	AssignJob(id, worker string) (bool, error)
+
+	// CancelJob asks the worker of a daemon job to cancel it.
+	// It returns ErrNotFound if the job does not exist.
+	CancelJob(id string) error
+
+	// SaveWorker creates or updates a daemon worker.
+	SaveWorker(worker *model.Worker) error
+
+	// GetWorkers returns the daemon workers.
+	GetWorkers() ([]*model.Worker, error)
+
+	// DeleteWorker removes a daemon worker.
+	DeleteWorker(id string) error
+
+	// SaveAnalyses creates or updates the results of plugin analyzers.
+	SaveAnalyses(analyses ...*model.Analysis) error
+
+	// GetAnalyses returns the results of the plugin analyzers run on the IPSW.
+	GetAnalyses(ipswID string) ([]*model.Analysis, error)
+
+	// SaveFirmwareKeys creates or updates firmware decryption keys (by device, build and filename).
+	SaveFirmwareKeys(keys ...*model.FirmwareKey) error
+
+	// GetFirmwareKeys returns the firmware decryption keys of the device/build.
+	GetFirmwareKeys(device, build string) ([]*model.FirmwareKey, error)
+
+	// SaveAEAKey creates or updates cached AEA key material.
+	SaveAEAKey(key *model.AEAKey) error
+
+	// GetAEAKey returns the cached AEA key material of the given kind and name.
+	// It returns ErrNotFound if the key does not exist.
+	GetAEAKey(kind, name string) (*model.AEAKey, error)
+
+	// AddAuditEntry records an API request in the audit log.
+	AddAuditEntry(entry *model.AuditEntry) error
+
+	// GetAuditEntries returns the audit log entries matching the query (oldest first).
+	GetAuditEntries(query model.AuditQuery) ([]*model.AuditEntry, error)
+
+	// DeleteAuditEntries removes the audit log entries older than before and returns how many were removed.
+	DeleteAuditEntries(before time.Time) (int64, error)
+
 	// Save updates the IPSW.
 	// It overwrites any previous value for that IPSW.
 	Save(value any) error
@@ -1,10 +1,14 @@
 package db
 
 import (
+	"context"
 	"encoding/gob"
 	"fmt"
 	"os"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/blacktop/ipsw/internal/model"
 	"github.com/pkg/errors"
@@ -15,6 +19,27 @@ import (
 type Memory struct {
 	IPSWs map[string]*model.Ipsw
 	Path  string
+
+	// Queue is the download queue (it is NOT persisted to Path)
+	Queue []*model.QueueItem
+	qmu   sync.Mutex
+	// Scans is the symbol scan queue (it is NOT persisted to Path)
+	Scans []*model.ScanItem
+	// Jobs are the daemon jobs (they are NOT persisted to Path)
+	Jobs []*model.Job
+	// Workers are the daemon workers (they are NOT persisted to Path)
+	Workers []*model.Worker
+	jmu     sync.Mutex
+	// Analyses are the plugin analyzer results (they are NOT persisted to Path)
+	Analyses map[string]*model.Analysis
+	amu      sync.Mutex
+	// Audit is the audit log (it is NOT persisted to Path)
+	Audit []*model.AuditEntry
+	// FirmwareKeys are the firmware decryption keys (they are NOT persisted to Path)
+	FirmwareKeys []*model.FirmwareKey
+	// AEAKeys is the cached AEA key material (it is NOT persisted to Path)
+	AEAKeys []*model.AEAKey
+	kmu     sync.Mutex
 }
 
 // NewInMemory creates a new in-memory database.
@@ -23,8 +48,9 @@ func NewInMemory(path string) (Database, error) {
 		return nil, errors.New("'path' is required")
 	}
 	return &Memory{
-		IPSWs: make(map[string]*model.Ipsw),
-		Path:  path,
+		IPSWs:    make(map[string]*model.Ipsw),
+		Path:     path,
+		Analyses: make(map[string]*model.Analysis),
 	}, nil
 }
 
@@ -89,6 +115,18 @@ func (m *Memory) GetIPSW(version, build, device string) (*model.Ipsw, error) {
 	return nil, model.ErrNotFound
 }
 
+// GetIPSWs returns the IPSWs (newest first).
+func (m *Memory) GetIPSWs() ([]*model.Ipsw, error) {
+	ipsws := make([]*model.Ipsw, 0, len(m.IPSWs))
+	for _, ipsw := range m.IPSWs {
+		ipsws = append(ipsws, ipsw)
+	}
+	slices.SortFunc(ipsws, func(a, b *model.Ipsw) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return ipsws, nil
+}
+
 func (m *Memory) GetDSC(uuid string) (*model.DyldSharedCache, error) {
 	for _, ipsw := range m.IPSWs {
 		for _, dyld := range ipsw.DSCs {
@@ -202,11 +240,480 @@ func (m *Memory) GetSymbols(uuid string) ([]*model.Symbol, error) {
 	return nil, model.ErrNotFound
 }
 
+// Enqueue adds items to the download queue.
+// Items that are already queued are skipped (failed items are reset to pending).
+func (m *Memory) Enqueue(items ...*model.QueueItem) error {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	for _, item := range items {
+		idx := slices.IndexFunc(m.Queue, func(q *model.QueueItem) bool {
+			return q.Device == item.Device && q.Build == item.Build
+		})
+		if idx < 0 {
+			if item.Status == "" {
+				item.Status = model.QueuePending
+			}
+			item.ID = 1
+			if len(m.Queue) > 0 {
+				item.ID = m.Queue[len(m.Queue)-1].ID + 1
+			}
+			item.CreatedAt = time.Now()
+			item.UpdatedAt = item.CreatedAt
+			m.Queue = append(m.Queue, item)
+			continue
+		}
+		if existing := m.Queue[idx]; existing.Status == model.QueueFailed {
+			existing.Status = model.QueuePending
+			existing.Attempts = 0
+			existing.Error = ""
+			existing.NextAttempt = time.Time{}
+			existing.UpdatedAt = time.Now()
+		}
+		*item = *m.Queue[idx]
+	}
+	return nil
+}
+
+// GetQueue returns the download queue items with the given status (or all items if status is empty).
+func (m *Memory) GetQueue(status model.QueueStatus) ([]*model.QueueItem, error) {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	var items []*model.QueueItem
+	for _, item := range m.Queue {
+		if status == "" || item.Status == status {
+			cp := *item
+			items = append(items, &cp)
+		}
+	}
+	return items, nil
+}
+
+// NextQueueItem claims the next pending download queue item that is ready to be attempted.
+// It returns ErrNotFound if no item is ready.
+func (m *Memory) NextQueueItem() (*model.QueueItem, error) {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	now := time.Now()
+	for _, item := range m.Queue {
+		if item.Status == model.QueuePending && !item.NextAttempt.After(now) {
+			item.Status = model.QueueRunning
+			cp := *item
+			return &cp, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+// ClearQueue removes the download queue items with the given status (or all items if status is empty).
+func (m *Memory) ClearQueue(status model.QueueStatus) error {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	m.Queue = slices.DeleteFunc(m.Queue, func(q *model.QueueItem) bool {
+		return status == "" || q.Status == status
+	})
+	return nil
+}
+
+// EnqueueScans adds items to the symbol scan queue.
+// Items whose URL is already queued are skipped (failed items are reset to pending and the priority
+// of the pending items is raised to the new item's).
+func (m *Memory) EnqueueScans(items ...*model.ScanItem) error {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	for _, item := range items {
+		idx := slices.IndexFunc(m.Scans, func(s *model.ScanItem) bool {
+			return s.URL == item.URL
+		})
+		if idx < 0 {
+			if item.Status == "" {
+				item.Status = model.QueuePending
+			}
+			item.ID = 1
+			if len(m.Scans) > 0 {
+				item.ID = m.Scans[len(m.Scans)-1].ID + 1
+			}
+			item.CreatedAt = time.Now()
+			item.UpdatedAt = item.CreatedAt
+			cp := *item
+			m.Scans = append(m.Scans, &cp)
+			continue
+		}
+		existing := m.Scans[idx]
+		if existing.Status == model.QueueFailed {
+			existing.Status = model.QueuePending
+			existing.Attempts = 0
+			existing.Error = ""
+			existing.NextAttempt = time.Time{}
+			existing.UpdatedAt = time.Now()
+		}
+		if existing.Status == model.QueuePending && item.Priority > existing.Priority {
+			existing.Priority = item.Priority
+			existing.UpdatedAt = time.Now()
+		}
+		*item = *existing
+	}
+	return nil
+}
+
+// sortedScans returns the symbol scan queue items highest priority first (the caller must hold the lock)
+func (m *Memory) sortedScans() []*model.ScanItem {
+	items := slices.Clone(m.Scans)
+	slices.SortStableFunc(items, func(a, b *model.ScanItem) int {
+		return b.Priority - a.Priority
+	})
+	return items
+}
+
+// GetScanQueue returns the symbol scan queue items with the given status (or all items if status is empty),
+// highest priority first.
+func (m *Memory) GetScanQueue(status model.QueueStatus) ([]*model.ScanItem, error) {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	var items []*model.ScanItem
+	for _, item := range m.sortedScans() {
+		if status == "" || item.Status == status {
+			cp := *item
+			items = append(items, &cp)
+		}
+	}
+	return items, nil
+}
+
+// NextScanItem claims the highest priority pending symbol scan queue item that is ready to be attempted.
+// It returns ErrNotFound if no item is ready.
+func (m *Memory) NextScanItem() (*model.ScanItem, error) {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	now := time.Now()
+	for _, item := range m.sortedScans() {
+		if item.Status == model.QueuePending && !item.NextAttempt.After(now) {
+			item.Status = model.QueueRunning
+			cp := *item
+			return &cp, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+// DeleteScanItem removes a symbol scan queue item.
+// It returns ErrNotFound if the item does not exist.
+func (m *Memory) DeleteScanItem(id uint) error {
+	m.qmu.Lock()
+	defer m.qmu.Unlock()
+	idx := slices.IndexFunc(m.Scans, func(s *model.ScanItem) bool { return s.ID == id })
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	m.Scans = slices.Delete(m.Scans, idx, idx+1)
+	return nil
+}
+
+// SaveJob creates or updates a daemon job.
+func (m *Memory) SaveJob(job *model.Job) error {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	job.UpdatedAt = time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = job.UpdatedAt
+	}
+	cp := *job
+	if idx := slices.IndexFunc(m.Jobs, func(j *model.Job) bool { return j.ID == job.ID }); idx >= 0 {
+		// only CancelJob sets CancelRequested (so saving a job doesn't clear a cancel request)
+		cp.CancelRequested = m.Jobs[idx].CancelRequested
+		m.Jobs[idx] = &cp
+	} else {
+		m.Jobs = append(m.Jobs, &cp)
+	}
+	return nil
+}
+
+// SaveAssignedJob updates a daemon job if it is still assigned to job.Worker.
+// It returns false if the job was reassigned to another worker (or no longer exists).
+func (m *Memory) SaveAssignedJob(job *model.Job) (bool, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	idx := slices.IndexFunc(m.Jobs, func(j *model.Job) bool { return j.ID == job.ID })
+	if idx < 0 || m.Jobs[idx].Worker != job.Worker {
+		return false, nil
+	}
+	job.UpdatedAt = time.Now()
+	cp := *job
+	cp.CancelRequested = m.Jobs[idx].CancelRequested
+	m.Jobs[idx] = &cp
+	return true, nil
+}
+
+// GetJobs returns the daemon jobs with the given state (or all jobs if state is empty).
+func (m *Memory) GetJobs(state model.JobState) ([]*model.Job, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	var jobs []*model.Job
+	for _, job := range m.Jobs {
+		if state == "" || job.State == state {
+			cp := *job
+			jobs = append(jobs, &cp)
+		}
+	}
+	return jobs, nil
+}
+
+// GetJob returns the daemon job with the given ID.
+// It returns ErrNotFound if the job does not exist.
+func (m *Memory) GetJob(id string) (*model.Job, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	for _, job := range m.Jobs {
+		if job.ID == id {
+			cp := *job
+			return &cp, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+// GetAssignedJobs returns the unfinished daemon jobs assigned to the worker.
+func (m *Memory) GetAssignedJobs(worker string) ([]*model.Job, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	var jobs []*model.Job
+	for _, job := range m.Jobs {
+		if job.Worker == worker && (job.State == model.JobPending || job.State == model.JobRunning) {
+			cp := *job
+			jobs = append(jobs, &cp)
+		}
+	}
+	return jobs, nil
+}
+
+// AssignJob assigns a pending daemon job that isn't assigned yet to the worker.
+// It returns false if the job was already assigned (or is no longer pending).
+func (m *Memory) AssignJob(id, worker string) (bool, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	for _, job := range m.Jobs {
+		if job.ID == id && job.State == model.JobPending && job.Worker == "" {
+			job.Worker = worker
+			job.UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CancelJob asks the worker of a daemon job to cancel it.
+// It returns ErrNotFound if the job does not exist.
+func (m *Memory) CancelJob(id string) error {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	for _, job := range m.Jobs {
+		if job.ID == id {
+			job.CancelRequested = true
+			job.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+// SaveWorker creates or updates a daemon worker.
+func (m *Memory) SaveWorker(worker *model.Worker) error {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	cp := *worker
+	if idx := slices.IndexFunc(m.Workers, func(w *model.Worker) bool { return w.ID == worker.ID }); idx >= 0 {
+		cp.CreatedAt = m.Workers[idx].CreatedAt
+		m.Workers[idx] = &cp
+	} else {
+		if cp.CreatedAt.IsZero() {
+			cp.CreatedAt = time.Now()
+		}
+		m.Workers = append(m.Workers, &cp)
+	}
+	return nil
+}
+
+// GetWorkers returns the daemon workers.
+func (m *Memory) GetWorkers() ([]*model.Worker, error) {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	workers := make([]*model.Worker, 0, len(m.Workers))
+	for _, w := range m.Workers {
+		cp := *w
+		workers = append(workers, &cp)
+	}
+	return workers, nil
+}
+
+// DeleteWorker removes a daemon worker.
+func (m *Memory) DeleteWorker(id string) error {
+	m.jmu.Lock()
+	defer m.jmu.Unlock()
+	m.Workers = slices.DeleteFunc(m.Workers, func(w *model.Worker) bool { return w.ID == id })
+	return nil
+}
+
+// AddAuditEntry records an API request in the audit log.
+func (m *Memory) AddAuditEntry(entry *model.AuditEntry) error {
+	m.amu.Lock()
+	defer m.amu.Unlock()
+	entry.ID = 1
+	if n := len(m.Audit); n > 0 {
+		entry.ID = m.Audit[n-1].ID + 1
+	}
+	cp := *entry
+	m.Audit = append(m.Audit, &cp)
+	return nil
+}
+
+// GetAuditEntries returns the audit log entries matching the query (oldest first).
+func (m *Memory) GetAuditEntries(query model.AuditQuery) ([]*model.AuditEntry, error) {
+	m.amu.Lock()
+	defer m.amu.Unlock()
+	var entries []*model.AuditEntry
+	for i := len(m.Audit) - 1; i >= 0; i-- {
+		e := m.Audit[i]
+		if (!query.Since.IsZero() && e.At.Before(query.Since)) ||
+			(!query.Until.IsZero() && !e.At.Before(query.Until)) ||
+			(query.Identity != "" && e.Identity != query.Identity) ||
+			(query.Route != "" && e.Route != query.Route) {
+			continue
+		}
+		cp := *e
+		entries = append(entries, &cp)
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// DeleteAuditEntries removes the audit log entries older than before and returns how many were removed.
+func (m *Memory) DeleteAuditEntries(before time.Time) (int64, error) {
+	m.amu.Lock()
+	defer m.amu.Unlock()
+	n := len(m.Audit)
+	m.Audit = slices.DeleteFunc(m.Audit, func(e *model.AuditEntry) bool { return e.At.Before(before) })
+	return int64(n - len(m.Audit)), nil
+}
+
+// SaveAnalyses creates or updates the results of plugin analyzers.
+func (m *Memory) SaveAnalyses(analyses ...*model.Analysis) error {
+	m.amu.Lock()
+	defer m.amu.Unlock()
+	for _, a := range analyses {
+		cp := *a
+		m.Analyses[a.ID] = &cp
+	}
+	return nil
+}
+
+// GetAnalyses returns the results of the plugin analyzers run on the IPSW.
+func (m *Memory) GetAnalyses(ipswID string) ([]*model.Analysis, error) {
+	m.amu.Lock()
+	defer m.amu.Unlock()
+	var analyses []*model.Analysis
+	for _, a := range m.Analyses {
+		if a.IpswID == ipswID {
+			cp := *a
+			analyses = append(analyses, &cp)
+		}
+	}
+	slices.SortFunc(analyses, func(a, b *model.Analysis) int {
+		return strings.Compare(a.Plugin+a.Analyzer+a.Path, b.Plugin+b.Analyzer+b.Path)
+	})
+	return analyses, nil
+}
+
+// SaveFirmwareKeys creates or updates firmware decryption keys (by device, build and filename).
+func (m *Memory) SaveFirmwareKeys(keys ...*model.FirmwareKey) error {
+	m.kmu.Lock()
+	defer m.kmu.Unlock()
+	for _, key := range keys {
+		key.UpdatedAt = time.Now()
+		cp := *key
+		if idx := slices.IndexFunc(m.FirmwareKeys, func(k *model.FirmwareKey) bool {
+			return k.Device == key.Device && k.Build == key.Build && k.Filename == key.Filename
+		}); idx >= 0 {
+			cp.ID = m.FirmwareKeys[idx].ID
+			m.FirmwareKeys[idx] = &cp
+		} else {
+			cp.ID = uint(len(m.FirmwareKeys) + 1)
+			m.FirmwareKeys = append(m.FirmwareKeys, &cp)
+		}
+	}
+	return nil
+}
+
+// GetFirmwareKeys returns the firmware decryption keys of the device/build.
+func (m *Memory) GetFirmwareKeys(device, build string) ([]*model.FirmwareKey, error) {
+	m.kmu.Lock()
+	defer m.kmu.Unlock()
+	var keys []*model.FirmwareKey
+	for _, k := range m.FirmwareKeys {
+		if k.Device == device && k.Build == build {
+			cp := *k
+			keys = append(keys, &cp)
+		}
+	}
+	slices.SortFunc(keys, func(a, b *model.FirmwareKey) int {
+		return strings.Compare(a.Filename, b.Filename)
+	})
+	return keys, nil
+}
+
+// SaveAEAKey creates or updates cached AEA key material.
+func (m *Memory) SaveAEAKey(key *model.AEAKey) error {
+	m.kmu.Lock()
+	defer m.kmu.Unlock()
+	key.UpdatedAt = time.Now()
+	cp := *key
+	if idx := slices.IndexFunc(m.AEAKeys, func(k *model.AEAKey) bool { return k.Kind == key.Kind && k.Name == key.Name }); idx >= 0 {
+		m.AEAKeys[idx] = &cp
+	} else {
+		m.AEAKeys = append(m.AEAKeys, &cp)
+	}
+	return nil
+}
+
+// GetAEAKey returns the cached AEA key material of the given kind and name.
+// It returns ErrNotFound if the key does not exist.
+func (m *Memory) GetAEAKey(kind, name string) (*model.AEAKey, error) {
+	m.kmu.Lock()
+	defer m.kmu.Unlock()
+	if idx := slices.IndexFunc(m.AEAKeys, func(k *model.AEAKey) bool { return k.Kind == kind && k.Name == name }); idx >= 0 {
+		cp := *m.AEAKeys[idx]
+		return &cp, nil
+	}
+	return nil, model.ErrNotFound
+}
+
 // Set sets the value for the given key.
 // It overwrites any previous value for that key.
 func (m *Memory) Save(value any) error {
-	if ipsw, ok := value.(*model.Ipsw); ok {
-		m.IPSWs[ipsw.ID] = ipsw
+	switch v := value.(type) {
+	case *model.Ipsw:
+		m.IPSWs[v.ID] = v
+	case *model.QueueItem:
+		m.qmu.Lock()
+		defer m.qmu.Unlock()
+		for idx, item := range m.Queue {
+			if item.ID == v.ID {
+				v.UpdatedAt = time.Now()
+				cp := *v
+				m.Queue[idx] = &cp
+			}
+		}
+	case *model.ScanItem:
+		m.qmu.Lock()
+		defer m.qmu.Unlock()
+		for idx, item := range m.Scans {
+			if item.ID == v.ID {
+				v.UpdatedAt = time.Now()
+				cp := *v
+				m.Scans[idx] = &cp
+			}
+		}
 	}
 	return nil
 }
@@ -228,6 +735,11 @@ func (m *Memory) Delete(id string) error {
 	return nil
 }
 
+// Ping checks the database connection is alive (the in-memory database is always alive).
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
 // Close closes the database.
 // It returns ErrClosed if the database is already closed.
 func (m *Memory) Close() error {
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/model"
+)
+
+// instrumented records the latency of a database's queries
+type instrumented struct {
+	db Database
+}
+
+// WithMetrics wraps the database to record its query latencies (in the ipswd_db_query_duration_seconds metric)
+func WithMetrics(d Database) Database {
+	if d == nil {
+		return nil
+	}
+	return &instrumented{db: d}
+}
+
+func observe(op string, start time.Time) {
+	metrics.DBDuration.WithLabelValues(op).ObserveSince(start)
+}
+
+func (i *instrumented) Connect() error {
+	defer observe("connect", time.Now())
+	return i.db.Connect()
+}
+
+func (i *instrumented) Ping(ctx context.Context) error {
+	defer observe("ping", time.Now())
+	return i.db.Ping(ctx)
+}
+
+func (i *instrumented) Create(value any) error {
+	defer observe("create", time.Now())
+	return i.db.Create(value)
+}
+
+func (i *instrumented) Get(key string) (*model.Ipsw, error) {
+	defer observe("get", time.Now())
+	return i.db.Get(key)
+}
+
+func (i *instrumented) GetIpswByName(name string) (*model.Ipsw, error) {
+	defer observe("get_ipsw_by_name", time.Now())
+	return i.db.GetIpswByName(name)
+}
+
+func (i *instrumented) GetIPSW(version, build, device string) (*model.Ipsw, error) {
+	defer observe("get_ipsw", time.Now())
+	return i.db.GetIPSW(version, build, device)
+}
+
+func (i *instrumented) GetIPSWs() ([]*model.Ipsw, error) {
+	defer observe("get_ipsws", time.Now())
+	return i.db.GetIPSWs()
+}
+
+func (i *instrumented) GetDSC(uuid string) (*model.DyldSharedCache, error) {
+	defer observe("get_dsc", time.Now())
+	return i.db.GetDSC(uuid)
+}
+
+func (i *instrumented) GetDSCImage(uuid string, addr uint64) (*model.Macho, error) {
+	defer observe("get_dsc_image", time.Now())
+	return i.db.GetDSCImage(uuid, addr)
+}
+
+func (i *instrumented) GetMachO(uuid string) (*model.Macho, error) {
+	defer observe("get_macho", time.Now())
+	return i.db.GetMachO(uuid)
+}
+
+func (i *instrumented) GetSymbol(uuid string, addr uint64) (*model.Symbol, error) {
+	defer observe("get_symbol", time.Now())
+	return i.db.GetSymbol(uuid, addr)
+}
+
+func (i *instrumented) GetSymbols(uuid string) ([]*model.Symbol, error) {
+	defer observe("get_symbols", time.Now())
+	return i.db.GetSymbols(uuid)
+}
+
+func (i *instrumented) Enqueue(items ...*model.QueueItem) error {
+	defer observe("enqueue", time.Now())
+	return i.db.Enqueue(items...)
+}
+
+func (i *instrumented) GetQueue(status model.QueueStatus) ([]*model.QueueItem, error) {
+	defer observe("get_queue", time.Now())
+	return i.db.GetQueue(status)
+}
+
+func (i *instrumented) NextQueueItem() (*model.QueueItem, error) {
+	defer observe("next_queue_item", time.Now())
+	return i.db.NextQueueItem()
+}
+
+func (i *instrumented) ClearQueue(status model.QueueStatus) error {
+	defer observe("clear_queue", time.Now())
+	return i.db.ClearQueue(status)
+}
+
+func (i *instrumented) EnqueueScans(items ...*model.ScanItem) error {
+	defer observe("enqueue_scans", time.Now())
+	return i.db.EnqueueScans(items...)
+}
+
+func (i *instrumented) GetScanQueue(status model.QueueStatus) ([]*model.ScanItem, error) {
+	defer observe("get_scan_queue", time.Now())
+	return i.db.GetScanQueue(status)
+}
+
+func (i *instrumented) NextScanItem() (*model.ScanItem, error) {
+	defer observe("next_scan_item", time.Now())
+	return i.db.NextScanItem()
+}
+
+func (i *instrumented) DeleteScanItem(id uint) error {
+	defer observe("delete_scan_item", time.Now())
+	return i.db.DeleteScanItem(id)
+}
+
+func (i *instrumented) SaveJob(job *model.Job) error {
+	defer observe("save_job", time.Now())
+	return i.db.SaveJob(job)
+}
+
+func (i *instrumented) SaveAssignedJob(job *model.Job) (bool, error) {
+	defer observe("save_assigned_job", time.Now())
+	return i.db.SaveAssignedJob(job)
+}
+
+func (i *instrumented) GetJobs(state model.JobState) ([]*model.Job, error) {
+	defer observe("get_jobs", time.Now())
+	return i.db.GetJobs(state)
+}
+
+func (i *instrumented) GetJob(id string) (*model.Job, error) {
+	defer observe("get_job", time.Now())
+	return i.db.GetJob(id)
+}
+
+func (i *instrumented) GetAssignedJobs(worker string) ([]*model.Job, error) {
+	defer observe("get_assigned_jobs", time.Now())
+	return i.db.GetAssignedJobs(worker)
+}
+
+func (i *instrumented) AssignJob(id, worker string) (bool, error) {
+	defer observe("assign_job", time.Now())
+	return i.db.AssignJob(id, worker)
+}
+
+func (i *instrumented) CancelJob(id string) error {
+	defer observe("cancel_job", time.Now())
+	return i.db.CancelJob(id)
+}
+
+func (i *instrumented) SaveWorker(worker *model.Worker) error {
+	defer observe("save_worker", time.Now())
+	return i.db.SaveWorker(worker)
+}
+
+func (i *instrumented) GetWorkers() ([]*model.Worker, error) {
+	defer observe("get_workers", time.Now())
+	return i.db.GetWorkers()
+}
+
+func (i *instrumented) DeleteWorker(id string) error {
+	defer observe("delete_worker", time.Now())
+	return i.db.DeleteWorker(id)
+}
+
+func (i *instrumented) AddAuditEntry(entry *model.AuditEntry) error {
+	defer observe("add_audit_entry", time.Now())
+	return i.db.AddAuditEntry(entry)
+}
+
+func (i *instrumented) GetAuditEntries(query model.AuditQuery) ([]*model.AuditEntry, error) {
+	defer observe("get_audit_entries", time.Now())
+	return i.db.GetAuditEntries(query)
+}
+
+func (i *instrumented) DeleteAuditEntries(before time.Time) (int64, error) {
+	defer observe("delete_audit_entries", time.Now())
+	return i.db.DeleteAuditEntries(before)
+}
+
+func (i *instrumented) SaveAnalyses(analyses ...*model.Analysis) error {
+	defer observe("save_analyses", time.Now())
+	return i.db.SaveAnalyses(analyses...)
+}
+
+func (i *instrumented) GetAnalyses(ipswID string) ([]*model.Analysis, error) {
+	defer observe("get_analyses", time.Now())
+	return i.db.GetAnalyses(ipswID)
+}
+
+func (i *instrumented) SaveFirmwareKeys(keys ...*model.FirmwareKey) error {
+	defer observe("save_firmware_keys", time.Now())
+	return i.db.SaveFirmwareKeys(keys...)
+}
+
+func (i *instrumented) GetFirmwareKeys(device, build string) ([]*model.FirmwareKey, error) {
+	defer observe("get_firmware_keys", time.Now())
+	return i.db.GetFirmwareKeys(device, build)
+}
+
+func (i *instrumented) SaveAEAKey(key *model.AEAKey) error {
+	defer observe("save_aea_key", time.Now())
+	return i.db.SaveAEAKey(key)
+}
+
+func (i *instrumented) GetAEAKey(kind, name string) (*model.AEAKey, error) {
+	defer observe("get_aea_key", time.Now())
+	return i.db.GetAEAKey(kind, name)
+}
+
+func (i *instrumented) Save(value any) error {
+	defer observe("save", time.Now())
+	return i.db.Save(value)
+}
+
+func (i *instrumented) Delete(key string) error {
+	defer observe("delete", time.Now())
+	return i.db.Delete(key)
+}
+
+func (i *instrumented) Close() error {
+	return i.db.Close()
+}
@@ -1,12 +1,16 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/blacktop/ipsw/internal/model"
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -48,6 +52,14 @@ func (s *Sqlite) Connect() (err error) {
 		&model.DyldSharedCache{},
 		&model.Macho{},
 		&model.Symbol{},
+		&model.QueueItem{},
+		&model.ScanItem{},
+		&model.Job{},
+		&model.Worker{},
+		&model.Analysis{},
+		&model.AuditEntry{},
+		&model.FirmwareKey{},
+		&model.AEAKey{},
 	)
 }
 
@@ -96,6 +108,17 @@ func (s *Sqlite) GetIPSW(version, build, device string) (*model.Ipsw, error) {
 	return &ipsw, nil
 }
 
+// GetIPSWs returns the IPSWs (with their devices, kernelcaches and dyld_shared_caches but not their files).
+func (s *Sqlite) GetIPSWs() ([]*model.Ipsw, error) {
+	var ipsws []*model.Ipsw
+	if err := s.db.Preload("Devices").Preload("Kernels").Preload("DSCs").
+		Order("created_at DESC").
+		Find(&ipsws).Error; err != nil {
+		return nil, err
+	}
+	return ipsws, nil
+}
+
 func (s *Sqlite) GetDSC(uuid string) (*model.DyldSharedCache, error) {
 	var dsc model.DyldSharedCache
 	if err := s.db.Where("uuid = ?", uuid).First(&dsc).Error; err != nil {
@@ -160,6 +183,370 @@ func (s *Sqlite) GetSymbols(uuid string) ([]*model.Symbol, error) {
 	return syms, nil
 }
 
+// Enqueue adds items to the download queue.
+// Items that are already queued are skipped (failed items are reset to pending).
+func (s *Sqlite) Enqueue(items ...*model.QueueItem) error {
+	for _, item := range items {
+		var existing model.QueueItem
+		err := s.db.Where("device = ? AND build = ?", item.Device, item.Build).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if item.Status == "" {
+				item.Status = model.QueuePending
+			}
+			if err := s.db.Create(item).Error; err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if existing.Status == model.QueueFailed {
+			existing.Status = model.QueuePending
+			existing.Attempts = 0
+			existing.Error = ""
+			existing.NextAttempt = time.Time{}
+			if err := s.db.Save(&existing).Error; err != nil {
+				return err
+			}
+		}
+		*item = existing
+	}
+	return nil
+}
+
+// GetQueue returns the download queue items with the given status (or all items if status is empty).
+func (s *Sqlite) GetQueue(status model.QueueStatus) ([]*model.QueueItem, error) {
+	var items []*model.QueueItem
+	tx := s.db.Order("id")
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if err := tx.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// NextQueueItem claims the next pending download queue item that is ready to be attempted.
+// It returns ErrNotFound if no item is ready.
+func (s *Sqlite) NextQueueItem() (*model.QueueItem, error) {
+	for {
+		var item model.QueueItem
+		if err := s.db.Where("status = ? AND next_attempt <= ?", model.QueuePending, time.Now()).
+			Order("id").
+			First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, model.ErrNotFound
+			}
+			return nil, err
+		}
+		// only claim the item if another worker hasn't already
+		result := s.db.Model(&model.QueueItem{}).
+			Where("id = ? AND status = ?", item.ID, model.QueuePending).
+			Update("status", model.QueueRunning)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			item.Status = model.QueueRunning
+			return &item, nil
+		}
+	}
+}
+
+// ClearQueue removes the download queue items with the given status (or all items if status is empty).
+func (s *Sqlite) ClearQueue(status model.QueueStatus) error {
+	tx := s.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	return tx.Delete(&model.QueueItem{}).Error
+}
+
+// EnqueueScans adds items to the symbol scan queue.
+// Items whose URL is already queued are skipped (failed items are reset to pending and the priority
+// of the pending items is raised to the new item's).
+func (s *Sqlite) EnqueueScans(items ...*model.ScanItem) error {
+	for _, item := range items {
+		var existing model.ScanItem
+		err := s.db.Where("url = ?", item.URL).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if item.Status == "" {
+				item.Status = model.QueuePending
+			}
+			if err := s.db.Create(item).Error; err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		changed := false
+		if existing.Status == model.QueueFailed {
+			existing.Status = model.QueuePending
+			existing.Attempts = 0
+			existing.Error = ""
+			existing.NextAttempt = time.Time{}
+			changed = true
+		}
+		if existing.Status == model.QueuePending && item.Priority > existing.Priority {
+			existing.Priority = item.Priority
+			changed = true
+		}
+		if changed {
+			if err := s.db.Save(&existing).Error; err != nil {
+				return err
+			}
+		}
+		*item = existing
+	}
+	return nil
+}
+
+// GetScanQueue returns the symbol scan queue items with the given status (or all items if status is empty),
+// highest priority first.
+func (s *Sqlite) GetScanQueue(status model.QueueStatus) ([]*model.ScanItem, error) {
+	var items []*model.ScanItem
+	tx := s.db.Order("priority DESC, id")
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if err := tx.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// NextScanItem claims the highest priority pending symbol scan queue item that is ready to be attempted.
+// It returns ErrNotFound if no item is ready.
+func (s *Sqlite) NextScanItem() (*model.ScanItem, error) {
+	for {
+		var item model.ScanItem
+		if err := s.db.Where("status = ? AND next_attempt <= ?", model.QueuePending, time.Now()).
+			Order("priority DESC, id").
+			First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, model.ErrNotFound
+			}
+			return nil, err
+		}
+		// only claim the item if another worker hasn't already
+		result := s.db.Model(&model.ScanItem{}).
+			Where("id = ? AND status = ?", item.ID, model.QueuePending).
+			Update("status", model.QueueRunning)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			item.Status = model.QueueRunning
+			return &item, nil
+		}
+	}
+}
+
+// DeleteScanItem removes a symbol scan queue item.
+// It returns ErrNotFound if the item does not exist.
+func (s *Sqlite) DeleteScanItem(id uint) error {
+	result := s.db.Delete(&model.ScanItem{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// SaveJob creates or updates a daemon job.
+func (s *Sqlite) SaveJob(job *model.Job) error {
+	// only CancelJob sets cancel_requested (so saving a job doesn't clear a cancel request)
+	return s.db.Omit("cancel_requested").Save(job).Error
+}
+
+// SaveAssignedJob updates a daemon job if it is still assigned to job.Worker.
+// It returns false if the job was reassigned to another worker (or no longer exists).
+func (s *Sqlite) SaveAssignedJob(job *model.Job) (bool, error) {
+	// only CancelJob sets cancel_requested (so saving a job doesn't clear a cancel request)
+	result := s.db.Model(job).Where("worker = ?", job.Worker).Select("*").Omit("id", "cancel_requested").Updates(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetJobs returns the daemon jobs with the given state (or all jobs if state is empty).
+func (s *Sqlite) GetJobs(state model.JobState) ([]*model.Job, error) {
+	var jobs []*model.Job
+	tx := s.db.Order("created_at")
+	if state != "" {
+		tx = tx.Where("state = ?", state)
+	}
+	if err := tx.Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJob returns the daemon job with the given ID.
+// It returns ErrNotFound if the job does not exist.
+func (s *Sqlite) GetJob(id string) (*model.Job, error) {
+	var job model.Job
+	if err := s.db.Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAssignedJobs returns the unfinished daemon jobs assigned to the worker.
+func (s *Sqlite) GetAssignedJobs(worker string) ([]*model.Job, error) {
+	var jobs []*model.Job
+	if err := s.db.Where("worker = ? AND state IN ?", worker, []model.JobState{model.JobPending, model.JobRunning}).
+		Order("created_at").
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// AssignJob assigns a pending daemon job that isn't assigned yet to the worker.
+// It returns false if the job was already assigned (or is no longer pending).
+func (s *Sqlite) AssignJob(id, worker string) (bool, error) {
+	result := s.db.Model(&model.Job{}).
+		Where("id = ? AND state = ? AND worker = ?", id, model.JobPending, "").
+		Update("worker", worker)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CancelJob asks the worker of a daemon job to cancel it.
+// It returns ErrNotFound if the job does not exist.
+func (s *Sqlite) CancelJob(id string) error {
+	result := s.db.Model(&model.Job{}).Where("id = ?", id).Update("cancel_requested", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// SaveWorker creates or updates a daemon worker.
+func (s *Sqlite) SaveWorker(worker *model.Worker) error {
+	return s.db.Save(worker).Error
+}
+
+// GetWorkers returns the daemon workers.
+func (s *Sqlite) GetWorkers() ([]*model.Worker, error) {
+	var workers []*model.Worker
+	if err := s.db.Order("id").Find(&workers).Error; err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// DeleteWorker removes a daemon worker.
+func (s *Sqlite) DeleteWorker(id string) error {
+	return s.db.Delete(&model.Worker{}, "id = ?", id).Error
+}
+
+// SaveFirmwareKeys creates or updates firmware decryption keys (by device, build and filename).
+func (s *Sqlite) SaveFirmwareKeys(keys ...*model.FirmwareKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device"}, {Name: "build"}, {Name: "filename"}},
+		DoUpdates: clause.AssignmentColumns([]string{"iv", "key", "k_bag", "source", "updated_at"}),
+	}).Create(keys).Error
+}
+
+// GetFirmwareKeys returns the firmware decryption keys of the device/build.
+func (s *Sqlite) GetFirmwareKeys(device, build string) ([]*model.FirmwareKey, error) {
+	var keys []*model.FirmwareKey
+	if err := s.db.Where("device = ? AND build = ?", device, build).Order("filename").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// SaveAEAKey creates or updates cached AEA key material.
+func (s *Sqlite) SaveAEAKey(key *model.AEAKey) error {
+	return s.db.Save(key).Error
+}
+
+// GetAEAKey returns the cached AEA key material of the given kind and name.
+// It returns ErrNotFound if the key does not exist.
+func (s *Sqlite) GetAEAKey(kind, name string) (*model.AEAKey, error) {
+	var key model.AEAKey
+	if err := s.db.Where("kind = ? AND name = ?", kind, name).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// AddAuditEntry records an API request in the audit log.
+func (s *Sqlite) AddAuditEntry(entry *model.AuditEntry) error {
+	return s.db.Create(entry).Error
+}
+
+// GetAuditEntries returns the audit log entries matching the query (oldest first).
+func (s *Sqlite) GetAuditEntries(query model.AuditQuery) ([]*model.AuditEntry, error) {
+	tx := s.db.Order("id DESC")
+	if !query.Since.IsZero() {
+		tx = tx.Where("at >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		tx = tx.Where("at < ?", query.Until)
+	}
+	if query.Identity != "" {
+		tx = tx.Where("identity = ?", query.Identity)
+	}
+	if query.Route != "" {
+		tx = tx.Where("route = ?", query.Route)
+	}
+	if query.Limit > 0 {
+		tx = tx.Limit(query.Limit)
+	}
+	var entries []*model.AuditEntry
+	if err := tx.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// DeleteAuditEntries removes the audit log entries older than before and returns how many were removed.
+func (s *Sqlite) DeleteAuditEntries(before time.Time) (int64, error) {
+	tx := s.db.Where("at < ?", before).Delete(&model.AuditEntry{})
+	return tx.RowsAffected, tx.Error
+}
+
+// SaveAnalyses creates or updates the results of plugin analyzers.
+func (s *Sqlite) SaveAnalyses(analyses ...*model.Analysis) error {
+	if len(analyses) == 0 {
+		return nil
+	}
+	return s.db.Save(analyses).Error
+}
+
+// GetAnalyses returns the results of the plugin analyzers run on the IPSW.
+func (s *Sqlite) GetAnalyses(ipswID string) ([]*model.Analysis, error) {
+	var analyses []*model.Analysis
+	if err := s.db.Where("ipsw_id = ?", ipswID).Order("plugin, analyzer, path").Find(&analyses).Error; err != nil {
+		return nil, err
+	}
+	return analyses, nil
+}
+
 // Set sets the value for the given key.
 // It overwrites any previous value for that key.
 func (s *Sqlite) Save(value any) error {
@@ -176,6 +563,15 @@ func (s *Sqlite) Delete(key string) error {
 	return nil
 }
 
+// Ping checks the database connection is alive.
+func (s *Sqlite) Ping(ctx context.Context) error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
 // Close closes the database.
 // It returns ErrClosed if the database is already closed.
 func (s *Sqlite) Close() error {
@@ -63,6 +63,10 @@ type Config struct {
 	BlockList []string
 	PemDB     string
 	Output    string
+
+	EntInclude     []string // regexes of the binary paths to diff the entitlements of
+	EntExclude     []string // regexes of the binary paths not to diff the entitlements of
+	EntInteresting []string // globs of the entitlements to highlight
 }
 
 // Context is the context for the diff
@@ -93,14 +97,20 @@ type Diff struct {
 	Old Context `json:"-"`
 	New Context `json:"-"`
 
-	Kexts     *mcmd.MachoDiff `json:"kexts,omitempty"`
-	KDKs      string          `json:"kdks,omitempty"`
-	Ents      string          `json:"ents,omitempty"`
-	Dylibs    *mcmd.MachoDiff `json:"dylibs,omitempty"`
-	Machos    *mcmd.MachoDiff `json:"machos,omitempty"`
-	Firmwares *mcmd.MachoDiff `json:"firmwares,omitempty"`
-	Launchd   string          `json:"launchd,omitempty"`
-	Features  *PlistDiff      `json:"features,omitempty"`
+	Kexts           *mcmd.MachoDiff   `json:"kexts,omitempty"`
+	IOKit           *kcmd.IOKitDiff   `json:"iokit,omitempty"`
+	KDKs            string            `json:"kdks,omitempty"`
+	Ents            string            `json:"ents,omitempty"`
+	EntDiffs        []*ent.EntDiff    `json:"entitlements,omitempty"`
+	Dylibs          *mcmd.MachoDiff   `json:"dylibs,omitempty"`
+	Machos          *mcmd.MachoDiff   `json:"machos,omitempty"`
+	Firmwares       *mcmd.MachoDiff   `json:"firmwares,omitempty"`
+	Launchd         string            `json:"launchd,omitempty"`
+	LaunchdServices *LaunchdDiff      `json:"launchd_services,omitempty"`
+	Features        *PlistDiff        `json:"features,omitempty"`
+	Flags           *FeatureFlagsDiff `json:"feature_flags,omitempty"`
+
+	FirmwareVersions []*FirmwareVersion `json:"firmware_versions,omitempty"`
 
 	tmpDir string `json:"-"`
 	conf   *Config
@@ -235,6 +245,11 @@ func (d *Diff) Diff() (err error) {
 		return err
 	}
 
+	log.Info("Diffing FIRMWARE VERSIONS")
+	if err := d.parseFirmwareVersions(); err != nil {
+		return err
+	}
+
 	log.Info("Diffing KERNELCACHES")
 	if err := d.parseKernelcache(); err != nil {
 		return err
@@ -284,8 +299,7 @@ func (d *Diff) Diff() (err error) {
 	}
 
 	log.Info("Diffing ENTITLEMENTS")
-	d.Ents, err = d.parseEntitlements()
-	if err != nil {
+	if err := d.parseEntitlements(); err != nil {
 		return err
 	}
 
@@ -449,6 +463,11 @@ func (d *Diff) parseKernelcache() error {
 		return err
 	}
 
+	d.IOKit, err = kcmd.DiffIOKit(m1, m2)
+	if err != nil {
+		utils.Indent(log.Warn, 2)(fmt.Sprintf("failed to diff kext inventory: %v", err))
+	}
+
 	// // diff kexts
 	// d.Old.Kernel.Kexts, err = kernelcache.KextList(d.Old.Kernel.Path, true)
 	// if err != nil {
@@ -576,22 +595,30 @@ func (d *Diff) parseDSC() error {
 	return nil
 }
 
-func (d *Diff) parseEntitlements() (string, error) {
+func (d *Diff) parseEntitlements() error {
 	oldDB, err := ent.GetDatabase(&ent.Config{IPSW: d.Old.IPSWPath})
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	newDB, err := ent.GetDatabase(&ent.Config{IPSW: d.New.IPSWPath})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return ent.DiffDatabases(oldDB, newDB, &ent.Config{
-		Markdown: true,
-		Color:    false,
-		DiffTool: "git",
-	})
+	conf := &ent.Config{
+		Markdown:    true,
+		Color:       false,
+		DiffTool:    "git",
+		Include:     d.conf.EntInclude,
+		Exclude:     d.conf.EntExclude,
+		Interesting: d.conf.EntInteresting,
+	}
+	if d.EntDiffs, err = ent.Diff(oldDB, newDB, conf); err != nil {
+		return err
+	}
+	d.Ents, err = ent.DiffDatabases(oldDB, newDB, conf)
+	return err
 }
 
 func (d *Diff) parseMachos() (err error) {
@@ -607,24 +634,37 @@ func (d *Diff) parseMachos() (err error) {
 }
 
 func (d *Diff) parseLaunchdPlists() error {
+	// macOS's launchd has no embedded config (its services are read from the plists instead)
 	oldConfig, err := extract.LaunchdConfig(d.Old.IPSWPath, d.conf.PemDB)
 	if err != nil {
-		return fmt.Errorf("diff: parseLaunchdPlists: failed to get 'Old' launchd config: %v", err)
+		log.WithError(err).Warn("diff: parseLaunchdPlists: failed to get 'Old' launchd config")
 	}
 	newConfig, err := extract.LaunchdConfig(d.New.IPSWPath, d.conf.PemDB)
 	if err != nil {
-		return fmt.Errorf("diff: parseLaunchdPlists: failed to get 'New' launchd config: %v", err)
+		log.WithError(err).Warn("diff: parseLaunchdPlists: failed to get 'New' launchd config")
 	}
-	out, err := utils.GitDiff(
-		string(oldConfig)+"\n",
-		string(newConfig)+"\n",
-		&utils.GitDiffConfig{Color: false, Tool: "git"})
+	if len(oldConfig) > 0 && len(newConfig) > 0 {
+		out, err := utils.GitDiff(
+			string(oldConfig)+"\n",
+			string(newConfig)+"\n",
+			&utils.GitDiffConfig{Color: false, Tool: "git"})
+		if err != nil {
+			return err
+		}
+		if len(out) > 0 {
+			d.Launchd = "```diff\n" + out + "\n```"
+		}
+	}
+
+	oldSvcs, err := LaunchdServices(d.Old.IPSWPath, oldConfig, d.conf.PemDB)
 	if err != nil {
-		return err
+		return fmt.Errorf("diff: parseLaunchdPlists: failed to get 'Old' launchd services: %v", err)
 	}
-	if len(out) > 0 {
-		d.Launchd = "```diff\n" + out + "\n```"
+	newSvcs, err := LaunchdServices(d.New.IPSWPath, newConfig, d.conf.PemDB)
+	if err != nil {
+		return fmt.Errorf("diff: parseLaunchdPlists: failed to get 'New' launchd services: %v", err)
 	}
+	d.LaunchdServices = diffLaunchdServices(oldSvcs, newSvcs)
 
 	return nil
 }
@@ -716,5 +756,7 @@ func (d *Diff) parseFeatureFlags() (err error) {
 		}
 	}
 
+	d.Flags = diffFeatureFlags(oldPlists, newPlists)
+
 	return nil
 }
@@ -0,0 +1,174 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+)
+
+// FeatureFlag is the state of a feature flag
+type FeatureFlag struct {
+	Enabled bool `json:"enabled"`
+	// Phase is the flag's DevelopmentPhase (i.e. FeatureComplete)
+	Phase string `json:"phase,omitempty"`
+	// Attributes are the flag's other attributes
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// FlagChange is a feature flag that was added, removed or changed
+type FlagChange struct {
+	Domain string       `json:"domain"`
+	Flag   string       `json:"flag"`
+	Old    *FeatureFlag `json:"old,omitempty"`
+	New    *FeatureFlag `json:"new,omitempty"`
+}
+
+// FeatureFlagsDiff is the diff of the feature flags (/System/Library/FeatureFlags)
+type FeatureFlagsDiff struct {
+	Added    []*FlagChange `json:"added,omitempty"`
+	Removed  []*FlagChange `json:"removed,omitempty"`
+	Enabled  []*FlagChange `json:"enabled,omitempty"`
+	Disabled []*FlagChange `json:"disabled,omitempty"`
+	// Updated are the flags whose phase or attributes changed
+	Updated []*FlagChange `json:"updated,omitempty"`
+}
+
+// Empty returns true if no feature flag changed
+func (f *FeatureFlagsDiff) Empty() bool {
+	return f == nil || len(f.Added)+len(f.Removed)+len(f.Enabled)+len(f.Disabled)+len(f.Updated) == 0
+}
+
+// parseFeatureFlagDomain parses the flags of a feature flag domain plist (i.e. Domain/UIKit.plist)
+func parseFeatureFlagDomain(data string) (map[string]*FeatureFlag, error) {
+	var raw map[string]any
+	if _, err := plist.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+	flags := make(map[string]*FeatureFlag, len(raw))
+	for name, v := range raw {
+		flag := &FeatureFlag{}
+		switch v := v.(type) {
+		case map[string]any:
+			for k, attr := range v {
+				switch k {
+				case "Enabled":
+					flag.Enabled, _ = attr.(bool)
+				case "DevelopmentPhase":
+					flag.Phase, _ = attr.(string)
+				default:
+					if flag.Attributes == nil {
+						flag.Attributes = make(map[string]any)
+					}
+					flag.Attributes[k] = attr
+				}
+			}
+		case bool:
+			flag.Enabled = v
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// diffFeatureFlags compares the flags of the feature flag plists (by path)
+func diffFeatureFlags(oldPlists, newPlists map[string]string) *FeatureFlagsDiff {
+	parse := func(plists map[string]string) map[string]map[string]*FeatureFlag {
+		domains := make(map[string]map[string]*FeatureFlag)
+		for path, data := range plists {
+			flags, err := parseFeatureFlagDomain(data)
+			if err != nil {
+				log.WithError(err).Debugf("failed to parse feature flags plist %s", path)
+				continue
+			}
+			domain := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if domains[domain] == nil {
+				domains[domain] = make(map[string]*FeatureFlag)
+			}
+			maps.Copy(domains[domain], flags)
+		}
+		return domains
+	}
+	prev, next := parse(oldPlists), parse(newPlists)
+
+	fd := &FeatureFlagsDiff{}
+	for domain, flags := range next {
+		for name, flag := range flags {
+			change := &FlagChange{Domain: domain, Flag: name, New: flag}
+			old, ok := prev[domain][name]
+			switch {
+			case !ok:
+				fd.Added = append(fd.Added, change)
+			case !old.Enabled && flag.Enabled:
+				change.Old = old
+				fd.Enabled = append(fd.Enabled, change)
+			case old.Enabled && !flag.Enabled:
+				change.Old = old
+				fd.Disabled = append(fd.Disabled, change)
+			case old.Phase != flag.Phase || !reflect.DeepEqual(old.Attributes, flag.Attributes):
+				change.Old = old
+				fd.Updated = append(fd.Updated, change)
+			}
+		}
+	}
+	for domain, flags := range prev {
+		for name, flag := range flags {
+			if _, ok := next[domain][name]; !ok {
+				fd.Removed = append(fd.Removed, &FlagChange{Domain: domain, Flag: name, Old: flag})
+			}
+		}
+	}
+	for _, changes := range [][]*FlagChange{fd.Added, fd.Removed, fd.Enabled, fd.Disabled, fd.Updated} {
+		slices.SortFunc(changes, func(a, b *FlagChange) int {
+			return cmp.Or(strings.Compare(a.Domain, b.Domain), strings.Compare(a.Flag, b.Flag))
+		})
+	}
+	return fd
+}
+
+func flagState(f *FeatureFlag) string {
+	if f == nil {
+		return ""
+	}
+	state := "disabled"
+	if f.Enabled {
+		state = "enabled"
+	}
+	if len(f.Phase) > 0 {
+		state += " *(" + f.Phase + ")*"
+	}
+	return state
+}
+
+// Markdown returns the feature flag changes as Markdown tables
+func (f *FeatureFlagsDiff) Markdown() string {
+	var out strings.Builder
+	for _, section := range []struct {
+		title   string
+		changes []*FlagChange
+	}{
+		{"🆕 NEW", f.Added},
+		{"✅ Enabled", f.Enabled},
+		{"⛔️ Disabled", f.Disabled},
+		{"⬆️ Updated", f.Updated},
+		{"❌ Removed", f.Removed},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("##### %s (%d)\n\n", section.title, len(section.changes)))
+		out.WriteString("| Domain | Flag | Old | New |\n")
+		out.WriteString("| :----- | :--- | :-- | :-- |\n")
+		for _, c := range section.changes {
+			out.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n", c.Domain, c.Flag, flagState(c.Old), flagState(c.New)))
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
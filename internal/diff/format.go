@@ -5,16 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
-	"github.com/gomarkdown/markdown"
-	"github.com/gomarkdown/markdown/ast"
-	"github.com/gomarkdown/markdown/html"
 )
 
 // {{ range $index, $element := .Ents }}
@@ -286,84 +281,6 @@ func (d *Diff) ToJSON() error {
 	return nil
 }
 
-func (d *Diff) ToHTML() error {
-	htmlHeader := `<!DOCTYPE html>
-<html lang="en">
-<head>
-   <meta charset="UTF-8">
-   <meta http-equiv="X-UA-Compatible" content="IE=edge">
-   <meta name="viewport" content="width=device-width, initial-scale=1.0">
-   <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/bulma@0.9.3/css/bulma.min.css">
-</head>
-<body>
-<br>
-<div class="container is-max-desktop">
-<div class="content">`
-
-	htmlFooter := `
-</div>
-</div>
-<br>
-</body>
-</html>
-`
-	var htmlBuf bytes.Buffer
-
-	renderer := html.NewRenderer(html.RendererOptions{
-		Title:          d.Title,
-		Flags:          html.TOC | html.CommonFlags,
-		RenderNodeHook: renderHook,
-	})
-	output := string(markdown.ToHTML([]byte(d.String()), nil, renderer))
-
-	tmpl := template.Must(template.New("Render").Parse(htmlHeader + "{{.}}" + htmlFooter))
-	if err := tmpl.Execute(&htmlBuf, template.HTML(output)); err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(d.conf.Output, 0755); err != nil {
-		return err
-	}
-
-	fname := filepath.Join(d.conf.Output, fmt.Sprintf("%s.html", d.Title))
-	log.Infof("Creating HTML diff file: %s", fname)
-	return os.WriteFile(fname, htmlBuf.Bytes(), 0644)
-}
-
-func renderHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
-	if _, ok := node.(*ast.Heading); ok {
-		level := strconv.Itoa(node.(*ast.Heading).Level)
-
-		if entering && level == "1" {
-			w.Write([]byte(`<h1 class="title is-1 has-text-centered">`))
-		} else if entering {
-			w.Write([]byte("<h" + level + ">"))
-		} else {
-			w.Write([]byte("</h" + level + ">"))
-		}
-
-		return ast.GoToNext, true
-
-	} else if _, ok := node.(*ast.Image); ok {
-		src := string(node.(*ast.Image).Destination)
-
-		c := node.(*ast.Image).GetChildren()[0]
-		alt := string(c.AsLeaf().Literal)
-
-		if entering && alt != "" {
-			w.Write([]byte(`<figure class="image is-5by3"><img src="` + src + `" alt="` + alt + `">`))
-		} else if entering {
-			w.Write([]byte(`<figure class="image is-5by3"><img src="` + src + `">`))
-		} else {
-			w.Write([]byte(`</figure>`))
-		}
-
-		return ast.SkipChildren, true
-	} else {
-		return ast.GoToNext, false
-	}
-}
-
 // func (c *Context) MarshalJSON() ([]byte, error) {
 // 	return json.Marshal(&struct {
 // 		ID       int         `json:"id,omitempty"`
@@ -0,0 +1,180 @@
+package diff
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	fwcmd "github.com/blacktop/ipsw/internal/commands/fw"
+	"github.com/blacktop/ipsw/pkg/img4"
+	"github.com/blacktop/ipsw/pkg/info"
+)
+
+// FirmwareVersion is the version of a firmware component (i.e. iBoot, SEP, baseband, AOP, ANE or DCP) in both IPSWs
+type FirmwareVersion struct {
+	Component string `json:"component"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// Changed returns true if the component was added, removed or its version changed
+func (f *FirmwareVersion) Changed() bool {
+	return f.Old != f.New
+}
+
+// componentVersion returns the version of a Firmware/ component: the SPTM/TXM build version, its IM4P description,
+// its BuildManifest build string, the baseband firmware's name or, if it has none, its CRC32
+func componentVersion(zf *zip.File, buildString string) string {
+	if fwcmd.IsMonitor(zf.Name) {
+		if v := monitorVersion(zf); len(v) > 0 {
+			return v
+		}
+	}
+	if strings.EqualFold(filepath.Ext(zf.Name), ".im4p") {
+		if r, err := zf.Open(); err == nil {
+			_, desc, err := img4.ReadIm4pHeader(r)
+			r.Close()
+			if err == nil && len(desc) > 0 {
+				return desc
+			}
+		}
+	}
+	if len(buildString) > 0 {
+		return buildString
+	}
+	if strings.EqualFold(filepath.Ext(zf.Name), ".bbfw") {
+		return strings.TrimSuffix(filepath.Base(zf.Name), filepath.Ext(zf.Name))
+	}
+	return fmt.Sprintf("crc32:%08x", zf.CRC32)
+}
+
+// monitorVersion returns the build version in an SPTM or TXM MachO (their IM4P descriptions are not versioned)
+func monitorVersion(zf *zip.File) string {
+	r, err := zf.Open()
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ""
+	}
+	mon, err := fwcmd.ParseMonitor(strings.ToUpper(strings.SplitN(filepath.Base(zf.Name), ".", 2)[0]), data)
+	if err != nil {
+		log.Debugf("failed to parse %s: %v", zf.Name, err)
+		return ""
+	}
+	return mon.Version
+}
+
+// firmwareVersions returns the versions of the IPSW's Firmware/ components by BuildManifest component name
+// (a component can have a version per device)
+func firmwareVersions(ipswPath string, inf *info.Info) (map[string][]string, error) {
+	if inf == nil || inf.Plists == nil || inf.Plists.BuildManifest == nil {
+		return nil, nil
+	}
+	zr, err := zip.OpenReader(ipswPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IPSW: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	versions := make(map[string][]string)
+	seen := make(map[string]string) // path -> version
+	for _, ident := range inf.Plists.BuildManifest.BuildIdentities {
+		for name, comp := range ident.Manifest {
+			path, _ := comp.Info["Path"].(string)
+			if !strings.HasPrefix(path, "Firmware/") {
+				continue
+			}
+			version, ok := seen[path]
+			if !ok {
+				zf, found := files[path]
+				if !found {
+					log.Debugf("firmware %s of %s not found in IPSW", path, name)
+					continue
+				}
+				version = componentVersion(zf, comp.BuildString)
+				seen[path] = version
+			}
+			if !slices.Contains(versions[name], version) {
+				versions[name] = append(versions[name], version)
+			}
+		}
+	}
+	for name := range versions {
+		slices.Sort(versions[name])
+	}
+	return versions, nil
+}
+
+// diffFirmwareVersions returns the version matrix of the firmware components of both IPSWs
+func diffFirmwareVersions(prev, next map[string][]string) []*FirmwareVersion {
+	names := slices.Sorted(maps.Keys(next))
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	matrix := make([]*FirmwareVersion, 0, len(names))
+	for _, name := range names {
+		matrix = append(matrix, &FirmwareVersion{
+			Component: name,
+			Old:       strings.Join(prev[name], ", "),
+			New:       strings.Join(next[name], ", "),
+		})
+	}
+	return matrix
+}
+
+func (d *Diff) parseFirmwareVersions() error {
+	prev, err := firmwareVersions(d.Old.IPSWPath, d.Old.Info)
+	if err != nil {
+		return fmt.Errorf("failed to get 'Old' firmware versions: %v", err)
+	}
+	next, err := firmwareVersions(d.New.IPSWPath, d.New.Info)
+	if err != nil {
+		return fmt.Errorf("failed to get 'New' firmware versions: %v", err)
+	}
+	d.FirmwareVersions = diffFirmwareVersions(prev, next)
+	return nil
+}
+
+// firmwareVersionsMarkdown returns the firmware components whose versions changed as a Markdown table
+func firmwareVersionsMarkdown(matrix []*FirmwareVersion) string {
+	var out strings.Builder
+	var unchanged int
+	out.WriteString("| Component | Old | New |\n")
+	out.WriteString("| :-------- | :-- | :-- |\n")
+	for _, fv := range matrix {
+		if !fv.Changed() {
+			unchanged++
+			continue
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s |\n", fv.Component, codeOrNone(fv.Old), codeOrNone(fv.New)))
+	}
+	if unchanged == len(matrix) {
+		return "- No firmware versions changed\n"
+	}
+	if unchanged > 0 {
+		out.WriteString(fmt.Sprintf("\n> %d other components are unchanged\n", unchanged))
+	}
+	return out.String()
+}
+
+func codeOrNone(s string) string {
+	if len(s) == 0 {
+		return "*(none)*"
+	}
+	return "`" + strings.ReplaceAll(s, ", ", "`, `") + "`"
+}
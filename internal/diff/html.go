@@ -1,5 +1,537 @@
 package diff
 
-func (d *Diff) HTML() error {
-	panic("not implemented")
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/ent"
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// diffHTMLTemplate is the self-contained HTML report (it has no external assets so it can be shared as a single file)
+const diffHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta name="generator" content="ipsw diff">
+<title>{{ .Title }}</title>
+<style>
+:root { --bg: #fff; --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --panel: #f6f8fa; --add: #dafbe1; --del: #ffebe9; --hunk: #ddf4ff; --accent: #0969da; }
+@media (prefers-color-scheme: dark) {
+  :root { --bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --border: #30363d; --panel: #161b22; --add: #12261e; --del: #25171c; --hunk: #121d2f; --accent: #4493f8; }
+}
+* { box-sizing: border-box; }
+body { margin: 0; background: var(--bg); color: var(--fg); font: 14px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 1100px; margin: 0 auto; padding: 24px; }
+h1 { margin: 0 0 4px; font-size: 28px; }
+h2, h3, h4 { margin: 16px 0 8px; }
+code, pre { font: 12px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+pre { background: var(--panel); border: 1px solid var(--border); border-radius: 6px; padding: 8px 0; overflow-x: auto; }
+pre > code > span, pre > code { display: block; padding: 0 12px; }
+pre.diff > code { padding: 0; }
+.add { background: var(--add); }
+.del { background: var(--del); }
+.hunk { background: var(--hunk); color: var(--muted); }
+table { border-collapse: collapse; margin: 8px 0; }
+th, td { border: 1px solid var(--border); padding: 4px 10px; text-align: left; }
+th { background: var(--panel); }
+.muted { color: var(--muted); }
+.toolbar { position: sticky; top: 0; z-index: 1; display: flex; gap: 8px; padding: 12px 0; background: var(--bg); border-bottom: 1px solid var(--border); margin-bottom: 16px; }
+.toolbar input { flex: 1; padding: 6px 10px; border: 1px solid var(--border); border-radius: 6px; background: var(--panel); color: var(--fg); }
+.toolbar button { padding: 6px 12px; border: 1px solid var(--border); border-radius: 6px; background: var(--panel); color: var(--fg); cursor: pointer; }
+.cards { display: flex; flex-wrap: wrap; gap: 8px; margin: 16px 0; }
+.card { flex: 1 1 140px; border: 1px solid var(--border); border-radius: 6px; padding: 8px 12px; }
+.card a { color: var(--accent); text-decoration: none; font-weight: 600; }
+.badge { display: inline-block; min-width: 20px; padding: 0 6px; margin-left: 4px; border-radius: 10px; font-size: 12px; text-align: center; background: var(--panel); border: 1px solid var(--border); }
+.badge.new { background: var(--add); }
+.badge.removed { background: var(--del); }
+.badge.updated { background: var(--hunk); }
+.sev-high { color: #cf222e; font-weight: 600; }
+.sev-medium { color: #bc4c00; font-weight: 600; }
+.sev-low { color: var(--muted); }
+details { border: 1px solid var(--border); border-radius: 6px; margin: 8px 0; }
+details > summary { cursor: pointer; padding: 8px 12px; background: var(--panel); border-radius: 6px; font-weight: 600; }
+details[open] > summary { border-bottom: 1px solid var(--border); border-radius: 6px 6px 0 0; }
+details > .body { padding: 4px 12px 8px; }
+details.section > summary { font-size: 18px; }
+details.item > summary { font-weight: normal; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 12px; }
+ul.files { margin: 4px 0; padding-left: 20px; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{ .Title }}</h1>
+<div class="muted">Generated {{ .Generated }}</div>
+
+<div class="cards">
+{{- range .Summary }}
+  <div class="card"><a href="#{{ .ID }}">{{ .Name }}</a><br>
+  {{- if .New }}<span class="badge new" title="new">+{{ .New }}</span>{{ end -}}
+  {{- if .Removed }}<span class="badge removed" title="removed">-{{ .Removed }}</span>{{ end -}}
+  {{- if .Updated }}<span class="badge updated" title="updated">~{{ .Updated }}</span>{{ end -}}
+  {{- if .Changed }}<span class="badge updated">changed</span>{{ end -}}
+  {{- if .Interesting }}<span class="badge removed" title="interesting">⚠️ {{ .Interesting }}</span>{{ end -}}
+  </div>
+{{- end }}
+</div>
+
+<div class="toolbar">
+  <input id="filter" type="search" placeholder="Filter by path...">
+  <button id="expand">Expand all</button>
+  <button id="collapse">Collapse all</button>
+</div>
+
+<details class="section" id="versions" open>
+<summary>Versions</summary>
+<div class="body">
+<table>
+  <tr><th></th><th>Old</th><th>New</th></tr>
+  <tr><td>IPSW</td><td><code>{{ .Old.IPSW }}</code></td><td><code>{{ .New.IPSW }}</code></td></tr>
+  <tr><td>Version</td><td>{{ .Old.Version }} <span class="muted">({{ .Old.Build }})</span></td><td>{{ .New.Version }} <span class="muted">({{ .New.Build }})</span></td></tr>
+  {{- if or .Old.Darwin .New.Darwin }}
+  <tr><td>Darwin</td><td>{{ .Old.Darwin }}</td><td>{{ .New.Darwin }}</td></tr>
+  <tr><td>XNU</td><td>{{ .Old.XNU }}</td><td>{{ .New.XNU }}</td></tr>
+  <tr><td>Kernel Date</td><td>{{ .Old.KernelDate }}</td><td>{{ .New.KernelDate }}</td></tr>
+  {{- end }}
+  {{- if or .Old.WebKit .New.WebKit }}
+  <tr><td>WebKit</td><td>{{ .Old.WebKit }}</td><td>{{ .New.WebKit }}</td></tr>
+  {{- end }}
+  {{- if or .Old.KDK .New.KDK }}
+  <tr><td>KDK</td><td><code>{{ .Old.KDK }}</code></td><td><code>{{ .New.KDK }}</code></td></tr>
+  {{- end }}
+</table>
+{{- if .FirmwareVersions }}
+<h3>Firmware</h3>
+<table>
+  <tr><th>Component</th><th>Old</th><th>New</th></tr>
+  {{- range .FirmwareVersions }}
+  <tr class="item{{ if not .Changed }} muted{{ end }}" data-path="{{ .Component }}"><td>{{ .Component }}</td><td><code>{{ .Old }}</code></td><td><code{{ if .Changed }} class="add"{{ end }}>{{ .New }}</code></td></tr>
+  {{- end }}
+</table>
+{{- end }}
+</div>
+</details>
+
+{{- define "files" }}
+{{- if .New }}
+<details class="group" open><summary>🆕 New <span class="badge new">{{ len .New }}</span></summary><div class="body"><ul class="files">
+{{- range .New }}<li class="item" data-path="{{ . }}"><code>{{ . }}</code></li>{{ end }}
+</ul></div></details>
+{{- end }}
+{{- if .Removed }}
+<details class="group" open><summary>❌ Removed <span class="badge removed">{{ len .Removed }}</span></summary><div class="body"><ul class="files">
+{{- range .Removed }}<li class="item" data-path="{{ . }}"><code>{{ . }}</code></li>{{ end }}
+</ul></div></details>
+{{- end }}
+{{- if .Updated }}
+<details class="group"><summary>⬆️ Updated <span class="badge updated">{{ len .Updated }}</span></summary><div class="body">
+{{- range .Updated }}
+<details class="item" data-path="{{ .Path }}"><summary>{{ .Path }}</summary><div class="body">{{ .Body }}</div></details>
+{{- end }}
+</div></details>
+{{- end }}
+{{- end }}
+
+{{- if or .Kexts .IOKit .KDKs }}
+<details class="section" id="kernel">
+<summary>Kernel</summary>
+<div class="body">
+{{- if .Kexts }}
+<h3>Kexts</h3>
+{{ template "files" .Kexts }}
+{{- end }}
+{{- if .IOKit }}
+<h3>IOKit</h3>
+{{ .IOKit }}
+{{- end }}
+{{- if .KDKs }}
+<h3>KDKs</h3>
+{{ .KDKs }}
+{{- end }}
+</div>
+</details>
+{{- end }}
+
+{{- if .Machos }}
+<details class="section" id="machos">
+<summary>MachOs</summary>
+<div class="body">{{ template "files" .Machos }}</div>
+</details>
+{{- end }}
+
+{{- if or .Ents .EntDiffs }}
+<details class="section" id="entitlements">
+<summary>🔑 Entitlements</summary>
+<div class="body">
+{{- if .EntDiffs }}
+<table>
+  <tr><th>Severity</th><th>Binary</th><th>Status</th><th>Added</th><th>Removed</th><th>Changed</th></tr>
+  {{- range $d := .EntDiffs }}
+  <tr class="item" data-path="{{ .Path }}">
+    <td class="sev-{{ .Severity }}">{{ .Severity }}</td>
+    <td><code>{{ .Path }}</code></td>
+    <td>{{ .Status }}</td>
+    <td>{{ range .Added }}<code{{ if interesting $d . }} class="sev-high"{{ end }}>{{ . }}</code><br>{{ end }}</td>
+    <td>{{ range .Removed }}<code{{ if interesting $d . }} class="sev-medium"{{ end }}>{{ . }}</code><br>{{ end }}</td>
+    <td>{{ range .Changed }}<code{{ if interesting $d . }} class="sev-high"{{ end }}>{{ . }}</code><br>{{ end }}</td>
+  </tr>
+  {{- end }}
+</table>
+{{- end }}
+{{- if .Ents }}
+<details class="group"><summary>Entitlement Diffs</summary><div class="body">{{ .Ents }}</div></details>
+{{- end }}
+</div>
+</details>
+{{- end }}
+
+{{- if .Dylibs }}
+<details class="section" id="dsc">
+<summary>DSC</summary>
+<div class="body">
+<h3>Dylibs</h3>
+{{ template "files" .Dylibs }}
+</div>
+</details>
+{{- end }}
+
+{{- if or .LaunchdServices .Launchd }}
+<details class="section" id="launchd">
+<summary>launchd</summary>
+<div class="body">
+{{- if .LaunchdServices }}
+{{ .LaunchdServices }}
+{{- end }}
+{{- if .Launchd }}
+<details class="group"><summary>Config Diff</summary><div class="body">{{ .Launchd }}</div></details>
+{{- end }}
+</div>
+</details>
+{{- end }}
+
+{{- if .Firmwares }}
+<details class="section" id="firmwares">
+<summary>Firmwares</summary>
+<div class="body">{{ template "files" .Firmwares }}</div>
+</details>
+{{- end }}
+
+{{- if or .Flags .Features }}
+<details class="section" id="features">
+<summary>Feature Flags</summary>
+<div class="body">
+{{- if .Flags }}
+{{ .Flags }}
+{{- end }}
+{{- if .Features }}
+<h3>Plists</h3>
+{{ template "files" .Features }}
+{{- end }}
+</div>
+</details>
+{{- end }}
+</main>
+<script>
+(function () {
+  var all = function (sel) { return Array.prototype.slice.call(document.querySelectorAll(sel)); };
+  var setOpen = function (open) { all("details").forEach(function (d) { d.open = open; }); };
+  document.getElementById("expand").onclick = function () { setOpen(true); };
+  document.getElementById("collapse").onclick = function () { setOpen(false); };
+  document.getElementById("filter").oninput = function (e) {
+    var q = e.target.value.toLowerCase();
+    all(".item").forEach(function (el) {
+      el.classList.toggle("hidden", q !== "" && el.dataset.path.toLowerCase().indexOf(q) === -1);
+    });
+    all("details.group").forEach(function (g) {
+      var visible = g.querySelectorAll(".item:not(.hidden)").length;
+      g.classList.toggle("hidden", q !== "" && visible === 0);
+      if (q !== "" && visible > 0) { g.open = true; g.closest("details.section").open = true; }
+    });
+  };
+  all("a[href^='#']").forEach(function (a) {
+    a.onclick = function () { var s = document.querySelector(a.getAttribute("href")); if (s) { s.open = true; } };
+  });
+})();
+</script>
+</body>
+</html>
+`
+
+type htmlVersion struct {
+	IPSW       string
+	Version    string
+	Build      string
+	Darwin     string
+	XNU        string
+	KernelDate string
+	WebKit     string
+	KDK        string
+}
+
+type htmlItem struct {
+	Path string
+	Body template.HTML
+}
+
+type htmlFiles struct {
+	New     []string
+	Removed []string
+	Updated []htmlItem
+}
+
+type htmlSummary struct {
+	ID      string
+	Name    string
+	New     int
+	Removed int
+	Updated int
+	Changed bool
+	// Interesting is the number of binaries with interesting entitlement changes
+	// (or of new user clients and user clients with new methods)
+	Interesting int
+}
+
+type htmlReport struct {
+	Title            string
+	Generated        string
+	Old              htmlVersion
+	New              htmlVersion
+	Summary          []htmlSummary
+	Kexts            *htmlFiles
+	IOKit            template.HTML
+	KDKs             template.HTML
+	Machos           *htmlFiles
+	Ents             template.HTML
+	EntDiffs         []*ent.EntDiff
+	Dylibs           *htmlFiles
+	Launchd          template.HTML
+	LaunchdServices  template.HTML
+	Firmwares        *htmlFiles
+	Features         *htmlFiles
+	Flags            template.HTML
+	FirmwareVersions []*FirmwareVersion
+}
+
+func newHTMLVersion(c *Context) htmlVersion {
+	v := htmlVersion{
+		IPSW:    filepath.Base(c.IPSWPath),
+		Version: c.Version,
+		Build:   c.Build,
+		WebKit:  c.Webkit,
+		KDK:     c.KDK,
+	}
+	if c.Kernel.Version != nil {
+		v.Darwin = c.Kernel.Version.KernelVersion.Darwin
+		v.XNU = c.Kernel.Version.KernelVersion.XNU
+		v.KernelDate = c.Kernel.Version.KernelVersion.Date.Format("Mon, 02Jan2006 15:04:05 MST")
+	}
+	return v
+}
+
+// newHTMLFiles sorts the new, removed and updated files and renders their (Markdown) diffs
+func newHTMLFiles(added, removed []string, updated map[string]string) *htmlFiles {
+	if len(added) == 0 && len(removed) == 0 && len(updated) == 0 {
+		return nil
+	}
+	f := &htmlFiles{New: slices.Sorted(slices.Values(added)), Removed: slices.Sorted(slices.Values(removed))}
+	for _, path := range slices.Sorted(maps.Keys(updated)) {
+		f.Updated = append(f.Updated, htmlItem{Path: path, Body: markdownToHTML(updated[path])})
+	}
+	return f
+}
+
+func (f *htmlFiles) summary(id, name string) htmlSummary {
+	return htmlSummary{ID: id, Name: name, New: len(f.New), Removed: len(f.Removed), Updated: len(f.Updated)}
+}
+
+// markdownToHTML renders the Markdown of a diff section (highlighting the lines of its diff code blocks)
+func markdownToHTML(md string) template.HTML {
+	if len(md) == 0 {
+		return ""
+	}
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	renderer := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags:          mdhtml.CommonFlags,
+		RenderNodeHook: diffCodeBlockHook,
+	})
+	return template.HTML(markdown.ToHTML([]byte(md), p, renderer))
+}
+
+// diffCodeBlockHook renders the ```diff code blocks with a span per line, classed by the line's change
+func diffCodeBlockHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	cb, ok := node.(*ast.CodeBlock)
+	if !ok || string(cb.Info) != "diff" {
+		return ast.GoToNext, false
+	}
+	io.WriteString(w, `<pre class="diff"><code>`)
+	for _, line := range strings.Split(strings.TrimSuffix(string(cb.Literal), "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		fmt.Fprintf(w, `<span class="%s">%s</span>`, class, template.HTMLEscapeString(line))
+	}
+	io.WriteString(w, "</code></pre>\n")
+	return ast.GoToNext, true
+}
+
+func (d *Diff) htmlReport() *htmlReport {
+	r := &htmlReport{
+		Title:            d.Title,
+		Generated:        time.Now().Format(time.RFC1123),
+		Old:              newHTMLVersion(&d.Old),
+		New:              newHTMLVersion(&d.New),
+		KDKs:             markdownToHTML(d.KDKs),
+		Ents:             markdownToHTML(d.Ents),
+		EntDiffs:         d.EntDiffs,
+		Launchd:          markdownToHTML(d.Launchd),
+		FirmwareVersions: d.FirmwareVersions,
+	}
+	if len(d.FirmwareVersions) > 0 {
+		s := htmlSummary{ID: "versions", Name: "Firmware Versions"}
+		for _, fv := range d.FirmwareVersions {
+			switch {
+			case len(fv.Old) == 0:
+				s.New++
+			case len(fv.New) == 0:
+				s.Removed++
+			case fv.Changed():
+				s.Updated++
+			}
+		}
+		r.Summary = append(r.Summary, s)
+	}
+	if d.Kexts != nil {
+		if r.Kexts = newHTMLFiles(d.Kexts.New, d.Kexts.Removed, d.Kexts.Updated); r.Kexts != nil {
+			r.Summary = append(r.Summary, r.Kexts.summary("kernel", "Kexts"))
+		}
+	}
+	if !d.IOKit.Empty() {
+		r.IOKit = markdownToHTML(d.IOKit.Markdown())
+		r.Summary = append(r.Summary, htmlSummary{
+			ID:          "kernel",
+			Name:        "IOKit",
+			New:         len(d.IOKit.NewKexts) + len(d.IOKit.NewClasses),
+			Removed:     len(d.IOKit.RemovedKexts) + len(d.IOKit.RemovedClasses),
+			Updated:     len(d.IOKit.UpdatedKexts) + len(d.IOKit.UpdatedClasses),
+			Interesting: len(d.IOKit.NewUserClients()) + len(d.IOKit.NewUserClientMethods()),
+		})
+	}
+	if len(r.KDKs) > 0 {
+		r.Summary = append(r.Summary, htmlSummary{ID: "kernel", Name: "KDKs", Changed: true})
+	}
+	if d.Machos != nil {
+		if r.Machos = newHTMLFiles(d.Machos.New, d.Machos.Removed, d.Machos.Updated); r.Machos != nil {
+			r.Summary = append(r.Summary, r.Machos.summary("machos", "MachOs"))
+		}
+	}
+	if len(d.EntDiffs) > 0 {
+		s := htmlSummary{ID: "entitlements", Name: "Entitlements"}
+		for _, e := range d.EntDiffs {
+			switch e.Status {
+			case ent.StatusNew:
+				s.New++
+			case ent.StatusRemoved:
+				s.Removed++
+			default:
+				s.Updated++
+			}
+			if len(e.Interesting) > 0 {
+				s.Interesting++
+			}
+		}
+		r.Summary = append(r.Summary, s)
+	} else if len(r.Ents) > 0 {
+		r.Summary = append(r.Summary, htmlSummary{ID: "entitlements", Name: "Entitlements", Changed: true})
+	}
+	if d.Dylibs != nil {
+		if r.Dylibs = newHTMLFiles(d.Dylibs.New, d.Dylibs.Removed, d.Dylibs.Updated); r.Dylibs != nil {
+			r.Summary = append(r.Summary, r.Dylibs.summary("dsc", "Dylibs"))
+		}
+	}
+	if !d.LaunchdServices.Empty() {
+		r.LaunchdServices = markdownToHTML(d.LaunchdServices.Markdown())
+		r.Summary = append(r.Summary, htmlSummary{
+			ID:      "launchd",
+			Name:    "launchd",
+			New:     len(d.LaunchdServices.Added),
+			Removed: len(d.LaunchdServices.Removed),
+			Updated: len(d.LaunchdServices.Updated),
+		})
+	} else if len(r.Launchd) > 0 {
+		r.Summary = append(r.Summary, htmlSummary{ID: "launchd", Name: "launchd", Changed: true})
+	}
+	if d.Firmwares != nil {
+		if r.Firmwares = newHTMLFiles(d.Firmwares.New, d.Firmwares.Removed, d.Firmwares.Updated); r.Firmwares != nil {
+			r.Summary = append(r.Summary, r.Firmwares.summary("firmwares", "Firmwares"))
+		}
+	}
+	if !d.Flags.Empty() {
+		r.Flags = markdownToHTML(d.Flags.Markdown())
+		r.Summary = append(r.Summary, htmlSummary{
+			ID:      "features",
+			Name:    "Feature Flags",
+			New:     len(d.Flags.Added),
+			Removed: len(d.Flags.Removed),
+			Updated: len(d.Flags.Enabled) + len(d.Flags.Disabled) + len(d.Flags.Updated),
+		})
+	}
+	if d.Features != nil {
+		if r.Features = newHTMLFiles(slices.Collect(maps.Keys(d.Features.New)), d.Features.Removed, d.Features.Updated); r.Features != nil && d.Flags.Empty() {
+			r.Summary = append(r.Summary, r.Features.summary("features", "Feature Flags"))
+		}
+	}
+	return r
+}
+
+// HTML renders the diff as a self-contained HTML report with collapsible sections
+func (d *Diff) HTML() ([]byte, error) {
+	tmpl, err := template.New("diff").Funcs(template.FuncMap{
+		"interesting": func(d *ent.EntDiff, key string) bool {
+			return slices.Contains(d.Interesting, key)
+		},
+	}).Parse(diffHTMLTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff HTML template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d.htmlReport()); err != nil {
+		return nil, fmt.Errorf("failed to execute diff HTML template: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ToHTML saves the diff as a self-contained HTML report
+func (d *Diff) ToHTML() error {
+	dat, err := d.HTML()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(d.conf.Output, 0o750); err != nil {
+		return err
+	}
+
+	fname := filepath.Join(d.conf.Output, fmt.Sprintf("%s.html", d.TitleToFilename()))
+	log.Infof("Creating HTML diff file: %s", fname)
+	return os.WriteFile(fname, dat, 0o644)
 }
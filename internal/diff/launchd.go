@@ -0,0 +1,242 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/internal/search"
+)
+
+// LaunchdService is a launchd daemon or agent
+type LaunchdService struct {
+	Label            string   `json:"label"`
+	Kind             string   `json:"kind"` // daemon or agent
+	Path             string   `json:"path,omitempty"`
+	Program          string   `json:"program,omitempty"`
+	ProgramArguments []string `json:"program_arguments,omitempty"`
+	MachServices     []string `json:"mach_services,omitempty"`
+
+	job map[string]any
+}
+
+// LaunchdChange is a launchd service whose config changed
+type LaunchdChange struct {
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	// OldArguments and NewArguments are the program (and its arguments) if they changed
+	OldArguments []string `json:"old_arguments,omitempty"`
+	NewArguments []string `json:"new_arguments,omitempty"`
+	// NewMachServices and RemovedMachServices are the mach service names registered (or not anymore)
+	NewMachServices     []string `json:"new_mach_services,omitempty"`
+	RemovedMachServices []string `json:"removed_mach_services,omitempty"`
+	// Keys are the other job keys that changed
+	Keys []string `json:"keys,omitempty"`
+}
+
+// LaunchdDiff is the diff of the launchd daemons and agents
+type LaunchdDiff struct {
+	Added   []*LaunchdService `json:"added,omitempty"`
+	Removed []*LaunchdService `json:"removed,omitempty"`
+	Updated []*LaunchdChange  `json:"updated,omitempty"`
+}
+
+// Empty returns true if no launchd service changed
+func (l *LaunchdDiff) Empty() bool {
+	return l == nil || len(l.Added)+len(l.Removed)+len(l.Updated) == 0
+}
+
+func newLaunchdService(kind, path string, job map[string]any) *LaunchdService {
+	svc := &LaunchdService{Kind: kind, Path: path, job: job}
+	svc.Label, _ = job["Label"].(string)
+	if len(svc.Label) == 0 {
+		svc.Label = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	svc.Program, _ = job["Program"].(string)
+	if args, ok := job["ProgramArguments"].([]any); ok {
+		for _, arg := range args {
+			svc.ProgramArguments = append(svc.ProgramArguments, fmt.Sprint(arg))
+		}
+	}
+	if ms, ok := job["MachServices"].(map[string]any); ok {
+		svc.MachServices = slices.Sorted(maps.Keys(ms))
+	}
+	return svc
+}
+
+// arguments returns the program and its arguments
+func (s *LaunchdService) arguments() []string {
+	if len(s.Program) > 0 && (len(s.ProgramArguments) == 0 || s.ProgramArguments[0] != s.Program) {
+		return append([]string{s.Program}, s.ProgramArguments...)
+	}
+	return s.ProgramArguments
+}
+
+// Job returns the service's launchd job (i.e. its plist)
+func (s *LaunchdService) Job() map[string]any {
+	return s.job
+}
+
+// parseLaunchdConfig parses the services of launchd's embedded config (its __TEXT.__config plist)
+func parseLaunchdConfig(config string) (map[string]*LaunchdService, error) {
+	var cfg map[string]any
+	if _, err := plist.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse launchd config: %v", err)
+	}
+	svcs := make(map[string]*LaunchdService)
+	for key, kind := range map[string]string{"LaunchDaemons": "daemon", "LaunchAgents": "agent"} {
+		jobs, _ := cfg[key].(map[string]any)
+		for path, job := range jobs {
+			if job, ok := job.(map[string]any); ok {
+				svc := newLaunchdService(kind, path, job)
+				svcs[svc.Label] = svc
+			}
+		}
+	}
+	return svcs, nil
+}
+
+// LaunchdServices returns the services (by label) of launchd's embedded config or, if it has none (i.e. macOS),
+// of the LaunchDaemons/LaunchAgents plists in the IPSW
+func LaunchdServices(ipswPath, config, pemDB string) (map[string]*LaunchdService, error) {
+	if len(config) > 0 {
+		svcs, err := parseLaunchdConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		if len(svcs) > 0 {
+			return svcs, nil
+		}
+	}
+	svcs := make(map[string]*LaunchdService)
+	for dir, kind := range map[string]string{"/System/Library/LaunchDaemons": "daemon", "/System/Library/LaunchAgents": "agent"} {
+		if err := search.ForEachPlistInIPSW(ipswPath, dir, pemDB, func(path, content string) error {
+			var job map[string]any
+			if _, err := plist.Unmarshal([]byte(content), &job); err != nil {
+				log.WithError(err).Debugf("failed to parse launchd plist %s", path)
+				return nil
+			}
+			svc := newLaunchdService(kind, filepath.Join(dir, path), job)
+			svcs[svc.Label] = svc
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return svcs, nil
+}
+
+// diffLaunchdServices compares the services by label
+func diffLaunchdServices(prev, next map[string]*LaunchdService) *LaunchdDiff {
+	ld := &LaunchdDiff{}
+	for label, svc := range next {
+		old, ok := prev[label]
+		if !ok {
+			ld.Added = append(ld.Added, svc)
+			continue
+		}
+		change := &LaunchdChange{Label: label, Kind: svc.Kind}
+		if oldArgs, newArgs := old.arguments(), svc.arguments(); !slices.Equal(oldArgs, newArgs) {
+			change.OldArguments, change.NewArguments = oldArgs, newArgs
+		}
+		for _, ms := range svc.MachServices {
+			if !slices.Contains(old.MachServices, ms) {
+				change.NewMachServices = append(change.NewMachServices, ms)
+			}
+		}
+		for _, ms := range old.MachServices {
+			if !slices.Contains(svc.MachServices, ms) {
+				change.RemovedMachServices = append(change.RemovedMachServices, ms)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(mergeKeys(old.job, svc.job))) {
+			switch key {
+			case "Label", "Program", "ProgramArguments", "MachServices":
+				continue
+			}
+			if !reflect.DeepEqual(old.job[key], svc.job[key]) {
+				change.Keys = append(change.Keys, key)
+			}
+		}
+		if len(change.OldArguments)+len(change.NewArguments)+len(change.NewMachServices)+len(change.RemovedMachServices)+len(change.Keys) > 0 {
+			ld.Updated = append(ld.Updated, change)
+		}
+	}
+	for label, svc := range prev {
+		if _, ok := next[label]; !ok {
+			ld.Removed = append(ld.Removed, svc)
+		}
+	}
+	byLabel := func(a, b *LaunchdService) int {
+		return cmp.Or(strings.Compare(a.Kind, b.Kind), strings.Compare(a.Label, b.Label))
+	}
+	slices.SortFunc(ld.Added, byLabel)
+	slices.SortFunc(ld.Removed, byLabel)
+	slices.SortFunc(ld.Updated, func(a, b *LaunchdChange) int {
+		return cmp.Or(strings.Compare(a.Kind, b.Kind), strings.Compare(a.Label, b.Label))
+	})
+	return ld
+}
+
+func mergeKeys(a, b map[string]any) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+func codeList(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(values, "`<br>`") + "`"
+}
+
+// Markdown returns the launchd service changes as Markdown tables
+func (l *LaunchdDiff) Markdown() string {
+	var out strings.Builder
+	for _, section := range []struct {
+		title string
+		svcs  []*LaunchdService
+	}{
+		{"🆕 NEW", l.Added},
+		{"❌ Removed", l.Removed},
+	} {
+		if len(section.svcs) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("#### %s (%d)\n\n", section.title, len(section.svcs)))
+		out.WriteString("| Label | Kind | Program | Mach Services |\n")
+		out.WriteString("| :---- | :--- | :------ | :------------ |\n")
+		for _, svc := range section.svcs {
+			out.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n",
+				svc.Label, svc.Kind, strings.Join(svc.arguments(), " "), codeList(svc.MachServices)))
+		}
+		out.WriteString("\n")
+	}
+	if len(l.Updated) > 0 {
+		out.WriteString(fmt.Sprintf("#### ⬆️ Updated (%d)\n\n", len(l.Updated)))
+		out.WriteString("| Label | Kind | Program | New Mach Services | Removed Mach Services | Changed |\n")
+		out.WriteString("| :---- | :--- | :------ | :---------------- | :-------------------- | :------ |\n")
+		for _, c := range l.Updated {
+			var args string
+			if len(c.OldArguments)+len(c.NewArguments) > 0 {
+				args = fmt.Sprintf("`%s` ➡️ `%s`", strings.Join(c.OldArguments, " "), strings.Join(c.NewArguments, " "))
+			}
+			out.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s |\n",
+				c.Label, c.Kind, args, codeList(c.NewMachServices), codeList(c.RemovedMachServices), codeList(c.Keys)))
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
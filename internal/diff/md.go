@@ -13,6 +13,26 @@ import (
 	"golang.org/x/exp/rand"
 )
 
+// markdownSections are the sections of the Markdown diff (in their default order)
+var markdownSections = []struct {
+	Name   string
+	render func(*Diff, *strings.Builder) error
+}{
+	{"ipsws", (*Diff).mdIPSWs},
+	{"firmware-versions", (*Diff).mdFirmwareVersions},
+	{"kernel", (*Diff).mdKernel},
+	{"kexts", (*Diff).mdKexts},
+	{"iokit", (*Diff).mdIOKit},
+	{"kdks", (*Diff).mdKDKs},
+	{"machos", (*Diff).mdMachos},
+	{"entitlements", (*Diff).mdEntitlements},
+	{"firmware", (*Diff).mdFirmware},
+	{"launchd", (*Diff).mdLaunchd},
+	{"dsc", (*Diff).mdDSC},
+	{"dylibs", (*Diff).mdDylibs},
+	{"features", (*Diff).mdFeatures},
+}
+
 // Markdown saves the diff as Markdown files.
 func (d *Diff) Markdown() error {
 	d.conf.Output = filepath.Join(d.conf.Output, d.TitleToFilename())
@@ -21,9 +41,24 @@ func (d *Diff) Markdown() error {
 	}
 
 	var out strings.Builder
-	/* TOC */
+	for _, section := range markdownSections {
+		if err := section.render(d, &out); err != nil {
+			return fmt.Errorf("failed to render '%s' section: %w", section.Name, err)
+		}
+	}
+	out.WriteString("## EOF\n")
+
+	// Write README.md
+	if err := os.MkdirAll(d.conf.Output, 0o750); err != nil {
+		return err
+	}
+	fname := filepath.Join(d.conf.Output, "README.md")
+	log.Infof("Creating diff file Markdown README: %s", fname)
+	return os.WriteFile(fname, []byte(out.String()), 0o644)
+}
 
-	// SECTION: IPSWs
+// mdIPSWs renders the IPSW file names
+func (d *Diff) mdIPSWs(out *strings.Builder) error {
 	out.WriteString(
 		fmt.Sprintf(
 			"# %s\n\n"+
@@ -35,8 +70,19 @@ func (d *Diff) Markdown() error {
 			filepath.Base(d.New.IPSWPath),
 		),
 	)
+	return nil
+}
+
+// mdFirmwareVersions renders the firmware component version matrix
+func (d *Diff) mdFirmwareVersions(out *strings.Builder) error {
+	if len(d.FirmwareVersions) > 0 {
+		out.WriteString("## Firmware Versions\n\n" + firmwareVersionsMarkdown(d.FirmwareVersions) + "\n")
+	}
+	return nil
+}
 
-	// SECTION: Kernel
+// mdKernel renders the kernel versions
+func (d *Diff) mdKernel(out *strings.Builder) error {
 	if d.Old.Kernel.Version != nil && d.New.Kernel.Version != nil {
 		out.WriteString(
 			fmt.Sprintf(
@@ -55,8 +101,11 @@ func (d *Diff) Markdown() error {
 			),
 		)
 	}
+	return nil
+}
 
-	// SUB-SECTION: Kexts
+// mdKexts renders the kexts
+func (d *Diff) mdKexts(out *strings.Builder) error {
 	if d.Kexts != nil && (len(d.Kexts.New) > 0 || len(d.Kexts.Removed) > 0 || len(d.Kexts.Updated) > 0) {
 		out.WriteString("### Kexts\n\n")
 		if len(d.Kexts.New) > 0 {
@@ -93,8 +142,19 @@ func (d *Diff) Markdown() error {
 			out.WriteString("</details>\n\n")
 		}
 	}
+	return nil
+}
+
+// mdIOKit renders the kext versions and IOKit classes
+func (d *Diff) mdIOKit(out *strings.Builder) error {
+	if !d.IOKit.Empty() {
+		out.WriteString("### IOKit\n\n" + d.IOKit.Markdown())
+	}
+	return nil
+}
 
-	// SUB-SECTION: KDKs
+// mdKDKs renders the KDKs (and writes KDK.md)
+func (d *Diff) mdKDKs(out *strings.Builder) error {
 	if len(d.KDKs) > 0 {
 		out.WriteString("### KDKs\n\n")
 		fname := filepath.Join(d.conf.Output, "KDK.md")
@@ -111,8 +171,11 @@ func (d *Diff) Markdown() error {
 		fmt.Fprintf(f, d.KDKs)
 		out.WriteString(fmt.Sprintf("- [%s](%s)\n\n", "KDK DIFF", "KDK.md"))
 	}
+	return nil
+}
 
-	// SECTION: MachO
+// mdMachos renders the MachOs
+func (d *Diff) mdMachos(out *strings.Builder) error {
 	if d.Machos != nil && (len(d.Machos.New) > 0 || len(d.Machos.Removed) > 0 || len(d.Machos.Updated) > 0) {
 		out.WriteString("## MachO\n\n")
 		if len(d.Machos.New) > 0 {
@@ -183,8 +246,11 @@ func (d *Diff) Markdown() error {
 			out.WriteString("\n</details>\n\n")
 		}
 	}
+	return nil
+}
 
-	// SUB-SECTION: Entitlements
+// mdEntitlements renders the entitlements (and writes Entitlements.md)
+func (d *Diff) mdEntitlements(out *strings.Builder) error {
 	if len(d.Ents) > 0 {
 		out.WriteString("### 🔑 Entitlements\n\n")
 		fname := filepath.Join(d.conf.Output, "Entitlements.md")
@@ -195,10 +261,23 @@ func (d *Diff) Markdown() error {
 		}
 		fmt.Fprintf(f, "## 🔑 Entitlements\n\n")
 		fmt.Fprintf(f, d.Ents)
-		out.WriteString(fmt.Sprintf("- [%s](%s)\n\n", "Entitlements DIFF", "Entitlements.md"))
+		out.WriteString(fmt.Sprintf("- [%s](%s)\n", "Entitlements DIFF", "Entitlements.md"))
+		var interesting int
+		for _, e := range d.EntDiffs {
+			if len(e.Interesting) > 0 {
+				interesting++
+			}
+		}
+		if interesting > 0 {
+			out.WriteString(fmt.Sprintf("- ⚠️ %d binaries with interesting entitlement changes\n", interesting))
+		}
+		out.WriteString("\n")
 	}
+	return nil
+}
 
-	// SECTION: Firmware
+// mdFirmware renders the other firmwares
+func (d *Diff) mdFirmware(out *strings.Builder) error {
 	if d.Firmwares != nil && (len(d.Firmwares.New) > 0 || len(d.Firmwares.Removed) > 0 || len(d.Firmwares.Updated) > 0) {
 		out.WriteString("## Firmware\n\n")
 		if len(d.Firmwares.New) > 0 {
@@ -269,13 +348,27 @@ func (d *Diff) Markdown() error {
 			out.WriteString("\n</details>\n\n")
 		}
 	}
+	return nil
+}
 
-	// SECTION: Launchd
-	if len(d.Launchd) > 0 {
-		out.WriteString("### Launchd\n\n" + d.Launchd + "\n")
+// mdLaunchd renders the launchd services and config
+func (d *Diff) mdLaunchd(out *strings.Builder) error {
+	if len(d.Launchd) > 0 || !d.LaunchdServices.Empty() {
+		out.WriteString("### Launchd\n\n")
+		if !d.LaunchdServices.Empty() {
+			out.WriteString(d.LaunchdServices.Markdown())
+		}
+		if len(d.Launchd) > 0 {
+			out.WriteString("<details>\n" +
+				"  <summary><i>View Config Diff</i></summary>\n\n" +
+				d.Launchd + "\n\n</details>\n\n")
+		}
 	}
+	return nil
+}
 
-	// SECTION: DSC
+// mdDSC renders the WebKit versions
+func (d *Diff) mdDSC(out *strings.Builder) error {
 	if len(d.Old.Webkit) > 0 && len(d.New.Webkit) > 0 &&
 		d.Dylibs != nil && (len(d.Dylibs.New) > 0 || len(d.Dylibs.Removed) > 0 || len(d.Dylibs.Updated) > 0) {
 		out.WriteString("## DSC\n\n")
@@ -293,8 +386,11 @@ func (d *Diff) Markdown() error {
 			),
 		)
 	}
+	return nil
+}
 
-	// SUB-SECTION: Dylibs
+// mdDylibs renders the dyld_shared_cache dylibs
+func (d *Diff) mdDylibs(out *strings.Builder) error {
 	if d.Dylibs != nil && (len(d.Dylibs.New) > 0 || len(d.Dylibs.Removed) > 0 || len(d.Dylibs.Updated) > 0) {
 		out.WriteString("### Dylibs\n\n")
 		if len(d.Dylibs.New) > 0 {
@@ -365,11 +461,18 @@ func (d *Diff) Markdown() error {
 			out.WriteString("\n</details>\n\n")
 		}
 	}
+	return nil
+}
 
-	// SUB-SECTION: Feature Flags
-	if d.Features != nil && (len(d.Features.New) > 0 || len(d.Features.Removed) > 0 || len(d.Features.Updated) > 0) {
+// mdFeatures renders the feature flags
+func (d *Diff) mdFeatures(out *strings.Builder) error {
+	if (d.Features != nil && (len(d.Features.New) > 0 || len(d.Features.Removed) > 0 || len(d.Features.Updated) > 0)) || !d.Flags.Empty() {
 		out.WriteString("### Feature Flags\n\n")
-		if len(d.Features.New) > 0 {
+		if !d.Flags.Empty() {
+			out.WriteString("#### 🚩 Flags\n\n")
+			out.WriteString(d.Flags.Markdown())
+		}
+		if d.Features != nil && len(d.Features.New) > 0 {
 			out.WriteString(fmt.Sprintf("#### 🆕 NEW (%d)\n\n", len(d.Features.New)))
 			out.WriteString("<details>\n" +
 				"  <summary><i>View New</i></summary>\n\n")
@@ -407,7 +510,7 @@ func (d *Diff) Markdown() error {
 			}
 			out.WriteString("\n</details>\n\n")
 		}
-		if len(d.Features.Removed) > 0 {
+		if d.Features != nil && len(d.Features.Removed) > 0 {
 			out.WriteString(fmt.Sprintf("#### ❌ Removed (%d)\n\n", len(d.Features.Removed)))
 			if len(d.Features.Removed) > 30 {
 				out.WriteString("<details>\n" +
@@ -421,7 +524,7 @@ func (d *Diff) Markdown() error {
 			}
 			out.WriteString("\n")
 		}
-		if len(d.Features.Updated) > 0 {
+		if d.Features != nil && len(d.Features.Updated) > 0 {
 			out.WriteString(fmt.Sprintf("#### ⬆️ Updated (%d)\n\n", len(d.Features.Updated)))
 			out.WriteString("<details>\n" +
 				"  <summary><i>View Updated</i></summary>\n\n")
@@ -459,14 +562,5 @@ func (d *Diff) Markdown() error {
 			out.WriteString("\n</details>\n\n")
 		}
 	}
-
-	out.WriteString("## EOF\n")
-
-	// Write README.md
-	if err := os.MkdirAll(d.conf.Output, 0o750); err != nil {
-		return err
-	}
-	fname := filepath.Join(d.conf.Output, "README.md")
-	log.Infof("Creating diff file Markdown README: %s", fname)
-	return os.WriteFile(fname, []byte(out.String()), 0o644)
+	return nil
 }
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+)
+
+// MarkdownSections returns the names of the Markdown diff sections (in their default order)
+func MarkdownSections() []string {
+	names := make([]string, 0, len(markdownSections))
+	for _, section := range markdownSections {
+		names = append(names, section.Name)
+	}
+	return names
+}
+
+// renderSection renders a Markdown diff section by name
+func (d *Diff) renderSection(name string) (string, error) {
+	for _, section := range markdownSections {
+		if section.Name == name {
+			var out strings.Builder
+			if err := section.render(d, &out); err != nil {
+				return "", fmt.Errorf("failed to render '%s' section: %w", name, err)
+			}
+			return out.String(), nil
+		}
+	}
+	return "", fmt.Errorf("unknown section '%s' (must be one of: %s)", name, strings.Join(MarkdownSections(), ", "))
+}
+
+// templateFuncs are the functions available to the Markdown diff templates
+func (d *Diff) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"section":  d.renderSection,
+		"sections": MarkdownSections,
+		"base":     filepath.Base,
+		"code":     func(value string) string { return "`" + value + "`" },
+		"slug":     utils.Slugify,
+		"join":     strings.Join,
+		"trim":     strings.TrimSpace,
+	}
+}
+
+// templateOutput returns the file name of a rendered template (i.e. blog.md.tmpl -> blog.md)
+func templateOutput(tmplPath string) string {
+	name := filepath.Base(tmplPath)
+	for _, ext := range []string{".tmpl", ".gotmpl", ".tpl"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+	return name
+}
+
+// MarkdownTemplate saves the diff as Markdown rendered by Go templates.
+//
+// The templates are executed with the diff as their data and can render the default sections
+// with {{ section "kernel" }} (in any order) or range over them with {{ range sections }}
+func (d *Diff) MarkdownTemplate(tmplPaths ...string) error {
+	d.conf.Output = filepath.Join(d.conf.Output, d.TitleToFilename())
+	if err := os.MkdirAll(d.conf.Output, 0o750); err != nil {
+		return err
+	}
+	for _, tmplPath := range tmplPaths {
+		data, err := os.ReadFile(tmplPath)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		tmpl, err := template.New(filepath.Base(tmplPath)).Funcs(d.templateFuncs()).Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse template '%s': %w", tmplPath, err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, d); err != nil {
+			return fmt.Errorf("failed to execute template '%s': %w", tmplPath, err)
+		}
+		fname := filepath.Join(d.conf.Output, templateOutput(tmplPath))
+		log.Infof("Creating templated diff Markdown: %s", fname)
+		if err := os.WriteFile(fname, []byte(out.String()), 0o644); err != nil {
+			return fmt.Errorf("failed to write templated diff Markdown: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package download
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -114,6 +113,19 @@ type AppleDbOsFile struct {
 	Sources   []OsFileSource `json:"sources"`
 }
 
+// HasDevice returns true if the osFile (or any of its sources) supports the device
+func (f AppleDbOsFile) HasDevice(device string) bool {
+	if slices.Contains(f.DeviceMap, device) {
+		return true
+	}
+	for _, source := range f.Sources {
+		if slices.Contains(source.DeviceMap, device) {
+			return true
+		}
+	}
+	return false
+}
+
 type OsFiles []AppleDbOsFile
 
 func (fs OsFiles) Len() int {
@@ -128,16 +140,10 @@ func (fs OsFiles) Swap(i, j int) {
 	fs[i], fs[j] = fs[j], fs[i]
 }
 
-func (fs OsFiles) Latest(query *ADBQuery) *AppleDbOsFile {
+// Filter returns the osFiles that match the query's version, build, release status, release date range and device
+func (fs OsFiles) Filter(query *ADBQuery) OsFiles {
 	var tmpFS OsFiles
 	for _, f := range fs {
-		if len(query.OSes) > 0 {
-			for _, os := range query.OSes {
-				if f.OS == os {
-					continue
-				}
-			}
-		}
 		if query.IsBeta && !f.Beta {
 			continue
 		} else if query.IsRC && !f.RC {
@@ -148,8 +154,25 @@ func (fs OsFiles) Latest(query *ADBQuery) *AppleDbOsFile {
 		if len(query.Version) > 0 && !strings.HasPrefix(f.Version, query.Version) {
 			continue
 		}
+		if len(query.Build) > 0 && f.Build != query.Build {
+			continue
+		}
+		if !query.Since.IsZero() && time.Time(f.Released).Before(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && time.Time(f.Released).After(query.Until) {
+			continue
+		}
+		if len(query.Device) > 0 && !f.HasDevice(query.Device) {
+			continue
+		}
 		tmpFS = append(tmpFS, f)
 	}
+	return tmpFS
+}
+
+func (fs OsFiles) Latest(query *ADBQuery) *AppleDbOsFile {
+	tmpFS := fs.Filter(query)
 	if len(tmpFS) == 0 {
 		return nil
 	}
@@ -159,32 +182,9 @@ func (fs OsFiles) Latest(query *ADBQuery) *AppleDbOsFile {
 
 // Query returns a list of OsFileSource objects that match the query
 func (fs OsFiles) Query(query *ADBQuery) []OsFileSource {
-	var tmpFS OsFiles
 	var sources []OsFileSource
 
-	for _, f := range fs {
-		if len(query.OSes) > 0 {
-			for _, os := range query.OSes {
-				if f.OS == os {
-					continue
-				}
-			}
-		}
-		if query.IsBeta && !f.Beta {
-			continue
-		} else if query.IsRC && !f.RC {
-			continue
-		} else if query.IsRelease && (f.Beta || f.RC) {
-			continue
-		}
-		if len(query.Version) > 0 && !strings.HasPrefix(f.Version, query.Version) {
-			continue
-		}
-		if len(query.Build) > 0 && f.Build != query.Build {
-			continue
-		}
-		tmpFS = append(tmpFS, f)
-	}
+	tmpFS := fs.Filter(query)
 
 	if query.Latest {
 		var latestFS OsFiles
@@ -258,6 +258,8 @@ type ADBQuery struct {
 	IsBeta            bool
 	IsRC              bool
 	Latest            bool
+	Since             time.Time
+	Until             time.Time
 	Proxy             string
 	Insecure          bool
 	APIToken          string
@@ -346,6 +348,17 @@ func LocalAppleDBLatest(q *ADBQuery) (*AppleDbOsFile, error) {
 	return osfiles.Latest(q), nil
 }
 
+// LocalAppleDBSearch returns the osFiles in the local appledb repo that match the query (newest first)
+func LocalAppleDBSearch(q *ADBQuery) (OsFiles, error) {
+	osfiles, err := getLocalOsfiles(q)
+	if err != nil {
+		return nil, err
+	}
+	found := osfiles.Filter(q)
+	sort.Sort(found)
+	return found, nil
+}
+
 func LocalAppleDBQuery(q *ADBQuery) ([]OsFileSource, error) {
 	osfiles, err := getLocalOsfiles(q)
 	if err != nil {
@@ -354,7 +367,26 @@ func LocalAppleDBQuery(q *ADBQuery) ([]OsFileSource, error) {
 	return osfiles.Query(q), nil
 }
 
+// AppleDBSearch returns the osFiles in appledb (via the Github API) that match the query (newest first)
+func AppleDBSearch(q *ADBQuery) (OsFiles, error) {
+	osfiles, err := getRemoteOsfiles(q)
+	if err != nil {
+		return nil, err
+	}
+	found := osfiles.Filter(q)
+	sort.Sort(found)
+	return found, nil
+}
+
 func AppleDBQuery(q *ADBQuery) ([]OsFileSource, error) {
+	osfiles, err := getRemoteOsfiles(q)
+	if err != nil {
+		return nil, err
+	}
+	return osfiles.Query(q), nil
+}
+
+func getRemoteOsfiles(q *ADBQuery) (OsFiles, error) {
 	var osfiles OsFiles
 
 	for _, os := range q.OSes {
@@ -384,7 +416,7 @@ func AppleDBQuery(q *ADBQuery) ([]OsFileSource, error) {
 					osfiles = append(osfiles, *of)
 				}
 
-				return osfiles.Query(q), nil
+				return osfiles, nil
 			}
 
 			build, version, found := strings.Cut(folder.Name, " - ")
@@ -424,7 +456,7 @@ func AppleDBQuery(q *ADBQuery) ([]OsFileSource, error) {
 		}
 	}
 
-	return osfiles.Query(q), nil
+	return osfiles, nil
 }
 
 func queryGithubAPI(path, proxy, api string, insecure bool) ([]GithubContentsResponse, error) {
@@ -444,7 +476,7 @@ func queryGithubAPI(path, proxy, api string, insecure bool) ([]GithubContentsRes
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -488,7 +520,7 @@ func getOsFiles(path, proxy, api string, insecure bool) (*AppleDbOsFile, error)
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
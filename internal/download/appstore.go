@@ -5,7 +5,6 @@ package download
 import (
 	"archive/zip"
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -258,7 +257,7 @@ func NewAppStore(config *AppStoreConfig) *AppStore {
 			Jar: jar,
 			Transport: &http.Transport{
 				Proxy:           GetProxy(config.Proxy),
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Insecure},
+				TLSClientConfig: TLSConfig(config.Insecure),
 			},
 		},
 		config: config,
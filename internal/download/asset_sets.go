@@ -1,7 +1,6 @@
 package download
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -41,7 +40,7 @@ func GetAssetSets(proxy string, insecure bool) (*AssetSets, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
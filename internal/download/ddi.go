@@ -0,0 +1,195 @@
+package download
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/plist"
+)
+
+// ddiAssetType is the MobileAsset type of the personalized Developer Disk Images
+const ddiAssetType = "com.apple.MobileAsset.DeveloperDiskImage"
+
+// DDIConfig is the personalized Developer Disk Image (DDI) download config
+type DDIConfig struct {
+	Device   string // product type (i.e. iPhone15,2)
+	Version  string // device's product version
+	Build    string // device's build version
+	Audience string // pallas asset audience (default: ios)
+	Output   string // folder to stage the DDI in
+	Proxy    string
+	Insecure bool
+}
+
+// DDI is a staged personalized DDI
+type DDI struct {
+	Folder        string
+	BuildManifest string
+	Image         string
+	TrustCache    string
+	Manifest      *plist.BuildManifest
+}
+
+// StagedDDI returns the DDI staged in the folder (if all its files are there)
+func StagedDDI(folder string) (*DDI, error) {
+	ddi := &DDI{Folder: folder, BuildManifest: filepath.Join(folder, "BuildManifest.plist")}
+	dat, err := os.ReadFile(ddi.BuildManifest)
+	if err != nil {
+		return nil, err
+	}
+	if ddi.Manifest, err = plist.ParseBuildManifest(dat); err != nil {
+		return nil, fmt.Errorf("failed to parse DDI BuildManifest.plist: %v", err)
+	}
+	img, tc, err := ddiPaths(ddi.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	ddi.Image = filepath.Join(folder, path.Base(img))
+	ddi.TrustCache = filepath.Join(folder, path.Base(tc))
+	for _, f := range []string{ddi.Image, ddi.TrustCache} {
+		if _, err := os.Stat(f); err != nil {
+			return nil, err
+		}
+	}
+	return ddi, nil
+}
+
+// ddiPaths returns the PersonalizedDMG and LoadableTrustCache paths (relative to the BuildManifest.plist) of a DDI
+func ddiPaths(bm *plist.BuildManifest) (string, string, error) {
+	if len(bm.BuildIdentities) == 0 {
+		return "", "", fmt.Errorf("DDI BuildManifest.plist has no build identities")
+	}
+	get := func(key string) (string, error) {
+		m, ok := bm.BuildIdentities[0].Manifest[key]
+		if !ok {
+			return "", fmt.Errorf("DDI BuildManifest.plist has no %s", key)
+		}
+		p, ok := m.Info["Path"].(string)
+		if !ok || len(p) == 0 {
+			return "", fmt.Errorf("DDI BuildManifest.plist %s has no path", key)
+		}
+		return p, nil
+	}
+	img, err := get("PersonalizedDMG")
+	if err != nil {
+		return "", "", err
+	}
+	tc, err := get("LoadableTrustCache")
+	if err != nil {
+		return "", "", err
+	}
+	return img, tc, nil
+}
+
+// GetDDI queries pallas for the device's personalized DDI MobileAsset and stages its
+// BuildManifest.plist, PersonalizedDMG and LoadableTrustCache in the output folder
+func GetDDI(conf *DDIConfig) (*DDI, error) {
+	if len(conf.Audience) == 0 {
+		conf.Audience = "ios"
+	}
+	audience, err := ResolveAudience(conf.Audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve asset audience: %v", err)
+	}
+	res, err := QueryPallas(&PallasQuery{
+		AssetType:      ddiAssetType,
+		AssetAudience:  audience,
+		ProductType:    conf.Device,
+		ProductVersion: conf.Version,
+		BuildVersion:   conf.Build,
+		Proxy:          conf.Proxy,
+		Insecure:       conf.Insecure,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var assetURL string
+	for _, asset := range res.Assets {
+		if u := asset.URL(); len(u) > 0 {
+			assetURL = u
+			break
+		}
+	}
+	if len(assetURL) == 0 {
+		return nil, fmt.Errorf("no DDI assets found for %s %s (%s)", conf.Device, conf.Version, conf.Build)
+	}
+	log.WithField("url", assetURL).Debug("Found DDI asset")
+
+	zr, err := NewRemoteZipReader(assetURL, &RemoteConfig{
+		Proxy:    conf.Proxy,
+		Insecure: conf.Insecure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote DDI asset: %v", err)
+	}
+
+	// the DDI files are relative to its BuildManifest.plist (i.e. AssetData/Restore/BuildManifest.plist)
+	var manifest *zip.File
+	for _, f := range zr.File {
+		if path.Base(f.Name) == "BuildManifest.plist" {
+			manifest = f
+			break
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("DDI asset has no BuildManifest.plist")
+	}
+	dat, err := readDDIFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := plist.ParseBuildManifest(dat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DDI BuildManifest.plist: %v", err)
+	}
+	img, tc, err := ddiPaths(bm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(conf.Output, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create DDI folder: %v", err)
+	}
+	base := path.Dir(manifest.Name)
+	for _, name := range []string{manifest.Name, path.Join(base, img), path.Join(base, tc)} {
+		f, err := zr.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s in DDI asset: %v", name, err)
+		}
+		dest := filepath.Join(conf.Output, path.Base(name))
+		log.WithField("file", dest).Debug("Staging DDI file")
+		if err := writeDDIFile(dest, f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.Close()
+	}
+
+	return StagedDDI(conf.Output)
+}
+
+func readDDIFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func writeDDIFile(dest string, r io.Reader) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to write %s: %v", dest, err)
+	}
+	return nil
+}
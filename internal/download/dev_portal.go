@@ -7,7 +7,6 @@ import (
 	"context"
 	"crypto"
 	"crypto/sha1"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
@@ -101,6 +100,7 @@ type DevConfig struct {
 	Insecure bool
 	// download type config
 	WatchList []string
+	Output    string
 	// behavior config
 	SkipAll       bool
 	ResumeAll     bool
@@ -350,7 +350,7 @@ func NewDevPortal(config *DevConfig) *DevPortal {
 			Jar: jar,
 			Transport: &http.Transport{
 				Proxy:           GetProxy(config.Proxy),
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Insecure},
+				TLSClientConfig: TLSConfig(config.Insecure),
 			},
 		},
 		config: config,
@@ -1484,7 +1484,7 @@ func (dp *DevPortal) DownloadADC(adcURL string) error {
 	downloader.Headers["Cookie"] = "ADCDownloadAuth=" + adcDownloadAuth
 
 	// destName := getDestName(adcDownloadURL+path, dp.config.RemoveCommas)
-	destName := getDestName(adcURL, dp.config.RemoveCommas)
+	destName := filepath.Join(dp.config.Output, getDestName(adcURL, dp.config.RemoveCommas))
 	if _, err := os.Stat(destName); os.IsNotExist(err) {
 
 		log.WithFields(log.Fields{
@@ -0,0 +1,204 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/info"
+	semver "github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the device list version constraint that matches the device's current major version
+const CurrentVersion = "current"
+
+// DeviceRule is a device/board/version constraint in a device list
+type DeviceRule struct {
+	// Device is the device identifier glob (i.e. iPhone17,*)
+	Device string `yaml:"device" json:"device"`
+	// Board is the board config glob (i.e. D47AP)
+	Board string `yaml:"board,omitempty" json:"board,omitempty"`
+	// Version is a version constraint (i.e. '>= 18.0, < 19') or 'current' for the latest major version
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+
+	constraint semver.Constraints
+}
+
+// DeviceList is a devices.yaml that declares the devices (and versions) to download
+//
+//	devices:
+//	  - device: iPhone17,*
+//	    version: current
+//	  - device: iPad16,*
+//	    board: J7*AP
+//	    version: '>= 17.0'
+//	exclude:
+//	  - device: iPhone17,5
+type DeviceList struct {
+	Devices []DeviceRule `yaml:"devices" json:"devices"`
+	Exclude []DeviceRule `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+
+	db      *info.Devices
+	mu      sync.Mutex
+	current map[string]int // device -> current major version
+}
+
+// LoadDeviceList reads and validates a devices.yaml
+func LoadDeviceList(path string) (*DeviceList, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device list: %v", err)
+	}
+	var dl DeviceList
+	if err := yaml.Unmarshal(dat, &dl); err != nil {
+		return nil, fmt.Errorf("failed to parse device list %s: %v", path, err)
+	}
+	if len(dl.Devices) == 0 && len(dl.Exclude) == 0 {
+		return nil, fmt.Errorf("device list %s has no devices", path)
+	}
+	for _, rules := range [][]DeviceRule{dl.Devices, dl.Exclude} {
+		for i := range rules {
+			if err := rules[i].init(); err != nil {
+				return nil, fmt.Errorf("invalid device list %s: %v", path, err)
+			}
+		}
+	}
+	if dl.db, err = info.GetIpswDB(); err != nil {
+		return nil, fmt.Errorf("failed to get IPSW device DB: %v", err)
+	}
+	dl.current = make(map[string]int)
+	return &dl, nil
+}
+
+func (r *DeviceRule) init() error {
+	if len(r.Device) == 0 {
+		r.Device = "*"
+	}
+	for _, glob := range []string{r.Device, r.Board} {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("bad glob '%s': %v", glob, err)
+		}
+	}
+	if len(r.Version) > 0 && !strings.EqualFold(r.Version, CurrentVersion) {
+		c, err := semver.NewConstraint(r.Version)
+		if err != nil {
+			return fmt.Errorf("bad version constraint '%s': %v", r.Version, err)
+		}
+		r.constraint = c
+	}
+	return nil
+}
+
+func globMatch(glob, s string) bool {
+	ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(s))
+	return ok
+}
+
+// matchesDevice returns true if the rule's device and board globs match the device
+func (dl *DeviceList) matchesDevice(r DeviceRule, device string) bool {
+	if !globMatch(r.Device, device) {
+		return false
+	}
+	if len(r.Board) == 0 {
+		return true
+	}
+	dev, err := dl.db.LookupDevice(device)
+	if err != nil {
+		return false
+	}
+	for board := range dev.Boards {
+		if globMatch(r.Board, board) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the rule matches the device and version (an empty version only checks the device)
+func (dl *DeviceList) matches(r DeviceRule, device, version string) bool {
+	if !dl.matchesDevice(r, device) {
+		return false
+	}
+	if len(version) == 0 || len(r.Version) == 0 {
+		return true
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		log.WithError(err).Debugf("failed to parse version %s", version)
+		return false
+	}
+	if r.constraint != nil {
+		return r.constraint.Check(v)
+	}
+	current, err := dl.currentMajor(device)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to get the current version of %s (skipping)", device)
+		return false
+	}
+	return v.Segments()[0] == current
+}
+
+// currentMajor returns the major version of the device's latest release (from ipsw.me)
+func (dl *DeviceList) currentMajor(device string) (int, error) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if major, ok := dl.current[device]; ok {
+		return major, nil
+	}
+	ipsws, err := GetDeviceIPSWs(device)
+	if err != nil {
+		return 0, err
+	}
+	var major int
+	for _, i := range ipsws {
+		if v, err := semver.NewVersion(i.Version); err == nil && v.Segments()[0] > major {
+			major = v.Segments()[0]
+		}
+	}
+	if major == 0 {
+		return 0, fmt.Errorf("no releases found")
+	}
+	dl.current[device] = major
+	return major, nil
+}
+
+// Allow returns true if the device list includes the device/version (an empty version only checks the device)
+func (dl *DeviceList) Allow(device, version string) bool {
+	if dl == nil {
+		return true
+	}
+	for _, r := range dl.Exclude {
+		if len(version) == 0 && len(r.Version) > 0 {
+			continue // only excludes some versions of the device
+		}
+		if dl.matches(r, device, version) {
+			return false
+		}
+	}
+	if len(dl.Devices) == 0 {
+		return true
+	}
+	for _, r := range dl.Devices {
+		if dl.matches(r, device, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Identifiers returns the (sorted) device identifiers in the IPSW device DB that the device list includes
+func (dl *DeviceList) Identifiers() []string {
+	var devices []string
+	for prod := range *dl.db {
+		if dl.Allow(prod, "") {
+			devices = append(devices, prod)
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
@@ -1,9 +1,9 @@
 package download
 
 import (
-	"bytes"
+	"context"
 	"crypto/sha1"
-	"crypto/tls"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -23,16 +23,27 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vbauerster/mpb/v8"
 	"github.com/vbauerster/mpb/v8/decor"
-	"golang.org/x/net/http/httpproxy"
 )
 
 // Download is a downloader object
 type Download struct {
 	URL      string
 	Sha1     string
+	Sha256   string
 	DestName string
 	Headers  map[string]string
-
+	// Segments is the number of parallel HTTP range requests to split the download into (0 or 1 disables)
+	Segments int
+	// MaxRate is the bandwidth limit in bytes/sec (0 is unlimited)
+	MaxRate int64
+	// Window is the daily time window downloads are allowed to run in (nil is always)
+	Window *Window
+	// Progress is called with the bytes written so far (and the total size) as the download proceeds
+	Progress func(written, total int64)
+	// Context stops the download with its cancelation cause when it is canceled (nil is never)
+	Context context.Context
+
+	written      int64
 	size         int64
 	bytesResumed int64
 	resume       bool
@@ -42,8 +53,13 @@ type Download struct {
 	restartAll   bool
 	ignoreSha1   bool
 	verbose      bool
+	verified     bool
+	sum1         string
+	sum256       string
 
-	client *http.Client
+	mirror  *url.URL
+	limiter *rateLimiter
+	client  *http.Client
 }
 
 type geoQuery struct {
@@ -74,10 +90,13 @@ func NewDownload(proxy string, insecure, skipAll, resumeAll, restartAll, ignoreS
 		restartAll: restartAll,
 		ignoreSha1: ignoreSha1,
 		verbose:    verbose,
+		MaxRate:    defaultMaxRate,
+		Window:     defaultWindow,
+		mirror:     defaultMirror,
 		client: &http.Client{
 			Transport: &http.Transport{
 				Proxy:           GetProxy(proxy),
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+				TLSClientConfig: TLSConfig(insecure),
 				// MaxConnsPerHost:   50,
 				ForceAttemptHTTP2: true,
 			},
@@ -85,30 +104,6 @@ func NewDownload(proxy string, insecure, skipAll, resumeAll, restartAll, ignoreS
 	}
 }
 
-// GetProxy takes either an input string or read the enviornment and returns a proxy function
-func GetProxy(proxy string) func(*http.Request) (*url.URL, error) {
-	if len(proxy) > 0 {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			log.WithError(err).Error("bad proxy url")
-		}
-		log.Debugf("proxy set to: %s", proxyURL)
-
-		return http.ProxyURL(proxyURL)
-	}
-
-	conf := httpproxy.FromEnvironment()
-	if len(conf.HTTPProxy) > 0 || len(conf.HTTPSProxy) > 0 {
-		log.WithFields(log.Fields{
-			"http_proxy":  conf.HTTPProxy,
-			"https_proxy": conf.HTTPSProxy,
-			"no_proxy":    conf.NoProxy,
-		}).Debugf("proxy info from environment")
-	}
-
-	return http.ProxyFromEnvironment
-}
-
 func (d *Download) getHEAD() error {
 
 	req, err := http.NewRequest("HEAD", d.URL, nil)
@@ -136,13 +131,38 @@ func (d *Download) getHEAD() error {
 	return nil
 }
 
+// useSegments returns true if the download should be split into parallel range requests
+func (d *Download) useSegments() bool {
+	if d.Segments < 2 || !d.canResume || d.size < 2*minSegmentSize {
+		return false
+	}
+	// don't clobber a previous (non-segmented) partial download
+	if _, err := os.Stat(d.DestName + ".download.state"); err == nil {
+		return true
+	}
+	_, err := os.Stat(d.DestName + ".download")
+	return os.IsNotExist(err)
+}
+
 // Do will download a url to a local file. It's efficient because it will
 // write as it downloads and not load the whole file into memory. We pass an io.TeeReader
 // into Copy() to report progress on the download.
 func (d *Download) Do() error {
 
+	if d.Window != nil {
+		d.Window.wait()
+	}
+	if d.MaxRate > 0 && (d.limiter == nil || d.limiter.rate != d.MaxRate) {
+		d.limiter = newRateLimiter(d.MaxRate)
+	}
+
+	d.useMirror()
 	d.getHEAD()
 
+	if d.useSegments() {
+		return d.doSegmented()
+	}
+
 	req, err := http.NewRequest("GET", d.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create http GET request: %v", err)
@@ -240,6 +260,8 @@ func (d *Download) Do() error {
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		return fmt.Errorf("server return status: %s", resp.Status)
 	}
+	d.written = d.bytesResumed
+	resp.Body = d.reportProgress(d.throttle(resp.Body))
 
 	// Apple likes to return 200 OK even when the file is not found/or is not available
 	if resp.Header.Get("Content-type") == "text/html; charset=UTF-8" {
@@ -289,6 +311,7 @@ func (d *Download) Do() error {
 	}
 
 	var p *mpb.Progress
+	var bar *mpb.Bar
 	var reader io.ReadCloser
 
 	if d.size > 0 {
@@ -297,7 +320,6 @@ func (d *Download) Do() error {
 			mpb.WithRefreshRate(180*time.Millisecond),
 		)
 
-		var bar *mpb.Bar
 		bar = p.New(d.size,
 			mpb.BarStyle().Lbound("[").Filler("=").Tip(">").Padding("-").Rbound("|"),
 			mpb.PrependDecorators(
@@ -341,6 +363,9 @@ func (d *Download) Do() error {
 
 	if d.resume {
 		if _, err := io.Copy(dest, reader); err != nil {
+			if errors.Is(err, errWindowClosed) {
+				return d.resumeInWindow(dest, reader, p, bar)
+			}
 			return fmt.Errorf("failed to copy body reader data: %v", err)
 		}
 
@@ -352,22 +377,21 @@ func (d *Download) Do() error {
 		dest.Sync()
 		dest.Close()
 
-		if len(d.Sha1) > 0 && !d.ignoreSha1 {
-			utils.Indent(log.Info, 2)("verifying sha1sum...")
-			if ok, _ := utils.Verify(d.Sha1, d.DestName+".download"); !ok {
-				// fileLock.Unlock()
-				if err := os.Remove(d.DestName + ".download"); err != nil {
-					return fmt.Errorf("cannot remove downloaded file with checksum mismatch: %v", err)
-				}
-				return fmt.Errorf("bad download: ipsw %s sha1 hash is incorrect", d.DestName+".download")
-			}
+		sum1, sum256, err := hashFile(d.DestName + ".download")
+		if err != nil {
+			return err
+		}
+		if err := d.verify(d.DestName+".download", sum1, sum256); err != nil {
+			return err
 		}
 
 	} else {
-		tee := io.TeeReader(reader, dest)
-
-		h := sha1.New()
-		if _, err := io.Copy(h, tee); err != nil {
+		h1 := sha1.New()
+		h256 := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(dest, h1, h256), reader); err != nil {
+			if errors.Is(err, errWindowClosed) {
+				return d.resumeInWindow(dest, reader, p, bar)
+			}
 			return err
 		}
 
@@ -379,20 +403,8 @@ func (d *Download) Do() error {
 		dest.Sync()
 		dest.Close()
 
-		if len(d.Sha1) > 0 && !d.ignoreSha1 {
-			utils.Indent(log.Info, 2)("verifying sha1sum...")
-			checksum, _ := hex.DecodeString(d.Sha1)
-
-			if !bytes.Equal(h.Sum(nil), checksum) {
-				utils.Indent(log.WithFields(log.Fields{
-					"expected": d.Sha1,
-					"actual":   fmt.Sprintf("%x", h.Sum(nil)),
-				}).Error, 3)("❌ BAD CHECKSUM")
-				// fileLock.Unlock()
-				if err := os.Remove(d.DestName); err != nil {
-					return fmt.Errorf("cannot remove downloaded file with checksum mismatch: %v", err)
-				}
-			}
+		if err := d.verify(d.DestName+".download", hex.EncodeToString(h1.Sum(nil)), hex.EncodeToString(h256.Sum(nil))); err != nil {
+			return err
 		}
 	}
 
@@ -400,9 +412,101 @@ func (d *Download) Do() error {
 		return fmt.Errorf("failed to rename %s to %s: %v", d.DestName+".download", d.DestName, err)
 	}
 
+	d.record()
+
+	return nil
+}
+
+// resumeInWindow stops a download interrupted by the end of its window and resumes it
+// (with a range request) once the window reopens
+func (d *Download) resumeInWindow(dest *os.File, body io.Closer, p *mpb.Progress, bar *mpb.Bar) error {
+	body.Close()
+	if bar != nil {
+		bar.Abort(false)
+		p.Wait()
+	}
+	dest.Sync()
+	dest.Close()
+
+	utils.Indent(log.WithField("file", d.DestName).Warn, 2)("Download window closed (the download will be resumed)")
+	resumeAll, restartAll, skipAll := d.resumeAll, d.restartAll, d.skipAll
+	d.resumeAll, d.restartAll, d.skipAll = true, false, false
+	defer func() {
+		d.resumeAll, d.restartAll, d.skipAll = resumeAll, restartAll, skipAll
+	}()
+	return d.Do()
+}
+
+// hashFile returns the sha1 and sha256 hashes of a file
+func hashFile(name string) (string, string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open %s: %v", name, err)
+	}
+	defer f.Close()
+	h1 := sha1.New()
+	h256 := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h1, h256), f); err != nil {
+		return "", "", fmt.Errorf("failed to hash %s: %v", name, err)
+	}
+	return hex.EncodeToString(h1.Sum(nil)), hex.EncodeToString(h256.Sum(nil)), nil
+}
+
+// verify checks a downloaded file's hashes against the expected Sha1/Sha256 and removes the file on a mismatch
+func (d *Download) verify(name, sum1, sum256 string) error {
+	d.sum1, d.sum256, d.verified = sum1, sum256, false
+	if d.ignoreSha1 {
+		return nil
+	}
+	for _, check := range []struct {
+		typ      string
+		expected string
+		actual   string
+	}{
+		{"sha1", d.Sha1, sum1},
+		{"sha256", d.Sha256, sum256},
+	} {
+		if len(check.expected) == 0 {
+			continue
+		}
+		utils.Indent(log.Info, 2)(fmt.Sprintf("verifying %ssum...", check.typ))
+		if !strings.EqualFold(check.expected, check.actual) {
+			utils.Indent(log.WithFields(log.Fields{
+				"expected": check.expected,
+				"actual":   check.actual,
+			}).Error, 3)("❌ BAD CHECKSUM")
+			if err := os.Remove(name); err != nil {
+				return fmt.Errorf("cannot remove downloaded file with checksum mismatch: %v", err)
+			}
+			return fmt.Errorf("bad download: %s %s: %w", name, check.typ, ErrChecksumMismatch)
+		}
+		d.verified = true
+	}
 	return nil
 }
 
+// record saves the completed download's hashes to the download records database (see SetRecords)
+func (d *Download) record() {
+	if len(defaultRecords) == 0 {
+		return
+	}
+	var size int64
+	if fi, err := os.Stat(d.DestName); err == nil {
+		size = fi.Size()
+	}
+	if err := updateRecord(defaultRecords, d.DestName, func(r *Record) {
+		r.URL = d.URL
+		r.Size = size
+		r.Sha1 = d.sum1
+		r.Sha256 = d.sum256
+		r.Verified = d.verified
+		r.Downloaded = time.Now()
+		r.Signed, r.SignedChecked = nil, nil // a new download needs a new signing check
+	}); err != nil {
+		log.WithError(err).Warn("Failed to record download")
+	}
+}
+
 // func multiDownload(urls []string, proxy string, insecure bool) {
 // 	var wg sync.WaitGroup
 // 	// pass &wg (optional), so p will wait for it eventually
@@ -433,7 +537,7 @@ func (d *Download) Do() error {
 // 			client := &http.Client{
 // 				Transport: &http.Transport{
 // 					Proxy:           getProxy(proxy),
-// 					TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+// 					TLSClientConfig: TLSConfig(insecure),
 // 				},
 // 			}
 
@@ -0,0 +1,232 @@
+package download
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const appleDBFirmwareURL = "https://appledb.dev/firmware"
+
+// FeedItem is a firmware release in the release feed
+type FeedItem struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	OS       string    `json:"os"`
+	Version  string    `json:"version"`
+	Build    string    `json:"build"`
+	Beta     bool      `json:"beta,omitempty"`
+	RC       bool      `json:"rc,omitempty"`
+	Released time.Time `json:"released"`
+	Devices  []string  `json:"devices,omitempty"`
+	// Download is the firmware URL of the feed's device (if the feed is for a single device)
+	Download string `json:"download,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// Feed is the normalized release timeline of a set of appledb firmwares
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	Items       []FeedItem
+}
+
+// NewFeed creates a release feed from appledb firmwares (newest first) where device (if set) is the device the
+// feed is for (its firmware download is added to each item) and limit (if set) is the max number of items
+func NewFeed(title string, fws OsFiles, device string, limit int) *Feed {
+	feed := &Feed{
+		Title:       title,
+		Description: "Firmware releases",
+		Link:        appleDBFirmwareURL,
+	}
+	if len(device) > 0 {
+		feed.Description = fmt.Sprintf("Firmware releases for %s", device)
+	}
+	for _, fw := range fws {
+		if limit > 0 && len(feed.Items) >= limit {
+			break
+		}
+		link, _ := url.JoinPath(appleDBFirmwareURL, fw.OS, fw.Build+".html")
+		item := FeedItem{
+			ID:       fmt.Sprintf("%s-%s", fw.OS, fw.Build),
+			Title:    fmt.Sprintf("%s %s (%s)", fw.OS, fw.Version, fw.Build),
+			URL:      link,
+			OS:       fw.OS,
+			Version:  fw.Version,
+			Build:    fw.Build,
+			Beta:     fw.Beta,
+			RC:       fw.RC,
+			Released: time.Time(fw.Released),
+			Devices:  fw.DeviceMap,
+		}
+		if len(device) > 0 {
+			for _, src := range fw.Sources {
+				if !slices.Contains(src.DeviceMap, device) || len(src.PrerequisiteBuild.Builds) > 0 {
+					continue
+				}
+				for _, link := range src.Links {
+					if link.Active {
+						item.Download = link.URL
+						item.Size = src.Size
+						break
+					}
+				}
+				if len(item.Download) > 0 {
+					break
+				}
+			}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssFeedItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Category    string        `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssFeedChannel struct {
+	Title         string        `xml:"title"`
+	Link          string        `xml:"link"`
+	Description   string        `xml:"description"`
+	LastBuildDate string        `xml:"lastBuildDate"`
+	Generator     string        `xml:"generator"`
+	Items         []rssFeedItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name       `xml:"rss"`
+	Version string         `xml:"version,attr"`
+	Channel rssFeedChannel `xml:"channel"`
+}
+
+func (i FeedItem) description() string {
+	desc := fmt.Sprintf("%s %s (%s) released %s", i.OS, i.Version, i.Build, i.Released.Format(time.DateOnly))
+	if len(i.Devices) > 0 {
+		desc += fmt.Sprintf(" for %d devices", len(i.Devices))
+	}
+	return desc
+}
+
+func (i FeedItem) category() string {
+	switch {
+	case i.Beta:
+		return "beta"
+	case i.RC:
+		return "rc"
+	default:
+		return "release"
+	}
+}
+
+// RSS renders the feed as RSS 2.0
+func (f *Feed) RSS() ([]byte, error) {
+	rss := rssFeed{
+		Version: "2.0",
+		Channel: rssFeedChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+			Generator:     "ipsw",
+		},
+	}
+	for _, i := range f.Items {
+		item := rssFeedItem{
+			Title:       i.Title,
+			Link:        i.URL,
+			Description: i.description(),
+			GUID:        rssGUID{Value: i.ID},
+			PubDate:     i.Released.UTC().Format(time.RFC1123Z),
+			Category:    i.category(),
+		}
+		if len(i.Download) > 0 {
+			item.Enclosure = &rssEnclosure{URL: i.Download, Length: i.Size, Type: "application/octet-stream"}
+		}
+		rss.Channel.Items = append(rss.Channel.Items, item)
+	}
+	dat, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal RSS feed: %v", err)
+	}
+	return append([]byte(xml.Header), dat...), nil
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	DatePublished string               `json:"date_published"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+	// Firmware is the normalized release (JSON Feed extensions must start with an underscore)
+	Firmware FeedItem `json:"_ipsw"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// JSON renders the feed as JSON Feed 1.1
+func (f *Feed) JSON() ([]byte, error) {
+	jf := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		Description: f.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, i := range f.Items {
+		item := jsonFeedItem{
+			ID:            i.ID,
+			URL:           i.URL,
+			Title:         i.Title,
+			ContentText:   i.description(),
+			DatePublished: i.Released.UTC().Format(time.RFC3339),
+			Tags:          []string{i.OS, i.category()},
+			Firmware:      i,
+		}
+		if len(i.Download) > 0 {
+			item.Attachments = []jsonFeedAttachment{{URL: i.Download, MimeType: "application/octet-stream", Size: i.Size}}
+		}
+		jf.Items = append(jf.Items, item)
+	}
+	dat, err := json.MarshalIndent(jf, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON feed: %v", err)
+	}
+	return dat, nil
+}
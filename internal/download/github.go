@@ -2,7 +2,6 @@ package download
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -110,7 +109,7 @@ func GetLatestTag(prod, proxy string, insecure bool, api string) (string, error)
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -150,7 +149,7 @@ func queryAppleGithubRepo(prod, proxy string, insecure bool, api string) (*githu
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -193,7 +192,7 @@ func queryAppleGithubRepos(proxy string, insecure bool, api string) (GithubRepos
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -341,7 +340,7 @@ func queryAppleGithubTags(url, proxy string, insecure bool, api string) (GithubT
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -384,7 +383,7 @@ func GetGithubIPSWReleases(proxy string, insecure bool, api string) (GithubRelea
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -423,7 +422,7 @@ func GetPreprocessedAppleOssTags(proxy string, insecure bool) (map[string]Github
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -461,7 +460,7 @@ func GetPreprocessedWebKitTags(proxy string, insecure bool) ([]GithubTag, error)
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -521,7 +520,7 @@ func GetGithubCommits(org, repo, branch, file, pattern string, days int, proxy s
 
 	// httpClient.Transport = &http.Transport{
 	// 	Proxy:           GetProxy(proxy),
-	// 	TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	// 	TLSClientConfig: TLSConfig(insecure),
 	// }
 
 	client := githubv4.NewClient(httpClient)
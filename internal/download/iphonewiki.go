@@ -3,7 +3,6 @@ package download
 import (
 	"bufio"
 	"container/list"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -189,7 +188,7 @@ func getWikiPage(page string, proxy string, insecure bool) (*wikiParseResults, e
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -233,7 +232,7 @@ func getWikiTable(page string, proxy string, insecure bool) (*wikiParseResults,
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -1117,7 +1116,7 @@ func GetWikiIPSWs(cfg *WikiConfig, proxy string, insecure bool) ([]WikiFirmware,
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -1197,7 +1196,7 @@ func GetWikiOTAs(cfg *WikiConfig, proxy string, insecure bool) ([]WikiFirmware,
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -1278,7 +1277,7 @@ func GetWikiFirmwareKeys(cfg *WikiConfig, proxy string, insecure bool) (map[stri
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -1,7 +1,6 @@
 package download
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,7 +26,7 @@ func GetIpswDB(proxy string, insecure bool) (*info.Devices, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
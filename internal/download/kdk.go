@@ -2,6 +2,7 @@ package download
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -81,3 +82,38 @@ func ListKDKs() (KDKs, error) {
 
 	return kdks, nil
 }
+
+// GetBuild returns the KDK for a macOS build
+func (ks KDKs) GetBuild(build string) (*KDK, error) {
+	for _, kdk := range ks {
+		if strings.EqualFold(kdk.Build, build) {
+			return &kdk, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find KDK for build '%s'", build)
+}
+
+// Match returns the KDK whose kernels have the kernel version (i.e. 'Darwin Kernel Version 23.0.0: ...; root:xnu-10002.1.13~1/RELEASE_ARM64_T6000')
+func (ks KDKs) Match(kernelVersion string) (*KDK, error) {
+	_, xnu, found := strings.Cut(kernelVersion, "root:")
+	if !found {
+		return nil, fmt.Errorf("invalid kernel version '%s'", kernelVersion)
+	}
+	xnu, _, _ = strings.Cut(xnu, "/")
+	// prefer an exact match
+	for _, kdk := range ks {
+		for _, kv := range kdk.KernelVersions {
+			if kv == kernelVersion {
+				return &kdk, nil
+			}
+		}
+	}
+	for _, kdk := range ks {
+		for _, kv := range kdk.KernelVersions {
+			if strings.Contains(kv, "root:"+xnu+"/") {
+				return &kdk, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("failed to find KDK for kernel '%s'", kernelVersion)
+}
@@ -124,11 +124,11 @@ func (i ProductInfo) String() string {
 
 type ProductInfos []ProductInfo
 
-// FilterByVersion filters out installers that do not match the given version
+// FilterByVersion filters out installers that do not match the given version (or version prefix i.e. 15 or 15.1)
 func (infos ProductInfos) FilterByVersion(version string) ProductInfos {
 	var out ProductInfos
 	for _, i := range infos {
-		if version == i.Version {
+		if version == i.Version || strings.HasPrefix(i.Version, version+".") {
 			out = append(out, i)
 		}
 	}
@@ -147,6 +147,9 @@ func (infos ProductInfos) FilterByBuild(build string) ProductInfos {
 
 func (infos ProductInfos) GetLatest() ProductInfos {
 	var out ProductInfos
+	if len(infos) == 0 {
+		return out
+	}
 	lastDate := infos[len(infos)-1].PostDate
 	for _, i := range infos {
 		if i.PostDate.YearDay() == lastDate.YearDay() {
@@ -212,73 +215,97 @@ func getDestName(url string, removeCommas bool) string {
 	return destName
 }
 
+// MacOSCatalogs are the named macOS software update catalogs
+var MacOSCatalogs = map[string]string{
+	"release":   sucatalogs24,
+	"customer":  sucatalogs24Cust,
+	"developer": sucatalogs24Dev,
+	"public":    sucatalogs24Public,
+}
+
 // GetProductInfo downloads and parses the macOS installer product infos
 func GetProductInfo() (ProductInfos, error) {
+	return GetCatalogProductInfo("", "", false)
+}
 
-	var catData []byte
-	var prods ProductInfos
+// getCatalog downloads a (gzipped) sucatalog
+func getCatalog(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to downoad the sucatalogs: %v", err)
+	}
+	defer resp.Body.Close()
 
-	if runtime.GOOS == "darwin" {
-		data, err := os.ReadFile(seedCatalogsPlist)
-		if err != nil {
-			return nil, err
-		}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to connect to URL: %s", resp.Status)
+	}
 
-		seed := seedCatalog{}
-		if err := plist.NewDecoder(bytes.NewReader(data)).Decode(&seed); err != nil {
-			return nil, fmt.Errorf("failed to decode sucatalogs plist: %v", err)
-		}
+	document, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sucatalogs data: %v", err)
+	}
 
-		// resp, err := http.Get(seed.CustomerSeed)
-		resp, err := http.Get(seed.DeveloperSeed)
-		if err != nil {
-			return nil, fmt.Errorf("failed to downoad the sucatalogs: %v", err)
-		}
-		defer resp.Body.Close()
+	if !bytes.HasPrefix(document, []byte{0x1f, 0x8b}) {
+		return document, nil // not gzipped
+	}
 
-		if resp.StatusCode != 200 {
-			return nil, fmt.Errorf("failed to connect to URL: %s", resp.Status)
-		}
+	gzr, err := gzip.NewReader(bytes.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %v", err)
+	}
+	defer gzr.Close()
 
-		document, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read sucatalogs data: %v", err)
-		}
+	var buff bytes.Buffer
+	if _, err := buff.ReadFrom(gzr); err != nil {
+		return nil, fmt.Errorf("failed to read gzip data: %v", err)
+	}
 
-		gzr, err := gzip.NewReader(bytes.NewReader(document))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
-		}
-		gzr.Close()
+	return buff.Bytes(), nil
+}
 
-		var buff bytes.Buffer
-		if _, err := buff.ReadFrom(gzr); err != nil {
-			return nil, fmt.Errorf("failed to read gzip data: %v", err)
-		}
-		catData = buff.Bytes()
+// GetCatalogProductInfo downloads and parses the macOS installer product infos from a catalog which is
+// either a name in MacOSCatalogs or a sucatalog URL (if empty the host's seed catalog on macOS or the developer seed catalog)
+func GetCatalogProductInfo(catalog, proxy string, insecure bool) (ProductInfos, error) {
 
-	} else {
-		resp, err := http.Get(sucatalogsLatest)
-		if err != nil {
-			return nil, fmt.Errorf("failed to downoad the sucatalogs: %v", err)
-		}
+	var catURL string
+	var prods ProductInfos
 
-		document, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read sucatalogs data: %v", err)
-		}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           GetProxy(proxy),
+			TLSClientConfig: TLSConfig(insecure),
+		},
+	}
 
-		gzr, err := gzip.NewReader(bytes.NewReader(document))
+	switch {
+	case len(catalog) > 0:
+		if u, ok := MacOSCatalogs[strings.ToLower(catalog)]; ok {
+			catURL = u
+		} else if strings.HasPrefix(catalog, "https://") || strings.HasPrefix(catalog, "http://") {
+			catURL = catalog
+		} else {
+			return nil, fmt.Errorf("unknown catalog '%s' (must be one of: release, customer, developer, public OR a sucatalog URL)", catalog)
+		}
+	case runtime.GOOS == "darwin":
+		data, err := os.ReadFile(seedCatalogsPlist)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
+			return nil, err
 		}
-		gzr.Close()
 
-		var buff bytes.Buffer
-		if _, err := buff.ReadFrom(gzr); err != nil {
-			return nil, fmt.Errorf("failed to read gzip data: %v", err)
+		seed := seedCatalog{}
+		if err := plist.NewDecoder(bytes.NewReader(data)).Decode(&seed); err != nil {
+			return nil, fmt.Errorf("failed to decode sucatalogs plist: %v", err)
 		}
-		catData = buff.Bytes()
+
+		catURL = seed.DeveloperSeed
+	default:
+		catURL = sucatalogsLatest
+	}
+
+	log.WithField("catalog", catURL).Debug("Downloading sucatalog")
+	catData, err := getCatalog(client, catURL)
+	if err != nil {
+		return nil, err
 	}
 
 	cat := Catalog{}
@@ -296,7 +323,7 @@ func GetProductInfo() (ProductInfos, error) {
 		pInfo := ProductInfo{ProductID: key, PostDate: prod.PostDate, Product: prod}
 
 		if len(prod.ServerMetadataURL) > 0 {
-			resp, err := http.Get(prod.ServerMetadataURL)
+			resp, err := client.Get(prod.ServerMetadataURL)
 			if err != nil {
 				return nil, fmt.Errorf("failed to download the server metadata %s: %v", prod.ServerMetadataURL, err)
 			}
@@ -331,7 +358,7 @@ func GetProductInfo() (ProductInfos, error) {
 			}
 		}
 
-		resp, err := http.Get(distURL)
+		resp, err := client.Get(distURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to download the distribution: %v", err)
 		}
@@ -0,0 +1,70 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+)
+
+var defaultMirror *url.URL
+
+// SetMirror sets the LAN mirror (see `ipsw serve --mirror`) that all new downloaders try before the original URL
+func SetMirror(mirror string) error {
+	if len(mirror) == 0 {
+		defaultMirror = nil
+		return nil
+	}
+	u, err := url.Parse(mirror)
+	if err != nil {
+		return fmt.Errorf("failed to parse mirror URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid mirror URL '%s' (must be http:// or https://)", mirror)
+	}
+	defaultMirror = u
+	return nil
+}
+
+// MirrorURL returns the URL of a file on the mirror (the original URL's path on the mirror's host)
+func MirrorURL(mirror *url.URL, orig string) (string, error) {
+	u, err := url.Parse(orig)
+	if err != nil {
+		return "", err
+	}
+	m := *mirror
+	m.Path = strings.TrimSuffix(mirror.Path, "/") + u.Path
+	m.RawQuery = u.RawQuery
+	return m.String(), nil
+}
+
+// useMirror switches the download to the mirror if it has the file
+func (d *Download) useMirror() {
+	if d.mirror == nil || strings.HasPrefix(d.URL, d.mirror.String()) {
+		return
+	}
+	murl, err := MirrorURL(d.mirror, d.URL)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest("HEAD", murl, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Add("User-Agent", utils.RandomAgent())
+	resp, err := d.client.Do(req)
+	if err != nil {
+		log.WithError(err).Debug("mirror unavailable")
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Debugf("mirror does not have %s (%s)", d.URL, resp.Status)
+		return
+	}
+	utils.Indent(log.WithField("mirror", d.mirror.Host).Info, 2)("Downloading from mirror")
+	d.URL = murl
+}
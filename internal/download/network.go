@@ -0,0 +1,126 @@
+package download
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+
+	"github.com/apex/log"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxySchemes are the supported proxy URL schemes
+var ProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+var (
+	defaultProxy   *url.URL
+	defaultRootCAs *x509.CertPool
+)
+
+// ParseProxy parses an HTTP, HTTPS or SOCKS5 proxy URL (i.e. socks5://127.0.0.1:1080)
+func ParseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy URL: %v", err)
+	}
+	if !slices.Contains(ProxySchemes, u.Scheme) || len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid proxy URL '%s' (must be <http|https|socks5|socks5h>://HOST:PORT)", proxy)
+	}
+	return u, nil
+}
+
+// SetNetworkConfig sets the default proxy (used by clients that aren't given one, before the environment's) and the
+// extra PEM CA bundles trusted by all network clients; it also applies them to http.DefaultClient
+func SetNetworkConfig(proxy string, caCerts []string) (err error) {
+	defaultProxy = nil
+	if len(proxy) > 0 {
+		if defaultProxy, err = ParseProxy(proxy); err != nil {
+			return err
+		}
+	}
+
+	defaultRootCAs = nil
+	if len(caCerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			log.WithError(err).Debug("failed to load system cert pool")
+			pool = x509.NewCertPool()
+		}
+		for _, caCert := range caCerts {
+			pem, err := os.ReadFile(caCert)
+			if err != nil {
+				return fmt.Errorf("failed to read CA bundle: %v", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no PEM certificates found in CA bundle %s", caCert)
+			}
+		}
+		defaultRootCAs = pool
+	}
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = GetProxy("")
+		t.TLSClientConfig = TLSConfig(false)
+	}
+
+	return nil
+}
+
+// TLSConfig returns the TLS client config that trusts the system roots and the CA bundles (see SetNetworkConfig)
+func TLSConfig(insecure bool) *tls.Config {
+	return &tls.Config{InsecureSkipVerify: insecure, RootCAs: defaultRootCAs}
+}
+
+// GetProxy takes either an input string or read the enviornment and returns a proxy function
+//
+// The proxy can be an HTTP, HTTPS or SOCKS5 URL and falls back to the default proxy (see SetNetworkConfig),
+// then the HTTP_PROXY/HTTPS_PROXY/NO_PROXY and finally the ALL_PROXY environment variables.
+func GetProxy(proxy string) func(*http.Request) (*url.URL, error) {
+	if len(proxy) > 0 {
+		proxyURL, err := ParseProxy(proxy)
+		if err != nil {
+			log.WithError(err).Error("bad proxy url")
+			return func(*http.Request) (*url.URL, error) { return nil, err }
+		}
+		log.Debugf("proxy set to: %s", proxyURL.Redacted())
+
+		return http.ProxyURL(proxyURL)
+	}
+
+	if defaultProxy != nil {
+		return http.ProxyURL(defaultProxy)
+	}
+
+	conf := httpproxy.FromEnvironment()
+	if len(conf.HTTPProxy) > 0 || len(conf.HTTPSProxy) > 0 {
+		log.WithFields(log.Fields{
+			"http_proxy":  conf.HTTPProxy,
+			"https_proxy": conf.HTTPSProxy,
+			"no_proxy":    conf.NoProxy,
+		}).Debugf("proxy info from environment")
+		return http.ProxyFromEnvironment
+	}
+
+	all := os.Getenv("ALL_PROXY")
+	if len(all) == 0 {
+		all = os.Getenv("all_proxy")
+	}
+	if len(all) > 0 {
+		log.WithFields(log.Fields{
+			"all_proxy": all,
+			"no_proxy":  conf.NoProxy,
+		}).Debugf("proxy info from environment")
+		conf.HTTPProxy = all
+		conf.HTTPSProxy = all
+		proxyFn := conf.ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return proxyFn(req.URL)
+		}
+	}
+
+	return http.ProxyFromEnvironment
+}
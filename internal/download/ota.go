@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"crypto/tls"
 	_ "embed"
 	"encoding/base64"
 	"encoding/json"
@@ -206,7 +205,7 @@ func NewOTA(as *AssetSets, conf OtaConf) (*Ota, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(conf.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.Insecure},
+			TLSClientConfig: TLSConfig(conf.Insecure),
 		},
 	}
 
@@ -526,6 +525,26 @@ func (o *Ota) buildPallasRequests() (reqs []pallasRequest, err error) {
 	return reqs, nil
 }
 
+// decodePallasResponse decodes the (JWT style) payload of a pallas response
+func decodePallasResponse(body []byte) ([]byte, error) {
+	// repair/parse base64 response data
+	parts := strings.Split(string(body), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("failed to base64 decode pallas response: cannot split response body \"%s\" ", string(body))
+	}
+	b64Str := parts[1]
+	b64Str = strings.ReplaceAll(b64Str, "-", "+")
+	b64Str = strings.ReplaceAll(b64Str, "_", "/")
+
+	// bas64 decode the results
+	b64data, err := base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(b64Str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64 decode pallas response: %v", err)
+	}
+
+	return b64data, nil
+}
+
 func sendPostAsync(body []byte, rc chan *http.Response, config *OtaConf) error {
 	req, err := http.NewRequest("POST", pallasURL, bytes.NewBuffer(body))
 	if err != nil {
@@ -538,7 +557,7 @@ func sendPostAsync(body []byte, rc chan *http.Response, config *OtaConf) error {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(config.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Insecure},
+			TLSClientConfig: TLSConfig(config.Insecure),
 		},
 		Timeout: config.Timeout * time.Second,
 	}
@@ -592,20 +611,9 @@ func (o *Ota) GetPallasOTAs() ([]types.Asset, error) {
 			continue
 		}
 
-		// repair/parse base64 response data
-		parts := strings.Split(string(body), ".")
-		if len(parts) < 2 {
-			log.Errorf("failed to base64 decode pallas response: cannot split response body \"%s\" ", string(body))
-			continue
-		}
-		b64Str := parts[1]
-		b64Str = strings.ReplaceAll(b64Str, "-", "+")
-		b64Str = strings.ReplaceAll(b64Str, "_", "/")
-
-		// bas64 decode the results
-		b64data, err := base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(b64Str)
+		b64data, err := decodePallasResponse(body)
 		if err != nil {
-			log.Errorf("failed to base64 decode pallas response: %v", err)
+			log.Error(err.Error())
 			continue
 		}
 
@@ -0,0 +1,263 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/info"
+)
+
+// PallasAssetTypes are the well known pallas asset types (any other asset type can be requested by its full name)
+var PallasAssetTypes = map[string]string{
+	"ota":          string(softwareUpdate),
+	"rsr":          string(rsrUpdate),
+	"watch-docs":   string(watchSoftwareUpdate),
+	"recovery":     string(recoveryOSUpdate),
+	"mac":          string(macSoftwareUpdate),
+	"mac-rsr":      string(macRsrUpdate),
+	"sfr":          string(recoveryOsSoftwareUpdate),
+	"accessory":    string(accessorySoftwareUpdate),
+	"brain":        "com.apple.MobileAsset.MobileSoftwareUpdate.UpdateBrain",
+	"ios-sim":      string(iOsSimulatorUpdate),
+	"watchos-sim":  string(watchOsSimulatorUpdate),
+	"sw-docs":      "com.apple.MobileAsset.SoftwareUpdateDocumentation",
+	"mac-brain":    "com.apple.MobileAsset.MacUpdateBrain",
+	"tvos-sim":     "com.apple.MobileAsset.appleTVOSSimulatorRuntime",
+	"visionos-sim": "com.apple.MobileAsset.xrOSSimulatorRuntime",
+	"ddi":          ddiAssetType,
+}
+
+var uuidRE = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// PallasAssetType resolves a pallas asset type alias (see PallasAssetTypes) to its full name
+func PallasAssetType(typ string) string {
+	if t, ok := PallasAssetTypes[strings.ToLower(typ)]; ok {
+		return t
+	}
+	return typ
+}
+
+// ResolveAudience resolves an asset audience which is either a UUID or <platform>[:<release|generic|alternate|VERSION-<developer|public|appleseed>-beta>]
+func ResolveAudience(audience string) (string, error) {
+	if uuidRE.MatchString(audience) {
+		return audience, nil
+	}
+
+	db, err := GetAssetAudienceIDs()
+	if err != nil {
+		return "", err
+	}
+
+	platform, kind, _ := strings.Cut(strings.ToLower(audience), ":")
+	ids, ok := db[platform]
+	if !ok {
+		var platforms []string
+		for p := range db {
+			platforms = append(platforms, p)
+		}
+		sort.Strings(platforms)
+		return "", fmt.Errorf("unknown audience platform '%s' (must be one of: %s)", platform, strings.Join(platforms, ", "))
+	}
+
+	var id string
+	switch kind {
+	case "", "release":
+		id = ids.Release
+	case "generic":
+		id = ids.Generic
+	case "alternate":
+		id = ids.Alternate
+	default:
+		version, beta, found := strings.Cut(kind, "-")
+		if !found {
+			return "", fmt.Errorf("invalid audience '%s'", audience)
+		}
+		v, ok := ids.Versions[version]
+		if !ok {
+			return "", fmt.Errorf("unknown %s audience version '%s' (known versions: %s)", platform, version, strings.Join(db.GetVersions(platform), ", "))
+		}
+		switch beta {
+		case "developer-beta", "beta":
+			id = v.DeveloperBeta
+		case "public-beta":
+			id = v.PublicBeta
+		case "appleseed-beta":
+			id = v.AppleSeedBeta
+		default:
+			return "", fmt.Errorf("invalid audience beta type '%s' (must be one of: developer-beta, public-beta, appleseed-beta)", beta)
+		}
+	}
+	if len(id) == 0 {
+		return "", fmt.Errorf("audience '%s' not found", audience)
+	}
+
+	return id, nil
+}
+
+// PallasQuery is a raw pallas asset request
+type PallasQuery struct {
+	AssetType               string
+	AssetAudience           string
+	ProductType             string
+	HWModel                 string
+	ProductVersion          string
+	BuildVersion            string
+	RequestedProductVersion string
+	ReleaseType             string
+	Supervised              bool
+	// Params are extra request parameters (they override the ones above)
+	Params   map[string]any
+	Proxy    string
+	Insecure bool
+	Timeout  time.Duration
+}
+
+func (q *PallasQuery) body() ([]byte, error) {
+	req := map[string]any{
+		"ClientVersion":        clientVersion,
+		"AssetType":            PallasAssetType(q.AssetType),
+		"AssetAudience":        q.AssetAudience,
+		"CertIssuanceDay":      certIssuanceDay,
+		"CompatibilityVersion": 20,
+	}
+	set := func(key, val string) {
+		if len(val) > 0 {
+			req[key] = val
+		}
+	}
+	set("ProductType", q.ProductType)
+	set("HWModelStr", q.HWModel)
+	set("ProductVersion", q.ProductVersion)
+	set("BuildVersion", q.BuildVersion)
+	set("RequestedProductVersion", q.RequestedProductVersion)
+	set("ReleaseType", q.ReleaseType)
+	if q.Supervised {
+		req["Supervised"] = true
+	}
+	for k, v := range q.Params {
+		req[k] = v
+	}
+	return json.Marshal(req)
+}
+
+// PallasAsset is a pallas asset (the keys depend on the asset type)
+type PallasAsset map[string]any
+
+// URL returns the download URL of the asset
+func (a PallasAsset) URL() string {
+	base, _ := a["__BaseURL"].(string)
+	rel, _ := a["__RelativePath"].(string)
+	if len(rel) == 0 {
+		return ""
+	}
+	return base + rel
+}
+
+// Get returns the string value of an asset key
+func (a PallasAsset) Get(key string) string {
+	switch v := a[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// PallasResponse is a decoded pallas response
+type PallasResponse struct {
+	AssetSetID    string        `json:"AssetSetId,omitempty"`
+	AssetAudience string        `json:"AssetAudience,omitempty"`
+	PostingDate   string        `json:"PostingDate,omitempty"`
+	Assets        []PallasAsset `json:"Assets,omitempty"`
+}
+
+// QueryPallas sends a raw asset request to Apple's pallas server
+func QueryPallas(q *PallasQuery) (*PallasResponse, error) {
+	if len(q.AssetType) == 0 {
+		return nil, fmt.Errorf("no asset type provided")
+	}
+	if len(q.AssetAudience) == 0 {
+		return nil, fmt.Errorf("no asset audience provided")
+	}
+
+	if len(q.ProductType) > 0 && len(q.HWModel) == 0 {
+		db, err := info.GetIpswDB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ipsw db: %v", err)
+		}
+		dev, err := db.LookupDevice(q.ProductType)
+		if err == nil {
+			var models []string
+			for model := range dev.Boards {
+				models = append(models, model)
+			}
+			if len(models) > 0 {
+				sort.Strings(models)
+				q.HWModel = models[0]
+			}
+		}
+	}
+
+	body, err := q.body()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pallas request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", pallasURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create https request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("User-Agent", utils.RandomAgent())
+
+	if q.Timeout == 0 {
+		q.Timeout = 90 * time.Second
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           GetProxy(q.Proxy),
+			TLSClientConfig: TLSConfig(q.Insecure),
+		},
+		Timeout: q.Timeout,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pallas: %v", err)
+	}
+	defer resp.Body.Close()
+
+	rbody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if dat, err := decodePallasResponse(rbody); err == nil {
+			return nil, fmt.Errorf("pallas returned status: %s: %s", resp.Status, string(dat))
+		}
+		return nil, fmt.Errorf("pallas returned status: %s", resp.Status)
+	}
+
+	dat, err := decodePallasResponse(rbody)
+	if err != nil {
+		return nil, err
+	}
+
+	var res PallasResponse
+	if err := json.Unmarshal(dat, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pallas response: %v", err)
+	}
+
+	return &res, nil
+}
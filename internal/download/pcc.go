@@ -3,7 +3,6 @@ package download
 import (
 	"bytes"
 	"crypto/sha256"
-	"crypto/tls"
 	"encoding/asn1"
 	"encoding/binary"
 	"encoding/hex"
@@ -256,7 +255,7 @@ func GetPCCReleases(proxy string, insecure bool) ([]PCCRelease, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: TLSConfig(insecure),
 		},
 	}
 
@@ -0,0 +1,118 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrChecksumMismatch is returned when a downloaded file's hash does not match the API metadata
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Record is the verification (and signing) state of a downloaded file
+type Record struct {
+	Path       string    `json:"path"`
+	URL        string    `json:"url,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	Sha1       string    `json:"sha1,omitempty"`
+	Sha256     string    `json:"sha256,omitempty"`
+	Verified   bool      `json:"verified"` // a hash from the API metadata matched
+	Downloaded time.Time `json:"downloaded"`
+	// signing status (from TSS)
+	Device        string     `json:"device,omitempty"`
+	Version       string     `json:"version,omitempty"`
+	Build         string     `json:"build,omitempty"`
+	Signed        *bool      `json:"signed,omitempty"`
+	SignedChecked *time.Time `json:"signed_checked,omitempty"`
+}
+
+var (
+	defaultRecords string
+	recordsMu      sync.Mutex
+)
+
+// SetRecords sets the download records database that all new downloaders record completed downloads to (empty disables)
+func SetRecords(path string) {
+	defaultRecords = path
+}
+
+// LoadRecords reads the download records database
+func LoadRecords(path string) ([]Record, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read download records: %v", err)
+	}
+	var records []Record
+	if err := json.Unmarshal(dat, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse download records %s: %v", path, err)
+	}
+	return records, nil
+}
+
+// updateRecord applies update to the record of the file (creating it if needed) and saves the database
+func updateRecord(path, name string, update func(*Record)) error {
+	recordsMu.Lock()
+	defer recordsMu.Unlock()
+
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %s: %v", name, err)
+	}
+
+	records, err := LoadRecords(path)
+	if err != nil {
+		return err
+	}
+
+	idx := -1
+	for i := range records {
+		if records[i].Path == abs {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		records = append(records, Record{Path: abs})
+		idx = len(records) - 1
+	}
+	update(&records[idx])
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Path < records[j].Path
+	})
+
+	dat, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal download records: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create download records folder: %v", err)
+	}
+	if err := os.WriteFile(path, dat, 0o644); err != nil {
+		return fmt.Errorf("failed to write download records: %v", err)
+	}
+	return nil
+}
+
+// RecordSigned records the TSS signing status of a downloaded file in the download records database
+func RecordSigned(name, device, version, build string, signed bool) error {
+	if len(defaultRecords) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return updateRecord(defaultRecords, name, func(r *Record) {
+		r.Device = device
+		r.Version = version
+		r.Build = build
+		r.Signed = &signed
+		r.SignedChecked = &now
+	})
+}
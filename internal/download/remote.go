@@ -2,7 +2,6 @@ package download
 
 import (
 	"archive/zip"
-	"crypto/tls"
 	"net/http"
 	"net/url"
 
@@ -31,7 +30,7 @@ func NewRemoteZipReader(zipURL string, config *RemoteConfig) (*zip.Reader, error
 		Client: &http.Client{
 			Transport: &http.Transport{
 				Proxy:           GetProxy(config.Proxy),
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Insecure},
+				TLSClientConfig: TLSConfig(config.Insecure),
 			},
 		},
 		DisableAcceptRangesHeaderCheck: true,
@@ -0,0 +1,303 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+const (
+	// minSegmentSize is the smallest range a segment will be split down to
+	minSegmentSize = 16 * 1024 * 1024
+	// segmentRetries is the number of times a failed segment is retried before giving up
+	segmentRetries = 5
+	// stateSaveInterval is how often the segment state file is flushed to disk
+	stateSaveInterval = 2 * time.Second
+)
+
+type segment struct {
+	Start   int64 `json:"start"`
+	End     int64 `json:"end"` // inclusive
+	Written int64 `json:"written"`
+}
+
+func (s *segment) remaining() int64 {
+	return s.End - s.Start + 1 - atomic.LoadInt64(&s.Written)
+}
+
+// segmentState is persisted next to the partial download so an interrupted download can be resumed
+type segmentState struct {
+	URL      string     `json:"url"`
+	Size     int64      `json:"size"`
+	Segments []*segment `json:"segments"`
+
+	mu sync.Mutex
+}
+
+func (s *segmentState) written() int64 {
+	var n int64
+	for _, seg := range s.Segments {
+		n += atomic.LoadInt64(&seg.Written)
+	}
+	return n
+}
+
+func (s *segmentState) save(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// snapshot the progress as the segments are still being written to
+	snap := segmentState{URL: s.URL, Size: s.Size}
+	for _, seg := range s.Segments {
+		snap.Segments = append(snap.Segments, &segment{
+			Start:   seg.Start,
+			End:     seg.End,
+			Written: atomic.LoadInt64(&seg.Written),
+		})
+	}
+	dat, err := json.Marshal(&snap)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", dat, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func loadSegmentState(path, url string, size int64) (*segmentState, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s segmentState
+	if err := json.Unmarshal(dat, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse download state %s: %v", path, err)
+	}
+	if s.URL != url || s.Size != size || len(s.Segments) == 0 {
+		return nil, fmt.Errorf("download state %s does not match %s", path, url)
+	}
+	return &s, nil
+}
+
+func newSegmentState(url string, size int64, count int) *segmentState {
+	if limit := int(size / minSegmentSize); count > limit {
+		count = limit
+	}
+	count = max(count, 1)
+	s := &segmentState{URL: url, Size: size}
+	chunk := size / int64(count)
+	for i := range count {
+		seg := &segment{Start: int64(i) * chunk, End: int64(i+1)*chunk - 1}
+		if i == count-1 {
+			seg.End = size - 1
+		}
+		s.Segments = append(s.Segments, seg)
+	}
+	return s
+}
+
+// segmentWriter writes a segment's body at its offset in the destination file
+type segmentWriter struct {
+	f   *os.File
+	seg *segment
+	bar *mpb.Bar
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	off := w.seg.Start + atomic.LoadInt64(&w.seg.Written)
+	n, err := w.f.WriteAt(p, off)
+	atomic.AddInt64(&w.seg.Written, int64(n))
+	if w.bar != nil {
+		w.bar.IncrBy(n)
+	}
+	return n, err
+}
+
+func (d *Download) fetchSegment(f *os.File, seg *segment, bar *mpb.Bar) error {
+	if seg.remaining() <= 0 {
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", d.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create http GET request: %v", err)
+	}
+	req.Header.Add("User-Agent", utils.RandomAgent())
+	for k, v := range d.Headers {
+		req.Header.Add(k, v)
+	}
+	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", seg.Start+atomic.LoadInt64(&seg.Written), seg.End))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("server did not honor range request: %s", resp.Status)
+	}
+
+	remaining := seg.remaining()
+	n, err := io.Copy(&segmentWriter{f: f, seg: seg, bar: bar}, io.LimitReader(d.reportProgress(d.throttle(resp.Body)), remaining))
+	if err != nil {
+		return err
+	}
+	if n < remaining {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// doSegmented downloads the file as parallel HTTP range requests, persisting the progress
+// of each segment to a state file so an interrupted download can be resumed
+func (d *Download) doSegmented() error {
+	partial := d.DestName + ".download"
+	statePath := partial + ".state"
+
+	state, err := loadSegmentState(statePath, d.URL, d.size)
+	if err == nil {
+		if _, err := os.Stat(partial); err != nil {
+			state = nil
+		}
+	}
+	if state != nil {
+		if d.skipAll {
+			log.Infof("%s - SKIPPED", partial)
+			return nil
+		}
+		if d.restartAll {
+			log.Infof("Downloading %s - RESTARTED", partial)
+			state = nil
+		} else {
+			utils.Indent(log.WithField("file", d.DestName).Warn, 2)("Resuming a previous segmented download")
+		}
+	}
+	if state == nil {
+		state = newSegmentState(d.URL, d.size, d.Segments)
+	}
+
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %v", partial, err)
+	}
+	if err := f.Truncate(d.size); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to allocate %s: %v", partial, err)
+	}
+
+	utils.Indent(log.WithFields(log.Fields{
+		"segments": len(state.Segments),
+		"size":     d.size,
+	}).Debug, 2)("Segmented Download")
+
+	p := mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(180*time.Millisecond),
+	)
+	bar := p.New(d.size,
+		mpb.BarStyle().Lbound("[").Filler("=").Tip(">").Padding("-").Rbound("|"),
+		mpb.PrependDecorators(
+			decor.CountersKibiByte("\t% .2f / % .2f"),
+		),
+		mpb.AppendDecorators(
+			decor.OnComplete(decor.AverageETA(decor.ET_STYLE_GO), "✅ "),
+			decor.Name(" ] "),
+			decor.AverageSpeed(decor.SizeB1024(0), "% .2f", decor.WCSyncWidth),
+		),
+	)
+	if resumed := state.written(); resumed > 0 {
+		bar.IncrInt64(resumed)
+		bar.SetRefill(resumed)
+	}
+	d.written = state.written()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(stateSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := state.save(statePath); err != nil {
+					log.Debugf("failed to save download state: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(state.Segments))
+	for i, seg := range state.Segments {
+		wg.Add(1)
+		go func(i int, seg *segment) {
+			defer wg.Done()
+			for attempt := 0; attempt < segmentRetries; {
+				if d.Window != nil {
+					d.Window.wait()
+				}
+				if errs[i] = d.fetchSegment(f, seg, bar); errs[i] == nil {
+					return
+				}
+				if d.Context != nil && d.Context.Err() != nil {
+					return // canceled
+				}
+				if errors.Is(errs[i], errWindowClosed) {
+					continue // resumed from where it stopped once the window reopens
+				}
+				utils.Indent(log.Debug, 3)(fmt.Sprintf("segment %d failed (attempt %d/%d): %v", i, attempt+1, segmentRetries, errs[i]))
+				attempt++
+				time.Sleep(time.Duration(attempt) * time.Second)
+			}
+		}(i, seg)
+	}
+	wg.Wait()
+	close(done)
+
+	if err := errors.Join(errs...); err != nil {
+		bar.Abort(false)
+		p.Wait()
+		f.Close()
+		if serr := state.save(statePath); serr != nil {
+			log.Errorf("failed to save download state: %v", serr)
+		}
+		return fmt.Errorf("segmented download failed (re-run to resume): %v", err)
+	}
+
+	p.Wait()
+	f.Sync()
+	f.Close()
+
+	sum1, sum256, err := hashFile(partial)
+	if err != nil {
+		return err
+	}
+	if err := d.verify(partial, sum1, sum256); err != nil {
+		os.Remove(statePath)
+		return err
+	}
+
+	os.Remove(statePath)
+
+	if err := os.Rename(partial, d.DestName); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %v", partial, d.DestName, err)
+	}
+
+	d.record()
+
+	return nil
+}
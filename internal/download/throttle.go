@@ -0,0 +1,207 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+)
+
+// throttleChunk is the largest read a throttled reader will do at once (keeps the rate smooth)
+const throttleChunk = 32 * 1024
+
+// errWindowClosed stops a download when its window closes (it is resumed with a range request once the window reopens)
+var errWindowClosed = errors.New("download window closed")
+
+var (
+	defaultMaxRate int64
+	defaultWindow  *Window
+)
+
+// SetThrottle sets the bandwidth limit (in bytes/sec) and download window used by all new downloaders
+func SetThrottle(maxRate int64, window *Window) {
+	defaultMaxRate = maxRate
+	defaultWindow = window
+}
+
+// Window is a daily time window (in local time) that downloads are allowed to run in
+type Window struct {
+	Start time.Duration // offset from midnight
+	End   time.Duration // offset from midnight (if before Start the window wraps past midnight)
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s' (expected HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWindow parses a download window in the form HH:MM-HH:MM (e.g. 22:00-06:00)
+func ParseWindow(s string) (*Window, error) {
+	start, end, found := strings.Cut(s, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid window '%s' (expected HH:MM-HH:MM)", s)
+	}
+	var w Window
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	if w.Start == w.End {
+		return nil, fmt.Errorf("invalid window '%s' (start and end are the same)", s)
+	}
+	return &w, nil
+}
+
+func (w *Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	y, m, d := t.Date()
+	return t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+}
+
+// Contains returns true if the time is inside the window
+func (w *Window) Contains(t time.Time) bool {
+	now := sinceMidnight(t)
+	if w.Start < w.End {
+		return now >= w.Start && now < w.End
+	}
+	return now >= w.Start || now < w.End
+}
+
+// Next returns the next time the window opens (or t if it is already open)
+func (w *Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	y, m, d := t.Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(w.Start)
+	if next.Before(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// wait blocks until the window is open
+func (w *Window) wait() {
+	now := time.Now()
+	if w.Contains(now) {
+		return
+	}
+	next := w.Next(now)
+	utils.Indent(log.WithField("window", w.String()).Warn, 2)(fmt.Sprintf("Outside of download window, pausing until %s", next.Format(time.DateTime)))
+	time.Sleep(time.Until(next))
+}
+
+// rateLimiter is a token bucket shared by all the connections of a download
+type rateLimiter struct {
+	rate   int64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func newRateLimiter(rate int64) *rateLimiter {
+	return &rateLimiter{rate: rate, tokens: float64(rate), last: time.Now()}
+}
+
+// wait blocks until n bytes can be consumed without exceeding the rate
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*float64(l.rate), float64(l.rate))
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// throttledReader limits the rate of the reads of a response body and stops them when the download window closes
+type throttledReader struct {
+	rc      io.ReadCloser
+	limiter *rateLimiter
+	window  *Window
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if r.window != nil && !r.window.Contains(time.Now()) {
+		return 0, errWindowClosed
+	}
+	if r.limiter != nil && len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := r.rc.Read(p)
+	if r.limiter != nil && n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	return r.rc.Close()
+}
+
+// throttle wraps the response body with the downloader's bandwidth limit and download window
+// (a download the server can't resume isn't stopped when the window closes)
+func (d *Download) throttle(body io.ReadCloser) io.ReadCloser {
+	window := d.Window
+	if !d.canResume {
+		window = nil
+	}
+	if d.MaxRate <= 0 && window == nil {
+		return body
+	}
+	return &throttledReader{rc: body, limiter: d.limiter, window: window}
+}
+
+// progressReader reports the bytes read from a response body to the downloader's Progress callback
+// (and stops the reads when the downloader's Context is canceled)
+type progressReader struct {
+	rc io.ReadCloser
+	d  *Download
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if ctx := r.d.Context; ctx != nil && ctx.Err() != nil {
+		return 0, context.Cause(ctx)
+	}
+	n, err := r.rc.Read(p)
+	if n > 0 && r.d.Progress != nil {
+		r.d.Progress(atomic.AddInt64(&r.d.written, int64(n)), r.d.size)
+	}
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	return r.rc.Close()
+}
+
+// reportProgress wraps the response body so its reads are reported to the downloader's Progress callback
+// and stopped by its Context (if set)
+func (d *Download) reportProgress(body io.ReadCloser) io.ReadCloser {
+	if d.Progress == nil && d.Context == nil {
+		return body
+	}
+	return &progressReader{rc: body, d: d}
+}
@@ -0,0 +1,296 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/utils"
+	semver "github.com/hashicorp/go-version"
+)
+
+// WatchConfig is the configuration of a download watcher
+type WatchConfig struct {
+	Devices   []string
+	Beta      bool
+	IPSWs     bool // also check ipsw.me for new IPSWs
+	StateFile string
+	Proxy     string
+	Insecure  bool
+}
+
+// WatchBuild is a newly detected build
+type WatchBuild struct {
+	Source  string    `json:"source"` // ota or ipsw
+	Device  string    `json:"device"`
+	OS      string    `json:"os,omitempty"`
+	Version string    `json:"version"`
+	Build   string    `json:"build"`
+	Beta    bool      `json:"beta,omitempty"`
+	URL     string    `json:"url"`
+	Sha1    string    `json:"sha1,omitempty"`
+	Found   time.Time `json:"found"`
+}
+
+func (b WatchBuild) key() string {
+	return b.Source + ":" + b.Build
+}
+
+func (b WatchBuild) String() string {
+	return fmt.Sprintf("New %s %s %s (%s) for %s: %s", strings.ToUpper(b.Source), b.OS, b.Version, b.Build, b.Device, b.URL)
+}
+
+// watchState is the set of builds already seen (per device) persisted between checks
+type watchState struct {
+	Seen map[string][]string `json:"seen"`
+}
+
+// Watcher polls Apple's pallas server (and ipsw.me) for new builds
+type Watcher struct {
+	conf  WatchConfig
+	state watchState
+}
+
+// NewWatcher creates a new download watcher
+func NewWatcher(conf WatchConfig) (*Watcher, error) {
+	w := &Watcher{
+		conf:  conf,
+		state: watchState{Seen: make(map[string][]string)},
+	}
+	if len(conf.StateFile) > 0 {
+		dat, err := os.ReadFile(conf.StateFile)
+		if err == nil {
+			if err := json.Unmarshal(dat, &w.state); err != nil {
+				return nil, fmt.Errorf("failed to parse watch state file %s: %v", conf.StateFile, err)
+			}
+			if w.state.Seen == nil {
+				w.state.Seen = make(map[string][]string)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read watch state file %s: %v", conf.StateFile, err)
+		}
+	}
+	return w, nil
+}
+
+func (w *Watcher) save() error {
+	if len(w.conf.StateFile) == 0 {
+		return nil
+	}
+	dat, err := json.MarshalIndent(&w.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.conf.StateFile), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(w.conf.StateFile, dat, 0o644)
+}
+
+func (w *Watcher) seen(device, key string) bool {
+	return slices.Contains(w.state.Seen[device], key)
+}
+
+func (w *Watcher) markSeen(b WatchBuild) {
+	if !w.seen(b.Device, b.key()) {
+		w.state.Seen[b.Device] = append(w.state.Seen[b.Device], b.key())
+	}
+}
+
+// Seen records that a build returned by Check has been handled so it isn't returned again
+func (w *Watcher) Seen(b WatchBuild) error {
+	w.markSeen(b)
+	if err := w.save(); err != nil {
+		return fmt.Errorf("failed to save watch state: %v", err)
+	}
+	return nil
+}
+
+// platformForDevice returns the OTA platform of a device's product type
+func platformForDevice(device string) (string, error) {
+	switch {
+	case strings.HasPrefix(device, "iPhone"), strings.HasPrefix(device, "iPad"), strings.HasPrefix(device, "iPod"):
+		return "ios", nil
+	case strings.HasPrefix(device, "Watch"):
+		return "watchos", nil
+	case strings.HasPrefix(device, "AppleTV"):
+		return "tvos", nil
+	case strings.HasPrefix(device, "AudioAccessory"):
+		return "audioos", nil
+	case strings.HasPrefix(device, "RealityDevice"):
+		return "visionos", nil
+	case strings.HasPrefix(device, "Mac"), strings.HasPrefix(device, "iMac"):
+		return "macos", nil
+	default:
+		return "", fmt.Errorf("unsupported device '%s'", device)
+	}
+}
+
+// Check queries for the latest builds of each device and returns the ones that have not been seen before
+// (the first check of a device only records its current builds as the baseline); the builds are returned
+// until they are marked as Seen
+func (w *Watcher) Check() ([]WatchBuild, error) {
+	var builds []WatchBuild
+
+	as, err := GetAssetSets(w.conf.Proxy, w.conf.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset sets: %v", err)
+	}
+
+	zero, _ := semver.NewVersion("0")
+
+	for _, device := range w.conf.Devices {
+		_, seeded := w.state.Seen[device]
+		if !seeded {
+			w.state.Seen[device] = []string{}
+		}
+		var found []WatchBuild
+		platform, err := platformForDevice(device)
+		if err != nil {
+			return nil, err
+		}
+		o, err := NewOTA(as, OtaConf{
+			Platform: platform,
+			Beta:     w.conf.Beta,
+			Latest:   true,
+			Device:   device,
+			Version:  zero,
+			Build:    "0",
+			Proxy:    w.conf.Proxy,
+			Insecure: w.conf.Insecure,
+			Timeout:  90,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTA query for %s: %v", device, err)
+		}
+		otas, err := o.GetPallasOTAs()
+		if err != nil {
+			utils.Indent(log.WithError(err).WithField("device", device).Error, 2)("Failed to query pallas")
+			if !seeded { // don't record an empty baseline
+				delete(w.state.Seen, device)
+				continue
+			}
+		}
+		for _, ota := range otas {
+			if len(ota.PrerequisiteBuild) > 0 { // only care about full OTAs
+				continue
+			}
+			if w.seen(device, "ota:"+ota.Build) || slices.ContainsFunc(found, func(b WatchBuild) bool { return b.key() == "ota:"+ota.Build }) {
+				continue
+			}
+			found = append(found, WatchBuild{
+				Source:  "ota",
+				Device:  device,
+				OS:      ota.ProductSystemName,
+				Version: strings.TrimPrefix(ota.OSVersion, "9.9."),
+				Build:   ota.Build,
+				Beta:    w.conf.Beta,
+				URL:     ota.BaseURL + ota.RelativePath,
+				Found:   time.Now(),
+			})
+		}
+		if w.conf.IPSWs {
+			ipsws, err := GetDeviceIPSWs(device)
+			if err != nil {
+				utils.Indent(log.WithError(err).WithField("device", device).Error, 2)("Failed to query ipsw.me")
+			}
+			for _, ipsw := range ipsws {
+				if w.seen(device, "ipsw:"+ipsw.BuildID) || slices.ContainsFunc(found, func(b WatchBuild) bool { return b.key() == "ipsw:"+ipsw.BuildID }) {
+					continue
+				}
+				found = append(found, WatchBuild{
+					Source:  "ipsw",
+					Device:  device,
+					Version: ipsw.Version,
+					Build:   ipsw.BuildID,
+					URL:     ipsw.URL,
+					Sha1:    ipsw.SHA1,
+					Found:   time.Now(),
+				})
+			}
+		}
+		if !seeded {
+			for _, b := range found {
+				w.markSeen(b)
+			}
+			utils.Indent(log.WithField("device", device).Info, 2)(fmt.Sprintf("Recorded %d existing builds as the baseline", len(found)))
+			continue
+		}
+		builds = append(builds, found...)
+	}
+
+	if err := w.save(); err != nil {
+		return nil, fmt.Errorf("failed to save watch state: %v", err)
+	}
+
+	return builds, nil
+}
+
+// RunHook runs a shell command with the build details in the IPSW_WATCH_* environment variables
+func (b WatchBuild) RunHook(command string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"IPSW_WATCH_SOURCE="+b.Source,
+		"IPSW_WATCH_DEVICE="+b.Device,
+		"IPSW_WATCH_OS="+b.OS,
+		"IPSW_WATCH_VERSION="+b.Version,
+		"IPSW_WATCH_BUILD="+b.Build,
+		"IPSW_WATCH_URL="+b.URL,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run hook '%s': %v", command, err)
+	}
+	return nil
+}
+
+// Notify posts the build details to a Slack or Discord webhook
+func (b WatchBuild) Notify(webhook, proxy string, insecure bool) error {
+	var payload map[string]string
+	if strings.Contains(webhook, "discord.com/") || strings.Contains(webhook, "discordapp.com/") {
+		payload = map[string]string{"content": b.String()}
+	} else {
+		payload = map[string]string{"text": b.String()}
+	}
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", webhook, bytes.NewReader(dat))
+	if err != nil {
+		return fmt.Errorf("cannot create http POST request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           GetProxy(proxy),
+			TLSClientConfig: TLSConfig(insecure),
+		},
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status: %s: %s", resp.Status, string(body))
+	}
+
+	return nil
+}
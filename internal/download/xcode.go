@@ -16,6 +16,7 @@ import (
 
 	"github.com/blacktop/go-plist"
 	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/hashicorp/go-version"
 )
 
 const (
@@ -280,3 +281,104 @@ func QueryXcodeReleasesAPI(name string) (string, error) {
 
 	return "", fmt.Errorf("could not find xcode release: %s", name)
 }
+
+// SimPlatforms maps the simulator runtime platform names to their DVT platform identifiers
+var SimPlatforms = map[string]string{
+	"ios":      "com.apple.platform.iphoneos",
+	"tvos":     "com.apple.platform.appletvos",
+	"watchos":  "com.apple.platform.watchos",
+	"visionos": "com.apple.platform.xros",
+	"xros":     "com.apple.platform.xros",
+}
+
+// URL returns the download URL of the downloadable (runtimes without a source are on the developer portal)
+func (d Downloadable) URL() string {
+	if len(d.Source) > 0 {
+		return d.Source
+	}
+	name := strings.ReplaceAll(d.Name, " ", "_")
+	return fmt.Sprintf("https://download.developer.apple.com/Developer_Tools/%s/%s.dmg", name, name)
+}
+
+// NeedsAuth returns true if the downloadable must be downloaded from the developer portal
+func (d Downloadable) NeedsAuth() bool {
+	return len(d.Source) == 0 || len(d.Authentication) > 0
+}
+
+// newer returns true if the downloadable is a newer simulator runtime than o (by version, then build)
+func (d Downloadable) newer(o Downloadable) bool {
+	v1, err1 := version.NewVersion(d.SimulatorVersion.Version)
+	v2, err2 := version.NewVersion(o.SimulatorVersion.Version)
+	switch {
+	case err1 == nil && err2 == nil && !v1.Equal(v2):
+		return v1.GreaterThan(v2)
+	case err1 == nil && err2 != nil:
+		return true
+	case err1 != nil && err2 == nil:
+		return false
+	}
+	return compareBuilds(d.SimulatorVersion.BuildUpdate, o.SimulatorVersion.BuildUpdate) > 0
+}
+
+// compareBuilds compares two build numbers (i.e. 21A328 < 21A342 < 21B5045a)
+func compareBuilds(a, b string) int {
+	split := func(build string) (int, string, int, string) {
+		var major, minor int
+		var train, suffix string
+		i := 0
+		for ; i < len(build) && build[i] >= '0' && build[i] <= '9'; i++ {
+			major = major*10 + int(build[i]-'0')
+		}
+		for ; i < len(build) && (build[i] < '0' || build[i] > '9'); i++ {
+			train += string(build[i])
+		}
+		for ; i < len(build) && build[i] >= '0' && build[i] <= '9'; i++ {
+			minor = minor*10 + int(build[i]-'0')
+		}
+		suffix = build[i:]
+		return major, train, minor, suffix
+	}
+	amaj, atrain, amin, asuf := split(a)
+	bmaj, btrain, bmin, bsuf := split(b)
+	switch {
+	case amaj != bmaj:
+		return amaj - bmaj
+	case atrain != btrain:
+		return strings.Compare(atrain, btrain)
+	case amin != bmin:
+		return amin - bmin
+	}
+	return strings.Compare(asuf, bsuf)
+}
+
+// Filter returns the downloadables for a simulator platform (see SimPlatforms or 'all'), version prefix and name
+// (newest first)
+func (dvt *DVTDownloadable) Filter(platform, version, name string) ([]Downloadable, error) {
+	var platformID string
+	if len(platform) > 0 && !strings.EqualFold(platform, "all") {
+		var ok bool
+		if platformID, ok = SimPlatforms[strings.ToLower(platform)]; !ok {
+			return nil, fmt.Errorf("unknown simulator platform '%s' (must be one of: ios, tvos, watchos, visionos or all)", platform)
+		}
+	}
+	var out []Downloadable
+	for _, dl := range dvt.Downloadables {
+		if len(platformID) > 0 && dl.Platform != platformID {
+			continue
+		}
+		if len(version) > 0 && dl.SimulatorVersion.Version != version && !strings.HasPrefix(dl.SimulatorVersion.Version, version+".") {
+			continue
+		}
+		if len(name) > 0 && !strings.Contains(strings.ToLower(dl.Name), strings.ToLower(name)) {
+			continue
+		}
+		out = append(out, dl)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no downloadables found for the given filters")
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].newer(out[j])
+	})
+	return out, nil
+}
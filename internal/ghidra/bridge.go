@@ -0,0 +1,324 @@
+// Package ghidra implements the HTTP/JSON bridge queried by the companion Ghidra script (ghidra_bridge.py)
+package ghidra
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/internal/swift"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// Config is the configuration of the bridge
+type Config struct {
+	// Output is the folder the dylibs requested by Ghidra are extracted (and cached) in
+	Output string
+}
+
+// Bridge serves the symbols, ObjC class layouts and dylibs of dyld_shared_caches to Ghidra
+type Bridge struct {
+	conf  *Config
+	files []*dyld.File
+
+	mu sync.Mutex // the caches' parsers aren't safe for concurrent use
+}
+
+// Image is a dyld_shared_cache image
+type Image struct {
+	Name  string `json:"name"`
+	UUID  string `json:"uuid"`
+	Addr  uint64 `json:"addr"`
+	Cache string `json:"cache"` // UUID of the dyld_shared_cache
+}
+
+// Ivar is an ObjC instance variable
+type Ivar struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"` // type encoding
+	Decl   string `json:"decl"` // C declaration
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+// Method is an ObjC method
+type Method struct {
+	Name       string `json:"name"`
+	Types      string `json:"types"`
+	ReturnType string `json:"return_type"`
+	Addr       uint64 `json:"addr,omitempty"`
+}
+
+// Property is an ObjC property
+type Property struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Attributes string `json:"attributes"`
+}
+
+// Class is the layout of an ObjC class
+type Class struct {
+	Name            string     `json:"name"`
+	SuperClass      string     `json:"superclass,omitempty"`
+	Image           string     `json:"image"`
+	Addr            uint64     `json:"addr"`
+	InstanceStart   uint32     `json:"instance_start"`
+	InstanceSize    uint64     `json:"instance_size"`
+	Ivars           []Ivar     `json:"ivars,omitempty"`
+	Properties      []Property `json:"properties,omitempty"`
+	ClassMethods    []Method   `json:"class_methods,omitempty"`
+	InstanceMethods []Method   `json:"instance_methods,omitempty"`
+	Protocols       []string   `json:"protocols,omitempty"`
+}
+
+// NewBridge returns the bridge to the dyld_shared_caches
+func NewBridge(files []*dyld.File, conf *Config) *Bridge {
+	return &Bridge{conf: conf, files: files}
+}
+
+// Handler returns the bridge's HTTP handler
+//
+//	GET /images                                  the caches' images
+//	GET /symbol?addr=0x1800a0000[&uuid=UUID]     the symbol at a cache address (or offset from the base of the image with UUID)
+//	GET /symbols?pattern=REGEX[&image=NAME]      the symbols matching a regex
+//	GET /objc/class?name=NAME&image=NAME         the layout of an ObjC class
+//	GET /extract?image=NAME|UUID[&slide=false]   the image extracted as a standalone dylib
+func (b *Bridge) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /images", b.images)
+	mux.HandleFunc("GET /symbol", b.symbol)
+	mux.HandleFunc("GET /symbols", b.symbols)
+	mux.HandleFunc("GET /objc/class", b.objcClass)
+	mux.HandleFunc("GET /extract", b.extract)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.WithField("query", r.URL.RawQuery).Debugf("%s %s", r.Method, r.URL.Path)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("failed to write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// image returns the image (and its cache) whose name (or path suffix) or UUID matches
+func (b *Bridge) image(name string) (*dyld.File, *dyld.CacheImage, error) {
+	if len(name) == 0 {
+		return nil, nil, fmt.Errorf("missing required 'image' query parameter")
+	}
+	for _, f := range b.files {
+		for _, img := range f.Images {
+			if strings.EqualFold(img.UUID.String(), name) {
+				return f, img, nil
+			}
+		}
+		if img, err := f.Image(name); err == nil {
+			return f, img, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("image '%s' not found", name)
+}
+
+func (b *Bridge) images(w http.ResponseWriter, r *http.Request) {
+	var images []Image
+	for _, f := range b.files {
+		for _, img := range f.Images {
+			images = append(images, Image{
+				Name:  img.Name,
+				UUID:  img.UUID.String(),
+				Addr:  img.LoadAddress,
+				Cache: f.UUID.String(),
+			})
+		}
+	}
+	writeJSON(w, images)
+}
+
+func (b *Bridge) symbol(w http.ResponseWriter, r *http.Request) {
+	addr, err := strconv.ParseUint(r.URL.Query().Get("addr"), 0, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid 'addr' query parameter: %v", err)
+		return
+	}
+	files := b.files
+	if uuid := r.URL.Query().Get("uuid"); len(uuid) > 0 {
+		files = nil
+		for _, f := range b.files {
+			if strings.EqualFold(f.UUID.String(), uuid) {
+				files = append(files, f)
+				break
+			}
+		}
+		if files == nil { // the UUID of an image (so addresses below its base are offsets from it)
+			f, img, err := b.image(uuid)
+			if err != nil {
+				writeError(w, http.StatusNotFound, "no cache or image with UUID %s", uuid)
+				return
+			}
+			if addr < img.LoadAddress {
+				addr += img.LoadAddress
+			}
+			files = []*dyld.File{f}
+		}
+	}
+	for _, f := range files {
+		sym, err := dsc.LookupSymbol(f, addr)
+		if err != nil {
+			continue
+		}
+		sym.Demanged = swift.DemangleBlob(demangle.Do(sym.Symbol, false, false))
+		writeJSON(w, sym)
+		return
+	}
+	writeError(w, http.StatusNotFound, "no symbol found at %#x", addr)
+}
+
+func (b *Bridge) symbols(w http.ResponseWriter, r *http.Request) {
+	pattern := r.URL.Query().Get("pattern")
+	if len(pattern) == 0 {
+		writeError(w, http.StatusBadRequest, "missing required 'pattern' query parameter")
+		return
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid 'pattern' query parameter: %v", err)
+		return
+	}
+	var found []dsc.Symbol
+	for _, f := range b.files {
+		syms, err := dsc.GetSymbols(f, []dsc.Symbol{{Pattern: pattern, Image: r.URL.Query().Get("image")}})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to search symbols: %v", err)
+			return
+		}
+		found = append(found, syms...)
+	}
+	if found == nil {
+		found = []dsc.Symbol{}
+	}
+	writeJSON(w, found)
+}
+
+func (b *Bridge) objcClass(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if len(name) == 0 {
+		writeError(w, http.StatusBadRequest, "missing required 'name' query parameter")
+		return
+	}
+	_, img, err := b.image(r.URL.Query().Get("image"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "%v", err)
+		return
+	}
+	m, err := img.GetMacho()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to parse %s: %v", img.Name, err)
+		return
+	}
+	defer m.Close()
+	classes, err := m.GetObjCClasses()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to parse %s ObjC classes: %v", img.Name, err)
+		return
+	}
+	for _, c := range classes {
+		if c.Name == name {
+			writeJSON(w, newClass(img.Name, &c))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "class '%s' not found in %s", name, img.Name)
+}
+
+func newClass(image string, c *objc.Class) *Class {
+	class := &Class{
+		Name:          c.Name,
+		SuperClass:    c.SuperClass,
+		Image:         image,
+		Addr:          c.ClassPtr,
+		InstanceStart: c.ReadOnlyData.InstanceStart,
+		InstanceSize:  c.ReadOnlyData.InstanceSize,
+	}
+	for _, iv := range c.Ivars {
+		class.Ivars = append(class.Ivars, Ivar{
+			Name:   iv.Name,
+			Type:   iv.Type,
+			Decl:   iv.Verbose(),
+			Offset: iv.Offset,
+			Size:   iv.Size,
+		})
+	}
+	for _, p := range c.Props {
+		attrs, _ := p.Attributes()
+		class.Properties = append(class.Properties, Property{
+			Name:       p.Name,
+			Type:       strings.TrimSpace(p.Type()),
+			Attributes: attrs,
+		})
+	}
+	methods := func(meths []objc.Method) []Method {
+		var out []Method
+		for _, m := range meths {
+			out = append(out, Method{
+				Name:       m.Name,
+				Types:      m.Types,
+				ReturnType: m.ReturnType(),
+				Addr:       m.ImpVMAddr,
+			})
+		}
+		return out
+	}
+	class.ClassMethods = methods(c.ClassMethods)
+	class.InstanceMethods = methods(c.InstanceMethods)
+	for _, p := range c.Protocols {
+		class.Protocols = append(class.Protocols, p.Name)
+	}
+	return class
+}
+
+func (b *Bridge) extract(w http.ResponseWriter, r *http.Request) {
+	f, img, err := b.image(r.URL.Query().Get("image"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "%v", err)
+		return
+	}
+	slide := r.URL.Query().Get("slide") != "false"
+	fname := filepath.Join(b.conf.Output, f.UUID.String(), img.Name)
+	if !slide {
+		fname += ".noslide"
+	}
+	if _, err := os.Stat(fname); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to create output folder: %v", err)
+			return
+		}
+		log.WithField("image", img.Name).Info("Extracting dylib")
+		if err := dsc.ExtractDylib(f, img, fname, &dsc.ExtractConfig{ObjC: true, Slide: slide}); err != nil {
+			os.Remove(fname)
+			writeError(w, http.StatusInternalServerError, "failed to extract %s: %v", img.Name, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(img.Name)))
+	http.ServeFile(w, r, fname)
+}
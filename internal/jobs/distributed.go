@@ -0,0 +1,429 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/model"
+)
+
+// Role is a daemon's role in a distributed deployment
+type Role string
+
+const (
+	// RoleStandalone runs all of the daemon's jobs itself
+	RoleStandalone Role = ""
+	// RoleCoordinator assigns the queued jobs to the workers (and requeues the jobs of the workers that stop responding)
+	RoleCoordinator Role = "coordinator"
+	// RoleWorker runs the jobs the coordinator assigns to it
+	RoleWorker Role = "worker"
+)
+
+const (
+	defaultCapacity = 2
+	// pollInterval is how often the distributed mode checks the shared jobs
+	pollInterval = 2 * time.Second
+	// workerTimeout is how long a worker can go without a heartbeat before its jobs are requeued
+	workerTimeout = 30 * time.Second
+)
+
+// ParseRole parses a distributed mode role
+func ParseRole(name string) (Role, error) {
+	switch r := Role(strings.ToLower(name)); r {
+	case RoleStandalone, RoleCoordinator, RoleWorker:
+		return r, nil
+	default:
+		return RoleStandalone, fmt.Errorf("invalid role '%s' (must be one of: coordinator, worker)", name)
+	}
+}
+
+// ClusterConfig is the distributed mode config
+//
+// The daemons share the jobs through the database (so it must be one they can all reach, i.e. Postgres).
+// Any of them can submit jobs, the coordinator assigns them to the least loaded workers that run their kind
+// and the workers report the jobs' state back through the database.
+type ClusterConfig struct {
+	Role Role
+	// WorkerID identifies the worker (it must be unique and defaults to the hostname)
+	WorkerID string
+	// Capacity is the number of jobs assigned to a worker at a time (defaults to 2)
+	Capacity int
+	// Kinds are the kinds of jobs the worker runs (defaults to all of the registered kinds)
+	Kinds []string
+}
+
+// Worker is a snapshot of a worker
+type Worker struct {
+	ID       string    `json:"id"`
+	Kinds    []string  `json:"kinds,omitempty"`
+	Capacity int       `json:"capacity"`
+	Running  int       `json:"running"`
+	Alive    bool      `json:"alive"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// distributed returns true if the daemon shares its jobs with other daemons
+func (m *Manager) distributed() bool {
+	return m.conf.Cluster.Role != RoleStandalone && m.db != nil
+}
+
+// Workers returns the registered workers
+func (m *Manager) Workers() ([]Worker, error) {
+	if !m.distributed() {
+		return []Worker{}, nil
+	}
+	all, err := m.db.GetWorkers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workers: %w", err)
+	}
+	workers := make([]Worker, 0, len(all))
+	for _, w := range all {
+		var kinds []string
+		if len(w.Kinds) > 0 {
+			kinds = strings.Split(w.Kinds, ",")
+		}
+		workers = append(workers, Worker{
+			ID:       w.ID,
+			Kinds:    kinds,
+			Capacity: w.Capacity,
+			Running:  w.Running,
+			Alive:    time.Since(w.SeenAt) < workerTimeout,
+			SeenAt:   w.SeenAt,
+		})
+	}
+	return workers, nil
+}
+
+// mirror tracks a job run by another daemon (the caller must hold the lock)
+func (m *Manager) mirror(mj *model.Job) *Job {
+	j := fromModel(mj)
+	j.remote = true
+	j.cancel = func() {
+		if err := m.db.CancelJob(mj.ID); err != nil {
+			log.WithError(err).WithField("id", mj.ID).Error("failed to request job cancel")
+		}
+	}
+	if finished(j.status.State) {
+		close(j.done)
+	}
+	m.track(j)
+	return j
+}
+
+// listShared returns a snapshot of all the shared jobs (the caller must hold the lock)
+func (m *Manager) listShared() ([]Status, error) {
+	all, err := m.db.GetJobs("")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, 0, len(all))
+	for _, mj := range all {
+		if finished(mj.State) && time.Since(mj.UpdatedAt) > retention {
+			continue
+		}
+		if j, ok := m.jobs[mj.ID]; ok {
+			list = append(list, j.Status())
+		} else {
+			list = append(list, statusFromModel(mj))
+		}
+	}
+	return list, nil
+}
+
+// startCluster starts coordinating or working on the shared jobs until the manager is closed
+func (m *Manager) startCluster() {
+	if m.conf.Cluster.Role == RoleWorker && len(m.conf.Cluster.Kinds) == 0 {
+		m.conf.Cluster.Kinds = m.Kinds()
+	}
+	log.WithFields(log.Fields{
+		"role":   m.conf.Cluster.Role,
+		"worker": m.conf.Cluster.WorkerID,
+	}).Info("Sharing jobs")
+
+	registered := time.Now()
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			var err error
+			if m.conf.Cluster.Role == RoleCoordinator {
+				err = m.coordinate()
+			} else {
+				err = m.work(registered)
+			}
+			if err != nil {
+				log.WithError(err).WithField("role", m.conf.Cluster.Role).Error("failed to check the shared jobs")
+			}
+			m.syncMirrors()
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+}
+
+// work starts the jobs assigned to the worker, cancels the ones with a cancel request, stops the ones
+// that were reassigned to another worker and sends the worker's heartbeat
+func (m *Manager) work(registered time.Time) error {
+	id := m.conf.Cluster.WorkerID
+	assigned, err := m.db.GetAssignedJobs(id)
+	if err != nil {
+		return fmt.Errorf("failed to get assigned jobs: %w", err)
+	}
+	ids := make(map[string]bool, len(assigned))
+	var running int
+	for _, mj := range assigned {
+		ids[mj.ID] = true
+		m.mu.Lock()
+		j, ok := m.jobs[mj.ID]
+		h, known := m.handlers[mj.Kind]
+		m.mu.Unlock()
+		if ok && !j.remote {
+			running++
+			if mj.CancelRequested {
+				j.Cancel()
+			}
+			continue
+		}
+		if ok {
+			// run the job this daemon was mirroring
+			j.mu.Lock()
+			j.status = statusFromModel(mj)
+			j.remote = false
+			j.mu.Unlock()
+		} else {
+			j = fromModel(mj)
+		}
+		j.mu.Lock()
+		if mj.CancelRequested {
+			j.status.Error = "canceled before it started"
+			j.setState(Canceled)
+		} else {
+			m.recover(j, known)
+		}
+		err := m.save(j)
+		state, released := j.status.State, j.released
+		j.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to save job %s: %w", mj.ID, err)
+		}
+		if released {
+			continue
+		}
+		if finished(state) {
+			m.mu.Lock()
+			m.track(j)
+			m.mu.Unlock()
+			j.finish()
+			if m.conf.Finished != nil {
+				m.conf.Finished(j.Status())
+			}
+			continue
+		}
+		log.WithFields(log.Fields{"id": mj.ID, "kind": mj.Kind}).Info("Running assigned job")
+		running++
+		m.start(j, h)
+	}
+
+	// the coordinator requeues the jobs of workers it thinks stopped responding
+	m.mu.Lock()
+	for jid, j := range m.jobs {
+		if ids[jid] || j.remote {
+			continue
+		}
+		j.mu.Lock()
+		stale := !finished(j.status.State)
+		if stale && !j.released {
+			j.released = true
+			j.publish(Event{Type: LogEvent, Message: "Reassigned to another worker"})
+		}
+		j.mu.Unlock()
+		if stale {
+			j.cancel()
+			delete(m.jobs, jid)
+		}
+	}
+	m.mu.Unlock()
+
+	return m.db.SaveWorker(&model.Worker{
+		ID:        id,
+		Kinds:     strings.Join(m.conf.Cluster.Kinds, ","),
+		Capacity:  m.conf.Cluster.Capacity,
+		Running:   running,
+		SeenAt:    time.Now(),
+		CreatedAt: registered,
+	})
+}
+
+// coordinate requeues the jobs of the workers that stopped responding, finishes the queued jobs that were
+// canceled and assigns the other queued jobs to the least loaded workers that run their kind
+func (m *Manager) coordinate() error {
+	workers, err := m.db.GetWorkers()
+	if err != nil {
+		return fmt.Errorf("failed to get workers: %w", err)
+	}
+	alive := make(map[string]*model.Worker)
+	for _, w := range workers {
+		if time.Since(w.SeenAt) < workerTimeout {
+			alive[w.ID] = w
+			continue
+		}
+		log.WithField("worker", w.ID).Warn("Worker stopped responding")
+		if err := m.db.DeleteWorker(w.ID); err != nil {
+			return fmt.Errorf("failed to delete worker %s: %w", w.ID, err)
+		}
+	}
+
+	var queued []*model.Job
+	load := make(map[string]int)
+	for _, state := range []State{Pending, Running} {
+		jobs, err := m.db.GetJobs(state)
+		if err != nil {
+			return fmt.Errorf("failed to get %s jobs: %w", state, err)
+		}
+		for _, mj := range jobs {
+			switch {
+			case mj.Worker == "" && mj.State == Pending:
+				queued = append(queued, mj)
+			case alive[mj.Worker] != nil:
+				load[mj.Worker]++
+			default:
+				requeued, err := m.requeue(mj)
+				if err != nil {
+					return err
+				}
+				if requeued != nil {
+					queued = append(queued, requeued)
+				}
+			}
+		}
+	}
+	sort.SliceStable(queued, func(i, k int) bool {
+		return queued[i].CreatedAt.Before(queued[k].CreatedAt)
+	})
+
+	for _, mj := range queued {
+		if mj.CancelRequested {
+			j := fromModel(mj)
+			j.mu.Lock()
+			j.status.Error = "canceled before it was assigned to a worker"
+			j.setState(Canceled)
+			err := m.save(j)
+			j.mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("failed to save job %s: %w", mj.ID, err)
+			}
+			if m.conf.Finished != nil {
+				m.conf.Finished(j.Status())
+			}
+			continue
+		}
+		w := leastLoaded(alive, load, mj.Kind)
+		if w == nil {
+			continue
+		}
+		ok, err := m.db.AssignJob(mj.ID, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to assign job %s: %w", mj.ID, err)
+		}
+		if ok {
+			load[w.ID]++
+			log.WithFields(log.Fields{"id": mj.ID, "kind": mj.Kind, "worker": w.ID}).Info("Assigned job")
+		}
+	}
+	return nil
+}
+
+// requeue unassigns a job from its unresponsive worker, failing it if it was interrupted on its last attempt
+// (it returns the requeued job or nil if it failed)
+func (m *Manager) requeue(mj *model.Job) (*model.Job, error) {
+	j := fromModel(mj)
+	j.mu.Lock()
+	worker := j.status.Worker
+	j.status.Worker = ""
+	if j.status.State == Running && j.status.Attempts >= m.conf.Retries {
+		j.status.Error = fmt.Sprintf("worker '%s' stopped responding", worker)
+		j.setState(Failed)
+	} else {
+		j.status.State = Pending
+	}
+	err := m.save(j)
+	requeued := j.model()
+	j.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save job %s: %w", mj.ID, err)
+	}
+	log.WithFields(log.Fields{"id": mj.ID, "kind": mj.Kind, "worker": worker}).Warn("Requeued job of unresponsive worker")
+	if requeued.State == Failed {
+		if m.conf.Finished != nil {
+			m.conf.Finished(j.Status())
+		}
+		return nil, nil
+	}
+	requeued.CancelRequested = mj.CancelRequested
+	return requeued, nil
+}
+
+// leastLoaded returns the alive worker with the fewest assigned jobs that runs the kind of job and has capacity left
+func leastLoaded(alive map[string]*model.Worker, load map[string]int, kind string) *model.Worker {
+	var best *model.Worker
+	for _, w := range alive {
+		if load[w.ID] >= w.Capacity {
+			continue
+		}
+		if len(w.Kinds) > 0 && !slices.Contains(strings.Split(w.Kinds, ","), kind) {
+			continue
+		}
+		if best == nil || load[w.ID] < load[best.ID] || (load[w.ID] == load[best.ID] && w.ID < best.ID) {
+			best = w
+		}
+	}
+	return best
+}
+
+// syncMirrors updates the jobs run by other daemons from the database
+func (m *Manager) syncMirrors() {
+	var mirrors []*Job
+	m.mu.Lock()
+	for _, j := range m.jobs {
+		j.mu.Lock()
+		if j.remote && !finished(j.status.State) {
+			mirrors = append(mirrors, j)
+		}
+		j.mu.Unlock()
+	}
+	m.mu.Unlock()
+
+	for _, j := range mirrors {
+		mj, err := m.db.GetJob(j.ID())
+		if err != nil {
+			log.WithError(err).WithField("id", j.ID()).Debug("failed to sync job")
+			continue
+		}
+		j.mu.Lock()
+		if !j.remote {
+			// this daemon started running it in the meantime
+			j.mu.Unlock()
+			continue
+		}
+		changed := mj.State != j.status.State || mj.Attempts != j.status.Attempts
+		j.status = statusFromModel(mj)
+		if changed {
+			if mj.State == Done && len(mj.Result) > 0 {
+				j.publish(Event{Type: ResultEvent, Result: json.RawMessage(mj.Result)})
+			}
+			j.publish(Event{Type: StateEvent, State: mj.State, Message: mj.Error})
+		}
+		j.mu.Unlock()
+		if finished(mj.State) {
+			j.finish()
+		}
+	}
+}
@@ -0,0 +1,716 @@
+// Package jobs runs long operations in the background and records their progress as a stream of events
+//
+// Jobs are persisted to the database (when there is one) so they are retried with backoff when they fail
+// and resumed when the daemon restarts. Several daemons sharing a database can also split the jobs between
+// them, with a coordinator assigning the queued jobs to the workers (see ClusterConfig).
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxEvents is the number of log/state events a job keeps for late subscribers
+	maxEvents = 1000
+	// subscriberBuffer is the number of events buffered per subscriber (slow subscribers miss events)
+	subscriberBuffer = 256
+	// progressInterval is the minimum time between progress events
+	progressInterval = 250 * time.Millisecond
+	// retention is how long finished jobs are kept around
+	retention = time.Hour
+
+	defaultRetries = 3
+	defaultBackoff = 30 * time.Second
+)
+
+// ErrNotFound is returned for unknown job IDs
+var ErrNotFound = errors.New("job not found")
+
+// State is a job's state
+type State = model.JobState
+
+const (
+	Pending  = model.JobPending
+	Running  = model.JobRunning
+	Done     = model.JobDone
+	Failed   = model.JobFailed
+	Canceled = model.JobCanceled
+)
+
+func finished(s State) bool {
+	return s == Done || s == Failed || s == Canceled
+}
+
+// EventType is the type of a job event
+type EventType string
+
+const (
+	StateEvent    EventType = "state"
+	LogEvent      EventType = "log"
+	ProgressEvent EventType = "progress"
+	ResultEvent   EventType = "result"
+)
+
+// Event is a job progress/log event
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	State   State     `json:"state,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Current int64     `json:"current,omitempty"`
+	Total   int64     `json:"total,omitempty"`
+	Result  any       `json:"result,omitempty"`
+}
+
+// Status is a snapshot of a job
+type Status struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	State       State           `json:"state"`
+	Attempts    int             `json:"attempts"`
+	Worker      string          `json:"worker,omitempty"`
+	Owner       string          `json:"owner,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Progress    *Event          `json:"progress,omitempty"`
+	NextAttempt *time.Time      `json:"next_attempt,omitempty"`
+	Created     time.Time       `json:"created"`
+	Started     *time.Time      `json:"started,omitempty"`
+	Finished    *time.Time      `json:"finished,omitempty"`
+}
+
+// Handler does the work of a kind of job (it should return when ctx is canceled)
+type Handler func(ctx context.Context, j *Job, params json.RawMessage) (any, error)
+
+// Job is a background operation
+type Job struct {
+	mu       sync.Mutex
+	status   Status
+	params   json.RawMessage
+	events   []Event
+	subs     map[chan Event]struct{}
+	cancel   context.CancelFunc
+	canceled bool // by the user (rather than by the daemon shutting down)
+	done     chan struct{}
+	lastProg time.Time
+	remote   bool // a mirror of a job run by another daemon
+	released bool // reassigned to another worker while running
+}
+
+func newJob(status Status, params json.RawMessage) *Job {
+	return &Job{
+		status: status,
+		params: params,
+		subs:   make(map[chan Event]struct{}),
+		cancel: func() {},
+		done:   make(chan struct{}),
+	}
+}
+
+// ID returns the job's ID
+func (j *Job) ID() string {
+	return j.status.ID
+}
+
+// Owner returns the API key (or client IP) that submitted the job
+func (j *Job) Owner() string {
+	return j.status.Owner
+}
+
+// Status returns a snapshot of the job
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := j.status
+	if st.Progress != nil {
+		prog := *st.Progress
+		st.Progress = &prog
+	}
+	return st
+}
+
+// Done is closed when the job stops running
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Cancel cancels the job (it isn't retried)
+func (j *Job) Cancel() {
+	j.mu.Lock()
+	j.canceled = true
+	j.mu.Unlock()
+	j.cancel()
+}
+
+// publish records and broadcasts an event (the caller must hold the lock)
+func (j *Job) publish(ev Event) {
+	ev.Time = time.Now()
+	if ev.Type == ProgressEvent {
+		j.status.Progress = &ev
+	} else {
+		if len(j.events) == maxEvents {
+			j.events = j.events[1:]
+		}
+		j.events = append(j.events, ev)
+	}
+	for ch := range j.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Logf adds a log event
+func (j *Job) Logf(format string, args ...any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.publish(Event{Type: LogEvent, Message: fmt.Sprintf(format, args...)})
+}
+
+// Progress adds a progress event (rate limited, except for the final one)
+func (j *Job) Progress(current, total int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if (total == 0 || current < total) && time.Since(j.lastProg) < progressInterval {
+		return
+	}
+	j.lastProg = time.Now()
+	j.publish(Event{Type: ProgressEvent, Current: current, Total: total})
+}
+
+// Subscribe returns the job's past events (and current progress) and a channel of its new events,
+// which is closed when the job stops running (call unsubscribe when done with it)
+func (j *Job) Subscribe() (past []Event, events <-chan Event, unsubscribe func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	past = append(past, j.events...)
+	if j.status.Progress != nil {
+		past = append(past, *j.status.Progress)
+	}
+	ch := make(chan Event, subscriberBuffer)
+	select {
+	case <-j.done:
+		close(ch)
+		return past, ch, func() {}
+	default:
+	}
+	j.subs[ch] = struct{}{}
+	return past, ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subs[ch]; ok {
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// finish closes the job's subscriptions and marks it as stopped
+func (j *Job) finish() {
+	j.mu.Lock()
+	for ch := range j.subs {
+		delete(j.subs, ch)
+		close(ch)
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// setState changes the job's state (the caller must hold the lock)
+func (j *Job) setState(state State) {
+	now := time.Now()
+	j.status.State = state
+	switch {
+	case state == Running:
+		j.status.Started = &now
+		j.status.NextAttempt = nil
+	case finished(state):
+		j.status.Finished = &now
+		j.status.NextAttempt = nil
+	}
+	j.publish(Event{Type: StateEvent, State: state, Message: j.status.Error})
+}
+
+// model returns the job's database model (the caller must hold the lock)
+func (j *Job) model() *model.Job {
+	m := &model.Job{
+		ID:         j.status.ID,
+		Kind:       j.status.Kind,
+		Params:     j.params,
+		State:      j.status.State,
+		Attempts:   j.status.Attempts,
+		Worker:     j.status.Worker,
+		Owner:      j.status.Owner,
+		Error:      j.status.Error,
+		Result:     j.status.Result,
+		StartedAt:  j.status.Started,
+		FinishedAt: j.status.Finished,
+		CreatedAt:  j.status.Created,
+	}
+	if j.status.NextAttempt != nil {
+		m.NextAttempt = *j.status.NextAttempt
+	}
+	return m
+}
+
+func fromModel(m *model.Job) *Job {
+	return newJob(statusFromModel(m), m.Params)
+}
+
+func statusFromModel(m *model.Job) Status {
+	st := Status{
+		ID:       m.ID,
+		Kind:     m.Kind,
+		State:    m.State,
+		Attempts: m.Attempts,
+		Worker:   m.Worker,
+		Owner:    m.Owner,
+		Error:    m.Error,
+		Result:   m.Result,
+		Created:  m.CreatedAt,
+		Started:  m.StartedAt,
+		Finished: m.FinishedAt,
+	}
+	if !m.NextAttempt.IsZero() {
+		next := m.NextAttempt
+		st.NextAttempt = &next
+	}
+	return st
+}
+
+// Config is the job manager config
+type Config struct {
+	// Retries is the number of attempts per job before it is marked as failed
+	Retries int
+	// Backoff is the delay before the first retry (it doubles with each attempt)
+	Backoff time.Duration
+	// Finished is called after a job is done, fails or is canceled (by the daemon that ran it)
+	Finished func(st Status)
+	// Cluster is the distributed mode config (the daemon runs all of its jobs itself if the role is empty)
+	Cluster ClusterConfig
+}
+
+// Manager runs and tracks jobs
+type Manager struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	stop     context.CancelFunc
+	wg       sync.WaitGroup
+	db       db.Database
+	conf     Config
+	handlers map[string]Handler
+	jobs     map[string]*Job
+}
+
+// NewManager creates a job manager (jobs are only kept in memory if d is nil)
+func NewManager(d db.Database, conf Config) *Manager {
+	if conf.Retries < 1 {
+		conf.Retries = defaultRetries
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = defaultBackoff
+	}
+	if conf.Cluster.Capacity < 1 {
+		conf.Cluster.Capacity = defaultCapacity
+	}
+	if conf.Cluster.Role == RoleWorker && conf.Cluster.WorkerID == "" {
+		conf.Cluster.WorkerID, _ = os.Hostname()
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:      ctx,
+		stop:     stop,
+		db:       d,
+		conf:     conf,
+		handlers: make(map[string]Handler),
+		jobs:     make(map[string]*Job),
+	}
+}
+
+// Register registers the handler for a kind of job
+func (m *Manager) Register(kind string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[kind] = h
+}
+
+// Handler returns the handler of a kind of job
+func (m *Manager) Handler(kind string) (Handler, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.handlers[kind]
+	return h, ok
+}
+
+// Kinds returns the registered kinds of jobs (sorted)
+func (m *Manager) Kinds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kinds := make([]string, 0, len(m.handlers))
+	for kind := range m.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// save persists the job (the caller must hold the job's lock)
+//
+// A worker only saves the jobs that are still assigned to it: if the coordinator reassigned the job
+// (i.e. it thought the worker stopped responding) the job is released and stopped instead.
+func (m *Manager) save(j *Job) error {
+	if m.db == nil || j.released {
+		return nil
+	}
+	if m.conf.Cluster.Role != RoleWorker || j.status.Worker == "" {
+		return m.db.SaveJob(j.model())
+	}
+	ok, err := m.db.SaveAssignedJob(j.model())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		j.released = true
+		j.publish(Event{Type: LogEvent, Message: "Reassigned to another worker"})
+		j.cancel()
+	}
+	return nil
+}
+
+// Submit queues a job with the given (JSON encodable) params
+func (m *Manager) Submit(kind string, params any) (*Job, error) {
+	return m.SubmitAs("", kind, params)
+}
+
+// SubmitAs queues a job on behalf of the owner (the API key or client IP whose quotas it uses)
+func (m *Manager) SubmitAs(owner, kind string, params any) (*Job, error) {
+	m.mu.Lock()
+	h, ok := m.handlers[kind]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind '%s'", kind)
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job params: %w", err)
+	}
+	j := newJob(Status{
+		ID:      uuid.NewString(),
+		Kind:    kind,
+		State:   Pending,
+		Owner:   owner,
+		Created: time.Now(),
+	}, data)
+	if err := m.save(j); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+	if m.distributed() {
+		// leave the job queued for the coordinator to assign to a worker
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.mirror(j.model()), nil
+	}
+	m.start(j, h)
+	return j, nil
+}
+
+// Resume restarts the pending jobs (and the jobs that were running when the daemon stopped)
+// and loads the recently finished jobs from the database (call it after registering the handlers)
+//
+// In distributed mode it starts coordinating or working on the shared jobs instead.
+func (m *Manager) Resume() error {
+	if m.db == nil {
+		return nil
+	}
+	if m.distributed() {
+		m.startCluster()
+		return nil
+	}
+	all, err := m.db.GetJobs("")
+	if err != nil {
+		return fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, mj := range all {
+		j := fromModel(mj)
+		if finished(j.status.State) {
+			if time.Since(mj.UpdatedAt) < retention {
+				close(j.done)
+				m.mu.Lock()
+				m.jobs[j.ID()] = j
+				m.mu.Unlock()
+			}
+			continue
+		}
+		m.mu.Lock()
+		h, ok := m.handlers[j.status.Kind]
+		m.mu.Unlock()
+		j.mu.Lock()
+		m.recover(j, ok)
+		err := m.save(j)
+		j.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to save job %s: %w", j.ID(), err)
+		}
+		if finished(j.status.State) {
+			close(j.done)
+			m.mu.Lock()
+			m.jobs[j.ID()] = j
+			m.mu.Unlock()
+			continue
+		}
+		log.WithFields(log.Fields{"id": j.ID(), "kind": j.status.Kind}).Info("Resuming job")
+		m.start(j, h)
+	}
+	return nil
+}
+
+// recover readies an unfinished job to be restarted, failing it if its kind is unknown
+// or it was interrupted on its last attempt (the caller must hold the job's lock)
+func (m *Manager) recover(j *Job, known bool) {
+	switch {
+	case !known:
+		j.status.Error = fmt.Sprintf("unknown job kind '%s'", j.status.Kind)
+		j.setState(Failed)
+	case j.status.State == Running && j.status.Attempts >= m.conf.Retries:
+		j.status.Error = "interrupted by a daemon restart"
+		j.setState(Failed)
+	default:
+		if j.status.State == Running {
+			j.publish(Event{Type: LogEvent, Message: "Resuming after a daemon restart"})
+		}
+		j.status.State = Pending
+	}
+}
+
+func (m *Manager) start(j *Job, h Handler) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	j.cancel = cancel
+
+	m.mu.Lock()
+	m.track(j)
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(ctx, j, h)
+	}()
+}
+
+// run runs the job's attempts until it succeeds, runs out of attempts or is canceled
+func (m *Manager) run(ctx context.Context, j *Job, h Handler) {
+	defer j.finish()
+	defer j.cancel()
+
+	for {
+		// wait for the retry backoff
+		if next := j.Status().NextAttempt; next != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(*next)):
+			}
+		}
+		if ctx.Err() == nil {
+			j.mu.Lock()
+			j.status.Attempts++
+			j.status.Error = ""
+			j.setState(Running)
+			m.persist(j)
+			j.mu.Unlock()
+		}
+
+		var result any
+		err := ctx.Err()
+		if err == nil {
+			result, err = h(ctx, j, j.params)
+		}
+
+		j.mu.Lock()
+		if j.released {
+			// the job's new worker reports its state
+			j.mu.Unlock()
+			return
+		}
+		switch {
+		case err == nil:
+			if result != nil {
+				data, merr := json.Marshal(result)
+				if merr != nil {
+					log.WithError(merr).WithField("id", j.ID()).Error("failed to encode job result")
+				}
+				j.status.Result = data
+			}
+			j.publish(Event{Type: ResultEvent, Result: result})
+			j.setState(Done)
+		case ctx.Err() != nil && !j.canceled:
+			// the daemon is shutting down, leave the job to be resumed when it restarts
+			j.status.Error = ""
+			j.status.State = Pending
+			j.publish(Event{Type: LogEvent, Message: "Interrupted by the daemon shutting down"})
+		case ctx.Err() != nil:
+			j.status.Error = err.Error()
+			j.setState(Canceled)
+		case j.status.Attempts >= m.conf.Retries:
+			j.status.Error = err.Error()
+			j.setState(Failed)
+		default:
+			next := time.Now().Add(m.conf.Backoff << (j.status.Attempts - 1))
+			j.status.Error = err.Error()
+			j.status.NextAttempt = &next
+			j.publish(Event{Type: LogEvent, Message: fmt.Sprintf("Attempt %d failed (retrying at %s): %v", j.status.Attempts, next.Format(time.Kitchen), err)})
+			j.setState(Pending)
+		}
+		m.persist(j)
+		state := j.status.State
+		j.mu.Unlock()
+
+		if finished(state) && m.conf.Finished != nil {
+			m.conf.Finished(j.Status())
+		}
+		if state != Pending || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// persist saves the job, logging failures (the caller must hold the job's lock)
+func (m *Manager) persist(j *Job) {
+	if err := m.save(j); err != nil {
+		log.WithError(err).WithField("id", j.ID()).Error("failed to save job")
+	}
+}
+
+// track adds the job to the tracked jobs (the caller must hold the lock)
+func (m *Manager) track(j *Job) {
+	m.prune()
+	m.jobs[j.ID()] = j
+}
+
+// prune removes the jobs that finished more than the retention period ago from memory (the caller must hold the lock)
+func (m *Manager) prune() {
+	for id, j := range m.jobs {
+		if st := j.Status(); st.Finished != nil && time.Since(*st.Finished) > retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Get returns a job
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if ok {
+		return j, nil
+	}
+	if !m.distributed() {
+		return nil, ErrNotFound
+	}
+	// the job was submitted to another daemon
+	mj, err := m.db.GetJob(id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return m.mirror(mj), nil
+}
+
+// List returns a snapshot of all the jobs (oldest first)
+func (m *Manager) List() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	if m.distributed() {
+		list, err := m.listShared()
+		if err == nil {
+			return list
+		}
+		log.WithError(err).Error("failed to list the shared jobs")
+	}
+	list := make([]Status, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, j.Status())
+	}
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].Created.Before(list[k].Created)
+	})
+	return list
+}
+
+// History returns the done jobs of a kind (newest first), including the ones older than the retention
+// period if the jobs are persisted to the database
+func (m *Manager) History(kind string) ([]Status, error) {
+	var history []Status
+	if m.db != nil {
+		all, err := m.db.GetJobs(Done)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get jobs: %w", err)
+		}
+		for _, mj := range all {
+			if mj.Kind == kind {
+				history = append(history, statusFromModel(mj))
+			}
+		}
+	} else {
+		for _, st := range m.List() {
+			if st.Kind == kind && st.State == Done {
+				history = append(history, st)
+			}
+		}
+	}
+	sort.SliceStable(history, func(i, k int) bool {
+		return history[i].Created.After(history[k].Created)
+	})
+	return history, nil
+}
+
+// Owned returns the IDs of the jobs submitted by owner, including the ones older than the retention period
+// if the jobs are persisted to the database
+func (m *Manager) Owned(owner string) ([]string, error) {
+	var ids []string
+	if m.db != nil {
+		all, err := m.db.GetJobs("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get jobs: %w", err)
+		}
+		for _, mj := range all {
+			if mj.Owner == owner {
+				ids = append(ids, mj.ID)
+			}
+		}
+		return ids, nil
+	}
+	for _, st := range m.List() {
+		if st.Owner == owner {
+			ids = append(ids, st.ID)
+		}
+	}
+	return ids, nil
+}
+
+// Close stops the running jobs (they are resumed by the next call to Resume) and waits for them to stop
+func (m *Manager) Close() {
+	m.stop()
+	m.wg.Wait()
+	if m.conf.Cluster.Role == RoleWorker && m.db != nil {
+		// let the coordinator reassign this worker's jobs right away
+		if err := m.db.DeleteWorker(m.conf.Cluster.WorkerID); err != nil {
+			log.WithError(err).Error("failed to unregister worker")
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package keys
+
+import (
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/model"
+)
+
+// AEA key material kinds
+const (
+	aeaFCSKey     = "fcs"
+	aeaArchiveKey = "archive"
+)
+
+// AEAStore caches the fcs-keys and AEA symmetric keys in the key database (opened on first use)
+type AEAStore struct {
+	mu   sync.Mutex
+	path string
+	db   *DB
+}
+
+// AEAKeyStore returns a store (for aea.SetKeyStore) that caches AEA keys in the key database at path (or the default path)
+func AEAKeyStore(path string) *AEAStore {
+	return &AEAStore{path: path}
+}
+
+func (s *AEAStore) open() *DB {
+	if s.db == nil {
+		db, err := Open(s.path, nil, "", false)
+		if err != nil {
+			log.WithError(err).Debug("failed to open key database")
+			return nil
+		}
+		s.db = db
+	}
+	return s.db
+}
+
+func (s *AEAStore) get(kind, name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db := s.open()
+	if db == nil {
+		return "", false
+	}
+	key, err := db.db.GetAEAKey(kind, name)
+	if err != nil || len(key.Value) == 0 {
+		return "", false
+	}
+	return key.Value, true
+}
+
+func (s *AEAStore) put(kind, name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	db := s.open()
+	if db == nil {
+		return nil
+	}
+	if key, err := db.db.GetAEAKey(kind, name); err == nil && key.Value == value {
+		return nil
+	}
+	return db.db.SaveAEAKey(&model.AEAKey{Kind: kind, Name: name, Value: value})
+}
+
+func (s *AEAStore) FCSKey(name string) ([]byte, bool) {
+	if pem, ok := s.get(aeaFCSKey, name); ok {
+		return []byte(pem), true
+	}
+	return nil, false
+}
+
+func (s *AEAStore) PutFCSKey(name string, pem []byte) error {
+	return s.put(aeaFCSKey, name, string(pem))
+}
+
+func (s *AEAStore) ArchiveKey(id string) (string, bool) {
+	return s.get(aeaArchiveKey, id)
+}
+
+func (s *AEAStore) PutArchiveKey(id, b64key string) error {
+	return s.put(aeaArchiveKey, id, b64key)
+}
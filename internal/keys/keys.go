@@ -0,0 +1,302 @@
+// Package keys is a local database of firmware decryption keys
+package keys
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/pkg/info"
+	"github.com/spf13/viper"
+)
+
+// ErrNotFound is returned when no key is known for a file
+var ErrNotFound = errors.New("key not found")
+
+// DefaultSources are the key sources used when none are configured (see `keys.sources` in the config file)
+var DefaultSources = []string{"wiki"}
+
+// Key is a firmware file's decryption key
+type Key struct {
+	Device   string `json:"device"`
+	Build    string `json:"build"`
+	Filename string `json:"filename"`
+	IV       string `json:"iv,omitempty"`
+	Key      string `json:"key,omitempty"`
+	KBag     string `json:"kbag,omitempty"`
+	Source   string `json:"source,omitempty"`
+}
+
+func (k Key) String() string {
+	out := fmt.Sprintf("‣ %s\n", k.Filename)
+	if len(k.IV) > 0 {
+		out += fmt.Sprintf("  IV:   %s\n", k.IV)
+	}
+	if len(k.Key) > 0 {
+		out += fmt.Sprintf("  Key:  %s\n", k.Key)
+	}
+	if len(k.KBag) > 0 {
+		out += fmt.Sprintf("  KBAG: %s\n", k.KBag)
+	}
+	if len(k.Source) > 0 {
+		out += fmt.Sprintf("  Source: %s\n", k.Source)
+	}
+	return out
+}
+
+// Decode returns the AES iv and key (the key field can also be the iv+key concatenated)
+func (k Key) Decode() (iv []byte, key []byte, err error) {
+	if len(k.IV) == 0 && len(k.Key) == 2*(aes.BlockSize+32) {
+		ivkey, err := hex.DecodeString(k.Key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode iv+key: %v", err)
+		}
+		return ivkey[:aes.BlockSize], ivkey[aes.BlockSize:], nil
+	}
+	if len(k.IV) == 0 || len(k.Key) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", k.Filename, ErrNotFound)
+	}
+	if iv, err = hex.DecodeString(k.IV); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode iv: %v", err)
+	}
+	if key, err = hex.DecodeString(k.Key); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode key: %v", err)
+	}
+	return iv, key, nil
+}
+
+// usable returns true if the key can be used to decrypt
+func (k Key) usable() bool {
+	_, _, err := k.Decode()
+	return err == nil
+}
+
+// normalize returns the lowercased base name of a firmware file (the wiki uses spaces where IPSWs use underscores)
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(filepath.Base(name), " ", "_"))
+}
+
+// fileType returns the image type of a firmware file name (i.e. iBoot.d83.RELEASE.im4p -> iboot)
+func fileType(name string) string {
+	typ, _, _ := strings.Cut(normalize(name), ".")
+	return typ
+}
+
+// matches returns true if the key is for the firmware file
+func (k Key) matches(filename string) bool {
+	return normalize(k.Filename) == normalize(filename)
+}
+
+// DB is the local firmware key database
+type DB struct {
+	Path    string
+	Sources []Source
+	db      db.Database
+}
+
+// DefaultPath returns the path of the key database in the ipsw config folder
+func DefaultPath() (string, error) {
+	if len(viper.ConfigFileUsed()) > 0 {
+		return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "keys.db"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".config", "ipsw", "keys.db"), nil
+}
+
+// Open opens the key database at path (or the default path) using the key sources (or the configured/default sources)
+func Open(path string, sources []string, proxy string, insecure bool) (*DB, error) {
+	var err error
+	if len(path) == 0 {
+		if path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	if len(sources) == 0 {
+		sources = viper.GetStringSlice("keys.sources")
+	}
+	if len(sources) == 0 {
+		sources = DefaultSources
+	}
+
+	kdb := &DB{Path: path}
+	if kdb.Sources, err = NewSources(sources, proxy, insecure); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create key database folder: %v", err)
+	}
+	if kdb.db, err = db.NewSqlite(path, 100); err != nil {
+		return nil, fmt.Errorf("failed to create key database: %v", err)
+	}
+	if err := kdb.db.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to open key database %s: %v", path, err)
+	}
+
+	return kdb, nil
+}
+
+// Close closes the key database
+func (kdb *DB) Close() error {
+	return kdb.db.Close()
+}
+
+// stored returns the keys of a device/build in the database
+func (kdb *DB) stored(device, build string) ([]Key, error) {
+	fks, err := kdb.db.GetFirmwareKeys(device, build)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys for %s %s: %v", device, build, err)
+	}
+	keys := make([]Key, 0, len(fks))
+	for _, fk := range fks {
+		keys = append(keys, Key{
+			Device:   fk.Device,
+			Build:    fk.Build,
+			Filename: fk.Filename,
+			IV:       fk.IV,
+			Key:      fk.Key,
+			KBag:     fk.KBag,
+			Source:   fk.Source,
+		})
+	}
+	return keys, nil
+}
+
+// Add adds (or updates) keys in the database and returns the number of new/updated keys
+func (kdb *DB) Add(keys ...Key) (int, error) {
+	existing := make(map[string][]Key)
+	var save []*model.FirmwareKey
+	for _, key := range keys {
+		id := key.Device + "/" + key.Build
+		if _, ok := existing[id]; !ok {
+			stored, err := kdb.stored(key.Device, key.Build)
+			if err != nil {
+				return 0, err
+			}
+			existing[id] = stored
+		}
+		idx := slices.IndexFunc(existing[id], func(k Key) bool { return k.matches(key.Filename) })
+		if idx >= 0 {
+			k := existing[id][idx]
+			if !key.usable() || (k.usable() && k.IV == key.IV && k.Key == key.Key) {
+				continue
+			}
+			key.Filename = k.Filename // keep the stored name so the key is updated
+			existing[id][idx] = key
+		} else {
+			existing[id] = append(existing[id], key)
+		}
+		save = append(save, &model.FirmwareKey{
+			Device:   key.Device,
+			Build:    key.Build,
+			Filename: key.Filename,
+			IV:       key.IV,
+			Key:      key.Key,
+			KBag:     key.KBag,
+			Source:   key.Source,
+		})
+	}
+	if err := kdb.db.SaveFirmwareKeys(save...); err != nil {
+		return 0, fmt.Errorf("failed to save keys: %v", err)
+	}
+	return len(save), nil
+}
+
+// List returns the keys of a device/build (from the database and the embedded keys)
+func (kdb *DB) List(device, build string) ([]Key, error) {
+	keys, err := kdb.stored(device, build)
+	if err != nil {
+		return nil, err
+	}
+	if embedded, err := info.GetFirmwareKeys(device, build); err == nil {
+		for name, val := range embedded {
+			typ, ok := strings.CutSuffix(name, "-key")
+			if !ok {
+				continue
+			}
+			keys = append(keys, Key{
+				Device:   device,
+				Build:    build,
+				Filename: typ,
+				IV:       embedded[typ+"-iv"],
+				Key:      val,
+				Source:   "embedded",
+			})
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Filename < keys[j].Filename
+	})
+	return keys, nil
+}
+
+// Get returns the (usable) key of a device/build's firmware file from the database or the embedded keys
+func (kdb *DB) Get(device, build, filename string) (*Key, error) {
+	stored, err := kdb.stored(device, build)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range stored {
+		if k.matches(filename) && k.usable() {
+			return &k, nil
+		}
+	}
+	if kbag, key, err := info.GetApFirmwareKey(device, build, filepath.Base(filename)); err == nil {
+		return &Key{Device: device, Build: build, Filename: filepath.Base(filename), Key: key, KBag: kbag, Source: "embedded"}, nil
+	}
+	if embedded, err := info.GetFirmwareKeys(device, build); err == nil {
+		typ := fileType(filename)
+		if iv, key := embedded[typ+"-iv"], embedded[typ+"-key"]; len(iv) > 0 && len(key) > 0 {
+			return &Key{Device: device, Build: build, Filename: typ, IV: iv, Key: key, Source: "embedded"}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s for %s %s: %w", filepath.Base(filename), device, build, ErrNotFound)
+}
+
+// Fetch queries the key sources for the keys of a device/build and caches them in the database
+func (kdb *DB) Fetch(device, build string) ([]Key, error) {
+	var keys []Key
+	var errs []error
+	for _, src := range kdb.Sources {
+		found, err := src.Fetch(device, build)
+		if err != nil {
+			log.WithError(err).WithField("source", src.Name()).Debug("Failed to fetch keys")
+			errs = append(errs, fmt.Errorf("%s: %v", src.Name(), err))
+			continue
+		}
+		keys = append(keys, found...)
+	}
+	if len(keys) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to fetch keys for %s %s: %v", device, build, errors.Join(errs...))
+		}
+		return nil, fmt.Errorf("keys for %s %s: %w", device, build, ErrNotFound)
+	}
+	if _, err := kdb.Add(keys...); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Find returns the key of a device/build's firmware file, fetching (and caching) the device/build's keys if needed
+func (kdb *DB) Find(device, build, filename string) (*Key, error) {
+	if key, err := kdb.Get(device, build, filename); err == nil {
+		return key, nil
+	}
+	if _, err := kdb.Fetch(device, build); err != nil {
+		return nil, err
+	}
+	return kdb.Get(device, build, filename)
+}
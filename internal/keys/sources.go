@@ -0,0 +1,151 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/utils"
+)
+
+// Source is a source of firmware keys
+type Source interface {
+	Name() string
+	Fetch(device, build string) ([]Key, error)
+}
+
+// NewSources creates the key sources which are either `wiki` (theapplewiki.com) OR a URL template that
+// returns a JSON list of keys where {device} and {build} are replaced with the device and build
+func NewSources(sources []string, proxy string, insecure bool) ([]Source, error) {
+	var srcs []Source
+	for _, src := range sources {
+		switch {
+		case src == "wiki" || src == "theapplewiki":
+			srcs = append(srcs, &wikiSource{proxy: proxy, insecure: insecure})
+		case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+			if _, err := url.Parse(src); err != nil {
+				return nil, fmt.Errorf("invalid key source URL '%s': %v", src, err)
+			}
+			srcs = append(srcs, &urlSource{tmpl: src, proxy: proxy, insecure: insecure})
+		default:
+			return nil, fmt.Errorf("unknown key source '%s' (must be 'wiki' or an http(s) URL template)", src)
+		}
+	}
+	return srcs, nil
+}
+
+// known returns the value unless the wiki lists it as unknown
+func known(vals []string, idx int) string {
+	if idx >= len(vals) || strings.EqualFold(vals[idx], "Unknown") || strings.EqualFold(vals[idx], "Not Encrypted") {
+		return ""
+	}
+	return vals[idx]
+}
+
+type wikiSource struct {
+	proxy    string
+	insecure bool
+}
+
+func (s *wikiSource) Name() string { return "theapplewiki.com" }
+
+func (s *wikiSource) Fetch(device, build string) ([]Key, error) {
+	wkeys, err := download.GetWikiFirmwareKeys(&download.WikiConfig{
+		Keys:   true,
+		Device: device,
+		Build:  build,
+	}, s.proxy, s.insecure)
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	for _, wk := range wkeys {
+		for idx, fn := range wk.Filename {
+			key := Key{
+				Device:   device,
+				Build:    build,
+				Filename: fn,
+				IV:       known(wk.Iv, idx),
+				Key:      known(wk.Key, idx),
+				KBag:     known(wk.Kbag, idx),
+				Source:   s.Name(),
+			}
+			if len(key.IV) == 0 && len(key.Key) == 0 && len(key.KBag) == 0 {
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+type urlSource struct {
+	tmpl     string
+	proxy    string
+	insecure bool
+}
+
+func (s *urlSource) Name() string {
+	if u, err := url.Parse(s.tmpl); err == nil {
+		return u.Host
+	}
+	return s.tmpl
+}
+
+func (s *urlSource) Fetch(device, build string) ([]Key, error) {
+	u := strings.NewReplacer("{device}", url.PathEscape(device), "{build}", url.PathEscape(build)).Replace(s.tmpl)
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %v", err)
+	}
+	req.Header.Add("User-Agent", utils.RandomAgent())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           download.GetProxy(s.proxy),
+			TLSClientConfig: download.TLSConfig(s.insecure),
+		},
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key source returned status: %s", resp.Status)
+	}
+
+	dat, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var keys []Key
+	if err := json.Unmarshal(dat, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse keys: %v", err)
+	}
+	for idx := range keys {
+		if len(keys[idx].Device) == 0 {
+			keys[idx].Device = device
+		}
+		if len(keys[idx].Build) == 0 {
+			keys[idx].Build = build
+		}
+		if len(keys[idx].Source) == 0 {
+			keys[idx].Source = s.Name()
+		}
+	}
+	return keys, nil
+}
@@ -139,6 +139,24 @@ func IsIm4p(filePath string) (bool, error) {
 	return false, nil
 }
 
+// IsImg4Data returns true if the data is an ASN.1 IMG4
+func IsImg4Data(dat []byte) bool {
+	var hdr Asn1Header
+	if _, err := asn1.Unmarshal(dat, &hdr); err != nil {
+		return false
+	}
+	return hdr.Name == "IMG4"
+}
+
+// IsIm4pData returns true if the data is an ASN.1 IM4P
+func IsIm4pData(dat []byte) bool {
+	var hdr Asn1Header
+	if _, err := asn1.Unmarshal(dat, &hdr); err != nil {
+		return false
+	}
+	return hdr.Name == "IM4P"
+}
+
 func IsImg3(filePath string) (bool, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -0,0 +1,302 @@
+// Package mcp implements a Model Context Protocol server (JSON-RPC 2.0 over stdio) that exposes tools to LLM agents
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/invopop/jsonschema"
+)
+
+// ProtocolVersion is the latest MCP revision the server implements
+const ProtocolVersion = "2025-06-18"
+
+// the revisions the server can negotiate
+var protocolVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Handler runs a tool call with the tool's (JSON) arguments
+type Handler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Tool is a tool exposed to the MCP clients
+type Tool struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	InputSchema *jsonschema.Schema `json:"inputSchema"`
+
+	handler Handler
+}
+
+// NewTool creates a tool whose input schema is reflected from the arguments type T
+// (use `jsonschema_description` tags to describe the arguments and omitempty for the optional ones)
+func NewTool[T any](name, description string, fn func(ctx context.Context, args T) (any, error)) Tool {
+	r := &jsonschema.Reflector{DoNotReference: true, ExpandedStruct: true}
+	schema := r.Reflect(new(T))
+	schema.Version = ""
+	schema.ID = ""
+	return Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+		handler: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			var args T
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("invalid arguments: %w", err)
+				}
+			}
+			return fn(ctx, args)
+		},
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Server is an MCP server
+type Server struct {
+	name         string
+	version      string
+	instructions string
+	tools        []Tool
+
+	mu    sync.Mutex // guards out and calls
+	out   *json.Encoder
+	calls map[string]context.CancelFunc
+	wg    sync.WaitGroup
+}
+
+// NewServer creates an MCP server (the instructions are sent to the clients to describe how to use the tools)
+func NewServer(name, version, instructions string, tools ...Tool) *Server {
+	return &Server{
+		name:         name,
+		version:      version,
+		instructions: instructions,
+		tools:        tools,
+		calls:        make(map[string]context.CancelFunc),
+	}
+}
+
+// Tools returns the server's tools
+func (s *Server) Tools() []Tool {
+	return s.tools
+}
+
+func (s *Server) tool(name string) (Tool, bool) {
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == name })
+	if i < 0 {
+		return Tool{}, false
+	}
+	return s.tools[i], true
+}
+
+func (s *Server) send(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.out.Encode(msg); err != nil {
+		log.WithError(err).Error("mcp: failed to write message")
+	}
+}
+
+func (s *Server) reply(id json.RawMessage, result any) {
+	s.send(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) fail(id json.RawMessage, code int, format string, args ...any) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	s.send(response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}})
+}
+
+// Serve reads the newline delimited JSON-RPC messages from r and writes the responses to w
+// until r is closed or ctx is canceled (the tool calls run concurrently and can be canceled by the client)
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.out = json.NewEncoder(w)
+
+	lines := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := slices.Clone(scanner.Bytes())
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- scanner.Err()
+	}()
+
+	defer s.wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			return err
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+			s.handle(ctx, line)
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, line []byte) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.fail(nil, codeParseError, "parse error: %v", err)
+		return
+	}
+	if req.JSONRPC != "2.0" || len(req.Method) == 0 {
+		if len(req.ID) > 0 && len(req.Method) == 0 {
+			return // a response to a server request (the server doesn't send any)
+		}
+		s.fail(req.ID, codeInvalidRequest, "invalid request")
+		return
+	}
+	// notifications have no ID and get no response
+	if len(req.ID) == 0 {
+		if req.Method == "notifications/cancelled" {
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if err := json.Unmarshal(req.Params, &params); err == nil {
+				s.mu.Lock()
+				if cancel, ok := s.calls[string(params.RequestID)]; ok {
+					cancel()
+				}
+				s.mu.Unlock()
+			}
+		}
+		return
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		s.reply(req.ID, map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools": map[string]any{"listChanged": false},
+			},
+			"serverInfo": map[string]any{
+				"name":    s.name,
+				"version": s.version,
+			},
+			"instructions": s.instructions,
+		})
+	case "ping":
+		s.reply(req.ID, struct{}{})
+	case "tools/list":
+		s.reply(req.ID, map[string]any{"tools": s.tools})
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.fail(req.ID, codeInvalidParams, "invalid params: %v", err)
+			return
+		}
+		t, ok := s.tool(params.Name)
+		if !ok {
+			s.fail(req.ID, codeInvalidParams, "unknown tool '%s'", params.Name)
+			return
+		}
+		s.call(ctx, req.ID, t, params.Arguments)
+	default:
+		s.fail(req.ID, codeMethodNotFound, "method '%s' not found", req.Method)
+	}
+}
+
+// call runs the tool in the background (tool errors are reported in the result so the model can see them)
+func (s *Server) call(ctx context.Context, id json.RawMessage, t Tool, args json.RawMessage) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.calls[string(id)] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.calls, string(id))
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		log.WithField("tool", t.Name).Debug("mcp: calling tool")
+		result, err := t.handler(ctx, args)
+		if ctx.Err() != nil {
+			return // canceled requests get no response
+		}
+		if err != nil {
+			s.reply(id, callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true})
+			return
+		}
+		text, ok := result.(string)
+		if !ok {
+			dat, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				s.reply(id, callResult{Content: []content{{Type: "text", Text: fmt.Sprintf("failed to encode result: %v", err)}}, IsError: true})
+				return
+			}
+			text = string(dat)
+		}
+		s.reply(id, callResult{Content: []content{{Type: "text", Text: text}}})
+	}()
+}
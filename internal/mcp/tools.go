@@ -0,0 +1,517 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/commands/ent"
+	"github.com/blacktop/ipsw/internal/commands/mount"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/diff"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/blacktop/ipsw/pkg/info"
+)
+
+// defaultLimit is the max number of results the search tools return by default
+const defaultLimit = 100
+
+// Config is the configuration of the ipsw tools
+type Config struct {
+	// DB is the symbol database (the symbol tools are disabled if nil)
+	DB db.Database
+	// PemDB is the AEA pem DB JSON file used to decrypt the IPSWs
+	PemDB string
+	// Cache is the folder the entitlement databases are cached in (they are only cached in memory if empty)
+	Cache string
+}
+
+// Tools are the ipsw analysis tools (they cache the mounted dyld_shared_caches and entitlement databases)
+type Tools struct {
+	conf *Config
+
+	mu   sync.Mutex
+	dscs map[string]*dscs
+	ents map[string]map[string]string
+}
+
+type dscs struct {
+	mount *mount.Context
+	files []*dyld.File
+}
+
+// NewTools creates the ipsw analysis tools
+func NewTools(conf *Config) *Tools {
+	return &Tools{
+		conf: conf,
+		dscs: make(map[string]*dscs),
+		ents: make(map[string]map[string]string),
+	}
+}
+
+// Close closes the cached dyld_shared_caches and unmounts their IPSWs
+func (t *Tools) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs []string
+	for path, d := range t.dscs {
+		for _, f := range d.files {
+			f.Close()
+		}
+		if err := d.mount.Unmount(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to unmount %s: %v", path, err))
+		}
+		delete(t.dscs, path)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// List returns the tools (the symbol database tools are only included if there is a database)
+func (t *Tools) List() []Tool {
+	tools := []Tool{
+		NewTool("ipsw_info", "Get the info of a local IPSW/OTA (version, build, devices, kernelcaches, DMGs).", t.info),
+		NewTool("dsc_symbols", "Search the dyld_shared_cache symbols of a local IPSW by name regex (optionally in one image).", t.dscSymbols),
+		NewTool("dsc_strings", "Search the C strings of the dyld_shared_cache images of a local IPSW by regex.", t.dscStrings),
+		NewTool("ent_search", "Search a local IPSW's MachOs for entitlements whose key and/or value match the regexes.", t.entSearch),
+		NewTool("ent_file", "Get the entitlements of the MachOs of a local IPSW whose path contains 'file'.", t.entFile),
+		NewTool("ent_diff", "Diff the entitlements of the MachOs of two local IPSWs (as markdown).", t.entDiff),
+		NewTool("ipsw_diff", "Diff two local IPSWs (kernelcache kexts, dyld_shared_cache dylibs, MachOs, entitlements and optionally launchd config, firmwares and feature flags). This can take several minutes.", t.ipswDiff),
+	}
+	if t.conf.DB != nil {
+		tools = append(tools,
+			NewTool("syms_ipsw", "Get the kernelcache and dyld_shared_cache UUIDs of an IPSW in the symbol database (by version or build and device).", t.symsIPSW),
+			NewTool("syms_lookup", "Look up the symbol at an address or search the symbols by name regex of a MachO/kernelcache/dyld_shared_cache (by UUID) in the symbol database.", t.symsLookup),
+		)
+	}
+	return tools
+}
+
+/* ARGUMENTS */
+
+type ipswArgs struct {
+	IPSW string `json:"ipsw" jsonschema_description:"path to the local IPSW/OTA"`
+}
+
+type dscSymbolsArgs struct {
+	IPSW    string `json:"ipsw" jsonschema_description:"path to the local IPSW"`
+	Pattern string `json:"pattern" jsonschema_description:"symbol name regex"`
+	Image   string `json:"image,omitempty" jsonschema_description:"only search this dylib (i.e. libsystem_c.dylib)"`
+	Limit   int    `json:"limit,omitempty" jsonschema_description:"max number of results (defaults to 100)"`
+}
+
+type dscStringsArgs struct {
+	IPSW    string `json:"ipsw" jsonschema_description:"path to the local IPSW"`
+	Pattern string `json:"pattern" jsonschema_description:"string regex"`
+	Limit   int    `json:"limit,omitempty" jsonschema_description:"max number of results (defaults to 100)"`
+}
+
+type entSearchArgs struct {
+	IPSW  string `json:"ipsw" jsonschema_description:"path to the local IPSW"`
+	Key   string `json:"key,omitempty" jsonschema_description:"entitlement key regex"`
+	Value string `json:"value,omitempty" jsonschema_description:"entitlement value regex"`
+	Limit int    `json:"limit,omitempty" jsonschema_description:"max number of results (defaults to 100)"`
+}
+
+type entFileArgs struct {
+	IPSW string `json:"ipsw" jsonschema_description:"path to the local IPSW"`
+	File string `json:"file" jsonschema_description:"(case insensitive) substring of the MachO path (i.e. WebContent)"`
+}
+
+type entDiffArgs struct {
+	Old string `json:"old" jsonschema_description:"path to the old local IPSW"`
+	New string `json:"new" jsonschema_description:"path to the new local IPSW"`
+}
+
+type ipswDiffArgs struct {
+	Old      string `json:"old" jsonschema_description:"path to the old local IPSW"`
+	New      string `json:"new" jsonschema_description:"path to the new local IPSW"`
+	LaunchD  bool   `json:"launchd,omitempty" jsonschema_description:"diff the launchd config"`
+	Firmware bool   `json:"firmware,omitempty" jsonschema_description:"diff the firmwares"`
+	Features bool   `json:"features,omitempty" jsonschema_description:"diff the feature flags"`
+	CStrings bool   `json:"cstrings,omitempty" jsonschema_description:"diff the MachOs' C strings"`
+}
+
+type symsIPSWArgs struct {
+	Version string `json:"version,omitempty" jsonschema_description:"iOS version (i.e. 18.2)"`
+	Build   string `json:"build,omitempty" jsonschema_description:"build (i.e. 22C152)"`
+	Device  string `json:"device" jsonschema_description:"device (i.e. iPhone17,1)"`
+}
+
+type symsLookupArgs struct {
+	UUID    string `json:"uuid" jsonschema_description:"UUID of the MachO, kernelcache or dyld_shared_cache"`
+	Address string `json:"address,omitempty" jsonschema_description:"address to symbolicate (hex with 0x prefix or decimal)"`
+	Pattern string `json:"pattern,omitempty" jsonschema_description:"symbol name regex (if there is no address)"`
+	Limit   int    `json:"limit,omitempty" jsonschema_description:"max number of results (defaults to 100)"`
+}
+
+/* RESULTS */
+
+type symbol struct {
+	Name    string `json:"name"`
+	Address uint64 `json:"address"`
+	End     uint64 `json:"end,omitempty"`
+}
+
+type symbols struct {
+	Symbols   any  `json:"symbols"`
+	Total     int  `json:"total"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type strs struct {
+	Strings   []dsc.String `json:"strings"`
+	Total     int          `json:"total"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+type entMatch struct {
+	File  string `json:"file"`
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+type entMatches struct {
+	Matches   []entMatch `json:"matches"`
+	Total     int        `json:"total"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+type symsIPSW struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Build   string      `json:"build"`
+	Devices []string    `json:"devices"`
+	Kernels []symsImage `json:"kernels,omitempty"`
+	DSCs    []symsImage `json:"dscs,omitempty"`
+}
+
+type symsImage struct {
+	UUID    string `json:"uuid"`
+	Version string `json:"version,omitempty"`
+}
+
+/* HELPERS */
+
+func limit(n int) int {
+	if n <= 0 {
+		return defaultLimit
+	}
+	return n
+}
+
+func truncate[T any](items []T, n int) ([]T, bool) {
+	if n = limit(n); len(items) > n {
+		return items[:n], true
+	}
+	return items, false
+}
+
+func clean(path string) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("path is required")
+	}
+	path = filepath.Clean(path)
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// openDSCs returns the (cached) dyld_shared_caches of the IPSW
+func (t *Tools) openDSCs(path string) ([]*dyld.File, error) {
+	path, err := clean(path)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d, ok := t.dscs[path]; ok {
+		return d.files, nil
+	}
+	log.WithField("ipsw", path).Info("Mounting dyld_shared_cache")
+	ctx, files, err := dsc.OpenFromIPSW(path, t.conf.PemDB, false, false)
+	if err != nil {
+		return nil, err
+	}
+	t.dscs[path] = &dscs{mount: ctx, files: files}
+	return files, nil
+}
+
+// entDB returns the (cached) entitlement database of the IPSW
+func (t *Tools) entDB(path string) (map[string]string, error) {
+	path, err := clean(path)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if edb, ok := t.ents[path]; ok {
+		return edb, nil
+	}
+	conf := &ent.Config{IPSW: path, PemDB: t.conf.PemDB}
+	if len(t.conf.Cache) > 0 {
+		if err := os.MkdirAll(t.conf.Cache, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create cache folder: %w", err)
+		}
+		// name the database after the IPSW's path so different IPSWs with the same name don't collide
+		sum := sha256.Sum256([]byte(path))
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		conf.Database = filepath.Join(t.conf.Cache, fmt.Sprintf("%s_%s.entDB", name, hex.EncodeToString(sum[:4])))
+	}
+	edb, err := ent.GetDatabase(conf)
+	if err != nil {
+		return nil, err
+	}
+	t.ents[path] = edb
+	return edb, nil
+}
+
+/* TOOLS */
+
+func (t *Tools) info(_ context.Context, args ipswArgs) (any, error) {
+	path, err := clean(args.IPSW)
+	if err != nil {
+		return nil, err
+	}
+	i, err := info.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPSW: %w", err)
+	}
+	return i.ToJSON(), nil
+}
+
+func (t *Tools) dscSymbols(ctx context.Context, args dscSymbolsArgs) (any, error) {
+	if len(args.Pattern) == 0 {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	files, err := t.openDSCs(args.IPSW)
+	if err != nil {
+		return nil, err
+	}
+	var found []dsc.Symbol
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		syms, err := dsc.GetSymbols(f, []dsc.Symbol{{Pattern: args.Pattern, Image: args.Image}})
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, syms...)
+	}
+	total := len(found)
+	found, truncated := truncate(found, args.Limit)
+	return symbols{Symbols: found, Total: total, Truncated: truncated}, nil
+}
+
+func (t *Tools) dscStrings(ctx context.Context, args dscStringsArgs) (any, error) {
+	files, err := t.openDSCs(args.IPSW)
+	if err != nil {
+		return nil, err
+	}
+	var found []dsc.String
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ss, err := dsc.GetStringsRegex(f, args.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, ss...)
+	}
+	total := len(found)
+	found, truncated := truncate(found, args.Limit)
+	return strs{Strings: found, Total: total, Truncated: truncated}, nil
+}
+
+func (t *Tools) entSearch(_ context.Context, args entSearchArgs) (any, error) {
+	if len(args.Key) == 0 && len(args.Value) == 0 {
+		return nil, fmt.Errorf("key or value is required")
+	}
+	keyRE, err := regexp.Compile(args.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key regex: %w", err)
+	}
+	valRE, err := regexp.Compile(args.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value regex: %w", err)
+	}
+	edb, err := t.entDB(args.IPSW)
+	if err != nil {
+		return nil, err
+	}
+	matches := []entMatch{}
+	for f, e := range edb {
+		if len(e) == 0 {
+			continue
+		}
+		ents := make(map[string]any)
+		if err := plist.NewDecoder(bytes.NewReader([]byte(e))).Decode(&ents); err != nil {
+			return nil, fmt.Errorf("failed to decode entitlements plist for %s: %w", f, err)
+		}
+		for k, v := range ents {
+			if keyRE.MatchString(k) && valRE.MatchString(fmt.Sprint(v)) {
+				matches = append(matches, entMatch{File: f, Key: k, Value: v})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].File == matches[j].File {
+			return matches[i].Key < matches[j].Key
+		}
+		return matches[i].File < matches[j].File
+	})
+	total := len(matches)
+	matches, truncated := truncate(matches, args.Limit)
+	return entMatches{Matches: matches, Total: total, Truncated: truncated}, nil
+}
+
+func (t *Tools) entFile(_ context.Context, args entFileArgs) (any, error) {
+	if len(args.File) == 0 {
+		return nil, fmt.Errorf("file is required")
+	}
+	edb, err := t.entDB(args.IPSW)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]map[string]any)
+	for f, e := range edb {
+		if !strings.Contains(strings.ToLower(f), strings.ToLower(args.File)) {
+			continue
+		}
+		ents := make(map[string]any)
+		if len(e) > 0 {
+			if err := plist.NewDecoder(bytes.NewReader([]byte(e))).Decode(&ents); err != nil {
+				return nil, fmt.Errorf("failed to decode entitlements plist for %s: %w", f, err)
+			}
+		}
+		found[f] = ents
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no MachO path contains '%s'", args.File)
+	}
+	return found, nil
+}
+
+func (t *Tools) entDiff(_ context.Context, args entDiffArgs) (any, error) {
+	prev, err := t.entDB(args.Old)
+	if err != nil {
+		return nil, err
+	}
+	curr, err := t.entDB(args.New)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ent.DiffDatabases(prev, curr, &ent.Config{Markdown: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff entitlement databases: %w", err)
+	}
+	if len(strings.TrimSpace(out)) == 0 {
+		return "No entitlement differences found", nil
+	}
+	return out, nil
+}
+
+func (t *Tools) ipswDiff(_ context.Context, args ipswDiffArgs) (any, error) {
+	prev, err := clean(args.Old)
+	if err != nil {
+		return nil, err
+	}
+	curr, err := clean(args.New)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "ipsw-mcp-diff")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	d := diff.New(&diff.Config{
+		IpswOld:  prev,
+		IpswNew:  curr,
+		LaunchD:  args.LaunchD,
+		Firmware: args.Firmware,
+		Features: args.Features,
+		CStrings: args.CStrings,
+		PemDB:    t.conf.PemDB,
+		Output:   tmp,
+	})
+	if err := d.Diff(); err != nil {
+		return nil, fmt.Errorf("failed to diff IPSWs: %w", err)
+	}
+	return d, nil
+}
+
+func (t *Tools) symsIPSW(_ context.Context, args symsIPSWArgs) (any, error) {
+	if len(args.Device) == 0 || (len(args.Version) == 0 && len(args.Build) == 0) {
+		return nil, fmt.Errorf("device and version or build are required")
+	}
+	i, err := t.conf.DB.GetIPSW(args.Version, args.Build, args.Device)
+	if err != nil {
+		return nil, err
+	}
+	out := symsIPSW{Name: i.Name, Version: i.Version, Build: i.BuildID}
+	for _, d := range i.Devices {
+		out.Devices = append(out.Devices, d.Name)
+	}
+	for _, k := range i.Kernels {
+		out.Kernels = append(out.Kernels, symsImage{UUID: k.UUID, Version: k.Version})
+	}
+	for _, d := range i.DSCs {
+		out.DSCs = append(out.DSCs, symsImage{UUID: d.UUID})
+	}
+	return out, nil
+}
+
+func (t *Tools) symsLookup(_ context.Context, args symsLookupArgs) (any, error) {
+	if len(args.UUID) == 0 {
+		return nil, fmt.Errorf("uuid is required")
+	}
+	if len(args.Address) > 0 {
+		addr, err := strconv.ParseUint(args.Address, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address '%s': %w", args.Address, err)
+		}
+		sym, err := t.conf.DB.GetSymbol(args.UUID, addr)
+		if err != nil {
+			return nil, err
+		}
+		return symbol{Name: sym.GetName(), Address: sym.Start, End: sym.End}, nil
+	}
+	if len(args.Pattern) == 0 {
+		return nil, fmt.Errorf("address or pattern is required")
+	}
+	re, err := regexp.Compile(args.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern regex: %w", err)
+	}
+	syms, err := t.conf.DB.GetSymbols(args.UUID)
+	if err != nil {
+		return nil, err
+	}
+	found := []symbol{}
+	for _, s := range syms {
+		if re.MatchString(s.GetName()) {
+			found = append(found, symbol{Name: s.GetName(), Address: s.Start, End: s.End})
+		}
+	}
+	total := len(found)
+	found, truncated := truncate(found, args.Limit)
+	return symbols{Symbols: found, Total: total, Truncated: truncated}, nil
+}
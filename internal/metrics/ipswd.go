@@ -0,0 +1,63 @@
+package metrics
+
+// dbBuckets are the DB query latency buckets (in seconds)
+var dbBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// The ipswd metrics
+var (
+	HTTPRequests = Default.NewCounterVec("ipswd_http_requests_total",
+		"Number of HTTP API requests by method, route and status code.",
+		"method", "route", "code")
+	HTTPRequestDuration = Default.NewHistogramVec("ipswd_http_request_duration_seconds",
+		"HTTP API request latency by method and route.",
+		nil, "method", "route")
+
+	Downloads = Default.NewCounterVec("ipswd_downloads_total",
+		"Number of finished IPSW downloads by result (success or failure).",
+		"result")
+	DownloadBytes = Default.NewCounterVec("ipswd_download_bytes_total",
+		"Number of bytes downloaded.")
+
+	ScanDuration = Default.NewHistogramVec("ipswd_syms_scan_duration_seconds",
+		"Symbol scan duration by result (success or failure).",
+		nil, "result")
+	SymbolLookups = Default.NewCounterVec("ipswd_symbol_lookups_total",
+		"Number of symbol lookups by API (rest or grpc) and result (found, not_found or error).",
+		"api", "result")
+
+	DBDuration = Default.NewHistogramVec("ipswd_db_query_duration_seconds",
+		"Database query latency by operation.",
+		dbBuckets, "op")
+
+	CacheRequests = Default.NewCounterVec("ipswd_cache_requests_total",
+		"Number of requests to the cached analysis routes by cache result (hit, shared, miss or bypass).",
+		"result")
+
+	RateLimited = Default.NewCounterVec("ipswd_rate_limited_requests_total",
+		"Number of requests rejected by the rate limits (rate) or quotas (download or storage).",
+		"reason")
+
+	JobsFinished = Default.NewCounterVec("ipswd_jobs_finished_total",
+		"Number of finished background jobs by kind and state.",
+		"kind", "state")
+	JobDuration = Default.NewHistogramVec("ipswd_job_duration_seconds",
+		"Background job run time (of its last attempt) by kind.",
+		nil, "kind")
+	Jobs = Default.NewGaugeFunc("ipswd_jobs",
+		"Number of background jobs by state.",
+		[]string{"state"}, nil)
+	DownloadQueue = Default.NewGaugeFunc("ipswd_download_queue_items",
+		"Number of download queue items by status.",
+		[]string{"status"}, nil)
+	ScanQueue = Default.NewGaugeFunc("ipswd_scan_queue_items",
+		"Number of symbol scan queue items by status.",
+		[]string{"status"}, nil)
+)
+
+// Result is the result label of an operation that returned err
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,329 @@
+// Package metrics provides counters, gauges and histograms exposed in the Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets (in seconds)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry is a set of metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Default is the registry of the ipswd metrics
+var Default = NewRegistry()
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric '%s'", name))
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, strings.ReplaceAll(d.help, "\n", " "), d.name, d.typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelPairs formats the label pairs (with the extra pair appended if not empty)
+func (d *desc) labelPairs(values []string, extra ...string) string {
+	var pairs []string
+	for i, l := range d.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l, labelEscaper.Replace(values[i])))
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[0], extra[1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+const sep = "\xff"
+
+// vec is the set of a metric's children (one per label values)
+type vec[T any] struct {
+	desc
+	mu       sync.Mutex
+	children map[string]*T
+	values   map[string][]string
+	create   func() *T
+}
+
+func (v *vec[T]) with(values ...string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values (got %d)", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, sep)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c
+	}
+	c := v.create()
+	v.children[key] = c
+	v.values[key] = append([]string(nil), values...)
+	return c
+}
+
+// each calls fn for the children sorted by their label values
+func (v *vec[T]) each(fn func(values []string, c *T)) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.children))
+	for k := range v.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	children := make([]*T, len(keys))
+	values := make([][]string, len(keys))
+	for i, k := range keys {
+		children[i] = v.children[k]
+		values[i] = v.values[k]
+	}
+	v.mu.Unlock()
+	for i := range keys {
+		fn(values[i], children[i])
+	}
+}
+
+func newVec[T any](name, help, typ string, labels []string, create func() *T) *vec[T] {
+	return &vec[T]{
+		desc:     desc{name: name, help: help, typ: typ, labels: labels},
+		children: make(map[string]*T),
+		values:   make(map[string][]string),
+		create:   create,
+	}
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds a non-negative value
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.v += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	*vec[Counter]
+}
+
+// NewCounterVec registers a counter partitioned by the labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(name, cv)
+	return cv
+}
+
+// WithLabelValues returns the counter for the label values
+func (cv *CounterVec) WithLabelValues(values ...string) *Counter {
+	return cv.with(values...)
+}
+
+func (cv *CounterVec) write(w *bufio.Writer) {
+	cv.header(w)
+	cv.each(func(values []string, c *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", cv.name, cv.labelPairs(values), formatFloat(c.value()))
+	})
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds an observation
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince observes the seconds since start
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	*vec[Histogram]
+}
+
+// NewHistogramVec registers a histogram partitioned by the labels (with DefBuckets if buckets is nil)
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	hv := &HistogramVec{newVec(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	})}
+	r.register(name, hv)
+	return hv
+}
+
+// WithLabelValues returns the histogram for the label values
+func (hv *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return hv.with(values...)
+}
+
+func (hv *HistogramVec) write(w *bufio.Writer) {
+	hv.header(w)
+	hv.each(func(values []string, h *Histogram) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, hv.labelPairs(values, "le", formatFloat(b)), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, hv.labelPairs(values, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.name, hv.labelPairs(values), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.name, hv.labelPairs(values), h.count)
+	})
+}
+
+// GaugeFunc is a gauge whose values are collected when the metrics are scraped
+type GaugeFunc struct {
+	desc
+	mu      sync.Mutex
+	collect func(set func(v float64, values ...string))
+}
+
+// NewGaugeFunc registers a gauge partitioned by the labels whose values are set by collect on each scrape
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(set func(v float64, values ...string))) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, typ: "gauge", labels: labels}, collect: collect}
+	r.register(name, g)
+	return g
+}
+
+// SetCollector replaces the gauge's collect func (a nil collect reports no values)
+func (g *GaugeFunc) SetCollector(collect func(set func(v float64, values ...string))) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.collect = collect
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.mu.Lock()
+	collect := g.collect
+	g.mu.Unlock()
+	g.header(w)
+	if collect == nil {
+		return
+	}
+	collect(func(v float64, values ...string) {
+		if len(values) != len(g.labels) {
+			return
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(values), formatFloat(v))
+	})
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// AuditEntry is an API request recorded in the audit log.
+type AuditEntry struct {
+	ID uint      `gorm:"primaryKey" json:"id"`
+	At time.Time `gorm:"index" json:"at"`
+	// Identity is the name of the request's API key (or JWT subject); it is empty if auth is disabled
+	Identity string `gorm:"index" json:"identity,omitempty"`
+	Role     string `json:"role,omitempty"`
+	ClientIP string `json:"client_ip,omitempty"`
+	Method   string `json:"method"`
+	// Route is the matched route (i.e. /v1/syms/:uuid) and Path the requested path
+	Route string `gorm:"index" json:"route"`
+	Path  string `json:"path"`
+	Query string `json:"query,omitempty"`
+	// Inputs are the SHA256 digests of the request's input files by param (JSON)
+	Inputs     string `gorm:"type:text" json:"inputs,omitempty"`
+	Status     int    `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AuditQuery filters the audit log entries.
+type AuditQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Identity string
+	Route    string
+	// Limit is the maximum number of (the most recent) entries returned (all entries if 0)
+	Limit int
+}
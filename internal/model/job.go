@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// JobState is the state of a daemon job.
+type JobState string
+
+const (
+	JobPending  JobState = "pending"
+	JobRunning  JobState = "running"
+	JobDone     JobState = "done"
+	JobFailed   JobState = "failed"
+	JobCanceled JobState = "canceled"
+)
+
+// Job is a persisted daemon background job.
+type Job struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	Kind        string     `gorm:"index" json:"kind"`
+	Params      []byte     `json:"params,omitempty"` // JSON
+	State       JobState   `gorm:"index" json:"state"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	Result      []byte     `json:"result,omitempty"` // JSON
+	NextAttempt time.Time  `json:"next_attempt"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Worker is the ID of the worker the job is assigned to (in distributed mode)
+	Worker string `gorm:"index" json:"worker,omitempty"`
+	// Owner is the API key (or client IP) that submitted the job (empty for the daemon's own jobs)
+	Owner string `gorm:"index" json:"owner,omitempty"`
+	// CancelRequested asks the job's worker to cancel it (in distributed mode)
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+}
+
+// Worker is a daemon instance that runs the jobs of a distributed deployment.
+type Worker struct {
+	ID       string `gorm:"primaryKey" json:"id"`
+	Kinds    string `json:"kinds"` // comma separated (all kinds if empty)
+	Capacity int    `json:"capacity"`
+	// Running is the number of jobs the worker is running
+	Running   int       `json:"running"`
+	SeenAt    time.Time `gorm:"index" json:"seen_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// FirmwareKey is a device/build firmware file's decryption key.
+type FirmwareKey struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Device    string    `gorm:"uniqueIndex:idx_fwkey_device_build_file" json:"device"`
+	Build     string    `gorm:"uniqueIndex:idx_fwkey_device_build_file" json:"build"`
+	Filename  string    `gorm:"uniqueIndex:idx_fwkey_device_build_file" json:"filename"`
+	IV        string    `json:"iv,omitempty"`
+	Key       string    `json:"key,omitempty"`
+	KBag      string    `json:"kbag,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AEAKey is cached AEA key material.
+type AEAKey struct {
+	// Kind is 'fcs' for fcs-key private keys (PEM) or 'archive' for AEA symmetric keys (base64)
+	Kind string `gorm:"primaryKey" json:"kind"`
+	// Name is the fcs-key name or the AEA ID
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Value     string    `gorm:"type:text" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -30,6 +30,20 @@ type Ipsw struct {
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// Analysis is the result of a plugin analyzer run on an IPSW (or one of its MachOs) during a scan.
+type Analysis struct {
+	// ID is the hash of the IPSW, plugin, analyzer and path (so a rescan updates the result)
+	ID       string    `gorm:"primaryKey" json:"id"`
+	IpswID   string    `gorm:"index" json:"ipsw_id"`
+	Plugin   string    `json:"plugin"`
+	Analyzer string    `json:"analyzer"`
+	Path     string    `json:"path,omitempty"`
+	UUID     string    `json:"uuid,omitempty"`
+	Result   string    `gorm:"type:text" json:"result,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
 type Device struct {
 	Name string `gorm:"primaryKey" json:"name"`
 }
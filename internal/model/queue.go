@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// QueueStatus is the status of a download queue item.
+type QueueStatus string
+
+const (
+	QueuePending QueueStatus = "pending"
+	QueueRunning QueueStatus = "running"
+	QueueDone    QueueStatus = "done"
+	QueueFailed  QueueStatus = "failed"
+)
+
+// QueueItem is a device/build IPSW in the download queue.
+type QueueItem struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	Device      string      `gorm:"uniqueIndex:idx_queue_device_build" json:"device"`
+	Build       string      `gorm:"uniqueIndex:idx_queue_device_build" json:"build"`
+	Version     string      `json:"version,omitempty"`
+	Status      QueueStatus `gorm:"index" json:"status"`
+	Attempts    int         `json:"attempts"`
+	Error       string      `json:"error,omitempty"`
+	Path        string      `json:"path,omitempty"`
+	NextAttempt time.Time   `json:"next_attempt"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func (q QueueItem) String() string {
+	return q.Device + " " + q.Build
+}
+
+// ScanItem is an IPSW/OTA URL in the daemon's symbol scan queue.
+type ScanItem struct {
+	ID  uint   `gorm:"primaryKey" json:"id"`
+	URL string `gorm:"uniqueIndex" json:"url"`
+	// Priority orders the pending items (the highest priority items are scanned first)
+	Priority int         `gorm:"index" json:"priority"`
+	Status   QueueStatus `gorm:"index" json:"status"`
+	Attempts int         `json:"attempts"`
+	Error    string      `json:"error,omitempty"`
+	// IpswID is the SHA1 of the scanned IPSW
+	IpswID string `json:"ipsw_id,omitempty"`
+	// Skipped is set if the IPSW was already scanned
+	Skipped bool `json:"skipped,omitempty"`
+	// Owner is the API key (or client IP) that queued the item
+	Owner       string    `json:"owner,omitempty"`
+	NextAttempt time.Time `json:"next_attempt"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (s ScanItem) String() string {
+	return s.URL
+}
@@ -0,0 +1,233 @@
+// Package pipeline runs declarative (YAML) pipelines of daemon jobs, i.e. when a new build is found:
+// download it, extract its kernelcache and dyld_shared_cache, scan its symbols, dump its entitlements,
+// diff it against the previous build and publish a report
+//
+// A pipeline is a list of steps that each run a kind of daemon job (or publish a webhook report) with the
+// given params. The params' strings are Go text/templates rendered with the run's Context, so steps can use
+// the trigger's build, the results of the previous steps and the previous run of the pipeline:
+//
+//	name: ios
+//	on:
+//	  build:
+//	    devices: ["iPhone16,1"]
+//	steps:
+//	  - name: download
+//	    uses: download/ipsw
+//	    with:
+//	      device: "{{ .Build.Device }}"
+//	      build: "{{ .Build.Build }}"
+//	      output: /data/ipsws
+//	  - name: diff
+//	    uses: diff/ipsw
+//	    if: "{{ if .Previous }}true{{ end }}"
+//	    with:
+//	      old: "{{ .Previous.Steps.download.path }}"
+//	      new: "{{ .Steps.download.path }}"
+//	      output: /data/diffs
+//	  - name: report
+//	    uses: publish
+//	    with:
+//	      title: "{{ .Build.Device }} {{ .Build.Version }} ({{ .Build.Build }})"
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Publish is the step kind that posts the run's report to the webhooks
+const Publish = "publish"
+
+// Pipeline is a declarative list of steps run when its trigger fires
+type Pipeline struct {
+	Name  string  `yaml:"name" json:"name"`
+	On    Trigger `yaml:"on" json:"on"`
+	Steps []Step  `yaml:"steps" json:"steps"`
+	// File is the file the pipeline was loaded from
+	File string `yaml:"-" json:"file,omitempty"`
+}
+
+// Trigger is when a pipeline is run (pipelines can always be run from the API)
+type Trigger struct {
+	// Build runs the pipeline for the new builds found by the daemon's watcher
+	Build *BuildTrigger `yaml:"build,omitempty" json:"build,omitempty"`
+}
+
+// BuildTrigger matches the new builds found by the daemon's watcher
+type BuildTrigger struct {
+	// Devices are the devices to watch (their builds are watched even if they are not in the watch config)
+	Devices []string `yaml:"devices" json:"devices"`
+	// Sources are the sources of the builds (ota and/or ipsw, all sources if empty)
+	Sources []string `yaml:"sources,omitempty" json:"sources,omitempty"`
+}
+
+// Step is a daemon job of a pipeline
+type Step struct {
+	// Name is the step's ID in the templates (i.e. '.Steps.<name>' is its result)
+	Name string `yaml:"name" json:"name"`
+	// Uses is the kind of job to run (i.e. 'download/ipsw') or 'publish' to post a report to the webhooks
+	Uses string `yaml:"uses" json:"uses"`
+	// With are the job's params (strings are templates)
+	With map[string]any `yaml:"with,omitempty" json:"with,omitempty"`
+	// If is a template the step is skipped unless it renders 'true'
+	If string `yaml:"if,omitempty" json:"if,omitempty"`
+	// ContinueOnError records the step's error as its result instead of failing the run
+	ContinueOnError bool `yaml:"continue-on-error,omitempty" json:"continue_on_error,omitempty"`
+}
+
+// Matches returns true if the pipeline is triggered by a new build of the device from the source
+func (p *Pipeline) Matches(device, source string) bool {
+	if p.On.Build == nil || !slices.Contains(p.On.Build.Devices, device) {
+		return false
+	}
+	return len(p.On.Build.Sources) == 0 || slices.Contains(p.On.Build.Sources, source)
+}
+
+var funcs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"base":  filepath.Base,
+	"dir":   filepath.Dir,
+	"join":  strings.Join,
+}
+
+// render renders a template with the context
+func render(name, text string, ctx *Context) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseTemplates parses the templates in the value (to report their errors when the pipeline is loaded)
+func parseTemplates(name string, v any) error {
+	switch v := v.(type) {
+	case string:
+		_, err := template.New(name).Funcs(funcs).Parse(v)
+		return err
+	case map[string]any:
+		for k, e := range v {
+			if err := parseTemplates(name+"."+k, e); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, e := range v {
+			if err := parseTemplates(fmt.Sprintf("%s[%d]", name, i), e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the pipeline's steps are valid (and only use the given kinds of jobs)
+func (p *Pipeline) Validate(kinds []string) error {
+	if len(p.Name) == 0 {
+		return fmt.Errorf("pipeline has no name")
+	}
+	if p.On.Build != nil {
+		if len(p.On.Build.Devices) == 0 {
+			return fmt.Errorf("pipeline '%s': build trigger has no devices", p.Name)
+		}
+		for _, src := range p.On.Build.Sources {
+			if src != "ota" && src != "ipsw" {
+				return fmt.Errorf("pipeline '%s': invalid build source '%s' (must be one of: ota, ipsw)", p.Name, src)
+			}
+		}
+	}
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("pipeline '%s' has no steps", p.Name)
+	}
+	seen := make(map[string]bool)
+	for i, s := range p.Steps {
+		if len(s.Name) == 0 {
+			return fmt.Errorf("pipeline '%s': step %d has no name", p.Name, i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("pipeline '%s': duplicate step '%s'", p.Name, s.Name)
+		}
+		seen[s.Name] = true
+		if s.Uses != Publish && !slices.Contains(kinds, s.Uses) {
+			return fmt.Errorf("pipeline '%s': step '%s' uses unknown job kind '%s'", p.Name, s.Name, s.Uses)
+		}
+		if err := parseTemplates(s.Name+".if", s.If); err != nil {
+			return fmt.Errorf("pipeline '%s': step '%s': %w", p.Name, s.Name, err)
+		}
+		if err := parseTemplates(s.Name+".with", s.With); err != nil {
+			return fmt.Errorf("pipeline '%s': step '%s': %w", p.Name, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Parse parses a pipeline definition
+func Parse(dat []byte) (*Pipeline, error) {
+	var p Pipeline
+	dec := yaml.NewDecoder(bytes.NewReader(dat))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+	return &p, nil
+}
+
+// Load loads the pipelines from the files (or the *.yml/*.yaml files of the folders)
+func Load(paths ...string) ([]*Pipeline, error) {
+	var files []string
+	for _, path := range paths {
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			}
+			path = filepath.Join(home, path[2:])
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat pipelines %s: %w", path, err)
+		}
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+	var pipelines []*Pipeline
+	names := make(map[string]string)
+	for _, file := range files {
+		dat, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pipeline %s: %w", file, err)
+		}
+		p, err := Parse(dat)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if prev, ok := names[p.Name]; ok {
+			return nil, fmt.Errorf("%s: pipeline '%s' is already defined in %s", file, p.Name, prev)
+		}
+		names[p.Name] = file
+		p.File = file
+		pipelines = append(pipelines, p)
+	}
+	return pipelines, nil
+}
@@ -0,0 +1,286 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/jobs"
+)
+
+// Kind is the kind of job that runs a pipeline
+const Kind = "pipeline/run"
+
+// Context is the data the step templates are rendered with
+type Context struct {
+	// Pipeline is the pipeline's name
+	Pipeline string
+	// Build is the build the pipeline is run for
+	Build download.WatchBuild
+	// Steps are the results of the steps that already ran (by step name, nil if the step was skipped)
+	Steps map[string]any
+	// Previous is the last successful run of the pipeline for another build of the device (nil if there is none)
+	Previous *Run
+}
+
+// Run is the result of a pipeline run
+type Run struct {
+	Pipeline string              `json:"pipeline"`
+	Build    download.WatchBuild `json:"build"`
+	Steps    map[string]any      `json:"steps"`
+	Skipped  []string            `json:"skipped,omitempty"`
+}
+
+// Report is the payload of the webhook event posted by a publish step
+type Report struct {
+	Run
+	// Data are the publish step's params
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Params are the params of a pipeline run job
+type Params struct {
+	Pipeline string              `json:"pipeline"`
+	Build    download.WatchBuild `json:"build"`
+}
+
+// Runner runs the pipelines as daemon jobs
+type Runner struct {
+	pipelines map[string]*Pipeline
+	jobs      *jobs.Manager
+	publish   func(report Report)
+}
+
+// NewRunner validates the pipelines against the manager's kinds of jobs and registers the pipeline run kind
+// (call it after registering the other kinds; the publish func posts the reports of the publish steps)
+func NewRunner(pipelines []*Pipeline, m *jobs.Manager, publish func(report Report)) (*Runner, error) {
+	r := &Runner{
+		pipelines: make(map[string]*Pipeline),
+		jobs:      m,
+		publish:   publish,
+	}
+	kinds := m.Kinds()
+	for _, p := range pipelines {
+		if err := p.Validate(kinds); err != nil {
+			return nil, err
+		}
+		if _, ok := r.pipelines[p.Name]; ok {
+			return nil, fmt.Errorf("pipeline '%s' is defined more than once", p.Name)
+		}
+		r.pipelines[p.Name] = p
+	}
+	m.Register(Kind, func(ctx context.Context, j *jobs.Job, raw json.RawMessage) (any, error) {
+		var params Params
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, fmt.Errorf("failed to decode pipeline params: %w", err)
+		}
+		return r.run(ctx, j, params)
+	})
+	return r, nil
+}
+
+// Pipelines returns the pipelines (sorted by name)
+func (r *Runner) Pipelines() []*Pipeline {
+	list := make([]*Pipeline, 0, len(r.pipelines))
+	for _, p := range r.pipelines {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].Name < list[k].Name
+	})
+	return list
+}
+
+// Get returns a pipeline by name
+func (r *Runner) Get(name string) (*Pipeline, bool) {
+	p, ok := r.pipelines[name]
+	return p, ok
+}
+
+// Devices returns the devices the pipelines' build triggers watch
+func (r *Runner) Devices() []string {
+	var devices []string
+	for _, p := range r.pipelines {
+		if p.On.Build == nil {
+			continue
+		}
+		for _, d := range p.On.Build.Devices {
+			if !slices.Contains(devices, d) {
+				devices = append(devices, d)
+			}
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// Submit queues a run of the pipeline for the build
+func (r *Runner) Submit(name string, build download.WatchBuild) (*jobs.Job, error) {
+	if _, ok := r.pipelines[name]; !ok {
+		return nil, fmt.Errorf("unknown pipeline '%s'", name)
+	}
+	return r.jobs.Submit(Kind, Params{Pipeline: name, Build: build})
+}
+
+// Trigger queues the runs of the pipelines triggered by the new build
+func (r *Runner) Trigger(build download.WatchBuild) {
+	for _, p := range r.Pipelines() {
+		if !p.Matches(build.Device, build.Source) {
+			continue
+		}
+		j, err := r.Submit(p.Name, build)
+		if err != nil {
+			log.WithError(err).WithField("pipeline", p.Name).Error("failed to run pipeline")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"pipeline": p.Name,
+			"device":   build.Device,
+			"build":    build.Build,
+			"job":      j.ID(),
+		}).Info("Running pipeline")
+	}
+}
+
+// previous returns the last successful run of the pipeline for another build of the device
+func (r *Runner) previous(name string, build download.WatchBuild) (*Run, error) {
+	history, err := r.jobs.History(Kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range history {
+		var run Run
+		if err := json.Unmarshal(st.Result, &run); err != nil {
+			continue
+		}
+		if run.Pipeline == name && run.Build.Device == build.Device && run.Build.Build != build.Build {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+// expand renders the templates in the value
+func expand(name string, v any, ctx *Context) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return render(name, v, ctx)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			x, err := expand(name+"."+k, e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = x
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			x, err := expand(fmt.Sprintf("%s[%d]", name, i), e, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// run runs the pipeline's steps in order (the steps run as part of the pipeline's job)
+func (r *Runner) run(ctx context.Context, j *jobs.Job, params Params) (any, error) {
+	p, ok := r.pipelines[params.Pipeline]
+	if !ok {
+		return nil, fmt.Errorf("unknown pipeline '%s'", params.Pipeline)
+	}
+	if params.Build.Found.IsZero() {
+		params.Build.Found = time.Now()
+	}
+	prev, err := r.previous(p.Name, params.Build)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the previous run: %w", err)
+	}
+	tctx := &Context{
+		Pipeline: p.Name,
+		Build:    params.Build,
+		Steps:    make(map[string]any),
+		Previous: prev,
+	}
+	run := &Run{Pipeline: p.Name, Build: params.Build, Steps: tctx.Steps}
+
+	for i, step := range p.Steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(step.If) > 0 {
+			cond, err := render(step.Name+".if", step.If, tctx)
+			if err != nil {
+				return nil, fmt.Errorf("step '%s': failed to render if: %w", step.Name, err)
+			}
+			if strings.TrimSpace(cond) != "true" {
+				j.Logf("Skipping step %d/%d: %s", i+1, len(p.Steps), step.Name)
+				run.Skipped = append(run.Skipped, step.Name)
+				// so the later steps can test for it (missing keys are errors)
+				tctx.Steps[step.Name] = nil
+				continue
+			}
+		}
+		j.Logf("Running step %d/%d: %s (%s)", i+1, len(p.Steps), step.Name, step.Uses)
+		result, err := r.step(ctx, j, step, tctx, run)
+		if err != nil {
+			if !step.ContinueOnError || ctx.Err() != nil {
+				return nil, fmt.Errorf("step '%s' failed: %w", step.Name, err)
+			}
+			j.Logf("Step %s failed (continuing): %v", step.Name, err)
+			result = map[string]any{"error": err.Error()}
+		}
+		tctx.Steps[step.Name] = result
+	}
+	return run, nil
+}
+
+// step runs a step and returns its result decoded from JSON (so the templates can use its JSON field names)
+func (r *Runner) step(ctx context.Context, j *jobs.Job, step Step, tctx *Context, run *Run) (any, error) {
+	with, err := expand(step.Name+".with", step.With, tctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render params: %w", err)
+	}
+	if step.Uses == Publish {
+		data, _ := with.(map[string]any)
+		r.publish(Report{Run: *run, Data: data})
+		return data, nil
+	}
+	h, ok := r.jobs.Handler(step.Uses)
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind '%s'", step.Uses)
+	}
+	raw, err := json.Marshal(with)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode params: %w", err)
+	}
+	result, err := h(ctx, j, raw)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	dat, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(dat, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return decoded, nil
+}
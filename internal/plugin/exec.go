@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// APIVersion is the version of the plugin protocol (passed to the plugins as IPSW_PLUGIN_API)
+const APIVersion = "1"
+
+// Input is the JSON an analyzer reads on stdin
+type Input struct {
+	// Analyzer is the name of the analyzer to run
+	Analyzer string `json:"analyzer"`
+	IPSW     *IPSW  `json:"ipsw,omitempty"`
+	// Macho is the MachO to analyze (only set for the macho scope)
+	Macho *Macho `json:"macho,omitempty"`
+}
+
+// IPSW is the IPSW being scanned
+type IPSW struct {
+	// Path is the local path of the IPSW
+	Path    string   `json:"path"`
+	SHA1    string   `json:"sha1,omitempty"`
+	Version string   `json:"version,omitempty"`
+	Build   string   `json:"build,omitempty"`
+	Devices []string `json:"devices,omitempty"`
+}
+
+// Macho is the MachO being analyzed
+type Macho struct {
+	// Path is the MachO's path in the IPSW's filesystem
+	Path string `json:"path"`
+	// File is the local path of the MachO (it is only readable while the analyzer runs)
+	File string `json:"file"`
+	UUID string `json:"uuid,omitempty"`
+}
+
+func (p *Plugin) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, p.Exec, args...)
+	cmd.Dir = p.Dir
+	cmd.Env = append(os.Environ(),
+		"IPSW_PLUGIN_API="+APIVersion,
+		"IPSW_PLUGIN_DIR="+p.Dir,
+	)
+	return cmd
+}
+
+// Analyze runs the analyzer on the input and returns its JSON result
+func (a *Analyzer) Analyze(ctx context.Context, in *Input) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	in.Analyzer = a.Name
+	dat, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin input: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := a.plugin.command(ctx, "analyze", a.Name)
+	cmd.Stdin = bytes.NewReader(dat)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("plugin '%s' analyzer '%s' timed out after %s", a.plugin.Name, a.Name, a.Timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return nil, fmt.Errorf("plugin '%s' analyzer '%s' failed: %w: %s", a.plugin.Name, a.Name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin '%s' analyzer '%s' failed: %w", a.plugin.Name, a.Name, err)
+	}
+	if stderr.Len() > 0 {
+		log.WithFields(log.Fields{
+			"plugin":   a.plugin.Name,
+			"analyzer": a.Name,
+		}).Debug(strings.TrimSpace(stderr.String()))
+	}
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) == 0 {
+		return nil, nil // nothing to report
+	}
+	if !json.Valid(out) {
+		return nil, fmt.Errorf("plugin '%s' analyzer '%s' wrote invalid JSON", a.plugin.Name, a.Name)
+	}
+	return json.RawMessage(out), nil
+}
+
+// Run runs the plugin's command with the args
+func (p *Plugin) Run(ctx context.Context, command string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cmd := p.command(ctx, append([]string{"command", command}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
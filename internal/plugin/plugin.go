@@ -0,0 +1,274 @@
+// Package plugin loads the exec-based ipsw plugins, so downstream teams can add (proprietary) analyzers
+// and CLI subcommands without forking ipsw
+//
+// A plugin is a folder with a plugin.yml manifest and an executable:
+//
+//	name: acme
+//	version: 1.0.0
+//	description: ACME's analyzers
+//	exec: ./acme # relative to the plugin's folder
+//	analyzers:
+//	  - name: secrets
+//	    scope: macho # run on each MachO of the scanned IPSWs (or 'ipsw' to run once per IPSW)
+//	    paths: ["/usr/libexec/*", "/System/Library/PrivateFrameworks/*"]
+//	    timeout: 30s
+//	commands:
+//	  - name: acme-report
+//	    short: Generate ACME's report
+//
+// An analyzer is run as '<exec> analyze <analyzer>' with the JSON Input on stdin and must write its (JSON)
+// result to stdout. A command is run as '<exec> command <command> [args...]' with the terminal's stdio.
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the name of the plugin manifest file in a plugin's folder
+const Manifest = "plugin.yml"
+
+// DefaultTimeout is how long an analyzer can run if its manifest doesn't set a timeout
+const DefaultTimeout = 5 * time.Minute
+
+// Scope is what an analyzer is run on
+type Scope string
+
+const (
+	// ScopeMacho analyzers are run on each MachO of the scanned IPSWs
+	ScopeMacho Scope = "macho"
+	// ScopeIPSW analyzers are run once per scanned IPSW
+	ScopeIPSW Scope = "ipsw"
+)
+
+// Plugin is a loaded plugin
+type Plugin struct {
+	Name        string     `yaml:"name" json:"name"`
+	Version     string     `yaml:"version,omitempty" json:"version,omitempty"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+	Exec        string     `yaml:"exec" json:"exec"`
+	Analyzers   []Analyzer `yaml:"analyzers,omitempty" json:"analyzers,omitempty"`
+	Commands    []Command  `yaml:"commands,omitempty" json:"commands,omitempty"`
+	// Dir is the folder the plugin was loaded from
+	Dir string `yaml:"-" json:"dir"`
+}
+
+// Analyzer is an analysis step of a plugin run during the symbol scans
+type Analyzer struct {
+	Name  string `yaml:"name" json:"name"`
+	Scope Scope  `yaml:"scope" json:"scope"`
+	// Paths are the globs of the MachO paths the analyzer is run on (all MachOs if empty)
+	Paths []string `yaml:"paths,omitempty" json:"paths,omitempty"`
+	// Timeout is how long the analyzer can run (defaults to DefaultTimeout)
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	plugin *Plugin
+}
+
+// Plugin returns the plugin of the analyzer
+func (a *Analyzer) Plugin() *Plugin {
+	return a.plugin
+}
+
+// Matches returns true if the analyzer is run on the MachO path
+func (a *Analyzer) Matches(path string) bool {
+	if len(a.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range a.Paths {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Command is a CLI subcommand of a plugin
+type Command struct {
+	Name  string `yaml:"name" json:"name"`
+	Short string `yaml:"short,omitempty" json:"short,omitempty"`
+	Long  string `yaml:"long,omitempty" json:"long,omitempty"`
+}
+
+// Validate checks the plugin's manifest
+func (p *Plugin) Validate() error {
+	if len(p.Name) == 0 {
+		return fmt.Errorf("plugin has no name")
+	}
+	if len(p.Exec) == 0 {
+		return fmt.Errorf("plugin '%s' has no exec", p.Name)
+	}
+	if len(p.Analyzers) == 0 && len(p.Commands) == 0 {
+		return fmt.Errorf("plugin '%s' has no analyzers or commands", p.Name)
+	}
+	seen := make(map[string]bool)
+	for i, a := range p.Analyzers {
+		if len(a.Name) == 0 {
+			return fmt.Errorf("plugin '%s': analyzer %d has no name", p.Name, i+1)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("plugin '%s': duplicate analyzer '%s'", p.Name, a.Name)
+		}
+		seen[a.Name] = true
+		if a.Scope != ScopeMacho && a.Scope != ScopeIPSW {
+			return fmt.Errorf("plugin '%s': analyzer '%s' has invalid scope '%s' (must be one of: macho, ipsw)", p.Name, a.Name, a.Scope)
+		}
+		for _, pattern := range a.Paths {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("plugin '%s': analyzer '%s' has invalid path glob '%s': %w", p.Name, a.Name, pattern, err)
+			}
+		}
+	}
+	clear(seen)
+	for i, c := range p.Commands {
+		if len(c.Name) == 0 {
+			return fmt.Errorf("plugin '%s': command %d has no name", p.Name, i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("plugin '%s': duplicate command '%s'", p.Name, c.Name)
+		}
+		seen[c.Name] = true
+	}
+	return nil
+}
+
+// Parse parses a plugin manifest
+func Parse(dat []byte) (*Plugin, error) {
+	var p Plugin
+	dec := yaml.NewDecoder(bytes.NewReader(dat))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin manifest: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	for i := range p.Analyzers {
+		if p.Analyzers[i].Timeout == 0 {
+			p.Analyzers[i].Timeout = DefaultTimeout
+		}
+		p.Analyzers[i].plugin = &p
+	}
+	return &p, nil
+}
+
+// Open loads the plugin in the folder
+func Open(dir string) (*Plugin, error) {
+	dat, err := os.ReadFile(filepath.Join(dir, Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
+	p, err := Parse(dat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	p.Dir = dir
+	if !filepath.IsAbs(p.Exec) {
+		p.Exec = filepath.Join(dir, p.Exec)
+	}
+	if _, err := os.Stat(p.Exec); err != nil {
+		return nil, fmt.Errorf("%s: plugin '%s' exec not found: %w", dir, p.Name, err)
+	}
+	return p, nil
+}
+
+// DefaultDirs returns the folders the plugins are loaded from if none are given:
+// the IPSW_PLUGINS_PATH folders (separated like PATH) or ~/.config/ipsw/plugins
+func DefaultDirs() []string {
+	if path := os.Getenv("IPSW_PLUGINS_PATH"); len(path) > 0 {
+		return filepath.SplitList(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".config", "ipsw", "plugins")}
+}
+
+// Load loads the plugins from the folders (each one is a plugin folder or a folder of plugin folders).
+// Missing folders are skipped
+func Load(dirs ...string) ([]*Plugin, error) {
+	var plugins []*Plugin
+	names := make(map[string]string)
+	add := func(dir string) error {
+		p, err := Open(dir)
+		if err != nil {
+			return err
+		}
+		if prev, ok := names[p.Name]; ok {
+			return fmt.Errorf("%s: plugin '%s' is already loaded from %s", dir, p.Name, prev)
+		}
+		names[p.Name] = dir
+		plugins = append(plugins, p)
+		return nil
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			}
+			dir = filepath.Join(home, dir[2:])
+		}
+		if _, err := os.Stat(filepath.Join(dir, Manifest)); err == nil {
+			if err := add(dir); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read plugins folder %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, e.Name(), Manifest)); err != nil {
+				continue
+			}
+			if err := add(filepath.Join(dir, e.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plugins, nil
+}
+
+// Analyzers returns the analyzers of the plugins with the scope
+func Analyzers(plugins []*Plugin, scope Scope) []*Analyzer {
+	var analyzers []*Analyzer
+	for _, p := range plugins {
+		for i := range p.Analyzers {
+			if p.Analyzers[i].Scope == scope {
+				analyzers = append(analyzers, &p.Analyzers[i])
+			}
+		}
+	}
+	return analyzers
+}
+
+// Find returns the plugin's analyzer
+func Find(plugins []*Plugin, plugin, analyzer string) (*Analyzer, error) {
+	idx := slices.IndexFunc(plugins, func(p *Plugin) bool { return p.Name == plugin })
+	if idx < 0 {
+		return nil, fmt.Errorf("plugin '%s' not found", plugin)
+	}
+	p := plugins[idx]
+	for i := range p.Analyzers {
+		if p.Analyzers[i].Name == analyzer {
+			return &p.Analyzers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("plugin '%s' has no analyzer '%s'", plugin, analyzer)
+}
@@ -122,6 +122,14 @@ func scanDmg(ipswPath, dmgPath, dmgType, pemDB string, handler func(string, stri
 
 // ForEachMachoInIPSW walks the IPSW and calls the handler for each macho file found
 func ForEachMachoInIPSW(ipswPath, pemDbPath string, handler func(string, *macho.File) error) error {
+	return ForEachMachoFileInIPSW(ipswPath, pemDbPath, func(path, _ string, m *macho.File) error {
+		return handler(path, m)
+	})
+}
+
+// ForEachMachoFileInIPSW walks the IPSW and calls the handler for each macho file found
+// with its path in the IPSW and its local path (in the mounted DMG)
+func ForEachMachoFileInIPSW(ipswPath, pemDbPath string, handler func(path, file string, m *macho.File) error) error {
 	scanMacho := func(mountPoint, machoPath string) error {
 		if ok, _ := magic.IsMachO(machoPath); ok {
 			var m *macho.File
@@ -140,10 +148,11 @@ func ForEachMachoInIPSW(ipswPath, pemDbPath string, handler func(string, *macho.
 					return nil
 				}
 			}
+			file := machoPath
 			if _, rest, ok := strings.Cut(machoPath, mountPoint); ok {
 				machoPath = rest
 			}
-			if err := handler(machoPath, m); err != nil {
+			if err := handler(machoPath, file, m); err != nil {
 				return fmt.Errorf("failed to handle macho %s: %w", machoPath, err)
 			}
 		}
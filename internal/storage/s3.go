@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPutSize is the largest object a single S3 PUT can upload
+	maxPutSize      = 5 << 30
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptySHA256     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3 stores the artifacts in an S3 (compatible) bucket
+type S3 struct {
+	conf   Config
+	base   *url.URL
+	client *http.Client
+}
+
+// NewS3 creates an S3 backend
+func NewS3(conf Config) (*S3, error) {
+	if len(conf.Bucket) == 0 {
+		return nil, fmt.Errorf("%s storage requires a bucket", conf.Driver)
+	}
+	if conf.Region == "" {
+		conf.Region = "us-east-1"
+	}
+	if conf.AccessKey == "" {
+		conf.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if conf.SecretKey == "" {
+		conf.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if conf.AccessKey == "" || conf.SecretKey == "" {
+		return nil, fmt.Errorf("%s storage requires an access key and secret key", conf.Driver)
+	}
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", conf.Region)
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid storage endpoint '%s'", endpoint)
+	}
+	if conf.PathStyle {
+		base.Path += "/" + conf.Bucket
+	} else {
+		base.Host = conf.Bucket + "." + base.Host
+	}
+	conf.Prefix = strings.Trim(conf.Prefix, "/")
+	return &S3{conf: conf, base: base, client: &http.Client{}}, nil
+}
+
+func (s *S3) objectKey(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if len(s.conf.Prefix) > 0 {
+		key = s.conf.Prefix + "/" + key
+	}
+	return key, nil
+}
+
+// uriEncode encodes a string as required by AWS Signature Version 4
+func uriEncode(s string, slash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !slash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// canonicalQuery encodes the query sorted by key as required by AWS Signature Version 4
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sign signs the request with AWS Signature Version 4
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	date := now.UTC().Format("20060102")
+	stamp := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-length" {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.conf.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.conf.SecretKey), date)
+	key = hmacSHA256(key, s.conf.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.conf.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3Error is an S3 error response
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	u := *s.base
+	if len(key) > 0 {
+		u.Path += "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	if body != nil && size == 0 {
+		body = http.NoBody // otherwise an empty body is sent chunked
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	payloadHash := emptySHA256
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		payloadHash = unsignedPayload
+	}
+	s.sign(req, payloadHash, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+	if resp.StatusCode == http.StatusNotFound && len(key) > 0 {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		dat, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		var serr s3Error
+		if xml.Unmarshal(dat, &serr) == nil && len(serr.Code) > 0 {
+			return nil, fmt.Errorf("%s %s failed: %s: %s", method, u.Path, serr.Code, serr.Message)
+		}
+		return nil, fmt.Errorf("%s %s failed: %s", method, u.Path, resp.Status)
+	}
+	return resp, nil
+}
+
+// Put uploads the object
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size > maxPutSize {
+		return fmt.Errorf("%s is too large for a single %s upload (%d bytes)", key, s.conf.Driver, size)
+	}
+	okey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, okey, nil, r, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	okey, err := s.objectKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, okey, nil, nil, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, _ = CleanKey(key)
+	obj := &Object{Key: key, Size: resp.ContentLength}
+	if mod, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.Modified = mod
+	}
+	return resp.Body, obj, nil
+}
+
+// Delete deletes the object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	okey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	// S3 doesn't report deleting a missing object as an error
+	resp, err := s.do(ctx, http.MethodHead, okey, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	resp, err = s.do(ctx, http.MethodDelete, okey, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists the objects with ListObjectsV2
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	base := ""
+	if len(s.conf.Prefix) > 0 {
+		base = s.conf.Prefix + "/"
+	}
+	var objs []Object
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {base + strings.TrimPrefix(prefix, "/")}}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode list response: %w", err)
+		}
+		for _, c := range res.Contents {
+			objs = append(objs, Object{Key: strings.TrimPrefix(c.Key, base), Size: c.Size, Modified: c.LastModified})
+		}
+		if !res.IsTruncated || len(res.NextContinuationToken) == 0 {
+			return objs, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
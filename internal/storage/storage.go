@@ -0,0 +1,128 @@
+// Package storage provides the artifact storage backends (local disk, S3 and GCS) of the daemon
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object is a stored artifact
+type Object struct {
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Backend stores artifacts by key (keys are slash separated paths)
+type Backend interface {
+	// Put stores size bytes read from r as the object key (replacing it if it exists)
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns the object's contents (the caller must close it)
+	// It returns ErrNotFound if the object does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes the object
+	// It returns ErrNotFound if the object does not exist.
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Config is the storage config
+type Config struct {
+	// Driver is the backend: local, s3 or gcs (storage is disabled if empty)
+	Driver string `json:"driver"`
+	// Path is the local backend's root folder
+	Path string `json:"path"`
+	// Bucket is the S3/GCS bucket
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to the S3/GCS object keys
+	Prefix string `json:"prefix"`
+	// Region is the S3 region (defaults to us-east-1)
+	Region string `json:"region"`
+	// Endpoint is the S3 compatible endpoint (i.e. for MinIO or R2)
+	Endpoint string `json:"endpoint"`
+	// AccessKey and SecretKey are the S3 credentials or the GCS HMAC key
+	// (they default to the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY env vars)
+	AccessKey string `json:"access_key" mapstructure:"access-key"`
+	SecretKey string `json:"secret_key" mapstructure:"secret-key"`
+	// PathStyle uses path style (endpoint/bucket/key) instead of virtual-hosted style S3 URLs
+	PathStyle bool `json:"path_style" mapstructure:"path-style"`
+	// Retention is how long artifacts are kept (forever if 0)
+	Retention time.Duration `json:"retention"`
+}
+
+// New creates the configured backend (it returns nil if storage is disabled)
+func New(conf Config) (Backend, error) {
+	switch conf.Driver {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocal(conf.Path)
+	case "s3":
+		return NewS3(conf)
+	case "gcs":
+		// GCS is accessed with its S3 compatible XML API (with an HMAC key)
+		if conf.Endpoint == "" {
+			conf.Endpoint = "https://storage.googleapis.com"
+		}
+		if conf.Region == "" {
+			conf.Region = "auto"
+		}
+		conf.PathStyle = true
+		return NewS3(conf)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver '%s' (must be one of: local, s3, gcs)", conf.Driver)
+	}
+}
+
+// CleanKey validates and normalizes an object key
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if len(key) == 0 || key == "." {
+		return "", fmt.Errorf("invalid object key")
+	}
+	return key, nil
+}
+
+// PutFile stores a file as the object key
+func PutFile(ctx context.Context, b Backend, key, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	return b.Put(ctx, key, f, fi.Size())
+}
+
+// Prune deletes the objects that were modified before the retention period and returns how many were deleted
+func Prune(ctx context.Context, b Backend, retention time.Duration) (int, error) {
+	objs, err := b.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-retention)
+	var deleted int
+	for _, obj := range objs {
+		if !obj.Modified.Before(cutoff) {
+			continue
+		}
+		if err := b.Delete(ctx, obj.Key); err != nil && !errors.Is(err, ErrNotFound) {
+			return deleted, fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,89 @@
+package syms
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/plugin"
+)
+
+// analyzers runs the plugin analyzers during a scan and collects their results
+type analyzers struct {
+	ipsw    *model.Ipsw
+	in      *plugin.IPSW
+	perIPSW []*plugin.Analyzer
+	perFile []*plugin.Analyzer
+	results []*model.Analysis
+}
+
+func newAnalyzers(ipsw *model.Ipsw, ipswPath string, plugins []*plugin.Plugin) *analyzers {
+	in := &plugin.IPSW{
+		Path:    ipswPath,
+		SHA1:    ipsw.ID,
+		Version: ipsw.Version,
+		Build:   ipsw.BuildID,
+	}
+	for _, dev := range ipsw.Devices {
+		in.Devices = append(in.Devices, dev.Name)
+	}
+	return &analyzers{
+		ipsw:    ipsw,
+		in:      in,
+		perIPSW: plugin.Analyzers(plugins, plugin.ScopeIPSW),
+		perFile: plugin.Analyzers(plugins, plugin.ScopeMacho),
+	}
+}
+
+// run runs the analyzer (a failed analyzer is recorded in its result instead of failing the scan)
+func (a *analyzers) run(an *plugin.Analyzer, in *plugin.Input) {
+	res := &model.Analysis{
+		IpswID:   a.ipsw.ID,
+		Plugin:   an.Plugin().Name,
+		Analyzer: an.Name,
+		At:       time.Now(),
+	}
+	if in.Macho != nil {
+		res.Path = in.Macho.Path
+		res.UUID = in.Macho.UUID
+	}
+	h := sha1.Sum([]byte(res.IpswID + "\x00" + res.Plugin + "\x00" + res.Analyzer + "\x00" + res.Path))
+	res.ID = hex.EncodeToString(h[:])
+	out, err := an.Analyze(context.Background(), in)
+	if err != nil {
+		log.WithError(err).WithField("path", res.Path).Warn("Plugin analyzer failed")
+		res.Error = err.Error()
+	} else if out == nil {
+		return // nothing to report
+	} else {
+		res.Result = string(out)
+	}
+	a.results = append(a.results, res)
+}
+
+// runIPSW runs the analyzers with the ipsw scope
+func (a *analyzers) runIPSW() {
+	for _, an := range a.perIPSW {
+		a.run(an, &plugin.Input{IPSW: a.in})
+	}
+}
+
+// runMacho runs the analyzers with the macho scope that match the MachO's path
+func (a *analyzers) runMacho(path, file, uuid string) {
+	for _, an := range a.perFile {
+		if an.Matches(path) {
+			a.run(an, &plugin.Input{
+				IPSW:  a.in,
+				Macho: &plugin.Macho{Path: path, File: file, UUID: uuid},
+			})
+		}
+	}
+}
+
+func (a *analyzers) save(db db.Database) error {
+	return db.SaveAnalyses(a.results...)
+}
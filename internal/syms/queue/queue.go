@@ -0,0 +1,272 @@
+// Package queue feeds the symbol server: it downloads and scans the IPSW/OTA URLs in the persistent
+// symbol scan queue (highest priority first) with a pool of background workers
+//
+// The URLs are deduplicated when they are queued, the IPSWs that were already scanned are skipped and the
+// scans reuse the DSCs and MachOs whose UUIDs were already scanned.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/db"
+	"github.com/blacktop/ipsw/internal/download"
+	"github.com/blacktop/ipsw/internal/metrics"
+	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/plugin"
+	"github.com/blacktop/ipsw/internal/syms"
+	"github.com/blacktop/ipsw/internal/utils"
+)
+
+const (
+	// pollInterval is how often idle workers check for items that are ready (or queued by other daemons)
+	pollInterval = 30 * time.Second
+
+	defaultRetries = 3
+	defaultBackoff = time.Minute
+)
+
+// Config is the symbol scan queue worker pool config
+type Config struct {
+	// Workers is the number of parallel scans (defaults to 1, the queue is not processed if it is negative)
+	Workers int `json:"workers"`
+	// Retries is the number of attempts per item before it is marked as failed (defaults to 3)
+	Retries int `json:"retries"`
+	// Backoff is the delay before the first retry (it doubles with each attempt, defaults to 1m)
+	Backoff time.Duration `json:"backoff"`
+	// Output is the folder the IPSWs are downloaded to (defaults to the temp folder)
+	Output string `json:"output"`
+	// Keep keeps the downloaded IPSWs after they are scanned
+	Keep     bool   `json:"keep"`
+	Proxy    string `json:"proxy"`
+	Insecure bool   `json:"insecure"`
+}
+
+// Feeder processes the symbol scan queue
+type Feeder struct {
+	conf    Config
+	db      db.Database
+	pemDB   string
+	sigsDir string
+	plugins []*plugin.Plugin
+	wake    chan struct{}
+
+	// Downloaded is called with the bytes downloaded for an item (i.e. to count them against its owner's quota),
+	// the download is stopped if it returns an error
+	Downloaded func(item *model.ScanItem, n uint64) error
+}
+
+// New creates a feeder of the symbol server in d
+func New(conf Config, d db.Database, pemDB, sigsDir string, plugins []*plugin.Plugin) *Feeder {
+	if conf.Workers == 0 {
+		conf.Workers = 1
+	}
+	if conf.Retries < 1 {
+		conf.Retries = defaultRetries
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = defaultBackoff
+	}
+	if len(conf.Output) == 0 {
+		conf.Output = filepath.Join(os.TempDir(), "ipswd-scans")
+	}
+	return &Feeder{
+		conf:    conf,
+		db:      d,
+		pemDB:   pemDB,
+		sigsDir: sigsDir,
+		plugins: plugins,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// validURL checks the URL is an http(s) URL of an IPSW or OTA
+func validURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid URL '%s': %v", u, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid URL '%s': scheme must be http or https", u)
+	}
+	switch strings.ToLower(path.Ext(parsed.Path)) {
+	case ".ipsw", ".zip":
+		return nil
+	default:
+		return fmt.Errorf("invalid URL '%s': must be an .ipsw or OTA .zip", u)
+	}
+}
+
+// Enqueue adds the URLs to the queue with the priority on behalf of the owner (the URLs that are
+// already queued are not duplicated) and wakes up an idle worker
+func (f *Feeder) Enqueue(owner string, priority int, urls ...string) ([]*model.ScanItem, error) {
+	items := make([]*model.ScanItem, 0, len(urls))
+	for _, u := range urls {
+		if err := validURL(u); err != nil {
+			return nil, err
+		}
+		items = append(items, &model.ScanItem{URL: u, Priority: priority, Owner: owner})
+	}
+	if err := f.db.EnqueueScans(items...); err != nil {
+		return nil, fmt.Errorf("failed to enqueue: %v", err)
+	}
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+	return items, nil
+}
+
+// Run processes the queue with the pool of workers until ctx is canceled
+func (f *Feeder) Run(ctx context.Context) error {
+	if f.conf.Workers < 0 {
+		return nil
+	}
+	// requeue the items of an interrupted run
+	running, err := f.db.GetScanQueue(model.QueueRunning)
+	if err != nil {
+		return fmt.Errorf("failed to get running scan queue items: %v", err)
+	}
+	for _, item := range running {
+		item.Status = model.QueuePending
+		if err := f.db.Save(item); err != nil {
+			return fmt.Errorf("failed to requeue %s: %v", item, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, f.conf.Workers)
+	for i := range f.conf.Workers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f.worker(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (f *Feeder) worker(ctx context.Context) error {
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		item, err := f.db.NextScanItem()
+		if errors.Is(err, model.ErrNotFound) {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-f.wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get next scan queue item: %v", err)
+		}
+
+		item.Attempts++
+		start := time.Now()
+		err = f.scan(ctx, item)
+		if !item.Skipped {
+			metrics.ScanDuration.WithLabelValues(metrics.Result(err)).ObserveSince(start)
+		}
+		if err != nil {
+			item.Error = err.Error()
+			if item.Attempts >= f.conf.Retries {
+				item.Status = model.QueueFailed
+				log.WithError(err).WithField("attempts", item.Attempts).Errorf("Failed to scan %s", item)
+			} else {
+				item.Status = model.QueuePending
+				item.NextAttempt = time.Now().Add(f.conf.Backoff << (item.Attempts - 1))
+				log.WithError(err).WithField("retry", item.NextAttempt.Format(time.Kitchen)).Warnf("Failed to scan %s", item)
+			}
+		} else {
+			item.Status = model.QueueDone
+			item.Error = ""
+		}
+		if err := f.db.Save(item); err != nil {
+			return fmt.Errorf("failed to update scan queue item %s: %v", item, err)
+		}
+	}
+}
+
+// scan downloads and scans the item's IPSW (skipping it if it was already scanned)
+func (f *Feeder) scan(ctx context.Context, item *model.ScanItem) error {
+	name := path.Base(item.URL)
+	if u, err := url.Parse(item.URL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if ipsw, err := f.db.GetIpswByName(name); err == nil {
+		log.WithField("url", item.URL).Info("Skipping already scanned IPSW")
+		item.IpswID, item.Skipped = ipsw.ID, true
+		return nil
+	}
+
+	dest := filepath.Join(f.conf.Output, name)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return fmt.Errorf("failed to create output folder: %v", err)
+	}
+	if !f.conf.Keep {
+		defer os.Remove(dest)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		log.WithFields(log.Fields{
+			"url":      item.URL,
+			"priority": item.Priority,
+			"attempt":  item.Attempts,
+		}).Info("Downloading IPSW to scan")
+		// always resume (there is no one to answer the prompt)
+		downloader := download.NewDownload(f.conf.Proxy, f.conf.Insecure, false, true, false, false, false)
+		downloader.URL = item.URL
+		downloader.DestName = dest
+		dctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		downloader.Context = dctx
+		var last int64
+		downloader.Progress = func(written, total int64) {
+			if written > last {
+				metrics.DownloadBytes.WithLabelValues().Add(float64(written - last))
+				if f.Downloaded != nil {
+					if err := f.Downloaded(item, uint64(written-last)); err != nil {
+						cancel(err)
+					}
+				}
+			}
+			last = written
+		}
+		if err := downloader.Do(); err != nil {
+			if cause := context.Cause(dctx); cause != nil {
+				return fmt.Errorf("failed to download: %w", cause)
+			}
+			return fmt.Errorf("failed to download: %v", err)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	sha1, err := utils.Sha1(dest)
+	if err != nil {
+		return fmt.Errorf("failed to calculate sha1: %v", err)
+	}
+	item.IpswID = sha1
+	if _, err := f.db.Get(sha1); err == nil {
+		log.WithField("url", item.URL).Info("Skipping already scanned IPSW")
+		item.Skipped = true
+		return nil
+	}
+	log.WithField("url", item.URL).Info("Scanning IPSW symbols")
+	return syms.Scan(dest, f.pemDB, f.sigsDir, f.db, f.plugins...)
+}
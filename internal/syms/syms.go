@@ -12,6 +12,7 @@ import (
 	"github.com/blacktop/ipsw/internal/commands/extract"
 	"github.com/blacktop/ipsw/internal/db"
 	"github.com/blacktop/ipsw/internal/model"
+	"github.com/blacktop/ipsw/internal/plugin"
 	"github.com/blacktop/ipsw/internal/search"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/info"
@@ -162,7 +163,8 @@ func scanKernels(ipswPath, sigDir string) ([]*model.Kernelcache, error) {
 	return kcs, nil
 }
 
-func scanDSCs(ipswPath, pemDB string) ([]*model.DyldSharedCache, error) {
+// scanDSCs scans the IPSW's dyld_shared_caches (reusing the ones already in d if it isn't nil)
+func scanDSCs(ipswPath, pemDB string, d db.Database) ([]*model.DyldSharedCache, error) {
 	ctx, fs, err := dsc.OpenFromIPSW(ipswPath, pemDB, false, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open DSC from IPSW: %w", err)
@@ -177,6 +179,13 @@ func scanDSCs(ipswPath, pemDB string) ([]*model.DyldSharedCache, error) {
 	var dscs []*model.DyldSharedCache
 
 	for _, f := range fs {
+		if d != nil {
+			if known, err := d.GetDSC(f.UUID.String()); err == nil {
+				log.WithField("uuid", f.UUID).Info("Skipping already scanned dyld_shared_cache")
+				dscs = append(dscs, known)
+				continue
+			}
+		}
 		dsc := &model.DyldSharedCache{
 			UUID:              f.UUID.String(),
 			SharedRegionStart: f.Headers[f.UUID].SharedRegionStart,
@@ -227,8 +236,10 @@ func scanDSCs(ipswPath, pemDB string) ([]*model.DyldSharedCache, error) {
 	return dscs, nil
 }
 
-// Scan scans the IPSW file and extracts information about the kernels, DSCs, and file system.
-func Scan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
+// Scan scans the IPSW file and extracts information about the kernels, DSCs, and file system
+// (running the plugins' analyzers on the IPSW and its MachOs).
+// The DSCs and MachOs whose UUIDs were already scanned (i.e. in another IPSW) are reused instead of re-extracting their symbols.
+func Scan(ipswPath, pemDB, sigsDir string, db db.Database, plugins ...*plugin.Plugin) (err error) {
 	/* IPSW */
 	sha1, err := utils.Sha1(ipswPath)
 	if err != nil {
@@ -256,17 +267,25 @@ func Scan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
 		return fmt.Errorf("failed to save IPSW to database: %w", err)
 	}
 
+	/* PLUGINS */
+	az := newAnalyzers(ipsw, ipswPath, plugins)
+	az.runIPSW()
 	/* KERNEL */
 	if ipsw.Kernels, err = scanKernels(ipswPath, sigsDir); err != nil {
 		return fmt.Errorf("failed to scan kernels: %w", err)
 	}
 	/* DSC */
-	if ipsw.DSCs, err = scanDSCs(ipswPath, pemDB); err != nil {
+	if ipsw.DSCs, err = scanDSCs(ipswPath, pemDB, db); err != nil {
 		return fmt.Errorf("failed to scan DSCs: %w", err)
 	}
 	/* FileSystem */
-	if err := search.ForEachMachoInIPSW(ipswPath, pemDB, func(path string, m *macho.File) error {
+	if err := search.ForEachMachoFileInIPSW(ipswPath, pemDB, func(path, file string, m *macho.File) error {
 		if m.UUID() != nil {
+			az.runMacho(path, file, m.UUID().String())
+			if known, err := db.GetMachO(m.UUID().String()); err == nil {
+				ipsw.FileSystem = append(ipsw.FileSystem, known)
+				return nil
+			}
 			mm := &model.Macho{
 				UUID: m.UUID().String(),
 				Path: model.Path{Path: path},
@@ -303,11 +322,18 @@ func Scan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
 	}
 
 	log.Debug("Saving IPSW with FileSystem")
-	return db.Save(ipsw)
+	if err := db.Save(ipsw); err != nil {
+		return err
+	}
+	if err := az.save(db); err != nil {
+		return fmt.Errorf("failed to save plugin analyses: %w", err)
+	}
+	return nil
 }
 
-// Rescan re-scans the IPSW file and extracts information about the kernels, DSCs, and file system.
-func Rescan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
+// Rescan re-scans the IPSW file and extracts information about the kernels, DSCs, and file system
+// (running the plugins' analyzers on the IPSW and its MachOs).
+func Rescan(ipswPath, pemDB, sigsDir string, db db.Database, plugins ...*plugin.Plugin) (err error) {
 	/* IPSW */
 	sha1, err := utils.Sha1(ipswPath)
 	if err != nil {
@@ -317,17 +343,21 @@ func Rescan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to get IPSW from database: %w", err)
 	}
+	/* PLUGINS */
+	az := newAnalyzers(ipsw, ipswPath, plugins)
+	az.runIPSW()
 	/* KERNEL */
 	if ipsw.Kernels, err = scanKernels(ipswPath, sigsDir); err != nil {
 		return fmt.Errorf("failed to scan kernels: %w", err)
 	}
 	/* DSC */
-	if ipsw.DSCs, err = scanDSCs(ipswPath, pemDB); err != nil {
+	if ipsw.DSCs, err = scanDSCs(ipswPath, pemDB, nil); err != nil {
 		return fmt.Errorf("failed to scan DSCs: %w", err)
 	}
 	/* FileSystem */
-	if err := search.ForEachMachoInIPSW(ipswPath, pemDB, func(path string, m *macho.File) error {
+	if err := search.ForEachMachoFileInIPSW(ipswPath, pemDB, func(path, file string, m *macho.File) error {
 		if m.UUID() != nil {
+			az.runMacho(path, file, m.UUID().String())
 			mm := &model.Macho{
 				UUID: m.UUID().String(),
 				Path: model.Path{Path: path},
@@ -364,7 +394,13 @@ func Rescan(ipswPath, pemDB, sigsDir string, db db.Database) (err error) {
 	}
 
 	log.Debug("Saving IPSW with FileSystem")
-	return db.Save(ipsw)
+	if err := db.Save(ipsw); err != nil {
+		return err
+	}
+	if err := az.save(db); err != nil {
+		return fmt.Errorf("failed to save plugin analyses: %w", err)
+	}
+	return nil
 }
 
 func GetIPSW(version, build, device string, db db.Database) (*model.Ipsw, error) {
@@ -387,6 +423,11 @@ func GetDSCImage(uuid string, addr uint64, db db.Database) (*model.Macho, error)
 	return db.GetDSCImage(uuid, addr)
 }
 
+// GetAnalyses retrieves the results of the plugin analyzers run on the IPSW with the given ID (SHA1).
+func GetAnalyses(ipswID string, db db.Database) ([]*model.Analysis, error) {
+	return db.GetAnalyses(ipswID)
+}
+
 // Get retrieves the symbols associated with the given UUID from the database.
 func Get(uuid string, db db.Database) ([]*model.Symbol, error) {
 	return db.GetSymbols(uuid)
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/apfs"
+)
+
+// ExtractFromAPFS extracts the files that match the regex pattern from a filesystem DMG without mounting it
+func ExtractFromAPFS(dmgPath, destPath string, pattern *regexp.Regexp) ([]string, error) {
+	Indent(log.Info, 2)(fmt.Sprintf("Parsing DMG %s", dmgPath))
+	fsys, err := apfs.Open(dmgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse IPSW FS dmg: %v", err)
+	}
+	defer fsys.Close()
+
+	var artifacts []string
+	if err := fsys.Walk("/", func(f *apfs.File) error {
+		if f.IsDir() || !pattern.MatchString(f.Path) {
+			return nil
+		}
+		fname := filepath.Join(destPath, filepath.FromSlash(f.Path))
+		Indent(log.Info, 3)(fmt.Sprintf("Extracting to %s", fname))
+		if err := fsys.Extract(f, fname); err != nil {
+			return fmt.Errorf("failed to extract %s: %v", f.Path, err)
+		}
+		artifacts = append(artifacts, fname)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to extract File System files from IPSW: %v", err)
+	}
+
+	return artifacts, nil
+}
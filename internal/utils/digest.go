@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileDigest is the digest of a file's contents when it had the size and modification time
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// digests memoizes the file digests (so large files are only hashed again when they change)
+var digests = struct {
+	sync.Mutex
+	files map[string]fileDigest
+}{files: make(map[string]fileDigest)}
+
+// FileSha256 returns the (memoized) SHA256 of the regular file at path (ok is false if path is not a regular file)
+func FileSha256(path string) (sum string, ok bool, err error) {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return "", false, nil
+	}
+	digests.Lock()
+	fd, found := digests.files[path]
+	digests.Unlock()
+	if found && fd.size == fi.Size() && fd.modTime.Equal(fi.ModTime()) {
+		return fd.sum, true, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+	if sum, err = Sha256Reader(f); err != nil {
+		return "", false, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	digests.Lock()
+	digests.files[path] = fileDigest{size: fi.Size(), modTime: fi.ModTime(), sum: sum}
+	digests.Unlock()
+	return sum, true, nil
+}
+
+// Sha256Reader returns the SHA256 of the reader's contents
+func Sha256Reader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobToRegex converts a glob path pattern into an anchored regex pattern where '*' matches anything
+// except '/', '**' matches anything (including '/'), '?' matches a single character and '[...]' matches
+// a character class. A glob without a '/' matches the base name of a path in any folder.
+func GlobToRegex(glob string) string {
+	var sb strings.Builder
+	if strings.Contains(glob, "/") {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("(?:^|/)")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' { // '**/' also matches zero folders
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += end + 1
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// MergePatterns combines a regex pattern and glob patterns into a single regex pattern that matches any of them
+func MergePatterns(pattern string, globs []string) string {
+	var patterns []string
+	if len(pattern) > 0 {
+		patterns = append(patterns, pattern)
+	}
+	for _, glob := range globs {
+		if len(glob) > 0 {
+			patterns = append(patterns, GlobToRegex(glob))
+		}
+	}
+	if len(patterns) == 1 {
+		return patterns[0]
+	}
+	for i, p := range patterns {
+		patterns[i] = "(?:" + p + ")"
+	}
+	return strings.Join(patterns, "|")
+}
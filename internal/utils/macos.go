@@ -655,6 +655,10 @@ func ExtractFromDMG(ipswPath, dmgPath, destPath, pemDB string, pattern *regexp.R
 		defer os.Remove(dmgPath)
 	}
 
+	if runtime.GOOS != "darwin" {
+		return ExtractFromAPFS(dmgPath, destPath, pattern)
+	}
+
 	Indent(log.Info, 2)(fmt.Sprintf("Mounting DMG %s", dmgPath))
 	mountPoint, alreadyMounted, err := MountDMG(dmgPath)
 	if err != nil {
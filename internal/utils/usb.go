@@ -12,9 +12,27 @@ import (
 	"github.com/blacktop/ipsw/pkg/usb/mount"
 )
 
+// networkDevice returns the values of the device set up to be used over the network (if any)
+func networkDevice() (*lockdownd.DeviceValues, error) {
+	nd := usb.NetworkDeviceInUse()
+	if nd == nil {
+		return nil, nil
+	}
+	ldc, err := lockdownd.NewClient(nd.UDID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to lockdownd at %s: %w", nd.Address, err)
+	}
+	defer ldc.Close()
+	return ldc.GetValues()
+}
+
 func PickDevice() (*lockdownd.DeviceValues, error) {
 	var deets []*lockdownd.DeviceValues
 
+	if dev, err := networkDevice(); dev != nil || err != nil {
+		return dev, err
+	}
+
 	conn, err := usb.NewConn()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to usbmuxd: %w", err)
@@ -80,6 +98,12 @@ func PickDevice() (*lockdownd.DeviceValues, error) {
 func PickDevices() ([]*lockdownd.DeviceValues, error) {
 	var deets []*lockdownd.DeviceValues
 
+	if dev, err := networkDevice(); err != nil {
+		return nil, err
+	} else if dev != nil {
+		return []*lockdownd.DeviceValues{dev}, nil
+	}
+
 	conn, err := usb.NewConn()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to usbmuxd: %w", err)
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"reflect"
+	"regexp"
 	"testing"
 )
 
@@ -82,3 +83,31 @@ func TestDifference(t *testing.T) {
 		})
 	}
 }
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"Firmware/*.im4p", "Firmware/all_flash/iBoot.d83.RELEASE.im4p", false},
+		{"Firmware/*.im4p", "Firmware/agx/armfw_g15p.im4p", false},
+		{"Firmware/*.im4p", "Firmware/sptm.d83.release.im4p", true},
+		{"Firmware/**/*.im4p", "Firmware/all_flash/iBoot.d83.RELEASE.im4p", true},
+		{"Firmware/**/*.im4p", "Firmware/sptm.d83.release.im4p", true},
+		{"Firmware/**/*.im4p", "Firmware/dfu/iBSS.d83.RELEASE.plist", false},
+		{"*.im4p", "Firmware/agx/armfw_g15p.im4p", true},
+		{"kernelcache.release.iPhone1?,?", "kernelcache.release.iPhone17,1", true},
+		{"kernelcache.release.iPhone1?,?", "kernelcache.release.iPhone9,1", false},
+		{"Firmware/[!a]*.im4p", "Firmware/adc.im4p", false},
+		{"Firmware/[!a]*.im4p", "Firmware/sptm.im4p", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+"_"+tt.path, func(t *testing.T) {
+			re := regexp.MustCompile(GlobToRegex(tt.glob))
+			if got := re.MatchString(tt.path); got != tt.match {
+				t.Errorf("GlobToRegex(%q) = %q matching %q = %v, want %v", tt.glob, re.String(), tt.path, got, tt.match)
+			}
+		})
+	}
+}
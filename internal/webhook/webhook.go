@@ -0,0 +1,219 @@
+// Package webhook posts signed JSON event payloads to webhook URLs
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/google/uuid"
+)
+
+const (
+	// SignatureHeader is the header of the hex HMAC-SHA256 of the body (prefixed with 'sha256=') keyed with the webhook's secret
+	SignatureHeader = "X-Ipsw-Signature-256"
+	// EventHeader is the header of the event type
+	EventHeader = "X-Ipsw-Event"
+	// DeliveryHeader is the header of the event's unique ID
+	DeliveryHeader = "X-Ipsw-Delivery"
+
+	retries = 3
+	backoff = 5 * time.Second
+	timeout = 30 * time.Second
+)
+
+// Event types
+const (
+	JobDone     = "job.done"
+	JobFailed   = "job.failed"
+	JobCanceled = "job.canceled"
+	WatchBuild  = "watch.build"
+	// PipelineReport is posted by the publish steps of the pipelines
+	PipelineReport = "pipeline.report"
+)
+
+// Config is a webhook's config
+type Config struct {
+	// URL to POST the events to
+	URL string `json:"url"`
+	// Secret to sign the payloads with (they are not signed if empty)
+	Secret string `json:"secret,omitempty"`
+	// Events are the event types (or path.Match patterns i.e. 'job.*') to post (all events if empty)
+	Events []string `json:"events,omitempty"`
+	// Template is a Go text/template rendering the JSON payload from the event (the event JSON if empty)
+	Template string `json:"template,omitempty"`
+}
+
+// Event is a webhook event
+type Event struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+type hook struct {
+	conf Config
+	tmpl *template.Template
+}
+
+func (h *hook) wants(typ string) bool {
+	if len(h.conf.Events) == 0 {
+		return true
+	}
+	for _, pattern := range h.conf.Events {
+		if ok, _ := path.Match(pattern, typ); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *hook) payload(ev *Event) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(ev)
+	}
+	var buf bytes.Buffer
+	if err := h.tmpl.Execute(&buf, ev); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template did not render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+var funcs = template.FuncMap{
+	// json encodes a value (i.e. to safely embed strings in the payload)
+	"json": func(v any) (string, error) {
+		dat, err := json.Marshal(v)
+		return string(dat), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Sign returns the signature of the body (as sent in the SignatureHeader)
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier posts events to the configured webhooks
+type Notifier struct {
+	hooks  []*hook
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+// NewNotifier creates a notifier for the webhooks
+func NewNotifier(confs []Config) (*Notifier, error) {
+	n := &Notifier{client: &http.Client{Timeout: timeout}}
+	for i, conf := range confs {
+		if u, err := url.Parse(conf.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("webhook %d: invalid URL '%s'", i, conf.URL)
+		}
+		for _, pattern := range conf.Events {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("webhook %d: invalid event pattern '%s': %w", i, pattern, err)
+			}
+		}
+		h := &hook{conf: conf}
+		if len(conf.Template) > 0 {
+			tmpl, err := template.New(conf.URL).Funcs(funcs).Parse(conf.Template)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %d: failed to parse template: %w", i, err)
+			}
+			h.tmpl = tmpl
+		}
+		n.hooks = append(n.hooks, h)
+	}
+	return n, nil
+}
+
+// Notify posts the event to the webhooks that want it in the background
+func (n *Notifier) Notify(typ string, data any) {
+	if n == nil {
+		return
+	}
+	ev := &Event{
+		ID:   uuid.NewString(),
+		Type: typ,
+		Time: time.Now(),
+		Data: data,
+	}
+	for _, h := range n.hooks {
+		if !h.wants(typ) {
+			continue
+		}
+		n.wg.Add(1)
+		go func(h *hook) {
+			defer n.wg.Done()
+			if err := n.deliver(h, ev); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"url":   h.conf.URL,
+					"event": typ,
+				}).Error("Webhook failed")
+			}
+		}(h)
+	}
+}
+
+func (n *Notifier) deliver(h *hook, ev *Event) error {
+	body, err := h.payload(ev)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = n.post(h, ev, body)
+		if err == nil || attempt == retries {
+			return err
+		}
+		time.Sleep(backoff * time.Duration(attempt))
+	}
+}
+
+func (n *Notifier) post(h *hook, ev *Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create http POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ipswd")
+	req.Header.Set(EventHeader, ev.Type)
+	req.Header.Set(DeliveryHeader, ev.ID)
+	if len(h.conf.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(h.conf.Secret, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned status: %s: %s", resp.Status, string(msg))
+	}
+	return nil
+}
+
+// Close waits for the pending deliveries
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
@@ -18,6 +18,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/apex/log"
 	"github.com/cloudflare/circl/hpke"
 )
 
@@ -96,6 +97,18 @@ func (md Metadata) GetPrivateKey(data []byte, pemDB string, skipEmbedded bool) (
 		}
 	}
 
+	name, err := fcsKeyName(string(privKeyURL))
+	if err != nil {
+		return nil, err
+	}
+
+	if keyStore != nil {
+		if pemData, ok := keyStore.FCSKey(name); ok {
+			out[name] = PrivateKey(pemData)
+			return out, nil
+		}
+	}
+
 	if pemDB != "" {
 		pemData, err := os.ReadFile(pemDB)
 		if err != nil {
@@ -132,11 +145,12 @@ func (md Metadata) GetPrivateKey(data []byte, pemDB string, skipEmbedded bool) (
 		return nil, err
 	}
 
-	u, err := url.Parse(string(privKeyURL))
-	if err != nil {
-		return nil, err
+	if keyStore != nil {
+		if err := keyStore.PutFCSKey(name, privKey); err != nil {
+			log.WithError(err).Warn("Failed to cache fcs-key")
+		}
 	}
-	out[path.Base(u.Path)] = PrivateKey(privKey)
+	out[name] = PrivateKey(privKey)
 
 	return out, nil
 }
@@ -467,17 +467,27 @@ func Decrypt(c *DecryptConfig) (string, error) {
 			return "", fmt.Errorf("failed to decode hex sym key: %v", err)
 		}
 		c.B64SymKey = base64.StdEncoding.EncodeToString(c.symEncKey)
+	} else if cached, ok := cachedArchiveKey(c.Input); ok && c.B64SymKey == "" && len(c.PrivKeyData) == 0 {
+		log.Debug("Using cached AEA key")
+		c.B64SymKey = cached
+		c.symEncKey, err = base64.StdEncoding.WithPadding(base64.StdPadding).DecodeString(c.B64SymKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode cached base64 sym key: %v", err)
+		}
 	} else if c.B64SymKey == "" {
 		c.symEncKey, err = metadata.DecryptFCS(c.PrivKeyData, c.PemDB)
 		if err != nil {
 			return "", fmt.Errorf("failed to HPKE decrypt fcs-key: %v", err)
 		}
 		c.B64SymKey = base64.StdEncoding.EncodeToString(c.symEncKey)
+		cacheArchiveKey(c.Input, c.B64SymKey)
 	} else {
+		c.B64SymKey = strings.TrimPrefix(c.B64SymKey, "base64:")
 		c.symEncKey, err = base64.StdEncoding.WithPadding(base64.StdPadding).DecodeString(c.B64SymKey)
 		if err != nil {
 			return "", fmt.Errorf("failed to decode base64 sym key: %v", err)
 		}
+		cacheArchiveKey(c.Input, c.B64SymKey)
 	}
 
 	// if true { // uncomment this is to test the pure Go implementation on darwin
@@ -0,0 +1,69 @@
+package aea
+
+import (
+	"encoding/hex"
+	"net/url"
+	"path"
+
+	"github.com/apex/log"
+)
+
+// KeyStore caches AEA key material for offline reuse
+type KeyStore interface {
+	// FCSKey returns the cached fcs-key private key PEM (by the base name of its fcs-key URL)
+	FCSKey(name string) ([]byte, bool)
+	// PutFCSKey caches an fcs-key private key PEM
+	PutFCSKey(name string, pem []byte) error
+	// ArchiveKey returns the cached base64 symmetric key of an AEA (by its ID)
+	ArchiveKey(id string) (string, bool)
+	// PutArchiveKey caches the base64 symmetric key of an AEA
+	PutArchiveKey(id, b64key string) error
+}
+
+var keyStore KeyStore
+
+// SetKeyStore sets the key store that fetched/derived AEA keys are cached in (nil disables caching)
+func SetKeyStore(ks KeyStore) {
+	keyStore = ks
+}
+
+// fcsKeyName returns the name an fcs-key is stored by (the base name of its URL)
+func fcsKeyName(fcsKeyURL string) (string, error) {
+	u, err := url.Parse(fcsKeyURL)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(u.Path), nil
+}
+
+// archiveID returns the hex ID of an AEA
+func archiveID(in string) string {
+	id, err := ID(in)
+	if err != nil {
+		log.WithError(err).Debug("failed to get AEA ID")
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func cachedArchiveKey(in string) (string, bool) {
+	if keyStore == nil {
+		return "", false
+	}
+	id := archiveID(in)
+	if len(id) == 0 {
+		return "", false
+	}
+	return keyStore.ArchiveKey(id)
+}
+
+func cacheArchiveKey(in, b64key string) {
+	if keyStore == nil {
+		return
+	}
+	if id := archiveID(in); len(id) > 0 {
+		if err := keyStore.PutArchiveKey(id, b64key); err != nil {
+			log.WithError(err).Warn("Failed to cache AEA key")
+		}
+	}
+}
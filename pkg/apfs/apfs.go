@@ -0,0 +1,267 @@
+// Package apfs is a read-only APFS reader used to list and extract the files in IPSW filesystem DMGs
+// without mounting them (so it works on Linux and Windows)
+package apfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-apfs/types"
+)
+
+const nxMagicOffset = 32 // offset of the 'NXSB' magic in the container superblock
+
+// ErrNotAPFS is returned when a disk image does not contain an APFS container
+var ErrNotAPFS = errors.New("no APFS container found")
+
+// FS is a read-only APFS volume
+type FS struct {
+	Name   string // the volume name
+	Sealed bool   // the volume is a Signed System Volume
+
+	r      io.ReaderAt // the APFS container
+	closer io.Closer
+	omap   *types.BTreeNodePhys // the volume's object map
+	vol    *types.ApfsSuperblock
+
+	nodes    map[uint64]*inode
+	children map[uint64][]dirent
+	extents  map[uint64][]types.FileExtent
+}
+
+// Open opens the first APFS volume in a DMG (UDIF), raw APFS image or GPT partitioned disk image
+func Open(name string) (*FS, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fsys, err := NewFS(io.NewSectionReader(f, 0, fi.Size()))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	fsys.closer = f
+	return fsys, nil
+}
+
+// NewFS reads the first APFS volume in a DMG (UDIF), raw APFS image or GPT partitioned disk image
+func NewFS(sr *io.SectionReader) (*FS, error) {
+	var r io.ReaderAt = sr
+	var partitions []int64
+	var size int64 = sr.Size()
+	if u, err := openUDIF(sr); err == nil {
+		r, partitions, size = u, u.partitions(), u.size
+	} else {
+		log.Debugf("not a UDIF disk image (reading as a raw image): %v", err)
+		partitions = []int64{0}
+	}
+	partitions = append(partitions, gptPartitions(r)...)
+	// find the APFS container partition
+	magic := make([]byte, 4)
+	for _, off := range partitions {
+		if _, err := r.ReadAt(magic, off+nxMagicOffset); err != nil {
+			continue
+		}
+		if string(magic) == types.NX_MAGIC {
+			fsys := &FS{r: io.NewSectionReader(r, off, size-off)}
+			if err := fsys.load(); err != nil {
+				return nil, err
+			}
+			return fsys, nil
+		}
+	}
+	return nil, ErrNotAPFS
+}
+
+// Close closes the underlying image file
+func (fsys *FS) Close() error {
+	if fsys.closer != nil {
+		return fsys.closer.Close()
+	}
+	return nil
+}
+
+// latestSuperblock returns the container superblock with the largest transaction identifier
+func (fsys *FS) latestSuperblock() (*types.NxSuperblock, error) {
+	types.BLOCK_SIZE = types.NX_DEFAULT_BLOCK_SIZE
+	o, err := types.ReadObj(fsys.r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container superblock: %v", err)
+	}
+	nxsb, ok := o.Body.(types.NxSuperblock)
+	if !ok {
+		return nil, fmt.Errorf("block 0 is not a container superblock (found %s)", o.Hdr.GetType())
+	}
+	if nxsb.BlockSize != types.NX_DEFAULT_BLOCK_SIZE {
+		types.BLOCK_SIZE = uint64(nxsb.BlockSize)
+	}
+	latest, xid := &nxsb, o.Hdr.Xid
+	if nxsb.XpDescBlocks>>31 == 0 { // contiguous checkpoint descriptor area
+		for i := range uint64(nxsb.XpDescBlocks) {
+			o, err := types.ReadObj(fsys.r, nxsb.XpDescBase+i)
+			if err != nil {
+				continue // bad checksum or not a superblock
+			}
+			if sb, ok := o.Body.(types.NxSuperblock); ok && o.Hdr.Xid > xid {
+				latest, xid = &sb, o.Hdr.Xid
+			}
+		}
+	}
+	return latest, nil
+}
+
+func (fsys *FS) load() error {
+	nxsb, err := fsys.latestSuperblock()
+	if err != nil {
+		return err
+	}
+	if nxsb.OMap == nil {
+		return fmt.Errorf("container has no object map")
+	}
+	cmap, err := omapTree(fsys.r, nxsb.OMap)
+	if err != nil {
+		return fmt.Errorf("failed to read container object map: %v", err)
+	}
+	// use the first volume (IPSW DMGs only have one)
+	for _, oid := range nxsb.FsOids {
+		if oid == 0 {
+			continue
+		}
+		entry, err := cmap.GetOMapEntry(fsys.r, oid, types.XidT(^uint64(0)))
+		if err != nil {
+			return fmt.Errorf("failed to find volume superblock %#x: %v", oid, err)
+		}
+		o, err := types.ReadObj(fsys.r, entry.Val.Paddr)
+		if err != nil {
+			return fmt.Errorf("failed to read volume superblock: %v", err)
+		}
+		vol, ok := o.Body.(types.ApfsSuperblock)
+		if !ok {
+			return fmt.Errorf("object %#x is not a volume superblock (found %s)", oid, o.Hdr.GetType())
+		}
+		fsys.vol = &vol
+		break
+	}
+	if fsys.vol == nil {
+		return fmt.Errorf("container has no volumes")
+	}
+	fsys.Name = string(bytes.TrimRight(fsys.vol.VolumeName[:], "\x00"))
+	fsys.Sealed = fsys.vol.IncompatibleFeatures&types.APFS_INCOMPAT_SEALED_VOLUME != 0
+	if fsys.vol.OMap == nil {
+		return fmt.Errorf("volume %s has no object map", fsys.Name)
+	}
+	if fsys.omap, err = omapTree(fsys.r, fsys.vol.OMap); err != nil {
+		return fmt.Errorf("failed to read volume object map: %v", err)
+	}
+	log.WithFields(log.Fields{
+		"name":   fsys.Name,
+		"role":   fmt.Sprintf("%#x", uint16(fsys.vol.Role)),
+		"sealed": fsys.Sealed,
+		"files":  fsys.vol.NumFiles,
+	}).Debug("APFS Volume")
+	return fsys.index()
+}
+
+// omapTree returns the root node of an object map's B-tree
+// (go-apfs replaces the tree with the snapshot tree when the map has snapshots)
+func omapTree(r io.ReaderAt, o *types.Obj) (*types.BTreeNodePhys, error) {
+	omap, ok := o.Body.(types.OMap)
+	if !ok {
+		return nil, fmt.Errorf("object is not an object map (found %s)", o.Hdr.GetType())
+	}
+	to, err := types.ReadObj(r, uint64(omap.TreeOid))
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := to.Body.(types.BTreeNodePhys)
+	if !ok {
+		return nil, fmt.Errorf("object map tree is not a B-tree (found %s)", to.Hdr.GetType())
+	}
+	return &tree, nil
+}
+
+// isPhysical returns true if the tree type's child node identifiers are physical block addresses
+// (i.e. the file-system tree of a sealed volume) instead of virtual ones resolved by the object map
+func isPhysical(typ uint32) bool {
+	return typ&uint32(types.OBJ_PHYSICAL) != 0
+}
+
+// nodeAddr returns the block address of a B-tree node by its (physical or virtual) object identifier
+func (fsys *FS) nodeAddr(oid uint64, physical bool) (uint64, error) {
+	if physical {
+		return oid, nil
+	}
+	entry, err := fsys.omap.GetOMapEntry(fsys.r, types.OidT(oid), types.XidT(^uint64(0)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find node %#x in object map: %v", oid, err)
+	}
+	return entry.Val.Paddr, nil
+}
+
+// readNode reads a B-tree node by its (physical or virtual) object identifier
+func (fsys *FS) readNode(oid uint64, physical bool) (*types.BTreeNodePhys, error) {
+	addr, err := fsys.nodeAddr(oid, physical)
+	if err != nil {
+		return nil, err
+	}
+	o, err := types.ReadObj(fsys.r, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node at block %#x: %v", addr, err)
+	}
+	node, ok := o.Body.(types.BTreeNodePhys)
+	if !ok {
+		return nil, fmt.Errorf("object at block %#x is not a B-tree node (found %s)", addr, o.Hdr.GetType())
+	}
+	return &node, nil
+}
+
+// walkTree calls fn for every leaf record of the B-tree
+func (fsys *FS) walkTree(node *types.BTreeNodePhys, physical bool, fn func(entry any)) error {
+	for _, entry := range node.Entries {
+		if node.IsLeaf() {
+			fn(entry)
+			continue
+		}
+		var child uint64
+		switch e := entry.(type) {
+		case types.NodeEntry: // file-system tree
+			switch v := e.Val.(type) {
+			case uint64:
+				child = v
+			case types.BTreeNodeIndexNodeValT: // hashed (sealed) tree
+				child = uint64(v.ChildOid)
+			default:
+				continue
+			}
+		case types.OMapNodeEntry: // file extent tree
+			child = e.PAddr
+		default:
+			continue
+		}
+		next, err := fsys.readNode(child, physical)
+		if err != nil {
+			return err
+		}
+		if err := fsys.walkTree(next, physical, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cstring(data any) string {
+	if b, ok := data.([]byte); ok {
+		return strings.TrimRight(string(b), "\x00")
+	}
+	return ""
+}
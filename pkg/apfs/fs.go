@@ -0,0 +1,383 @@
+package apfs
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-apfs/types"
+)
+
+const (
+	sIFMT  = 0o170000
+	sIFDIR = 0o040000
+	sIFLNK = 0o120000
+)
+
+// FirmlinkRoot is the mount point of the Data volume that firmlinks point into
+const FirmlinkRoot = "/System/Volumes/Data"
+
+type inode struct {
+	mode     uint16
+	size     uint64
+	modTime  types.EpochTime
+	private  uint64 // data stream identifier
+	symlink  string
+	firmlink string
+	decmpfs  *types.DecmpfsDiskHeader
+	rsrc     uint64 // resource fork data stream identifier
+}
+
+type dirent struct {
+	name string
+	id   uint64
+}
+
+// File is a file, directory or link in the volume
+type File struct {
+	Path     string      `json:"path"`
+	Mode     os.FileMode `json:"mode"`
+	Size     uint64      `json:"size"`
+	ModTime  time.Time   `json:"mod_time"`
+	Link     string      `json:"link,omitempty"`     // the symlink target
+	Firmlink string      `json:"firmlink,omitempty"` // the firmlink target (in the Data volume)
+
+	id uint64
+}
+
+// IsDir returns true if the file is a directory
+func (f *File) IsDir() bool {
+	return f.Mode.IsDir()
+}
+
+func fileMode(mode uint16) os.FileMode {
+	m := os.FileMode(mode & 0o777)
+	if mode&0o4000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&0o2000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		m |= os.ModeSticky
+	}
+	switch mode & sIFMT {
+	case sIFDIR:
+		m |= os.ModeDir
+	case sIFLNK:
+		m |= os.ModeSymlink
+	}
+	return m
+}
+
+// index reads every record of the volume's file-system tree (and file extent tree if it is sealed)
+func (fsys *FS) index() error {
+	fsys.nodes = make(map[uint64]*inode)
+	fsys.children = make(map[uint64][]dirent)
+	fsys.extents = make(map[uint64][]types.FileExtent)
+
+	node := func(id uint64) *inode {
+		if _, ok := fsys.nodes[id]; !ok {
+			fsys.nodes[id] = &inode{}
+		}
+		return fsys.nodes[id]
+	}
+
+	physical := isPhysical(uint32(fsys.vol.RootTreeType))
+	root, err := fsys.readNode(uint64(fsys.vol.RootTreeOid), physical)
+	if err != nil {
+		return fmt.Errorf("failed to read file-system tree: %v", err)
+	}
+	if err := fsys.walkTree(root, physical, func(entry any) {
+		rec, ok := entry.(types.NodeEntry)
+		if !ok {
+			return
+		}
+		id := rec.Hdr.GetID()
+		switch rec.Hdr.GetType() {
+		case types.APFS_TYPE_INODE:
+			val := rec.Val.(types.JInodeVal)
+			ino := node(id)
+			ino.mode = uint16(val.Mode)
+			ino.modTime = val.ModTime
+			ino.private = val.PrivateID
+			for _, xf := range val.Xfields {
+				if xf.XType == types.INO_EXT_TYPE_DSTREAM {
+					ino.size = xf.Field.(types.JDstreamT).Size
+				}
+			}
+			if val.InternalFlags&types.INODE_HAS_UNCOMPRESSED_SIZE != 0 {
+				ino.size = val.UncompressedSize
+			}
+		case types.APFS_TYPE_DIR_REC:
+			key := rec.Key.(types.JDrecHashedKeyT)
+			fsys.children[id] = append(fsys.children[id], dirent{name: key.Name, id: rec.Val.(types.JDrecVal).FileID})
+		case types.APFS_TYPE_FILE_EXTENT:
+			val := rec.Val.(types.JFileExtentValT)
+			fsys.extents[id] = append(fsys.extents[id], types.FileExtent{
+				Address: rec.Key.(types.JFileExtentKeyT).LogicalAddr,
+				Block:   val.PhysBlockNum,
+				Length:  val.Length(),
+			})
+		case types.APFS_TYPE_XATTR:
+			val := rec.Val.(types.JXattrValT)
+			ino := node(id)
+			switch rec.Key.(types.JXattrKeyT).Name {
+			case types.XATTR_SYMLINK_EA_NAME:
+				ino.symlink = cstring(val.Data)
+			case types.XATTR_FIRMLINK_EA_NAME:
+				ino.firmlink = cstring(val.Data)
+			case types.XATTR_DECMPFS_EA_NAME:
+				if hdr, err := types.GetDecmpfsHeader(rec); err == nil {
+					ino.decmpfs = hdr
+				}
+			case types.XATTR_RESOURCEFORK_EA_NAME:
+				if ds, ok := val.Data.(types.JXattrDstreamT); ok {
+					ino.rsrc = ds.XattrObjID
+				}
+			}
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to walk file-system tree: %v", err)
+	}
+
+	if fsys.Sealed && fsys.vol.FextTreeOid != 0 { // sealed volumes keep their file extents in a separate tree
+		root, err := fsys.readNode(uint64(fsys.vol.FextTreeOid), true)
+		if err != nil {
+			return fmt.Errorf("failed to read file extent tree: %v", err)
+		}
+		if err := fsys.walkTree(root, true, func(entry any) {
+			if fext, ok := entry.(types.FextNodeEntry); ok {
+				fsys.extents[fext.Key.PrivateID] = append(fsys.extents[fext.Key.PrivateID], types.FileExtent{
+					Address: fext.Key.LogicalAddr,
+					Block:   fext.Val.PhysBlockNum,
+					Length:  fext.Val.Length(),
+				})
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to walk file extent tree: %v", err)
+		}
+	}
+
+	for id := range fsys.extents {
+		slices.SortFunc(fsys.extents[id], func(a, b types.FileExtent) int {
+			return cmp.Compare(a.Address, b.Address)
+		})
+	}
+	for id := range fsys.children {
+		slices.SortFunc(fsys.children[id], func(a, b dirent) int { return strings.Compare(a.name, b.name) })
+	}
+
+	return nil
+}
+
+func (fsys *FS) file(id uint64, fpath string) *File {
+	f := &File{Path: fpath, id: id}
+	if ino, ok := fsys.nodes[id]; ok {
+		f.Mode = fileMode(ino.mode)
+		f.Size = ino.size
+		f.ModTime = time.Unix(0, int64(ino.modTime))
+		f.Link = ino.symlink
+		f.Firmlink = ino.firmlink
+	}
+	return f
+}
+
+// Stat returns the file at the path (i.e. /System/Library/Caches)
+func (fsys *FS) Stat(fpath string) (*File, error) {
+	id := uint64(types.ROOT_DIR_INO_NUM)
+	for _, part := range strings.FieldsFunc(fpath, func(r rune) bool { return r == '/' || r == '\\' }) {
+		found := false
+		for _, ent := range fsys.children[id] {
+			if ent.name == part {
+				id, found = ent.id, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s: %w", fpath, os.ErrNotExist)
+		}
+	}
+	return fsys.file(id, path.Clean("/"+filepath.ToSlash(fpath))), nil
+}
+
+// ReadDir returns the files in the directory
+func (fsys *FS) ReadDir(dir string) ([]*File, error) {
+	d, err := fsys.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsDir() {
+		return nil, fmt.Errorf("%s: not a directory", dir)
+	}
+	var files []*File
+	for _, ent := range fsys.children[d.id] {
+		files = append(files, fsys.file(ent.id, path.Join(d.Path, ent.name)))
+	}
+	return files, nil
+}
+
+// Walk calls fn for every file in the tree rooted at root (in lexical order);
+// fn can return filepath.SkipDir to skip a directory
+func (fsys *FS) Walk(root string, fn func(f *File) error) error {
+	f, err := fsys.Stat(root)
+	if err != nil {
+		return err
+	}
+	var walk func(f *File) error
+	walk = func(f *File) error {
+		if err := fn(f); err != nil {
+			if err == filepath.SkipDir && f.IsDir() {
+				return nil
+			}
+			return err
+		}
+		if !f.IsDir() {
+			return nil
+		}
+		for _, ent := range fsys.children[f.id] {
+			if err := walk(fsys.file(ent.id, path.Join(f.Path, ent.name))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(f); err != nil && err != filepath.SkipDir {
+		return err
+	}
+	return nil
+}
+
+// Copy writes the (decompressed) contents of the file to w
+func (fsys *FS) Copy(w io.Writer, f *File) error {
+	ino, ok := fsys.nodes[f.id]
+	if !ok || f.IsDir() {
+		return fmt.Errorf("%s: not a regular file", f.Path)
+	}
+	if ino.decmpfs != nil {
+		bw := bufio.NewWriter(w)
+		if err := ino.decmpfs.DecompressFile(fsys.r, bw, fsys.extents[ino.rsrc], true); err != nil {
+			return fmt.Errorf("failed to decompress %s: %v", f.Path, err)
+		}
+		return bw.Flush()
+	}
+	remaining := int64(ino.size)
+	for _, ext := range fsys.extents[ino.private] {
+		if remaining <= 0 {
+			break
+		}
+		n := min(int64(ext.Length), remaining)
+		var r io.Reader
+		if ext.Block == 0 { // sparse
+			r = io.LimitReader(zeros{}, n)
+		} else {
+			r = io.NewSectionReader(fsys.r, int64(ext.Block*types.BLOCK_SIZE), n)
+		}
+		if _, err := io.CopyN(w, r, n); err != nil {
+			return fmt.Errorf("failed to read %s: %v", f.Path, err)
+		}
+		remaining -= n
+	}
+	return nil
+}
+
+// OpenFile returns a random access reader of the (decompressed) contents of the file
+// (compressed files are decompressed into memory)
+func (fsys *FS) OpenFile(f *File) (io.ReaderAt, error) {
+	ino, ok := fsys.nodes[f.id]
+	if !ok || f.IsDir() {
+		return nil, fmt.Errorf("%s: not a regular file", f.Path)
+	}
+	if ino.decmpfs != nil {
+		var buf bytes.Buffer
+		if err := fsys.Copy(&buf, f); err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+	return &extentReader{r: fsys.r, extents: fsys.extents[ino.private], size: int64(ino.size)}, nil
+}
+
+// extentReader reads a file's data stream from its (sorted) extents
+type extentReader struct {
+	r       io.ReaderAt
+	extents []types.FileExtent
+	size    int64
+}
+
+func (er *extentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= er.size {
+		return 0, io.EOF
+	}
+	p = p[:min(int64(len(p)), er.size-off)]
+	idx := sort.Search(len(er.extents), func(i int) bool {
+		return int64(er.extents[i].Address+er.extents[i].Length) > off
+	})
+	var n int
+	for n < len(p) {
+		if idx >= len(er.extents) || int64(er.extents[idx].Address) > off { // hole
+			end := int64(len(p) - n)
+			if idx < len(er.extents) {
+				end = min(end, int64(er.extents[idx].Address)-off)
+			}
+			clear(p[n : n+int(end)])
+			n += int(end)
+			off += end
+			continue
+		}
+		ext := er.extents[idx]
+		chunk := p[n:min(len(p), n+int(int64(ext.Address+ext.Length)-off))]
+		if ext.Block == 0 { // sparse
+			clear(chunk)
+		} else if _, err := er.r.ReadAt(chunk, int64(ext.Block*types.BLOCK_SIZE)+off-int64(ext.Address)); err != nil && err != io.EOF {
+			return n, err
+		}
+		n += len(chunk)
+		off += int64(len(chunk))
+		idx++
+	}
+	if off >= er.size {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// Extract extracts the file, directory or link to dest (a firmlink is extracted as a symlink into FirmlinkRoot)
+func (fsys *FS) Extract(f *File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dest), err)
+	}
+	switch {
+	case f.Firmlink != "":
+		return os.Symlink(path.Join(FirmlinkRoot, f.Firmlink), dest)
+	case f.IsDir():
+		return os.MkdirAll(dest, 0o750)
+	case f.Mode&os.ModeSymlink != 0:
+		return os.Symlink(f.Link, dest)
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode.Perm()|0o200)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+	defer out.Close()
+	if err := fsys.Copy(out, f); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, f.ModTime, f.ModTime)
+}
@@ -0,0 +1,226 @@
+//go:build darwin || linux
+
+package apfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blacktop/go-apfs/types"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+// the volumes are read-only so the kernel can cache attributes and entries for as long as they are mounted
+const fuseCacheTimeout = time.Hour
+
+// apfsNode is a FUSE inode backed by a file in one of the volumes
+// (or a folder leading to the mount point of a volume)
+type apfsNode struct {
+	fs.Inode
+	fsys   *FS   // nil for the folders leading to a volume's mount point
+	file   *File // nil for the folders leading to a volume's mount point
+	dir    string
+	mounts map[string]*FS // volumes by the folder (relative to the mountpoint) they are mounted at
+}
+
+var (
+	_ fs.NodeGetattrer  = (*apfsNode)(nil)
+	_ fs.NodeLookuper   = (*apfsNode)(nil)
+	_ fs.NodeReaddirer  = (*apfsNode)(nil)
+	_ fs.NodeReadlinker = (*apfsNode)(nil)
+	_ fs.NodeOpener     = (*apfsNode)(nil)
+)
+
+// newNode returns the node for the root of the volume mounted at dir or a folder leading to one
+func newNode(dir string, mounts map[string]*FS) *apfsNode {
+	n := &apfsNode{dir: dir, mounts: mounts}
+	if fsys, ok := mounts[dir]; ok {
+		n.fsys, n.file = fsys, fsys.file(uint64(types.ROOT_DIR_INO_NUM), "/")
+	}
+	return n
+}
+
+// leadsToMount returns true if a volume is mounted in (a subfolder of) dir
+func (n *apfsNode) leadsToMount(dir string) bool {
+	for mp := range n.mounts {
+		if mp != "" && (dir == "" || strings.HasPrefix(mp, dir+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func fillAttr(f *File, out *fuse.Attr) {
+	switch {
+	case f == nil: // folder leading to a mount point
+		out.Mode = syscall.S_IFDIR | 0o555
+	case f.Firmlink != "":
+		out.Mode = syscall.S_IFLNK | 0o777
+		out.Size = uint64(len(path.Join(FirmlinkRoot, f.Firmlink)))
+	case f.IsDir():
+		out.Mode = syscall.S_IFDIR | uint32(f.Mode.Perm())
+	case f.Mode&os.ModeSymlink != 0:
+		out.Mode = syscall.S_IFLNK | uint32(f.Mode.Perm())
+		out.Size = uint64(len(f.Link))
+	default:
+		out.Mode = syscall.S_IFREG | uint32(f.Mode.Perm())
+		out.Size = f.Size
+	}
+	if f != nil {
+		if f.Mode&os.ModeSetuid != 0 {
+			out.Mode |= syscall.S_ISUID
+		}
+		if f.Mode&os.ModeSetgid != 0 {
+			out.Mode |= syscall.S_ISGID
+		}
+		if f.Mode&os.ModeSticky != 0 {
+			out.Mode |= syscall.S_ISVTX
+		}
+		out.SetTimes(nil, &f.ModTime, &f.ModTime)
+	}
+	out.Blocks = (out.Size + 511) / 512
+}
+
+func (n *apfsNode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	fillAttr(n.file, &out.Attr)
+	return fs.OK
+}
+
+func (n *apfsNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	dir := path.Join(n.dir, name)
+	var child *apfsNode
+	if _, ok := n.mounts[dir]; ok {
+		child = newNode(dir, n.mounts)
+	} else if n.fsys != nil {
+		for _, ent := range n.fsys.children[n.file.id] {
+			if ent.name == name {
+				child = &apfsNode{fsys: n.fsys, file: n.fsys.file(ent.id, path.Join(n.file.Path, name)), dir: dir, mounts: n.mounts}
+				break
+			}
+		}
+	}
+	if child == nil && n.leadsToMount(dir) {
+		child = newNode(dir, n.mounts)
+	}
+	if child == nil {
+		return nil, syscall.ENOENT
+	}
+	fillAttr(child.file, &out.Attr)
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: out.Attr.Mode & syscall.S_IFMT}), fs.OK
+}
+
+func (n *apfsNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	var entries []fuse.DirEntry
+	seen := make(map[string]bool)
+	if n.fsys != nil {
+		for _, ent := range n.fsys.children[n.file.id] {
+			var attr fuse.Attr
+			fillAttr(n.fsys.file(ent.id, ent.name), &attr)
+			entries = append(entries, fuse.DirEntry{Name: ent.name, Mode: attr.Mode & syscall.S_IFMT})
+			seen[ent.name] = true
+		}
+	}
+	// add the folders leading to the volumes mounted below this one
+	for _, mp := range slices.Sorted(maps.Keys(n.mounts)) {
+		if mp == "" || (n.dir != "" && !strings.HasPrefix(mp, n.dir+"/")) {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(mp, n.dir), "/"), "/")
+		if !seen[name] {
+			entries = append(entries, fuse.DirEntry{Name: name, Mode: syscall.S_IFDIR})
+			seen[name] = true
+		}
+	}
+	return fs.NewListDirStream(entries), fs.OK
+}
+
+func (n *apfsNode) Readlink(ctx context.Context) ([]byte, syscall.Errno) {
+	switch {
+	case n.file == nil:
+		return nil, syscall.EINVAL
+	case n.file.Firmlink != "":
+		return []byte(path.Join(FirmlinkRoot, n.file.Firmlink)), fs.OK
+	case n.file.Mode&os.ModeSymlink != 0:
+		return []byte(n.file.Link), fs.OK
+	}
+	return nil, syscall.EINVAL
+}
+
+func (n *apfsNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if int(flags)&syscall.O_ACCMODE != syscall.O_RDONLY {
+		return nil, 0, syscall.EROFS
+	}
+	if n.file == nil || n.file.IsDir() {
+		return nil, 0, syscall.EISDIR
+	}
+	r, err := n.fsys.OpenFile(n.file)
+	if err != nil {
+		return nil, 0, syscall.EIO
+	}
+	return &apfsHandle{r: r}, fuse.FOPEN_KEEP_CACHE, fs.OK
+}
+
+// apfsHandle is an open file in a volume
+type apfsHandle struct {
+	r io.ReaderAt
+}
+
+var _ fs.FileReader = (*apfsHandle)(nil)
+
+func (h *apfsHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	n, err := h.r.ReadAt(dest, off)
+	if err != nil && err != io.EOF {
+		return nil, syscall.EIO
+	}
+	return fuse.ReadResultData(dest[:n]), fs.OK
+}
+
+// Mount serves the volumes read-only on mountpoint until ctx is canceled or the mountpoint is
+// unmounted externally (e.g. umount/fusermount -u); volumes are keyed by the folder (relative to
+// the mountpoint) they are mounted at (i.e. "" for the root filesystem and System/Cryptexes/OS for the SystemOS cryptex)
+func Mount(ctx context.Context, mountpoint string, volumes map[string]*FS) error {
+	mounts := make(map[string]*FS, len(volumes))
+	for dir, fsys := range volumes {
+		mounts[strings.Trim(path.Clean("/"+dir), "/")] = fsys
+	}
+	timeout := fuseCacheTimeout
+	opts := &fs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName:  "apfs",
+			Name:    "ipsw",
+			Options: []string{"ro"},
+		},
+		EntryTimeout: &timeout,
+		AttrTimeout:  &timeout,
+	}
+	server, err := fs.Mount(mountpoint, newNode("", mounts), opts)
+	if err != nil {
+		return fmt.Errorf("failed to mount %s: %w", mountpoint, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		if err := server.Unmount(); err != nil {
+			return fmt.Errorf("failed to unmount %s: %w", mountpoint, err)
+		}
+		<-done
+	case <-done:
+	}
+
+	return nil
+}
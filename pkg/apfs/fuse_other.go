@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package apfs
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mount serves the volumes read-only on mountpoint until ctx is canceled
+func Mount(ctx context.Context, mountpoint string, volumes map[string]*FS) error {
+	return fmt.Errorf("mounting APFS volumes over FUSE is only supported on darwin and linux")
+}
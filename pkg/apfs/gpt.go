@@ -0,0 +1,50 @@
+package apfs
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	gptSignature     = "EFI PART"
+	gptMaxPartitions = 128
+)
+
+// gptPartitions returns the offsets of the partitions in a GPT partitioned disk image
+// (i.e. the whole-disk images ASR restores, where the APFS container isn't the first partition)
+func gptPartitions(r io.ReaderAt) []int64 {
+	for _, sectorSize := range []int64{512, 4096} { // the header is in the second logical block
+		hdr := make([]byte, 92)
+		if _, err := r.ReadAt(hdr, sectorSize); err != nil || string(hdr[:8]) != gptSignature {
+			continue
+		}
+		entriesLBA := int64(binary.LittleEndian.Uint64(hdr[72:]))
+		count := min(binary.LittleEndian.Uint32(hdr[80:]), gptMaxPartitions)
+		entrySize := int64(binary.LittleEndian.Uint32(hdr[84:]))
+		if entrySize < 48 {
+			return nil
+		}
+		var offs []int64
+		entry := make([]byte, entrySize)
+		for i := range int64(count) {
+			if _, err := r.ReadAt(entry, entriesLBA*sectorSize+i*entrySize); err != nil {
+				break
+			}
+			if isZero(entry[:16]) { // unused entry
+				continue
+			}
+			offs = append(offs, int64(binary.LittleEndian.Uint64(entry[32:]))*sectorSize)
+		}
+		return offs
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,265 @@
+package apfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/blacktop/go-apfs/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	objPhysSize         = 32 // obj_phys_t
+	btreeNodeHeaderSize = 56 // obj_phys_t + btree_node_phys_t
+	btreeInfoSize       = 40 // btree_info_t (at the end of root nodes)
+	fileInfoDataHash    = 1  // APFS_FILE_INFO_DATA_HASH
+)
+
+var (
+	// ErrNotSealed is returned when reading the seal of a volume that isn't a Signed System Volume
+	ErrNotSealed = errors.New("volume is not sealed")
+	// ErrSealMismatch is returned when the file-system tree doesn't match the volume's seal
+	ErrSealMismatch = errors.New("file-system tree does not match the seal")
+)
+
+// Seal is the integrity metadata of a sealed volume
+type Seal struct {
+	Version   uint32 `json:"version"`
+	HashType  string `json:"hash_type"`
+	RootHash  string `json:"root_hash"` // hash of the file-system tree's root node
+	Broken    bool   `json:"broken,omitempty"`
+	BrokenXid uint64 `json:"broken_xid,omitempty"` // transaction that broke the seal
+
+	hashType uint32
+	rootHash []byte
+}
+
+// fileHash is the hash of a range of a data stream (a j_file_info_t record)
+type fileHash struct {
+	id     uint64 // data stream identifier
+	off    int64
+	length int64
+	hash   []byte
+}
+
+// hashFunc returns the hash function and size of an APFS hash type
+func hashFunc(typ uint32) (func() hash.Hash, int, string, error) {
+	switch typ {
+	case uint32(types.APFS_HASH_SHA256):
+		return sha256.New, types.APFS_HASH_CCSHA256_SIZE, "SHA256", nil
+	case uint32(types.APFS_HASH_SHA512_256):
+		return sha512.New512_256, types.APFS_HASH_CCSHA512_256_SIZE, "SHA512/256", nil
+	case uint32(types.APFS_HASH_SHA384):
+		return sha512.New384, types.APFS_HASH_CCSHA384_SIZE, "SHA384", nil
+	case uint32(types.APFS_HASH_SHA512):
+		return sha512.New, types.APFS_HASH_CCSHA512_SIZE, "SHA512", nil
+	}
+	return nil, 0, "", fmt.Errorf("unsupported seal hash type %#x", typ)
+}
+
+// readBlock reads and checksums the object at the block address
+func (fsys *FS) readBlock(addr uint64) ([]byte, error) {
+	block := make([]byte, types.BLOCK_SIZE)
+	if _, err := fsys.r.ReadAt(block, int64(addr*types.BLOCK_SIZE)); err != nil {
+		return nil, fmt.Errorf("failed to read block %#x: %v", addr, err)
+	}
+	if !types.VerifyChecksum(block) {
+		return nil, fmt.Errorf("block %#x: %w", addr, types.ErrBadBlockChecksum)
+	}
+	return block, nil
+}
+
+// Seal returns the volume's integrity metadata (the hash its file-system tree is sealed with)
+func (fsys *FS) Seal() (*Seal, error) {
+	if !fsys.Sealed || fsys.vol.IntegrityMetaOid == 0 {
+		return nil, ErrNotSealed
+	}
+	block, err := fsys.readBlock(uint64(fsys.vol.IntegrityMetaOid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read integrity metadata: %v", err)
+	}
+	if typ := binary.LittleEndian.Uint32(block[24:]) & uint32(types.OBJECT_TYPE_MASK); typ != uint32(types.OBJECT_TYPE_INTEGRITY_META) {
+		return nil, fmt.Errorf("object %#x is not integrity metadata (found type %#x)", fsys.vol.IntegrityMetaOid, typ)
+	}
+	seal := &Seal{
+		Version:  binary.LittleEndian.Uint32(block[objPhysSize:]),
+		hashType: binary.LittleEndian.Uint32(block[objPhysSize+8:]),
+	}
+	flags := binary.LittleEndian.Uint32(block[objPhysSize+4:])
+	seal.Broken = flags&types.APFS_SEAL_BROKEN != 0
+	seal.BrokenXid = binary.LittleEndian.Uint64(block[objPhysSize+16:])
+	_, size, name, err := hashFunc(seal.hashType)
+	if err != nil {
+		return nil, err
+	}
+	seal.HashType = name
+	off := int(binary.LittleEndian.Uint32(block[objPhysSize+12:]))
+	if off < objPhysSize || off+size > len(block) {
+		return nil, fmt.Errorf("invalid root hash offset %#x", off)
+	}
+	seal.rootHash = block[off : off+size]
+	seal.RootHash = hex.EncodeToString(seal.rootHash)
+	return seal, nil
+}
+
+// VerifySeal verifies the hashes of the file-system tree from the seal's root hash down to its leaves
+// (returning an error wrapping ErrSealMismatch if a node doesn't match) and, if data is true, the hashes
+// of the files' data; it returns the paths of the files whose data doesn't match
+func (fsys *FS) VerifySeal(ctx context.Context, data bool) ([]string, error) {
+	seal, err := fsys.Seal()
+	if err != nil {
+		return nil, err
+	}
+	if seal.Broken {
+		return nil, fmt.Errorf("%w: seal was broken by transaction %#x", ErrSealMismatch, seal.BrokenXid)
+	}
+	newHash, size, _, err := hashFunc(seal.hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []fileHash
+	physical := isPhysical(uint32(fsys.vol.RootTreeType))
+	var verify func(oid uint64, want []byte) error
+	verify = func(oid uint64, want []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		addr, err := fsys.nodeAddr(oid, physical)
+		if err != nil {
+			return err
+		}
+		block, err := fsys.readBlock(addr)
+		if err != nil {
+			return err
+		}
+		h := newHash()
+		h.Write(block)
+		if !bytes.Equal(h.Sum(nil), want) {
+			return fmt.Errorf("%w: node at block %#x has hash %x (expected %x)", ErrSealMismatch, addr, h.Sum(nil), want)
+		}
+		return walkRawNode(block, func(key, val []byte, leaf bool) error {
+			if !leaf { // btn_index_node_val_t
+				if len(val) < 8+size {
+					return fmt.Errorf("malformed index entry in node at block %#x", addr)
+				}
+				return verify(binary.LittleEndian.Uint64(val), val[8:8+size])
+			}
+			if !data || len(key) < 16 {
+				return nil
+			}
+			hdr := types.JKeyT{ObjIDAndType: binary.LittleEndian.Uint64(key)}
+			if hdr.GetType() != types.APFS_TYPE_FILE_INFO {
+				return nil
+			}
+			infoAndLba := binary.LittleEndian.Uint64(key[8:])
+			if infoAndLba>>types.J_FILE_INFO_TYPE_SHIFT != fileInfoDataHash || len(val) < 3 || len(val) < 3+int(val[2]) {
+				return nil
+			}
+			hashes = append(hashes, fileHash{ // j_file_data_hash_val_t
+				id:     hdr.GetID(),
+				off:    int64((infoAndLba & types.J_FILE_INFO_LBA_MASK) * types.BLOCK_SIZE),
+				length: int64(uint64(binary.LittleEndian.Uint16(val)) * types.BLOCK_SIZE),
+				hash:   val[3 : 3+int(val[2])],
+			})
+			return nil
+		})
+	}
+	if err := verify(uint64(fsys.vol.RootTreeOid), seal.rootHash); err != nil {
+		return nil, err
+	}
+	if !data {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	bad := make(map[uint64]bool)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(runtime.NumCPU())
+	for _, fh := range hashes {
+		eg.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			exts, ok := fsys.extents[fh.id]
+			if !ok {
+				if ino, found := fsys.nodes[fh.id]; found {
+					exts = fsys.extents[ino.private]
+				}
+			}
+			h := newHash()
+			er := &extentReader{r: fsys.r, extents: exts, size: fh.off + fh.length}
+			if _, err := io.Copy(h, io.NewSectionReader(er, fh.off, fh.length)); err != nil {
+				return fmt.Errorf("failed to read data stream %#x: %v", fh.id, err)
+			}
+			if !bytes.Equal(h.Sum(nil), fh.hash) {
+				mu.Lock()
+				bad[fh.id] = true
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	if len(bad) == 0 {
+		return nil, nil
+	}
+
+	var files []string
+	if err := fsys.Walk("/", func(f *File) error {
+		if ino, ok := fsys.nodes[f.id]; ok && (bad[f.id] || bad[ino.private] || bad[ino.rsrc]) {
+			files = append(files, f.Path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// walkRawNode calls fn with the raw key and value of every entry in a B-tree node block
+// (the file-system tree's nodes have variable size keys and values)
+func walkRawNode(block []byte, fn func(key, val []byte, leaf bool) error) error {
+	flags := binary.LittleEndian.Uint16(block[objPhysSize:])
+	nkeys := int(binary.LittleEndian.Uint32(block[objPhysSize+4:]))
+	tocOff := btreeNodeHeaderSize + int(binary.LittleEndian.Uint16(block[objPhysSize+8:]))
+	keyStart := tocOff + int(binary.LittleEndian.Uint16(block[objPhysSize+10:]))
+	valEnd := len(block)
+	if flags&uint16(types.BTNODE_ROOT) != 0 {
+		valEnd -= btreeInfoSize
+	}
+	if flags&uint16(types.BTNODE_FIXED_KV_SIZE) != 0 {
+		return fmt.Errorf("unsupported fixed key/value size B-tree node")
+	}
+	for i := range nkeys {
+		toc := tocOff + i*8 // kvloc_t
+		if toc+8 > keyStart {
+			return fmt.Errorf("malformed B-tree node table of contents")
+		}
+		koff := keyStart + int(binary.LittleEndian.Uint16(block[toc:]))
+		klen := int(binary.LittleEndian.Uint16(block[toc+2:]))
+		voff := valEnd - int(binary.LittleEndian.Uint16(block[toc+4:]))
+		vlen := int(binary.LittleEndian.Uint16(block[toc+6:]))
+		if koff+klen > valEnd || voff < keyStart || voff+vlen > valEnd {
+			return fmt.Errorf("malformed B-tree node entry %d", i)
+		}
+		if err := fn(block[koff:koff+klen], block[voff:voff+vlen], flags&uint16(types.BTNODE_LEAF) != 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package apfs
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/blacktop/go-apfs/pkg/adc"
+	"github.com/blacktop/go-apfs/pkg/disk/dmg"
+	lzfse "github.com/blacktop/lzfse-cgo"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	sectorSize     = 0x200
+	chunkCacheSize = 64
+)
+
+type chunk struct {
+	typ   uint32
+	off   int64 // offset in the disk image
+	size  int64 // size in the disk image
+	coff  int64 // offset in the DMG file
+	csize int64 // size in the DMG file
+}
+
+// udif is a read-only UDIF (.dmg) disk image that decompresses its chunks on demand
+type udif struct {
+	sr     *io.SectionReader
+	blocks []dmg.UDIFBlockData
+	chunks []chunk // sorted by disk offset
+	size   int64
+	cache  *lru.Cache[int, []byte]
+}
+
+func openUDIF(sr *io.SectionReader) (*udif, error) {
+	d, err := dmg.NewDMG(sr)
+	if err != nil {
+		return nil, err
+	}
+	u := &udif{
+		sr:     sr,
+		blocks: d.Blocks,
+		size:   int64(d.Footer.SectorCount * sectorSize),
+	}
+	for _, block := range d.Blocks {
+		for _, c := range block.Chunks {
+			switch c.Type {
+			case dmg.COMMENT, dmg.LAST_BLOCK:
+				continue
+			}
+			u.chunks = append(u.chunks, chunk{
+				typ:   uint32(c.Type),
+				off:   int64(c.DiskOffset),
+				size:  int64(c.DiskLength),
+				coff:  int64(c.CompressedOffset),
+				csize: int64(c.CompressedLength),
+			})
+		}
+	}
+	sort.Slice(u.chunks, func(i, j int) bool { return u.chunks[i].off < u.chunks[j].off })
+	u.cache, err = lru.New[int, []byte](chunkCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// partitions returns the offsets of the partitions (blkx blocks) in the disk image
+func (u *udif) partitions() []int64 {
+	var offs []int64
+	for _, block := range u.blocks {
+		offs = append(offs, int64(block.StartSector*sectorSize))
+	}
+	return offs
+}
+
+func (u *udif) decompress(idx int) ([]byte, error) {
+	if data, ok := u.cache.Get(idx); ok {
+		return data, nil
+	}
+	c := u.chunks[idx]
+	var data []byte
+	switch c.typ {
+	case uint32(dmg.ZERO_FILL), uint32(dmg.IGNORED):
+		data = make([]byte, c.size)
+	case uint32(dmg.UNCOMPRESSED):
+		data = make([]byte, c.size)
+		if _, err := u.sr.ReadAt(data, c.coff); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read uncompressed chunk at %#x: %v", c.coff, err)
+		}
+	default:
+		in := make([]byte, c.csize)
+		if _, err := u.sr.ReadAt(in, c.coff); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read compressed chunk at %#x: %v", c.coff, err)
+		}
+		switch c.typ {
+		case uint32(dmg.COMPRESS_ADC):
+			data = adc.DecompressADC(in)
+		case uint32(dmg.COMPRESS_ZLIB):
+			zr, err := zlib.NewReader(bytes.NewReader(in))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create zlib reader: %v", err)
+			}
+			defer zr.Close()
+			if data, err = io.ReadAll(zr); err != nil {
+				return nil, fmt.Errorf("failed to decompress zlib chunk: %v", err)
+			}
+		case uint32(dmg.COMPRESSS_BZ2):
+			var err error
+			if data, err = io.ReadAll(bzip2.NewReader(bytes.NewReader(in))); err != nil {
+				return nil, fmt.Errorf("failed to decompress bzip2 chunk: %v", err)
+			}
+		case uint32(dmg.COMPRESSS_LZFSE):
+			data = lzfse.DecodeBuffer(in)
+		default:
+			return nil, fmt.Errorf("unsupported DMG chunk type %#x", c.typ)
+		}
+	}
+	if int64(len(data)) < c.size { // pad short chunks
+		data = append(data, make([]byte, c.size-int64(len(data)))...)
+	}
+	u.cache.Add(idx, data)
+	return data, nil
+}
+
+// ReadAt reads from the decompressed disk image
+func (u *udif) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+	idx := sort.Search(len(u.chunks), func(i int) bool { return u.chunks[i].off+u.chunks[i].size > off })
+	for n < len(p) {
+		if off >= u.size {
+			return n, io.EOF
+		}
+		end := u.size
+		if idx < len(u.chunks) {
+			end = u.chunks[idx].off
+		}
+		if off < end { // hole between (or after the) chunks
+			gap := min(end-off, int64(len(p)-n))
+			clear(p[n : n+int(gap)])
+			n += int(gap)
+			off += gap
+			continue
+		}
+		c := u.chunks[idx]
+		data, err := u.decompress(idx)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], data[off-c.off:])
+		n += copied
+		off += int64(copied)
+		idx++
+	}
+	return n, nil
+}
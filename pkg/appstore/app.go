@@ -1,7 +1,6 @@
 package appstore
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -67,7 +66,7 @@ func (as *AppStore) GetApps() ([]App, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
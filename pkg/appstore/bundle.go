@@ -2,7 +2,6 @@ package appstore
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -88,7 +87,7 @@ func (as *AppStore) GetBundleIDs() ([]BundleID, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -133,7 +132,7 @@ func (as *AppStore) GetBundleID(id string) (*BundleID, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -179,7 +178,7 @@ func (as *AppStore) GetBundleIDApp(id string) (*AppResponse, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -224,7 +223,7 @@ func (as *AppStore) GetBundleIDProfiles(id string) (*ProfileResponse, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -269,7 +268,7 @@ func (as *AppStore) GetBundleIDCapabilities(id string) (*BundleIdCapabilitiesRes
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -326,7 +325,7 @@ func (as *AppStore) RegisterBundleID(name, id string) (*BundleIdResponse, error)
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -372,7 +371,7 @@ func (as *AppStore) DeleteBundleID(id string) (*BundleIdResponse, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
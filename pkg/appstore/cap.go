@@ -2,7 +2,6 @@ package appstore
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -179,7 +178,7 @@ func (as *AppStore) EnableCapability(id, ctype string) (*BundleIdCapability, err
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -2,7 +2,6 @@ package appstore
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -103,7 +102,7 @@ func (as *AppStore) GetCertificates() ([]Certificate, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -167,7 +166,7 @@ func (as *AppStore) CreateCertificate(ctype string, csrData string) (*Certificat
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -213,7 +212,7 @@ func (as *AppStore) RevokeCertificate(id string) error {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -2,7 +2,6 @@ package appstore
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -66,7 +65,7 @@ func (as *AppStore) GetDevices() ([]Device, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -126,7 +125,7 @@ func (as *AppStore) RegisterDevice(name, platform, udid string) (*Device, error)
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
@@ -195,7 +194,7 @@ func (as *AppStore) ModifyDevice(id, name, status string) (*Device, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(as.Proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: as.Insecure},
+			TLSClientConfig: download.TLSConfig(as.Insecure),
 		},
 	}
 
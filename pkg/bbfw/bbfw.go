@@ -0,0 +1,222 @@
+// Package bbfw parses the baseband firmware archives (.bbfw) found in IPSWs
+package bbfw
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ImageType is the role of an image inside a baseband firmware archive
+type ImageType string
+
+const (
+	Bootloader ImageType = "bootloader"
+	Firmware   ImageType = "firmware"
+	Mirror     ImageType = "mirror"
+	NVItems    ImageType = "nv"
+	Ticket     ImageType = "ticket"
+	Metadata   ImageType = "metadata"
+	Unknown    ImageType = "unknown"
+)
+
+var (
+	qcVersionRE  = regexp.MustCompile(`QC_IMAGE_VERSION_STRING=([[:print:]]+)`)
+	oemVersionRE = regexp.MustCompile(`OEM_IMAGE_VERSION_STRING=([[:print:]]+)`)
+	// Intel/Apple baseband builds embed a banner like "ICE19.51.00"
+	iceVersionRE = regexp.MustCompile(`\b(?:ICE|MAV|SAV)[0-9]{2}\.[0-9]{2}\.[0-9]{2}(?:[-_.][[:alnum:]]+)*`)
+
+	bootloaderRE = regexp.MustCompile(`(?i)^(restore)?(sbl[0-9]?|xbl|pbl|dbl|osbl|prg|psi|ebl|abl|hyp|tz|rpm|aop|uefi)`)
+	firmwareRE   = regexp.MustCompile(`(?i)^(qdsp6sw|mpss|modem|apps|dsp[0-9]?|acdb|fw|mcfg|wlan|sdi)`)
+	nvRE         = regexp.MustCompile(`(?i)(qpnv|\.nvm$|\.eep$|nvitem|\.nv$)`)
+)
+
+// Image is a single file inside a baseband firmware archive
+type Image struct {
+	Name    string    `json:"name"`
+	Type    ImageType `json:"type"`
+	Size    uint64    `json:"size"`
+	Version string    `json:"version,omitempty"`
+	Machine string    `json:"machine,omitempty"`
+
+	zf *zip.File
+}
+
+func (i Image) String() string {
+	s := fmt.Sprintf("%-10s %-36s %8s", i.Type, i.Name, humanize.Bytes(i.Size))
+	if len(i.Machine) > 0 {
+		s += fmt.Sprintf("  (%s)", i.Machine)
+	}
+	if len(i.Version) > 0 {
+		s += "  " + i.Version
+	}
+	return s
+}
+
+// BBFW is a parsed baseband firmware archive
+type BBFW struct {
+	Name   string  `json:"name"`
+	Images []Image `json:"images"`
+
+	zr *zip.ReadCloser
+}
+
+func (b BBFW) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Baseband Firmware: %s\n", b.Name))
+	for _, img := range b.Images {
+		sb.WriteString("  " + img.String() + "\n")
+	}
+	return sb.String()
+}
+
+// Close closes the underlying archive
+func (b *BBFW) Close() error {
+	return b.zr.Close()
+}
+
+func classify(name string) ImageType {
+	base := strings.ToLower(filepath.Base(name))
+	switch {
+	case strings.Contains(base, "mirror") || strings.Contains(base, "backup"):
+		return Mirror
+	case strings.Contains(base, "ticket") || strings.HasSuffix(base, ".der"):
+		return Ticket
+	case strings.HasSuffix(base, ".plist") || strings.HasSuffix(base, ".txt") || strings.HasSuffix(base, ".xml"):
+		return Metadata
+	case nvRE.MatchString(base):
+		return NVItems
+	case bootloaderRE.MatchString(base):
+		return Bootloader
+	case firmwareRE.MatchString(base) || strings.HasSuffix(base, ".fls") || strings.HasSuffix(base, ".elf"):
+		return Firmware
+	default:
+		return Unknown
+	}
+}
+
+func version(dat []byte) string {
+	if m := qcVersionRE.FindSubmatch(dat); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	if m := oemVersionRE.FindSubmatch(dat); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	if m := iceVersionRE.Find(dat); m != nil {
+		return string(m)
+	}
+	return ""
+}
+
+func machine(dat []byte) string {
+	if !bytes.HasPrefix(dat, []byte(elf.ELFMAG)) {
+		return ""
+	}
+	f, err := elf.NewFile(bytes.NewReader(dat))
+	if err != nil {
+		return "ELF"
+	}
+	defer f.Close()
+	if f.Machine == 164 { // EM_QDSP6
+		return "ELF Hexagon"
+	}
+	return "ELF " + strings.TrimPrefix(f.Machine.String(), "EM_")
+}
+
+// Open opens and parses a baseband firmware archive
+func Open(path string) (*BBFW, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bbfw %s: %v", path, err)
+	}
+
+	bb := &BBFW{
+		Name: filepath.Base(path),
+		zr:   zr,
+	}
+
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		img := Image{
+			Name: zf.Name,
+			Type: classify(zf.Name),
+			Size: zf.UncompressedSize64,
+			zf:   zf,
+		}
+		if img.Type != Metadata {
+			rc, err := zf.Open()
+			if err != nil {
+				zr.Close()
+				return nil, fmt.Errorf("failed to open %s in bbfw: %v", zf.Name, err)
+			}
+			dat, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				zr.Close()
+				return nil, fmt.Errorf("failed to read %s in bbfw: %v", zf.Name, err)
+			}
+			img.Version = version(dat)
+			img.Machine = machine(dat)
+		}
+		bb.Images = append(bb.Images, img)
+	}
+
+	sort.SliceStable(bb.Images, func(i, j int) bool {
+		return bb.Images[i].Type < bb.Images[j].Type
+	})
+
+	return bb, nil
+}
+
+// Extract writes the images matching the pattern (all images if empty) to the output folder
+func (b *BBFW) Extract(pattern, output string) ([]string, error) {
+	var out []string
+
+	var re *regexp.Regexp
+	if len(pattern) > 0 {
+		var err error
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regex '%s': %v", pattern, err)
+		}
+	}
+
+	for _, img := range b.Images {
+		if re != nil && !re.MatchString(img.Name) {
+			continue
+		}
+		fname := filepath.Join(output, filepath.Clean("/"+img.Name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %v", filepath.Dir(fname), err)
+		}
+		rc, err := img.zf.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s in bbfw: %v", img.Name, err)
+		}
+		of, err := os.Create(fname)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("failed to create file %s: %v", fname, err)
+		}
+		_, err = io.Copy(of, rc)
+		rc.Close()
+		of.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to write file %s: %v", fname, err)
+		}
+		out = append(out, fname)
+	}
+
+	return out, nil
+}
@@ -517,6 +517,16 @@ type Frame struct {
 	Slide          uint64 `json:"slide,omitempty"`
 }
 
+type UsedImage struct {
+	Arch   string `json:"arch,omitempty"`
+	Base   uint64 `json:"base,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Size   uint64 `json:"size,omitempty"`
+	Source string `json:"source,omitempty"`
+	UUID   string `json:"uuid,omitempty"`
+}
+
 type PanicFrame struct {
 	ImageIndex     uint64 `json:"imageIndex,omitempty"`
 	ImageName      string `json:"imageName,omitempty"`
@@ -614,16 +624,8 @@ type IPSPayload struct {
 	LastExceptionBacktrace        []Frame      `json:"lastExceptionBacktrace,omitempty"`
 	FaultingThread                int          `json:"faultingThread,omitempty"`
 	Threads                       []UserThread `json:"threads,omitempty"`
-	UsedImages                    []struct {
-		Arch   string `json:"arch,omitempty"`
-		Base   uint64 `json:"base,omitempty"`
-		Name   string `json:"name,omitempty"`
-		Path   string `json:"path,omitempty"`
-		Size   uint64 `json:"size,omitempty"`
-		Source string `json:"source,omitempty"`
-		UUID   string `json:"uuid,omitempty"`
-	} `json:"usedImages,omitempty"`
-	SharedCache struct {
+	UsedImages                    []UsedImage  `json:"usedImages,omitempty"`
+	SharedCache                   struct {
 		Base uint64 `json:"base,omitempty"`
 		Size uint64 `json:"size,omitempty"`
 		UUID string `json:"uuid,omitempty"`
@@ -730,6 +732,21 @@ func demangleSym(do bool, in string) string {
 	return in
 }
 
+// Panic returns the parsed panic string of a panic (BugType=210) crashlog
+func (i *Ips) Panic() (*Panic210, error) {
+	if i.Payload.panic210 == nil {
+		if len(i.Payload.PanicString) == 0 {
+			return nil, fmt.Errorf("crashlog has no panic string")
+		}
+		p, err := parsePanicString210(i.Payload.PanicString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse panic string: %w", err)
+		}
+		i.Payload.panic210 = p
+	}
+	return i.Payload.panic210, nil
+}
+
 func (i *Ips) Symbolicate210(ipswPath string) (err error) {
 
 	i.Payload.panic210, err = parsePanicString210(i.Payload.PanicString)
@@ -1298,6 +1315,255 @@ func colorVMSummary(in string) string {
 	return in
 }
 
+// frames309 returns the frames of a crash (BugType=309) crashlog's threads and last exception backtrace
+func (i *Ips) frames309() []*Frame {
+	var frames []*Frame
+	for tid := range i.Payload.Threads {
+		for idx := range i.Payload.Threads[tid].Frames {
+			frames = append(frames, &i.Payload.Threads[tid].Frames[idx])
+		}
+	}
+	for idx := range i.Payload.LastExceptionBacktrace {
+		frames = append(frames, &i.Payload.LastExceptionBacktrace[idx])
+	}
+	return frames
+}
+
+// unsymbolicated309 returns the used images of a crash (BugType=309) crashlog's frames the OS didn't symbolicate
+func (i *Ips) unsymbolicated309() []UsedImage {
+	var imgs []UsedImage
+	for _, f := range i.frames309() {
+		if len(f.Symbol) > 0 || f.ImageIndex >= uint64(len(i.Payload.UsedImages)) {
+			continue
+		}
+		img := i.Payload.UsedImages[f.ImageIndex]
+		if len(img.UUID) == 0 { // absolute
+			continue
+		}
+		if !slices.ContainsFunc(imgs, func(u UsedImage) bool { return strings.EqualFold(u.UUID, img.UUID) }) {
+			imgs = append(imgs, img)
+		}
+	}
+	return imgs
+}
+
+// inSharedCache returns true if the used image of a crash (BugType=309) crashlog is a dyld_shared_cache dylib
+func (i *Ips) inSharedCache(img UsedImage) bool {
+	sc := i.Payload.SharedCache
+	return sc.Size > 0 && sc.Base <= img.Base && img.Base < sc.Base+sc.Size
+}
+
+// symbolicate309 symbolicates the frames of a crash (BugType=309) crashlog the OS didn't symbolicate with lookup,
+// which returns the symbol containing the (slid) address in the used image and the address' offset from it
+func (i *Ips) symbolicate309(lookup func(img UsedImage, addr uint64) (string, uint64, error)) {
+	for _, f := range i.frames309() {
+		if len(f.Symbol) > 0 || f.ImageIndex >= uint64(len(i.Payload.UsedImages)) {
+			continue
+		}
+		img := i.Payload.UsedImages[f.ImageIndex]
+		if len(img.UUID) == 0 { // absolute
+			continue
+		}
+		sym, loc, err := lookup(img, img.Base+f.ImageOffset)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"img":  img.Name,
+				"uuid": img.UUID,
+			}).Debugf("failed to find symbol for image offset %#x: %v", f.ImageOffset, err)
+			continue
+		}
+		f.Symbol = demangleSym(i.Config.Demangle, sym)
+		f.SymbolLocation = loc
+	}
+}
+
+// Symbolicate309 symbolicates the frames of a crash (BugType=309) crashlog the OS didn't symbolicate
+// with the MachOs (and dyld_shared_cache) in the IPSW and the extra MachOs folder
+func (i *Ips) Symbolicate309(ipswPath string) error {
+	var machos, dylibs []UsedImage
+	for _, img := range i.unsymbolicated309() {
+		if i.inSharedCache(img) {
+			dylibs = append(dylibs, img)
+		} else {
+			machos = append(machos, img)
+		}
+	}
+
+	/* SYMBOLICATE MACHOS */
+	machoFuncMap := make(map[string][]types.Function) // UUID -> slid functions
+	if len(machos) > 0 {
+		handler := func(path string, m *macho.File) error {
+			if m.UUID() == nil {
+				return nil
+			}
+			for _, img := range machos {
+				if _, ok := machoFuncMap[img.UUID]; ok || !strings.EqualFold(img.UUID, m.UUID().UUID.String()) {
+					continue
+				}
+				slide := img.Base - m.GetBaseAddress()
+				for _, fn := range m.GetFunctions() {
+					if syms, err := m.FindAddressSymbols(fn.StartAddr); err == nil {
+						for _, sym := range syms {
+							fn.Name = sym.Name
+						}
+					}
+					fn.StartAddr += slide
+					fn.EndAddr += slide
+					if fn.Name == "" {
+						fn.Name = fmt.Sprintf("func_%x", fn.StartAddr)
+					}
+					machoFuncMap[img.UUID] = append(machoFuncMap[img.UUID], fn)
+				}
+			}
+			if len(machoFuncMap) == len(machos) {
+				return ErrDone // break
+			}
+			return nil
+		}
+		if i.Config.ExtrasDir != "" {
+			if err := search.ForEachMacho(i.Config.ExtrasDir, handler); err != nil && !errors.Is(err, ErrDone) {
+				return fmt.Errorf("failed to symbolicate: %w", err)
+			}
+		}
+		if len(machoFuncMap) < len(machos) {
+			if err := search.ForEachMachoInIPSW(ipswPath, i.Config.PemDB, handler); err != nil && !errors.Is(err, ErrDone) {
+				return fmt.Errorf("failed to symbolicate: %w", err)
+			}
+		}
+	}
+
+	lookupMacho := func(img UsedImage, addr uint64) (string, uint64, error) {
+		fns, ok := machoFuncMap[img.UUID]
+		if !ok {
+			return "", 0, fmt.Errorf("MachO not found")
+		}
+		for _, fn := range fns {
+			if fn.StartAddr <= addr && addr < fn.EndAddr {
+				return fn.Name, addr - fn.StartAddr, nil
+			}
+		}
+		return "", 0, fmt.Errorf("function not found")
+	}
+
+	/* SYMBOLICATE DSC DYLIBS */
+	lookupDylib := func(UsedImage, uint64) (string, uint64, error) {
+		return "", 0, fmt.Errorf("dyld_shared_cache %s not found", i.Payload.SharedCache.UUID)
+	}
+	if len(dylibs) > 0 {
+		ctx, fs, err := dsc.OpenFromIPSW(ipswPath, i.Config.PemDB, false, true)
+		if err != nil {
+			return fmt.Errorf("failed to open DSC from IPSW: %w", err)
+		}
+		defer func() {
+			for _, f := range fs {
+				f.Close()
+			}
+			ctx.Unmount()
+		}()
+		for _, f := range fs {
+			if !strings.EqualFold(f.UUID.String(), i.Payload.SharedCache.UUID) {
+				continue // skip to next DSC
+			}
+			slide := i.Payload.SharedCache.Base - f.Headers[f.UUID].SharedRegionStart
+			parsed := make(map[string]bool)
+			lookupDylib = func(_ UsedImage, addr uint64) (string, uint64, error) {
+				addr -= slide
+				img, err := f.GetImageContainingVMAddr(addr)
+				if err != nil {
+					return "", 0, err
+				}
+				if !parsed[img.Name] {
+					img.ParsePublicSymbols(false)
+					img.ParseLocalSymbols(false)
+					parsed[img.Name] = true
+				}
+				m, err := img.GetMacho()
+				if err != nil {
+					return "", 0, fmt.Errorf("failed to get macho from image: %w", err)
+				}
+				fn, err := m.GetFunctionForVMAddr(addr)
+				if err != nil {
+					return "", 0, err
+				}
+				if sym, ok := f.AddressToSymbol[fn.StartAddr]; ok {
+					return sym, addr - fn.StartAddr, nil
+				}
+				return fmt.Sprintf("func_%x", fn.StartAddr), addr - fn.StartAddr, nil
+			}
+		}
+	}
+
+	i.symbolicate309(func(img UsedImage, addr uint64) (string, uint64, error) {
+		if i.inSharedCache(img) {
+			return lookupDylib(img, addr)
+		}
+		return lookupMacho(img, addr)
+	})
+
+	return nil
+}
+
+// Symbolicate309WithDatabase symbolicates the frames of a crash (BugType=309) crashlog the OS didn't symbolicate
+// with the symbol server's database
+func (i *Ips) Symbolicate309WithDatabase(dbURL string) error {
+
+	db := server.NewServer(dbURL)
+
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed symbolicate crash 309: %w", err)
+	}
+
+	if ok, err := db.HasIPSW(i.Header.Version(), i.Header.Build(), i.Payload.Product); err != nil {
+		return fmt.Errorf("failed symbolicate crash 309: %w", err)
+	} else {
+		if !ok {
+			need := fmt.Sprintf("%s (%s) for %s", i.Header.Version(), i.Header.Build(), i.Payload.Product)
+			return fmt.Errorf("failed symbolicate crash 309: required IPSW not found in symbol server database; need %s", need)
+		}
+	}
+
+	var dscUUID string
+	var slide uint64
+	if i.Payload.SharedCache.Size > 0 {
+		if cache, err := db.GetDSC(strings.ToUpper(i.Payload.SharedCache.UUID)); err == nil {
+			dscUUID = cache.UUID
+			slide = i.Payload.SharedCache.Base - cache.SharedRegionStart
+		} else {
+			log.WithField("uuid", i.Payload.SharedCache.UUID).Debug("failed to find DSC for uuid")
+		}
+	}
+
+	i.symbolicate309(func(img UsedImage, addr uint64) (string, uint64, error) {
+		if i.inSharedCache(img) {
+			if len(dscUUID) == 0 {
+				return "", 0, fmt.Errorf("dyld_shared_cache %s not found", i.Payload.SharedCache.UUID)
+			}
+			addr -= slide
+			dimg, err := db.GetDSCImage(dscUUID, addr)
+			if err != nil {
+				return "", 0, err
+			}
+			sym, err := db.GetSymbol(dimg.UUID, addr)
+			if err != nil {
+				return "", 0, err
+			}
+			return sym.GetName(), addr - sym.Start, nil
+		}
+		m, err := db.GetMachO(strings.ToUpper(img.UUID))
+		if err != nil {
+			return "", 0, err
+		}
+		addr -= img.Base - m.TextStart
+		sym, err := db.GetSymbol(strings.ToUpper(img.UUID), addr)
+		if err != nil {
+			return "", 0, err
+		}
+		return sym.GetName(), addr - sym.Start, nil
+	})
+
+	return nil
+}
+
 func (i *Ips) String() string {
 	var out string
 
@@ -0,0 +1,124 @@
+package devicetree
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// forEachNode calls fn for every node of the DeviceTree with its path (i.e. /device-tree/arm-io/uart0)
+// (sibling nodes with the same name get an @<index> suffix)
+func (dtree *DeviceTree) forEachNode(fn func(path string, props Properties)) {
+	var walk func(node DeviceTree, parent string)
+	walk = func(node DeviceTree, parent string) {
+		for name, props := range node {
+			path := parent + "/" + name
+			fn(path, props)
+			children, _ := props["children"].([]DeviceTree)
+			seen := make(map[string]int)
+			for _, child := range children {
+				for cname, cprops := range child {
+					if n := seen[cname]; n > 0 {
+						child = DeviceTree{fmt.Sprintf("%s@%d", cname, n): cprops}
+					}
+					seen[cname]++
+				}
+				walk(child, path)
+			}
+		}
+	}
+	walk(*dtree, "")
+}
+
+// Flatten returns the properties of every node of the DeviceTree by node path
+func (dtree *DeviceTree) Flatten() map[string]Properties {
+	nodes := make(map[string]Properties)
+	dtree.forEachNode(func(path string, props Properties) {
+		flat := make(Properties, len(props))
+		for k, v := range props {
+			if k != "children" {
+				flat[k] = v
+			}
+		}
+		nodes[path] = flat
+	})
+	return nodes
+}
+
+// FormatValue returns a property value in a human readable form
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := make([]string, 0, len(v))
+		for _, s := range v {
+			quoted = append(quoted, strconv.Quote(s))
+		}
+		return strings.Join(quoted, ", ")
+	case int, int16, int32, int64:
+		return fmt.Sprintf("%d", v)
+	case uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%#x", v)
+	default:
+		if dat, err := json.Marshal(v); err == nil {
+			return string(dat)
+		}
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// dtsValue returns a property value as DTS
+func dtsValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string, []string:
+		return " = " + FormatValue(v)
+	case int, int16, int32, uint8, uint16, uint32:
+		return fmt.Sprintf(" = <%#x>", v)
+	case int64:
+		return fmt.Sprintf(" = /bits/ 64 <%#x>", uint64(v))
+	case uint64:
+		return fmt.Sprintf(" = /bits/ 64 <%#x>", v)
+	default: // parsed structures (i.e. reg, pmap-io-ranges)
+		return " = " + strconv.Quote(FormatValue(v))
+	}
+}
+
+// DTS returns the DeviceTree as device tree source (the root node is '/')
+func (dtree *DeviceTree) DTS() string {
+	var out strings.Builder
+	out.WriteString("/dts-v1/;\n")
+
+	var write func(node DeviceTree, depth int)
+	write = func(node DeviceTree, depth int) {
+		for _, name := range slices.Sorted(maps.Keys(node)) {
+			props := node[name]
+			indent := strings.Repeat("\t", depth)
+			if depth == 0 {
+				name = "/"
+			}
+			out.WriteString(fmt.Sprintf("\n%s%s {\n", indent, name))
+			for _, k := range slices.Sorted(maps.Keys(props)) {
+				if k == "children" {
+					continue
+				}
+				out.WriteString(fmt.Sprintf("%s\t%s%s;\n", indent, k, dtsValue(props[k])))
+			}
+			children, _ := props["children"].([]DeviceTree)
+			for _, child := range children {
+				write(child, depth+1)
+			}
+			out.WriteString(fmt.Sprintf("%s};\n", indent))
+		}
+	}
+	write(*dtree, 0)
+
+	return out.String()
+}
@@ -0,0 +1,122 @@
+package devicetree
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	colorAdded   = color.New(color.FgGreen).SprintFunc()
+	colorRemoved = color.New(color.FgRed).SprintFunc()
+	colorChanged = color.New(color.FgYellow).SprintFunc()
+	colorNode    = color.New(color.Bold).SprintFunc()
+)
+
+// PropertyChange is a node property that was added, removed or changed
+type PropertyChange struct {
+	Node     string `json:"node"`
+	Property string `json:"property"`
+	Old      any    `json:"old,omitempty"`
+	New      any    `json:"new,omitempty"`
+
+	op byte // +, - or ~
+}
+
+// Diff is the diff of two DeviceTrees
+type Diff struct {
+	NewNodes          []string          `json:"new_nodes,omitempty"`
+	RemovedNodes      []string          `json:"removed_nodes,omitempty"`
+	NewProperties     []*PropertyChange `json:"new_properties,omitempty"`
+	RemovedProperties []*PropertyChange `json:"removed_properties,omitempty"`
+	ChangedProperties []*PropertyChange `json:"changed_properties,omitempty"`
+}
+
+// Empty returns true if the DeviceTrees are the same
+func (d *Diff) Empty() bool {
+	return d == nil || len(d.NewNodes)+len(d.RemovedNodes)+
+		len(d.NewProperties)+len(d.RemovedProperties)+len(d.ChangedProperties) == 0
+}
+
+// Compare compares two DeviceTrees by node path and property
+// (the properties of new and removed nodes are not listed)
+func Compare(prev, next *DeviceTree) *Diff {
+	d := &Diff{}
+	oldNodes, newNodes := prev.Flatten(), next.Flatten()
+	for path, props := range newNodes {
+		oldProps, ok := oldNodes[path]
+		if !ok {
+			d.NewNodes = append(d.NewNodes, path)
+			continue
+		}
+		for k, v := range props {
+			if ov, ok := oldProps[k]; !ok {
+				d.NewProperties = append(d.NewProperties, &PropertyChange{Node: path, Property: k, New: v, op: '+'})
+			} else if !reflect.DeepEqual(ov, v) {
+				d.ChangedProperties = append(d.ChangedProperties, &PropertyChange{Node: path, Property: k, Old: ov, New: v, op: '~'})
+			}
+		}
+		for k, v := range oldProps {
+			if _, ok := props[k]; !ok {
+				d.RemovedProperties = append(d.RemovedProperties, &PropertyChange{Node: path, Property: k, Old: v, op: '-'})
+			}
+		}
+	}
+	for path := range oldNodes {
+		if _, ok := newNodes[path]; !ok {
+			d.RemovedNodes = append(d.RemovedNodes, path)
+		}
+	}
+	slices.Sort(d.NewNodes)
+	slices.Sort(d.RemovedNodes)
+	for _, changes := range [][]*PropertyChange{d.NewProperties, d.RemovedProperties, d.ChangedProperties} {
+		slices.SortFunc(changes, func(a, b *PropertyChange) int {
+			return cmp.Or(strings.Compare(a.Node, b.Node), strings.Compare(a.Property, b.Property))
+		})
+	}
+	return d
+}
+
+func (d *Diff) String() string {
+	var out strings.Builder
+	if len(d.NewNodes) > 0 {
+		out.WriteString(colorNode(fmt.Sprintf("NEW Nodes (%d):\n", len(d.NewNodes))))
+		for _, node := range d.NewNodes {
+			out.WriteString(colorAdded("+ "+node) + "\n")
+		}
+		out.WriteString("\n")
+	}
+	if len(d.RemovedNodes) > 0 {
+		out.WriteString(colorNode(fmt.Sprintf("Removed Nodes (%d):\n", len(d.RemovedNodes))))
+		for _, node := range d.RemovedNodes {
+			out.WriteString(colorRemoved("- "+node) + "\n")
+		}
+		out.WriteString("\n")
+	}
+	// group the property changes by node
+	changes := slices.Concat(d.NewProperties, d.RemovedProperties, d.ChangedProperties)
+	slices.SortStableFunc(changes, func(a, b *PropertyChange) int { return strings.Compare(a.Node, b.Node) })
+	if len(changes) > 0 {
+		out.WriteString(colorNode(fmt.Sprintf("Changed Properties (%d):\n", len(changes))))
+	}
+	var node string
+	for _, c := range changes {
+		if c.Node != node {
+			node = c.Node
+			out.WriteString(colorNode(node) + "\n")
+		}
+		switch c.op {
+		case '+':
+			out.WriteString(colorAdded(fmt.Sprintf("  + %s: %s", c.Property, FormatValue(c.New))) + "\n")
+		case '-':
+			out.WriteString(colorRemoved(fmt.Sprintf("  - %s: %s", c.Property, FormatValue(c.Old))) + "\n")
+		default:
+			out.WriteString(colorChanged(fmt.Sprintf("  ~ %s: %s -> %s", c.Property, FormatValue(c.Old), FormatValue(c.New))) + "\n")
+		}
+	}
+	return out.String()
+}
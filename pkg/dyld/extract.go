@@ -17,6 +17,7 @@ import (
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/aea"
+	"github.com/blacktop/ipsw/pkg/apfs"
 	"github.com/blacktop/ipsw/pkg/info"
 	"github.com/blacktop/ipsw/pkg/ota/ridiff"
 	"github.com/pkg/errors"
@@ -65,6 +66,26 @@ func GetDscPathsInMount(mountPoint string, driverKit, all bool) ([]string, error
 	return matches, nil
 }
 
+// GetDscPathsInAPFS returns the paths of the dyld_shared_caches in an (unmounted) APFS volume
+func GetDscPathsInAPFS(fsys *apfs.FS, driverKit, all bool) ([]string, error) {
+	re := regexp.MustCompile("^/" + CacheRegex)
+	if driverKit {
+		re = regexp.MustCompile("^/" + DriverKitCacheRegex)
+	} else if all {
+		re = regexp.MustCompile("^/" + CacheUberRegex)
+	}
+	var matches []string
+	if err := fsys.Walk("/", func(f *apfs.File) error {
+		if !f.IsDir() && re.MatchString(f.Path) {
+			matches = append(matches, f.Path)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to walk APFS volume: %v", err)
+	}
+	return matches, nil
+}
+
 func ExtractFromDMG(i *info.Info, dmgPath, destPath, pemDB string, arches []string, driverkit, all bool) ([]string, error) {
 
 	if filepath.Ext(dmgPath) == ".aea" {
@@ -80,23 +101,40 @@ func ExtractFromDMG(i *info.Info, dmgPath, destPath, pemDB string, arches []stri
 		defer os.Remove(dmgPath)
 	}
 
-	utils.Indent(log.Info, 2)(fmt.Sprintf("Mounting DMG %s", dmgPath))
-	var alreadyMounted bool
-	mountPoint, alreadyMounted, err := utils.MountDMG(dmgPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to IPSW FS dmg: %v", err)
-	}
-	if alreadyMounted {
-		utils.Indent(log.Debug, 3)(fmt.Sprintf("%s already mounted", dmgPath))
+	var matches []string
+	var fsys *apfs.FS // read the DMG without mounting it on Linux/Windows
+	if runtime.GOOS == "darwin" {
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Mounting DMG %s", dmgPath))
+		mountPoint, alreadyMounted, err := utils.MountDMG(dmgPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to IPSW FS dmg: %v", err)
+		}
+		if alreadyMounted {
+			utils.Indent(log.Debug, 3)(fmt.Sprintf("%s already mounted", dmgPath))
+		} else {
+			defer func() {
+				utils.Indent(log.Debug, 2)(fmt.Sprintf("Unmounting %s", dmgPath))
+				if err := utils.Retry(3, 2*time.Second, func() error {
+					return utils.Unmount(mountPoint, true)
+				}); err != nil {
+					log.Errorf("failed to unmount DMG %s at %s: %v", dmgPath, mountPoint, err)
+				}
+			}()
+		}
+		if matches, err = GetDscPathsInMount(mountPoint, driverkit, all); err != nil {
+			return nil, err
+		}
 	} else {
-		defer func() {
-			utils.Indent(log.Debug, 2)(fmt.Sprintf("Unmounting %s", dmgPath))
-			if err := utils.Retry(3, 2*time.Second, func() error {
-				return utils.Unmount(mountPoint, true)
-			}); err != nil {
-				log.Errorf("failed to unmount DMG %s at %s: %v", dmgPath, mountPoint, err)
-			}
-		}()
+		utils.Indent(log.Info, 2)(fmt.Sprintf("Parsing DMG %s", dmgPath))
+		var err error
+		fsys, err = apfs.Open(dmgPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse IPSW FS dmg: %v", err)
+		}
+		defer fsys.Close()
+		if matches, err = GetDscPathsInAPFS(fsys, driverkit, all); err != nil {
+			return nil, err
+		}
 	}
 
 	if runtime.GOOS == "darwin" {
@@ -115,11 +153,6 @@ func ExtractFromDMG(i *info.Info, dmgPath, destPath, pemDB string, arches []stri
 		}
 	}
 
-	matches, err := GetDscPathsInMount(mountPoint, driverkit, all)
-	if err != nil {
-		return nil, err
-	}
-
 	if utils.StrSliceContains(i.Plists.BuildManifest.SupportedProductTypes, "mac") { // Is macOS IPSW
 		if len(arches) == 0 {
 			selMatches := []string{}
@@ -161,7 +194,15 @@ func ExtractFromDMG(i *info.Info, dmgPath, destPath, pemDB string, arches []stri
 		dyldDest := filepath.Join(destPath, filepath.Base(match))
 		// TODO: remove this (was commented out because I added --json to `ipsw extract` so the higher level func is now where this is printed)
 		// utils.Indent(log.Info, 3)(fmt.Sprintf("Extracting %s to %s", filepath.Base(match), dyldDest))
-		if err := utils.Copy(match, dyldDest); err != nil {
+		if fsys != nil {
+			f, err := fsys.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if err := fsys.Extract(f, dyldDest); err != nil {
+				return nil, fmt.Errorf("failed to extract %s to %s: %v", match, dyldDest, err)
+			}
+		} else if err := utils.Copy(match, dyldDest); err != nil {
 			return nil, fmt.Errorf("failed to copy %s to %s: %v", match, dyldDest, err)
 		}
 		artifacts = append(artifacts, dyldDest)
@@ -173,10 +214,6 @@ func ExtractFromDMG(i *info.Info, dmgPath, destPath, pemDB string, arches []stri
 // Extract extracts dyld_shared_cache from IPSW
 func Extract(ipsw, destPath, pemDB string, arches []string, driverkit, all bool) ([]string, error) {
 
-	if runtime.GOOS == "windows" {
-		return nil, fmt.Errorf("dyld extraction is not supported on Windows (see github.com/blacktop/go-apfs)")
-	}
-
 	i, err := info.Parse(ipsw)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse IPSW: %v", err)
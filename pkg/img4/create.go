@@ -0,0 +1,80 @@
+package img4
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// Create stitches an IM4P payload, an IM4M manifest and an optional IM4R restore info together into an IMG4
+func Create(im4p, im4m, im4r []byte) ([]byte, error) {
+	var p asn1.RawValue
+	if _, err := asn1.Unmarshal(im4p, &p); err != nil {
+		return nil, fmt.Errorf("failed to ASN.1 parse IM4P: %v", err)
+	}
+	var m asn1.RawValue
+	if _, err := asn1.Unmarshal(im4m, &m); err != nil {
+		return nil, fmt.Errorf("failed to ASN.1 parse IM4M: %v", err)
+	}
+
+	name, err := asn1.MarshalWithParams("IMG4", "ia5")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal IMG4 name: %v", err)
+	}
+
+	body := append(name, p.FullBytes...)
+	// IM4M is wrapped in an explicit context-specific [0] tag
+	manifest, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: m.FullBytes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal IM4M: %v", err)
+	}
+	body = append(body, manifest...)
+
+	if len(im4r) > 0 {
+		var r asn1.RawValue
+		if _, err := asn1.Unmarshal(im4r, &r); err != nil {
+			return nil, fmt.Errorf("failed to ASN.1 parse IM4R: %v", err)
+		}
+		// IM4R is wrapped in an explicit context-specific [1] tag
+		restoreInfo, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: r.FullBytes})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal IM4R: %v", err)
+		}
+		body = append(body, restoreInfo...)
+	}
+
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: body})
+}
+
+// SetIm4pType returns a copy of the IM4P payload with its four-character type tag replaced
+// (i.e. "krnl" -> "rkrn" when the kernelcache is loaded as the RestoreKernelCache)
+func SetIm4pType(im4p []byte, typ string) ([]byte, error) {
+	if len(typ) != 4 {
+		return nil, fmt.Errorf("invalid IM4P type %q: must be 4 characters", typ)
+	}
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(im4p, &seq); err != nil {
+		return nil, fmt.Errorf("failed to ASN.1 parse IM4P: %v", err)
+	}
+	var elems [][]byte
+	for rest := seq.Bytes; len(rest) > 0; {
+		var elem asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &elem); err != nil {
+			return nil, fmt.Errorf("failed to ASN.1 parse IM4P element: %v", err)
+		}
+		elems = append(elems, elem.FullBytes)
+	}
+	if len(elems) < 2 {
+		return nil, fmt.Errorf("invalid IM4P: missing type")
+	}
+	tag, err := asn1.MarshalWithParams(typ, "ia5")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal IM4P type: %v", err)
+	}
+	elems[1] = tag
+	var body []byte
+	for _, e := range elems {
+		body = append(body, e...)
+	}
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: body})
+}
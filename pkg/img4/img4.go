@@ -11,6 +11,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/internal/utils"
@@ -433,6 +435,41 @@ func ParseIm4p(r io.Reader) (*Im4p, error) {
 	return &i, nil
 }
 
+// ReadIm4pHeader reads the type and description (usually the version, i.e. iBoot-11881.0.167) of an IM4P
+// without reading its payload
+func ReadIm4pHeader(r io.Reader) (typ, desc string, err error) {
+	hdr := make([]byte, 1024)
+	n, err := io.ReadFull(r, hdr)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", "", fmt.Errorf("failed to read Im4p header: %v", err)
+	}
+	hdr = hdr[:n]
+	// skip the outer SEQUENCE's tag and length (the payload is not read so it can't be unmarshaled)
+	if len(hdr) < 2 || hdr[0] != 0x30 {
+		return "", "", fmt.Errorf("not an Im4p")
+	}
+	off := 2
+	if hdr[1]&0x80 != 0 {
+		off += int(hdr[1] & 0x7f)
+	}
+	if off > len(hdr) {
+		return "", "", fmt.Errorf("not an Im4p")
+	}
+	rest := hdr[off:]
+	var fields [3]string
+	for i := range fields {
+		var rv asn1.RawValue
+		if rest, err = asn1.Unmarshal(rest, &rv); err != nil {
+			return "", "", fmt.Errorf("failed to ASN.1 parse Im4p header: %v", err)
+		}
+		fields[i] = string(rv.Bytes)
+	}
+	if fields[0] != "IM4P" {
+		return "", "", fmt.Errorf("not an Im4p (found %s)", fields[0])
+	}
+	return fields[1], fields[2], nil
+}
+
 func OpenImg4(path string) (*img4, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -524,3 +561,52 @@ func ParseZipKeyBags(files []*zip.File, inf *info.Info, pattern string) (*KeyBag
 
 	return kbags, nil
 }
+
+// FileKeybags are an IM4P's keybags in the format used by decryption key databases
+// (the hex encoded IV followed by the key)
+type FileKeybags struct {
+	Production  string `json:"production,omitempty"`
+	Development string `json:"development,omitempty"`
+}
+
+// ParseKeybagMap returns the keybags of the IM4Ps (the names ending in .im4p that match the pattern if set)
+// by their path in the IPSW/OTA; open returns the contents of a file
+func ParseKeybagMap(names []string, pattern string, open func(name string) (io.ReadCloser, error)) (map[string]*FileKeybags, error) {
+	re := regexp.MustCompile(`(?i)\.im4p$`)
+	if len(pattern) > 0 {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("failed to compile --pattern regexp: %v", err)
+		}
+	}
+	kbags := make(map[string]*FileKeybags)
+	for _, name := range names {
+		if !strings.EqualFold(filepath.Ext(name), ".im4p") || !re.MatchString(name) {
+			continue
+		}
+		rc, err := open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", name, err)
+		}
+		im4p, err := ParseIm4p(rc)
+		rc.Close()
+		if err != nil {
+			log.Errorf("failed to parse im4p %s: %v", name, err)
+			continue
+		}
+		if len(im4p.Kbags) == 0 { // kbags are optional
+			continue
+		}
+		fk := &FileKeybags{}
+		for _, kb := range im4p.Kbags {
+			switch kb.Type {
+			case PRODUCTION:
+				fk.Production = hex.EncodeToString(append(slices.Clone(kb.IV), kb.Key...))
+			case DEVELOPMENT:
+				fk.Development = hex.EncodeToString(append(slices.Clone(kb.IV), kb.Key...))
+			}
+		}
+		kbags[name] = fk
+	}
+	return kbags, nil
+}
@@ -0,0 +1,110 @@
+package img4
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/blacktop/ipsw/pkg/rtkit"
+)
+
+// PayloadType is the kind of firmware contained in an IM4P payload
+type PayloadType string
+
+const (
+	PayloadKernelCache PayloadType = "kernelcache"
+	PayloadIBoot       PayloadType = "iboot"
+	PayloadSEP         PayloadType = "sep"
+	PayloadDeviceTree  PayloadType = "devicetree"
+	PayloadTrustCache  PayloadType = "trustcache"
+	PayloadSPTM        PayloadType = "sptm"
+	PayloadTXM         PayloadType = "txm"
+	PayloadRTKit       PayloadType = "rtkit"
+	PayloadMachO       PayloadType = "macho"
+	PayloadLZFSE       PayloadType = "lzfse"
+	PayloadLZSS        PayloadType = "lzss"
+	PayloadEncrypted   PayloadType = "encrypted"
+	PayloadUnknown     PayloadType = "unknown"
+)
+
+// Compression is the compression of an IM4P payload
+type Compression string
+
+const (
+	CompressionNone  Compression = "none"
+	CompressionLZFSE Compression = "lzfse"
+	CompressionLZSS  Compression = "lzss"
+)
+
+// im4pTypes maps the IM4P 4-char type tags to their payload type
+var im4pTypes = map[string]PayloadType{
+	"krnl": PayloadKernelCache,
+	"rkrn": PayloadKernelCache,
+	"ibot": PayloadIBoot,
+	"ibec": PayloadIBoot,
+	"ibss": PayloadIBoot,
+	"illb": PayloadIBoot,
+	"iboo": PayloadIBoot,
+	"sepi": PayloadSEP,
+	"rsep": PayloadSEP,
+	"dtre": PayloadDeviceTree,
+	"rdtr": PayloadDeviceTree,
+	"trst": PayloadTrustCache,
+	"rtsc": PayloadTrustCache,
+	"ltrs": PayloadTrustCache,
+	"sptm": PayloadSPTM,
+	"rspt": PayloadSPTM,
+	"trxm": PayloadTXM,
+	"rtrx": PayloadTXM,
+}
+
+// DetectCompression returns the compression used by an IM4P payload
+func DetectCompression(dat []byte) Compression {
+	switch {
+	case bytes.HasPrefix(dat, []byte("bvx2")), bytes.HasPrefix(dat, []byte("bvx1")),
+		bytes.HasPrefix(dat, []byte("bvxn")), bytes.HasPrefix(dat, []byte("bvx-")):
+		return CompressionLZFSE
+	case bytes.HasPrefix(dat, []byte("complzss")):
+		return CompressionLZSS
+	default:
+		return CompressionNone
+	}
+}
+
+func isMachO(dat []byte) bool {
+	if len(dat) < 4 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(dat) {
+	case 0xfeedface, 0xfeedfacf: // MH_MAGIC, MH_MAGIC_64
+		return true
+	}
+	return binary.BigEndian.Uint32(dat) == 0xcafebabe // FAT_MAGIC
+}
+
+// DetectPayloadType identifies the firmware in an (already decompressed) IM4P payload
+// using the IM4P type tag first and then the payload contents
+func DetectPayloadType(tag string, dat []byte) PayloadType {
+	switch DetectCompression(dat) {
+	case CompressionLZFSE:
+		return PayloadLZFSE
+	case CompressionLZSS:
+		return PayloadLZSS
+	}
+	if typ, ok := im4pTypes[tag]; ok {
+		return typ
+	}
+	switch {
+	case bytes.Contains(dat, []byte("Built by legion2")):
+		return PayloadSEP
+	case len(dat) > 0x300 && bytes.Contains(dat[:0x300], []byte("iBoot")):
+		return PayloadIBoot
+	case rtkit.IsRTKit(dat):
+		return PayloadRTKit
+	case isMachO(dat):
+		if bytes.Contains(dat, []byte("Darwin Kernel Version")) {
+			return PayloadKernelCache
+		}
+		return PayloadMachO
+	}
+	return PayloadUnknown
+}
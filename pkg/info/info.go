@@ -128,7 +128,8 @@ func getProcessor(cpuid string) (processors, error) {
 	return processors{}, fmt.Errorf("failed to find processor for %s", cpuid)
 }
 
-func getFirmwareKeys(device, build string) (map[string]string, error) {
+// GetFirmwareKeys returns the embedded firmware keys (<type>-iv/<type>-key) for a device/build
+func GetFirmwareKeys(device, build string) (map[string]string, error) {
 	var keys map[string]map[string]map[string]string
 
 	zr, err := gzip.NewReader(bytes.NewReader(keysJSONData))
@@ -144,7 +145,8 @@ func getFirmwareKeys(device, build string) (map[string]string, error) {
 	return keys[device][build], nil
 }
 
-func getApFirmwareKey(device, build, filename string) (string, string, error) {
+// GetApFirmwareKey returns the embedded AP kbag and decrypted iv+key for a device/build's file
+func GetApFirmwareKey(device, build, filename string) (string, string, error) {
 	var m1Keys []apKey
 	var a13Keys []apKey
 	var a14Keys []apKey
@@ -192,7 +194,7 @@ func getApFirmwareKey(device, build, filename string) (string, string, error) {
 	}
 
 	for _, key := range m1Keys {
-		if key.Name() == filename {
+		if key.Name() == filename && strings.Contains(key.IPSW, "_"+build+"_") {
 			return key.KBag, key.Key, nil
 		}
 	}
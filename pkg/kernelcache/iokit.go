@@ -0,0 +1,153 @@
+package kernelcache
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+)
+
+// IOKitClass is an IOKit class registered by a kext
+type IOKitClass struct {
+	Name string `json:"name"`
+	Kext string `json:"kext,omitempty"`
+	// UserClient is true if the class is an IOUserClient (reachable from userland via IOServiceOpen)
+	UserClient bool `json:"user_client,omitempty"`
+	// Methods are the class's C++ methods (only available if the kernelcache has symbols); for user clients
+	// these are NOT the external methods (the selectors of their IOExternalMethodDispatch tables)
+	Methods []string `json:"methods,omitempty"`
+}
+
+// KextInventory is the kexts and IOKit classes of a kernelcache
+type KextInventory struct {
+	// Kexts are the kext versions by bundle ID
+	Kexts   map[string]string      `json:"kexts"`
+	Classes map[string]*IOKitClass `json:"classes"`
+}
+
+func (inv *KextInventory) addClass(name, kext string, userClient bool) *IOKitClass {
+	class, ok := inv.Classes[name]
+	if !ok {
+		class = &IOKitClass{Name: name, Kext: kext}
+		inv.Classes[name] = class
+	}
+	if len(class.Kext) == 0 {
+		class.Kext = kext
+	}
+	class.UserClient = class.UserClient || userClient || strings.HasSuffix(name, "UserClient")
+	return class
+}
+
+// parseNestedName parses the class and method of a mangled C++ nested name (i.e. __ZN12IOUserClient5startEP9IOService)
+func parseNestedName(sym string) (class, method string, ok bool) {
+	sym, ok = strings.CutPrefix(strings.TrimPrefix(sym, "_"), "_ZN")
+	if !ok {
+		return "", "", false
+	}
+	sym = strings.TrimPrefix(sym, "K") // const method
+	var names []string
+	for len(names) < 2 {
+		i := 0
+		for i < len(sym) && sym[i] >= '0' && sym[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(sym[:i])
+		if err != nil || n == 0 || i+n > len(sym) {
+			break
+		}
+		names = append(names, sym[i:i+n])
+		sym = sym[i+n:]
+	}
+	if len(names) < 2 {
+		return "", "", false
+	}
+	return names[0], names[1], true
+}
+
+// parseClassSymbols adds the classes (and their C++ methods) of a kext's symbols to the inventory
+func (inv *KextInventory) parseClassSymbols(m *macho.File, kext string) {
+	if m.Symtab == nil {
+		return
+	}
+	methods := make(map[string][]string)
+	for _, sym := range m.Symtab.Syms {
+		if sym.Type.IsUndefinedSym() { // imported from another kext
+			continue
+		}
+		class, method, ok := parseNestedName(sym.Name)
+		if !ok {
+			continue
+		}
+		if method == "gMetaClass" || method == "MetaClass" {
+			inv.addClass(class, kext, false)
+			continue
+		}
+		if !slices.Contains(methods[class], method) {
+			methods[class] = append(methods[class], method)
+		}
+	}
+	for name, meths := range methods {
+		if class, ok := inv.Classes[name]; ok {
+			for _, meth := range meths {
+				if !slices.Contains(class.Methods, meth) {
+					class.Methods = append(class.Methods, meth)
+				}
+			}
+		}
+	}
+}
+
+// GetKextInventory returns the kext versions and the IOKit classes of a kernelcache
+//
+// The classes are the IOClass and IOUserClientClass of the kexts' IOKitPersonalities and, if the kernelcache
+// has symbols, the classes with an OSMetaClass (and their methods)
+func GetKextInventory(m *macho.File) (*KextInventory, error) {
+	inv := &KextInventory{
+		Kexts:   make(map[string]string),
+		Classes: make(map[string]*IOKitClass),
+	}
+	bundles, err := GetKexts(m)
+	if err != nil {
+		return nil, err
+	}
+	for _, bundle := range bundles {
+		inv.Kexts[bundle.ID] = bundle.Version
+		for _, personality := range bundle.IOKitPersonalities {
+			p, ok := personality.(map[string]any)
+			if !ok {
+				continue
+			}
+			kext := bundle.ID
+			if id, ok := p["CFBundleIdentifier"].(string); ok && len(id) > 0 {
+				kext = id
+			}
+			if class, ok := p["IOClass"].(string); ok && len(class) > 0 {
+				inv.addClass(class, kext, false)
+			}
+			if class, ok := p["IOUserClientClass"].(string); ok && len(class) > 0 {
+				inv.addClass(class, kext, true)
+			}
+		}
+	}
+
+	if m.FileTOC.FileHeader.Type == types.MH_FILESET {
+		for _, fe := range m.FileSets() {
+			mfe, err := m.GetFileSetFileByName(fe.EntryID)
+			if err != nil {
+				log.WithError(err).Debugf("failed to parse fileset entry %s", fe.EntryID)
+				continue
+			}
+			inv.parseClassSymbols(mfe, fe.EntryID)
+		}
+	} else {
+		inv.parseClassSymbols(m, "com.apple.kernel")
+	}
+	for _, class := range inv.Classes {
+		slices.Sort(class.Methods)
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,129 @@
+package kernelcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/pkg/sandbox"
+)
+
+const sandboxKextID = "com.apple.security.sandbox"
+
+func getSandboxKext(m *macho.File) (*macho.File, error) {
+	if m.FileTOC.FileHeader.Type == types.MH_FILESET {
+		kext, err := m.GetFileSetFileByName(sandboxKextID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fileset entry %s; %v", sandboxKextID, err)
+		}
+		return kext, nil
+	}
+	return m, nil // the Sandbox kext itself
+}
+
+// GetSandboxOperations returns the names of the sandbox operations (in the order of the compiled profiles' operation tables)
+func GetSandboxOperations(m *macho.File) ([]string, error) {
+	kext, err := getSandboxKext(m)
+	if err != nil {
+		return nil, err
+	}
+
+	cstrings := kext.Section("__TEXT", "__cstring")
+	if cstrings == nil {
+		return nil, fmt.Errorf("failed to find __TEXT.__cstring section in %s", sandboxKextID)
+	}
+	dat, err := cstrings.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read __TEXT.__cstring section: %v", err)
+	}
+	found := bytes.Index(dat, []byte("\x00default\x00"))
+	if found < 0 {
+		return nil, fmt.Errorf("failed to find the 'default' operation name")
+	}
+	defaultAddr := cstrings.Addr + uint64(found) + 1
+
+	for _, name := range [][2]string{{"__DATA_CONST", "__const"}, {"__DATA", "__const"}} {
+		sec := kext.Section(name[0], name[1])
+		if sec == nil {
+			continue
+		}
+		dat, err := sec.Data()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s.%s section: %v", name[0], name[1], err)
+		}
+		ptrs := make([]uint64, len(dat)/8)
+		if err := binary.Read(bytes.NewReader(dat), binary.LittleEndian, ptrs); err != nil {
+			return nil, err
+		}
+		for i, ptr := range ptrs {
+			if kext.SlidePointer(ptr) != defaultAddr {
+				continue
+			}
+			// the operation names table (terminated by the first pointer outside of __cstring)
+			var ops []string
+			for _, ptr := range ptrs[i:] {
+				addr := kext.SlidePointer(ptr)
+				if addr < cstrings.Addr || addr >= cstrings.Addr+cstrings.Size {
+					break
+				}
+				op, err := kext.GetCString(addr)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read operation name at %#x: %v", addr, err)
+				}
+				ops = append(ops, op)
+			}
+			log.WithField("table", fmt.Sprintf("%#x", sec.Addr+uint64(i*8))).Debugf("Found %d sandbox operations", len(ops))
+			return ops, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find the sandbox operation names table")
+}
+
+// GetSandboxProfiles returns the compiled sandbox profile collections and profiles (i.e. the platform profile)
+// embedded in the Sandbox kext
+func GetSandboxProfiles(m *macho.File, ops []string) ([]*sandbox.Sandbox, error) {
+	kext, err := getSandboxKext(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var sbs []*sandbox.Sandbox
+	for _, sec := range kext.Sections {
+		if sec.Flags.IsPureInstructions() || sec.Flags.IsSomeInstructions() || sec.Name == "__cstring" || sec.Size == 0 {
+			continue
+		}
+		dat, err := sec.Data()
+		if err != nil {
+			continue // i.e. zerofill
+		}
+		for off := 0; off+12 < len(dat); off += 8 {
+			// fast check: the type (profile or collection) and the operations count
+			if typ := binary.LittleEndian.Uint16(dat[off:]); (typ != 0 && typ != 0x8000) || int(dat[off+4]) != len(ops) {
+				continue
+			}
+			sb, err := sandbox.Parse(dat[off:], ops)
+			if err != nil {
+				if !errors.Is(err, sandbox.ErrInvalidProfile) {
+					log.WithError(err).Debugf("failed to parse sandbox profile at %#x", sec.Addr+uint64(off))
+				}
+				continue
+			}
+			log.WithFields(log.Fields{
+				"addr":     fmt.Sprintf("%#x", sec.Addr+uint64(off)),
+				"profiles": len(sb.Profiles),
+			}).Debug("Found compiled sandbox profile")
+			sbs = append(sbs, sb)
+		}
+	}
+
+	if len(sbs) == 0 {
+		return nil, fmt.Errorf("failed to find compiled sandbox profiles in %s", sandboxKextID)
+	}
+
+	return sbs, nil
+}
@@ -2,9 +2,11 @@ package ota
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -410,11 +412,15 @@ func (r *Reader) PostFiles() []fs.FileInfo {
 
 func (r *Reader) GetPayloadFiles(pattern, payloadRange, output string) error {
 	r.initFileList()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex pattern '%s': %v", pattern, err)
+	}
 	pre := regexp.MustCompile(`^payload.\d+$`)
 	if payloadRange != "" {
 		pre = regexp.MustCompile(payloadRange)
 	}
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(context.Background())
 	for _, file := range r.Files() {
 		if file.isDir {
 			continue
@@ -426,31 +432,14 @@ func (r *Reader) GetPayloadFiles(pattern, payloadRange, output string) error {
 					return err
 				}
 				defer f.Close()
-				tmpdir, err := os.MkdirTemp("", "ota_payload_extract")
+				out, err := ExtractPayload(ctx, f, output, re)
 				if err != nil {
-					return err
-				}
-				defer os.RemoveAll(tmpdir)
-				if err := aaExtractPattern(f, pattern, tmpdir); err != nil {
-					return err
+					return fmt.Errorf("failed to extract files from '%s': %v", file.Name(), err)
 				}
-				if err := filepath.Walk(tmpdir, func(path string, f os.FileInfo, err error) error {
-					if err != nil {
-						return err
+				for _, fname := range out {
+					if fi, err := os.Stat(fname); err == nil {
+						utils.Indent(log.Info, 2)(fmt.Sprintf("Extracting from '%s' -> %s\t%s", file.Name(), humanize.Bytes(uint64(fi.Size())), fname))
 					}
-					if !f.IsDir() {
-						fname := filepath.Join(output, filepath.Clean(strings.TrimPrefix(path, tmpdir)))
-						if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
-							return fmt.Errorf("failed to create dir %s: %v", filepath.Dir(fname), err)
-						}
-						utils.Indent(log.Info, 2)(fmt.Sprintf("Extracting from '%s' -> %s\t%s", file.Name(), humanize.Bytes(uint64(f.Size())), fname))
-						if err := os.Rename(path, fname); err != nil {
-							return fmt.Errorf("failed to mv file %s to %s: %v", strings.TrimPrefix(path, tmpdir), fname, err)
-						}
-					}
-					return nil
-				}); err != nil {
-					return fmt.Errorf("failed to read files in tmp folder: %v", err)
 				}
 				return nil
 			})
@@ -495,10 +484,10 @@ func (r *Reader) PayloadFiles(pattern string, json bool) error {
 	return nil
 }
 
-func aaList(in io.Reader, pattern string, json bool) (string, error) {
+func aaList(in io.Reader, pattern string, asJSON bool) (string, error) {
 	aaPath, err := execabs.LookPath("aa")
-	if err != nil {
-		return "", err
+	if err != nil { // fallback to the pure Go YAA parser
+		return yaaList(in, pattern, asJSON)
 	}
 
 	args := []string{"list", "-exclude-field", "all", "-include-field", "attr"}
@@ -506,10 +495,10 @@ func aaList(in io.Reader, pattern string, json bool) (string, error) {
 	if len(pattern) > 0 {
 		args = append(args, []string{"-include-regex", pattern}...)
 	}
-	if json {
+	if asJSON {
 		args = append(args, []string{"-list-format", "json"}...)
 	}
-	if len(pattern) == 0 && !json {
+	if len(pattern) == 0 && !asJSON {
 		args = append(args, "-v")
 	}
 
@@ -523,20 +512,80 @@ func aaList(in io.Reader, pattern string, json bool) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-func aaExtractPattern(in io.Reader, pattern, output string) error {
-	aaPath, err := execabs.LookPath("aa")
-	if err != nil {
-		return err
+func yaaList(in io.Reader, pattern string, asJSON bool) (string, error) {
+	var include *regexp.Regexp
+	if len(pattern) > 0 {
+		var err error
+		if include, err = regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("failed to compile regex pattern '%s': %v", pattern, err)
+		}
 	}
-	cmd := exec.Command(aaPath, "extract", "-d", output, "-include-regex", pattern)
-	cmd.Stdin = in
-	out, err := cmd.CombinedOutput()
+	entries, err := ListPayload(context.Background(), in, include)
 	if err != nil {
-		if _, ok := err.(*exec.ExitError); !ok {
-			return fmt.Errorf("%v: %s", err, out)
+		return "", fmt.Errorf("failed to list payload: %v", err)
+	}
+	if asJSON {
+		if entries == nil {
+			entries = []*yaa.Entry{}
+		}
+		dat, err := json.Marshal(entries)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal payload entries: %v", err)
 		}
+		return string(dat), nil
 	}
-	return nil
+	var sb strings.Builder
+	for _, ent := range entries {
+		sb.WriteString(ent.String() + "\n")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// ExtractPayload extracts the files matching include (all of them if nil) from a (pbzx compressed) payloadv2
+// YAA stream into dest; the pbzx chunks are decompressed by a pool of workers and streamed straight into
+// the YAA extractor (which writes the files with its own pool) so the payload is never buffered in memory
+func ExtractPayload(ctx context.Context, r io.Reader, dest string, include *regexp.Regexp) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, err := payloadReader(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer pr.Close() // unblocks the pbzx writer if the YAA extractor fails
+
+	return yaa.Extract(ctx, pr, dest, include, runtime.NumCPU())
+}
+
+// ListPayload returns the entries matching include (all of them if nil) of a (pbzx compressed) payloadv2 YAA stream
+func ListPayload(ctx context.Context, r io.Reader, include *regexp.Regexp) ([]*yaa.Entry, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, err := payloadReader(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer pr.Close()
+
+	return yaa.List(pr, include)
+}
+
+// payloadReader returns the YAA stream of a payload (decompressing it on the fly if it is pbzx compressed)
+func payloadReader(ctx context.Context, r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	hdr, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload magic: %v", err)
+	}
+	if magic.Magic(binary.BigEndian.Uint32(hdr)) != magic.MagicPBZX {
+		return io.NopCloser(br), nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(pbzx.Extract(ctx, br, pw, runtime.NumCPU()))
+	}()
+	return pr, nil
 }
 
 func (r *Reader) ExtractFromCryptexes(pattern, output string) ([]string, error) {
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -48,137 +49,129 @@ func readOffset(data []byte) int64 {
 	return offset
 }
 
-func Patch(patch, target, output string) (err error) {
-	dat, err := os.ReadFile(patch)
-	if err != nil {
-		return err
+// IsBXDIFF50 returns true if the data is a BXDIFF50 patch
+func IsBXDIFF50(dat []byte) bool {
+	return len(dat) >= len(magic) && string(dat[:len(magic)]) == magic
+}
+
+var (
+	// ErrTargetMismatch is returned when the file being patched is not the one the patch was created against
+	ErrTargetMismatch = errors.New("input file SHA1 does not match expected SHA1 from patch")
+	// ErrResultMismatch is returned (along with the output) when the patched data does not match the expected SHA1
+	ErrResultMismatch = errors.New("output file SHA1 does not match expected SHA1 from patch")
+)
+
+func decompress(r io.Reader, size uint64) (*bytes.Reader, error) {
+	comp := make([]byte, size)
+	if _, err := io.ReadFull(r, comp); err != nil {
+		return nil, err
 	}
+	var buf bytes.Buffer
+	if err := pbzx.Extract(context.Background(), bytes.NewReader(comp), &buf, runtime.NumCPU()); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf.Bytes()), nil
+}
 
-	r := bytes.NewReader(dat)
+// Apply applies a BXDIFF50 patch to the target data and returns the patched data
+// (a target that doesn't match the patch's SHA1 is only an error if strict, otherwise it is patched with a warning)
+func Apply(patch, target []byte, strict bool) ([]byte, error) {
+	r := bytes.NewReader(patch)
 
 	var header Header
 	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
-		return err
+		return nil, err
 	}
 
 	if string(header.Magic[:]) != magic {
-		return errors.New("patch has invalid BXDIFF50 magic")
+		return nil, errors.New("patch has invalid BXDIFF50 magic")
 	}
 
-	tf, err := os.Open(target)
-	if err != nil {
-		return err
-	}
-	defer tf.Close()
-
 	// check input SHA1
-	sha1Hash := sha1.New()
-	if _, err := io.Copy(sha1Hash, tf); err != nil {
-		return err
-	}
-	if !bytes.Equal(sha1Hash.Sum(nil), header.TargetSHA1[:]) {
-		log.Errorf("input file SHA1 does not match expected SHA1 from patch: got %s, expected %s", hex.EncodeToString(sha1Hash.Sum(nil)), hex.EncodeToString(header.TargetSHA1[:]))
+	if sum := sha1.Sum(target); !bytes.Equal(sum[:], header.TargetSHA1[:]) {
+		err := fmt.Errorf("%w: got %s, expected %s", ErrTargetMismatch, hex.EncodeToString(sum[:]), hex.EncodeToString(header.TargetSHA1[:]))
+		if strict {
+			return nil, err
+		}
+		log.Warn(err.Error())
 	}
-	tf.Seek(0, io.SeekStart) // rewind
+	tf := bytes.NewReader(target)
 
 	// parse control data
-	compControl := make([]byte, header.ControlSize)
-	if _, err := r.Read(compControl); err != nil {
-		return err
-	}
-	var cbuf bytes.Buffer
-	if err := pbzx.Extract(context.Background(), bytes.NewReader(compControl), &cbuf, runtime.NumCPU()); err != nil {
-		return err
+	cr, err := decompress(r, header.ControlSize)
+	if err != nil {
+		return nil, err
 	}
-	cr := bytes.NewReader(cbuf.Bytes())
 
 	// parse controls
 	in := make([]byte, 8)
 	var controls []Control
 	for {
 		var control Control
-		if _, err := cr.Read(in); err != nil {
+		if _, err := io.ReadFull(cr, in); err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return err
+			return nil, err
 		}
 		control.MixLen = readOffset(in)
-		if _, err := cr.Read(in); err != nil {
+		if _, err := io.ReadFull(cr, in); err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return err
+			return nil, err
 		}
 		control.CopyLen = readOffset(in)
-		if _, err := cr.Read(in); err != nil {
+		if _, err := io.ReadFull(cr, in); err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return err
+			return nil, err
 		}
 		control.SeekLen = readOffset(in)
 		controls = append(controls, control)
 	}
 
 	// parse diff data
-	compDiff := make([]byte, header.DiffSize)
-	if _, err := r.Read(compDiff); err != nil {
-		return err
-	}
-	var dbuf bytes.Buffer
-	if err := pbzx.Extract(context.Background(), bytes.NewReader(compDiff), &dbuf, runtime.NumCPU()); err != nil {
-		return err
+	dr, err := decompress(r, header.DiffSize)
+	if err != nil {
+		return nil, err
 	}
-	dr := bytes.NewReader(dbuf.Bytes())
 
 	// parse extra data
-	compExtra := make([]byte, header.ExtraSize)
-	if _, err := r.Read(compExtra); err != nil {
-		return err
-	}
-
-	var ebuf bytes.Buffer
-	if err := pbzx.Extract(context.Background(), bytes.NewReader(compExtra), &ebuf, runtime.NumCPU()); err != nil {
-		return err
+	er, err := decompress(r, header.ExtraSize)
+	if err != nil {
+		return nil, err
 	}
-	er := bytes.NewReader(ebuf.Bytes())
 
 	var obuf bytes.Buffer
+	obuf.Grow(int(header.PatchedFileSize))
 
 	// apply patch to output
 	for _, control := range controls {
 		if control.MixLen != 0 {
 			indata := make([]uint8, control.MixLen)
 			ddata := make([]uint8, control.MixLen)
-			if err := binary.Read(tf, binary.LittleEndian, indata); err != nil {
+			if _, err := io.ReadFull(tf, indata); err != nil {
 				if errors.Is(err, io.EOF) {
 					break
 				}
-				return err
+				return nil, err
 			}
-			if err := binary.Read(dr, binary.LittleEndian, ddata); err != nil {
-				return err
+			if _, err := io.ReadFull(dr, ddata); err != nil {
+				return nil, err
 			}
 			for i := 0; i < len(indata); i++ {
 				indata[i] += ddata[i]
 			}
-			if n, err := obuf.Write(indata); err != nil {
-				return err
-			} else if n != int(control.MixLen) {
-				return errors.New("failed to write diff data")
-			}
+			obuf.Write(indata)
 		}
 		if control.CopyLen != 0 {
 			exdata := make([]byte, control.CopyLen)
-			if err := binary.Read(er, binary.LittleEndian, exdata); err != nil {
-				return err
-			}
-			if n, err := obuf.Write(exdata); err != nil {
-				return err
-			} else if n != int(control.CopyLen) {
-				return errors.New("failed to write extra data")
+			if _, err := io.ReadFull(er, exdata); err != nil {
+				return nil, err
 			}
+			obuf.Write(exdata)
 		}
 		if control.SeekLen != 0 {
 			tf.Seek(control.SeekLen, io.SeekCurrent)
@@ -186,12 +179,31 @@ func Patch(patch, target, output string) (err error) {
 	}
 
 	// check output SHA1
-	sha1Hash.Reset()
-	if _, err := sha1Hash.Write(obuf.Bytes()); err != nil {
+	if sum := sha1.Sum(obuf.Bytes()); !bytes.Equal(sum[:], header.ResultSHA1[:]) {
+		return obuf.Bytes(), fmt.Errorf("%w: got %s, expected %s", ErrResultMismatch, hex.EncodeToString(sum[:]), hex.EncodeToString(header.ResultSHA1[:]))
+	}
+
+	return obuf.Bytes(), nil
+}
+
+// Patch applies a BXDIFF50 patch file to the target file and writes the result to the output folder
+// (the SHA1 mismatches are only errors if strict, otherwise they are logged as warnings)
+func Patch(patch, target, output string, strict bool) (err error) {
+	pdat, err := os.ReadFile(patch)
+	if err != nil {
 		return err
 	}
-	if !bytes.Equal(sha1Hash.Sum(nil), header.ResultSHA1[:]) {
-		log.Errorf("output file SHA1 does not match expected SHA1 from patch: got %s, expected %s", hex.EncodeToString(sha1Hash.Sum(nil)), hex.EncodeToString(header.ResultSHA1[:]))
+	tdat, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+
+	out, err := Apply(pdat, tdat, strict)
+	if err != nil {
+		if strict || !errors.Is(err, ErrResultMismatch) {
+			return err
+		}
+		log.Warn(err.Error())
 	}
 
 	// write output
@@ -200,5 +212,5 @@ func Patch(patch, target, output string) (err error) {
 	}
 	fname := filepath.Join(output, filepath.Base(target)+".patched")
 	log.Infof("Writing patched file to: %s", fname)
-	return os.WriteFile(fname, obuf.Bytes(), 0o660)
+	return os.WriteFile(fname, out, 0o660)
 }
@@ -0,0 +1,184 @@
+package pbzx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/ulikunitz/xz"
+)
+
+// DefaultBlockSize is the uncompressed size of the chunks in Apple's pbzx payloads
+const DefaultBlockSize = 16 << 20
+
+// Compress compresses src into a pbzx stream written to dst; src is split into chunks of blockSize
+// (DefaultBlockSize if 0) bytes which are xz compressed by a pool of numWorker workers (runtime.NumCPU() if 0)
+// and written in order (chunks that don't compress are stored as is)
+func Compress(ctx context.Context, src io.Reader, dst io.Writer, blockSize, numWorker int) error {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	if numWorker <= 0 {
+		numWorker = runtime.NumCPU()
+	}
+
+	hdr := make([]byte, 12)
+	copy(hdr, "pbzx")
+	binary.BigEndian.PutUint64(hdr[4:], uint64(blockSize))
+	if _, err := dst.Write(hdr); err != nil {
+		return fmt.Errorf("write error: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	deflateCh := make(chan _Chunk, 1)
+	writeCh := make(chan _Chunk, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cancelIfError := func(err error) {
+		if err != nil {
+			select {
+			case errCh <- err: // do nothing
+			default: // do not block
+			}
+			cancel()
+		}
+	}
+
+	var wg1, wg2 sync.WaitGroup
+
+	wg1.Add(1)
+	go func() {
+		defer wg1.Done()
+		cancelIfError(split(ctx, src, blockSize, deflateCh))
+	}()
+
+	wg1.Add(numWorker)
+	for range numWorker {
+		go func() {
+			defer wg1.Done()
+			cancelIfError(deflate(ctx, deflateCh, writeCh))
+		}()
+	}
+
+	wg2.Add(1)
+	go func() {
+		defer wg2.Done()
+		cancelIfError(write(ctx, writeCh, dst))
+	}()
+
+	wg1.Wait()
+	close(writeCh)
+	wg2.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// split reads src in chunks of blockSize bytes
+func split(ctx context.Context, src io.Reader, blockSize int, deflateCh chan<- _Chunk) error {
+	defer close(deflateCh)
+
+	for idx := 0; ; idx++ {
+		data := make([]byte, blockSize)
+		n, err := io.ReadFull(src, data)
+		if n > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case deflateCh <- _Chunk{idx: idx, meta: n, data: data[:n]}:
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read error: %w", err)
+		}
+	}
+}
+
+// deflate xz compresses the chunks and prepends their chunk header
+func deflate(ctx context.Context, reader <-chan _Chunk, writeCh chan<- _Chunk) error {
+	for {
+		var chunk _Chunk
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok = <-reader:
+		}
+		if !ok {
+			return nil
+		}
+		var buf bytes.Buffer
+		buf.Write(make([]byte, 16)) // chunk header
+		xw, err := xz.NewWriter(&buf)
+		if err != nil {
+			return fmt.Errorf("deflate error: %w", err)
+		}
+		if _, err := xw.Write(chunk.data); err != nil {
+			return fmt.Errorf("deflate error: %w", err)
+		}
+		if err := xw.Close(); err != nil {
+			return fmt.Errorf("deflate error: %w", err)
+		}
+		out := buf.Bytes()
+		if len(out)-16 >= chunk.meta { // store the chunk uncompressed
+			out = append(out[:16], chunk.data...)
+		}
+		binary.BigEndian.PutUint64(out, uint64(chunk.meta))
+		binary.BigEndian.PutUint64(out[8:], uint64(len(out)-16))
+		chunk.data = out
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case writeCh <- chunk:
+		}
+	}
+}
+
+// NewReader returns a reader of the decompressed pbzx stream r (decompressed by a pool of numWorker workers);
+// closing it stops the decompression
+func NewReader(ctx context.Context, r io.Reader, numWorker int) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Extract(ctx, r, pw, numWorker))
+	}()
+	return pr
+}
+
+type writer struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close flushes the last chunk and waits for the compressed stream to be written
+func (w *writer) Close() error {
+	w.pw.Close()
+	return <-w.done
+}
+
+// NewWriter returns a writer compressing the data written to it into the pbzx stream w (see Compress);
+// it must be closed to flush the last chunk
+func NewWriter(ctx context.Context, w io.Writer, blockSize, numWorker int) io.WriteCloser {
+	pr, pw := io.Pipe()
+	zw := &writer{pw: pw, done: make(chan error, 1)}
+	go func() {
+		err := Compress(ctx, pr, w, blockSize, numWorker)
+		pr.CloseWithError(err) // unblocks the writer if the compression fails
+		zw.done <- err
+	}()
+	return zw
+}
@@ -0,0 +1,187 @@
+package yaa
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const streamBufferSize = 4 << 20
+
+// Extract extracts the entries of the YAA stream whose path matches include (all of them if it is nil) into dest;
+// the stream is read sequentially (so it can be piped straight out of a pbzx decoder) while the file data
+// is written to disk by a pool of numWorker workers (runtime.NumCPU() if 0); it returns the extracted files
+func Extract(ctx context.Context, r io.Reader, dest string, include *regexp.Regexp, numWorker int) ([]string, error) {
+	if numWorker <= 0 {
+		numWorker = runtime.NumCPU()
+	}
+
+	var mu sync.Mutex
+	var artifacts []string
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(numWorker) // bounds the number of file buffers in flight
+
+	br := bufio.NewReaderSize(r, streamBufferSize)
+	seen := make(map[string]bool)
+	g := newPathGuard(dest)
+
+	read := func() error {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ent, err := readEntry(br)
+			if err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+
+			match := len(ent.Path) > 0 && !seen[ent.Path] && (include == nil || include.MatchString(ent.Path))
+
+			var data []byte
+			if ent.Type == RegularFile && ent.Size > 0 {
+				if match {
+					data = make([]byte, ent.Size)
+					if _, err := io.ReadFull(br, data); err != nil {
+						return fmt.Errorf("failed to read %s data: %w", ent.Path, err)
+					}
+				} else if _, err := io.CopyN(io.Discard, br, int64(ent.Size)); err != nil {
+					return fmt.Errorf("failed to skip %s data: %w", ent.Path, err)
+				}
+			}
+			if ent.Xat > 0 { // skip extended attributes
+				if _, err := io.CopyN(io.Discard, br, int64(ent.Xat)); err != nil {
+					return fmt.Errorf("failed to skip %s extended attributes: %w", ent.Path, err)
+				}
+			}
+
+			if !match {
+				continue
+			}
+			seen[ent.Path] = true
+
+			fname := filepath.Join(dest, filepath.Clean("/"+ent.Path))
+			if err := g.check(fname, ent.Type); err != nil {
+				return err
+			}
+			switch ent.Type {
+			case Directory:
+				if err := os.MkdirAll(fname, 0o750); err != nil {
+					return fmt.Errorf("failed to create directory %s: %v", fname, err)
+				}
+			case SymbolicLink:
+				if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+					return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(fname), err)
+				}
+				os.Remove(fname)
+				if err := os.Symlink(ent.Link, fname); err != nil {
+					return fmt.Errorf("failed to create symlink %s: %v", fname, err)
+				}
+			case RegularFile:
+				eg.Go(func() error {
+					if err := writeFile(fname, ent, data); err != nil {
+						return err
+					}
+					mu.Lock()
+					artifacts = append(artifacts, fname)
+					mu.Unlock()
+					return nil
+				})
+			}
+		}
+	}
+
+	rerr := read()
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	slices.Sort(artifacts)
+
+	return artifacts, nil
+}
+
+// pathGuard keeps the extracted entries inside dest: an entry is rejected if one of its parents is a symlink
+// (a malicious archive could otherwise symlink a folder out of dest and then write files through it)
+type pathGuard struct {
+	dest  string
+	links map[string]bool // the symlinks extracted so far
+	dirs  map[string]bool // the folders the entries extracted so far were written to
+}
+
+func newPathGuard(dest string) *pathGuard {
+	return &pathGuard{dest: filepath.Clean(dest), links: make(map[string]bool), dirs: make(map[string]bool)}
+}
+
+// check returns an error if the entry's parent resolves through a symlink (or if the entry is a symlink
+// replacing a folder earlier entries were extracted to, as those may still be being written by the workers)
+func (g *pathGuard) check(fname string, typ entryType) error {
+	var parents []string
+	for dir := filepath.Dir(fname); len(dir) > len(g.dest); dir = filepath.Dir(dir) {
+		parents = append(parents, dir)
+	}
+	for _, dir := range parents {
+		if g.links[dir] {
+			return fmt.Errorf("refusing to extract %s: parent %s is a symlink", fname, dir)
+		}
+		if g.dirs[dir] {
+			continue
+		}
+		if fi, err := os.Lstat(dir); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to extract %s: parent %s is a symlink", fname, dir)
+		}
+	}
+	if typ == SymbolicLink {
+		if g.dirs[fname] {
+			return fmt.Errorf("refusing to extract symlink %s: it replaces a folder of extracted files", fname)
+		}
+		g.links[fname] = true
+	}
+	for _, dir := range parents {
+		g.dirs[dir] = true
+	}
+	if typ == Directory {
+		g.dirs[fname] = true
+	}
+	return nil
+}
+
+func writeFile(fname string, ent *Entry, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(fname), err)
+	}
+	if fi, err := os.Lstat(fname); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		// don't write through a symlink left by a previous extraction
+		if err := os.Remove(fname); err != nil {
+			return fmt.Errorf("failed to remove symlink %s: %v", fname, err)
+		}
+	}
+	perm := ent.Mod.Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	if err := os.WriteFile(fname, data, perm|0o200); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fname, err)
+	}
+	if !ent.Mtm.IsZero() {
+		if err := os.Chtimes(fname, ent.Mtm, ent.Mtm); err != nil {
+			return fmt.Errorf("failed to set %s modification time: %v", fname, err)
+		}
+	}
+	return nil
+}
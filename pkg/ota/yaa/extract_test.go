@@ -0,0 +1,102 @@
+package yaa
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeArchive(t *testing.T, entries ...*Entry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, ent := range entries {
+		var data io.Reader
+		if ent.Type == RegularFile {
+			data = strings.NewReader(strings.Repeat("A", int(ent.Size)))
+		}
+		if err := w.WriteEntry(ent, data); err != nil {
+			t.Fatalf("WriteEntry(%s) error = %v", ent.Path, err)
+		}
+	}
+	return &buf
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*Entry
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "files",
+			entries: []*Entry{
+				{Type: Directory, Path: "usr", Mod: 0o755},
+				{Type: RegularFile, Path: "usr/a", Mod: 0o644, Size: 4},
+				{Type: SymbolicLink, Path: "usr/b", Link: "a", Mod: 0o755},
+			},
+			want: []string{"usr/a"},
+		},
+		{
+			name: "file through symlink",
+			entries: []*Entry{
+				{Type: SymbolicLink, Path: "escape", Link: "OUTSIDE", Mod: 0o755},
+				{Type: RegularFile, Path: "escape/pwned", Mod: 0o644, Size: 4},
+			},
+			wantErr: true,
+		},
+		{
+			name: "directory through symlink",
+			entries: []*Entry{
+				{Type: SymbolicLink, Path: "escape", Link: "OUTSIDE", Mod: 0o755},
+				{Type: Directory, Path: "escape/dir", Mod: 0o755},
+			},
+			wantErr: true,
+		},
+		{
+			name: "symlink replacing folder",
+			entries: []*Entry{
+				{Type: RegularFile, Path: "dir/a", Mod: 0o644, Size: 4},
+				{Type: SymbolicLink, Path: "dir", Link: "OUTSIDE", Mod: 0o755},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			outside := filepath.Join(root, "outside")
+			if err := os.Mkdir(outside, 0o750); err != nil {
+				t.Fatal(err)
+			}
+			for _, ent := range tt.entries {
+				ent.Link = strings.ReplaceAll(ent.Link, "OUTSIDE", outside)
+			}
+			dest := filepath.Join(root, "dest")
+
+			got, err := Extract(context.Background(), writeArchive(t, tt.entries...), dest, nil, 2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if leaked, _ := os.ReadDir(outside); len(leaked) > 0 {
+				t.Errorf("Extract() wrote %s outside of dest", leaked[0].Name())
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Extract() = %v, want %v", got, tt.want)
+			}
+			for i, f := range tt.want {
+				if got[i] != filepath.Join(dest, f) {
+					t.Errorf("Extract()[%d] = %s, want %s", i, got[i], filepath.Join(dest, f))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package yaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Writer writes entries to an (uncompressed) Apple Archive stream
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer writing an Apple Archive to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteEntry writes the entry's header followed by its ent.Size bytes of data (for regular files)
+func (w *Writer) WriteEntry(ent *Entry, data io.Reader) error {
+	hdr, err := encodeEntry(ent)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(hdr); err != nil {
+		return fmt.Errorf("failed to write %s header: %w", ent.Path, err)
+	}
+	if ent.Type == RegularFile && ent.Size > 0 {
+		if data == nil {
+			return fmt.Errorf("missing %s data", ent.Path)
+		}
+		if _, err := io.CopyN(w.w, data, int64(ent.Size)); err != nil {
+			return fmt.Errorf("failed to write %s data: %w", ent.Path, err)
+		}
+	}
+	return nil
+}
+
+// encodeEntry encodes an entry header (the inverse of DecodeEntry)
+func encodeEntry(ent *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	field := func(key string, val any) {
+		buf.WriteString(key)
+		binary.Write(&buf, binary.LittleEndian, val)
+	}
+	str := func(key, val string) error {
+		if len(val) > math.MaxUint16 {
+			return fmt.Errorf("%s field too long: %s", key, val)
+		}
+		field(key, uint16(len(val)))
+		buf.WriteString(val)
+		return nil
+	}
+
+	field("TYP1", byte(ent.Type))
+	if err := str("PATP", ent.Path); err != nil {
+		return nil, err
+	}
+	if ent.Type == SymbolicLink {
+		if err := str("LNKP", ent.Link); err != nil {
+			return nil, err
+		}
+	}
+	if ent.Uid > math.MaxUint8 {
+		field("UID2", ent.Uid)
+	} else {
+		field("UID1", uint8(ent.Uid))
+	}
+	if ent.Gid > math.MaxUint8 {
+		field("GID2", ent.Gid)
+	} else {
+		field("GID1", uint8(ent.Gid))
+	}
+	field("MOD2", uint16(ent.Mod))
+	if ent.Flag > 0 {
+		field("FLG4", ent.Flag)
+	}
+	if !ent.Mtm.IsZero() {
+		field("MTMT", ent.Mtm.Unix())
+		binary.Write(&buf, binary.LittleEndian, int32(ent.Mtm.Nanosecond()))
+	}
+	if ent.Type == RegularFile {
+		if ent.Size > math.MaxUint16 {
+			field("DATB", ent.Size)
+		} else {
+			field("DATA", uint16(ent.Size))
+		}
+	}
+
+	size := 4 + 2 + buf.Len() // magic + header size + fields
+	if size > math.MaxUint16 {
+		return nil, fmt.Errorf("%s header too large", ent.Path)
+	}
+	hdr := make([]byte, 6, size)
+	binary.LittleEndian.PutUint32(hdr, MagicAA01)
+	binary.LittleEndian.PutUint16(hdr[4:], uint16(size))
+	return append(hdr, buf.Bytes()...), nil
+}
+
+// fileModeToUnixMode returns the unix permission bits of a file mode (the inverse of unixModeToFileMode)
+func fileModeToUnixMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		mode |= s_ISUID
+	}
+	if m&fs.ModeSetgid != 0 {
+		mode |= s_ISGID
+	}
+	if m&fs.ModeSticky != 0 {
+		mode |= s_ISVTX
+	}
+	return mode
+}
+
+// Create writes an Apple Archive of the folder src (its files whose path matches include, all of them if
+// it is nil) to w; the entries' owners aren't archived (they are extracted as the current user) and it
+// returns the archived paths
+func Create(w io.Writer, src string, include *regexp.Regexp) ([]string, error) {
+	aw := NewWriter(w)
+	var paths []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = "" // the archive's root folder
+		}
+		if len(rel) > 0 && include != nil && !d.IsDir() && !include.MatchString(rel) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		ent := &Entry{
+			Path: rel,
+			Mod:  fs.FileMode(fileModeToUnixMode(fi.Mode())),
+			Mtm:  fi.ModTime(),
+		}
+		var data io.Reader
+		switch {
+		case d.IsDir():
+			ent.Type = Directory
+		case fi.Mode()&fs.ModeSymlink != 0:
+			ent.Type = SymbolicLink
+			if ent.Link, err = os.Readlink(path); err != nil {
+				return fmt.Errorf("failed to read symlink %s: %v", path, err)
+			}
+		case fi.Mode().IsRegular():
+			if fi.Size() > math.MaxUint32 {
+				return fmt.Errorf("%s is too large to archive (%d bytes)", path, fi.Size())
+			}
+			ent.Type = RegularFile
+			ent.Size = uint32(fi.Size())
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %v", path, err)
+			}
+			defer f.Close()
+			data = f
+		default: // devices, fifos and sockets
+			return nil
+		}
+		if err := aw.WriteEntry(ent, data); err != nil {
+			return err
+		}
+		if len(rel) > 0 {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive %s: %w", src, err)
+	}
+	return paths, nil
+}
+
+// List returns the entries of the YAA stream whose path matches include (all of them if it is nil);
+// unlike Parse the stream is read sequentially so it doesn't need to be seekable
+func List(r io.Reader, include *regexp.Regexp) ([]*Entry, error) {
+	var entries []*Entry
+	for {
+		ent, err := readEntry(r)
+		if err != nil {
+			if err == io.EOF {
+				return entries, nil
+			}
+			return nil, err
+		}
+		skip := int64(ent.Xat)
+		if ent.Type == RegularFile {
+			skip += int64(ent.Size)
+		}
+		if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+			return nil, fmt.Errorf("failed to skip %s data: %w", ent.Path, err)
+		}
+		if include == nil || include.MatchString(ent.Path) {
+			entries = append(entries, ent)
+		}
+	}
+}
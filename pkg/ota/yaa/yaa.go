@@ -5,6 +5,7 @@ package yaa
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -132,6 +133,32 @@ func (e *Entry) String() string {
 	}
 }
 
+// MarshalJSON returns the JSON of the entry's file attributes
+func (e *Entry) MarshalJSON() ([]byte, error) {
+	out := &struct {
+		Type string     `json:"type"`
+		Path string     `json:"path"`
+		Link string     `json:"link,omitempty"`
+		Uid  uint16     `json:"uid"`
+		Gid  uint16     `json:"gid"`
+		Mode string     `json:"mode"`
+		Size uint32     `json:"size,omitempty"`
+		Mtm  *time.Time `json:"mtime,omitempty"`
+	}{
+		Type: string(rune(e.Type)),
+		Path: e.Path,
+		Link: e.Link,
+		Uid:  e.Uid,
+		Gid:  e.Gid,
+		Mode: unixModeToFileMode(uint32(e.Mod)).String(),
+		Size: e.Size,
+	}
+	if !e.Mtm.IsZero() {
+		out.Mtm = &e.Mtm
+	}
+	return json.Marshal(out)
+}
+
 func (e *Entry) IsDir() bool {
 	return e.Type == Directory
 }
@@ -590,42 +617,53 @@ func (y *YAA) FileSize() uint64 {
 	return total
 }
 
+// readEntry reads the next entry header (returns io.EOF at the end of the archive)
+func readEntry(r io.Reader) (*Entry, error) {
+	var magic uint32
+	var headerSize uint16
+
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read magic: %w", err)
+	}
+	if magic != MagicYAA1 && magic != MagicAA01 {
+		return nil, ErrInvalidMagic
+	}
+	if err := binary.Read(r, binary.LittleEndian, &headerSize); err != nil {
+		return nil, fmt.Errorf("failed to read header size: %w", err)
+	}
+	if headerSize <= 5 {
+		return nil, fmt.Errorf("invalid header size: %d", headerSize)
+	}
+
+	header := make([]byte, headerSize-uint16(binary.Size(magic))-uint16(binary.Size(headerSize)))
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	ent, err := DecodeEntry(bytes.NewReader(header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode AA entry: %v", err)
+	}
+	return ent, nil
+}
+
 func Parse(r io.ReadSeeker) (*YAA, error) {
 	yaa := &YAA{sr: r}
 
 	seen := make(map[string]int)
 
-	var magic uint32
-	var headerSize uint16
-
 	for {
-		var ent *Entry
-		err := binary.Read(r, binary.LittleEndian, &magic)
+		ent, err := readEntry(r)
 		if err != nil {
 			if err == io.EOF {
 				break
+			} else if err == ErrInvalidMagic {
+				return nil, ErrInvalidMagic
 			}
-			return yaa, fmt.Errorf("Parse: failed to read magic: %w", err)
-		}
-
-		if magic != MagicYAA1 && magic != MagicAA01 {
-			return nil, ErrInvalidMagic
-		}
-		if err := binary.Read(r, binary.LittleEndian, &headerSize); err != nil {
-			return yaa, fmt.Errorf("Parse: failed to read header size: %w", err)
-		}
-		if headerSize <= 5 {
-			return yaa, fmt.Errorf("Parse invalid header size: %d", headerSize)
-		}
-
-		header := make([]byte, headerSize-uint16(binary.Size(magic))-uint16(binary.Size(headerSize)))
-		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
-			return yaa, fmt.Errorf("Parse: failed to read header: %w", err)
-		}
-
-		ent, err = DecodeEntry(bytes.NewReader(header))
-		if err != nil {
-			return yaa, fmt.Errorf("Parse: failed to decode AA entry: %v", err)
+			return yaa, fmt.Errorf("Parse: %w", err)
 		}
 
 		if ent.Type == RegularFile {
@@ -0,0 +1,260 @@
+package plist
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-plist"
+)
+
+// ParseManifests returns the full BuildManifest.plist and Restore.plist of an IPSW/OTA as generic
+// structured data (keyed by "BuildManifest" and "Restore") with their data values as hex strings
+func ParseManifests(files []*zip.File) (map[string]any, error) {
+	manifests := make(map[string]any)
+	for _, f := range files {
+		var name string
+		switch {
+		case strings.HasSuffix(f.Name, "Restore.plist"):
+			name = "Restore"
+		case strings.HasSuffix(f.Name, "BuildManifest.plist") && !strings.Contains(f.Name, "Restore"):
+			name = "BuildManifest"
+		default:
+			continue
+		}
+		dat, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plist file: %s", err)
+		}
+		var v any
+		if err := plist.NewDecoder(bytes.NewReader(dat)).Decode(&v); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", f.Name, err)
+		}
+		manifests[name] = normalize(v)
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no BuildManifest.plist or Restore.plist found")
+	}
+	return manifests, nil
+}
+
+// normalize converts the plist values that don't translate to JSON (data and dates) to strings
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = normalize(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+	case []byte:
+		return hex.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return v
+}
+
+// Query returns the values of v selected by the query; a query is a '.' separated path of keys each optionally
+// followed by selectors in brackets: an index ([0]), all the elements or values ([*] or a '*' key) or the elements
+// whose (nested) key matches a value ([Info.DeviceClass=j414sap] or [ApBoardID!=0x0C]) compared case-insensitively.
+//
+//	BuildIdentities[Info.DeviceClass=j414sap][Info.Variant=Customer Erase Install (IPSW)].Manifest.KernelCache.Info.Path
+//
+// A single value is returned unless the query contains a wildcard or a filter, in which case the list of matches is returned
+func Query(v any, query string) (any, error) {
+	segments, err := splitQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results := []any{v}
+	multi := false
+	for _, seg := range segments {
+		key, sels, err := parseSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		var next []any
+		prevMulti := multi
+		collected := false // next holds the matches of a wildcard or filter of this segment
+		for _, r := range results {
+			switch key {
+			case "":
+				next = append(next, r)
+			case "*":
+				multi, collected = true, true
+				next = append(next, values(r)...)
+			default:
+				if val, ok := lookup(r, key); ok {
+					next = append(next, val)
+				}
+			}
+		}
+		for _, sel := range sels {
+			if collected { // the selectors following a wildcard or filter apply to its matches (i.e. [Variant=Erase][0])
+				s, m, err := selectValues(next, sel)
+				if err != nil {
+					return nil, err
+				}
+				next, multi = s, prevMulti || m
+				continue
+			}
+			var selected []any
+			for _, r := range next {
+				s, m, err := selectValues(r, sel)
+				if err != nil {
+					return nil, err
+				}
+				multi, collected = multi || m, collected || m
+				selected = append(selected, s...)
+			}
+			next = selected
+		}
+		results = next
+	}
+	if multi {
+		if results == nil {
+			results = []any{}
+		}
+		return results, nil
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no value found for query '%s'", query)
+	}
+	return results[0], nil
+}
+
+// splitQuery splits a query on the dots that aren't inside brackets
+func splitQuery(query string) ([]string, error) {
+	var segments []string
+	depth, start := 0, 0
+	for i, c := range query {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced ']' at offset %d of query", i)
+			}
+		case '.':
+			if depth == 0 {
+				segments = append(segments, query[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced '[' in query")
+	}
+	return append(segments, query[start:]), nil
+}
+
+// parseSegment splits a query segment into its key and bracketed selectors
+func parseSegment(seg string) (string, []string, error) {
+	key, rest, _ := strings.Cut(seg, "[")
+	if len(rest) == 0 {
+		return key, nil, nil
+	}
+	var sels []string
+	rest = "[" + rest
+	for len(rest) > 0 {
+		if rest[0] != '[' {
+			return "", nil, fmt.Errorf("invalid query segment '%s'", seg)
+		}
+		depth := 0
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			switch r {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			return depth == 0
+		})
+		sels = append(sels, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return key, sels, nil
+}
+
+// selectValues applies a bracketed selector to v returning the selected values and whether it can select several
+func selectValues(v any, sel string) ([]any, bool, error) {
+	switch {
+	case sel == "*":
+		return values(v), true, nil
+	case strings.Contains(sel, "="):
+		path, want, _ := strings.Cut(sel, "=")
+		negate := strings.HasSuffix(path, "!")
+		path = strings.TrimSpace(strings.TrimSuffix(path, "!"))
+		want = strings.TrimSpace(want)
+		var matches []any
+		for _, elem := range values(v) {
+			got, err := Query(elem, path)
+			if err != nil { // elements without the key never match
+				if negate {
+					matches = append(matches, elem)
+				}
+				continue
+			}
+			if strings.EqualFold(fmt.Sprint(got), want) != negate {
+				matches = append(matches, elem)
+			}
+		}
+		return matches, true, nil
+	}
+	idx, err := strconv.Atoi(sel)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid selector '[%s]'", sel)
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, false, fmt.Errorf("cannot index %T with [%d]", v, idx)
+	}
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil, false, nil
+	}
+	return []any{arr[idx]}, false, nil
+}
+
+// lookup returns the value of a dictionary's key (falling back to a case-insensitive match)
+func lookup(v any, key string) (any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+	for k, val := range m {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// values returns the elements of an array or the values of a dictionary (in key order)
+func values(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		var vals []any
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			vals = append(vals, v[k])
+		}
+		return vals
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Package rtkit parses the firmware of the RTKit-based Apple coprocessors (AOP, DCP, ANE, AVE, PMP, AGX, ...)
+package rtkit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blacktop/go-macho"
+)
+
+// TableMagic is the magic of the RTKit firmware table that bundles several RTKit images
+const TableMagic = "rkosftab"
+
+const tableMagicOffset = 32
+
+// VersionRE matches the RTKit version banner (e.g. RTKit_iOS-2938.100.9.RELEASE)
+var VersionRE = regexp.MustCompile(`RTKit[A-Za-z_]*-[0-9]+(?:\.[0-9]+)*(?:\.[A-Z]+)?`)
+
+var machoMagic = []byte{0xcf, 0xfa, 0xed, 0xfe} // MH_MAGIC_64
+
+// TableHeader is the header of an RTKit firmware table
+type TableHeader struct {
+	_        [32]byte
+	Magic    [8]byte // "rkosftab"
+	NumBlobs uint32
+	_        uint32
+}
+
+// TableEntry is a single entry of an RTKit firmware table
+type TableEntry struct {
+	Name   [4]byte
+	Offset uint32
+	Size   uint32
+	_      uint32
+}
+
+// Segment is a MachO segment of an RTKit image
+type Segment struct {
+	Name     string `json:"name"`
+	Addr     uint64 `json:"addr"`
+	Size     uint64 `json:"size"`
+	Offset   uint64 `json:"offset"`
+	FileSize uint64 `json:"file_size"`
+	Prot     string `json:"prot"`
+}
+
+func (s Segment) String() string {
+	return fmt.Sprintf("%-16s addr=%#016x size=%#08x off=%#08x filesz=%#08x %s", s.Name, s.Addr, s.Size, s.Offset, s.FileSize, s.Prot)
+}
+
+// MachO is a MachO embedded in an RTKit image
+type MachO struct {
+	Offset   uint64    `json:"offset"`
+	Size     uint64    `json:"size"`
+	CPU      string    `json:"cpu"`
+	Type     string    `json:"type"`
+	UUID     string    `json:"uuid,omitempty"`
+	Segments []Segment `json:"segments,omitempty"`
+}
+
+// Image is an entry in an RTKit firmware table
+type Image struct {
+	Name     string    `json:"name"`
+	Offset   uint32    `json:"offset"`
+	Size     uint32    `json:"size"`
+	Firmware *Firmware `json:"firmware"`
+}
+
+// Firmware is a parsed RTKit firmware image (or firmware table)
+type Firmware struct {
+	Version string  `json:"version,omitempty"`
+	Size    int     `json:"size"`
+	MachOs  []MachO `json:"machos,omitempty"`
+	Images  []Image `json:"images,omitempty"`
+
+	data []byte
+}
+
+// Data returns the raw firmware
+func (f *Firmware) Data() []byte {
+	return f.data
+}
+
+// IsTable returns true if the firmware is an RTKit firmware table
+func (f *Firmware) IsTable() bool {
+	return len(f.Images) > 0
+}
+
+func (f *Firmware) String() string {
+	var sb strings.Builder
+	if len(f.Version) > 0 {
+		sb.WriteString(fmt.Sprintf("Version: %s\n", f.Version))
+	}
+	sb.WriteString(fmt.Sprintf("Size:    %#x\n", f.Size))
+	for _, m := range f.MachOs {
+		sb.WriteString(fmt.Sprintf("MachO @ %#x (%s %s)", m.Offset, m.CPU, m.Type))
+		if len(m.UUID) > 0 {
+			sb.WriteString(" " + m.UUID)
+		}
+		sb.WriteString("\n")
+		for _, s := range m.Segments {
+			sb.WriteString("  " + s.String() + "\n")
+		}
+	}
+	for _, img := range f.Images {
+		sb.WriteString(fmt.Sprintf("\n[%s] off=%#08x size=%#08x\n", img.Name, img.Offset, img.Size))
+		sb.WriteString("  " + strings.ReplaceAll(strings.TrimSuffix(img.Firmware.String(), "\n"), "\n", "\n  ") + "\n")
+	}
+	return sb.String()
+}
+
+// IsRTKit returns true if the data looks like RTKit firmware
+func IsRTKit(dat []byte) bool {
+	return isTable(dat) || VersionRE.Match(dat)
+}
+
+func isTable(dat []byte) bool {
+	return len(dat) >= tableMagicOffset+len(TableMagic) &&
+		string(dat[tableMagicOffset:tableMagicOffset+len(TableMagic)]) == TableMagic
+}
+
+// Parse parses RTKit firmware (an RTKit firmware table or a single RTKit image)
+func Parse(dat []byte) (*Firmware, error) {
+	if isTable(dat) {
+		return parseTable(dat)
+	}
+	f := &Firmware{
+		Size:   len(dat),
+		MachOs: findMachOs(dat),
+		data:   dat,
+	}
+	if v := VersionRE.Find(dat); v != nil {
+		f.Version = string(v)
+	}
+	return f, nil
+}
+
+func parseTable(dat []byte) (*Firmware, error) {
+	r := bytes.NewReader(dat)
+
+	var hdr TableHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to read RTKit table header: %v", err)
+	}
+
+	if uint64(hdr.NumBlobs)*uint64(binary.Size(TableEntry{})) > uint64(r.Len()) {
+		return nil, fmt.Errorf("RTKit table has more entries (%d) than fit in the file", hdr.NumBlobs)
+	}
+
+	entries := make([]TableEntry, hdr.NumBlobs)
+	if err := binary.Read(r, binary.LittleEndian, &entries); err != nil {
+		return nil, fmt.Errorf("failed to read RTKit table entries: %v", err)
+	}
+
+	f := &Firmware{
+		Size: len(dat),
+		data: dat,
+	}
+	for _, e := range entries {
+		name := strings.TrimRight(string(e.Name[:]), "\x00")
+		if uint64(e.Offset)+uint64(e.Size) > uint64(len(dat)) {
+			return nil, fmt.Errorf("RTKit image %s extends past end of file (offset=%#x, size=%#x)", name, e.Offset, e.Size)
+		}
+		sub, err := Parse(dat[e.Offset : e.Offset+e.Size])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RTKit image %s: %v", name, err)
+		}
+		if len(f.Version) == 0 {
+			f.Version = sub.Version
+		}
+		f.Images = append(f.Images, Image{
+			Name:     name,
+			Offset:   e.Offset,
+			Size:     e.Size,
+			Firmware: sub,
+		})
+	}
+
+	return f, nil
+}
+
+// findMachOs finds the 64-bit MachOs embedded in the data (including one at offset 0)
+func findMachOs(dat []byte) []MachO {
+	var machos []MachO
+
+	for off := 0; off < len(dat); {
+		idx := bytes.Index(dat[off:], machoMagic)
+		if idx < 0 {
+			break
+		}
+		off += idx
+		if off%4 != 0 {
+			off++
+			continue
+		}
+		m, err := macho.NewFile(bytes.NewReader(dat[off:]))
+		if err != nil {
+			off += len(machoMagic)
+			continue
+		}
+		mo := MachO{
+			Offset: uint64(off),
+			CPU:    m.CPU.String(),
+			Type:   m.Type.String(),
+		}
+		if id := m.UUID(); id != nil {
+			mo.UUID = id.String()
+		}
+		for _, seg := range m.Segments() {
+			mo.Segments = append(mo.Segments, Segment{
+				Name:     seg.Name,
+				Addr:     seg.Addr,
+				Size:     seg.Memsz,
+				Offset:   seg.Offset,
+				FileSize: seg.Filesz,
+				Prot:     seg.Prot.String(),
+			})
+			mo.Size = max(mo.Size, seg.Offset+seg.Filesz)
+		}
+		m.Close()
+		machos = append(machos, mo)
+		if mo.Size > 0 {
+			off += int(mo.Size)
+		} else {
+			off += len(machoMagic)
+		}
+	}
+
+	return machos
+}
@@ -0,0 +1,158 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the maximum number of paths through an operation's nodes turned into rules
+const maxPaths = 1024
+
+type cond struct {
+	node  uint16
+	match bool
+}
+
+type rule struct {
+	action uint16
+	conds  []cond
+}
+
+type decompiler struct {
+	sb   *Sandbox
+	only map[uint32]bool // (node<<16 | action) -> whether all the terminals reachable from node have action
+}
+
+func action(flags uint16) string {
+	if flags&1 == 1 {
+		return "deny"
+	}
+	return "allow"
+}
+
+// onlyAction returns true if all the terminals reachable from the node have the action
+func (d *decompiler) onlyAction(node, act uint16, depth int) bool {
+	key := uint32(node)<<16 | uint32(act)
+	if v, ok := d.only[key]; ok {
+		return v
+	}
+	n := d.sb.Nodes[node]
+	var v bool
+	switch {
+	case n.Terminal:
+		v = n.Action == act
+	case depth > len(d.sb.Nodes): // cycle
+		v = false
+	default:
+		v = d.onlyAction(n.Match, act, depth+1) && d.onlyAction(n.Unmatch, act, depth+1)
+	}
+	d.only[key] = v
+	return v
+}
+
+// rules returns the rules of the paths from an operation's first node to its terminals (keeping only the conditions
+// changing the outcome) and whether the paths were truncated
+func (d *decompiler) rules(start uint16) ([]rule, bool) {
+	var rules []rule
+	truncated := false
+	var walk func(node uint16, path []cond)
+	walk = func(node uint16, path []cond) {
+		if len(rules) >= maxPaths {
+			truncated = true
+			return
+		}
+		n := d.sb.Nodes[node]
+		if n.Terminal {
+			r := rule{action: n.Action}
+			for _, c := range path {
+				other := d.sb.Nodes[c.node].Unmatch
+				if !c.match {
+					other = d.sb.Nodes[c.node].Match
+				}
+				if !d.onlyAction(other, n.Action, 0) { // the condition matters
+					r.conds = append(r.conds, c)
+				}
+			}
+			rules = append(rules, r)
+			return
+		}
+		if len(path) > len(d.sb.Nodes) { // cycle
+			truncated = true
+			return
+		}
+		walk(n.Match, append(path[:len(path):len(path)], cond{node: node, match: true}))
+		walk(n.Unmatch, append(path[:len(path):len(path)], cond{node: node, match: false}))
+	}
+	walk(start, nil)
+	return rules, truncated
+}
+
+func (d *decompiler) writeRule(sb *strings.Builder, op string, r rule) {
+	var conds []string
+	for _, c := range r.conds {
+		f := d.sb.filter(d.sb.Nodes[c.node])
+		if !c.match {
+			f = "(require-not " + f + ")"
+		}
+		conds = append(conds, f)
+	}
+	switch len(conds) {
+	case 0:
+		fmt.Fprintf(sb, "(%s %s)", action(r.action), op)
+	case 1:
+		fmt.Fprintf(sb, "(%s %s %s)", action(r.action), op, conds[0])
+	default:
+		fmt.Fprintf(sb, "(%s %s\n    (require-all\n        %s))", action(r.action), op, strings.Join(conds, "\n        "))
+	}
+	if r.action&^1 != 0 {
+		fmt.Fprintf(sb, " ; flags %#x", r.action)
+	}
+	sb.WriteString("\n")
+}
+
+// Decompile returns the SBPL of a profile (the rules of each operation differing from the default action)
+func (sb *Sandbox) Decompile(p *Profile) string {
+	d := &decompiler{sb: sb, only: make(map[uint32]bool)}
+
+	var out strings.Builder
+	if len(p.Name) > 0 {
+		fmt.Fprintf(&out, ";; %s\n", p.Name)
+	}
+	if len(sb.Vars) > 0 {
+		fmt.Fprintf(&out, ";; variables: %s\n", strings.Join(sb.Vars, ", "))
+	}
+	out.WriteString("(version 1)\n")
+
+	defAction := uint16(1)
+	if def := sb.Nodes[p.Ops[0]]; def.Terminal {
+		defAction = def.Action
+	} else {
+		out.WriteString(";; NOTE: the default operation is not a terminal node\n")
+	}
+	fmt.Fprintf(&out, "(%s default)\n", action(defAction))
+
+	for i := 1; i < len(p.Ops); i++ {
+		if p.Ops[i] == p.Ops[0] {
+			continue // same as default
+		}
+		rules, truncated := d.rules(p.Ops[i])
+		seen := make(map[string]bool)
+		for _, r := range rules {
+			if r.action == defAction {
+				continue
+			}
+			var rs strings.Builder
+			d.writeRule(&rs, sb.Operations[i], r)
+			if seen[rs.String()] {
+				continue
+			}
+			seen[rs.String()] = true
+			out.WriteString(rs.String())
+		}
+		if truncated {
+			fmt.Fprintf(&out, ";; NOTE: %s has more than %d paths (truncated)\n", sb.Operations[i], maxPaths)
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,162 @@
+package sandbox
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type argType int
+
+const (
+	argString argType = iota
+	argInt
+	argOctal
+	argBool
+	argVnodeType
+	argSocketDomain
+	argTarget
+)
+
+type filterInfo struct {
+	Name string
+	Arg  argType
+}
+
+// the filters (the node's filter IDs with the high bit set take a regex index instead of their argument)
+var filters = map[uint8]filterInfo{
+	0x01: {"literal", argString},
+	0x02: {"mount-relative-path", argString},
+	0x03: {"xattr", argString},
+	0x04: {"file-mode", argOctal},
+	0x05: {"ipc-posix-name", argString},
+	0x06: {"global-name", argString},
+	0x07: {"local-name", argString},
+	0x08: {"local", argInt},
+	0x09: {"remote", argInt},
+	0x0a: {"control-name", argString},
+	0x0b: {"socket-domain", argSocketDomain},
+	0x0c: {"socket-type", argInt},
+	0x0d: {"socket-protocol", argInt},
+	0x0e: {"target", argTarget},
+	0x0f: {"fsctl-command", argInt},
+	0x10: {"ioctl-command", argInt},
+	0x11: {"iokit-user-client-class", argString},
+	0x12: {"iokit-property", argString},
+	0x13: {"iokit-connection", argString},
+	0x14: {"device-major", argInt},
+	0x15: {"device-minor", argInt},
+	0x16: {"device-conforms-to", argString},
+	0x17: {"extension", argString},
+	0x18: {"extension-class", argString},
+	0x19: {"appleevent-destination", argString},
+	0x1a: {"system-attribute", argString},
+	0x1b: {"right-name", argString},
+	0x1c: {"preference-domain", argString},
+	0x1d: {"vnode-type", argVnodeType},
+	0x1e: {"require-entitlement", argString},
+	0x1f: {"entitlement-value", argBool},
+	0x20: {"entitlement-value", argString},
+	0x21: {"kext-bundle-id", argString},
+	0x22: {"info-type", argString},
+	0x23: {"notification-name", argString},
+	0x24: {"notification-payload", argString},
+	0x25: {"semaphore-owner", argTarget},
+	0x26: {"sysctl-name", argString},
+	0x27: {"process-name", argString},
+	0x2c: {"privilege-id", argInt},
+	0x2d: {"process-attribute", argInt},
+	0x2e: {"uid", argInt},
+	0x2f: {"nvram-variable", argString},
+	0x30: {"csr", argInt},
+	0x31: {"host-special-port", argInt},
+	0x32: {"filesystem-name", argString},
+	0x33: {"boot-arg", argString},
+	0x34: {"xpc-service-name", argString},
+	0x35: {"signing-identifier", argString},
+	0x36: {"signal-number", argInt},
+	0x37: {"target-signing-identifier", argString},
+	0x38: {"reference-count", argInt},
+}
+
+var vnodeTypes = map[uint16]string{
+	1:      "REGULAR-FILE",
+	2:      "DIRECTORY",
+	3:      "BLOCK-DEVICE",
+	4:      "CHARACTER-DEVICE",
+	5:      "SYMLINK",
+	6:      "SOCKET",
+	7:      "FIFO",
+	0xffff: "TTY",
+}
+
+var socketDomains = map[uint16]string{
+	1:  "AF_UNIX",
+	2:  "AF_INET",
+	17: "AF_ROUTE",
+	27: "AF_NDRV",
+	30: "AF_INET6",
+	32: "AF_SYSTEM",
+}
+
+var targets = map[uint16]string{
+	1: "self",
+	2: "pgrp",
+	3: "others",
+	4: "children",
+	5: "same-sandbox",
+}
+
+// filter returns the SBPL of a filter node's condition
+func (sb *Sandbox) filter(n Node) string {
+	id := n.Filter &^ 0x80
+	info, ok := filters[id]
+	if !ok {
+		info = filterInfo{Name: fmt.Sprintf("filter-%#x", id), Arg: argInt}
+	}
+
+	if n.Filter&0x80 != 0 { // regex argument
+		re := fmt.Sprintf("<regex %d>", n.Arg)
+		if int(n.Arg) < len(sb.Regexes) {
+			re = sb.Regexes[n.Arg]
+		}
+		if id == 0x01 {
+			return fmt.Sprintf("(regex #\"%s\")", re)
+		}
+		return fmt.Sprintf("(%s (regex #\"%s\"))", info.Name, re)
+	}
+
+	var arg string
+	switch info.Arg {
+	case argString:
+		s, err := sb.str(n.Arg)
+		if err != nil {
+			arg = fmt.Sprintf("#x%x", n.Arg)
+		} else {
+			arg = strconv.Quote(s)
+		}
+	case argOctal:
+		arg = fmt.Sprintf("#o%o", n.Arg)
+	case argBool:
+		arg = "#f"
+		if n.Arg != 0 {
+			arg = "#t"
+		}
+	case argVnodeType:
+		arg = lookup(vnodeTypes, n.Arg)
+	case argSocketDomain:
+		arg = lookup(socketDomains, n.Arg)
+	case argTarget:
+		arg = lookup(targets, n.Arg)
+	default:
+		arg = strconv.Itoa(int(n.Arg))
+	}
+
+	return fmt.Sprintf("(%s %s)", info.Name, arg)
+}
+
+func lookup(names map[uint16]string, v uint16) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return strconv.Itoa(int(v))
+}
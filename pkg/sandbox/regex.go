@@ -0,0 +1,72 @@
+package sandbox
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const regexVersion = 3
+
+// decodeRegex decodes a compiled (AppleMatch) regex back to its pattern
+//
+// NOTE: only the linear regexes (literals, '.', '^', '$' and character classes) are decoded,
+// the others (alternations and repetitions) are returned as their compiled hex bytes
+func decodeRegex(dat []byte) string {
+	if len(dat) < 4 || binary.BigEndian.Uint32(dat) != regexVersion {
+		return fmt.Sprintf("<compiled %x>", dat)
+	}
+
+	var sb strings.Builder
+	for i := 4; i < len(dat); {
+		op := dat[i]
+		switch {
+		case op == 0x15: // end
+			return sb.String()
+		case op == 0x19:
+			sb.WriteByte('^')
+			i++
+		case op == 0x29:
+			sb.WriteByte('$')
+			i++
+		case op&0x0f == 0x02: // literal
+			if i+1 >= len(dat) {
+				return fmt.Sprintf("<compiled %x>", dat)
+			}
+			sb.WriteString(escapeRegex(dat[i+1]))
+			i += 2
+		case op&0x0f == 0x09:
+			sb.WriteByte('.')
+			i++
+		case op&0x0f == 0x0b: // character class of (op>>4) ranges
+			count := int(op >> 4)
+			if i+1+2*count > len(dat) {
+				return fmt.Sprintf("<compiled %x>", dat)
+			}
+			sb.WriteByte('[')
+			for j := range count {
+				lo, hi := dat[i+1+2*j], dat[i+2+2*j]
+				sb.WriteString(escapeRegex(lo))
+				if hi != lo {
+					sb.WriteString("-" + escapeRegex(hi))
+				}
+			}
+			sb.WriteByte(']')
+			i += 1 + 2*count
+		default:
+			return fmt.Sprintf("<compiled %x>", dat)
+		}
+	}
+
+	return sb.String()
+}
+
+func escapeRegex(c byte) string {
+	if strings.IndexByte(`\.+*?()|[]{}^$"`, c) >= 0 {
+		return `\` + string(c)
+	}
+	if c < 0x20 || c >= 0x7f {
+		return fmt.Sprintf(`\x%02x`, c)
+	}
+	return string(c)
+}
@@ -0,0 +1,240 @@
+// Package sandbox parses compiled sandbox profiles (and profile collections) and decompiles them to SBPL
+package sandbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	typeProfile    = 0x0000
+	typeCollection = 0x8000
+
+	nodeSize = 8
+)
+
+// ErrInvalidProfile is returned when the data is not a compiled sandbox profile with the given operations
+var ErrInvalidProfile = errors.New("invalid compiled sandbox profile")
+
+type header struct {
+	Type             uint16
+	OpNodeCount      uint16
+	OpCount          uint8
+	VarCount         uint8
+	StateCount       uint8
+	ProfileCount     uint8
+	RegexCount       uint16
+	EntitlementCount uint16
+}
+
+// Node is an operation node: a filter (branching on whether its argument matches) or a terminal (the action)
+type Node struct {
+	Terminal bool
+	Filter   uint8
+	Arg      uint16
+	Match    uint16 // the next node if the filter matches
+	Unmatch  uint16 // the next node if the filter does NOT match
+	Action   uint16 // the terminal's flags (bit 0 is deny)
+}
+
+// Deny returns true if the terminal denies the operation
+func (n Node) Deny() bool {
+	return n.Action&1 == 1
+}
+
+// Profile is a sandbox profile (the operations' first node)
+type Profile struct {
+	Name    string
+	Version uint16
+	Ops     []uint16
+}
+
+// Sandbox is a compiled sandbox profile or profile collection
+type Sandbox struct {
+	Collection   bool
+	Operations   []string // the operations' names (of the OS version the profile was compiled for)
+	Profiles     []Profile
+	Nodes        []Node
+	Regexes      []string
+	Vars         []string
+	Entitlements []string
+
+	data []byte // the strings and regexes (referenced by offsets in 8 byte units)
+}
+
+// Parse parses a compiled sandbox profile (or profile collection) given the operation names of its OS version
+// (the order of the kernel's operation table, see kernelcache.GetSandboxOperations)
+func Parse(data []byte, ops []string) (*Sandbox, error) {
+	var hdr header
+	r := bytes.NewReader(data)
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if hdr.Type != typeProfile && hdr.Type != typeCollection {
+		return nil, fmt.Errorf("%w: unknown type %#x", ErrInvalidProfile, hdr.Type)
+	}
+	if int(hdr.OpCount) != len(ops) {
+		return nil, fmt.Errorf("%w: has %d operations (expected %d for this OS version)", ErrInvalidProfile, hdr.OpCount, len(ops))
+	}
+	if hdr.OpNodeCount == 0 {
+		return nil, fmt.Errorf("%w: no operation nodes", ErrInvalidProfile)
+	}
+
+	sb := &Sandbox{
+		Collection: hdr.Type == typeCollection,
+		Operations: ops,
+	}
+
+	regexOffsets := make([]uint16, hdr.RegexCount)
+	varOffsets := make([]uint16, hdr.VarCount)
+	stateOffsets := make([]uint16, hdr.StateCount)
+	entOffsets := make([]uint16, hdr.EntitlementCount)
+	for _, offsets := range [][]uint16{regexOffsets, varOffsets, stateOffsets, entOffsets} {
+		if err := binary.Read(r, binary.LittleEndian, offsets); err != nil {
+			return nil, fmt.Errorf("failed to read offsets: %w", err)
+		}
+	}
+
+	var nameOffsets []uint16
+	if sb.Collection {
+		if hdr.ProfileCount == 0 {
+			return nil, fmt.Errorf("%w: collection has no profiles", ErrInvalidProfile)
+		}
+		for range hdr.ProfileCount {
+			var p struct {
+				NameOffset uint16
+				Version    uint16
+			}
+			if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+				return nil, fmt.Errorf("failed to read profile: %w", err)
+			}
+			prof := Profile{Version: p.Version, Ops: make([]uint16, hdr.OpCount)}
+			if err := binary.Read(r, binary.LittleEndian, prof.Ops); err != nil {
+				return nil, fmt.Errorf("failed to read profile operations: %w", err)
+			}
+			nameOffsets = append(nameOffsets, p.NameOffset)
+			sb.Profiles = append(sb.Profiles, prof)
+		}
+	} else {
+		prof := Profile{Ops: make([]uint16, hdr.OpCount)}
+		if err := binary.Read(r, binary.LittleEndian, prof.Ops); err != nil {
+			return nil, fmt.Errorf("failed to read operations: %w", err)
+		}
+		sb.Profiles = append(sb.Profiles, prof)
+	}
+
+	// the operation nodes are 8 byte aligned
+	off, _ := r.Seek(0, io.SeekCurrent)
+	if _, err := r.Seek((off+nodeSize-1)&^(nodeSize-1), io.SeekStart); err != nil {
+		return nil, err
+	}
+	raw := make([][nodeSize]byte, hdr.OpNodeCount)
+	if err := binary.Read(r, binary.LittleEndian, raw); err != nil {
+		return nil, fmt.Errorf("failed to read operation nodes: %w", err)
+	}
+	for _, n := range raw {
+		switch n[0] {
+		case 0:
+			node := Node{
+				Filter:  n[1],
+				Arg:     binary.LittleEndian.Uint16(n[2:]),
+				Match:   binary.LittleEndian.Uint16(n[4:]),
+				Unmatch: binary.LittleEndian.Uint16(n[6:]),
+			}
+			if node.Match >= hdr.OpNodeCount || node.Unmatch >= hdr.OpNodeCount {
+				return nil, fmt.Errorf("%w: filter node branches out of bounds", ErrInvalidProfile)
+			}
+			sb.Nodes = append(sb.Nodes, node)
+		case 1:
+			sb.Nodes = append(sb.Nodes, Node{Terminal: true, Action: binary.LittleEndian.Uint16(n[2:])})
+		default:
+			return nil, fmt.Errorf("%w: unknown operation node kind %d", ErrInvalidProfile, n[0])
+		}
+	}
+	for _, p := range sb.Profiles {
+		for _, idx := range p.Ops {
+			if idx >= hdr.OpNodeCount {
+				return nil, fmt.Errorf("%w: operation node %d out of bounds", ErrInvalidProfile, idx)
+			}
+		}
+	}
+
+	off, _ = r.Seek(0, io.SeekCurrent)
+	sb.data = data[off:]
+
+	for i, noff := range nameOffsets {
+		name, err := sb.str(noff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile %d name: %w", i, err)
+		}
+		sb.Profiles[i].Name = name
+	}
+	for i, roff := range regexOffsets {
+		re, err := sb.regex(roff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read regex %d: %w", i, err)
+		}
+		sb.Regexes = append(sb.Regexes, re)
+	}
+	for i, voff := range varOffsets {
+		v, err := sb.str(voff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read variable %d: %w", i, err)
+		}
+		sb.Vars = append(sb.Vars, v)
+	}
+	for i, eoff := range entOffsets {
+		e, err := sb.str(eoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entitlement %d: %w", i, err)
+		}
+		sb.Entitlements = append(sb.Entitlements, e)
+	}
+
+	return sb, nil
+}
+
+// blob returns the length prefixed data at off (in 8 byte units) of the data area
+func (sb *Sandbox) blob(off uint16) ([]byte, error) {
+	pos := int(off) * 8
+	if pos+2 > len(sb.data) {
+		return nil, fmt.Errorf("offset %#x out of bounds", pos)
+	}
+	size := int(binary.LittleEndian.Uint16(sb.data[pos:]))
+	if pos+2+size > len(sb.data) {
+		return nil, fmt.Errorf("data at offset %#x (size %d) out of bounds", pos, size)
+	}
+	return sb.data[pos+2 : pos+2+size], nil
+}
+
+func (sb *Sandbox) str(off uint16) (string, error) {
+	dat, err := sb.blob(off)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(dat, "\x00")), nil
+}
+
+func (sb *Sandbox) regex(off uint16) (string, error) {
+	dat, err := sb.blob(off)
+	if err != nil {
+		return "", err
+	}
+	return decodeRegex(dat), nil
+}
+
+// Profile returns the profile with name (or the only profile of a standalone profile)
+func (sb *Sandbox) Profile(name string) (*Profile, error) {
+	if !sb.Collection && len(name) == 0 {
+		return &sb.Profiles[0], nil
+	}
+	for i := range sb.Profiles {
+		if sb.Profiles[i].Name == name {
+			return &sb.Profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("profile '%s' not found", name)
+}
@@ -265,6 +265,19 @@ func (sm SymbolMap) getMig(m *macho.File) error {
 	return nil
 }
 
+// SymbolicateMachO symbolicates a standalone firmware MachO (i.e. SPTM or TXM) with the signatures whose target is name
+func (sm SymbolMap) SymbolicateMachO(m *macho.File, name string, sigs []Symbolicator, quiet bool) error {
+	for _, sig := range sigs {
+		if !strings.EqualFold(sig.Target, name) {
+			continue
+		}
+		if err := sm.symbolicate(m, name, sig, quiet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (sm SymbolMap) Symbolicate(infile string, sigs []Symbolicator, quiet bool) error {
 	kc, err := macho.Open(infile)
 	if err != nil {
@@ -0,0 +1,162 @@
+package tss
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/google/uuid"
+)
+
+// RestoreConfig is the config for personalizing every component of a full restore
+type RestoreConfig struct {
+	ECID             uint64
+	ApBoardID        uint64
+	ApChipID         uint64
+	ApSecurityDomain uint64
+	ApNonce          []byte
+	SepNonce         []byte
+	ProductionMode   bool
+	SecurityMode     bool
+	// Identity is the raw BuildIdentity dictionary (from the IPSW's BuildManifest.plist) being restored
+	Identity map[string]any
+	Proxy    string
+	Insecure bool
+}
+
+// restoreRequestRuleMatches reports whether all of a RestoreRequestRules rule's conditions hold for params
+func restoreRequestRuleMatches(rule map[string]any, params map[string]any) bool {
+	conds, _ := rule["Conditions"].(map[string]any)
+	for k, v := range conds {
+		var key string
+		switch k {
+		case "ApRawProductionMode", "ApCurrentProductionMode":
+			key = "ApProductionMode"
+		case "ApRawSecurityMode":
+			key = "ApSecurityMode"
+		case "ApRequiresImage4":
+			key = "ApSupportsImg4"
+		case "ApDemotionPolicyOverride":
+			key = "DemotionPolicy"
+		case "ApInRomDFU":
+			key = "ApInRomDFU"
+		default:
+			log.Debugf("unknown RestoreRequestRules condition %s (skipping rule)", k)
+			return false
+		}
+		if params[key] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// restoreEntry builds the TSS request entry for a manifest component or returns nil if it should not be requested
+func restoreEntry(name string, entry map[string]any, params map[string]any) map[string]any {
+	// baseband firmware is personalized with its own BbTicket request and diags are never restored
+	if name == "BasebandFirmware" || name == "Diags" {
+		return nil
+	}
+	info, ok := entry["Info"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	rules, ok := info["RestoreRequestRules"].([]any)
+	if !ok {
+		return nil
+	}
+	if isFTAB, _ := info["IsFTAB"].(bool); isFTAB {
+		return nil
+	}
+
+	out := make(map[string]any, len(entry))
+	for k, v := range entry {
+		if k != "Info" {
+			out[k] = v
+		}
+	}
+	for _, r := range rules {
+		rule, ok := r.(map[string]any)
+		if !ok || !restoreRequestRuleMatches(rule, params) {
+			continue
+		}
+		actions, _ := rule["Actions"].(map[string]any)
+		for k, v := range actions {
+			// a value of 255 means "leave as is"
+			if n, ok := v.(uint64); ok && n == 255 {
+				continue
+			}
+			out[k] = v
+		}
+	}
+	// trusted components always need a Digest, even if empty
+	if trusted, _ := entry["Trusted"].(bool); trusted {
+		if _, ok := entry["Digest"]; !ok {
+			out["Digest"] = []byte{}
+		}
+	}
+	return out
+}
+
+// PersonalizeRestore requests the ApImg4Ticket (IM4M) covering every restorable component of the build identity
+func PersonalizeRestore(conf *RestoreConfig) (*Blob, error) {
+	req := map[string]any{
+		"@UUID":             uuid.New().String(),
+		"@ApImg4Ticket":     true,
+		"@BBTicket":         true,
+		"@HostPlatformInfo": "mac",
+		"@Locality":         "en_US",
+		"@VersionInfo":      tssClientVersion,
+		"ApBoardID":         conf.ApBoardID,
+		"ApChipID":          conf.ApChipID,
+		"ApECID":            conf.ECID,
+		"ApProductionMode":  conf.ProductionMode,
+		"ApSecurityDomain":  conf.ApSecurityDomain,
+		"ApSecurityMode":    conf.SecurityMode,
+		"ApSupportsImg4":    true,
+		"UID_MODE":          false,
+	}
+	if len(conf.ApNonce) > 0 {
+		req["ApNonce"] = conf.ApNonce
+	}
+	if len(conf.SepNonce) > 0 {
+		req["SepNonce"] = conf.SepNonce
+	} else {
+		req["SepNonce"] = make([]byte, 20)
+	}
+	for _, key := range []string{"UniqueBuildID", "PearlCertificationRootPub"} {
+		if v, ok := conf.Identity[key]; ok {
+			req[key] = v
+		}
+	}
+	for k, v := range conf.Identity {
+		if strings.HasPrefix(k, "Ap,") {
+			req[k] = v
+		}
+	}
+
+	params := map[string]any{
+		"ApProductionMode": conf.ProductionMode,
+		"ApSecurityMode":   conf.SecurityMode,
+		"ApSupportsImg4":   true,
+		"ApInRomDFU":       false,
+	}
+	manifest, _ := conf.Identity["Manifest"].(map[string]any)
+	for name, v := range manifest {
+		entry, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if e := restoreEntry(name, entry, params); e != nil {
+			req[name] = e
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := plist.NewEncoder(buf).Encode(req); err != nil {
+		return nil, err
+	}
+
+	return getApImg4Ticket(buf, conf.Proxy, conf.Insecure)
+}
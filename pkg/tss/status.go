@@ -0,0 +1,151 @@
+package tss
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/download"
+)
+
+// Status is the TSS signing status of a build for a device
+type Status struct {
+	Device  string    `json:"device"`
+	Version string    `json:"version"`
+	Build   string    `json:"build"`
+	Signed  bool      `json:"signed"`
+	Checked time.Time `json:"checked"`
+	Error   string    `json:"error,omitempty"` // the check failed (the status is unknown)
+}
+
+// StatusConfig is the signing status matrix config
+type StatusConfig struct {
+	Devices   []string
+	MaxBuilds int           // newest builds per device to check (0 for all)
+	Workers   int           // parallel TSS requests
+	CacheFile string        // JSON file to cache statuses in (empty disables)
+	CacheTTL  time.Duration // how long cached statuses are used for
+	Proxy     string
+	Insecure  bool
+}
+
+func statusKey(device, build string) string {
+	return device + "_" + build
+}
+
+func loadStatusCache(path string) map[string]Status {
+	cache := make(map[string]Status)
+	if len(path) == 0 {
+		return cache
+	}
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(dat, &cache); err != nil {
+		log.WithError(err).Warnf("failed to parse TSS status cache %s (ignoring)", path)
+		return make(map[string]Status)
+	}
+	return cache
+}
+
+func saveStatusCache(path string, cache map[string]Status) error {
+	if len(path) == 0 {
+		return nil
+	}
+	dat, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal TSS status cache: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create TSS status cache folder: %v", err)
+	}
+	return os.WriteFile(path, dat, 0o644)
+}
+
+// GetSigningStatus checks which of the devices' builds (newest first, from ipsw.me) are currently being signed by Apple's TSS server
+func GetSigningStatus(conf *StatusConfig) ([]Status, error) {
+	if conf.Workers < 1 {
+		conf.Workers = 1
+	}
+
+	var todo []Status
+	for _, device := range conf.Devices {
+		ipsws, err := download.GetDeviceIPSWs(device)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get IPSWs for %s: %v", device, err)
+		}
+		sort.SliceStable(ipsws, func(i, j int) bool {
+			return ipsws[i].ReleaseDate.After(ipsws[j].ReleaseDate)
+		})
+		for idx, i := range ipsws {
+			if conf.MaxBuilds > 0 && idx >= conf.MaxBuilds {
+				break
+			}
+			todo = append(todo, Status{Device: device, Version: i.Version, Build: i.BuildID})
+		}
+	}
+
+	cache := loadStatusCache(conf.CacheFile)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for range conf.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				st := &todo[idx]
+				key := statusKey(st.Device, st.Build)
+				mu.Lock()
+				cached, ok := cache[key]
+				mu.Unlock()
+				if ok && time.Since(cached.Checked) < conf.CacheTTL {
+					*st = cached
+					continue
+				}
+				// the board/chip (and so the build identity) are looked up from the device
+				_, err := GetTSSResponse(&Config{
+					Device:   st.Device,
+					Version:  st.Version,
+					Build:    st.Build,
+					Proxy:    conf.Proxy,
+					Insecure: conf.Insecure,
+				})
+				st.Checked = time.Now()
+				var rerr *ResponseError
+				switch {
+				case err == nil:
+					st.Signed = true
+				case errors.As(err, &rerr):
+					st.Signed = false
+				default:
+					st.Error = err.Error()
+					log.WithError(err).Debugf("failed to check %s %s", st.Device, st.Build)
+					continue // don't cache failed checks
+				}
+				mu.Lock()
+				cache[key] = *st
+				mu.Unlock()
+			}
+		}()
+	}
+	for idx := range todo {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := saveStatusCache(conf.CacheFile, cache); err != nil {
+		log.WithError(err).Warn("failed to save TSS status cache")
+	}
+
+	return todo, nil
+}
@@ -3,17 +3,19 @@ package tss
 import (
 	"bytes"
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/apex/log"
 	"github.com/blacktop/go-plist"
 	"github.com/blacktop/ipsw/internal/download"
+	pinfo "github.com/blacktop/ipsw/pkg/info"
 	info "github.com/blacktop/ipsw/pkg/plist"
 	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
@@ -105,7 +107,7 @@ func getApImg4Ticket(payload io.Reader, proxy string, insecure bool) (*Blob, err
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:           download.GetProxy(proxy),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			TLSClientConfig: download.TLSConfig(insecure),
 		},
 	}
 
@@ -159,20 +161,35 @@ func getApImg4Ticket(payload io.Reader, proxy string, insecure bool) (*Blob, err
 		return &blob, nil
 	}
 
-	return nil, fmt.Errorf("failed to personalize TSS blob: %s", tr.Message)
+	return nil, &ResponseError{Status: tr.Status, Message: tr.Message}
+}
+
+// ResponseError is a TSS server refusal (i.e. the build is no longer being signed)
+type ResponseError struct {
+	Status  int
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("failed to personalize TSS blob: %s", e.Message)
 }
 
 // Config represents the configuration for a TSS request.
 type Config struct {
-	Device          string
-	Version         string
-	Build           string
-	ApNonce         []byte
-	SepNonce        []byte
-	ECID            uint64
+	Device   string
+	Version  string
+	Build    string
+	ApNonce  []byte
+	SepNonce []byte
+	ECID     uint64
+	// ApBoardID and ApChipID select the build identity (they are looked up from Device when not set)
+	ApBoardID       uint64
+	ApChipID        uint64
 	Image4Supported bool
 	Proxy           string
 	Insecure        bool
+	// BuildManifest is used instead of parsing the remote IPSW's (i.e. of an already downloaded IPSW)
+	BuildManifest *info.BuildManifest
 }
 
 // GetTSSResponse retrieves a TSS response for the given configuration.
@@ -199,36 +216,30 @@ func GetTSSResponse(conf *Config) ([]byte, error) {
 		}
 	}
 
-	ipsw, err := download.GetIPSW(conf.Device, conf.Build)
-	zr, err := download.NewRemoteZipReader(ipsw.URL, &download.RemoteConfig{
-		Proxy:    conf.Proxy,
-		Insecure: conf.Insecure,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse remote ipsw: %v", err)
-	}
+	manifest := conf.BuildManifest
+	if manifest == nil {
+		ipsw, err := download.GetIPSW(conf.Device, conf.Build)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ipsw: %v", err)
+		}
+		zr, err := download.NewRemoteZipReader(ipsw.URL, &download.RemoteConfig{
+			Proxy:    conf.Proxy,
+			Insecure: conf.Insecure,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse remote ipsw: %v", err)
+		}
 
-	info, err := info.ParseZipFiles(zr.File)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse remote ipsw info: %v", err)
+		info, err := info.ParseZipFiles(zr.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse remote ipsw info: %v", err)
+		}
+		manifest = info.BuildManifest
 	}
 
-	tssReq := Request{
-		UUID:                      uuid.New().String(),
-		ApImg4Ticket:              true,
-		BBTicket:                  true,
-		HostPlatformInfo:          "mac",
-		Locality:                  "en_US",
-		VersionInfo:               tssClientVersion,
-		ApBoardID:                 6,                // device.ApBoardID
-		ApChipID:                  32789,            // device.ApChipID
-		ApECID:                    6303405673529390, // device.ApECID
-		ApNonce:                   conf.ApNonce,     // device.ApNonce
-		ApProductionMode:          true,             // device.EPRO
-		ApSecurityDomain:          1,                // device.ApSecurityDomain
-		SepNonce:                  conf.SepNonce,
-		UniqueBuildID:             info.BuildManifest.BuildIdentities[1].UniqueBuildID,
-		PearlCertificationRootPub: info.BuildManifest.BuildIdentities[1].PearlCertificationRootPub,
+	tssReq, err := newRequest(conf, manifest)
+	if err != nil {
+		return nil, err
 	}
 
 	if conf.Image4Supported {
@@ -257,6 +268,96 @@ func GetTSSResponse(conf *Config) ([]byte, error) {
 	return plistData, nil
 }
 
+// newRequest builds the TSS request for the build identity of manifest matching the device's board/chip
+func newRequest(conf *Config, manifest *info.BuildManifest) (*Request, error) {
+	if manifest == nil || len(manifest.BuildIdentities) == 0 {
+		return nil, fmt.Errorf("ipsw BuildManifest has no build identities")
+	}
+
+	if conf.ApBoardID == 0 || conf.ApChipID == 0 {
+		var err error
+		conf.ApBoardID, conf.ApChipID, err = LookupBoard(conf.Device, manifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.ECID == 0 {
+		conf.ECID = 6303405673529390 // any ECID will do to check if a build is signed
+	}
+
+	idx, err := identityIndex(manifest, conf.ApBoardID, conf.ApChipID)
+	if err != nil {
+		return nil, err
+	}
+	identity := manifest.BuildIdentities[idx]
+
+	return &Request{
+		UUID:                      uuid.New().String(),
+		ApImg4Ticket:              true,
+		BBTicket:                  true,
+		HostPlatformInfo:          "mac",
+		Locality:                  "en_US",
+		VersionInfo:               tssClientVersion,
+		ApBoardID:                 conf.ApBoardID, // device.ApBoardID
+		ApChipID:                  conf.ApChipID,  // device.ApChipID
+		ApECID:                    conf.ECID,      // device.ApECID
+		ApNonce:                   conf.ApNonce,   // device.ApNonce
+		ApProductionMode:          true,           // device.EPRO
+		ApSecurityDomain:          1,              // device.ApSecurityDomain
+		SepNonce:                  conf.SepNonce,
+		UniqueBuildID:             identity.UniqueBuildID,
+		PearlCertificationRootPub: identity.PearlCertificationRootPub,
+	}, nil
+}
+
+// identityIndex returns the index of the manifest's build identity for board/chip
+func identityIndex(manifest *info.BuildManifest, board, chip uint64) (int, error) {
+	for idx, bid := range manifest.BuildIdentities {
+		boardID, err := strconv.ParseUint(strings.TrimPrefix(bid.ApBoardID, "0x"), 16, 64)
+		if err != nil {
+			return -1, fmt.Errorf("failed to parse board id: %v", err)
+		}
+		chipID, err := strconv.ParseUint(strings.TrimPrefix(bid.ApChipID, "0x"), 16, 64)
+		if err != nil {
+			return -1, fmt.Errorf("failed to parse chip id: %v", err)
+		}
+		if boardID == board && chipID == chip {
+			return idx, nil
+		}
+	}
+	return -1, fmt.Errorf("ipsw BuildManifest has no build identity for board %#x chip %#x", board, chip)
+}
+
+// LookupBoard returns the board/chip IDs of the device's first board (in the ipsw device DB) that has a build identity in manifest
+func LookupBoard(device string, manifest *info.BuildManifest) (uint64, uint64, error) {
+	if device == "" {
+		return 0, 0, fmt.Errorf("a device or its board/chip IDs are required")
+	}
+	db, err := pinfo.GetIpswDB()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get ipsw device DB: %v", err)
+	}
+	dev, err := db.LookupDevice(device)
+	if err != nil {
+		return 0, 0, err
+	}
+	models := slices.Sorted(maps.Keys(dev.Boards))
+	for _, model := range models {
+		board, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(dev.Boards[model].BoardID), "0x"), 16, 64)
+		if err != nil {
+			continue
+		}
+		chip, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(dev.Boards[model].ChipID), "0x"), 16, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := identityIndex(manifest, board, chip); err == nil {
+			return board, chip, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("ipsw BuildManifest has no build identity for %s", device)
+}
+
 // PersonalConfig is the config for personalizing a TSS blob
 type PersonalConfig struct {
 	Proxy         string
@@ -1,6 +1,8 @@
 package tss
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"reflect"
 	"testing"
@@ -58,3 +60,82 @@ func TestPersonalize(t *testing.T) {
 		})
 	}
 }
+
+func testBuildManifest(t *testing.T) *plist.BuildManifest {
+	t.Helper()
+	identity := func(board, chip, buildID string) string {
+		return fmt.Sprintf(`<dict>
+			<key>ApBoardID</key><string>%s</string>
+			<key>ApChipID</key><string>%s</string>
+			<key>UniqueBuildID</key><data>%s</data>
+		</dict>`, board, chip, buildID)
+	}
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>BuildIdentities</key>
+	<array>` +
+		identity("0x06", "0x8015", "AAAA") + // iPhone10,3 (D22AP)
+		identity("0x0C", "0x8120", "BBBB") + // iPhone15,2 (D73AP)
+		`</array>
+</dict>
+</plist>`)
+	bman, err := plist.ParseBuildManifest(data)
+	if err != nil {
+		t.Fatalf("failed to parse BuildManifest.plist: %v", err)
+	}
+	return bman
+}
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        *Config
+		wantBoard   uint64
+		wantChip    uint64
+		wantBuildID []byte
+		wantErr     bool
+	}{
+		{
+			name:        "board and chip",
+			conf:        &Config{ApBoardID: 0x0C, ApChipID: 0x8120},
+			wantBoard:   0x0C,
+			wantChip:    0x8120,
+			wantBuildID: []byte{0x04, 0x10, 0x41},
+		},
+		{
+			name:        "device",
+			conf:        &Config{Device: "iPhone15,2"},
+			wantBoard:   0x0C,
+			wantChip:    0x8120,
+			wantBuildID: []byte{0x04, 0x10, 0x41},
+		},
+		{
+			name:    "device without identity",
+			conf:    &Config{Device: "iPad13,1"},
+			wantErr: true,
+		},
+		{
+			name:    "no matching identity",
+			conf:    &Config{ApBoardID: 0x08, ApChipID: 0x8110},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRequest(tt.conf, testBuildManifest(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.ApBoardID != tt.wantBoard || got.ApChipID != tt.wantChip {
+				t.Errorf("newRequest() board/chip = %#x/%#x, want %#x/%#x", got.ApBoardID, got.ApChipID, tt.wantBoard, tt.wantChip)
+			}
+			if !bytes.Equal(got.UniqueBuildID, tt.wantBuildID) {
+				t.Errorf("newRequest() UniqueBuildID = %x, want %x", got.UniqueBuildID, tt.wantBuildID)
+			}
+		})
+	}
+}
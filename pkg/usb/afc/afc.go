@@ -184,10 +184,15 @@ func NewClient(udid string, service ...string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewClientFromConn(c), nil
+}
+
+// NewClientFromConn creates an AFC client over an already started service connection (e.g. house_arrest)
+func NewClientFromConn(c *usb.Client) *Client {
 	return &Client{
 		c:  c,
 		mu: &sync.RWMutex{},
-	}, nil
+	}
 }
 
 func (c *Client) request(operation int, payload []byte, args ...any) (*response, error) {
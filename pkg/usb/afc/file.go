@@ -70,7 +70,7 @@ func (f *FileRef) Seek(offset int64, whence int) (int64, error) {
 	f.c.mu.Lock()
 	defer f.c.mu.Unlock()
 	// Fast path for querying the current offset
-	if offset != 0 && whence != io.SeekCurrent {
+	if offset != 0 || whence != io.SeekCurrent {
 		_, err := f.c.requestNoLock(afcOpFileRefSeek, nil, f.ref, uint64(whence), uint64(offset))
 		if err != nil {
 			return 0, err
@@ -83,6 +83,11 @@ func (f *FileRef) Seek(offset int64, whence int) (int64, error) {
 	return int64(binary.LittleEndian.Uint64(resp.data)), nil
 }
 
+// Truncate changes the size of the file
+func (f *FileRef) Truncate(size int64) error {
+	return f.c.requestNoReply(afcOpFileRefSetSize, nil, f.ref, uint64(size))
+}
+
 func (f *FileRef) Close() error {
 	return f.c.requestNoReply(afcOpFileRefClose, nil, f.ref)
 }
@@ -176,7 +181,7 @@ func (c *Client) FileRefOpen(name string, flags int) (*FileRef, error) {
 }
 
 func (c *Client) FileRefSetFileSize(ref int, size int64) error {
-	return nil
+	return c.requestNoReply(afcOpFileRefSetSize, nil, uint64(ref), uint64(size))
 }
 
 func (c *Client) GetConnectionInfo() error {
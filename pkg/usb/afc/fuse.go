@@ -0,0 +1,289 @@
+//go:build darwin || linux
+
+package afc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+var errnos = map[error]syscall.Errno{
+	errorsToErrors[afcEObjectNotFound]: syscall.ENOENT,
+	errorsToErrors[afcEObjectIsDir]:    syscall.EISDIR,
+	errorsToErrors[afcEPermDenied]:     syscall.EACCES,
+	errorsToErrors[afcEObjectExists]:   syscall.EEXIST,
+	errorsToErrors[afcEObjectBusy]:     syscall.EBUSY,
+	errorsToErrors[afcENoSpaceLeft]:    syscall.ENOSPC,
+	errorsToErrors[afcEInvalidArg]:     syscall.EINVAL,
+	errorsToErrors[afcEOpNotSupported]: syscall.ENOTSUP,
+}
+
+func toErrno(err error) syscall.Errno {
+	if err == nil {
+		return fs.OK
+	}
+	if errno, ok := errnos[err]; ok {
+		return errno
+	}
+	return syscall.EIO
+}
+
+// afcNode is a FUSE inode backed by a path on the AFC connection
+type afcNode struct {
+	fs.Inode
+	c    *Client
+	root string
+}
+
+var (
+	_ fs.NodeGetattrer = (*afcNode)(nil)
+	_ fs.NodeSetattrer = (*afcNode)(nil)
+	_ fs.NodeLookuper  = (*afcNode)(nil)
+	_ fs.NodeReaddirer = (*afcNode)(nil)
+	_ fs.NodeOpener    = (*afcNode)(nil)
+	_ fs.NodeCreater   = (*afcNode)(nil)
+	_ fs.NodeMkdirer   = (*afcNode)(nil)
+	_ fs.NodeUnlinker  = (*afcNode)(nil)
+	_ fs.NodeRmdirer   = (*afcNode)(nil)
+	_ fs.NodeRenamer   = (*afcNode)(nil)
+)
+
+func (n *afcNode) path(name ...string) string {
+	return path.Join(append([]string{n.root, n.Path(nil)}, name...)...)
+}
+
+func (n *afcNode) newChild(ctx context.Context, info os.FileInfo, out *fuse.EntryOut) *fs.Inode {
+	fillAttr(info, &out.Attr)
+	child := &afcNode{c: n.c, root: n.root}
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: out.Attr.Mode & syscall.S_IFMT})
+}
+
+func fillAttr(info os.FileInfo, out *fuse.Attr) {
+	switch {
+	case info.IsDir():
+		out.Mode = syscall.S_IFDIR | 0755
+	case info.Mode()&os.ModeSymlink != 0:
+		out.Mode = syscall.S_IFLNK | 0777
+	default:
+		out.Mode = syscall.S_IFREG | 0644
+	}
+	out.Size = uint64(info.Size())
+	out.Blocks = (out.Size + 511) / 512
+	mtime := info.ModTime()
+	out.SetTimes(nil, &mtime, &mtime)
+}
+
+func (n *afcNode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	info, err := n.c.GetFileInfo(n.path())
+	if err != nil {
+		return toErrno(err)
+	}
+	fillAttr(info, &out.Attr)
+	return fs.OK
+}
+
+func (n *afcNode) Setattr(ctx context.Context, fh fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if size, ok := in.GetSize(); ok {
+		if h, ok := fh.(*afcHandle); ok {
+			if err := h.f.Truncate(int64(size)); err != nil {
+				return toErrno(err)
+			}
+		} else {
+			f, err := n.c.FileRefOpen(n.path(), os.O_RDWR|os.O_CREATE)
+			if err != nil {
+				return toErrno(err)
+			}
+			err = f.Truncate(int64(size))
+			f.Close()
+			if err != nil {
+				return toErrno(err)
+			}
+		}
+	}
+	return n.Getattr(ctx, fh, out)
+}
+
+func (n *afcNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	info, err := n.c.GetFileInfo(n.path(name))
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	return n.newChild(ctx, info, out), fs.OK
+}
+
+func (n *afcNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	names, err := n.c.ReadDir(n.path())
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	var entries []fuse.DirEntry
+	for _, name := range names {
+		if name == "." || name == ".." {
+			continue
+		}
+		entry := fuse.DirEntry{Name: name, Mode: syscall.S_IFREG}
+		if info, err := n.c.GetFileInfo(n.path(name)); err == nil {
+			var attr fuse.Attr
+			fillAttr(info, &attr)
+			entry.Mode = attr.Mode & syscall.S_IFMT
+		}
+		entries = append(entries, entry)
+	}
+	return fs.NewListDirStream(entries), fs.OK
+}
+
+// fuseFlagsToOpenFlags maps the kernel open flags onto one of the modes AFC supports
+func fuseFlagsToOpenFlags(flags uint32) int {
+	switch {
+	case int(flags)&syscall.O_ACCMODE == syscall.O_RDONLY:
+		return os.O_RDONLY
+	case int(flags)&syscall.O_APPEND != 0:
+		return os.O_RDWR | os.O_APPEND | os.O_CREATE
+	case int(flags)&syscall.O_TRUNC != 0:
+		return os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	default:
+		return os.O_RDWR | os.O_CREATE
+	}
+}
+
+func (n *afcNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	f, err := n.c.FileRefOpen(n.path(), fuseFlagsToOpenFlags(flags))
+	if err != nil {
+		return nil, 0, toErrno(err)
+	}
+	return &afcHandle{f: f}, fuse.FOPEN_DIRECT_IO, fs.OK
+}
+
+func (n *afcNode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	fpath := n.path(name)
+	f, err := n.c.FileRefOpen(fpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, nil, 0, toErrno(err)
+	}
+	info, err := n.c.GetFileInfo(fpath)
+	if err != nil {
+		f.Close()
+		return nil, nil, 0, toErrno(err)
+	}
+	return n.newChild(ctx, info, out), &afcHandle{f: f}, fuse.FOPEN_DIRECT_IO, fs.OK
+}
+
+func (n *afcNode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	dir := n.path(name)
+	if err := n.c.MakeDir(dir); err != nil {
+		return nil, toErrno(err)
+	}
+	info, err := n.c.GetFileInfo(dir)
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	return n.newChild(ctx, info, out), fs.OK
+}
+
+func (n *afcNode) Unlink(ctx context.Context, name string) syscall.Errno {
+	return toErrno(n.c.RemovePath(n.path(name)))
+}
+
+func (n *afcNode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	return toErrno(n.c.RemovePath(n.path(name)))
+}
+
+func (n *afcNode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	parent, ok := newParent.(*afcNode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	return toErrno(n.c.RenamePath(n.path(name), parent.path(newName)))
+}
+
+// afcHandle is an open AFC file reference; AFC file refs carry a single
+// offset so reads and writes are serialized to keep seek+io atomic
+type afcHandle struct {
+	mu sync.Mutex
+	f  *FileRef
+}
+
+var (
+	_ fs.FileReader   = (*afcHandle)(nil)
+	_ fs.FileWriter   = (*afcHandle)(nil)
+	_ fs.FileReleaser = (*afcHandle)(nil)
+)
+
+func (h *afcHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.f.Seek(off, io.SeekStart); err != nil {
+		return nil, toErrno(err)
+	}
+	var total int
+	for total < len(dest) {
+		n, err := h.f.Read(dest[total:])
+		total += n
+		if err == io.EOF || n == 0 {
+			break
+		} else if err != nil {
+			return nil, toErrno(err)
+		}
+	}
+	return fuse.ReadResultData(dest[:total]), fs.OK
+}
+
+func (h *afcHandle) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.f.Seek(off, io.SeekStart); err != nil {
+		return 0, toErrno(err)
+	}
+	n, err := h.f.Write(data)
+	if err != nil {
+		return 0, toErrno(err)
+	}
+	return uint32(n), fs.OK
+}
+
+func (h *afcHandle) Release(ctx context.Context) syscall.Errno {
+	return toErrno(h.f.Close())
+}
+
+// Mount serves the AFC tree rooted at root on mountpoint until ctx is canceled
+// or the mountpoint is unmounted externally (e.g. umount/fusermount -u)
+func (c *Client) Mount(ctx context.Context, mountpoint, root string, readOnly bool) error {
+	opts := &fs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName: "afc",
+			Name:   "ipsw",
+		},
+	}
+	if readOnly {
+		opts.MountOptions.Options = append(opts.MountOptions.Options, "ro")
+	}
+	server, err := fs.Mount(mountpoint, &afcNode{c: c, root: path.Clean("/" + root)}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to mount %s: %w", mountpoint, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		if err := server.Unmount(); err != nil {
+			return fmt.Errorf("failed to unmount %s: %w", mountpoint, err)
+		}
+		<-done
+	case <-done:
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package afc
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mount serves the AFC tree rooted at root on mountpoint until ctx is canceled
+func (c *Client) Mount(ctx context.Context, mountpoint, root string, readOnly bool) error {
+	return fmt.Errorf("mounting AFC over FUSE is only supported on darwin and linux")
+}
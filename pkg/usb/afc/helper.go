@@ -1,12 +1,13 @@
 package afc
 
 import (
-	"fmt"
+	"errors"
 	"io"
 	"os"
 	pathpkg "path"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
 func (c *Client) Walk(root string, walkFn filepath.WalkFunc) error {
@@ -108,17 +109,26 @@ func (c *Client) CopyToDevice(dst, src string, copyCbFn CopyCallbackFunc) error
 		return err
 	}
 	if srcInfo.IsDir() {
+		base := filepath.Dir(filepath.Clean(src))
 		return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-			targetPath := pathpkg.Join(dst, path)
-			fmt.Println(targetPath, path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			targetPath := pathpkg.Join(dst, filepath.ToSlash(rel))
 			if info.IsDir() {
-				return c.MakeDir(targetPath)
+				if err := c.MakeDir(targetPath); err != nil && !errors.Is(err, errorsToErrors[afcEObjectExists]) {
+					return err
+				}
+				return nil
 			}
-			err = c.CopyFileToDevice(targetPath, path)
 			if copyCbFn != nil {
-				copyCbFn(targetPath, src, info)
+				copyCbFn(targetPath, path, info)
 			}
-			return err
+			return c.CopyFileToDevice(targetPath, path)
 		})
 	}
 
@@ -137,15 +147,18 @@ func (c *Client) CopyFromDevice(dst, src string, copyCbFn CopyCallbackFunc) erro
 		return err
 	}
 	if srcInfo.IsDir() {
+		base := pathpkg.Dir(pathpkg.Clean(src))
 		return c.Walk(src, func(path string, info os.FileInfo, err error) error {
-			// If destination is a directory, append the path to it
-			targetPath := pathpkg.Join(dst, path)
+			if err != nil {
+				return err
+			}
+			// mirror the remote tree (including src itself) under dst
+			targetPath := filepath.Join(dst, filepath.FromSlash(strings.TrimPrefix(path, base)))
 			if info.IsDir() {
-				_ = os.Mkdir(targetPath, 0755)
-				return nil
+				return os.MkdirAll(targetPath, 0755)
 			}
 			if copyCbFn != nil {
-				copyCbFn(targetPath, src, info)
+				copyCbFn(targetPath, path, info)
 			}
 			return c.CopyFileFromDevice(targetPath, path)
 		})
@@ -8,11 +8,13 @@ import (
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/afc"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
 	"github.com/mitchellh/mapstructure"
 )
 
 const (
-	serviceName = "com.apple.mobile.installation_proxy"
+	serviceName       = "com.apple.mobile.installation_proxy"
+	remoteServiceName = "com.apple.mobile.installation_proxy.shim.remote"
 )
 
 type Client struct {
@@ -29,6 +31,17 @@ func NewClient(udid string) (*Client, error) {
 	}, nil
 }
 
+// NewRemoteClient connects to installation_proxy over RemoteXPC (iOS 17+ via the CoreDevice tunnel)
+func NewRemoteClient(r *rsd.Client) (*Client, error) {
+	c, err := r.DialLockdown(remoteServiceName)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c: c,
+	}, nil
+}
+
 func (c *Client) Lookup() (map[string]*AppBundle, error) {
 	req := &Lookup{
 		Command: NewCommand("Lookup"),
@@ -181,6 +194,9 @@ func (c *Client) watchProgress(cb ProgressFunc) error {
 		if err := c.c.Recv(ev); err != nil {
 			return err
 		}
+		if len(ev.Error) > 0 {
+			return fmt.Errorf("%s: %s", ev.Error, ev.ErrorDescription)
+		}
 		if ev.Status == "Complete" {
 			ev.PercentComplete = 100
 		}
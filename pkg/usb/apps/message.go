@@ -47,8 +47,10 @@ type ApplicationIdentifierRequest struct {
 }
 
 type ProgressEvent struct {
-	Status          string `plist:"Status"`
-	PercentComplete int    `plist:"PercentComplete"`
+	Status           string `plist:"Status"`
+	PercentComplete  int    `plist:"PercentComplete"`
+	Error            string `plist:"Error,omitempty"`
+	ErrorDescription string `plist:"ErrorDescription,omitempty"`
 }
 
 type LookupArchivesRequest struct {
@@ -0,0 +1,89 @@
+package apps
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-plist"
+	"github.com/fullsailor/pkcs7"
+)
+
+var embeddedProfileName = regexp.MustCompile("^Payload/[^/]+.app/embedded.mobileprovision$")
+
+// ProvisioningProfile is an app's embedded.mobileprovision
+type ProvisioningProfile struct {
+	AppIDName            string         `plist:"AppIDName,omitempty" json:"appid_name,omitempty"`
+	Name                 string         `plist:"Name,omitempty" json:"name,omitempty"`
+	UUID                 string         `plist:"UUID,omitempty" json:"uuid,omitempty"`
+	TeamName             string         `plist:"TeamName,omitempty" json:"team_name,omitempty"`
+	TeamIdentifier       []string       `plist:"TeamIdentifier,omitempty" json:"team_identifier,omitempty"`
+	Platform             []string       `plist:"Platform,omitempty" json:"platform,omitempty"`
+	CreationDate         time.Time      `plist:"CreationDate,omitempty" json:"creation_date,omitempty"`
+	ExpirationDate       time.Time      `plist:"ExpirationDate,omitempty" json:"expiration_date,omitempty"`
+	Entitlements         map[string]any `plist:"Entitlements,omitempty" json:"entitlements,omitempty"`
+	ProvisionedDevices   []string       `plist:"ProvisionedDevices,omitempty" json:"provisioned_devices,omitempty"`
+	ProvisionsAllDevices bool           `plist:"ProvisionsAllDevices,omitempty" json:"provisions_all_devices,omitempty"`
+}
+
+// ParseProvisioningProfile parses a (CMS signed) provisioning profile
+func ParseProvisioningProfile(dat []byte) (*ProvisioningProfile, error) {
+	p7, err := pkcs7.Parse(dat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse provisioning profile signature: %w", err)
+	}
+	prof := &ProvisioningProfile{}
+	if _, err := plist.Unmarshal(p7.Content, prof); err != nil {
+		return nil, fmt.Errorf("failed to parse provisioning profile: %w", err)
+	}
+	return prof, nil
+}
+
+// ProvisioningProfileFromIPA returns the IPA's embedded provisioning profile (or nil if it has none, i.e. App Store apps)
+func ProvisioningProfileFromIPA(ipa string) (*ProvisioningProfile, error) {
+	ipaFile, err := zip.OpenReader(ipa)
+	if err != nil {
+		return nil, err
+	}
+	defer ipaFile.Close()
+	for _, f := range ipaFile.File {
+		if !embeddedProfileName.MatchString(f.Name) {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		dat, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return ParseProvisioningProfile(dat)
+	}
+	return nil, nil
+}
+
+// IsDevelopment returns true if the profile is a development profile (its apps can be debugged)
+func (p *ProvisioningProfile) IsDevelopment() bool {
+	allow, _ := p.Entitlements["get-task-allow"].(bool)
+	return allow
+}
+
+// Check returns an error if the profile has expired or does not provision the device
+func (p *ProvisioningProfile) Check(udid string) error {
+	if !p.ExpirationDate.IsZero() && time.Now().After(p.ExpirationDate) {
+		return fmt.Errorf("provisioning profile '%s' expired on %s", p.Name, p.ExpirationDate.Format(time.DateOnly))
+	}
+	if p.ProvisionsAllDevices || len(p.ProvisionedDevices) == 0 {
+		return nil
+	}
+	if !slices.ContainsFunc(p.ProvisionedDevices, func(d string) bool { return strings.EqualFold(d, udid) }) {
+		return fmt.Errorf("provisioning profile '%s' does not include device %s", p.Name, udid)
+	}
+	return nil
+}
@@ -1,34 +1,352 @@
 package backup
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 )
 
 const serviceName = "com.apple.mobilebackup2"
 
+// supportedVersions are the mobilebackup2 protocol versions the client supports
+var supportedVersions = []float64{2.0, 2.1}
+
+// ErrPasswordRequired is returned when restoring an encrypted backup without its password
+var ErrPasswordRequired = errors.New("backup is encrypted and requires a password")
+
 type Client struct {
-	c *usb.Client
+	c       *usb.Client
+	udid    string
+	version float64
+}
+
+type helloRequest struct {
+	MessageName               string    `plist:"MessageName"`
+	SupportedProtocolVersions []float64 `plist:"SupportedProtocolVersions"`
+}
+
+type request struct {
+	MessageName      string         `plist:"MessageName"`
+	TargetIdentifier string         `plist:"TargetIdentifier,omitempty"`
+	SourceIdentifier string         `plist:"SourceIdentifier,omitempty"`
+	Options          map[string]any `plist:"Options,omitempty"`
+	OldPassword      string         `plist:"OldPassword,omitempty"`
+	NewPassword      string         `plist:"NewPassword,omitempty"`
+}
+
+// Progress is a backup/restore progress update
+type Progress struct {
+	Percent float64 // overall progress reported by the device (0-100)
+	Bytes   int64   // bytes transferred so far
+	File    string  // last file transferred
+}
+
+// Config is the backup config
+type Config struct {
+	Directory string // backups folder (the backup is saved in <Directory>/<UDID>)
+	Full      bool   // force a full backup (even if there is a previous backup to update)
+	Progress  func(*Progress)
+}
+
+// RestoreConfig is the backup restore config
+type RestoreConfig struct {
+	Directory         string // backups folder
+	SourceUDID        string // UDID of the backup to restore (default: the device's UDID)
+	Password          string // encrypted backup password
+	System            bool   // restore system files
+	NoReboot          bool   // do not reboot the device after the restore
+	NoCopy            bool   // do not copy the backup before restoring it
+	Settings          bool   // restore the device settings
+	RemoveNotRestored bool   // remove the items that are not in the backup
+	Progress          func(*Progress)
+}
+
+// Info is a backup's Info.plist (the renamed fields need a tag option for go-plist to use their tag name)
+type Info struct {
+	BuildVersion     string    `plist:"Build Version,omitempty"`
+	DeviceName       string    `plist:"Device Name,omitempty"`
+	DisplayName      string    `plist:"Display Name,omitempty"`
+	GUID             string    `plist:"GUID,omitempty"`
+	ICCID            string    `plist:"ICCID,omitempty"`
+	IMEI             string    `plist:"IMEI,omitempty"`
+	LastBackupDate   time.Time `plist:"Last Backup Date,omitempty"`
+	PhoneNumber      string    `plist:"Phone Number,omitempty"`
+	ProductName      string    `plist:"Product Name,omitempty"`
+	ProductType      string    `plist:"Product Type,omitempty"`
+	ProductVersion   string    `plist:"Product Version,omitempty"`
+	SerialNumber     string    `plist:"Serial Number,omitempty"`
+	TargetIdentifier string    `plist:"Target Identifier,omitempty"`
+	TargetType       string    `plist:"Target Type,omitempty"`
+	UniqueIdentifier string    `plist:"Unique Identifier,omitempty"`
+	ITunesVersion    string    `plist:"iTunes Version,omitempty"`
+}
+
+// Manifest is a backup's Manifest.plist
+type Manifest struct {
+	IsEncrypted    bool      `plist:"IsEncrypted"`
+	Date           time.Time `plist:"Date"`
+	Version        string    `plist:"Version"`
+	WasPasscodeSet bool      `plist:"WasPasscodeSet"`
+	Lockdown       struct {
+		DeviceName     string `plist:"DeviceName"`
+		ProductType    string `plist:"ProductType"`
+		ProductVersion string `plist:"ProductVersion"`
+		BuildVersion   string `plist:"BuildVersion"`
+		UniqueDeviceID string `plist:"UniqueDeviceID"`
+		SerialNumber   string `plist:"SerialNumber"`
+	} `plist:"Lockdown"`
+}
+
+// Status is a backup's Status.plist
+type Status struct {
+	BackupState   string    `plist:"BackupState"`
+	Date          time.Time `plist:"Date"`
+	IsFullBackup  bool      `plist:"IsFullBackup"`
+	SnapshotState string    `plist:"SnapshotState"`
+	UUID          string    `plist:"UUID"`
+	Version       string    `plist:"Version"`
 }
 
 func NewClient(udid string) (*Client, error) {
-	c, err := lockdownd.NewClientForService(serviceName, udid, false)
+	c, err := lockdownd.NewClientForService(serviceName, udid, true)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
-		c: c,
-	}, nil
+	if _, err := c.DeviceLinkHandshake(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to perform device link handshake: %w", err)
+	}
+	cli := &Client{
+		c:    c,
+		udid: udid,
+	}
+	if err := cli.hello(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return cli, nil
+}
+
+// hello negotiates the mobilebackup2 protocol version
+func (c *Client) hello() error {
+	if err := c.c.DeviceLinkSend(helloRequest{
+		MessageName:               "Hello",
+		SupportedProtocolVersions: supportedVersions,
+	}); err != nil {
+		return fmt.Errorf("failed to send hello: %w", err)
+	}
+	resp, err := c.c.DeviceLinkRecv()
+	if err != nil {
+		return fmt.Errorf("failed to receive hello response: %w", err)
+	}
+	respMap, ok := resp.(map[string]any)
+	if !ok {
+		return fmt.Errorf("unexpected hello response: %v", resp)
+	}
+	if code := toInt(respMap["ErrorCode"]); code != 0 {
+		return fmt.Errorf("device does not support the mobilebackup2 protocol versions %v (error code %d)", supportedVersions, code)
+	}
+	c.version = toFloat(respMap["ProtocolVersion"])
+	log.Debugf("Using mobilebackup2 protocol version %.1f", c.version)
+	return nil
 }
 
 func (c *Client) Close() error {
+	_ = c.c.Send([]any{"DLMessageDisconnect", "___EmptyParameterString___"})
 	return c.c.Close()
 }
 
-func (c *Client) GetMsg() (any, error) {
-	var resp any
-	if err := c.c.Recv(resp); err != nil {
+// Backup creates (or incrementally updates) the device's backup in <Directory>/<UDID>
+func (c *Client) Backup(conf *Config) error {
+	folder := filepath.Join(conf.Directory, c.udid)
+	if err := os.MkdirAll(folder, 0o750); err != nil {
+		return fmt.Errorf("failed to create backup folder: %w", err)
+	}
+	if err := c.writeInfo(folder); err != nil {
+		return err
+	}
+
+	opts := map[string]any{}
+	if _, err := ReadStatus(folder); err == nil && !conf.Full {
+		log.WithField("folder", folder).Info("Starting incremental backup")
+	} else {
+		log.WithField("folder", folder).Info("Starting full backup")
+		opts["ForceFullBackup"] = true
+	}
+
+	if err := c.c.DeviceLinkSend(request{
+		MessageName:      "Backup",
+		TargetIdentifier: c.udid,
+		SourceIdentifier: c.udid,
+		Options:          opts,
+	}); err != nil {
+		return fmt.Errorf("failed to send backup request: %w", err)
+	}
+	if err := c.handleMessages(conf.Directory, conf.Progress); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	status, err := ReadStatus(folder)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	if !strings.EqualFold(status.SnapshotState, "finished") {
+		return fmt.Errorf("backup is incomplete (snapshot state: %s)", status.SnapshotState)
+	}
+	return nil
+}
+
+// Restore restores the backup in <Directory>/<SourceUDID> to the device
+func (c *Client) Restore(conf *RestoreConfig) error {
+	if len(conf.SourceUDID) == 0 {
+		conf.SourceUDID = c.udid
+	}
+	folder := filepath.Join(conf.Directory, conf.SourceUDID)
+	manifest, err := ReadManifest(folder)
+	if err != nil {
+		return err
+	}
+	if status, err := ReadStatus(folder); err != nil {
+		return err
+	} else if !strings.EqualFold(status.SnapshotState, "finished") {
+		return fmt.Errorf("backup is incomplete (snapshot state: %s)", status.SnapshotState)
+	}
+	if manifest.IsEncrypted && len(conf.Password) == 0 {
+		return ErrPasswordRequired
+	}
+
+	opts := map[string]any{
+		"RestoreSystemFiles":      conf.System,
+		"RestoreShouldReboot":     !conf.NoReboot,
+		"RestoreDontCopyBackup":   conf.NoCopy,
+		"RestorePreserveSettings": !conf.Settings,
+		"RemoveItemsNotRestored":  conf.RemoveNotRestored,
+	}
+	if manifest.IsEncrypted {
+		opts["Password"] = conf.Password
+	}
+
+	log.WithField("folder", folder).Info("Starting restore")
+	if err := c.c.DeviceLinkSend(request{
+		MessageName:      "Restore",
+		TargetIdentifier: c.udid,
+		SourceIdentifier: conf.SourceUDID,
+		Options:          opts,
+	}); err != nil {
+		return fmt.Errorf("failed to send restore request: %w", err)
+	}
+	if err := c.handleMessages(conf.Directory, conf.Progress); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword enables (empty old password), disables (empty new password) or changes the backup encryption password
+//
+// NOTE: the device asks for its passcode to confirm the change
+func (c *Client) ChangePassword(oldPassword, newPassword string) error {
+	if err := c.c.DeviceLinkSend(request{
+		MessageName:      "ChangePassword",
+		TargetIdentifier: c.udid,
+		OldPassword:      oldPassword,
+		NewPassword:      newPassword,
+	}); err != nil {
+		return fmt.Errorf("failed to send change password request: %w", err)
+	}
+	if err := c.handleMessages("", nil); err != nil {
+		return fmt.Errorf("failed to change backup password: %w", err)
+	}
+	return nil
+}
+
+// writeInfo (re)creates the backup's Info.plist from the device's lockdown values
+func (c *Client) writeInfo(folder string) error {
+	ldc, err := lockdownd.NewClient(c.udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+	dev, err := ldc.GetValues()
+	if err != nil {
+		return fmt.Errorf("failed to get device values: %w", err)
+	}
+
+	guid := make([]byte, 16)
+	if _, err := rand.Read(guid); err != nil {
+		return fmt.Errorf("failed to generate backup GUID: %w", err)
+	}
+	info := Info{
+		BuildVersion:     dev.BuildVersion,
+		DeviceName:       dev.DeviceName,
+		DisplayName:      dev.DeviceName,
+		GUID:             strings.ToUpper(hex.EncodeToString(guid)),
+		ICCID:            dev.IntegratedCircuitCardIdentity,
+		IMEI:             dev.InternationalMobileEquipmentIdentity,
+		LastBackupDate:   time.Now(),
+		PhoneNumber:      dev.PhoneNumber,
+		ProductName:      dev.ProductName,
+		ProductType:      dev.ProductType,
+		ProductVersion:   dev.ProductVersion,
+		SerialNumber:     dev.SerialNumber,
+		TargetIdentifier: c.udid,
+		TargetType:       "Device",
+		UniqueIdentifier: strings.ToUpper(c.udid),
+		ITunesVersion:    "10.0.1",
+	}
+	dat, err := plist.MarshalIndent(info, plist.XMLFormat, "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Info.plist: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "Info.plist"), dat, 0o644); err != nil {
+		return fmt.Errorf("failed to write Info.plist: %w", err)
+	}
+	return nil
+}
+
+func readPlist(path string, v any) error {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if _, err := plist.Unmarshal(dat, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// ReadInfo reads the Info.plist of the backup in folder
+func ReadInfo(folder string) (*Info, error) {
+	var info Info
+	if err := readPlist(filepath.Join(folder, "Info.plist"), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ReadManifest reads the Manifest.plist of the backup in folder
+func ReadManifest(folder string) (*Manifest, error) {
+	var manifest Manifest
+	if err := readPlist(filepath.Join(folder, "Manifest.plist"), &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ReadStatus reads the Status.plist of the backup in folder
+func ReadStatus(folder string) (*Status, error) {
+	var status Status
+	if err := readPlist(filepath.Join(folder, "Status.plist"), &status); err != nil {
 		return nil, err
 	}
-	return resp, nil
+	return &status, nil
 }
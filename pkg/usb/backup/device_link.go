@@ -1 +1,549 @@
 package backup
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/apex/log"
+)
+
+// DeviceLink file transfer codes
+const (
+	codeSuccess     = 0x00
+	codeErrorLocal  = 0x06
+	codeErrorRemote = 0x0b
+	codeFileData    = 0x0c
+)
+
+const (
+	emptyParameter = "___EmptyParameterString___"
+	multiStatus    = -13
+	chunkSize      = 32 * 1024
+)
+
+// fileError is a per-file DeviceLink error
+type fileError struct {
+	DLFileErrorString string `plist:"DLFileErrorString"`
+	DLFileErrorCode   int    `plist:"DLFileErrorCode"`
+}
+
+// fileInfo is a DeviceLink directory entry
+type fileInfo struct {
+	DLFileType             string `plist:"DLFileType"`
+	DLFileSize             uint64 `plist:"DLFileSize"`
+	DLFileModificationDate any    `plist:"DLFileModificationDate"`
+}
+
+// deviceError converts a host file error to the DeviceLink error code the device expects
+func deviceError(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return -6
+	case errors.Is(err, fs.ErrExist):
+		return -7
+	case errors.Is(err, syscall.ENOTDIR):
+		return -8
+	case errors.Is(err, syscall.EISDIR):
+		return -9
+	case errors.Is(err, syscall.ELOOP):
+		return -10
+	case errors.Is(err, syscall.EIO):
+		return -11
+	case errors.Is(err, syscall.ENOSPC):
+		return -15
+	default:
+		return -1
+	}
+}
+
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case uint64:
+		return int(int64(n))
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	}
+	return 0
+}
+
+// transfer is the state of a backup/restore DeviceLink session
+type transfer struct {
+	root     string
+	progress func(*Progress)
+	status   Progress
+}
+
+// path resolves a device-relative path in the backups folder
+func (t *transfer) path(name string) (string, error) {
+	if len(t.root) == 0 {
+		return "", fmt.Errorf("no backup folder")
+	}
+	p := filepath.Join(t.root, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(t.root, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of the backup folder", name)
+	}
+	return p, nil
+}
+
+func (t *transfer) update(msg []any, idx int) {
+	if idx < len(msg) {
+		if pct := toFloat(msg[idx]); pct > 0 {
+			t.status.Percent = pct
+		}
+	}
+	if t.progress != nil {
+		t.progress(&t.status)
+	}
+}
+
+// handleMessages handles the device's DeviceLink requests until it reports the result of the operation
+func (c *Client) handleMessages(root string, progress func(*Progress)) error {
+	t := &transfer{root: root, progress: progress}
+	for {
+		var msg []any
+		if err := c.c.Recv(&msg); err != nil {
+			return fmt.Errorf("failed to receive device link message: %w", err)
+		}
+		if len(msg) == 0 {
+			return fmt.Errorf("received empty device link message")
+		}
+		name, _ := msg[0].(string)
+		log.Debugf("Received %s", name)
+
+		var err error
+		switch name {
+		case "DLMessageDownloadFiles":
+			t.update(msg, 3)
+			err = c.sendFiles(t, msg)
+		case "DLMessageUploadFiles":
+			t.update(msg, 2)
+			err = c.receiveFiles(t)
+		case "DLMessageGetFreeDiskSpace":
+			free, ferr := freeSpace(root)
+			if ferr != nil {
+				err = c.statusResponse(deviceError(ferr), ferr.Error(), map[string]any{})
+			} else {
+				err = c.statusResponse(0, "", free)
+			}
+		case "DLMessageContentsOfDirectory":
+			err = c.listDirectory(t, msg)
+		case "DLMessageCreateDirectory":
+			err = c.respond(t.mkdir(msg))
+		case "DLMessageMoveFiles", "DLMessageMoveItems":
+			t.update(msg, 3)
+			err = c.respond(t.move(msg))
+		case "DLMessageRemoveFiles", "DLMessageRemoveItems":
+			t.update(msg, 3)
+			err = c.respond(t.remove(msg))
+		case "DLMessageCopyItem":
+			err = c.respond(t.copy(msg))
+		case "DLMessagePurgeDiskSpace":
+			err = c.statusResponse(-1, "Operation not supported", map[string]any{})
+		case "DLMessageDisconnect":
+			return nil
+		case "DLMessageProcessMessage":
+			if len(msg) < 2 {
+				return fmt.Errorf("received invalid process message")
+			}
+			res, _ := msg[1].(map[string]any)
+			if code := toInt(res["ErrorCode"]); code != 0 {
+				desc, _ := res["ErrorDescription"].(string)
+				return fmt.Errorf("device returned error %d: %s", code, desc)
+			}
+			t.status.Percent = 100
+			if progress != nil {
+				progress(&t.status)
+			}
+			return nil
+		default:
+			log.Warnf("Unsupported device link message: %s", name)
+			err = c.statusResponse(-1, "Operation not supported", map[string]any{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// statusResponse replies to a DeviceLink request
+func (c *Client) statusResponse(code int, desc string, status any) error {
+	if len(desc) == 0 {
+		desc = emptyParameter
+	}
+	return c.c.Send([]any{"DLMessageStatusResponse", code, desc, status})
+}
+
+// respond replies to a DeviceLink request with the result of a file operation
+func (c *Client) respond(err error) error {
+	if err != nil {
+		log.WithError(err).Debug("Device link file operation failed")
+		return c.statusResponse(deviceError(err), err.Error(), map[string]any{})
+	}
+	return c.statusResponse(0, "", map[string]any{})
+}
+
+func (c *Client) writeUint32(v uint32) error {
+	return binary.Write(c.c.Conn(), binary.BigEndian, v)
+}
+
+func (c *Client) readUint32() (uint32, error) {
+	var v uint32
+	err := binary.Read(c.c.Conn(), binary.BigEndian, &v)
+	return v, err
+}
+
+// readString reads a length prefixed string (an empty string marks the end of a file list)
+func (c *Client) readString() (string, error) {
+	n, err := c.readUint32()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+	if n > 4096 {
+		return "", fmt.Errorf("invalid device link string length %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.c.Conn(), buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// writeBlock sends a file transfer block (its length includes the code byte)
+func (c *Client) writeBlock(code byte, data []byte) error {
+	buf := make([]byte, 5+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)+1))
+	buf[4] = code
+	copy(buf[5:], data)
+	_, err := c.c.Conn().Write(buf)
+	return err
+}
+
+// sendFiles sends the requested backup files to the device
+func (c *Client) sendFiles(t *transfer, msg []any) error {
+	if len(msg) < 2 {
+		return fmt.Errorf("received invalid download files message")
+	}
+	files, _ := msg[1].([]any)
+	errs := make(map[string]any)
+	for _, f := range files {
+		name, _ := f.(string)
+		if err := c.writeUint32(uint32(len(name))); err != nil {
+			return err
+		}
+		if _, err := c.c.Conn().Write([]byte(name)); err != nil {
+			return err
+		}
+		if ferr := c.sendFile(t, name); ferr != nil {
+			var netErr *sendError
+			if errors.As(ferr, &netErr) {
+				return netErr.err
+			}
+			log.WithError(ferr).Debugf("Failed to send %s", name)
+			errs[name] = fileError{DLFileErrorString: ferr.Error(), DLFileErrorCode: deviceError(ferr)}
+			if err := c.writeBlock(codeErrorLocal, []byte(ferr.Error())); err != nil {
+				return err
+			}
+		}
+	}
+	// end of file list
+	if err := c.writeUint32(0); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return c.statusResponse(multiStatus, "Multi status", errs)
+	}
+	return c.statusResponse(0, "", map[string]any{})
+}
+
+// sendError is a connection error (as opposed to a local file error that is reported to the device)
+type sendError struct{ err error }
+
+func (e *sendError) Error() string { return e.err.Error() }
+
+func (c *Client) sendFile(t *transfer, name string) error {
+	path, err := t.path(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "open", Path: path, Err: syscall.EISDIR}
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, rerr := f.Read(buf)
+		if n > 0 {
+			if err := c.writeBlock(codeFileData, buf[:n]); err != nil {
+				return &sendError{err}
+			}
+			t.status.Bytes += int64(n)
+		}
+		if rerr == io.EOF {
+			break
+		} else if rerr != nil {
+			return &sendError{fmt.Errorf("failed to read %s: %w", path, rerr)}
+		}
+	}
+	if err := c.writeBlock(codeSuccess, nil); err != nil {
+		return &sendError{err}
+	}
+	t.status.File = name
+	if t.progress != nil {
+		t.progress(&t.status)
+	}
+	return nil
+}
+
+// receiveFiles saves the backup files the device sends
+func (c *Client) receiveFiles(t *transfer) error {
+	errs := make(map[string]any)
+	for {
+		if dname, err := c.readString(); err != nil {
+			return fmt.Errorf("failed to receive device file name: %w", err)
+		} else if len(dname) == 0 {
+			break
+		}
+		name, err := c.readString()
+		if err != nil {
+			return fmt.Errorf("failed to receive backup file name: %w", err)
+		} else if len(name) == 0 {
+			break
+		}
+
+		var out io.Writer = io.Discard
+		path, ferr := t.path(name)
+		if ferr == nil {
+			ferr = os.MkdirAll(filepath.Dir(path), 0o750)
+		}
+		var f *os.File
+		if ferr == nil {
+			f, ferr = os.Create(path)
+		}
+		if ferr != nil {
+			log.WithError(ferr).Warnf("Failed to create %s", name)
+			errs[name] = fileError{DLFileErrorString: ferr.Error(), DLFileErrorCode: deviceError(ferr)}
+		} else {
+			out = f
+		}
+
+		var code byte
+		for {
+			n, err := c.readUint32()
+			if err != nil {
+				return fmt.Errorf("failed to receive %s: %w", name, err)
+			}
+			if n == 0 {
+				break
+			}
+			if code, err = c.c.RecvByte(); err != nil {
+				return fmt.Errorf("failed to receive %s: %w", name, err)
+			}
+			switch code {
+			case codeFileData:
+				if _, err := io.CopyN(out, c.c.Conn(), int64(n-1)); err != nil {
+					if f != nil {
+						f.Close()
+					}
+					return fmt.Errorf("failed to receive %s: %w", name, err)
+				}
+				t.status.Bytes += int64(n - 1)
+				continue
+			case codeErrorRemote:
+				msg := make([]byte, n-1)
+				if _, err := io.ReadFull(c.c.Conn(), msg); err != nil {
+					return fmt.Errorf("failed to receive %s error: %w", name, err)
+				}
+				log.Warnf("Device failed to send %s: %s", name, string(msg))
+			default:
+				if _, err := io.CopyN(io.Discard, c.c.Conn(), int64(n-1)); err != nil {
+					return fmt.Errorf("failed to receive %s: %w", name, err)
+				}
+			}
+			break
+		}
+		if f != nil {
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to save %s: %w", path, err)
+			}
+		}
+		t.status.File = name
+		if t.progress != nil {
+			t.progress(&t.status)
+		}
+	}
+	if len(errs) > 0 {
+		return c.statusResponse(multiStatus, "Multi status", errs)
+	}
+	return c.statusResponse(0, "", map[string]any{})
+}
+
+func (c *Client) listDirectory(t *transfer, msg []any) error {
+	if len(msg) < 2 {
+		return fmt.Errorf("received invalid contents of directory message")
+	}
+	name, _ := msg[1].(string)
+	entries := make(map[string]any)
+	if path, err := t.path(name); err == nil {
+		des, _ := os.ReadDir(path)
+		for _, de := range des {
+			info, err := de.Info()
+			if err != nil {
+				continue
+			}
+			ftype := "DLFileTypeUnknown"
+			if info.Mode().IsRegular() {
+				ftype = "DLFileTypeRegular"
+			} else if info.IsDir() {
+				ftype = "DLFileTypeDirectory"
+			}
+			entries[de.Name()] = fileInfo{
+				DLFileType:             ftype,
+				DLFileSize:             uint64(info.Size()),
+				DLFileModificationDate: info.ModTime(),
+			}
+		}
+	}
+	return c.statusResponse(0, "", entries)
+}
+
+func (t *transfer) mkdir(msg []any) error {
+	if len(msg) < 2 {
+		return fmt.Errorf("received invalid create directory message")
+	}
+	name, _ := msg[1].(string)
+	path, err := t.path(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, 0o750)
+}
+
+func (t *transfer) move(msg []any) error {
+	if len(msg) < 2 {
+		return fmt.Errorf("received invalid move items message")
+	}
+	items, _ := msg[1].(map[string]any)
+	for src, d := range items {
+		dst, _ := d.(string)
+		srcPath, err := t.path(src)
+		if err != nil {
+			return err
+		}
+		dstPath, err := t.path(dst)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dstPath); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
+			return err
+		}
+		if err := os.Rename(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *transfer) remove(msg []any) error {
+	if len(msg) < 2 {
+		return fmt.Errorf("received invalid remove items message")
+	}
+	items, _ := msg[1].([]any)
+	for _, item := range items {
+		name, _ := item.(string)
+		path, err := t.path(name)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *transfer) copy(msg []any) error {
+	if len(msg) < 3 {
+		return fmt.Errorf("received invalid copy item message")
+	}
+	src, _ := msg[1].(string)
+	dst, _ := msg[2].(string)
+	srcPath, err := t.path(src)
+	if err != nil {
+		return err
+	}
+	dstPath, err := t.path(dst)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstPath, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o750)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package backup
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the free disk space (in bytes) of the backups folder
+func freeSpace(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrEncrypted is returned when reading the files of an encrypted backup (its Manifest.db is encrypted)
+var ErrEncrypted = errors.New("encrypted backups are not supported")
+
+// File flags in a backup's Manifest.db
+const (
+	FileFlagFile      = 1
+	FileFlagDirectory = 2
+	FileFlagSymlink   = 4
+)
+
+// File is a file in a backup's Manifest.db
+type File struct {
+	ID           string `json:"id" gorm:"column:fileID"`
+	Domain       string `json:"domain" gorm:"column:domain"`
+	RelativePath string `json:"path" gorm:"column:relativePath"`
+	Flags        int    `json:"flags" gorm:"column:flags"`
+}
+
+// IsFile returns true if the entry is a regular file (and not a directory or symlink)
+func (f File) IsFile() bool {
+	return f.Flags == FileFlagFile
+}
+
+// ReadFiles reads the files of the (unencrypted) backup in folder from its Manifest.db
+func ReadFiles(folder string) ([]File, error) {
+	manifest, err := ReadManifest(folder)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.IsEncrypted {
+		return nil, ErrEncrypted
+	}
+	dbPath := filepath.Join(folder, "Manifest.db")
+	if _, err := os.Stat(dbPath); err != nil { // don't let sqlite create an empty database
+		return nil, fmt.Errorf("failed to read Manifest.db: %w", err)
+	}
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Manifest.db: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var files []File
+	if err := db.Raw("SELECT fileID, domain, relativePath, flags FROM Files ORDER BY domain, relativePath").Scan(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to read Manifest.db files: %w", err)
+	}
+	return files, nil
+}
+
+// ExtractFile copies a file out of the (unencrypted) backup in folder to output
+// (files are stored in the backup as <folder>/<first 2 chars of ID>/<ID>)
+func ExtractFile(folder string, f File, output string) error {
+	if len(f.ID) < 2 {
+		return fmt.Errorf("invalid file ID '%s' for %s-%s", f.ID, f.Domain, f.RelativePath)
+	}
+	src, err := os.Open(filepath.Join(folder, f.ID[:2], f.ID))
+	if err != nil {
+		return fmt.Errorf("failed to open %s-%s in backup: %w", f.Domain, f.RelativePath, err)
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(output), 0o750); err != nil {
+		return fmt.Errorf("failed to create output folder: %w", err)
+	}
+	dst, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
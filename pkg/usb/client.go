@@ -19,6 +19,18 @@ type Client struct {
 }
 
 func NewClient(udid string, port int) (*Client, error) {
+	if nd := NetworkDeviceInUse(); nd != nil && (len(udid) == 0 || udid == nd.UDID) {
+		return newNetworkClient(nd, port)
+	}
+	return newClient(udid, port, true)
+}
+
+// NewUnpairedClient connects to the device's port without requiring a pair record (i.e. to pair with lockdownd)
+func NewUnpairedClient(udid string, port int) (*Client, error) {
+	return newClient(udid, port, false)
+}
+
+func newClient(udid string, port int, paired bool) (*Client, error) {
 	conn, err := NewConn()
 	if err != nil {
 		return nil, err
@@ -42,7 +54,7 @@ func NewClient(udid string, port int) (*Client, error) {
 	}
 
 	pairRecord, err := conn.ReadPairRecord(udid)
-	if err != nil {
+	if err != nil && paired {
 		return nil, err
 	}
 
@@ -58,6 +70,14 @@ func NewClient(udid string, port int) (*Client, error) {
 	}, nil
 }
 
+// NewClientFromConn wraps an already established service connection (e.g. a RemoteXPC shim service)
+func NewClientFromConn(conn net.Conn, udid string) *Client {
+	return &Client{
+		conn: conn,
+		udid: udid,
+	}
+}
+
 func (c *Client) EnableSSL() error {
 	cert, err := tls.X509KeyPair(c.pairRecord.HostCertificate, c.pairRecord.HostPrivateKey)
 	if err != nil {
@@ -0,0 +1,77 @@
+// Package coredevice invokes CoreDevice features (the RemoteXPC services that back `devicectl` on iOS 17+)
+package coredevice
+
+import (
+	"fmt"
+
+	"github.com/blacktop/ipsw/pkg/usb/xpc"
+	"github.com/google/uuid"
+)
+
+// coreDeviceVersion is the CoreDevice framework version ipsw claims to be
+var coreDeviceVersion = map[string]any{
+	"components":              []any{uint64(348), uint64(1), uint64(0), uint64(0), uint64(0)},
+	"originalComponentsCount": int64(2),
+	"stringValue":             "348.1",
+}
+
+// Error is a CoreDevice feature invocation error
+type Error struct {
+	Domain      string
+	Code        int64
+	Description string
+}
+
+func (e *Error) Error() string {
+	if len(e.Description) > 0 {
+		return fmt.Sprintf("%s (%s %d)", e.Description, e.Domain, e.Code)
+	}
+	return fmt.Sprintf("%s error %d", e.Domain, e.Code)
+}
+
+// Invoke calls the CoreDevice feature (i.e. "com.apple.coredevice.feature.capturesysdiagnose") with input
+// and returns its output
+func Invoke(conn *xpc.Conn, feature string, input map[string]any) (map[string]any, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	resp, err := conn.Request(map[string]any{
+		"CoreDevice.CoreDeviceDDIProtocolVersion": int64(0),
+		"CoreDevice.action":                       map[string]any{},
+		"CoreDevice.coreDeviceVersion":            coreDeviceVersion,
+		"CoreDevice.deviceIdentifier":             uuid.New().String(),
+		"CoreDevice.featureIdentifier":            feature,
+		"CoreDevice.input":                        input,
+		"CoreDevice.invocationIdentifier":         uuid.New().String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke %s: %w", feature, err)
+	}
+	if e, ok := resp["CoreDevice.error"].(map[string]any); ok {
+		return nil, parseError(e)
+	}
+	out, ok := resp["CoreDevice.output"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s returned no output", feature)
+	}
+	return out, nil
+}
+
+func parseError(e map[string]any) *Error {
+	err := &Error{}
+	err.Domain, _ = e["domain"].(string)
+	switch code := e["code"].(type) {
+	case int64:
+		err.Code = code
+	case uint64:
+		err.Code = int64(code)
+	}
+	if info, ok := e["userInfo"].(map[string]any); ok {
+		if desc, ok := info["NSLocalizedDescription"].(map[string]any); ok {
+			err.Description, _ = desc["string"].(string)
+		} else {
+			err.Description, _ = info["NSLocalizedDescription"].(string)
+		}
+	}
+	return err
+}
@@ -1,14 +1,36 @@
 package crashlog
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 )
 
 const (
-	moverService      = "com.apple.mobile.diagnostics_relay"
+	moverService      = "com.apple.crashreportmover"
 	copyMobileService = "com.apple.crashreportcopymobile"
 )
 
 func NewClient(udid string) (*afc.Client, error) {
 	return afc.NewClient(udid, copyMobileService)
 }
+
+// Flush asks the crash report mover to move the device's pending crash reports to the crashreportcopymobile folder
+func Flush(udid string) error {
+	c, err := lockdownd.NewClientForService(moverService, udid, false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// the mover replies 'ping' once it has moved the crash reports
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(c.Conn(), ack); err != nil {
+		return fmt.Errorf("failed to receive crash report mover reply: %w", err)
+	}
+	if string(ack) != "ping" {
+		return fmt.Errorf("unexpected crash report mover reply: %q", ack)
+	}
+	return nil
+}
@@ -5,9 +5,14 @@ import (
 
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
 )
 
-const serviceName = "com.apple.debugserver"
+const (
+	serviceName       = "com.apple.debugserver"
+	secureServiceName = "com.apple.debugserver.DVTSecureSocketProxy"
+	remoteServiceName = "com.apple.internal.dt.remote.debugproxy"
+)
 
 type Client struct {
 	c         *usb.Client
@@ -28,6 +33,31 @@ func NewClient(udid string) (*Client, error) {
 	}, nil
 }
 
+// NewSecureClient connects to the SSL wrapped debugserver proxy used by iOS14+ DDIs
+func NewSecureClient(udid string) (*Client, error) {
+	cli, err := lockdownd.NewClientForService(secureServiceName, udid, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c:         cli,
+		gdbServer: NewGDBServer(cli.Conn()),
+	}, nil
+}
+
+// NewRemoteClient connects to the RemoteXPC debugproxy service (iOS17+ via the CoreDevice tunnel)
+func NewRemoteClient(r *rsd.Client) (*Client, error) {
+	conn, err := r.Dial(remoteServiceName)
+	if err != nil {
+		return nil, err
+	}
+	cli := usb.NewClientFromConn(conn, r.Property("UniqueDeviceID"))
+	return &Client{
+		c:         cli,
+		gdbServer: NewGDBServer(cli.Conn()),
+	}, nil
+}
+
 func (c *Client) Recv() (string, error) {
 	return c.gdbServer.Recv()
 }
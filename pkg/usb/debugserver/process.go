@@ -41,7 +41,11 @@ func NewProcess(udid string, args, env []string) (*Process, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewProcessWithClient(client, args, env), nil
+}
 
+// NewProcessWithClient creates a process driven over an existing debugserver connection
+func NewProcessWithClient(client *Client, args, env []string) *Process {
 	stdoutR, stdoutW := io.Pipe()
 	p := &Process{
 		c:        client,
@@ -52,7 +56,7 @@ func NewProcess(udid string, args, env []string) (*Process, error) {
 		env:      env,
 	}
 
-	return p, nil
+	return p
 }
 
 func (p *Process) Args() []string {
@@ -97,19 +101,36 @@ func (p *Process) requests(commands ...string) error {
 	return nil
 }
 
+// Client returns the debugserver connection driving the process
+func (p *Process) Client() *Client {
+	return p.c
+}
+
 func (p *Process) Start() error {
+	if err := p.Launch(); err != nil {
+		return err
+	}
+	return p.Continue()
+}
+
+// Launch spawns the process and leaves it stopped at its entry point (i.e. for a debugger to take over)
+func (p *Process) Launch() error {
 	seq := []string{
 		makeArgs(p.Args()),
 	}
 	for _, e := range p.env {
 		seq = append(seq, "QEnvironmentHexEncoded:"+hex.EncodeToString([]byte(e)))
 	}
-	seq = append(seq, "qLaunchSuccess")
 
 	if err := p.start(seq...); err != nil {
 		return err
 	}
-	return p.Continue()
+	if resp, err := p.c.Request("qLaunchSuccess"); err != nil {
+		return err
+	} else if resp != "OK" {
+		return fmt.Errorf("failed to launch %s: %s", strings.Join(p.args, " "), resp)
+	}
+	return nil
 }
 
 func (p *Process) Continue() error {
@@ -125,6 +146,17 @@ func (p *Process) Interrupt() error {
 	return nil
 }
 
+// Detach detaches the debugger from the (running) process
+func (p *Process) Detach() error {
+	if err := p.Interrupt(); err != nil {
+		return err
+	}
+	if _, err := p.c.Request("D"); err != nil {
+		return err
+	}
+	return p.stdoutW.Close()
+}
+
 func (p *Process) Kill() error {
 	if err := p.Interrupt(); err != nil {
 		return err
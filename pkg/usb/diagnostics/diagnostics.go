@@ -36,6 +36,20 @@ type DiagnosticsResponse struct {
 	Diagnostics map[string]any `plist:"Diagnostics,omitempty" json:"diagnostics,omitempty"`
 }
 
+// PowerOptions are the flags of a restart, shutdown or sleep request
+type PowerOptions struct {
+	// WaitForDisconnect delays the action until the host disconnects
+	WaitForDisconnect bool `plist:"WaitForDisconnect,omitempty"`
+	// DisplayPass/DisplayFail show a pass/fail screen before the action
+	DisplayPass bool `plist:"DisplayPass,omitempty"`
+	DisplayFail bool `plist:"DisplayFail,omitempty"`
+}
+
+type powerRequest struct {
+	Request
+	PowerOptions
+}
+
 type IORegistryRequest struct {
 	Request
 	CurrentPlane string `plist:"CurrentPlane,omitempty"`
@@ -233,6 +247,27 @@ func (c *Client) Shutdown() error {
 	return nil
 }
 
+// Power sends the Restart, Shutdown or Sleep request with options
+func (c *Client) Power(action string, opts PowerOptions) error {
+	switch action {
+	case "Restart", "Shutdown", "Sleep":
+	default:
+		return fmt.Errorf("unsupported power action %s", action)
+	}
+	req := &powerRequest{
+		Request:      Request{action},
+		PowerOptions: opts,
+	}
+	var resp Response
+	if err := c.c.Request(req, &resp); err != nil {
+		return err
+	}
+	if status, ok := resp["Status"]; ok && status != "Success" {
+		return fmt.Errorf("failed to %s: %s", strings.ToLower(action), status)
+	}
+	return nil
+}
+
 func (c *Client) Close() error {
 	return c.c.Close()
 }
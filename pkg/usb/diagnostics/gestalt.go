@@ -0,0 +1,123 @@
+package diagnostics
+
+import "sort"
+
+// GestaltKey is a known MobileGestalt key
+type GestaltKey struct {
+	Key         string `json:"key"`
+	Obfuscated  string `json:"obfuscated"`
+	Description string `json:"desc"`
+}
+
+// KnownGestaltKeys are MobileGestalt keys (by name) that can be queried through the diagnostics relay
+var KnownGestaltKeys = map[string]string{
+	"ActiveWirelessTechnology":              "Active wireless technology",
+	"AirplaneMode":                          "Airplane mode is on",
+	"ArtworkTraits":                         "Device artwork traits (colors, screen, etc.)",
+	"BasebandCertId":                        "Baseband certificate ID",
+	"BasebandChipId":                        "Baseband chip ID",
+	"BasebandFirmwareVersion":               "Baseband firmware version",
+	"BasebandKeyHashInformation":            "Baseband key hash information",
+	"BasebandRegionSKU":                     "Baseband region SKU",
+	"BasebandSerialNumber":                  "Baseband serial number",
+	"BatteryCurrentCapacity":                "Battery charge (percent)",
+	"BatteryIsCharging":                     "Battery is charging",
+	"BatteryIsFullyCharged":                 "Battery is fully charged",
+	"BluetoothAddress":                      "Bluetooth MAC address",
+	"BoardId":                               "Board ID",
+	"BuildVersion":                          "OS build version",
+	"CPUArchitecture":                       "CPU architecture",
+	"ChipID":                                "Chip ID",
+	"CompatibleDeviceFallback":              "Compatible device fallback",
+	"CoverglassSerialNumber":                "Cover glass serial number",
+	"DeviceClass":                           "Device class (iPhone, iPad, etc.)",
+	"DeviceColor":                           "Device (front) color",
+	"DeviceCoverGlassColor":                 "Cover glass color",
+	"DeviceEnclosureColor":                  "Enclosure (back) color",
+	"DeviceName":                            "User assigned device name",
+	"DeviceSupportsFaceTime":                "Device supports FaceTime",
+	"DeviceSupportsNFC":                     "Device supports NFC",
+	"DeviceSupportsTouchID":                 "Device supports Touch ID",
+	"DiagData":                              "Diagnostics data",
+	"DieId":                                 "Die ID",
+	"DiskUsage":                             "Storage usage",
+	"EthernetMacAddress":                    "Ethernet MAC address",
+	"FirmwareVersion":                       "iBoot version",
+	"HWModelStr":                            "Hardware model",
+	"HardwarePlatform":                      "Hardware platform (SoC)",
+	"HasBaseband":                           "Device has a baseband",
+	"HasSEP":                                "Device has a Secure Enclave",
+	"InternalBuild":                         "OS is an internal build",
+	"InternationalMobileEquipmentIdentity":  "IMEI",
+	"InternationalMobileEquipmentIdentity2": "Second IMEI",
+	"IsSimulator":                           "Running in the simulator",
+	"IsUIBuild":                             "OS is a UI build",
+	"IsVirtualDevice":                       "Device is virtual",
+	"MLBSerialNumber":                       "Main logic board serial number",
+	"MainScreenHeight":                      "Main screen height (pixels)",
+	"MainScreenScale":                       "Main screen scale",
+	"MainScreenWidth":                       "Main screen width (pixels)",
+	"MarketingName":                         "Marketing name",
+	"MinimumSupportediTunesVersion":         "Minimum supported iTunes version",
+	"MobileEquipmentIdentifier":             "MEID",
+	"ModelNumber":                           "Model number",
+	"PartitionType":                         "Partition type",
+	"PasswordConfigured":                    "Passcode is set",
+	"PasswordProtected":                     "Device is passcode protected",
+	"PhysicalHardwareNameString":            "Physical hardware name",
+	"ProductHash":                           "Product hash",
+	"ProductName":                           "Product name (OS)",
+	"ProductType":                           "Product type (i.e. iPhone15,2)",
+	"ProductVersion":                        "OS version",
+	"ProductVersionExtra":                   "Rapid Security Response version extra (i.e. (a))",
+	"ProximitySensorCalibration":            "Proximity sensor calibration",
+	"RegionCode":                            "Region code",
+	"RegionInfo":                            "Region info",
+	"RegulatoryModelNumber":                 "Regulatory model number",
+	"ReleaseType":                           "Release type (i.e. Beta)",
+	"SIMStatus":                             "SIM status",
+	"SIMTrayStatus":                         "SIM tray status",
+	"SerialNumber":                          "Serial number",
+	"SoftwareBehavior":                      "Software behavior flags",
+	"SoftwareBundleVersion":                 "Software bundle version",
+	"SupplementalBuildVersion":              "Supplemental (RSR) build version",
+	"SupportedDeviceFamilies":               "Supported device families",
+	"SupportedKeyboards":                    "Supported keyboards",
+	"SysCfg":                                "SysCfg data",
+	"UniqueChipID":                          "ECID",
+	"UniqueDeviceID":                        "UDID",
+	"UserAssignedDeviceName":                "User assigned device name",
+	"WifiAddress":                           "Wi-Fi MAC address",
+	"WirelessBoardSnum":                     "Wireless board serial number",
+	"hw-model":                              "Hardware model",
+	"ipad":                                  "Device is an iPad",
+	"main-screen-class":                     "Main screen class",
+	"main-screen-height":                    "Main screen height (pixels)",
+	"main-screen-pitch":                     "Main screen pitch",
+	"main-screen-scale":                     "Main screen scale",
+	"main-screen-width":                     "Main screen width (pixels)",
+}
+
+// GestaltKeys returns the known MobileGestalt keys sorted by name
+func GestaltKeys() []GestaltKey {
+	keys := make([]GestaltKey, 0, len(KnownGestaltKeys))
+	for k, desc := range KnownGestaltKeys {
+		keys = append(keys, GestaltKey{
+			Key:         k,
+			Obfuscated:  MobileGestaltEncrypt(k),
+			Description: desc,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// GestaltKeyName returns the name of a known key from its obfuscated form (or the key itself if unknown)
+func GestaltKeyName(obfuscated string) string {
+	for k := range KnownGestaltKeys {
+		if MobileGestaltEncrypt(k) == obfuscated {
+			return k
+		}
+	}
+	return obfuscated
+}
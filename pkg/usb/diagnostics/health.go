@@ -0,0 +1,104 @@
+package diagnostics
+
+import (
+	"fmt"
+)
+
+// BatteryHealth is the battery state reported by the IOPMPowerSource (AppleSmartBattery) IORegistry entry
+type BatteryHealth struct {
+	CycleCount         int64   `json:"cycle_count"`
+	DesignCapacity     int64   `json:"design_capacity_mah"`
+	MaxCapacity        int64   `json:"max_capacity_mah"`
+	HealthPercent      float64 `json:"health_percent"`
+	ChargePercent      int64   `json:"charge_percent"`
+	Voltage            int64   `json:"voltage_mv"`
+	InstantAmperage    int64   `json:"instant_amperage_ma"`
+	Temperature        float64 `json:"temperature_c"`
+	IsCharging         bool    `json:"is_charging"`
+	ExternalConnected  bool    `json:"external_connected"`
+	FullyCharged       bool    `json:"fully_charged"`
+	AtCriticalLevel    bool    `json:"at_critical_level"`
+	Serial             string  `json:"serial,omitempty"`
+	AdapterDescription string  `json:"adapter,omitempty"`
+	AdapterWatts       int64   `json:"adapter_watts,omitempty"`
+}
+
+func (b BatteryHealth) String() string {
+	return fmt.Sprintf(
+		colorHeader("[BATTERY]\n")+
+			colorFaint("  Health:        ")+colorBold("%.1f%%\n")+
+			colorFaint("  Cycle Count:   ")+colorBold("%d\n")+
+			colorFaint("  Capacity:      ")+colorBold("%d / %d mAh (design)\n")+
+			colorFaint("  Charge:        ")+colorBold("%d%%\n")+
+			colorFaint("  Charging:      ")+colorBold("%t (external power: %t)\n")+
+			colorFaint("  Voltage:       ")+colorBold("%d mV\n")+
+			colorFaint("  Amperage:      ")+colorBold("%d mA\n")+
+			colorFaint("  Temperature:   ")+colorBold("%.2f °C\n"),
+		b.HealthPercent,
+		b.CycleCount,
+		b.MaxCapacity, b.DesignCapacity,
+		b.ChargePercent,
+		b.IsCharging, b.ExternalConnected,
+		b.Voltage,
+		b.InstantAmperage,
+		b.Temperature,
+	)
+}
+
+func asInt(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case uint64:
+		return int64(n)
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+// BatteryHealth parses the battery's IORegistry entry
+func (c *Client) BatteryHealth() (*BatteryHealth, error) {
+	bat, err := c.Battery()
+	if err != nil {
+		return nil, err
+	}
+	reg, ok := bat["IORegistry"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("battery IORegistry entry not found")
+	}
+
+	b := &BatteryHealth{
+		CycleCount:      asInt(reg["CycleCount"]),
+		DesignCapacity:  asInt(reg["DesignCapacity"]),
+		ChargePercent:   asInt(reg["CurrentCapacity"]),
+		Voltage:         asInt(reg["Voltage"]),
+		InstantAmperage: asInt(reg["InstantAmperage"]),
+		// reported in centi-degrees Celsius
+		Temperature: float64(asInt(reg["Temperature"])) / 100,
+	}
+	b.IsCharging, _ = reg["IsCharging"].(bool)
+	b.ExternalConnected, _ = reg["ExternalConnected"].(bool)
+	b.FullyCharged, _ = reg["FullyCharged"].(bool)
+	b.AtCriticalLevel, _ = reg["AtCriticalLevel"].(bool)
+	b.Serial, _ = reg["Serial"].(string)
+	// newer devices report MaxCapacity as a percentage and the mAh value as AppleRawMaxCapacity
+	if raw := asInt(reg["AppleRawMaxCapacity"]); raw > 0 {
+		b.MaxCapacity = raw
+	} else if nominal := asInt(reg["NominalChargeCapacity"]); nominal > 0 {
+		b.MaxCapacity = nominal
+	} else {
+		b.MaxCapacity = asInt(reg["MaxCapacity"])
+	}
+	if b.DesignCapacity > 0 {
+		b.HealthPercent = float64(b.MaxCapacity) * 100 / float64(b.DesignCapacity)
+	}
+	if adapter, ok := reg["AdapterDetails"].(map[string]any); ok {
+		b.AdapterDescription, _ = adapter["Description"].(string)
+		b.AdapterWatts = asInt(adapter["Watts"])
+	}
+
+	return b, nil
+}
@@ -0,0 +1,327 @@
+package dvt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/blacktop/go-plist"
+)
+
+// nsReferenceDate is the NSDate epoch (2001-01-01 00:00:00 UTC)
+var nsReferenceDate = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// keyedArchive is the NSKeyedArchiver plist (go-plist only uses the tag names of the tags with an option)
+type keyedArchive struct {
+	Archiver string         `plist:"$archiver,omitempty"`
+	Objects  []any          `plist:"$objects,omitempty"`
+	Top      map[string]any `plist:"$top,omitempty"`
+	Version  int            `plist:"$version,omitempty"`
+}
+
+type archiver struct {
+	objects []any
+	classes map[string]plist.UID
+}
+
+// archive serializes v as an NSKeyedArchiver binary plist
+func archive(v any) ([]byte, error) {
+	a := &archiver{
+		objects: []any{"$null"},
+		classes: make(map[string]plist.UID),
+	}
+	root, err := a.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return plist.Marshal(keyedArchive{
+		Archiver: "NSKeyedArchiver",
+		Objects:  a.objects,
+		Top:      map[string]any{"root": root},
+		Version:  100000,
+	}, plist.BinaryFormat)
+}
+
+func (a *archiver) add(obj any) plist.UID {
+	a.objects = append(a.objects, obj)
+	return plist.UID(len(a.objects) - 1)
+}
+
+func (a *archiver) class(name string, supers ...string) plist.UID {
+	if uid, ok := a.classes[name]; ok {
+		return uid
+	}
+	uid := a.add(map[string]any{
+		"$classname": name,
+		"$classes":   append(append([]string{name}, supers...), "NSObject"),
+	})
+	a.classes[name] = uid
+	return uid
+}
+
+func (a *archiver) encode(v any) (plist.UID, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case string, bool, []byte,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		// NSString, NSNumber and NSData are archived as plain plist values
+		return a.add(t), nil
+	case time.Time:
+		// reserve the object slot before encoding the class so the archive reads in order
+		uid := a.add(nil)
+		a.objects[uid] = map[string]any{
+			"NS.time": t.Sub(nsReferenceDate).Seconds(),
+			"$class":  a.class("NSDate"),
+		}
+		return uid, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		uid := a.add(nil)
+		objs := make([]plist.UID, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			o, err := a.encode(rv.Index(i).Interface())
+			if err != nil {
+				return 0, err
+			}
+			objs = append(objs, o)
+		}
+		a.objects[uid] = map[string]any{
+			"NS.objects": objs,
+			"$class":     a.class("NSArray"),
+		}
+		return uid, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return 0, fmt.Errorf("unsupported NSDictionary key type %s", rv.Type().Key())
+		}
+		uid := a.add(nil)
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		var kuids, ouids []plist.UID
+		for _, k := range keys {
+			ku, err := a.encode(k)
+			if err != nil {
+				return 0, err
+			}
+			ou, err := a.encode(rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface())
+			if err != nil {
+				return 0, fmt.Errorf("failed to archive key %s: %w", k, err)
+			}
+			kuids = append(kuids, ku)
+			ouids = append(ouids, ou)
+		}
+		a.objects[uid] = map[string]any{
+			"NS.keys":    kuids,
+			"NS.objects": ouids,
+			"$class":     a.class("NSDictionary"),
+		}
+		return uid, nil
+	}
+
+	return 0, fmt.Errorf("unsupported NSKeyedArchiver type %T", v)
+}
+
+// unarchive decodes an NSKeyedArchiver plist into plain Go values
+// (NSArray/NSSet → []any, NSDictionary → map[string]any, NSDate → time.Time, ...)
+func unarchive(data []byte) (any, error) {
+	var ka keyedArchive
+	if _, err := plist.Unmarshal(data, &ka); err != nil {
+		return nil, fmt.Errorf("failed to parse NSKeyedArchiver plist: %w", err)
+	}
+	if ka.Archiver != "NSKeyedArchiver" {
+		return nil, fmt.Errorf("unexpected archiver %q", ka.Archiver)
+	}
+	root, ok := ka.Top["root"].(plist.UID)
+	if !ok {
+		return nil, fmt.Errorf("NSKeyedArchiver plist has no root object")
+	}
+	u := &unarchiver{objects: ka.Objects}
+	return u.decode(root, 0)
+}
+
+type unarchiver struct {
+	objects []any
+}
+
+// max nesting before we assume the archive contains a reference cycle
+const maxArchiveDepth = 64
+
+func (u *unarchiver) object(uid plist.UID) (any, error) {
+	if int(uid) >= len(u.objects) {
+		return nil, fmt.Errorf("NSKeyedArchiver UID %d out of range", uid)
+	}
+	return u.objects[uid], nil
+}
+
+func (u *unarchiver) className(obj map[string]any) string {
+	uid, ok := obj["$class"].(plist.UID)
+	if !ok {
+		return ""
+	}
+	cls, err := u.object(uid)
+	if err != nil {
+		return ""
+	}
+	if m, ok := cls.(map[string]any); ok {
+		name, _ := m["$classname"].(string)
+		return name
+	}
+	return ""
+}
+
+func (u *unarchiver) list(v any, depth int) ([]any, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, nil
+	}
+	out := make([]any, 0, len(arr))
+	for _, e := range arr {
+		o, err := u.decode(e, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (u *unarchiver) decode(v any, depth int) (any, error) {
+	if depth > maxArchiveDepth {
+		return nil, fmt.Errorf("NSKeyedArchiver object graph too deep")
+	}
+	if uid, ok := v.(plist.UID); ok {
+		obj, err := u.object(uid)
+		if err != nil {
+			return nil, err
+		}
+		v = obj
+	}
+
+	switch t := v.(type) {
+	case string:
+		if t == "$null" {
+			return nil, nil
+		}
+		return t, nil
+	case map[string]any:
+		if _, ok := t["$class"]; !ok {
+			return t, nil
+		}
+		switch cls := u.className(t); cls {
+		case "NSArray", "NSMutableArray", "NSSet", "NSMutableSet", "NSOrderedSet", "NSMutableOrderedSet":
+			return u.list(t["NS.objects"], depth)
+		case "NSDictionary", "NSMutableDictionary":
+			keys, err := u.list(t["NS.keys"], depth)
+			if err != nil {
+				return nil, err
+			}
+			vals, err := u.list(t["NS.objects"], depth)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) != len(vals) {
+				return nil, fmt.Errorf("NSDictionary has %d keys but %d objects", len(keys), len(vals))
+			}
+			dict := make(map[string]any, len(keys))
+			for i, k := range keys {
+				dict[fmt.Sprint(k)] = vals[i]
+			}
+			return dict, nil
+		case "NSString", "NSMutableString":
+			return u.decode(t["NS.string"], depth+1)
+		case "NSData", "NSMutableData":
+			return u.decode(t["NS.data"], depth+1)
+		case "NSDate":
+			secs, _ := t["NS.time"].(float64)
+			return nsReferenceDate.Add(time.Duration(secs * float64(time.Second))), nil
+		case "NSNull":
+			return nil, nil
+		case "NSUUID":
+			b, _ := t["NS.uuidbytes"].([]byte)
+			if len(b) != 16 {
+				return nil, fmt.Errorf("invalid NSUUID")
+			}
+			return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+		case "NSURL":
+			base, err := u.decode(t["NS.base"], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := u.decode(t["NS.relative"], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if base != nil {
+				return fmt.Sprintf("%v%v", base, rel), nil
+			}
+			return rel, nil
+		case "NSError":
+			domain, _ := u.decode(t["NSDomain"], depth+1)
+			info, _ := u.decode(t["NSUserInfo"], depth+1)
+			return &Error{Domain: fmt.Sprint(domain), Code: toInt(t["NSCode"]), UserInfo: info}, nil
+		case "DTSysmonTapMessage", "DTTapHeartbeatMessage", "DTTapStatusMessage", "DTTapMessage", "DTKTraceTapMessage":
+			return u.decode(t["DTTapMessagePlist"], depth+1)
+		default:
+			obj := map[string]any{"$class": cls}
+			for k, e := range t {
+				if k == "$class" {
+					continue
+				}
+				o, err := u.decode(e, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				obj[k] = o
+			}
+			return obj, nil
+		}
+	case []any:
+		return u.list(t, depth)
+	default:
+		return t, nil
+	}
+}
+
+// Error is an archived NSError returned by an instruments service
+type Error struct {
+	Domain   string
+	Code     int
+	UserInfo any
+}
+
+func (e *Error) Error() string {
+	if info, ok := e.UserInfo.(map[string]any); ok {
+		if desc, ok := info["NSLocalizedDescription"].(string); ok {
+			return fmt.Sprintf("%s (%s code %d)", desc, e.Domain, e.Code)
+		}
+	}
+	return fmt.Sprintf("%s error %d", e.Domain, e.Code)
+}
+
+func toInt(v any) int {
+	switch t := v.(type) {
+	case int:
+		return t
+	case int64:
+		return int(t)
+	case uint64:
+		return int(t)
+	case uint32:
+		return int(t)
+	case int32:
+		return int(t)
+	case float64:
+		return int(t)
+	}
+	return 0
+}
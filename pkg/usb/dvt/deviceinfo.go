@@ -0,0 +1,83 @@
+package dvt
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Process is a running process as reported by the deviceinfo service
+type Process struct {
+	PID           int       `json:"pid"`
+	Name          string    `json:"name"`
+	RealAppName   string    `json:"real_app_name,omitempty"`
+	BundleID      string    `json:"bundle_id,omitempty"`
+	IsApplication bool      `json:"is_application"`
+	StartDate     time.Time `json:"start_date,omitempty"`
+}
+
+// ProcessList returns the running processes sorted by pid
+func (c *Client) ProcessList() ([]Process, error) {
+	ch, err := c.Channel(deviceInfoChannel)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ch.Call("runningProcesses")
+	if err != nil {
+		return nil, err
+	}
+	list, ok := resp.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected runningProcesses response: %T", resp)
+	}
+	var procs []Process
+	for _, p := range list {
+		info, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		proc := Process{PID: toInt(info["pid"])}
+		proc.Name, _ = info["name"].(string)
+		proc.RealAppName, _ = info["realAppName"].(string)
+		proc.BundleID, _ = info["bundleIdentifier"].(string)
+		proc.IsApplication, _ = info["isApplication"].(bool)
+		proc.StartDate, _ = info["startDate"].(time.Time)
+		procs = append(procs, proc)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].PID < procs[j].PID
+	})
+	return procs, nil
+}
+
+func (c *Client) stringList(selector string) ([]string, error) {
+	ch, err := c.Channel(deviceInfoChannel)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ch.Call(selector)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := resp.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s response: %T", selector, resp)
+	}
+	var out []string
+	for _, s := range list {
+		if str, ok := s.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out, nil
+}
+
+// SysmonProcessAttributes returns the per-process attributes sysmontap can sample
+func (c *Client) SysmonProcessAttributes() ([]string, error) {
+	return c.stringList("sysmonProcessAttributes")
+}
+
+// SysmonSystemAttributes returns the system wide attributes sysmontap can sample
+func (c *Client) SysmonSystemAttributes() ([]string, error) {
+	return c.stringList("sysmonSystemAttributes")
+}
@@ -0,0 +1,303 @@
+package dvt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	dtxMagic             = 0x1F3D5B79
+	dtxHeaderSize        = 32
+	dtxPayloadHeaderSize = 16
+	dtxAuxMagic          = 0x1F0
+	dtxExpectsReplyFlag  = 0x1000
+)
+
+// DTX payload message types
+const (
+	MessageTypeOK         = 0
+	MessageTypeData       = 1
+	MessageTypeDispatch   = 2
+	MessageTypeObject     = 3
+	MessageTypeError      = 4
+	MessageTypeBarrier    = 5
+	MessageTypePrimitive  = 6
+	MessageTypeCompressed = 7
+)
+
+// auxiliary (primitive dictionary) value types
+const (
+	auxTypeNull    = 0x0a
+	auxTypeString  = 0x01
+	auxTypeObject  = 0x02
+	auxTypeUint32  = 0x03
+	auxTypeUint64  = 0x04
+	auxTypeInt64   = 0x06
+	auxTypeFloat64 = 0x09
+)
+
+type dtxHeader struct {
+	Magic             uint32
+	HeaderLength      uint32
+	FragmentIndex     uint16
+	FragmentCount     uint16
+	Length            uint32
+	Identifier        uint32
+	ConversationIndex uint32
+	ChannelCode       int32
+	ExpectsReply      uint32
+}
+
+type dtxPayloadHeader struct {
+	Flags           uint32
+	AuxiliaryLength uint32
+	TotalLength     uint64
+}
+
+// Message is a decoded DTX message
+type Message struct {
+	Identifier        uint32
+	ConversationIndex uint32
+	ChannelCode       int32
+	ExpectsReply      bool
+	Type              uint32
+	// Payload is the unarchived payload object (the selector for dispatch messages)
+	Payload any
+	// Aux holds the message arguments
+	Aux []any
+}
+
+// Selector returns the method selector of a dispatch message
+func (m *Message) Selector() string {
+	if m.Type != MessageTypeDispatch {
+		return ""
+	}
+	s, _ := m.Payload.(string)
+	return s
+}
+
+// Err returns the NSError carried by an error reply (if any)
+func (m *Message) Err() error {
+	if err, ok := m.Payload.(*Error); ok {
+		return err
+	}
+	if m.Type == MessageTypeError {
+		return fmt.Errorf("instruments error reply: %v", m.Payload)
+	}
+	return nil
+}
+
+// Int32 is an argument sent as a primitive uint32 instead of an archived NSNumber
+// (i.e. the channel code of _requestChannelWithCode:identifier:)
+type Int32 int32
+
+func encodeAux(args []any) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	le := binary.LittleEndian
+	items := new(bytes.Buffer)
+	for _, arg := range args {
+		binary.Write(items, le, uint32(auxTypeNull))
+		switch v := arg.(type) {
+		case Int32:
+			binary.Write(items, le, uint32(auxTypeUint32))
+			binary.Write(items, le, uint32(v))
+		default:
+			data, err := archive(arg)
+			if err != nil {
+				return nil, err
+			}
+			binary.Write(items, le, uint32(auxTypeObject))
+			binary.Write(items, le, uint32(len(data)))
+			items.Write(data)
+		}
+	}
+	out := new(bytes.Buffer)
+	binary.Write(out, le, uint64(dtxAuxMagic))
+	binary.Write(out, le, uint64(items.Len()))
+	out.Write(items.Bytes())
+	return out.Bytes(), nil
+}
+
+func decodeAux(data []byte) ([]any, error) {
+	le := binary.LittleEndian
+	r := bytes.NewReader(data)
+	var magic, size uint64
+	if err := binary.Read(r, le, &magic); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, le, &size); err != nil {
+		return nil, err
+	}
+	var args []any
+	for r.Len() > 0 {
+		var typ uint32
+		if err := binary.Read(r, le, &typ); err != nil {
+			return nil, err
+		}
+		if typ == auxTypeNull {
+			if err := binary.Read(r, le, &typ); err != nil {
+				return nil, err
+			}
+		}
+		switch typ {
+		case auxTypeString, auxTypeObject:
+			var n uint32
+			if err := binary.Read(r, le, &n); err != nil {
+				return nil, err
+			}
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, err
+			}
+			if typ == auxTypeString {
+				args = append(args, string(buf))
+				continue
+			}
+			obj, err := unarchive(buf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unarchive auxiliary argument: %w", err)
+			}
+			args = append(args, obj)
+		case auxTypeUint32:
+			var v uint32
+			if err := binary.Read(r, le, &v); err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		case auxTypeUint64:
+			var v uint64
+			if err := binary.Read(r, le, &v); err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		case auxTypeInt64:
+			var v int64
+			if err := binary.Read(r, le, &v); err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		case auxTypeFloat64:
+			var v float64
+			if err := binary.Read(r, le, &v); err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		default:
+			return nil, fmt.Errorf("unsupported auxiliary argument type %#x", typ)
+		}
+	}
+	return args, nil
+}
+
+// encode serializes the message; the payload must already be archived (or nil)
+func (m *Message) encode(payload []byte) ([]byte, error) {
+	aux, err := encodeAux(m.Aux)
+	if err != nil {
+		return nil, err
+	}
+	flags := m.Type
+	if m.ExpectsReply {
+		flags |= dtxExpectsReplyFlag
+	}
+	hdr := dtxHeader{
+		Magic:             dtxMagic,
+		HeaderLength:      dtxHeaderSize,
+		FragmentIndex:     0,
+		FragmentCount:     1,
+		Length:            uint32(dtxPayloadHeaderSize + len(aux) + len(payload)),
+		Identifier:        m.Identifier,
+		ConversationIndex: m.ConversationIndex,
+		ChannelCode:       m.ChannelCode,
+	}
+	if m.ExpectsReply {
+		hdr.ExpectsReply = 1
+	}
+	out := new(bytes.Buffer)
+	binary.Write(out, binary.LittleEndian, hdr)
+	binary.Write(out, binary.LittleEndian, dtxPayloadHeader{
+		Flags:           flags,
+		AuxiliaryLength: uint32(len(aux)),
+		TotalLength:     uint64(len(aux) + len(payload)),
+	})
+	out.Write(aux)
+	out.Write(payload)
+	return out.Bytes(), nil
+}
+
+// readMessage reads the next (reassembled) DTX message from r
+func readMessage(r io.Reader) (*Message, error) {
+	var hdr dtxHeader
+	var body []byte
+	for {
+		if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+			return nil, err
+		}
+		if hdr.Magic != dtxMagic {
+			return nil, fmt.Errorf("invalid DTX message magic: %#x", hdr.Magic)
+		}
+		if hdr.HeaderLength > dtxHeaderSize {
+			if _, err := io.CopyN(io.Discard, r, int64(hdr.HeaderLength-dtxHeaderSize)); err != nil {
+				return nil, err
+			}
+		}
+		// the first fragment of a multi-fragment message only carries the header
+		if hdr.FragmentCount > 1 && hdr.FragmentIndex == 0 {
+			continue
+		}
+		frag := make([]byte, hdr.Length)
+		if _, err := io.ReadFull(r, frag); err != nil {
+			return nil, err
+		}
+		body = append(body, frag...)
+		if hdr.FragmentIndex+1 >= hdr.FragmentCount {
+			break
+		}
+	}
+
+	msg := &Message{
+		Identifier:        hdr.Identifier,
+		ConversationIndex: hdr.ConversationIndex,
+		ChannelCode:       hdr.ChannelCode,
+		ExpectsReply:      hdr.ExpectsReply != 0,
+	}
+	if len(body) < dtxPayloadHeaderSize {
+		return msg, nil
+	}
+
+	var phdr dtxPayloadHeader
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &phdr); err != nil {
+		return nil, err
+	}
+	msg.Type = phdr.Flags & 0xff
+	if msg.Type == MessageTypeCompressed {
+		return nil, fmt.Errorf("compressed DTX messages are not supported")
+	}
+	body = body[dtxPayloadHeaderSize:]
+	if uint64(len(body)) < phdr.TotalLength || phdr.TotalLength < uint64(phdr.AuxiliaryLength) {
+		return nil, fmt.Errorf("truncated DTX message payload")
+	}
+	if phdr.AuxiliaryLength > 0 {
+		aux, err := decodeAux(body[:phdr.AuxiliaryLength])
+		if err != nil {
+			return nil, err
+		}
+		msg.Aux = aux
+	}
+	if payload := body[phdr.AuxiliaryLength:phdr.TotalLength]; len(payload) > 0 {
+		if msg.Type == MessageTypeData || msg.Type == MessageTypePrimitive {
+			msg.Payload = payload
+			return msg, nil
+		}
+		obj, err := unarchive(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unarchive DTX payload: %w", err)
+		}
+		msg.Payload = obj
+	}
+
+	return msg, nil
+}
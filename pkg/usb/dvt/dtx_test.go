@@ -0,0 +1,55 @@
+package dvt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMessage_EncodeRead(t *testing.T) {
+	msg := &Message{
+		Identifier:   5,
+		ChannelCode:  2,
+		ExpectsReply: true,
+		Type:         MessageTypeDispatch,
+		Aux: []any{
+			Int32(3),
+			"com.apple.instruments.server.services.deviceinfo",
+			map[string]any{
+				"procAttrs": []any{"pid", "name"},
+				"ur":        int64(1000),
+				"cpuUsage":  true,
+			},
+		},
+	}
+	payload, err := archive("_requestChannelWithCode:identifier:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := msg.encode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Identifier != msg.Identifier || got.ChannelCode != msg.ChannelCode || !got.ExpectsReply {
+		t.Errorf("readMessage() header = %+v, want %+v", got, msg)
+	}
+	if sel := got.Selector(); sel != "_requestChannelWithCode:identifier:" {
+		t.Errorf("Selector() = %q", sel)
+	}
+	want := []any{
+		uint32(3),
+		"com.apple.instruments.server.services.deviceinfo",
+		map[string]any{
+			"procAttrs": []any{"pid", "name"},
+			"ur":        uint64(1000),
+			"cpuUsage":  true,
+		},
+	}
+	if !reflect.DeepEqual(got.Aux, want) {
+		t.Errorf("readMessage() aux = %#v, want %#v", got.Aux, want)
+	}
+}
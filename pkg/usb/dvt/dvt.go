@@ -1,13 +1,23 @@
+// Package dvt implements the DTX messaging protocol spoken by the instruments remote server
+// (DVTInstrumentsFoundation) found in the iOS developer disk image
 package dvt
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/apex/log"
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
 )
 
 const (
-	serviceName    = "com.apple.instruments.remoteserver.DVTSecureSocketProxy"
-	oldServiceName = "com.apple.instruments.remoteserver"
+	serviceName       = "com.apple.instruments.remoteserver.DVTSecureSocketProxy"
+	oldServiceName    = "com.apple.instruments.remoteserver"
+	remoteServiceName = "com.apple.instruments.dtservicehub"
 )
 
 const (
@@ -20,22 +30,297 @@ const (
 	procControlChannel           = "com.apple.instruments.server.services.processcontrol"
 	procControlPosixSpawnChannel = "com.apple.instruments.server.services.processcontrol.posixspawn"
 	watchProcessControlChannel   = "com.apple.dt.Xcode.WatchProcessControl"
+	sysmontapChannel             = "com.apple.instruments.server.services.sysmontap"
+	graphicsChannel              = "com.apple.instruments.server.services.graphics.opengl"
+	energyChannel                = "com.apple.xcode.debug-gauge-data-providers.Energy"
 )
 
+// Client is a DTX connection to the instruments remote server
 type Client struct {
 	c *usb.Client
+
+	mu       sync.Mutex
+	wmu      sync.Mutex
+	nextID   uint32
+	nextCode int32
+	ctrl     *Channel
+	channels map[int32]*Channel
+	named    map[string]*Channel
+	replies  map[uint32]chan *Message
+	err      error
+	done     chan struct{}
+}
+
+// NewClient connects to the instruments remote server (iOS14+ DVTSecureSocketProxy with a fallback to the classic service)
+func NewClient(udid string) (*Client, error) {
+	c, err := NewSecureSocketProxy(udid)
+	if err == nil {
+		return c, nil
+	}
+	log.WithError(err).Debug("failed to connect to instruments DVTSecureSocketProxy (falling back)")
+	cli, err := lockdownd.NewClientForService(oldServiceName, udid, false)
+	if err != nil {
+		return nil, err
+	}
+	// the classic service only uses SSL for the handshake
+	cli.DisableSSL()
+	return newClient(cli)
 }
 
+// NewSecureSocketProxy connects to the SSL wrapped instruments remote server used by iOS14+ DDIs
 func NewSecureSocketProxy(udid string) (*Client, error) {
 	c, err := lockdownd.NewClientForService(serviceName, udid, false)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
-		c: c,
-	}, nil
+	return newClient(c)
+}
+
+// NewRemoteClient connects to the instruments service hub over RemoteXPC (iOS17+ via the CoreDevice tunnel)
+func NewRemoteClient(r *rsd.Client) (*Client, error) {
+	conn, err := r.Dial(remoteServiceName)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(usb.NewClientFromConn(conn, r.Property("UniqueDeviceID")))
+}
+
+func newClient(c *usb.Client) (*Client, error) {
+	cli := &Client{
+		c:        c,
+		nextID:   1,
+		nextCode: 1,
+		channels: make(map[int32]*Channel),
+		named:    make(map[string]*Channel),
+		replies:  make(map[uint32]chan *Message),
+		done:     make(chan struct{}),
+	}
+	// channel 0 is the implicit control channel
+	ctrl := newChannel(cli, 0, "")
+	cli.ctrl = ctrl
+	cli.channels[0] = ctrl
+
+	go cli.readLoop()
+
+	if err := ctrl.Send("_notifyOfPublishedCapabilities:", map[string]any{
+		"com.apple.private.DTXBlockCompression": 0,
+		"com.apple.private.DTXConnection":       1,
+	}); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to send DTX capabilities: %w", err)
+	}
+	msg, err := ctrl.Recv(context.Background())
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to receive DTX capabilities: %w", err)
+	}
+	if msg.Selector() != "_notifyOfPublishedCapabilities:" {
+		cli.Close()
+		return nil, fmt.Errorf("unexpected DTX handshake message: %v", msg.Payload)
+	}
+
+	return cli, nil
+}
+
+func (c *Client) write(msg *Message, payload any) error {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = archive(payload); err != nil {
+			return fmt.Errorf("failed to archive DTX payload: %w", err)
+		}
+	}
+	buf, err := msg.encode(data)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err = c.c.Conn().Write(buf)
+	return err
+}
+
+func (c *Client) readLoop() {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		if err == io.EOF {
+			err = fmt.Errorf("instruments connection closed")
+		}
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	}()
+	for {
+		var msg *Message
+		msg, err = readMessage(c.c.Conn())
+		if err != nil {
+			return
+		}
+
+		if msg.ConversationIndex > 0 {
+			c.mu.Lock()
+			reply, ok := c.replies[msg.Identifier]
+			delete(c.replies, msg.Identifier)
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+				continue
+			}
+		}
+
+		code := msg.ChannelCode
+		if code < 0 {
+			// device initiated messages on a client channel use the negated channel code
+			code = -code
+		}
+		c.mu.Lock()
+		ch, ok := c.channels[code]
+		c.mu.Unlock()
+		if !ok {
+			log.Debugf("dropping DTX message for unknown channel %d: %v", msg.ChannelCode, msg.Payload)
+			continue
+		}
+		if msg.ExpectsReply {
+			if err := c.write(&Message{
+				Identifier:        msg.Identifier,
+				ConversationIndex: msg.ConversationIndex + 1,
+				ChannelCode:       msg.ChannelCode,
+				Type:              MessageTypeOK,
+			}, nil); err != nil {
+				log.WithError(err).Debug("failed to acknowledge DTX message")
+			}
+		}
+		select {
+		case ch.queue <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Channel opens (or returns the already open) channel to the instruments service identifier
+func (c *Client) Channel(identifier string) (*Channel, error) {
+	c.mu.Lock()
+	if ch, ok := c.named[identifier]; ok {
+		c.mu.Unlock()
+		return ch, nil
+	}
+	code := c.nextCode
+	c.nextCode++
+	ch := newChannel(c, code, identifier)
+	c.channels[code] = ch
+	c.mu.Unlock()
+
+	if _, err := c.ctrl.Call("_requestChannelWithCode:identifier:", Int32(code), identifier); err != nil {
+		c.mu.Lock()
+		delete(c.channels, code)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("failed to open channel %s: %w", identifier, err)
+	}
+
+	c.mu.Lock()
+	c.named[identifier] = ch
+	c.mu.Unlock()
+
+	return ch, nil
 }
 
+// Close closes the connection
 func (c *Client) Close() error {
 	return c.c.Close()
 }
+
+// Channel is a DTX channel to a single instruments service
+type Channel struct {
+	c          *Client
+	code       int32
+	identifier string
+	queue      chan *Message
+}
+
+func newChannel(c *Client, code int32, identifier string) *Channel {
+	return &Channel{
+		c:          c,
+		code:       code,
+		identifier: identifier,
+		queue:      make(chan *Message, 128),
+	}
+}
+
+// Identifier returns the service identifier of the channel
+func (ch *Channel) Identifier() string {
+	return ch.identifier
+}
+
+func (ch *Channel) send(selector string, expectsReply bool, args []any) (chan *Message, error) {
+	c := ch.c
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	id := c.nextID
+	c.nextID++
+	var reply chan *Message
+	if expectsReply {
+		reply = make(chan *Message, 1)
+		c.replies[id] = reply
+	}
+	c.mu.Unlock()
+
+	if err := c.write(&Message{
+		Identifier:   id,
+		ChannelCode:  ch.code,
+		ExpectsReply: expectsReply,
+		Type:         MessageTypeDispatch,
+		Aux:          args,
+	}, selector); err != nil {
+		c.mu.Lock()
+		delete(c.replies, id)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("failed to send %s: %w", selector, err)
+	}
+	return reply, nil
+}
+
+// Send invokes selector with args without waiting for a reply
+func (ch *Channel) Send(selector string, args ...any) error {
+	_, err := ch.send(selector, false, args)
+	return err
+}
+
+// Call invokes selector with args and returns the unarchived reply payload
+func (ch *Channel) Call(selector string, args ...any) (any, error) {
+	reply, err := ch.send(selector, true, args)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case msg := <-reply:
+		if err := msg.Err(); err != nil {
+			return nil, fmt.Errorf("%s failed: %w", selector, err)
+		}
+		return msg.Payload, nil
+	case <-ch.c.done:
+		return nil, ch.c.err
+	}
+}
+
+// Recv returns the next message the device sent on the channel
+func (ch *Channel) Recv(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-ch.queue:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-ch.c.done:
+		// drain anything received before the connection went away
+		select {
+		case msg := <-ch.queue:
+			return msg, nil
+		default:
+		}
+		return nil, ch.c.err
+	}
+}
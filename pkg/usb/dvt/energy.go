@@ -0,0 +1,66 @@
+package dvt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EnergySample is a single energy gauge sample for a process
+type EnergySample struct {
+	Timestamp time.Time      `json:"timestamp"`
+	PID       int            `json:"pid"`
+	Energy    map[string]any `json:"energy"`
+}
+
+// Energy samples the Xcode energy gauge (CPU/GPU/networking/location cost) of pids every interval
+// calling fn for every sample until ctx is canceled or fn returns an error
+func (c *Client) Energy(ctx context.Context, pids []int, interval time.Duration, fn func(*EnergySample) error) error {
+	if len(pids) == 0 {
+		return fmt.Errorf("no pids to sample")
+	}
+	if interval == 0 {
+		interval = time.Second
+	}
+	ch, err := c.Channel(energyChannel)
+	if err != nil {
+		return err
+	}
+	// clear any sampling session left behind by a previous client
+	if _, err := ch.Call("stopSamplingForPIDs:", pids); err != nil {
+		return err
+	}
+	if _, err := ch.Call("startSamplingForPIDs:", pids); err != nil {
+		return err
+	}
+	defer ch.Send("stopSamplingForPIDs:", pids)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := ch.Call("sampleAttributes:forPIDs:", map[string]any{}, pids)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		samples, _ := resp.(map[string]any)
+		for _, pid := range pids {
+			energy, ok := samples[fmt.Sprint(pid)].(map[string]any)
+			if !ok {
+				continue
+			}
+			if err := fn(&EnergySample{Timestamp: now, PID: pid, Energy: energy}); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package dvt
+
+import (
+	"context"
+	"time"
+)
+
+// GraphicsSample is a single graphics.opengl sample (i.e. CoreAnimationFramesPerSecond, Device Utilization %)
+type GraphicsSample struct {
+	Timestamp time.Time      `json:"timestamp"`
+	FPS       int            `json:"fps"`
+	Stats     map[string]any `json:"stats"`
+}
+
+// Graphics samples the GPU/CoreAnimation statistics calling fn for every sample until ctx is canceled or fn returns an error
+func (c *Client) Graphics(ctx context.Context, fn func(*GraphicsSample) error) error {
+	ch, err := c.Channel(graphicsChannel)
+	if err != nil {
+		return err
+	}
+	if _, err := ch.Call("startSamplingAtTimeInterval:", 0.0); err != nil {
+		return err
+	}
+	defer ch.Send("stopSampling")
+
+	for {
+		msg, err := ch.Recv(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		stats, ok := msg.Payload.(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := fn(&GraphicsSample{
+			Timestamp: time.Now(),
+			FPS:       toInt(stats["CoreAnimationFramesPerSecond"]),
+			Stats:     stats,
+		}); err != nil {
+			return err
+		}
+	}
+}
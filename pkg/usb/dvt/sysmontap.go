@@ -0,0 +1,178 @@
+package dvt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DefaultProcessAttributes are the per-process attributes sampled when none are requested
+var DefaultProcessAttributes = []string{
+	"pid", "name", "cpuUsage", "physFootprint", "memResidentSize", "memVirtualSize", "threadCount",
+}
+
+// SysmonConfig configures a sysmontap sampling session
+type SysmonConfig struct {
+	// Interval is the sampling interval (defaults to 1s)
+	Interval time.Duration
+	// ProcessAttributes are the per-process attributes to sample (see SysmonProcessAttributes)
+	ProcessAttributes []string
+	// SystemAttributes are the system wide attributes to sample (defaults to all supported)
+	SystemAttributes []string
+	// PIDs limits process samples to these pids (all processes if empty)
+	PIDs []int
+}
+
+// SysmonSample is a single sysmontap sample
+type SysmonSample struct {
+	Timestamp time.Time        `json:"timestamp"`
+	CPUCount  int              `json:"cpu_count,omitempty"`
+	CPUUsage  map[string]any   `json:"cpu_usage,omitempty"`
+	System    map[string]any   `json:"system,omitempty"`
+	Processes []map[string]any `json:"processes,omitempty"`
+}
+
+func filterAttrs(requested, supported []string) ([]string, error) {
+	var attrs []string
+	for _, a := range requested {
+		if !slices.Contains(supported, a) {
+			return nil, fmt.Errorf("attribute %s not supported by device (supported: %v)", a, supported)
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// Sysmon samples system and per-process CPU/memory usage calling fn for every sample until ctx is canceled or fn returns an error
+func (c *Client) Sysmon(ctx context.Context, conf *SysmonConfig, fn func(*SysmonSample) error) error {
+	if conf.Interval == 0 {
+		conf.Interval = time.Second
+	}
+
+	supportedProc, err := c.SysmonProcessAttributes()
+	if err != nil {
+		return fmt.Errorf("failed to get sysmon process attributes: %w", err)
+	}
+	supportedSys, err := c.SysmonSystemAttributes()
+	if err != nil {
+		return fmt.Errorf("failed to get sysmon system attributes: %w", err)
+	}
+	procAttrs := conf.ProcessAttributes
+	if len(procAttrs) == 0 {
+		for _, a := range DefaultProcessAttributes {
+			if slices.Contains(supportedProc, a) {
+				procAttrs = append(procAttrs, a)
+			}
+		}
+	} else if procAttrs, err = filterAttrs(procAttrs, supportedProc); err != nil {
+		return err
+	}
+	sysAttrs := conf.SystemAttributes
+	if len(sysAttrs) == 0 {
+		sysAttrs = supportedSys
+	} else if sysAttrs, err = filterAttrs(sysAttrs, supportedSys); err != nil {
+		return err
+	}
+
+	ch, err := c.Channel(sysmontapChannel)
+	if err != nil {
+		return err
+	}
+	if _, err := ch.Call("setConfig:", map[string]any{
+		"ur":             conf.Interval.Milliseconds(),
+		"bm":             0,
+		"cpuUsage":       true,
+		"sampleInterval": conf.Interval.Nanoseconds(),
+		"procAttrs":      procAttrs,
+		"sysAttrs":       sysAttrs,
+	}); err != nil {
+		return err
+	}
+	if _, err := ch.Call("start"); err != nil {
+		return err
+	}
+	defer ch.Send("stop")
+
+	for {
+		msg, err := ch.Recv(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		// samples arrive as a list of dictionaries (heartbeats are empty)
+		var entries []any
+		switch p := msg.Payload.(type) {
+		case []any:
+			entries = p
+		case map[string]any:
+			entries = []any{p}
+		}
+		for _, e := range entries {
+			entry, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			sample := parseSysmonEntry(entry, procAttrs, sysAttrs, conf.PIDs)
+			if sample == nil {
+				continue
+			}
+			if err := fn(sample); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func parseSysmonEntry(entry map[string]any, procAttrs, sysAttrs []string, pids []int) *SysmonSample {
+	procs, hasProcs := entry["Processes"].(map[string]any)
+	system, hasSys := entry["System"].([]any)
+	if !hasProcs && !hasSys {
+		return nil
+	}
+
+	sample := &SysmonSample{
+		Timestamp: time.Now(),
+		CPUCount:  toInt(entry["CPUCount"]),
+	}
+	sample.CPUUsage, _ = entry["SystemCPUUsage"].(map[string]any)
+	if hasSys {
+		sample.System = make(map[string]any, len(sysAttrs))
+		for i, v := range system {
+			if i < len(sysAttrs) {
+				sample.System[sysAttrs[i]] = v
+			}
+		}
+	}
+	for pid, v := range procs {
+		values, ok := v.([]any)
+		if !ok {
+			continue
+		}
+		proc := map[string]any{"pid": toInt(pidValue(pid))}
+		for i, val := range values {
+			if i < len(procAttrs) {
+				proc[procAttrs[i]] = val
+			}
+		}
+		if len(pids) > 0 && !slices.Contains(pids, toInt(proc["pid"])) {
+			continue
+		}
+		sample.Processes = append(sample.Processes, proc)
+	}
+	sort.Slice(sample.Processes, func(i, j int) bool {
+		return toInt(sample.Processes[i]["pid"]) < toInt(sample.Processes[j]["pid"])
+	})
+
+	return sample
+}
+
+// pidValue converts an NSDictionary key (stringified by unarchive) back into a pid
+func pidValue(key string) int {
+	var pid int
+	fmt.Sscanf(key, "%d", &pid)
+	return pid
+}
@@ -9,23 +9,40 @@ import (
 	"github.com/blacktop/ipsw/pkg/usb"
 )
 
+// DialFunc opens a new connection to the device side of a forward
+type DialFunc func() (net.Conn, error)
+
 func Start(ctx context.Context, udid string, lport, rport int, callback func(string, error)) (err error) {
+	_, err = StartDial(ctx, lport, func() (net.Conn, error) {
+		c, err := usb.NewClient(udid, rport)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to device port %d: %v", rport, err)
+		}
+		return c.Conn(), nil
+	}, callback)
+	return err
+}
+
+// StartDial forwards every connection accepted on localhost:lport to a connection returned by dial
+// (i.e. a freshly started lockdown service) and returns the listening address (lport 0 picks a free port)
+func StartDial(ctx context.Context, lport int, dial DialFunc, callback func(string, error)) (string, error) {
 	listen, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", lport))
 	if err != nil {
-		return fmt.Errorf("failed to listen on tcp port %d: %v", lport, err)
+		return "", fmt.Errorf("failed to listen on tcp port %d: %v", lport, err)
 	}
 
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				_ = listen.Close()
-				return
-			default:
-			}
+		<-ctx.Done()
+		_ = listen.Close()
+	}()
 
+	go func() {
+		for {
 			conn, err := listen.Accept()
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				if callback != nil {
 					callback("", fmt.Errorf("accepting new conn error: %v", err))
 				}
@@ -35,30 +52,36 @@ func Start(ctx context.Context, udid string, lport, rport int, callback func(str
 				callback("new client connected", nil)
 			}
 
-			go startNewProxy(ctx, conn, udid, rport, callback)
+			go startNewProxy(ctx, conn, dial, callback)
 		}
 	}()
 
-	return nil
+	return listen.Addr().String(), nil
 }
 
-func startNewProxy(ctx context.Context, conn net.Conn, udid string, rport int, callback func(string, error)) {
-	c, err := usb.NewClient(udid, rport)
+func startNewProxy(ctx context.Context, conn net.Conn, dial DialFunc, callback func(string, error)) {
+	c, err := dial()
 	if err != nil {
+		_ = conn.Close()
 		if callback != nil {
-			callback("", fmt.Errorf("failed to connect to device port %d: %v", rport, err))
+			callback("", err)
 		}
 		return
 	}
+	Pipe(ctx, conn, c)
+}
+
+// Pipe copies data between a and b until ctx is canceled
+func Pipe(ctx context.Context, a, b net.Conn) {
 	go func() {
 		<-ctx.Done()
-		_ = conn.Close()
-		_ = c.Close()
+		_ = a.Close()
+		_ = b.Close()
 	}()
 	go func() {
-		io.Copy(conn, c.Conn())
+		io.Copy(a, b)
 	}()
 	go func() {
-		io.Copy(c.Conn(), conn)
+		io.Copy(b, a)
 	}()
 }
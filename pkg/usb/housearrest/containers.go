@@ -0,0 +1,90 @@
+package housearrest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/blacktop/ipsw/pkg/usb/apps"
+)
+
+// ContainerDirs are the folders of an app's data container
+var ContainerDirs = []string{"Documents", "Library", "tmp"}
+
+// Container is an installed user app and how much of its container house_arrest will vend
+type Container struct {
+	BundleID string `json:"bundle_id"`
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+	// FileSharing apps (UIFileSharingEnabled) can have their Documents folder vended
+	FileSharing bool `json:"file_sharing"`
+	// Development signed apps (get-task-allow) can have their whole container vended
+	Development bool `json:"development"`
+}
+
+// Vend returns the vend command allowed for the app (or an empty string if its container is not accessible)
+func (c Container) Vend() string {
+	switch {
+	case c.Development:
+		return VendContainer
+	case c.FileSharing:
+		return VendDocuments
+	default:
+		return ""
+	}
+}
+
+// Containers returns the installed user apps and whether their containers are accessible
+func Containers(udid string) ([]Container, error) {
+	cli, err := apps.NewClient(udid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to installation_proxy: %w", err)
+	}
+	defer cli.Close()
+
+	lookup, err := cli.LookupRaw(
+		"CFBundleIdentifier",
+		"CFBundleDisplayName",
+		"CFBundleShortVersionString",
+		"UIFileSharingEnabled",
+		"Entitlements",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup apps: %w", err)
+	}
+
+	var containers []Container
+	for id, v := range lookup {
+		app, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		c := Container{BundleID: id}
+		c.Name, _ = app["CFBundleDisplayName"].(string)
+		c.Version, _ = app["CFBundleShortVersionString"].(string)
+		c.FileSharing, _ = app["UIFileSharingEnabled"].(bool)
+		if ents, ok := app["Entitlements"].(map[string]any); ok {
+			c.Development, _ = ents["get-task-allow"].(bool)
+		}
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].BundleID < containers[j].BundleID })
+
+	return containers, nil
+}
+
+// Open vends the app's whole container, falling back to its Documents folder
+// (which is all house_arrest allows for non-development file sharing apps)
+func Open(udid, bundleID string) (*afc.Client, string, error) {
+	cli, err := NewClient(udid, bundleID, VendContainer)
+	if err == nil {
+		return cli, VendContainer, nil
+	}
+	log.WithError(err).Debug("failed to vend container (trying Documents)")
+	cli, derr := NewClient(udid, bundleID, VendDocuments)
+	if derr != nil {
+		return nil, "", fmt.Errorf("%w (whole containers are only vended for development signed apps and Documents for apps with file sharing enabled)", err)
+	}
+	return cli, VendDocuments, nil
+}
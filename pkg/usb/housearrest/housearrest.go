@@ -0,0 +1,56 @@
+package housearrest
+
+import (
+	"fmt"
+
+	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+const (
+	serviceName = "com.apple.mobile.house_arrest"
+
+	// VendContainer vends an app's whole data container
+	VendContainer = "VendContainer"
+	// VendDocuments vends only an app's Documents folder (for apps with UIFileSharingEnabled)
+	VendDocuments = "VendDocuments"
+)
+
+type vendRequest struct {
+	Command    string `plist:"Command"`
+	Identifier string `plist:"Identifier"`
+}
+
+type vendResponse struct {
+	Status string `plist:"Status,omitempty"`
+	Error  string `plist:"Error,omitempty"`
+}
+
+// NewClient returns an AFC client rooted at the container of the app bundleID
+// where command is either VendContainer or VendDocuments
+func NewClient(udid, bundleID, command string) (*afc.Client, error) {
+	c, err := lockdownd.NewClientForService(serviceName, udid, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp vendResponse
+	if err := c.Request(&vendRequest{
+		Command:    command,
+		Identifier: bundleID,
+	}, &resp); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to send %s request: %w", command, err)
+	}
+	if len(resp.Error) > 0 {
+		c.Close()
+		return nil, fmt.Errorf("failed to vend %s container: %s", bundleID, resp.Error)
+	}
+	if resp.Status != "Complete" {
+		c.Close()
+		return nil, fmt.Errorf("failed to vend %s container: unexpected status %q", bundleID, resp.Status)
+	}
+
+	// the connection now speaks AFC
+	return afc.NewClientFromConn(c), nil
+}
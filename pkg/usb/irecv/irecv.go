@@ -3,9 +3,15 @@
 package irecv
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"hash/crc32"
+	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/google/gousb"
@@ -13,9 +19,61 @@ import (
 
 const (
 	AppleUSBVendor = 0x5ac
+
+	usbTimeout = 10 * time.Second
+
+	recoveryPacketSize = 0x8000
+	dfuPacketSize      = 0x800
+)
+
+// Mode is the USB mode the device is in
+type Mode uint16
+
+const (
+	ModeWTF       Mode = 0x1222
+	ModeDFU       Mode = 0x1227
+	ModeRecovery1 Mode = 0x1280
+	ModeRecovery2 Mode = 0x1281
+	ModeRecovery3 Mode = 0x1282
+	ModeRecovery4 Mode = 0x1283
+	ModePortDFU   Mode = 0x1881
 )
 
+func (m Mode) String() string {
+	switch m {
+	case ModeWTF:
+		return "WTF"
+	case ModeDFU:
+		return "DFU"
+	case ModeRecovery1, ModeRecovery2, ModeRecovery3, ModeRecovery4:
+		return "Recovery"
+	case ModePortDFU:
+		return "Port DFU"
+	default:
+		return fmt.Sprintf("Unknown(%#x)", uint16(m))
+	}
+}
+
+// IsRecovery returns true if the device is in recovery (iBoot) mode
+func (m Mode) IsRecovery() bool {
+	return m >= ModeRecovery1 && m <= ModeRecovery4
+}
+
+func isAppleBootMode(desc *gousb.DeviceDesc) bool {
+	if desc.Vendor != AppleUSBVendor {
+		return false
+	}
+	switch Mode(desc.Product) {
+	case ModeWTF, ModeDFU, ModeRecovery1, ModeRecovery2, ModeRecovery3, ModeRecovery4, ModePortDFU:
+		return true
+	default:
+		return false
+	}
+}
+
 type Client struct {
+	Mode Mode
+
 	SDOM string
 	CPID string
 	CPRV string
@@ -25,70 +83,145 @@ type Client struct {
 	ECID string
 	IBFL string
 	SRNM string
+	// ApNonce and SEPNonce are only reported by iBoot/SecureROM on newer devices
+	ApNonce  []byte
+	SEPNonce []byte
 
-	dev *gousb.Device
+	ctx  *gousb.Context
+	dev  *gousb.Device
+	cfg  *gousb.Config
+	intf *gousb.Interface
 }
 
+var (
+	noncRE = regexp.MustCompile(`NONC:([0-9A-Fa-f]+)`)
+	snonRE = regexp.MustCompile(`SNON:([0-9A-Fa-f]+)`)
+)
+
+// NewClient connects to the first Apple device found in Recovery or DFU mode
 func NewClient() (*Client, error) {
 	ctx := gousb.NewContext()
-	defer ctx.Close()
 
-	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
-		dev, err := ctx.OpenDeviceWithVIDPID(desc.Vendor, desc.Product)
-		if err != nil {
-			log.Fatalf("Could not open a device: %v", err)
-		}
-		man, _ := dev.Manufacturer()
-		prod, _ := dev.Product()
-		log.WithFields(log.Fields{
-			"vendor":  man,
-			"product": prod,
-		}).Debug("USB Device")
-		return desc.Vendor == AppleUSBVendor && strings.Contains(prod, "Recovery Mode")
-	})
+	devs, err := ctx.OpenDevices(isAppleBootMode)
 	if err != nil {
+		for _, d := range devs {
+			d.Close()
+		}
+		ctx.Close()
 		return nil, err
 	}
 
 	if len(devs) == 0 {
-		return nil, fmt.Errorf("no 'Recovery Mode' devices found")
+		ctx.Close()
+		return nil, fmt.Errorf("no 'Recovery Mode' or 'DFU Mode' devices found")
+	}
+	for _, d := range devs[1:] {
+		d.Close()
+	}
+
+	c := &Client{
+		Mode: Mode(devs[0].Desc.Product),
+		ctx:  ctx,
+		dev:  devs[0],
 	}
+	c.dev.ControlTimeout = usbTimeout
+
+	prod, _ := c.dev.Product()
+	log.WithFields(log.Fields{
+		"product": prod,
+		"mode":    c.Mode,
+	}).Debug("USB Device")
 
-	serial, err := devs[0].SerialNumber()
+	serial, err := c.dev.SerialNumber()
 	if err != nil {
-		return nil, err
+		c.Close()
+		return nil, fmt.Errorf("failed to read device serial number: %v", err)
+	}
+	c.parseSerial(serial)
+	// the nonces are either appended to the serial number or in their own string descriptor
+	nonces := serial
+	if !noncRE.MatchString(nonces) {
+		if desc, err := c.dev.GetStringDescriptor(1); err == nil {
+			nonces = desc
+		}
+	}
+	if m := noncRE.FindStringSubmatch(nonces); m != nil {
+		c.ApNonce, _ = hex.DecodeString(m[1])
 	}
+	if m := snonRE.FindStringSubmatch(nonces); m != nil {
+		c.SEPNonce, _ = hex.DecodeString(m[1])
+	}
+
+	return c, nil
+}
 
-	re := regexp.MustCompile(`^SDOM:(?P<SDOM>\d+) CPID:(?P<CPID>\d+) CPRV:(?P<CPRV>\d+) CPFM:(?P<CPFM>\d+) SCEP:(?P<SCEP>\d+) BDID:(?P<BDID>\d+) ECID:(?P<ECID>\S+) IBFL:(?P<IBFL>\S+) SRNM:\[(?P<SRNM>\S+)\]$`)
-	if re.MatchString(serial) {
-		matches := re.FindStringSubmatch(serial)
-		if len(matches) != 10 {
-			return nil, fmt.Errorf("failed to parse SerialNumber")
+// parseSerial reads the space separated KEY:VALUE fields of the serial number
+// (the DFU mode serials may not have all of them, i.e. SRNM and SDOM)
+func (c *Client) parseSerial(serial string) {
+	for _, field := range strings.Fields(serial) {
+		key, val, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "SDOM":
+			c.SDOM = val
+		case "CPID":
+			c.CPID = val
+		case "CPRV":
+			c.CPRV = val
+		case "CPFM":
+			c.CPFM = val
+		case "SCEP":
+			c.SCEP = val
+		case "BDID":
+			c.BDID = val
+		case "ECID":
+			c.ECID = val
+		case "IBFL":
+			c.IBFL = val
+		case "SRNM":
+			c.SRNM = strings.Trim(val, "[]")
 		}
-		return &Client{
-			SDOM: matches[1],
-			CPID: matches[2],
-			CPRV: matches[3],
-			CPFM: matches[4],
-			SCEP: matches[5],
-			BDID: matches[6],
-			ECID: matches[7],
-			IBFL: matches[8],
-			SRNM: matches[9],
-			dev:  devs[0],
-		}, nil
-	} else {
-		return &Client{
-			dev: devs[0],
-		}, nil
 	}
 }
 
+func (c *Client) String() string {
+	return fmt.Sprintf(
+		"Mode: %s\n"+
+			"CPID: %s\n"+
+			"CPRV: %s\n"+
+			"CPFM: %s\n"+
+			"SCEP: %s\n"+
+			"BDID: %s\n"+
+			"ECID: %s\n"+
+			"IBFL: %s\n"+
+			"SRNM: %s\n"+
+			"NONC: %x\n"+
+			"SNON: %x",
+		c.Mode, c.CPID, c.CPRV, c.CPFM, c.SCEP, c.BDID, c.ECID, c.IBFL, c.SRNM, c.ApNonce, c.SEPNonce)
+}
+
 func (c *Client) Close() error {
-	return c.dev.Close()
+	if c.intf != nil {
+		c.intf.Close()
+	}
+	if c.cfg != nil {
+		c.cfg.Close()
+	}
+	if err := c.dev.Close(); err != nil {
+		return err
+	}
+	return c.ctx.Close()
 }
 
 func (c *Client) SendCommand(cmd string) error {
+	if !c.Mode.IsRecovery() {
+		return fmt.Errorf("device must be in recovery mode to send commands (current mode: %s)", c.Mode)
+	}
+	if len(cmd) > 0x100 {
+		return fmt.Errorf("command too long (max 256 bytes)")
+	}
 	if n, err := c.dev.Control(gousb.ControlVendor, 0x0, 0x0, 0x0, []byte(cmd+"\x00")); err != nil {
 		return fmt.Errorf("%s.Control(%s): %v", c.dev, cmd, err)
 	} else if n != len(cmd)+1 {
@@ -97,15 +230,142 @@ func (c *Client) SendCommand(cmd string) error {
 	return nil
 }
 
-func (c *Client) SendFile(file string) error {
-	return nil
+// Getenv returns the value of an iBoot environment variable
+func (c *Client) Getenv(name string) (string, error) {
+	if err := c.SendCommand("getenv " + name); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 0x100)
+	n, err := c.dev.Control(gousb.ControlIn|gousb.ControlVendor, 0x0, 0x0, 0x0, buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to read getenv response: %v", err)
+	}
+	return strings.TrimRight(string(buf[:n]), "\x00"), nil
 }
 
-func (c *Client) SetAutoboot(set bool) error {
-	if err := c.SendCommand("setenv auto-boot " + fmt.Sprintf("%t", set)); err != nil {
+// Setenv sets an iBoot environment variable (and saves it to NVRAM if save is true)
+func (c *Client) Setenv(name, value string, save bool) error {
+	if err := c.SendCommand(fmt.Sprintf("setenv %s %s", name, value)); err != nil {
 		return err
 	}
-	return c.SendCommand("saveenv")
+	if save {
+		return c.SendCommand("saveenv")
+	}
+	return nil
+}
+
+func (c *Client) claim() (*gousb.OutEndpoint, error) {
+	if c.intf == nil {
+		cfg, err := c.dev.Config(1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set USB configuration: %v", err)
+		}
+		c.cfg = cfg
+		intf, err := cfg.Interface(1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim USB interface: %v", err)
+		}
+		c.intf = intf
+	}
+	return c.intf.OutEndpoint(0x04)
+}
+
+type dfuStatus struct {
+	Status      uint8
+	PollTimeout [3]byte
+	State       uint8
+	Index       uint8
+}
+
+func (c *Client) getStatus() (*dfuStatus, error) {
+	buf := make([]byte, 6)
+	if _, err := c.dev.Control(0xa1, 3, 0, 0, buf); err != nil {
+		return nil, fmt.Errorf("failed to get DFU status: %v", err)
+	}
+	var st dfuStatus
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// dfuSuffix appends the DFU file suffix (and CRC) that SecureROM expects
+func dfuSuffix(dat []byte) []byte {
+	suffix := []byte{0xff, 0xff, 0xff, 0xff, 0xac, 0x05, 0x00, 0x01, 0x55, 0x46, 0x44, 0x10}
+	out := append(append([]byte{}, dat...), suffix...)
+	crc := ^crc32.ChecksumIEEE(out)
+	return binary.LittleEndian.AppendUint32(out, crc)
+}
+
+// SendBuffer uploads a buffer (usually an IMG4) to the device
+func (c *Client) SendBuffer(dat []byte, progress func(sent, total int)) error {
+	if c.Mode.IsRecovery() {
+		if _, err := c.dev.Control(0x41, 0, 0, 0, nil); err != nil {
+			return fmt.Errorf("failed to initiate upload: %v", err)
+		}
+		ep, err := c.claim()
+		if err != nil {
+			return err
+		}
+		for off := 0; off < len(dat); off += recoveryPacketSize {
+			end := min(off+recoveryPacketSize, len(dat))
+			if _, err := ep.Write(dat[off:end]); err != nil {
+				return fmt.Errorf("failed to write chunk at offset %#x: %v", off, err)
+			}
+			if progress != nil {
+				progress(end, len(dat))
+			}
+		}
+		return nil
+	}
+
+	dat = dfuSuffix(dat)
+	packets := 0
+	for off := 0; off < len(dat); off += dfuPacketSize {
+		end := min(off+dfuPacketSize, len(dat))
+		if n, err := c.dev.Control(0x21, 1, uint16(packets), 0, dat[off:end]); err != nil {
+			return fmt.Errorf("failed to send DFU packet %d: %v", packets, err)
+		} else if n != end-off {
+			return fmt.Errorf("short DFU packet %d: %d bytes written, want %d", packets, n, end-off)
+		}
+		st, err := c.getStatus()
+		if err != nil {
+			return err
+		}
+		if st.State != 5 { // dfuDNLOAD-IDLE
+			return fmt.Errorf("unexpected DFU state %d after packet %d", st.State, packets)
+		}
+		packets++
+		if progress != nil {
+			progress(end, len(dat))
+		}
+	}
+	// notify SecureROM that the upload is finished
+	if _, err := c.dev.Control(0x21, 1, uint16(packets), 0, nil); err != nil {
+		return fmt.Errorf("failed to finish DFU upload: %v", err)
+	}
+	for range 3 {
+		if _, err := c.getStatus(); err != nil {
+			return err
+		}
+	}
+	if err := c.dev.Reset(); err != nil {
+		log.Debugf("USB reset after DFU upload: %v", err) // expected as the device re-enumerates
+	}
+	return nil
+}
+
+// SendFile uploads a file (usually an IMG4) to the device
+func (c *Client) SendFile(file string) error {
+	dat, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %v", file, err)
+	}
+	return c.SendBuffer(dat, nil)
+}
+
+func (c *Client) SetAutoboot(set bool) error {
+	return c.Setenv("auto-boot", fmt.Sprintf("%t", set), true)
 }
 
 func (c *Client) Reboot(set bool) error {
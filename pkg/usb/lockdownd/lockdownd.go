@@ -1,14 +1,17 @@
 package lockdownd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 )
 
 const lockdownPort = 62078
 
+var colorHeader = color.New(color.FgHiBlue).SprintFunc()
 var colorFaint = color.New(color.Faint, color.FgHiBlue).SprintFunc()
 var colorBold = color.New(color.Bold).SprintFunc()
 
@@ -57,7 +60,19 @@ func NewClient(udid string) (*Client, error) {
 	return &Client{cli}, nil
 }
 
+// NewClientForService starts the lockdown service serviceName and connects to it (through the device's
+// CoreDevice tunnel if one was set with UseTunnel and the device advertises the service's RemoteXPC shim)
 func NewClientForService(serviceName, udid string, withEscrowBag bool) (*usb.Client, error) {
+	if rsdAddr, ok := tunnelInUse(udid); ok {
+		cli, err := newTunnelClient(rsdAddr, serviceName)
+		if err == nil {
+			return cli, nil
+		}
+		if !errors.Is(err, errNoShim) {
+			return nil, err
+		}
+	}
+
 	lc, err := NewClient(udid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create lockdownd client for service %s: %v", serviceName, err)
@@ -497,6 +512,51 @@ func (lc *Client) SetWifiConnections(on bool) error {
 	return nil
 }
 
+// DiskUsage is the device's storage usage (com.apple.disk_usage domain) in bytes
+type DiskUsage struct {
+	TotalDiskCapacity    uint64 `plist:"TotalDiskCapacity,omitempty" json:"total_disk_capacity"`
+	TotalDataCapacity    uint64 `plist:"TotalDataCapacity,omitempty" json:"total_data_capacity"`
+	TotalDataAvailable   uint64 `plist:"TotalDataAvailable,omitempty" json:"total_data_available"`
+	AmountDataAvailable  uint64 `plist:"AmountDataAvailable,omitempty" json:"amount_data_available"`
+	AmountDataReserved   uint64 `plist:"AmountDataReserved,omitempty" json:"amount_data_reserved"`
+	TotalSystemCapacity  uint64 `plist:"TotalSystemCapacity,omitempty" json:"total_system_capacity"`
+	TotalSystemAvailable uint64 `plist:"TotalSystemAvailable,omitempty" json:"total_system_available"`
+}
+
+func (d DiskUsage) String() string {
+	return fmt.Sprintf(
+		colorHeader("[STORAGE]\n")+
+			colorFaint("  Capacity: ")+colorBold("%s\n")+
+			colorFaint("  Data:     ")+colorBold("%s free of %s\n")+
+			colorFaint("  System:   ")+colorBold("%s free of %s\n"),
+		humanize.Bytes(d.TotalDiskCapacity),
+		humanize.Bytes(d.TotalDataAvailable), humanize.Bytes(d.TotalDataCapacity),
+		humanize.Bytes(d.TotalSystemAvailable), humanize.Bytes(d.TotalSystemCapacity),
+	)
+}
+
+type getDiskUsageResponse struct {
+	Request string
+	Error   string `plist:"Error,omitempty"`
+	Value   DiskUsage
+}
+
+func (lc *Client) DiskUsage() (*DiskUsage, error) {
+	req := &getValueRequest{
+		Request: "GetValue",
+		Label:   usb.BundleID,
+		Domain:  "com.apple.disk_usage",
+	}
+	var resp getDiskUsageResponse
+	if err := lc.Request(req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("failed to get disk usage: %s", resp.Error)
+	}
+	return &resp.Value, nil
+}
+
 type queryTypeRequest struct {
 	Label   string
 	Request string `plist:"Request"`
@@ -0,0 +1,201 @@
+package lockdownd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/google/uuid"
+)
+
+const pairRecordValidity = 10 * 365 * 24 * time.Hour
+
+// ErrPairingDialogPending is returned while the device shows the "Trust This Computer?" prompt
+var ErrPairingDialogPending = errors.New("waiting for the user to tap 'Trust' on the device")
+
+type pairRecordRequest struct {
+	DeviceCertificate []byte `plist:"DeviceCertificate,omitempty"`
+	HostCertificate   []byte `plist:"HostCertificate,omitempty"`
+	HostID            string `plist:"HostID"`
+	RootCertificate   []byte `plist:"RootCertificate,omitempty"`
+	SystemBUID        string `plist:"SystemBUID,omitempty"`
+}
+
+type pairingOptions struct {
+	ExtendedPairingErrors bool `plist:"ExtendedPairingErrors"`
+}
+
+type pairRequest struct {
+	Label           string
+	ProtocolVersion string
+	Request         string
+	PairRecord      *pairRecordRequest
+	PairingOptions  *pairingOptions `plist:"PairingOptions,omitempty"`
+}
+
+type pairResponse struct {
+	Request   string
+	EscrowBag []byte `plist:"EscrowBag,omitempty"`
+	Error     string `plist:"Error,omitempty"`
+}
+
+// NewUnpairedClient connects to lockdownd without a pair record or session (only pairing and a limited set of values are available)
+func NewUnpairedClient(udid string) (*Client, error) {
+	cli, err := usb.NewUnpairedClient(udid, lockdownPort)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{cli}, nil
+}
+
+func newCertificate(template, parent *x509.Certificate, pub any, priv *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+func parseDevicePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode device public key PEM")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse device public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported device public key type %T", key)
+	}
+	return rsaKey, nil
+}
+
+// NewPairRecord generates the root, host and device certificates of a new pair record for the device's public key
+func NewPairRecord(devicePublicKey []byte, systemBUID string) (*usb.PairRecord, error) {
+	devKey, err := parseDevicePublicKey(devicePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	rootKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Add(-time.Hour)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{},
+		NotBefore:             now,
+		NotAfter:              now.Add(pairRecordValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	rootCert, err := newCertificate(root, root, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root certificate: %w", err)
+	}
+	leaf := func(pub *rsa.PublicKey) *x509.Certificate {
+		ski := sha1.Sum(x509.MarshalPKCS1PublicKey(pub))
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			NotBefore:             now,
+			NotAfter:              now.Add(pairRecordValidity),
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			SubjectKeyId:          ski[:],
+		}
+	}
+	hostCert, err := newCertificate(leaf(&hostKey.PublicKey), root, &hostKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host certificate: %w", err)
+	}
+	devCert, err := newCertificate(leaf(devKey), root, devKey, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device certificate: %w", err)
+	}
+
+	if len(systemBUID) == 0 {
+		systemBUID = strings.ToUpper(uuid.New().String())
+	}
+
+	return &usb.PairRecord{
+		DeviceCertificate: devCert,
+		HostCertificate:   hostCert,
+		HostID:            strings.ToUpper(uuid.New().String()),
+		HostPrivateKey:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(hostKey)}),
+		RootCertificate:   rootCert,
+		RootPrivateKey:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rootKey)}),
+		SystemBUID:        systemBUID,
+	}, nil
+}
+
+// Pair asks the device to trust the pair record; it returns ErrPairingDialogPending until the user accepts the prompt
+func (lc *Client) Pair(record *usb.PairRecord) error {
+	req := &pairRequest{
+		Label:           usb.BundleID,
+		ProtocolVersion: "2",
+		Request:         "Pair",
+		PairRecord: &pairRecordRequest{
+			DeviceCertificate: record.DeviceCertificate,
+			HostCertificate:   record.HostCertificate,
+			HostID:            record.HostID,
+			RootCertificate:   record.RootCertificate,
+			SystemBUID:        record.SystemBUID,
+		},
+		PairingOptions: &pairingOptions{ExtendedPairingErrors: true},
+	}
+	var resp pairResponse
+	if err := lc.Request(req, &resp); err != nil {
+		return err
+	}
+	switch resp.Error {
+	case "":
+	case "PairingDialogResponsePending":
+		return ErrPairingDialogPending
+	case "PasswordProtected":
+		return fmt.Errorf("device is locked: unlock it and try again")
+	case "UserDeniedPairing":
+		return fmt.Errorf("user denied pairing on the device")
+	default:
+		return fmt.Errorf("failed to pair: %s", resp.Error)
+	}
+	record.EscrowBag = resp.EscrowBag
+	return nil
+}
+
+// Unpair removes the host's pair record from the device
+func (lc *Client) Unpair(record *usb.PairRecord) error {
+	req := &pairRequest{
+		Label:           usb.BundleID,
+		ProtocolVersion: "2",
+		Request:         "Unpair",
+		PairRecord:      &pairRecordRequest{HostID: record.HostID},
+	}
+	var resp pairResponse
+	if err := lc.Request(req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Error) > 0 {
+		return fmt.Errorf("failed to unpair: %s", resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package lockdownd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
+)
+
+// errNoShim is returned when the device doesn't advertise a RemoteXPC shim for a lockdown service
+var errNoShim = errors.New("no RemoteXPC shim for service")
+
+var (
+	tunnelMu sync.RWMutex
+	tunnels  = make(map[string]string)
+)
+
+// UseTunnel makes the clients of the device's lockdown services connect to them through its CoreDevice
+// tunnel, i.e. to the '<service>.shim.remote' services advertised by the RemoteServiceDiscovery at rsdAddr
+func UseTunnel(udid, rsdAddr string) {
+	tunnelMu.Lock()
+	defer tunnelMu.Unlock()
+	tunnels[udid] = rsdAddr
+}
+
+// tunnelInUse returns the RemoteServiceDiscovery address set with UseTunnel for the device (if any)
+func tunnelInUse(udid string) (string, bool) {
+	tunnelMu.RLock()
+	defer tunnelMu.RUnlock()
+	addr, ok := tunnels[udid]
+	return addr, ok
+}
+
+func newTunnelClient(rsdAddr, serviceName string) (*usb.Client, error) {
+	r, err := rsd.NewClient(rsdAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RemoteServiceDiscovery at %s: %w", rsdAddr, err)
+	}
+	shim := serviceName + ".shim.remote"
+	if _, err := r.Service(shim); err != nil {
+		return nil, fmt.Errorf("%w %s", errNoShim, serviceName)
+	}
+	cli, err := r.DialLockdown(shim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to service %s through the tunnel: %v", serviceName, err)
+	}
+	return cli, nil
+}
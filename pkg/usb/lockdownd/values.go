@@ -0,0 +1,106 @@
+package lockdownd
+
+import (
+	"fmt"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+)
+
+// KnownDomains are lockdownd value domains (the empty/global domain holds the device values)
+var KnownDomains = []string{
+	"com.apple.disk_usage",
+	"com.apple.disk_usage.factory",
+	"com.apple.fairplay",
+	"com.apple.fmip",
+	"com.apple.iTunes",
+	"com.apple.international",
+	"com.apple.iqagent",
+	"com.apple.mobile.backup",
+	"com.apple.mobile.battery",
+	"com.apple.mobile.chaperone",
+	"com.apple.mobile.data_sync",
+	"com.apple.mobile.debug",
+	"com.apple.mobile.iTunes",
+	"com.apple.mobile.iTunes.SQLMusicLibraryPostProcessCommands",
+	"com.apple.mobile.iTunes.accessories",
+	"com.apple.mobile.iTunes.store",
+	"com.apple.mobile.internal",
+	"com.apple.mobile.lockdown_cache",
+	"com.apple.mobile.lockdownd",
+	"com.apple.mobile.mobile_application_usage",
+	"com.apple.mobile.nikita",
+	"com.apple.mobile.restriction",
+	"com.apple.mobile.software_behavior",
+	"com.apple.mobile.sync_data_class",
+	"com.apple.mobile.tethered_sync",
+	"com.apple.mobile.third_party_termination",
+	"com.apple.mobile.user_preferences",
+	"com.apple.mobile.wireless_lockdown",
+	"com.apple.purplebuddy",
+	"com.apple.PurpleBuddy",
+	"com.apple.security.mac.amfi",
+	"com.apple.xcode.developerdomain",
+}
+
+// WritableValues are the domain keys that are safe to set/remove (changing anything else can break pairing, activation or backups)
+var WritableValues = map[string][]string{
+	"":                                   {"DeviceName", "TimeZone", "Uses24HourClock"},
+	"com.apple.international":            {"Language", "Locale"},
+	"com.apple.mobile.backup":            {"CloudBackupEnabled", "RequiresEncrypt", "WillEncrypt"},
+	"com.apple.mobile.wireless_lockdown": {"EnableWifiConnections", "EnableWifiDebugging", "EnableWifiPairing"},
+	"com.apple.xcode.developerdomain":    {"DeveloperStatus"},
+}
+
+// IsWritable reports whether the domain key is in WritableValues
+func IsWritable(domain, key string) bool {
+	for _, k := range WritableValues[domain] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+type valueResponse struct {
+	Domain  string `plist:"Domain,omitempty"`
+	Error   string `plist:"Error,omitempty"`
+	Key     string `plist:"Key,omitempty"`
+	Request string `plist:"Request,omitempty"`
+}
+
+// SetValue sets the domain key's value
+func (lc *Client) SetValue(domain, key string, value any) error {
+	req := &setValueRequest{
+		Request: "SetValue",
+		Label:   usb.BundleID,
+		Domain:  domain,
+		Key:     key,
+		Value:   value,
+	}
+	var resp valueResponse
+	if err := lc.Request(req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("failed to set value: %s", resp.Error)
+	}
+	return nil
+}
+
+// RemoveValue removes the domain key
+func (lc *Client) RemoveValue(domain, key string) error {
+	req := &getValueRequest{
+		Request: "RemoveValue",
+		Label:   usb.BundleID,
+		Domain:  domain,
+		Key:     key,
+	}
+	var resp valueResponse
+	if err := lc.Request(req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("failed to remove value: %s", resp.Error)
+	}
+	return nil
+}
@@ -1,13 +1,18 @@
 package mcinstall
 
 import (
+	"crypto"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/blacktop/go-plist"
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 	"github.com/fatih/color"
+	"github.com/fullsailor/pkcs7"
 )
 
 const (
@@ -94,12 +99,35 @@ type profileRemoveRequest struct {
 	PayloadVersion    int    `plist:"PayloadVersion,omitempty"`
 }
 
+// err returns the profile request's error chain as an error (or nil if the request was acknowledged)
+func (r profileSendResponse) err() error {
+	if r.Status == "Acknowledged" {
+		return nil
+	}
+	var msgs []string
+	for _, e := range r.ErrorChain {
+		msg := e.USEnglishDescription
+		if len(msg) == 0 {
+			msg = e.LocalizedDescription
+		}
+		msgs = append(msgs, fmt.Sprintf("%s (%s %d)", msg, e.ErrorDomain, e.ErrorCode))
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("status %s", r.Status)
+	}
+	return errors.New(strings.Join(msgs, ": "))
+}
+
 func (c *Client) Install(profilePath string) error {
 	dat, err := os.ReadFile(profilePath)
 	if err != nil {
 		return fmt.Errorf("failed to read profile: %s", err)
 	}
+	return c.InstallData(dat)
+}
 
+// InstallData installs the profile (the user has to confirm the install in Settings unless the device is supervised)
+func (c *Client) InstallData(dat []byte) error {
 	var resp profileSendResponse
 	if err := c.c.Request(&map[string]any{
 		"RequestType": "InstallProfile",
@@ -107,12 +135,70 @@ func (c *Client) Install(profilePath string) error {
 	}, &resp); err != nil {
 		return err
 	}
+	return resp.err()
+}
 
-	if resp.Status != "Acknowledged" {
-		return fmt.Errorf("failed to get cloud config: %#v", resp.ErrorChain)
+// Escalate authenticates as the device's supervisor to allow silent profile installs
+func (c *Client) Escalate(cert *x509.Certificate, key crypto.PrivateKey) error {
+	var challenge struct {
+		profileSendResponse
+		Challenge []byte `plist:"Challenge,omitempty"`
+	}
+	if err := c.c.Request(&map[string]any{
+		"RequestType":           "Escalate",
+		"SupervisorCertificate": cert.Raw,
+	}, &challenge); err != nil {
+		return err
+	}
+	if err := challenge.err(); err != nil {
+		return fmt.Errorf("failed to escalate: %w", err)
 	}
 
-	return nil
+	sd, err := pkcs7.NewSignedData(challenge.Challenge)
+	if err != nil {
+		return err
+	}
+	if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+		return fmt.Errorf("failed to sign escalation challenge: %w", err)
+	}
+	signed, err := sd.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to sign escalation challenge: %w", err)
+	}
+
+	var resp profileSendResponse
+	if err := c.c.Request(&map[string]any{
+		"RequestType":   "EscalateResponse",
+		"SignedRequest": signed,
+	}, &resp); err != nil {
+		return err
+	}
+	if err := resp.err(); err != nil {
+		return fmt.Errorf("supervisor identity was rejected: %w", err)
+	}
+
+	resp = profileSendResponse{}
+	if err := c.c.Request(&map[string]any{
+		"RequestType": "ProceedWithKeybagMigration",
+	}, &resp); err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// InstallSilent installs the profile on a supervised device without user interaction
+func (c *Client) InstallSilent(dat []byte, cert *x509.Certificate, key crypto.PrivateKey) error {
+	if err := c.Escalate(cert, key); err != nil {
+		return err
+	}
+	var resp profileSendResponse
+	if err := c.c.Request(&map[string]any{
+		"RequestType": "InstallProfileSilent",
+		"Payload":     dat,
+	}, &resp); err != nil {
+		return err
+	}
+	return resp.err()
 }
 
 func (c *Client) Upload(profilePath string) error { // FIXME: I'm not sure what this is for, but fails if I use it like Install
@@ -137,13 +223,14 @@ func (c *Client) Upload(profilePath string) error { // FIXME: I'm not sure what
 	return nil
 }
 
+// Remove removes the installed profile with the identifier (or UUID/display name)
 func (c *Client) Remove(identifier string) error {
 	profiles, err := c.List()
 	if err != nil {
 		return err
 	}
 	for id, meta := range profiles.Metadatas {
-		if identifier == id {
+		if identifier == id || identifier == meta.UUID || identifier == meta.Name {
 			dat, err := plist.Marshal(profileRemoveRequest{
 				PayloadType:       "Configuration",
 				PayloadIdentifier: id,
@@ -154,7 +241,7 @@ func (c *Client) Remove(identifier string) error {
 				return err
 			}
 
-			var resp map[string]any
+			var resp profileSendResponse
 			if err := c.c.Request(&map[string]any{
 				"RequestType":       "RemoveProfile",
 				"ProfileIdentifier": dat,
@@ -162,11 +249,7 @@ func (c *Client) Remove(identifier string) error {
 				return err
 			}
 
-			if status, ok := resp["Status"]; !ok || status != "Acknowledged" {
-				return fmt.Errorf("failed to get cloud config: resp=%#v", resp)
-			}
-
-			return nil
+			return resp.err()
 		}
 	}
 
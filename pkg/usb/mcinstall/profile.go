@@ -0,0 +1,80 @@
+package mcinstall
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/blacktop/go-plist"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pkcs12"
+)
+
+type payload struct {
+	PayloadContent      any    `plist:"PayloadContent,omitempty"`
+	PayloadDescription  string `plist:"PayloadDescription,omitempty"`
+	PayloadDisplayName  string `plist:"PayloadDisplayName"`
+	PayloadIdentifier   string `plist:"PayloadIdentifier"`
+	PayloadOrganization string `plist:"PayloadOrganization,omitempty"`
+	PayloadType         string `plist:"PayloadType"`
+	PayloadUUID         string `plist:"PayloadUUID"`
+	PayloadVersion      int    `plist:"PayloadVersion"`
+}
+
+// ParseCertificate parses a PEM or DER encoded certificate
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// RootCertificateProfile wraps a (PEM or DER encoded) CA certificate in a configuration profile
+//
+// NOTE: after installing, full trust for the root has to be enabled in Settings > General > About > Certificate Trust Settings
+func RootCertificateProfile(data []byte) ([]byte, error) {
+	cert, err := ParseCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+	name := cert.Subject.CommonName
+	if len(name) == 0 {
+		name = cert.Subject.String()
+	}
+	sum := sha1.Sum(cert.Raw)
+	id := fmt.Sprintf("io.blacktop.ipsw.rootca.%X", sum)
+
+	return plist.MarshalIndent(&payload{
+		PayloadContent: []payload{{
+			PayloadContent:     cert.Raw,
+			PayloadDescription: "Adds a CA root certificate",
+			PayloadDisplayName: name,
+			PayloadIdentifier:  id + ".cert",
+			PayloadType:        "com.apple.security.root",
+			PayloadUUID:        strings.ToUpper(uuid.New().String()),
+			PayloadVersion:     1,
+		}},
+		PayloadDisplayName:  name,
+		PayloadIdentifier:   id,
+		PayloadOrganization: "ipsw",
+		PayloadType:         "Configuration",
+		PayloadUUID:         strings.ToUpper(uuid.New().String()),
+		PayloadVersion:      1,
+	}, plist.XMLFormat, "\t")
+}
+
+// ParseSupervisorIdentity returns the certificate and private key of a supervising organization's PKCS#12 identity
+func ParseSupervisorIdentity(p12 []byte, password string) (*x509.Certificate, crypto.PrivateKey, error) {
+	key, cert, err := pkcs12.Decode(p12, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode supervisor identity: %w", err)
+	}
+	return cert, key, nil
+}
@@ -0,0 +1,189 @@
+// Package mdns implements a minimal Bonjour (DNS-SD over multicast DNS) browser for finding devices on the LAN
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// MobileDeviceService is advertised by devices with Wi-Fi sync (wireless lockdown) enabled
+	MobileDeviceService = "_apple-mobdev2._tcp"
+	// RemotePairingService is advertised by iOS 17+ devices that can be paired over the network
+	RemotePairingService = "_remotepairing._tcp"
+
+	domain = "local."
+	// the top bit of the question class asks for a unicast response
+	classUnicastResponse = 0x8000
+	queryInterval        = time.Second
+)
+
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is a discovered DNS-SD service instance
+type Service struct {
+	Instance string            `json:"instance"`
+	Type     string            `json:"type"`
+	Host     string            `json:"host,omitempty"`
+	Port     int               `json:"port,omitempty"`
+	Addrs    []net.IP          `json:"addrs,omitempty"`
+	TXT      map[string]string `json:"txt,omitempty"`
+}
+
+// Address returns the service's preferred address (IPv4 over IPv6 as link-local IPv6 addresses need a zone)
+func (s *Service) Address() string {
+	for _, ip := range s.Addrs {
+		if ip.To4() != nil {
+			return ip.String()
+		}
+	}
+	if len(s.Addrs) > 0 {
+		return s.Addrs[0].String()
+	}
+	return ""
+}
+
+// Browse queries the LAN for instances of the service type (i.e. "_apple-mobdev2._tcp") until ctx is done
+func Browse(ctx context.Context, service string) ([]*Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	svcName := strings.TrimSuffix(service, ".") + "." + domain
+	query, err := newQuery(svcName)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &browser{
+		service:   svcName,
+		instances: make(map[string]*Service),
+		addrs:     make(map[string][]net.IP),
+	}
+
+	buf := make([]byte, 9000)
+	next := time.Now()
+	for ctx.Err() == nil {
+		if time.Now().After(next) {
+			if _, err := conn.WriteToUDP(query, mdnsAddr); err != nil {
+				return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+			}
+			next = time.Now().Add(queryInterval)
+		}
+		deadline := next
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetReadDeadline(deadline)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read mDNS response: %w", err)
+		}
+		b.parse(buf[:n])
+	}
+
+	return b.results(), nil
+}
+
+func newQuery(name string) ([]byte, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service name %s: %w", name, err)
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET | classUnicastResponse,
+		}},
+	}
+	return msg.Pack()
+}
+
+type browser struct {
+	service   string
+	instances map[string]*Service
+	addrs     map[string][]net.IP
+}
+
+func (b *browser) instance(name string) *Service {
+	s, ok := b.instances[name]
+	if !ok {
+		s = &Service{
+			Instance: strings.TrimSuffix(name, "."+b.service),
+			Type:     strings.TrimSuffix(b.service, "."+domain),
+		}
+		b.instances[name] = s
+	}
+	return s
+}
+
+func (b *browser) addAddr(host string, ip net.IP) {
+	for _, a := range b.addrs[host] {
+		if a.Equal(ip) {
+			return
+		}
+	}
+	b.addrs[host] = append(b.addrs[host], ip)
+}
+
+func (b *browser) parse(data []byte) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(data); err != nil {
+		return
+	}
+	records := append(msg.Answers, msg.Additionals...)
+	// PTRs first so SRV/TXT records for instances in the same response are not dropped
+	for _, rr := range records {
+		if ptr, ok := rr.Body.(*dnsmessage.PTRResource); ok && strings.EqualFold(rr.Header.Name.String(), b.service) {
+			b.instance(ptr.PTR.String())
+		}
+	}
+	for _, rr := range records {
+		name := rr.Header.Name.String()
+		switch body := rr.Body.(type) {
+		case *dnsmessage.SRVResource:
+			if s, ok := b.instances[name]; ok {
+				s.Host = body.Target.String()
+				s.Port = int(body.Port)
+			}
+		case *dnsmessage.TXTResource:
+			if s, ok := b.instances[name]; ok {
+				s.TXT = make(map[string]string)
+				for _, txt := range body.TXT {
+					k, v, _ := strings.Cut(txt, "=")
+					s.TXT[k] = v
+				}
+			}
+		case *dnsmessage.AResource:
+			b.addAddr(name, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			b.addAddr(name, net.IP(body.AAAA[:]))
+		}
+	}
+}
+
+func (b *browser) results() []*Service {
+	var svcs []*Service
+	for _, s := range b.instances {
+		s.Addrs = b.addrs[s.Host]
+		svcs = append(svcs, s)
+	}
+	sort.Slice(svcs, func(i, j int) bool {
+		return svcs[i].Instance < svcs[j].Instance
+	})
+	return svcs
+}
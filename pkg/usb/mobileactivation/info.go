@@ -0,0 +1,126 @@
+package mobileactivation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+// Info is the activation info the device sends to the activation server
+type Info struct {
+	ActivationRequestInfo struct {
+		ActivationRandomness string `plist:"ActivationRandomness,omitempty" json:"activation_randomness,omitempty"`
+		ActivationState      string `plist:"ActivationState,omitempty" json:"activation_state,omitempty"`
+		// FMiPAccountExists is set when Find My is enabled (i.e. the device is activation locked)
+		FMiPAccountExists bool `plist:"FMiPAccountExists" json:"fmip_account_exists"`
+	} `plist:"ActivationRequestInfo,omitempty" json:"activation_request_info"`
+	BasebandRequestInfo map[string]any `plist:"BasebandRequestInfo,omitempty" json:"baseband_request_info,omitempty"`
+	DeviceID            struct {
+		SerialNumber   string `plist:"SerialNumber,omitempty" json:"serial_number,omitempty"`
+		UniqueDeviceID string `plist:"UniqueDeviceID,omitempty" json:"unique_device_id,omitempty"`
+	} `plist:"DeviceID,omitempty" json:"device_id"`
+	DeviceInfo map[string]any `plist:"DeviceInfo,omitempty" json:"device_info,omitempty"`
+}
+
+func parseInfo(v any) (*Info, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected activation info type %T", v)
+	}
+	xml, ok := m["ActivationInfoXML"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("activation info has no ActivationInfoXML")
+	}
+	var info Info
+	if _, err := plist.Unmarshal(xml, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse ActivationInfoXML: %w", err)
+	}
+	return &info, nil
+}
+
+// Info returns the device's activation info (the activation request it would send)
+func (c *Client) Info() (*Info, error) {
+	var resp response
+	if err := c.c.Request(&request{Command: "CreateActivationInfoRequest"}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("failed to create activation info: %s", resp.Error)
+	}
+	return parseInfo(resp.Value)
+}
+
+// LockdownInfo returns the activation info from lockdownd (for devices without mobileactivationd)
+func LockdownInfo(lc *lockdownd.Client) (*Info, error) {
+	v, err := lc.GetValue("", "ActivationInfo")
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(v)
+}
+
+// Baseband is the device's baseband, SIM and carrier state
+type Baseband struct {
+	Status                  string `json:"status,omitempty"`
+	Version                 string `json:"version,omitempty"`
+	ActivationTicketVersion string `json:"activation_ticket_version,omitempty"`
+	IMEI                    string `json:"imei,omitempty"`
+	IMEI2                   string `json:"imei2,omitempty"`
+	MEID                    string `json:"meid,omitempty"`
+	ICCID                   string `json:"iccid,omitempty"`
+	IMSI                    string `json:"imsi,omitempty"`
+	PhoneNumber             string `json:"phone_number,omitempty"`
+	MobileCountryCode       string `json:"mobile_country_code,omitempty"`
+	MobileNetworkCode       string `json:"mobile_network_code,omitempty"`
+	SIMStatus               string `json:"sim_status,omitempty"`
+	SIMTrayStatus           string `json:"sim_tray_status,omitempty"`
+	// SIMLocked is set when the SIM is waiting for its PIN/PUK (or is otherwise unusable)
+	SIMLocked          bool             `json:"sim_locked"`
+	PostponementStatus string           `json:"postponement_status,omitempty"`
+	Carriers           []map[string]any `json:"carriers,omitempty"`
+}
+
+// SIM status values that leave the SIM unusable
+var lockedSIMStatus = []string{
+	"kCTSIMSupportSIMStatusPINLocked",
+	"kCTSIMSupportSIMStatusPUKLocked",
+	"kCTSIMSupportSIMStatusNetworkLocked",
+	"kCTSIMSupportSIMStatusCorporateLocked",
+	"kCTSIMSupportSIMStatusOperatorLocked",
+}
+
+func str(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// NewBaseband collects the baseband state from the lockdownd device values
+func NewBaseband(dv *lockdownd.DeviceValues) *Baseband {
+	bb := &Baseband{
+		Status:                  dv.BasebandStatus,
+		Version:                 dv.BasebandVersion,
+		ActivationTicketVersion: dv.BasebandActivationTicketVersion,
+		IMEI:                    dv.InternationalMobileEquipmentIdentity,
+		IMEI2:                   dv.InternationalMobileEquipmentIdentity2,
+		MEID:                    dv.MobileEquipmentIdentifier,
+		ICCID:                   dv.IntegratedCircuitCardIdentity,
+		IMSI:                    dv.InternationalMobileSubscriberIdentity,
+		PhoneNumber:             dv.PhoneNumber,
+		MobileCountryCode:       dv.MobileSubscriberCountryCode,
+		MobileNetworkCode:       dv.MobileSubscriberNetworkCode,
+		SIMStatus:               str(dv.SIMStatus),
+		SIMTrayStatus:           str(dv.SIMTrayStatus),
+		PostponementStatus:      dv.CTPostponementStatus,
+		Carriers:                dv.CarrierBundleInfoArray,
+	}
+	for _, s := range lockedSIMStatus {
+		if strings.EqualFold(bb.SIMStatus, s) {
+			bb.SIMLocked = true
+		}
+	}
+	return bb
+}
@@ -0,0 +1,55 @@
+// Package mobileactivation reads a device's activation state from mobileactivationd and lockdownd
+// and parses activation info requests and activation records
+package mobileactivation
+
+import (
+	"fmt"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+const serviceName = "com.apple.mobileactivationd"
+
+type request struct {
+	Command string `plist:"Command"`
+}
+
+type response struct {
+	Value any    `plist:"Value,omitempty"`
+	Error string `plist:"Error,omitempty"`
+}
+
+type Client struct {
+	c *usb.Client
+}
+
+func NewClient(udid string) (*Client, error) {
+	c, err := lockdownd.NewClientForService(serviceName, udid, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c: c,
+	}, nil
+}
+
+// State returns the activation state (e.g. Unactivated, Activated, FactoryActivated)
+func (c *Client) State() (string, error) {
+	var resp response
+	if err := c.c.Request(&request{Command: "GetActivationStateRequest"}, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("failed to get activation state: %s", resp.Error)
+	}
+	state, ok := resp.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected activation state response: %v", resp.Value)
+	}
+	return state, nil
+}
+
+func (c *Client) Close() error {
+	return c.c.Close()
+}
@@ -0,0 +1,137 @@
+package mobileactivation
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/blacktop/go-plist"
+)
+
+// Record is a device activation record (as returned by the activation server and stored on the device)
+type Record struct {
+	AccountToken            *AccountToken `json:"account_token,omitempty"`
+	AccountTokenCertificate *Certificate  `json:"account_token_certificate,omitempty"`
+	DeviceCertificate       *Certificate  `json:"device_certificate,omitempty"`
+	UniqueDeviceCertificate *Certificate  `json:"unique_device_certificate,omitempty"`
+	HasFairPlayKeyData      bool          `json:"has_fairplay_key_data"`
+	Unbrick                 bool          `json:"unbrick"`
+	Version                 int           `json:"version,omitempty"`
+}
+
+type rawRecord struct {
+	AccountToken            []byte `plist:"AccountToken,omitempty"`
+	AccountTokenCertificate []byte `plist:"AccountTokenCertificate,omitempty"`
+	AccountTokenSignature   []byte `plist:"AccountTokenSignature,omitempty"`
+	DeviceCertificate       []byte `plist:"DeviceCertificate,omitempty"`
+	FairPlayKeyData         []byte `plist:"FairPlayKeyData,omitempty"`
+	UniqueDeviceCertificate []byte `plist:"UniqueDeviceCertificate,omitempty"`
+	Unbrick                 bool   `plist:"unbrick,omitempty"`
+	LDActivationVersion     int    `plist:"LDActivationVersion,omitempty"`
+}
+
+// AccountToken is the signed ticket binding the activation to the device (and its baseband)
+type AccountToken struct {
+	UniqueDeviceID                        string `plist:"UniqueDeviceID,omitempty" json:"unique_device_id,omitempty"`
+	UniqueChipID                          any    `plist:"UniqueChipID,omitempty" json:"unique_chip_id,omitempty"`
+	SerialNumber                          string `plist:"SerialNumber,omitempty" json:"serial_number,omitempty"`
+	ProductType                           string `plist:"ProductType,omitempty" json:"product_type,omitempty"`
+	InternationalMobileEquipmentIdentity  string `plist:"InternationalMobileEquipmentIdentity,omitempty" json:"imei,omitempty"`
+	InternationalMobileEquipmentIdentity2 string `plist:"InternationalMobileEquipmentIdentity2,omitempty" json:"imei2,omitempty"`
+	MobileEquipmentIdentifier             string `plist:"MobileEquipmentIdentifier,omitempty" json:"meid,omitempty"`
+	IntegratedCircuitCardIdentity         string `plist:"IntegratedCircuitCardIdentity,omitempty" json:"iccid,omitempty"`
+	InternationalMobileSubscriberIdentity string `plist:"InternationalMobileSubscriberIdentity,omitempty" json:"imsi,omitempty"`
+	ActivationRandomness                  string `plist:"ActivationRandomness,omitempty" json:"activation_randomness,omitempty"`
+	ActivityURL                           string `plist:"ActivityURL,omitempty" json:"activity_url,omitempty"`
+	CertificateURL                        string `plist:"CertificateURL,omitempty" json:"certificate_url,omitempty"`
+	PhoneNumberNotificationURL            string `plist:"PhoneNumberNotificationURL,omitempty" json:"phone_number_notification_url,omitempty"`
+	// ActivationTicket is the baseband activation ticket (a WildcardTicket activates the baseband for any carrier)
+	ActivationTicket string `plist:"ActivationTicket,omitempty" json:"activation_ticket,omitempty"`
+	WildcardTicket   string `plist:"WildcardTicket,omitempty" json:"wildcard_ticket,omitempty"`
+}
+
+// Certificate is the summary of an activation record certificate
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+func parseCertificate(data []byte) (*Certificate, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	} else if dec, err := base64.StdEncoding.DecodeString(string(data)); err == nil {
+		if block, _ := pem.Decode(dec); block != nil {
+			data = block.Bytes
+		} else {
+			data = dec
+		}
+	}
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		Serial:    cert.SerialNumber.Text(16),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}, nil
+}
+
+// ParseAccountToken parses an activation record's AccountToken (an OpenStep plist)
+func ParseAccountToken(data []byte) (*AccountToken, error) {
+	var tok AccountToken
+	if _, err := plist.Unmarshal(data, &tok); err != nil {
+		return nil, fmt.Errorf("failed to parse account token: %w", err)
+	}
+	return &tok, nil
+}
+
+// ParseRecord parses an activation record plist (either the bare record or an activation server response wrapping it)
+func ParseRecord(data []byte) (*Record, error) {
+	var wrapped struct {
+		ActivationRecord *rawRecord `plist:"ActivationRecord,omitempty"`
+	}
+	if _, err := plist.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse activation record: %w", err)
+	}
+	raw := wrapped.ActivationRecord
+	if raw == nil {
+		raw = new(rawRecord)
+		if _, err := plist.Unmarshal(data, raw); err != nil {
+			return nil, fmt.Errorf("failed to parse activation record: %w", err)
+		}
+	}
+	if len(raw.AccountToken) == 0 {
+		return nil, fmt.Errorf("activation record has no AccountToken")
+	}
+
+	rec := &Record{
+		HasFairPlayKeyData: len(raw.FairPlayKeyData) > 0,
+		Unbrick:            raw.Unbrick,
+		Version:            raw.LDActivationVersion,
+	}
+	var err error
+	if rec.AccountToken, err = ParseAccountToken(raw.AccountToken); err != nil {
+		return nil, err
+	}
+	if rec.AccountTokenCertificate, err = parseCertificate(raw.AccountTokenCertificate); err != nil {
+		return nil, fmt.Errorf("failed to parse AccountTokenCertificate: %w", err)
+	}
+	if rec.DeviceCertificate, err = parseCertificate(raw.DeviceCertificate); err != nil {
+		return nil, fmt.Errorf("failed to parse DeviceCertificate: %w", err)
+	}
+	if rec.UniqueDeviceCertificate, err = parseCertificate(raw.UniqueDeviceCertificate); err != nil {
+		return nil, fmt.Errorf("failed to parse UniqueDeviceCertificate: %w", err)
+	}
+	return rec, nil
+}
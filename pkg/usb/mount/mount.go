@@ -8,10 +8,12 @@ import (
 
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
 )
 
 const (
-	serviceName = "com.apple.mobile.mobile_image_mounter"
+	serviceName       = "com.apple.mobile.mobile_image_mounter"
+	remoteServiceName = "com.apple.mobile.mobile_image_mounter.shim.remote"
 )
 
 const (
@@ -43,6 +45,17 @@ func NewClient(udid string) (*Client, error) {
 	}, nil
 }
 
+// NewRemoteClient connects to mobile_image_mounter over RemoteXPC (iOS 17+ via the CoreDevice tunnel)
+func NewRemoteClient(r *rsd.Client) (*Client, error) {
+	c, err := r.DialLockdown(remoteServiceName)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c: c,
+	}, nil
+}
+
 type ListImageResponse struct {
 	Status    string               `plist:"Status,omitempty" json:"status,omitempty"`
 	EntryList []ListImageEntryList `plist:"EntryList,omitempty" json:"entry_list,omitempty"`
@@ -0,0 +1,138 @@
+package usb
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blacktop/go-plist"
+)
+
+const networkDialTimeout = 5 * time.Second
+
+// NetworkDevice is a device reachable over the LAN via Wi-Fi (wireless) lockdown
+type NetworkDevice struct {
+	UDID    string
+	Address string
+	Record  *PairRecord
+}
+
+var (
+	networkMu     sync.RWMutex
+	networkDevice *NetworkDevice
+)
+
+// UseNetworkDevice makes clients for the device connect to it over the network instead of through usbmuxd
+func UseNetworkDevice(dev *NetworkDevice) {
+	networkMu.Lock()
+	defer networkMu.Unlock()
+	networkDevice = dev
+}
+
+// NetworkDeviceInUse returns the device set with UseNetworkDevice (if any)
+func NetworkDeviceInUse() *NetworkDevice {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	return networkDevice
+}
+
+func newNetworkClient(dev *NetworkDevice, port int) (*Client, error) {
+	addr := net.JoinHostPort(dev.Address, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", addr, networkDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	return &Client{
+		conn:       conn,
+		pairRecord: dev.Record,
+		udid:       dev.UDID,
+		deviceID:   -1,
+	}, nil
+}
+
+// PairRecordStore is a folder of pair records (<UDID>.plist) kept by ipsw so devices can be used over Wi-Fi
+// without usbmuxd having them (the same layout as libimobiledevice's /var/lib/lockdown)
+type PairRecordStore struct {
+	Dir string
+}
+
+func (s *PairRecordStore) path(udid string) string {
+	return filepath.Join(s.Dir, udid+".plist")
+}
+
+// Load reads the device's pair record
+func (s *PairRecordStore) Load(udid string) (*PairRecord, error) {
+	data, err := os.ReadFile(s.path(udid))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no pair record for %s in %s", udid, s.Dir)
+		}
+		return nil, err
+	}
+	var record PairRecord
+	if _, err := plist.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse pair record %s: %w", s.path(udid), err)
+	}
+	return &record, nil
+}
+
+// Save writes the device's pair record
+func (s *PairRecordStore) Save(udid string, record *PairRecord) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create pair record folder: %w", err)
+	}
+	data, err := plist.MarshalIndent(record, plist.XMLFormat, "\t")
+	if err != nil {
+		return err
+	}
+	// pair records contain private keys
+	return os.WriteFile(s.path(udid), data, 0o600)
+}
+
+// Remove deletes the device's pair record
+func (s *PairRecordStore) Remove(udid string) error {
+	return os.Remove(s.path(udid))
+}
+
+// List returns the UDIDs of the stored pair records
+func (s *PairRecordStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var udids []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".plist") && name != "SystemConfiguration.plist" {
+			udids = append(udids, strings.TrimSuffix(name, ".plist"))
+		}
+	}
+	sort.Strings(udids)
+	return udids, nil
+}
+
+// FindByWiFiMAC returns the UDID of the stored pair record with the Wi-Fi MAC address
+func (s *PairRecordStore) FindByWiFiMAC(mac string) (string, *PairRecord, error) {
+	udids, err := s.List()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, udid := range udids {
+		record, err := s.Load(udid)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(record.WiFiMACAddress, mac) {
+			return udid, record, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no pair record with Wi-Fi MAC address %s", mac)
+}
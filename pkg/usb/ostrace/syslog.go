@@ -0,0 +1,230 @@
+package ostrace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+// LogLevel is an os_log entry level
+type LogLevel uint8
+
+const (
+	LevelNotice LogLevel = 0x00
+	LevelInfo   LogLevel = 0x01
+	LevelDebug  LogLevel = 0x02
+	LevelError  LogLevel = 0x10
+	LevelFault  LogLevel = 0x11
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelNotice:
+		return "Notice"
+	case LevelInfo:
+		return "Info"
+	case LevelDebug:
+		return "Debug"
+	case LevelError:
+		return "Error"
+	case LevelFault:
+		return "Fault"
+	default:
+		return fmt.Sprintf("Level(%#x)", uint8(l))
+	}
+}
+
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// SyslogEntry is a device log entry streamed by the os_trace relay
+type SyslogEntry struct {
+	PID       uint32    `json:"pid"`
+	Timestamp time.Time `json:"timestamp"`
+	Level     LogLevel  `json:"level"`
+	Filename  string    `json:"filename"`
+	ImageName string    `json:"image_name,omitempty"`
+	Message   string    `json:"message"`
+	Subsystem string    `json:"subsystem,omitempty"`
+	Category  string    `json:"category,omitempty"`
+}
+
+// ProcessName returns the name of the process that logged the entry
+func (e *SyslogEntry) ProcessName() string {
+	return path.Base(e.Filename)
+}
+
+// syslogHeader is the fixed size header of an os_trace relay log entry
+type syslogHeader struct {
+	_             [9]byte
+	PID           uint32
+	_             [42]byte
+	Seconds       uint32
+	_             [4]byte
+	Microseconds  uint32
+	_             byte
+	Level         LogLevel
+	_             [38]byte
+	ImageNameSize uint16
+	MessageSize   uint16
+	_             [6]byte
+	SubsystemSize uint32
+	CategorySize  uint32
+	_             [4]byte
+}
+
+func cstring(dat []byte) string {
+	if idx := bytes.IndexByte(dat, 0); idx >= 0 {
+		dat = dat[:idx]
+	}
+	return string(dat)
+}
+
+func parseSyslogEntry(dat []byte) (*SyslogEntry, error) {
+	r := bytes.NewReader(dat)
+	var hdr syslogHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to read log entry header: %w", err)
+	}
+	rest := dat[binary.Size(hdr):]
+	idx := bytes.IndexByte(rest, 0)
+	if idx < 0 {
+		return nil, fmt.Errorf("failed to read log entry filename")
+	}
+	entry := &SyslogEntry{
+		PID:       hdr.PID,
+		Timestamp: time.Unix(int64(hdr.Seconds), int64(hdr.Microseconds)*int64(time.Microsecond)),
+		Level:     hdr.Level,
+		Filename:  string(rest[:idx]),
+	}
+	rest = rest[idx+1:]
+	next := func(size int) (string, error) {
+		if size > len(rest) {
+			return "", io.ErrUnexpectedEOF
+		}
+		s := cstring(rest[:size])
+		rest = rest[size:]
+		return s, nil
+	}
+	var err error
+	if entry.ImageName, err = next(int(hdr.ImageNameSize)); err != nil {
+		return nil, fmt.Errorf("failed to read log entry image name: %w", err)
+	}
+	if entry.Message, err = next(int(hdr.MessageSize)); err != nil {
+		return nil, fmt.Errorf("failed to read log entry message: %w", err)
+	}
+	if hdr.SubsystemSize > 0 {
+		if entry.Subsystem, err = next(int(hdr.SubsystemSize)); err != nil {
+			return nil, fmt.Errorf("failed to read log entry subsystem: %w", err)
+		}
+		if entry.Category, err = next(int(hdr.CategorySize)); err != nil {
+			return nil, fmt.Errorf("failed to read log entry category: %w", err)
+		}
+	}
+	entry.Message = strings.TrimRight(entry.Message, "\n")
+	return entry, nil
+}
+
+type startActivityRequest struct {
+	Request       string `plist:"Request"`
+	MessageFilter int    `plist:"MessageFilter"`
+	Pid           int    `plist:"Pid"`
+	StreamFlags   int    `plist:"StreamFlags"`
+}
+
+type startActivityResponse struct {
+	Status string `plist:"Status,omitempty"`
+	Error  string `plist:"Error,omitempty"`
+}
+
+// SyslogStream is a live os_trace relay log stream
+type SyslogStream struct {
+	c *usb.Client
+}
+
+// Syslog starts streaming the device's log entries of the process pid (or all processes if pid is -1)
+func (c *Client) Syslog(pid int) (*SyslogStream, error) {
+	fc, err := lockdownd.NewClientForService(serviceName, c.udid, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fc.Send(&startActivityRequest{
+		Request:       "StartActivity",
+		MessageFilter: 0xffff,
+		Pid:           pid,
+		StreamFlags:   60,
+	}); err != nil {
+		fc.Close()
+		return nil, err
+	}
+
+	// the response is prefixed with the (little endian) size of its big endian length
+	var sizeLen uint32
+	if err := binary.Read(fc.Conn(), binary.LittleEndian, &sizeLen); err != nil {
+		fc.Close()
+		return nil, fmt.Errorf("failed to read start activity response: %w", err)
+	}
+	if sizeLen == 0 || sizeLen > 8 {
+		fc.Close()
+		return nil, fmt.Errorf("invalid start activity response length size: %d", sizeLen)
+	}
+	lenBuf := make([]byte, sizeLen)
+	if _, err := io.ReadFull(fc.Conn(), lenBuf); err != nil {
+		fc.Close()
+		return nil, fmt.Errorf("failed to read start activity response: %w", err)
+	}
+	var size uint64
+	for i := len(lenBuf) - 1; i >= 0; i-- {
+		size = size<<8 | uint64(lenBuf[i])
+	}
+	dat := make([]byte, size)
+	if _, err := io.ReadFull(fc.Conn(), dat); err != nil {
+		fc.Close()
+		return nil, fmt.Errorf("failed to read start activity response: %w", err)
+	}
+	var resp startActivityResponse
+	if _, err := plist.Unmarshal(dat, &resp); err != nil {
+		fc.Close()
+		return nil, fmt.Errorf("failed to parse start activity response: %w", err)
+	}
+	if resp.Status != "RequestSuccessful" {
+		fc.Close()
+		return nil, fmt.Errorf("failed to start activity: %s%s", resp.Status, resp.Error)
+	}
+
+	return &SyslogStream{c: fc}, nil
+}
+
+// Next returns the next log entry
+func (s *SyslogStream) Next() (*SyslogEntry, error) {
+	marker, err := s.c.RecvByte()
+	if err != nil {
+		return nil, err
+	}
+	if marker != 0x02 {
+		return nil, fmt.Errorf("unexpected log entry marker: %#x", marker)
+	}
+	var size uint32
+	if err := binary.Read(s.c.Conn(), binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	dat := make([]byte, size)
+	if _, err := io.ReadFull(s.c.Conn(), dat); err != nil {
+		return nil, err
+	}
+	return parseSyslogEntry(dat)
+}
+
+func (s *SyslogStream) Close() error {
+	return s.c.Close()
+}
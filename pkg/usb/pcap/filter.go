@@ -0,0 +1,344 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Filter is a tcpdump/BPF style capture filter expression, i.e. "tcp port 443 and not host 17.0.0.1"
+//
+// Supported primitives (combined with and/&&, or/||, not/! and parentheses):
+//
+//	ip, ip6, tcp, udp, icmp, icmp6
+//	[src|dst] host <addr>
+//	[src|dst] net <cidr>
+//	[src|dst] port <port>
+//	proc <name>, pid <pid>, iface <name>
+type Filter struct {
+	root node
+}
+
+type node interface {
+	match(*decoded) bool
+}
+
+type decoded struct {
+	p     *Packet
+	proto uint8 // IP protocol
+	ipv6  bool
+	src   net.IP
+	dst   net.IP
+	sport int
+	dport int
+}
+
+const (
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+)
+
+func decode(p *Packet) *decoded {
+	d := &decoded{p: p, sport: -1, dport: -1}
+	data := p.Data
+	var l4 []byte
+	switch {
+	case len(data) >= 20 && data[0]>>4 == 4:
+		ihl := int(data[0]&0x0f) * 4
+		d.proto = data[9]
+		d.src = net.IP(data[12:16])
+		d.dst = net.IP(data[16:20])
+		if ihl >= 20 && ihl <= len(data) {
+			l4 = data[ihl:]
+		}
+	case len(data) >= 40 && data[0]>>4 == 6:
+		d.ipv6 = true
+		d.proto = data[6]
+		d.src = net.IP(data[8:24])
+		d.dst = net.IP(data[24:40])
+		l4 = data[40:]
+	default:
+		return d
+	}
+	if (d.proto == protoTCP || d.proto == protoUDP) && len(l4) >= 4 {
+		d.sport = int(binary.BigEndian.Uint16(l4[0:2]))
+		d.dport = int(binary.BigEndian.Uint16(l4[2:4]))
+	}
+	return d
+}
+
+type andNode struct{ l, r node }
+type orNode struct{ l, r node }
+type notNode struct{ n node }
+type protoNode struct {
+	name string
+}
+type hostNode struct {
+	dir string
+	ip  net.IP
+}
+type netNode struct {
+	dir string
+	net *net.IPNet
+}
+type portNode struct {
+	dir  string
+	port int
+}
+type procNode struct{ name string }
+type pidNode struct{ pid int32 }
+type ifaceNode struct{ name string }
+
+func (n andNode) match(d *decoded) bool { return n.l.match(d) && n.r.match(d) }
+func (n orNode) match(d *decoded) bool  { return n.l.match(d) || n.r.match(d) }
+func (n notNode) match(d *decoded) bool { return !n.n.match(d) }
+
+func (n protoNode) match(d *decoded) bool {
+	switch n.name {
+	case "ip":
+		return d.src != nil && !d.ipv6
+	case "ip6":
+		return d.ipv6
+	case "tcp":
+		return d.proto == protoTCP
+	case "udp":
+		return d.proto == protoUDP
+	case "icmp":
+		return d.proto == protoICMP
+	case "icmp6":
+		return d.proto == protoICMPv6
+	}
+	return false
+}
+
+func matchDir[T any](dir string, src, dst T, fn func(T) bool) bool {
+	switch dir {
+	case "src":
+		return fn(src)
+	case "dst":
+		return fn(dst)
+	default:
+		return fn(src) || fn(dst)
+	}
+}
+
+func (n hostNode) match(d *decoded) bool {
+	return d.src != nil && matchDir(n.dir, d.src, d.dst, n.ip.Equal)
+}
+
+func (n netNode) match(d *decoded) bool {
+	return d.src != nil && matchDir(n.dir, d.src, d.dst, n.net.Contains)
+}
+
+func (n portNode) match(d *decoded) bool {
+	return matchDir(n.dir, d.sport, d.dport, func(p int) bool { return p == n.port })
+}
+
+func (n procNode) match(d *decoded) bool {
+	return strings.HasPrefix(d.p.Process, n.name) || strings.HasPrefix(d.p.SubProcess, n.name)
+}
+
+func (n pidNode) match(d *decoded) bool {
+	return d.p.PID == n.pid || d.p.SubPID == n.pid
+}
+
+func (n ifaceNode) match(d *decoded) bool {
+	return d.p.Interface == n.name
+}
+
+// ParseFilter compiles a filter expression
+func ParseFilter(expr string) (*Filter, error) {
+	p := &parser{toks: tokenize(expr)}
+	if len(p.toks) == 0 {
+		return &Filter{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); len(tok) > 0 {
+		return nil, fmt.Errorf("invalid filter: unexpected '%s'", tok)
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match reports whether the packet matches the filter (an empty filter matches everything)
+func (f *Filter) Match(p *Packet) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.match(decode(p))
+}
+
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ", "!", " ! ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	if len(tok) > 0 {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" || p.peek() == "||" {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orNode{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" || p.peek() == "&&" {
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = andNode{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch tok := p.peek(); tok {
+	case "not", "!":
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case "(":
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("invalid filter: missing ')'")
+		}
+		return n, nil
+	case "":
+		return nil, fmt.Errorf("invalid filter: unexpected end of expression")
+	default:
+		return p.parsePrimitive()
+	}
+}
+
+func (p *parser) arg(kind string) (string, error) {
+	arg := p.next()
+	if len(arg) == 0 {
+		return "", fmt.Errorf("invalid filter: '%s' requires an argument", kind)
+	}
+	return arg, nil
+}
+
+func (p *parser) parsePrimitive() (node, error) {
+	tok := p.next()
+	switch tok {
+	case "ip", "ip6", "tcp", "udp", "icmp", "icmp6":
+		n := node(protoNode{tok})
+		// i.e. "tcp port 443" is shorthand for "tcp and port 443"
+		switch p.peek() {
+		case "src", "dst", "host", "net", "port":
+			q, err := p.parsePrimitive()
+			if err != nil {
+				return nil, err
+			}
+			n = andNode{n, q}
+		}
+		return n, nil
+	case "src", "dst":
+		switch p.peek() {
+		case "host", "net", "port":
+		default:
+			return nil, fmt.Errorf("invalid filter: '%s' must be followed by host, net or port", tok)
+		}
+		return p.parseQualified(tok, p.next())
+	case "host", "net", "port":
+		return p.parseQualified("", tok)
+	case "proc":
+		name, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		return procNode{name}, nil
+	case "pid":
+		arg, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.ParseInt(arg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: bad pid '%s'", arg)
+		}
+		return pidNode{int32(pid)}, nil
+	case "iface":
+		name, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		return ifaceNode{name}, nil
+	default:
+		return nil, fmt.Errorf("invalid filter: unknown primitive '%s'", tok)
+	}
+}
+
+func (p *parser) parseQualified(dir, kind string) (node, error) {
+	arg, err := p.arg(kind)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "host":
+		ip := net.ParseIP(arg)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid filter: bad host address '%s'", arg)
+		}
+		return hostNode{dir, ip}, nil
+	case "net":
+		_, cidr, err := net.ParseCIDR(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: bad net '%s'", arg)
+		}
+		return netNode{dir, cidr}, nil
+	default: // port
+		port, err := strconv.Atoi(arg)
+		if err != nil || port < 0 || port > 0xffff {
+			return nil, fmt.Errorf("invalid filter: bad port '%s'", arg)
+		}
+		return portNode{dir, port}, nil
+	}
+}
@@ -0,0 +1,51 @@
+package pcap
+
+import (
+	"testing"
+)
+
+func tcpPacket(src, dst [4]byte, sport, dport uint16) *Packet {
+	data := make([]byte, 40)
+	data[0] = 0x45
+	data[9] = protoTCP
+	copy(data[12:16], src[:])
+	copy(data[16:20], dst[:])
+	data[20], data[21] = byte(sport>>8), byte(sport)
+	data[22], data[23] = byte(dport>>8), byte(dport)
+	return &Packet{Data: data, Process: "nsurlsessiond", PID: 123, Interface: "en0"}
+}
+
+func TestFilter_Match(t *testing.T) {
+	p := tcpPacket([4]byte{192, 168, 1, 2}, [4]byte{17, 253, 144, 10}, 51234, 443)
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"tcp", true},
+		{"udp", false},
+		{"tcp port 443", true},
+		{"src port 443", false},
+		{"dst port 443 and host 17.253.144.10", true},
+		{"net 17.0.0.0/8 and not iface pdp_ip0", true},
+		{"src net 17.0.0.0/8", false},
+		{"udp or (proc nsurl && pid 123)", true},
+		{"ip6 || !tcp", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			if got := f.Match(p); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	for _, expr := range []string{"port", "tcp and", "(tcp", "host nope", "foo"} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package pcap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	mbits "math/bits"
+	"strings"
+	"time"
+
+	"github.com/blacktop/go-plist"
+)
+
+const ethernetHeaderSize = 14
+
+// Packet is a packet captured by pcapd along with the metadata about the process that sent/received it
+type Packet struct {
+	Header     IOSPacketHeader
+	Timestamp  time.Time
+	Interface  string
+	Process    string
+	PID        int32
+	SubProcess string
+	SubPID     int32
+	// Data is the network layer (IPv4/IPv6) packet
+	Data []byte
+	// Frame is the packet as an ethernet frame (a fake ethernet header is added to packets from non-ethernet interfaces)
+	Frame []byte
+}
+
+// Comment describes the process(es) that sent/received the packet
+func (p *Packet) Comment() string {
+	var s string
+	if len(p.Process) > 0 {
+		s = fmt.Sprintf("%s[%d]", p.Process, p.PID)
+	}
+	if len(p.SubProcess) > 0 && (p.SubProcess != p.Process || p.SubPID != p.PID) {
+		s += fmt.Sprintf(" via %s[%d]", p.SubProcess, p.SubPID)
+	}
+	return s
+}
+
+func cstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func parsePacket(data []byte) (*Packet, error) {
+	var hdr IOSPacketHeader
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to read packet header: %w", err)
+	}
+	if int(hdr.HdrLength) > len(data) {
+		return nil, fmt.Errorf("invalid packet header length %d", hdr.HdrLength)
+	}
+	frame := data[hdr.HdrLength:]
+	if hdr.PktLength > 0 && int(hdr.PktLength) < len(frame) {
+		frame = frame[:hdr.PktLength]
+	}
+	if int(hdr.FramePreLength) > len(frame) {
+		return nil, fmt.Errorf("invalid packet frame pre-length %d", hdr.FramePreLength)
+	}
+
+	p := &Packet{
+		Header:     hdr,
+		Timestamp:  time.Unix(int64(hdr.Seconds), int64(hdr.MicroSeconds)*int64(time.Microsecond)),
+		Interface:  cstring(hdr.InterfaceName[:]),
+		Process:    cstring(hdr.ProcName[:]),
+		PID:        int32(mbits.ReverseBytes32(hdr.Pid)),
+		SubProcess: cstring(hdr.SubProcName[:]),
+		SubPID:     int32(mbits.ReverseBytes32(hdr.SubPid)),
+		Data:       frame[hdr.FramePreLength:],
+	}
+
+	if hdr.FramePreLength == ethernetHeaderSize {
+		p.Frame = frame
+	} else {
+		p.Frame = make([]byte, 0, ethernetHeaderSize+len(p.Data))
+		p.Frame = append(p.Frame, ethernetHeader[:12]...)
+		if hdr.ProtocolFamily == IPv6 {
+			p.Frame = append(p.Frame, 0x86, 0xdd)
+		} else {
+			p.Frame = append(p.Frame, 0x08, 0x00)
+		}
+		p.Frame = append(p.Frame, p.Data...)
+	}
+
+	return p, nil
+}
+
+// Packets streams captured packets to fn until ctx is canceled
+func (c *Client) Packets(ctx context.Context, fn func(*Packet) error) error {
+	// unblock the pending read when the capture is stopped
+	stop := context.AfterFunc(ctx, func() {
+		c.c.Close()
+	})
+	defer stop()
+
+	for {
+		bs, err := c.c.RecvBytes()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var data []byte
+		if _, err := plist.Unmarshal(bs, &data); err != nil {
+			return fmt.Errorf("failed to parse pcapd message: %w", err)
+		}
+		p, err := parsePacket(data)
+		if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+}
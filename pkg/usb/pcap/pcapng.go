@@ -0,0 +1,114 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// pcapng block types and options (https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html)
+const (
+	blockSectionHeader    = 0x0a0d0d0a
+	blockInterfaceDesc    = 0x00000001
+	blockEnhancedPacket   = 0x00000006
+	byteOrderMagic        = 0x1a2b3c4d
+	optEndOfOpt           = 0
+	optComment            = 1
+	optSHBUserAppl        = 4
+	optIfName             = 2
+	defaultSnapLen        = 0x40000
+	pcapngUnknownSecLen   = -1
+	pcapngVersionMajor    = 1
+	pcapngVersionMinor    = 0
+	pcapngBlockHeaderSize = 12 // type + 2x total length
+)
+
+// NGWriter writes packets as a pcapng capture (one interface description per device interface)
+type NGWriter struct {
+	w      io.Writer
+	ifaces map[string]uint32
+}
+
+// NewNGWriter writes the pcapng section header to w
+func NewNGWriter(w io.Writer) (*NGWriter, error) {
+	body := new(bytes.Buffer)
+	binary.Write(body, binary.LittleEndian, uint32(byteOrderMagic))
+	binary.Write(body, binary.LittleEndian, uint16(pcapngVersionMajor))
+	binary.Write(body, binary.LittleEndian, uint16(pcapngVersionMinor))
+	binary.Write(body, binary.LittleEndian, int64(pcapngUnknownSecLen))
+	writeOption(body, optSHBUserAppl, []byte("ipsw"))
+	writeOption(body, optEndOfOpt, nil)
+
+	ng := &NGWriter{w: w, ifaces: make(map[string]uint32)}
+	if err := ng.writeBlock(blockSectionHeader, body.Bytes()); err != nil {
+		return nil, err
+	}
+	return ng, nil
+}
+
+func writeOption(w *bytes.Buffer, code uint16, val []byte) {
+	binary.Write(w, binary.LittleEndian, code)
+	binary.Write(w, binary.LittleEndian, uint16(len(val)))
+	w.Write(val)
+	w.Write(make([]byte, pad4(len(val))))
+}
+
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
+
+func (ng *NGWriter) writeBlock(typ uint32, body []byte) error {
+	total := uint32(pcapngBlockHeaderSize + len(body))
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, typ)
+	binary.Write(buf, binary.LittleEndian, total)
+	buf.Write(body)
+	binary.Write(buf, binary.LittleEndian, total)
+	_, err := ng.w.Write(buf.Bytes())
+	return err
+}
+
+func (ng *NGWriter) iface(name string) (uint32, error) {
+	if id, ok := ng.ifaces[name]; ok {
+		return id, nil
+	}
+	body := new(bytes.Buffer)
+	binary.Write(body, binary.LittleEndian, uint16(LinkTypeEthernet))
+	binary.Write(body, binary.LittleEndian, uint16(0)) // reserved
+	binary.Write(body, binary.LittleEndian, uint32(defaultSnapLen))
+	if len(name) > 0 {
+		writeOption(body, optIfName, []byte(name))
+	}
+	writeOption(body, optEndOfOpt, nil)
+	if err := ng.writeBlock(blockInterfaceDesc, body.Bytes()); err != nil {
+		return 0, err
+	}
+	id := uint32(len(ng.ifaces))
+	ng.ifaces[name] = id
+	return id, nil
+}
+
+// WritePacket writes the packet's ethernet frame annotated with its process
+func (ng *NGWriter) WritePacket(p *Packet) error {
+	id, err := ng.iface(p.Interface)
+	if err != nil {
+		return err
+	}
+	ts := uint64(p.Timestamp.UnixMicro())
+
+	body := new(bytes.Buffer)
+	binary.Write(body, binary.LittleEndian, id)
+	binary.Write(body, binary.LittleEndian, uint32(ts>>32))
+	binary.Write(body, binary.LittleEndian, uint32(ts))
+	binary.Write(body, binary.LittleEndian, uint32(len(p.Frame)))
+	binary.Write(body, binary.LittleEndian, uint32(len(p.Frame)))
+	body.Write(p.Frame)
+	body.Write(make([]byte, pad4(len(p.Frame))))
+
+	if comment := p.Comment(); len(comment) > 0 {
+		writeOption(body, optComment, []byte(comment))
+	}
+	writeOption(body, optEndOfOpt, nil)
+
+	return ng.writeBlock(blockEnhancedPacket, body.Bytes())
+}
@@ -0,0 +1,155 @@
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/pkg/usb"
+)
+
+const (
+	asrPort              = 12345
+	asrPayloadChunkSize  = 0x20000
+	asrChecksumChunkSize = 0x20000
+)
+
+// asrClient streams the root filesystem to the device with the Apple Software Restore protocol
+// (unframed XML plists followed by the raw payload)
+type asrClient struct {
+	conn           net.Conn
+	r              *bufio.Reader
+	checksumChunks bool
+}
+
+func newASRClient(udid string) (*asrClient, error) {
+	cli, err := usb.NewClient(udid, asrPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ASR: %w", err)
+	}
+	a := &asrClient{conn: cli.Conn(), r: bufio.NewReader(cli.Conn())}
+
+	msg, err := a.recv()
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("failed to receive ASR initiate packet: %w", err)
+	}
+	if cmd, _ := msg["Command"].(string); cmd != "Initiate" {
+		a.Close()
+		return nil, fmt.Errorf("unexpected ASR command: %v", msg["Command"])
+	}
+	a.checksumChunks, _ = msg["Checksum Chunks"].(bool)
+
+	return a, nil
+}
+
+func (a *asrClient) recv() (map[string]any, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := a.r.ReadBytes('\n')
+		buf.Write(line)
+		if bytes.Contains(line, []byte("</plist>")) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	var msg map[string]any
+	if _, err := plist.Unmarshal(buf.Bytes(), &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse ASR packet: %w", err)
+	}
+	return msg, nil
+}
+
+func (a *asrClient) send(msg any) error {
+	data, err := plist.Marshal(msg, plist.XMLFormat)
+	if err != nil {
+		return err
+	}
+	_, err = a.conn.Write(data)
+	return err
+}
+
+// Send streams the filesystem image of length size to the device
+func (a *asrClient) Send(img io.ReaderAt, size int64) error {
+	info := map[string]any{
+		"FEC Slice Stride":    40,
+		"Packet Payload Size": 1450,
+		"Packets Per FEC":     25,
+		"Payload": map[string]any{
+			"Port": 1,
+			"Size": size,
+		},
+		"Stream ID": 1,
+		"Version":   1,
+	}
+	if a.checksumChunks {
+		info["Checksum Chunk Size"] = asrChecksumChunkSize
+	}
+	if err := a.send(info); err != nil {
+		return fmt.Errorf("failed to send ASR payload info: %w", err)
+	}
+
+	// the device validates the image by requesting out-of-band chunks before asking for the payload
+	for {
+		msg, err := a.recv()
+		if err != nil {
+			return fmt.Errorf("failed to receive ASR validation request: %w", err)
+		}
+		cmd, _ := msg["Command"].(string)
+		if cmd == "Payload" {
+			break
+		}
+		if cmd != "OOBData" {
+			return fmt.Errorf("unexpected ASR command: %s", cmd)
+		}
+		off, _ := msg["OOB Offset"].(uint64)
+		length, _ := msg["OOB Length"].(uint64)
+		oob := make([]byte, length)
+		if _, err := img.ReadAt(oob, int64(off)); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read ASR OOB data at %#x: %w", off, err)
+		}
+		if _, err := a.conn.Write(oob); err != nil {
+			return fmt.Errorf("failed to send ASR OOB data: %w", err)
+		}
+	}
+
+	log.Info("Sending filesystem")
+	chunk := make([]byte, asrPayloadChunkSize)
+	var pct int64 = -1
+	for off := int64(0); off < size; {
+		n, err := img.ReadAt(chunk[:min(int64(len(chunk)), size-off)], off)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read filesystem at %#x: %w", off, err)
+		}
+		if n == 0 {
+			return fmt.Errorf("short filesystem read at %#x", off)
+		}
+		if _, err := a.conn.Write(chunk[:n]); err != nil {
+			return fmt.Errorf("failed to send filesystem: %w", err)
+		}
+		if a.checksumChunks {
+			sum := sha1.Sum(chunk[:n])
+			if _, err := a.conn.Write(sum[:]); err != nil {
+				return fmt.Errorf("failed to send filesystem chunk checksum: %w", err)
+			}
+		}
+		off += int64(n)
+		if p := off * 100 / size; p/10 != pct/10 {
+			pct = p
+			log.Infof("Sent %d%% of filesystem", p)
+		}
+	}
+
+	return nil
+}
+
+func (a *asrClient) Close() error {
+	return a.conn.Close()
+}
@@ -0,0 +1,261 @@
+//go:build libusb
+
+package restore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/irecv"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+const (
+	recoveryTimeout = 2 * time.Minute
+	restoreTimeout  = 5 * time.Minute
+	// give the device time to drop off the bus before polling for it again
+	reenumerateDelay = 3 * time.Second
+)
+
+// waitForRecovery waits for a device in Recovery/DFU mode
+func waitForRecovery(ctx context.Context) (*irecv.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, recoveryTimeout)
+	defer cancel()
+	for {
+		if cli, err := irecv.NewClient(); err == nil {
+			return cli, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for a Recovery/DFU mode device")
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// normalModeUDID returns the UDID of the normal mode device to restore
+func (r *Restore) normalModeUDID() (string, error) {
+	if len(r.conf.UDID) > 0 {
+		return r.conf.UDID, nil
+	}
+	conn, err := usb.NewConn()
+	if err != nil {
+		return "", err
+	}
+	devices, err := conn.ListDevices()
+	conn.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to list devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return "", fmt.Errorf("no device found in normal, Recovery or DFU mode")
+	}
+	return devices[0].SerialNumber, nil
+}
+
+// enterRecovery puts a normal mode device into recovery mode via lockdownd
+func (r *Restore) enterRecovery() error {
+	udid, err := r.normalModeUDID()
+	if err != nil {
+		return err
+	}
+	ldc, err := lockdownd.NewClient(udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+	log.WithField("udid", udid).Info("Entering recovery mode")
+	if _, err := ldc.EnterRecovery(); err != nil {
+		return fmt.Errorf("failed to enter recovery: %w", err)
+	}
+	return nil
+}
+
+// Check selects the build identity for the connected device (without putting a normal mode device into recovery)
+// so that unsupported devices are refused before the restore is started
+func (r *Restore) Check() error {
+	if cli, err := irecv.NewClient(); err == nil {
+		r.device = deviceFromRecovery(cli)
+		cli.Close()
+		return r.selectIdentity()
+	}
+	udid, err := r.normalModeUDID()
+	if err != nil {
+		return err
+	}
+	ldc, err := lockdownd.NewClient(udid)
+	if err != nil {
+		return fmt.Errorf("failed to connect to lockdownd: %w", err)
+	}
+	defer ldc.Close()
+	values, err := ldc.GetValues()
+	if err != nil {
+		return fmt.Errorf("failed to get device values: %w", err)
+	}
+	r.device = &Device{
+		ECID:    uint64(values.UniqueChipID),
+		ChipID:  uint64(values.ChipID),
+		BoardID: uint64(values.BoardID),
+	}
+	return r.selectIdentity()
+}
+
+func deviceFromRecovery(cli *irecv.Client) *Device {
+	cpfm := parseHex(cli.CPFM)
+	return &Device{
+		ECID:           parseHex(cli.ECID),
+		ChipID:         parseHex(cli.CPID),
+		BoardID:        parseHex(cli.BDID),
+		SecurityDomain: parseHex(cli.SDOM),
+		ProductionMode: cpfm&0x1 != 0,
+		SecurityMode:   cpfm&0x2 != 0,
+		ApNonce:        cli.ApNonce,
+		SepNonce:       cli.SEPNonce,
+	}
+}
+
+// send uploads a personalized component and optionally runs an iBoot command
+func (r *Restore) send(cli *irecv.Client, name, command string) error {
+	data, err := r.stitch(name)
+	if err != nil {
+		return err
+	}
+	log.WithField("mode", cli.Mode).Infof("Sending %s", name)
+	if err := cli.SendBuffer(data, nil); err != nil {
+		return fmt.Errorf("failed to send %s: %w", name, err)
+	}
+	if len(command) > 0 {
+		if err := cli.SendCommand(command); err != nil {
+			return fmt.Errorf("failed to send '%s' command: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// reconnect waits for the device to re-enumerate after (re)booting a stage of the boot chain
+func reconnect(ctx context.Context, cli *irecv.Client) (*irecv.Client, error) {
+	cli.Close()
+	time.Sleep(reenumerateDelay)
+	return waitForRecovery(ctx)
+}
+
+// Run restores the IPSW onto the device
+func (r *Restore) Run(ctx context.Context) error {
+	cli, err := irecv.NewClient()
+	if err != nil {
+		if err := r.enterRecovery(); err != nil {
+			return err
+		}
+		time.Sleep(reenumerateDelay)
+		if cli, err = waitForRecovery(ctx); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if cli != nil {
+			cli.Close()
+		}
+	}()
+
+	r.device = deviceFromRecovery(cli)
+	log.WithFields(log.Fields{
+		"mode": cli.Mode,
+		"ecid": fmt.Sprintf("%#x", r.device.ECID),
+		"cpid": fmt.Sprintf("%#x", r.device.ChipID),
+		"bdid": fmt.Sprintf("%#x", r.device.BoardID),
+	}).Info("Found device")
+
+	if err := r.selectIdentity(); err != nil {
+		return err
+	}
+	if err := r.personalize(); err != nil {
+		return err
+	}
+
+	// DFU: SecureROM loads iBSS which then waits (in recovery mode) for iBEC
+	if !cli.Mode.IsRecovery() {
+		if err := r.send(cli, "iBSS", ""); err != nil {
+			return err
+		}
+		if cli, err = reconnect(ctx, cli); err != nil {
+			return err
+		}
+	}
+	if err := cli.SetAutoboot(false); err != nil {
+		log.WithError(err).Debug("failed to disable auto-boot")
+	}
+	if err := r.send(cli, "iBEC", "go"); err != nil {
+		return err
+	}
+	if cli, err = reconnect(ctx, cli); err != nil {
+		return err
+	}
+
+	// iBEC generates a fresh nonce so the components it loads need a new ticket
+	if dev := deviceFromRecovery(cli); !bytes.Equal(dev.ApNonce, r.device.ApNonce) {
+		log.Debug("ApNonce changed after iBEC (re-personalizing)")
+		r.device = dev
+		if err := r.personalize(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.bootRestoreRamdisk(cli); err != nil {
+		return err
+	}
+	cli.Close()
+	cli = nil
+
+	log.Info("Waiting for device to boot the restore ramdisk")
+	rd, err := waitForRestored(ctx, restoreTimeout)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+
+	return r.runRestored(ctx, rd)
+}
+
+// bootRestoreRamdisk loads the restore ramdisk, its firmware and the restore kernelcache from iBEC
+func (r *Restore) bootRestoreRamdisk(cli *irecv.Client) error {
+	if err := cli.SetAutoboot(false); err != nil {
+		return fmt.Errorf("failed to disable auto-boot: %w", err)
+	}
+	if err := cli.Setenv("boot-args", r.bootArgs(), false); err != nil {
+		return fmt.Errorf("failed to set boot-args: %w", err)
+	}
+
+	for _, name := range r.componentsWith("IsLoadedByiBoot") {
+		if stage1, _ := r.componentInfo(name)["IsLoadedByiBootStage1"].(bool); stage1 {
+			continue
+		}
+		if err := r.send(cli, name, "firmware"); err != nil {
+			return err
+		}
+	}
+
+	if err := r.send(cli, "RestoreRamDisk", "ramdisk"); err != nil {
+		return err
+	}
+	// iBoot needs a moment to process the ramdisk before the next upload
+	time.Sleep(2 * time.Second)
+
+	if r.hasComponent("RestoreTrustCache") {
+		if err := r.send(cli, "RestoreTrustCache", "firmware"); err != nil {
+			return err
+		}
+	}
+	if err := r.send(cli, "RestoreDeviceTree", "devicetree"); err != nil {
+		return err
+	}
+	if r.hasComponent("RestoreSEP") {
+		if err := r.send(cli, "RestoreSEP", "rsepfirmware"); err != nil {
+			return err
+		}
+	}
+	return r.send(cli, "RestoreKernelCache", "bootx")
+}
@@ -0,0 +1,311 @@
+// Package restore implements the device restore/upgrade flow (an idevicerestore equivalent):
+// TSS personalization, the Recovery/DFU boot chain and driving restored
+package restore
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-plist"
+	"github.com/blacktop/ipsw/pkg/img4"
+	info "github.com/blacktop/ipsw/pkg/plist"
+	"github.com/blacktop/ipsw/pkg/tss"
+)
+
+// Config is the restore configuration
+type Config struct {
+	// IPSW is the path to the firmware to restore
+	IPSW string
+	// UDID of a normal mode device to put into recovery mode (defaults to the first connected device)
+	UDID string
+	// Update preserves user data (uses the 'Update' instead of the 'Erase' build identity)
+	Update   bool
+	Proxy    string
+	Insecure bool
+}
+
+// Restore is a restore of an IPSW onto a device
+type Restore struct {
+	conf     *Config
+	zr       *zip.ReadCloser
+	manifest *info.BuildManifest
+	// raw BuildIdentities as TSS requests copy entries verbatim
+	identities []map[string]any
+
+	identity int
+	ticket   []byte
+	device   *Device
+}
+
+// Device is the identity of the device being restored as reported in Recovery/DFU mode
+type Device struct {
+	ECID           uint64
+	ChipID         uint64
+	BoardID        uint64
+	SecurityDomain uint64
+	ProductionMode bool
+	SecurityMode   bool
+	ApNonce        []byte
+	SepNonce       []byte
+}
+
+// restore components whose IM4P type must be changed to match their manifest entry
+var restoreTypes = map[string]string{
+	"RestoreKernelCache":                   "rkrn",
+	"RestoreDeviceTree":                    "rdtr",
+	"RestoreSEP":                           "rsep",
+	"RestoreLogo":                          "rlgo",
+	"RestoreTrustCache":                    "rtsc",
+	"RestoreDCP":                           "rdcp",
+	"Ap,RestoreTMU":                        "rtmu",
+	"Ap,RestoreCIO":                        "rcio",
+	"Ap,DCP2":                              "dcp2",
+	"Ap,RestoreSecureM3Firmware":           "rsm3",
+	"Ap,RestoreSecurePageTableMonitor":     "rspt",
+	"Ap,RestoreTrustedExecutionMonitor":    "rtrx",
+	"Ap,RestorecL4":                        "rxcl",
+	"Ap,RestoreSecureManifestHashRegistry": "rsmh",
+}
+
+// manifest entry prefixes of the firmware restored updates via FirmwareUpdaterData
+var firmwareUpdaters = map[string]string{
+	"Savage,":  "Savage",
+	"Yonkers,": "Yonkers",
+	"Rap,":     "Rose",
+	"BMU,":     "Veridian",
+}
+
+// New opens the IPSW and parses its BuildManifest
+func New(conf *Config) (*Restore, error) {
+	zr, err := zip.OpenReader(conf.IPSW)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IPSW %s: %w", conf.IPSW, err)
+	}
+	r := &Restore{conf: conf, zr: zr, identity: -1}
+
+	data, err := r.readFile("BuildManifest.plist")
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	r.manifest, err = info.ParseBuildManifest(data)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	var raw struct {
+		BuildIdentities []map[string]any `plist:"BuildIdentities"`
+	}
+	if _, err := plist.Unmarshal(data, &raw); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("failed to parse BuildManifest.plist: %w", err)
+	}
+	if len(raw.BuildIdentities) != len(r.manifest.BuildIdentities) {
+		zr.Close()
+		return nil, fmt.Errorf("failed to parse BuildManifest.plist build identities")
+	}
+	r.identities = raw.BuildIdentities
+
+	return r, nil
+}
+
+// Close closes the IPSW
+func (r *Restore) Close() error {
+	return r.zr.Close()
+}
+
+func (r *Restore) readFile(name string) ([]byte, error) {
+	for _, f := range r.zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s in IPSW: %w", name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in IPSW", name)
+}
+
+func parseHex(s string) uint64 {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 64)
+	return v
+}
+
+// selectIdentity picks the build identity matching the device and restore behavior
+func (r *Restore) selectIdentity() error {
+	behavior := "Erase"
+	if r.conf.Update {
+		behavior = "Update"
+	}
+	r.identity = -1
+	for i, bi := range r.manifest.BuildIdentities {
+		if parseHex(bi.ApChipID) != r.device.ChipID || parseHex(bi.ApBoardID) != r.device.BoardID {
+			continue
+		}
+		if bi.Info.RestoreBehavior != behavior {
+			continue
+		}
+		// prefer the customer variants over research/internal ones
+		if r.identity < 0 || (strings.Contains(bi.Info.Variant, "Customer") && !strings.Contains(bi.Info.Variant, "Research")) {
+			r.identity = i
+		}
+	}
+	if r.identity < 0 {
+		return fmt.Errorf("no %s build identity in IPSW for device (ChipID %#x, BoardID %#x)", behavior, r.device.ChipID, r.device.BoardID)
+	}
+	bi := r.manifest.BuildIdentities[r.identity]
+	log.WithFields(log.Fields{
+		"build":   bi.Info.BuildNumber,
+		"device":  bi.Info.DeviceClass,
+		"variant": bi.Info.Variant,
+	}).Info("Selected build identity")
+	// restored is told not to update the baseband (there is no BBFW personalization) which would leave it mismatched
+	if _, ok := r.manifestEntries()["BasebandFirmware"]; ok || bi.BbChipID != "" {
+		return fmt.Errorf("devices with a baseband are not supported (%s has BbChipID %s)", bi.Info.DeviceClass, bi.BbChipID)
+	}
+	// restored requests these over FirmwareUpdaterData which needs their own TSS personalization
+	for name := range r.manifestEntries() {
+		for prefix, fw := range firmwareUpdaters {
+			if strings.HasPrefix(name, prefix) {
+				return fmt.Errorf("devices with %s firmware are not supported (%s has %s)", fw, bi.Info.DeviceClass, name)
+			}
+		}
+	}
+	return nil
+}
+
+// personalize requests the ApImg4Ticket for the current device nonces
+func (r *Restore) personalize() error {
+	log.Info("Requesting TSS personalization")
+	blob, err := tss.PersonalizeRestore(&tss.RestoreConfig{
+		ECID:             r.device.ECID,
+		ApBoardID:        r.device.BoardID,
+		ApChipID:         r.device.ChipID,
+		ApSecurityDomain: r.device.SecurityDomain,
+		ApNonce:          r.device.ApNonce,
+		SepNonce:         r.device.SepNonce,
+		ProductionMode:   r.device.ProductionMode,
+		SecurityMode:     r.device.SecurityMode,
+		Identity:         r.identities[r.identity],
+		Proxy:            r.conf.Proxy,
+		Insecure:         r.conf.Insecure,
+	})
+	if err != nil {
+		return err
+	}
+	if len(blob.ApImg4Ticket) == 0 {
+		return fmt.Errorf("TSS response does not contain an ApImg4Ticket")
+	}
+	r.ticket = blob.ApImg4Ticket
+	return nil
+}
+
+// manifestEntries returns the build identity's raw manifest
+func (r *Restore) manifestEntries() map[string]any {
+	m, _ := r.identities[r.identity]["Manifest"].(map[string]any)
+	return m
+}
+
+func (r *Restore) componentInfo(name string) map[string]any {
+	entry, _ := r.manifestEntries()[name].(map[string]any)
+	info, _ := entry["Info"].(map[string]any)
+	return info
+}
+
+func (r *Restore) hasComponent(name string) bool {
+	path, _ := r.componentInfo(name)["Path"].(string)
+	return len(path) > 0
+}
+
+// componentData returns the (unpersonalized) IM4P of a build identity component
+func (r *Restore) componentData(name string) ([]byte, error) {
+	path, _ := r.componentInfo(name)["Path"].(string)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("component %s not found in build identity", name)
+	}
+	return r.readFile(path)
+}
+
+// stitch returns the component personalized as an IMG4 with the TSS ticket
+func (r *Restore) stitch(name string) ([]byte, error) {
+	data, err := r.componentData(name)
+	if err != nil {
+		return nil, err
+	}
+	if typ, ok := restoreTypes[name]; ok {
+		if data, err = img4.SetIm4pType(data, typ); err != nil {
+			return nil, fmt.Errorf("failed to set %s IM4P type: %w", name, err)
+		}
+	}
+	out, err := img4.Create(data, r.ticket, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to personalize %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// componentsWith returns the names of the build identity components with the boolean Info key set
+func (r *Restore) componentsWith(key string) []string {
+	var names []string
+	for name := range r.manifestEntries() {
+		if set, _ := r.componentInfo(name)[key].(bool); set {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// systemImage returns a reader for the root filesystem DMG
+func (r *Restore) systemImage() (io.ReaderAt, int64, func(), error) {
+	path, _ := r.componentInfo("OS")["Path"].(string)
+	if len(path) == 0 {
+		return nil, 0, nil, fmt.Errorf("build identity has no OS component")
+	}
+	for _, f := range r.zr.File {
+		if f.Name != path {
+			continue
+		}
+		// IPSWs store the DMGs uncompressed so ASR can seek in them directly
+		if f.Method == zip.Store {
+			off, err := f.DataOffset()
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			zf, err := os.Open(r.conf.IPSW)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			return io.NewSectionReader(zf, off, int64(f.UncompressedSize64)), int64(f.UncompressedSize64), func() { zf.Close() }, nil
+		}
+		log.Infof("Extracting %s", path)
+		tmp, err := os.CreateTemp("", "ipsw_restore_*.dmg")
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		cleanup := func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+		rc, err := f.Open()
+		if err != nil {
+			cleanup()
+			return nil, 0, nil, err
+		}
+		defer rc.Close()
+		if _, err := io.Copy(tmp, rc); err != nil {
+			cleanup()
+			return nil, 0, nil, fmt.Errorf("failed to extract %s: %w", path, err)
+		}
+		return tmp, int64(f.UncompressedSize64), cleanup, nil
+	}
+	return nil, 0, nil, fmt.Errorf("%s not found in IPSW", path)
+}
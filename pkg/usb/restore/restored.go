@@ -0,0 +1,465 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/google/uuid"
+)
+
+const (
+	restoredPort            = 62078
+	restoredServiceType     = "com.apple.mobile.restored"
+	restoreProtocolVersion  = 14
+	bootObjectChunkSize     = 0x2000
+	restoredConnectInterval = 2 * time.Second
+)
+
+// data types restored may request (true if handled)
+var supportedDataTypes = map[string]bool{
+	"BasebandBootData":                   false,
+	"BasebandData":                       false,
+	"BasebandStackData":                  false,
+	"BasebandUpdaterOutputData":          false,
+	"BootabilityBundle":                  false,
+	"BuildIdentityDict":                  true,
+	"BuildIdentityDictV2":                true,
+	"DataType":                           false,
+	"DiagData":                           false,
+	"EANData":                            false,
+	"FDRMemoryCommit":                    false,
+	"FDRTrustData":                       true,
+	"FUDData":                            true,
+	"FileData":                           false,
+	"FileDataDone":                       false,
+	"FirmwareUpdaterData":                false,
+	"FirmwareUpdaterPreflight":           true,
+	"GrapeFWData":                        false,
+	"HPMFWData":                          false,
+	"HostSystemTime":                     true,
+	"KernelCache":                        true,
+	"NORData":                            true,
+	"NitrogenFWData":                     false,
+	"OpalFWData":                         false,
+	"OverlayRootDataCount":               false,
+	"OverlayRootDataForKey":              false,
+	"PeppyFWData":                        false,
+	"PersonalizedBootObjectV3":           true,
+	"PersonalizedData":                   false,
+	"ProvisioningData":                   false,
+	"RamdiskFWData":                      false,
+	"RecoveryOSASRImage":                 false,
+	"RecoveryOSAppleLogo":                false,
+	"RecoveryOSDeviceTree":               false,
+	"RecoveryOSFileAssetImage":           false,
+	"RecoveryOSIBEC":                     false,
+	"RecoveryOSIBootFWFilesImages":       false,
+	"RecoveryOSImage":                    false,
+	"RecoveryOSKernelCache":              false,
+	"RecoveryOSLocalPolicy":              false,
+	"RecoveryOSOverlayRootDataCount":     false,
+	"RecoveryOSRootTicketData":           false,
+	"RecoveryOSStaticTrustCache":         false,
+	"RecoveryOSVersionData":              false,
+	"RootData":                           false,
+	"RootTicket":                         true,
+	"S3EOverride":                        false,
+	"SourceBootObjectV3":                 true,
+	"SourceBootObjectV4":                 true,
+	"SsoServiceTicket":                   false,
+	"StockholmPostflight":                false,
+	"SystemImageCanonicalMetadata":       false,
+	"SystemImageData":                    true,
+	"SystemImageRootHash":                false,
+	"USBCFWData":                         false,
+	"USBCOverride":                       false,
+	"FirmwareUpdaterDataV2":              false,
+	"RestoreLocalPolicy":                 false,
+	"AuthInstallCACert":                  false,
+	"OverrideAuthInstallRequestsWithTss": false,
+}
+
+var supportedMessageTypes = map[string]bool{
+	"BBUpdateStatusMsg":      false,
+	"CheckpointMsg":          true,
+	"DataRequestMsg":         false,
+	"FDRSubmit":              true,
+	"MsgType":                false,
+	"PreviousRestoreLogMsg":  false,
+	"ProgressMsg":            false,
+	"ProvisioningAck":        false,
+	"ProvisioningInfo":       false,
+	"ProvisioningStatusMsg":  false,
+	"ReceivedFinalStatusMsg": false,
+	"RestoredCrash":          true,
+	"StatusMsg":              false,
+}
+
+// restored operation codes reported in ProgressMsg
+var restoreOperations = map[int]string{
+	11: "Creating partition map",
+	12: "Creating filesystem",
+	13: "Restoring image",
+	14: "Verifying restore",
+	15: "Checking filesystems",
+	16: "Mounting filesystems",
+	17: "Fixing up /var",
+	18: "Flashing firmware",
+	19: "Requesting FUD data",
+	20: "Updating baseband",
+	21: "Setting CPU frequency",
+	22: "Unmounting filesystems",
+	25: "Modifying persistent boot-args",
+	27: "Waiting for NAND",
+	28: "Unmounting filesystems",
+	29: "Flashing NOR",
+	35: "Loading NOR data to flash",
+	36: "Flashing NOR",
+	41: "Updating baseband",
+	46: "Creating system key",
+	48: "Installing kernelcache",
+	49: "Installing SEP firmware",
+	51: "Creating protected volume",
+	53: "Resizing system partition",
+	58: "Loading kernelcache",
+	59: "Loading boot objects",
+	61: "Updating gas gauge software",
+	67: "Preparing for baseband update",
+	70: "Reconfiguring display",
+	74: "Verifying restored filesystem",
+	76: "Installing firmware",
+}
+
+// restored is a connection to the restore ramdisk's restored daemon
+type restored struct {
+	c    *usb.Client
+	udid string
+}
+
+// waitForRestored waits for a device running the restore ramdisk to appear on usbmuxd
+func waitForRestored(ctx context.Context, timeout time.Duration) (*restored, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		if rd, err := findRestored(); err == nil {
+			return rd, nil
+		} else {
+			log.WithError(err).Debug("waiting for restore mode device")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for device to boot the restore ramdisk")
+		case <-time.After(restoredConnectInterval):
+		}
+	}
+}
+
+type queryTypeRequest struct {
+	Label   string `plist:"Label"`
+	Request string `plist:"Request"`
+}
+
+type queryTypeResponse struct {
+	Type                   string `plist:"Type"`
+	RestoreProtocolVersion int    `plist:"RestoreProtocolVersion,omitempty"`
+}
+
+func findRestored() (*restored, error) {
+	conn, err := usb.NewConn()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := conn.ListDevices()
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		cli, err := usb.NewClient(dev.SerialNumber, restoredPort)
+		if err != nil {
+			continue
+		}
+		var resp queryTypeResponse
+		if err := cli.Request(&queryTypeRequest{Label: "ipsw", Request: "QueryType"}, &resp); err != nil || resp.Type != restoredServiceType {
+			cli.Close()
+			continue
+		}
+		log.WithFields(log.Fields{
+			"udid":     dev.SerialNumber,
+			"protocol": resp.RestoreProtocolVersion,
+		}).Debug("Connected to restored")
+		return &restored{c: cli, udid: dev.SerialNumber}, nil
+	}
+	return nil, fmt.Errorf("no restore mode device found")
+}
+
+func (rd *restored) Close() error {
+	return rd.c.Close()
+}
+
+// restoreOptions builds the StartRestore RestoreOptions
+func (r *Restore) restoreOptions() map[string]any {
+	bi := r.manifest.BuildIdentities[r.identity]
+	opts := map[string]any{
+		"AutoBootDelay":               0,
+		"BootImageType":               "UserOrInternal",
+		"CreateFilesystemPartitions":  true,
+		"DFUFileType":                 "RELEASE",
+		"DataImage":                   false,
+		"FirmwareDirectory":           ".",
+		"FlashNOR":                    true,
+		"KernelCacheType":             "Release",
+		"NORImageType":                "production",
+		"PersonalizedDuringPreflight": true,
+		"RestoreBootArgs":             r.bootArgs(),
+		"RestoreBundlePath":           "/tmp/Per2.tmp",
+		"RootToInstall":               false,
+		"SupportedDataTypes":          supportedDataTypes,
+		"SupportedMessageTypes":       supportedMessageTypes,
+		"SystemImageType":             "User",
+		"UUID":                        strings.ToUpper(uuid.New().String()),
+		"UpdateBaseband":              false,
+	}
+	if len(bi.Info.SystemPartitionPadding) > 0 {
+		opts["SystemPartitionPadding"] = bi.Info.SystemPartitionPadding
+	}
+	if bi.Info.MinimumSystemPartition > 0 {
+		opts["SystemPartitionSize"] = bi.Info.MinimumSystemPartition
+	}
+	if r.conf.Update {
+		opts["PreserveDataVolumes"] = true
+	}
+	return opts
+}
+
+func (r *Restore) bootArgs() string {
+	if r.conf.Update {
+		return "rd=md0 nand-enable-reformat=0x0 -progress"
+	}
+	return "rd=md0 nand-enable-reformat=0x1 -progress"
+}
+
+type startRestoreRequest struct {
+	Label                  string         `plist:"Label"`
+	Request                string         `plist:"Request"`
+	RestoreProtocolVersion int            `plist:"RestoreProtocolVersion"`
+	RestoreOptions         map[string]any `plist:"RestoreOptions"`
+}
+
+// runRestored starts the restore and services restored's requests until it reports its final status
+func (r *Restore) runRestored(ctx context.Context, rd *restored) error {
+	if err := rd.c.Send(&startRestoreRequest{
+		Label:                  "ipsw",
+		Request:                "StartRestore",
+		RestoreProtocolVersion: restoreProtocolVersion,
+		RestoreOptions:         r.restoreOptions(),
+	}); err != nil {
+		return fmt.Errorf("failed to send StartRestore: %w", err)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var msg map[string]any
+		if err := rd.c.Recv(&msg); err != nil {
+			return fmt.Errorf("failed to receive restored message: %w", err)
+		}
+		typ, _ := msg["MsgType"].(string)
+		switch typ {
+		case "DataRequestMsg", "AsyncDataRequestMsg":
+			if err := r.handleDataRequest(rd, msg); err != nil {
+				return err
+			}
+		case "ProgressMsg":
+			op := toInt(msg["Operation"])
+			name, ok := restoreOperations[op]
+			if !ok {
+				name = fmt.Sprintf("Operation %d", op)
+			}
+			if progress := toInt(msg["Progress"]); progress > 0 && progress <= 100 {
+				log.Infof("%s (%d%%)", name, progress)
+			} else {
+				log.Info(name)
+			}
+		case "StatusMsg":
+			status := toInt(msg["Status"])
+			if status == 0 {
+				log.Info("Restore completed successfully")
+				rd.c.Send(map[string]any{"MsgType": "ReceivedFinalStatusMsg"})
+				return nil
+			}
+			return fmt.Errorf("restore failed with status %d: %v", status, msg["Log"])
+		case "CheckpointMsg":
+			log.Debugf("Checkpoint %v (%v)", msg["CHECKPOINT_ID"], msg["CHECKPOINT_NAME"])
+		case "PreviousRestoreLogMsg":
+			log.Debugf("Previous restore log: %v", msg["PreviousRestoreLog"])
+		case "BBUpdateStatusMsg":
+			log.Debugf("Baseband update status: %v", msg)
+		case "RestoredCrash":
+			log.Errorf("restored crashed: %v", msg["RestoredBacktrace"])
+		default:
+			log.Debugf("Unhandled restored message: %v", msg)
+		}
+	}
+}
+
+func (r *Restore) handleDataRequest(rd *restored, msg map[string]any) error {
+	typ, _ := msg["DataType"].(string)
+	args, _ := msg["Arguments"].(map[string]any)
+	log.Debugf("restored requested %s", typ)
+
+	switch typ {
+	case "SystemImageData":
+		img, size, cleanup, err := r.systemImage()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		asr, err := newASRClient(rd.udid)
+		if err != nil {
+			return err
+		}
+		defer asr.Close()
+		return asr.Send(img, size)
+	case "RootTicket":
+		return rd.c.Send(map[string]any{"RootTicketData": r.ticket})
+	case "KernelCache":
+		data, err := r.stitch("RestoreKernelCache")
+		if err != nil {
+			return err
+		}
+		return rd.c.Send(map[string]any{"KernelCacheFile": data})
+	case "DeviceTree":
+		data, err := r.stitch("RestoreDeviceTree")
+		if err != nil {
+			return err
+		}
+		return rd.c.Send(map[string]any{"DeviceTreeFile": data})
+	case "NORData":
+		return r.sendNORData(rd, args)
+	case "FDRTrustData", "FirmwareUpdaterPreflight":
+		return rd.c.Send(map[string]any{})
+	case "HostSystemTime":
+		return rd.c.Send(map[string]any{"HostSystemTime": time.Now()})
+	case "FUDData":
+		images := make(map[string]any)
+		for _, name := range r.componentsWith("IsFUDFirmware") {
+			data, err := r.stitch(name)
+			if err != nil {
+				return err
+			}
+			images[name] = data
+		}
+		return rd.c.Send(map[string]any{"FUDImageData": images})
+	case "BuildIdentityDict", "BuildIdentityDictV2":
+		return rd.c.Send(map[string]any{
+			"BuildIdentityDict": r.identities[r.identity],
+			"Variant":           r.manifest.BuildIdentities[r.identity].Info.Variant,
+		})
+	case "PersonalizedBootObjectV3", "SourceBootObjectV3", "SourceBootObjectV4":
+		name, _ := args["ImageName"].(string)
+		var data []byte
+		var err error
+		if typ == "PersonalizedBootObjectV3" {
+			data, err = r.stitch(name)
+		} else {
+			data, err = r.sourceBootObject(name)
+		}
+		if err != nil {
+			return err
+		}
+		return r.sendChunked(rd, data)
+	default:
+		return fmt.Errorf("restored requested unsupported data type %s", typ)
+	}
+}
+
+// sourceBootObject returns the unpersonalized boot object restored requested by name
+func (r *Restore) sourceBootObject(name string) ([]byte, error) {
+	switch name {
+	case "__RestoreVersion__":
+		return r.readFile("RestoreVersion.plist")
+	case "__SystemVersion__":
+		return r.readFile("SystemVersion.plist")
+	case "__GlobalManifest__":
+		return r.readFile("BuildManifest.plist")
+	}
+	return r.componentData(name)
+}
+
+func (r *Restore) sendChunked(rd *restored, data []byte) error {
+	for off := 0; off < len(data); off += bootObjectChunkSize {
+		end := min(off+bootObjectChunkSize, len(data))
+		if err := rd.c.Send(map[string]any{"FileData": data[off:end]}); err != nil {
+			return fmt.Errorf("failed to send boot object: %w", err)
+		}
+	}
+	return rd.c.Send(map[string]any{"FileDataDone": true})
+}
+
+// sendNORData sends LLB and the firmware restored flashes to NOR
+func (r *Restore) sendNORData(rd *restored, args map[string]any) error {
+	llb, err := r.stitch("LLB")
+	if err != nil {
+		return err
+	}
+	_, flashV1 := args["FlashVersion1"]
+
+	var norArray [][]byte
+	norDict := make(map[string]any)
+	for _, name := range append(r.componentsWith("IsFirmwarePayload"), r.componentsWith("IsSecondaryFirmwarePayload")...) {
+		// LLB and the restore SEP are sent separately
+		if name == "LLB" || name == "RestoreSEP" || name == "SEP" {
+			continue
+		}
+		if loaded, _ := r.componentInfo(name)["IsLoadedByiBoot"].(bool); loaded && strings.HasPrefix(name, "Restore") {
+			continue
+		}
+		data, err := r.stitch(name)
+		if err != nil {
+			return err
+		}
+		if flashV1 {
+			norArray = append(norArray, data)
+		} else {
+			norDict[name] = data
+		}
+	}
+
+	req := map[string]any{"LlbImageData": llb}
+	if flashV1 {
+		req["NorImageData"] = norArray
+	} else {
+		req["NorImageData"] = norDict
+	}
+	for name, key := range map[string]string{"RestoreSEP": "RestoreSEPImageData", "SEP": "SEPImageData"} {
+		if !r.hasComponent(name) {
+			continue
+		}
+		data, err := r.stitch(name)
+		if err != nil {
+			return err
+		}
+		req[key] = data
+	}
+	return rd.c.Send(req)
+}
+
+func toInt(v any) int {
+	switch t := v.(type) {
+	case int:
+		return t
+	case int64:
+		return int(t)
+	case uint64:
+		return int(t)
+	case float64:
+		return int(t)
+	}
+	return 0
+}
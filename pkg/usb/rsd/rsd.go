@@ -0,0 +1,197 @@
+// Package rsd implements RemoteServiceDiscovery, the iOS 17+ RemoteXPC service
+// directory reachable over the CoreDevice tunnel
+package rsd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/xpc"
+)
+
+// Port is the RemoteServiceDiscovery port on the device's tunnel address
+const Port = 58783
+
+// Service is a service advertised by RemoteServiceDiscovery
+type Service struct {
+	Name        string
+	Port        int
+	Entitlement string
+	Properties  map[string]any
+}
+
+// Client is a RemoteServiceDiscovery client
+type Client struct {
+	host       string
+	uuid       string
+	properties map[string]any
+	services   map[string]Service
+}
+
+// NewClient connects to the RemoteServiceDiscovery service at addr (the device's tunnel address
+// with an optional port, i.e. "fd7b:e5b:6f53::1" or "[fd7b:e5b:6f53::1]:58783") and reads the
+// device's service directory
+func NewClient(addr string) (*Client, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, strconv.Itoa(Port)
+	}
+	conn, err := xpc.Dial(net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	handshake, err := conn.Receive(xpc.RootChannel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive RSD handshake: %w", err)
+	}
+	if typ, _ := handshake["MessageType"].(string); typ != "Handshake" {
+		return nil, fmt.Errorf("unexpected RSD message type: %v", handshake["MessageType"])
+	}
+
+	c := &Client{
+		host:     host,
+		services: make(map[string]Service),
+	}
+	c.uuid, _ = handshake["UUID"].(string)
+	c.properties, _ = handshake["Properties"].(map[string]any)
+	services, _ := handshake["Services"].(map[string]any)
+	for name, v := range services {
+		svc, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		s := Service{Name: name}
+		if p, ok := svc["Port"].(string); ok {
+			s.Port, _ = strconv.Atoi(p)
+		}
+		s.Entitlement, _ = svc["Entitlement"].(string)
+		s.Properties, _ = svc["Properties"].(map[string]any)
+		c.services[name] = s
+	}
+
+	return c, nil
+}
+
+// Host returns the device's tunnel address
+func (c *Client) Host() string {
+	return c.host
+}
+
+// UUID returns the device's RemoteXPC identifier
+func (c *Client) UUID() string {
+	return c.uuid
+}
+
+// Properties returns the device properties advertised in the handshake (i.e. UniqueDeviceID, OSVersion)
+func (c *Client) Properties() map[string]any {
+	return c.properties
+}
+
+// Property returns a string device property or "" if not present
+func (c *Client) Property(key string) string {
+	if v, ok := c.properties[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Services returns the advertised services sorted by name
+func (c *Client) Services() []Service {
+	var svcs []Service
+	for _, s := range c.services {
+		svcs = append(svcs, s)
+	}
+	sort.Slice(svcs, func(i, j int) bool {
+		return svcs[i].Name < svcs[j].Name
+	})
+	return svcs
+}
+
+// Service returns the advertised service name
+func (c *Client) Service(name string) (Service, error) {
+	s, ok := c.services[name]
+	if !ok {
+		return Service{}, fmt.Errorf("service %s not advertised by device", name)
+	}
+	return s, nil
+}
+
+// Dial opens a raw connection to the service name
+func (c *Client) Dial(name string) (net.Conn, error) {
+	svc, err := c.Service(name)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(c.host, strconv.Itoa(svc.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s on port %d: %w", name, svc.Port, err)
+	}
+	return conn, nil
+}
+
+// DialXPC opens a RemoteXPC connection to the service name
+func (c *Client) DialXPC(name string) (*xpc.Conn, error) {
+	conn, err := c.Dial(name)
+	if err != nil {
+		return nil, err
+	}
+	xc, err := xpc.NewConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return xc, nil
+}
+
+type checkinRequest struct {
+	Label           string `plist:"Label"`
+	ProtocolVersion string `plist:"ProtocolVersion"`
+	Request         string `plist:"Request"`
+}
+
+type checkinResponse struct {
+	Request string `plist:"Request,omitempty"`
+	Error   string `plist:"Error,omitempty"`
+}
+
+// DialLockdown connects to a lockdown "shim" service (i.e. com.apple.mobile.mobile_image_mounter.shim.remote)
+// and performs the RSDCheckin so the returned client speaks the classic lockdown service protocol
+func (c *Client) DialLockdown(name string) (*usb.Client, error) {
+	conn, err := c.Dial(name)
+	if err != nil {
+		return nil, err
+	}
+	cli := usb.NewClientFromConn(conn, c.Property("UniqueDeviceID"))
+
+	if err := cli.Send(&checkinRequest{
+		Label:           "ipsw",
+		ProtocolVersion: "2",
+		Request:         "RSDCheckin",
+	}); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to send RSDCheckin: %w", err)
+	}
+	// the device answers with the checkin followed by StartService
+	for _, expected := range []string{"RSDCheckin", "StartService"} {
+		var resp checkinResponse
+		if err := cli.Recv(&resp); err != nil {
+			cli.Close()
+			return nil, fmt.Errorf("failed to receive %s response: %w", expected, err)
+		}
+		if len(resp.Error) > 0 {
+			cli.Close()
+			return nil, fmt.Errorf("%s failed: %s", expected, resp.Error)
+		}
+		if resp.Request != expected {
+			cli.Close()
+			return nil, fmt.Errorf("unexpected RSD checkin response: %s (expected %s)", resp.Request, expected)
+		}
+	}
+
+	return cli, nil
+}
@@ -3,6 +3,8 @@ package springboard
 //go:generate stringer -type=Orientation -output springboard_string.go
 
 import (
+	"fmt"
+
 	"github.com/blacktop/ipsw/pkg/usb"
 	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
 )
@@ -35,9 +37,27 @@ func NewClient(udid string) (*Client, error) {
 	}, nil
 }
 
+// Wallpaper names for GetWallpaperPreview
+//
+// NOTE: springboardservices has no command to set the wallpaper (only to get it)
+const (
+	HomeScreenWallpaper = "homescreen"
+	LockScreenWallpaper = "lockscreen"
+)
+
+// iconStateFormatVersion is the icon state format with folders as dictionaries (version 1 is the legacy page array)
+const iconStateFormatVersion = "2"
+
 type springboardRequest struct {
-	Command  string `plist:"command,omitempty"`
-	BundleID string `plist:"bundleId,omitempty"`
+	Command       string `plist:"command,omitempty"`
+	BundleID      string `plist:"bundleId,omitempty"`
+	FormatVersion string `plist:"formatVersion,omitempty"`
+	WallpaperName string `plist:"wallpaperName,omitempty"`
+}
+
+type setIconStateRequest struct {
+	Command   string `plist:"command,omitempty"`
+	IconState any    `plist:"iconState,omitempty"`
 }
 
 type getPngResponse struct {
@@ -67,6 +87,56 @@ func (c *Client) GetWallpaper() ([]byte, error) {
 	return resp.PngData, nil
 }
 
+// GetWallpaperPreview returns the home or lock screen wallpaper as a PNG
+func (c *Client) GetWallpaperPreview(name string) ([]byte, error) {
+	req := &springboardRequest{
+		Command:       "getWallpaperPreviewImage",
+		WallpaperName: name,
+	}
+	resp := &getPngResponse{}
+	if err := c.c.Request(req, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.PngData) == 0 {
+		return nil, fmt.Errorf("no %s wallpaper preview returned", name)
+	}
+	return resp.PngData, nil
+}
+
+// GetIconState returns the home screen layout (an array of pages where the first is the dock)
+func (c *Client) GetIconState() ([]any, error) {
+	req := &springboardRequest{
+		Command:       "getIconState",
+		FormatVersion: iconStateFormatVersion,
+	}
+	var resp []any
+	if err := c.c.Request(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetIconState replaces the home screen layout with one returned by GetIconState
+func (c *Client) SetIconState(state []any) error {
+	// springboard does not reply to setIconState
+	return c.c.Send(&setIconStateRequest{
+		Command:   "setIconState",
+		IconState: state,
+	})
+}
+
+// GetHomeScreenIconMetrics returns the home screen icon grid metrics
+func (c *Client) GetHomeScreenIconMetrics() (map[string]any, error) {
+	req := &springboardRequest{
+		Command: "getHomeScreenIconMetrics",
+	}
+	var resp map[string]any
+	if err := c.c.Request(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 type getOrientationResponse struct {
 	InterfaceOrientation Orientation `plist:"interfaceOrientation,omitempty"`
 }
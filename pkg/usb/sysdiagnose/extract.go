@@ -0,0 +1,131 @@
+package sysdiagnose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Extract unpacks the sysdiagnose archive into dest and returns the extracted sysdiagnose folder
+func Extract(archive, dest string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", archive, err)
+	}
+	defer gr.Close()
+
+	root := ""
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		fname := filepath.Join(dest, filepath.Clean("/"+hdr.Name))
+		if top := strings.Split(filepath.Clean(hdr.Name), "/")[0]; len(root) == 0 && top != "." && top != ".." {
+			root = filepath.Join(dest, top)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fname, 0o750); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+				return "", err
+			}
+			out, err := os.OpenFile(fname, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return "", err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return "", fmt.Errorf("failed to extract %s: %w", hdr.Name, err)
+			}
+			out.Close()
+		}
+	}
+	return root, nil
+}
+
+// Entry is an interesting file in an extracted sysdiagnose
+type Entry struct {
+	Category string `json:"category"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+}
+
+var categories = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"panic", regexp.MustCompile(`(?i)panic-(full|base)-.*\.(ips|panic)$`)},
+	{"jetsam", regexp.MustCompile(`(?i)JetsamEvent-.*\.ips$`)},
+	{"crash", regexp.MustCompile(`(?i)crashes_and_spins/.*\.ips$`)},
+	{"logarchive", regexp.MustCompile(`\.logarchive$`)},
+	{"processes", regexp.MustCompile(`(^|/)(ps|ps_thread|taskinfo)\.txt$`)},
+	{"memory", regexp.MustCompile(`(^|/)(vm_stat|zprint|footprint-all)\.txt$`)},
+	{"network", regexp.MustCompile(`(?i)(^|/)(ifconfig|netstat|WiFi/.*)\.(txt|log)$`)},
+	{"ioreg", regexp.MustCompile(`(?i)ioreg/.*\.txt$`)},
+	{"power", regexp.MustCompile(`(?i)(powerlogs/.*\.PLSQL|pmset.*\.txt)$`)},
+	{"summary", regexp.MustCompile(`(^|/)(sysdiagnose\.log|remotectl_dumpstate\.txt|errors/.*\.txt)$`)},
+}
+
+// Index walks an extracted sysdiagnose and returns its interesting files (crashes, panics, unified logs, etc.)
+func Index(root string) ([]Entry, error) {
+	var entries []Entry
+	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, c := range categories {
+			if !c.re.MatchString(rel) {
+				continue
+			}
+			if info.IsDir() { // i.e. system_logs.logarchive
+				entries = append(entries, Entry{Category: c.name, Path: rel, Size: dirSize(path)})
+				return filepath.SkipDir
+			}
+			entries = append(entries, Entry{Category: c.name, Path: rel, Size: info.Size()})
+			return nil
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", root, err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Category < entries[j].Category
+	})
+	return entries, nil
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
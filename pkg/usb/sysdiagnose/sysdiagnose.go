@@ -0,0 +1,135 @@
+// Package sysdiagnose triggers, waits for and retrieves sysdiagnose archives from a device
+package sysdiagnose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb/afc"
+	"github.com/blacktop/ipsw/pkg/usb/coredevice"
+	crashmover "github.com/blacktop/ipsw/pkg/usb/crashlog"
+	"github.com/blacktop/ipsw/pkg/usb/rsd"
+)
+
+const (
+	// Dir is where finished sysdiagnose archives are moved to in the crash reports folder
+	Dir = "/DiagnosticLogs/sysdiagnose"
+
+	diagnosticsService = "com.apple.coredevice.diagnosticsservice"
+	captureFeature     = "com.apple.coredevice.feature.capturesysdiagnose"
+)
+
+// Client is a sysdiagnose client for a device paired over usbmuxd
+type Client struct {
+	udid string
+	afc  *afc.Client
+}
+
+// NewClient connects to the device's crash reports folder
+func NewClient(udid string) (*Client, error) {
+	c, err := crashmover.NewClient(udid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to crash report service: %w", err)
+	}
+	return &Client{udid: udid, afc: c}, nil
+}
+
+// Archives returns the finished sysdiagnose archives on the device
+func (c *Client) Archives() ([]string, error) {
+	// sysdiagnose hands its archive to the crash report mover so it only shows up once flushed
+	if err := crashmover.Flush(c.udid); err != nil {
+		log.WithError(err).Debug("failed to flush crash reports")
+	}
+	files, err := c.afc.ReadDir(Dir)
+	if err != nil {
+		return nil, nil // the folder doesn't exist until the first sysdiagnose
+	}
+	var archives []string
+	for _, f := range files {
+		if isArchive(f) {
+			archives = append(archives, path.Join(Dir, f))
+		}
+	}
+	slices.Sort(archives)
+	return archives, nil
+}
+
+func isArchive(name string) bool {
+	return strings.HasPrefix(name, "sysdiagnose_") && (strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"))
+}
+
+// Wait polls the device until a finished sysdiagnose archive that is not in existing appears
+func (c *Client) Wait(ctx context.Context, existing []string) (string, error) {
+	for {
+		archives, err := c.Archives()
+		if err != nil {
+			return "", err
+		}
+		for _, a := range archives {
+			if !slices.Contains(existing, a) {
+				return a, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed waiting for sysdiagnose: %w", ctx.Err())
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// Pull copies the sysdiagnose archive src from the device to dst
+func (c *Client) Pull(dst, src string) error {
+	return c.afc.CopyFileFromDevice(dst, src)
+}
+
+// Remove deletes the sysdiagnose archive from the device
+func (c *Client) Remove(archive string) error {
+	return c.afc.RemovePath(archive)
+}
+
+func (c *Client) Close() error {
+	return c.afc.Close()
+}
+
+// Capture triggers a sysdiagnose over RemoteXPC (iOS 17+), writes the archive to w and returns its preferred filename
+func Capture(r *rsd.Client, full bool, w io.Writer) (string, error) {
+	conn, err := r.DialXPC(diagnosticsService)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", diagnosticsService, err)
+	}
+	defer conn.Close()
+
+	log.Info("Capturing sysdiagnose (this can take several minutes)")
+	out, err := coredevice.Invoke(conn, captureFeature, map[string]any{
+		"options": map[string]any{
+			"collectFullLogs": full,
+		},
+		"isDryRun": false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	name, _ := out["preferredFilename"].(string)
+	if len(name) == 0 {
+		name = fmt.Sprintf("sysdiagnose_%s.tar.gz", time.Now().Format("2006.01.02_15-04-05"))
+	}
+	xfer, _ := out["fileTransfer"].(map[string]any)
+	info, _ := xfer["Data"].(map[string]any)
+	size, ok := info["s"].(uint64)
+	if !ok {
+		return "", fmt.Errorf("sysdiagnose reply is missing the file transfer size")
+	}
+	log.WithField("size", size).Debugf("Receiving %s", name)
+	if err := conn.ReceiveFile(0, int64(size), w); err != nil {
+		return "", fmt.Errorf("failed to receive sysdiagnose: %w", err)
+	}
+	return name, nil
+}
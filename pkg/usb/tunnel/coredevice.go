@@ -0,0 +1,125 @@
+// Package tunnel implements the CoreDevice tunnel of USB connected iOS 17.4+ devices that exposes the
+// device's RemoteXPC services (RemoteServiceDiscovery) on a local TUN interface
+// (the Wi-Fi RemotePairing/QUIC transport is not supported)
+package tunnel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/blacktop/ipsw/pkg/usb"
+	"github.com/blacktop/ipsw/pkg/usb/lockdownd"
+)
+
+const (
+	serviceName    = "com.apple.internal.devicecompute.CoreDeviceProxy"
+	handshakeMagic = "CDTunnel"
+	defaultMTU     = 16000
+	ipv6HeaderSize = 40
+)
+
+type handshakeRequest struct {
+	Type string `json:"type"`
+	MTU  int    `json:"mtu"`
+}
+
+// Parameters are the tunnel addresses negotiated with the device
+type Parameters struct {
+	ClientParameters struct {
+		Address string `json:"address"`
+		Netmask string `json:"netmask"`
+		MTU     int    `json:"mtu"`
+	} `json:"clientParameters"`
+	ServerAddress string `json:"serverAddress"`
+	ServerRSDPort int    `json:"serverRSDPort"`
+	Type          string `json:"type"`
+}
+
+// proxy is a CoreDeviceProxy connection carrying raw IPv6 packets
+type proxy struct {
+	c *usb.Client
+}
+
+// newProxy starts the CoreDeviceProxy lockdown service (USB, iOS 17.4+) and performs the tunnel handshake
+func newProxy(udid string) (*proxy, *Parameters, error) {
+	c, err := lockdownd.NewClientForService(serviceName, udid, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &proxy{c: c}
+	params, err := p.handshake()
+	if err != nil {
+		c.Close()
+		return nil, nil, fmt.Errorf("CoreDeviceProxy handshake failed: %w", err)
+	}
+	return p, params, nil
+}
+
+func (p *proxy) handshake() (*Parameters, error) {
+	req, err := json.Marshal(&handshakeRequest{Type: "clientHandshakeRequest", MTU: defaultMTU})
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(handshakeMagic)
+	binary.Write(buf, binary.BigEndian, uint16(len(req)))
+	buf.Write(req)
+	if _, err := p.c.Conn().Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, len(handshakeMagic))
+	if _, err := io.ReadFull(p.c.Conn(), magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != handshakeMagic {
+		return nil, fmt.Errorf("invalid handshake magic: %q", magic)
+	}
+	var size uint16
+	if err := binary.Read(p.c.Conn(), binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(p.c.Conn(), data); err != nil {
+		return nil, err
+	}
+	var params Parameters
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse handshake response: %w", err)
+	}
+	if params.Type != "serverHandshakeResponse" {
+		return nil, fmt.Errorf("unexpected handshake response type: %s", params.Type)
+	}
+	return &params, nil
+}
+
+// ReadPacket reads the next IPv6 packet sent by the device into buf
+func (p *proxy) ReadPacket(buf []byte) (int, error) {
+	if _, err := io.ReadFull(p.c.Conn(), buf[:ipv6HeaderSize]); err != nil {
+		return 0, err
+	}
+	if buf[0]>>4 != 6 {
+		return 0, fmt.Errorf("unexpected IP version %d in tunnel stream", buf[0]>>4)
+	}
+	size := ipv6HeaderSize + int(binary.BigEndian.Uint16(buf[4:6]))
+	if size > len(buf) {
+		return 0, fmt.Errorf("tunnel packet of %d bytes exceeds MTU", size)
+	}
+	if _, err := io.ReadFull(p.c.Conn(), buf[ipv6HeaderSize:size]); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// WritePacket sends an IPv6 packet to the device
+func (p *proxy) WritePacket(pkt []byte) error {
+	_, err := p.c.Conn().Write(pkt)
+	return err
+}
+
+func (p *proxy) Close() error {
+	return p.c.Close()
+}
@@ -0,0 +1,203 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/usb"
+)
+
+// DefaultRegistryAddr is where the tunnel daemon serves the tunnel registry
+const DefaultRegistryAddr = "127.0.0.1:49151"
+
+const (
+	pollInterval = 5 * time.Second
+	retryBackoff = 30 * time.Second
+)
+
+// Manager keeps a CoreDevice tunnel up for every USB connected device that supports one
+type Manager struct {
+	mu      sync.RWMutex
+	tunnels map[string]*Tunnel
+	failed  map[string]time.Time
+}
+
+// NewManager creates a tunnel Manager
+func NewManager() *Manager {
+	return &Manager{
+		tunnels: make(map[string]*Tunnel),
+		failed:  make(map[string]time.Time),
+	}
+}
+
+// Run polls usbmuxd for devices, starting tunnels for new devices and dropping
+// tunnels of disconnected devices, until ctx is canceled
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.refresh(ctx); err != nil {
+			log.WithError(err).Warn("failed to refresh tunnels")
+		}
+		select {
+		case <-ctx.Done():
+			m.closeAll()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) refresh(ctx context.Context) error {
+	conn, err := usb.NewConn()
+	if err != nil {
+		return fmt.Errorf("failed to connect to usbmuxd: %w", err)
+	}
+	devices, err := conn.ListDevices()
+	conn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	connected := make(map[string]bool)
+	for _, d := range devices {
+		if d.ConnectionType != "USB" {
+			continue
+		}
+		connected[d.SerialNumber] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for udid, t := range m.tunnels {
+		select {
+		case <-t.Done():
+			if err := t.Err(); err != nil {
+				log.WithError(err).WithField("udid", udid).Warn("Tunnel closed")
+			}
+			delete(m.tunnels, udid)
+			continue
+		default:
+		}
+		if !connected[udid] {
+			log.WithField("udid", udid).Info("Device disconnected, closing tunnel")
+			t.Close()
+			delete(m.tunnels, udid)
+		}
+	}
+
+	for udid := range connected {
+		if _, ok := m.tunnels[udid]; ok {
+			continue
+		}
+		if last, ok := m.failed[udid]; ok && time.Since(last) < retryBackoff {
+			continue
+		}
+		t, err := Start(ctx, udid)
+		if err != nil {
+			// pre-iOS 17.4 devices do not expose CoreDeviceProxy
+			log.WithError(err).WithField("udid", udid).Debug("failed to start tunnel")
+			m.failed[udid] = time.Now()
+			continue
+		}
+		delete(m.failed, udid)
+		log.WithFields(log.Fields{
+			"udid":      udid,
+			"interface": t.Interface,
+			"rsd":       t.RSDAddress(),
+		}).Info("Tunnel started")
+		m.tunnels[udid] = t
+	}
+
+	return nil
+}
+
+func (m *Manager) closeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for udid, t := range m.tunnels {
+		t.Close()
+		delete(m.tunnels, udid)
+	}
+}
+
+// Tunnels returns the active tunnels sorted by UDID
+func (m *Manager) Tunnels() []*Tunnel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var tunnels []*Tunnel
+	for _, t := range m.tunnels {
+		tunnels = append(tunnels, t)
+	}
+	sort.Slice(tunnels, func(i, j int) bool {
+		return tunnels[i].UDID < tunnels[j].UDID
+	})
+	return tunnels
+}
+
+// ServeHTTP serves the tunnel registry as JSON
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Tunnels()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Serve runs the manager and serves its registry on addr until ctx is canceled
+func (m *Manager) Serve(ctx context.Context, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: m}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("tunnel registry server failed")
+		}
+	}()
+	return m.Run(ctx)
+}
+
+// List returns the tunnels registered with the tunnel daemon at addr
+func List(addr string) ([]*Tunnel, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tunnel daemon at %s (is 'ipsw idev tunnel start' running?): %w", addr, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tunnel daemon returned %s", resp.Status)
+	}
+	var tunnels []*Tunnel
+	if err := json.NewDecoder(resp.Body).Decode(&tunnels); err != nil {
+		return nil, fmt.Errorf("failed to decode tunnel registry: %w", err)
+	}
+	return tunnels, nil
+}
+
+// Lookup returns the tunnel registered for udid with the tunnel daemon at addr
+func Lookup(addr, udid string) (*Tunnel, error) {
+	tunnels, err := List(addr)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tunnels {
+		if t.UDID == udid {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("no tunnel registered for device %s", udid)
+}
@@ -0,0 +1,9 @@
+package tunnel
+
+// device is a host TUN interface carrying raw IPv6 packets
+type device interface {
+	Name() string
+	ReadPacket(buf []byte) (int, error)
+	WritePacket(pkt []byte) error
+	Close() error
+}
@@ -0,0 +1,86 @@
+//go:build darwin
+
+package tunnel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	sysprotoControl = 2
+	utunOptIfname   = 2
+	utunControlName = "com.apple.net.utun_control"
+	// utun packets are prefixed with the protocol family
+	utunHeaderSize = 4
+)
+
+type utun struct {
+	name string
+	f    *os.File
+	rbuf []byte
+	wbuf []byte
+}
+
+func newDevice(address string, prefixLen, mtu int) (device, error) {
+	fd, err := unix.Socket(unix.AF_SYSTEM, unix.SOCK_DGRAM, sysprotoControl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create utun control socket: %w", err)
+	}
+	info := &unix.CtlInfo{}
+	copy(info.Name[:], utunControlName)
+	if err := unix.IoctlCtlInfo(fd, info); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to lookup %s: %w", utunControlName, err)
+	}
+	if err := unix.Connect(fd, &unix.SockaddrCtl{ID: info.Id, Unit: 0}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to create utun interface (are you root?): %w", err)
+	}
+	name, err := unix.GetsockoptString(fd, sysprotoControl, utunOptIfname)
+	if err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to get utun interface name: %w", err)
+	}
+	t := &utun{
+		name: name,
+		f:    os.NewFile(uintptr(fd), name),
+		rbuf: make([]byte, mtu+utunHeaderSize),
+		wbuf: make([]byte, mtu+utunHeaderSize),
+	}
+	if out, err := exec.Command("ifconfig", t.name, "inet6", "add", address, "prefixlen", fmt.Sprint(prefixLen), "mtu", fmt.Sprint(mtu), "up").CombinedOutput(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("failed to configure %s: %v: %s", t.name, err, out)
+	}
+	return t, nil
+}
+
+func (t *utun) Name() string {
+	return t.name
+}
+
+func (t *utun) ReadPacket(buf []byte) (int, error) {
+	n, err := t.f.Read(t.rbuf)
+	if err != nil {
+		return 0, err
+	}
+	if n < utunHeaderSize {
+		return 0, nil
+	}
+	return copy(buf, t.rbuf[utunHeaderSize:n]), nil
+}
+
+func (t *utun) WritePacket(pkt []byte) error {
+	binary.BigEndian.PutUint32(t.wbuf, unix.AF_INET6)
+	n := copy(t.wbuf[utunHeaderSize:], pkt)
+	_, err := t.f.Write(t.wbuf[:utunHeaderSize+n])
+	return err
+}
+
+func (t *utun) Close() error {
+	return t.f.Close()
+}
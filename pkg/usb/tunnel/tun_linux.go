@@ -0,0 +1,64 @@
+//go:build linux
+
+package tunnel
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+type linuxTun struct {
+	name string
+	f    *os.File
+}
+
+func newDevice(address string, prefixLen, mtu int) (device, error) {
+	fd, err := unix.Open("/dev/net/tun", unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/net/tun (are you root?): %w", err)
+	}
+	ifr, err := unix.NewIfreq("")
+	if err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	ifr.SetUint16(unix.IFF_TUN | unix.IFF_NO_PI)
+	if err := unix.IoctlIfreq(fd, unix.TUNSETIFF, ifr); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to create tun interface: %w", err)
+	}
+	t := &linuxTun{
+		name: ifr.Name(),
+		f:    os.NewFile(uintptr(fd), "/dev/net/tun"),
+	}
+	for _, args := range [][]string{
+		{"-6", "addr", "add", fmt.Sprintf("%s/%d", address, prefixLen), "dev", t.name},
+		{"link", "set", "dev", t.name, "mtu", fmt.Sprint(mtu), "up"},
+	} {
+		if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to configure %s: %v: %s", t.name, err, out)
+		}
+	}
+	return t, nil
+}
+
+func (t *linuxTun) Name() string {
+	return t.name
+}
+
+func (t *linuxTun) ReadPacket(buf []byte) (int, error) {
+	return t.f.Read(buf)
+}
+
+func (t *linuxTun) WritePacket(pkt []byte) error {
+	_, err := t.f.Write(pkt)
+	return err
+}
+
+func (t *linuxTun) Close() error {
+	return t.f.Close()
+}
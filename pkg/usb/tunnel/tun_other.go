@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package tunnel
+
+import "fmt"
+
+func newDevice(address string, prefixLen, mtu int) (device, error) {
+	return nil, fmt.Errorf("CoreDevice tunnels are only supported on darwin and linux")
+}
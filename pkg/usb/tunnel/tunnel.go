@@ -0,0 +1,131 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// Tunnel is an established CoreDevice tunnel to a device
+type Tunnel struct {
+	UDID      string `json:"udid"`
+	Interface string `json:"interface"`
+	Address   string `json:"address"`
+	RSDPort   int    `json:"rsd_port"`
+	ClientIP  string `json:"client_address"`
+	MTU       int    `json:"mtu"`
+
+	proxy   *proxy
+	dev     device
+	done    chan struct{}
+	err     error
+	closeFn func()
+}
+
+// RSDAddress returns the RemoteServiceDiscovery address of the device over the tunnel
+func (t *Tunnel) RSDAddress() string {
+	return net.JoinHostPort(t.Address, strconv.Itoa(t.RSDPort))
+}
+
+// Start establishes a CoreDevice tunnel to the USB connected device udid and
+// forwards packets between it and a new TUN interface until ctx is canceled,
+// Close is called or either side fails (see Done/Err)
+func Start(ctx context.Context, udid string) (*Tunnel, error) {
+	p, params, err := newProxy(udid)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixLen := 64
+	if mask := net.ParseIP(params.ClientParameters.Netmask); mask != nil {
+		prefixLen, _ = net.IPMask(mask.To16()).Size()
+	}
+	dev, err := newDevice(params.ClientParameters.Address, prefixLen, params.ClientParameters.MTU)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	t := &Tunnel{
+		UDID:      udid,
+		Interface: dev.Name(),
+		Address:   params.ServerAddress,
+		RSDPort:   params.ServerRSDPort,
+		ClientIP:  params.ClientParameters.Address,
+		MTU:       params.ClientParameters.MTU,
+		proxy:     p,
+		dev:       dev,
+		done:      make(chan struct{}),
+	}
+
+	var once sync.Once
+	stop := func(err error) {
+		once.Do(func() {
+			t.err = err
+			p.Close()
+			dev.Close()
+			close(t.done)
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop(nil)
+		case <-t.done:
+		}
+	}()
+	go func() {
+		buf := make([]byte, t.MTU+ipv6HeaderSize)
+		for {
+			n, err := p.ReadPacket(buf)
+			if err != nil {
+				stop(fmt.Errorf("failed to read from device: %w", err))
+				return
+			}
+			if err := dev.WritePacket(buf[:n]); err != nil {
+				stop(fmt.Errorf("failed to write to %s: %w", dev.Name(), err))
+				return
+			}
+		}
+	}()
+	go func() {
+		buf := make([]byte, t.MTU+ipv6HeaderSize)
+		for {
+			n, err := dev.ReadPacket(buf)
+			if err != nil {
+				stop(fmt.Errorf("failed to read from %s: %w", dev.Name(), err))
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			if err := p.WritePacket(buf[:n]); err != nil {
+				stop(fmt.Errorf("failed to write to device: %w", err))
+				return
+			}
+		}
+	}()
+
+	t.closeFn = func() { stop(nil) }
+
+	return t, nil
+}
+
+// Done is closed once the tunnel is torn down
+func (t *Tunnel) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns why the tunnel was torn down (nil if it was closed)
+func (t *Tunnel) Err() error {
+	<-t.done
+	return t.err
+}
+
+// Close tears down the tunnel
+func (t *Tunnel) Close() error {
+	t.closeFn()
+	return nil
+}
@@ -170,6 +170,7 @@ type PairRecord struct {
 	RootCertificate   []byte
 	RootPrivateKey    []byte
 	SystemBUID        string
+	WiFiMACAddress    string `plist:"WiFiMACAddress,omitempty"`
 }
 
 type readPairRecordRequest struct {
@@ -214,6 +215,81 @@ func (c *Conn) ReadPairRecord(udid string) (*PairRecord, error) {
 	return &record, nil
 }
 
+type savePairRecordRequest struct {
+	MessageType         string `plist:"MessageType"`
+	BundleID            string `plist:"BundleID,omitempty"`
+	ClientVersionString string `plist:"ClientVersionString"`
+	ProgName            string `plist:"ProgName,omitempty"`
+	LibUSBMuxVersion    uint32 `plist:"kLibUSBMuxVersion"`
+	PairRecordID        string `plist:"PairRecordID"`
+	PairRecordData      []byte `plist:"PairRecordData,omitempty"`
+	DeviceID            int    `plist:"DeviceID,omitempty"`
+}
+
+// SavePairRecord stores the pair record for the device with usbmuxd
+func (c *Conn) SavePairRecord(udid string, deviceID int, record *PairRecord) error {
+	data, err := plist.Marshal(record, plist.XMLFormat)
+	if err != nil {
+		return err
+	}
+	req := &savePairRecordRequest{
+		MessageType:         "SavePairRecord",
+		BundleID:            BundleID,
+		ClientVersionString: ClientVersionString,
+		ProgName:            ProgName,
+		LibUSBMuxVersion:    3,
+		PairRecordID:        udid,
+		PairRecordData:      data,
+		DeviceID:            deviceID,
+	}
+	var resp resultResponse
+	if err := c.Request(req, &resp); err != nil {
+		return err
+	}
+	if resp.Number != ResultValueOK {
+		return fmt.Errorf("failed to save pair record: usbmuxd error %d", resp.Number)
+	}
+	return nil
+}
+
+// DeletePairRecord removes the device's pair record from usbmuxd
+func (c *Conn) DeletePairRecord(udid string) error {
+	req := &savePairRecordRequest{
+		MessageType:         "DeletePairRecord",
+		BundleID:            BundleID,
+		ClientVersionString: ClientVersionString,
+		ProgName:            ProgName,
+		LibUSBMuxVersion:    3,
+		PairRecordID:        udid,
+	}
+	var resp resultResponse
+	if err := c.Request(req, &resp); err != nil {
+		return err
+	}
+	if resp.Number != ResultValueOK {
+		return fmt.Errorf("failed to delete pair record: usbmuxd error %d", resp.Number)
+	}
+	return nil
+}
+
+type readBUIDResponse struct {
+	BUID string `plist:"BUID"`
+}
+
+// ReadBUID returns the host's SystemBUID
+func (c *Conn) ReadBUID() (string, error) {
+	req := &listDevicesRequest{
+		MessageType:         "ReadBUID",
+		ProgName:            ProgName,
+		ClientVersionString: ClientVersionString,
+	}
+	var resp readBUIDResponse
+	if err := c.Request(req, &resp); err != nil {
+		return "", err
+	}
+	return resp.BUID, nil
+}
+
 func (c *Conn) Request(req, resp any) error {
 	if err := c.Send(req); err != nil {
 		return err
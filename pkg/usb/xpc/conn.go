@@ -0,0 +1,215 @@
+package xpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	// RootChannel carries client requests
+	RootChannel = 1
+	// ReplyChannel carries device replies to requests sent with FlagWantingReply
+	ReplyChannel = 3
+)
+
+// Conn is a RemoteXPC connection (XPC messages framed over a bare HTTP/2 connection)
+type Conn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	framer *http2.Framer
+	msgIDs map[uint32]uint64
+	bufs   map[uint32]*bytes.Buffer
+}
+
+// NewConn performs the RemoteXPC handshake over conn and opens the root and reply channels
+func NewConn(conn net.Conn) (*Conn, error) {
+	c := &Conn{
+		conn:   conn,
+		framer: http2.NewFramer(conn, conn),
+		msgIDs: map[uint32]uint64{RootChannel: 0, ReplyChannel: 0},
+		bufs:   map[uint32]*bytes.Buffer{RootChannel: new(bytes.Buffer), ReplyChannel: new(bytes.Buffer)},
+	}
+	if err := c.handshake(); err != nil {
+		return nil, fmt.Errorf("remotexpc handshake failed: %w", err)
+	}
+	return c, nil
+}
+
+// Dial connects to a RemoteXPC service listening on addr (i.e. "[fd00::1]:58783")
+func Dial(addr string) (*Conn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	c, err := NewConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) handshake() error {
+	if _, err := io.WriteString(c.conn, http2.ClientPreface); err != nil {
+		return err
+	}
+	if err := c.framer.WriteSettings(
+		http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: 100},
+		http2.Setting{ID: http2.SettingInitialWindowSize, Val: 1048576},
+	); err != nil {
+		return err
+	}
+	if err := c.framer.WriteWindowUpdate(0, 983041); err != nil {
+		return err
+	}
+	if err := c.openChannel(RootChannel, 0); err != nil {
+		return err
+	}
+	if err := c.Send(map[string]any{}, 0); err != nil {
+		return err
+	}
+	if err := c.sendRaw(RootChannel, &Message{Flags: FlagAlwaysSet | 0x200, ID: c.msgIDs[RootChannel]}); err != nil {
+		return err
+	}
+	c.msgIDs[RootChannel]++
+	if err := c.openChannel(ReplyChannel, FlagInitHandshake); err != nil {
+		return err
+	}
+	c.msgIDs[ReplyChannel]++
+	return nil
+}
+
+func (c *Conn) openChannel(stream uint32, flags uint32) error {
+	if err := c.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:   stream,
+		EndHeaders: true,
+	}); err != nil {
+		return err
+	}
+	if flags == 0 {
+		return nil
+	}
+	return c.sendRaw(stream, &Message{Flags: FlagAlwaysSet | flags, ID: c.msgIDs[stream]})
+}
+
+func (c *Conn) sendRaw(stream uint32, msg *Message) error {
+	data, err := msg.Encode()
+	if err != nil {
+		return err
+	}
+	return c.framer.WriteData(stream, false, data)
+}
+
+// Send sends body on the root channel
+func (c *Conn) Send(body map[string]any, flags uint32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.sendRaw(RootChannel, &Message{
+		Flags: FlagAlwaysSet | flags,
+		ID:    c.msgIDs[RootChannel],
+		Body:  body,
+	}); err != nil {
+		return err
+	}
+	c.msgIDs[RootChannel]++
+	return nil
+}
+
+// Receive returns the next message with a body received on stream
+func (c *Conn) Receive(stream uint32) (map[string]any, error) {
+	for {
+		if buf := c.bufs[stream]; buf.Len() >= 24 {
+			data := buf.Bytes()
+			r := bytes.NewReader(data)
+			msg, err := Decode(r)
+			if err == nil {
+				buf.Next(len(data) - r.Len())
+				if msg.Body != nil {
+					return msg.Body, nil
+				}
+				continue
+			} else if err != io.ErrUnexpectedEOF && err != io.EOF {
+				return nil, err
+			}
+		}
+		if err := c.readFrame(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Request sends body on the root channel and waits for the device's reply
+func (c *Conn) Request(body map[string]any) (map[string]any, error) {
+	if err := c.Send(body, FlagWantingReply); err != nil {
+		return nil, err
+	}
+	return c.Receive(ReplyChannel)
+}
+
+// ReceiveFile copies size bytes of the idx'th file transfer announced in a reply (an XPC file transfer object) to w
+func (c *Conn) ReceiveFile(idx int, size int64, w io.Writer) error {
+	stream := uint32(idx+1) * 2
+	if err := c.openChannel(stream, FlagFileTxReply); err != nil {
+		return fmt.Errorf("failed to open file transfer stream: %w", err)
+	}
+	for n := int64(0); n < size; {
+		if buf := c.bufs[stream]; buf != nil && buf.Len() > 0 {
+			m, err := io.CopyN(w, buf, min(int64(buf.Len()), size-n))
+			if err != nil {
+				return err
+			}
+			n += m
+			// the device stalls once the (1MB) flow control windows are used up
+			if err := c.framer.WriteWindowUpdate(0, uint32(m)); err != nil {
+				return err
+			}
+			if err := c.framer.WriteWindowUpdate(stream, uint32(m)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.readFrame(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Conn) readFrame() error {
+	frame, err := c.framer.ReadFrame()
+	if err != nil {
+		return err
+	}
+	switch f := frame.(type) {
+	case *http2.DataFrame:
+		buf, ok := c.bufs[f.StreamID]
+		if !ok {
+			buf = new(bytes.Buffer)
+			c.bufs[f.StreamID] = buf
+		}
+		buf.Write(f.Data())
+	case *http2.SettingsFrame:
+		if !f.IsAck() {
+			return c.framer.WriteSettingsAck()
+		}
+	case *http2.PingFrame:
+		if !f.IsAck() {
+			return c.framer.WritePing(true, f.Data)
+		}
+	case *http2.GoAwayFrame:
+		return fmt.Errorf("remotexpc connection closed by device: %s", f.ErrCode)
+	case *http2.RSTStreamFrame:
+		return fmt.Errorf("remotexpc stream %d reset by device: %s", f.StreamID, f.ErrCode)
+	}
+	return nil
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
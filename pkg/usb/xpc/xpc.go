@@ -0,0 +1,332 @@
+// Package xpc implements the XPC wire format used by RemoteXPC services on iOS 17+
+package xpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	wrapperMagic = 0x29b00b92
+	objectMagic  = 0x42133742
+	bodyVersion  = 0x00000005
+)
+
+// Wrapper flags
+const (
+	FlagAlwaysSet     uint32 = 0x00000001
+	FlagPing          uint32 = 0x00000002
+	FlagDataPresent   uint32 = 0x00000100
+	FlagWantingReply  uint32 = 0x00010000
+	FlagReply         uint32 = 0x00020000
+	FlagFileTxRequest uint32 = 0x00100000
+	FlagFileTxReply   uint32 = 0x00200000
+	FlagInitHandshake uint32 = 0x00400000
+)
+
+const (
+	typeNull       uint32 = 0x00001000
+	typeBool       uint32 = 0x00002000
+	typeInt64      uint32 = 0x00003000
+	typeUint64     uint32 = 0x00004000
+	typeDouble     uint32 = 0x00005000
+	typeDate       uint32 = 0x00007000
+	typeData       uint32 = 0x00008000
+	typeString     uint32 = 0x00009000
+	typeUUID       uint32 = 0x0000a000
+	typeArray      uint32 = 0x0000e000
+	typeDictionary uint32 = 0x0000f000
+	typeFileTx     uint32 = 0x0001a000
+)
+
+// Message is a single XPC message as framed on a RemoteXPC stream
+type Message struct {
+	Flags uint32
+	ID    uint64
+	Body  map[string]any
+}
+
+type wrapperHeader struct {
+	Magic uint32
+	Flags uint32
+	Size  uint64
+	ID    uint64
+}
+
+// Encode serializes the message (wrapper + body) into its wire format
+func (m *Message) Encode() ([]byte, error) {
+	var body []byte
+	if m.Body != nil {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint32(objectMagic))
+		binary.Write(buf, binary.LittleEndian, uint32(bodyVersion))
+		if err := encodeObject(buf, m.Body); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+	flags := m.Flags
+	if body != nil {
+		flags |= FlagDataPresent
+	}
+	out := new(bytes.Buffer)
+	binary.Write(out, binary.LittleEndian, wrapperHeader{
+		Magic: wrapperMagic,
+		Flags: flags,
+		Size:  uint64(len(body)),
+		ID:    m.ID,
+	})
+	out.Write(body)
+	return out.Bytes(), nil
+}
+
+// Decode reads a single message from r
+func Decode(r io.Reader) (*Message, error) {
+	var hdr wrapperHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Magic != wrapperMagic {
+		return nil, fmt.Errorf("invalid xpc wrapper magic: %#x", hdr.Magic)
+	}
+	msg := &Message{Flags: hdr.Flags, ID: hdr.ID}
+	if hdr.Size == 0 {
+		return msg, nil
+	}
+	data := make([]byte, hdr.Size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	br := bytes.NewReader(data)
+	var magic, version uint32
+	binary.Read(br, binary.LittleEndian, &magic)
+	binary.Read(br, binary.LittleEndian, &version)
+	if magic != objectMagic {
+		return nil, fmt.Errorf("invalid xpc object magic: %#x", magic)
+	}
+	obj, err := decodeObject(br)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := obj.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected xpc dictionary body, got %T", obj)
+	}
+	msg.Body = dict
+	return msg, nil
+}
+
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
+
+func writeAligned(w *bytes.Buffer, data []byte) {
+	w.Write(data)
+	w.Write(make([]byte, pad4(len(data))))
+}
+
+func encodeObject(w *bytes.Buffer, obj any) error {
+	le := binary.LittleEndian
+	switch v := obj.(type) {
+	case nil:
+		binary.Write(w, le, typeNull)
+	case bool:
+		binary.Write(w, le, typeBool)
+		if v {
+			binary.Write(w, le, uint32(1))
+		} else {
+			binary.Write(w, le, uint32(0))
+		}
+	case int:
+		binary.Write(w, le, typeInt64)
+		binary.Write(w, le, int64(v))
+	case int64:
+		binary.Write(w, le, typeInt64)
+		binary.Write(w, le, v)
+	case uint64:
+		binary.Write(w, le, typeUint64)
+		binary.Write(w, le, v)
+	case float64:
+		binary.Write(w, le, typeDouble)
+		binary.Write(w, le, math.Float64bits(v))
+	case time.Time:
+		binary.Write(w, le, typeDate)
+		binary.Write(w, le, v.UnixNano())
+	case []byte:
+		binary.Write(w, le, typeData)
+		binary.Write(w, le, uint32(len(v)))
+		writeAligned(w, v)
+	case string:
+		binary.Write(w, le, typeString)
+		binary.Write(w, le, uint32(len(v)+1))
+		writeAligned(w, append([]byte(v), 0))
+	case uuid.UUID:
+		binary.Write(w, le, typeUUID)
+		w.Write(v[:])
+	case []any:
+		body := new(bytes.Buffer)
+		binary.Write(body, le, uint32(len(v)))
+		for _, e := range v {
+			if err := encodeObject(body, e); err != nil {
+				return err
+			}
+		}
+		binary.Write(w, le, typeArray)
+		binary.Write(w, le, uint32(body.Len()))
+		w.Write(body.Bytes())
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		body := new(bytes.Buffer)
+		binary.Write(body, le, uint32(len(v)))
+		for _, k := range keys {
+			writeAligned(body, append([]byte(k), 0))
+			if err := encodeObject(body, v[k]); err != nil {
+				return fmt.Errorf("failed to encode key %s: %w", k, err)
+			}
+		}
+		binary.Write(w, le, typeDictionary)
+		binary.Write(w, le, uint32(body.Len()))
+		w.Write(body.Bytes())
+	default:
+		return fmt.Errorf("unsupported xpc type %T", obj)
+	}
+	return nil
+}
+
+func readAligned(r *bytes.Reader, n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(int64(pad4(n)), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readCString(r *bytes.Reader) (string, error) {
+	var buf []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == 0 {
+			break
+		}
+		buf = append(buf, b)
+	}
+	if _, err := r.Seek(int64(pad4(len(buf)+1)), io.SeekCurrent); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func decodeObject(r *bytes.Reader) (any, error) {
+	le := binary.LittleEndian
+	var typ uint32
+	if err := binary.Read(r, le, &typ); err != nil {
+		return nil, err
+	}
+	switch typ {
+	case typeNull:
+		return nil, nil
+	case typeBool:
+		var v uint32
+		err := binary.Read(r, le, &v)
+		return v != 0, err
+	case typeInt64:
+		var v int64
+		err := binary.Read(r, le, &v)
+		return v, err
+	case typeUint64:
+		var v uint64
+		err := binary.Read(r, le, &v)
+		return v, err
+	case typeDouble:
+		var v uint64
+		err := binary.Read(r, le, &v)
+		return math.Float64frombits(v), err
+	case typeDate:
+		var v int64
+		err := binary.Read(r, le, &v)
+		return time.Unix(0, v), err
+	case typeData:
+		var size uint32
+		if err := binary.Read(r, le, &size); err != nil {
+			return nil, err
+		}
+		return readAligned(r, int(size))
+	case typeString:
+		var size uint32
+		if err := binary.Read(r, le, &size); err != nil {
+			return nil, err
+		}
+		data, err := readAligned(r, int(size))
+		if err != nil {
+			return nil, err
+		}
+		return string(bytes.TrimRight(data, "\x00")), nil
+	case typeUUID:
+		var v uuid.UUID
+		_, err := io.ReadFull(r, v[:])
+		return v, err
+	case typeArray:
+		var size, count uint32
+		binary.Read(r, le, &size)
+		if err := binary.Read(r, le, &count); err != nil {
+			return nil, err
+		}
+		arr := make([]any, 0, count)
+		for i := uint32(0); i < count; i++ {
+			obj, err := decodeObject(r)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, obj)
+		}
+		return arr, nil
+	case typeDictionary:
+		var size, count uint32
+		binary.Read(r, le, &size)
+		if err := binary.Read(r, le, &count); err != nil {
+			return nil, err
+		}
+		dict := make(map[string]any, count)
+		for i := uint32(0); i < count; i++ {
+			key, err := readCString(r)
+			if err != nil {
+				return nil, err
+			}
+			obj, err := decodeObject(r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode key %s: %w", key, err)
+			}
+			dict[key] = obj
+		}
+		return dict, nil
+	case typeFileTx:
+		var id uint64
+		if err := binary.Read(r, le, &id); err != nil {
+			return nil, err
+		}
+		obj, err := decodeObject(r)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"MessageID": id, "Data": obj}, nil
+	default:
+		return nil, fmt.Errorf("unsupported xpc object type %#x", typ)
+	}
+}
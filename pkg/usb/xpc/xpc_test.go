@@ -0,0 +1,38 @@
+package xpc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMessage_EncodeDecode(t *testing.T) {
+	msg := &Message{
+		Flags: FlagAlwaysSet | FlagWantingReply,
+		ID:    7,
+		Body: map[string]any{
+			"MessageType": "Handshake",
+			"Port":        "58783",
+			"Enabled":     true,
+			"Count":       int64(-3),
+			"Size":        uint64(1 << 40),
+			"Data":        []byte{1, 2, 3},
+			"Services":    []any{"a", "bc", nil},
+			"Properties":  map[string]any{"OSVersion": "18.0"},
+		},
+	}
+	data, err := msg.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Flags != msg.Flags|FlagDataPresent || got.ID != msg.ID {
+		t.Errorf("Decode() header = %#x/%d, want %#x/%d", got.Flags, got.ID, msg.Flags|FlagDataPresent, msg.ID)
+	}
+	if !reflect.DeepEqual(got.Body, msg.Body) {
+		t.Errorf("Decode() body = %v, want %v", got.Body, msg.Body)
+	}
+}